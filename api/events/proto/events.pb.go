@@ -0,0 +1,341 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.20.3
+// source: api/events/proto/events.proto
+
+// Schemas of the domain events published to the message broker. The value
+// of a message is the event encoded in protobuf, its key the aggregate, such
+// as booking:7K3M9X2QAB4Z, and its headers carry the event ID and type.
+
+package eventspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ConcertEvent is the value of concert.created and concert.updated
+type ConcertEvent struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Artist           string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Venue            string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
+	ConcertDate      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
+	TotalTickets     int32                  `protobuf:"varint,6,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	Price            float64                `protobuf:"fixed64,7,opt,name=price,proto3" json:"price,omitempty"`
+	Currency         string                 `protobuf:"bytes,8,opt,name=currency,proto3" json:"currency,omitempty"`
+	BookingStartTime *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
+	BookingEndTime   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
+	Visibility       string                 `protobuf:"bytes,11,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	Version          int32                  `protobuf:"varint,12,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ConcertEvent) Reset() {
+	*x = ConcertEvent{}
+	mi := &file_api_events_proto_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConcertEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConcertEvent) ProtoMessage() {}
+
+func (x *ConcertEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConcertEvent.ProtoReflect.Descriptor instead.
+func (*ConcertEvent) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConcertEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ConcertEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ConcertEvent) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *ConcertEvent) GetVenue() string {
+	if x != nil {
+		return x.Venue
+	}
+	return ""
+}
+
+func (x *ConcertEvent) GetConcertDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConcertDate
+	}
+	return nil
+}
+
+func (x *ConcertEvent) GetTotalTickets() int32 {
+	if x != nil {
+		return x.TotalTickets
+	}
+	return 0
+}
+
+func (x *ConcertEvent) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *ConcertEvent) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ConcertEvent) GetBookingStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BookingStartTime
+	}
+	return nil
+}
+
+func (x *ConcertEvent) GetBookingEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BookingEndTime
+	}
+	return nil
+}
+
+func (x *ConcertEvent) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *ConcertEvent) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// BookingEvent is the value of booking.confirmed and booking.cancelled.
+// Attendee details are never published.
+type BookingEvent struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Reference   string                 `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	ConcertId   int64                  `protobuf:"varint,2,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	UserId      string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TicketCount int32                  `protobuf:"varint,4,opt,name=ticket_count,json=ticketCount,proto3" json:"ticket_count,omitempty"`
+	UnitPrice   float64                `protobuf:"fixed64,5,opt,name=unit_price,json=unitPrice,proto3" json:"unit_price,omitempty"`
+	// status is confirmed or cancelled
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	BookingTime   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=booking_time,json=bookingTime,proto3" json:"booking_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookingEvent) Reset() {
+	*x = BookingEvent{}
+	mi := &file_api_events_proto_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookingEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookingEvent) ProtoMessage() {}
+
+func (x *BookingEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_events_proto_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookingEvent.ProtoReflect.Descriptor instead.
+func (*BookingEvent) Descriptor() ([]byte, []int) {
+	return file_api_events_proto_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BookingEvent) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *BookingEvent) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *BookingEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BookingEvent) GetTicketCount() int32 {
+	if x != nil {
+		return x.TicketCount
+	}
+	return 0
+}
+
+func (x *BookingEvent) GetUnitPrice() float64 {
+	if x != nil {
+		return x.UnitPrice
+	}
+	return 0
+}
+
+func (x *BookingEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *BookingEvent) GetBookingTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BookingTime
+	}
+	return nil
+}
+
+var File_api_events_proto_events_proto protoreflect.FileDescriptor
+
+const file_api_events_proto_events_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapi/events/proto/events.proto\x12\x06events\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc0\x03\n" +
+	"\fConcertEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06artist\x18\x03 \x01(\tR\x06artist\x12\x14\n" +
+	"\x05venue\x18\x04 \x01(\tR\x05venue\x12=\n" +
+	"\fconcert_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vconcertDate\x12#\n" +
+	"\rtotal_tickets\x18\x06 \x01(\x05R\ftotalTickets\x12\x14\n" +
+	"\x05price\x18\a \x01(\x01R\x05price\x12\x1a\n" +
+	"\bcurrency\x18\b \x01(\tR\bcurrency\x12H\n" +
+	"\x12booking_start_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x10bookingStartTime\x12D\n" +
+	"\x10booking_end_time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x0ebookingEndTime\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\v \x01(\tR\n" +
+	"visibility\x12\x18\n" +
+	"\aversion\x18\f \x01(\x05R\aversion\"\xfd\x01\n" +
+	"\fBookingEvent\x12\x1c\n" +
+	"\treference\x18\x01 \x01(\tR\treference\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x02 \x01(\x03R\tconcertId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12!\n" +
+	"\fticket_count\x18\x04 \x01(\x05R\vticketCount\x12\x1d\n" +
+	"\n" +
+	"unit_price\x18\x05 \x01(\x01R\tunitPrice\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12=\n" +
+	"\fbooking_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vbookingTimeB.Z,concert-ticket-api/api/events/proto;eventspbb\x06proto3"
+
+var (
+	file_api_events_proto_events_proto_rawDescOnce sync.Once
+	file_api_events_proto_events_proto_rawDescData []byte
+)
+
+func file_api_events_proto_events_proto_rawDescGZIP() []byte {
+	file_api_events_proto_events_proto_rawDescOnce.Do(func() {
+		file_api_events_proto_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_events_proto_events_proto_rawDesc), len(file_api_events_proto_events_proto_rawDesc)))
+	})
+	return file_api_events_proto_events_proto_rawDescData
+}
+
+var file_api_events_proto_events_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_api_events_proto_events_proto_goTypes = []any{
+	(*ConcertEvent)(nil),          // 0: events.ConcertEvent
+	(*BookingEvent)(nil),          // 1: events.BookingEvent
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_api_events_proto_events_proto_depIdxs = []int32{
+	2, // 0: events.ConcertEvent.concert_date:type_name -> google.protobuf.Timestamp
+	2, // 1: events.ConcertEvent.booking_start_time:type_name -> google.protobuf.Timestamp
+	2, // 2: events.ConcertEvent.booking_end_time:type_name -> google.protobuf.Timestamp
+	2, // 3: events.BookingEvent.booking_time:type_name -> google.protobuf.Timestamp
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_api_events_proto_events_proto_init() }
+func file_api_events_proto_events_proto_init() {
+	if File_api_events_proto_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_events_proto_events_proto_rawDesc), len(file_api_events_proto_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_api_events_proto_events_proto_goTypes,
+		DependencyIndexes: file_api_events_proto_events_proto_depIdxs,
+		MessageInfos:      file_api_events_proto_events_proto_msgTypes,
+	}.Build()
+	File_api_events_proto_events_proto = out.File
+	file_api_events_proto_events_proto_goTypes = nil
+	file_api_events_proto_events_proto_depIdxs = nil
+}
@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"errors"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// Error codes reported in the extensions of GraphQL errors
+const (
+	codeNotFound     = "NOT_FOUND"
+	codeInvalidInput = "INVALID_INPUT"
+	codeForbidden    = "FORBIDDEN"
+	codeConflict     = "CONFLICT"
+	codeInternal     = "INTERNAL"
+)
+
+// resolverError is a GraphQL error with a machine-readable code
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions implements the graphql-go extension interface
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// toError maps service errors to the messages the REST API uses. Unexpected
+// errors are reported with the fallback message so internals don't leak.
+func toError(err error, fallback string) error {
+	var errWithMsg *pkgErr.ErrorWithMessage
+
+	switch {
+	case errors.Is(err, pkgErr.ErrNotFound):
+		return &resolverError{message: "Not found", code: codeNotFound}
+	case errors.Is(err, pkgErr.ErrBookingClosed):
+		return &resolverError{message: "Booking is not open for this concert", code: codeInvalidInput}
+	case errors.Is(err, pkgErr.ErrInsufficientTickets):
+		return &resolverError{message: "Not enough tickets available", code: codeInvalidInput}
+	case errors.Is(err, pkgErr.ErrOptimisticLockFailed):
+		return &resolverError{message: "Booking conflict, please try again", code: codeConflict}
+	case errors.Is(err, pkgErr.ErrBookingTokenRequired):
+		return &resolverError{message: "A booking token is required for this concert", code: codeForbidden}
+	case errors.Is(err, pkgErr.ErrInvalidBookingToken):
+		return &resolverError{message: "Invalid or expired booking token", code: codeForbidden}
+	case errors.Is(err, pkgErr.ErrInviteRedeemed):
+		return &resolverError{message: "This invite has already been used to book", code: codeConflict}
+	case errors.Is(err, pkgErr.ErrIdempotencyKeyInUse):
+		return &resolverError{message: "A request with this idempotency key is in progress, please try again", code: codeConflict}
+	case errors.Is(err, pkgErr.ErrUnauthorized):
+		return &resolverError{message: "You are not authorized to cancel this booking", code: codeForbidden}
+	case errors.Is(err, pkgErr.ErrBookingAlreadyCancelled):
+		return &resolverError{message: "Booking is already cancelled", code: codeInvalidInput}
+	case errors.As(err, &errWithMsg):
+		return &resolverError{message: errWithMsg.Message(), code: codeInvalidInput}
+	default:
+		return &resolverError{message: fallback, code: codeInternal}
+	}
+}
+
+// nullIfNotFound resolves missing objects to null instead of an error
+func nullIfNotFound(err error) error {
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return nil
+	}
+	return toError(err, "Failed to get resource")
+}
@@ -0,0 +1,60 @@
+// Package graphql serves a GraphQL API over the concert and booking services.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/money"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+type contextKey int
+
+const (
+	loaderKey contextKey = iota
+	localeKey
+)
+
+// NewHandler creates an HTTP handler for GraphQL POST requests. Queries
+// nested deeper than maxDepth are rejected; zero means no limit.
+func NewHandler(concertService service.ConcertService, bookingService service.BookingService, maxDepth int) (http.Handler, error) {
+	parsed, err := graphql.ParseSchema(schema, NewResolver(concertService, bookingService),
+		graphql.MaxDepth(maxDepth),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	relayHandler := &relay.Handler{Schema: parsed}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every request gets its own loader so cached concerts never outlive it
+		ctx := context.WithValue(r.Context(), loaderKey, newConcertLoader(concertService.GetByIDs))
+		ctx = context.WithValue(ctx, localeKey, money.ResolveLocale(r.Header.Get("Accept-Language")))
+		relayHandler.ServeHTTP(w, r.WithContext(ctx))
+	}), nil
+}
+
+// loaderFrom returns the loader of the request, or an unshared one outside of
+// the handler
+func loaderFrom(ctx context.Context, concertService service.ConcertService) *concertLoader {
+	if loader, ok := ctx.Value(loaderKey).(*concertLoader); ok {
+		return loader
+	}
+	return newConcertLoader(concertService.GetByIDs)
+}
+
+// localeFrom returns the locale prices are formatted in
+func localeFrom(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok {
+		return locale
+	}
+	return money.ResolveLocale("")
+}
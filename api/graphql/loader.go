@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// Loader defaults, small enough not to be noticed in response times
+const (
+	defaultBatchWait    = 2 * time.Millisecond
+	defaultMaxBatchSize = 100
+)
+
+// concertLoader batches the concert lookups of one request. Fields resolved
+// concurrently, like the concert of every booking in a list, are collected
+// for a short wait and fetched with a single GetByIDs call. Results are
+// cached for the rest of the request.
+type concertLoader struct {
+	fetch        func(ctx context.Context, ids []int64) ([]*model.Concert, error)
+	wait         time.Duration
+	maxBatchSize int
+
+	mutex   sync.Mutex
+	results map[int64]*concertResult
+	batch   *concertBatch
+}
+
+// concertResult is the outcome of loading one concert, ready once done is closed
+type concertResult struct {
+	done    chan struct{}
+	concert *model.Concert
+	err     error
+}
+
+// concertBatch collects the IDs of the next fetch
+type concertBatch struct {
+	ids     []int64
+	results []*concertResult
+	timer   *time.Timer
+}
+
+func newConcertLoader(fetch func(ctx context.Context, ids []int64) ([]*model.Concert, error)) *concertLoader {
+	return &concertLoader{
+		fetch:        fetch,
+		wait:         defaultBatchWait,
+		maxBatchSize: defaultMaxBatchSize,
+		results:      make(map[int64]*concertResult),
+	}
+}
+
+// Load returns the concert with the given ID, or ErrNotFound
+func (l *concertLoader) Load(ctx context.Context, id int64) (*model.Concert, error) {
+	l.mutex.Lock()
+	result, ok := l.results[id]
+	if !ok {
+		result = &concertResult{done: make(chan struct{})}
+		l.results[id] = result
+		l.enqueue(ctx, id, result)
+	}
+	l.mutex.Unlock()
+
+	select {
+	case <-result.done:
+		return result.concert, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// enqueue adds an ID to the pending batch; the caller must hold the lock
+func (l *concertLoader) enqueue(ctx context.Context, id int64, result *concertResult) {
+	if l.batch == nil {
+		batch := &concertBatch{}
+		batch.timer = time.AfterFunc(l.wait, func() { l.dispatch(ctx, batch) })
+		l.batch = batch
+	}
+
+	l.batch.ids = append(l.batch.ids, id)
+	l.batch.results = append(l.batch.results, result)
+
+	if len(l.batch.ids) >= l.maxBatchSize {
+		// Full batches don't wait for the timer
+		if batch := l.batch; batch.timer.Stop() {
+			l.batch = nil
+			go l.run(ctx, batch)
+		}
+	}
+}
+
+// dispatch runs a batch when its wait is over
+func (l *concertLoader) dispatch(ctx context.Context, batch *concertBatch) {
+	l.mutex.Lock()
+	if l.batch == batch {
+		l.batch = nil
+	}
+	l.mutex.Unlock()
+
+	l.run(ctx, batch)
+}
+
+// run fetches the concerts of a batch and completes its results
+func (l *concertLoader) run(ctx context.Context, batch *concertBatch) {
+	concerts, err := l.fetch(ctx, batch.ids)
+
+	byID := make(map[int64]*model.Concert, len(concerts))
+	for _, concert := range concerts {
+		byID[concert.ID] = concert
+	}
+
+	for i, id := range batch.ids {
+		result := batch.results[i]
+		switch concert, ok := byID[id]; {
+		case err != nil:
+			result.err = err
+		case ok:
+			result.concert = concert
+		default:
+			result.err = pkgErr.ErrNotFound
+		}
+		close(result.done)
+	}
+}
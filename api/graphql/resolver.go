@@ -0,0 +1,329 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver is the root resolver of the GraphQL schema
+type Resolver struct {
+	concertService service.ConcertService
+	bookingService service.BookingService
+}
+
+// NewResolver creates the root resolver over the existing services
+func NewResolver(concertService service.ConcertService, bookingService service.BookingService) *Resolver {
+	return &Resolver{
+		concertService: concertService,
+		bookingService: bookingService,
+	}
+}
+
+// Concert resolves Query.concert
+func (r *Resolver) Concert(ctx context.Context, args struct{ ID graphql.ID }) (*concertResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	concert, err := loaderFrom(ctx, r.concertService).Load(ctx, id)
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	return newConcertResolver(ctx, concert), nil
+}
+
+// Concerts resolves Query.concerts
+func (r *Resolver) Concerts(ctx context.Context, args struct {
+	Page          int32
+	PageSize      int32
+	Cursor        *string
+	Sort          *string
+	Artist        *string
+	Venue         *string
+	Name          *string
+	AvailableOnly bool
+	ExactCount    bool
+}) (*concertPageResolver, error) {
+	page := query.NewPage(int(args.Page), int(args.PageSize))
+	if args.Cursor != nil && *args.Cursor != "" {
+		var err error
+		if page, err = query.DecodeCursor(*args.Cursor); err != nil {
+			return nil, toError(err, "")
+		}
+	}
+
+	var sorts []query.Sort
+	if args.Sort != nil {
+		var err error
+		if sorts, err = query.ParseSort(*args.Sort, model.ConcertSortFields); err != nil {
+			return nil, toError(err, "")
+		}
+	}
+
+	filters := make(query.Filters)
+	if args.Artist != nil && *args.Artist != "" {
+		filters["artist"] = *args.Artist
+	}
+	if args.Venue != nil && *args.Venue != "" {
+		filters["venue"] = *args.Venue
+	}
+	if args.Name != nil && *args.Name != "" {
+		filters["name"] = *args.Name
+	}
+	if args.AvailableOnly {
+		filters["available"] = true
+	}
+
+	opts := query.Options{Page: page, Sort: sorts, Filters: filters, EstimateCount: !args.ExactCount}
+	concerts, totalCount, err := r.concertService.ListConcerts(ctx, opts)
+	if err != nil {
+		return nil, toError(err, "Failed to list concerts")
+	}
+
+	meta := page.Meta(totalCount)
+	meta.TotalCountEstimated = opts.EstimateCount
+	return &concertPageResolver{
+		ctx:      ctx,
+		concerts: concerts,
+		meta:     meta,
+	}, nil
+}
+
+// Booking resolves Query.booking
+func (r *Resolver) Booking(ctx context.Context, args struct{ Reference string }) (*bookingResolver, error) {
+	booking, err := r.bookingService.GetBookingByReference(ctx, args.Reference)
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	return r.newBookingResolver(booking), nil
+}
+
+// Bookings resolves Query.bookings
+func (r *Resolver) Bookings(ctx context.Context, args struct {
+	UserID   string
+	Page     int32
+	PageSize int32
+}) ([]*bookingResolver, error) {
+	if args.UserID == "" {
+		return nil, toError(pkgErr.ErrInvalidInput("userID is required"), "")
+	}
+
+	bookings, err := r.bookingService.GetUserBookings(ctx, args.UserID, query.NewPage(int(args.Page), int(args.PageSize)))
+	if err != nil {
+		return nil, toError(err, "Failed to get user bookings")
+	}
+
+	resolvers := make([]*bookingResolver, 0, len(bookings))
+	for _, booking := range bookings {
+		resolvers = append(resolvers, r.newBookingResolver(booking))
+	}
+	return resolvers, nil
+}
+
+// BookTickets resolves Mutation.bookTickets
+func (r *Resolver) BookTickets(ctx context.Context, args struct {
+	Input struct {
+		ConcertID     graphql.ID
+		UserID        string
+		TicketCount   int32
+		AttendeeName  *string
+		AttendeeEmail *string
+		BookingToken  *string
+	}
+}) (*bookingResolver, error) {
+	concertID, err := parseID(args.Input.ConcertID)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, err := r.bookingService.BookTickets(ctx, &model.BookingRequest{
+		ConcertID:     concertID,
+		UserID:        args.Input.UserID,
+		TicketCount:   int(args.Input.TicketCount),
+		AttendeeName:  deref(args.Input.AttendeeName),
+		AttendeeEmail: deref(args.Input.AttendeeEmail),
+		BookingToken:  deref(args.Input.BookingToken),
+	})
+	if err != nil {
+		return nil, toError(err, "Failed to book tickets")
+	}
+
+	return r.newBookingResolver(booking), nil
+}
+
+// CancelBooking resolves Mutation.cancelBooking
+func (r *Resolver) CancelBooking(ctx context.Context, args struct {
+	Reference string
+	UserID    string
+}) (*bookingResolver, error) {
+	if err := r.bookingService.CancelBookingByReference(ctx, args.Reference, args.UserID); err != nil {
+		return nil, toError(err, "Failed to cancel booking")
+	}
+
+	booking, err := r.bookingService.GetBookingByReference(ctx, args.Reference)
+	if err != nil {
+		return nil, toError(err, "Failed to get booking")
+	}
+
+	return r.newBookingResolver(booking), nil
+}
+
+func (r *Resolver) newBookingResolver(booking *model.Booking) *bookingResolver {
+	return &bookingResolver{booking: booking, concerts: r.concertService}
+}
+
+// concertPageResolver resolves ConcertPage
+type concertPageResolver struct {
+	ctx      context.Context
+	concerts []*model.Concert
+	meta     query.Meta
+}
+
+func (p *concertPageResolver) Data() []*concertResolver {
+	resolvers := make([]*concertResolver, 0, len(p.concerts))
+	for _, concert := range p.concerts {
+		resolvers = append(resolvers, newConcertResolver(p.ctx, concert))
+	}
+	return resolvers
+}
+
+func (p *concertPageResolver) Page() int32       { return int32(p.meta.Page) }
+func (p *concertPageResolver) PageSize() int32   { return int32(p.meta.PageSize) }
+func (p *concertPageResolver) TotalCount() int32 { return int32(p.meta.TotalCount) }
+func (p *concertPageResolver) TotalPages() int32 { return int32(p.meta.TotalPages) }
+func (p *concertPageResolver) TotalCountEstimated() bool {
+	return p.meta.TotalCountEstimated
+}
+func (p *concertPageResolver) NextCursor() *string {
+	if p.meta.NextCursor == "" {
+		return nil
+	}
+	return &p.meta.NextCursor
+}
+
+// concertResolver resolves Concert
+type concertResolver struct {
+	concert *model.Concert
+	locale  string
+}
+
+func newConcertResolver(ctx context.Context, concert *model.Concert) *concertResolver {
+	return &concertResolver{concert: concert, locale: localeFrom(ctx)}
+}
+
+func (c *concertResolver) ID() graphql.ID { return graphql.ID(strconv.FormatInt(c.concert.ID, 10)) }
+func (c *concertResolver) Name() string   { return c.concert.Name }
+func (c *concertResolver) Artist() string { return c.concert.Artist }
+func (c *concertResolver) Venue() string  { return c.concert.Venue }
+func (c *concertResolver) ConcertDate() graphql.Time {
+	return graphql.Time{Time: c.concert.ConcertDate}
+}
+func (c *concertResolver) TotalTickets() int32     { return int32(c.concert.TotalTickets) }
+func (c *concertResolver) AvailableTickets() int32 { return int32(c.concert.AvailableTickets) }
+func (c *concertResolver) Price() float64          { return c.concert.Price }
+func (c *concertResolver) Currency() string        { return c.concert.Currency }
+func (c *concertResolver) PriceDisplay() string {
+	return money.Format(c.concert.Price, c.concert.Currency, c.locale)
+}
+func (c *concertResolver) DoorPrice() *float64 { return c.concert.DoorPrice }
+func (c *concertResolver) DoorPriceStartsAt() *graphql.Time {
+	if c.concert.DoorPrice == nil {
+		return nil
+	}
+	return &graphql.Time{Time: c.concert.DoorPriceStartsAt()}
+}
+func (c *concertResolver) PricingPhase() string  { return c.concert.PricingPhaseAt(clock.Now()) }
+func (c *concertResolver) CurrentPrice() float64 { return c.concert.PriceAt(clock.Now()) }
+func (c *concertResolver) BookingStartTime() graphql.Time {
+	return graphql.Time{Time: c.concert.BookingStartTime}
+}
+func (c *concertResolver) BookingEndTime() graphql.Time {
+	return graphql.Time{Time: c.concert.BookingEndTime}
+}
+func (c *concertResolver) BookingOpen() bool          { return c.concert.IsBookingOpen() }
+func (c *concertResolver) RequiresBookingToken() bool { return c.concert.RequiresBookingToken }
+
+// bookingResolver resolves Booking
+type bookingResolver struct {
+	booking  *model.Booking
+	concerts service.ConcertService
+}
+
+// Concert is loaded through the request's loader, so listing bookings costs
+// one concert query however many bookings there are
+func (b *bookingResolver) Concert(ctx context.Context) (*concertResolver, error) {
+	concert, err := loaderFrom(ctx, b.concerts).Load(ctx, b.booking.ConcertID)
+	if err != nil {
+		return nil, toError(err, "Failed to get concert")
+	}
+	return newConcertResolver(ctx, concert), nil
+}
+
+func (b *bookingResolver) Reference() string  { return b.booking.Reference }
+func (b *bookingResolver) UserID() string     { return b.booking.UserID }
+func (b *bookingResolver) TicketCount() int32 { return int32(b.booking.TicketCount) }
+func (b *bookingResolver) UnitPrice() float64 { return b.booking.UnitPrice }
+func (b *bookingResolver) BookingTime() graphql.Time {
+	return graphql.Time{Time: b.booking.BookingTime}
+}
+func (b *bookingResolver) Status() string         { return string(b.booking.Status) }
+func (b *bookingResolver) AttendeeName() *string  { return optional(b.booking.AttendeeName) }
+func (b *bookingResolver) AttendeeEmail() *string { return optional(b.booking.AttendeeEmail) }
+
+// Payment is only set on a booking that was just made pending
+func (b *bookingResolver) Payment() *paymentResolver {
+	if b.booking.Payment == nil {
+		return nil
+	}
+	return &paymentResolver{payment: b.booking.Payment}
+}
+
+// paymentResolver resolves Payment
+type paymentResolver struct {
+	payment *model.Payment
+}
+
+func (p *paymentResolver) Provider() string          { return p.payment.Provider }
+func (p *paymentResolver) ProviderReference() string { return p.payment.ProviderReference }
+func (p *paymentResolver) ClientSecret() *string     { return optional(p.payment.ClientSecret) }
+func (p *paymentResolver) Amount() float64           { return p.payment.Amount }
+func (p *paymentResolver) Currency() string          { return p.payment.Currency }
+func (p *paymentResolver) Status() string            { return p.payment.Status }
+func (p *paymentResolver) ExpiresAt() graphql.Time {
+	return graphql.Time{Time: p.payment.ExpiresAt}
+}
+
+// parseID converts a GraphQL ID into a numeric ID
+func parseID(id graphql.ID) (int64, error) {
+	parsed, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, toError(pkgErr.ErrInvalidInput("invalid ID"), "")
+	}
+	return parsed, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
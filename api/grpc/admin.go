@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// adminServer implements the AdminService RPCs, the operations of the
+// internal admin API for operator tools
+type adminServer struct {
+	ops    service.AdminOpsService
+	server *Server
+	pb.UnimplementedAdminServiceServer
+}
+
+// RegisterAdmin serves the AdminService with ops to the callers with the
+// credentials of the internal admin API in cfg. It must be called before the
+// server starts.
+func (s *Server) RegisterAdmin(ops service.AdminOpsService, cfg config.InternalAdmin) {
+	s.authorizer.AllowInternalAdmin(cfg)
+	pb.RegisterAdminServiceServer(s.server, &adminServer{ops: ops, server: s})
+}
+
+// ForceCancelBooking implements the AdminService.ForceCancelBooking RPC
+func (a *adminServer) ForceCancelBooking(ctx context.Context, req *pb.ForceCancelBookingRequest) (*pb.Booking, error) {
+	actor := AdminActorFromContext(ctx)
+	booking, err := a.ops.ForceCancelBooking(ctx, actor, req.Reference, req.Reason)
+	if err != nil {
+		if booking != nil {
+			a.server.log(ctx).Error("Booking %s was force-cancelled by %s but not audited: %v", booking.Reference, actor, err)
+		} else {
+			a.server.log(ctx).Error("Failed to force-cancel booking %s: %v", req.Reference, err)
+		}
+		return nil, err
+	}
+
+	a.server.log(ctx).Warn("Booking %s force-cancelled by %s: %s", booking.Reference, actor, req.Reason)
+	return convertModelToPbBooking(booking), nil
+}
+
+// AdjustInventory implements the AdminService.AdjustInventory RPC
+func (a *adminServer) AdjustInventory(ctx context.Context, req *pb.AdjustInventoryRequest) (*pb.ConcertAvailability, error) {
+	actor := AdminActorFromContext(ctx)
+	availability, err := a.ops.AdjustInventory(ctx, actor, req.ConcertId, model.InventoryAdjustment{
+		Delta:  int(req.Delta),
+		Reason: req.Reason,
+	})
+	if err != nil {
+		if availability != nil {
+			a.server.log(ctx).Error("Inventory of concert %d was adjusted by %s but not audited: %v", req.ConcertId, actor, err)
+		} else {
+			a.server.log(ctx).Error("Failed to adjust inventory of concert %d: %v", req.ConcertId, err)
+		}
+		return nil, err
+	}
+
+	a.server.log(ctx).Warn("Inventory of concert %d adjusted by %+d by %s: %s", req.ConcertId, req.Delta, actor, req.Reason)
+	return convertModelToPbAvailability(availability), nil
+}
+
+// CheckInventory implements the AdminService.CheckInventory RPC
+func (a *adminServer) CheckInventory(ctx context.Context, req *pb.CheckInventoryRequest) (*pb.CheckInventoryResponse, error) {
+	check, err := a.ops.CheckInventory(ctx, req.ConcertId)
+	if err != nil {
+		a.server.log(ctx).Error("Failed to check inventory: %v", err)
+		return nil, err
+	}
+
+	resp := &pb.CheckInventoryResponse{Checked: int32(check.Checked)}
+	for _, count := range check.Discrepancies {
+		resp.Discrepancies = append(resp.Discrepancies, &pb.InventoryCount{
+			ConcertId:                count.ConcertID,
+			TotalTickets:             int32(count.TotalTickets),
+			AvailableTickets:         int32(count.AvailableTickets),
+			ExpectedAvailableTickets: int32(count.ExpectedAvailable()),
+			BookedTickets:            int32(count.BookedTickets),
+			PendingTickets:           int32(count.PendingTickets),
+			HeldTickets:              int32(count.HeldTickets),
+		})
+	}
+	if len(check.Discrepancies) > 0 {
+		a.server.log(ctx).Warn("Inventory check found %d of %d concerts whose tickets don't add up", len(check.Discrepancies), check.Checked)
+	}
+	return resp, nil
+}
@@ -0,0 +1,349 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"concert-ticket-api/config"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// Roles that can be granted to gRPC clients
+const (
+	// RoleUser is for clients booking tickets on behalf of end users
+	RoleUser = "user"
+	// RoleOrganizer is for clients managing concerts
+	RoleOrganizer = "organizer"
+	// RoleAgency is for ticket agencies booking allocations in bulk
+	RoleAgency = "agency"
+	// RoleAdmin is granted by the admin token and may call every RPC
+	RoleAdmin = "admin"
+)
+
+// Policy describes who may call an RPC
+type Policy struct {
+	// Public allows unauthenticated callers
+	Public bool
+	// Roles lists the roles allowed to call the RPC, any of which is sufficient
+	Roles []string
+	// Internal requires the credentials of the internal admin API instead of
+	// a role: its token, the operator in x-admin-actor metadata and a client
+	// address in its allowed networks. The admin token doesn't grant it.
+	Internal bool
+}
+
+// methodPolicies is the authorization matrix of every RPC the server exposes.
+// A method without an entry is denied, and the server refuses to start if a
+// registered method has no entry, so new RPCs must be added here explicitly.
+var methodPolicies = map[string]Policy{
+	pb.ConcertService_GetConcert_FullMethodName:       {Public: true},
+	pb.ConcertService_ListConcerts_FullMethodName:     {Public: true},
+	pb.ConcertService_BatchGetConcerts_FullMethodName: {Public: true},
+	pb.ConcertService_CreateConcert_FullMethodName:    {Roles: []string{RoleOrganizer, RoleAdmin}},
+	pb.ConcertService_UpdateConcert_FullMethodName:    {Roles: []string{RoleOrganizer, RoleAdmin}},
+
+	pb.ConcertService_WatchConcertAvailability_FullMethodName: {Public: true},
+
+	pb.BookingService_GetBooking_FullMethodName:        {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_GetUserBookings_FullMethodName:   {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_BookTickets_FullMethodName:       {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_CancelBooking_FullMethodName:     {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_BookTicketsStream_FullMethodName: {Roles: []string{RoleAgency, RoleAdmin}},
+	pb.BookingService_IssueBookingToken_FullMethodName: {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_GetOrder_FullMethodName:          {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_GetUserOrders_FullMethodName:     {Roles: []string{RoleUser, RoleAdmin}},
+	pb.BookingService_CancelOrder_FullMethodName:       {Roles: []string{RoleUser, RoleAdmin}},
+
+	// The operations of the internal admin API keep its credentials
+	pb.AdminService_ForceCancelBooking_FullMethodName: {Internal: true},
+	pb.AdminService_AdjustInventory_FullMethodName:    {Internal: true},
+	pb.AdminService_CheckInventory_FullMethodName:     {Internal: true},
+
+	// Reflection only describes the API, which is public anyway
+	reflectionv1.ServerReflection_ServerReflectionInfo_FullMethodName:      {Public: true},
+	reflectionv1alpha.ServerReflection_ServerReflectionInfo_FullMethodName: {Public: true},
+
+	// Probes carry no credentials
+	healthpb.Health_Check_FullMethodName: {Public: true},
+	healthpb.Health_Watch_FullMethodName: {Public: true},
+
+	// Channelz exposes peer addresses and traffic, so it is for admins
+	channelzpb.Channelz_GetTopChannels_FullMethodName:   {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetServers_FullMethodName:       {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetServer_FullMethodName:        {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetServerSockets_FullMethodName: {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetChannel_FullMethodName:       {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetSubchannel_FullMethodName:    {Roles: []string{RoleAdmin}},
+	channelzpb.Channelz_GetSocket_FullMethodName:        {Roles: []string{RoleAdmin}},
+}
+
+// Client is an authenticated gRPC caller
+type Client struct {
+	Name  string
+	Roles []string
+}
+
+type clientKey struct{}
+
+type adminActorKey struct{}
+
+// adminActorMetadata names the operator of an internal admin call
+const adminActorMetadata = "x-admin-actor"
+
+// AdminActorFromContext returns the operator of an internal admin call, or ""
+func AdminActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(adminActorKey{}).(string)
+	return actor
+}
+
+// ClientFromContext returns the authenticated client, or nil for public calls
+// made without credentials
+func ClientFromContext(ctx context.Context) *Client {
+	client, _ := ctx.Value(clientKey{}).(*Client)
+	return client
+}
+
+type credential struct {
+	token  []byte
+	client *Client
+}
+
+// Authorizer enforces the authorization matrix on incoming RPCs
+type Authorizer struct {
+	policies    map[string]Policy
+	credentials []credential
+	// internalToken and internalNetworks authorize the internal admin
+	// RPCs, which are denied while the token is unset
+	internalToken    []byte
+	internalNetworks []*net.IPNet
+}
+
+// NewAuthorizer creates an Authorizer accepting the configured client tokens
+// and the admin token
+func NewAuthorizer(cfg config.GRPCAuth, adminToken string) *Authorizer {
+	a := &Authorizer{policies: methodPolicies}
+
+	for _, c := range cfg.Clients {
+		a.credentials = append(a.credentials, credential{
+			token:  []byte(c.Token),
+			client: &Client{Name: c.Name, Roles: c.Roles},
+		})
+	}
+
+	if adminToken != "" {
+		a.credentials = append(a.credentials, credential{
+			token:  []byte(adminToken),
+			client: &Client{Name: "admin", Roles: []string{RoleAdmin}},
+		})
+	}
+
+	return a
+}
+
+// AllowInternalAdmin lets callers with the credentials of the internal admin
+// API call the RPCs whose policy is Internal. It must be called before the
+// server starts.
+func (a *Authorizer) AllowInternalAdmin(cfg config.InternalAdmin) {
+	a.internalToken = []byte(cfg.Token)
+	a.internalNetworks = nil
+	for _, cidr := range cfg.AllowedNetworks {
+		// Load validates the networks, so invalid ones are only skipped here
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			a.internalNetworks = append(a.internalNetworks, network)
+		}
+	}
+}
+
+// CheckCoverage verifies that every registered method has an explicit policy
+func (a *Authorizer) CheckCoverage(services map[string]grpc.ServiceInfo) error {
+	var missing []string
+	for serviceName, info := range services {
+		for _, method := range info.Methods {
+			fullMethod := "/" + serviceName + "/" + method.Name
+			if _, ok := a.policies[fullMethod]; !ok {
+				missing = append(missing, fullMethod)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("no authorization policy for gRPC methods: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// UnaryInterceptor returns a unary interceptor enforcing the authorization matrix
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns a stream interceptor enforcing the authorization
+// matrix. Like the unary one, it hands the authenticated client on to the
+// handler through the stream's context.
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+// authorize checks the caller against the policy of a method and returns a
+// context carrying the authenticated client
+func (a *Authorizer) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	policy, ok := a.policies[fullMethod]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "no authorization policy for %s", fullMethod)
+	}
+	if policy.Internal {
+		return a.authorizeInternal(ctx)
+	}
+
+	client, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if client != nil {
+		ctx = context.WithValue(ctx, clientKey{}, client)
+	}
+
+	if policy.Public {
+		return ctx, nil
+	}
+
+	if client == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	for _, role := range client.Roles {
+		if role == RoleAdmin || hasRole(policy.Roles, role) {
+			return ctx, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.PermissionDenied, "client %q may not call %s", client.Name, fullMethod)
+}
+
+// authorizeInternal checks the caller of an internal admin RPC like the
+// internal admin API checks its requests, judging the network by the
+// connection's address, and returns a context carrying the operator
+func (a *Authorizer) authorizeInternal(ctx context.Context) (context.Context, error) {
+	if len(a.internalToken) == 0 {
+		return nil, status.Error(codes.PermissionDenied, "internal admin API is disabled")
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || !inNetworks(p.Addr, a.internalNetworks) {
+		return nil, status.Error(codes.PermissionDenied, "calls from this address are not allowed")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+	if subtle.ConstantTimeCompare([]byte(parts[1]), a.internalToken) != 1 {
+		return nil, status.Error(codes.PermissionDenied, "invalid internal admin token")
+	}
+
+	// Every operation is audited under the operator's name
+	var actor string
+	if actors := md.Get(adminActorMetadata); len(actors) > 0 {
+		actor = strings.TrimSpace(actors[0])
+	}
+	if actor == "" {
+		return nil, status.Error(codes.Unauthenticated, "x-admin-actor metadata is required")
+	}
+	return context.WithValue(ctx, adminActorKey{}, actor), nil
+}
+
+// inNetworks reports whether the IP of a peer address is in one of the networks
+func inNetworks(addr net.Addr, networks []*net.IPNet) bool {
+	var ip net.IP
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		ip = tcp.IP
+	} else if addr != nil {
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			host = addr.String()
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate resolves the bearer token in the request metadata. It returns
+// nil without an error when no credentials were sent.
+func (a *Authorizer) authenticate(ctx context.Context) (*Client, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+
+	token := []byte(parts[1])
+	for _, cred := range a.credentials {
+		if subtle.ConstantTimeCompare(token, cred.token) == 1 {
+			return cred.client, nil
+		}
+	}
+
+	return nil, status.Error(codes.Unauthenticated, "invalid token")
+}
+
+// hasRole reports whether roles contains role
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
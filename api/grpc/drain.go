@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// drainedMethods are the RPCs changing state, rejected once the server is
+// draining. Reads are served until the server stops.
+var drainedMethods = map[string]bool{
+	pb.ConcertService_CreateConcert_FullMethodName:     true,
+	pb.ConcertService_UpdateConcert_FullMethodName:     true,
+	pb.BookingService_BookTickets_FullMethodName:       true,
+	pb.BookingService_BookTicketsStream_FullMethodName: true,
+	pb.BookingService_CancelBooking_FullMethodName:     true,
+	pb.BookingService_CancelOrder_FullMethodName:       true,
+	pb.BookingService_IssueBookingToken_FullMethodName: true,
+}
+
+// drainingMessage is the message of the RPCs rejected while shutting down
+const drainingMessage = "server is shutting down"
+
+// drain tracks the shutdown of a server. Once draining, the RPCs changing
+// state are rejected so the ones in flight are the last; once stopping, the
+// availability watchers return so GracefulStop doesn't wait on them.
+type drain struct {
+	draining atomic.Bool
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+func newDrain() *drain {
+	return &drain{stopping: make(chan struct{})}
+}
+
+// stop ends the streams that would otherwise run until their callers leave
+func (d *drain) stop() {
+	d.stopOnce.Do(func() { close(d.stopping) })
+}
+
+// rejects reports whether a method is turned away
+func (d *drain) rejects(method string) bool {
+	return d.draining.Load() && drainedMethods[method]
+}
+
+// unaryInterceptor rejects new writes with Unavailable while draining
+func (d *drain) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if d.rejects(info.FullMethod) {
+		return nil, status.Error(codes.Unavailable, drainingMessage)
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor rejects new streams with Unavailable while draining
+func (d *drain) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if d.rejects(info.FullMethod) {
+		return status.Error(codes.Unavailable, drainingMessage)
+	}
+	return handler(srv, ss)
+}
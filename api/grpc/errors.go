@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail attached to every error
+const ErrorDomain = "concert-ticket-api"
+
+// internalMessage is reported for errors that aren't registered, so their
+// messages don't leak
+const internalMessage = "internal error"
+
+// ToStatus converts a service error into a gRPC status error with the code
+// pkg/errors registers it with and an ErrorInfo detail, plus
+// PreconditionFailure or RetryInfo details where they apply. Status errors are
+// returned unchanged, and unexpected errors become Internal without revealing
+// their message. The ErrorInfo reason is the code the REST API reports for the
+// same error, so clients can branch on the same codes over both transports.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	kind, _ := pkgErr.Lookup(err)
+	return errorStatus(kind, pkgErr.MessageOf(err, internalMessage))
+}
+
+func errorStatus(kind pkgErr.Kind, message string) error {
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: kind.Code, Domain: ErrorDomain},
+	}
+	if kind.Precondition != "" {
+		details = append(details, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{Type: kind.Precondition, Description: message}},
+		})
+	}
+	if kind.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(kind.RetryAfter)})
+	}
+
+	st, err := status.New(kind.GRPCCode, message).WithDetails(details...)
+	if err != nil {
+		return status.Error(kind.GRPCCode, message)
+	}
+	return st.Err()
+}
+
+// errorUnaryInterceptor converts the errors of unary RPCs into statuses
+func errorUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, ToStatus(err)
+}
+
+// errorStreamInterceptor converts the errors of streaming RPCs into statuses
+func errorStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return ToStatus(handler(srv, ss))
+}
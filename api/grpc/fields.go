@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/internal/model"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// readMask is the set of Concert fields a caller asked for, like the fields
+// parameter of the REST listings. A nil mask selects every field.
+type readMask map[protoreflect.Name]bool
+
+// parseReadMask checks a read mask before any work is done for the request.
+// Concert has no nested messages worth selecting into, so only top-level
+// paths are accepted. The id is always returned.
+func parseReadMask(mask *fieldmaskpb.FieldMask) (readMask, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, nil
+	}
+
+	fields := (&pb.Concert{}).ProtoReflect().Descriptor().Fields()
+	keep := readMask{"id": true}
+	for _, path := range mask.GetPaths() {
+		if strings.Contains(path, ".") || fields.ByName(protoreflect.Name(path)) == nil {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("read_mask: unknown Concert field %q", path))
+		}
+		keep[protoreflect.Name(path)] = true
+	}
+	return keep, nil
+}
+
+// apply clears the fields of the concerts outside the mask
+func (m readMask) apply(concerts []*pb.Concert) {
+	if m == nil {
+		return
+	}
+
+	fields := (&pb.Concert{}).ProtoReflect().Descriptor().Fields()
+	for _, concert := range concerts {
+		message := concert.ProtoReflect()
+		for i := 0; i < fields.Len(); i++ {
+			if !m[fields.Get(i).Name()] {
+				message.Clear(fields.Get(i))
+			}
+		}
+	}
+}
+
+// updateFields returns the fields an UpdateConcert request updates: the
+// paths of its update mask, or the fields it sets to non-zero values.
+// Without the mask a field can't be cleared, since proto3 can't tell an
+// unset scalar from a zero one.
+func updateFields(req *pb.UpdateConcertRequest) ([]string, error) {
+	if paths := req.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		for _, path := range paths {
+			if !model.IsConcertUpdateField(path) {
+				return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("update_mask: field %q cannot be updated", path))
+			}
+		}
+		return paths, nil
+	}
+
+	var fields []string
+	message := req.ProtoReflect()
+	message.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if name := string(field.Name()); model.IsConcertUpdateField(name) {
+			fields = append(fields, name)
+		}
+		return true
+	})
+	sort.Strings(fields)
+	return fields, nil
+}
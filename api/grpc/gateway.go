@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/internal/service"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// GatewayPrefix is the path prefix of the HTTP bindings annotated in the proto files
+const GatewayPrefix = "/gateway/v1"
+
+// NewGateway creates an HTTP handler serving the HTTP bindings annotated in
+// the proto files. Requests are forwarded to the gRPC server at endpoint
+// rather than to the services directly, so they go through the same
+// interceptors (including authorization) as native gRPC calls. The
+// connection is closed when ctx is done.
+func NewGateway(ctx context.Context, endpoint string) (http.Handler, error) {
+	// Keep proto field names so the JSON matches the hand-written REST API
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+	)
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+
+	if err := pb.RegisterConcertServiceHandlerFromEndpoint(ctx, mux, endpoint, opts); err != nil {
+		return nil, fmt.Errorf("failed to register concert gateway: %w", err)
+	}
+
+	if err := pb.RegisterBookingServiceHandlerFromEndpoint(ctx, mux, endpoint, opts); err != nil {
+		return nil, fmt.Errorf("failed to register booking gateway: %w", err)
+	}
+
+	return mux, nil
+}
+
+// gatewayHeaderMatcher forwards the invite token and request ID headers next
+// to the headers the gateway forwards by default
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, service.InviteTokenHeader) {
+		return inviteTokenMetadata, true
+	}
+	if strings.EqualFold(key, requestIDMetadata) {
+		return requestIDMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
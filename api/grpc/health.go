@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"concert-ticket-api/pkg/health"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// healthServices are the services whose serving status follows readiness.
+// The overall status, the empty service name, only reports that the server
+// is up, like /health/live, so liveness probes don't restart replicas during
+// a database outage.
+var healthServices = []string{
+	pb.ConcertService_ServiceDesc.ServiceName,
+	pb.BookingService_ServiceDesc.ServiceName,
+}
+
+// TrackHealth reports the serving status of every registered service on the
+// grpc.health.v1.Health service from the components in registry, every
+// interval until ctx is done
+func (s *Server) TrackHealth(ctx context.Context, registry *health.Registry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.reportHealth(registry)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportHealth sets the status of the services in healthServices that are
+// registered, NOT_SERVING while a required component is unhealthy, like
+// /health/ready
+func (s *Server) reportHealth(registry *health.Registry) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if !registry.Ready() {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	registered := s.server.GetServiceInfo()
+	for _, service := range healthServices {
+		if _, ok := registered[service]; ok {
+			s.health.SetServingStatus(service, status)
+		}
+	}
+}
+
+func newHealthServer() *grpchealth.Server {
+	server := grpchealth.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	return server
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"concert-ticket-api/internal/service"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// inviteTokenMetadata is the metadata key callers present invite tokens in
+var inviteTokenMetadata = strings.ToLower(service.InviteTokenHeader)
+
+// withInviteToken passes the invite token in the request metadata on to the
+// services, which grant access to the private concert it belongs to
+func withInviteToken(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(inviteTokenMetadata); len(values) > 0 {
+		return service.WithInviteToken(ctx, values[0])
+	}
+	return ctx
+}
+
+// inviteTokenUnaryInterceptor passes invite tokens on to unary RPCs
+func inviteTokenUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withInviteToken(ctx), req)
+}
+
+// inviteTokenStreamInterceptor passes invite tokens on to streaming RPCs
+func inviteTokenStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = withInviteToken(ss.Context())
+	return handler(srv, wrapped)
+}
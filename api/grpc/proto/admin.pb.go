@@ -0,0 +1,430 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.20.3
+// source: api/grpc/proto/admin.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ForceCancelBookingRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Reference string                 `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	// reason is written to the audit log
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceCancelBookingRequest) Reset() {
+	*x = ForceCancelBookingRequest{}
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceCancelBookingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceCancelBookingRequest) ProtoMessage() {}
+
+func (x *ForceCancelBookingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceCancelBookingRequest.ProtoReflect.Descriptor instead.
+func (*ForceCancelBookingRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ForceCancelBookingRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *ForceCancelBookingRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type AdjustInventoryRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ConcertId int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	// delta is added to the total and available tickets; negative values
+	// withdraw unsold tickets
+	Delta int32 `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	// reason is written to the audit log
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustInventoryRequest) Reset() {
+	*x = AdjustInventoryRequest{}
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustInventoryRequest) ProtoMessage() {}
+
+func (x *AdjustInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustInventoryRequest.ProtoReflect.Descriptor instead.
+func (*AdjustInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AdjustInventoryRequest) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *AdjustInventoryRequest) GetDelta() int32 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *AdjustInventoryRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CheckInventoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// concert_id selects the concert to check, every concert when unset
+	ConcertId     int64 `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckInventoryRequest) Reset() {
+	*x = CheckInventoryRequest{}
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckInventoryRequest) ProtoMessage() {}
+
+func (x *CheckInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckInventoryRequest.ProtoReflect.Descriptor instead.
+func (*CheckInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckInventoryRequest) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+type CheckInventoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// checked is the number of concerts checked
+	Checked       int32             `protobuf:"varint,1,opt,name=checked,proto3" json:"checked,omitempty"`
+	Discrepancies []*InventoryCount `protobuf:"bytes,2,rep,name=discrepancies,proto3" json:"discrepancies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckInventoryResponse) Reset() {
+	*x = CheckInventoryResponse{}
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckInventoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckInventoryResponse) ProtoMessage() {}
+
+func (x *CheckInventoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckInventoryResponse.ProtoReflect.Descriptor instead.
+func (*CheckInventoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckInventoryResponse) GetChecked() int32 {
+	if x != nil {
+		return x.Checked
+	}
+	return 0
+}
+
+func (x *CheckInventoryResponse) GetDiscrepancies() []*InventoryCount {
+	if x != nil {
+		return x.Discrepancies
+	}
+	return nil
+}
+
+// InventoryCount is what the tickets of a concert add up to
+type InventoryCount struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ConcertId        int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	TotalTickets     int32                  `protobuf:"varint,2,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	AvailableTickets int32                  `protobuf:"varint,3,opt,name=available_tickets,json=availableTickets,proto3" json:"available_tickets,omitempty"`
+	// expected_available_tickets is the total less the booked and held tickets
+	ExpectedAvailableTickets int32 `protobuf:"varint,4,opt,name=expected_available_tickets,json=expectedAvailableTickets,proto3" json:"expected_available_tickets,omitempty"`
+	// booked_tickets are the tickets of the bookings that aren't cancelled
+	BookedTickets int32 `protobuf:"varint,5,opt,name=booked_tickets,json=bookedTickets,proto3" json:"booked_tickets,omitempty"`
+	// pending_tickets are booked in the redis inventory mode and not yet
+	// taken from the concert
+	PendingTickets int32 `protobuf:"varint,6,opt,name=pending_tickets,json=pendingTickets,proto3" json:"pending_tickets,omitempty"`
+	// held_tickets are held back by pending inventory releases
+	HeldTickets   int32 `protobuf:"varint,7,opt,name=held_tickets,json=heldTickets,proto3" json:"held_tickets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryCount) Reset() {
+	*x = InventoryCount{}
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryCount) ProtoMessage() {}
+
+func (x *InventoryCount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryCount.ProtoReflect.Descriptor instead.
+func (*InventoryCount) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InventoryCount) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetTotalTickets() int32 {
+	if x != nil {
+		return x.TotalTickets
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetAvailableTickets() int32 {
+	if x != nil {
+		return x.AvailableTickets
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetExpectedAvailableTickets() int32 {
+	if x != nil {
+		return x.ExpectedAvailableTickets
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetBookedTickets() int32 {
+	if x != nil {
+		return x.BookedTickets
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetPendingTickets() int32 {
+	if x != nil {
+		return x.PendingTickets
+	}
+	return 0
+}
+
+func (x *InventoryCount) GetHeldTickets() int32 {
+	if x != nil {
+		return x.HeldTickets
+	}
+	return 0
+}
+
+var File_api_grpc_proto_admin_proto protoreflect.FileDescriptor
+
+const file_api_grpc_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x1aapi/grpc/proto/admin.proto\x12\x05admin\x1a\x1capi/grpc/proto/booking.proto\x1a\x1capi/grpc/proto/concert.proto\"Q\n" +
+	"\x19ForceCancelBookingRequest\x12\x1c\n" +
+	"\treference\x18\x01 \x01(\tR\treference\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"e\n" +
+	"\x16AdjustInventoryRequest\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x05R\x05delta\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"6\n" +
+	"\x15CheckInventoryRequest\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\"o\n" +
+	"\x16CheckInventoryResponse\x12\x18\n" +
+	"\achecked\x18\x01 \x01(\x05R\achecked\x12;\n" +
+	"\rdiscrepancies\x18\x02 \x03(\v2\x15.admin.InventoryCountR\rdiscrepancies\"\xb2\x02\n" +
+	"\x0eInventoryCount\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12#\n" +
+	"\rtotal_tickets\x18\x02 \x01(\x05R\ftotalTickets\x12+\n" +
+	"\x11available_tickets\x18\x03 \x01(\x05R\x10availableTickets\x12<\n" +
+	"\x1aexpected_available_tickets\x18\x04 \x01(\x05R\x18expectedAvailableTickets\x12%\n" +
+	"\x0ebooked_tickets\x18\x05 \x01(\x05R\rbookedTickets\x12'\n" +
+	"\x0fpending_tickets\x18\x06 \x01(\x05R\x0ependingTickets\x12!\n" +
+	"\fheld_tickets\x18\a \x01(\x05R\vheldTickets2\xf7\x01\n" +
+	"\fAdminService\x12H\n" +
+	"\x12ForceCancelBooking\x12 .admin.ForceCancelBookingRequest\x1a\x10.booking.Booking\x12N\n" +
+	"\x0fAdjustInventory\x12\x1d.admin.AdjustInventoryRequest\x1a\x1c.concert.ConcertAvailability\x12M\n" +
+	"\x0eCheckInventory\x12\x1c.admin.CheckInventoryRequest\x1a\x1d.admin.CheckInventoryResponseB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
+
+var (
+	file_api_grpc_proto_admin_proto_rawDescOnce sync.Once
+	file_api_grpc_proto_admin_proto_rawDescData []byte
+)
+
+func file_api_grpc_proto_admin_proto_rawDescGZIP() []byte {
+	file_api_grpc_proto_admin_proto_rawDescOnce.Do(func() {
+		file_api_grpc_proto_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_grpc_proto_admin_proto_rawDesc), len(file_api_grpc_proto_admin_proto_rawDesc)))
+	})
+	return file_api_grpc_proto_admin_proto_rawDescData
+}
+
+var file_api_grpc_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_grpc_proto_admin_proto_goTypes = []any{
+	(*ForceCancelBookingRequest)(nil), // 0: admin.ForceCancelBookingRequest
+	(*AdjustInventoryRequest)(nil),    // 1: admin.AdjustInventoryRequest
+	(*CheckInventoryRequest)(nil),     // 2: admin.CheckInventoryRequest
+	(*CheckInventoryResponse)(nil),    // 3: admin.CheckInventoryResponse
+	(*InventoryCount)(nil),            // 4: admin.InventoryCount
+	(*Booking)(nil),                   // 5: booking.Booking
+	(*ConcertAvailability)(nil),       // 6: concert.ConcertAvailability
+}
+var file_api_grpc_proto_admin_proto_depIdxs = []int32{
+	4, // 0: admin.CheckInventoryResponse.discrepancies:type_name -> admin.InventoryCount
+	0, // 1: admin.AdminService.ForceCancelBooking:input_type -> admin.ForceCancelBookingRequest
+	1, // 2: admin.AdminService.AdjustInventory:input_type -> admin.AdjustInventoryRequest
+	2, // 3: admin.AdminService.CheckInventory:input_type -> admin.CheckInventoryRequest
+	5, // 4: admin.AdminService.ForceCancelBooking:output_type -> booking.Booking
+	6, // 5: admin.AdminService.AdjustInventory:output_type -> concert.ConcertAvailability
+	3, // 6: admin.AdminService.CheckInventory:output_type -> admin.CheckInventoryResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_grpc_proto_admin_proto_init() }
+func file_api_grpc_proto_admin_proto_init() {
+	if File_api_grpc_proto_admin_proto != nil {
+		return
+	}
+	file_api_grpc_proto_booking_proto_init()
+	file_api_grpc_proto_concert_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_proto_admin_proto_rawDesc), len(file_api_grpc_proto_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_grpc_proto_admin_proto_goTypes,
+		DependencyIndexes: file_api_grpc_proto_admin_proto_depIdxs,
+		MessageInfos:      file_api_grpc_proto_admin_proto_msgTypes,
+	}.Build()
+	File_api_grpc_proto_admin_proto = out.File
+	file_api_grpc_proto_admin_proto_goTypes = nil
+	file_api_grpc_proto_admin_proto_depIdxs = nil
+}
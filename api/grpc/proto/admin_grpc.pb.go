@@ -0,0 +1,221 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.20.3
+// source: api/grpc/proto/admin.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AdminService_ForceCancelBooking_FullMethodName = "/admin.AdminService/ForceCancelBooking"
+	AdminService_AdjustInventory_FullMethodName    = "/admin.AdminService/AdjustInventory"
+	AdminService_CheckInventory_FullMethodName     = "/admin.AdminService/CheckInventory"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AdminService serves the operations of the internal admin API to operator
+// tools such as cmd/ctl. Its RPCs need the internal admin token, the
+// operator in x-admin-actor metadata and a client address in
+// internal_admin.allowed_networks; the admin token doesn't grant them. They
+// have no HTTP bindings, since the gateway would call them from the server's
+// own address.
+type AdminServiceClient interface {
+	// ForceCancelBooking cancels a booking on behalf of its user and returns
+	// its tickets to the concert
+	ForceCancelBooking(ctx context.Context, in *ForceCancelBookingRequest, opts ...grpc.CallOption) (*Booking, error)
+	// AdjustInventory adds tickets to or withdraws unsold tickets from a concert
+	AdjustInventory(ctx context.Context, in *AdjustInventoryRequest, opts ...grpc.CallOption) (*ConcertAvailability, error)
+	// CheckInventory reports the concerts whose available tickets differ from
+	// what their bookings and pending releases leave
+	CheckInventory(ctx context.Context, in *CheckInventoryRequest, opts ...grpc.CallOption) (*CheckInventoryResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ForceCancelBooking(ctx context.Context, in *ForceCancelBookingRequest, opts ...grpc.CallOption) (*Booking, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Booking)
+	err := c.cc.Invoke(ctx, AdminService_ForceCancelBooking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) AdjustInventory(ctx context.Context, in *AdjustInventoryRequest, opts ...grpc.CallOption) (*ConcertAvailability, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConcertAvailability)
+	err := c.cc.Invoke(ctx, AdminService_AdjustInventory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) CheckInventory(ctx context.Context, in *CheckInventoryRequest, opts ...grpc.CallOption) (*CheckInventoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckInventoryResponse)
+	err := c.cc.Invoke(ctx, AdminService_CheckInventory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// AdminService serves the operations of the internal admin API to operator
+// tools such as cmd/ctl. Its RPCs need the internal admin token, the
+// operator in x-admin-actor metadata and a client address in
+// internal_admin.allowed_networks; the admin token doesn't grant them. They
+// have no HTTP bindings, since the gateway would call them from the server's
+// own address.
+type AdminServiceServer interface {
+	// ForceCancelBooking cancels a booking on behalf of its user and returns
+	// its tickets to the concert
+	ForceCancelBooking(context.Context, *ForceCancelBookingRequest) (*Booking, error)
+	// AdjustInventory adds tickets to or withdraws unsold tickets from a concert
+	AdjustInventory(context.Context, *AdjustInventoryRequest) (*ConcertAvailability, error)
+	// CheckInventory reports the concerts whose available tickets differ from
+	// what their bookings and pending releases leave
+	CheckInventory(context.Context, *CheckInventoryRequest) (*CheckInventoryResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) ForceCancelBooking(context.Context, *ForceCancelBookingRequest) (*Booking, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceCancelBooking not implemented")
+}
+func (UnimplementedAdminServiceServer) AdjustInventory(context.Context, *AdjustInventoryRequest) (*ConcertAvailability, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdjustInventory not implemented")
+}
+func (UnimplementedAdminServiceServer) CheckInventory(context.Context, *CheckInventoryRequest) (*CheckInventoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckInventory not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ForceCancelBooking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceCancelBookingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ForceCancelBooking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ForceCancelBooking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ForceCancelBooking(ctx, req.(*ForceCancelBookingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_AdjustInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustInventoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).AdjustInventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_AdjustInventory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).AdjustInventory(ctx, req.(*AdjustInventoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_CheckInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckInventoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).CheckInventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_CheckInventory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).CheckInventory(ctx, req.(*CheckInventoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ForceCancelBooking",
+			Handler:    _AdminService_ForceCancelBooking_Handler,
+		},
+		{
+			MethodName: "AdjustInventory",
+			Handler:    _AdminService_AdjustInventory_Handler,
+		},
+		{
+			MethodName: "CheckInventory",
+			Handler:    _AdminService_CheckInventory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/grpc/proto/admin.proto",
+}
@@ -7,6 +7,7 @@
 package proto
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
@@ -23,8 +24,11 @@ const (
 )
 
 type GetBookingRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// internal booking ID, only accepted from admins
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// reference takes precedence over id when set
+	Reference     string `protobuf:"bytes,2,opt,name=reference,proto3" json:"reference,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,11 +70,22 @@ func (x *GetBookingRequest) GetId() int64 {
 	return 0
 }
 
+func (x *GetBookingRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
 type GetUserBookingsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
-	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UserId   string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Page     int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// cursor is the next_cursor of the previous page and replaces page and page_size
+	Cursor string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// expand concert embeds a summary of each booking's concert
+	Expand        string `protobuf:"bytes,5,opt,name=expand,proto3" json:"expand,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -126,6 +141,20 @@ func (x *GetUserBookingsRequest) GetPageSize() int32 {
 	return 0
 }
 
+func (x *GetUserBookingsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *GetUserBookingsRequest) GetExpand() string {
+	if x != nil {
+		return x.Expand
+	}
+	return ""
+}
+
 type GetUserBookingsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Bookings      []*Booking             `protobuf:"bytes,1,rep,name=bookings,proto3" json:"bookings,omitempty"`
@@ -183,8 +212,14 @@ type BookTicketsRequest struct {
 	ConcertId     int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
 	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	TicketCount   int32                  `protobuf:"varint,3,opt,name=ticket_count,json=ticketCount,proto3" json:"ticket_count,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	AttendeeName  string                 `protobuf:"bytes,4,opt,name=attendee_name,json=attendeeName,proto3" json:"attendee_name,omitempty"`
+	AttendeeEmail string                 `protobuf:"bytes,5,opt,name=attendee_email,json=attendeeEmail,proto3" json:"attendee_email,omitempty"`
+	BookingToken  string                 `protobuf:"bytes,6,opt,name=booking_token,json=bookingToken,proto3" json:"booking_token,omitempty"`
+	// idempotency_key makes retries return the booking the first request made.
+	// BookTicketsStream ignores it.
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *BookTicketsRequest) Reset() {
@@ -238,17 +273,205 @@ func (x *BookTicketsRequest) GetTicketCount() int32 {
 	return 0
 }
 
-type CancelBookingRequest struct {
+func (x *BookTicketsRequest) GetAttendeeName() string {
+	if x != nil {
+		return x.AttendeeName
+	}
+	return ""
+}
+
+func (x *BookTicketsRequest) GetAttendeeEmail() string {
+	if x != nil {
+		return x.AttendeeEmail
+	}
+	return ""
+}
+
+func (x *BookTicketsRequest) GetBookingToken() string {
+	if x != nil {
+		return x.BookingToken
+	}
+	return ""
+}
+
+func (x *BookTicketsRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type BookTicketsStreamSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Received      int32                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Booked        int32                  `protobuf:"varint,2,opt,name=booked,proto3" json:"booked,omitempty"`
+	Failed        int32                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	TicketsBooked int32                  `protobuf:"varint,4,opt,name=tickets_booked,json=ticketsBooked,proto3" json:"tickets_booked,omitempty"`
+	// chunks is the number of chunks the requests were committed in
+	Chunks int32 `protobuf:"varint,5,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// results has one entry per request, in stream order
+	Results       []*BookTicketsStreamResult `protobuf:"bytes,6,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookTicketsStreamSummary) Reset() {
+	*x = BookTicketsStreamSummary{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookTicketsStreamSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookTicketsStreamSummary) ProtoMessage() {}
+
+func (x *BookTicketsStreamSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookTicketsStreamSummary.ProtoReflect.Descriptor instead.
+func (*BookTicketsStreamSummary) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BookTicketsStreamSummary) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamSummary) GetBooked() int32 {
+	if x != nil {
+		return x.Booked
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamSummary) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamSummary) GetTicketsBooked() int32 {
+	if x != nil {
+		return x.TicketsBooked
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamSummary) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamSummary) GetResults() []*BookTicketsStreamResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BookTicketsStreamResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// index is the position of the request in the stream, starting at 0
+	Index int32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// reference is set for the requests that were booked
+	Reference string `protobuf:"bytes,2,opt,name=reference,proto3" json:"reference,omitempty"`
+	// error_reason is the problem code of a failed request, e.g. INSUFFICIENT_TICKETS
+	ErrorReason   string `protobuf:"bytes,3,opt,name=error_reason,json=errorReason,proto3" json:"error_reason,omitempty"`
+	ErrorMessage  string `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookTicketsStreamResult) Reset() {
+	*x = BookTicketsStreamResult{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookTicketsStreamResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookTicketsStreamResult) ProtoMessage() {}
+
+func (x *BookTicketsStreamResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookTicketsStreamResult.ProtoReflect.Descriptor instead.
+func (*BookTicketsStreamResult) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BookTicketsStreamResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BookTicketsStreamResult) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *BookTicketsStreamResult) GetErrorReason() string {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return ""
+}
+
+func (x *BookTicketsStreamResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+type CancelBookingRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// internal booking ID, only accepted from admins
+	Id     int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// reference takes precedence over id when set
+	Reference     string `protobuf:"bytes,3,opt,name=reference,proto3" json:"reference,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CancelBookingRequest) Reset() {
 	*x = CancelBookingRequest{}
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[4]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -260,7 +483,7 @@ func (x *CancelBookingRequest) String() string {
 func (*CancelBookingRequest) ProtoMessage() {}
 
 func (x *CancelBookingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[4]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -273,7 +496,7 @@ func (x *CancelBookingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelBookingRequest.ProtoReflect.Descriptor instead.
 func (*CancelBookingRequest) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{4}
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CancelBookingRequest) GetId() int64 {
@@ -290,6 +513,13 @@ func (x *CancelBookingRequest) GetUserId() string {
 	return ""
 }
 
+func (x *CancelBookingRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
 type CancelBookingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -299,7 +529,7 @@ type CancelBookingResponse struct {
 
 func (x *CancelBookingResponse) Reset() {
 	*x = CancelBookingResponse{}
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[5]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -311,7 +541,7 @@ func (x *CancelBookingResponse) String() string {
 func (*CancelBookingResponse) ProtoMessage() {}
 
 func (x *CancelBookingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[5]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,7 +554,7 @@ func (x *CancelBookingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelBookingResponse.ProtoReflect.Descriptor instead.
 func (*CancelBookingResponse) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{5}
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CancelBookingResponse) GetMessage() string {
@@ -344,13 +574,22 @@ type Booking struct {
 	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	AttendeeName  string                 `protobuf:"bytes,9,opt,name=attendee_name,json=attendeeName,proto3" json:"attendee_name,omitempty"`
+	AttendeeEmail string                 `protobuf:"bytes,10,opt,name=attendee_email,json=attendeeEmail,proto3" json:"attendee_email,omitempty"`
+	Reference     string                 `protobuf:"bytes,11,opt,name=reference,proto3" json:"reference,omitempty"`
+	UnitPrice     float64                `protobuf:"fixed64,12,opt,name=unit_price,json=unitPrice,proto3" json:"unit_price,omitempty"`
+	// concert is only set on listings that expand it
+	Concert *ConcertSummary `protobuf:"bytes,13,opt,name=concert,proto3" json:"concert,omitempty"`
+	// payment is only set on pending bookings returned to the client that
+	// booked them, to complete the payment with
+	Payment       *Payment `protobuf:"bytes,14,opt,name=payment,proto3" json:"payment,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Booking) Reset() {
 	*x = Booking{}
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[6]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -362,7 +601,7 @@ func (x *Booking) String() string {
 func (*Booking) ProtoMessage() {}
 
 func (x *Booking) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_booking_proto_msgTypes[6]
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -375,7 +614,7 @@ func (x *Booking) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Booking.ProtoReflect.Descriptor instead.
 func (*Booking) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{6}
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Booking) GetId() int64 {
@@ -434,48 +673,793 @@ func (x *Booking) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
-var File_api_grpc_proto_booking_proto protoreflect.FileDescriptor
+func (x *Booking) GetAttendeeName() string {
+	if x != nil {
+		return x.AttendeeName
+	}
+	return ""
+}
 
-const file_api_grpc_proto_booking_proto_rawDesc = "" +
-	"\n" +
-	"\x1capi/grpc/proto/booking.proto\x12\abooking\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/grpc/proto/common.proto\"#\n" +
-	"\x11GetBookingRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\"b\n" +
-	"\x16GetUserBookingsRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
-	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
-	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"s\n" +
-	"\x17GetUserBookingsResponse\x12,\n" +
-	"\bbookings\x18\x01 \x03(\v2\x10.booking.BookingR\bbookings\x12*\n" +
-	"\x04meta\x18\x02 \x01(\v2\x16.common.PaginationMetaR\x04meta\"o\n" +
-	"\x12BookTicketsRequest\x12\x1d\n" +
-	"\n" +
-	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\x12!\n" +
-	"\fticket_count\x18\x03 \x01(\x05R\vticketCount\"?\n" +
-	"\x14CancelBookingRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\"1\n" +
-	"\x15CancelBookingResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"\xc1\x02\n" +
-	"\aBooking\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
-	"\n" +
-	"concert_id\x18\x02 \x01(\x03R\tconcertId\x12\x17\n" +
-	"\auser_id\x18\x03 \x01(\tR\x06userId\x12!\n" +
-	"\fticket_count\x18\x04 \x01(\x05R\vticketCount\x12=\n" +
-	"\fbooking_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vbookingTime\x12\x16\n" +
-	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
-	"\n" +
-	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+func (x *Booking) GetAttendeeEmail() string {
+	if x != nil {
+		return x.AttendeeEmail
+	}
+	return ""
+}
+
+func (x *Booking) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Booking) GetUnitPrice() float64 {
+	if x != nil {
+		return x.UnitPrice
+	}
+	return 0
+}
+
+func (x *Booking) GetConcert() *ConcertSummary {
+	if x != nil {
+		return x.Concert
+	}
+	return nil
+}
+
+func (x *Booking) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+// Payment is the payment of a pending booking at the payment provider
+type Payment struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Provider          string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	ProviderReference string                 `protobuf:"bytes,2,opt,name=provider_reference,json=providerReference,proto3" json:"provider_reference,omitempty"`
+	ClientSecret      string                 `protobuf:"bytes,3,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"`
+	Amount            float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency          string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status            string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	// expires_at is when the tickets are released if the booking isn't paid
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Payment) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Payment) GetProviderReference() string {
+	if x != nil {
+		return x.ProviderReference
+	}
+	return ""
+}
+
+func (x *Payment) GetClientSecret() string {
+	if x != nil {
+		return x.ClientSecret
+	}
+	return ""
+}
+
+func (x *Payment) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Payment) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Payment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Payment) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+// ConcertSummary is the part of a concert embedded in a booking
+type ConcertSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Artist        string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Venue         string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
+	ConcertDate   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConcertSummary) Reset() {
+	*x = ConcertSummary{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConcertSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConcertSummary) ProtoMessage() {}
+
+func (x *ConcertSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConcertSummary.ProtoReflect.Descriptor instead.
+func (*ConcertSummary) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ConcertSummary) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ConcertSummary) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ConcertSummary) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *ConcertSummary) GetVenue() string {
+	if x != nil {
+		return x.Venue
+	}
+	return ""
+}
+
+func (x *ConcertSummary) GetConcertDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConcertDate
+	}
+	return nil
+}
+
+// Order groups the bookings of one checkout, which are paid as one
+type Order struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Reference string                 `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	UserId    string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// mode is the checkout mode, all_or_nothing or best_effort
+	Mode     string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	Currency string `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status   string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	// total is the amount paid for the bookings of the order
+	Total         float64                `protobuf:"fixed64,6,opt,name=total,proto3" json:"total,omitempty"`
+	Bookings      []*Booking             `protobuf:"bytes,7,rep,name=bookings,proto3" json:"bookings,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Order) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Order) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Order) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Order) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Order) GetBookings() []*Booking {
+	if x != nil {
+		return x.Bookings
+	}
+	return nil
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reference     string                 `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetOrderRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type GetUserOrdersRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UserId   string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Page     int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// cursor is the next_cursor of the previous page and replaces page and page_size
+	Cursor        string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserOrdersRequest) Reset() {
+	*x = GetUserOrdersRequest{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserOrdersRequest) ProtoMessage() {}
+
+func (x *GetUserOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserOrdersRequest.ProtoReflect.Descriptor instead.
+func (*GetUserOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetUserOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetUserOrdersRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *GetUserOrdersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetUserOrdersRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type GetUserOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Meta          *PaginationMeta        `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserOrdersResponse) Reset() {
+	*x = GetUserOrdersResponse{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserOrdersResponse) ProtoMessage() {}
+
+func (x *GetUserOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserOrdersResponse.ProtoReflect.Descriptor instead.
+func (*GetUserOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetUserOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *GetUserOrdersResponse) GetMeta() *PaginationMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reference     string                 `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CancelOrderRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type IssueBookingTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConcertId     int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssueBookingTokenRequest) Reset() {
+	*x = IssueBookingTokenRequest{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueBookingTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueBookingTokenRequest) ProtoMessage() {}
+
+func (x *IssueBookingTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueBookingTokenRequest.ProtoReflect.Descriptor instead.
+func (*IssueBookingTokenRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *IssueBookingTokenRequest) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *IssueBookingTokenRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type BookingToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ConcertId     int64                  `protobuf:"varint,2,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookingToken) Reset() {
+	*x = BookingToken{}
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookingToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookingToken) ProtoMessage() {}
+
+func (x *BookingToken) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_booking_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookingToken.ProtoReflect.Descriptor instead.
+func (*BookingToken) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_booking_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BookingToken) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *BookingToken) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *BookingToken) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BookingToken) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+var File_api_grpc_proto_booking_proto protoreflect.FileDescriptor
+
+const file_api_grpc_proto_booking_proto_rawDesc = "" +
+	"\n" +
+	"\x1capi/grpc/proto/booking.proto\x12\abooking\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/grpc/proto/common.proto\"A\n" +
+	"\x11GetBookingRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1c\n" +
+	"\treference\x18\x02 \x01(\tR\treference\"\x92\x01\n" +
+	"\x16GetUserBookingsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\x12\x16\n" +
+	"\x06expand\x18\x05 \x01(\tR\x06expand\"s\n" +
+	"\x17GetUserBookingsResponse\x12,\n" +
+	"\bbookings\x18\x01 \x03(\v2\x10.booking.BookingR\bbookings\x12*\n" +
+	"\x04meta\x18\x02 \x01(\v2\x16.common.PaginationMetaR\x04meta\"\x89\x02\n" +
+	"\x12BookTicketsRequest\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12!\n" +
+	"\fticket_count\x18\x03 \x01(\x05R\vticketCount\x12#\n" +
+	"\rattendee_name\x18\x04 \x01(\tR\fattendeeName\x12%\n" +
+	"\x0eattendee_email\x18\x05 \x01(\tR\rattendeeEmail\x12#\n" +
+	"\rbooking_token\x18\x06 \x01(\tR\fbookingToken\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\"\xe1\x01\n" +
+	"\x18BookTicketsStreamSummary\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x05R\breceived\x12\x16\n" +
+	"\x06booked\x18\x02 \x01(\x05R\x06booked\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x12%\n" +
+	"\x0etickets_booked\x18\x04 \x01(\x05R\rticketsBooked\x12\x16\n" +
+	"\x06chunks\x18\x05 \x01(\x05R\x06chunks\x12:\n" +
+	"\aresults\x18\x06 \x03(\v2 .booking.BookTicketsStreamResultR\aresults\"\x95\x01\n" +
+	"\x17BookTicketsStreamResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1c\n" +
+	"\treference\x18\x02 \x01(\tR\treference\x12!\n" +
+	"\ferror_reason\x18\x03 \x01(\tR\verrorReason\x12#\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\"]\n" +
+	"\x14CancelBookingRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1c\n" +
+	"\treference\x18\x03 \x01(\tR\treference\"1\n" +
+	"\x15CancelBookingResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xa9\x04\n" +
+	"\aBooking\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x02 \x01(\x03R\tconcertId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12!\n" +
+	"\fticket_count\x18\x04 \x01(\x05R\vticketCount\x12=\n" +
+	"\fbooking_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vbookingTime\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12#\n" +
+	"\rattendee_name\x18\t \x01(\tR\fattendeeName\x12%\n" +
+	"\x0eattendee_email\x18\n" +
+	" \x01(\tR\rattendeeEmail\x12\x1c\n" +
+	"\treference\x18\v \x01(\tR\treference\x12\x1d\n" +
+	"\n" +
+	"unit_price\x18\f \x01(\x01R\tunitPrice\x121\n" +
+	"\aconcert\x18\r \x01(\v2\x17.booking.ConcertSummaryR\aconcert\x12*\n" +
+	"\apayment\x18\x0e \x01(\v2\x10.booking.PaymentR\apayment\"\x80\x02\n" +
+	"\aPayment\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12-\n" +
+	"\x12provider_reference\x18\x02 \x01(\tR\x11providerReference\x12#\n" +
+	"\rclient_secret\x18\x03 \x01(\tR\fclientSecret\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xa1\x01\n" +
+	"\x0eConcertSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06artist\x18\x03 \x01(\tR\x06artist\x12\x14\n" +
+	"\x05venue\x18\x04 \x01(\tR\x05venue\x12=\n" +
+	"\fconcert_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vconcertDate\"\xc0\x02\n" +
+	"\x05Order\x12\x1c\n" +
+	"\treference\x18\x01 \x01(\tR\treference\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x14\n" +
+	"\x05total\x18\x06 \x01(\x01R\x05total\x12,\n" +
+	"\bbookings\x18\a \x03(\v2\x10.booking.BookingR\bbookings\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"/\n" +
+	"\x0fGetOrderRequest\x12\x1c\n" +
+	"\treference\x18\x01 \x01(\tR\treference\"x\n" +
+	"\x14GetUserOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"k\n" +
+	"\x15GetUserOrdersResponse\x12&\n" +
+	"\x06orders\x18\x01 \x03(\v2\x0e.booking.OrderR\x06orders\x12*\n" +
+	"\x04meta\x18\x02 \x01(\v2\x16.common.PaginationMetaR\x04meta\"K\n" +
+	"\x12CancelOrderRequest\x12\x1c\n" +
+	"\treference\x18\x01 \x01(\tR\treference\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"R\n" +
+	"\x18IssueBookingTokenRequest\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\x97\x01\n" +
+	"\fBookingToken\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x02 \x01(\x03R\tconcertId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x129\n" +
 	"\n" +
-	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\xb0\x02\n" +
-	"\x0eBookingService\x12:\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt2\xb2\b\n" +
+	"\x0eBookingService\x12d\n" +
 	"\n" +
-	"GetBooking\x12\x1a.booking.GetBookingRequest\x1a\x10.booking.Booking\x12T\n" +
-	"\x0fGetUserBookings\x12\x1f.booking.GetUserBookingsRequest\x1a .booking.GetUserBookingsResponse\x12<\n" +
-	"\vBookTickets\x12\x1b.booking.BookTicketsRequest\x1a\x10.booking.Booking\x12N\n" +
-	"\rCancelBooking\x12\x1d.booking.CancelBookingRequest\x1a\x1e.booking.CancelBookingResponseB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
+	"GetBooking\x12\x1a.booking.GetBookingRequest\x1a\x10.booking.Booking\"(\x82\xd3\xe4\x93\x02\"\x12 /gateway/v1/bookings/{reference}\x12\x82\x01\n" +
+	"\x0fGetUserBookings\x12\x1f.booking.GetUserBookingsRequest\x1a .booking.GetUserBookingsResponse\",\x82\xd3\xe4\x93\x02&\x12$/gateway/v1/users/{user_id}/bookings\x12]\n" +
+	"\vBookTickets\x12\x1b.booking.BookTicketsRequest\x1a\x10.booking.Booking\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/gateway/v1/bookings\x12}\n" +
+	"\x11BookTicketsStream\x12\x1b.booking.BookTicketsRequest\x1a!.booking.BookTicketsStreamSummary\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/gateway/v1/bookings:stream(\x01\x12\x82\x01\n" +
+	"\rCancelBooking\x12\x1d.booking.CancelBookingRequest\x1a\x1e.booking.CancelBookingResponse\"2\x82\xd3\xe4\x93\x02,:\x01*\"'/gateway/v1/bookings/{reference}/cancel\x12\\\n" +
+	"\bGetOrder\x12\x18.booking.GetOrderRequest\x1a\x0e.booking.Order\"&\x82\xd3\xe4\x93\x02 \x12\x1e/gateway/v1/orders/{reference}\x12z\n" +
+	"\rGetUserOrders\x12\x1d.booking.GetUserOrdersRequest\x1a\x1e.booking.GetUserOrdersResponse\"*\x82\xd3\xe4\x93\x02$\x12\"/gateway/v1/users/{user_id}/orders\x12l\n" +
+	"\vCancelOrder\x12\x1b.booking.CancelOrderRequest\x1a\x0e.booking.Order\"0\x82\xd3\xe4\x93\x02*:\x01*\"%/gateway/v1/orders/{reference}/cancel\x12\x89\x01\n" +
+	"\x11IssueBookingToken\x12!.booking.IssueBookingTokenRequest\x1a\x15.booking.BookingToken\":\x82\xd3\xe4\x93\x024:\x01*\"//gateway/v1/concerts/{concert_id}/booking-tokenB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
 
 var (
 	file_api_grpc_proto_booking_proto_rawDescOnce sync.Once
@@ -489,37 +1473,69 @@ func file_api_grpc_proto_booking_proto_rawDescGZIP() []byte {
 	return file_api_grpc_proto_booking_proto_rawDescData
 }
 
-var file_api_grpc_proto_booking_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_grpc_proto_booking_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_api_grpc_proto_booking_proto_goTypes = []any{
-	(*GetBookingRequest)(nil),       // 0: booking.GetBookingRequest
-	(*GetUserBookingsRequest)(nil),  // 1: booking.GetUserBookingsRequest
-	(*GetUserBookingsResponse)(nil), // 2: booking.GetUserBookingsResponse
-	(*BookTicketsRequest)(nil),      // 3: booking.BookTicketsRequest
-	(*CancelBookingRequest)(nil),    // 4: booking.CancelBookingRequest
-	(*CancelBookingResponse)(nil),   // 5: booking.CancelBookingResponse
-	(*Booking)(nil),                 // 6: booking.Booking
-	(*PaginationMeta)(nil),          // 7: common.PaginationMeta
-	(*timestamppb.Timestamp)(nil),   // 8: google.protobuf.Timestamp
+	(*GetBookingRequest)(nil),        // 0: booking.GetBookingRequest
+	(*GetUserBookingsRequest)(nil),   // 1: booking.GetUserBookingsRequest
+	(*GetUserBookingsResponse)(nil),  // 2: booking.GetUserBookingsResponse
+	(*BookTicketsRequest)(nil),       // 3: booking.BookTicketsRequest
+	(*BookTicketsStreamSummary)(nil), // 4: booking.BookTicketsStreamSummary
+	(*BookTicketsStreamResult)(nil),  // 5: booking.BookTicketsStreamResult
+	(*CancelBookingRequest)(nil),     // 6: booking.CancelBookingRequest
+	(*CancelBookingResponse)(nil),    // 7: booking.CancelBookingResponse
+	(*Booking)(nil),                  // 8: booking.Booking
+	(*Payment)(nil),                  // 9: booking.Payment
+	(*ConcertSummary)(nil),           // 10: booking.ConcertSummary
+	(*Order)(nil),                    // 11: booking.Order
+	(*GetOrderRequest)(nil),          // 12: booking.GetOrderRequest
+	(*GetUserOrdersRequest)(nil),     // 13: booking.GetUserOrdersRequest
+	(*GetUserOrdersResponse)(nil),    // 14: booking.GetUserOrdersResponse
+	(*CancelOrderRequest)(nil),       // 15: booking.CancelOrderRequest
+	(*IssueBookingTokenRequest)(nil), // 16: booking.IssueBookingTokenRequest
+	(*BookingToken)(nil),             // 17: booking.BookingToken
+	(*PaginationMeta)(nil),           // 18: common.PaginationMeta
+	(*timestamppb.Timestamp)(nil),    // 19: google.protobuf.Timestamp
 }
 var file_api_grpc_proto_booking_proto_depIdxs = []int32{
-	6, // 0: booking.GetUserBookingsResponse.bookings:type_name -> booking.Booking
-	7, // 1: booking.GetUserBookingsResponse.meta:type_name -> common.PaginationMeta
-	8, // 2: booking.Booking.booking_time:type_name -> google.protobuf.Timestamp
-	8, // 3: booking.Booking.created_at:type_name -> google.protobuf.Timestamp
-	8, // 4: booking.Booking.updated_at:type_name -> google.protobuf.Timestamp
-	0, // 5: booking.BookingService.GetBooking:input_type -> booking.GetBookingRequest
-	1, // 6: booking.BookingService.GetUserBookings:input_type -> booking.GetUserBookingsRequest
-	3, // 7: booking.BookingService.BookTickets:input_type -> booking.BookTicketsRequest
-	4, // 8: booking.BookingService.CancelBooking:input_type -> booking.CancelBookingRequest
-	6, // 9: booking.BookingService.GetBooking:output_type -> booking.Booking
-	2, // 10: booking.BookingService.GetUserBookings:output_type -> booking.GetUserBookingsResponse
-	6, // 11: booking.BookingService.BookTickets:output_type -> booking.Booking
-	5, // 12: booking.BookingService.CancelBooking:output_type -> booking.CancelBookingResponse
-	9, // [9:13] is the sub-list for method output_type
-	5, // [5:9] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	8,  // 0: booking.GetUserBookingsResponse.bookings:type_name -> booking.Booking
+	18, // 1: booking.GetUserBookingsResponse.meta:type_name -> common.PaginationMeta
+	5,  // 2: booking.BookTicketsStreamSummary.results:type_name -> booking.BookTicketsStreamResult
+	19, // 3: booking.Booking.booking_time:type_name -> google.protobuf.Timestamp
+	19, // 4: booking.Booking.created_at:type_name -> google.protobuf.Timestamp
+	19, // 5: booking.Booking.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 6: booking.Booking.concert:type_name -> booking.ConcertSummary
+	9,  // 7: booking.Booking.payment:type_name -> booking.Payment
+	19, // 8: booking.Payment.expires_at:type_name -> google.protobuf.Timestamp
+	19, // 9: booking.ConcertSummary.concert_date:type_name -> google.protobuf.Timestamp
+	8,  // 10: booking.Order.bookings:type_name -> booking.Booking
+	19, // 11: booking.Order.created_at:type_name -> google.protobuf.Timestamp
+	19, // 12: booking.Order.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 13: booking.GetUserOrdersResponse.orders:type_name -> booking.Order
+	18, // 14: booking.GetUserOrdersResponse.meta:type_name -> common.PaginationMeta
+	19, // 15: booking.BookingToken.expires_at:type_name -> google.protobuf.Timestamp
+	0,  // 16: booking.BookingService.GetBooking:input_type -> booking.GetBookingRequest
+	1,  // 17: booking.BookingService.GetUserBookings:input_type -> booking.GetUserBookingsRequest
+	3,  // 18: booking.BookingService.BookTickets:input_type -> booking.BookTicketsRequest
+	3,  // 19: booking.BookingService.BookTicketsStream:input_type -> booking.BookTicketsRequest
+	6,  // 20: booking.BookingService.CancelBooking:input_type -> booking.CancelBookingRequest
+	12, // 21: booking.BookingService.GetOrder:input_type -> booking.GetOrderRequest
+	13, // 22: booking.BookingService.GetUserOrders:input_type -> booking.GetUserOrdersRequest
+	15, // 23: booking.BookingService.CancelOrder:input_type -> booking.CancelOrderRequest
+	16, // 24: booking.BookingService.IssueBookingToken:input_type -> booking.IssueBookingTokenRequest
+	8,  // 25: booking.BookingService.GetBooking:output_type -> booking.Booking
+	2,  // 26: booking.BookingService.GetUserBookings:output_type -> booking.GetUserBookingsResponse
+	8,  // 27: booking.BookingService.BookTickets:output_type -> booking.Booking
+	4,  // 28: booking.BookingService.BookTicketsStream:output_type -> booking.BookTicketsStreamSummary
+	7,  // 29: booking.BookingService.CancelBooking:output_type -> booking.CancelBookingResponse
+	11, // 30: booking.BookingService.GetOrder:output_type -> booking.Order
+	14, // 31: booking.BookingService.GetUserOrders:output_type -> booking.GetUserOrdersResponse
+	11, // 32: booking.BookingService.CancelOrder:output_type -> booking.Order
+	17, // 33: booking.BookingService.IssueBookingToken:output_type -> booking.BookingToken
+	25, // [25:34] is the sub-list for method output_type
+	16, // [16:25] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_api_grpc_proto_booking_proto_init() }
@@ -534,7 +1550,7 @@ func file_api_grpc_proto_booking_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_proto_booking_proto_rawDesc), len(file_api_grpc_proto_booking_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,20 +19,36 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BookingService_GetBooking_FullMethodName      = "/booking.BookingService/GetBooking"
-	BookingService_GetUserBookings_FullMethodName = "/booking.BookingService/GetUserBookings"
-	BookingService_BookTickets_FullMethodName     = "/booking.BookingService/BookTickets"
-	BookingService_CancelBooking_FullMethodName   = "/booking.BookingService/CancelBooking"
+	BookingService_GetBooking_FullMethodName        = "/booking.BookingService/GetBooking"
+	BookingService_GetUserBookings_FullMethodName   = "/booking.BookingService/GetUserBookings"
+	BookingService_BookTickets_FullMethodName       = "/booking.BookingService/BookTickets"
+	BookingService_BookTicketsStream_FullMethodName = "/booking.BookingService/BookTicketsStream"
+	BookingService_CancelBooking_FullMethodName     = "/booking.BookingService/CancelBooking"
+	BookingService_GetOrder_FullMethodName          = "/booking.BookingService/GetOrder"
+	BookingService_GetUserOrders_FullMethodName     = "/booking.BookingService/GetUserOrders"
+	BookingService_CancelOrder_FullMethodName       = "/booking.BookingService/CancelOrder"
+	BookingService_IssueBookingToken_FullMethodName = "/booking.BookingService/IssueBookingToken"
 )
 
 // BookingServiceClient is the client API for BookingService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// HTTP bindings are served by the REST gateway under /gateway/v1
 type BookingServiceClient interface {
 	GetBooking(ctx context.Context, in *GetBookingRequest, opts ...grpc.CallOption) (*Booking, error)
 	GetUserBookings(ctx context.Context, in *GetUserBookingsRequest, opts ...grpc.CallOption) (*GetUserBookingsResponse, error)
 	BookTickets(ctx context.Context, in *BookTicketsRequest, opts ...grpc.CallOption) (*Booking, error)
+	// BookTicketsStream books many requests sent on one stream, such as the
+	// allocation of an agency. Requests are committed in chunks as they arrive
+	// and the summary is returned when the client closes the stream.
+	BookTicketsStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BookTicketsRequest, BookTicketsStreamSummary], error)
 	CancelBooking(ctx context.Context, in *CancelBookingRequest, opts ...grpc.CallOption) (*CancelBookingResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	GetUserOrders(ctx context.Context, in *GetUserOrdersRequest, opts ...grpc.CallOption) (*GetUserOrdersResponse, error)
+	// CancelOrder cancels an order and every booking of it still confirmed
+	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	IssueBookingToken(ctx context.Context, in *IssueBookingTokenRequest, opts ...grpc.CallOption) (*BookingToken, error)
 }
 
 type bookingServiceClient struct {
@@ -73,6 +89,19 @@ func (c *bookingServiceClient) BookTickets(ctx context.Context, in *BookTicketsR
 	return out, nil
 }
 
+func (c *bookingServiceClient) BookTicketsStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BookTicketsRequest, BookTicketsStreamSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BookingService_ServiceDesc.Streams[0], BookingService_BookTicketsStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BookTicketsRequest, BookTicketsStreamSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookingService_BookTicketsStreamClient = grpc.ClientStreamingClient[BookTicketsRequest, BookTicketsStreamSummary]
+
 func (c *bookingServiceClient) CancelBooking(ctx context.Context, in *CancelBookingRequest, opts ...grpc.CallOption) (*CancelBookingResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CancelBookingResponse)
@@ -83,14 +112,65 @@ func (c *bookingServiceClient) CancelBooking(ctx context.Context, in *CancelBook
 	return out, nil
 }
 
+func (c *bookingServiceClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, BookingService_GetOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) GetUserOrders(ctx context.Context, in *GetUserOrdersRequest, opts ...grpc.CallOption) (*GetUserOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserOrdersResponse)
+	err := c.cc.Invoke(ctx, BookingService_GetUserOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, BookingService_CancelOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) IssueBookingToken(ctx context.Context, in *IssueBookingTokenRequest, opts ...grpc.CallOption) (*BookingToken, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BookingToken)
+	err := c.cc.Invoke(ctx, BookingService_IssueBookingToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BookingServiceServer is the server API for BookingService service.
 // All implementations must embed UnimplementedBookingServiceServer
 // for forward compatibility.
+//
+// HTTP bindings are served by the REST gateway under /gateway/v1
 type BookingServiceServer interface {
 	GetBooking(context.Context, *GetBookingRequest) (*Booking, error)
 	GetUserBookings(context.Context, *GetUserBookingsRequest) (*GetUserBookingsResponse, error)
 	BookTickets(context.Context, *BookTicketsRequest) (*Booking, error)
+	// BookTicketsStream books many requests sent on one stream, such as the
+	// allocation of an agency. Requests are committed in chunks as they arrive
+	// and the summary is returned when the client closes the stream.
+	BookTicketsStream(grpc.ClientStreamingServer[BookTicketsRequest, BookTicketsStreamSummary]) error
 	CancelBooking(context.Context, *CancelBookingRequest) (*CancelBookingResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	GetUserOrders(context.Context, *GetUserOrdersRequest) (*GetUserOrdersResponse, error)
+	// CancelOrder cancels an order and every booking of it still confirmed
+	CancelOrder(context.Context, *CancelOrderRequest) (*Order, error)
+	IssueBookingToken(context.Context, *IssueBookingTokenRequest) (*BookingToken, error)
 	mustEmbedUnimplementedBookingServiceServer()
 }
 
@@ -110,9 +190,24 @@ func (UnimplementedBookingServiceServer) GetUserBookings(context.Context, *GetUs
 func (UnimplementedBookingServiceServer) BookTickets(context.Context, *BookTicketsRequest) (*Booking, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BookTickets not implemented")
 }
+func (UnimplementedBookingServiceServer) BookTicketsStream(grpc.ClientStreamingServer[BookTicketsRequest, BookTicketsStreamSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method BookTicketsStream not implemented")
+}
 func (UnimplementedBookingServiceServer) CancelBooking(context.Context, *CancelBookingRequest) (*CancelBookingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelBooking not implemented")
 }
+func (UnimplementedBookingServiceServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedBookingServiceServer) GetUserOrders(context.Context, *GetUserOrdersRequest) (*GetUserOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserOrders not implemented")
+}
+func (UnimplementedBookingServiceServer) CancelOrder(context.Context, *CancelOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedBookingServiceServer) IssueBookingToken(context.Context, *IssueBookingTokenRequest) (*BookingToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueBookingToken not implemented")
+}
 func (UnimplementedBookingServiceServer) mustEmbedUnimplementedBookingServiceServer() {}
 func (UnimplementedBookingServiceServer) testEmbeddedByValue()                        {}
 
@@ -188,6 +283,13 @@ func _BookingService_BookTickets_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookingService_BookTicketsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BookingServiceServer).BookTicketsStream(&grpc.GenericServerStream[BookTicketsRequest, BookTicketsStreamSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookingService_BookTicketsStreamServer = grpc.ClientStreamingServer[BookTicketsRequest, BookTicketsStreamSummary]
+
 func _BookingService_CancelBooking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CancelBookingRequest)
 	if err := dec(in); err != nil {
@@ -206,6 +308,78 @@ func _BookingService_CancelBooking_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookingService_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookingService_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_GetUserOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).GetUserOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookingService_GetUserOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).GetUserOrders(ctx, req.(*GetUserOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookingService_CancelOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).CancelOrder(ctx, req.(*CancelOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_IssueBookingToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueBookingTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).IssueBookingToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookingService_IssueBookingToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).IssueBookingToken(ctx, req.(*IssueBookingTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BookingService_ServiceDesc is the grpc.ServiceDesc for BookingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,7 +403,29 @@ var BookingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelBooking",
 			Handler:    _BookingService_CancelBooking_Handler,
 		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _BookingService_GetOrder_Handler,
+		},
+		{
+			MethodName: "GetUserOrders",
+			Handler:    _BookingService_GetUserOrders_Handler,
+		},
+		{
+			MethodName: "CancelOrder",
+			Handler:    _BookingService_CancelOrder_Handler,
+		},
+		{
+			MethodName: "IssueBookingToken",
+			Handler:    _BookingService_IssueBookingToken_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BookTicketsStream",
+			Handler:       _BookingService_BookTicketsStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/grpc/proto/booking.proto",
 }
@@ -22,13 +22,17 @@ const (
 )
 
 type PaginationMeta struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	TotalCount    int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
-	TotalPages    int32                  `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Page       int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize   int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	TotalCount int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	TotalPages int32                  `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	// next_cursor fetches the following page and is empty on the last page
+	NextCursor string `protobuf:"bytes,5,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	// total_count_estimated is set when an estimate of the total was asked for
+	TotalCountEstimated bool `protobuf:"varint,6,opt,name=total_count_estimated,json=totalCountEstimated,proto3" json:"total_count_estimated,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *PaginationMeta) Reset() {
@@ -89,18 +93,35 @@ func (x *PaginationMeta) GetTotalPages() int32 {
 	return 0
 }
 
+func (x *PaginationMeta) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *PaginationMeta) GetTotalCountEstimated() bool {
+	if x != nil {
+		return x.TotalCountEstimated
+	}
+	return false
+}
+
 var File_api_grpc_proto_common_proto protoreflect.FileDescriptor
 
 const file_api_grpc_proto_common_proto_rawDesc = "" +
 	"\n" +
-	"\x1bapi/grpc/proto/common.proto\x12\x06common\"\x83\x01\n" +
+	"\x1bapi/grpc/proto/common.proto\x12\x06common\"\xd8\x01\n" +
 	"\x0ePaginationMeta\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
 	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1f\n" +
 	"\vtotal_count\x18\x03 \x01(\x05R\n" +
 	"totalCount\x12\x1f\n" +
 	"\vtotal_pages\x18\x04 \x01(\x05R\n" +
-	"totalPagesB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
+	"totalPages\x12\x1f\n" +
+	"\vnext_cursor\x18\x05 \x01(\tR\n" +
+	"nextCursor\x122\n" +
+	"\x15total_count_estimated\x18\x06 \x01(\bR\x13totalCountEstimatedB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
 
 var (
 	file_api_grpc_proto_common_proto_rawDescOnce sync.Once
@@ -7,8 +7,10 @@
 package proto
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -76,6 +78,16 @@ type ListConcertsRequest struct {
 	DateFrom      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=date_from,json=dateFrom,proto3" json:"date_from,omitempty"`
 	DateTo        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=date_to,json=dateTo,proto3" json:"date_to,omitempty"`
 	AvailableOnly bool                   `protobuf:"varint,8,opt,name=available_only,json=availableOnly,proto3" json:"available_only,omitempty"`
+	// cursor is the next_cursor of the previous page and replaces page and page_size
+	Cursor string `protobuf:"bytes,9,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// sort lists fields such as -price,concert_date; - sorts in descending order
+	Sort string `protobuf:"bytes,10,opt,name=sort,proto3" json:"sort,omitempty"`
+	// read_mask lists the Concert fields to return, such as name,artist,price;
+	// id is always returned. All fields are returned without a mask.
+	ReadMask *fieldmaskpb.FieldMask `protobuf:"bytes,11,opt,name=read_mask,json=readMask,proto3" json:"read_mask,omitempty"`
+	// exact_count false estimates the total_count of the meta instead of
+	// counting it, for large listings; unset counts it
+	ExactCount    *bool `protobuf:"varint,12,opt,name=exact_count,json=exactCount,proto3,oneof" json:"exact_count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -166,6 +178,34 @@ func (x *ListConcertsRequest) GetAvailableOnly() bool {
 	return false
 }
 
+func (x *ListConcertsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListConcertsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListConcertsRequest) GetReadMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.ReadMask
+	}
+	return nil
+}
+
+func (x *ListConcertsRequest) GetExactCount() bool {
+	if x != nil && x.ExactCount != nil {
+		return *x.ExactCount
+	}
+	return false
+}
+
 type ListConcertsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Concerts      []*Concert             `protobuf:"bytes,1,rep,name=concerts,proto3" json:"concerts,omitempty"`
@@ -218,23 +258,143 @@ func (x *ListConcertsResponse) GetMeta() *PaginationMeta {
 	return nil
 }
 
+type BatchGetConcertsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ids   []int64                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	// read_mask lists the Concert fields to return, like in ListConcertsRequest
+	ReadMask      *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=read_mask,json=readMask,proto3" json:"read_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetConcertsRequest) Reset() {
+	*x = BatchGetConcertsRequest{}
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetConcertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetConcertsRequest) ProtoMessage() {}
+
+func (x *BatchGetConcertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetConcertsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetConcertsRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetConcertsRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *BatchGetConcertsRequest) GetReadMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.ReadMask
+	}
+	return nil
+}
+
+type BatchGetConcertsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Concerts []*Concert             `protobuf:"bytes,1,rep,name=concerts,proto3" json:"concerts,omitempty"`
+	// not_found lists the requested IDs without a concert
+	NotFound      []int64 `protobuf:"varint,2,rep,packed,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetConcertsResponse) Reset() {
+	*x = BatchGetConcertsResponse{}
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetConcertsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetConcertsResponse) ProtoMessage() {}
+
+func (x *BatchGetConcertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetConcertsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetConcertsResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetConcertsResponse) GetConcerts() []*Concert {
+	if x != nil {
+		return x.Concerts
+	}
+	return nil
+}
+
+func (x *BatchGetConcertsResponse) GetNotFound() []int64 {
+	if x != nil {
+		return x.NotFound
+	}
+	return nil
+}
+
 type CreateConcertRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Artist           string                 `protobuf:"bytes,2,opt,name=artist,proto3" json:"artist,omitempty"`
-	Venue            string                 `protobuf:"bytes,3,opt,name=venue,proto3" json:"venue,omitempty"`
-	ConcertDate      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
-	TotalTickets     int32                  `protobuf:"varint,5,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
-	Price            float64                `protobuf:"fixed64,6,opt,name=price,proto3" json:"price,omitempty"`
-	BookingStartTime *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
-	BookingEndTime   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Name                 string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Artist               string                 `protobuf:"bytes,2,opt,name=artist,proto3" json:"artist,omitempty"`
+	Venue                string                 `protobuf:"bytes,3,opt,name=venue,proto3" json:"venue,omitempty"`
+	ConcertDate          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
+	TotalTickets         int32                  `protobuf:"varint,5,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	Price                float64                `protobuf:"fixed64,6,opt,name=price,proto3" json:"price,omitempty"`
+	BookingStartTime     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
+	BookingEndTime       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
+	ArtistAliases        []string               `protobuf:"bytes,9,rep,name=artist_aliases,json=artistAliases,proto3" json:"artist_aliases,omitempty"`
+	VenueAliases         []string               `protobuf:"bytes,10,rep,name=venue_aliases,json=venueAliases,proto3" json:"venue_aliases,omitempty"`
+	RequiresBookingToken bool                   `protobuf:"varint,11,opt,name=requires_booking_token,json=requiresBookingToken,proto3" json:"requires_booking_token,omitempty"`
+	Currency             string                 `protobuf:"bytes,12,opt,name=currency,proto3" json:"currency,omitempty"`
+	OrganizerEmail       string                 `protobuf:"bytes,13,opt,name=organizer_email,json=organizerEmail,proto3" json:"organizer_email,omitempty"`
+	// door_price replaces price door_price_lead_minutes before the concert starts
+	DoorPrice            *float64 `protobuf:"fixed64,14,opt,name=door_price,json=doorPrice,proto3,oneof" json:"door_price,omitempty"`
+	DoorPriceLeadMinutes int32    `protobuf:"varint,15,opt,name=door_price_lead_minutes,json=doorPriceLeadMinutes,proto3" json:"door_price_lead_minutes,omitempty"`
+	// visibility is "public" (default), "unlisted" or "private"
+	Visibility string `protobuf:"bytes,16,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// max_tickets_per_booking and max_order_value limit a single booking;
+	// unset limits use the configured defaults
+	MaxTicketsPerBooking *int32   `protobuf:"varint,17,opt,name=max_tickets_per_booking,json=maxTicketsPerBooking,proto3,oneof" json:"max_tickets_per_booking,omitempty"`
+	MaxOrderValue        *float64 `protobuf:"fixed64,18,opt,name=max_order_value,json=maxOrderValue,proto3,oneof" json:"max_order_value,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *CreateConcertRequest) Reset() {
 	*x = CreateConcertRequest{}
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[3]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -246,7 +406,7 @@ func (x *CreateConcertRequest) String() string {
 func (*CreateConcertRequest) ProtoMessage() {}
 
 func (x *CreateConcertRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[3]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -259,7 +419,7 @@ func (x *CreateConcertRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateConcertRequest.ProtoReflect.Descriptor instead.
 func (*CreateConcertRequest) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{3}
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CreateConcertRequest) GetName() string {
@@ -318,25 +478,110 @@ func (x *CreateConcertRequest) GetBookingEndTime() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *CreateConcertRequest) GetArtistAliases() []string {
+	if x != nil {
+		return x.ArtistAliases
+	}
+	return nil
+}
+
+func (x *CreateConcertRequest) GetVenueAliases() []string {
+	if x != nil {
+		return x.VenueAliases
+	}
+	return nil
+}
+
+func (x *CreateConcertRequest) GetRequiresBookingToken() bool {
+	if x != nil {
+		return x.RequiresBookingToken
+	}
+	return false
+}
+
+func (x *CreateConcertRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateConcertRequest) GetOrganizerEmail() string {
+	if x != nil {
+		return x.OrganizerEmail
+	}
+	return ""
+}
+
+func (x *CreateConcertRequest) GetDoorPrice() float64 {
+	if x != nil && x.DoorPrice != nil {
+		return *x.DoorPrice
+	}
+	return 0
+}
+
+func (x *CreateConcertRequest) GetDoorPriceLeadMinutes() int32 {
+	if x != nil {
+		return x.DoorPriceLeadMinutes
+	}
+	return 0
+}
+
+func (x *CreateConcertRequest) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *CreateConcertRequest) GetMaxTicketsPerBooking() int32 {
+	if x != nil && x.MaxTicketsPerBooking != nil {
+		return *x.MaxTicketsPerBooking
+	}
+	return 0
+}
+
+func (x *CreateConcertRequest) GetMaxOrderValue() float64 {
+	if x != nil && x.MaxOrderValue != nil {
+		return *x.MaxOrderValue
+	}
+	return 0
+}
+
 type UpdateConcertRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Artist           string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
-	Venue            string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
-	ConcertDate      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
-	TotalTickets     int32                  `protobuf:"varint,6,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
-	Price            float64                `protobuf:"fixed64,7,opt,name=price,proto3" json:"price,omitempty"`
-	BookingStartTime *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
-	BookingEndTime   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
-	Version          int32                  `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Id                   int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Artist               string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Venue                string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
+	ConcertDate          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
+	TotalTickets         int32                  `protobuf:"varint,6,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	Price                float64                `protobuf:"fixed64,7,opt,name=price,proto3" json:"price,omitempty"`
+	BookingStartTime     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
+	BookingEndTime       *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
+	Version              int32                  `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	ArtistAliases        []string               `protobuf:"bytes,11,rep,name=artist_aliases,json=artistAliases,proto3" json:"artist_aliases,omitempty"`
+	VenueAliases         []string               `protobuf:"bytes,12,rep,name=venue_aliases,json=venueAliases,proto3" json:"venue_aliases,omitempty"`
+	RequiresBookingToken bool                   `protobuf:"varint,13,opt,name=requires_booking_token,json=requiresBookingToken,proto3" json:"requires_booking_token,omitempty"`
+	Currency             string                 `protobuf:"bytes,14,opt,name=currency,proto3" json:"currency,omitempty"`
+	OrganizerEmail       string                 `protobuf:"bytes,15,opt,name=organizer_email,json=organizerEmail,proto3" json:"organizer_email,omitempty"`
+	DoorPrice            *float64               `protobuf:"fixed64,16,opt,name=door_price,json=doorPrice,proto3,oneof" json:"door_price,omitempty"`
+	DoorPriceLeadMinutes int32                  `protobuf:"varint,17,opt,name=door_price_lead_minutes,json=doorPriceLeadMinutes,proto3" json:"door_price_lead_minutes,omitempty"`
+	// visibility is kept when empty
+	Visibility string `protobuf:"bytes,18,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// update_mask lists the fields to update, e.g. "price,door_price"; fields
+	// outside it are kept and listed fields left unset are cleared. Without a
+	// mask the fields set to non-zero values are updated.
+	UpdateMask           *fieldmaskpb.FieldMask `protobuf:"bytes,19,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	MaxTicketsPerBooking *int32                 `protobuf:"varint,20,opt,name=max_tickets_per_booking,json=maxTicketsPerBooking,proto3,oneof" json:"max_tickets_per_booking,omitempty"`
+	MaxOrderValue        *float64               `protobuf:"fixed64,21,opt,name=max_order_value,json=maxOrderValue,proto3,oneof" json:"max_order_value,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *UpdateConcertRequest) Reset() {
 	*x = UpdateConcertRequest{}
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[4]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -348,7 +593,7 @@ func (x *UpdateConcertRequest) String() string {
 func (*UpdateConcertRequest) ProtoMessage() {}
 
 func (x *UpdateConcertRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[4]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -361,7 +606,7 @@ func (x *UpdateConcertRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateConcertRequest.ProtoReflect.Descriptor instead.
 func (*UpdateConcertRequest) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{4}
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *UpdateConcertRequest) GetId() int64 {
@@ -434,28 +679,123 @@ func (x *UpdateConcertRequest) GetVersion() int32 {
 	return 0
 }
 
+func (x *UpdateConcertRequest) GetArtistAliases() []string {
+	if x != nil {
+		return x.ArtistAliases
+	}
+	return nil
+}
+
+func (x *UpdateConcertRequest) GetVenueAliases() []string {
+	if x != nil {
+		return x.VenueAliases
+	}
+	return nil
+}
+
+func (x *UpdateConcertRequest) GetRequiresBookingToken() bool {
+	if x != nil {
+		return x.RequiresBookingToken
+	}
+	return false
+}
+
+func (x *UpdateConcertRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *UpdateConcertRequest) GetOrganizerEmail() string {
+	if x != nil {
+		return x.OrganizerEmail
+	}
+	return ""
+}
+
+func (x *UpdateConcertRequest) GetDoorPrice() float64 {
+	if x != nil && x.DoorPrice != nil {
+		return *x.DoorPrice
+	}
+	return 0
+}
+
+func (x *UpdateConcertRequest) GetDoorPriceLeadMinutes() int32 {
+	if x != nil {
+		return x.DoorPriceLeadMinutes
+	}
+	return 0
+}
+
+func (x *UpdateConcertRequest) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *UpdateConcertRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateConcertRequest) GetMaxTicketsPerBooking() int32 {
+	if x != nil && x.MaxTicketsPerBooking != nil {
+		return *x.MaxTicketsPerBooking
+	}
+	return 0
+}
+
+func (x *UpdateConcertRequest) GetMaxOrderValue() float64 {
+	if x != nil && x.MaxOrderValue != nil {
+		return *x.MaxOrderValue
+	}
+	return 0
+}
+
 type Concert struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Artist           string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
-	Venue            string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
-	ConcertDate      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
-	TotalTickets     int32                  `protobuf:"varint,6,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
-	AvailableTickets int32                  `protobuf:"varint,7,opt,name=available_tickets,json=availableTickets,proto3" json:"available_tickets,omitempty"`
-	Price            float64                `protobuf:"fixed64,8,opt,name=price,proto3" json:"price,omitempty"`
-	BookingStartTime *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
-	BookingEndTime   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
-	Version          int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
-	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Id                   int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Artist               string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Venue                string                 `protobuf:"bytes,4,opt,name=venue,proto3" json:"venue,omitempty"`
+	ConcertDate          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=concert_date,json=concertDate,proto3" json:"concert_date,omitempty"`
+	TotalTickets         int32                  `protobuf:"varint,6,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	AvailableTickets     int32                  `protobuf:"varint,7,opt,name=available_tickets,json=availableTickets,proto3" json:"available_tickets,omitempty"`
+	Price                float64                `protobuf:"fixed64,8,opt,name=price,proto3" json:"price,omitempty"`
+	BookingStartTime     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=booking_start_time,json=bookingStartTime,proto3" json:"booking_start_time,omitempty"`
+	BookingEndTime       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=booking_end_time,json=bookingEndTime,proto3" json:"booking_end_time,omitempty"`
+	Version              int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt            *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt            *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ArtistAliases        []string               `protobuf:"bytes,14,rep,name=artist_aliases,json=artistAliases,proto3" json:"artist_aliases,omitempty"`
+	VenueAliases         []string               `protobuf:"bytes,15,rep,name=venue_aliases,json=venueAliases,proto3" json:"venue_aliases,omitempty"`
+	RequiresBookingToken bool                   `protobuf:"varint,16,opt,name=requires_booking_token,json=requiresBookingToken,proto3" json:"requires_booking_token,omitempty"`
+	Currency             string                 `protobuf:"bytes,17,opt,name=currency,proto3" json:"currency,omitempty"`
+	PriceDisplay         string                 `protobuf:"bytes,18,opt,name=price_display,json=priceDisplay,proto3" json:"price_display,omitempty"`
+	OrganizerEmail       string                 `protobuf:"bytes,19,opt,name=organizer_email,json=organizerEmail,proto3" json:"organizer_email,omitempty"`
+	ReportingState       string                 `protobuf:"bytes,20,opt,name=reporting_state,json=reportingState,proto3" json:"reporting_state,omitempty"`
+	DoorPrice            *float64               `protobuf:"fixed64,21,opt,name=door_price,json=doorPrice,proto3,oneof" json:"door_price,omitempty"`
+	DoorPriceLeadMinutes int32                  `protobuf:"varint,22,opt,name=door_price_lead_minutes,json=doorPriceLeadMinutes,proto3" json:"door_price_lead_minutes,omitempty"`
+	// pricing_phase is "advance" or "door", and current_price the price that applies now
+	PricingPhase string  `protobuf:"bytes,23,opt,name=pricing_phase,json=pricingPhase,proto3" json:"pricing_phase,omitempty"`
+	CurrentPrice float64 `protobuf:"fixed64,24,opt,name=current_price,json=currentPrice,proto3" json:"current_price,omitempty"`
+	Visibility   string  `protobuf:"bytes,25,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// invite_token is only set in the response that made the concert private.
+	// Send it as x-invite-token metadata to view and book the concert.
+	InviteToken          string   `protobuf:"bytes,26,opt,name=invite_token,json=inviteToken,proto3" json:"invite_token,omitempty"`
+	MaxTicketsPerBooking *int32   `protobuf:"varint,27,opt,name=max_tickets_per_booking,json=maxTicketsPerBooking,proto3,oneof" json:"max_tickets_per_booking,omitempty"`
+	MaxOrderValue        *float64 `protobuf:"fixed64,28,opt,name=max_order_value,json=maxOrderValue,proto3,oneof" json:"max_order_value,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *Concert) Reset() {
 	*x = Concert{}
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[5]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -467,7 +807,7 @@ func (x *Concert) String() string {
 func (*Concert) ProtoMessage() {}
 
 func (x *Concert) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_proto_concert_proto_msgTypes[5]
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -480,7 +820,7 @@ func (x *Concert) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Concert.ProtoReflect.Descriptor instead.
 func (*Concert) Descriptor() ([]byte, []int) {
-	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{5}
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Concert) GetId() int64 {
@@ -574,13 +914,230 @@ func (x *Concert) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Concert) GetArtistAliases() []string {
+	if x != nil {
+		return x.ArtistAliases
+	}
+	return nil
+}
+
+func (x *Concert) GetVenueAliases() []string {
+	if x != nil {
+		return x.VenueAliases
+	}
+	return nil
+}
+
+func (x *Concert) GetRequiresBookingToken() bool {
+	if x != nil {
+		return x.RequiresBookingToken
+	}
+	return false
+}
+
+func (x *Concert) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Concert) GetPriceDisplay() string {
+	if x != nil {
+		return x.PriceDisplay
+	}
+	return ""
+}
+
+func (x *Concert) GetOrganizerEmail() string {
+	if x != nil {
+		return x.OrganizerEmail
+	}
+	return ""
+}
+
+func (x *Concert) GetReportingState() string {
+	if x != nil {
+		return x.ReportingState
+	}
+	return ""
+}
+
+func (x *Concert) GetDoorPrice() float64 {
+	if x != nil && x.DoorPrice != nil {
+		return *x.DoorPrice
+	}
+	return 0
+}
+
+func (x *Concert) GetDoorPriceLeadMinutes() int32 {
+	if x != nil {
+		return x.DoorPriceLeadMinutes
+	}
+	return 0
+}
+
+func (x *Concert) GetPricingPhase() string {
+	if x != nil {
+		return x.PricingPhase
+	}
+	return ""
+}
+
+func (x *Concert) GetCurrentPrice() float64 {
+	if x != nil {
+		return x.CurrentPrice
+	}
+	return 0
+}
+
+func (x *Concert) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Concert) GetInviteToken() string {
+	if x != nil {
+		return x.InviteToken
+	}
+	return ""
+}
+
+func (x *Concert) GetMaxTicketsPerBooking() int32 {
+	if x != nil && x.MaxTicketsPerBooking != nil {
+		return *x.MaxTicketsPerBooking
+	}
+	return 0
+}
+
+func (x *Concert) GetMaxOrderValue() float64 {
+	if x != nil && x.MaxOrderValue != nil {
+		return *x.MaxOrderValue
+	}
+	return 0
+}
+
+type WatchConcertAvailabilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConcertId     int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchConcertAvailabilityRequest) Reset() {
+	*x = WatchConcertAvailabilityRequest{}
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchConcertAvailabilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchConcertAvailabilityRequest) ProtoMessage() {}
+
+func (x *WatchConcertAvailabilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchConcertAvailabilityRequest.ProtoReflect.Descriptor instead.
+func (*WatchConcertAvailabilityRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchConcertAvailabilityRequest) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+type ConcertAvailability struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ConcertId        int64                  `protobuf:"varint,1,opt,name=concert_id,json=concertId,proto3" json:"concert_id,omitempty"`
+	AvailableTickets int32                  `protobuf:"varint,2,opt,name=available_tickets,json=availableTickets,proto3" json:"available_tickets,omitempty"`
+	TotalTickets     int32                  `protobuf:"varint,3,opt,name=total_tickets,json=totalTickets,proto3" json:"total_tickets,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ConcertAvailability) Reset() {
+	*x = ConcertAvailability{}
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConcertAvailability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConcertAvailability) ProtoMessage() {}
+
+func (x *ConcertAvailability) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_concert_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConcertAvailability.ProtoReflect.Descriptor instead.
+func (*ConcertAvailability) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_concert_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ConcertAvailability) GetConcertId() int64 {
+	if x != nil {
+		return x.ConcertId
+	}
+	return 0
+}
+
+func (x *ConcertAvailability) GetAvailableTickets() int32 {
+	if x != nil {
+		return x.AvailableTickets
+	}
+	return 0
+}
+
+func (x *ConcertAvailability) GetTotalTickets() int32 {
+	if x != nil {
+		return x.TotalTickets
+	}
+	return 0
+}
+
+func (x *ConcertAvailability) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
 var File_api_grpc_proto_concert_proto protoreflect.FileDescriptor
 
 const file_api_grpc_proto_concert_proto_rawDesc = "" +
 	"\n" +
-	"\x1capi/grpc/proto/concert.proto\x12\aconcert\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/grpc/proto/common.proto\"#\n" +
+	"\x1capi/grpc/proto/concert.proto\x12\aconcert\x1a\x1cgoogle/api/annotations.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/grpc/proto/common.proto\"#\n" +
 	"\x11GetConcertRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\"\x9d\x02\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xb8\x03\n" +
 	"\x13ListConcertsRequest\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
 	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x16\n" +
@@ -589,10 +1146,23 @@ const file_api_grpc_proto_concert_proto_rawDesc = "" +
 	"\x04name\x18\x05 \x01(\tR\x04name\x127\n" +
 	"\tdate_from\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bdateFrom\x123\n" +
 	"\adate_to\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x06dateTo\x12%\n" +
-	"\x0eavailable_only\x18\b \x01(\bR\ravailableOnly\"p\n" +
+	"\x0eavailable_only\x18\b \x01(\bR\ravailableOnly\x12\x16\n" +
+	"\x06cursor\x18\t \x01(\tR\x06cursor\x12\x12\n" +
+	"\x04sort\x18\n" +
+	" \x01(\tR\x04sort\x127\n" +
+	"\tread_mask\x18\v \x01(\v2\x1a.google.protobuf.FieldMaskR\breadMask\x12$\n" +
+	"\vexact_count\x18\f \x01(\bH\x00R\n" +
+	"exactCount\x88\x01\x01B\x0e\n" +
+	"\f_exact_count\"p\n" +
 	"\x14ListConcertsResponse\x12,\n" +
 	"\bconcerts\x18\x01 \x03(\v2\x10.concert.ConcertR\bconcerts\x12*\n" +
-	"\x04meta\x18\x02 \x01(\v2\x16.common.PaginationMetaR\x04meta\"\xe2\x02\n" +
+	"\x04meta\x18\x02 \x01(\v2\x16.common.PaginationMetaR\x04meta\"d\n" +
+	"\x17BatchGetConcertsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\x127\n" +
+	"\tread_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\breadMask\"e\n" +
+	"\x18BatchGetConcertsResponse\x12,\n" +
+	"\bconcerts\x18\x01 \x03(\v2\x10.concert.ConcertR\bconcerts\x12\x1b\n" +
+	"\tnot_found\x18\x02 \x03(\x03R\bnotFound\"\xcc\x06\n" +
 	"\x14CreateConcertRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
 	"\x06artist\x18\x02 \x01(\tR\x06artist\x12\x14\n" +
@@ -601,7 +1171,24 @@ const file_api_grpc_proto_concert_proto_rawDesc = "" +
 	"\rtotal_tickets\x18\x05 \x01(\x05R\ftotalTickets\x12\x14\n" +
 	"\x05price\x18\x06 \x01(\x01R\x05price\x12H\n" +
 	"\x12booking_start_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x10bookingStartTime\x12D\n" +
-	"\x10booking_end_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x0ebookingEndTime\"\x8c\x03\n" +
+	"\x10booking_end_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x0ebookingEndTime\x12%\n" +
+	"\x0eartist_aliases\x18\t \x03(\tR\rartistAliases\x12#\n" +
+	"\rvenue_aliases\x18\n" +
+	" \x03(\tR\fvenueAliases\x124\n" +
+	"\x16requires_booking_token\x18\v \x01(\bR\x14requiresBookingToken\x12\x1a\n" +
+	"\bcurrency\x18\f \x01(\tR\bcurrency\x12'\n" +
+	"\x0forganizer_email\x18\r \x01(\tR\x0eorganizerEmail\x12\"\n" +
+	"\n" +
+	"door_price\x18\x0e \x01(\x01H\x00R\tdoorPrice\x88\x01\x01\x125\n" +
+	"\x17door_price_lead_minutes\x18\x0f \x01(\x05R\x14doorPriceLeadMinutes\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x10 \x01(\tR\n" +
+	"visibility\x12:\n" +
+	"\x17max_tickets_per_booking\x18\x11 \x01(\x05H\x01R\x14maxTicketsPerBooking\x88\x01\x01\x12+\n" +
+	"\x0fmax_order_value\x18\x12 \x01(\x01H\x02R\rmaxOrderValue\x88\x01\x01B\r\n" +
+	"\v_door_priceB\x1a\n" +
+	"\x18_max_tickets_per_bookingB\x12\n" +
+	"\x10_max_order_value\"\xb3\a\n" +
 	"\x14UpdateConcertRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
@@ -613,7 +1200,25 @@ const file_api_grpc_proto_concert_proto_rawDesc = "" +
 	"\x12booking_start_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x10bookingStartTime\x12D\n" +
 	"\x10booking_end_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x0ebookingEndTime\x12\x18\n" +
 	"\aversion\x18\n" +
-	" \x01(\x05R\aversion\"\xa2\x04\n" +
+	" \x01(\x05R\aversion\x12%\n" +
+	"\x0eartist_aliases\x18\v \x03(\tR\rartistAliases\x12#\n" +
+	"\rvenue_aliases\x18\f \x03(\tR\fvenueAliases\x124\n" +
+	"\x16requires_booking_token\x18\r \x01(\bR\x14requiresBookingToken\x12\x1a\n" +
+	"\bcurrency\x18\x0e \x01(\tR\bcurrency\x12'\n" +
+	"\x0forganizer_email\x18\x0f \x01(\tR\x0eorganizerEmail\x12\"\n" +
+	"\n" +
+	"door_price\x18\x10 \x01(\x01H\x00R\tdoorPrice\x88\x01\x01\x125\n" +
+	"\x17door_price_lead_minutes\x18\x11 \x01(\x05R\x14doorPriceLeadMinutes\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x12 \x01(\tR\n" +
+	"visibility\x12;\n" +
+	"\vupdate_mask\x18\x13 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12:\n" +
+	"\x17max_tickets_per_booking\x18\x14 \x01(\x05H\x01R\x14maxTicketsPerBooking\x88\x01\x01\x12+\n" +
+	"\x0fmax_order_value\x18\x15 \x01(\x01H\x02R\rmaxOrderValue\x88\x01\x01B\r\n" +
+	"\v_door_priceB\x1a\n" +
+	"\x18_max_tickets_per_bookingB\x12\n" +
+	"\x10_max_order_value\"\xc7\t\n" +
 	"\aConcert\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
@@ -630,13 +1235,46 @@ const file_api_grpc_proto_concert_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\x9d\x02\n" +
-	"\x0eConcertService\x12:\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12%\n" +
+	"\x0eartist_aliases\x18\x0e \x03(\tR\rartistAliases\x12#\n" +
+	"\rvenue_aliases\x18\x0f \x03(\tR\fvenueAliases\x124\n" +
+	"\x16requires_booking_token\x18\x10 \x01(\bR\x14requiresBookingToken\x12\x1a\n" +
+	"\bcurrency\x18\x11 \x01(\tR\bcurrency\x12#\n" +
+	"\rprice_display\x18\x12 \x01(\tR\fpriceDisplay\x12'\n" +
+	"\x0forganizer_email\x18\x13 \x01(\tR\x0eorganizerEmail\x12'\n" +
+	"\x0freporting_state\x18\x14 \x01(\tR\x0ereportingState\x12\"\n" +
+	"\n" +
+	"door_price\x18\x15 \x01(\x01H\x00R\tdoorPrice\x88\x01\x01\x125\n" +
+	"\x17door_price_lead_minutes\x18\x16 \x01(\x05R\x14doorPriceLeadMinutes\x12#\n" +
+	"\rpricing_phase\x18\x17 \x01(\tR\fpricingPhase\x12#\n" +
+	"\rcurrent_price\x18\x18 \x01(\x01R\fcurrentPrice\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x19 \x01(\tR\n" +
+	"visibility\x12!\n" +
+	"\finvite_token\x18\x1a \x01(\tR\vinviteToken\x12:\n" +
+	"\x17max_tickets_per_booking\x18\x1b \x01(\x05H\x01R\x14maxTicketsPerBooking\x88\x01\x01\x12+\n" +
+	"\x0fmax_order_value\x18\x1c \x01(\x01H\x02R\rmaxOrderValue\x88\x01\x01B\r\n" +
+	"\v_door_priceB\x1a\n" +
+	"\x18_max_tickets_per_bookingB\x12\n" +
+	"\x10_max_order_value\"@\n" +
+	"\x1fWatchConcertAvailabilityRequest\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\"\xc1\x01\n" +
+	"\x13ConcertAvailability\x12\x1d\n" +
+	"\n" +
+	"concert_id\x18\x01 \x01(\x03R\tconcertId\x12+\n" +
+	"\x11available_tickets\x18\x02 \x01(\x05R\x10availableTickets\x12#\n" +
+	"\rtotal_tickets\x18\x03 \x01(\x05R\ftotalTickets\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\xca\x05\n" +
+	"\x0eConcertService\x12]\n" +
 	"\n" +
-	"GetConcert\x12\x1a.concert.GetConcertRequest\x1a\x10.concert.Concert\x12K\n" +
-	"\fListConcerts\x12\x1c.concert.ListConcertsRequest\x1a\x1d.concert.ListConcertsResponse\x12@\n" +
-	"\rCreateConcert\x12\x1d.concert.CreateConcertRequest\x1a\x10.concert.Concert\x12@\n" +
-	"\rUpdateConcert\x12\x1d.concert.UpdateConcertRequest\x1a\x10.concert.ConcertB#Z!concert-ticket-api/api/grpc/protob\x06proto3"
+	"GetConcert\x12\x1a.concert.GetConcertRequest\x1a\x10.concert.Concert\"!\x82\xd3\xe4\x93\x02\x1b\x12\x19/gateway/v1/concerts/{id}\x12i\n" +
+	"\fListConcerts\x12\x1c.concert.ListConcertsRequest\x1a\x1d.concert.ListConcertsResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/gateway/v1/concerts\x12~\n" +
+	"\x10BatchGetConcerts\x12 .concert.BatchGetConcertsRequest\x1a!.concert.BatchGetConcertsResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/gateway/v1/concerts:batchGet\x12a\n" +
+	"\rCreateConcert\x12\x1d.concert.CreateConcertRequest\x1a\x10.concert.Concert\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/gateway/v1/concerts\x12f\n" +
+	"\rUpdateConcert\x12\x1d.concert.UpdateConcertRequest\x1a\x10.concert.Concert\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\x1a\x19/gateway/v1/concerts/{id}\x12\xa2\x01\n" +
+	"\x18WatchConcertAvailability\x12(.concert.WatchConcertAvailabilityRequest\x1a\x1c.concert.ConcertAvailability\"<\x82\xd3\xe4\x93\x026\x124/gateway/v1/concerts/{concert_id}/availability:watch0\x01B#Z!concert-ticket-api/api/grpc/protob\x06proto3"
 
 var (
 	file_api_grpc_proto_concert_proto_rawDescOnce sync.Once
@@ -650,46 +1288,60 @@ func file_api_grpc_proto_concert_proto_rawDescGZIP() []byte {
 	return file_api_grpc_proto_concert_proto_rawDescData
 }
 
-var file_api_grpc_proto_concert_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_api_grpc_proto_concert_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_api_grpc_proto_concert_proto_goTypes = []any{
-	(*GetConcertRequest)(nil),     // 0: concert.GetConcertRequest
-	(*ListConcertsRequest)(nil),   // 1: concert.ListConcertsRequest
-	(*ListConcertsResponse)(nil),  // 2: concert.ListConcertsResponse
-	(*CreateConcertRequest)(nil),  // 3: concert.CreateConcertRequest
-	(*UpdateConcertRequest)(nil),  // 4: concert.UpdateConcertRequest
-	(*Concert)(nil),               // 5: concert.Concert
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
-	(*PaginationMeta)(nil),        // 7: common.PaginationMeta
+	(*GetConcertRequest)(nil),               // 0: concert.GetConcertRequest
+	(*ListConcertsRequest)(nil),             // 1: concert.ListConcertsRequest
+	(*ListConcertsResponse)(nil),            // 2: concert.ListConcertsResponse
+	(*BatchGetConcertsRequest)(nil),         // 3: concert.BatchGetConcertsRequest
+	(*BatchGetConcertsResponse)(nil),        // 4: concert.BatchGetConcertsResponse
+	(*CreateConcertRequest)(nil),            // 5: concert.CreateConcertRequest
+	(*UpdateConcertRequest)(nil),            // 6: concert.UpdateConcertRequest
+	(*Concert)(nil),                         // 7: concert.Concert
+	(*WatchConcertAvailabilityRequest)(nil), // 8: concert.WatchConcertAvailabilityRequest
+	(*ConcertAvailability)(nil),             // 9: concert.ConcertAvailability
+	(*timestamppb.Timestamp)(nil),           // 10: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil),           // 11: google.protobuf.FieldMask
+	(*PaginationMeta)(nil),                  // 12: common.PaginationMeta
 }
 var file_api_grpc_proto_concert_proto_depIdxs = []int32{
-	6,  // 0: concert.ListConcertsRequest.date_from:type_name -> google.protobuf.Timestamp
-	6,  // 1: concert.ListConcertsRequest.date_to:type_name -> google.protobuf.Timestamp
-	5,  // 2: concert.ListConcertsResponse.concerts:type_name -> concert.Concert
-	7,  // 3: concert.ListConcertsResponse.meta:type_name -> common.PaginationMeta
-	6,  // 4: concert.CreateConcertRequest.concert_date:type_name -> google.protobuf.Timestamp
-	6,  // 5: concert.CreateConcertRequest.booking_start_time:type_name -> google.protobuf.Timestamp
-	6,  // 6: concert.CreateConcertRequest.booking_end_time:type_name -> google.protobuf.Timestamp
-	6,  // 7: concert.UpdateConcertRequest.concert_date:type_name -> google.protobuf.Timestamp
-	6,  // 8: concert.UpdateConcertRequest.booking_start_time:type_name -> google.protobuf.Timestamp
-	6,  // 9: concert.UpdateConcertRequest.booking_end_time:type_name -> google.protobuf.Timestamp
-	6,  // 10: concert.Concert.concert_date:type_name -> google.protobuf.Timestamp
-	6,  // 11: concert.Concert.booking_start_time:type_name -> google.protobuf.Timestamp
-	6,  // 12: concert.Concert.booking_end_time:type_name -> google.protobuf.Timestamp
-	6,  // 13: concert.Concert.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 14: concert.Concert.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 15: concert.ConcertService.GetConcert:input_type -> concert.GetConcertRequest
-	1,  // 16: concert.ConcertService.ListConcerts:input_type -> concert.ListConcertsRequest
-	3,  // 17: concert.ConcertService.CreateConcert:input_type -> concert.CreateConcertRequest
-	4,  // 18: concert.ConcertService.UpdateConcert:input_type -> concert.UpdateConcertRequest
-	5,  // 19: concert.ConcertService.GetConcert:output_type -> concert.Concert
-	2,  // 20: concert.ConcertService.ListConcerts:output_type -> concert.ListConcertsResponse
-	5,  // 21: concert.ConcertService.CreateConcert:output_type -> concert.Concert
-	5,  // 22: concert.ConcertService.UpdateConcert:output_type -> concert.Concert
-	19, // [19:23] is the sub-list for method output_type
-	15, // [15:19] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	10, // 0: concert.ListConcertsRequest.date_from:type_name -> google.protobuf.Timestamp
+	10, // 1: concert.ListConcertsRequest.date_to:type_name -> google.protobuf.Timestamp
+	11, // 2: concert.ListConcertsRequest.read_mask:type_name -> google.protobuf.FieldMask
+	7,  // 3: concert.ListConcertsResponse.concerts:type_name -> concert.Concert
+	12, // 4: concert.ListConcertsResponse.meta:type_name -> common.PaginationMeta
+	11, // 5: concert.BatchGetConcertsRequest.read_mask:type_name -> google.protobuf.FieldMask
+	7,  // 6: concert.BatchGetConcertsResponse.concerts:type_name -> concert.Concert
+	10, // 7: concert.CreateConcertRequest.concert_date:type_name -> google.protobuf.Timestamp
+	10, // 8: concert.CreateConcertRequest.booking_start_time:type_name -> google.protobuf.Timestamp
+	10, // 9: concert.CreateConcertRequest.booking_end_time:type_name -> google.protobuf.Timestamp
+	10, // 10: concert.UpdateConcertRequest.concert_date:type_name -> google.protobuf.Timestamp
+	10, // 11: concert.UpdateConcertRequest.booking_start_time:type_name -> google.protobuf.Timestamp
+	10, // 12: concert.UpdateConcertRequest.booking_end_time:type_name -> google.protobuf.Timestamp
+	11, // 13: concert.UpdateConcertRequest.update_mask:type_name -> google.protobuf.FieldMask
+	10, // 14: concert.Concert.concert_date:type_name -> google.protobuf.Timestamp
+	10, // 15: concert.Concert.booking_start_time:type_name -> google.protobuf.Timestamp
+	10, // 16: concert.Concert.booking_end_time:type_name -> google.protobuf.Timestamp
+	10, // 17: concert.Concert.created_at:type_name -> google.protobuf.Timestamp
+	10, // 18: concert.Concert.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 19: concert.ConcertAvailability.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 20: concert.ConcertService.GetConcert:input_type -> concert.GetConcertRequest
+	1,  // 21: concert.ConcertService.ListConcerts:input_type -> concert.ListConcertsRequest
+	3,  // 22: concert.ConcertService.BatchGetConcerts:input_type -> concert.BatchGetConcertsRequest
+	5,  // 23: concert.ConcertService.CreateConcert:input_type -> concert.CreateConcertRequest
+	6,  // 24: concert.ConcertService.UpdateConcert:input_type -> concert.UpdateConcertRequest
+	8,  // 25: concert.ConcertService.WatchConcertAvailability:input_type -> concert.WatchConcertAvailabilityRequest
+	7,  // 26: concert.ConcertService.GetConcert:output_type -> concert.Concert
+	2,  // 27: concert.ConcertService.ListConcerts:output_type -> concert.ListConcertsResponse
+	4,  // 28: concert.ConcertService.BatchGetConcerts:output_type -> concert.BatchGetConcertsResponse
+	7,  // 29: concert.ConcertService.CreateConcert:output_type -> concert.Concert
+	7,  // 30: concert.ConcertService.UpdateConcert:output_type -> concert.Concert
+	9,  // 31: concert.ConcertService.WatchConcertAvailability:output_type -> concert.ConcertAvailability
+	26, // [26:32] is the sub-list for method output_type
+	20, // [20:26] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_api_grpc_proto_concert_proto_init() }
@@ -698,13 +1350,17 @@ func file_api_grpc_proto_concert_proto_init() {
 		return
 	}
 	file_api_grpc_proto_common_proto_init()
+	file_api_grpc_proto_concert_proto_msgTypes[1].OneofWrappers = []any{}
+	file_api_grpc_proto_concert_proto_msgTypes[5].OneofWrappers = []any{}
+	file_api_grpc_proto_concert_proto_msgTypes[6].OneofWrappers = []any{}
+	file_api_grpc_proto_concert_proto_msgTypes[7].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_proto_concert_proto_rawDesc), len(file_api_grpc_proto_concert_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
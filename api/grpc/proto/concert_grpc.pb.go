@@ -19,20 +19,29 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ConcertService_GetConcert_FullMethodName    = "/concert.ConcertService/GetConcert"
-	ConcertService_ListConcerts_FullMethodName  = "/concert.ConcertService/ListConcerts"
-	ConcertService_CreateConcert_FullMethodName = "/concert.ConcertService/CreateConcert"
-	ConcertService_UpdateConcert_FullMethodName = "/concert.ConcertService/UpdateConcert"
+	ConcertService_GetConcert_FullMethodName               = "/concert.ConcertService/GetConcert"
+	ConcertService_ListConcerts_FullMethodName             = "/concert.ConcertService/ListConcerts"
+	ConcertService_BatchGetConcerts_FullMethodName         = "/concert.ConcertService/BatchGetConcerts"
+	ConcertService_CreateConcert_FullMethodName            = "/concert.ConcertService/CreateConcert"
+	ConcertService_UpdateConcert_FullMethodName            = "/concert.ConcertService/UpdateConcert"
+	ConcertService_WatchConcertAvailability_FullMethodName = "/concert.ConcertService/WatchConcertAvailability"
 )
 
 // ConcertServiceClient is the client API for ConcertService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// HTTP bindings are served by the REST gateway under /gateway/v1
 type ConcertServiceClient interface {
 	GetConcert(ctx context.Context, in *GetConcertRequest, opts ...grpc.CallOption) (*Concert, error)
 	ListConcerts(ctx context.Context, in *ListConcertsRequest, opts ...grpc.CallOption) (*ListConcertsResponse, error)
+	// BatchGetConcerts fetches up to 100 concerts in one call, in the order requested
+	BatchGetConcerts(ctx context.Context, in *BatchGetConcertsRequest, opts ...grpc.CallOption) (*BatchGetConcertsResponse, error)
 	CreateConcert(ctx context.Context, in *CreateConcertRequest, opts ...grpc.CallOption) (*Concert, error)
 	UpdateConcert(ctx context.Context, in *UpdateConcertRequest, opts ...grpc.CallOption) (*Concert, error)
+	// WatchConcertAvailability sends the current availability of a concert,
+	// then every change caused by bookings and cancellations
+	WatchConcertAvailability(ctx context.Context, in *WatchConcertAvailabilityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConcertAvailability], error)
 }
 
 type concertServiceClient struct {
@@ -63,6 +72,16 @@ func (c *concertServiceClient) ListConcerts(ctx context.Context, in *ListConcert
 	return out, nil
 }
 
+func (c *concertServiceClient) BatchGetConcerts(ctx context.Context, in *BatchGetConcertsRequest, opts ...grpc.CallOption) (*BatchGetConcertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetConcertsResponse)
+	err := c.cc.Invoke(ctx, ConcertService_BatchGetConcerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *concertServiceClient) CreateConcert(ctx context.Context, in *CreateConcertRequest, opts ...grpc.CallOption) (*Concert, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Concert)
@@ -83,14 +102,40 @@ func (c *concertServiceClient) UpdateConcert(ctx context.Context, in *UpdateConc
 	return out, nil
 }
 
+func (c *concertServiceClient) WatchConcertAvailability(ctx context.Context, in *WatchConcertAvailabilityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConcertAvailability], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConcertService_ServiceDesc.Streams[0], ConcertService_WatchConcertAvailability_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchConcertAvailabilityRequest, ConcertAvailability]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConcertService_WatchConcertAvailabilityClient = grpc.ServerStreamingClient[ConcertAvailability]
+
 // ConcertServiceServer is the server API for ConcertService service.
 // All implementations must embed UnimplementedConcertServiceServer
 // for forward compatibility.
+//
+// HTTP bindings are served by the REST gateway under /gateway/v1
 type ConcertServiceServer interface {
 	GetConcert(context.Context, *GetConcertRequest) (*Concert, error)
 	ListConcerts(context.Context, *ListConcertsRequest) (*ListConcertsResponse, error)
+	// BatchGetConcerts fetches up to 100 concerts in one call, in the order requested
+	BatchGetConcerts(context.Context, *BatchGetConcertsRequest) (*BatchGetConcertsResponse, error)
 	CreateConcert(context.Context, *CreateConcertRequest) (*Concert, error)
 	UpdateConcert(context.Context, *UpdateConcertRequest) (*Concert, error)
+	// WatchConcertAvailability sends the current availability of a concert,
+	// then every change caused by bookings and cancellations
+	WatchConcertAvailability(*WatchConcertAvailabilityRequest, grpc.ServerStreamingServer[ConcertAvailability]) error
 	mustEmbedUnimplementedConcertServiceServer()
 }
 
@@ -107,12 +152,18 @@ func (UnimplementedConcertServiceServer) GetConcert(context.Context, *GetConcert
 func (UnimplementedConcertServiceServer) ListConcerts(context.Context, *ListConcertsRequest) (*ListConcertsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListConcerts not implemented")
 }
+func (UnimplementedConcertServiceServer) BatchGetConcerts(context.Context, *BatchGetConcertsRequest) (*BatchGetConcertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetConcerts not implemented")
+}
 func (UnimplementedConcertServiceServer) CreateConcert(context.Context, *CreateConcertRequest) (*Concert, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateConcert not implemented")
 }
 func (UnimplementedConcertServiceServer) UpdateConcert(context.Context, *UpdateConcertRequest) (*Concert, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateConcert not implemented")
 }
+func (UnimplementedConcertServiceServer) WatchConcertAvailability(*WatchConcertAvailabilityRequest, grpc.ServerStreamingServer[ConcertAvailability]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchConcertAvailability not implemented")
+}
 func (UnimplementedConcertServiceServer) mustEmbedUnimplementedConcertServiceServer() {}
 func (UnimplementedConcertServiceServer) testEmbeddedByValue()                        {}
 
@@ -170,6 +221,24 @@ func _ConcertService_ListConcerts_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConcertService_BatchGetConcerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetConcertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConcertServiceServer).BatchGetConcerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConcertService_BatchGetConcerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConcertServiceServer).BatchGetConcerts(ctx, req.(*BatchGetConcertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ConcertService_CreateConcert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateConcertRequest)
 	if err := dec(in); err != nil {
@@ -206,6 +275,17 @@ func _ConcertService_UpdateConcert_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConcertService_WatchConcertAvailability_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchConcertAvailabilityRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConcertServiceServer).WatchConcertAvailability(m, &grpc.GenericServerStream[WatchConcertAvailabilityRequest, ConcertAvailability]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConcertService_WatchConcertAvailabilityServer = grpc.ServerStreamingServer[ConcertAvailability]
+
 // ConcertService_ServiceDesc is the grpc.ServiceDesc for ConcertService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -221,6 +301,10 @@ var ConcertService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListConcerts",
 			Handler:    _ConcertService_ListConcerts_Handler,
 		},
+		{
+			MethodName: "BatchGetConcerts",
+			Handler:    _ConcertService_BatchGetConcerts_Handler,
+		},
 		{
 			MethodName: "CreateConcert",
 			Handler:    _ConcertService_CreateConcert_Handler,
@@ -230,6 +314,12 @@ var ConcertService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _ConcertService_UpdateConcert_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchConcertAvailability",
+			Handler:       _ConcertService_WatchConcertAvailability_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/grpc/proto/concert.proto",
 }
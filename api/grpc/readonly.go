@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+// readOnlyMethods are the RPCs served in read-only mode. They read public
+// concert data only, so mirrors need no credentials.
+var readOnlyMethods = map[string]bool{
+	pb.ConcertService_GetConcert_FullMethodName:               true,
+	pb.ConcertService_ListConcerts_FullMethodName:             true,
+	pb.ConcertService_BatchGetConcerts_FullMethodName:         true,
+	pb.ConcertService_WatchConcertAvailability_FullMethodName: true,
+}
+
+// readOnlyServiceDesc returns a copy of a service description without the
+// methods that aren't served in read-only mode
+func readOnlyServiceDesc(desc *grpc.ServiceDesc) *grpc.ServiceDesc {
+	filtered := *desc
+	filtered.Methods = nil
+	filtered.Streams = nil
+
+	for _, method := range desc.Methods {
+		if readOnlyMethods["/"+desc.ServiceName+"/"+method.MethodName] {
+			filtered.Methods = append(filtered.Methods, method)
+		}
+	}
+
+	for _, stream := range desc.Streams {
+		if readOnlyMethods["/"+desc.ServiceName+"/"+stream.StreamName] {
+			filtered.Streams = append(filtered.Streams, stream)
+		}
+	}
+
+	return &filtered
+}
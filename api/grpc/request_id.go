@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"concert-ticket-api/pkg/trace"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadata is the metadata key carrying request IDs, the lowercase
+// X-Request-ID header of the REST API, which the gateway forwards under it
+var requestIDMetadata = strings.ToLower("X-Request-ID")
+
+// withRequestID attaches the request ID in the request metadata, or a new one
+// if the caller sent none or an invalid one
+func withRequestID(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := ""
+	if values := md.Get(requestIDMetadata); len(values) > 0 {
+		id = values[0]
+	}
+	if !trace.ValidRequestID(id) {
+		id = trace.NewRequestID()
+	}
+	return trace.WithRequestID(ctx, id), id
+}
+
+// requestIDUnaryInterceptor attaches request IDs to unary RPCs and returns
+// them in the response header, errors included
+func requestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, id := withRequestID(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return handler(ctx, req)
+}
+
+// requestIDStreamInterceptor attaches request IDs to streaming RPCs
+func requestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, id := withRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestIDMetadata, id))
+
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
@@ -3,18 +3,31 @@ package grpc
 import (
 	"concert-ticket-api/internal/model"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	_ "time"
 
 	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
 	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/trace"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/validator"
 	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "concert-ticket-api/api/grpc/proto"
@@ -24,49 +37,107 @@ import (
 type Server struct {
 	concertService service.ConcertService
 	bookingService service.BookingService
+	tokenService   service.BookingTokenService
+	orderService   service.OrderService
+	events         *events.Bus
+	authorizer     *Authorizer
 	logger         logger.Logger
 	server         *grpc.Server
+	health         *grpchealth.Server
+	drain          *drain
 	port           int
 	pb.UnimplementedConcertServiceServer
 	pb.UnimplementedBookingServiceServer
 }
 
-// NewServer creates a new gRPC server
+// NewServer creates a new gRPC server. Availability watchers are fed from
+// the event bus; without one they only receive the current availability.
+// In read-only mode only the public concert reads are registered.
 func NewServer(
 	concertService service.ConcertService,
 	bookingService service.BookingService,
+	tokenService service.BookingTokenService,
+	orderService service.OrderService,
+	bus *events.Bus,
+	authorizer *Authorizer,
+	readOnly bool,
 	logger logger.Logger,
 	port int,
 ) *Server {
 	// Create gRPC server with middleware
+	drain := newDrain()
 	grpcServer := grpc.NewServer(
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
 			grpc_recovery.UnaryServerInterceptor(),
+			errorUnaryInterceptor,
+			requestIDUnaryInterceptor,
+			drain.unaryInterceptor,
+			authorizer.UnaryInterceptor(),
+			inviteTokenUnaryInterceptor,
 			grpc_validator.UnaryServerInterceptor(),
 		)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
+			grpc_recovery.StreamServerInterceptor(),
+			errorStreamInterceptor,
+			requestIDStreamInterceptor,
+			drain.streamInterceptor,
+			authorizer.StreamInterceptor(),
+			inviteTokenStreamInterceptor,
+		)),
 	)
 
+	if bus == nil {
+		bus = events.NewBus()
+	}
+
 	// Create server instance
 	server := &Server{
 		concertService: concertService,
 		bookingService: bookingService,
+		tokenService:   tokenService,
+		orderService:   orderService,
+		events:         bus,
+		authorizer:     authorizer,
 		logger:         logger,
 		server:         grpcServer,
+		health:         newHealthServer(),
+		drain:          drain,
 		port:           port,
 	}
 
 	// Register services
-	pb.RegisterConcertServiceServer(grpcServer, server)
-	pb.RegisterBookingServiceServer(grpcServer, server)
+	if readOnly {
+		grpcServer.RegisterService(readOnlyServiceDesc(&pb.ConcertService_ServiceDesc), server)
+	} else {
+		pb.RegisterConcertServiceServer(grpcServer, server)
+		pb.RegisterBookingServiceServer(grpcServer, server)
+	}
 
 	// Register reflection service (helpful for grpcurl and other tools)
 	reflection.Register(grpcServer)
 
+	// Kubernetes gRPC probes and grpc_health_probe check the health service;
+	// services report SERVING until TrackHealth says otherwise. Channelz
+	// shows the server's channels and sockets to debugging tools.
+	healthpb.RegisterHealthServer(grpcServer, server.health)
+	for _, service := range healthServices {
+		if _, ok := grpcServer.GetServiceInfo()[service]; ok {
+			server.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+		}
+	}
+	channelzservice.RegisterChannelzServiceToServer(grpcServer)
+
 	return server
 }
 
 // Start starts the gRPC server
 func (s *Server) Start() error {
+	// Refuse to serve methods without an explicit authorization policy
+	if err := s.ValidateAuthorization(); err != nil {
+		s.logger.Error("Authorization matrix is incomplete: %v", err)
+		return err
+	}
+
 	addr := fmt.Sprintf(":%d", s.port)
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -75,20 +146,71 @@ func (s *Server) Start() error {
 	}
 
 	s.logger.Info("Starting gRPC server on %s", addr)
+	return s.Serve(lis)
+}
+
+// Serve serves the gRPC API on a listener
+func (s *Server) Serve(lis net.Listener) error {
 	return s.server.Serve(lis)
 }
 
-// Shutdown gracefully shuts down the server
-func (s *Server) Shutdown() {
+// ValidateAuthorization checks that every registered method has an authorization policy
+func (s *Server) ValidateAuthorization() error {
+	return s.authorizer.CheckCoverage(s.ServiceInfo())
+}
+
+// ServiceInfo returns the registered services and their methods
+func (s *Server) ServiceInfo() map[string]grpc.ServiceInfo {
+	return s.server.GetServiceInfo()
+}
+
+// Drain rejects new writes with Unavailable, while the calls in flight
+// finish and reads are still served. Probes see the server going away.
+func (s *Server) Drain() {
+	if s.drain.draining.Swap(true) {
+		return
+	}
+	s.logger.Info("Draining gRPC server")
+	s.health.Shutdown()
+}
+
+// Shutdown drains the server, ends the availability watchers and waits for
+// the calls in flight to finish. Calls still running when ctx is done are
+// cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
 	s.logger.Info("Shutting down gRPC server")
-	s.server.GracefulStop()
+	s.drain.stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+}
+
+// log returns the logger of an RPC, which adds its request ID
+func (s *Server) log(ctx context.Context) logger.Logger {
+	if id := trace.RequestID(ctx); id != "" {
+		return s.logger.With(logger.RequestID(id))
+	}
+	return s.logger
 }
 
 // GetConcert implements the ConcertService.GetConcert RPC
 func (s *Server) GetConcert(ctx context.Context, req *pb.GetConcertRequest) (*pb.Concert, error) {
 	concert, err := s.concertService.GetByID(ctx, req.Id)
 	if err != nil {
-		s.logger.Error("Failed to get concert: %v", err)
+		s.log(ctx).Error("Failed to get concert: %v", err)
 		return nil, err
 	}
 
@@ -97,8 +219,23 @@ func (s *Server) GetConcert(ctx context.Context, req *pb.GetConcertRequest) (*pb
 
 // ListConcerts implements the ConcertService.ListConcerts RPC
 func (s *Server) ListConcerts(ctx context.Context, req *pb.ListConcertsRequest) (*pb.ListConcertsResponse, error) {
+	page, err := convertPbPage(req.Page, req.PageSize, req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	sorts, err := query.ParseSort(req.Sort, model.ConcertSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, err := parseReadMask(req.ReadMask)
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert request to filters
-	filters := make(map[string]interface{})
+	filters := make(query.Filters)
 
 	if req.Artist != "" {
 		filters["artist"] = req.Artist
@@ -125,9 +262,10 @@ func (s *Server) ListConcerts(ctx context.Context, req *pb.ListConcertsRequest)
 	}
 
 	// Get concerts
-	concerts, totalCount, err := s.concertService.ListConcerts(ctx, int(req.Page), int(req.PageSize), filters)
+	opts := query.Options{Page: page, Sort: sorts, Filters: filters, EstimateCount: req.ExactCount != nil && !*req.ExactCount}
+	concerts, totalCount, err := s.concertService.ListConcerts(ctx, opts)
 	if err != nil {
-		s.logger.Error("Failed to list concerts: %v", err)
+		s.log(ctx).Error("Failed to list concerts: %v", err)
 		return nil, err
 	}
 
@@ -136,17 +274,38 @@ func (s *Server) ListConcerts(ctx context.Context, req *pb.ListConcertsRequest)
 	for _, concert := range concerts {
 		pbConcerts = append(pbConcerts, convertModelToPbConcert(concert))
 	}
+	mask.apply(pbConcerts)
 
-	totalPages := (totalCount + int(req.PageSize) - 1) / int(req.PageSize)
-
+	meta := page.Meta(totalCount)
+	meta.TotalCountEstimated = opts.EstimateCount
 	return &pb.ListConcertsResponse{
 		Concerts: pbConcerts,
-		Meta: &pb.PaginationMeta{
-			Page:       req.Page,
-			PageSize:   req.PageSize,
-			TotalCount: int32(totalCount),
-			TotalPages: int32(totalPages),
-		},
+		Meta:     convertMetaToPb(meta),
+	}, nil
+}
+
+// BatchGetConcerts implements the ConcertService.BatchGetConcerts RPC
+func (s *Server) BatchGetConcerts(ctx context.Context, req *pb.BatchGetConcertsRequest) (*pb.BatchGetConcertsResponse, error) {
+	mask, err := parseReadMask(req.ReadMask)
+	if err != nil {
+		return nil, err
+	}
+
+	concerts, missing, err := s.concertService.BatchGetConcerts(ctx, req.Ids)
+	if err != nil {
+		s.log(ctx).Error("Failed to batch get concerts: %v", err)
+		return nil, err
+	}
+
+	pbConcerts := make([]*pb.Concert, 0, len(concerts))
+	for _, concert := range concerts {
+		pbConcerts = append(pbConcerts, convertModelToPbConcert(concert))
+	}
+	mask.apply(pbConcerts)
+
+	return &pb.BatchGetConcertsResponse{
+		Concerts: pbConcerts,
+		NotFound: missing,
 	}, nil
 }
 
@@ -163,77 +322,182 @@ func (s *Server) CreateConcert(ctx context.Context, req *pb.CreateConcertRequest
 		Price:            req.Price,
 		BookingStartTime: req.BookingStartTime.AsTime(),
 		BookingEndTime:   req.BookingEndTime.AsTime(),
+		ArtistAliases:    req.ArtistAliases,
+		VenueAliases:     req.VenueAliases,
+
+		RequiresBookingToken: req.RequiresBookingToken,
+		Currency:             req.Currency,
+		OrganizerEmail:       req.OrganizerEmail,
+		DoorPrice:            req.DoorPrice,
+		DoorPriceLeadMinutes: int(req.DoorPriceLeadMinutes),
+		Visibility:           req.Visibility,
+		MaxTicketsPerBooking: intFromPb(req.MaxTicketsPerBooking),
+		MaxOrderValue:        req.MaxOrderValue,
 	}
 
 	// Create concert
 	createdConcert, err := s.concertService.CreateConcert(ctx, concert)
 	if err != nil {
-		s.logger.Error("Failed to create concert: %v", err)
+		s.log(ctx).Error("Failed to create concert: %v", err)
 		return nil, err
 	}
 
 	return convertModelToPbConcert(createdConcert), nil
 }
 
-// UpdateConcert implements the ConcertService.UpdateConcert RPC
+// UpdateConcert implements the ConcertService.UpdateConcert RPC. Only the
+// fields of the update mask, or the fields set without one, are updated.
 func (s *Server) UpdateConcert(ctx context.Context, req *pb.UpdateConcertRequest) (*pb.Concert, error) {
-	// Convert request to model
-	concert := &model.Concert{
-		ID:               req.Id,
-		Name:             req.Name,
-		Artist:           req.Artist,
-		Venue:            req.Venue,
-		ConcertDate:      req.ConcertDate.AsTime(),
-		TotalTickets:     int(req.TotalTickets),
-		Price:            req.Price,
-		BookingStartTime: req.BookingStartTime.AsTime(),
-		BookingEndTime:   req.BookingEndTime.AsTime(),
-		Version:          int(req.Version),
-	}
-
-	// Get current concert to preserve available tickets
-	currentConcert, err := s.concertService.GetByID(ctx, req.Id)
+	fields, err := updateFields(req)
 	if err != nil {
-		s.logger.Error("Failed to get concert for update: %v", err)
 		return nil, err
 	}
 
-	// Preserve available tickets
-	concert.AvailableTickets = currentConcert.AvailableTickets
+	patch := &model.ConcertPatch{
+		Fields: fields,
+		Values: model.Concert{
+			Name:             req.Name,
+			Artist:           req.Artist,
+			Venue:            req.Venue,
+			ConcertDate:      req.ConcertDate.AsTime(),
+			TotalTickets:     int(req.TotalTickets),
+			Price:            req.Price,
+			BookingStartTime: req.BookingStartTime.AsTime(),
+			BookingEndTime:   req.BookingEndTime.AsTime(),
+			ArtistAliases:    req.ArtistAliases,
+			VenueAliases:     req.VenueAliases,
+
+			RequiresBookingToken: req.RequiresBookingToken,
+			Currency:             req.Currency,
+			OrganizerEmail:       req.OrganizerEmail,
+			DoorPrice:            req.DoorPrice,
+			DoorPriceLeadMinutes: int(req.DoorPriceLeadMinutes),
+			Visibility:           req.Visibility,
+			MaxTicketsPerBooking: intFromPb(req.MaxTicketsPerBooking),
+			MaxOrderValue:        req.MaxOrderValue,
+		},
+	}
+
+	actor := "anonymous"
+	if client := ClientFromContext(ctx); client != nil {
+		actor = client.Name
+	}
+
+	// Only organizers and admins may update, and they manage private concerts too
+	ctx = service.WithPrivateAccess(ctx)
 
-	// Update concert
-	err = s.concertService.UpdateConcert(ctx, concert)
+	updated, _, err := s.concertService.PatchConcert(ctx, actor, req.Id, int(req.Version), patch)
 	if err != nil {
-		s.logger.Error("Failed to update concert: %v", err)
+		s.log(ctx).Error("Failed to update concert: %v", err)
 		return nil, err
 	}
 
-	// Get updated concert
-	updatedConcert, err := s.concertService.GetByID(ctx, req.Id)
-	if err != nil {
-		s.logger.Error("Failed to get updated concert: %v", err)
+	// The invite token is set when the update made the concert private
+	inviteToken := updated.InviteToken
+	if updated, err = s.concertService.GetByID(ctx, req.Id); err != nil {
+		s.log(ctx).Error("Failed to get updated concert: %v", err)
 		return nil, err
 	}
+	updated.InviteToken = inviteToken
 
-	return convertModelToPbConcert(updatedConcert), nil
+	return convertModelToPbConcert(updated), nil
+}
+
+// availabilityBuffer is the number of availability changes queued per
+// watcher. Watchers that fall behind skip to the latest changes.
+const availabilityBuffer = 16
+
+// WatchConcertAvailability implements the ConcertService.WatchConcertAvailability RPC
+func (s *Server) WatchConcertAvailability(req *pb.WatchConcertAvailabilityRequest, stream pb.ConcertService_WatchConcertAvailabilityServer) error {
+	ctx := stream.Context()
+
+	// Subscribe before reading the current state so no change is missed
+	sub := s.events.Subscribe(model.EventConcertAvailability, req.ConcertId, availabilityBuffer)
+	defer sub.Close()
+
+	concert, err := s.concertService.GetByID(ctx, req.ConcertId)
+	if err != nil {
+		s.log(ctx).Error("Failed to get concert: %v", err)
+		return err
+	}
+
+	if err := stream.Send(&pb.ConcertAvailability{
+		ConcertId:        concert.ID,
+		AvailableTickets: int32(concert.AvailableTickets),
+		TotalTickets:     int32(concert.TotalTickets),
+		UpdatedAt:        timestamppb.New(concert.UpdatedAt),
+	}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.drain.stopping:
+			return status.Error(codes.Unavailable, drainingMessage)
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			availability, ok := event.Payload.(*model.ConcertAvailability)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(convertModelToPbAvailability(availability)); err != nil {
+				return err
+			}
+		}
+	}
 }
 
 // GetBooking implements the BookingService.GetBooking RPC
 func (s *Server) GetBooking(ctx context.Context, req *pb.GetBookingRequest) (*pb.Booking, error) {
-	booking, err := s.bookingService.GetBookingByID(ctx, req.Id)
+	var booking *model.Booking
+	var err error
+	if req.Reference != "" {
+		booking, err = s.bookingService.GetBookingByReference(ctx, req.Reference)
+	} else if err = requireAdminForBookingID(ctx); err == nil {
+		booking, err = s.bookingService.GetBookingByID(ctx, req.Id)
+	}
 	if err != nil {
-		s.logger.Error("Failed to get booking: %v", err)
+		s.log(ctx).Error("Failed to get booking: %v", err)
 		return nil, err
 	}
 
 	return convertModelToPbBooking(booking), nil
 }
 
+// requireAdminForBookingID only lets admins address a booking by its
+// sequential integer ID; everyone else has to send the reference, so bookings
+// can't be enumerated by counting up
+func requireAdminForBookingID(ctx context.Context) error {
+	if client := ClientFromContext(ctx); client != nil && hasRole(client.Roles, RoleAdmin) {
+		return nil
+	}
+	return pkgErr.ErrInvalidInput("reference is required")
+}
+
 // GetUserBookings implements the BookingService.GetUserBookings RPC
 func (s *Server) GetUserBookings(ctx context.Context, req *pb.GetUserBookingsRequest) (*pb.GetUserBookingsResponse, error) {
-	bookings, err := s.bookingService.GetUserBookings(ctx, req.UserId, int(req.Page), int(req.PageSize))
+	page, err := convertPbPage(req.Page, req.PageSize, req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	expand, err := query.ParseExpand(req.Expand, model.BookingExpansions)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []*model.Booking
+	if expand.Has(model.ExpandConcert) {
+		bookings, err = s.bookingService.GetUserBookingsWithConcerts(ctx, req.UserId, page)
+	} else {
+		bookings, err = s.bookingService.GetUserBookings(ctx, req.UserId, page)
+	}
 	if err != nil {
-		s.logger.Error("Failed to get user bookings: %v", err)
+		s.log(ctx).Error("Failed to get user bookings: %v", err)
 		return nil, err
 	}
 
@@ -246,8 +510,9 @@ func (s *Server) GetUserBookings(ctx context.Context, req *pb.GetUserBookingsReq
 	return &pb.GetUserBookingsResponse{
 		Bookings: pbBookings,
 		Meta: &pb.PaginationMeta{
-			Page:     req.Page,
-			PageSize: req.PageSize,
+			Page:       int32(page.Number),
+			PageSize:   int32(page.Size),
+			NextCursor: page.NextCursor(len(bookings)),
 		},
 	}, nil
 }
@@ -256,26 +521,100 @@ func (s *Server) GetUserBookings(ctx context.Context, req *pb.GetUserBookingsReq
 func (s *Server) BookTickets(ctx context.Context, req *pb.BookTicketsRequest) (*pb.Booking, error) {
 	// Convert request to model
 	bookingReq := &model.BookingRequest{
-		ConcertID:   req.ConcertId,
-		UserID:      req.UserId,
-		TicketCount: int(req.TicketCount),
+		ConcertID:      req.ConcertId,
+		UserID:         req.UserId,
+		TicketCount:    int(req.TicketCount),
+		AttendeeName:   req.AttendeeName,
+		AttendeeEmail:  req.AttendeeEmail,
+		BookingToken:   req.BookingToken,
+		IdempotencyKey: req.IdempotencyKey,
 	}
 
 	// Book tickets
 	booking, err := s.bookingService.BookTickets(ctx, bookingReq)
 	if err != nil {
-		s.logger.Error("Failed to book tickets: %v", err)
+		s.log(ctx).Error("Failed to book tickets: %v", err)
 		return nil, err
 	}
 
 	return convertModelToPbBooking(booking), nil
 }
 
+// bookingStreamChunkSize is how many streamed booking requests are committed
+// together
+const bookingStreamChunkSize = 100
+
+// BookTicketsStream implements the BookingService.BookTicketsStream RPC.
+// Requests are booked in chunks as they arrive, so a chunk stays committed
+// if the stream breaks later; the summary is sent once the client closes the
+// stream.
+func (s *Server) BookTicketsStream(stream pb.BookingService_BookTicketsStreamServer) error {
+	ctx := stream.Context()
+	summary := &pb.BookTicketsStreamSummary{}
+	chunk := make([]*model.BookingRequest, 0, bookingStreamChunkSize)
+
+	commit := func() {
+		if len(chunk) == 0 {
+			return
+		}
+
+		first := int(summary.Received) - len(chunk)
+		for i, result := range s.bookingService.BookTicketsBatch(ctx, chunk) {
+			entry := &pb.BookTicketsStreamResult{Index: int32(first + i)}
+			if result.Err != nil {
+				kind, _ := pkgErr.Lookup(result.Err)
+				entry.ErrorReason, entry.ErrorMessage = kind.Code, pkgErr.MessageOf(result.Err, internalMessage)
+				summary.Failed++
+			} else {
+				entry.Reference = result.Booking.Reference
+				summary.Booked++
+				summary.TicketsBooked += int32(result.Booking.TicketCount)
+			}
+			summary.Results = append(summary.Results, entry)
+		}
+
+		summary.Chunks++
+		chunk = chunk[:0]
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			commit()
+			s.log(ctx).Info("Booked %d of %d streamed booking requests in %d chunks", summary.Booked, summary.Received, summary.Chunks)
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			s.log(ctx).Error("Booking stream ended after %d requests: %v", summary.Received, err)
+			return err
+		}
+
+		chunk = append(chunk, &model.BookingRequest{
+			ConcertID:     req.ConcertId,
+			UserID:        req.UserId,
+			TicketCount:   int(req.TicketCount),
+			AttendeeName:  req.AttendeeName,
+			AttendeeEmail: req.AttendeeEmail,
+			BookingToken:  req.BookingToken,
+		})
+		summary.Received++
+
+		if len(chunk) == bookingStreamChunkSize {
+			commit()
+		}
+	}
+}
+
 // CancelBooking implements the BookingService.CancelBooking RPC
 func (s *Server) CancelBooking(ctx context.Context, req *pb.CancelBookingRequest) (*pb.CancelBookingResponse, error) {
-	err := s.bookingService.CancelBooking(ctx, req.Id, req.UserId)
+	var err error
+	if req.Reference != "" {
+		err = s.bookingService.CancelBookingByReference(ctx, req.Reference, req.UserId)
+	} else if err = requireAdminForBookingID(ctx); err == nil {
+		err = s.bookingService.CancelBooking(ctx, req.Id, req.UserId)
+	}
 	if err != nil {
-		s.logger.Error("Failed to cancel booking: %v", err)
+		s.log(ctx).Error("Failed to cancel booking: %v", err)
 		return nil, err
 	}
 
@@ -284,10 +623,111 @@ func (s *Server) CancelBooking(ctx context.Context, req *pb.CancelBookingRequest
 	}, nil
 }
 
+// GetOrder implements the BookingService.GetOrder RPC
+func (s *Server) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+	// Without the postgres driver there are no orders to serve
+	if s.orderService == nil {
+		return s.UnimplementedBookingServiceServer.GetOrder(ctx, req)
+	}
+
+	order, err := s.orderService.GetOrderByReference(ctx, req.Reference)
+	if err != nil {
+		s.log(ctx).Error("Failed to get order: %v", err)
+		return nil, err
+	}
+
+	return convertModelToPbOrder(order), nil
+}
+
+// GetUserOrders implements the BookingService.GetUserOrders RPC
+func (s *Server) GetUserOrders(ctx context.Context, req *pb.GetUserOrdersRequest) (*pb.GetUserOrdersResponse, error) {
+	if s.orderService == nil {
+		return s.UnimplementedBookingServiceServer.GetUserOrders(ctx, req)
+	}
+
+	page, err := convertPbPage(req.Page, req.PageSize, req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderService.GetUserOrders(ctx, req.UserId, page)
+	if err != nil {
+		s.log(ctx).Error("Failed to get user orders: %v", err)
+		return nil, err
+	}
+
+	// Convert to response
+	var pbOrders []*pb.Order
+	for _, order := range orders {
+		pbOrders = append(pbOrders, convertModelToPbOrder(order))
+	}
+
+	return &pb.GetUserOrdersResponse{
+		Orders: pbOrders,
+		Meta: &pb.PaginationMeta{
+			Page:       int32(page.Number),
+			PageSize:   int32(page.Size),
+			NextCursor: page.NextCursor(len(orders)),
+		},
+	}, nil
+}
+
+// CancelOrder implements the BookingService.CancelOrder RPC
+func (s *Server) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.Order, error) {
+	if s.orderService == nil {
+		return s.UnimplementedBookingServiceServer.CancelOrder(ctx, req)
+	}
+
+	order, err := s.orderService.CancelOrder(ctx, req.Reference, req.UserId)
+	if err != nil {
+		s.log(ctx).Error("Failed to cancel order: %v", err)
+		return nil, err
+	}
+
+	return convertModelToPbOrder(order), nil
+}
+
+// IssueBookingToken implements the BookingService.IssueBookingToken RPC
+func (s *Server) IssueBookingToken(ctx context.Context, req *pb.IssueBookingTokenRequest) (*pb.BookingToken, error) {
+	token, err := s.tokenService.IssueToken(ctx, req.ConcertId, req.UserId)
+	if err != nil {
+		s.log(ctx).Error("Failed to issue booking token: %v", err)
+		return nil, err
+	}
+
+	return &pb.BookingToken{
+		Token:     token.Token,
+		ConcertId: token.ConcertID,
+		UserId:    token.UserID,
+		ExpiresAt: timestamppb.New(token.ExpiresAt),
+	}, nil
+}
+
 // Helper functions to convert between model and protobuf types
 
 // convertModelToPbConcert converts a model.Concert to a pb.Concert
+// convertPbPage converts the paging fields of a request; unset fields select
+// the first page and the default size
+func convertPbPage(page, pageSize int32, cursor string) (query.Page, error) {
+	if cursor != "" {
+		return query.DecodeCursor(cursor)
+	}
+	return query.NewPage(int(page), int(pageSize)), nil
+}
+
+func convertMetaToPb(meta query.Meta) *pb.PaginationMeta {
+	return &pb.PaginationMeta{
+		Page:                int32(meta.Page),
+		PageSize:            int32(meta.PageSize),
+		TotalCount:          int32(meta.TotalCount),
+		TotalPages:          int32(meta.TotalPages),
+		NextCursor:          meta.NextCursor,
+		TotalCountEstimated: meta.TotalCountEstimated,
+	}
+}
+
 func convertModelToPbConcert(concert *model.Concert) *pb.Concert {
+	now := clock.Now()
 	return &pb.Concert{
 		Id:               concert.ID,
 		Name:             concert.Name,
@@ -302,19 +742,113 @@ func convertModelToPbConcert(concert *model.Concert) *pb.Concert {
 		Version:          int32(concert.Version),
 		CreatedAt:        timestamppb.New(concert.CreatedAt),
 		UpdatedAt:        timestamppb.New(concert.UpdatedAt),
+		ArtistAliases:    concert.ArtistAliases,
+		VenueAliases:     concert.VenueAliases,
+
+		RequiresBookingToken: concert.RequiresBookingToken,
+		Currency:             concert.Currency,
+		PriceDisplay:         money.Format(concert.Price, concert.Currency, ""),
+		OrganizerEmail:       concert.OrganizerEmail,
+		ReportingState:       concert.ReportingState,
+		DoorPrice:            concert.DoorPrice,
+		DoorPriceLeadMinutes: int32(concert.DoorPriceLeadMinutes),
+		PricingPhase:         concert.PricingPhaseAt(now),
+		CurrentPrice:         concert.PriceAt(now),
+		Visibility:           concert.Visibility,
+		InviteToken:          concert.InviteToken,
+		MaxTicketsPerBooking: intToPb(concert.MaxTicketsPerBooking),
+		MaxOrderValue:        concert.MaxOrderValue,
+	}
+}
+
+// intFromPb converts an optional proto integer
+func intFromPb(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+// intToPb converts an optional integer for proto messages
+func intToPb(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	i := int32(*v)
+	return &i
+}
+
+// convertModelToPbAvailability converts a model.ConcertAvailability to a pb.ConcertAvailability
+func convertModelToPbAvailability(availability *model.ConcertAvailability) *pb.ConcertAvailability {
+	return &pb.ConcertAvailability{
+		ConcertId:        availability.ConcertID,
+		AvailableTickets: int32(availability.AvailableTickets),
+		TotalTickets:     int32(availability.TotalTickets),
+		UpdatedAt:        timestamppb.New(availability.UpdatedAt),
+	}
+}
+
+// convertModelToPbOrder converts a model.Order to a pb.Order
+func convertModelToPbOrder(order *model.Order) *pb.Order {
+	bookings := make([]*pb.Booking, 0, len(order.Bookings))
+	for _, booking := range order.Bookings {
+		bookings = append(bookings, convertModelToPbBooking(booking))
+	}
+
+	return &pb.Order{
+		Reference: order.Reference,
+		UserId:    order.UserID,
+		Mode:      order.Mode,
+		Currency:  order.Currency,
+		Status:    string(order.Status),
+		Total:     order.Total,
+		Bookings:  bookings,
+		CreatedAt: timestamppb.New(order.CreatedAt),
+		UpdatedAt: timestamppb.New(order.UpdatedAt),
 	}
 }
 
 // convertModelToPbBooking converts a model.Booking to a pb.Booking
 func convertModelToPbBooking(booking *model.Booking) *pb.Booking {
+	var concert *pb.ConcertSummary
+	if booking.Concert != nil {
+		concert = &pb.ConcertSummary{
+			Id:          booking.Concert.ID,
+			Name:        booking.Concert.Name,
+			Artist:      booking.Concert.Artist,
+			Venue:       booking.Concert.Venue,
+			ConcertDate: timestamppb.New(booking.Concert.ConcertDate),
+		}
+	}
+
+	var payment *pb.Payment
+	if booking.Payment != nil {
+		payment = &pb.Payment{
+			Provider:          booking.Payment.Provider,
+			ProviderReference: booking.Payment.ProviderReference,
+			ClientSecret:      booking.Payment.ClientSecret,
+			Amount:            booking.Payment.Amount,
+			Currency:          booking.Payment.Currency,
+			Status:            booking.Payment.Status,
+			ExpiresAt:         timestamppb.New(booking.Payment.ExpiresAt),
+		}
+	}
+
 	return &pb.Booking{
-		Id:          booking.ID,
-		ConcertId:   booking.ConcertID,
-		UserId:      booking.UserID,
-		TicketCount: int32(booking.TicketCount),
-		BookingTime: timestamppb.New(booking.BookingTime),
-		Status:      string(booking.Status),
-		CreatedAt:   timestamppb.New(booking.CreatedAt),
-		UpdatedAt:   timestamppb.New(booking.UpdatedAt),
+		Id:            booking.ID,
+		ConcertId:     booking.ConcertID,
+		UserId:        booking.UserID,
+		TicketCount:   int32(booking.TicketCount),
+		BookingTime:   timestamppb.New(booking.BookingTime),
+		Status:        string(booking.Status),
+		CreatedAt:     timestamppb.New(booking.CreatedAt),
+		UpdatedAt:     timestamppb.New(booking.UpdatedAt),
+		AttendeeName:  booking.AttendeeName,
+		AttendeeEmail: booking.AttendeeEmail,
+		Reference:     booking.Reference,
+		UnitPrice:     booking.UnitPrice,
+		Concert:       concert,
+		Payment:       payment,
 	}
 }
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminServer serves the dangerous admin operations on a listener of its
+// own, which is bound to an internal address and never routed through the
+// public load balancer. None of its routes exist on the public server.
+type AdminServer struct {
+	router     *gin.Engine
+	httpServer *http.Server
+	logger     logger.Logger
+}
+
+// NewAdminServer creates the internal admin server. The runtime settings
+// routes are only served with a settings service.
+func NewAdminServer(ops service.AdminOpsService, settings service.RuntimeSettingsService, logger logger.Logger, cfg config.InternalAdmin) *AdminServer {
+	router := gin.New()
+	router.NoRoute(func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Route not found")
+	})
+
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+	}))
+	router.Use(middleware.TraceID())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(logger))
+
+	auth := middleware.InternalAdminAuth(cfg)
+	handler.NewInternalAdminHandler(ops, logger).RegisterRoutes(router, auth)
+	if settings != nil {
+		handler.NewRuntimeSettingsHandler(settings, logger).RegisterRoutes(router, auth)
+	}
+
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:      router,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	return &AdminServer{
+		router:     router,
+		httpServer: httpServer,
+		logger:     logger,
+	}
+}
+
+// Handler returns the HTTP handler of the server
+func (s *AdminServer) Handler() http.Handler {
+	return s.router
+}
+
+// Start starts the server
+func (s *AdminServer) Start() error {
+	s.logger.Info("Starting internal admin server on %s", s.httpServer.Addr)
+	return s.httpServer.ListenAndServe()
+}
+
+// Shutdown gracefully shuts down the server
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	s.logger.Info("Shutting down internal admin server")
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugServer serves pprof profiles, GC stats and goroutine dumps on a
+// listener of its own, like the internal admin server. Profiles show the
+// code and the data in flight, so none of its routes exist on the public
+// server.
+type DebugServer struct {
+	router     *gin.Engine
+	httpServer *http.Server
+	logger     logger.Logger
+}
+
+// NewDebugServer creates the debug server and turns on the block and mutex
+// profiles if configured
+func NewDebugServer(logger logger.Logger, cfg config.Debug) *DebugServer {
+	runtime.SetBlockProfileRate(cfg.BlockProfileRate)
+	runtime.SetMutexProfileFraction(cfg.MutexProfileFraction)
+
+	router := gin.New()
+	router.NoRoute(func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Route not found")
+	})
+
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+	}))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(logger))
+
+	handler.NewDebugHandler().RegisterRoutes(router, middleware.AllowedNetworks(cfg.AllowedNetworks))
+
+	httpServer := &http.Server{
+		Addr:        fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:     router,
+		ReadTimeout: 10 * time.Second,
+		// CPU profiles and execution traces stream for as many seconds as
+		// asked, so writes aren't limited
+		IdleTimeout: 120 * time.Second,
+	}
+
+	return &DebugServer{
+		router:     router,
+		httpServer: httpServer,
+		logger:     logger,
+	}
+}
+
+// Handler returns the HTTP handler of the server
+func (s *DebugServer) Handler() http.Handler {
+	return s.router
+}
+
+// Start starts the server
+func (s *DebugServer) Start() error {
+	s.logger.Info("Starting debug server on %s", s.httpServer.Addr)
+	return s.httpServer.ListenAndServe()
+}
+
+// Shutdown gracefully shuts down the server
+func (s *DebugServer) Shutdown(ctx context.Context) error {
+	s.logger.Info("Shutting down debug server")
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles HTTP requests for operational admin endpoints
+type AdminHandler struct {
+	conflictTracker    service.ConflictTracker
+	salesReportService service.SalesReportService
+	accountingService  service.AccountingService
+	attemptService     service.BookingAttemptService
+}
+
+// NewAdminHandler creates a new AdminHandler. The routes of the services
+// that are nil aren't served, like on database drivers without them.
+func NewAdminHandler(
+	conflictTracker service.ConflictTracker,
+	salesReportService service.SalesReportService,
+	accountingService service.AccountingService,
+	attemptService service.BookingAttemptService,
+) *AdminHandler {
+	return &AdminHandler{
+		conflictTracker:    conflictTracker,
+		salesReportService: salesReportService,
+		accountingService:  accountingService,
+		attemptService:     attemptService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the admin middleware
+func (h *AdminHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", adminAuth)
+	{
+		adminGroup.GET("/booking-conflicts", h.GetBookingConflicts)
+		if h.salesReportService != nil {
+			adminGroup.GET("/concerts/:id/sales-report", h.GetSalesReport)
+		}
+		if h.accountingService != nil {
+			adminGroup.GET("/accounting/reconciliation", h.GetAccountingReconciliation)
+		}
+		if h.attemptService != nil {
+			adminGroup.GET("/booking-attempts", h.GetBookingAttempts)
+			adminGroup.GET("/concerts/:id/booking-attempts", h.GetConcertBookingAttempts)
+		}
+	}
+}
+
+// serves reports whether the route with the given path is registered
+func (h *AdminHandler) serves(path string) bool {
+	switch path {
+	case "/admin/concerts/:id/sales-report":
+		return h.salesReportService != nil
+	case "/admin/accounting/reconciliation":
+		return h.accountingService != nil
+	case "/admin/booking-attempts", "/admin/concerts/:id/booking-attempts":
+		return h.attemptService != nil
+	}
+	return true
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *AdminHandler) Operations() []openapi.Operation {
+	operations := []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/admin/booking-conflicts", Tag: "admin", Summary: "Summarize booking conflicts",
+			Parameters: []openapi.Parameter{
+				openapi.QueryParam("window", "string", "Time window as a Go duration, default 1h"),
+				openapi.QueryParam("bucket", "string", "Bucket size as a Go duration, default 5m; at least 1m and at most 1000 buckets per window"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: model.BookingConflictSummary{}, http.StatusBadRequest: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/concerts/:id/sales-report", Tag: "admin", Summary: "Get the sales report of a concert",
+			Parameters: []openapi.Parameter{
+				openapi.PathParam("id", "integer", "Concert ID"),
+				openapi.QueryParam("format", "string", "csv to download the report as CSV"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: model.SalesReport{}, http.StatusNotFound: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/accounting/reconciliation", Tag: "admin", Summary: "Reconcile the accounting export",
+			Responses: map[int]interface{}{http.StatusOK: AccountingReconciliationResponse{}, http.StatusInternalServerError: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/booking-attempts", Tag: "admin", Summary: "Summarize booking attempts by outcome",
+			Parameters: []openapi.Parameter{openapi.QueryParam("window", "string", "Time window as a Go duration, default 24h")},
+			Responses:  map[int]interface{}{http.StatusOK: model.BookingAttemptSummary{}, http.StatusBadRequest: problem.Details{}},
+			Admin:      true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/concerts/:id/booking-attempts", Tag: "admin", Summary: "Summarize the booking attempts of a concert by outcome",
+			Parameters: []openapi.Parameter{
+				openapi.PathParam("id", "integer", "Concert ID"),
+				openapi.QueryParam("window", "string", "Time window as a Go duration, default 24h"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: model.BookingAttemptSummary{}, http.StatusBadRequest: problem.Details{}},
+			Admin:     true,
+		},
+	}
+
+	served := operations[:0]
+	for _, operation := range operations {
+		if h.serves(operation.Path) {
+			served = append(served, operation)
+		}
+	}
+	return served
+}
+
+// GetBookingConflicts handles GET /api/v1/admin/booking-conflicts requests
+func (h *AdminHandler) GetBookingConflicts(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid window")
+		return
+	}
+
+	bucket, err := time.ParseDuration(c.DefaultQuery("bucket", "5m"))
+	if err != nil || bucket <= 0 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid bucket")
+		return
+	}
+
+	// Every concert gets a slice of buckets, so their number is capped
+	if bucket < service.ConflictResolution {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput,
+			fmt.Sprintf("bucket must be at least %s", service.ConflictResolution))
+		return
+	}
+	if (window-1)/bucket >= service.MaxConflictBuckets {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput,
+			fmt.Sprintf("window may span at most %d buckets", service.MaxConflictBuckets))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.conflictTracker.Summary(window, bucket))
+}
+
+// GetSalesReport handles GET /api/v1/admin/concerts/:id/sales-report requests.
+// The report is returned as JSON, or as a CSV download with ?format=csv.
+func (h *AdminHandler) GetSalesReport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	report, err := h.salesReportService.GetReport(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Sales report not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get sales report")
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=sales-report-%d.csv", id))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(report.CSV))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAccountingReconciliation handles GET /api/v1/admin/accounting/reconciliation requests
+func (h *AdminHandler) GetAccountingReconciliation(c *gin.Context) {
+	reconciliations, err := h.accountingService.Reconcile(c.Request.Context())
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to reconcile accounting export")
+		return
+	}
+
+	c.JSON(http.StatusOK, AccountingReconciliationResponse{Providers: reconciliations})
+}
+
+// GetBookingAttempts handles GET /api/v1/admin/booking-attempts requests
+func (h *AdminHandler) GetBookingAttempts(c *gin.Context) {
+	h.summarizeAttempts(c, 0)
+}
+
+// GetConcertBookingAttempts handles GET /api/v1/admin/concerts/:id/booking-attempts requests
+func (h *AdminHandler) GetConcertBookingAttempts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	h.summarizeAttempts(c, id)
+}
+
+// summarizeAttempts renders the booking attempt summary of one or all concerts
+func (h *AdminHandler) summarizeAttempts(c *gin.Context, concertID int64) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "24h"))
+	if err != nil || window <= 0 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid window")
+		return
+	}
+
+	summary, err := h.attemptService.Summary(c.Request.Context(), concertID, window)
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to summarize booking attempts")
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
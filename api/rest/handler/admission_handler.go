@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdmissionHandler handles HTTP requests that inspect and override the
+// waiting room's admission rate. Like worker states, the rate is the
+// answering instance's own.
+type AdmissionHandler struct {
+	admission service.AdmissionController
+	logger    logger.Logger
+}
+
+// NewAdmissionHandler creates a new AdmissionHandler
+func NewAdmissionHandler(admission service.AdmissionController, logger logger.Logger) *AdmissionHandler {
+	return &AdmissionHandler{
+		admission: admission,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the admin middleware
+func (h *AdmissionHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	admissionGroup := router.Group("/admin/admission", adminAuth)
+	{
+		admissionGroup.GET("", h.GetAdmission)
+		admissionGroup.PUT("/override", h.OverrideAdmission)
+		admissionGroup.DELETE("/override", h.ClearAdmissionOverride)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *AdmissionHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/admin/admission", Tag: "admin", Summary: "Get the admission rate and the controller's last decision",
+			Responses: map[int]interface{}{http.StatusOK: model.AdmissionStatus{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodPut, Path: "/admin/admission/override", Tag: "admin", Summary: "Pin the admission rate until the override is cleared",
+			Request:   AdmissionOverrideRequest{},
+			Responses: map[int]interface{}{http.StatusOK: model.AdmissionStatus{}, http.StatusBadRequest: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/admission/override", Tag: "admin", Summary: "Hand the admission rate back to the controller",
+			Responses: map[int]interface{}{http.StatusOK: model.AdmissionStatus{}},
+			Admin:     true,
+		},
+	}
+}
+
+// GetAdmission handles GET /api/v1/admin/admission requests
+func (h *AdmissionHandler) GetAdmission(c *gin.Context) {
+	c.JSON(http.StatusOK, h.admission.Status())
+}
+
+// OverrideAdmission handles PUT /api/v1/admin/admission/override requests
+func (h *AdmissionHandler) OverrideAdmission(c *gin.Context) {
+	var req AdmissionOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid admission override", err)
+		return
+	}
+
+	actor := c.GetString("adminActor")
+	status, err := h.admission.Override(req.Rate, req.Burst, actor)
+	if err != nil {
+		problem.Error(c, err, "Failed to override the admission rate")
+		return
+	}
+
+	h.logger.Warn("Admission rate overridden to %.1f/s by %s", status.Rate, actor)
+	c.JSON(http.StatusOK, status)
+}
+
+// ClearAdmissionOverride handles DELETE /api/v1/admin/admission/override requests
+func (h *AdmissionHandler) ClearAdmissionOverride(c *gin.Context) {
+	status := h.admission.ClearOverride()
+	h.logger.Info("Admission rate override cleared by %s", c.GetString("adminActor"))
+	c.JSON(http.StatusOK, status)
+}
@@ -3,11 +3,13 @@ package handler
 import (
 	"errors"
 	"net/http"
-	"strconv"
 
+	"concert-ticket-api/api/rest/problem"
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/service"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/query"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,13 +27,60 @@ func NewBookingHandler(bookingService service.BookingService) *BookingHandler {
 }
 
 // RegisterRoutes registers the routes for this handler
-func (h *BookingHandler) RegisterRoutes(router *gin.Engine) {
-	bookingGroup := router.Group("/api/v1/bookings")
+func (h *BookingHandler) RegisterRoutes(router gin.IRouter) {
+	bookingGroup := router.Group("/bookings")
 	{
 		bookingGroup.POST("", h.BookTickets)
 		bookingGroup.GET("", h.GetUserBookings)
-		bookingGroup.GET("/:id", h.GetBooking)
-		bookingGroup.POST("/:id/cancel", h.CancelBooking)
+		bookingGroup.GET("/:reference", h.GetBooking)
+		bookingGroup.POST("/:reference/cancel", h.CancelBooking)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *BookingHandler) Operations() []openapi.Operation {
+	ref := openapi.PathParam("reference", "string", "Booking reference")
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/bookings", Tag: "bookings", Summary: "Book tickets",
+			Parameters: []openapi.Parameter{{Name: "Idempotency-Key", In: "header", Schema: &openapi.Schema{Type: "string"},
+				Description: "Retries with the same key return the booking the first request made instead of booking again"}},
+			Request: model.BookingRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    model.Booking{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+				http.StatusConflict:   problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/bookings", Tag: "bookings", Summary: "List the bookings of a user",
+			Parameters: []openapi.Parameter{
+				{Name: "userID", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				openapi.QueryParam("page", "integer", "Page number, starting at 1"),
+				openapi.QueryParam("pageSize", "integer", "Bookings per page, at most 100"),
+				openapi.QueryParam("cursor", "string", "nextCursor of the previous page, replaces page and pageSize"),
+				openapi.QueryParam("fields", "string", "Comma-separated booking fields to return, such as concert_id,status; reference is always returned"),
+				openapi.QueryParam("expand", "string", "concert embeds a summary of each booking's concert"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: BookingListResponse{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/bookings/:reference", Tag: "bookings", Summary: "Get a booking",
+			Parameters: []openapi.Parameter{ref},
+			Responses:  map[int]interface{}{http.StatusOK: model.Booking{}, http.StatusNotFound: problem.Details{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/bookings/:reference/cancel", Tag: "bookings", Summary: "Cancel a booking",
+			Parameters: []openapi.Parameter{ref},
+			Request:    CancelBookingRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         MessageResponse{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusForbidden:  problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
 	}
 }
 
@@ -39,64 +88,36 @@ func (h *BookingHandler) RegisterRoutes(router *gin.Engine) {
 func (h *BookingHandler) BookTickets(c *gin.Context) {
 	var req model.BookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking data"})
+		problem.InvalidBody(c, "Invalid booking data", err)
 		return
 	}
 
 	// In a real app, userID would come from auth middleware
 	// For this exercise, we'll use the one in the request
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
 
 	booking, err := h.bookingService.BookTickets(c.Request.Context(), &req)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		errorMsg := "Failed to book tickets"
-
-		// Map specific errors to appropriate HTTP status codes
-		switch {
-		case errors.Is(err, pkgErr.ErrInvalidInput("")):
-			statusCode = http.StatusBadRequest
-			if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
-				errorMsg = errWithMsg.Message()
-			} else {
-				errorMsg = "Invalid booking request"
-			}
-		case errors.Is(err, pkgErr.ErrNotFound):
-			statusCode = http.StatusNotFound
-			errorMsg = "Concert not found"
-		case errors.Is(err, pkgErr.ErrBookingClosed):
-			statusCode = http.StatusBadRequest
-			errorMsg = "Booking is not open for this concert"
-		case errors.Is(err, pkgErr.ErrInsufficientTickets):
-			statusCode = http.StatusBadRequest
-			errorMsg = "Not enough tickets available"
-		case errors.Is(err, pkgErr.ErrOptimisticLockFailed):
-			statusCode = http.StatusConflict
-			errorMsg = "Booking conflict, please try again"
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
 		}
-
-		c.JSON(statusCode, gin.H{"error": errorMsg})
+		problem.Error(c, err, "Failed to book tickets")
 		return
 	}
 
 	c.JSON(http.StatusCreated, booking)
 }
 
-// GetBooking handles GET /api/v1/bookings/:id requests
+// GetBooking handles GET /api/v1/bookings/:reference requests
 func (h *BookingHandler) GetBooking(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
-		return
-	}
-
-	booking, err := h.bookingService.GetBookingByID(c.Request.Context(), id)
+	booking, err := h.bookingService.GetBookingByReference(c.Request.Context(), c.Param("reference"))
 	if err != nil {
 		if errors.Is(err, pkgErr.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			problem.Write(c, http.StatusNotFound, problem.CodeBookingNotFound, "Booking not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get booking"})
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get booking")
 		return
 	}
 
@@ -112,70 +133,67 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 	// For this exercise, we'll use a query parameter
 	userID := c.Query("userID")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
 		return
 	}
 
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-
-	bookings, err := h.bookingService.GetUserBookings(c.Request.Context(), userID, page, pageSize)
+	page, err := query.ParsePage(c.Query("page"), c.Query("pageSize"), c.Query("cursor"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user bookings"})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": bookings,
-		"meta": gin.H{
-			"page":     page,
-			"pageSize": pageSize,
-		},
-	})
-}
+	fields, ok := parseFields(c, bookingFields)
+	if !ok {
+		return
+	}
 
-// CancelBooking handles POST /api/v1/bookings/:id/cancel requests
-func (h *BookingHandler) CancelBooking(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	expand, err := query.ParseExpand(c.Query("expand"), model.BookingExpansions)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
 		return
 	}
 
-	// In a real app, userID would come from auth middleware
-	// For this exercise, we'll use a JSON request body
-	var req struct {
-		UserID string `json:"userID"`
+	var bookings []*model.Booking
+	if expand.Has(model.ExpandConcert) {
+		bookings, err = h.bookingService.GetUserBookingsWithConcerts(c.Request.Context(), userID, page)
+	} else {
+		bookings, err = h.bookingService.GetUserBookings(c.Request.Context(), userID, page)
+	}
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get user bookings")
+		return
 	}
 
+	writeList(c, BookingListResponse{
+		Data: bookings,
+		Meta: BookingListMeta{
+			Page:       page.Number,
+			PageSize:   page.Size,
+			NextCursor: page.NextCursor(len(bookings)),
+		},
+	}, fields, "reference")
+}
+
+// CancelBooking handles POST /api/v1/bookings/:reference/cancel requests
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a JSON request body
+	var req CancelBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		problem.InvalidBody(c, "Invalid request", err)
 		return
 	}
 
-	err = h.bookingService.CancelBooking(c.Request.Context(), id, req.UserID)
+	err := h.bookingService.CancelBookingByReference(c.Request.Context(), c.Param("reference"), req.UserID)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		errorMsg := "Failed to cancel booking"
-
-		// Map specific errors to appropriate HTTP status codes
-		switch {
-		case errors.Is(err, pkgErr.ErrNotFound):
-			statusCode = http.StatusNotFound
-			errorMsg = "Booking not found"
-		case errors.Is(err, pkgErr.ErrUnauthorized):
-			statusCode = http.StatusForbidden
-			errorMsg = "You are not authorized to cancel this booking"
-		case errors.Is(err, pkgErr.ErrBookingAlreadyCancelled):
-			statusCode = http.StatusBadRequest
-			errorMsg = "Booking is already cancelled"
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeBookingNotFound, "Booking not found")
+			return
 		}
-
-		c.JSON(statusCode, gin.H{"error": errorMsg})
+		problem.Error(c, err, "Failed to cancel booking")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Booking cancelled successfully"})
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookingTokenHandler handles HTTP requests related to booking tokens
+type BookingTokenHandler struct {
+	tokenService service.BookingTokenService
+}
+
+// NewBookingTokenHandler creates a new BookingTokenHandler
+func NewBookingTokenHandler(tokenService service.BookingTokenService) *BookingTokenHandler {
+	return &BookingTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *BookingTokenHandler) RegisterRoutes(router gin.IRouter) {
+	router.POST("/concerts/:id/booking-token", h.IssueToken)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *BookingTokenHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/concerts/:id/booking-token", Tag: "bookings", Summary: "Issue a booking token",
+			Parameters: []openapi.Parameter{openapi.PathParam("id", "integer", "Concert ID")},
+			Request:    model.BookingTokenRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:         model.BookingToken{},
+				http.StatusBadRequest:      problem.Details{},
+				http.StatusNotFound:        problem.Details{},
+				http.StatusTooManyRequests: problem.Details{},
+			},
+		},
+	}
+}
+
+// IssueToken handles POST /api/v1/concerts/:id/booking-token requests
+func (h *BookingTokenHandler) IssueToken(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	var req model.BookingTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid request", err)
+		return
+	}
+
+	token, err := h.tokenService.IssueToken(c.Request.Context(), id, req.UserID)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		problem.Error(c, err, "Failed to issue booking token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/ical"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/pagelink"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// calendarRefreshInterval is how often subscribed calendars are asked to
+	// poll the feed
+	calendarRefreshInterval = time.Hour
+	// concertDuration is the length of the calendar events; concerts only
+	// record when they start
+	concertDuration = 3 * time.Hour
+)
+
+// CalendarFeed documents the iCalendar body of the feed
+type CalendarFeed string
+
+// ContentType documents the feed as text/calendar
+func (CalendarFeed) ContentType() string {
+	return "text/calendar"
+}
+
+// CalendarHandler serves the iCalendar feeds of users' bookings
+type CalendarHandler struct {
+	calendarService service.CalendarService
+}
+
+// NewCalendarHandler creates a new CalendarHandler
+func NewCalendarHandler(calendarService service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *CalendarHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/users/:id/bookings.ics", h.GetUserCalendar)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *CalendarHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/users/:id/bookings.ics", Tag: "bookings",
+			Summary: "Get an iCalendar feed of the confirmed bookings of a user",
+			Parameters: []openapi.Parameter{
+				openapi.PathParam("id", "string", "User ID"),
+				{Name: "token", In: "query", Description: "Feed token of the user, from the calendar_url of the booking links",
+					Required: true, Schema: &openapi.Schema{Type: "string"}},
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  CalendarFeed(""),
+				http.StatusNotFound:            problem.Details{},
+				http.StatusInternalServerError: problem.Details{},
+			},
+		},
+	}
+}
+
+// GetUserCalendar handles GET /api/v1/users/:id/bookings.ics requests
+func (h *CalendarHandler) GetUserCalendar(c *gin.Context) {
+	booked, err := h.calendarService.UserBookings(c.Request.Context(), c.Param("id"), c.Query("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrInvalidInput("")):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		case errors.Is(err, pagelink.ErrInvalid):
+			// Feeds of other users look like feeds that don't exist
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Calendar feed not found")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get user bookings")
+		}
+		return
+	}
+
+	calendar := &ical.Calendar{
+		ProdID:          "-//Concert Ticket API//Bookings//EN",
+		Name:            "Concert bookings",
+		RefreshInterval: calendarRefreshInterval,
+	}
+	for _, entry := range booked {
+		booking, concert := entry.Booking, entry.Concert
+		tickets := "1 ticket"
+		if booking.TicketCount != 1 {
+			tickets = fmt.Sprintf("%d tickets", booking.TicketCount)
+		}
+
+		lastModified := booking.UpdatedAt
+		if concert.UpdatedAt.After(lastModified) {
+			lastModified = concert.UpdatedAt
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:          "booking-" + booking.Reference + "@concert-ticket-api",
+			Start:        concert.ConcertDate,
+			Duration:     concertDuration,
+			Summary:      concert.Name,
+			Location:     concert.Venue,
+			Description:  fmt.Sprintf("%s by %s, %s. Booking reference %s.", concert.Name, concert.Artist, tickets, booking.Reference),
+			LastModified: lastModified,
+		})
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Content-Disposition", `inline; filename="bookings.ics"`)
+	c.Data(http.StatusOK, ical.ContentType, calendar.Encode(clock.Now()))
+}
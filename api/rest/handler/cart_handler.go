@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartHandler handles HTTP requests related to carts and checkout
+type CartHandler struct {
+	cartService service.CartService
+}
+
+// NewCartHandler creates a new CartHandler
+func NewCartHandler(cartService service.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *CartHandler) RegisterRoutes(router gin.IRouter) {
+	cartGroup := router.Group("/cart")
+	{
+		cartGroup.GET("", h.GetCart)
+		cartGroup.PUT("/items/:concert_id", h.PutItem)
+		cartGroup.DELETE("/items/:concert_id", h.RemoveItem)
+		cartGroup.POST("/checkout", h.Checkout)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *CartHandler) Operations() []openapi.Operation {
+	userID := openapi.Parameter{Name: "userID", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	concertID := openapi.PathParam("concert_id", "integer", "Concert ID")
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/cart", Tag: "cart", Summary: "Get the cart of a user",
+			Parameters: []openapi.Parameter{userID},
+			Responses:  map[int]interface{}{http.StatusOK: model.Cart{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/cart/items/:concert_id", Tag: "cart", Summary: "Put tickets of a concert in a cart",
+			Parameters: []openapi.Parameter{concertID},
+			Request:    model.CartItemRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         model.CartItem{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusForbidden:  problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/cart/items/:concert_id", Tag: "cart", Summary: "Remove the tickets of a concert from a cart",
+			Parameters: []openapi.Parameter{concertID, userID},
+			Responses: map[int]interface{}{
+				http.StatusOK:         MessageResponse{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/cart/checkout", Tag: "cart", Summary: "Book the items of a cart as one order",
+			Request: model.CheckoutRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    model.Order{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusForbidden:  problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+				http.StatusConflict:   problem.Details{},
+			},
+		},
+	}
+}
+
+// GetCart handles GET /api/v1/cart requests
+func (h *CartHandler) GetCart(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a query parameter
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	cart, err := h.cartService.GetCart(c.Request.Context(), userID)
+	if err != nil {
+		problem.Error(c, err, "Failed to get cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// PutItem handles PUT /api/v1/cart/items/:concert_id requests
+func (h *CartHandler) PutItem(c *gin.Context) {
+	concertID, err := strconv.ParseInt(c.Param("concert_id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use the one in the request
+	var req model.CartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid cart item", err)
+		return
+	}
+
+	item, err := h.cartService.AddItem(c.Request.Context(), concertID, &req)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		problem.Error(c, err, "Failed to put item in cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// RemoveItem handles DELETE /api/v1/cart/items/:concert_id requests
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	concertID, err := strconv.ParseInt(c.Param("concert_id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a query parameter
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	if err := h.cartService.RemoveItem(c.Request.Context(), userID, concertID); err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Cart item not found")
+			return
+		}
+		problem.Error(c, err, "Failed to remove item from cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Item removed from cart"})
+}
+
+// Checkout handles POST /api/v1/cart/checkout requests
+func (h *CartHandler) Checkout(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use the one in the request
+	var req model.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid checkout request", err)
+		return
+	}
+
+	// Errors about an item name its concert, so they are reported as they are
+	order, err := h.cartService.Checkout(c.Request.Context(), &req)
+	if err != nil {
+		problem.Error(c, err, "Failed to check out")
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
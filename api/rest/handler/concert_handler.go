@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"sort"
 	"strconv"
-	"time"
+	"strings"
 
+	"concert-ticket-api/api/rest/problem"
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/query"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,46 +33,211 @@ func NewConcertHandler(concertService service.ConcertService) *ConcertHandler {
 }
 
 // RegisterRoutes registers the routes for this handler
-func (h *ConcertHandler) RegisterRoutes(router *gin.Engine) {
-	concertGroup := router.Group("/api/v1/concerts")
+func (h *ConcertHandler) RegisterRoutes(router gin.IRouter) {
+	h.RegisterReadRoutes(router)
+
+	concertGroup := router.Group("/concerts")
 	{
-		concertGroup.GET("", h.ListConcerts)
-		concertGroup.GET("/:id", h.GetConcert)
 		concertGroup.POST("", h.CreateConcert)
 		concertGroup.PUT("/:id", h.UpdateConcert)
+		concertGroup.PATCH("/:id", h.PatchConcert)
+	}
+}
+
+// RegisterReadRoutes registers only the routes that read concerts
+func (h *ConcertHandler) RegisterReadRoutes(router gin.IRouter) {
+	concertGroup := router.Group("/concerts")
+	{
+		concertGroup.GET("", h.ListConcerts)
+		concertGroup.HEAD("", h.ListConcerts)
+		concertGroup.GET("/compare", h.CompareConcerts)
+		concertGroup.GET("/:id", h.GetConcert)
+		concertGroup.HEAD("/:id", h.GetConcert)
+		concertGroup.GET("/:id/price-history", h.GetPriceHistory)
+		concertGroup.GET("/:id/quote", h.GetQuote)
 	}
 }
 
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *ConcertHandler) Operations() []openapi.Operation {
+	id := openapi.PathParam("id", "integer", "Concert ID")
+	ifModifiedSince := openapi.HeaderParam("If-Modified-Since", "string", "Last-Modified of the copy held; 304 if it is current")
+	operations := []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/concerts", Tag: "concerts", Summary: "List concerts",
+			Parameters: []openapi.Parameter{
+				openapi.QueryParam("page", "integer", "Page number, starting at 1"),
+				openapi.QueryParam("pageSize", "integer", "Concerts per page, at most 100"),
+				openapi.QueryParam("cursor", "string", "nextCursor of the previous page, replaces page and pageSize"),
+				openapi.QueryParam("sort", "string", "Comma-separated sort fields, prefixed with - for descending order: concert_date, name, artist, price, available_tickets or created_at"),
+				openapi.QueryParam("artist", "string", "Artist name or alias"),
+				openapi.QueryParam("venue", "string", "Venue name or alias"),
+				openapi.QueryParam("name", "string", "Concert name"),
+				openapi.QueryParam("dateFrom", "string", "Earliest concert date, RFC 3339"),
+				openapi.QueryParam("dateTo", "string", "Latest concert date, RFC 3339"),
+				openapi.QueryParam("availableOnly", "boolean", "Only concerts with tickets left"),
+				openapi.QueryParam("exactCount", "boolean", "false estimates totalCount instead of counting it, for large listings; defaults to true"),
+				openapi.QueryParam("ids", "string", "Comma-separated IDs of up to 100 concerts to fetch instead of listing; other parameters are ignored"),
+				openapi.QueryParam("fields", "string", "Comma-separated concert fields to return, such as name,artist,price; id is always returned"),
+				ifModifiedSince,
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  ConcertListResponse{},
+				http.StatusNotModified:         nil,
+				http.StatusBadRequest:          problem.Details{},
+				http.StatusInternalServerError: problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/concerts/compare", Tag: "concerts", Summary: "Compare concerts side by side",
+			Parameters: []openapi.Parameter{openapi.QueryParam("ids", "string", "Comma-separated concert IDs")},
+			Responses:  map[int]interface{}{http.StatusOK: ConcertComparisonResponse{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/concerts/:id", Tag: "concerts", Summary: "Get a concert",
+			Parameters: []openapi.Parameter{
+				id,
+				openapi.HeaderParam("If-None-Match", "string", "ETag of the copy held; 304 if it is current"),
+				ifModifiedSince,
+			},
+			Responses: map[int]interface{}{http.StatusOK: model.Concert{}, http.StatusNotModified: nil, http.StatusNotFound: problem.Details{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/concerts/:id/price-history", Tag: "concerts", Summary: "Get the price history of a concert",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: PriceHistoryResponse{}, http.StatusNotFound: problem.Details{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/concerts/:id/quote", Tag: "concerts", Summary: "Quote the price of tickets bought now",
+			Parameters: []openapi.Parameter{id, openapi.QueryParam("ticketCount", "integer", "Number of tickets, 1 by default")},
+			Responses: map[int]interface{}{
+				http.StatusOK:         model.PriceQuote{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/concerts", Tag: "concerts", Summary: "Create a concert",
+			Request:   model.Concert{},
+			Responses: map[int]interface{}{http.StatusCreated: model.Concert{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/concerts/:id", Tag: "concerts", Summary: "Update a concert",
+			Parameters: []openapi.Parameter{id, openapi.HeaderParam("If-Match", "string", "ETag of the concert the update is based on")},
+			Request:    model.Concert{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                   model.Concert{},
+				http.StatusBadRequest:           problem.Details{},
+				http.StatusNotFound:             problem.Details{},
+				http.StatusPreconditionFailed:   problem.Details{},
+				http.StatusPreconditionRequired: problem.Details{},
+			},
+		},
+		{
+			Method: http.MethodPatch, Path: "/concerts/:id", Tag: "concerts", Summary: "Update some fields of a concert",
+			Parameters: []openapi.Parameter{id, openapi.HeaderParam("If-Match", "string", "ETag of the concert the update is based on")},
+			Request:    model.Concert{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                   model.Concert{},
+				http.StatusBadRequest:           problem.Details{},
+				http.StatusNotFound:             problem.Details{},
+				http.StatusPreconditionFailed:   problem.Details{},
+				http.StatusPreconditionRequired: problem.Details{},
+			},
+		},
+	}
+
+	for _, operation := range operations {
+		if operation.Method == http.MethodGet && (operation.Path == "/concerts" || operation.Path == "/concerts/:id") {
+			operations = append(operations, headOperation(operation))
+		}
+	}
+	return operations
+}
+
+// headOperation documents the HEAD route answering like a GET route without
+// the body
+func headOperation(get openapi.Operation) openapi.Operation {
+	head := get
+	head.Method = http.MethodHead
+	head.Summary = get.Summary + " (headers only)"
+	head.Responses = make(map[int]interface{}, len(get.Responses))
+	for status := range get.Responses {
+		head.Responses[status] = nil
+	}
+	return head
+}
+
 // GetConcert handles GET /api/v1/concerts/:id requests
 func (h *ConcertHandler) GetConcert(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid concert ID"})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
 		return
 	}
 
 	concert, err := h.concertService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, pkgErr.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Concert not found"})
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get concert"})
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get concert")
 		return
 	}
 
+	// Shared caches must not hand a private concert to callers without the invite
+	if concert.Visibility == model.VisibilityPrivate {
+		c.Header("Cache-Control", "no-store")
+	}
+
+	setVersionETag(c, concert.Version)
+
+	modified := concert.LastModified(clock.Now())
+	if present, match := ifNoneMatchVersion(c, concert.Version); present {
+		// The version stays the same when the door price takes over, so the
+		// tag only validates copies of concerts that haven't switched since
+		// their last update
+		if match && modified.Equal(concert.UpdatedAt) {
+			setLastModified(c, modified)
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if notModified(c, modified) {
+		return
+	}
+
+	setPriceDisplay(c, concert)
 	c.JSON(http.StatusOK, concert)
 }
 
 // ListConcerts handles GET /api/v1/concerts requests
 func (h *ConcertHandler) ListConcerts(c *gin.Context) {
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	fields, ok := parseFields(c, concertFields)
+	if !ok {
+		return
+	}
+
+	if _, ok := c.GetQuery("ids"); ok {
+		h.batchGetConcerts(c, fields)
+		return
+	}
+
+	page, err := query.ParsePage(c.Query("page"), c.Query("pageSize"), c.Query("cursor"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return
+	}
+
+	sorts, err := query.ParseSort(c.Query("sort"), model.ConcertSortFields)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return
+	}
 
 	// Parse filter parameters
-	filters := make(map[string]interface{})
+	filters := make(query.Filters)
 
 	if artist := c.Query("artist"); artist != "" {
 		filters["artist"] = artist
@@ -79,92 +251,315 @@ func (h *ConcertHandler) ListConcerts(c *gin.Context) {
 		filters["name"] = name
 	}
 
-	if dateFromStr := c.Query("dateFrom"); dateFromStr != "" {
-		dateFrom, err := time.Parse(time.RFC3339, dateFromStr)
-		if err == nil {
-			filters["date_from"] = dateFrom
+	for _, date := range []struct{ param, filter string }{{"dateFrom", "date_from"}, {"dateTo", "date_to"}} {
+		raw := c.Query(date.param)
+		if raw == "" {
+			continue
 		}
-	}
-
-	if dateToStr := c.Query("dateTo"); dateToStr != "" {
-		dateTo, err := time.Parse(time.RFC3339, dateToStr)
-		if err == nil {
-			filters["date_to"] = dateTo
+		t, err := query.ParseTime(date.param, raw)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+			return
 		}
+		filters[date.filter] = t
 	}
 
 	if availableOnly := c.Query("availableOnly"); availableOnly == "true" {
 		filters["available"] = true
 	}
 
-	concerts, totalCount, err := h.concertService.ListConcerts(c.Request.Context(), page, pageSize, filters)
+	// Every listing page changes whenever any concert does, so quiet periods
+	// are answered without listing
+	modified, err := h.concertService.ListingLastModified(c.Request.Context())
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list concerts")
+		return
+	}
+	if notModified(c, modified) {
+		return
+	}
+
+	opts := query.Options{Page: page, Sort: sorts, Filters: filters, EstimateCount: c.Query("exactCount") == "false"}
+	concerts, totalCount, err := h.concertService.ListConcerts(c.Request.Context(), opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list concerts"})
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list concerts")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": concerts,
-		"meta": gin.H{
-			"page":       page,
-			"pageSize":   pageSize,
-			"totalCount": totalCount,
-			"totalPages": (totalCount + pageSize - 1) / pageSize,
+	meta := page.Meta(totalCount)
+	meta.TotalCountEstimated = opts.EstimateCount
+	setPriceDisplay(c, concerts...)
+	writeList(c, ConcertListResponse{
+		Data: concerts,
+		Meta: meta,
+	}, fields, "id")
+}
+
+// batchGetConcerts handles GET /api/v1/concerts?ids=1,2,3 requests
+func (h *ConcertHandler) batchGetConcerts(c *gin.Context, fields query.Fields) {
+	ids, ok := parseConcertIDs(c)
+	if !ok {
+		return
+	}
+
+	concerts, missing, err := h.concertService.BatchGetConcerts(c.Request.Context(), ids)
+	if err != nil {
+		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get concerts")
+		return
+	}
+
+	// Shared caches must not hand a private concert to callers without the invite
+	for _, concert := range concerts {
+		if concert.Visibility == model.VisibilityPrivate {
+			c.Header("Cache-Control", "no-store")
+			break
+		}
+	}
+
+	setPriceDisplay(c, concerts...)
+	writeList(c, ConcertListResponse{
+		Data: concerts,
+		// The whole batch is one page
+		Meta: query.Meta{
+			Page:       1,
+			PageSize:   len(concerts),
+			TotalCount: len(concerts),
+			TotalPages: 1,
 		},
-	})
+		NotFound: missing,
+	}, fields, "id")
 }
 
 // CreateConcert handles POST /api/v1/concerts requests
 func (h *ConcertHandler) CreateConcert(c *gin.Context) {
 	var concert model.Concert
 	if err := c.ShouldBindJSON(&concert); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid concert data"})
+		problem.InvalidBody(c, "Invalid concert data", err)
 		return
 	}
 
 	createdConcert, err := h.concertService.CreateConcert(c.Request.Context(), &concert)
 	if err != nil {
 		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errWithMsg.Message()})
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create concert"})
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to create concert")
 		return
 	}
 
+	setPriceDisplay(c, createdConcert)
 	c.JSON(http.StatusCreated, createdConcert)
 }
 
-// UpdateConcert handles PUT /api/v1/concerts/:id requests
+// UpdateConcert handles PUT /api/v1/concerts/:id requests, which replace
+// every field clients may update
 func (h *ConcertHandler) UpdateConcert(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	patch := &model.ConcertPatch{Fields: model.ConcertUpdateFields}
+	if err := c.ShouldBindJSON(&patch.Values); err != nil {
+		problem.InvalidBody(c, "Invalid concert data", err)
+		return
+	}
+
+	h.patchConcert(c, patch)
+}
+
+// PatchConcert handles PATCH /api/v1/concerts/:id requests, a JSON merge
+// patch updating only the fields present in the body. A field set to null
+// is cleared.
+func (h *ConcertHandler) PatchConcert(c *gin.Context) {
+	var fields map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&fields); err != nil {
+		problem.InvalidBody(c, "Invalid concert data", err)
+		return
+	}
+
+	patch := &model.ConcertPatch{}
+	for field := range fields {
+		patch.Fields = append(patch.Fields, field)
+	}
+	sort.Strings(patch.Fields)
+
+	// Fields set to null decode to their zero value
+	body, _ := json.Marshal(fields)
+	if err := json.Unmarshal(body, &patch.Values); err != nil {
+		problem.InvalidBody(c, "Invalid concert data", err)
+		return
+	}
+
+	h.patchConcert(c, patch)
+}
+
+// patchConcert applies a patch to the concert of the request
+func (h *ConcertHandler) patchConcert(c *gin.Context, patch *model.ConcertPatch) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid concert ID"})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
 		return
 	}
 
-	var concert model.Concert
-	if err := c.ShouldBindJSON(&concert); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid concert data"})
+	// The version the update is based on comes from If-Match, not the body
+	version, present, ok := ifMatchVersion(c)
+	if !present {
+		problem.Write(c, http.StatusPreconditionRequired, problem.CodePreconditionRequired, "If-Match header with the concert ETag is required")
+		return
+	}
+	if !ok {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid If-Match header")
 		return
 	}
 
-	concert.ID = id
+	// Concert writes aren't authenticated on the REST API unless they come
+	// through admin auth
+	actor := c.GetString("adminActor")
+	if actor == "" {
+		actor = "anonymous"
+	}
 
-	err = h.concertService.UpdateConcert(c.Request.Context(), &concert)
+	concert, _, err := h.concertService.PatchConcert(c.Request.Context(), actor, id, version, patch)
 	if err != nil {
+		if concert != nil {
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Concert updated but the audit log entry failed")
+			return
+		}
 		if errors.Is(err, pkgErr.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Concert not found"})
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		if errors.Is(err, pkgErr.ErrOptimisticLockFailed) {
+			problem.Write(c, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "Concert was modified; fetch it again and retry")
 			return
 		}
 		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errWithMsg.Message()})
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update concert"})
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to update concert")
 		return
 	}
 
+	setVersionETag(c, concert.Version)
+	setPriceDisplay(c, concert)
 	c.JSON(http.StatusOK, concert)
 }
+
+// GetPriceHistory handles GET /api/v1/concerts/:id/price-history requests
+func (h *ConcertHandler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	history, err := h.concertService.GetPriceHistory(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get price history")
+		return
+	}
+
+	locale := money.ResolveLocale(c.GetHeader("Accept-Language"))
+	for _, snapshot := range history {
+		snapshot.PriceDisplay = money.Format(snapshot.Price, snapshot.Currency, locale)
+	}
+
+	c.JSON(http.StatusOK, PriceHistoryResponse{ConcertID: id, Data: history})
+}
+
+// GetQuote handles GET /api/v1/concerts/:id/quote?ticketCount=2 requests
+func (h *ConcertHandler) GetQuote(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	ticketCount, err := strconv.Atoi(c.DefaultQuery("ticketCount", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid ticket count")
+		return
+	}
+
+	quote, err := h.concertService.Quote(c.Request.Context(), id, ticketCount)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to quote tickets")
+		return
+	}
+
+	quote.TotalDisplay = money.Format(quote.Total, quote.Currency, money.ResolveLocale(c.GetHeader("Accept-Language")))
+	c.JSON(http.StatusOK, quote)
+}
+
+// CompareConcerts handles GET /api/v1/concerts/compare?ids=1,2,3 requests
+func (h *ConcertHandler) CompareConcerts(c *gin.Context) {
+	ids, ok := parseConcertIDs(c)
+	if !ok {
+		return
+	}
+
+	concerts, missing, err := h.concertService.CompareConcerts(c.Request.Context(), ids)
+	if err != nil {
+		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to compare concerts")
+		return
+	}
+
+	setPriceDisplay(c, concerts...)
+	comparisons := make([]*model.ConcertComparison, 0, len(concerts))
+	for _, concert := range concerts {
+		comparisons = append(comparisons, model.NewConcertComparison(concert))
+	}
+
+	if missing == nil {
+		missing = []int64{}
+	}
+
+	c.JSON(http.StatusOK, ConcertComparisonResponse{Data: comparisons, NotFound: missing})
+}
+
+// parseConcertIDs parses the comma-separated concert IDs in the ids query
+// parameter. It writes the error response and returns false for invalid IDs.
+func parseConcertIDs(c *gin.Context) ([]int64, bool) {
+	var ids []int64
+	for _, part := range strings.Split(c.Query("ids"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// setPriceDisplay formats concert prices for the locale in the Accept-Language
+// header and sets the price that applies now
+func setPriceDisplay(c *gin.Context, concerts ...*model.Concert) {
+	locale := money.ResolveLocale(c.GetHeader("Accept-Language"))
+	for _, concert := range concerts {
+		concert.SetPricing()
+		concert.PriceDisplay = money.Format(concert.Price, concert.Currency, locale)
+	}
+}
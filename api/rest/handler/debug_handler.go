@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rtpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugHandler handles the HTTP requests of the debug listener: the pprof
+// profiles, GC stats and goroutine dumps
+type DebugHandler struct{}
+
+// NewDebugHandler creates a new DebugHandler
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *DebugHandler) RegisterRoutes(router gin.IRouter, auth gin.HandlerFunc) {
+	debugGroup := router.Group("/debug", auth)
+	{
+		debugGroup.GET("/pprof/*profile", h.Profile)
+		debugGroup.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+		debugGroup.GET("/gc", h.GCStats)
+		debugGroup.GET("/goroutines", h.Goroutines)
+	}
+}
+
+// Profile handles GET /debug/pprof/* requests with the handlers of
+// net/http/pprof, so `go tool pprof` works against the listener
+func (h *DebugHandler) Profile(c *gin.Context) {
+	switch name := strings.TrimPrefix(c.Param("profile"), "/"); name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		if rtpprof.Lookup(name) == nil {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Unknown profile "+name)
+			return
+		}
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// GCStats is the state of the garbage collector and the heap
+type GCStats struct {
+	NumGC         int64           `json:"num_gc"`
+	LastGC        time.Time       `json:"last_gc"`
+	PauseTotal    time.Duration   `json:"pause_total_ns"`
+	RecentPauses  []time.Duration `json:"recent_pauses_ns"`
+	GCCPUFraction float64         `json:"gc_cpu_fraction"`
+	HeapAlloc     uint64          `json:"heap_alloc_bytes"`
+	HeapInuse     uint64          `json:"heap_inuse_bytes"`
+	HeapObjects   uint64          `json:"heap_objects"`
+	NextGC        uint64          `json:"next_gc_bytes"`
+	Sys           uint64          `json:"sys_bytes"`
+	Goroutines    int             `json:"goroutines"`
+	GOMAXPROCS    int             `json:"gomaxprocs"`
+}
+
+// recentPauses is how many of the last GC pauses GCStats lists
+const recentPauses = 16
+
+// GCStats handles GET /debug/gc requests
+func (h *DebugHandler) GCStats(c *gin.Context) {
+	var gc debug.GCStats
+	gc.Pause = make([]time.Duration, recentPauses)
+	debug.ReadGCStats(&gc)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, GCStats{
+		NumGC:         gc.NumGC,
+		LastGC:        gc.LastGC,
+		PauseTotal:    gc.PauseTotal,
+		RecentPauses:  gc.Pause,
+		GCCPUFraction: mem.GCCPUFraction,
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		NextGC:        mem.NextGC,
+		Sys:           mem.Sys,
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+	})
+}
+
+// Goroutines handles GET /debug/goroutines requests with the stack of every
+// goroutine, in the format of an unrecovered panic
+func (h *DebugHandler) Goroutines(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = rtpprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
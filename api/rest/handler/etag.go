@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/pkg/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag is the entity tag of a versioned resource. The version changes
+// with every update, so it identifies the state clients base updates on.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setVersionETag sets the ETag header of a versioned resource
+func setVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", versionETag(version))
+}
+
+// ifMatchVersion parses the If-Match header of a request for a versioned
+// resource. present is false without the header; ok is false unless it holds
+// exactly one entity tag produced by versionETag. "*" isn't accepted because
+// it would skip the version check the header exists for.
+func ifMatchVersion(c *gin.Context) (version int, present, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, false, false
+	}
+
+	// Intermediaries may weaken entity tags; the version is still exact
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, true, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		return 0, true, false
+	}
+
+	return version, true, true
+}
+
+// ifNoneMatchVersion reports whether the request has an If-None-Match header
+// and whether it lists the entity tag of version, or is "*"
+func ifNoneMatchVersion(c *gin.Context, version int) (present, match bool) {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false, false
+	}
+	if header == "*" {
+		return true, true
+	}
+
+	// If-None-Match uses the weak comparison
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// setLastModified sets the Last-Modified header and returns the date it was
+// set to. Last-Modified has whole seconds, so a change later in the current
+// second would carry the same date; the header is only set once that second
+// has passed, and ok is false until then.
+func setLastModified(c *gin.Context, modified time.Time) (date time.Time, ok bool) {
+	if modified.IsZero() {
+		return time.Time{}, false
+	}
+
+	date = modified.UTC().Truncate(time.Second)
+	if !date.Before(clock.Now().UTC().Truncate(time.Second)) {
+		return time.Time{}, false
+	}
+
+	c.Header("Last-Modified", date.Format(http.TimeFormat))
+	return date, true
+}
+
+// notModified sets Last-Modified and answers 304 Not Modified when the
+// client's copy, validated by If-Modified-Since, is current. It reports
+// whether it answered. Requests with If-None-Match are left to the entity
+// tag check, which takes precedence (RFC 9110 section 13.2.2).
+func notModified(c *gin.Context, modified time.Time) bool {
+	date, ok := setLastModified(c, modified)
+	if !ok || c.GetHeader("If-None-Match") != "" {
+		return false
+	}
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || date.After(since) {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
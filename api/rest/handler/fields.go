@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The fields listings can be narrowed to with ?fields=
+var (
+	concertFields = query.FieldNames(model.Concert{})
+	bookingFields = query.FieldNames(model.Booking{})
+)
+
+// parseFields parses the fields parameter of a listing, writing a problem if
+// it names an unknown field
+func parseFields(c *gin.Context, allowed []string) (query.Fields, bool) {
+	fields, err := query.ParseFields(c.Query("fields"), allowed)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeList writes a listing whose data items keep only the requested
+// fields and the identifying field keep. Without a fieldset the response is
+// written as is.
+func writeList(c *gin.Context, response interface{}, fields query.Fields, keep string) {
+	if len(fields) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	encoded, err := json.Marshal(response)
+	if err == nil {
+		err = json.Unmarshal(encoded, &body)
+	}
+	if err == nil {
+		body["data"], err = fields.Select(body["data"], keep)
+	}
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to select fields")
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
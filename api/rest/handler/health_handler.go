@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"concert-ticket-api/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler handles the health checks of probes and load balancers
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+// NewHealthHandler creates a new HealthHandler reporting the components in
+// registry
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *HealthHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/health", h.Health)
+	router.GET("/health/live", h.Live)
+	router.GET("/health/ready", h.Ready)
+}
+
+// Health handles GET /health requests: always 200 OK, degraded while any
+// component is unhealthy
+func (h *HealthHandler) Health(c *gin.Context) {
+	status := "up"
+	if !h.registry.AllHealthy() {
+		status = "degraded"
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status, "components": h.registry.Statuses()})
+}
+
+// Live handles GET /health/live requests. Answering is the check: a replica
+// whose database is down is still alive, and restarting it wouldn't help.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "up"})
+}
+
+// Ready handles GET /health/ready requests: 200 OK while every required
+// component passed its last check, 503 Service Unavailable otherwise or once
+// the service is shutting down. The checks run in the background, so probes
+// never wait on a dependency.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	status, code := "ready", http.StatusOK
+	switch {
+	case h.registry.Draining():
+		status, code = "draining", http.StatusServiceUnavailable
+	case !h.registry.Ready():
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "components": h.registry.Statuses()})
+}
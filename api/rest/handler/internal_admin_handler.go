@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InternalAdminHandler handles the dangerous operations served on the
+// internal admin listener only
+type InternalAdminHandler struct {
+	ops    service.AdminOpsService
+	logger logger.Logger
+}
+
+// NewInternalAdminHandler creates a new InternalAdminHandler
+func NewInternalAdminHandler(ops service.AdminOpsService, logger logger.Logger) *InternalAdminHandler {
+	return &InternalAdminHandler{
+		ops:    ops,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the internal admin middleware
+func (h *InternalAdminHandler) RegisterRoutes(router gin.IRouter, auth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", auth)
+	{
+		adminGroup.POST("/bookings/:reference/force-cancel", h.ForceCancelBooking)
+		adminGroup.POST("/concerts/:id/inventory-adjustments", h.AdjustInventory)
+		adminGroup.GET("/inventory-check", h.CheckInventory)
+		adminGroup.GET("/audit-logs", h.ListAuditLogs)
+	}
+}
+
+// ForceCancelBooking handles POST /admin/bookings/:reference/force-cancel
+// requests. The booking is cancelled whoever made it and its tickets are
+// returned to the concert.
+func (h *InternalAdminHandler) ForceCancelBooking(c *gin.Context) {
+	var req model.ForceCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid request", err)
+		return
+	}
+
+	actor := c.GetString("adminActor")
+	booking, err := h.ops.ForceCancelBooking(c.Request.Context(), actor, c.Param("reference"), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrInvalidInput("")):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeBookingNotFound, "Booking not found")
+		case errors.Is(err, pkgErr.ErrBookingAlreadyCancelled):
+			problem.Write(c, http.StatusBadRequest, problem.CodeBookingAlreadyCancelled, "Booking is already cancelled")
+		case booking != nil:
+			h.logger.Error("Booking %s was force-cancelled by %s but not audited: %v", booking.Reference, actor, err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Booking cancelled, but the audit log entry failed")
+		default:
+			h.logger.Error("Failed to force-cancel booking %s: %v", c.Param("reference"), err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to cancel booking")
+		}
+		return
+	}
+
+	h.logger.Warn("Booking %s force-cancelled by %s: %s", booking.Reference, actor, req.Reason)
+	c.JSON(http.StatusOK, booking)
+}
+
+// AdjustInventory handles POST /admin/concerts/:id/inventory-adjustments
+// requests, which add tickets to or withdraw unsold tickets from a concert
+func (h *InternalAdminHandler) AdjustInventory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	var req model.InventoryAdjustment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid request", err)
+		return
+	}
+
+	actor := c.GetString("adminActor")
+	availability, err := h.ops.AdjustInventory(c.Request.Context(), actor, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrInvalidInput("")):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+		case errors.Is(err, pkgErr.ErrInsufficientTickets):
+			problem.Write(c, http.StatusConflict, problem.CodeInsufficientTickets, "Fewer unsold tickets than the adjustment withdraws")
+		case availability != nil:
+			h.logger.Error("Inventory of concert %d was adjusted by %s but not audited: %v", id, actor, err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Inventory adjusted, but the audit log entry failed")
+		default:
+			h.logger.Error("Failed to adjust inventory of concert %d: %v", id, err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to adjust inventory")
+		}
+		return
+	}
+
+	h.logger.Warn("Inventory of concert %d adjusted by %+d by %s: %s", id, req.Delta, actor, req.Reason)
+	c.JSON(http.StatusOK, availability)
+}
+
+// CheckInventory handles GET /admin/inventory-check requests, which report
+// the concerts whose available tickets don't add up, or whether the one of
+// ?concert_id= does
+func (h *InternalAdminHandler) CheckInventory(c *gin.Context) {
+	var concertID int64
+	if raw := c.Query("concert_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+			return
+		}
+		concertID = id
+	}
+
+	check, err := h.ops.CheckInventory(c.Request.Context(), concertID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+		default:
+			h.logger.Error("Failed to check inventory: %v", err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to check inventory")
+		}
+		return
+	}
+
+	if len(check.Discrepancies) > 0 {
+		h.logger.Warn("Inventory check found %d of %d concerts whose tickets don't add up", len(check.Discrepancies), check.Checked)
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// ListAuditLogs handles GET /admin/audit-logs requests, latest entries first
+func (h *InternalAdminHandler) ListAuditLogs(c *gin.Context) {
+	page, err := query.ParsePage(c.Query("page"), c.Query("pageSize"), c.Query("cursor"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return
+	}
+
+	filter := model.AuditFilter{
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+	}
+	entries, total, err := h.ops.ListAuditLogs(c.Request.Context(), filter, page)
+	if err != nil {
+		h.logger.Error("Failed to list audit logs: %v", err)
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list audit logs")
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditLogListResponse{Data: entries, Meta: page.Meta(total)})
+}
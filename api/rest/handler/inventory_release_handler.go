@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryReleaseHandler handles HTTP requests related to scheduled inventory releases
+type InventoryReleaseHandler struct {
+	releaseService service.InventoryReleaseService
+}
+
+// NewInventoryReleaseHandler creates a new InventoryReleaseHandler
+func NewInventoryReleaseHandler(releaseService service.InventoryReleaseService) *InventoryReleaseHandler {
+	return &InventoryReleaseHandler{
+		releaseService: releaseService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler. Anyone can see the
+// release schedule; changing it requires the admin token.
+func (h *InventoryReleaseHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	h.RegisterReadRoutes(router)
+
+	adminGroup := router.Group("/admin/concerts/:id/inventory-releases", adminAuth)
+	{
+		adminGroup.POST("", h.ScheduleRelease)
+		adminGroup.DELETE("/:releaseId", h.CancelRelease)
+	}
+}
+
+// RegisterReadRoutes registers only the public release schedule
+func (h *InventoryReleaseHandler) RegisterReadRoutes(router gin.IRouter) {
+	router.GET("/concerts/:id/inventory-releases", h.ListReleases)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *InventoryReleaseHandler) Operations() []openapi.Operation {
+	id := openapi.PathParam("id", "integer", "Concert ID")
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/concerts/:id/inventory-releases", Tag: "concerts", Summary: "List the inventory releases of a concert",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: InventoryReleaseListResponse{}, http.StatusNotFound: problem.Details{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/concerts/:id/inventory-releases", Tag: "admin", Summary: "Schedule an inventory release",
+			Parameters: []openapi.Parameter{id},
+			Request:    model.InventoryReleaseRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    model.InventoryRelease{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+				http.StatusConflict:   problem.Details{},
+			},
+			Admin: true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/concerts/:id/inventory-releases/:releaseId", Tag: "admin", Summary: "Cancel a pending inventory release",
+			Parameters: []openapi.Parameter{id, openapi.PathParam("releaseId", "integer", "Inventory release ID")},
+			Responses:  map[int]interface{}{http.StatusOK: MessageResponse{}, http.StatusNotFound: problem.Details{}},
+			Admin:      true,
+		},
+	}
+}
+
+// ListReleases handles GET /api/v1/concerts/:id/inventory-releases requests
+func (h *InventoryReleaseHandler) ListReleases(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	releases, err := h.releaseService.ListReleases(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list inventory releases")
+		return
+	}
+
+	c.JSON(http.StatusOK, InventoryReleaseListResponse{ConcertID: id, Data: releases})
+}
+
+// ScheduleRelease handles POST /api/v1/admin/concerts/:id/inventory-releases requests
+func (h *InventoryReleaseHandler) ScheduleRelease(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	var req model.InventoryReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid inventory release", err)
+		return
+	}
+
+	release, err := h.releaseService.Schedule(c.Request.Context(), id, &req)
+	if err != nil {
+		var errWithMsg *pkgErr.ErrorWithMessage
+		switch {
+		case errors.As(err, &errWithMsg):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+		case errors.Is(err, pkgErr.ErrInsufficientTickets):
+			problem.Write(c, http.StatusConflict, problem.CodeInsufficientTickets, "Not enough available tickets to hold back")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to schedule inventory release")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, release)
+}
+
+// CancelRelease handles DELETE /api/v1/admin/concerts/:id/inventory-releases/:releaseId requests
+func (h *InventoryReleaseHandler) CancelRelease(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	releaseID, err := strconv.ParseInt(c.Param("releaseId"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid inventory release ID")
+		return
+	}
+
+	if err := h.releaseService.Cancel(c.Request.Context(), id, releaseID); err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Pending inventory release not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to cancel inventory release")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Inventory release cancelled, its tickets are on sale"})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteHandler handles HTTP requests related to the invites of private concerts
+type InviteHandler struct {
+	inviteService service.InviteService
+}
+
+// NewInviteHandler creates a new InviteHandler
+func NewInviteHandler(inviteService service.InviteService) *InviteHandler {
+	return &InviteHandler{
+		inviteService: inviteService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the admin middleware
+func (h *InviteHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin/concerts/:id/invites", adminAuth)
+	{
+		adminGroup.POST("", h.GenerateInvites)
+		adminGroup.GET("/export", h.ExportInvites)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *InviteHandler) Operations() []openapi.Operation {
+	id := openapi.PathParam("id", "integer", "Concert ID")
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/admin/concerts/:id/invites", Tag: "admin", Summary: "Generate invites for a private concert",
+			Parameters: []openapi.Parameter{id},
+			Request:    model.InviteRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    InviteListResponse{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+			Admin: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/concerts/:id/invites/export", Tag: "admin", Summary: "Export the invites of a concert and the bookings made with them",
+			Parameters: []openapi.Parameter{id, openapi.QueryParam("format", "string", "csv to download the export as CSV")},
+			Responses:  map[int]interface{}{http.StatusOK: model.InviteExport{}, http.StatusNotFound: problem.Details{}},
+			Admin:      true,
+		},
+	}
+}
+
+// GenerateInvites handles POST /api/v1/admin/concerts/:id/invites requests
+func (h *InviteHandler) GenerateInvites(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	var req model.InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid invite request", err)
+		return
+	}
+
+	invites, err := h.inviteService.GenerateInvites(c.Request.Context(), id, &req)
+	if err != nil {
+		var errWithMsg *pkgErr.ErrorWithMessage
+		switch {
+		case errors.As(err, &errWithMsg):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to generate invites")
+		}
+		return
+	}
+
+	// The tokens are only ever shown in this response
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, InviteListResponse{ConcertID: id, Data: invites})
+}
+
+// ExportInvites handles GET /api/v1/admin/concerts/:id/invites/export requests.
+// The export is returned as JSON, or as a CSV download with ?format=csv.
+func (h *InviteHandler) ExportInvites(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	export, err := h.inviteService.ExportInvites(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to export invites")
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=invites-%d.csv", id))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(export.CSV))
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationPreferenceHandler handles HTTP requests related to the
+// notification preferences of users
+type NotificationPreferenceHandler struct {
+	preferenceService service.NotificationPreferenceService
+}
+
+// NewNotificationPreferenceHandler creates a new NotificationPreferenceHandler
+func NewNotificationPreferenceHandler(preferenceService service.NotificationPreferenceService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		preferenceService: preferenceService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *NotificationPreferenceHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/notification-preferences", h.GetPreferences)
+	router.PUT("/notification-preferences", h.UpdatePreferences)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *NotificationPreferenceHandler) Operations() []openapi.Operation {
+	userID := openapi.Parameter{Name: "userID", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/notification-preferences", Tag: "notifications", Summary: "Get the notification preferences of a user",
+			Parameters: []openapi.Parameter{userID},
+			Responses:  map[int]interface{}{http.StatusOK: model.NotificationPreferences{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/notification-preferences", Tag: "notifications", Summary: "Change the notification preferences of a user",
+			Parameters: []openapi.Parameter{userID},
+			Request:    model.NotificationPreferencesRequest{},
+			Responses:  map[int]interface{}{http.StatusOK: model.NotificationPreferences{}, http.StatusBadRequest: problem.Details{}},
+		},
+	}
+}
+
+// GetPreferences handles GET /api/v1/notification-preferences requests
+func (h *NotificationPreferenceHandler) GetPreferences(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a query parameter
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	preferences, err := h.preferenceService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		problem.Error(c, err, "Failed to get notification preferences")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences handles PUT /api/v1/notification-preferences requests
+func (h *NotificationPreferenceHandler) UpdatePreferences(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a query parameter
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	var req model.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid notification preferences", err)
+		return
+	}
+
+	preferences, err := h.preferenceService.UpdatePreferences(c.Request.Context(), userID, &req)
+	if err != nil {
+		problem.Error(c, err, "Failed to update notification preferences")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIVersion pins the Swagger UI release loaded from the CDN
+const swaggerUIVersion = "5.17.14"
+
+const swaggerUICDN = "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion
+
+// docsPolicy replaces the default Content-Security-Policy on the Swagger UI
+// pages, which load their assets from the CDN
+const docsPolicy = "default-src 'none'; script-src 'self' https://unpkg.com; style-src https://unpkg.com; " +
+	"img-src data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'"
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Concert Ticket API</title>
+<link rel="stylesheet" href="` + swaggerUICDN + `/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="` + swaggerUICDN + `/swagger-ui-bundle.js"></script>
+<script src="/docs/init.js"></script>
+</body>
+</html>
+`
+
+// The init script is served separately so the page needs no inline script
+const swaggerUIInit = `window.ui = SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});
+`
+
+// OpenAPIHandler serves the OpenAPI document and the Swagger UI
+type OpenAPIHandler struct {
+	document []byte
+}
+
+// NewOpenAPIHandler creates a new OpenAPIHandler serving the given document
+func NewOpenAPIHandler(document *openapi.Document) (*OpenAPIHandler, error) {
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAPIHandler{
+		document: encoded,
+	}, nil
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *OpenAPIHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/api/v1/openapi.json", h.GetDocument)
+	router.GET("/docs", h.GetSwaggerUI)
+	router.GET("/docs/init.js", h.GetSwaggerUIInit)
+}
+
+// GetDocument handles GET /api/v1/openapi.json requests
+func (h *OpenAPIHandler) GetDocument(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.document)
+}
+
+// GetSwaggerUI handles GET /docs requests
+func (h *OpenAPIHandler) GetSwaggerUI(c *gin.Context) {
+	c.Header("Content-Security-Policy", docsPolicy)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// GetSwaggerUIInit handles GET /docs/init.js requests
+func (h *OpenAPIHandler) GetSwaggerUIInit(c *gin.Context) {
+	c.Header("Content-Security-Policy", docsPolicy)
+	c.Data(http.StatusOK, "text/javascript; charset=utf-8", []byte(swaggerUIInit))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderHandler handles HTTP requests related to orders
+type OrderHandler struct {
+	orderService service.OrderService
+}
+
+// NewOrderHandler creates a new OrderHandler
+func NewOrderHandler(orderService service.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *OrderHandler) RegisterRoutes(router gin.IRouter) {
+	orderGroup := router.Group("/orders")
+	{
+		orderGroup.GET("", h.GetUserOrders)
+		orderGroup.GET("/:reference", h.GetOrder)
+		orderGroup.POST("/:reference/cancel", h.CancelOrder)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *OrderHandler) Operations() []openapi.Operation {
+	ref := openapi.PathParam("reference", "string", "Order reference")
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/orders", Tag: "orders", Summary: "List the orders of a user",
+			Parameters: []openapi.Parameter{
+				{Name: "userID", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				openapi.QueryParam("page", "integer", "Page number, starting at 1"),
+				openapi.QueryParam("pageSize", "integer", "Orders per page, at most 100"),
+				openapi.QueryParam("cursor", "string", "nextCursor of the previous page, replaces page and pageSize"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: OrderListResponse{}, http.StatusBadRequest: problem.Details{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/orders/:reference", Tag: "orders", Summary: "Get an order with its bookings",
+			Parameters: []openapi.Parameter{ref},
+			Responses:  map[int]interface{}{http.StatusOK: model.Order{}, http.StatusNotFound: problem.Details{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/orders/:reference/cancel", Tag: "orders", Summary: "Cancel an order and its bookings",
+			Parameters: []openapi.Parameter{ref},
+			Request:    CancelOrderRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         model.Order{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusForbidden:  problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
+	}
+}
+
+// GetOrder handles GET /api/v1/orders/:reference requests
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	order, err := h.orderService.GetOrderByReference(c.Request.Context(), c.Param("reference"))
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeOrderNotFound, "Order not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get order")
+		return
+	}
+
+	// In a real app, check if the order belongs to the authenticated user
+	// For this exercise, we'll skip this check, like for bookings
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetUserOrders handles GET /api/v1/orders requests
+func (h *OrderHandler) GetUserOrders(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a query parameter
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	page, err := query.ParsePage(c.Query("page"), c.Query("pageSize"), c.Query("cursor"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return
+	}
+
+	orders, err := h.orderService.GetUserOrders(c.Request.Context(), userID, page)
+	if err != nil {
+		problem.Error(c, err, "Failed to get user orders")
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderListResponse{
+		Data: orders,
+		Meta: BookingListMeta{
+			Page:       page.Number,
+			PageSize:   page.Size,
+			NextCursor: page.NextCursor(len(orders)),
+		},
+	})
+}
+
+// CancelOrder handles POST /api/v1/orders/:reference/cancel requests
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	// For this exercise, we'll use a JSON request body
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid request", err)
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(c.Request.Context(), c.Param("reference"), req.UserID)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeOrderNotFound, "Order not found")
+			return
+		}
+		problem.Error(c, err, "Failed to cancel order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
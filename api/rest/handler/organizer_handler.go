@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits of the number of top concerts on the dashboard
+const (
+	defaultTopConcerts = 5
+	maxTopConcerts     = 50
+)
+
+// OrganizerHandler handles HTTP requests of organizers about their own concerts
+type OrganizerHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+// NewOrganizerHandler creates a new OrganizerHandler
+func NewOrganizerHandler(analyticsService service.AnalyticsService) *OrganizerHandler {
+	return &OrganizerHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the organizer middleware
+func (h *OrganizerHandler) RegisterRoutes(router gin.IRouter, organizerAuth gin.HandlerFunc) {
+	organizerGroup := router.Group("/organizers/me", organizerAuth)
+	{
+		organizerGroup.GET("/dashboard", h.GetDashboard)
+	}
+	router.GET("/concerts/:id/sales-timeseries", organizerAuth, h.GetSalesTimeseries)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *OrganizerHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/organizers/me/dashboard", Tag: "organizers", Summary: "Summarize the sales of the organizer's concerts",
+			Parameters: []openapi.Parameter{
+				openapi.QueryParam("tz", "string", "IANA time zone the day of the sales today starts in, default UTC"),
+				openapi.QueryParam("top", "integer", "Number of top-selling concerts, default 5, at most 50"),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  model.OrganizerDashboard{},
+				http.StatusBadRequest:          problem.Details{},
+				http.StatusInternalServerError: problem.Details{},
+			},
+			Organizer: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/concerts/:id/sales-timeseries", Tag: "organizers", Summary: "Bucket the sales of one of the organizer's concerts by hour or day",
+			Parameters: []openapi.Parameter{
+				openapi.PathParam("id", "integer", "Concert ID"),
+				openapi.QueryParam("interval", "string", "hour or day, default hour"),
+				openapi.QueryParam("from", "string", "Start of the range as RFC 3339, default a day or 30 days before to"),
+				openapi.QueryParam("to", "string", "End of the range as RFC 3339, default now"),
+				openapi.QueryParam("tz", "string", "IANA time zone the buckets start in, default UTC"),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  model.SalesTimeseries{},
+				http.StatusBadRequest:          problem.Details{},
+				http.StatusNotFound:            problem.Details{},
+				http.StatusInternalServerError: problem.Details{},
+			},
+			Organizer: true,
+		},
+	}
+}
+
+// GetDashboard handles GET /api/v1/organizers/me/dashboard requests
+func (h *OrganizerHandler) GetDashboard(c *gin.Context) {
+	location, ok := timeZone(c)
+	if !ok {
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(defaultTopConcerts)))
+	if err != nil || top < 1 || top > maxTopConcerts {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid top, must be between 1 and 50")
+		return
+	}
+
+	dashboard, err := h.analyticsService.OrganizerDashboard(c.Request.Context(), c.GetString("organizerEmail"), location, top)
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to build the dashboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// GetSalesTimeseries handles GET /api/v1/concerts/:id/sales-timeseries requests
+func (h *OrganizerHandler) GetSalesTimeseries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid concert ID")
+		return
+	}
+
+	location, ok := timeZone(c)
+	if !ok {
+		return
+	}
+
+	query := model.SalesTimeseriesQuery{Interval: c.DefaultQuery("interval", model.SalesIntervalHour), Location: location}
+	for _, bound := range []struct {
+		name string
+		time *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if value := c.Query(bound.name); value != "" {
+			if *bound.time, err = time.Parse(time.RFC3339, value); err != nil {
+				problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid "+bound.name+", must be RFC 3339")
+				return
+			}
+		}
+	}
+
+	timeseries, err := h.analyticsService.ConcertSalesTimeseries(c.Request.Context(), c.GetString("organizerEmail"), id, query)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeConcertNotFound, "Concert not found")
+			return
+		}
+		if errWithMsg, ok := err.(*pkgErr.ErrorWithMessage); ok {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get the sales timeseries")
+		return
+	}
+
+	c.JSON(http.StatusOK, timeseries)
+}
+
+// timeZone reads the tz query parameter, responding with 400 Bad Request
+// for time zones that aren't IANA names. The server's Local zone isn't one
+// the database knows.
+func timeZone(c *gin.Context) (*time.Location, bool) {
+	name := c.DefaultQuery("tz", "UTC")
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid time zone")
+		return nil, false
+	}
+	return location, true
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/payments"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPaymentWebhookBytes bounds the notifications of payment providers,
+// which are a few kilobytes
+const maxPaymentWebhookBytes = 1 << 20
+
+// PaymentHandler handles the notifications of the payment provider
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+// NewPaymentHandler creates a new PaymentHandler
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// webhookPath is the route the provider sends its notifications to
+func (h *PaymentHandler) webhookPath() string {
+	return "/payments/" + h.paymentService.Provider() + "/webhook"
+}
+
+// RegisterRoutes registers the routes for this handler. The notifications
+// are authenticated by their signature, not by a token.
+func (h *PaymentHandler) RegisterRoutes(router gin.IRouter) {
+	router.POST(h.webhookPath(), h.HandleWebhook)
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *PaymentHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: h.webhookPath(), Tag: "payments",
+			Summary: "Receive a signed notification of the payment provider",
+			Responses: map[int]interface{}{
+				http.StatusOK:         MessageResponse{},
+				http.StatusBadRequest: problem.Details{},
+			},
+		},
+	}
+}
+
+// HandleWebhook handles POST /api/v1/payments/:provider/webhook requests.
+// Errors other than a bad signature answer 500, so the provider retries.
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPaymentWebhookBytes))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Failed to read notification")
+		return
+	}
+
+	if err := h.paymentService.HandleWebhook(c.Request.Context(), payload, c.Request.Header); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid signature")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to handle notification")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Notification received"})
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
+)
+
+// MessageResponse is the body of responses that only confirm an action
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ConcertListResponse is the body of GET /api/v1/concerts responses
+type ConcertListResponse struct {
+	Data []*model.Concert `json:"data"`
+	Meta query.Meta       `json:"meta"`
+	// NotFound lists the requested IDs without a concert when fetching by ?ids=
+	NotFound []int64 `json:"not_found,omitempty"`
+}
+
+// BookingListResponse is the body of GET /api/v1/bookings responses
+type BookingListResponse struct {
+	Data []*model.Booking `json:"data"`
+	Meta BookingListMeta  `json:"meta"`
+}
+
+// BookingListMeta describes the page of a booking listing
+type BookingListMeta struct {
+	Page     int `json:"page"`
+	PageSize int `json:"pageSize"`
+	// NextCursor fetches the following page and is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// OrderListResponse is the body of GET /api/v1/orders responses. Orders are
+// paged like bookings.
+type OrderListResponse struct {
+	Data []*model.Order  `json:"data"`
+	Meta BookingListMeta `json:"meta"`
+}
+
+// PriceHistoryResponse is the body of GET /api/v1/concerts/:id/price-history responses
+type PriceHistoryResponse struct {
+	ConcertID int64                  `json:"concert_id"`
+	Data      []*model.PriceSnapshot `json:"data"`
+}
+
+// InventoryReleaseListResponse is the body of GET /api/v1/concerts/:id/inventory-releases responses
+type InventoryReleaseListResponse struct {
+	ConcertID int64                     `json:"concert_id"`
+	Data      []*model.InventoryRelease `json:"data"`
+}
+
+// InviteListResponse is the body of POST /api/v1/admin/concerts/:id/invites responses
+type InviteListResponse struct {
+	ConcertID int64                  `json:"concert_id"`
+	Data      []*model.ConcertInvite `json:"data"`
+}
+
+// WebhookListResponse is the body of GET /api/v1/organizers/me/webhooks responses
+type WebhookListResponse struct {
+	Data []*model.Webhook `json:"data"`
+}
+
+// WebhookDeliveryListResponse is the body of GET /api/v1/organizers/me/webhooks/:id/deliveries responses
+type WebhookDeliveryListResponse struct {
+	WebhookID int64                    `json:"webhook_id"`
+	Data      []*model.WebhookDelivery `json:"data"`
+}
+
+// ConcertComparisonResponse is the body of GET /api/v1/concerts/compare responses
+type ConcertComparisonResponse struct {
+	Data     []*model.ConcertComparison `json:"data"`
+	NotFound []int64                    `json:"not_found"`
+}
+
+// CancelBookingRequest is the body of POST /api/v1/bookings/:reference/cancel requests
+type CancelBookingRequest struct {
+	UserID string `json:"userID"`
+}
+
+// CancelOrderRequest is the body of POST /api/v1/orders/:reference/cancel requests
+type CancelOrderRequest struct {
+	UserID string `json:"userID"`
+}
+
+// AccountingReconciliationResponse is the body of GET /api/v1/admin/accounting/reconciliation responses
+type AccountingReconciliationResponse struct {
+	Providers []*model.AccountingReconciliation `json:"providers"`
+}
+
+// WorkerListResponse is the body of GET /api/v1/admin/workers responses
+type WorkerListResponse struct {
+	// Instance is the ID of the instance that answered; the states are its own
+	Instance string          `json:"instance"`
+	Workers  []worker.Status `json:"workers"`
+}
+
+// WorkerRunListResponse is the body of GET /api/v1/admin/workers/:name/runs responses
+type WorkerRunListResponse struct {
+	Data []*worker.Run `json:"data"`
+	Meta query.Meta    `json:"meta"`
+}
+
+// AuditLogListResponse is the body of GET /admin/audit-logs responses on the internal admin listener
+type AuditLogListResponse struct {
+	Data []*model.AuditLog `json:"data"`
+	Meta query.Meta        `json:"meta"`
+}
+
+// ClockState describes the test clock
+type ClockState struct {
+	Now    time.Time `json:"now"`
+	Offset string    `json:"offset"`
+}
+
+// SetClockRequest is the body of PUT /api/v1/admin/test-clock requests
+type SetClockRequest struct {
+	Time time.Time `json:"time" binding:"required"`
+}
+
+// AdvanceClockRequest is the body of POST /api/v1/admin/test-clock/advance requests
+type AdvanceClockRequest struct {
+	Duration string `json:"duration" binding:"required"`
+}
+
+// AdmissionOverrideRequest is the body of PUT /api/v1/admin/admission/override requests
+type AdmissionOverrideRequest struct {
+	Rate float64 `json:"rate" binding:"required,gt=0"`
+	// Burst defaults to the burst of the ceiling
+	Burst int `json:"burst" binding:"min=0"`
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuntimeSettingsHandler serves the operational settings changed at runtime,
+// on the internal admin listener only
+type RuntimeSettingsHandler struct {
+	settings service.RuntimeSettingsService
+	logger   logger.Logger
+}
+
+// NewRuntimeSettingsHandler creates a new RuntimeSettingsHandler
+func NewRuntimeSettingsHandler(settings service.RuntimeSettingsService, logger logger.Logger) *RuntimeSettingsHandler {
+	return &RuntimeSettingsHandler{
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the internal admin middleware
+func (h *RuntimeSettingsHandler) RegisterRoutes(router gin.IRouter, auth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", auth)
+	{
+		adminGroup.GET("/settings", h.GetSettings)
+		adminGroup.PATCH("/settings", h.UpdateSettings)
+	}
+}
+
+// GetSettings handles GET /admin/settings requests. The ETag identifies the
+// version changes are based on.
+func (h *RuntimeSettingsHandler) GetSettings(c *gin.Context) {
+	settings := h.settings.Current()
+	setVersionETag(c, settings.Version)
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PATCH /admin/settings requests, which change the
+// settings present in the body. The version they are based on comes from
+// If-Match, so operators can't overwrite a change they haven't seen.
+func (h *RuntimeSettingsHandler) UpdateSettings(c *gin.Context) {
+	var patch model.RuntimeSettingsPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		problem.InvalidBody(c, "Invalid settings", err)
+		return
+	}
+
+	// Settings that were never changed have version 0, which versionETag
+	// produces but ifMatchVersion rejects
+	version, present, ok := ifMatchVersion(c)
+	if !present {
+		problem.Write(c, http.StatusPreconditionRequired, problem.CodePreconditionRequired, "If-Match header with the settings ETag is required")
+		return
+	}
+	if !ok {
+		if strings.TrimSpace(c.GetHeader("If-Match")) != versionETag(0) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid If-Match header")
+			return
+		}
+		version = 0
+	}
+
+	actor := c.GetString("adminActor")
+	settings, changes, err := h.settings.Update(c.Request.Context(), actor, version, &patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrInvalidInput("")):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		case errors.Is(err, pkgErr.ErrOptimisticLockFailed):
+			problem.Write(c, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "Settings were changed; fetch them again and retry")
+		case settings != nil:
+			h.logger.Error("Runtime settings were changed by %s but not audited: %v", actor, err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Settings changed, but the audit log entry failed")
+		default:
+			h.logger.Error("Failed to change runtime settings: %v", err)
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to change settings")
+		}
+		return
+	}
+
+	for _, change := range changes {
+		h.logger.Warn("Runtime setting %s changed from %v to %v by %s", change.Field, change.From, change.To, actor)
+	}
+	setVersionETag(c, settings.Version)
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestClockHandler handles HTTP requests that shift the process clock. It is
+// meant for staging environments and only registered when enabled by config.
+type TestClockHandler struct {
+	clock *clock.OffsetClock
+}
+
+// NewTestClockHandler creates a new TestClockHandler
+func NewTestClockHandler(clock *clock.OffsetClock) *TestClockHandler {
+	return &TestClockHandler{
+		clock: clock,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the admin middleware
+func (h *TestClockHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	clockGroup := router.Group("/admin/test-clock", adminAuth)
+	{
+		clockGroup.GET("", h.GetClock)
+		clockGroup.PUT("", h.SetClock)
+		clockGroup.POST("/advance", h.AdvanceClock)
+		clockGroup.DELETE("", h.ResetClock)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *TestClockHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/admin/test-clock", Tag: "admin", Summary: "Get the test clock",
+			Responses: map[int]interface{}{http.StatusOK: ClockState{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodPut, Path: "/admin/test-clock", Tag: "admin", Summary: "Set the test clock",
+			Request:   SetClockRequest{},
+			Responses: map[int]interface{}{http.StatusOK: ClockState{}, http.StatusBadRequest: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/test-clock/advance", Tag: "admin", Summary: "Advance the test clock",
+			Request:   AdvanceClockRequest{},
+			Responses: map[int]interface{}{http.StatusOK: ClockState{}, http.StatusBadRequest: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/test-clock", Tag: "admin", Summary: "Reset the test clock to real time",
+			Responses: map[int]interface{}{http.StatusOK: ClockState{}},
+			Admin:     true,
+		},
+	}
+}
+
+// GetClock handles GET /api/v1/admin/test-clock requests
+func (h *TestClockHandler) GetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, h.state())
+}
+
+// SetClock handles PUT /api/v1/admin/test-clock requests, which set the clock to a given time
+func (h *TestClockHandler) SetClock(c *gin.Context) {
+	var req SetClockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid time, expected RFC 3339", err)
+		return
+	}
+
+	h.clock.Set(req.Time)
+	c.JSON(http.StatusOK, h.state())
+}
+
+// AdvanceClock handles POST /api/v1/admin/test-clock/advance requests
+func (h *TestClockHandler) AdvanceClock(c *gin.Context) {
+	var req AdvanceClockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "duration is required", err)
+		return
+	}
+
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid duration")
+		return
+	}
+
+	h.clock.Advance(d)
+	c.JSON(http.StatusOK, h.state())
+}
+
+// ResetClock handles DELETE /api/v1/admin/test-clock requests, which return the clock to real time
+func (h *TestClockHandler) ResetClock(c *gin.Context) {
+	h.clock.Reset()
+	c.JSON(http.StatusOK, h.state())
+}
+
+// state describes the current clock
+func (h *TestClockHandler) state() ClockState {
+	return ClockState{
+		Now:    h.clock.Now(),
+		Offset: h.clock.Offset().String(),
+	}
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/pagelink"
+
+	"github.com/gin-gonic/gin"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	pageTemplates = template.Must(template.New("pages").Funcs(template.FuncMap{
+		"date": func(t time.Time) string { return t.Format("Mon 2 Jan 2006, 15:04 MST") },
+	}).ParseFS(templateFiles, "templates/*.html"))
+
+	pageStyle = template.CSS(mustReadTemplate("templates/page.css"))
+
+	// pagePolicy replaces the default Content-Security-Policy on the HTML
+	// pages. The only thing they load is their inline stylesheet, allowed by
+	// its hash; the QR code is inline SVG.
+	pagePolicy = fmt.Sprintf("default-src 'none'; style-src 'sha256-%s'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+		base64.StdEncoding.EncodeToString(sha256Of(string(pageStyle))))
+)
+
+func mustReadTemplate(name string) string {
+	data, err := templateFiles.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func sha256Of(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+// TicketPageHandler serves the HTML ticket and receipt pages opened from
+// signed links, and issues those links to the users of the bookings
+type TicketPageHandler struct {
+	pageService service.TicketPageService
+}
+
+// NewTicketPageHandler creates a new TicketPageHandler
+func NewTicketPageHandler(pageService service.TicketPageService) *TicketPageHandler {
+	return &TicketPageHandler{
+		pageService: pageService,
+	}
+}
+
+// RegisterRoutes registers the link endpoint on an API version group
+func (h *TicketPageHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/bookings/:reference/links", h.GetLinks)
+}
+
+// RegisterPageRoutes registers the pages, which live outside the API versions
+// so the links stay short and stable
+func (h *TicketPageHandler) RegisterPageRoutes(router gin.IRouter) {
+	router.GET("/t/:ticketToken", h.GetTicketPage)
+	router.GET("/r/:receiptToken", h.GetReceiptPage)
+}
+
+// Operations documents the routes registered by RegisterRoutes
+func (h *TicketPageHandler) Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/bookings/:reference/links", Tag: "bookings",
+			Summary: "Get signed links to the HTML ticket and receipt pages of a booking",
+			Parameters: []openapi.Parameter{
+				openapi.PathParam("reference", "string", "Booking reference"),
+				{Name: "userID", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         model.BookingLinks{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+		},
+	}
+}
+
+// GetLinks handles GET /api/v1/bookings/:reference/links requests
+func (h *TicketPageHandler) GetLinks(c *gin.Context) {
+	// In a real app, userID would come from auth middleware
+	links, err := h.pageService.Links(c.Request.Context(), c.Param("reference"), c.Query("userID"))
+	if err != nil {
+		switch {
+		case errors.Is(err, pkgErr.ErrInvalidInput("")):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeBookingNotFound, "Booking not found")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to issue booking links")
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, links)
+}
+
+// ticketView is the data of the ticket and receipt templates
+type ticketView struct {
+	*model.BookingPage
+	Title     string
+	Style     template.CSS
+	QR        template.HTML
+	UnitPrice string
+	Total     string
+}
+
+// GetTicketPage handles GET /t/:ticketToken requests
+func (h *TicketPageHandler) GetTicketPage(c *gin.Context) {
+	page, err := h.pageService.Ticket(c.Request.Context(), c.Param("ticketToken"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	view := ticketView{BookingPage: page, Title: "Ticket for " + page.Concert.Name, Style: pageStyle}
+	if page.Valid() {
+		if view.QR, err = qrSVG(page.Code); err != nil {
+			h.renderError(c, err)
+			return
+		}
+	}
+	h.render(c, http.StatusOK, "ticket.html", view)
+}
+
+// GetReceiptPage handles GET /r/:receiptToken requests
+func (h *TicketPageHandler) GetReceiptPage(c *gin.Context) {
+	page, err := h.pageService.Receipt(c.Request.Context(), c.Param("receiptToken"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	locale := money.ResolveLocale(c.GetHeader("Accept-Language"))
+	currency := page.Concert.Currency
+	total := money.Round(page.Booking.UnitPrice*float64(page.Booking.TicketCount), currency)
+	h.render(c, http.StatusOK, "receipt.html", ticketView{
+		BookingPage: page,
+		Title:       "Receipt for " + page.Concert.Name,
+		Style:       pageStyle,
+		UnitPrice:   money.Format(page.Booking.UnitPrice, currency, locale),
+		Total:       money.Format(total, currency, locale),
+	})
+}
+
+// renderError shows an error page; links that were tampered with and
+// bookings that no longer exist look the same
+func (h *TicketPageHandler) renderError(c *gin.Context, err error) {
+	status, title, message := http.StatusInternalServerError, "Something went wrong", "The page couldn't be loaded. Please try again later."
+	switch {
+	case errors.Is(err, pagelink.ErrExpired):
+		status, title, message = http.StatusGone, "Link expired", "This link has expired. Open your booking in the app or request a new link."
+	case errors.Is(err, pagelink.ErrInvalid), errors.Is(err, pkgErr.ErrNotFound):
+		status, title, message = http.StatusNotFound, "Link not found", "This link isn't valid. Check that it was copied completely."
+	}
+
+	h.render(c, status, "error.html", map[string]interface{}{"Title": title, "Message": message, "Style": pageStyle})
+}
+
+// render executes a page template. The pages carry the token of a link, so
+// they are neither cached nor sent as a referrer.
+func (h *TicketPageHandler) render(c *gin.Context, status int, name string, data interface{}) {
+	var body bytes.Buffer
+	if err := pageTemplates.ExecuteTemplate(&body, name, data); err != nil {
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	c.Header("Content-Security-Policy", pagePolicy)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+// qrSVG renders a QR code as inline SVG, one path over the dark modules
+func qrSVG(content string) (template.HTML, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+
+	bitmap := code.Bitmap()
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<svg class="code" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" shape-rendering="crispEdges" role="img" aria-label="Ticket code">`+
+			`<rect width="%[1]d" height="%[1]d" fill="#fff"/><path d="%[2]s" fill="#000"/></svg>`,
+		len(bitmap), path.String())), nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler handles HTTP requests related to user data
+type UserHandler struct {
+	userDataService service.UserDataService
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(userDataService service.UserDataService) *UserHandler {
+	return &UserHandler{
+		userDataService: userDataService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the admin middleware
+func (h *UserHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	userGroup := router.Group("/users", adminAuth)
+	{
+		userGroup.GET("/:id/export", h.ExportUserData)
+		userGroup.DELETE("/:id/data", h.EraseUserData)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *UserHandler) Operations() []openapi.Operation {
+	id := openapi.PathParam("id", "string", "User ID")
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/users/:id/export", Tag: "admin", Summary: "Export the data of a user",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: model.UserDataExport{}, http.StatusInternalServerError: problem.Details{}},
+			Admin:      true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/users/:id/data", Tag: "admin", Summary: "Erase the data of a user",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: model.UserDataErasure{}, http.StatusInternalServerError: problem.Details{}},
+			Admin:      true,
+		},
+	}
+}
+
+// ExportUserData handles GET /api/v1/users/:id/export requests
+func (h *UserHandler) ExportUserData(c *gin.Context) {
+	export, err := h.userDataService.ExportUserData(c.Request.Context(), c.Param("id"), c.GetString("adminActor"))
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to export user data")
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// EraseUserData handles DELETE /api/v1/users/:id/data requests
+func (h *UserHandler) EraseUserData(c *gin.Context) {
+	erasure, err := h.userDataService.EraseUserData(c.Request.Context(), c.Param("id"), c.GetString("adminActor"))
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to erase user data")
+		return
+	}
+
+	c.JSON(http.StatusOK, erasure)
+}
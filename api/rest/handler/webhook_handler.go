@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles HTTP requests related to the webhooks of organizers
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler behind the organizer
+// middleware. Organizers manage their own webhooks only.
+func (h *WebhookHandler) RegisterRoutes(router gin.IRouter, organizerAuth gin.HandlerFunc) {
+	organizerGroup := router.Group("/organizers/me/webhooks", organizerAuth)
+	{
+		organizerGroup.POST("", h.CreateWebhook)
+		organizerGroup.GET("", h.ListWebhooks)
+		organizerGroup.GET("/:id", h.GetWebhook)
+		organizerGroup.PUT("/:id", h.UpdateWebhook)
+		organizerGroup.DELETE("/:id", h.DeleteWebhook)
+		organizerGroup.GET("/:id/deliveries", h.ListDeliveries)
+		organizerGroup.POST("/:id/deliveries/:deliveryId/redeliver", h.Redeliver)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *WebhookHandler) Operations() []openapi.Operation {
+	id := openapi.PathParam("id", "integer", "Webhook ID")
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/organizers/me/webhooks", Tag: "organizers", Summary: "Register a webhook",
+			Request: model.WebhookRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    model.Webhook{},
+				http.StatusBadRequest: problem.Details{},
+			},
+			Organizer: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/organizers/me/webhooks", Tag: "organizers", Summary: "List the organizer's webhooks",
+			Responses: map[int]interface{}{http.StatusOK: WebhookListResponse{}},
+			Organizer: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/organizers/me/webhooks/:id", Tag: "organizers", Summary: "Get a webhook",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: model.Webhook{}, http.StatusNotFound: problem.Details{}},
+			Organizer:  true,
+		},
+		{
+			Method: http.MethodPut, Path: "/organizers/me/webhooks/:id", Tag: "organizers", Summary: "Replace a webhook",
+			Parameters: []openapi.Parameter{id},
+			Request:    model.WebhookRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         model.Webhook{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+			Organizer: true,
+		},
+		{
+			Method: http.MethodDelete, Path: "/organizers/me/webhooks/:id", Tag: "organizers", Summary: "Delete a webhook and its deliveries",
+			Parameters: []openapi.Parameter{id},
+			Responses:  map[int]interface{}{http.StatusOK: MessageResponse{}, http.StatusNotFound: problem.Details{}},
+			Organizer:  true,
+		},
+		{
+			Method: http.MethodGet, Path: "/organizers/me/webhooks/:id/deliveries", Tag: "organizers", Summary: "List the latest deliveries of a webhook",
+			Parameters: []openapi.Parameter{
+				id,
+				openapi.QueryParam("status", "string", "pending, delivered or dead"),
+				openapi.QueryParam("limit", "integer", "Deliveries to list, at most 100"),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         WebhookDeliveryListResponse{},
+				http.StatusBadRequest: problem.Details{},
+				http.StatusNotFound:   problem.Details{},
+			},
+			Organizer: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/organizers/me/webhooks/:id/deliveries/:deliveryId/redeliver", Tag: "organizers", Summary: "Attempt a delivery again",
+			Parameters: []openapi.Parameter{id, openapi.PathParam("deliveryId", "integer", "Delivery ID")},
+			Responses:  map[int]interface{}{http.StatusOK: model.WebhookDelivery{}, http.StatusNotFound: problem.Details{}},
+			Organizer:  true,
+		},
+	}
+}
+
+// CreateWebhook handles POST /api/v1/organizers/me/webhooks requests
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req model.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid webhook", err)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), c.GetString("organizerEmail"), &req)
+	if err != nil {
+		var errWithMsg *pkgErr.ErrorWithMessage
+		if errors.As(err, &errWithMsg) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	// The secret is only ever shown in this response
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// ListWebhooks handles GET /api/v1/organizers/me/webhooks requests
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), c.GetString("organizerEmail"))
+	if err != nil {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, WebhookListResponse{Data: webhooks})
+}
+
+// GetWebhook handles GET /api/v1/organizers/me/webhooks/:id requests
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), c.GetString("organizerEmail"), id)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Webhook not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles PUT /api/v1/organizers/me/webhooks/:id requests
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	var req model.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.InvalidBody(c, "Invalid webhook", err)
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), c.GetString("organizerEmail"), id, &req)
+	if err != nil {
+		var errWithMsg *pkgErr.ErrorWithMessage
+		switch {
+		case errors.As(err, &errWithMsg):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Webhook not found")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to update webhook")
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /api/v1/organizers/me/webhooks/:id requests
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), c.GetString("organizerEmail"), id); err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Webhook not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Webhook deleted"})
+}
+
+// ListDeliveries handles GET /api/v1/organizers/me/webhooks/:id/deliveries requests
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "limit must be a positive integer")
+			return
+		}
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.GetString("organizerEmail"), id, c.Query("status"), limit)
+	if err != nil {
+		var errWithMsg *pkgErr.ErrorWithMessage
+		switch {
+		case errors.As(err, &errWithMsg):
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, errWithMsg.Message())
+		case errors.Is(err, pkgErr.ErrNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Webhook not found")
+		default:
+			problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list webhook deliveries")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, WebhookDeliveryListResponse{WebhookID: id, Data: deliveries})
+}
+
+// Redeliver handles POST /api/v1/organizers/me/webhooks/:id/deliveries/:deliveryId/redeliver requests
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), c.GetString("organizerEmail"), id, deliveryID)
+	if err != nil {
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Webhook delivery not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to redeliver webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// webhookID parses the webhook ID of a request, or responds with 400 Bad Request
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "Invalid webhook ID")
+		return 0, false
+	}
+	return id, true
+}
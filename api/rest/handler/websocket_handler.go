@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/pagelink"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// wsWriteTimeout is how long a message may take to reach a client
+	wsWriteTimeout = 10 * time.Second
+	// wsBookingBuffer is how many booking events may wait for a slow client
+	wsBookingBuffer = 16
+	// wsMaxQueues limits the waiting room queues one connection can join
+	wsMaxQueues = 10
+)
+
+// WebSocket actions sent by clients
+const (
+	WebSocketActionJoin  = "join"
+	WebSocketActionLeave = "leave"
+)
+
+// WebSocket message types sent to clients
+const (
+	WebSocketMessageQueue   = "queue"
+	WebSocketMessageBooking = "booking"
+	WebSocketMessageError   = "error"
+)
+
+// WebSocketCommand is a message from a client, e.g.
+// {"action": "join", "concert_id": 1} to queue for a booking token
+type WebSocketCommand struct {
+	Action    string `json:"action"`
+	ConcertID int64  `json:"concert_id"`
+}
+
+// WebSocketMessage is a message to a client. Type says which field is set.
+type WebSocketMessage struct {
+	Type    string                   `json:"type"`
+	Queue   *model.WaitingRoomUpdate `json:"queue,omitempty"`
+	Booking *model.Booking           `json:"booking,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// WebSocketHandler pushes waiting room positions and booking status changes
+// to a user over a WebSocket, so on-sale clients don't have to poll
+type WebSocketHandler struct {
+	waitingRoom  service.WaitingRoom
+	events       *events.Bus
+	signer       *pagelink.Signer
+	allowOrigins func() []string
+}
+
+// NewWebSocketHandler creates a new WebSocketHandler. Users connect with a
+// token signer verifies. Browsers may only connect from the origins
+// allowOrigins returns at the time; "*" allows any origin.
+func NewWebSocketHandler(waitingRoom service.WaitingRoom, bus *events.Bus, signer *pagelink.Signer, allowOrigins func() []string) *WebSocketHandler {
+	return &WebSocketHandler{
+		waitingRoom:  waitingRoom,
+		events:       bus,
+		signer:       signer,
+		allowOrigins: allowOrigins,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *WebSocketHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/ws", h.Connect)
+}
+
+// Connect handles GET /ws?userID=...&token=... requests
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	userID := c.Query("userID")
+	if userID == "" {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, "userID is required")
+		return
+	}
+
+	// The socket pushes the user's bookings and holds their place in the
+	// waiting room, so it is refused before upgrading unless the token was
+	// issued to this user. Browsers can't send headers on WebSockets, so the
+	// token is a query parameter.
+	subject, err := h.signer.Verify(pagelink.KindWebSocket, c.Query("token"), clock.Now())
+	if err != nil || subject != userID {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "A valid token of the user is required")
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.serve(c.Request.Context(), conn, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkOrigin rejects browsers connecting from origins CORS doesn't allow.
+// Clients that aren't browsers send no Origin and are accepted.
+func (h *WebSocketHandler) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	for _, allowed := range h.allowOrigins() {
+		if allowed == "*" || allowed == origin {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+// serve runs a connection until the client disconnects. ctx carries the
+// invite token the connection was opened with.
+func (h *WebSocketHandler) serve(ctx context.Context, conn *websocket.Conn, userID string) {
+	defer conn.Close()
+
+	// The HTTP server's read and write timeouts would end the connection
+	conn.SetDeadline(time.Time{})
+
+	var mu sync.Mutex
+	send := func(msg WebSocketMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			// Closing ends the read loop below
+			conn.Close()
+		}
+	}
+
+	bookings := h.events.Subscribe(model.EventBookingStatus, model.UserEventKey(userID), wsBookingBuffer)
+	defer bookings.Close()
+
+	go func() {
+		for event := range bookings.Events() {
+			booking, ok := event.Payload.(*model.Booking)
+			if ok && booking.UserID == userID {
+				send(WebSocketMessage{Type: WebSocketMessageBooking, Booking: booking})
+			}
+		}
+	}()
+
+	entries := make(map[int64]*service.WaitingEntry)
+	defer func() {
+		for _, entry := range entries {
+			h.waitingRoom.Disconnect(entry)
+		}
+	}()
+
+	for {
+		var cmd WebSocketCommand
+		if err := websocket.JSON.Receive(conn, &cmd); err != nil {
+			return
+		}
+
+		switch {
+		case cmd.ConcertID <= 0:
+			send(WebSocketMessage{Type: WebSocketMessageError, Error: "concert_id is required"})
+
+		case cmd.Action == WebSocketActionJoin:
+			if _, ok := entries[cmd.ConcertID]; !ok && len(entries) >= wsMaxQueues {
+				send(WebSocketMessage{Type: WebSocketMessageError, Error: fmt.Sprintf("cannot wait for more than %d concerts at once", wsMaxQueues)})
+				continue
+			}
+
+			entry := h.waitingRoom.Join(ctx, cmd.ConcertID, userID)
+			entries[cmd.ConcertID] = entry
+			go func() {
+				for update := range entry.Updates() {
+					update := update
+					send(WebSocketMessage{Type: WebSocketMessageQueue, Queue: &update})
+				}
+			}()
+
+		case cmd.Action == WebSocketActionLeave:
+			if entry, ok := entries[cmd.ConcertID]; ok {
+				h.waitingRoom.Leave(entry)
+				delete(entries, cmd.ConcertID)
+			}
+
+		default:
+			send(WebSocketMessage{Type: WebSocketMessageError, Error: fmt.Sprintf("unknown action %q", cmd.Action)})
+		}
+	}
+}
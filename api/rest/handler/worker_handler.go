@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkerHandler handles HTTP requests that control the background workers
+type WorkerHandler struct {
+	workers *worker.Registry
+	logger  logger.Logger
+}
+
+// NewWorkerHandler creates a new WorkerHandler
+func NewWorkerHandler(workers *worker.Registry, logger logger.Logger) *WorkerHandler {
+	return &WorkerHandler{
+		workers: workers,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the routes for this handler
+func (h *WorkerHandler) RegisterRoutes(router gin.IRouter, adminAuth gin.HandlerFunc) {
+	workerGroup := router.Group("/admin/workers", adminAuth)
+	{
+		workerGroup.GET("", h.ListWorkers)
+		workerGroup.GET("/:name/runs", h.ListWorkerRuns)
+		workerGroup.POST("/:name/pause", h.PauseWorker)
+		workerGroup.POST("/:name/resume", h.ResumeWorker)
+	}
+}
+
+// Operations documents the routes of this handler for the OpenAPI document
+func (h *WorkerHandler) Operations() []openapi.Operation {
+	name := openapi.PathParam("name", "string", "Worker name, e.g. accounting-export")
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/admin/workers", Tag: "admin", Summary: "List the background workers and their states",
+			Responses: map[int]interface{}{http.StatusOK: WorkerListResponse{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/workers/:name/runs", Tag: "admin", Summary: "List the recorded runs of an exclusive worker across all instances",
+			Parameters: []openapi.Parameter{
+				name,
+				openapi.QueryParam("page", "integer", "Page number, starting at 1"),
+				openapi.QueryParam("pageSize", "integer", "Runs per page, at most 100"),
+				openapi.QueryParam("cursor", "string", "nextCursor of the previous page, replaces page and pageSize"),
+			},
+			Responses: map[int]interface{}{http.StatusOK: WorkerRunListResponse{}, http.StatusBadRequest: problem.Details{}, http.StatusNotFound: problem.Details{}},
+			Admin:     true,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/workers/:name/pause", Tag: "admin", Summary: "Pause a background worker",
+			Parameters: []openapi.Parameter{name},
+			Responses:  map[int]interface{}{http.StatusOK: worker.Status{}, http.StatusNotFound: problem.Details{}},
+			Admin:      true,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/workers/:name/resume", Tag: "admin", Summary: "Resume a paused background worker",
+			Parameters: []openapi.Parameter{name},
+			Responses:  map[int]interface{}{http.StatusOK: worker.Status{}, http.StatusNotFound: problem.Details{}},
+			Admin:      true,
+		},
+	}
+}
+
+// ListWorkers handles GET /api/v1/admin/workers requests
+func (h *WorkerHandler) ListWorkers(c *gin.Context) {
+	c.JSON(http.StatusOK, WorkerListResponse{Instance: h.workers.InstanceID(), Workers: h.workers.Statuses()})
+}
+
+// ListWorkerRuns handles GET /api/v1/admin/workers/:name/runs requests. The
+// runs of all instances are listed latest first, so overlapping runs and the
+// instance that executed each one can be told apart.
+func (h *WorkerHandler) ListWorkerRuns(c *gin.Context) {
+	page, err := query.ParsePage(c.Query("page"), c.Query("pageSize"), c.Query("cursor"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidInput, err.Error())
+		return
+	}
+
+	runs, total, err := h.workers.Runs(c.Request.Context(), c.Param("name"), page)
+	if err != nil {
+		if errors.Is(err, worker.ErrUnknownWorker) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Worker not found")
+			return
+		}
+		h.logger.Error("Failed to list runs of worker %s: %v", c.Param("name"), err)
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to list the worker runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkerRunListResponse{Data: runs, Meta: page.Meta(total)})
+}
+
+// PauseWorker handles POST /api/v1/admin/workers/:name/pause requests. A run
+// in progress is finished; later runs are skipped until the worker is resumed.
+func (h *WorkerHandler) PauseWorker(c *gin.Context) {
+	actor := c.GetString("adminActor")
+	status, err := h.workers.Pause(c.Param("name"), actor)
+	h.respond(c, status, err)
+	if err == nil {
+		h.logger.Warn("Worker %s paused by %s", status.Name, actor)
+	}
+}
+
+// ResumeWorker handles POST /api/v1/admin/workers/:name/resume requests
+func (h *WorkerHandler) ResumeWorker(c *gin.Context) {
+	status, err := h.workers.Resume(c.Param("name"))
+	h.respond(c, status, err)
+	if err == nil {
+		h.logger.Info("Worker %s resumed by %s", status.Name, c.GetString("adminActor"))
+	}
+}
+
+func (h *WorkerHandler) respond(c *gin.Context, status worker.Status, err error) {
+	if err != nil {
+		if errors.Is(err, worker.ErrUnknownWorker) {
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Worker not found")
+			return
+		}
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Failed to change the worker state")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
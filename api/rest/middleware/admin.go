@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/api/rest/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth creates a Gin middleware that only lets requests carrying the
+// configured admin token through. All admin routes are rejected when no
+// token is configured.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			problem.Write(c, http.StatusForbidden, problem.CodeAdminDisabled, "Admin API is disabled")
+			return
+		}
+
+		// Check if it's a Bearer token
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid authorization format")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(parts[1]), []byte(token)) != 1 {
+			problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "Invalid admin token")
+			return
+		}
+
+		// Record who is acting so it can be written to the audit log
+		actor := c.GetHeader("X-Admin-Actor")
+		if actor == "" {
+			actor = "admin"
+		}
+		c.Set("adminActor", actor)
+
+		c.Next()
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"concert-ticket-api/api/rest/problem"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,16 +18,14 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Check if Authorization header is provided
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Authorization header is required")
 			return
 		}
 
 		// Check if it's a Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid authorization format")
 			return
 		}
 
@@ -34,8 +34,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// In a real app, we would validate the token here
 		// For this exercise, we'll just check if it's not empty
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid token")
 			return
 		}
 
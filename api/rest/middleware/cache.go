@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicCache creates a Gin middleware that lets shared caches such as a CDN
+// keep successful read responses for the configured lifetimes. Other
+// responses are marked no-store so errors aren't served after they're fixed.
+func PublicCache(cfg config.ReadOnly) gin.HandlerFunc {
+	policy := fmt.Sprintf("public, max-age=%d", int64(cfg.MaxAge.Seconds()))
+	if cfg.StaleWhileRevalidate > 0 {
+		policy += fmt.Sprintf(", stale-while-revalidate=%d", int64(cfg.StaleWhileRevalidate.Seconds()))
+	}
+
+	return func(c *gin.Context) {
+		// /health and /metrics must reflect this instance, not a cached copy
+		path := c.Request.URL.Path
+		if !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/gateway/") {
+			c.Next()
+			return
+		}
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		// Unknown paths are answered by gin without calling WriteHeader, and
+		// private concerts must not reach callers without the invite
+		if c.FullPath() == "" || c.GetHeader(service.InviteTokenHeader) != "" {
+			c.Header("Cache-Control", "no-store")
+			c.Next()
+			return
+		}
+
+		// Prices are formatted for the Accept-Language of the request
+		c.Header("Vary", "Accept-Language")
+		c.Header("Cache-Control", policy)
+		c.Writer = &cachePolicyWriter{ResponseWriter: c.Writer}
+		c.Next()
+	}
+}
+
+// cachePolicyWriter withdraws the cache policy from unsuccessful responses
+type cachePolicyWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *cachePolicyWriter) WriteHeader(code int) {
+	// 304 responses refresh the cached copy, so they keep the policy
+	if code != http.StatusOK && code != http.StatusNotModified {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"slices"
+	"sync"
+
+	"concert-ticket-api/internal/service"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// RuntimeCORS creates a Gin middleware applying the CORS policy of cfg with
+// the allowed origins of the runtime settings in effect. The policy is
+// rebuilt when the origins change.
+func RuntimeCORS(cfg cors.Config, settings service.RuntimeSettingsService) gin.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		origins []string
+		policy  gin.HandlerFunc
+	)
+
+	// current returns the policy for the origins in effect
+	current := func() gin.HandlerFunc {
+		allowed := settings.Current().CORSAllowOrigins
+
+		mu.Lock()
+		defer mu.Unlock()
+		if policy == nil || !slices.Equal(origins, allowed) {
+			cfg.AllowOrigins = allowed
+			origins, policy = allowed, cors.New(cfg)
+		}
+		return policy
+	}
+
+	return func(c *gin.Context) {
+		current()(c)
+	}
+}
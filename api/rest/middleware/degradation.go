@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// maxCachedBodySize is the largest response body kept for degraded reads
+const maxCachedBodySize = 1 << 20
+
+var degradedResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_degraded_responses_total",
+	Help: "Number of REST requests answered in degradation mode, by outcome.",
+}, []string{"outcome"})
+
+// Degradation creates a Gin middleware that keeps reads available while the
+// database is down. While the health registry reports the database healthy,
+// successful anonymous GET responses are cached. While it is unhealthy, GET
+// and HEAD requests are answered from the cache with a Warning header and the time
+// the data was fetched, and all other requests are rejected with 503.
+func Degradation(cfg config.Degradation, registry *health.Registry) gin.HandlerFunc {
+	cache := newResponseCache(cfg.CacheEntries)
+
+	return func(c *gin.Context) {
+		// Only API routes depend on the database; /health and /metrics don't
+		path := c.Request.URL.Path
+		if !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/gateway/") {
+			c.Next()
+			return
+		}
+
+		// Authenticated responses and private concerts are never shared
+		// between callers. HEAD requests are answered from the GET responses.
+		cacheable := (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+			c.GetHeader("Authorization") == "" && c.GetHeader(service.InviteTokenHeader) == ""
+		key := c.Request.URL.RequestURI() + "|" + c.GetHeader("Accept-Language")
+
+		if registry.Healthy(health.Database) {
+			if !cacheable || c.Request.Method != http.MethodGet {
+				c.Next()
+				return
+			}
+
+			writer := &capturingWriter{ResponseWriter: c.Writer}
+			c.Writer = writer
+			c.Next()
+
+			if writer.Status() == http.StatusOK && !writer.overflow {
+				cache.put(key, &cachedResponse{
+					contentType: writer.Header().Get("Content-Type"),
+					body:        writer.body.Bytes(),
+					fetchedAt:   time.Now(),
+				})
+			}
+			return
+		}
+
+		if cacheable {
+			if cached, ok := cache.get(key); ok && time.Since(cached.fetchedAt) <= cfg.MaxStaleness {
+				degradedResponses.WithLabelValues("stale").Inc()
+				c.Header("Warning", `110 - "Response is Stale"`)
+				c.Header("X-Data-Fetched-At", cached.fetchedAt.UTC().Format(time.RFC3339))
+				c.Header("Age", formatAge(time.Since(cached.fetchedAt)))
+				c.Data(http.StatusOK, cached.contentType, cached.body)
+				c.Abort()
+				return
+			}
+		}
+
+		degradedResponses.WithLabelValues("unavailable").Inc()
+		c.Header("Retry-After", formatAge(cfg.RetryAfter))
+		problem.Write(c, http.StatusServiceUnavailable, problem.CodeServiceUnavailable, "Service temporarily unavailable")
+	}
+}
+
+// formatAge formats a duration as whole seconds for Age and Retry-After headers
+func formatAge(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Second))
+}
+
+// capturingWriter records the response body alongside writing it
+type capturingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *capturingWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxCachedBodySize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	fetchedAt   time.Time
+}
+
+type cacheEntry struct {
+	key      string
+	response *cachedResponse
+}
+
+// responseCache is a fixed-size LRU cache of responses
+type responseCache struct {
+	mutex   sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).response, true
+}
+
+func (c *responseCache) put(key string, response *cachedResponse) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, response: response})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation creates a Gin middleware that announces the deprecation of an
+// API version: Deprecation (RFC 9745) and Sunset (RFC 8594) headers, a
+// rel="deprecation" link to the migration guide and a rel="successor-version"
+// link to the same route under the successor prefix. Versions without a
+// deprecation date pass through unchanged.
+func Deprecation(cfg config.APIVersion, prefix, successorPrefix string) gin.HandlerFunc {
+	deprecatedAt, sunsetAt, err := cfg.Schedule()
+	if err != nil || deprecatedAt.IsZero() {
+		// Load validates the schedule, so this only skips undeprecated versions
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	var sunset string
+	if !sunsetAt.IsZero() {
+		sunset = sunsetAt.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+
+		var links []string
+		if cfg.Link != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, cfg.Link))
+		}
+		if successorPrefix != "" {
+			successor := successorPrefix + strings.TrimPrefix(c.Request.URL.Path, prefix)
+			links = append(links, fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+		if len(links) > 0 {
+			c.Header("Link", strings.Join(links, ", "))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainingRetryAfter is how long clients are asked to wait before retrying a
+// write rejected while shutting down, by when the load balancer sends it to
+// another instance
+const drainingRetryAfter = time.Second
+
+// Draining creates a Gin middleware that rejects new bookings and other API
+// writes with 503 once the service is shutting down, so the writes in flight
+// are the last ones. Reads are still served until the listener closes.
+func Draining(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !registry.Draining() || !isWrite(c.Request.Method) ||
+			(!strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/gateway/")) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", formatAge(drainingRetryAfter))
+		c.Header("Connection", "close")
+		problem.Write(c, http.StatusServiceUnavailable, problem.CodeServiceUnavailable, "The server is shutting down, please try again")
+	}
+}
+
+// isWrite reports whether a request method changes state
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
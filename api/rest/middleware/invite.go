@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"concert-ticket-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteToken creates a Gin middleware that passes the invite token in the
+// X-Invite-Token header on to the services, which grant access to the
+// private concert it belongs to
+func InviteToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.GetHeader(service.InviteTokenHeader); token != "" {
+			c.Request = c.Request.WithContext(service.WithInviteToken(c.Request.Context(), token))
+		}
+		c.Next()
+	}
+}
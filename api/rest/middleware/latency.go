@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/trace"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var slowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_slow_requests_total",
+	Help: "Number of REST requests that exceeded their latency budget.",
+}, []string{"method", "route"})
+
+// LatencyBudget creates a Gin middleware that flags requests exceeding the
+// latency budget configured for their route. A sample of requests is traced,
+// and the span breakdown of sampled slow requests is logged.
+func LatencyBudget(cfg config.Latency, log logger.Logger) gin.HandlerFunc {
+	// Viper lowercases map keys, so budgets are matched case-insensitively
+	budgets := make(map[string]time.Duration, len(cfg.Budgets))
+	for route, budget := range cfg.Budgets {
+		budgets[strings.ToLower(route)] = budget
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// Unmatched routes are not tracked to keep the metric cardinality bounded
+			c.Next()
+			return
+		}
+
+		// A WebSocket stays open as long as the client wants, so it has no latency
+		if c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		budget, ok := budgets[strings.ToLower(c.Request.Method+" "+route)]
+		if !ok {
+			budget = cfg.DefaultBudget
+		}
+
+		var t *trace.Trace
+		if cfg.TraceSampleRate > 0 && rand.Float64() < cfg.TraceSampleRate {
+			var ctx = c.Request.Context()
+			ctx, t = trace.New(ctx)
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		if budget <= 0 || latency <= budget {
+			return
+		}
+
+		c.Set("slowRequest", true)
+		slowRequests.WithLabelValues(c.Request.Method, route).Inc()
+
+		if t == nil {
+			log.Warn("Slow request: %s %s took %s (budget %s)", c.Request.Method, route, latency, budget)
+			return
+		}
+
+		var breakdown strings.Builder
+		for _, span := range t.Spans() {
+			fmt.Fprintf(&breakdown, " | %s +%s %s", span.Name, span.Offset, span.Duration)
+		}
+		log.Warn("Slow request: %s %s took %s (budget %s)%s",
+			c.Request.Method, route, latency, budget, breakdown.String())
+	}
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/trace"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,8 +35,58 @@ func RequestLogger(log logger.Logger) gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
-		// Log request details
-		log.Info("Request: %s | %s | %d | %s | %s",
-			method, path, statusCode, clientIP, latency.String())
+		// Log request details, with the user and the concert or booking the
+		// route names when it has them
+		fields := []logger.Field{
+			logger.F("method", method),
+			logger.F("path", path),
+			logger.F("status", statusCode),
+			logger.F("client_ip", clientIP),
+			logger.Latency(latency),
+			logger.F("trace_id", trace.ID(c.Request.Context())),
+		}
+		if id := trace.RequestID(c.Request.Context()); id != "" {
+			fields = append(fields, logger.RequestID(id))
+		}
+		requestLog := log.With(append(fields, routeFields(c)...)...)
+
+		// Errors hidden from the caller are logged with the request, so the
+		// request ID of the response leads to them
+		if err := c.Errors.Last(); err != nil && statusCode >= 500 {
+			requestLog.With(logger.Err(err.Err)).Error("Request: %s %s", method, path)
+			return
+		}
+		requestLog.Info("Request: %s %s", method, path)
+	}
+}
+
+// routeFields returns the fields of the user, concert and booking a request
+// is about, from the userID query parameter and the IDs of the route
+func routeFields(c *gin.Context) []logger.Field {
+	var fields []logger.Field
+	route := c.FullPath()
+
+	userID := c.Query("userID")
+	if strings.Contains(route, "/users/:id") {
+		userID = c.Param("id")
 	}
+	if userID != "" {
+		fields = append(fields, logger.UserID(userID))
+	}
+
+	concertID := c.Param("concert_id")
+	if strings.Contains(route, "/concerts/:id") {
+		concertID = c.Param("id")
+	}
+	if id, err := strconv.ParseInt(concertID, 10, 64); err == nil {
+		fields = append(fields, logger.ConcertID(id))
+	}
+
+	if strings.Contains(route, "/bookings/:id") {
+		if id, err := strconv.ParseInt(c.Param("id"), 10, 64); err == nil {
+			fields = append(fields, logger.BookingID(id))
+		}
+	}
+
+	return fields
 }
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maintenanceRetryAfter is how long clients are asked to wait during
+	// maintenance
+	maintenanceRetryAfter = time.Minute
+	// defaultMaintenanceMessage is the detail of maintenance rejections
+	// without a message of their own
+	defaultMaintenanceMessage = "The service is down for maintenance, please try again later"
+)
+
+// Maintenance creates a Gin middleware that rejects client requests with 503
+// while the runtime settings in effect turn maintenance mode on. Health
+// checks, metrics and the admin routes stay available, so operators can
+// still see and work on the service.
+func Maintenance(settings service.RuntimeSettingsService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := settings.Current()
+		if !current.Maintenance || maintenanceExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		message := current.MaintenanceMessage
+		if message == "" {
+			message = defaultMaintenanceMessage
+		}
+		c.Header("Retry-After", formatAge(maintenanceRetryAfter))
+		problem.Write(c, http.StatusServiceUnavailable, problem.CodeMaintenance, message)
+	}
+}
+
+// maintenanceExempt reports whether a path is served during maintenance
+func maintenanceExempt(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/") || path == "/metrics" || strings.Contains(path, "/admin/")
+}
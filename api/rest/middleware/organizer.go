@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizerAuth creates a Gin middleware that only lets requests carrying the
+// token of one of the organizer accounts through, recording the account's
+// email. All organizer routes are rejected when no account is configured.
+func OrganizerAuth(accounts []config.OrganizerAccount) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(accounts) == 0 {
+			problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "Organizer API is disabled")
+			return
+		}
+
+		// Check if it's a Bearer token
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid authorization format")
+			return
+		}
+
+		// Compare with every account, so the time taken doesn't tell which
+		// one the token was close to
+		email := ""
+		for _, account := range accounts {
+			if subtle.ConstantTimeCompare([]byte(parts[1]), []byte(account.Token)) == 1 {
+				email = account.Email
+			}
+		}
+		if email == "" {
+			problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "Invalid organizer token")
+			return
+		}
+		c.Set("organizerEmail", email)
+
+		c.Next()
+	}
+}
@@ -4,32 +4,56 @@ import (
 	"net/http"
 	"sync"
 
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/service"
+
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
 // RateLimiter creates a Gin middleware for rate limiting
 func RateLimiter(rps int) gin.HandlerFunc {
+	return rateLimiter(func() int { return rps })
+}
+
+// RateLimiterFunc creates a Gin middleware for rate limiting at the rate rps
+// returns, read on every request
+func RateLimiterFunc(rps func() int) gin.HandlerFunc {
+	return rateLimiter(rps)
+}
+
+// RuntimeRateLimiter creates a Gin middleware for rate limiting at the rate
+// of the runtime settings in effect, so operators can change it without a
+// deploy
+func RuntimeRateLimiter(settings service.RuntimeSettingsService) gin.HandlerFunc {
+	return rateLimiter(func() int { return settings.Current().RateLimit })
+}
+
+// rateLimiter limits every client IP to rps requests per second, reading the
+// rate on every request
+func rateLimiter(rps func() int) gin.HandlerFunc {
 	// Create a map to store limiters for each client IP
 	limiters := &sync.Map{}
 
-	// Define the limit rate (requests per second)
-	limit := rate.Limit(rps)
-
 	return func(c *gin.Context) {
+		// Define the limit rate (requests per second)
+		burst := rps()
+		limit := rate.Limit(burst)
+
 		// Get client IP
 		clientIP := c.ClientIP()
 
-		// Get or create limiter for this client
-		limiterI, _ := limiters.LoadOrStore(clientIP, rate.NewLimiter(limit, rps))
+		// Get or create limiter for this client, following rate changes
+		limiterI, _ := limiters.LoadOrStore(clientIP, rate.NewLimiter(limit, burst))
 		limiter := limiterI.(*rate.Limiter)
+		if limiter.Limit() != limit || limiter.Burst() != burst {
+			limiter.SetLimit(limit)
+			limiter.SetBurst(burst)
+		}
 
 		// Check if the request can proceed
 		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-			})
-			c.Abort()
+			problem.Write(c, http.StatusTooManyRequests, problem.CodeRateLimited, "Rate limit exceeded")
 			return
 		}
 
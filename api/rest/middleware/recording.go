@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/traffic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrafficRecorder creates a Gin middleware that writes the API requests of
+// the sampled clients to a traffic recording. Only matched /api routes are
+// recorded; WebSocket connections can't be replayed as requests.
+func TrafficRecorder(recorder *traffic.Recorder, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if !strings.HasPrefix(route, "/api/") || c.IsWebsocket() || !recorder.Sampled(c.ClientIP()) {
+			c.Next()
+			return
+		}
+
+		req := traffic.Request{
+			Time:     time.Now(),
+			Method:   c.Request.Method,
+			Route:    route,
+			Query:    c.Request.URL.Query(),
+			Header:   c.Request.Header.Clone(),
+			ClientIP: c.ClientIP(),
+		}
+
+		// Read the body up to the limit and hand the handler everything read
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(recorder.MaxBodyBytes())+1))
+			if err == nil {
+				if len(body) > recorder.MaxBodyBytes() {
+					req.BodyOmitted = true
+				} else {
+					req.Body = body
+				}
+			}
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+
+		c.Next()
+
+		req.Latency = time.Since(req.Time)
+		req.Status = c.Writer.Status()
+		req.Params = make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			req.Params[param.Key] = param.Value
+		}
+		_, req.Admin = c.Get("adminActor")
+
+		if err := recorder.Record(req); err != nil {
+			log.Warn("Failed to record request: %v", err)
+		}
+	}
+}
+
+// readCloser reads from a reader and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
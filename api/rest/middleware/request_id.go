@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"concert-ticket-api/pkg/trace"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the request and response header carrying the ID of a
+// request
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID creates a Gin middleware that attaches a request ID to every
+// request. The X-Request-ID of the caller is kept when it is valid, so a
+// failed booking can be found in the logs by the ID the client logged;
+// otherwise a new one is generated. The header is set on the request as well,
+// so the REST gateway forwards the ID to the gRPC server.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !trace.ValidRequestID(id) {
+			id = trace.NewRequestID()
+		}
+
+		c.Request.Header.Set(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(trace.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders creates a Gin middleware that adds the configured security
+// headers to every response. Headers configured as empty are not sent.
+func SecurityHeaders(cfg config.SecurityHeaders) gin.HandlerFunc {
+	headers := map[string]string{
+		"X-Content-Type-Options":  cfg.ContentTypeOptions,
+		"X-Frame-Options":         cfg.FrameOptions,
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+	}
+
+	if cfg.HSTSMaxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", int64(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+
+	return func(c *gin.Context) {
+		// Set before the handler runs so the headers are present on every response
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	inFlightRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_in_flight_requests",
+		Help: "API requests being served, by kind (read or write).",
+	}, []string{"kind"})
+	queuedRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_queued_requests",
+		Help: "API requests waiting for a slot to be served, by kind (read or write).",
+	}, []string{"kind"})
+	shedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_shed_requests_total",
+		Help: "API requests rejected by load shedding, by kind and reason (queue_full, queue_timeout, canceled).",
+	}, []string{"kind", "reason"})
+)
+
+// LoadShedding creates a Gin middleware that serves at most
+// cfg.MaxInFlight API requests at once, of which at most
+// cfg.MaxInFlightWrites change state. Requests past the limits wait in a
+// queue of cfg.MaxQueue for up to cfg.QueueTimeout; those that find it full
+// or wait too long are rejected with 503 before any work is done. Reads are
+// preferred: they are admitted before queued writes and take the place of
+// the last queued write when the queue is full, so concerts keep loading
+// while an on-sale spike of bookings is shed.
+func LoadShedding(cfg config.LoadShedding) gin.HandlerFunc {
+	s := newShedder(cfg)
+
+	return func(c *gin.Context) {
+		// Only API routes do work worth shedding, and a WebSocket would hold
+		// its slot as long as it stays open
+		path := c.Request.URL.Path
+		if (!strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/gateway/")) || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		write := isWrite(c.Request.Method)
+		if reason := s.acquire(c.Request.Context(), write, cfg.QueueTimeout); reason != "" {
+			shedRequests.WithLabelValues(requestKind(write), reason).Inc()
+			c.Header("Retry-After", formatAge(cfg.RetryAfter))
+			problem.Write(c, http.StatusServiceUnavailable, problem.CodeServiceUnavailable, "The server is busy, please try again")
+			return
+		}
+		defer s.release(write)
+
+		c.Next()
+	}
+}
+
+func requestKind(write bool) string {
+	if write {
+		return "write"
+	}
+	return "read"
+}
+
+// waiter is a queued request, admitted when ready is closed
+type waiter struct {
+	write   bool
+	ready   chan struct{}
+	element *list.Element
+	// admitted or shed tell why ready was closed
+	admitted bool
+	shed     bool
+}
+
+// shedder counts the requests in flight and queues those past the limits
+type shedder struct {
+	maxInFlight int
+	maxWrites   int
+	maxQueue    int
+
+	mu         sync.Mutex
+	inFlight   int
+	writes     int
+	readQueue  *list.List
+	writeQueue *list.List
+}
+
+func newShedder(cfg config.LoadShedding) *shedder {
+	return &shedder{
+		maxInFlight: cfg.MaxInFlight,
+		maxWrites:   cfg.MaxInFlightWrites,
+		maxQueue:    cfg.MaxQueue,
+		readQueue:   list.New(),
+		writeQueue:  list.New(),
+	}
+}
+
+// acquire takes a slot for a request, waiting in the queue for up to
+// timeout, or returns the reason it was shed
+func (s *shedder) acquire(ctx context.Context, write bool, timeout time.Duration) string {
+	s.mu.Lock()
+	if s.admits(write) && s.queue(write).Len() == 0 {
+		s.admit(write)
+		s.mu.Unlock()
+		return ""
+	}
+
+	if s.readQueue.Len()+s.writeQueue.Len() >= s.maxQueue {
+		// A read pushes the last queued write out of a full queue
+		if write || s.writeQueue.Len() == 0 {
+			s.mu.Unlock()
+			return "queue_full"
+		}
+		s.shed(s.writeQueue.Back().Value.(*waiter))
+	}
+
+	w := &waiter{write: write, ready: make(chan struct{})}
+	w.element = s.queue(write).PushBack(w)
+	s.observe()
+	s.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	reason := ""
+	select {
+	case <-w.ready:
+	case <-timer.C:
+		reason = "queue_timeout"
+	case <-ctx.Done():
+		reason = "canceled"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case w.admitted:
+		// Admitted as the wait ended; the slot is the request's now
+		return ""
+	case w.shed:
+		return "queue_full"
+	}
+	s.queue(write).Remove(w.element)
+	s.observe()
+	return reason
+}
+
+// release frees the slot of a request and hands it to the first queued
+// read, or to the first queued write while writes are under their limit
+func (s *shedder) release(write bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	if write {
+		s.writes--
+	}
+
+	for {
+		var next *list.Element
+		switch {
+		case s.readQueue.Len() > 0 && s.admits(false):
+			next = s.readQueue.Front()
+		case s.writeQueue.Len() > 0 && s.admits(true):
+			next = s.writeQueue.Front()
+		}
+		if next == nil {
+			break
+		}
+
+		w := next.Value.(*waiter)
+		s.queue(w.write).Remove(next)
+		s.admit(w.write)
+		w.admitted = true
+		close(w.ready)
+	}
+	s.observe()
+}
+
+// admits reports whether a request fits in the limits; the caller holds mu
+func (s *shedder) admits(write bool) bool {
+	return s.inFlight < s.maxInFlight && (!write || s.writes < s.maxWrites)
+}
+
+func (s *shedder) admit(write bool) {
+	s.inFlight++
+	if write {
+		s.writes++
+	}
+	s.observe()
+}
+
+func (s *shedder) shed(w *waiter) {
+	s.queue(w.write).Remove(w.element)
+	w.shed = true
+	close(w.ready)
+}
+
+func (s *shedder) queue(write bool) *list.List {
+	if write {
+		return s.writeQueue
+	}
+	return s.readQueue
+}
+
+func (s *shedder) observe() {
+	inFlightRequests.WithLabelValues("write").Set(float64(s.writes))
+	inFlightRequests.WithLabelValues("read").Set(float64(s.inFlight - s.writes))
+	queuedRequests.WithLabelValues("write").Set(float64(s.writeQueue.Len()))
+	queuedRequests.WithLabelValues("read").Set(float64(s.readQueue.Len()))
+}
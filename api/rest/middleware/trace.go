@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"concert-ticket-api/pkg/trace"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TraceIDHeader is the response header carrying the trace ID of a request
+const TraceIDHeader = "X-Trace-ID"
+
+// TraceID creates a Gin middleware that attaches a trace ID to every request.
+// The trace ID of an incoming W3C traceparent header is kept, so errors can be
+// correlated with the caller's trace; otherwise a new one is generated.
+func TraceID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := trace.ParseTraceparent(c.GetHeader("traceparent"))
+		if !ok {
+			id = trace.NewID()
+		}
+
+		c.Request = c.Request.WithContext(trace.WithID(c.Request.Context(), id))
+		c.Header(TraceIDHeader, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,192 @@
+// Package problem renders REST API errors as RFC 7807 problem details
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/trace"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType is the media type of problem details responses
+const ContentType = "application/problem+json"
+
+// Codes identify the kind of problem. Unlike the human-readable detail they
+// are part of the API contract: clients may branch on them, so existing codes
+// must never be renamed. The codes of service errors are declared with their
+// statuses in pkg/errors; the others are specific to the REST API.
+const (
+	CodeInvalidInput            = pkgErr.CodeInvalidInput
+	CodeNotFound                = pkgErr.CodeNotFound
+	CodeConcertNotFound         = "CONCERT_NOT_FOUND"
+	CodeBookingNotFound         = "BOOKING_NOT_FOUND"
+	CodeOrderNotFound           = "ORDER_NOT_FOUND"
+	CodeBookingClosed           = pkgErr.CodeBookingClosed
+	CodeInsufficientTickets     = pkgErr.CodeInsufficientTickets
+	CodeBookingConflict         = pkgErr.CodeBookingConflict
+	CodeBookingAlreadyCancelled = pkgErr.CodeBookingAlreadyCancelled
+	CodeOrderAlreadyCancelled   = pkgErr.CodeOrderAlreadyCancelled
+	CodeBookingTokenRequired    = pkgErr.CodeBookingTokenRequired
+	CodeInvalidBookingToken     = pkgErr.CodeInvalidBookingToken
+	CodeInviteRedeemed          = pkgErr.CodeInviteRedeemed
+	CodeIdempotencyKeyInUse     = pkgErr.CodeIdempotencyKeyInUse
+	CodePaymentUnavailable      = pkgErr.CodePaymentUnavailable
+	CodePreconditionRequired    = "PRECONDITION_REQUIRED"
+	CodePreconditionFailed      = pkgErr.CodePreconditionFailed
+	CodeUnauthorized            = "UNAUTHORIZED"
+	CodeForbidden               = pkgErr.CodeForbidden
+	CodeAdminDisabled           = "ADMIN_DISABLED"
+	CodeRateLimited             = pkgErr.CodeRateLimited
+	CodeServiceUnavailable      = pkgErr.CodeServiceUnavailable
+	CodeMaintenance             = "MAINTENANCE"
+	CodeInternal                = pkgErr.CodeInternal
+)
+
+// Details is the body of every error response
+type Details struct {
+	// Type is always about:blank; the code identifies the problem instead
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// TraceID correlates the error with the server logs
+	TraceID string `json:"trace_id,omitempty"`
+	// RequestID is the X-Request-ID of the request, the caller's own if it
+	// sent one
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of a rejected request body
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why one field of a request body was rejected
+type FieldError struct {
+	// Field is the JSON path of the field, such as ticketCount
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ContentType documents problem details as application/problem+json
+func (Details) ContentType() string {
+	return ContentType
+}
+
+func init() {
+	// Report validation errors by JSON field name instead of Go field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// Write responds with a problem and aborts the handler chain
+func Write(c *gin.Context, status int, code, detail string) {
+	write(c, status, code, detail, nil)
+}
+
+// Error responds with the status and code pkg/errors registers err with. The
+// detail is the message err carries, or the registered one; errors that
+// aren't registered are reported as 500 Internal Server Error with the
+// fallback detail so internals don't leak.
+func Error(c *gin.Context, err error, fallback string) {
+	kind, ok := pkgErr.Lookup(err)
+	if kind.RetryAfter >= time.Second {
+		c.Header("Retry-After", strconv.Itoa(int(kind.RetryAfter/time.Second)))
+	}
+	detail := fallback
+	if ok && kind.Err != pkgErr.ErrInternalServer {
+		detail = pkgErr.MessageOf(err, fallback)
+	} else {
+		// The message isn't shown to the caller, so the request logger logs
+		// it under the request ID instead
+		_ = c.Error(err)
+	}
+	write(c, kind.HTTPStatus, kind.Code, detail, nil)
+}
+
+// InvalidBody responds with 400 Bad Request for a request body that failed to
+// bind, listing the offending fields when err identifies them
+func InvalidBody(c *gin.Context, detail string, err error) {
+	write(c, http.StatusBadRequest, CodeInvalidInput, detail, FieldErrors(err))
+}
+
+// FieldErrors extracts the invalid fields from a binding error
+func FieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{Field: fieldPath(fe), Message: validationMessage(fe)})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()}}
+	}
+	return nil
+}
+
+func write(c *gin.Context, status int, code, detail string, fields []FieldError) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(status, Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		TraceID:   trace.ID(c.Request.Context()),
+		RequestID: trace.RequestID(c.Request.Context()),
+		Errors:    fields,
+	})
+}
+
+// fieldPath strips the name of the bound struct from the namespace, so
+// Concert.ticketCount becomes ticketCount
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be an email address"
+	case "len":
+		return "must have length " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
@@ -4,67 +4,396 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	graphqlapi "concert-ticket-api/api/graphql"
+	grpcapi "concert-ticket-api/api/grpc"
 	"concert-ticket-api/api/rest/handler"
 	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
 	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/health"
 	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/pagelink"
+	"concert-ticket-api/pkg/traffic"
+	"concert-ticket-api/pkg/worker"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// defaultRateLimit is the per-IP rate limit, in requests per second, of
+// servers configured without one
+const defaultRateLimit = 500
+
 // Server represents a REST API server
 type Server struct {
 	router         *gin.Engine
 	httpServer     *http.Server
 	concertHandler *handler.ConcertHandler
 	bookingHandler *handler.BookingHandler
+	userHandler    *handler.UserHandler
+	adminHandler   *handler.AdminHandler
+	tokenHandler   *handler.BookingTokenHandler
+	recorder       *traffic.Recorder
 	logger         logger.Logger
+	// rateLimit is the per-IP rate limit without runtime settings
+	rateLimit *atomic.Int64
 }
 
 // NewServer creates a new REST API server
 func NewServer(
 	concertService service.ConcertService,
 	bookingService service.BookingService,
+	userDataService service.UserDataService,
+	calendarService service.CalendarService,
+	conflictTracker service.ConflictTracker,
+	tokenService service.BookingTokenService,
+	salesReportService service.SalesReportService,
+	accountingService service.AccountingService,
+	attemptService service.BookingAttemptService,
+	analyticsService service.AnalyticsService,
+	releaseService service.InventoryReleaseService,
+	inviteService service.InviteService,
+	cartService service.CartService,
+	orderService service.OrderService,
+	webhookService service.WebhookService,
+	preferenceService service.NotificationPreferenceService,
+	paymentService service.PaymentService,
+	pageService service.TicketPageService,
+	waitingRoom service.WaitingRoom,
+	admission service.AdmissionController,
+	bus *events.Bus,
+	healthRegistry *health.Registry,
+	workers *worker.Registry,
+	settings service.RuntimeSettingsService,
 	logger logger.Logger,
-	port int,
+	cfg *config.Config,
 ) *Server {
 	// Create Gin router
 	router := gin.New()
+	router.NoRoute(func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "Route not found")
+	})
 
 	// Set up middleware
-	router.Use(gin.Recovery())
-	router.Use(middleware.RequestLogger(logger))
-	router.Use(middleware.RateLimiter(500)) // 500 requests per second
-
-	// Set up CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
 	}))
+	router.Use(middleware.TraceID())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(logger))
+
+	// Record a sample of anonymized requests for replaying on staging
+	var recorder *traffic.Recorder
+	if cfg.Recording.Enabled {
+		var err error
+		if recorder, err = traffic.OpenRecorder(cfg.Recording); err != nil {
+			logger.Error("Failed to start traffic recording: %v", err)
+		} else {
+			logger.Info("Recording %.2f%% of clients to %s", cfg.Recording.SampleRate*100, cfg.Recording.Path)
+			router.Use(middleware.TrafficRecorder(recorder, logger))
+		}
+	}
+
+	router.Use(middleware.SecurityHeaders(cfg.SecurityHeaders))
+	router.Use(middleware.LatencyBudget(cfg.Latency, logger))
+	corsConfig := cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     cfg.CORS.AllowMethods,
+		AllowHeaders:     cfg.CORS.AllowHeaders,
+		ExposeHeaders:    cfg.CORS.ExposeHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	}
+	allowOrigins := func() []string { return cfg.CORS.AllowOrigins }
+	rateLimit := &atomic.Int64{}
+	rateLimit.Store(defaultRateLimit)
+	if cfg.Runtime.RateLimit > 0 {
+		rateLimit.Store(int64(cfg.Runtime.RateLimit))
+	}
+
+	// Operators change the rate limit, allowed origins and maintenance mode
+	// at runtime through the internal admin listener. Maintenance responses
+	// carry the CORS headers, so browser clients can show them.
+	if settings != nil {
+		router.Use(middleware.RuntimeRateLimiter(settings))
+		router.Use(middleware.InviteToken())
+		router.Use(middleware.RuntimeCORS(corsConfig, settings))
+		router.Use(middleware.Maintenance(settings))
+		allowOrigins = func() []string { return settings.Current().CORSAllowOrigins }
+	} else {
+		router.Use(middleware.RateLimiterFunc(func() int { return int(rateLimit.Load()) }))
+		router.Use(middleware.InviteToken())
+		router.Use(cors.New(corsConfig))
+	}
+
+	// Serve cached reads and reject writes while the database is down
+	if cfg.Degradation.Enabled {
+		router.Use(middleware.Degradation(cfg.Degradation, healthRegistry))
+	}
+
+	// Take no new writes once the server is shutting down
+	router.Use(middleware.Draining(healthRegistry))
+
+	// Reject requests past the in-flight limits before they do any work
+	if cfg.LoadShedding.Enabled {
+		router.Use(middleware.LoadShedding(cfg.LoadShedding))
+	}
 
 	// Create handlers
 	concertHandler := handler.NewConcertHandler(concertService)
 	bookingHandler := handler.NewBookingHandler(bookingService)
+	userHandler := handler.NewUserHandler(userDataService)
+	adminHandler := handler.NewAdminHandler(conflictTracker, salesReportService, accountingService, attemptService)
+	tokenHandler := handler.NewBookingTokenHandler(tokenService)
+	inviteHandler := handler.NewInviteHandler(inviteService)
+
+	// Releases are served when the server is given a release service
+	var releaseHandler *handler.InventoryReleaseHandler
+	if releaseService != nil {
+		releaseHandler = handler.NewInventoryReleaseHandler(releaseService)
+	}
 
-	// Register routes
-	concertHandler.RegisterRoutes(router)
-	bookingHandler.RegisterRoutes(router)
+	// routes registers the handlers of an API version on its group and
+	// documents them with paths relative to the group
+	var routes func(group *gin.RouterGroup) []openapi.Operation
+	if cfg.ReadOnly.Enabled {
+		// Public mirrors only serve reads that need no credentials, cached by a CDN
+		logger.Info("Read-only mode, only public read endpoints are served")
+		router.Use(middleware.PublicCache(cfg.ReadOnly))
+		routes = func(group *gin.RouterGroup) []openapi.Operation {
+			concertHandler.RegisterReadRoutes(group)
+			operations := concertHandler.Operations()
+			if releaseHandler != nil {
+				releaseHandler.RegisterReadRoutes(group)
+				operations = append(operations, releaseHandler.Operations()...)
+			}
+			return readOperations(operations)
+		}
+	} else {
+		adminAuth := middleware.AdminAuth(cfg.Admin.Token)
+		organizerAuth := middleware.OrganizerAuth(cfg.Organizers.Accounts)
 
-	// Add health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "up"})
-	})
+		// The test clock lets staging fast-forward time, so it is opt-in
+		var clockHandler *handler.TestClockHandler
+		if cfg.TestClock.Enabled {
+			logger.Warn("Test clock API is enabled, the process clock can be shifted through /api/v1/admin/test-clock")
+			clockHandler = handler.NewTestClockHandler(clock.Process())
+		}
+
+		// Ticket and receipt pages are only served when a signing key is
+		// configured, and so are the calendar feeds the key signs
+		var calendarHandler *handler.CalendarHandler
+		if calendarService != nil {
+			calendarHandler = handler.NewCalendarHandler(calendarService)
+		}
+
+		var pageHandler *handler.TicketPageHandler
+		if pageService != nil {
+			pageHandler = handler.NewTicketPageHandler(pageService)
+			pageHandler.RegisterPageRoutes(router)
+		}
+
+		// Carts are served when the server is given a cart service
+		var cartHandler *handler.CartHandler
+		if cartService != nil {
+			cartHandler = handler.NewCartHandler(cartService)
+		}
+
+		// Orders are served when the server is given an order service
+		var orderHandler *handler.OrderHandler
+		if orderService != nil {
+			orderHandler = handler.NewOrderHandler(orderService)
+		}
+
+		// Webhooks are served when the server is given a webhook service
+		var webhookHandler *handler.WebhookHandler
+		if webhookService != nil {
+			webhookHandler = handler.NewWebhookHandler(webhookService)
+		}
+
+		// Notification preferences are served where they are stored
+		var preferenceHandler *handler.NotificationPreferenceHandler
+		if preferenceService != nil {
+			preferenceHandler = handler.NewNotificationPreferenceHandler(preferenceService)
+		}
+
+		// Payment notifications are served when bookings are paid
+		var paymentHandler *handler.PaymentHandler
+		if paymentService != nil {
+			paymentHandler = handler.NewPaymentHandler(paymentService)
+		}
+
+		// The organizer dashboard is served where its aggregates are queried
+		var organizerHandler *handler.OrganizerHandler
+		if analyticsService != nil {
+			organizerHandler = handler.NewOrganizerHandler(analyticsService)
+		}
+
+		// The admission rate is served when the controller is enabled
+		var admissionHandler *handler.AdmissionHandler
+		if admission != nil {
+			admissionHandler = handler.NewAdmissionHandler(admission, logger)
+		}
+
+		// Read-only mirrors and tests run without background workers
+		var workerHandler *handler.WorkerHandler
+		if workers != nil {
+			workerHandler = handler.NewWorkerHandler(workers, logger)
+		}
+
+		routes = func(group *gin.RouterGroup) []openapi.Operation {
+			concertHandler.RegisterRoutes(group)
+			bookingHandler.RegisterRoutes(group)
+			tokenHandler.RegisterRoutes(group)
+			userHandler.RegisterRoutes(group, adminAuth)
+			adminHandler.RegisterRoutes(group, adminAuth)
+			if releaseHandler != nil {
+				releaseHandler.RegisterRoutes(group, adminAuth)
+			}
+			inviteHandler.RegisterRoutes(group, adminAuth)
+
+			var operations []openapi.Operation
+			operations = append(operations, concertHandler.Operations()...)
+			operations = append(operations, bookingHandler.Operations()...)
+			operations = append(operations, tokenHandler.Operations()...)
+			operations = append(operations, userHandler.Operations()...)
+			operations = append(operations, adminHandler.Operations()...)
+			if releaseHandler != nil {
+				operations = append(operations, releaseHandler.Operations()...)
+			}
+			operations = append(operations, inviteHandler.Operations()...)
+
+			if cartHandler != nil {
+				cartHandler.RegisterRoutes(group)
+				operations = append(operations, cartHandler.Operations()...)
+			}
+
+			if orderHandler != nil {
+				orderHandler.RegisterRoutes(group)
+				operations = append(operations, orderHandler.Operations()...)
+			}
+
+			if webhookHandler != nil {
+				webhookHandler.RegisterRoutes(group, organizerAuth)
+				operations = append(operations, webhookHandler.Operations()...)
+			}
+
+			if preferenceHandler != nil {
+				preferenceHandler.RegisterRoutes(group)
+				operations = append(operations, preferenceHandler.Operations()...)
+			}
+
+			if paymentHandler != nil {
+				paymentHandler.RegisterRoutes(group)
+				operations = append(operations, paymentHandler.Operations()...)
+			}
+
+			if pageHandler != nil {
+				pageHandler.RegisterRoutes(group)
+				operations = append(operations, pageHandler.Operations()...)
+			}
+
+			if calendarHandler != nil {
+				calendarHandler.RegisterRoutes(group)
+				operations = append(operations, calendarHandler.Operations()...)
+			}
+
+			if organizerHandler != nil {
+				organizerHandler.RegisterRoutes(group, organizerAuth)
+				operations = append(operations, organizerHandler.Operations()...)
+			}
+
+			if workerHandler != nil {
+				workerHandler.RegisterRoutes(group, adminAuth)
+				operations = append(operations, workerHandler.Operations()...)
+			}
+
+			if admissionHandler != nil {
+				admissionHandler.RegisterRoutes(group, adminAuth)
+				operations = append(operations, admissionHandler.Operations()...)
+			}
+
+			if clockHandler != nil {
+				clockHandler.RegisterRoutes(group, adminAuth)
+				operations = append(operations, clockHandler.Operations()...)
+			}
+			return authResponses(operations)
+		}
+	}
+
+	// Every API version is wired separately. /api/v2 serves the v1 handlers
+	// until a breaking change, such as the ticket tier remodel, gives it
+	// handlers of its own; /api/v1 keeps working unchanged.
+	versions := []apiVersion{{name: "v1", cfg: cfg.API.V1, routes: routes}}
+	if cfg.API.V2Enabled {
+		versions = append(versions, apiVersion{name: "v2", cfg: cfg.API.V2, routes: routes})
+	}
+	operations := mountVersions(router, versions)
+
+	// Document the routes registered above, derived from their handler types
+	document := openapi.Build(openapi.Info{Title: "Concert Ticket API", Version: "1.0.0"}, operations)
+	if openAPIHandler, err := handler.NewOpenAPIHandler(document); err != nil {
+		logger.Error("Failed to encode OpenAPI document: %v", err)
+	} else {
+		openAPIHandler.RegisterRoutes(router)
+	}
+
+	// Serve the REST gateway generated from the proto files next to the
+	// hand-written handlers. It calls the gRPC server over loopback.
+	if cfg.Gateway.Enabled {
+		gateway, err := grpcapi.NewGateway(context.Background(), fmt.Sprintf("localhost:%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("Failed to create REST gateway: %v", err)
+		} else if cfg.ReadOnly.Enabled {
+			router.GET(grpcapi.GatewayPrefix+"/*path", gin.WrapH(gateway))
+		} else {
+			router.Any(grpcapi.GatewayPrefix+"/*path", gin.WrapH(gateway))
+		}
+	}
+
+	// GraphQL offers the concert and booking services as one query surface.
+	// Its queries are POSTed, so mirrors can't cache them and don't serve it.
+	if cfg.GraphQL.Enabled && !cfg.ReadOnly.Enabled {
+		graphqlHandler, err := graphqlapi.NewHandler(concertService, bookingService, cfg.GraphQL.MaxDepth)
+		if err != nil {
+			logger.Error("Failed to create GraphQL handler: %v", err)
+		} else {
+			router.POST("/graphql", gin.WrapH(graphqlHandler))
+		}
+	}
+
+	// Push waiting room positions and booking status changes instead of
+	// having clients poll. Mirrors have no users, so they don't serve it,
+	// and without a signing key users can't prove who they are.
+	if cfg.WebSocket.Enabled && !cfg.ReadOnly.Enabled {
+		if cfg.WebSocket.SigningKey == "" {
+			logger.Warn("Not serving /ws without websocket.signing_key")
+		} else {
+			handler.NewWebSocketHandler(waitingRoom, bus, pagelink.NewSigner([]byte(cfg.WebSocket.SigningKey)), allowOrigins).RegisterRoutes(router)
+		}
+	}
+
+	// Expose Prometheus metrics to scrapers holding the admin token. They
+	// name routes, workers and queue sizes, so they aren't public.
+	router.GET("/metrics", middleware.AdminAuth(cfg.Admin.Token), gin.WrapH(promhttp.Handler()))
+
+	// Add health check endpoints: /health/live for liveness probes, which
+	// only restart a replica that stopped answering, and /health/ready for
+	// readiness probes, which take it out of the load balancer while a
+	// required dependency is down. /health is kept for existing probes.
+	handler.NewHealthHandler(healthRegistry).RegisterRoutes(router)
 
 	// Create HTTP server
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
+		Addr:         fmt.Sprintf(":%d", cfg.RESTPort),
 		Handler:      router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -72,22 +401,81 @@ func NewServer(
 	}
 
 	return &Server{
+		rateLimit:      rateLimit,
 		router:         router,
 		httpServer:     httpServer,
 		concertHandler: concertHandler,
 		bookingHandler: bookingHandler,
+		userHandler:    userHandler,
+		adminHandler:   adminHandler,
+		tokenHandler:   tokenHandler,
+		recorder:       recorder,
 		logger:         logger,
 	}
 }
 
+// authResponses documents the problems the admin and organizer middlewares
+// answer with on the operations that require their tokens
+func authResponses(operations []openapi.Operation) []openapi.Operation {
+	for i, operation := range operations {
+		if !operation.Admin && !operation.Organizer {
+			continue
+		}
+		responses := make(map[int]interface{}, len(operation.Responses)+2)
+		for status, body := range operation.Responses {
+			responses[status] = body
+		}
+		responses[http.StatusUnauthorized] = problem.Details{}
+		responses[http.StatusForbidden] = problem.Details{}
+		operations[i].Responses = responses
+	}
+	return operations
+}
+
+// readOperations keeps the public reads of the documented operations
+func readOperations(groups ...[]openapi.Operation) []openapi.Operation {
+	var operations []openapi.Operation
+	for _, group := range groups {
+		for _, operation := range group {
+			if (operation.Method == http.MethodGet || operation.Method == http.MethodHead) && !operation.Admin && !operation.Organizer {
+				operations = append(operations, operation)
+			}
+		}
+	}
+	return operations
+}
+
+// Routes lists the routes registered on the server
+func (s *Server) Routes() gin.RoutesInfo {
+	return s.router.Routes()
+}
+
+// Handler returns the HTTP handler of the server
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
 // Start starts the server
 func (s *Server) Start() error {
 	s.logger.Info("Starting REST API server on %s", s.httpServer.Addr)
 	return s.httpServer.ListenAndServe()
 }
 
+// SetRateLimit changes the per-IP rate limit of a server without runtime
+// settings, when the configuration is reloaded. With runtime settings the
+// limit is theirs.
+func (s *Server) SetRateLimit(rps int) {
+	s.rateLimit.Store(int64(rps))
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down REST API server")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.recorder != nil {
+		if closeErr := s.recorder.Close(); closeErr != nil {
+			s.logger.Error("Failed to close traffic recording: %v", closeErr)
+		}
+	}
+	return err
 }
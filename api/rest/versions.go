@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersion is a version of the REST API, served under /api/<name>
+type apiVersion struct {
+	name string
+	cfg  config.APIVersion
+	// routes registers the handlers of the version on its group and
+	// documents them with paths relative to the group
+	routes func(group *gin.RouterGroup) []openapi.Operation
+}
+
+// mountVersions registers every version under its prefix and returns the
+// documented operations of all of them. Versions are given oldest first, and
+// deprecated versions link to the route of the next one as their successor.
+func mountVersions(router *gin.Engine, versions []apiVersion) []openapi.Operation {
+	var operations []openapi.Operation
+	for i, version := range versions {
+		prefix := "/api/" + version.name
+		var successor string
+		if i+1 < len(versions) {
+			successor = "/api/" + versions[i+1].name
+		}
+
+		group := router.Group(prefix, middleware.Deprecation(version.cfg, prefix, successor))
+		deprecatedAt, _, _ := version.cfg.Schedule()
+		operations = append(operations, openapi.Mount(prefix, version.routes(group), !deprecatedAt.IsZero())...)
+	}
+	return operations
+}
@@ -0,0 +1,210 @@
+// Package client is a typed Go client for the concert ticket API, for
+// services that call it over REST or gRPC. Both transports implement the same
+// ConcertsClient and BookingsClient interfaces, retry what is safe to retry
+// and page through listings with iterators.
+//
+//	c := client.NewREST("https://tickets.example.com", client.Options{})
+//	for concert, err := range c.Concerts().List(ctx, client.ListConcertsOptions{Artist: "Radiohead"}) {
+//		...
+//	}
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ConcertsClient reads concerts
+type ConcertsClient interface {
+	// Get returns a concert by ID
+	Get(ctx context.Context, id int64) (*Concert, error)
+
+	// List returns the concerts matching opts, fetching pages as the
+	// iteration goes on. An error ends the iteration.
+	List(ctx context.Context, opts ListConcertsOptions) iter.Seq2[*Concert, error]
+}
+
+// BookingsClient books tickets and manages bookings
+type BookingsClient interface {
+	// Book books tickets. Retries send the same idempotency key, so a
+	// booking is made at most once however many attempts it takes.
+	Book(ctx context.Context, req BookRequest) (*Booking, error)
+
+	// Get returns a booking by reference
+	Get(ctx context.Context, reference string) (*Booking, error)
+
+	// ListByUser returns the bookings of a user, fetching pages as the
+	// iteration goes on. An error ends the iteration.
+	ListByUser(ctx context.Context, userID string) iter.Seq2[*Booking, error]
+
+	// Cancel cancels a booking of a user
+	Cancel(ctx context.Context, reference, userID string) error
+}
+
+// Options configure a client
+type Options struct {
+	// Token is sent as a bearer token. gRPC clients need one for bookings.
+	Token string
+
+	// Retry is the retry policy. The zero value retries with the defaults
+	// of DefaultRetryPolicy; set MaxAttempts to 1 to disable retries.
+	Retry RetryPolicy
+
+	// PageSize is the page size listings are fetched with, the server's
+	// default if zero
+	PageSize int
+}
+
+// Concert is a concert as the API returns it
+type Concert struct {
+	ID                   int64     `json:"id"`
+	Name                 string    `json:"name"`
+	Artist               string    `json:"artist"`
+	Venue                string    `json:"venue"`
+	ConcertDate          time.Time `json:"concert_date"`
+	TotalTickets         int       `json:"total_tickets"`
+	AvailableTickets     int       `json:"available_tickets"`
+	Price                float64   `json:"price"`
+	CurrentPrice         float64   `json:"current_price"`
+	Currency             string    `json:"currency"`
+	DoorPrice            *float64  `json:"door_price,omitempty"`
+	PricingPhase         string    `json:"pricing_phase,omitempty"`
+	BookingStartTime     time.Time `json:"booking_start_time"`
+	BookingEndTime       time.Time `json:"booking_end_time"`
+	ArtistAliases        []string  `json:"artist_aliases"`
+	VenueAliases         []string  `json:"venue_aliases"`
+	RequiresBookingToken bool      `json:"requires_booking_token"`
+	Visibility           string    `json:"visibility"`
+	// MaxTicketsPerBooking and MaxOrderValue are the concert's own booking
+	// limits; the server's defaults apply when they are nil
+	MaxTicketsPerBooking *int      `json:"max_tickets_per_booking,omitempty"`
+	MaxOrderValue        *float64  `json:"max_order_value,omitempty"`
+	Version              int       `json:"version"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// ListConcertsOptions filter and order a concert listing
+type ListConcertsOptions struct {
+	Artist        string
+	Venue         string
+	Name          string
+	DateFrom      time.Time
+	DateTo        time.Time
+	AvailableOnly bool
+	// Sort is a comma-separated list of sort fields, such as "-price,name"
+	Sort string
+}
+
+// Booking is a booking as the API returns it
+type Booking struct {
+	Reference     string    `json:"reference"`
+	ConcertID     int64     `json:"concert_id"`
+	UserID        string    `json:"user_id"`
+	TicketCount   int       `json:"ticket_count"`
+	Status        string    `json:"status"`
+	BookingTime   time.Time `json:"booking_time"`
+	AttendeeName  string    `json:"attendee_name,omitempty"`
+	AttendeeEmail string    `json:"attendee_email,omitempty"`
+	UnitPrice     float64   `json:"unit_price"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// BookRequest books tickets for a concert
+type BookRequest struct {
+	ConcertID     int64  `json:"concert_id"`
+	UserID        string `json:"user_id"`
+	TicketCount   int    `json:"ticket_count"`
+	AttendeeName  string `json:"attendee_name,omitempty"`
+	AttendeeEmail string `json:"attendee_email,omitempty"`
+	BookingToken  string `json:"booking_token,omitempty"`
+
+	// IdempotencyKey identifies the booking across retries. Book generates
+	// one if it is empty; set it to keep retries safe across restarts of
+	// the caller, e.g. to the ID of the order being fulfilled.
+	IdempotencyKey string `json:"-"`
+}
+
+// Error is an error response of the API. Code is the problem code of REST
+// responses or the ErrorInfo reason of gRPC statuses, which are the same for
+// both transports, such as "INSUFFICIENT_TICKETS".
+type Error struct {
+	Code    string
+	Message string
+	// HTTPStatus is the status of REST responses
+	HTTPStatus int
+	// GRPCCode is the code of gRPC statuses
+	GRPCCode codes.Code
+	// RetryAfter is how long the server asked clients to wait, if it did
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the code of an API error, or "" for other errors
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// alreadyCancelled treats a cancellation a retry finds already done as done.
+// Cancelling isn't idempotent on the server, so a retry after a lost
+// response gets BOOKING_ALREADY_CANCELLED.
+func alreadyCancelled(attempt int, err error) error {
+	if attempt > 1 && ErrorCode(err) == problem.CodeBookingAlreadyCancelled {
+		return nil
+	}
+	return err
+}
+
+// newIdempotencyKey returns a random key for a booking request
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("client: failed to generate idempotency key: %v", err))
+	}
+	return hex.EncodeToString(key)
+}
+
+// paginate iterates over the items of pages fetched by cursor until a page
+// comes without a next cursor
+func paginate[T any](ctx context.Context, fetch func(ctx context.Context, cursor string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cursor := ""
+		for {
+			items, next, err := fetch(ctx, cursor)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}
+}
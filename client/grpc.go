@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	pb "concert-ticket-api/api/grpc/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPC calls the ConcertService and BookingService RPCs
+type GRPC struct {
+	concerts pb.ConcertServiceClient
+	bookings pb.BookingServiceClient
+	opts     Options
+}
+
+// NewGRPC returns a client for the gRPC API reachable through conn
+func NewGRPC(conn grpc.ClientConnInterface, opts Options) *GRPC {
+	return &GRPC{
+		concerts: pb.NewConcertServiceClient(conn),
+		bookings: pb.NewBookingServiceClient(conn),
+		opts:     opts,
+	}
+}
+
+// Concerts returns the concerts client
+func (c *GRPC) Concerts() ConcertsClient { return grpcConcerts{c} }
+
+// Bookings returns the bookings client
+func (c *GRPC) Bookings() BookingsClient { return grpcBookings{c} }
+
+// grpcRetryable reports whether an RPC failed in a way another attempt may
+// not: the server is unavailable or sent RetryInfo, as it does for rate
+// limits and booking conflicts
+func grpcRetryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.GRPCCode == codes.Unavailable || apiErr.RetryAfter > 0
+}
+
+// call makes an RPC with retries, converting status errors to *Error
+func (c *GRPC) call(ctx context.Context, rpc func(ctx context.Context) error) error {
+	return c.opts.Retry.do(ctx, grpcRetryable, func(ctx context.Context, _ int) error {
+		return c.attempt(ctx, rpc)
+	})
+}
+
+// attempt makes one attempt of an RPC
+func (c *GRPC) attempt(ctx context.Context, rpc func(ctx context.Context) error) error {
+	if c.opts.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.opts.Token)
+	}
+	return grpcError(rpc(ctx))
+}
+
+// grpcError converts a status error, reading the problem code from its
+// ErrorInfo and the retry delay from its RetryInfo
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	apiErr := &Error{GRPCCode: st.Code(), Message: st.Message()}
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			apiErr.Code = detail.Reason
+		case *errdetails.RetryInfo:
+			apiErr.RetryAfter = detail.RetryDelay.AsDuration()
+		}
+	}
+	return apiErr
+}
+
+type grpcConcerts struct{ c *GRPC }
+
+func (g grpcConcerts) Get(ctx context.Context, id int64) (*Concert, error) {
+	var resp *pb.Concert
+	err := g.c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = g.c.concerts.GetConcert(ctx, &pb.GetConcertRequest{Id: id})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return concertFromPb(resp), nil
+}
+
+func (g grpcConcerts) List(ctx context.Context, opts ListConcertsOptions) iter.Seq2[*Concert, error] {
+	return paginate(ctx, func(ctx context.Context, cursor string) ([]*Concert, string, error) {
+		req := &pb.ListConcertsRequest{
+			Artist:        opts.Artist,
+			Venue:         opts.Venue,
+			Name:          opts.Name,
+			AvailableOnly: opts.AvailableOnly,
+			Sort:          opts.Sort,
+			Cursor:        cursor,
+		}
+		if cursor == "" {
+			req.PageSize = int32(g.c.opts.PageSize)
+		}
+		if !opts.DateFrom.IsZero() {
+			req.DateFrom = timestamppb.New(opts.DateFrom)
+		}
+		if !opts.DateTo.IsZero() {
+			req.DateTo = timestamppb.New(opts.DateTo)
+		}
+
+		var resp *pb.ListConcertsResponse
+		err := g.c.call(ctx, func(ctx context.Context) (err error) {
+			resp, err = g.c.concerts.ListConcerts(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		concerts := make([]*Concert, len(resp.Concerts))
+		for i, concert := range resp.Concerts {
+			concerts[i] = concertFromPb(concert)
+		}
+		return concerts, resp.GetMeta().GetNextCursor(), nil
+	})
+}
+
+type grpcBookings struct{ c *GRPC }
+
+func (g grpcBookings) Book(ctx context.Context, req BookRequest) (*Booking, error) {
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = newIdempotencyKey()
+	}
+
+	pbReq := &pb.BookTicketsRequest{
+		ConcertId:      req.ConcertID,
+		UserId:         req.UserID,
+		TicketCount:    int32(req.TicketCount),
+		AttendeeName:   req.AttendeeName,
+		AttendeeEmail:  req.AttendeeEmail,
+		BookingToken:   req.BookingToken,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	var resp *pb.Booking
+	err := g.c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = g.c.bookings.BookTickets(ctx, pbReq)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookingFromPb(resp), nil
+}
+
+func (g grpcBookings) Get(ctx context.Context, reference string) (*Booking, error) {
+	var resp *pb.Booking
+	err := g.c.call(ctx, func(ctx context.Context) (err error) {
+		resp, err = g.c.bookings.GetBooking(ctx, &pb.GetBookingRequest{Reference: reference})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookingFromPb(resp), nil
+}
+
+func (g grpcBookings) ListByUser(ctx context.Context, userID string) iter.Seq2[*Booking, error] {
+	return paginate(ctx, func(ctx context.Context, cursor string) ([]*Booking, string, error) {
+		req := &pb.GetUserBookingsRequest{UserId: userID, Cursor: cursor}
+		if cursor == "" {
+			req.PageSize = int32(g.c.opts.PageSize)
+		}
+
+		var resp *pb.GetUserBookingsResponse
+		err := g.c.call(ctx, func(ctx context.Context) (err error) {
+			resp, err = g.c.bookings.GetUserBookings(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		bookings := make([]*Booking, len(resp.Bookings))
+		for i, booking := range resp.Bookings {
+			bookings[i] = bookingFromPb(booking)
+		}
+		return bookings, resp.GetMeta().GetNextCursor(), nil
+	})
+}
+
+func (g grpcBookings) Cancel(ctx context.Context, reference, userID string) error {
+	req := &pb.CancelBookingRequest{Reference: reference, UserId: userID}
+	return g.c.opts.Retry.do(ctx, grpcRetryable, func(ctx context.Context, attempt int) error {
+		return alreadyCancelled(attempt, g.c.attempt(ctx, func(ctx context.Context) error {
+			_, err := g.c.bookings.CancelBooking(ctx, req)
+			return err
+		}))
+	})
+}
+
+func concertFromPb(c *pb.Concert) *Concert {
+	concert := &Concert{
+		ID:                   c.Id,
+		Name:                 c.Name,
+		Artist:               c.Artist,
+		Venue:                c.Venue,
+		ConcertDate:          c.ConcertDate.AsTime(),
+		TotalTickets:         int(c.TotalTickets),
+		AvailableTickets:     int(c.AvailableTickets),
+		Price:                c.Price,
+		CurrentPrice:         c.CurrentPrice,
+		Currency:             c.Currency,
+		PricingPhase:         c.PricingPhase,
+		BookingStartTime:     c.BookingStartTime.AsTime(),
+		BookingEndTime:       c.BookingEndTime.AsTime(),
+		ArtistAliases:        c.ArtistAliases,
+		VenueAliases:         c.VenueAliases,
+		RequiresBookingToken: c.RequiresBookingToken,
+		Visibility:           c.Visibility,
+		Version:              int(c.Version),
+		CreatedAt:            c.CreatedAt.AsTime(),
+		UpdatedAt:            c.UpdatedAt.AsTime(),
+	}
+	if c.DoorPrice != nil {
+		doorPrice := c.GetDoorPrice()
+		concert.DoorPrice = &doorPrice
+	}
+	if c.MaxTicketsPerBooking != nil {
+		maxTickets := int(c.GetMaxTicketsPerBooking())
+		concert.MaxTicketsPerBooking = &maxTickets
+	}
+	if c.MaxOrderValue != nil {
+		maxOrderValue := c.GetMaxOrderValue()
+		concert.MaxOrderValue = &maxOrderValue
+	}
+	return concert
+}
+
+func bookingFromPb(b *pb.Booking) *Booking {
+	return &Booking{
+		Reference:     b.Reference,
+		ConcertID:     b.ConcertId,
+		UserID:        b.UserId,
+		TicketCount:   int(b.TicketCount),
+		Status:        b.Status,
+		BookingTime:   b.BookingTime.AsTime(),
+		AttendeeName:  b.AttendeeName,
+		AttendeeEmail: b.AttendeeEmail,
+		UnitPrice:     b.UnitPrice,
+		CreatedAt:     b.CreatedAt.AsTime(),
+		UpdatedAt:     b.UpdatedAt.AsTime(),
+	}
+}
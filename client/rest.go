@@ -0,0 +1,249 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/api/rest/problem"
+)
+
+// REST calls the REST API under /api/v1
+type REST struct {
+	baseURL    string
+	httpClient *http.Client
+	opts       Options
+}
+
+// NewREST returns a client for the REST API served at baseURL. Requests are
+// sent with httpClient, or http.DefaultClient if it is nil.
+func NewREST(baseURL string, httpClient *http.Client, opts Options) *REST {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &REST{baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1", httpClient: httpClient, opts: opts}
+}
+
+// Concerts returns the concerts client
+func (c *REST) Concerts() ConcertsClient { return restConcerts{c} }
+
+// Bookings returns the bookings client
+func (c *REST) Bookings() BookingsClient { return restBookings{c} }
+
+// restRetryable reports whether a REST call failed in a way another attempt
+// may not: the server is overloaded or unavailable, a booking lost an
+// optimistic lock, or the connection broke
+func restRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch apiErr.Code {
+	case problem.CodeRateLimited, problem.CodeServiceUnavailable, problem.CodeBookingConflict, problem.CodeIdempotencyKeyInUse:
+		return true
+	}
+	switch apiErr.HTTPStatus {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends a request with retries and decodes the JSON response into out
+func (c *REST) do(ctx context.Context, method, path string, header http.Header, body, out interface{}) error {
+	payload, err := encodeBody(body)
+	if err != nil {
+		return err
+	}
+	return c.opts.Retry.do(ctx, restRetryable, func(ctx context.Context, _ int) error {
+		return c.send(ctx, method, path, header, payload, out)
+	})
+}
+
+func encodeBody(body interface{}) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return payload, nil
+}
+
+// send makes one attempt of a request
+func (c *REST) send(ctx context.Context, method, path string, header http.Header, payload []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return restError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// restError reads the problem details of an error response
+func restError(resp *http.Response) error {
+	apiErr := &Error{HTTPStatus: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var details problem.Details
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(body, &details) == nil {
+		apiErr.Code = details.Code
+		if details.Detail != "" {
+			apiErr.Message = details.Detail
+		}
+	}
+	return apiErr
+}
+
+type restConcerts struct{ c *REST }
+
+func (r restConcerts) Get(ctx context.Context, id int64) (*Concert, error) {
+	var concert Concert
+	if err := r.c.do(ctx, http.MethodGet, "/concerts/"+strconv.FormatInt(id, 10), nil, nil, &concert); err != nil {
+		return nil, err
+	}
+	return &concert, nil
+}
+
+func (r restConcerts) List(ctx context.Context, opts ListConcertsOptions) iter.Seq2[*Concert, error] {
+	params := url.Values{}
+	for name, value := range map[string]string{"artist": opts.Artist, "venue": opts.Venue, "name": opts.Name, "sort": opts.Sort} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	if !opts.DateFrom.IsZero() {
+		params.Set("dateFrom", opts.DateFrom.Format(time.RFC3339))
+	}
+	if !opts.DateTo.IsZero() {
+		params.Set("dateTo", opts.DateTo.Format(time.RFC3339))
+	}
+	if opts.AvailableOnly {
+		params.Set("availableOnly", "true")
+	}
+	if r.c.opts.PageSize > 0 {
+		params.Set("pageSize", strconv.Itoa(r.c.opts.PageSize))
+	}
+
+	return paginate(ctx, func(ctx context.Context, cursor string) ([]*Concert, string, error) {
+		page := params
+		if cursor != "" {
+			// The cursor carries the page size and replaces the page params
+			page = url.Values{"cursor": {cursor}}
+			for name, values := range params {
+				if name != "pageSize" {
+					page[name] = values
+				}
+			}
+		}
+
+		var resp struct {
+			Data []*Concert `json:"data"`
+			Meta struct {
+				NextCursor string `json:"nextCursor"`
+			} `json:"meta"`
+		}
+		if err := r.c.do(ctx, http.MethodGet, "/concerts?"+page.Encode(), nil, nil, &resp); err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.Meta.NextCursor, nil
+	})
+}
+
+type restBookings struct{ c *REST }
+
+func (r restBookings) Book(ctx context.Context, req BookRequest) (*Booking, error) {
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = newIdempotencyKey()
+	}
+
+	var booking Booking
+	header := http.Header{"Idempotency-Key": {req.IdempotencyKey}}
+	if err := r.c.do(ctx, http.MethodPost, "/bookings", header, req, &booking); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (r restBookings) Get(ctx context.Context, reference string) (*Booking, error) {
+	var booking Booking
+	if err := r.c.do(ctx, http.MethodGet, "/bookings/"+url.PathEscape(reference), nil, nil, &booking); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (r restBookings) ListByUser(ctx context.Context, userID string) iter.Seq2[*Booking, error] {
+	return paginate(ctx, func(ctx context.Context, cursor string) ([]*Booking, string, error) {
+		params := url.Values{"userID": {userID}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		} else if r.c.opts.PageSize > 0 {
+			params.Set("pageSize", strconv.Itoa(r.c.opts.PageSize))
+		}
+
+		var resp struct {
+			Data []*Booking `json:"data"`
+			Meta struct {
+				NextCursor string `json:"nextCursor"`
+			} `json:"meta"`
+		}
+		if err := r.c.do(ctx, http.MethodGet, "/bookings?"+params.Encode(), nil, nil, &resp); err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.Meta.NextCursor, nil
+	})
+}
+
+func (r restBookings) Cancel(ctx context.Context, reference, userID string) error {
+	payload, err := encodeBody(map[string]string{"userID": userID})
+	if err != nil {
+		return err
+	}
+	path := "/bookings/" + url.PathEscape(reference) + "/cancel"
+	return r.c.opts.Retry.do(ctx, restRetryable, func(ctx context.Context, attempt int) error {
+		return alreadyCancelled(attempt, r.c.send(ctx, http.MethodPost, path, nil, payload, nil))
+	})
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides how often and how long apart failed calls are retried.
+// Only calls that can't take effect twice are retried: reads, bookings with
+// their idempotency key and cancellations.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each
+	// further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including the delays the
+	// server asks for
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used for the fields of a policy left at zero
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// delay is the wait before the given retry, starting at 1. The server's
+// Retry-After or RetryInfo wins over the backoff.
+func (p RetryPolicy) delay(retry int, err error) time.Duration {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, p.MaxDelay)
+	}
+
+	backoff := p.BaseDelay << (retry - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	// Full jitter over the upper half, so clients that failed together
+	// don't retry together
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// do calls attempt until it succeeds, fails with an error retryable doesn't
+// accept, the attempts run out or ctx is done
+func (p RetryPolicy) do(ctx context.Context, retryable func(error) bool, attempt func(ctx context.Context, n int) error) error {
+	p = p.withDefaults()
+
+	var err error
+	for n := 1; ; n++ {
+		err = attempt(ctx, n)
+		if err == nil || n >= p.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(p.delay(n, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
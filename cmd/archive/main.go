@@ -0,0 +1,60 @@
+// cmd/archive/main.go
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/secrets"
+)
+
+// archive moves the bookings of concerts that took place more than -months
+// months ago from the bookings table into the partitioned bookings_archive,
+// so the bookings table stays small for lookups. Archived bookings are still
+// read by the user, order and accounting endpoints. Run it from cron; runs
+// are safe to repeat and to interrupt.
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to config file")
+	months := flag.Int("months", 12, "archive the bookings of concerts that took place more than this many months ago")
+	batchSize := flag.Int("batch", 1000, "bookings to move in each transaction")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	cfg, err = secrets.ResolveConfig(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	log := logger.New(logger.Options{Level: cfg.LogLevel, Format: cfg.Logging.Format, Levels: cfg.Logging.Levels})
+
+	if !cfg.Database.Postgres() {
+		log.Error("The bookings archive needs postgres, not %s", cfg.Database.Driver)
+		os.Exit(1)
+	}
+
+	database, err := db.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Error("Failed to connect to database: %v", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	archiveService := service.NewArchiveService(postgres.NewArchiveRepository(database))
+	result, err := archiveService.ArchiveBookings(context.Background(), *months, *batchSize)
+	if err != nil {
+		log.Error("Failed to archive bookings: %v", err)
+		os.Exit(1)
+	}
+
+	log.Info("Archived %d bookings of concerts before %s in %d batches",
+		result.Archived, result.Cutoff.Format("2006-01-02"), result.Batches)
+}
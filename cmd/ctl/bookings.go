@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+func newBookingsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "bookings", Short: "Inspect and force-cancel bookings"}
+	cmd.AddCommand(newBookingsGetCommand(opts), newBookingsListCommand(opts), newBookingsForceCancelCommand(opts))
+	return cmd
+}
+
+func newBookingsGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <reference>",
+		Short: "Show a booking by its reference",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.call(cmd)
+			defer cancel()
+			booking, err := pb.NewBookingServiceClient(opts.conn).GetBooking(ctx, &pb.GetBookingRequest{Reference: args[0]})
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), booking, func(w io.Writer) { printBookings(w, booking) })
+		},
+	}
+}
+
+func newBookingsListCommand(opts *options) *cobra.Command {
+	req := &pb.GetUserBookingsRequest{}
+	cmd := &cobra.Command{
+		Use:   "list --user <user-id>",
+		Short: "List the bookings of a user a page at a time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.call(cmd)
+			defer cancel()
+			resp, err := pb.NewBookingServiceClient(opts.conn).GetUserBookings(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w io.Writer) {
+				printBookings(w, resp.Bookings...)
+				if resp.Meta.GetNextCursor() != "" {
+					fmt.Fprintf(w, "\nmore with --cursor %s\n", resp.Meta.NextCursor)
+				}
+			})
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&req.UserId, "user", "", "user whose bookings to list")
+	flags.Int32Var(&req.PageSize, "page-size", 20, "bookings per page")
+	flags.StringVar(&req.Cursor, "cursor", "", "cursor of the next page")
+	_ = cmd.MarkFlagRequired("user")
+	return cmd
+}
+
+func newBookingsForceCancelCommand(opts *options) *cobra.Command {
+	req := &pb.ForceCancelBookingRequest{}
+	cmd := &cobra.Command{
+		Use:   "force-cancel <reference> --reason <reason>",
+		Short: "Cancel a booking on the user's behalf and return its tickets",
+		Long: "Cancel a booking on the user's behalf, whatever the cancellation rules, and return its tickets.\n" +
+			"The cancellation is audited under the operator with the reason.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Reference = args[0]
+			ctx, cancel, err := opts.adminCall(cmd)
+			if err != nil {
+				return err
+			}
+			defer cancel()
+			booking, err := pb.NewAdminServiceClient(opts.conn).ForceCancelBooking(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), booking, func(w io.Writer) { printBookings(w, booking) })
+		},
+	}
+	cmd.Flags().StringVar(&req.Reason, "reason", "", "why the booking is cancelled, for the audit log")
+	_ = cmd.MarkFlagRequired("reason")
+	return cmd
+}
+
+func printBookings(w io.Writer, bookings ...*pb.Booking) {
+	fmt.Fprintln(w, "REFERENCE\tCONCERT\tUSER\tTICKETS\tSTATUS\tBOOKED")
+	for _, b := range bookings {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%s\n", b.Reference, b.ConcertId, b.UserId, b.TicketCount, b.Status, formatTime(b.BookingTime))
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+func newConcertsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "concerts", Short: "List, inspect and create concerts"}
+	cmd.AddCommand(newConcertsListCommand(opts), newConcertsGetCommand(opts), newConcertsCreateCommand(opts))
+	return cmd
+}
+
+func newConcertsListCommand(opts *options) *cobra.Command {
+	req := &pb.ListConcertsRequest{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List concerts a page at a time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.call(cmd)
+			defer cancel()
+			resp, err := pb.NewConcertServiceClient(opts.conn).ListConcerts(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), resp, func(w io.Writer) {
+				printConcerts(w, resp.Concerts...)
+				if resp.Meta.GetNextCursor() != "" {
+					fmt.Fprintf(w, "\nmore with --cursor %s\n", resp.Meta.NextCursor)
+				}
+			})
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&req.Name, "name", "", "filter by name")
+	flags.StringVar(&req.Artist, "artist", "", "filter by artist")
+	flags.StringVar(&req.Venue, "venue", "", "filter by venue")
+	flags.BoolVar(&req.AvailableOnly, "available", false, "only concerts with tickets left")
+	flags.StringVar(&req.Sort, "sort", "", "sort order, such as concert_date or -created_at")
+	flags.Int32Var(&req.PageSize, "page-size", 20, "concerts per page")
+	flags.StringVar(&req.Cursor, "cursor", "", "cursor of the next page")
+	return cmd
+}
+
+func newConcertsGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <concert-id>",
+		Short: "Show a concert",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseConcertID(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.call(cmd)
+			defer cancel()
+			concert, err := pb.NewConcertServiceClient(opts.conn).GetConcert(ctx, &pb.GetConcertRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), concert, func(w io.Writer) { printConcerts(w, concert) })
+		},
+	}
+}
+
+func newConcertsCreateCommand(opts *options) *cobra.Command {
+	req := &pb.CreateConcertRequest{}
+	var date, bookingStart, bookingEnd string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a concert",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			concertDate, err := parseTime("--date", date, time.Time{})
+			if err != nil {
+				return err
+			}
+			start, err := parseTime("--booking-start", bookingStart, time.Now().Add(time.Minute))
+			if err != nil {
+				return err
+			}
+			end, err := parseTime("--booking-end", bookingEnd, concertDate)
+			if err != nil {
+				return err
+			}
+			req.ConcertDate = timestamppb.New(concertDate)
+			req.BookingStartTime = timestamppb.New(start)
+			req.BookingEndTime = timestamppb.New(end)
+
+			ctx, cancel := opts.call(cmd)
+			defer cancel()
+			concert, err := pb.NewConcertServiceClient(opts.conn).CreateConcert(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), concert, func(w io.Writer) { printConcerts(w, concert) })
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&req.Name, "name", "", "name of the concert")
+	flags.StringVar(&req.Artist, "artist", "", "performing artist")
+	flags.StringVar(&req.Venue, "venue", "", "venue")
+	flags.StringVar(&date, "date", "", "start of the concert, in RFC 3339")
+	flags.Int32Var(&req.TotalTickets, "tickets", 0, "tickets on sale")
+	flags.Float64Var(&req.Price, "price", 0, "price of a ticket")
+	flags.StringVar(&req.Currency, "currency", "", "currency of the price; the configured default unless set")
+	flags.StringVar(&bookingStart, "booking-start", "", "start of the sale, in RFC 3339; in a minute unless set")
+	flags.StringVar(&bookingEnd, "booking-end", "", "end of the sale, in RFC 3339; the concert's start unless set")
+	flags.StringVar(&req.OrganizerEmail, "organizer-email", "", "address the end-of-sale report goes to")
+	for _, name := range []string{"name", "artist", "venue", "date", "tickets", "price"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+func printConcerts(w io.Writer, concerts ...*pb.Concert) {
+	fmt.Fprintln(w, "ID\tNAME\tARTIST\tVENUE\tDATE\tAVAILABLE\tPRICE")
+	for _, c := range concerts {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d/%d\t%.2f %s\n", c.Id, c.Name, c.Artist, c.Venue,
+			formatTime(c.ConcertDate), c.AvailableTickets, c.TotalTickets, c.CurrentPrice, c.Currency)
+	}
+}
+
+func parseConcertID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid concert ID %q", arg)
+	}
+	return id, nil
+}
+
+// parseTime parses an RFC 3339 flag value, which is fallback when empty
+func parseTime(flag, value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be in RFC 3339, such as 2026-07-01T20:00:00Z: %w", flag, err)
+	}
+	return t, nil
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().Local().Format("2006-01-02 15:04")
+}
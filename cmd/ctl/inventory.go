@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	pb "concert-ticket-api/api/grpc/proto"
+)
+
+func newInventoryCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "inventory", Short: "Adjust and check the tickets of concerts"}
+	cmd.AddCommand(newInventoryAdjustCommand(opts), newInventoryCheckCommand(opts))
+	return cmd
+}
+
+func newInventoryAdjustCommand(opts *options) *cobra.Command {
+	req := &pb.AdjustInventoryRequest{}
+	cmd := &cobra.Command{
+		Use:   "adjust <concert-id> --delta <n> --reason <reason>",
+		Short: "Add tickets to a concert or withdraw unsold ones",
+		Long: "Add tickets to a concert, or withdraw unsold ones with a negative delta.\n" +
+			"The adjustment is audited under the operator with the reason.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseConcertID(args[0])
+			if err != nil {
+				return err
+			}
+			req.ConcertId = id
+			ctx, cancel, err := opts.adminCall(cmd)
+			if err != nil {
+				return err
+			}
+			defer cancel()
+			availability, err := pb.NewAdminServiceClient(opts.conn).AdjustInventory(ctx, req)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), availability, func(w io.Writer) {
+				fmt.Fprintln(w, "CONCERT\tAVAILABLE\tTOTAL")
+				fmt.Fprintf(w, "%d\t%d\t%d\n", availability.ConcertId, availability.AvailableTickets, availability.TotalTickets)
+			})
+		},
+	}
+	flags := cmd.Flags()
+	flags.Int32Var(&req.Delta, "delta", 0, "tickets to add, or to withdraw when negative")
+	flags.StringVar(&req.Reason, "reason", "", "why the inventory changes, for the audit log")
+	_ = cmd.MarkFlagRequired("delta")
+	_ = cmd.MarkFlagRequired("reason")
+	return cmd
+}
+
+func newInventoryCheckCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check [concert-id]",
+		Short: "Check that the available tickets of concerts match their bookings",
+		Long: "Check that the available tickets of a concert, or of all concerts, match its total less\n" +
+			"the tickets of its bookings and inventory releases. Exits with 1 when any don't.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &pb.CheckInventoryRequest{}
+			if len(args) > 0 {
+				id, err := parseConcertID(args[0])
+				if err != nil {
+					return err
+				}
+				req.ConcertId = id
+			}
+			ctx, cancel, err := opts.adminCall(cmd)
+			if err != nil {
+				return err
+			}
+			defer cancel()
+			resp, err := pb.NewAdminServiceClient(opts.conn).CheckInventory(ctx, req)
+			if err != nil {
+				return err
+			}
+
+			err = opts.print(cmd.OutOrStdout(), resp, func(w io.Writer) {
+				fmt.Fprintf(w, "checked %d concerts, %d don't add up\n", resp.Checked, len(resp.Discrepancies))
+				if len(resp.Discrepancies) == 0 {
+					return
+				}
+				fmt.Fprintln(w, "\nCONCERT\tTOTAL\tAVAILABLE\tEXPECTED\tBOOKED\tPENDING\tHELD")
+				for _, d := range resp.Discrepancies {
+					fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\t%d\n", d.ConcertId, d.TotalTickets, d.AvailableTickets,
+						d.ExpectedAvailableTickets, d.BookedTickets, d.PendingTickets, d.HeldTickets)
+				}
+			})
+			if err == nil && len(resp.Discrepancies) > 0 {
+				err = fmt.Errorf("%d of %d concerts have discrepancies", len(resp.Discrepancies), resp.Checked)
+			}
+			return err
+		},
+	}
+}
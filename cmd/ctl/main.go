@@ -0,0 +1,147 @@
+// cmd/ctl/main.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ctl is the operator CLI. It lists and creates concerts and inspects
+// bookings as an API client, usually with the admin token, and force-cancels
+// bookings, adjusts inventory and checks it with the credentials of the
+// internal admin API, all over the gRPC API.
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "ctl: %s\n", describe(err))
+		os.Exit(1)
+	}
+}
+
+// options are the connection and output settings shared by the commands
+type options struct {
+	addr          string
+	token         string
+	internalToken string
+	actor         string
+	output        string
+	timeout       time.Duration
+
+	conn *grpc.ClientConn
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:           "ctl",
+		Short:         "Operate the concert ticket API over gRPC",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The tokens come from the environment after the flags, keeping
+			// them out of the usage
+			if opts.token == "" {
+				opts.token = os.Getenv("CTL_TOKEN")
+			}
+			if opts.internalToken == "" {
+				opts.internalToken = os.Getenv("CTL_INTERNAL_TOKEN")
+			}
+			if opts.output != "table" && opts.output != "json" {
+				return fmt.Errorf("--output must be table or json, not %q", opts.output)
+			}
+			conn, err := grpc.NewClient(opts.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				return fmt.Errorf("connecting to %s: %w", opts.addr, err)
+			}
+			opts.conn = conn
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.conn.Close()
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.addr, "addr", envOr("CTL_ADDR", "localhost:50051"), "address of the gRPC API (env CTL_ADDR)")
+	flags.StringVar(&opts.token, "token", "", "bearer token of the concert and booking commands (env CTL_TOKEN)")
+	flags.StringVar(&opts.internalToken, "internal-token", "", "internal admin token of the force-cancel and inventory commands (env CTL_INTERNAL_TOKEN)")
+	flags.StringVar(&opts.actor, "actor", envOr("CTL_ACTOR", os.Getenv("USER")), "operator the admin operations are audited under (env CTL_ACTOR)")
+	flags.StringVarP(&opts.output, "output", "o", "table", "output format: table or json")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of each call")
+
+	root.AddCommand(newConcertsCommand(opts), newBookingsCommand(opts), newInventoryCommand(opts))
+	return root
+}
+
+// call returns the context of a call with the API token
+func (o *options) call(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+	if o.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+o.token)
+	}
+	return ctx, cancel
+}
+
+// adminCall returns the context of a call with the internal admin
+// credentials, which the admin operations require
+func (o *options) adminCall(cmd *cobra.Command) (context.Context, context.CancelFunc, error) {
+	if o.internalToken == "" {
+		return nil, nil, fmt.Errorf("the internal admin token is required: set --internal-token or CTL_INTERNAL_TOKEN")
+	}
+	if o.actor == "" {
+		return nil, nil, fmt.Errorf("the operator is required: set --actor or CTL_ACTOR")
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+o.internalToken, "x-admin-actor", o.actor)
+	return ctx, cancel, nil
+}
+
+// print writes msg as JSON, or as the table that table writes
+func (o *options) print(out io.Writer, msg proto.Message, table func(w io.Writer)) error {
+	if o.output == "json" {
+		data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		// protojson varies its whitespace on purpose; indent it the same
+		// way every time for scripts and diffs
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, data, "", "  "); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, indented.String())
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+// describe reports the status of a failed call, or the error as it is
+func describe(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("%s: %s", st.Code(), st.Message())
+	}
+	return err.Error()
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,203 @@
+// cmd/loadgen/main.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"concert-ticket-api/client"
+	"concert-ticket-api/pkg/loadgen"
+	"concert-ticket-api/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// loadgen drives a live deployment over REST or gRPC with virtual users that
+// browse, book and cancel, ramping up to the concurrency, and reports the
+// latency percentiles and errors of every operation. It exits with 1 when
+// the run exceeds its error budget, so it can gate a release.
+//
+//	go run ./cmd/loadgen -target http://staging:8080 -concurrency 200 -ramp-up 1m -duration 5m
+//	go run ./cmd/loadgen -transport grpc -target staging:50051 -token $TOKEN -mix browse=50,book=40,cancel=10
+func main() {
+	transport := flag.String("transport", "rest", "rest or grpc")
+	target := flag.String("target", "", "base URL of the REST API or address of the gRPC API; localhost on the default ports unless set")
+	token := flag.String("token", os.Getenv("LOADGEN_TOKEN"), "bearer token of the requests; gRPC bookings need one with the user role (env LOADGEN_TOKEN)")
+	concurrency := flag.Int("concurrency", 50, "virtual users at full load")
+	rampUp := flag.Duration("ramp-up", 30*time.Second, "time over which the virtual users start")
+	duration := flag.Duration("duration", 2*time.Minute, "length of the run including the ramp-up")
+	think := flag.Duration("think", 0, "pause of a virtual user between iterations")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each request")
+	interval := flag.Duration("interval", 10*time.Second, "width of the intervals of the timeline")
+	mixFlag := flag.String("mix", "browse=70,book=25,cancel=5", "weights of the browse, book and cancel scenarios")
+	concerts := flag.String("concerts", "", "comma-separated IDs of the concerts to book; the concerts with tickets left on the first page unless set")
+	users := flag.Int("users", 1000, "user IDs to spread the bookings over")
+	maxTickets := flag.Int("max-tickets", 2, "most tickets of a booking")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "seed of the virtual users' choices")
+	maxErrorRate := flag.Float64("max-error-rate", 0.01, "share of requests that may fail before the run fails")
+	maxP99 := flag.Duration("max-p99", 0, "p99 latency every operation must stay under, 0 for no limit")
+	jsonReport := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	log := logger.NewLogger("info")
+
+	mix, err := loadgen.ParseMix(*mixFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -mix: %v\n", err)
+		os.Exit(2)
+	}
+	concertIDs, err := parseIDs(*concerts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -concerts: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Every request is made once, so the latencies are those of single
+	// requests and failures aren't hidden by retries
+	opts := client.Options{Token: *token, Retry: client.RetryPolicy{MaxAttempts: 1}}
+	runner := &loadgen.Runner{
+		Concurrency: *concurrency,
+		RampUp:      *rampUp,
+		Duration:    *duration,
+		Think:       *think,
+		Timeout:     *timeout,
+		Interval:    *interval,
+		Mix:         mix,
+		ConcertIDs:  concertIDs,
+		Users:       *users,
+		MaxTickets:  *maxTickets,
+		Seed:        *seed,
+		Budget:      loadgen.Budget{ErrorRate: *maxErrorRate, P99: *maxP99},
+	}
+	switch *transport {
+	case "rest":
+		if *target == "" {
+			*target = "http://localhost:8080"
+		}
+		// Keep a connection per virtual user instead of the default two
+		httpClient := &http.Client{Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConnsPerHost: *concurrency,
+			IdleConnTimeout:     90 * time.Second,
+		}}
+		rest := client.NewREST(*target, httpClient, opts)
+		runner.Concerts, runner.Bookings = rest.Concerts(), rest.Bookings()
+	case "grpc":
+		if *target == "" {
+			*target = "localhost:50051"
+		}
+		conn, err := grpc.NewClient(*target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Error("Failed to connect to %s: %v", *target, err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+		rpc := client.NewGRPC(conn, opts)
+		runner.Concerts, runner.Bookings = rpc.Concerts(), rpc.Bookings()
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -transport %q, want rest or grpc\n", *transport)
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Info("Running %d virtual users against %s over %s for %s (ramp-up %s, mix %s, seed %d)",
+		*concurrency, *target, *transport, *duration, *rampUp, mix, *seed)
+	report, err := runner.Run(ctx)
+	if err != nil {
+		log.Error("Failed to run: %v", err)
+		os.Exit(1)
+	}
+
+	if *jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Error("Failed to write report: %v", err)
+			os.Exit(1)
+		}
+	} else {
+		printReport(os.Stdout, report)
+	}
+	if len(report.Violations) > 0 {
+		os.Exit(1)
+	}
+}
+
+func parseIDs(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid concert ID %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func printReport(out io.Writer, report *loadgen.Report) {
+	fmt.Fprintf(out, "Sent %d requests in %s (%.1f/s), %d errors\n",
+		report.Requests, report.Duration.Round(time.Millisecond), report.Throughput, report.Errors)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nOPERATION\tCOUNT\tERRORS\tP50\tP90\tP95\tP99\tMAX\tOUTCOMES")
+	for _, op := range report.Operations {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", op.Name, op.Count, op.Errors,
+			op.P50.Round(time.Millisecond), op.P90.Round(time.Millisecond), op.P95.Round(time.Millisecond),
+			op.P99.Round(time.Millisecond), op.Max.Round(time.Millisecond), outcomes(op.Outcomes))
+	}
+
+	fmt.Fprintln(w, "\nSTART\tUSERS\tREQUESTS\tERRORS\tP95")
+	for _, interval := range report.Timeline {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", interval.Start, interval.Users, interval.Requests,
+			interval.Errors, interval.P95.Round(time.Millisecond))
+	}
+	w.Flush()
+
+	if len(report.Violations) == 0 {
+		fmt.Fprintln(out, "\nWithin budget")
+		return
+	}
+	fmt.Fprintln(out, "\nOver budget:")
+	for _, violation := range report.Violations {
+		fmt.Fprintf(out, "  %s\n", violation)
+	}
+}
+
+// outcomes lists the outcomes of an operation, most frequent first
+func outcomes(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, counts[name])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,98 @@
+// cmd/sample/main.go
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/secrets"
+)
+
+// sample exports an anonymized sample of concerts and bookings as
+// concerts.csv and bookings.csv, for the analytics team and for seeding
+// realistic load tests
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to config file")
+	outDir := flag.String("out", "sample", "directory to write concerts.csv and bookings.csv to")
+	rate := flag.Float64("rate", 0.1, "fraction of the concerts of every month to sample")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed for sampling and jitter, for reproducible samples")
+	jitter := flag.Duration("jitter", time.Hour, "maximum shift of booking timestamps in either direction")
+	minBookings := flag.Int("min-bookings", 5, "leave out sampled concerts with fewer bookings")
+	salt := flag.String("salt", "", "key for hashing user IDs; random unless set, so separate samples cannot be joined")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	cfg, err = secrets.ResolveConfig(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	log := logger.New(logger.Options{Level: cfg.LogLevel, Format: cfg.Logging.Format, Levels: cfg.Logging.Levels})
+
+	database, err := db.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Error("Failed to connect to database: %v", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	sampleService := service.NewSampleService(postgres.NewSampleRepository(database))
+	dataset, err := sampleService.Generate(context.Background(), model.SampleOptions{
+		Rate:        *rate,
+		Seed:        *seed,
+		Jitter:      *jitter,
+		MinBookings: *minBookings,
+		Salt:        []byte(*salt),
+	})
+	if err != nil {
+		log.Error("Failed to generate sample: %v", err)
+		os.Exit(1)
+	}
+
+	if err := writeSample(dataset, *outDir); err != nil {
+		log.Error("Failed to write sample: %v", err)
+		os.Exit(1)
+	}
+
+	log.Info("Wrote %d concerts and %d bookings to %s (seed %d, %d concerts suppressed)",
+		len(dataset.Concerts), len(dataset.Bookings), *outDir, *seed, dataset.Suppressed)
+}
+
+func writeSample(dataset *model.SampleDataset, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	concerts, err := os.Create(filepath.Join(dir, "concerts.csv"))
+	if err != nil {
+		return err
+	}
+	defer concerts.Close()
+
+	bookings, err := os.Create(filepath.Join(dir, "bookings.csv"))
+	if err != nil {
+		return err
+	}
+	defer bookings.Close()
+
+	if err := service.WriteSampleCSV(dataset, concerts, bookings); err != nil {
+		return err
+	}
+
+	if err := concerts.Close(); err != nil {
+		return err
+	}
+	return bookings.Close()
+}
@@ -4,115 +4,87 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"concert-ticket-api/api/grpc"
-	"concert-ticket-api/api/rest"
 	"concert-ticket-api/config"
-	"concert-ticket-api/internal/repository/postgres"
-	"concert-ticket-api/internal/service"
+	"concert-ticket-api/internal/app"
 	"concert-ticket-api/pkg/db"
 	"concert-ticket-api/pkg/logger"
-
-	_ "github.com/jmoiron/sqlx"
+	"concert-ticket-api/pkg/secrets"
 )
 
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "validate" {
+		os.Exit(validateConfig(os.Args[3:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrateCommand(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		os.Exit(seedCommand(os.Args[2:], os.Stdout))
+	}
+	os.Exit(run())
+}
+
+// run runs the service until SIGINT or SIGTERM and returns the exit status,
+// so the deferred cleanups run before the process exits
+func run() int {
 	// Parse command line flags
 	configPath := flag.String("config", "config/config.yaml", "path to config file")
-	migrateOnly := flag.Bool("migrate", false, "run migrations and exit")
+	migrateOnly := flag.Bool("migrate", false, `run migrations and exit, like "migrate up"`)
 	waitForDB := flag.Bool("wait-for-db", false, "wait for database to be available")
+	strict := flag.Bool("strict", false, "fail on unknown and missing required config keys")
 	flag.Parse()
 
 	// Load configuration
-	cfg, err := config.Load(*configPath)
+	load := config.Load
+	if *strict {
+		load = config.LoadStrict
+	}
+	cfg, err := load(*configPath)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
 	}
 
-	// Initialize logger
-	log := logger.NewLogger(cfg.LogLevel)
-	log.Info("Starting Concert Ticket Reservation API")
+	log := logger.New(logger.Options{Level: cfg.LogLevel, Format: cfg.Logging.Format, Levels: cfg.Logging.Levels})
 
 	// Wait for database if requested (useful in Docker/Kubernetes environments)
-	if *waitForDB {
+	if *waitForDB && !cfg.Database.Memory() {
 		log.Info("Waiting for database to be available...")
-		if err := db.WaitForDatabase(cfg.Database, 60*time.Second); err != nil {
+		resolved, err := secrets.ResolveConfig(context.Background(), cfg)
+		if err != nil {
+			log.Error("Failed to load config: %v", err)
+			return 1
+		}
+		if err := db.WaitForDatabase(resolved.Database, 60*time.Second); err != nil {
 			log.Error("Failed to connect to database after waiting: %v", err)
-			os.Exit(1)
+			return 1
 		}
 	}
 
-	// Connect to database
-	database, err := db.NewPostgresDB(cfg.Database)
-	if err != nil {
-		log.Error("Failed to connect to database: %v", err)
-		os.Exit(1)
-	}
-	defer database.Close()
-
-	// Run database migrations
-	log.Info("Running database migrations...")
-	if err := db.RunMigrations(cfg.Database, "scripts/migrations"); err != nil {
-		log.Error("Failed to run migrations: %v", err)
-		os.Exit(1)
-	}
-	log.Info("Migrations completed successfully")
-
 	// Exit if only running migrations
 	if *migrateOnly {
+		log.Info("Running database migrations...")
+		if err := app.Migrate(cfg); err != nil {
+			log.Error("Migration failed: %v", err)
+			return 1
+		}
 		log.Info("Migration only mode, exiting")
-		os.Exit(0)
+		return 0
 	}
 
-	// Initialize repositories
-	concertRepo := postgres.NewConcertRepository(database)
-	bookingRepo := postgres.NewBookingRepository(database)
-
-	// Initialize services
-	concertService := service.NewConcertService(concertRepo)
-	bookingService := service.NewBookingService(bookingRepo, concertRepo, cfg.MaxRetries)
-
-	// Start REST API server
-	restServer := rest.NewServer(concertService, bookingService, log, cfg.RESTPort)
-	go func() {
-		log.Info("Starting REST API server on port %d", cfg.RESTPort)
-		if err := restServer.Start(); err != nil {
-			log.Error("REST server error: %v", err)
-			os.Exit(1)
-		}
-	}()
-
-	// Start gRPC server
-	grpcServer := grpc.NewServer(concertService, bookingService, log, cfg.GRPCPort)
-	go func() {
-		log.Info("Starting gRPC server on port %d", cfg.GRPCPort)
-		if err := grpcServer.Start(); err != nil {
-			log.Error("gRPC server error: %v", err)
-			os.Exit(1)
-		}
-	}()
-
-	// Wait for termination signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	<-quit
-	log.Info("Shutting down servers...")
+	// Serve until a termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Create a timeout context for the shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Shutdown servers gracefully
-	if err := restServer.Shutdown(ctx); err != nil {
-		log.Error("REST server shutdown error: %v", err)
+	if err := app.Run(ctx, cfg); err != nil {
+		log.Error("Server failed: %v", err)
+		return 1
 	}
-
-	grpcServer.Shutdown()
-
-	log.Info("Servers stopped")
+	return 0
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/app"
+	"concert-ticket-api/pkg/db"
+)
+
+// migrateCommand implements "migrate": it applies, reverts or reports the
+// migrations embedded in the binary on the database of the config, so the
+// schema is migrated by a deployment step rather than by every replica on
+// start.
+//
+//	server migrate up                apply the pending migrations
+//	server migrate down [n|all]      revert the last n migrations, 1 by default
+//	server migrate status            print the applied and latest migration
+//	server migrate force <version>   record version as applied after fixing a failed one, -1 for none
+func migrateCommand(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	configPath := flags.String("config", "config/config.yaml", "path to config file")
+	strict := flags.Bool("strict", false, "fail on unknown and missing required config keys")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "usage: server migrate [-config file] [-strict] up | down [n|all] | status | force <version>")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	command, err := parseMigrateCommand(flags.Args())
+	if err != nil {
+		fmt.Fprintln(out, err)
+		flags.Usage()
+		return 2
+	}
+
+	load := config.Load
+	if *strict {
+		load = config.LoadStrict
+	}
+	cfg, err := load(*configPath)
+	if err != nil {
+		fmt.Fprintf(out, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	migrator, closeMigrator, err := app.OpenMigrator(context.Background(), cfg)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	defer closeMigrator()
+
+	if err := command(migrator, out); err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	return 0
+}
+
+// parseMigrateCommand returns the migrate command the arguments ask for
+func parseMigrateCommand(args []string) (func(*db.Migrator, io.Writer) error, error) {
+	switch name, rest := args[0], args[1:]; {
+	case name == "up" && len(rest) == 0:
+		return func(m *db.Migrator, out io.Writer) error {
+			if err := m.Up(); err != nil {
+				return err
+			}
+			return printStatus(m, out)
+		}, nil
+
+	case name == "down" && len(rest) <= 1:
+		steps := 1
+		if len(rest) == 1 {
+			if rest[0] == "all" {
+				steps = 0
+			} else if n, err := strconv.Atoi(rest[0]); err == nil && n > 0 {
+				steps = n
+			} else {
+				return nil, fmt.Errorf("down takes a positive number of migrations or all, not %q", rest[0])
+			}
+		}
+		return func(m *db.Migrator, out io.Writer) error {
+			if err := m.Down(steps); err != nil {
+				return err
+			}
+			return printStatus(m, out)
+		}, nil
+
+	case name == "status" && len(rest) == 0:
+		return printStatus, nil
+
+	case name == "force" && len(rest) == 1:
+		version, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return nil, fmt.Errorf("force takes a migration version, not %q", rest[0])
+		}
+		return func(m *db.Migrator, out io.Writer) error {
+			if err := m.Force(version); err != nil {
+				return err
+			}
+			return printStatus(m, out)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown migrate command %q", args)
+}
+
+// printStatus prints where the schema stands
+func printStatus(m *db.Migrator, out io.Writer) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Dirty:
+		fmt.Fprintf(out, "migration %d failed halfway; fix the schema by hand, then force the version it is at\n", status.Version)
+	case status.Version < status.Latest:
+		fmt.Fprintf(out, "at migration %d of %d, %d pending\n", status.Version, status.Latest, status.Latest-status.Version)
+	default:
+		fmt.Fprintf(out, "at migration %d of %d, up to date\n", status.Version, status.Latest)
+	}
+	return nil
+}
@@ -1,63 +1,2403 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Database drivers, which decide the database the repositories run on
+const (
+	// DriverPostgres runs every feature on PostgreSQL
+	DriverPostgres = "postgres"
+	// DriverMySQL runs concerts, bookings, booking tokens and the audit log
+	// on MySQL 8
+	DriverMySQL = "mysql"
+	// DriverSQLite runs concerts, bookings, booking tokens and the audit log
+	// on a SQLite file, for edge and demo deployments on a single instance
+	DriverSQLite = "sqlite"
+	// DriverMemory keeps concerts, bookings, booking tokens and the audit
+	// log in the memory of a single instance, for development and demos
+	// without a database. Everything is lost when the process exits.
+	DriverMemory = "memory"
+)
+
 // Database holds the database configuration
 type Database struct {
+	// Driver is DriverPostgres, DriverMySQL, DriverSQLite or DriverMemory
+	Driver   string `mapstructure:"driver"`
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
-	Name     string `mapstructure:"name"`
-	SSLMode  string `mapstructure:"sslmode"`
+	Name     string `mapstructure:"name"`
+	SSLMode  string `mapstructure:"sslmode"`
+	// Path is the database file of the sqlite driver, or :memory: for a
+	// database that is gone when the process exits
+	Path string `mapstructure:"path"`
+	// Replicas are the host:port addresses of postgres read replicas of
+	// the database, reached with the primary's credentials. Reads that may
+	// lag behind writes go to them.
+	Replicas []string `mapstructure:"replicas"`
+	// AutoMigrate applies the pending migrations when the service starts.
+	// Without it the schema is migrated with "server migrate up", and
+	// replicas aren't ready until it is. An in-memory SQLite database is
+	// always migrated, since nothing else can reach it.
+	AutoMigrate bool `mapstructure:"auto_migrate"`
+
+	// The connection pool of the postgres and mysql drivers, and of each
+	// replica. SQLite always keeps a single connection. 0 open connections
+	// are unlimited.
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	// ConnMaxIdleTime closes connections idle for longer, 0 keeps them
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// StatementTimeout is the deadline of each statement, added to the
+	// context it runs with; 0 leaves statements to the request's context
+	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
+	// StatementCacheCapacity is the number of prepared statements each
+	// postgres connection keeps. 0 prepares none, which PgBouncer in
+	// transaction pooling mode needs.
+	StatementCacheCapacity int `mapstructure:"statement_cache_capacity"`
+	// Timeouts bound the operations on the pool by kind
+	Timeouts DatabaseTimeouts `mapstructure:"timeouts"`
+	// CircuitBreaker fails operations fast while the database doesn't answer
+	CircuitBreaker CircuitBreaker `mapstructure:"circuit_breaker"`
+
+	// PasswordSource, when set, is asked for the password of every new
+	// connection instead of Password, so connections pick up a rotated
+	// password. It's set by pkg/secrets for passwords that are references.
+	PasswordSource func(ctx context.Context) (string, error) `mapstructure:"-"`
+}
+
+// DatabaseTimeouts are the deadlines of the operations on a database pool,
+// added to the context they run with. 0 leaves query and exec to
+// statement_timeout.
+type DatabaseTimeouts struct {
+	// Connect bounds opening a connection. 0 waits as long as the context.
+	Connect time.Duration `mapstructure:"connect"`
+	// Query bounds statements returning rows, until the rows are closed
+	Query time.Duration `mapstructure:"query"`
+	// Exec bounds statements that don't return rows
+	Exec time.Duration `mapstructure:"exec"`
+}
+
+// QueryTimeout returns the deadline of statements returning rows
+func (d *Database) QueryTimeout() time.Duration {
+	if d.Timeouts.Query > 0 {
+		return d.Timeouts.Query
+	}
+	return d.StatementTimeout
+}
+
+// ExecTimeout returns the deadline of statements that don't return rows
+func (d *Database) ExecTimeout() time.Duration {
+	if d.Timeouts.Exec > 0 {
+		return d.Timeouts.Exec
+	}
+	return d.StatementTimeout
+}
+
+// CircuitBreaker configures the circuit breaker of each database pool. It
+// opens after FailureThreshold consecutive operations failed because the
+// database didn't answer, by timeout or a lost connection; while open,
+// operations fail at once with 503 Service Unavailable or UNAVAILABLE.
+// After OpenDuration one operation is let through to try the database.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures that open the
+	// circuit, 0 disables the breaker
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	OpenDuration     time.Duration `mapstructure:"open_duration"`
+}
+
+// Validate checks the database driver and the settings it needs
+func (d *Database) Validate() error {
+	if err := d.validatePool(); err != nil {
+		return err
+	}
+
+	switch d.Driver {
+	case DriverPostgres:
+		return d.validateReplicas()
+	case DriverMySQL, DriverMemory:
+	case DriverSQLite:
+		if d.Path == "" {
+			return fmt.Errorf("database.path is required by the %q driver", DriverSQLite)
+		}
+	default:
+		return fmt.Errorf("database.driver must be %q, %q, %q or %q", DriverPostgres, DriverMySQL, DriverSQLite, DriverMemory)
+	}
+
+	if len(d.Replicas) > 0 {
+		return fmt.Errorf("database.replicas requires database.driver %q", DriverPostgres)
+	}
+	return nil
+}
+
+// validatePool checks the connection pool and the statement timeout
+func (d *Database) validatePool() error {
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		return fmt.Errorf("database connection limits cannot be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		return fmt.Errorf("database.max_idle_conns cannot exceed database.max_open_conns")
+	}
+	if d.ConnMaxLifetime < 0 || d.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("database connection lifetimes cannot be negative")
+	}
+	if d.StatementTimeout < 0 {
+		return fmt.Errorf("database.statement_timeout cannot be negative")
+	}
+	if d.StatementCacheCapacity < 0 {
+		return fmt.Errorf("database.statement_cache_capacity cannot be negative")
+	}
+	if d.Timeouts.Connect < 0 || d.Timeouts.Query < 0 || d.Timeouts.Exec < 0 {
+		return fmt.Errorf("database.timeouts cannot be negative")
+	}
+	if d.CircuitBreaker.FailureThreshold < 0 {
+		return fmt.Errorf("database.circuit_breaker.failure_threshold cannot be negative")
+	}
+	if d.CircuitBreaker.FailureThreshold > 0 && d.CircuitBreaker.OpenDuration <= 0 {
+		return fmt.Errorf("database.circuit_breaker.open_duration must be positive")
+	}
+	return nil
+}
+
+// validateReplicas checks that every replica has a host and a port
+func (d *Database) validateReplicas() error {
+	for _, addr := range d.Replicas {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || host == "" {
+			return fmt.Errorf("database.replicas contains invalid address %q, want host:port", addr)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("database.replicas contains invalid port in %q", addr)
+		}
+	}
+	return nil
+}
+
+// Replica returns the configuration of the replica at addr, one of
+// Replicas: the primary's with the replica's host and port
+func (d *Database) Replica(addr string) Database {
+	replica := *d
+	host, port, _ := net.SplitHostPort(addr)
+	replica.Host = host
+	replica.Port, _ = strconv.Atoi(port)
+	replica.Replicas = nil
+	return replica
+}
+
+// Postgres reports whether the repositories run on PostgreSQL, which the
+// features beyond concerts, bookings, booking tokens and the audit log need
+func (d *Database) Postgres() bool {
+	return d.Driver == DriverPostgres
+}
+
+// Memory reports whether the repositories keep their data in memory, so
+// there is no database to connect to or migrate
+func (d *Database) Memory() bool {
+	return d.Driver == DriverMemory
+}
+
+// Admin holds the configuration for administrative endpoints
+type Admin struct {
+	// Token is the bearer token required by admin endpoints. Admin endpoints
+	// are disabled when it is empty.
+	Token string `mapstructure:"token"`
+}
+
+// InternalAdmin holds the configuration of the internal admin API, which
+// serves dangerous operations on a listener of its own
+type InternalAdmin struct {
+	// Port is the port of the internal admin listener. The API is disabled when it is 0.
+	Port int `mapstructure:"port"`
+	// Host is the address the listener binds to, loopback unless reachable
+	// through a private network only
+	Host string `mapstructure:"host"`
+	// Token is the bearer token required by the internal admin API. It must
+	// differ from the admin token, so leaking one doesn't expose both APIs.
+	Token string `mapstructure:"token"`
+	// AllowedNetworks are the CIDR blocks requests are accepted from
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
+// Validate checks that an enabled internal admin API has its own token and
+// valid networks
+func (a *InternalAdmin) Validate(adminToken string) error {
+	if a.Port == 0 {
+		return nil
+	}
+
+	if a.Port < 0 || a.Port > 65535 {
+		return fmt.Errorf("internal_admin.port must be between 1 and 65535")
+	}
+	if a.Token == "" {
+		return fmt.Errorf("internal_admin.token must be set when internal_admin.port is set")
+	}
+	if a.Token == adminToken {
+		return fmt.Errorf("internal_admin.token must differ from admin.token")
+	}
+	if len(a.AllowedNetworks) == 0 {
+		return fmt.Errorf("internal_admin.allowed_networks must not be empty")
+	}
+	for _, network := range a.AllowedNetworks {
+		if _, _, err := net.ParseCIDR(network); err != nil {
+			return fmt.Errorf("internal_admin.allowed_networks contains invalid network %q", network)
+		}
+	}
+
+	return nil
+}
+
+// Debug configures the listener serving pprof profiles, GC stats and
+// goroutine dumps, for profiling the booking path during on-sales
+type Debug struct {
+	// Port is the port of the debug listener. It is disabled when 0.
+	Port int `mapstructure:"port"`
+	// Host is the address the listener binds to, loopback unless reachable
+	// through a private network only
+	Host string `mapstructure:"host"`
+	// AllowedNetworks are the CIDR blocks requests are accepted from
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+	// BlockProfileRate and MutexProfileFraction turn on the block and mutex
+	// profiles, see runtime.SetBlockProfileRate and
+	// runtime.SetMutexProfileFraction. Both cost time on the hot path, so
+	// they are off at 0.
+	BlockProfileRate     int `mapstructure:"block_profile_rate"`
+	MutexProfileFraction int `mapstructure:"mutex_profile_fraction"`
+}
+
+// Validate checks the port, the networks and the profile rates of an enabled
+// debug listener
+func (d *Debug) Validate() error {
+	if d.Port == 0 {
+		return nil
+	}
+
+	if d.Port < 0 || d.Port > 65535 {
+		return fmt.Errorf("debug.port must be between 1 and 65535")
+	}
+	if len(d.AllowedNetworks) == 0 {
+		return fmt.Errorf("debug.allowed_networks must not be empty")
+	}
+	for _, network := range d.AllowedNetworks {
+		if _, _, err := net.ParseCIDR(network); err != nil {
+			return fmt.Errorf("debug.allowed_networks contains invalid network %q", network)
+		}
+	}
+	if d.BlockProfileRate < 0 {
+		return fmt.Errorf("debug.block_profile_rate cannot be negative")
+	}
+	if d.MutexProfileFraction < 0 {
+		return fmt.Errorf("debug.mutex_profile_fraction cannot be negative")
+	}
+
+	return nil
+}
+
+// Shutdown configures how the process stops on SIGINT or SIGTERM
+type Shutdown struct {
+	// DrainDelay is how long the process keeps serving, while rejecting new
+	// writes and failing its readiness check, before it closes its listeners,
+	// so load balancers stop sending it requests first
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// Timeout bounds the whole shutdown: the delay, the requests and jobs in
+	// flight and the final flushes
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Validate checks that the drain delay leaves time for the rest of the shutdown
+func (s *Shutdown) Validate() error {
+	if s.DrainDelay < 0 {
+		return fmt.Errorf("shutdown.drain_delay cannot be negative")
+	}
+	if s.Timeout <= 0 {
+		return fmt.Errorf("shutdown.timeout must be positive")
+	}
+	if s.DrainDelay >= s.Timeout {
+		return fmt.Errorf("shutdown.drain_delay must be shorter than shutdown.timeout")
+	}
+	return nil
+}
+
+// The stores a setting can reference a secret in instead of holding it, as
+// file:///run/secrets/db_password, vault://secret/tickets/db#password or
+// awssm://tickets/stripe#secret_key
+const (
+	SecretStoreFile  = "file"
+	SecretStoreVault = "vault"
+	SecretStoreAWS   = "awssm"
+)
+
+// IsSecretReference reports whether value references a secret in one of the
+// secret stores rather than holding it
+func IsSecretReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	return ok && (scheme == SecretStoreFile || scheme == SecretStoreVault || scheme == SecretStoreAWS)
+}
+
+// Secrets configures the stores settings reference secrets in. References
+// are resolved at startup; database.password and payments.stripe.secret_key
+// are resolved again for each connection or request, so they can rotate.
+type Secrets struct {
+	// CacheTTL is how long a fetched secret is used before it is fetched
+	// again. A failed fetch keeps using the previous value.
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+	// Timeout is how long a store has to answer
+	Timeout time.Duration `mapstructure:"timeout"`
+	Vault   VaultSecrets  `mapstructure:"vault"`
+	AWS     AWSSecrets    `mapstructure:"aws"`
+}
+
+// VaultSecrets is the HashiCorp Vault server whose KV version 2 secrets
+// engines vault:// references read from
+type VaultSecrets struct {
+	// Addr is the URL of the server, e.g. https://vault.internal:8200.
+	// Empty takes VAULT_ADDR.
+	Addr string `mapstructure:"addr"`
+	// Token authenticates the requests. Empty takes VAULT_TOKEN.
+	Token     string `mapstructure:"token"`
+	Namespace string `mapstructure:"namespace"`
+}
+
+// AWSSecrets is the AWS Secrets Manager awssm:// references read from. Empty
+// credentials take the standard AWS_ environment variables.
+type AWSSecrets struct {
+	Region          string `mapstructure:"region"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	// Endpoint replaces the regional endpoint, e.g. for a VPC endpoint
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// Validate checks the cache and the addresses of the stores
+func (s *Secrets) Validate() error {
+	if s.CacheTTL <= 0 {
+		return fmt.Errorf("secrets.cache_ttl must be positive")
+	}
+	if s.Timeout <= 0 {
+		return fmt.Errorf("secrets.timeout must be positive")
+	}
+	if s.Vault.Addr != "" {
+		if addr, err := url.Parse(s.Vault.Addr); err != nil || (addr.Scheme != "http" && addr.Scheme != "https") || addr.Host == "" {
+			return fmt.Errorf("secrets.vault.addr must be an http or https URL")
+		}
+	}
+	if (s.AWS.AccessKeyID == "") != (s.AWS.SecretAccessKey == "") {
+		return fmt.Errorf("secrets.aws.access_key_id and secrets.aws.secret_access_key must be set together")
+	}
+	if s.AWS.Endpoint != "" {
+		if endpoint, err := url.Parse(s.AWS.Endpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("secrets.aws.endpoint must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// ConfigReload configures how a running process picks up changes to its
+// config, see Reloader
+type ConfigReload struct {
+	// Watch reloads the config when its file changes. SIGHUP always does.
+	Watch bool `mapstructure:"watch"`
+}
+
+// TestClock holds the configuration of the test clock admin API
+type TestClock struct {
+	// Enabled exposes an admin API that shifts the process clock. Never enable in production.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// Gateway holds the configuration of the REST gateway generated from the proto files
+type Gateway struct {
+	// Enabled serves the gateway under /gateway/v1 on the REST port
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// GraphQL holds the configuration of the GraphQL API
+type GraphQL struct {
+	// Enabled serves the GraphQL API under /graphql on the REST port
+	Enabled bool `mapstructure:"enabled"`
+	// MaxDepth rejects queries nested deeper than this, 0 for no limit
+	MaxDepth int `mapstructure:"max_depth"`
+}
+
+// GRPCClient is a gRPC API client identified by a bearer token
+type GRPCClient struct {
+	Name  string   `mapstructure:"name"`
+	Token string   `mapstructure:"token"`
+	Roles []string `mapstructure:"roles"`
+}
+
+// GRPCAuth holds the credentials accepted by the gRPC API. The admin token
+// is also accepted and grants the admin role.
+type GRPCAuth struct {
+	Clients []GRPCClient `mapstructure:"clients"`
+}
+
+// Validate checks that every client has a unique token and at least one role
+func (a *GRPCAuth) Validate() error {
+	seen := make(map[string]bool, len(a.Clients))
+	for _, client := range a.Clients {
+		if client.Name == "" {
+			return fmt.Errorf("grpc_auth.clients: every client needs a name")
+		}
+		if client.Token == "" {
+			return fmt.Errorf("grpc_auth.clients: client %q has no token", client.Name)
+		}
+		if len(client.Roles) == 0 {
+			return fmt.Errorf("grpc_auth.clients: client %q has no roles", client.Name)
+		}
+		if seen[client.Token] {
+			return fmt.Errorf("grpc_auth.clients: client %q reuses another client's token", client.Name)
+		}
+		seen[client.Token] = true
+	}
+
+	return nil
+}
+
+// OrganizerAccount is an organizer identified by a bearer token. The token
+// shows the aggregates of the concerts with the account's organizer email.
+type OrganizerAccount struct {
+	Email string `mapstructure:"email"`
+	Token string `mapstructure:"token"`
+}
+
+// Organizers holds the credentials accepted by the organizer routes of the
+// REST API, such as the dashboard
+type Organizers struct {
+	Accounts []OrganizerAccount `mapstructure:"accounts"`
+}
+
+// Validate checks that every account has an email and a token of its own,
+// which isn't the admin token
+func (o *Organizers) Validate(adminToken string) error {
+	seen := make(map[string]bool, len(o.Accounts))
+	for _, account := range o.Accounts {
+		if account.Email == "" {
+			return fmt.Errorf("organizers.accounts: every account needs an email")
+		}
+		if account.Token == "" {
+			return fmt.Errorf("organizers.accounts: account %q has no token", account.Email)
+		}
+		if account.Token == adminToken {
+			return fmt.Errorf("organizers.accounts: account %q must not use admin.token", account.Email)
+		}
+		if seen[account.Token] {
+			return fmt.Errorf("organizers.accounts: account %q reuses another account's token", account.Email)
+		}
+		seen[account.Token] = true
+	}
+
+	return nil
+}
+
+// Encryption holds the configuration for field-level encryption of personal data
+type Encryption struct {
+	// KeyID identifies the active master key and is stored alongside every ciphertext
+	KeyID string `mapstructure:"key_id"`
+	// Key is the base64-encoded 256-bit master key. Encryption is disabled when empty.
+	Key string `mapstructure:"key"`
+	// RetiredKeys holds previous base64-encoded master keys by ID so data written
+	// before a rotation can still be decrypted
+	RetiredKeys map[string]string `mapstructure:"retired_keys"`
+}
+
+// Latency holds the per-route latency budgets used for SLO tracking
+type Latency struct {
+	// DefaultBudget applies to routes without an explicit budget. Zero disables it.
+	DefaultBudget time.Duration `mapstructure:"default_budget"`
+	// Budgets maps "METHOD /route/pattern" (e.g. "POST /api/v1/bookings") to a budget
+	Budgets map[string]time.Duration `mapstructure:"budgets"`
+	// TraceSampleRate is the fraction of requests (0-1) that record a span breakdown
+	TraceSampleRate float64 `mapstructure:"trace_sample_rate"`
+}
+
+// BookingTokens holds the configuration for booking tokens on high-demand concerts
+type BookingTokens struct {
+	// TTL is how long an issued token stays valid
+	TTL time.Duration `mapstructure:"ttl"`
+	// IssueRate is the number of tokens issued per second per concert. Zero means unlimited.
+	// Replicas share it through Redis; without Redis it applies to each replica.
+	IssueRate float64 `mapstructure:"issue_rate"`
+	// IssueBurst is the number of tokens that can be issued at once before throttling
+	IssueBurst int `mapstructure:"issue_burst"`
+}
+
+// Admission configures the controller that adapts the admission rate of the
+// waiting room, the booking token issue rate, to how bookings and the
+// database cope with it
+type Admission struct {
+	// Enabled adjusts the issue rate every Interval, between MinRate and
+	// booking_tokens.issue_rate (or the admission rate runtime setting)
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	MinRate  float64       `mapstructure:"min_rate"`
+	// IncreaseStep is added to the rate after an interval within the
+	// targets, and DecreaseFactor multiplies it after one that missed them
+	IncreaseStep   float64 `mapstructure:"increase_step"`
+	DecreaseFactor float64 `mapstructure:"decrease_factor"`
+	// LatencyTarget is the highest average booking latency, PoolWaitTarget
+	// the highest average wait for a database connection and
+	// ErrorRateTarget the highest share of bookings failing with errors
+	LatencyTarget   time.Duration `mapstructure:"latency_target"`
+	PoolWaitTarget  time.Duration `mapstructure:"pool_wait_target"`
+	ErrorRateTarget float64       `mapstructure:"error_rate_target"`
+}
+
+// Validate checks the controller settings against the issue rate they
+// adjust
+func (a *Admission) Validate(tokens BookingTokens) error {
+	if !a.Enabled {
+		return nil
+	}
+
+	if tokens.IssueRate <= 0 {
+		return fmt.Errorf("admission.enabled requires a positive booking_tokens.issue_rate")
+	}
+	if a.Interval <= 0 {
+		return fmt.Errorf("admission.interval must be positive")
+	}
+	if a.MinRate <= 0 || a.MinRate > tokens.IssueRate {
+		return fmt.Errorf("admission.min_rate must be positive and at most booking_tokens.issue_rate")
+	}
+	if a.IncreaseStep <= 0 {
+		return fmt.Errorf("admission.increase_step must be positive")
+	}
+	if a.DecreaseFactor <= 0 || a.DecreaseFactor >= 1 {
+		return fmt.Errorf("admission.decrease_factor must be between 0 and 1")
+	}
+	if a.LatencyTarget <= 0 || a.PoolWaitTarget <= 0 {
+		return fmt.Errorf("admission.latency_target and admission.pool_wait_target must be positive")
+	}
+	if a.ErrorRateTarget <= 0 || a.ErrorRateTarget > 1 {
+		return fmt.Errorf("admission.error_rate_target must be between 0 and 1")
+	}
+	return nil
+}
+
+// BookingLimits holds the limits of a single booking for concerts that
+// don't set their own
+type BookingLimits struct {
+	// MaxTicketsPerBooking is the most tickets booked at once
+	MaxTicketsPerBooking int `mapstructure:"max_tickets_per_booking"`
+	// MaxOrderValue is the highest total price of a booking, in the currency
+	// of the concert. Zero means no limit.
+	MaxOrderValue float64 `mapstructure:"max_order_value"`
+}
+
+// Validate checks that the limits let bookings through
+func (b *BookingLimits) Validate() error {
+	if b.MaxTicketsPerBooking <= 0 {
+		return fmt.Errorf("booking_limits.max_tickets_per_booking must be positive")
+	}
+	if b.MaxOrderValue < 0 {
+		return fmt.Errorf("booking_limits.max_order_value cannot be negative")
+	}
+
+	return nil
+}
+
+// SecurityHeaders holds the security headers added to every REST response.
+// Empty values disable the corresponding header.
+type SecurityHeaders struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age. Zero disables HSTS.
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	ContentTypeOptions    string        `mapstructure:"content_type_options"`
+	FrameOptions          string        `mapstructure:"frame_options"`
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+	ReferrerPolicy        string        `mapstructure:"referrer_policy"`
+}
+
+// Mail providers outgoing email is sent through
+const (
+	// MailProviderSMTP sends email through an SMTP server
+	MailProviderSMTP = "smtp"
+	// MailProviderSendGrid sends email through the SendGrid API
+	MailProviderSendGrid = "sendgrid"
+)
+
+// Mail holds the configuration for outgoing email
+type Mail struct {
+	// Provider is smtp or sendgrid
+	Provider string `mapstructure:"provider"`
+	// Host is the SMTP server. With smtp, emails are only logged when it is
+	// empty.
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	SendGrid SendGrid `mapstructure:"sendgrid"`
+}
+
+// SendGrid holds the settings of the SendGrid mail API
+type SendGrid struct {
+	APIKey  string `mapstructure:"api_key"`
+	BaseURL string `mapstructure:"base_url"`
+	// Timeout is how long SendGrid has to accept a message
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Validate checks that the provider can send email
+func (m *Mail) Validate() error {
+	switch m.Provider {
+	case MailProviderSMTP:
+		return nil
+	case MailProviderSendGrid:
+		if m.SendGrid.APIKey == "" {
+			return fmt.Errorf("mail.sendgrid.api_key is required with mail.provider %q", MailProviderSendGrid)
+		}
+		if m.SendGrid.BaseURL == "" {
+			return fmt.Errorf("mail.sendgrid.base_url is required with mail.provider %q", MailProviderSendGrid)
+		}
+		if m.SendGrid.Timeout <= 0 {
+			return fmt.Errorf("mail.sendgrid.timeout must be positive")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown mail.provider %q", m.Provider)
+}
+
+// SMS providers text messages are sent through
+const (
+	// SMSProviderLog only logs text messages
+	SMSProviderLog = "log"
+	// SMSProviderTwilio sends text messages through the Twilio API
+	SMSProviderTwilio = "twilio"
+)
+
+// SMS holds the configuration for outgoing text messages
+type SMS struct {
+	// Provider is log or twilio
+	Provider string `mapstructure:"provider"`
+	Twilio   Twilio `mapstructure:"twilio"`
+}
+
+// Twilio holds the settings of the Twilio messaging API
+type Twilio struct {
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	// From is the Twilio phone number messages are sent from
+	From    string `mapstructure:"from"`
+	BaseURL string `mapstructure:"base_url"`
+	// Timeout is how long Twilio has to accept a message
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Validate checks that the provider can send text messages
+func (s *SMS) Validate() error {
+	switch s.Provider {
+	case SMSProviderLog:
+		return nil
+	case SMSProviderTwilio:
+		if s.Twilio.AccountSID == "" || s.Twilio.AuthToken == "" {
+			return fmt.Errorf("sms.twilio.account_sid and sms.twilio.auth_token are required with sms.provider %q", SMSProviderTwilio)
+		}
+		if s.Twilio.From == "" {
+			return fmt.Errorf("sms.twilio.from is required with sms.provider %q", SMSProviderTwilio)
+		}
+		if s.Twilio.BaseURL == "" {
+			return fmt.Errorf("sms.twilio.base_url is required with sms.provider %q", SMSProviderTwilio)
+		}
+		if s.Twilio.Timeout <= 0 {
+			return fmt.Errorf("sms.twilio.timeout must be positive")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown sms.provider %q", s.Provider)
+}
+
+// Notifications holds the configuration of the notifications sent to the
+// attendees of bookings. The delivery settings are shared by the channels.
+type Notifications struct {
+	// Interval is how often events are turned into notifications and
+	// notifications are due
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize is the maximum number of events and notifications handled per
+	// run
+	BatchSize int `mapstructure:"batch_size"`
+	// MaxAttempts is how often a notification is attempted before it is dead
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BackoffBase is the wait after the first failed attempt, doubled after
+	// every further one up to BackoffMax
+	BackoffBase time.Duration `mapstructure:"backoff_base"`
+	BackoffMax  time.Duration `mapstructure:"backoff_max"`
+	// Retention is how long sent and dead notifications are kept
+	Retention time.Duration `mapstructure:"retention"`
+	// MaxEventAge is how old an event may be to be turned into notifications,
+	// so an outage or enabling a channel doesn't send stale ones
+	MaxEventAge time.Duration `mapstructure:"max_event_age"`
+	// ReminderLead is how long before a concert its attendees are reminded
+	ReminderLead time.Duration `mapstructure:"reminder_lead"`
+
+	Email EmailNotifications `mapstructure:"email"`
+	SMS   SMSNotifications   `mapstructure:"sms"`
+}
+
+// EmailNotifications holds the configuration of the emails sent to the
+// attendees of bookings, through the mail provider
+type EmailNotifications struct {
+	// Enabled turns booking and concert events into emails and sends them
+	Enabled bool `mapstructure:"enabled"`
+	// Templates switches the emails of each template on or off
+	Templates EmailTemplates `mapstructure:"templates"`
+}
+
+// EmailTemplates switches the emails of each template on or off
+type EmailTemplates struct {
+	BookingConfirmed   bool `mapstructure:"booking_confirmed"`
+	BookingCancelled   bool `mapstructure:"booking_cancelled"`
+	ConcertReminder    bool `mapstructure:"concert_reminder"`
+	ConcertRescheduled bool `mapstructure:"concert_rescheduled"`
+}
+
+// SMSNotifications holds the configuration of the text messages sent to the
+// users who opted in, through the SMS provider
+type SMSNotifications struct {
+	// Enabled texts booking confirmations and waiting room admissions
+	Enabled bool `mapstructure:"enabled"`
+	// Templates switches the text messages of each template on or off
+	Templates SMSTemplates `mapstructure:"templates"`
+}
+
+// SMSTemplates switches the text messages of each template on or off
+type SMSTemplates struct {
+	BookingConfirmed bool `mapstructure:"booking_confirmed"`
+	QueueTurn        bool `mapstructure:"queue_turn"`
+}
+
+// Enabled reports whether any channel sends notifications
+func (n *Notifications) Enabled() bool {
+	return n.Email.Enabled || n.SMS.Enabled
+}
+
+// Validate checks that notifications can be sent and retried
+func (n *Notifications) Validate() error {
+	if !n.Enabled() {
+		return nil
+	}
+
+	if n.Interval <= 0 {
+		return fmt.Errorf("notifications.interval must be positive")
+	}
+	if n.BatchSize <= 0 {
+		return fmt.Errorf("notifications.batch_size must be positive")
+	}
+	if n.MaxAttempts <= 0 {
+		return fmt.Errorf("notifications.max_attempts must be positive")
+	}
+	if n.BackoffBase <= 0 || n.BackoffMax < n.BackoffBase {
+		return fmt.Errorf("notifications.backoff_base must be positive and at most notifications.backoff_max")
+	}
+	if n.Retention <= 0 {
+		return fmt.Errorf("notifications.retention must be positive")
+	}
+	if n.MaxEventAge <= 0 {
+		return fmt.Errorf("notifications.max_event_age must be positive")
+	}
+	if n.Email.Enabled && n.Email.Templates.ConcertReminder && n.ReminderLead <= 0 {
+		return fmt.Errorf("notifications.reminder_lead must be positive to send concert reminders")
+	}
+
+	return nil
+}
+
+// Alert providers operational alerts are posted to
+const (
+	// AlertProviderSlack posts to a Slack incoming webhook
+	AlertProviderSlack = "slack"
+	// AlertProviderDiscord posts to a Discord channel webhook
+	AlertProviderDiscord = "discord"
+)
+
+// Alerts holds the configuration for the operational alerts posted to a
+// chat webhook
+type Alerts struct {
+	// Provider is slack or discord. Empty disables alerts.
+	Provider   string `mapstructure:"provider"`
+	WebhookURL string `mapstructure:"webhook_url"`
+	// Timeout is how long the webhook has to accept an alert
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Throttle is how long alerts of the same kind and subject are held back
+	// after one was posted. The next one counts those held back.
+	Throttle time.Duration `mapstructure:"throttle"`
+	// SellOuts alerts when a concert sells out
+	SellOuts bool `mapstructure:"sell_outs"`
+	// Drift alerts when the payment reconciliation finds bookings that
+	// disagree with their payments
+	Drift     bool           `mapstructure:"drift"`
+	ErrorRate ErrorRateAlert `mapstructure:"error_rate"`
+}
+
+// ErrorRateAlert configures the alert on the share of booking attempts of a
+// replica that fail with an error
+type ErrorRateAlert struct {
+	// Threshold is the share of failed attempts in an interval above which
+	// the alert is posted. Zero disables it.
+	Threshold float64 `mapstructure:"threshold"`
+	// MinAttempts is the number of attempts an interval needs before its
+	// error rate counts
+	MinAttempts int           `mapstructure:"min_attempts"`
+	Interval    time.Duration `mapstructure:"interval"`
+}
+
+// Enabled reports whether alerts are posted
+func (a *Alerts) Enabled() bool {
+	return a.Provider != ""
+}
+
+// Validate checks that alerts can be posted when they are enabled
+func (a *Alerts) Validate() error {
+	if !a.Enabled() {
+		return nil
+	}
+
+	if a.Provider != AlertProviderSlack && a.Provider != AlertProviderDiscord {
+		return fmt.Errorf("unknown alerts.provider %q", a.Provider)
+	}
+	if target, err := url.Parse(a.WebhookURL); !IsSecretReference(a.WebhookURL) && (err != nil || target.Scheme != "https" || target.Host == "") {
+		return fmt.Errorf("alerts.webhook_url must be an https URL with alerts.provider %q", a.Provider)
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("alerts.timeout must be positive")
+	}
+	if a.Throttle < 0 {
+		return fmt.Errorf("alerts.throttle must not be negative")
+	}
+	if a.ErrorRate.Threshold < 0 || a.ErrorRate.Threshold > 1 {
+		return fmt.Errorf("alerts.error_rate.threshold must be between 0 and 1")
+	}
+	if a.ErrorRate.Threshold > 0 {
+		if a.ErrorRate.MinAttempts <= 0 {
+			return fmt.Errorf("alerts.error_rate.min_attempts must be positive")
+		}
+		if a.ErrorRate.Interval <= 0 {
+			return fmt.Errorf("alerts.error_rate.interval must be positive")
+		}
+	}
+
+	return nil
+}
+
+// Reporting holds the configuration for final sales reports
+type Reporting struct {
+	// Interval is how often concerts whose sale ended are checked. Zero disables reporting.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// Pricing holds the configuration for pricing phase switches
+type Pricing struct {
+	// SwitchInterval is how often concerts are checked for door prices that
+	// took effect, to announce the switch. Zero disables the announcements;
+	// the door price applies on time either way.
+	SwitchInterval time.Duration `mapstructure:"switch_interval"`
+}
+
+// InventoryReleases holds the configuration for scheduled inventory releases
+type InventoryReleases struct {
+	// Interval is how often due releases are put on sale. Zero disables releases.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// QuickBooks holds the QuickBooks Online connection used by the accounting export
+type QuickBooks struct {
+	// AccessToken is the OAuth access token. The adapter is disabled when it is empty.
+	AccessToken string `mapstructure:"access_token"`
+	BaseURL     string `mapstructure:"base_url"`
+	RealmID     string `mapstructure:"realm_id"`
+	// CashAccountID and RevenueAccountID are the QuickBooks account IDs bookings post to
+	CashAccountID    string `mapstructure:"cash_account_id"`
+	RevenueAccountID string `mapstructure:"revenue_account_id"`
+}
+
+// Xero holds the Xero connection used by the accounting export
+type Xero struct {
+	// AccessToken is the OAuth access token. The adapter is disabled when it is empty.
+	AccessToken string `mapstructure:"access_token"`
+	BaseURL     string `mapstructure:"base_url"`
+	TenantID    string `mapstructure:"tenant_id"`
+	// CashAccountCode and RevenueAccountCode are the Xero account codes bookings post to
+	CashAccountCode    string `mapstructure:"cash_account_code"`
+	RevenueAccountCode string `mapstructure:"revenue_account_code"`
+}
+
+// Accounting holds the configuration for exporting bookings to accounting systems
+type Accounting struct {
+	// Interval is how often pending journal entries are pushed. Zero disables the export.
+	Interval   time.Duration `mapstructure:"interval"`
+	QuickBooks QuickBooks    `mapstructure:"quickbooks"`
+	Xero       Xero          `mapstructure:"xero"`
+}
+
+// Validate checks that every enabled accounting adapter is fully configured
+func (a *Accounting) Validate() error {
+	if a.QuickBooks.AccessToken != "" {
+		if a.QuickBooks.RealmID == "" {
+			return fmt.Errorf("accounting.quickbooks.realm_id is required when QuickBooks is enabled")
+		}
+		if a.QuickBooks.CashAccountID == "" || a.QuickBooks.RevenueAccountID == "" {
+			return fmt.Errorf("accounting.quickbooks needs cash_account_id and revenue_account_id")
+		}
+	}
+
+	if a.Xero.AccessToken != "" {
+		if a.Xero.TenantID == "" {
+			return fmt.Errorf("accounting.xero.tenant_id is required when Xero is enabled")
+		}
+		if a.Xero.CashAccountCode == "" || a.Xero.RevenueAccountCode == "" {
+			return fmt.Errorf("accounting.xero needs cash_account_code and revenue_account_code")
+		}
+	}
+
+	if a.Interval < 0 {
+		return fmt.Errorf("accounting.interval cannot be negative")
+	}
+
+	return nil
+}
+
+// Destinations the warehouse export uploads to
+const (
+	// WarehouseDestinationS3 uploads to an S3 bucket, or one of a store
+	// speaking the S3 API
+	WarehouseDestinationS3 = "s3"
+	// WarehouseDestinationGCS uploads to a Google Cloud Storage bucket
+	WarehouseDestinationGCS = "gcs"
+	// WarehouseDestinationDir writes to a local directory, for development
+	WarehouseDestinationDir = "dir"
+)
+
+// Formats of the warehouse export files
+const (
+	// WarehouseFormatCSV writes gzipped CSV files with a header row
+	WarehouseFormatCSV = "csv"
+)
+
+// WarehouseS3 is the S3 bucket the warehouse export uploads to. An empty
+// region and credentials take the standard AWS_ environment variables.
+type WarehouseS3 struct {
+	Bucket          string `mapstructure:"bucket"`
+	Region          string `mapstructure:"region"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	// Endpoint replaces the regional endpoint and addresses the bucket in
+	// the path, e.g. for MinIO
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// WarehouseGCS is the Google Cloud Storage bucket the warehouse export uploads to
+type WarehouseGCS struct {
+	Bucket string `mapstructure:"bucket"`
+	// AccessToken is an OAuth access token allowed to create objects. It is
+	// used as configured; refreshing it is left to the deployment.
+	AccessToken string `mapstructure:"access_token"`
+	BaseURL     string `mapstructure:"base_url"`
+}
+
+// Warehouse holds the configuration for exporting snapshots of the bookings
+// and concerts that changed to the data warehouse
+type Warehouse struct {
+	// Interval is how often the changes are exported. Zero disables the export.
+	Interval time.Duration `mapstructure:"interval"`
+	// Destination is s3, gcs or dir
+	Destination string `mapstructure:"destination"`
+	// Format is csv, the only format supported; parquet is rejected
+	Format string `mapstructure:"format"`
+	// Prefix is prepended to the keys of the files, e.g. tickets/
+	Prefix string `mapstructure:"prefix"`
+	// BatchSize is the maximum number of rows per file
+	BatchSize int `mapstructure:"batch_size"`
+	// Lag holds back the changes of the last moments, which transactions
+	// still running may yet commit changes dated before
+	Lag time.Duration `mapstructure:"lag"`
+	S3  WarehouseS3   `mapstructure:"s3"`
+	GCS WarehouseGCS  `mapstructure:"gcs"`
+	// Dir is the directory of the dir destination
+	Dir string `mapstructure:"dir"`
+}
+
+// Validate checks that the export has a destination and a format it supports
+func (w *Warehouse) Validate() error {
+	if w.Interval < 0 {
+		return fmt.Errorf("warehouse.interval cannot be negative")
+	}
+	if w.Interval == 0 {
+		return nil
+	}
+
+	switch w.Format {
+	case WarehouseFormatCSV:
+	case "parquet":
+		return fmt.Errorf("warehouse.format parquet isn't supported, use %s", WarehouseFormatCSV)
+	default:
+		return fmt.Errorf("unknown warehouse.format %q", w.Format)
+	}
+
+	switch w.Destination {
+	case WarehouseDestinationS3:
+		if w.S3.Bucket == "" {
+			return fmt.Errorf("warehouse.s3.bucket is required for the s3 destination")
+		}
+		if (w.S3.AccessKeyID == "") != (w.S3.SecretAccessKey == "") {
+			return fmt.Errorf("warehouse.s3.access_key_id and warehouse.s3.secret_access_key must be set together")
+		}
+	case WarehouseDestinationGCS:
+		if w.GCS.Bucket == "" || w.GCS.AccessToken == "" {
+			return fmt.Errorf("warehouse.gcs needs bucket and access_token")
+		}
+	case WarehouseDestinationDir:
+		if w.Dir == "" {
+			return fmt.Errorf("warehouse.dir is required for the dir destination")
+		}
+	default:
+		return fmt.Errorf("unknown warehouse.destination %q, must be s3, gcs or dir", w.Destination)
+	}
+
+	if w.BatchSize <= 0 {
+		return fmt.Errorf("warehouse.batch_size must be positive")
+	}
+	if w.Lag < 0 {
+		return fmt.Errorf("warehouse.lag cannot be negative")
+	}
+
+	return nil
+}
+
+// Message brokers domain events are published to
+const (
+	// BrokerNone keeps the events in the outbox without publishing them
+	BrokerNone = "none"
+	// BrokerLog logs the events instead of publishing them
+	BrokerLog = "log"
+	// BrokerKafka publishes the events to Kafka
+	BrokerKafka = "kafka"
+	// BrokerNATS publishes the events to NATS JetStream
+	BrokerNATS = "nats"
+)
+
+// Kafka holds the connection of the Kafka broker events are published to
+type Kafka struct {
+	// Brokers are the host:port addresses the client bootstraps from
+	Brokers []string `mapstructure:"brokers"`
+	// TopicPrefix is put in front of the event type to name its topic, so
+	// with concert-tickets. booking.confirmed goes to concert-tickets.booking.confirmed
+	TopicPrefix string `mapstructure:"topic_prefix"`
+	// WriteTimeout is how long the brokers have to acknowledge an event
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
+// NATS holds the connection of the NATS JetStream server events are published to
+type NATS struct {
+	// URL is the server to connect to, or a comma-separated list of servers
+	URL string `mapstructure:"url"`
+	// SubjectPrefix is put in front of the event type to name its subject,
+	// which a stream must capture
+	SubjectPrefix string `mapstructure:"subject_prefix"`
+	// PublishTimeout is how long the stream has to store an event
+	PublishTimeout time.Duration `mapstructure:"publish_timeout"`
+}
+
+// Events holds the configuration of the outbox of domain events
+type Events struct {
+	// Broker is where the relay publishes the events: none, log, kafka or nats
+	Broker string `mapstructure:"broker"`
+	Kafka  Kafka  `mapstructure:"kafka"`
+	NATS   NATS   `mapstructure:"nats"`
+	// RelayInterval is how often the outbox is relayed to the broker
+	RelayInterval time.Duration `mapstructure:"relay_interval"`
+	// BatchSize is the maximum number of events relayed per run
+	BatchSize int `mapstructure:"batch_size"`
+	// Retention is how long events are kept in the outbox. Without a broker
+	// every event is purged by then, otherwise only the published ones.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// Publishes reports whether the events are relayed to a broker
+func (e *Events) Publishes() bool {
+	return e.Broker != BrokerNone
+}
+
+// Validate checks the broker and that the relay can run and purge
+func (e *Events) Validate() error {
+	switch e.Broker {
+	case BrokerNone, BrokerLog:
+	case BrokerKafka:
+		if len(e.Kafka.Brokers) == 0 {
+			return fmt.Errorf("events.kafka.brokers is required when events.broker is %q", BrokerKafka)
+		}
+		if e.Kafka.WriteTimeout <= 0 {
+			return fmt.Errorf("events.kafka.write_timeout must be positive")
+		}
+	case BrokerNATS:
+		if e.NATS.URL == "" {
+			return fmt.Errorf("events.nats.url is required when events.broker is %q", BrokerNATS)
+		}
+		if e.NATS.PublishTimeout <= 0 {
+			return fmt.Errorf("events.nats.publish_timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("events.broker must be %q, %q, %q or %q, got %q", BrokerNone, BrokerLog, BrokerKafka, BrokerNATS, e.Broker)
+	}
+
+	if e.RelayInterval <= 0 {
+		return fmt.Errorf("events.relay_interval must be positive")
+	}
+	if e.BatchSize <= 0 {
+		return fmt.Errorf("events.batch_size must be positive")
+	}
+	if e.Retention <= 0 {
+		return fmt.Errorf("events.retention must be positive")
+	}
+
+	return nil
+}
+
+// Webhooks holds the configuration of the webhooks organizers register
+type Webhooks struct {
+	// Enabled serves the webhook endpoints and delivers the events
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often events are fanned out and deliveries are due
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize is the maximum number of deliveries attempted per run
+	BatchSize int `mapstructure:"batch_size"`
+	// Concurrency is how many deliveries are attempted at once
+	Concurrency int `mapstructure:"concurrency"`
+	// Timeout is how long a receiver has to answer
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts is how often a delivery is attempted before it is dead
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BackoffBase is the wait after the first failed attempt, doubled after
+	// every further one up to BackoffMax
+	BackoffBase time.Duration `mapstructure:"backoff_base"`
+	BackoffMax  time.Duration `mapstructure:"backoff_max"`
+	// Retention is how long delivered and dead deliveries are kept
+	Retention time.Duration `mapstructure:"retention"`
+	// AllowHTTP accepts plain http URLs, for receivers in development
+	AllowHTTP bool `mapstructure:"allow_http"`
+	// AllowPrivateNetworks accepts URLs of loopback, private and link-local
+	// addresses, for receivers in development. Otherwise webhooks could
+	// reach the services next to ours, like the cloud metadata endpoint.
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// Validate checks that deliveries can run and be retried
+func (w *Webhooks) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+
+	if w.Interval <= 0 {
+		return fmt.Errorf("webhooks.interval must be positive")
+	}
+	if w.BatchSize <= 0 {
+		return fmt.Errorf("webhooks.batch_size must be positive")
+	}
+	if w.Concurrency <= 0 {
+		return fmt.Errorf("webhooks.concurrency must be positive")
+	}
+	if w.Timeout <= 0 {
+		return fmt.Errorf("webhooks.timeout must be positive")
+	}
+	if w.MaxAttempts <= 0 {
+		return fmt.Errorf("webhooks.max_attempts must be positive")
+	}
+	if w.BackoffBase <= 0 || w.BackoffMax < w.BackoffBase {
+		return fmt.Errorf("webhooks.backoff_base must be positive and at most webhooks.backoff_max")
+	}
+	if w.Retention <= 0 {
+		return fmt.Errorf("webhooks.retention must be positive")
+	}
+
+	return nil
+}
+
+// Payment providers bookings are paid through
+const (
+	// PaymentProviderNone confirms bookings without a payment
+	PaymentProviderNone = "none"
+	// PaymentProviderFake takes payments with an in-process fake provider,
+	// for development and tests
+	PaymentProviderFake = "fake"
+	// PaymentProviderStripe takes payments with Stripe PaymentIntents
+	PaymentProviderStripe = "stripe"
+)
+
+// Stripe holds the Stripe account bookings are paid to
+type Stripe struct {
+	// SecretKey authenticates the API requests
+	SecretKey string `mapstructure:"secret_key"`
+	// WebhookSecret verifies the signatures of Stripe's webhooks
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	BaseURL       string `mapstructure:"base_url"`
+	// SecretKeySource, when set, is asked for the secret key of every
+	// request instead of SecretKey, like Database.PasswordSource
+	SecretKeySource func(ctx context.Context) (string, error) `mapstructure:"-"`
+	// Timeout is how long Stripe has to answer a request
+	Timeout time.Duration `mapstructure:"timeout"`
+	// WebhookTolerance is how old a webhook's signature may be
+	WebhookTolerance time.Duration `mapstructure:"webhook_tolerance"`
+}
+
+// Outcomes of the payments of the fake provider
+const (
+	// FakeOutcomeAuthorized authorizes payments right away, so bookings are
+	// confirmed without waiting for a notification
+	FakeOutcomeAuthorized = "authorized"
+	// FakeOutcomePending leaves payments for notifications signed with the
+	// webhook secret to settle
+	FakeOutcomePending = "pending"
+)
+
+// FakePayments configures the fake payment provider
+type FakePayments struct {
+	Outcome string `mapstructure:"outcome"`
+	// WebhookSecret verifies the signatures of the notifications settling
+	// pending payments
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// PaymentReconciliation configures the job comparing the bookings of
+// payments with the provider's records
+type PaymentReconciliation struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	// Lookback is how old the payments compared may be
+	Lookback time.Duration `mapstructure:"lookback"`
+	// Grace leaves payments that changed more recently to the provider's
+	// notifications
+	Grace     time.Duration `mapstructure:"grace"`
+	BatchSize int           `mapstructure:"batch_size"`
+	// Repair confirms the bookings of paid payments and expires confirmed
+	// bookings that weren't paid, instead of only flagging them
+	Repair bool `mapstructure:"repair"`
+}
+
+// Validate checks the reconciliation settings when it is enabled
+func (r *PaymentReconciliation) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.Interval <= 0 || r.Lookback <= 0 || r.Grace <= 0 {
+		return fmt.Errorf("payments.reconciliation.interval, lookback and grace must be positive")
+	}
+	if r.BatchSize <= 0 {
+		return fmt.Errorf("payments.reconciliation.batch_size must be positive")
+	}
+	return nil
+}
+
+// Payments holds the configuration for taking the payments of bookings
+type Payments struct {
+	// Provider takes the payments of bookings. With a provider, bookings are
+	// pending until they are paid.
+	Provider string `mapstructure:"provider"`
+	// HoldTTL is how long a pending booking holds its tickets
+	HoldTTL time.Duration `mapstructure:"hold_ttl"`
+	// ExpiryInterval is how often the tickets of expired holds are released
+	ExpiryInterval time.Duration         `mapstructure:"expiry_interval"`
+	Reconciliation PaymentReconciliation `mapstructure:"reconciliation"`
+	Fake           FakePayments          `mapstructure:"fake"`
+	Stripe         Stripe                `mapstructure:"stripe"`
+}
+
+// Enabled checks if bookings are paid through a provider
+func (p *Payments) Enabled() bool {
+	return p.Provider != PaymentProviderNone
+}
+
+// Validate checks that the provider is known and fully configured
+func (p *Payments) Validate() error {
+	switch p.Provider {
+	case PaymentProviderNone:
+		return nil
+	case PaymentProviderFake:
+		switch p.Fake.Outcome {
+		case FakeOutcomeAuthorized:
+		case FakeOutcomePending:
+			if p.Fake.WebhookSecret == "" {
+				return fmt.Errorf("payments.fake needs webhook_secret for pending payments")
+			}
+		default:
+			return fmt.Errorf("unknown payments.fake.outcome %q", p.Fake.Outcome)
+		}
+	case PaymentProviderStripe:
+		if p.Stripe.SecretKey == "" || p.Stripe.WebhookSecret == "" {
+			return fmt.Errorf("payments.stripe needs secret_key and webhook_secret")
+		}
+		if p.Stripe.Timeout <= 0 || p.Stripe.WebhookTolerance <= 0 {
+			return fmt.Errorf("payments.stripe.timeout and payments.stripe.webhook_tolerance must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown payments.provider %q", p.Provider)
+	}
+
+	if p.HoldTTL <= 0 {
+		return fmt.Errorf("payments.hold_ttl must be positive")
+	}
+	if p.ExpiryInterval <= 0 {
+		return fmt.Errorf("payments.expiry_interval must be positive")
+	}
+
+	return p.Reconciliation.Validate()
+}
+
+// BookingAttempts holds the configuration of booking attempt recording
+type BookingAttempts struct {
+	// Enabled records the outcome of every booking attempt in booking_attempts
+	Enabled bool `mapstructure:"enabled"`
+	// BufferSize is how many attempts can wait to be written before new ones are dropped
+	BufferSize int `mapstructure:"buffer_size"`
+	// FlushInterval is how often waiting attempts are written
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Retention is how long attempts are kept before they are purged
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// Validate checks that enabled attempt recording can buffer, flush and purge
+func (b *BookingAttempts) Validate() error {
+	if !b.Enabled {
+		return nil
+	}
+
+	if b.BufferSize <= 0 {
+		return fmt.Errorf("booking_attempts.buffer_size must be positive")
+	}
+	if b.FlushInterval <= 0 {
+		return fmt.Errorf("booking_attempts.flush_interval must be positive")
+	}
+	if b.Retention <= 0 {
+		return fmt.Errorf("booking_attempts.retention must be positive")
+	}
+
+	return nil
+}
+
+// Jobs holds the configuration shared by the background jobs
+type Jobs struct {
+	// InstanceID names this instance in the job run history; the hostname
+	// is used when empty
+	InstanceID string `mapstructure:"instance_id"`
+	// RunRetention is how long job runs are kept before they are purged
+	RunRetention time.Duration `mapstructure:"run_retention"`
+}
+
+// Instance returns the configured instance ID, or the hostname
+func (j *Jobs) Instance() string {
+	if j.InstanceID != "" {
+		return j.InstanceID
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return "unknown"
+}
+
+// Recording holds the configuration of the traffic recorder, which writes a
+// sample of anonymized REST requests for replaying on staging
+type Recording struct {
+	// Enabled records the sampled requests to Path
+	Enabled bool `mapstructure:"enabled"`
+	// Path is the file recorded requests are appended to, one JSON object per line
+	Path string `mapstructure:"path"`
+	// SampleRate is the fraction of clients (0-1] whose requests are recorded
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// Salt keys the pseudonyms of users and clients. It is random unless set;
+	// replicas recording the same on-sale need the same salt so a user keeps
+	// one pseudonym.
+	Salt string `mapstructure:"salt"`
+	// MaxBodyBytes is the largest request body recorded; requests with larger
+	// bodies are recorded without them
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+}
+
+// Validate checks that an enabled recorder has a file and a sample rate
+func (r *Recording) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+
+	if r.Path == "" {
+		return fmt.Errorf("recording.path must be set")
+	}
+	if r.SampleRate <= 0 || r.SampleRate > 1 {
+		return fmt.Errorf("recording.sample_rate must be greater than 0 and at most 1")
+	}
+	if r.MaxBodyBytes < 0 {
+		return fmt.Errorf("recording.max_body_bytes cannot be negative")
+	}
+
+	return nil
+}
+
+// Pages holds the configuration of the HTML ticket and receipt pages and the
+// calendar feeds, which are opened from signed links rather than with credentials
+type Pages struct {
+	// Enabled serves /t/:ticketToken, /r/:receiptToken and the calendar feeds
+	Enabled bool `mapstructure:"enabled"`
+	// SigningKey signs the page links; links issued under another key stop working
+	SigningKey string `mapstructure:"signing_key"`
+	// BaseURL is the public URL the issued links start with, e.g. https://tickets.example.com
+	BaseURL string `mapstructure:"base_url"`
+	// LinkTTL is how long an issued link stays valid
+	LinkTTL time.Duration `mapstructure:"link_ttl"`
+}
+
+// Validate checks that enabled pages have a strong key and an absolute base URL
+func (p *Pages) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+
+	if !IsSecretReference(p.SigningKey) && len(p.SigningKey) < 32 {
+		return fmt.Errorf("pages.signing_key must be at least 32 characters")
+	}
+	base, err := url.Parse(p.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return fmt.Errorf("pages.base_url must be an absolute http or https URL")
+	}
+	if p.LinkTTL <= 0 {
+		return fmt.Errorf("pages.link_ttl must be positive")
+	}
+
+	return nil
+}
+
+// WebSocket holds the configuration of the /ws endpoint
+type WebSocket struct {
+	// Enabled serves waiting room positions and booking status changes on /ws
+	Enabled bool `mapstructure:"enabled"`
+	// SigningKey verifies the tokens users connect with, issued by the
+	// service that authenticates them; /ws isn't served without one
+	SigningKey string `mapstructure:"signing_key"`
+	// AdmitInterval is how often users in the waiting room are issued booking tokens
+	AdmitInterval time.Duration `mapstructure:"admit_interval"`
+	// RejoinGrace is how long a user whose connection dropped keeps their
+	// place in the shared waiting room, e.g. while replicas are replaced
+	RejoinGrace time.Duration `mapstructure:"rejoin_grace"`
+	// SnapshotInterval is how often the shared waiting room is copied to the
+	// database, to recover the queues if Redis loses them
+	SnapshotInterval time.Duration `mapstructure:"snapshot_interval"`
+}
+
+// Redis holds the connection settings of the Redis server shared by the
+// replicas
+type Redis struct {
+	// Addr is the host:port of the server. Without it, state the replicas
+	// would share stays in each instance's memory.
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+}
+
+// Enabled reports whether a Redis server is configured
+func (r *Redis) Enabled() bool {
+	return r.Addr != ""
+}
+
+// ConcertCache holds the configuration of the cache of concert details, which
+// is kept in Redis when a server is configured
+type ConcertCache struct {
+	// TTL is how long a concert stays cached. Concerts changed or booked
+	// through the API are dropped from the cache at once, so it bounds how
+	// stale other changes, such as scheduled releases, can be. Zero disables
+	// the cache.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Inventory modes, which decide where bookings take their tickets from
+const (
+	// InventoryModeDatabase books tickets by locking the concert row
+	InventoryModeDatabase = "database"
+	// InventoryModeRedis books tickets from counters in Redis, which are
+	// subtracted from the concerts in the database later
+	InventoryModeRedis = "redis"
+)
+
+// Inventory holds the configuration of how booked tickets are counted
+type Inventory struct {
+	// Mode is InventoryModeDatabase or InventoryModeRedis. The redis mode
+	// needs a Redis server.
+	Mode string `mapstructure:"mode"`
+	// FlushInterval is how often the tickets booked in the redis mode are
+	// subtracted from the concerts, which bounds how far the available
+	// tickets in the database lag behind
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// Validate checks the inventory mode against the Redis configuration
+func (i *Inventory) Validate(redis Redis) error {
+	switch i.Mode {
+	case InventoryModeDatabase:
+		return nil
+	case InventoryModeRedis:
+	default:
+		return fmt.Errorf("inventory.mode must be %q or %q", InventoryModeDatabase, InventoryModeRedis)
+	}
+
+	if !redis.Enabled() {
+		return fmt.Errorf("inventory.mode %q requires redis.addr", InventoryModeRedis)
+	}
+	if i.FlushInterval <= 0 {
+		return fmt.Errorf("inventory.flush_interval must be positive")
+	}
+	return nil
+}
+
+// Booking strategies, which decide how concurrent bookings of a concert are
+// kept from selling the same tickets
+const (
+	// BookingStrategyOptimistic books against the concert version read
+	// before and retries when another booking changed it meanwhile
+	BookingStrategyOptimistic = "optimistic"
+	// BookingStrategyAdvisory makes the bookings of a concert one at a time
+	// under a Postgres advisory lock, so none of them retry
+	BookingStrategyAdvisory = "advisory"
+	// BookingStrategyConditional takes the tickets with one update that
+	// only passes while enough are left, so bookings neither read the
+	// concert first nor retry
+	BookingStrategyConditional = "conditional"
+)
+
+// Booking holds the configuration of how tickets are booked from the database
+type Booking struct {
+	// Strategy is BookingStrategyConditional, BookingStrategyOptimistic or
+	// BookingStrategyAdvisory. It
+	// applies to bookings of single concerts that lock the concert row, which
+	// are all of them unless inventory.mode is redis.
+	Strategy string `mapstructure:"strategy"`
+	// Retry is how long bookings, batch bookings and checkouts wait before
+	// retrying a version conflict, up to max_retries attempts
+	Retry BookingRetry `mapstructure:"retry"`
+}
+
+// BookingRetry holds the exponential backoff between the attempts of
+// operations that lost a race for a concert
+type BookingRetry struct {
+	// BaseDelay is the delay before the first retry. Each retry waits
+	// Multiplier times longer than the one before, at most MaxDelay.
+	BaseDelay  time.Duration `mapstructure:"base_delay"`
+	MaxDelay   time.Duration `mapstructure:"max_delay"`
+	Multiplier float64       `mapstructure:"multiplier"`
+	// Jitter is the largest share of a delay left out at random, between 0
+	// and 1, so bookings that collided don't retry in step
+	Jitter float64 `mapstructure:"jitter"`
+}
+
+// Validate checks the booking strategy and its retries
+func (b *Booking) Validate() error {
+	switch b.Strategy {
+	case BookingStrategyConditional, BookingStrategyOptimistic, BookingStrategyAdvisory:
+	default:
+		return fmt.Errorf("booking.strategy must be %q, %q or %q", BookingStrategyConditional, BookingStrategyOptimistic, BookingStrategyAdvisory)
+	}
+
+	// Unset delays and multiplier keep the defaults of the booking service
+	if b.Retry.BaseDelay < 0 || b.Retry.MaxDelay < 0 {
+		return fmt.Errorf("booking.retry delays cannot be negative")
+	}
+	if b.Retry.MaxDelay > 0 && b.Retry.MaxDelay < b.Retry.BaseDelay {
+		return fmt.Errorf("booking.retry.max_delay must be at least booking.retry.base_delay")
+	}
+	if b.Retry.Multiplier != 0 && b.Retry.Multiplier < 1 {
+		return fmt.Errorf("booking.retry.multiplier must be at least 1")
+	}
+	if b.Retry.Jitter < 0 || b.Retry.Jitter > 1 {
+		return fmt.Errorf("booking.retry.jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// RuntimeSettings holds the defaults of the operational settings operators
+// change at runtime through the internal admin listener. Once they have been
+// changed, the stored settings are used instead.
+type RuntimeSettings struct {
+	// RateLimit is the number of requests per second each client IP may make
+	RateLimit int `mapstructure:"rate_limit"`
+	// PollInterval is how often every replica reloads the settings, in case
+	// it missed the announcement of a change
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+// Health holds the configuration of the component health checks
+type Health struct {
+	// CheckInterval is how often dependencies such as the database are checked
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	CheckTimeout  time.Duration `mapstructure:"check_timeout"`
+	// FailureThreshold is the number of consecutive failed checks before a component is unhealthy
+	FailureThreshold int `mapstructure:"failure_threshold"`
+}
+
+// Degradation holds the configuration of the degradation mode used while the database is down
+type Degradation struct {
+	// Enabled caches GET responses and serves them while the database is unhealthy
+	Enabled bool `mapstructure:"enabled"`
+	// CacheEntries is the maximum number of cached responses
+	CacheEntries int `mapstructure:"cache_entries"`
+	// MaxStaleness is the age after which cached responses are no longer served
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+	// RetryAfter is sent with 503 responses to tell clients when to retry
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+}
+
+// LoadShedding holds the limits past which API requests are rejected before
+// any work is done, so those admitted keep their latency during spikes
+type LoadShedding struct {
+	// Enabled limits the API requests served at once to MaxInFlight, of which
+	// at most MaxInFlightWrites change state
+	Enabled           bool `mapstructure:"enabled"`
+	MaxInFlight       int  `mapstructure:"max_in_flight"`
+	MaxInFlightWrites int  `mapstructure:"max_in_flight_writes"`
+	// MaxQueue requests wait up to QueueTimeout for a slot, reads first;
+	// those beyond are rejected at once
+	MaxQueue     int           `mapstructure:"max_queue"`
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+	// RetryAfter is sent with the 503 responses of rejected requests
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+}
+
+// Validate checks the limits of load shedding
+func (l *LoadShedding) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+
+	if l.MaxInFlight <= 0 {
+		return fmt.Errorf("load_shedding.max_in_flight must be positive")
+	}
+	if l.MaxInFlightWrites <= 0 || l.MaxInFlightWrites > l.MaxInFlight {
+		return fmt.Errorf("load_shedding.max_in_flight_writes must be positive and at most load_shedding.max_in_flight")
+	}
+	if l.MaxQueue < 0 {
+		return fmt.Errorf("load_shedding.max_queue cannot be negative")
+	}
+	if l.MaxQueue > 0 && l.QueueTimeout <= 0 {
+		return fmt.Errorf("load_shedding.queue_timeout must be positive")
+	}
+
+	return nil
+}
+
+// ReadOnly holds the configuration of the read-only mode used by public mirrors
+type ReadOnly struct {
+	// Enabled serves only the public read endpoints: writes, admin endpoints,
+	// GraphQL and background jobs are left out and migrations are not run
+	Enabled bool `mapstructure:"enabled"`
+	// MaxAge is how long caches such as a CDN may serve a read response
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// StaleWhileRevalidate is how long caches may keep serving a stale
+	// response while they fetch a fresh one
+	StaleWhileRevalidate time.Duration `mapstructure:"stale_while_revalidate"`
+}
+
+// API holds the configuration of the REST API versions
+type API struct {
+	// V2Enabled serves /api/v2 next to /api/v1
+	V2Enabled bool       `mapstructure:"v2_enabled"`
+	V1        APIVersion `mapstructure:"v1"`
+	V2        APIVersion `mapstructure:"v2"`
+}
+
+// APIVersion holds the deprecation schedule of a REST API version. Responses
+// of a version with a deprecation date carry Deprecation and Sunset headers.
+type APIVersion struct {
+	// DeprecatedAt is the RFC 3339 time the version is deprecated from, empty
+	// while it is supported
+	DeprecatedAt string `mapstructure:"deprecated_at"`
+	// SunsetAt is the RFC 3339 time after which the version may be removed
+	SunsetAt string `mapstructure:"sunset_at"`
+	// Link points to the migration guide, sent as a rel="deprecation" link
+	Link string `mapstructure:"link"`
+}
+
+// Schedule parses the deprecation and sunset times. Unset times are zero.
+func (v *APIVersion) Schedule() (deprecatedAt, sunsetAt time.Time, err error) {
+	if v.DeprecatedAt != "" {
+		if deprecatedAt, err = time.Parse(time.RFC3339, v.DeprecatedAt); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid deprecated_at %q, expected RFC 3339", v.DeprecatedAt)
+		}
+	}
+	if v.SunsetAt != "" {
+		if sunsetAt, err = time.Parse(time.RFC3339, v.SunsetAt); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid sunset_at %q, expected RFC 3339", v.SunsetAt)
+		}
+	}
+	return deprecatedAt, sunsetAt, nil
+}
+
+// Validate checks the deprecation schedule of every version
+func (a *API) Validate() error {
+	for name, version := range map[string]*APIVersion{"v1": &a.V1, "v2": &a.V2} {
+		deprecatedAt, sunsetAt, err := version.Schedule()
+		if err != nil {
+			return fmt.Errorf("api.%s: %w", name, err)
+		}
+		if !sunsetAt.IsZero() && deprecatedAt.IsZero() {
+			return fmt.Errorf("api.%s: sunset_at requires deprecated_at", name)
+		}
+		if !sunsetAt.IsZero() && sunsetAt.Before(deprecatedAt) {
+			return fmt.Errorf("api.%s: sunset_at must not be before deprecated_at", name)
+		}
+	}
+
+	if !a.V2Enabled && a.V1.DeprecatedAt != "" {
+		return fmt.Errorf("api.v1 cannot be deprecated while api.v2_enabled is false")
+	}
+
+	return nil
+}
+
+// CORS holds the cross-origin resource sharing policy for the REST API
+type CORS struct {
+	// AllowOrigins lists the allowed origins, e.g. "https://tickets.example.com".
+	// "*" allows any origin and cannot be combined with AllowCredentials.
+	AllowOrigins     []string      `mapstructure:"allow_origins"`
+	AllowMethods     []string      `mapstructure:"allow_methods"`
+	AllowHeaders     []string      `mapstructure:"allow_headers"`
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+// Validate checks that the CORS policy is one browsers will accept
+func (c *CORS) Validate() error {
+	if len(c.AllowOrigins) == 0 {
+		return fmt.Errorf("cors.allow_origins must not be empty")
+	}
+
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("cors.allow_origins cannot contain \"*\" when cors.allow_credentials is enabled")
+			}
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("cors.allow_origins contains invalid origin %q", origin)
+		}
+	}
+
+	if len(c.AllowMethods) == 0 {
+		return fmt.Errorf("cors.allow_methods must not be empty")
+	}
+
+	for _, method := range c.AllowMethods {
+		switch strings.ToUpper(method) {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("cors.allow_methods contains invalid method %q", method)
+		}
+	}
+
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors.max_age cannot be negative")
+	}
+
+	return nil
+}
+
+// The log output formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Logging configures the log output beyond the level of log_level
+type Logging struct {
+	// Format is text, a line per message with its fields as key=value, or
+	// json, an object per message for log aggregation
+	Format string `mapstructure:"format"`
+	// Levels maps components, like "http" or "workers", to the level they
+	// log at instead of log_level
+	Levels map[string]string `mapstructure:"levels"`
+}
+
+// Validate checks the format and the levels of the components
+func (l *Logging) Validate() error {
+	if l.Format != LogFormatText && l.Format != LogFormatJSON {
+		return fmt.Errorf("logging.format must be %q or %q", LogFormatText, LogFormatJSON)
+	}
+
+	for component, level := range l.Levels {
+		switch strings.ToLower(level) {
+		case "debug", "info", "warn", "error", "fatal":
+		default:
+			return fmt.Errorf("logging.levels.%s must be one of debug, info, warn, error or fatal", component)
+		}
+	}
+
+	return nil
 }
 
 // Config holds all configuration for the application
 type Config struct {
-	LogLevel   string   `mapstructure:"log_level"`
-	RESTPort   int      `mapstructure:"rest_port"`
-	GRPCPort   int      `mapstructure:"grpc_port"`
-	Database   Database `mapstructure:"database"`
-	MaxRetries int      `mapstructure:"max_retries"`
+	LogLevel      string            `mapstructure:"log_level"`
+	Logging       Logging           `mapstructure:"logging"`
+	RESTPort      int               `mapstructure:"rest_port"`
+	GRPCPort      int               `mapstructure:"grpc_port"`
+	Database      Database          `mapstructure:"database"`
+	Redis         Redis             `mapstructure:"redis"`
+	ConcertCache  ConcertCache      `mapstructure:"concert_cache"`
+	Inventory     Inventory         `mapstructure:"inventory"`
+	Booking       Booking           `mapstructure:"booking"`
+	Runtime       RuntimeSettings   `mapstructure:"runtime_settings"`
+	MaxRetries    int               `mapstructure:"max_retries"`
+	Admin         Admin             `mapstructure:"admin"`
+	InternalAdmin InternalAdmin     `mapstructure:"internal_admin"`
+	Debug         Debug             `mapstructure:"debug"`
+	Shutdown      Shutdown          `mapstructure:"shutdown"`
+	Reload        ConfigReload      `mapstructure:"reload"`
+	Secrets       Secrets           `mapstructure:"secrets"`
+	Encryption    Encryption        `mapstructure:"encryption"`
+	Latency       Latency           `mapstructure:"latency"`
+	BookingTokens BookingTokens     `mapstructure:"booking_tokens"`
+	Admission     Admission         `mapstructure:"admission"`
+	BookingLimits BookingLimits     `mapstructure:"booking_limits"`
+	CORS          CORS              `mapstructure:"cors"`
+	Mail          Mail              `mapstructure:"mail"`
+	SMS           SMS               `mapstructure:"sms"`
+	Notifications Notifications     `mapstructure:"notifications"`
+	Alerts        Alerts            `mapstructure:"alerts"`
+	Reporting     Reporting         `mapstructure:"reporting"`
+	Pricing       Pricing           `mapstructure:"pricing"`
+	Releases      InventoryReleases `mapstructure:"inventory_releases"`
+	Accounting    Accounting        `mapstructure:"accounting"`
+	Warehouse     Warehouse         `mapstructure:"warehouse"`
+	Events        Events            `mapstructure:"events"`
+	Webhooks      Webhooks          `mapstructure:"webhooks"`
+	Payments      Payments          `mapstructure:"payments"`
+	Attempts      BookingAttempts   `mapstructure:"booking_attempts"`
+	GRPCAuth      GRPCAuth          `mapstructure:"grpc_auth"`
+	Organizers    Organizers        `mapstructure:"organizers"`
+	Gateway       Gateway           `mapstructure:"gateway"`
+	GraphQL       GraphQL           `mapstructure:"graphql"`
+	WebSocket     WebSocket         `mapstructure:"websocket"`
+	Health        Health            `mapstructure:"health"`
+	Degradation   Degradation       `mapstructure:"degradation"`
+	LoadShedding  LoadShedding      `mapstructure:"load_shedding"`
+	ReadOnly      ReadOnly          `mapstructure:"read_only"`
+	API           API               `mapstructure:"api"`
+	Jobs          Jobs              `mapstructure:"jobs"`
+	Recording     Recording         `mapstructure:"recording"`
+	Pages         Pages             `mapstructure:"pages"`
+
+	SecurityHeaders SecurityHeaders `mapstructure:"security_headers"`
+	TestClock       TestClock       `mapstructure:"test_clock"`
+
+	// Strict rejects unknown keys and missing required keys, see LoadStrict
+	Strict bool `mapstructure:"strict"`
+
+	// path and strict are how the config was loaded, to reload it the same way
+	path   string
+	strict bool
+}
+
+// Validate checks the configuration for values that would fail at runtime
+func (c *Config) Validate() error {
+	if err := c.Database.Validate(); err != nil {
+		return err
+	}
+
+	if !c.Database.Postgres() {
+		if c.Inventory.Mode == InventoryModeRedis {
+			return fmt.Errorf("inventory.mode %q requires database.driver %q", InventoryModeRedis, DriverPostgres)
+		}
+		if c.InternalAdmin.Port > 0 {
+			return fmt.Errorf("internal_admin.port requires database.driver %q", DriverPostgres)
+		}
+		if c.Webhooks.Enabled {
+			return fmt.Errorf("webhooks.enabled requires database.driver %q", DriverPostgres)
+		}
+		if c.Payments.Enabled() {
+			return fmt.Errorf("payments.provider requires database.driver %q", DriverPostgres)
+		}
+		if c.Notifications.Enabled() {
+			return fmt.Errorf("notifications.email.enabled and notifications.sms.enabled require database.driver %q", DriverPostgres)
+		}
+		if len(c.Organizers.Accounts) > 0 {
+			return fmt.Errorf("organizers.accounts requires database.driver %q", DriverPostgres)
+		}
+		if c.Warehouse.Interval > 0 {
+			return fmt.Errorf("warehouse.interval requires database.driver %q", DriverPostgres)
+		}
+	}
+
+	if err := c.Logging.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.CORS.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.GRPCAuth.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Organizers.Validate(c.Admin.Token); err != nil {
+		return err
+	}
+
+	if err := c.Accounting.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Warehouse.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Events.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Webhooks.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Mail.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.SMS.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Notifications.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Alerts.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Payments.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Attempts.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.BookingLimits.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.API.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.InternalAdmin.Validate(c.Admin.Token); err != nil {
+		return err
+	}
+
+	if err := c.Debug.Validate(); err != nil {
+		return err
+	}
+	if c.Debug.Port > 0 && (c.Debug.Port == c.RESTPort || c.Debug.Port == c.GRPCPort || c.Debug.Port == c.InternalAdmin.Port) {
+		return fmt.Errorf("debug.port must differ from rest_port, grpc_port and internal_admin.port")
+	}
+
+	if err := c.Shutdown.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Secrets.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Recording.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.LoadShedding.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Pages.Validate(); err != nil {
+		return err
+	}
+
+	if c.Jobs.RunRetention <= 0 {
+		return fmt.Errorf("jobs.run_retention must be positive")
+	}
+
+	if c.WebSocket.Enabled && c.WebSocket.AdmitInterval <= 0 {
+		return fmt.Errorf("websocket.admit_interval must be positive")
+	}
+
+	if c.WebSocket.SigningKey != "" && !IsSecretReference(c.WebSocket.SigningKey) && len(c.WebSocket.SigningKey) < 32 {
+		return fmt.Errorf("websocket.signing_key must be at least 32 characters")
+	}
+
+	if c.WebSocket.Enabled && c.Redis.Enabled() {
+		if c.WebSocket.RejoinGrace < 0 {
+			return fmt.Errorf("websocket.rejoin_grace must not be negative")
+		}
+		if c.WebSocket.SnapshotInterval <= 0 {
+			return fmt.Errorf("websocket.snapshot_interval must be positive")
+		}
+	}
+
+	if c.ConcertCache.TTL < 0 {
+		return fmt.Errorf("concert_cache.ttl cannot be negative")
+	}
+
+	if err := c.Inventory.Validate(c.Redis); err != nil {
+		return err
+	}
+
+	if err := c.Booking.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Admission.Validate(c.BookingTokens); err != nil {
+		return err
+	}
+
+	if c.Runtime.RateLimit <= 0 {
+		return fmt.Errorf("runtime_settings.rate_limit must be positive")
+	}
+	if c.Runtime.PollInterval <= 0 {
+		return fmt.Errorf("runtime_settings.poll_interval must be positive")
+	}
+
+	if c.ReadOnly.MaxAge < 0 || c.ReadOnly.StaleWhileRevalidate < 0 {
+		return fmt.Errorf("read_only cache lifetimes cannot be negative")
+	}
+
+	if c.Health.CheckInterval <= 0 {
+		return fmt.Errorf("health.check_interval must be positive")
+	}
+
+	return nil
+}
+
+// DriverName returns the name the database/sql driver of Driver is registered with
+func (d *Database) DriverName() string {
+	switch d.Driver {
+	case DriverMySQL:
+		return "mysql"
+	case DriverSQLite:
+		return "sqlite3"
+	}
+	return "pgx"
 }
 
-// DSN returns the PostgreSQL connection string
+// DSN returns the connection string of the configured driver. MySQL times
+// are read and written in UTC; SQLite write transactions take the database
+// lock when they begin, so they queue instead of failing when they upgrade.
 func (d *Database) DSN() string {
+	switch d.Driver {
+	case DriverMySQL:
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=UTC",
+			d.Username, d.Password, d.Host, d.Port, d.Name,
+		)
+	case DriverSQLite:
+		return fmt.Sprintf("file:%s?_txlock=immediate&_foreign_keys=on&_busy_timeout=5000", d.Path)
+	}
+
 	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		d.Host, d.Port, d.Username, d.Password, d.Name, d.SSLMode,
 	)
 }
 
-// MigrationDSN returns the PostgreSQL connection string for migrations
+// MigrationDSN returns the connection string for migrations of the postgres
+// and mysql drivers. SQLite is migrated on the connection of the application,
+// since an in-memory database only exists on it.
 func (d *Database) MigrationDSN() string {
+	if d.Driver == DriverMySQL {
+		return fmt.Sprintf(
+			"mysql://%s:%s@tcp(%s:%d)/%s?multiStatements=true",
+			d.Username, d.Password, d.Host, d.Port, d.Name,
+		)
+	}
+
 	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		"pgx5://%s:%s@%s:%d/%s?sslmode=%s",
 		d.Username, d.Password, d.Host, d.Port, d.Name, d.SSLMode,
 	)
 }
 
 // Load reads the configuration from a file and environment variables
 func Load(configPath string) (*Config, error) {
+	return load(configPath, false)
+}
+
+// LoadStrict is Load in strict mode: keys of the config file and APP_
+// environment variables that don't match a config field are rejected instead
+// of ignored, as are missing required keys. The error is a *StrictError
+// listing every problem by its key path. Setting strict: true in the config
+// file has the same effect on Load.
+func LoadStrict(configPath string) (*Config, error) {
+	return load(configPath, true)
+}
+
+func load(configPath string, strict bool) (*Config, error) {
 	v := viper.New()
 
 	// Set default values
+	v.SetDefault("strict", false)
 	v.SetDefault("log_level", "info")
+	v.SetDefault("logging.format", LogFormatText)
+	v.SetDefault("logging.levels", map[string]string{})
 	v.SetDefault("rest_port", 8080)
 	v.SetDefault("grpc_port", 50051)
 	v.SetDefault("max_retries", 3)
+	v.SetDefault("database.driver", DriverPostgres)
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
 	v.SetDefault("database.username", "postgres")
 	v.SetDefault("database.password", "postgres")
 	v.SetDefault("database.name", "concert_tickets")
 	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.path", "concert_tickets.db")
+	v.SetDefault("database.replicas", []string{})
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("database.max_open_conns", 100)
+	v.SetDefault("database.max_idle_conns", 25)
+	v.SetDefault("database.conn_max_lifetime", "5m")
+	v.SetDefault("database.conn_max_idle_time", "0s")
+	v.SetDefault("database.statement_timeout", "0s")
+	v.SetDefault("database.statement_cache_capacity", 512)
+	v.SetDefault("database.timeouts.connect", "5s")
+	v.SetDefault("database.timeouts.query", "0s")
+	v.SetDefault("database.timeouts.exec", "0s")
+	v.SetDefault("database.circuit_breaker.failure_threshold", 5)
+	v.SetDefault("database.circuit_breaker.open_duration", "10s")
+	v.SetDefault("redis.addr", "")
+	v.SetDefault("redis.password", "")
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("concert_cache.ttl", "5s")
+	v.SetDefault("inventory.mode", InventoryModeDatabase)
+	v.SetDefault("inventory.flush_interval", "1s")
+	v.SetDefault("booking.strategy", BookingStrategyConditional)
+	v.SetDefault("booking.retry.base_delay", "10ms")
+	v.SetDefault("booking.retry.max_delay", "200ms")
+	v.SetDefault("booking.retry.multiplier", 2)
+	v.SetDefault("booking.retry.jitter", 0.5)
+	v.SetDefault("runtime_settings.rate_limit", 500)
+	v.SetDefault("runtime_settings.poll_interval", "30s")
+	v.SetDefault("admin.token", "")
+	v.SetDefault("internal_admin.port", 0)
+	v.SetDefault("internal_admin.host", "127.0.0.1")
+	v.SetDefault("internal_admin.token", "")
+	v.SetDefault("internal_admin.allowed_networks", []string{"127.0.0.1/32", "::1/128"})
+	v.SetDefault("debug.port", 0)
+	v.SetDefault("debug.host", "127.0.0.1")
+	v.SetDefault("debug.allowed_networks", []string{"127.0.0.1/32", "::1/128"})
+	v.SetDefault("debug.block_profile_rate", 0)
+	v.SetDefault("debug.mutex_profile_fraction", 0)
+	v.SetDefault("shutdown.drain_delay", "0s")
+	v.SetDefault("shutdown.timeout", "30s")
+	v.SetDefault("reload.watch", true)
+	v.SetDefault("secrets.cache_ttl", "5m")
+	v.SetDefault("secrets.timeout", "5s")
+	v.SetDefault("secrets.vault.addr", "")
+	v.SetDefault("secrets.vault.token", "")
+	v.SetDefault("secrets.vault.namespace", "")
+	v.SetDefault("secrets.aws.region", "")
+	v.SetDefault("secrets.aws.access_key_id", "")
+	v.SetDefault("secrets.aws.secret_access_key", "")
+	v.SetDefault("secrets.aws.session_token", "")
+	v.SetDefault("secrets.aws.endpoint", "")
+	v.SetDefault("encryption.key_id", "primary")
+	v.SetDefault("encryption.key", "")
+	v.SetDefault("latency.default_budget", "1s")
+	v.SetDefault("latency.budgets", map[string]string{"POST /api/v1/bookings": "500ms"})
+	v.SetDefault("latency.trace_sample_rate", 0.1)
+	v.SetDefault("booking_tokens.ttl", "2m")
+	v.SetDefault("booking_tokens.issue_rate", 50)
+	v.SetDefault("booking_tokens.issue_burst", 100)
+	v.SetDefault("admission.enabled", false)
+	v.SetDefault("admission.interval", "5s")
+	v.SetDefault("admission.min_rate", 5)
+	v.SetDefault("admission.increase_step", 5)
+	v.SetDefault("admission.decrease_factor", 0.5)
+	v.SetDefault("admission.latency_target", "500ms")
+	v.SetDefault("admission.pool_wait_target", "50ms")
+	v.SetDefault("admission.error_rate_target", 0.05)
+	v.SetDefault("booking_limits.max_tickets_per_booking", 10)
+	v.SetDefault("booking_limits.max_order_value", 0)
+	v.SetDefault("cors.allow_origins", []string{"*"})
+	v.SetDefault("cors.allow_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE"})
+	v.SetDefault("cors.allow_headers", []string{"Origin", "Content-Type", "Authorization", "X-Admin-Actor", "X-Invite-Token", "If-Match", "If-None-Match", "If-Modified-Since", "Idempotency-Key", "traceparent", "X-Request-ID"})
+	v.SetDefault("cors.expose_headers", []string{"Content-Length", "ETag", "Last-Modified", "X-Trace-ID", "X-Request-ID", "Deprecation", "Sunset", "Link"})
+	v.SetDefault("cors.allow_credentials", false)
+	v.SetDefault("cors.max_age", "12h")
+	v.SetDefault("security_headers.hsts_max_age", "8760h")
+	v.SetDefault("security_headers.hsts_include_subdomains", true)
+	v.SetDefault("security_headers.content_type_options", "nosniff")
+	v.SetDefault("security_headers.frame_options", "DENY")
+	v.SetDefault("security_headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	v.SetDefault("security_headers.referrer_policy", "no-referrer")
+	v.SetDefault("test_clock.enabled", false)
+	v.SetDefault("pricing.switch_interval", "1m")
+	v.SetDefault("inventory_releases.interval", "10s")
+	v.SetDefault("gateway.enabled", true)
+	v.SetDefault("graphql.enabled", true)
+	v.SetDefault("booking_attempts.enabled", false)
+	v.SetDefault("booking_attempts.buffer_size", 10000)
+	v.SetDefault("booking_attempts.flush_interval", "1s")
+	v.SetDefault("booking_attempts.retention", "720h")
+	v.SetDefault("graphql.max_depth", 8)
+	v.SetDefault("websocket.enabled", true)
+	v.SetDefault("websocket.signing_key", "")
+	v.SetDefault("websocket.admit_interval", "250ms")
+	v.SetDefault("websocket.rejoin_grace", "2m")
+	v.SetDefault("websocket.snapshot_interval", "30s")
+	v.SetDefault("health.check_interval", "5s")
+	v.SetDefault("health.check_timeout", "2s")
+	v.SetDefault("health.failure_threshold", 2)
+	v.SetDefault("degradation.enabled", true)
+	v.SetDefault("degradation.cache_entries", 10000)
+	v.SetDefault("degradation.max_staleness", "1h")
+	v.SetDefault("degradation.retry_after", "30s")
+	v.SetDefault("load_shedding.enabled", false)
+	v.SetDefault("load_shedding.max_in_flight", 500)
+	v.SetDefault("load_shedding.max_in_flight_writes", 300)
+	v.SetDefault("load_shedding.max_queue", 1000)
+	v.SetDefault("load_shedding.queue_timeout", "200ms")
+	v.SetDefault("load_shedding.retry_after", "1s")
+	v.SetDefault("api.v2_enabled", true)
+	for _, version := range []string{"v1", "v2"} {
+		v.SetDefault("api."+version+".deprecated_at", "")
+		v.SetDefault("api."+version+".sunset_at", "")
+		v.SetDefault("api."+version+".link", "")
+	}
+	v.SetDefault("jobs.instance_id", "")
+	v.SetDefault("jobs.run_retention", "168h")
+	v.SetDefault("recording.enabled", false)
+	v.SetDefault("recording.path", "traffic.jsonl")
+	v.SetDefault("recording.sample_rate", 0.01)
+	v.SetDefault("recording.salt", "")
+	v.SetDefault("recording.max_body_bytes", 65536)
+	v.SetDefault("pages.enabled", false)
+	v.SetDefault("pages.signing_key", "")
+	v.SetDefault("pages.base_url", "http://localhost:8080")
+	v.SetDefault("pages.link_ttl", "2160h")
+	v.SetDefault("read_only.enabled", false)
+	v.SetDefault("read_only.max_age", "1m")
+	v.SetDefault("read_only.stale_while_revalidate", "5m")
+	v.SetDefault("mail.provider", "smtp")
+	v.SetDefault("mail.host", "")
+	v.SetDefault("mail.port", 587)
+	v.SetDefault("mail.from", "reports@concert-tickets.local")
+	v.SetDefault("mail.sendgrid.api_key", "")
+	v.SetDefault("mail.sendgrid.base_url", "https://api.sendgrid.com")
+	v.SetDefault("mail.sendgrid.timeout", "10s")
+
+	v.SetDefault("sms.provider", SMSProviderLog)
+	v.SetDefault("sms.twilio.account_sid", "")
+	v.SetDefault("sms.twilio.auth_token", "")
+	v.SetDefault("sms.twilio.from", "")
+	v.SetDefault("sms.twilio.base_url", "https://api.twilio.com")
+	v.SetDefault("sms.twilio.timeout", "10s")
+
+	v.SetDefault("notifications.interval", "30s")
+	v.SetDefault("notifications.batch_size", 100)
+	v.SetDefault("notifications.max_attempts", 8)
+	v.SetDefault("notifications.backoff_base", "1m")
+	v.SetDefault("notifications.backoff_max", "2h")
+	v.SetDefault("notifications.retention", "720h")
+	v.SetDefault("notifications.max_event_age", "24h")
+	v.SetDefault("notifications.reminder_lead", "24h")
+	v.SetDefault("notifications.email.enabled", false)
+	v.SetDefault("notifications.email.templates.booking_confirmed", true)
+	v.SetDefault("notifications.email.templates.booking_cancelled", true)
+	v.SetDefault("notifications.email.templates.concert_reminder", true)
+	v.SetDefault("notifications.email.templates.concert_rescheduled", true)
+	v.SetDefault("notifications.sms.enabled", false)
+	v.SetDefault("notifications.sms.templates.booking_confirmed", true)
+	v.SetDefault("notifications.sms.templates.queue_turn", true)
+	v.SetDefault("alerts.provider", "")
+	v.SetDefault("alerts.webhook_url", "")
+	v.SetDefault("alerts.timeout", "10s")
+	v.SetDefault("alerts.throttle", "15m")
+	v.SetDefault("alerts.sell_outs", true)
+	v.SetDefault("alerts.drift", true)
+	v.SetDefault("alerts.error_rate.threshold", 0.2)
+	v.SetDefault("alerts.error_rate.min_attempts", 50)
+	v.SetDefault("alerts.error_rate.interval", "1m")
+	v.SetDefault("reporting.interval", "1m")
+	v.SetDefault("accounting.interval", "5m")
+	v.SetDefault("accounting.quickbooks.access_token", "")
+	v.SetDefault("accounting.quickbooks.base_url", "https://quickbooks.api.intuit.com")
+	v.SetDefault("accounting.xero.access_token", "")
+	v.SetDefault("accounting.xero.base_url", "https://api.xero.com")
+	v.SetDefault("warehouse.interval", "0s")
+	v.SetDefault("warehouse.destination", WarehouseDestinationS3)
+	v.SetDefault("warehouse.format", WarehouseFormatCSV)
+	v.SetDefault("warehouse.prefix", "")
+	v.SetDefault("warehouse.batch_size", 50000)
+	v.SetDefault("warehouse.lag", "5m")
+	v.SetDefault("warehouse.s3.bucket", "")
+	v.SetDefault("warehouse.s3.region", "")
+	v.SetDefault("warehouse.s3.access_key_id", "")
+	v.SetDefault("warehouse.s3.secret_access_key", "")
+	v.SetDefault("warehouse.s3.session_token", "")
+	v.SetDefault("warehouse.s3.endpoint", "")
+	v.SetDefault("warehouse.gcs.bucket", "")
+	v.SetDefault("warehouse.gcs.access_token", "")
+	v.SetDefault("warehouse.gcs.base_url", "https://storage.googleapis.com")
+	v.SetDefault("warehouse.dir", "")
+	v.SetDefault("events.broker", BrokerNone)
+	v.SetDefault("events.relay_interval", "5s")
+	v.SetDefault("events.batch_size", 500)
+	v.SetDefault("events.retention", "168h")
+	v.SetDefault("events.kafka.brokers", []string{})
+	v.SetDefault("events.kafka.topic_prefix", "")
+	v.SetDefault("events.kafka.write_timeout", "10s")
+	v.SetDefault("events.nats.url", "")
+	v.SetDefault("events.nats.subject_prefix", "")
+	v.SetDefault("events.nats.publish_timeout", "10s")
+	v.SetDefault("webhooks.enabled", false)
+	v.SetDefault("webhooks.interval", "5s")
+	v.SetDefault("webhooks.batch_size", 100)
+	v.SetDefault("webhooks.concurrency", 8)
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.max_attempts", 15)
+	v.SetDefault("webhooks.backoff_base", "30s")
+	v.SetDefault("webhooks.backoff_max", "6h")
+	v.SetDefault("webhooks.retention", "720h")
+	v.SetDefault("webhooks.allow_http", false)
+	v.SetDefault("webhooks.allow_private_networks", false)
+	v.SetDefault("payments.provider", PaymentProviderNone)
+	v.SetDefault("payments.hold_ttl", "15m")
+	v.SetDefault("payments.expiry_interval", "30s")
+	v.SetDefault("payments.reconciliation.enabled", false)
+	v.SetDefault("payments.reconciliation.interval", "10m")
+	v.SetDefault("payments.reconciliation.lookback", "168h")
+	v.SetDefault("payments.reconciliation.grace", "15m")
+	v.SetDefault("payments.reconciliation.batch_size", 100)
+	v.SetDefault("payments.reconciliation.repair", false)
+	v.SetDefault("payments.fake.outcome", FakeOutcomeAuthorized)
+	v.SetDefault("payments.fake.webhook_secret", "")
+	v.SetDefault("payments.stripe.secret_key", "")
+	v.SetDefault("payments.stripe.webhook_secret", "")
+	v.SetDefault("payments.stripe.base_url", "https://api.stripe.com")
+	v.SetDefault("payments.stripe.timeout", "10s")
+	v.SetDefault("payments.stripe.webhook_tolerance", "5m")
 
 	// Set config file properties
 	configName := filepath.Base(configPath)
@@ -89,5 +2429,17 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if strict || config.Strict {
+		if err := checkStrict(v); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	config.path = configPath
+	config.strict = strict
 	return &config, nil
 }
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Reloadable lists the keys a running process applies when its config is
+// reloaded. Changes to any other key take effect on the next restart.
+var Reloadable = []string{
+	"log_level",
+	"logging.levels",
+	"runtime_settings.rate_limit",
+	"booking_limits.max_tickets_per_booking",
+	"booking_limits.max_order_value",
+	"alerts.sell_outs",
+	"alerts.drift",
+	"alerts.error_rate.threshold",
+	"alerts.error_rate.min_attempts",
+}
+
+// Path returns the file the config was loaded from. It's empty for a config
+// that wasn't loaded from a file.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Reload is the outcome of a reload that was applied
+type Reload struct {
+	// Applied are the reloadable keys that changed
+	Applied []string
+	// Restart are the keys that changed but keep their value until the
+	// process restarts
+	Restart []string
+}
+
+// Reloader reloads the config of a running process from its file and the
+// environment, the way it was loaded at startup
+type Reloader struct {
+	mu        sync.Mutex
+	current   atomic.Pointer[Config]
+	listeners []func(*Config)
+}
+
+// NewReloader creates a reloader of cfg, which must have been loaded by Load
+// or LoadStrict
+func NewReloader(cfg *Config) *Reloader {
+	r := &Reloader{}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the config in effect
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers fn to be called with the new config after every reload
+// that changed a reloadable key
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload loads the config again. A config that doesn't load or validate is
+// rejected as a whole and the current one stays in effect. Otherwise the
+// reloadable keys of the new config replace those of the current one, and
+// the listeners are called if any of them changed.
+func (r *Reloader) Reload() (*Reload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.current.Load()
+	loaded, err := load(current.path, current.strict)
+	if err != nil {
+		return nil, err
+	}
+
+	next := *current
+	reload := &Reload{}
+	for _, key := range Reloadable {
+		from, to := field(&next, key), field(loaded, key)
+		if !reflect.DeepEqual(from.Interface(), to.Interface()) {
+			from.Set(to)
+			reload.Applied = append(reload.Applied, key)
+		}
+	}
+	reload.Restart = diff("", reflect.ValueOf(next), reflect.ValueOf(*loaded))
+	if len(reload.Applied) == 0 {
+		return reload, nil
+	}
+
+	// The reloadable keys are validated together with the rest of the
+	// config they now belong to
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	r.current.Store(&next)
+	for _, listener := range r.listeners {
+		listener(&next)
+	}
+	return reload, nil
+}
+
+// field returns the settable field of cfg at the dotted key
+func field(cfg *Config, key string) reflect.Value {
+	value := reflect.ValueOf(cfg).Elem()
+	for _, name := range strings.Split(key, ".") {
+		value = value.FieldByIndex(fieldIndex(value.Type(), name))
+	}
+	return value
+}
+
+func fieldIndex(t reflect.Type, name string) []int {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") == name {
+			return []int{i}
+		}
+	}
+	panic(fmt.Sprintf("config: no field %q in %s", name, t))
+}
+
+// diff returns the keys of the fields that differ between a and b, as deep
+// as their sections go
+func diff(prefix string, a, b reflect.Value) []string {
+	var keys []string
+	for i := 0; i < a.NumField(); i++ {
+		name := a.Type().Field(i).Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		from, to := a.Field(i), b.Field(i)
+		if isSection(from.Type()) {
+			keys = append(keys, diff(key+".", from, to)...)
+		} else if !reflect.DeepEqual(from.Interface(), to.Interface()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// isSection reports whether t is a section of the config rather than a value
+func isSection(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("mapstructure"); tag != "" && tag != "-" {
+			return true
+		}
+	}
+	return false
+}
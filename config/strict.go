@@ -0,0 +1,288 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// requiredKeys must be set in the config file or the environment in strict
+// mode; their defaults only suit local development
+var requiredKeys = []string{
+	"database.host",
+	"database.username",
+	"database.password",
+	"database.name",
+}
+
+// sqliteRequiredKeys replace requiredKeys for the sqlite driver, which only
+// needs the file of the database
+var sqliteRequiredKeys = []string{"database.path"}
+
+// Problem is a config key strict mode rejects
+type Problem struct {
+	// Path is the key in the config file, e.g. "grpc_auth.clients[0].token",
+	// or the environment variable
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// StrictError lists every problem strict mode found, so a deployment config
+// can be fixed in one pass
+type StrictError struct {
+	Problems []Problem
+}
+
+func (e *StrictError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "; ")
+}
+
+// schema describes the keys a config section accepts
+type schema struct {
+	// fields maps the keys of a struct to their schema
+	fields map[string]*schema
+	// open is set for maps, which accept any key
+	open bool
+	// items is the schema of the elements of a list of structs
+	items *schema
+}
+
+func schemaOf(t reflect.Type) *schema {
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &schema{fields: make(map[string]*schema)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := field.Tag.Get("mapstructure")
+			if key == "" || key == "-" {
+				continue
+			}
+			s.fields[key] = schemaOf(field.Type)
+		}
+		return s
+	case reflect.Map:
+		return &schema{open: true}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Struct {
+			return &schema{items: schemaOf(t.Elem())}
+		}
+	}
+	return &schema{}
+}
+
+// lookup returns the schema of a dotted key, or nil if it is unknown
+func (s *schema) lookup(key string) *schema {
+	current := s
+	for _, part := range strings.Split(key, ".") {
+		if current.open {
+			return current
+		}
+		next, ok := current.fields[part]
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// paths lists the dotted keys of every value, for suggestions
+func (s *schema) paths(prefix string) []string {
+	var paths []string
+	for key, field := range s.fields {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if field.fields == nil {
+			paths = append(paths, path)
+			continue
+		}
+		paths = append(paths, field.paths(path)...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// envNames maps the environment variables AutomaticEnv reads to their keys
+func (s *schema) envNames() map[string]string {
+	names := make(map[string]string)
+	for _, path := range s.paths("") {
+		names[envName(path)] = path
+	}
+	return names
+}
+
+// checkStrict reports the keys of the config file and the APP_ environment
+// variables that don't match a config field, and the required keys that are
+// missing
+func checkStrict(v *viper.Viper) error {
+	root := schemaOf(reflect.TypeOf(Config{}))
+	known := root.paths("")
+	var problems []Problem
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := root.lookup(key)
+		if field == nil {
+			problems = append(problems, unknownKey(key, known))
+			continue
+		}
+		if field.items != nil {
+			problems = append(problems, checkItems(key, v.Get(key), field.items)...)
+		}
+	}
+
+	envNames := root.envNames()
+	var environ []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(name, "APP_") {
+			environ = append(environ, name)
+		}
+	}
+	sort.Strings(environ)
+	for _, name := range environ {
+		if _, ok := envNames[name]; ok || openEnv(root, known, name) {
+			continue
+		}
+		problem := Problem{Path: name, Message: "environment variable does not match a config key"}
+		if suggestion := closest(name, keysOf(envNames)); suggestion != "" {
+			problem.Message += fmt.Sprintf(", did you mean %s?", suggestion)
+		}
+		problems = append(problems, problem)
+	}
+
+	required := requiredKeys
+	switch v.GetString("database.driver") {
+	case DriverSQLite:
+		required = sqliteRequiredKeys
+	case DriverMemory:
+		required = nil
+	}
+	for _, key := range required {
+		if !v.InConfig(key) && os.Getenv(envName(key)) == "" {
+			problems = append(problems, Problem{Path: key, Message: fmt.Sprintf("is required, set it in the config file or %s", envName(key))})
+		} else if v.GetString(key) == "" {
+			problems = append(problems, Problem{Path: key, Message: "must not be empty"})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &StrictError{Problems: problems}
+	}
+	return nil
+}
+
+// checkItems checks the keys of the elements of a list of structs
+func checkItems(key string, value interface{}, items *schema) []Problem {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var problems []Problem
+	known := items.paths("")
+	for i, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if items.lookup(strings.ToLower(name)) == nil {
+				problem := unknownKey(strings.ToLower(name), known)
+				problem.Path = fmt.Sprintf("%s[%d].%s", key, i, problem.Path)
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+func unknownKey(key string, known []string) Problem {
+	problem := Problem{Path: key, Message: "unknown key"}
+	if suggestion := closest(key, known); suggestion != "" {
+		problem.Message += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+	return problem
+}
+
+// openEnv reports whether an environment variable names an entry of a map,
+// such as APP_LATENCY_BUDGETS_...
+func openEnv(root *schema, known []string, name string) bool {
+	for _, path := range known {
+		if root.lookup(path).open && strings.HasPrefix(name, envName(path)+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(key string) string {
+	return "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func keysOf(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closest returns the known key a typo most likely meant, if any is within
+// two edits
+func closest(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Damerau-Levenshtein distance, so swapped letters as in
+// "max_retires" count as one edit
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,1107 @@
+// internal/app/app.go
+
+// Package app assembles the Concert Ticket Reservation API from its
+// configuration and runs it, so cmd/server and tests start the same service
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/api/grpc"
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/accounting"
+	"concert-ticket-api/pkg/alerts"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/lifecycle"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/mail"
+	"concert-ticket-api/pkg/notifications"
+	"concert-ticket-api/pkg/pagelink"
+	"concert-ticket-api/pkg/payments"
+	"concert-ticket-api/pkg/secrets"
+	"concert-ticket-api/pkg/warehouse"
+	"concert-ticket-api/pkg/webhook"
+	"concert-ticket-api/pkg/worker"
+	"concert-ticket-api/scripts/migrations"
+
+	"github.com/jmoiron/sqlx"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Migrate applies the pending database migrations of cfg. The memory
+// driver has no schema to migrate.
+func Migrate(cfg *config.Config) error {
+	if cfg.Database.Memory() {
+		return nil
+	}
+
+	migrator, closeMigrator, err := OpenMigrator(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer closeMigrator()
+	return migrator.Up()
+}
+
+// OpenMigrator connects to the database of cfg, resolving its secrets, and
+// returns the migrator of its schema with a function closing both
+func OpenMigrator(ctx context.Context, cfg *config.Config) (*db.Migrator, func(), error) {
+	if cfg.Database.Memory() {
+		return nil, nil, fmt.Errorf("database driver %q has no schema to migrate", config.DriverMemory)
+	}
+
+	cfg, err := secrets.ResolveConfig(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	database, err := db.Open(cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	migrator, err := db.NewMigrator(database, cfg.Database, migrations.FS)
+	if err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+	return migrator, func() {
+		migrator.Close()
+		database.Close()
+	}, nil
+}
+
+// Seed connects to the database of cfg, resolving its secrets, and seeds it
+// with the fixtures of opts. Attendee details are encrypted with the
+// configured key like those of real bookings. The memory driver keeps
+// nothing across processes to seed.
+func Seed(ctx context.Context, cfg *config.Config, opts model.SeedOptions) (*model.SeedResult, error) {
+	if cfg.Database.Memory() {
+		return nil, fmt.Errorf("database driver %q keeps nothing to seed", config.DriverMemory)
+	}
+
+	cfg, err := secrets.ResolveConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := crypto.NewCipher(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+	}
+
+	database, err := db.Open(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer database.Close()
+
+	concertRepo, bookingRepo, _, _ := newCoreRepositories(cfg.Database.Driver, database, nil, cipher)
+	return service.NewSeedService(concertRepo, bookingRepo).Seed(ctx, opts)
+}
+
+// Run starts the servers and background workers configured by cfg and
+// serves until ctx is done or one of the servers fails, then shuts them all
+// down in order. It returns the error that stopped the service, nil after a
+// clean shutdown; a setup error returns before anything is served.
+func Run(ctx context.Context, cfg *config.Config) error {
+	// Initialize logger
+	log := logger.New(logger.Options{Level: cfg.LogLevel, Format: cfg.Logging.Format, Levels: cfg.Logging.Levels})
+	log.Info("Starting Concert Ticket Reservation API")
+
+	// Settings referencing secrets are resolved on a copy, so reloads compare
+	// the file with the references rather than with the secrets
+	loaded := cfg
+	cfg, err := secrets.ResolveConfig(ctx, loaded)
+	if err != nil {
+		return err
+	}
+
+	// The background loops outlive ctx until the shutdown finished, so they
+	// don't cancel the work the shutdown waits for
+	background, stopBackground := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopBackground()
+
+	// The connections close after the shutdown steps, services before the
+	// repositories they use
+	repos, closeRepositories, err := buildRepositories(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories()
+
+	svc, closeServices, err := buildServices(ctx, background, cfg, repos, log)
+	if err != nil {
+		return err
+	}
+	defer closeServices()
+
+	go repos.health.Run(background, cfg.Health.CheckInterval)
+
+	workers, workerSteps := startWorkers(ctx, background, cfg, repos, svc, log)
+
+	// The servers run under the lifecycle manager: a server failing shuts
+	// the others down, and so does ctx being done
+	lifecycleManager := lifecycle.New(log, cfg.Shutdown.Timeout)
+	serverSteps := startServers(background, cfg, loaded, repos, svc, workers, lifecycleManager, log)
+
+	// Shut down in order. New bookings are turned away first, while the load
+	// balancers notice the failing readiness check; then the servers finish
+	// their requests in flight and the workers their runs, and the events and
+	// state they left behind are flushed before the connections close.
+	for _, step := range append(serverSteps, workerSteps...) {
+		lifecycleManager.OnShutdown(step.name, step.stop)
+	}
+
+	err = lifecycleManager.Wait(ctx)
+	log.Info("Servers stopped")
+	return err
+}
+
+// shutdownStep is a named step of the ordered shutdown of Run
+type shutdownStep struct {
+	name string
+	stop lifecycle.Step
+}
+
+// closers close what a setup step opened, last opened first, like deferred
+// calls would
+type closers []func()
+
+func (c *closers) add(close func()) {
+	*c = append(*c, close)
+}
+
+func (c closers) close() {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i]()
+	}
+}
+
+// repositories holds the connections of Run and the repositories every
+// database driver implements
+type repositories struct {
+	// database is nil for the memory driver
+	database *sqlx.DB
+	replicas *db.Replicas
+	redis    *goredis.Client
+	cipher   crypto.Cipher
+	health   *health.Registry
+
+	concerts repository.ConcertRepository
+	bookings repository.BookingRepository
+	audit    repository.AuditRepository
+	tokens   repository.BookingTokenRepository
+
+	// fullFeatured is set for postgres, whose further repositories back
+	// the features the other drivers don't serve
+	fullFeatured bool
+}
+
+// buildRepositories connects to the database, its replicas and Redis,
+// migrates the database when asked to and registers their health checks.
+// The returned function closes the connections; on an error they are
+// closed already.
+func buildRepositories(ctx context.Context, cfg *config.Config, log logger.Logger) (*repositories, func(), error) {
+	repos := &repositories{}
+	var opened closers
+	fail := func(err error) (*repositories, func(), error) {
+		opened.close()
+		return nil, nil, err
+	}
+
+	// The memory driver has no database to connect to or migrate
+	if cfg.Database.Memory() {
+		log.Warn("Database driver memory keeps every concert and booking in this process, they are lost when it exits")
+	} else {
+		// Connect to database
+		database, err := db.Open(cfg.Database)
+		if err != nil {
+			return fail(fmt.Errorf("failed to connect to database: %w", err))
+		}
+		opened.add(func() { database.Close() })
+		repos.database = database
+
+		// Run database migrations when asked to. Read-only mirrors may point
+		// at a replica and leave the schema to the primary deployment. An
+		// in-memory SQLite database can't be migrated from another process.
+		inMemory := cfg.Database.Driver == config.DriverSQLite && cfg.Database.Path == ":memory:"
+		switch {
+		case cfg.ReadOnly.Enabled:
+			log.Info("Read-only mode, skipping database migrations")
+		case !cfg.Database.AutoMigrate && !inMemory:
+			log.Info("Not migrating the database, run \"migrate up\" to apply pending migrations")
+		default:
+			log.Info("Running database migrations...")
+			if err := db.Migrate(database, cfg.Database, migrations.FS); err != nil {
+				return fail(fmt.Errorf("failed to run migrations: %w", err))
+			}
+			log.Info("Migrations completed successfully")
+		}
+	}
+
+	// Initialize field-level encryption for personal data
+	cipher, err := crypto.NewCipher(cfg.Encryption)
+	if err != nil {
+		return fail(fmt.Errorf("failed to initialize encryption: %w", err))
+	}
+	if cfg.Encryption.Key == "" {
+		log.Warn("No encryption key configured, attendee data will be stored in plaintext")
+	}
+	repos.cipher = cipher
+
+	// Check dependencies in the background so requests can react to outages
+	repos.health = health.NewRegistry(cfg.Health.CheckTimeout, cfg.Health.FailureThreshold)
+	if database := repos.database; database != nil {
+		repos.health.Register(health.Database, func(ctx context.Context) error {
+			return database.PingContext(ctx)
+		})
+
+		// Mirrors leave the schema to the primary deployment, so they aren't
+		// ready until it migrated
+		latest, err := db.LatestMigration(cfg.Database, migrations.FS)
+		if err != nil {
+			log.Warn("Not checking the schema version for readiness: %v", err)
+		} else {
+			repos.health.Register(health.Migrations, func(ctx context.Context) error {
+				return db.CheckMigrations(ctx, database, latest)
+			})
+		}
+	}
+
+	// Reads that may lag behind writes go to the healthy read replicas
+	if len(cfg.Database.Replicas) > 0 {
+		members := make([]db.Replica, 0, len(cfg.Database.Replicas))
+		for i, addr := range cfg.Database.Replicas {
+			replica, err := db.OpenPostgresReplica(cfg.Database.Replica(addr))
+			if err != nil {
+				return fail(fmt.Errorf("failed to open database replica: %w", err))
+			}
+			opened.add(func() { replica.Close() })
+
+			name := health.Replica(i + 1)
+			repos.health.RegisterOptional(name, replica.PingContext)
+			members = append(members, db.Replica{Name: name, DB: replica})
+		}
+		repos.replicas = db.NewReplicas(repos.database, members, repos.health.Healthy)
+		log.Info("Reading concerts and user bookings on %d database replicas", len(members))
+	}
+
+	// Initialize repositories. Every driver implements the core ones, the
+	// features on further repositories need postgres.
+	repos.concerts, repos.bookings, repos.audit, repos.tokens = newCoreRepositories(cfg.Database.Driver, repos.database, repos.replicas, cipher)
+	repos.fullFeatured = cfg.Database.Postgres()
+	if !repos.fullFeatured {
+		log.Warn("Database driver %s only serves concerts, bookings, booking tokens and the audit log", cfg.Database.Driver)
+	}
+
+	// Redis holds the state the replicas share
+	if cfg.Redis.Enabled() {
+		redisClient, err := db.NewRedisClient(cfg.Redis)
+		if err != nil {
+			return fail(fmt.Errorf("failed to connect to Redis: %w", err))
+		}
+		opened.add(func() { redisClient.Close() })
+		repos.redis = redisClient
+
+		repos.health.Register(health.Redis, func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+
+	return repos, opened.close, nil
+}
+
+// services holds the services Run serves. Those of features the database
+// driver or the config leaves out are nil.
+type services struct {
+	eventBus *events.Bus
+
+	auditService        service.AuditService
+	concertService      service.ConcertService
+	conflictTracker     service.ConflictTracker
+	tokenService        service.BookingTokenService
+	attemptService      service.BookingAttemptService
+	attemptRecorder     service.BookingAttemptRecorder
+	admission           service.AdmissionController
+	inventoryService    service.InventoryService
+	paymentService      service.PaymentService
+	opsAlerts           service.OpsAlerts
+	bookingService      service.BookingService
+	pricingService      service.PricingService
+	inviteService       service.InviteService
+	runtimeSettings     service.RuntimeSettingsService
+	releaseService      service.InventoryReleaseService
+	cartService         service.CartService
+	orderService        service.OrderService
+	waitingRoom         service.WaitingRoom
+	sharedWaitingRoom   service.PersistentWaitingRoom
+	preferenceService   service.NotificationPreferenceService
+	userDataService     service.UserDataService
+	calendarService     service.CalendarService
+	salesReportService  service.SalesReportService
+	analyticsService    service.AnalyticsService
+	accountingService   service.AccountingService
+	accountingAdapters  []accounting.Adapter
+	warehouseService    service.WarehouseService
+	outboxService       service.OutboxService
+	webhookService      service.WebhookService
+	notificationService service.NotificationService
+	pageService         service.TicketPageService
+}
+
+// buildServices creates the services on repos and starts polling the
+// runtime settings under background. The returned function closes the
+// events publisher; on an error it is closed already.
+func buildServices(ctx, background context.Context, cfg *config.Config, repos *repositories, log logger.Logger) (*services, func(), error) {
+	database, cipher := repos.database, repos.cipher
+	concertRepo, bookingRepo := repos.concerts, repos.bookings
+	fullFeatured := repos.fullFeatured
+
+	var opened closers
+	fail := func(err error) (*services, func(), error) {
+		opened.close()
+		return nil, nil, err
+	}
+
+	// Availability changes and bookings are fanned out in-process to the
+	// streaming clients and to the features reacting to them
+	svc := &services{eventBus: events.NewBus()}
+	eventBus := svc.eventBus
+
+	// Concert details are read through a short-lived cache in Redis, so
+	// on-sales don't query the database for every page view. Its TTL is a
+	// runtime setting, so the cache exists even while it is 0.
+	var concertCache repository.ConcertCache
+	if repos.redis != nil {
+		concertCache = redis.NewConcertCache(repos.redis, cfg.ConcertCache.TTL)
+		service.SubscribeConcertCache(eventBus, concertCache)
+	}
+
+	// Initialize services
+	bookingLimits := bookingLimitsOf(cfg)
+	svc.auditService = service.NewAuditService(repos.audit)
+	svc.concertService = service.NewConcertService(concertRepo, svc.auditService, bookingLimits, concertCache)
+	svc.conflictTracker = service.NewConflictTracker(24 * time.Hour)
+	// With Redis the issue rate of booking tokens is shared by the replicas,
+	// without it each replica issues at the rate on its own
+	var issueLimiter repository.IssueLimiter
+	if repos.redis != nil {
+		issueLimiter = redis.NewIssueLimiter(repos.redis)
+	}
+	svc.tokenService = service.NewBookingTokenService(repos.tokens, concertRepo,
+		cfg.BookingTokens.TTL, cfg.BookingTokens.IssueRate, cfg.BookingTokens.IssueBurst, issueLimiter)
+	if fullFeatured {
+		svc.attemptService = service.NewBookingAttemptService(postgres.NewBookingAttemptRepository(database),
+			cfg.Attempts.BufferSize, cfg.Attempts.Retention)
+		if cfg.Attempts.Enabled {
+			svc.attemptRecorder = svc.attemptService
+		}
+	}
+	// The admission controller paces the waiting room by how bookings and
+	// the database pool cope, from the bookings' outcomes and latencies
+	if cfg.Admission.Enabled {
+		var pool service.PoolStats
+		if database != nil {
+			pool = database.Stats
+		}
+		svc.admission = service.NewAdmissionController(svc.tokenService, model.AdmissionPolicy{
+			MinRate:         cfg.Admission.MinRate,
+			IncreaseStep:    cfg.Admission.IncreaseStep,
+			DecreaseFactor:  cfg.Admission.DecreaseFactor,
+			LatencyTarget:   cfg.Admission.LatencyTarget,
+			PoolWaitTarget:  cfg.Admission.PoolWaitTarget,
+			ErrorRateTarget: cfg.Admission.ErrorRateTarget,
+		}, cfg.BookingTokens.IssueRate, cfg.BookingTokens.IssueBurst, pool, func() bool {
+			return repos.health.Healthy(health.Database)
+		})
+	}
+	// In the redis inventory mode bookings take their tickets from counters
+	// in Redis instead of locking the concert row, and a worker writes them
+	// back to the database
+	if cfg.Inventory.Mode == config.InventoryModeRedis {
+		svc.inventoryService = service.NewInventoryService(postgres.NewInventoryRepository(database, cipher), redis.NewInventoryCounter(repos.redis))
+	}
+	// With a payment provider, bookings hold their tickets until they are
+	// paid
+	if fullFeatured {
+		provider, err := payments.New(cfg.Payments)
+		if err != nil {
+			return fail(fmt.Errorf("failed to create the payment provider: %w", err))
+		}
+		if provider != nil {
+			svc.paymentService = service.NewPaymentService(postgres.NewPaymentRepository(database, cipher), bookingRepo, provider, eventBus, model.PaymentPolicy{
+				HoldTTL:            cfg.Payments.HoldTTL,
+				ReconcileLookback:  cfg.Payments.Reconciliation.Lookback,
+				ReconcileGrace:     cfg.Payments.Reconciliation.Grace,
+				ReconcileBatchSize: cfg.Payments.Reconciliation.BatchSize,
+				Repair:             cfg.Payments.Reconciliation.Repair,
+			})
+			log.Info("Taking payments with %s", provider.Name())
+		}
+	}
+	// Sell-outs, booking error spikes and payment drift are posted to the
+	// ops channel
+	if cfg.Alerts.Enabled() {
+		svc.opsAlerts = service.NewOpsAlerts(alerts.NewAlerter(alerts.NewPoster(cfg.Alerts), cfg.Alerts.Throttle), concertRepo, opsAlertPolicyOf(cfg))
+		service.SubscribeSellOutAlerts(eventBus, svc.opsAlerts, log.Named("alerts"))
+		log.Info("Posting operational alerts to %s", cfg.Alerts.Provider)
+	}
+	bookingStrategy := service.BookingConditional
+	switch cfg.Booking.Strategy {
+	case config.BookingStrategyOptimistic:
+		bookingStrategy = service.BookingOptimistic
+	case config.BookingStrategyAdvisory:
+		bookingStrategy = service.BookingSerialized
+	}
+	svc.bookingService = service.NewBookingService(bookingRepo, concertRepo, retryPolicyOf(cfg), svc.conflictTracker, svc.tokenService, service.AttemptRecorders(svc.attemptRecorder, svc.admission, svc.opsAlerts), eventBus, bookingLimits, svc.inventoryService, bookingStrategy, svc.paymentService)
+	svc.pricingService = service.NewPricingService(concertRepo, eventBus)
+
+	svc.inviteService = service.NewInviteService(concertRepo)
+
+	// Operational settings changed at runtime on the internal admin listener.
+	// Every replica applies a change once it is announced, or polls for it.
+	if fullFeatured {
+		svc.runtimeSettings = service.NewRuntimeSettingsService(postgres.NewRuntimeSettingsRepository(database, cfg.Database.DSN()), svc.auditService, runtimeDefaultsOf(cfg))
+		if err := svc.runtimeSettings.Reload(ctx); err != nil {
+			log.Error("Failed to load runtime settings, using the configured defaults: %v", err)
+		}
+		svc.runtimeSettings.OnChange(func(settings *model.RuntimeSettings) {
+			// The controller admits up to the admission rate setting
+			if svc.admission != nil {
+				svc.admission.SetCeiling(settings.AdmissionRate, settings.AdmissionBurst)
+			} else {
+				svc.tokenService.SetIssueRate(settings.AdmissionRate, settings.AdmissionBurst)
+			}
+			if concertCache != nil {
+				concertCache.SetTTL(settings.ConcertCacheTTL())
+			}
+		})
+		go svc.runtimeSettings.Run(background, cfg.Runtime.PollInterval)
+
+		svc.releaseService = service.NewInventoryReleaseService(postgres.NewInventoryReleaseRepository(database), concertRepo, eventBus)
+		svc.cartService = service.NewCartService(postgres.NewCartRepository(database, cipher), concertRepo, retryPolicyOf(cfg), eventBus, bookingLimits)
+		svc.orderService = service.NewOrderService(postgres.NewOrderRepository(database, cipher), eventBus)
+	}
+
+	// Replicas share the waiting room through Redis, or each keeps its own
+	// queues in memory and clients need sticky sessions
+	svc.waitingRoom = service.NewWaitingRoom(svc.tokenService, eventBus)
+	if cfg.WebSocket.Enabled && repos.redis != nil && fullFeatured {
+		svc.sharedWaitingRoom = service.NewSharedWaitingRoom(svc.tokenService, eventBus, redis.NewWaitingRoomStore(repos.redis),
+			postgres.NewWaitingRoomSnapshotRepository(database, cipher), cfg.Jobs.Instance(),
+			cfg.WebSocket.AdmitInterval, cfg.WebSocket.RejoinGrace)
+		svc.waitingRoom = svc.sharedWaitingRoom
+	}
+
+	// Notification preferences are only stored in Postgres
+	var preferenceRepo repository.NotificationPreferenceRepository
+	if fullFeatured {
+		preferenceRepo = postgres.NewNotificationPreferenceRepository(database, cipher)
+	}
+
+	if preferenceRepo != nil {
+		svc.preferenceService = service.NewNotificationPreferenceService(preferenceRepo)
+	}
+
+	svc.userDataService = service.NewUserDataService(bookingRepo, preferenceRepo, svc.auditService)
+
+	// The ticket pages and the calendar feeds open from links signed with the
+	// pages key, so the feeds are only served with the pages
+	var pageSigner *pagelink.Signer
+	if cfg.Pages.Enabled {
+		pageSigner = pagelink.NewSigner([]byte(cfg.Pages.SigningKey))
+		svc.calendarService = service.NewCalendarService(bookingRepo, concertRepo, pageSigner)
+	}
+
+	if fullFeatured {
+		mailSender := mail.NewSender(cfg.Mail, log.Named("notifications"))
+		svc.salesReportService = service.NewSalesReportService(postgres.NewSalesReportRepository(database), concertRepo, bookingRepo, mailSender)
+		svc.analyticsService = service.NewAnalyticsService(postgres.NewAnalyticsRepository(database), concertRepo)
+		svc.accountingAdapters = accounting.NewAdapters(cfg.Accounting)
+		svc.accountingService = service.NewAccountingService(postgres.NewAccountingRepository(database), svc.accountingAdapters)
+		if cfg.Warehouse.Interval > 0 {
+			sink, err := warehouse.NewSink(cfg.Warehouse)
+			if err != nil {
+				return fail(fmt.Errorf("failed to create the warehouse sink: %w", err))
+			}
+			svc.warehouseService = service.NewWarehouseService(postgres.NewWarehouseRepository(database), sink,
+				cfg.Warehouse.Prefix, cfg.Warehouse.BatchSize, cfg.Warehouse.Lag)
+		}
+
+		publisher, err := events.NewPublisher(cfg.Events, log.Named("events"))
+		if err != nil {
+			return fail(fmt.Errorf("failed to create the events publisher: %w", err))
+		}
+		if publisher != nil {
+			opened.add(func() { publisher.Close() })
+			log.Info("Publishing domain events to %s", publisher.Name())
+			repos.health.Register(health.Broker, publisher.Ping)
+		}
+		svc.outboxService = service.NewOutboxService(postgres.NewOutboxRepository(database), publisher, cfg.Events.BatchSize, cfg.Events.Retention)
+
+		if cfg.Webhooks.Enabled {
+			svc.webhookService = service.NewWebhookService(postgres.NewWebhookRepository(database, cipher),
+				webhook.NewSender(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateNetworks), model.WebhookPolicy{
+					BatchSize:            cfg.Webhooks.BatchSize,
+					Concurrency:          cfg.Webhooks.Concurrency,
+					MaxAttempts:          cfg.Webhooks.MaxAttempts,
+					BackoffBase:          cfg.Webhooks.BackoffBase,
+					BackoffMax:           cfg.Webhooks.BackoffMax,
+					Retention:            cfg.Webhooks.Retention,
+					AllowHTTP:            cfg.Webhooks.AllowHTTP,
+					AllowPrivateNetworks: cfg.Webhooks.AllowPrivateNetworks,
+				})
+		}
+
+		if notificationsCfg := cfg.Notifications; notificationsCfg.Enabled() {
+			notifiers := make(map[string]notifications.Notifier)
+			templates := make(map[string]map[string]bool)
+			if email := notificationsCfg.Email; email.Enabled {
+				notifiers[model.NotificationChannelEmail] = notifications.NewEmailNotifier(mailSender)
+				templates[model.NotificationChannelEmail] = map[string]bool{
+					notifications.TemplateBookingConfirmed:   email.Templates.BookingConfirmed,
+					notifications.TemplateBookingCancelled:   email.Templates.BookingCancelled,
+					notifications.TemplateConcertReminder:    email.Templates.ConcertReminder,
+					notifications.TemplateConcertRescheduled: email.Templates.ConcertRescheduled,
+				}
+			}
+			if sms := notificationsCfg.SMS; sms.Enabled {
+				notifiers[model.NotificationChannelSMS] = notifications.NewSMSNotifier(cfg.SMS, log.Named("notifications"))
+				templates[model.NotificationChannelSMS] = map[string]bool{
+					notifications.TemplateBookingConfirmed: sms.Templates.BookingConfirmed,
+					notifications.TemplateQueueTurn:        sms.Templates.QueueTurn,
+				}
+			}
+
+			svc.notificationService = service.NewNotificationService(postgres.NewNotificationRepository(database), preferenceRepo,
+				bookingRepo, concertRepo, notifiers, model.NotificationPolicy{
+					BatchSize:    notificationsCfg.BatchSize,
+					MaxAttempts:  notificationsCfg.MaxAttempts,
+					BackoffBase:  notificationsCfg.BackoffBase,
+					BackoffMax:   notificationsCfg.BackoffMax,
+					Retention:    notificationsCfg.Retention,
+					MaxEventAge:  notificationsCfg.MaxEventAge,
+					ReminderLead: notificationsCfg.ReminderLead,
+					Templates:    templates,
+				})
+			if notificationsCfg.SMS.Enabled {
+				service.SubscribeQueueTurnNotifications(eventBus, svc.notificationService, log.Named("notifications"))
+			}
+		}
+	}
+
+	// Ticket and receipt pages for users opening email links without the app
+	if cfg.Pages.Enabled {
+		svc.pageService = service.NewTicketPageService(bookingRepo, concertRepo, pageSigner, cfg.Pages.BaseURL, cfg.Pages.LinkTTL)
+	}
+
+	return svc, opened.close, nil
+}
+
+// startWorkers registers the background jobs of svc and starts them under
+// background. It returns the registry, nil on read-only mirrors, and the
+// shutdown steps stopping the workers and flushing what their last runs
+// left behind.
+func startWorkers(ctx, background context.Context, cfg *config.Config, repos *repositories, svc *services, log logger.Logger) (*worker.Registry, []shutdownStep) {
+	// Background jobs write, so read-only mirrors leave them to the primary
+	// deployment. Operators can pause and resume them on /api/v1/admin/workers.
+	// Jobs working on the shared database are exclusive: one replica takes
+	// the advisory lock of each run and records it in job_runs. The other
+	// drivers serve a single instance, which runs every job unrecorded.
+	// Mirrors don't serve bookings either, so they leave nothing to flush.
+	if cfg.ReadOnly.Enabled {
+		return nil, nil
+	}
+
+	var workers *worker.Registry
+	var jobRunRepo repository.JobRunRepository
+	if repos.fullFeatured {
+		jobRunRepo = postgres.NewJobRunRepository(repos.database)
+		workers = worker.NewRegistry(cfg.Jobs.Instance(), postgres.NewJobLocker(repos.database), jobRunRepo)
+	} else {
+		workers = worker.NewRegistry(cfg.Jobs.Instance(), nil, nil)
+	}
+
+	// Periodically remove expired booking tokens
+	workers.RegisterExclusive("booking-token-purge", time.Hour, func(ctx context.Context) error {
+		purged, err := svc.tokenService.PurgeExpired(ctx)
+		if err != nil {
+			log.Error("Failed to purge expired booking tokens: %v", err)
+		} else if purged > 0 {
+			log.Debug("Purged %d expired booking tokens", purged)
+		}
+		return err
+	})
+
+	// Subtract the tickets booked from the Redis counters from the concerts
+	if svc.inventoryService != nil {
+		workers.RegisterExclusive("inventory-flush", cfg.Inventory.FlushInterval, func(ctx context.Context) error {
+			settled, err := svc.inventoryService.Flush(ctx)
+			if err != nil {
+				log.Error("Failed to write booked tickets back to the database: %v", err)
+			} else if settled > 0 {
+				log.Debug("Wrote back the tickets of %d bookings", settled)
+			}
+			return err
+		})
+	}
+
+	// Issue booking tokens to the users waiting for them on /ws
+	if cfg.WebSocket.Enabled {
+		workers.Register("waiting-room", cfg.WebSocket.AdmitInterval, func(ctx context.Context) error {
+			if admitted := svc.waitingRoom.Admit(ctx); admitted > 0 {
+				log.Debug("Admitted %d users from the waiting room", admitted)
+			}
+			return nil
+		})
+	}
+
+	// Snapshot the shared waiting room, so its queues survive losing Redis.
+	// Queues lost since the last run are restored before anyone is admitted.
+	if svc.sharedWaitingRoom != nil {
+		if err := svc.sharedWaitingRoom.Persist(ctx); err != nil {
+			log.Error("Failed to restore the waiting room: %v", err)
+		}
+		workers.RegisterExclusive("waiting-room-snapshot", cfg.WebSocket.SnapshotInterval, func(ctx context.Context) error {
+			err := svc.sharedWaitingRoom.Persist(ctx)
+			if err != nil {
+				log.Error("Failed to snapshot the waiting room: %v", err)
+			}
+			return err
+		})
+	}
+
+	// Generate final sales reports for concerts whose sale ended
+	if cfg.Reporting.Interval > 0 && svc.salesReportService != nil {
+		workers.RegisterExclusive("sales-reports", cfg.Reporting.Interval, func(ctx context.Context) error {
+			generated, err := svc.salesReportService.FinalizeDue(ctx)
+			if err != nil {
+				log.Error("Failed to finalize sales reports: %v", err)
+			} else if generated > 0 {
+				log.Info("Generated %d final sales reports", generated)
+			}
+			return err
+		})
+	}
+
+	// Put scheduled inventory releases on sale
+	if cfg.Releases.Interval > 0 && svc.releaseService != nil {
+		workers.RegisterExclusive("inventory-releases", cfg.Releases.Interval, func(ctx context.Context) error {
+			released, err := svc.releaseService.ReleaseDue(ctx)
+			if err != nil {
+				log.Error("Failed to release inventory: %v", err)
+			} else if released > 0 {
+				log.Info("Executed %d inventory releases", released)
+			}
+			return err
+		})
+	}
+
+	// Announce concerts switching to their door price
+	if cfg.Pricing.SwitchInterval > 0 {
+		workers.RegisterExclusive("door-pricing", cfg.Pricing.SwitchInterval, func(ctx context.Context) error {
+			switched, err := svc.pricingService.SwitchDue(ctx)
+			if err != nil {
+				log.Error("Failed to switch door prices: %v", err)
+			} else if switched > 0 {
+				log.Info("Switched %d concerts to their door price", switched)
+			}
+			return err
+		})
+	}
+
+	// Export bookings to the configured accounting systems
+	if cfg.Accounting.Interval > 0 && len(svc.accountingAdapters) > 0 {
+		workers.RegisterExclusive("accounting-export", cfg.Accounting.Interval, func(ctx context.Context) error {
+			pushed, err := svc.accountingService.SyncPending(ctx)
+			if err != nil {
+				log.Error("Failed to export bookings to accounting: %v", err)
+			} else if pushed > 0 {
+				log.Info("Exported %d journal entries to accounting", pushed)
+			}
+			return err
+		})
+	}
+
+	// Export the changed bookings and concerts to the data warehouse
+	if svc.warehouseService != nil {
+		workers.RegisterExclusive("warehouse-export", cfg.Warehouse.Interval, func(ctx context.Context) error {
+			exported, err := svc.warehouseService.Export(ctx)
+			if err != nil {
+				log.Error("Failed to export to the data warehouse: %v", err)
+			} else if exported > 0 {
+				log.Info("Exported %d rows to the data warehouse", exported)
+			}
+			return err
+		})
+	}
+
+	// Publish the domain events of the outbox to the broker and purge
+	// the old ones
+	if svc.outboxService != nil {
+		if cfg.Events.Publishes() {
+			workers.RegisterExclusive("outbox-relay", cfg.Events.RelayInterval, func(ctx context.Context) error {
+				published, err := svc.outboxService.Relay(ctx)
+				if err != nil {
+					log.Error("Failed to relay domain events: %v", err)
+				} else if published > 0 {
+					log.Debug("Published %d domain events to %s", published, cfg.Events.Broker)
+				}
+				return err
+			})
+		}
+		workers.RegisterExclusive("outbox-purge", time.Hour, func(ctx context.Context) error {
+			purged, err := svc.outboxService.PurgeExpired(ctx)
+			if err != nil {
+				log.Error("Failed to purge domain events: %v", err)
+			} else if purged > 0 {
+				log.Debug("Purged %d domain events", purged)
+			}
+			return err
+		})
+	}
+
+	// Fan the domain events out to the webhooks, deliver them and purge
+	// the old deliveries
+	if svc.webhookService != nil {
+		workers.RegisterExclusive("webhook-delivery", cfg.Webhooks.Interval, func(ctx context.Context) error {
+			enqueued, err := svc.webhookService.Dispatch(ctx)
+			if err != nil {
+				log.Error("Failed to dispatch domain events to webhooks: %v", err)
+				return err
+			} else if enqueued > 0 {
+				log.Debug("Enqueued %d webhook deliveries", enqueued)
+			}
+
+			delivered, err := svc.webhookService.DeliverDue(ctx)
+			if err != nil {
+				log.Error("Failed to deliver webhooks: %v", err)
+			} else if delivered > 0 {
+				log.Debug("Delivered %d webhooks", delivered)
+			}
+			return err
+		})
+		workers.RegisterExclusive("webhook-purge", time.Hour, func(ctx context.Context) error {
+			purged, err := svc.webhookService.PurgeDeliveries(ctx)
+			if err != nil {
+				log.Error("Failed to purge webhook deliveries: %v", err)
+			} else if purged > 0 {
+				log.Debug("Purged %d webhook deliveries", purged)
+			}
+			return err
+		})
+	}
+
+	// Turn booking and concert events into emails and texts to the
+	// attendees, send them and purge the old ones
+	if svc.notificationService != nil {
+		workers.RegisterExclusive("notification-delivery", cfg.Notifications.Interval, func(ctx context.Context) error {
+			enqueued, err := svc.notificationService.Dispatch(ctx)
+			if err != nil {
+				log.Error("Failed to turn domain events into notifications: %v", err)
+				return err
+			}
+			reminders, err := svc.notificationService.EnqueueReminders(ctx)
+			if err != nil {
+				log.Error("Failed to enqueue concert reminders: %v", err)
+				return err
+			}
+			if enqueued+reminders > 0 {
+				log.Debug("Enqueued %d notifications and %d reminders", enqueued, reminders)
+			}
+
+			sent, err := svc.notificationService.SendDue(ctx)
+			if err != nil {
+				log.Error("Failed to send notifications: %v", err)
+			} else if sent > 0 {
+				log.Debug("Sent %d notifications", sent)
+			}
+			return err
+		})
+		workers.RegisterExclusive("notification-purge", time.Hour, func(ctx context.Context) error {
+			purged, err := svc.notificationService.PurgeNotifications(ctx)
+			if err != nil {
+				log.Error("Failed to purge notifications: %v", err)
+			} else if purged > 0 {
+				log.Debug("Purged %d notifications", purged)
+			}
+			return err
+		})
+	}
+
+	// Release the tickets of bookings that weren't paid in time
+	if svc.paymentService != nil {
+		workers.RegisterExclusive("payment-expiry", cfg.Payments.ExpiryInterval, func(ctx context.Context) error {
+			released, err := svc.paymentService.ExpireHolds(ctx)
+			if err != nil {
+				log.Error("Failed to expire payment holds: %v", err)
+			}
+			if released > 0 {
+				log.Info("Released %d unpaid bookings", released)
+			}
+			return err
+		})
+	}
+
+	// Compare the bookings of payments with the provider's records
+	if svc.paymentService != nil && cfg.Payments.Reconciliation.Enabled {
+		workers.RegisterExclusive("payment-reconciliation", cfg.Payments.Reconciliation.Interval, func(ctx context.Context) error {
+			report, err := svc.paymentService.Reconcile(ctx)
+			if err != nil {
+				log.Error("Failed to reconcile payments: %v", err)
+			}
+			if report == nil {
+				return err
+			}
+			paid, confirmed := report.Mismatches[model.MismatchPaidNotConfirmed], report.Mismatches[model.MismatchConfirmedNotPaid]
+			if paid+confirmed > 0 {
+				log.Warn("Reconciled %d payments: %d paid but not confirmed, %d confirmed but not paid, %d repaired",
+					report.Checked, paid, confirmed, report.Repaired)
+			} else {
+				log.Debug("Reconciled %d payments", report.Checked)
+			}
+			if svc.opsAlerts != nil {
+				if alertErr := svc.opsAlerts.Drift(ctx, report); alertErr != nil {
+					log.Warn("Failed to alert on payment drift: %v", alertErr)
+				}
+			}
+			return err
+		})
+	}
+
+	// Each replica alerts on the error rate of its own booking attempts.
+	// The check runs at a zero threshold too, so a reload can enable it.
+	if svc.opsAlerts != nil && cfg.Alerts.ErrorRate.Interval > 0 {
+		workers.Register("error-rate-alerts", cfg.Alerts.ErrorRate.Interval, func(ctx context.Context) error {
+			if err := svc.opsAlerts.CheckErrorRate(ctx); err != nil {
+				log.Warn("Failed to alert on the booking error rate: %v", err)
+			}
+			return nil
+		})
+	}
+
+	// Each replica paces its own waiting room
+	if svc.admission != nil {
+		workers.Register("admission-control", cfg.Admission.Interval, func(ctx context.Context) error {
+			status := svc.admission.Adjust()
+			if status.Decision == model.AdmissionDecisionDecrease {
+				log.Warn("Admission rate lowered to %.1f/s, missed targets: %v", status.Rate, status.Reasons)
+			}
+			return nil
+		})
+	}
+
+	// Write recorded booking attempts and purge the expired ones
+	if svc.attemptRecorder != nil {
+		lastPurge := clock.Now()
+		workers.Register("booking-attempts", cfg.Attempts.FlushInterval, func(ctx context.Context) error {
+			if _, err := svc.attemptService.Flush(ctx); err != nil {
+				log.Error("Failed to write booking attempts: %v", err)
+				return err
+			}
+			if clock.Now().Sub(lastPurge) >= time.Hour {
+				lastPurge = clock.Now()
+				if purged, err := svc.attemptService.PurgeExpired(ctx); err != nil {
+					log.Error("Failed to purge booking attempts: %v", err)
+					return err
+				} else if purged > 0 {
+					log.Debug("Purged %d expired booking attempts", purged)
+				}
+			}
+			return nil
+		})
+	}
+
+	// Purge the job runs older than the retention
+	if jobRunRepo != nil {
+		workers.RegisterExclusive("job-run-purge", time.Hour, func(ctx context.Context) error {
+			purged, err := jobRunRepo.PurgeBefore(ctx, clock.Now().Add(-cfg.Jobs.RunRetention))
+			if err != nil {
+				log.Error("Failed to purge job runs: %v", err)
+			} else if purged > 0 {
+				log.Debug("Purged %d job runs", purged)
+			}
+			return err
+		})
+	}
+
+	log.Info("Starting background workers as instance %s", workers.InstanceID())
+	workers.Start(background)
+
+	steps := []shutdownStep{{"workers", workers.Stop}}
+
+	// Publish the events of the last bookings instead of leaving them to
+	// the next replica's relay
+	if svc.outboxService != nil && cfg.Events.Publishes() {
+		steps = append(steps, shutdownStep{"outbox", func(ctx context.Context) error {
+			published, err := svc.outboxService.Relay(ctx)
+			if published > 0 {
+				log.Info("Published %d domain events on shutdown", published)
+			}
+			return err
+		}})
+	}
+
+	// Keep the places of the users this replica disconnected
+	if svc.sharedWaitingRoom != nil {
+		steps = append(steps, shutdownStep{"waiting room", svc.sharedWaitingRoom.Persist})
+	}
+
+	// Keep the attempts recorded since the last flush
+	if svc.attemptRecorder != nil {
+		steps = append(steps, shutdownStep{"booking attempts", func(ctx context.Context) error {
+			_, err := svc.attemptService.Flush(ctx)
+			return err
+		}})
+	}
+
+	return workers, steps
+}
+
+// startServers starts the REST, gRPC, internal admin and debug servers under
+// manager and reloads the config file loaded was read from. It returns the
+// shutdown steps draining the servers and then closing them.
+func startServers(background context.Context, cfg, loaded *config.Config, repos *repositories, svc *services, workers *worker.Registry, manager *lifecycle.Manager, log logger.Logger) []shutdownStep {
+	// Start REST API server
+	restServer := rest.NewServer(svc.concertService, svc.bookingService, svc.userDataService, svc.calendarService, svc.conflictTracker, svc.tokenService, svc.salesReportService, svc.accountingService, svc.attemptService, svc.analyticsService, svc.releaseService, svc.inviteService, svc.cartService, svc.orderService, svc.webhookService, svc.preferenceService, svc.paymentService, svc.pageService, svc.waitingRoom, svc.admission, svc.eventBus, repos.health, workers, svc.runtimeSettings, log.Named("http"), cfg)
+	log.Info("Starting REST API server on port %d", cfg.RESTPort)
+	manager.Go("REST server", restServer.Start)
+
+	// The dangerous admin operations are on while the internal admin API is
+	// configured; mirrors never serve them
+	var adminOpsService service.AdminOpsService
+	if cfg.InternalAdmin.Port > 0 && !cfg.ReadOnly.Enabled {
+		adminOpsService = service.NewAdminOpsService(postgres.NewAdminRepository(repos.database, repos.cipher), svc.auditService, svc.eventBus)
+	}
+
+	// Start gRPC server. It serves the admin operations to operator tools
+	// holding the internal admin credentials.
+	grpcServer := grpc.NewServer(svc.concertService, svc.bookingService, svc.tokenService, svc.orderService, svc.eventBus,
+		grpc.NewAuthorizer(cfg.GRPCAuth, cfg.Admin.Token), cfg.ReadOnly.Enabled, log.Named("grpc"), cfg.GRPCPort)
+	if adminOpsService != nil {
+		grpcServer.RegisterAdmin(adminOpsService, cfg.InternalAdmin)
+	}
+	go grpcServer.TrackHealth(background, repos.health, cfg.Health.CheckInterval)
+	log.Info("Starting gRPC server on port %d", cfg.GRPCPort)
+	manager.Go("gRPC server", grpcServer.Start)
+
+	// Apply the reloadable settings of the config file on SIGHUP or when it
+	// changes, without restarting mid-sale
+	if loaded.Path() != "" {
+		reloader := config.NewReloader(loaded)
+		reloader.OnReload(func(cfg *config.Config) {
+			log.SetLevels(cfg.LogLevel, cfg.Logging.Levels)
+			if svc.runtimeSettings != nil {
+				svc.runtimeSettings.SetDefaults(runtimeDefaultsOf(cfg))
+			} else {
+				restServer.SetRateLimit(cfg.Runtime.RateLimit)
+			}
+			bookingLimits := bookingLimitsOf(cfg)
+			svc.concertService.SetBookingLimits(bookingLimits)
+			svc.bookingService.SetBookingLimits(bookingLimits)
+			if svc.cartService != nil {
+				svc.cartService.SetBookingLimits(bookingLimits)
+			}
+			if svc.opsAlerts != nil {
+				svc.opsAlerts.SetPolicy(opsAlertPolicyOf(cfg))
+			}
+		})
+		go watchConfig(background, reloader, cfg.Reload.Watch, log.Named("config"))
+	}
+
+	// Serve the dangerous admin operations on the internal listener. It is
+	// off unless a port is configured.
+	var adminServer *rest.AdminServer
+	if adminOpsService != nil {
+		adminServer = rest.NewAdminServer(adminOpsService, svc.runtimeSettings, log.Named("admin"), cfg.InternalAdmin)
+		manager.Go("internal admin server", adminServer.Start)
+	}
+
+	// Serve profiles and runtime stats on the debug listener only, off unless
+	// a port is configured
+	var debugServer *rest.DebugServer
+	if cfg.Debug.Port > 0 {
+		debugServer = rest.NewDebugServer(log.Named("debug"), cfg.Debug)
+		manager.Go("debug server", debugServer.Start)
+	}
+
+	// New bookings are turned away while the load balancers notice the
+	// failing readiness check, then the listeners close
+	steps := []shutdownStep{
+		{"drain", func(ctx context.Context) error {
+			repos.health.Drain()
+			grpcServer.Drain()
+			if cfg.Shutdown.DrainDelay > 0 {
+				log.Info("Draining for %s before closing the listeners", cfg.Shutdown.DrainDelay)
+				select {
+				case <-time.After(cfg.Shutdown.DrainDelay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		}},
+		{"REST server", restServer.Shutdown},
+		{"gRPC server", grpcServer.Shutdown},
+	}
+	if adminServer != nil {
+		steps = append(steps, shutdownStep{"internal admin server", adminServer.Shutdown})
+	}
+	if debugServer != nil {
+		steps = append(steps, shutdownStep{"debug server", debugServer.Shutdown})
+	}
+	return steps
+}
+
+// bookingLimitsOf returns the limits of bookings for concerts without their own
+func bookingLimitsOf(cfg *config.Config) model.BookingLimits {
+	return model.BookingLimits{
+		MaxTickets:    cfg.BookingLimits.MaxTicketsPerBooking,
+		MaxOrderValue: cfg.BookingLimits.MaxOrderValue,
+	}
+}
+
+// retryPolicyOf returns how bookings and checkouts retry version conflicts
+func retryPolicyOf(cfg *config.Config) service.RetryPolicy {
+	return service.RetryPolicy{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  cfg.Booking.Retry.BaseDelay,
+		MaxDelay:   cfg.Booking.Retry.MaxDelay,
+		Multiplier: cfg.Booking.Retry.Multiplier,
+		Jitter:     cfg.Booking.Retry.Jitter,
+	}
+}
+
+// opsAlertPolicyOf returns the operational alerts this replica posts
+func opsAlertPolicyOf(cfg *config.Config) model.OpsAlertPolicy {
+	return model.OpsAlertPolicy{
+		SellOuts:             cfg.Alerts.SellOuts,
+		Drift:                cfg.Alerts.Drift,
+		ErrorRateThreshold:   cfg.Alerts.ErrorRate.Threshold,
+		ErrorRateMinAttempts: cfg.Alerts.ErrorRate.MinAttempts,
+		Instance:             cfg.Jobs.Instance(),
+	}
+}
+
+// runtimeDefaultsOf returns the runtime settings in effect until an operator
+// changes them
+func runtimeDefaultsOf(cfg *config.Config) model.RuntimeSettings {
+	return model.RuntimeSettings{
+		RateLimit:         cfg.Runtime.RateLimit,
+		AdmissionRate:     cfg.BookingTokens.IssueRate,
+		AdmissionBurst:    cfg.BookingTokens.IssueBurst,
+		ConcertCacheTTLMs: cfg.ConcertCache.TTL.Milliseconds(),
+		CORSAllowOrigins:  cfg.CORS.AllowOrigins,
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var configReloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "config_reloads_total",
+	Help: "Config reloads by outcome: applied, unchanged or rejected.",
+}, []string{"outcome"})
+
+// reloadDebounce is how long the config file has to stay unchanged before it
+// is reloaded, as editors and Kubernetes write it in several steps
+const reloadDebounce = 100 * time.Millisecond
+
+// watchConfig reloads the config on SIGHUP and, with watch, when its file
+// changes, until ctx is done
+func watchConfig(ctx context.Context, reloader *config.Reloader, watch bool, log logger.Logger) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var changes <-chan fsnotify.Event
+	if watch {
+		// The directory is watched rather than the file, which is replaced
+		// instead of written to by most editors and by Kubernetes
+		path := reloader.Current().Path()
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			err = watcher.Add(filepath.Dir(path))
+		}
+		if err != nil {
+			log.Warn("Failed to watch %s for changes, reload the config with SIGHUP: %v", path, err)
+		} else {
+			defer watcher.Close()
+			changes = watcher.Events
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			reload(reloader, log)
+		case event := <-changes:
+			if isConfigChange(event, reloader.Current().Path()) {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			reload(reloader, log)
+		}
+	}
+}
+
+// isConfigChange reports whether event may have changed the file at path.
+// Kubernetes swaps the ..data link of a mounted ConfigMap.
+func isConfigChange(event fsnotify.Event, path string) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	return name == filepath.Base(path) || strings.HasPrefix(name, "..data")
+}
+
+func reload(reloader *config.Reloader, log logger.Logger) {
+	reloaded, err := reloader.Reload()
+	if err != nil {
+		configReloadsTotal.WithLabelValues("rejected").Inc()
+		log.Error("Rejected the reloaded config, keeping the current one: %v", err)
+		return
+	}
+
+	if len(reloaded.Restart) > 0 {
+		log.Warn("Changes to %s take effect on restart", strings.Join(reloaded.Restart, ", "))
+	}
+	if len(reloaded.Applied) == 0 {
+		configReloadsTotal.WithLabelValues("unchanged").Inc()
+		return
+	}
+	configReloadsTotal.WithLabelValues("applied").Inc()
+	log.Info("Reloaded the config, applied %s", strings.Join(reloaded.Applied, ", "))
+}
@@ -0,0 +1,41 @@
+// internal/app/repositories.go
+package app
+
+import (
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/memory"
+	"concert-ticket-api/internal/repository/mysql"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/repository/sqlite"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// newCoreRepositories creates the repositories every database driver
+// implements on the database of the driver. Those of the memory driver
+// share a store instead and leave database nil. The postgres ones read on
+// replicas, if set.
+func newCoreRepositories(driver string, database *sqlx.DB, replicas *db.Replicas, cipher crypto.Cipher) (
+	repository.ConcertRepository,
+	repository.BookingRepository,
+	repository.AuditRepository,
+	repository.BookingTokenRepository,
+) {
+	switch driver {
+	case config.DriverMySQL:
+		return mysql.NewConcertRepository(database), mysql.NewBookingRepository(database, cipher),
+			mysql.NewAuditRepository(database), mysql.NewBookingTokenRepository(database)
+	case config.DriverSQLite:
+		return sqlite.NewConcertRepository(database), sqlite.NewBookingRepository(database, cipher),
+			sqlite.NewAuditRepository(database), sqlite.NewBookingTokenRepository(database)
+	case config.DriverMemory:
+		store := memory.NewStore()
+		return memory.NewConcertRepository(store), memory.NewBookingRepository(store),
+			memory.NewAuditRepository(store), memory.NewBookingTokenRepository(store)
+	}
+	return postgres.NewConcertRepositoryWithReplicas(database, replicas), postgres.NewBookingRepositoryWithReplicas(database, replicas, cipher),
+		postgres.NewAuditRepository(database), postgres.NewBookingTokenRepository(database)
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+)
+
+// Types of journal entries exported to accounting systems
+const (
+	// AccountingEntrySale records the revenue of a booking
+	AccountingEntrySale = "sale"
+	// AccountingEntryRefund reverses the revenue of a cancelled booking
+	AccountingEntryRefund = "refund"
+)
+
+// AccountingRecord is a booking sale or refund that still has to be pushed
+// to an accounting system
+type AccountingRecord struct {
+	BookingID   int64         `json:"-" db:"booking_id"`
+	Reference   string        `json:"booking_reference" db:"reference"`
+	EntryType   string        `json:"entry_type" db:"entry_type"`
+	ConcertID   int64         `json:"concert_id" db:"concert_id"`
+	ConcertName string        `json:"concert_name" db:"concert_name"`
+	TicketCount int           `json:"ticket_count" db:"ticket_count"`
+	Price       float64       `json:"price" db:"price"`
+	Currency    string        `json:"currency" db:"currency"`
+	Status      BookingStatus `json:"status" db:"status"`
+	BookingTime time.Time     `json:"booking_time" db:"booking_time"`
+	// UpdatedAt is when the booking last changed, which dates the refund of a cancelled booking
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	LastError string    `json:"last_error,omitempty" db:"last_error"`
+}
+
+// AccountingReconciliation compares the bookings synced to an accounting
+// system with those still pending
+type AccountingReconciliation struct {
+	Provider       string     `json:"provider"`
+	SyncedSales    int        `json:"synced_sales" db:"synced_sales"`
+	SyncedRefunds  int        `json:"synced_refunds" db:"synced_refunds"`
+	PendingSales   int        `json:"pending_sales" db:"pending_sales"`
+	PendingRefunds int        `json:"pending_refunds" db:"pending_refunds"`
+	Failing        int        `json:"failing" db:"failing"`
+	LastSyncedAt   *time.Time `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	// Pending lists the oldest pending records with their last error
+	Pending []*AccountingRecord `json:"pending"`
+}
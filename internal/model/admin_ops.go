@@ -0,0 +1,48 @@
+package model
+
+// ForceCancelRequest is the body of a forced booking cancellation
+type ForceCancelRequest struct {
+	// Reason is written to the audit log
+	Reason string `json:"reason"`
+}
+
+// InventoryAdjustment is the body of a manual inventory adjustment
+type InventoryAdjustment struct {
+	// Delta is added to the total and available tickets; negative values
+	// withdraw unsold tickets
+	Delta int `json:"delta"`
+	// Reason is written to the audit log
+	Reason string `json:"reason"`
+}
+
+// InventoryCount is what a concert's tickets add up to: the available tickets
+// it records next to the tickets its bookings took and its pending releases
+// hold back
+type InventoryCount struct {
+	ConcertID        int64 `json:"concert_id" db:"concert_id"`
+	TotalTickets     int   `json:"total_tickets" db:"total_tickets"`
+	AvailableTickets int   `json:"available_tickets" db:"available_tickets"`
+	// BookedTickets are the tickets of the bookings that aren't cancelled,
+	// archived ones included, and the concert took
+	BookedTickets int `json:"booked_tickets" db:"booked_tickets"`
+	// PendingTickets are the tickets of bookings made in the redis inventory
+	// mode that the concert doesn't count yet
+	PendingTickets int `json:"pending_tickets" db:"pending_tickets"`
+	// HeldTickets are held back by inventory releases still pending
+	HeldTickets int `json:"held_tickets" db:"held_tickets"`
+}
+
+// ExpectedAvailable returns the available tickets the bookings and releases
+// leave
+func (c *InventoryCount) ExpectedAvailable() int {
+	return c.TotalTickets - c.BookedTickets - c.HeldTickets
+}
+
+// InventoryCheck is the outcome of checking the tickets of concerts against
+// their bookings
+type InventoryCheck struct {
+	// Checked is the number of concerts checked
+	Checked int `json:"checked"`
+	// Discrepancies are the concerts whose available tickets don't add up
+	Discrepancies []*InventoryCount `json:"discrepancies"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Admission decisions, made once per controller interval
+const (
+	// AdmissionDecisionIncrease raised the rate, the signals being within their targets
+	AdmissionDecisionIncrease = "increase"
+	// AdmissionDecisionDecrease lowered the rate, a signal having missed its target
+	AdmissionDecisionDecrease = "decrease"
+	// AdmissionDecisionHold kept the rate, which is at the ceiling
+	AdmissionDecisionHold = "hold"
+	// AdmissionDecisionOverride kept the rate an operator set
+	AdmissionDecisionOverride = "override"
+)
+
+// AdmissionStatus is the waiting room's admission rate and the decision
+// that set it
+type AdmissionStatus struct {
+	// Rate and Burst are the booking token issue rate per concert in use
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// MinRate and Ceiling bound the rates the controller sets
+	MinRate  float64 `json:"min_rate"`
+	Ceiling  float64 `json:"ceiling"`
+	Decision string  `json:"decision"`
+	// Reasons are the signals that missed their targets, for decreases
+	Reasons    []string           `json:"reasons,omitempty"`
+	Signals    AdmissionSignals   `json:"signals"`
+	Override   *AdmissionOverride `json:"override,omitempty"`
+	AdjustedAt time.Time          `json:"adjusted_at"`
+}
+
+// AdmissionSignals are what the bookings and the database pool showed in
+// the last controller interval
+type AdmissionSignals struct {
+	Attempts        int     `json:"attempts"`
+	AvgLatencyMS    float64 `json:"avg_latency_ms"`
+	ErrorRate       float64 `json:"error_rate"`
+	AvgPoolWaitMS   float64 `json:"avg_pool_wait_ms"`
+	DatabaseHealthy bool    `json:"database_healthy"`
+}
+
+// AdmissionOverride is a rate set by an operator, which the controller
+// keeps until it is cleared
+type AdmissionOverride struct {
+	Rate  float64   `json:"rate"`
+	Burst int       `json:"burst"`
+	SetBy string    `json:"set_by,omitempty"`
+	SetAt time.Time `json:"set_at"`
+}
+
+// AdmissionPolicy is how the controller moves the admission rate
+type AdmissionPolicy struct {
+	MinRate float64
+	// IncreaseStep is added to the rate after an interval within the
+	// targets, DecreaseFactor multiplies it after one that missed them
+	IncreaseStep   float64
+	DecreaseFactor float64
+	// The targets the signals of an interval are held to
+	LatencyTarget   time.Duration
+	PoolWaitTarget  time.Duration
+	ErrorRateTarget float64
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ArchiveResult summarizes a run of the bookings archival
+type ArchiveResult struct {
+	// Cutoff is the date before which concerts had their bookings archived
+	Cutoff time.Time `json:"cutoff"`
+	// Archived is the number of bookings moved into the archive
+	Archived int `json:"archived"`
+	// Batches is the number of transactions the bookings were moved in
+	Batches int `json:"batches"`
+}
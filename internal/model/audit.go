@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded for sensitive operations
+const (
+	AuditActionUserDataExported      = "user_data.exported"
+	AuditActionUserDataErased        = "user_data.erased"
+	AuditActionBookingForceCancelled = "booking.force_cancelled"
+	AuditActionInventoryAdjusted     = "concert.inventory_adjusted"
+)
+
+// AuditLog represents a record of a sensitive operation performed on a resource
+type AuditLog struct {
+	ID           int64           `json:"id" db:"id"`
+	Actor        string          `json:"actor" db:"actor"`
+	Action       string          `json:"action" db:"action"`
+	ResourceType string          `json:"resource_type" db:"resource_type"`
+	ResourceID   string          `json:"resource_id" db:"resource_id"`
+	Details      json.RawMessage `json:"details" db:"details"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter selects audit log entries; empty fields match every entry
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// EventConcertAvailability is the topic of availability changes, keyed by
+// concert ID with a *ConcertAvailability payload
+const EventConcertAvailability = "concert.availability"
+
+// ConcertAvailability is the ticket availability of a concert at a point in time
+type ConcertAvailability struct {
+	ConcertID        int64     `json:"concert_id"`
+	AvailableTickets int       `json:"available_tickets"`
+	TotalTickets     int       `json:"total_tickets"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -15,14 +15,56 @@ const (
 
 // Booking represents a ticket booking for a concert
 type Booking struct {
-	ID          int64         `json:"id" db:"id"`
+	// ID is internal; clients refer to bookings by their opaque Reference
+	ID          int64         `json:"-" db:"id"`
+	Reference   string        `json:"reference" db:"reference"`
 	ConcertID   int64         `json:"concert_id" db:"concert_id"`
 	UserID      string        `json:"user_id" db:"user_id"`
 	TicketCount int           `json:"ticket_count" db:"ticket_count"`
 	BookingTime time.Time     `json:"booking_time" db:"booking_time"`
 	Status      BookingStatus `json:"status" db:"status"`
-	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+	// Attendee details are personal data and are encrypted at rest
+	AttendeeName  string `json:"attendee_name,omitempty" db:"attendee_name"`
+	AttendeeEmail string `json:"attendee_email,omitempty" db:"attendee_email"`
+	// UnitPrice is the ticket price at the time of booking
+	UnitPrice float64   `json:"unit_price" db:"unit_price"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// IdempotencyKey is the key of the request that made the booking, if any.
+	// It is only written; retries are matched by GetByIdempotencyKey.
+	IdempotencyKey string `json:"-" db:"-"`
+	// Concert is only set on listings asked to expand the concert
+	Concert *ConcertSummary `json:"concert,omitempty" db:"concert"`
+	// Payment is only set on pending bookings returned to the client that
+	// booked them, to complete the payment with
+	Payment *Payment `json:"payment,omitempty" db:"-"`
+}
+
+// ExpandConcert embeds the concert of each booking in booking listings
+const ExpandConcert = "concert"
+
+// BookingExpansions are the resources booking listings can embed
+var BookingExpansions = []string{ExpandConcert}
+
+// ConcertSummary is the part of a concert embedded in a booking, enough to
+// show the booking without fetching the concert
+type ConcertSummary struct {
+	ID          int64     `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Artist      string    `json:"artist" db:"artist"`
+	Venue       string    `json:"venue" db:"venue"`
+	ConcertDate time.Time `json:"concert_date" db:"concert_date"`
+}
+
+// SummarizeConcert returns the summary of a concert embedded in its bookings
+func SummarizeConcert(concert *Concert) *ConcertSummary {
+	return &ConcertSummary{
+		ID:          concert.ID,
+		Name:        concert.Name,
+		Artist:      concert.Artist,
+		Venue:       concert.Venue,
+		ConcertDate: concert.ConcertDate,
+	}
 }
 
 // BookingRequest represents a request to book tickets
@@ -30,4 +72,22 @@ type BookingRequest struct {
 	ConcertID   int64  `json:"concert_id" validate:"required"`
 	UserID      string `json:"user_id" validate:"required"`
 	TicketCount int    `json:"ticket_count" validate:"required,min=1"`
+	// AttendeeName and AttendeeEmail are optional contact details for the ticket holder
+	AttendeeName  string `json:"attendee_name"`
+	AttendeeEmail string `json:"attendee_email"`
+	// BookingToken is required for concerts that require booking tokens
+	BookingToken string `json:"booking_token"`
+	// IdempotencyKey comes from the Idempotency-Key header. A request with
+	// the key of an earlier booking of the user returns that booking.
+	IdempotencyKey string `json:"-"`
+}
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted
+const MaxIdempotencyKeyLength = 255
+
+// BatchBookingResult is the outcome of one request of a batch booking: the
+// booking made, or the error that kept it from being made
+type BatchBookingResult struct {
+	Booking *Booking
+	Err     error
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+)
+
+// Booking attempt outcomes. Every reason except BookingAttemptBooked turned the fan away.
+const (
+	BookingAttemptBooked        = "booked"
+	BookingAttemptSoldOut       = "sold_out"
+	BookingAttemptClosed        = "booking_closed"
+	BookingAttemptConflict      = "conflict"
+	BookingAttemptTokenRequired = "token_required"
+	BookingAttemptInvalidToken  = "invalid_token"
+	BookingAttemptInviteUsed    = "invite_used"
+	BookingAttemptInvalidInput  = "invalid_input"
+	BookingAttemptNotFound      = "not_found"
+	BookingAttemptError         = "error"
+)
+
+// BookingAttempt is the outcome of one call to book tickets
+type BookingAttempt struct {
+	ConcertID   int64     `json:"concert_id" db:"concert_id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Reason      string    `json:"reason" db:"reason"`
+	LatencyMS   int64     `json:"latency_ms" db:"latency_ms"`
+	Retries     int       `json:"retries" db:"retries"`
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// BookingAttemptSummary aggregates the booking attempts in a time window
+type BookingAttemptSummary struct {
+	ConcertID   int64     `json:"concert_id,omitempty"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Attempts    int       `json:"attempts"`
+	// TurnedAway counts the failed attempts
+	TurnedAway int `json:"turned_away"`
+	// TurnedAwayUsers counts the users whose attempts all failed
+	TurnedAwayUsers int                     `json:"turned_away_users"`
+	Reasons         []*BookingAttemptReason `json:"reasons"`
+}
+
+// BookingAttemptReason aggregates the booking attempts with the same outcome
+type BookingAttemptReason struct {
+	Reason       string  `json:"reason" db:"reason"`
+	Attempts     int     `json:"attempts" db:"attempts"`
+	Users        int     `json:"users" db:"users"`
+	AvgLatencyMS float64 `json:"avg_latency_ms" db:"avg_latency_ms"`
+	AvgRetries   float64 `json:"avg_retries" db:"avg_retries"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// DefaultMaxTicketsPerBooking is the ticket limit of a booking when none is
+// configured
+const DefaultMaxTicketsPerBooking = 10
+
+// BookingLimits limit a single booking
+type BookingLimits struct {
+	// MaxTickets is the most tickets booked at once
+	MaxTickets int
+	// MaxOrderValue is the highest total price of a booking. Zero means no limit.
+	MaxOrderValue float64
+}
+
+// BookingLimits returns the limits of bookings for the concert: its own,
+// where set, and the defaults otherwise
+func (c *Concert) BookingLimits(defaults BookingLimits) BookingLimits {
+	limits := defaults
+	if c.MaxTicketsPerBooking != nil {
+		limits.MaxTickets = *c.MaxTicketsPerBooking
+	}
+	if c.MaxOrderValue != nil {
+		limits.MaxOrderValue = *c.MaxOrderValue
+	}
+	return limits
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// BookingToken is a short-lived, single-use permission for a user to book
+// tickets for a concert that requires one
+type BookingToken struct {
+	Token     string    `json:"token"`
+	ConcertID int64     `json:"concert_id"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BookingTokenRequest represents a request for a booking token
+type BookingTokenRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// BookedConcert is a confirmed booking with its concert, an event of the
+// calendar feed of the booking's user
+type BookedConcert struct {
+	Booking *Booking
+	Concert *Concert
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// Checkout modes, which decide what happens when some items of a cart can't
+// be booked
+const (
+	// CheckoutAllOrNothing books every item of the cart or none
+	CheckoutAllOrNothing = "all_or_nothing"
+	// CheckoutBestEffort books the items that can be booked and leaves the
+	// others in the cart
+	CheckoutBestEffort = "best_effort"
+)
+
+// CartItem is a number of tickets of a concert a user means to book at checkout
+type CartItem struct {
+	UserID      string    `json:"-" db:"user_id"`
+	ConcertID   int64     `json:"concert_id" db:"concert_id"`
+	TicketCount int       `json:"ticket_count" db:"ticket_count"`
+	AddedAt     time.Time `json:"added_at" db:"added_at"`
+	// UnitPrice and Currency are the concert's current price, which may
+	// change until checkout
+	UnitPrice float64 `json:"unit_price" db:"-"`
+	Currency  string  `json:"currency" db:"-"`
+}
+
+// Cart holds the items of a user
+type Cart struct {
+	UserID string      `json:"user_id"`
+	Items  []*CartItem `json:"items"`
+}
+
+// CartItemRequest represents a request to put tickets of a concert in a cart
+type CartItemRequest struct {
+	UserID      string `json:"user_id" binding:"required"`
+	TicketCount int    `json:"ticket_count" binding:"required,min=1"`
+}
+
+// CheckoutRequest represents a request to book the items of a cart
+type CheckoutRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	// Mode is CheckoutAllOrNothing, the default, or CheckoutBestEffort
+	Mode string `json:"mode"`
+	// AttendeeName and AttendeeEmail are optional contact details for the
+	// holder of every ticket of the order
+	AttendeeName  string `json:"attendee_name"`
+	AttendeeEmail string `json:"attendee_email"`
+}
+
+// Order groups the bookings made by one checkout, which are paid as one
+type Order struct {
+	// ID is internal; clients refer to orders by their opaque Reference
+	ID        int64  `json:"-" db:"id"`
+	Reference string `json:"reference" db:"reference"`
+	UserID    string `json:"user_id" db:"user_id"`
+	Mode      string `json:"mode" db:"mode"`
+	Currency  string `json:"currency" db:"currency"`
+	// Status is BookingStatusCancelled once the order was cancelled as a
+	// whole; its bookings may also be cancelled one by one
+	Status    BookingStatus `json:"status" db:"status"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+	// Total is the amount paid for the order's bookings, the one payment of
+	// the order
+	Total    float64    `json:"total" db:"-"`
+	Bookings []*Booking `json:"bookings" db:"-"`
+	// Failed lists the items a best-effort checkout couldn't book, which
+	// stay in the cart
+	Failed []*CheckoutFailure `json:"failed,omitempty" db:"-"`
+}
+
+// CheckoutFailure is an item of a cart that couldn't be booked and why
+type CheckoutFailure struct {
+	ConcertID   int64  `json:"concert_id"`
+	TicketCount int    `json:"ticket_count"`
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"time"
+
+	"concert-ticket-api/pkg/clock"
 )
 
 // Concert represents a concert event with ticket information
@@ -19,14 +21,137 @@ type Concert struct {
 	Version          int       `json:"version" db:"version"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+
+	// Alternate names, e.g. transliterations or spellings in other scripts
+	ArtistAliases StringList `json:"artist_aliases" db:"artist_aliases"`
+	VenueAliases  StringList `json:"venue_aliases" db:"venue_aliases"`
+
+	// RequiresBookingToken marks high-demand concerts that can only be booked
+	// with a booking token issued beforehand
+	RequiresBookingToken bool `json:"requires_booking_token" db:"requires_booking_token"`
+
+	// Currency is the ISO 4217 code the price is denominated in
+	Currency string `json:"currency" db:"currency"`
+
+	// PriceDisplay is the price formatted for the requesting locale
+	PriceDisplay string `json:"price_display,omitempty" db:"-"`
+
+	// OrganizerEmail receives the final sales report when the sale ends
+	OrganizerEmail string `json:"organizer_email,omitempty" db:"organizer_email"`
+
+	// ReportingState tracks the final sales report and is maintained by the
+	// reporting job (see ReportingStateOpen)
+	ReportingState     string     `json:"reporting_state" db:"reporting_state"`
+	ReportingClaimedAt *time.Time `json:"-" db:"reporting_claimed_at"`
+
+	// DoorPrice replaces Price from DoorPriceLeadMinutes before the concert
+	// starts. Concerts without a door price keep their price until the end.
+	DoorPrice            *float64 `json:"door_price,omitempty" db:"door_price"`
+	DoorPriceLeadMinutes int      `json:"door_price_lead_minutes,omitempty" db:"door_price_lead_minutes"`
+	// DoorPriceSwitchedAt is set by the pricing job once the switch to the
+	// door price was announced
+	DoorPriceSwitchedAt *time.Time `json:"-" db:"door_price_switched_at"`
+
+	// PricingPhase and CurrentPrice describe the price that applies now and
+	// are set by SetPricing for responses
+	PricingPhase string  `json:"pricing_phase,omitempty" db:"-"`
+	CurrentPrice float64 `json:"current_price" db:"-"`
+
+	// Visibility controls who can find the concert (see VisibilityPublic)
+	Visibility string `json:"visibility" db:"visibility"`
+	// InviteTokenHash is the hash of the token that grants access to a private concert
+	InviteTokenHash string `json:"-" db:"invite_token_hash"`
+	// InviteToken is only set in the response that made a concert private
+	InviteToken string `json:"invite_token,omitempty" db:"-"`
+
+	// MaxTicketsPerBooking and MaxOrderValue limit a single booking, replacing
+	// the configured defaults when set (see BookingLimits)
+	MaxTicketsPerBooking *int     `json:"max_tickets_per_booking,omitempty" db:"max_tickets_per_booking"`
+	MaxOrderValue        *float64 `json:"max_order_value,omitempty" db:"max_order_value"`
+
+	// Normalized search keys, maintained by the repository on every write
+	SearchName   string `json:"-" db:"search_name"`
+	SearchArtist string `json:"-" db:"search_artist"`
+	SearchVenue  string `json:"-" db:"search_venue"`
+}
+
+// Visibilities of a concert
+const (
+	// VisibilityPublic concerts are listed and searchable
+	VisibilityPublic = "public"
+	// VisibilityUnlisted concerts are left out of listings and search but
+	// can be viewed and booked by anyone who knows their ID
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate concerts are unlisted and can only be viewed and
+	// booked with their invite token
+	VisibilityPrivate = "private"
+)
+
+// ConcertSortFields are the fields concert listings can be sorted by
+var ConcertSortFields = []string{"concert_date", "name", "artist", "price", "available_tickets", "created_at"}
+
+// Pricing phases of a concert
+const (
+	// PricingPhaseAdvance is the regular price before the door price applies
+	PricingPhaseAdvance = "advance"
+	// PricingPhaseDoor is the door price on the day of the show
+	PricingPhaseDoor = "door"
+)
+
+// DoorPriceStartsAt returns when the door price takes over, or the zero time
+// for concerts without a door price
+func (c *Concert) DoorPriceStartsAt() time.Time {
+	if c.DoorPrice == nil {
+		return time.Time{}
+	}
+	return c.ConcertDate.Add(-time.Duration(c.DoorPriceLeadMinutes) * time.Minute)
+}
+
+// PricingPhaseAt returns the pricing phase in effect at the given time
+func (c *Concert) PricingPhaseAt(t time.Time) string {
+	if c.DoorPrice != nil && !t.Before(c.DoorPriceStartsAt()) {
+		return PricingPhaseDoor
+	}
+	return PricingPhaseAdvance
+}
+
+// PriceAt returns the ticket price in effect at the given time
+func (c *Concert) PriceAt(t time.Time) float64 {
+	if c.PricingPhaseAt(t) == PricingPhaseDoor {
+		return *c.DoorPrice
+	}
+	return c.Price
+}
+
+// LastModified returns when the representation of the concert last changed at
+// the given time: its last update, or the switch to the door price if that
+// came later, since the switch changes the current price without a write
+func (c *Concert) LastModified(t time.Time) time.Time {
+	modified := c.UpdatedAt
+	if switchAt := c.DoorPriceStartsAt(); !switchAt.IsZero() && !t.Before(switchAt) && switchAt.After(modified) {
+		modified = switchAt
+	}
+	return modified
+}
+
+// SetPricing sets PricingPhase and CurrentPrice to the price that applies now
+func (c *Concert) SetPricing() {
+	now := clock.Now()
+	c.PricingPhase = c.PricingPhaseAt(now)
+	c.CurrentPrice = c.PriceAt(now)
 }
 
 // IsBookingOpen checks if booking is currently open for this concert
 func (c *Concert) IsBookingOpen() bool {
-	now := time.Now()
+	now := clock.Now()
 	return now.After(c.BookingStartTime) && now.Before(c.BookingEndTime)
 }
 
+// IsSaleEnded checks if the concert sold out or its booking window closed
+func (c *Concert) IsSaleEnded() bool {
+	return c.AvailableTickets <= 0 || !clock.Now().Before(c.BookingEndTime)
+}
+
 // HasAvailableTickets checks if the concert has enough available tickets
 func (c *Concert) HasAvailableTickets(count int) bool {
 	return c.AvailableTickets >= count
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// ConcertInvite grants one invitee, or anyone it is passed on to, access to a
+// private concert. Its token is presented like the concert's own invite token.
+type ConcertInvite struct {
+	ID        int64  `json:"id" db:"id"`
+	ConcertID int64  `json:"concert_id" db:"concert_id"`
+	Invitee   string `json:"invitee" db:"invitee"`
+	// SingleUse invites can be redeemed by one booking; others by any number
+	SingleUse bool `json:"single_use" db:"single_use"`
+	// Redemptions counts the bookings made with the invite
+	Redemptions int       `json:"redemptions" db:"redemptions"`
+	TokenHash   string    `json:"-" db:"token_hash"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	// Token is only set in the response that generated the invite
+	Token string `json:"token,omitempty" db:"-"`
+}
+
+// IsRedeemable checks if another booking can be made with the invite
+func (i *ConcertInvite) IsRedeemable() bool {
+	return !i.SingleUse || i.Redemptions == 0
+}
+
+// InviteRequest generates invites for a private concert: one per invitee, or
+// Count anonymous ones
+type InviteRequest struct {
+	Invitees  []string `json:"invitees"`
+	Count     int      `json:"count"`
+	SingleUse bool     `json:"single_use"`
+}
+
+// InviteRedemption records a booking made with an invite
+type InviteRedemption struct {
+	InviteID         int64     `json:"invite_id" db:"invite_id"`
+	BookingReference string    `json:"booking_reference" db:"booking_reference"`
+	RedeemedAt       time.Time `json:"redeemed_at" db:"redeemed_at"`
+}
+
+// InviteExportRow is an invite with one of its redemptions. Invites that were
+// never redeemed have a single row without booking fields.
+type InviteExportRow struct {
+	InviteID         int64      `json:"invite_id" db:"invite_id"`
+	Invitee          string     `json:"invitee" db:"invitee"`
+	SingleUse        bool       `json:"single_use" db:"single_use"`
+	BookingReference *string    `json:"booking_reference,omitempty" db:"booking_reference"`
+	UserID           *string    `json:"user_id,omitempty" db:"user_id"`
+	TicketCount      *int       `json:"ticket_count,omitempty" db:"ticket_count"`
+	BookingStatus    *string    `json:"booking_status,omitempty" db:"booking_status"`
+	RedeemedAt       *time.Time `json:"redeemed_at,omitempty" db:"redeemed_at"`
+}
+
+// InviteExport lists which invitees of a concert booked
+type InviteExport struct {
+	ConcertID  int64              `json:"concert_id"`
+	Invites    int                `json:"invites"`
+	Redeemed   int                `json:"redeemed"`
+	Rows       []*InviteExportRow `json:"rows"`
+	ExportedAt time.Time          `json:"exported_at"`
+	CSV        string             `json:"-"`
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"reflect"
+	"time"
+)
+
+// AuditActionConcertUpdated is recorded with the fields an update changed
+const AuditActionConcertUpdated = "concert.updated"
+
+// ConcertPatch updates the listed fields of a concert to their values in
+// Values and leaves the others as they are. Fields are named as in the JSON
+// representation; a field that is listed and zero in Values is cleared.
+type ConcertPatch struct {
+	Fields []string
+	Values Concert
+}
+
+// FieldChange is a field an update changed, with its old and new value
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// concertField reads and writes a field clients may update
+type concertField struct {
+	name string
+	get  func(c *Concert) interface{}
+	set  func(dst, src *Concert)
+}
+
+// concertFields are the fields of a concert clients may update. Available
+// tickets, versions and timestamps are maintained by the service.
+var concertFields = []concertField{
+	{"name", func(c *Concert) interface{} { return c.Name }, func(dst, src *Concert) { dst.Name = src.Name }},
+	{"artist", func(c *Concert) interface{} { return c.Artist }, func(dst, src *Concert) { dst.Artist = src.Artist }},
+	{"venue", func(c *Concert) interface{} { return c.Venue }, func(dst, src *Concert) { dst.Venue = src.Venue }},
+	{"concert_date", func(c *Concert) interface{} { return c.ConcertDate },
+		func(dst, src *Concert) { dst.ConcertDate = src.ConcertDate }},
+	{"total_tickets", func(c *Concert) interface{} { return c.TotalTickets },
+		func(dst, src *Concert) { dst.TotalTickets = src.TotalTickets }},
+	{"price", func(c *Concert) interface{} { return c.Price }, func(dst, src *Concert) { dst.Price = src.Price }},
+	{"booking_start_time", func(c *Concert) interface{} { return c.BookingStartTime },
+		func(dst, src *Concert) { dst.BookingStartTime = src.BookingStartTime }},
+	{"booking_end_time", func(c *Concert) interface{} { return c.BookingEndTime },
+		func(dst, src *Concert) { dst.BookingEndTime = src.BookingEndTime }},
+	{"artist_aliases", func(c *Concert) interface{} { return append([]string{}, c.ArtistAliases...) },
+		func(dst, src *Concert) { dst.ArtistAliases = src.ArtistAliases }},
+	{"venue_aliases", func(c *Concert) interface{} { return append([]string{}, c.VenueAliases...) },
+		func(dst, src *Concert) { dst.VenueAliases = src.VenueAliases }},
+	{"requires_booking_token", func(c *Concert) interface{} { return c.RequiresBookingToken },
+		func(dst, src *Concert) { dst.RequiresBookingToken = src.RequiresBookingToken }},
+	{"currency", func(c *Concert) interface{} { return c.Currency }, func(dst, src *Concert) { dst.Currency = src.Currency }},
+	{"organizer_email", func(c *Concert) interface{} { return c.OrganizerEmail },
+		func(dst, src *Concert) { dst.OrganizerEmail = src.OrganizerEmail }},
+	{"door_price", func(c *Concert) interface{} {
+		if c.DoorPrice == nil {
+			return nil
+		}
+		return *c.DoorPrice
+	}, func(dst, src *Concert) { dst.DoorPrice = src.DoorPrice }},
+	{"door_price_lead_minutes", func(c *Concert) interface{} { return c.DoorPriceLeadMinutes },
+		func(dst, src *Concert) { dst.DoorPriceLeadMinutes = src.DoorPriceLeadMinutes }},
+	{"visibility", func(c *Concert) interface{} { return c.Visibility }, func(dst, src *Concert) { dst.Visibility = src.Visibility }},
+	{"max_tickets_per_booking", func(c *Concert) interface{} {
+		if c.MaxTicketsPerBooking == nil {
+			return nil
+		}
+		return *c.MaxTicketsPerBooking
+	}, func(dst, src *Concert) { dst.MaxTicketsPerBooking = src.MaxTicketsPerBooking }},
+	{"max_order_value", func(c *Concert) interface{} {
+		if c.MaxOrderValue == nil {
+			return nil
+		}
+		return *c.MaxOrderValue
+	}, func(dst, src *Concert) { dst.MaxOrderValue = src.MaxOrderValue }},
+}
+
+// ConcertUpdateFields lists the fields of a concert clients may update, in
+// the order of the JSON representation
+var ConcertUpdateFields = func() []string {
+	names := make([]string, len(concertFields))
+	for i, field := range concertFields {
+		names[i] = field.name
+	}
+	return names
+}()
+
+func lookupConcertField(name string) (concertField, bool) {
+	for _, field := range concertFields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return concertField{}, false
+}
+
+// IsConcertUpdateField reports whether clients may update a field
+func IsConcertUpdateField(name string) bool {
+	_, ok := lookupConcertField(name)
+	return ok
+}
+
+// Apply sets the patched fields of c. Fields clients may not update are
+// ignored; the service rejects them before.
+func (p *ConcertPatch) Apply(c *Concert) {
+	for _, name := range p.Fields {
+		if field, ok := lookupConcertField(name); ok {
+			field.set(c, &p.Values)
+		}
+	}
+}
+
+// DiffConcerts returns the updatable fields that differ between two
+// versions of a concert, in the order of ConcertUpdateFields
+func DiffConcerts(before, after *Concert) []FieldChange {
+	var changes []FieldChange
+	for _, field := range concertFields {
+		from, to := field.get(before), field.get(after)
+		if equalValues(from, to) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field.name, From: from, To: to})
+	}
+	return changes
+}
+
+// equalValues compares field values, times by instant
+func equalValues(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// BookingConflictSummary summarizes optimistic-lock conflicts across concerts for a time window
+type BookingConflictSummary struct {
+	WindowStart time.Time               `json:"window_start"`
+	WindowEnd   time.Time               `json:"window_end"`
+	BucketSize  string                  `json:"bucket_size"`
+	Concerts    []*ConcertConflictStats `json:"concerts"`
+}
+
+// ConcertConflictStats contains booking conflict statistics for a single concert
+type ConcertConflictStats struct {
+	ConcertID int64 `json:"concert_id"`
+	// Bookings is the number of booking attempts that reached the retry loop
+	Bookings int `json:"bookings"`
+	// Conflicts is the total number of optimistic-lock conflicts encountered
+	Conflicts int `json:"conflicts"`
+	// ExhaustedRetries is the number of bookings that failed after using every retry
+	ExhaustedRetries int `json:"exhausted_retries"`
+	// ConflictRate is the ratio of conflicts to attempts made against the database
+	ConflictRate float64 `json:"conflict_rate"`
+	// RetryDepth maps the number of attempts a booking needed to how many bookings needed it
+	RetryDepth map[int]int      `json:"retry_depth"`
+	Buckets    []ConflictBucket `json:"buckets"`
+}
+
+// ConflictBucket holds conflict counts for one time bucket
+type ConflictBucket struct {
+	Start     time.Time `json:"start"`
+	Bookings  int       `json:"bookings"`
+	Conflicts int       `json:"conflicts"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// OrganizerDashboard aggregates the bookings of the concerts of an organizer,
+// the concerts with the organizer's email
+type OrganizerDashboard struct {
+	OrganizerEmail string `json:"organizer_email"`
+	// DayStart and DayEnd bound the day of the sales today, midnight to
+	// midnight in the time zone asked for
+	DayStart time.Time `json:"day_start"`
+	DayEnd   time.Time `json:"day_end"`
+	Concerts int       `json:"concerts"`
+	// SalesToday are the confirmed bookings made today, per currency
+	SalesToday []*CurrencySales `json:"sales_today"`
+	// TopConcerts are the concerts that sold the most tickets
+	TopConcerts   []*ConcertSales   `json:"top_concerts"`
+	Holds         HoldConversion    `json:"holds"`
+	Cancellations CancellationStats `json:"cancellations"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+}
+
+// CurrencySales aggregates the confirmed bookings in one currency
+type CurrencySales struct {
+	Currency string  `json:"currency" db:"currency"`
+	Bookings int     `json:"bookings" db:"bookings"`
+	Tickets  int     `json:"tickets" db:"tickets"`
+	Gross    float64 `json:"gross" db:"gross"`
+}
+
+// ConcertSales aggregates the confirmed bookings of a concert
+type ConcertSales struct {
+	ConcertID    int64     `json:"concert_id" db:"concert_id"`
+	Name         string    `json:"name" db:"name"`
+	ConcertDate  time.Time `json:"concert_date" db:"concert_date"`
+	TotalTickets int       `json:"total_tickets" db:"total_tickets"`
+	TicketsSold  int       `json:"tickets_sold" db:"tickets_sold"`
+	Gross        float64   `json:"gross" db:"gross"`
+	Currency     string    `json:"currency" db:"currency"`
+}
+
+// HoldConversion counts the bookings that held their tickets while waiting
+// for their payments
+type HoldConversion struct {
+	Holds int `json:"holds"`
+	// Pending are still waiting for their payments
+	Pending int `json:"pending"`
+	// Confirmed were paid, including those cancelled and refunded since
+	Confirmed int `json:"confirmed"`
+	// Lapsed were declined or expired and gave their tickets back
+	Lapsed int `json:"lapsed"`
+	// Rate is the fraction of the holds that were settled which were confirmed
+	Rate float64 `json:"rate"`
+}
+
+// CancellationStats counts the confirmed bookings that were cancelled. Holds
+// that lapsed were never confirmed and aren't counted.
+type CancellationStats struct {
+	// Bookings were confirmed, whether or not they were cancelled since
+	Bookings  int `json:"bookings"`
+	Cancelled int `json:"cancelled"`
+	// Rate is the fraction of the bookings that were cancelled
+	Rate float64 `json:"rate"`
+}
+
+// Intervals of sales timeseries buckets
+const (
+	SalesIntervalHour = "hour"
+	SalesIntervalDay  = "day"
+)
+
+// SalesTimeseriesQuery selects the buckets of a sales timeseries
+type SalesTimeseriesQuery struct {
+	// Interval is SalesIntervalHour or SalesIntervalDay
+	Interval string
+	// Location is the time zone the buckets start in
+	Location *time.Location
+	// From and To bound the booking times, the last day or month up to now
+	// by interval when zero
+	From time.Time
+	To   time.Time
+}
+
+// SalesTimeseries buckets the confirmed bookings of a concert by the time
+// they were made
+type SalesTimeseries struct {
+	ConcertID int64     `json:"concert_id"`
+	Interval  string    `json:"interval"`
+	Currency  string    `json:"currency"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	// Buckets cover the range without gaps, those without bookings at zero
+	Buckets []*SalesBucket `json:"buckets"`
+}
+
+// SalesBucket aggregates the confirmed bookings made in an hour or a day
+type SalesBucket struct {
+	Start    time.Time `json:"start"`
+	Bookings int       `json:"bookings"`
+	Tickets  int       `json:"tickets"`
+	Revenue  float64   `json:"revenue"`
+}
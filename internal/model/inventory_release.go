@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// EventConcertBackInStock is the topic of concerts that had sold out and got
+// tickets again, keyed by concert ID with a *ReleasedInventory payload
+const EventConcertBackInStock = "concert.back_in_stock"
+
+// InventoryRelease is a batch of a concert's tickets that goes on sale at a
+// scheduled time. Its tickets are held back from the available tickets until then.
+type InventoryRelease struct {
+	ID         int64      `json:"id" db:"id"`
+	ConcertID  int64      `json:"concert_id" db:"concert_id"`
+	Quantity   int        `json:"quantity" db:"quantity"`
+	ReleaseAt  time.Time  `json:"release_at" db:"release_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty" db:"released_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// InventoryReleaseRequest schedules an inventory release
+type InventoryReleaseRequest struct {
+	Quantity  int       `json:"quantity" binding:"required"`
+	ReleaseAt time.Time `json:"release_at" binding:"required"`
+}
+
+// ReleasedInventory is the outcome of an executed inventory release
+type ReleasedInventory struct {
+	Release *InventoryRelease `json:"release"`
+	// AvailableTickets and TotalTickets are the concert's tickets right after the release
+	AvailableTickets int `json:"available_tickets"`
+	TotalTickets     int `json:"total_tickets"`
+}
+
+// WasSoldOut checks if the concert had no tickets left before the release
+func (r *ReleasedInventory) WasSoldOut() bool {
+	return r.AvailableTickets-r.Release.Quantity <= 0
+}
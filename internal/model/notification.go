@@ -0,0 +1,138 @@
+package model
+
+import "time"
+
+// Notification channels
+const (
+	// NotificationChannelEmail sends notifications to the attendee's email
+	NotificationChannelEmail = "email"
+	// NotificationChannelSMS sends notifications to the phone number of the
+	// user who made the booking, if they opted in
+	NotificationChannelSMS = "sms"
+	// NotificationChannelPush is only a preference the apps read: no
+	// notifier sends push notifications
+	NotificationChannelPush = "push"
+)
+
+// NotificationPreferenceChannels are the channels users set preferences for
+var NotificationPreferenceChannels = []string{
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+	NotificationChannelPush,
+}
+
+// Notification statuses
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	// NotificationDead notifications failed every attempt or can't be sent
+	// anymore, e.g. because the attendee's details were erased
+	NotificationDead = "dead"
+)
+
+// Notification is a message of a template about a booking, sent to its
+// attendee or its user over a channel. The recipient and what the message
+// shows are read from the booking, its concert and the user's preferences
+// when it is sent, so no personal data is copied.
+type Notification struct {
+	ID        int64  `db:"id"`
+	Channel   string `db:"channel"`
+	Template  string `db:"template"`
+	BookingID int64  `db:"booking_id"`
+	// EventID is the outbox event the notification is about, zero for
+	// reminders. A booking gets one notification of a template per event.
+	EventID int64 `db:"event_id"`
+	// PreviousConcertDate is when the concert of a rescheduling notification
+	// was planned
+	PreviousConcertDate *time.Time `db:"previous_concert_date"`
+	Status              string     `db:"status"`
+	Attempts            int        `db:"attempts"`
+	NextAttemptAt       time.Time  `db:"next_attempt_at"`
+	LastError           *string    `db:"last_error"`
+	SentAt              *time.Time `db:"sent_at"`
+	CreatedAt           time.Time  `db:"created_at"`
+}
+
+// NotificationPolicy is which notifications are sent and how they are
+// attempted
+type NotificationPolicy struct {
+	// BatchSize is the maximum number of events turned into notifications
+	// and of notifications sent at once
+	BatchSize   int
+	MaxAttempts int
+	// BackoffBase is the wait after the first failed attempt, doubled after
+	// every further one up to BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long sent and dead notifications are kept
+	Retention time.Duration
+	// MaxEventAge is how old an event may be to be turned into notifications
+	MaxEventAge time.Duration
+	// ReminderLead is how long before a concert its attendees are reminded
+	ReminderLead time.Duration
+	// Templates are the enabled templates of each enabled channel
+	Templates map[string]map[string]bool
+}
+
+// Enabled reports whether notifications of a template are sent over a channel
+func (p NotificationPolicy) Enabled(channel, template string) bool {
+	return p.Templates[channel][template]
+}
+
+// Backoff returns the wait before the next attempt of a notification that
+// failed a number of times
+func (p NotificationPolicy) Backoff(attempts int) time.Duration {
+	return backoff(p.BackoffBase, p.BackoffMax, attempts)
+}
+
+// NotificationPreferences are the channels a user wants to be notified on,
+// and the notifications they turned on or off on each
+type NotificationPreferences struct {
+	UserID string `json:"user_id" db:"user_id"`
+	Email  bool   `json:"email" db:"email"`
+	SMS    bool   `json:"sms" db:"sms"`
+	Push   bool   `json:"push" db:"push"`
+	// PhoneNumber is personal data and is encrypted at rest
+	PhoneNumber string `json:"phone_number,omitempty" db:"phone_number"`
+	// Types turns the notifications of a template on or off per channel,
+	// like {"email": {"concert_reminder": false}}. Templates not listed
+	// follow their channel.
+	Types     NotificationTypes `json:"types" db:"types"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultNotificationPreferences are the preferences of users who didn't
+// set any: they are emailed, but not texted
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{UserID: userID, Email: true, Types: NotificationTypes{}}
+}
+
+// Wants reports whether the user wants notifications of a template over a
+// channel and can get them
+func (p *NotificationPreferences) Wants(channel, template string) bool {
+	if wanted, ok := p.Types[channel][template]; ok && !wanted {
+		return false
+	}
+
+	switch channel {
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelSMS:
+		return p.SMS && p.PhoneNumber != ""
+	case NotificationChannelPush:
+		return p.Push
+	}
+	return false
+}
+
+// NotificationPreferencesRequest changes the preferences of a user. Fields
+// left out keep their value; types replace the ones set before.
+type NotificationPreferencesRequest struct {
+	Email *bool `json:"email"`
+	SMS   *bool `json:"sms"`
+	Push  *bool `json:"push"`
+	// PhoneNumber is in E.164 format, like +15005550006. An empty one
+	// removes it.
+	PhoneNumber *string           `json:"phone_number"`
+	Types       NotificationTypes `json:"types"`
+}
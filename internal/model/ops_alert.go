@@ -0,0 +1,17 @@
+package model
+
+// OpsAlertPolicy is which operational alerts are posted
+type OpsAlertPolicy struct {
+	SellOuts bool
+	// Drift alerts on bookings the payment reconciliation finds disagreeing
+	// with their payments
+	Drift bool
+	// ErrorRateThreshold is the share of booking attempts failing with an
+	// error in an interval above which an alert is posted, zero to never
+	ErrorRateThreshold float64
+	// ErrorRateMinAttempts is the number of attempts an interval needs
+	// before its error rate counts
+	ErrorRateMinAttempts int
+	// Instance names the replica whose booking attempts are counted
+	Instance string
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Domain event types written to the outbox
+const (
+	EventConcertCreated   = "concert.created"
+	EventConcertUpdated   = "concert.updated"
+	EventBookingConfirmed = "booking.confirmed"
+	EventBookingCancelled = "booking.cancelled"
+)
+
+// Aggregates the events are about. The events of one aggregate are
+// published in the order they were written.
+const (
+	AggregateConcert = "concert"
+	AggregateBooking = "booking"
+)
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes and published to the message broker afterwards
+type OutboxEvent struct {
+	ID            int64  `db:"id"`
+	AggregateType string `db:"aggregate_type"`
+	// AggregateID is the concert ID or the booking reference
+	AggregateID string `db:"aggregate_id"`
+	EventType   string `db:"event_type"`
+	// Payload is the JSON encoding of a ConcertEvent or a BookingEvent
+	Payload   []byte    `db:"payload"`
+	CreatedAt time.Time `db:"created_at"`
+	// Attempts counts the failed attempts to publish the event
+	Attempts int `db:"attempts"`
+}
+
+// Key identifies the aggregate of the event, such as booking:BK-7Q2M4X
+func (e *OutboxEvent) Key() string {
+	return e.AggregateType + ":" + e.AggregateID
+}
+
+// ConcertEvent is the payload of concert events
+type ConcertEvent struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Artist           string    `json:"artist"`
+	Venue            string    `json:"venue"`
+	ConcertDate      time.Time `json:"concert_date"`
+	TotalTickets     int       `json:"total_tickets"`
+	Price            float64   `json:"price"`
+	Currency         string    `json:"currency"`
+	BookingStartTime time.Time `json:"booking_start_time"`
+	BookingEndTime   time.Time `json:"booking_end_time"`
+	Visibility       string    `json:"visibility"`
+	Version          int       `json:"version"`
+	// PreviousConcertDate is only set on concert.updated events that
+	// rescheduled the concert
+	PreviousConcertDate *time.Time `json:"previous_concert_date,omitempty"`
+}
+
+// BookingEvent is the payload of booking events. Attendee details stay in
+// the database.
+type BookingEvent struct {
+	Reference   string        `json:"reference"`
+	ConcertID   int64         `json:"concert_id"`
+	UserID      string        `json:"user_id"`
+	TicketCount int           `json:"ticket_count"`
+	UnitPrice   float64       `json:"unit_price"`
+	Status      BookingStatus `json:"status"`
+	BookingTime time.Time     `json:"booking_time"`
+}
+
+// NewConcertEvent creates an event of the given type about a concert
+func NewConcertEvent(eventType string, concert *Concert) (*OutboxEvent, error) {
+	return newConcertEvent(eventType, concert, nil)
+}
+
+// NewConcertUpdatedEvent creates the concert.updated event of a concert that
+// took place at previousDate before the update. A rescheduled concert's
+// event carries its previous date.
+func NewConcertUpdatedEvent(concert *Concert, previousDate time.Time) (*OutboxEvent, error) {
+	var previous *time.Time
+	if !previousDate.IsZero() && !previousDate.Equal(concert.ConcertDate) {
+		previous = &previousDate
+	}
+	return newConcertEvent(EventConcertUpdated, concert, previous)
+}
+
+func newConcertEvent(eventType string, concert *Concert, previousDate *time.Time) (*OutboxEvent, error) {
+	payload, err := json.Marshal(ConcertEvent{
+		ID:                  concert.ID,
+		Name:                concert.Name,
+		Artist:              concert.Artist,
+		Venue:               concert.Venue,
+		ConcertDate:         concert.ConcertDate,
+		TotalTickets:        concert.TotalTickets,
+		Price:               concert.Price,
+		Currency:            concert.Currency,
+		BookingStartTime:    concert.BookingStartTime,
+		BookingEndTime:      concert.BookingEndTime,
+		Visibility:          concert.Visibility,
+		Version:             concert.Version,
+		PreviousConcertDate: previousDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		AggregateType: AggregateConcert,
+		AggregateID:   strconv.FormatInt(concert.ID, 10),
+		EventType:     eventType,
+		Payload:       payload,
+	}, nil
+}
+
+// NewBookingEvent creates an event of the given type about a booking
+func NewBookingEvent(eventType string, booking *Booking) (*OutboxEvent, error) {
+	payload, err := json.Marshal(BookingEvent{
+		Reference:   booking.Reference,
+		ConcertID:   booking.ConcertID,
+		UserID:      booking.UserID,
+		TicketCount: booking.TicketCount,
+		UnitPrice:   booking.UnitPrice,
+		Status:      booking.Status,
+		BookingTime: booking.BookingTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		AggregateType: AggregateBooking,
+		AggregateID:   booking.Reference,
+		EventType:     eventType,
+		Payload:       payload,
+	}, nil
+}
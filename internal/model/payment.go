@@ -0,0 +1,100 @@
+package model
+
+import "time"
+
+// Statuses of payments
+const (
+	// PaymentPending waits for the customer to pay
+	PaymentPending = "pending"
+	// PaymentAuthorized was authorized by the customer, which confirmed the
+	// booking, and waits to be captured
+	PaymentAuthorized = "authorized"
+	// PaymentSucceeded was captured
+	PaymentSucceeded = "succeeded"
+	// PaymentFailed was declined, which released the booking's tickets
+	PaymentFailed = "failed"
+	// PaymentCanceled was withdrawn because the booking was cancelled or its
+	// hold expired
+	PaymentCanceled = "canceled"
+	// PaymentRefunding is being paid back or voided by the cancellation of
+	// its booking, which claimed it so no other cancellation does it again
+	PaymentRefunding = "refunding"
+	// PaymentRefunded was paid back because its booking was cancelled
+	PaymentRefunded = "refunded"
+)
+
+// Mismatches between the status of a booking and the provider's record of
+// its payment
+const (
+	// MismatchPaidNotConfirmed is a payment the provider authorized or
+	// captured whose booking isn't confirmed
+	MismatchPaidNotConfirmed = "paid_not_confirmed"
+	// MismatchConfirmedNotPaid is a confirmed booking whose payment the
+	// provider neither authorized nor captured
+	MismatchConfirmedNotPaid = "confirmed_not_paid"
+)
+
+// Payment is the payment of a booking at a payment provider. The booking is
+// pending, holding its tickets, until the payment is authorized or its hold
+// expires.
+type Payment struct {
+	ID        int64 `json:"-" db:"id"`
+	BookingID int64 `json:"-" db:"booking_id"`
+	// Provider names the payment provider, such as stripe
+	Provider string `json:"provider" db:"provider"`
+	// ProviderReference identifies the payment at the provider, such as the
+	// ID of a Stripe PaymentIntent
+	ProviderReference string `json:"provider_reference" db:"provider_reference"`
+	// ClientSecret lets the client complete the payment with the provider. It
+	// is only returned to the client that booked.
+	ClientSecret string  `json:"client_secret,omitempty" db:"client_secret"`
+	Amount       float64 `json:"amount" db:"amount"`
+	Currency     string  `json:"currency" db:"currency"`
+	Status       string  `json:"status" db:"status"`
+	// Mismatch flags a payment whose booking disagrees with the provider's
+	// records, as the last reconciliation found it
+	Mismatch string `json:"-" db:"mismatch"`
+	// ExpiresAt is when the tickets are released if the booking isn't paid,
+	// and when an authorized payment that wasn't captured is captured again
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IsSettled checks if the payment was captured or can no longer be
+func (p *Payment) IsSettled() bool {
+	return p.Status != PaymentPending && p.Status != PaymentAuthorized
+}
+
+// PaymentPolicy is how long bookings wait for their payments and how they
+// are reconciled with the provider's records
+type PaymentPolicy struct {
+	// HoldTTL is how long a pending booking holds its tickets
+	HoldTTL time.Duration
+	// ReconcileLookback is how old the payments reconciled may be
+	ReconcileLookback time.Duration
+	// ReconcileGrace leaves payments that changed more recently to the
+	// provider's notifications
+	ReconcileGrace time.Duration
+	// ReconcileBatchSize is the maximum number of payments reconciled per run
+	ReconcileBatchSize int
+	// Repair fixes the mismatches reconciliation finds instead of only
+	// flagging them
+	Repair bool
+}
+
+// PaymentCheck is a payment to reconcile, with the status of its booking
+type PaymentCheck struct {
+	Payment
+	BookingStatus BookingStatus `db:"booking_status"`
+}
+
+// PaymentReconciliation is the outcome of a reconciliation run
+type PaymentReconciliation struct {
+	// Checked is the number of payments compared with the provider's records
+	Checked int
+	// Mismatches counts the mismatches found by kind
+	Mismatches map[string]int
+	// Repaired is the number of mismatches repaired
+	Repaired int
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+)
+
+// PriceSnapshot is the price of a concert from a point in time until the next snapshot
+type PriceSnapshot struct {
+	Price        float64   `json:"price" db:"price"`
+	Currency     string    `json:"currency" db:"currency"`
+	PriceDisplay string    `json:"price_display,omitempty" db:"-"`
+	RecordedAt   time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// ConcertComparison is the summary of a concert compared side by side with others
+type ConcertComparison struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Artist           string    `json:"artist"`
+	Venue            string    `json:"venue"`
+	ConcertDate      time.Time `json:"concert_date"`
+	Price            float64   `json:"price"`
+	Currency         string    `json:"currency"`
+	PriceDisplay     string    `json:"price_display,omitempty"`
+	PricingPhase     string    `json:"pricing_phase"`
+	CurrentPrice     float64   `json:"current_price"`
+	AvailableTickets int       `json:"available_tickets"`
+	TotalTickets     int       `json:"total_tickets"`
+	SoldOut          bool      `json:"sold_out"`
+	BookingOpen      bool      `json:"booking_open"`
+}
+
+// NewConcertComparison summarizes a concert for comparison
+func NewConcertComparison(concert *Concert) *ConcertComparison {
+	return &ConcertComparison{
+		ID:               concert.ID,
+		Name:             concert.Name,
+		Artist:           concert.Artist,
+		Venue:            concert.Venue,
+		ConcertDate:      concert.ConcertDate,
+		Price:            concert.Price,
+		Currency:         concert.Currency,
+		PriceDisplay:     concert.PriceDisplay,
+		PricingPhase:     concert.PricingPhase,
+		CurrentPrice:     concert.CurrentPrice,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		SoldOut:          concert.AvailableTickets <= 0,
+		BookingOpen:      concert.IsBookingOpen(),
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// EventConcertPricing is the topic of pricing phase switches, keyed by
+// concert ID with a *PricingPhaseChange payload
+const EventConcertPricing = "concert.pricing"
+
+// PricingPhaseChange announces that a concert switched to another pricing phase
+type PricingPhaseChange struct {
+	ConcertID int64     `json:"concert_id"`
+	Phase     string    `json:"phase"`
+	Price     float64   `json:"price"`
+	Currency  string    `json:"currency"`
+	At        time.Time `json:"at"`
+}
+
+// PriceQuote is the price of booking tickets for a concert right now
+type PriceQuote struct {
+	ConcertID    int64   `json:"concert_id"`
+	TicketCount  int     `json:"ticket_count"`
+	PricingPhase string  `json:"pricing_phase"`
+	UnitPrice    float64 `json:"unit_price"`
+	Total        float64 `json:"total"`
+	Currency     string  `json:"currency"`
+	TotalDisplay string  `json:"total_display,omitempty"`
+	// ValidUntil is when the door price takes over, for quotes made before
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
@@ -0,0 +1,103 @@
+package model
+
+import "time"
+
+// AuditActionRuntimeSettingsUpdated is recorded with the settings a change
+// changed
+const AuditActionRuntimeSettingsUpdated = "runtime_settings.updated"
+
+// RuntimeSettings are the operational settings operators change at runtime,
+// without a deploy. Every replica applies them once they change.
+type RuntimeSettings struct {
+	// Maintenance rejects client requests with 503 while it is set
+	Maintenance bool `json:"maintenance"`
+	// MaintenanceMessage is the detail of the rejections
+	MaintenanceMessage string `json:"maintenance_message"`
+	// RateLimit is the number of requests per second each client IP may make
+	RateLimit int `json:"rate_limit"`
+	// AdmissionRate is the number of booking tokens issued per second per
+	// concert, which paces the waiting room, and AdmissionBurst how many may
+	// be issued at once
+	AdmissionRate  float64 `json:"admission_rate"`
+	AdmissionBurst int     `json:"admission_burst"`
+	// ConcertCacheTTLMs is how long concerts are cached, in milliseconds;
+	// zero stops caching
+	ConcertCacheTTLMs int64 `json:"concert_cache_ttl_ms"`
+	// CORSAllowOrigins are the origins browsers may call the REST API from
+	CORSAllowOrigins []string `json:"cors_allow_origins"`
+
+	// Version is 0 until the settings are first changed; until then they
+	// are the configured defaults
+	Version   int       `json:"version"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConcertCacheTTL returns how long concerts are cached
+func (s *RuntimeSettings) ConcertCacheTTL() time.Duration {
+	return time.Duration(s.ConcertCacheTTLMs) * time.Millisecond
+}
+
+// RuntimeSettingsPatch changes the settings it has a value for and leaves the
+// others as they are
+type RuntimeSettingsPatch struct {
+	Maintenance        *bool     `json:"maintenance"`
+	MaintenanceMessage *string   `json:"maintenance_message"`
+	RateLimit          *int      `json:"rate_limit"`
+	AdmissionRate      *float64  `json:"admission_rate"`
+	AdmissionBurst     *int      `json:"admission_burst"`
+	ConcertCacheTTLMs  *int64    `json:"concert_cache_ttl_ms"`
+	CORSAllowOrigins   *[]string `json:"cors_allow_origins"`
+}
+
+// Empty reports whether the patch changes nothing
+func (p *RuntimeSettingsPatch) Empty() bool {
+	return p.Maintenance == nil && p.MaintenanceMessage == nil && p.RateLimit == nil &&
+		p.AdmissionRate == nil && p.AdmissionBurst == nil && p.ConcertCacheTTLMs == nil &&
+		p.CORSAllowOrigins == nil
+}
+
+// Apply returns a copy of s with the settings of the patch changed, and the
+// changes it made. The version and update fields are left to the caller.
+func (p *RuntimeSettingsPatch) Apply(s *RuntimeSettings) (*RuntimeSettings, []FieldChange) {
+	updated := *s
+	updated.CORSAllowOrigins = append([]string(nil), s.CORSAllowOrigins...)
+
+	var changes []FieldChange
+	change := func(field string, from, to interface{}) {
+		if !equalValues(from, to) {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	if p.Maintenance != nil {
+		change("maintenance", s.Maintenance, *p.Maintenance)
+		updated.Maintenance = *p.Maintenance
+	}
+	if p.MaintenanceMessage != nil {
+		change("maintenance_message", s.MaintenanceMessage, *p.MaintenanceMessage)
+		updated.MaintenanceMessage = *p.MaintenanceMessage
+	}
+	if p.RateLimit != nil {
+		change("rate_limit", s.RateLimit, *p.RateLimit)
+		updated.RateLimit = *p.RateLimit
+	}
+	if p.AdmissionRate != nil {
+		change("admission_rate", s.AdmissionRate, *p.AdmissionRate)
+		updated.AdmissionRate = *p.AdmissionRate
+	}
+	if p.AdmissionBurst != nil {
+		change("admission_burst", s.AdmissionBurst, *p.AdmissionBurst)
+		updated.AdmissionBurst = *p.AdmissionBurst
+	}
+	if p.ConcertCacheTTLMs != nil {
+		change("concert_cache_ttl_ms", s.ConcertCacheTTLMs, *p.ConcertCacheTTLMs)
+		updated.ConcertCacheTTLMs = *p.ConcertCacheTTLMs
+	}
+	if p.CORSAllowOrigins != nil {
+		origins := append([]string(nil), *p.CORSAllowOrigins...)
+		change("cors_allow_origins", s.CORSAllowOrigins, origins)
+		updated.CORSAllowOrigins = origins
+	}
+	return &updated, changes
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// Reporting states of a concert
+const (
+	// ReportingStateOpen means sales are ongoing and no final report exists yet
+	ReportingStateOpen = "open"
+	// ReportingStateFinalizing means a final report is being generated
+	ReportingStateFinalizing = "finalizing"
+	// ReportingStateFinalized means the final sales report has been generated
+	ReportingStateFinalized = "finalized"
+)
+
+// Reasons a final sales report was generated
+const (
+	SalesReportReasonSoldOut       = "sold_out"
+	SalesReportReasonBookingClosed = "booking_closed"
+)
+
+// SalesReport is the final sales report generated when a concert's sale ends
+type SalesReport struct {
+	ConcertID           int64               `json:"concert_id" db:"concert_id"`
+	Reason              string              `json:"reason" db:"reason"`
+	TicketsSold         int                 `json:"tickets_sold" db:"tickets_sold"`
+	TicketsAvailable    int                 `json:"tickets_available" db:"tickets_available"`
+	ConfirmedBookings   int                 `json:"confirmed_bookings" db:"confirmed_bookings"`
+	CancelledBookings   int                 `json:"cancelled_bookings" db:"cancelled_bookings"`
+	Revenue             float64             `json:"revenue" db:"revenue"`
+	Currency            string              `json:"currency" db:"currency"`
+	CSV                 string              `json:"-" db:"report_csv"`
+	AvailabilityHistory AvailabilityHistory `json:"availability_history" db:"availability_history"`
+	Recipient           string              `json:"recipient,omitempty" db:"recipient"`
+	EmailedAt           *time.Time          `json:"emailed_at,omitempty" db:"emailed_at"`
+	GeneratedAt         time.Time           `json:"generated_at" db:"generated_at"`
+}
+
+// AvailabilityPoint is the number of available tickets at a point in time
+type AvailabilityPoint struct {
+	Time             time.Time `json:"time"`
+	AvailableTickets int       `json:"available_tickets"`
+}
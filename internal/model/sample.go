@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// SampleOptions controls how an anonymized sample dataset is drawn
+type SampleOptions struct {
+	// Rate is the fraction of concerts sampled from every month, in (0, 1]
+	Rate float64
+	// Seed makes the sample, and the jitter applied to it, reproducible
+	Seed int64
+	// Jitter is the maximum shift applied to booking timestamps in either direction
+	Jitter time.Duration
+	// MinBookings suppresses sampled concerts with fewer bookings, whose
+	// buyers would be easy to single out
+	MinBookings int
+	// Salt keys the user ID hashes. Samples drawn with different salts cannot
+	// be joined on users.
+	Salt []byte
+}
+
+// SampleConcert is a concert in a sample dataset. It is identified by a
+// sequence number local to the sample instead of its ID.
+type SampleConcert struct {
+	SampleID         int       `json:"sample_id"`
+	Month            string    `json:"month"`
+	Name             string    `json:"name"`
+	Artist           string    `json:"artist"`
+	Venue            string    `json:"venue"`
+	ConcertDate      time.Time `json:"concert_date"`
+	TotalTickets     int       `json:"total_tickets"`
+	AvailableTickets int       `json:"available_tickets"`
+	Price            float64   `json:"price"`
+	Currency         string    `json:"currency"`
+	BookingStartTime time.Time `json:"booking_start_time"`
+	BookingEndTime   time.Time `json:"booking_end_time"`
+	// Weight is the number of concerts of its month this concert stands for,
+	// for scaling sample totals up to the full population
+	Weight float64 `json:"weight"`
+}
+
+// SampleBooking is a booking in a sample dataset, without its reference,
+// attendee details or the real user ID
+type SampleBooking struct {
+	ConcertSampleID int           `json:"concert_sample_id"`
+	UserHash        string        `json:"user_hash"`
+	TicketCount     int           `json:"ticket_count"`
+	Status          BookingStatus `json:"status"`
+	UnitPrice       float64       `json:"unit_price"`
+	// BookedAt is the booking time shifted by a random jitter
+	BookedAt time.Time `json:"booked_at"`
+}
+
+// SampleDataset is an anonymized sample of concerts and their bookings
+type SampleDataset struct {
+	Concerts []*SampleConcert `json:"concerts"`
+	Bookings []*SampleBooking `json:"bookings"`
+	// Suppressed counts the sampled concerts left out for having too few bookings
+	Suppressed  int       `json:"suppressed"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
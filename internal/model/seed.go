@@ -0,0 +1,29 @@
+package model
+
+// SeedOptions controls the fixtures seeded into a development or demo
+// database
+type SeedOptions struct {
+	// Concerts is the number of concerts to seed
+	Concerts int
+	// Users is the number of users the bookings are spread over
+	Users int
+	// Bookings is the number of bookings to attempt
+	Bookings int
+	// Seed makes the fixtures reproducible: runs with the same seed and
+	// counts seed the same concerts and bookings
+	Seed int64
+}
+
+// SeedResult reports what a seed run did. Fixtures found were seeded by an
+// earlier run and left as they are.
+type SeedResult struct {
+	ConcertsCreated int
+	ConcertsFound   int
+	BookingsCreated int
+	BookingsFound   int
+	// BookingsCancelled are the created bookings that were cancelled again,
+	// returning their tickets
+	BookingsCancelled int
+	// BookingsSkipped found no concert on sale or their concert sold out
+	BookingsSkipped int
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// BookingLinks are the signed links to the HTML pages of a booking, which
+// open without credentials
+type BookingLinks struct {
+	TicketURL  string    `json:"ticket_url"`
+	ReceiptURL string    `json:"receipt_url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// CalendarURL is the feed of all of the user's bookings to subscribe to.
+	// It doesn't expire.
+	CalendarURL string `json:"calendar_url"`
+}
+
+// BookingPage is what the ticket and receipt pages of a booking show
+type BookingPage struct {
+	Booking *Booking
+	Concert *Concert
+	// Code is scanned at the door; it is the signed ticket token, so
+	// scanners can check it offline
+	Code string
+}
+
+// Valid reports whether the booking admits its holder
+func (p *BookingPage) Valid() bool {
+	return p.Booking.Status == BookingStatusConfirmed
+}
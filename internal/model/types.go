@@ -0,0 +1,150 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringList is a list of strings stored as a JSON array in the database
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	encoded, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// AvailabilityHistory is a list of availability points stored as a JSON array in the database
+type AvailabilityHistory []AvailabilityPoint
+
+// Value implements driver.Valuer
+func (h AvailabilityHistory) Value() (driver.Value, error) {
+	if h == nil {
+		return "[]", nil
+	}
+
+	encoded, err := json.Marshal([]AvailabilityPoint(h))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (h *AvailabilityHistory) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into AvailabilityHistory", src)
+	}
+
+	return json.Unmarshal(data, (*[]AvailabilityPoint)(h))
+}
+
+// NotificationTypes are notification templates turned on or off per channel,
+// stored as a JSON object in the database
+type NotificationTypes map[string]map[string]bool
+
+// Value implements driver.Valuer
+func (t NotificationTypes) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+
+	encoded, err := json.Marshal(map[string]map[string]bool(t))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (t *NotificationTypes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into NotificationTypes", src)
+	}
+
+	return json.Unmarshal(data, (*map[string]map[string]bool)(t))
+}
+
+// RawJSON is a JSON document stored as JSONB in the database and marshalled as is
+type RawJSON []byte
+
+// Value implements driver.Valuer
+func (r RawJSON) Value() (driver.Value, error) {
+	if r == nil {
+		return "null", nil
+	}
+	return string(r), nil
+}
+
+// Scan implements sql.Scanner. The source is copied, since drivers may reuse it.
+func (r *RawJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+	case []byte:
+		*r = append(RawJSON(nil), v...)
+	case string:
+		*r = RawJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", src)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *RawJSON) UnmarshalJSON(data []byte) error {
+	*r = append(RawJSON(nil), data...)
+	return nil
+}
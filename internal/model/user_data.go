@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// UserDataExport contains all data stored about a user
+type UserDataExport struct {
+	UserID     string     `json:"user_id"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Bookings   []*Booking `json:"bookings"`
+	// NotificationPreferences are only set if the user set any
+	NotificationPreferences *NotificationPreferences `json:"notification_preferences,omitempty"`
+}
+
+// UserDataErasure summarizes the result of erasing a user's data
+type UserDataErasure struct {
+	UserID            string    `json:"user_id"`
+	ErasedAt          time.Time `json:"erased_at"`
+	AnonymizedRecords int       `json:"anonymized_records"`
+}
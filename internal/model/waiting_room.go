@@ -0,0 +1,117 @@
+package model
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// EventBookingStatus is the topic of booking confirmations and cancellations,
+// keyed by UserEventKey of the booking's user with a *Booking payload
+const EventBookingStatus = "booking.status"
+
+// EventWaitingRoomAdmitted is the topic of the users admitted from the
+// waiting room, keyed by UserEventKey of the user with the *BookingToken
+// they were issued
+const EventWaitingRoomAdmitted = "waiting_room.admitted"
+
+// UserEventKey derives the event bus key of a user's events. Different users
+// can share a key, so subscribers still check the user of each event.
+func UserEventKey(userID string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(userID))
+
+	// Zero subscribes to every key, so it is never used for a user
+	key := int64(h.Sum64() &^ (1 << 63))
+	if key == 0 {
+		key = 1
+	}
+	return key
+}
+
+// WaitingRoomUpdate tells a user queued for a booking token where they stand
+type WaitingRoomUpdate struct {
+	ConcertID int64 `json:"concert_id"`
+	// Position is the 1-based place in the queue, 0 once the user is admitted
+	Position int `json:"position"`
+	// Token is set when the user is admitted
+	Token *BookingToken `json:"token,omitempty"`
+	// Error is set when the user can't be admitted, e.g. because booking closed
+	Error string `json:"error,omitempty"`
+}
+
+// WaitingRoomStateFormat is the format of waiting room snapshots. Snapshots
+// of other formats aren't restored.
+const WaitingRoomStateFormat = 1
+
+// WaitingRoomMember is a user queued in the shared waiting room
+type WaitingRoomMember struct {
+	UserID string `json:"user_id"`
+	// Seq orders a queue: members who joined earlier have lower numbers
+	Seq         int64  `json:"seq"`
+	InviteToken string `json:"invite_token,omitempty"`
+	// Connection identifies the user's latest connection
+	Connection string `json:"connection,omitempty"`
+	// DepartedAt is set while the user has no open connection
+	DepartedAt *time.Time `json:"departed_at,omitempty"`
+}
+
+// WaitingRoomState is the state of every queue of the shared waiting room.
+// Version grows with every change, so of two states the one with the higher
+// version is the more recent.
+type WaitingRoomState struct {
+	Format  int   `json:"format"`
+	Version int64 `json:"version"`
+	// Seq is the last sequence number handed out
+	Seq     int64                         `json:"seq"`
+	Queues  map[int64][]WaitingRoomMember `json:"queues"`
+	TakenAt time.Time                     `json:"taken_at"`
+}
+
+// MergeWaitingRoomStates combines a snapshot with the state a store built up
+// after losing its data. Members of the snapshot keep their places; members
+// who only joined since are queued behind them in their order. The result
+// is newer than both states.
+func MergeWaitingRoomStates(snapshot, current *WaitingRoomState) *WaitingRoomState {
+	merged := &WaitingRoomState{
+		Format:  WaitingRoomStateFormat,
+		Version: snapshot.Version + current.Version + 1,
+		Seq:     snapshot.Seq,
+		Queues:  make(map[int64][]WaitingRoomMember, len(snapshot.Queues)),
+		TakenAt: current.TakenAt,
+	}
+
+	for concertID, members := range snapshot.Queues {
+		merged.Queues[concertID] = append([]WaitingRoomMember(nil), members...)
+	}
+
+	concertIDs := make([]int64, 0, len(current.Queues))
+	for concertID := range current.Queues {
+		concertIDs = append(concertIDs, concertID)
+	}
+	sort.Slice(concertIDs, func(i, j int) bool { return concertIDs[i] < concertIDs[j] })
+
+	for _, concertID := range concertIDs {
+		queued := make(map[string]int, len(merged.Queues[concertID]))
+		for i, member := range merged.Queues[concertID] {
+			queued[member.UserID] = i
+		}
+
+		members := append([]WaitingRoomMember(nil), current.Queues[concertID]...)
+		sort.Slice(members, func(i, j int) bool { return members[i].Seq < members[j].Seq })
+		for _, member := range members {
+			if i, ok := queued[member.UserID]; ok {
+				// The user reconnected since: their connection state is newer
+				merged.Queues[concertID][i].InviteToken = member.InviteToken
+				merged.Queues[concertID][i].Connection = member.Connection
+				merged.Queues[concertID][i].DepartedAt = member.DepartedAt
+				continue
+			}
+			merged.Seq++
+			member.Seq = merged.Seq
+			merged.Queues[concertID] = append(merged.Queues[concertID], member)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+)
+
+// Datasets exported to the data warehouse
+const (
+	// WarehouseDatasetBookings holds the bookings, archived ones included
+	WarehouseDatasetBookings = "bookings"
+	// WarehouseDatasetConcerts holds the concerts with their ticket counts
+	WarehouseDatasetConcerts = "concerts"
+)
+
+// WarehouseWatermark is how far a dataset was exported: every row changed
+// before UpdatedAt, and the rows changed at UpdatedAt up to LastID. Rows are
+// exported in that order, so the next export resumes after it.
+type WarehouseWatermark struct {
+	Dataset   string    `json:"dataset" db:"dataset"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	LastID    int64     `json:"last_id" db:"last_id"`
+	// RowsExported counts the rows of every export of the dataset
+	RowsExported  int64     `json:"rows_exported" db:"rows_exported"`
+	LastObjectKey string    `json:"last_object_key" db:"last_object_key"`
+	ExportedAt    time.Time `json:"exported_at" db:"exported_at"`
+}
+
+// WarehouseBooking is the snapshot of a booking exported to the data
+// warehouse. It leaves out the attendee's personal data.
+type WarehouseBooking struct {
+	ID          int64         `db:"id"`
+	Reference   string        `db:"reference"`
+	ConcertID   int64         `db:"concert_id"`
+	OrderID     *int64        `db:"order_id"`
+	UserID      string        `db:"user_id"`
+	TicketCount int           `db:"ticket_count"`
+	UnitPrice   float64       `db:"unit_price"`
+	Status      BookingStatus `db:"status"`
+	BookingTime time.Time     `db:"booking_time"`
+	CreatedAt   time.Time     `db:"created_at"`
+	UpdatedAt   time.Time     `db:"updated_at"`
+}
+
+// WarehouseConcert is the snapshot of a concert exported to the data warehouse
+type WarehouseConcert struct {
+	ID               int64     `db:"id"`
+	Name             string    `db:"name"`
+	Artist           string    `db:"artist"`
+	Venue            string    `db:"venue"`
+	ConcertDate      time.Time `db:"concert_date"`
+	TotalTickets     int       `db:"total_tickets"`
+	AvailableTickets int       `db:"available_tickets"`
+	Price            float64   `db:"price"`
+	Currency         string    `db:"currency"`
+	Visibility       string    `db:"visibility"`
+	BookingStartTime time.Time `db:"booking_start_time"`
+	BookingEndTime   time.Time `db:"booking_end_time"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
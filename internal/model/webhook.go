@@ -0,0 +1,122 @@
+package model
+
+import "time"
+
+// WebhookEventTypes are the domain events organizers can receive on webhooks
+var WebhookEventTypes = []string{
+	EventConcertCreated,
+	EventConcertUpdated,
+	EventBookingConfirmed,
+	EventBookingCancelled,
+}
+
+// Webhook receives the domain events of the selected types about the
+// concerts of its organizer as signed POST requests
+type Webhook struct {
+	ID int64 `json:"id" db:"id"`
+	// OrganizerEmail is the organizer who registered the webhook. Only the
+	// events of the concerts with this organizer_email are delivered to it.
+	OrganizerEmail string `json:"organizer_email" db:"organizer_email"`
+	URL            string `json:"url" db:"url"`
+	// Secret signs the deliveries. It is only set in the response that
+	// created the webhook or changed the secret.
+	Secret      string     `json:"secret,omitempty" db:"secret"`
+	EventTypes  StringList `json:"event_types" db:"event_types"`
+	Description string     `json:"description" db:"description"`
+	// Active webhooks receive events. Events written while a webhook is
+	// inactive aren't delivered to it later.
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Receives checks if the webhook is sent events of a type
+func (w *Webhook) Receives(eventType string) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest creates or replaces a webhook. Without a secret, a new
+// webhook gets a generated one and an existing webhook keeps its own.
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	Description string   `json:"description"`
+	Secret      string   `json:"secret"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryDead deliveries failed every attempt and are only
+	// retried on request
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookDelivery is the delivery of one domain event to one webhook, with
+// the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	WebhookID      int64      `json:"webhook_id" db:"webhook_id"`
+	EventID        int64      `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Payload        RawJSON    `json:"payload" db:"payload"`
+	EventCreatedAt time.Time  `json:"event_created_at" db:"event_created_at"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	// ResponseStatus is the HTTP status of the last attempt, if it got a response
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	// URL and Secret are the webhook's, set on the deliveries due
+	URL    string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+}
+
+// WebhookPolicy is how the deliveries of webhooks are attempted
+type WebhookPolicy struct {
+	// BatchSize is the maximum number of events fanned out and of deliveries
+	// attempted at once
+	BatchSize   int
+	Concurrency int
+	MaxAttempts int
+	// BackoffBase is the wait after the first failed attempt, doubled after
+	// every further one up to BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long delivered and dead deliveries are kept
+	Retention time.Duration
+	// AllowHTTP accepts plain http URLs
+	AllowHTTP bool
+	// AllowPrivateNetworks accepts URLs of loopback, private and link-local
+	// addresses
+	AllowPrivateNetworks bool
+}
+
+// Backoff returns the wait before the next attempt of a delivery that failed
+// a number of times
+func (p WebhookPolicy) Backoff(attempts int) time.Duration {
+	return backoff(p.BackoffBase, p.BackoffMax, attempts)
+}
+
+// backoff doubles base for every attempt after the first, up to max
+func backoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
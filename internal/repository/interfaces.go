@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -15,11 +19,20 @@ type ConcertRepository interface {
 	// GetByID retrieves a concert by its ID
 	GetByID(ctx context.Context, id int64) (*model.Concert, error)
 
-	// List retrieves concerts with optional filtering
-	List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*model.Concert, error)
+	// List retrieves a page of concerts with optional filtering and sorting,
+	// ordered by concert date unless sorted otherwise
+	List(ctx context.Context, opts query.Options) ([]*model.Concert, error)
 
 	// Count returns the total number of concerts matching the filters
-	Count(ctx context.Context, filters map[string]interface{}) (int, error)
+	Count(ctx context.Context, filters query.Filters) (int, error)
+
+	// EstimateCount returns an estimate of the number of concerts matching the
+	// filters, for listings too large to count on every page
+	EstimateCount(ctx context.Context, filters query.Filters) (int, error)
+
+	// LastModified returns the latest Concert.LastModified at the given time
+	// across all concerts, listed or not, or the zero time if there are none
+	LastModified(ctx context.Context, at time.Time) (time.Time, error)
 
 	// Create inserts a new concert
 	Create(ctx context.Context, concert *model.Concert) (*model.Concert, error)
@@ -27,11 +40,40 @@ type ConcertRepository interface {
 	// Update updates an existing concert
 	Update(ctx context.Context, concert *model.Concert) error
 
+	// GetByIDs retrieves the concerts with the given IDs. Unknown IDs are skipped.
+	GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error)
+
+	// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+	GetPriceHistory(ctx context.Context, concertID int64) ([]*model.PriceSnapshot, error)
+
 	// GetForUpdate retrieves a concert for update with row locking
 	GetForUpdate(ctx context.Context, id int64) (*model.Concert, error)
 
+	// SwitchDueDoorPrices marks up to limit concerts whose door price took
+	// effect by now as switched and returns them. Each concert is returned once.
+	SwitchDueDoorPrices(ctx context.Context, now time.Time, limit int) ([]*model.Concert, error)
+
 	// UpdateTicketCount atomically updates the available ticket count using optimistic locking
 	UpdateTicketCount(ctx context.Context, id int64, version int, ticketCount int) error
+
+	// CreateInvites inserts invites of a concert in one statement and sets their IDs
+	CreateInvites(ctx context.Context, invites []*model.ConcertInvite) error
+
+	// GetInviteByTokenHash retrieves the invite of a concert with the given
+	// token hash. It returns ErrNotFound if the concert has no such invite.
+	GetInviteByTokenHash(ctx context.Context, concertID int64, tokenHash string) (*model.ConcertInvite, error)
+
+	// RedeemInvite records a booking made with an invite. It returns
+	// ErrInviteRedeemed if the invite is single-use and was redeemed before.
+	RedeemInvite(ctx context.Context, redemption *model.InviteRedemption) error
+
+	// WithdrawInviteRedemption removes the redemption of a booking that wasn't
+	// made, so single-use invites can be redeemed again
+	WithdrawInviteRedemption(ctx context.Context, redemption *model.InviteRedemption) error
+
+	// ListInviteRedemptions lists the invites of a concert with their
+	// redemptions and the bookings made, ordered by invite
+	ListInviteRedemptions(ctx context.Context, concertID int64) ([]*model.InviteExportRow, error)
 }
 
 // BookingRepository defines the interface for booking data access
@@ -41,8 +83,19 @@ type BookingRepository interface {
 	// GetByID retrieves a booking by its ID
 	GetByID(ctx context.Context, id int64) (*model.Booking, error)
 
+	// GetByReference retrieves a booking by its public reference
+	GetByReference(ctx context.Context, reference string) (*model.Booking, error)
+
+	// GetByIdempotencyKey retrieves the booking a user made with an
+	// idempotency key
+	GetByIdempotencyKey(ctx context.Context, userID, key string) (*model.Booking, error)
+
 	// GetByUserID retrieves bookings for a user
-	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Booking, error)
+	GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error)
+
+	// GetByUserIDWithConcerts retrieves bookings for a user like GetByUserID,
+	// with a summary of each booking's concert read in the same query
+	GetByUserIDWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error)
 
 	// Create inserts a new booking
 	Create(ctx context.Context, booking *model.Booking) (*model.Booking, error)
@@ -55,4 +108,551 @@ type BookingRepository interface {
 
 	// CreateWithTicketUpdate creates a booking and updates ticket count in a transaction
 	CreateWithTicketUpdate(ctx context.Context, booking *model.Booking, concertVersion int) error
+
+	// CreateWithConcertLock creates a booking and updates the ticket count in
+	// a transaction that holds the concert's booking lock, so bookings of a
+	// concert are made one at a time rather than failing on its version.
+	// check is called with the locked concert and may refuse the booking or
+	// set its fields, such as the unit price. It returns the concert as
+	// locked, before the booking.
+	CreateWithConcertLock(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error)
+
+	// CreateWithConditionalUpdate creates a booking and takes its tickets in
+	// a transaction whose one update of the concert only passes while the
+	// booking window is open and enough tickets are left, without reading
+	// the concert or checking its version first. check is called like for
+	// CreateWithConcertLock, with the concert as updated but before the
+	// booking, and its error rolls the booking back.
+	CreateWithConditionalUpdate(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error)
+
+	// CreateBatchWithTicketUpdate creates bookings for one concert and updates
+	// its ticket count in a single transaction
+	CreateBatchWithTicketUpdate(ctx context.Context, bookings []*model.Booking, concertVersion int) error
+
+	// CancelWithTicketRelease cancels a booking and returns its tickets to
+	// the concert in a transaction, and returns the concert's availability.
+	// It fails with ErrBookingAlreadyCancelled if the booking was cancelled,
+	// so concurrent cancellations return the tickets once.
+	CancelWithTicketRelease(ctx context.Context, bookingID int64) (*model.ConcertAvailability, error)
+
+	// GetAllByUserID retrieves every booking for a user without pagination
+	GetAllByUserID(ctx context.Context, userID string) ([]*model.Booking, error)
+
+	// AnonymizeUser replaces the user ID on all of a user's bookings and the
+	// records tied to them with a pseudonym, deleting those that can't keep
+	// one, and returns the number of records anonymized or deleted
+	AnonymizeUser(ctx context.Context, userID, pseudonym string) (int, error)
+
+	// GetAllByConcertID retrieves every booking for a concert ordered by booking time
+	GetAllByConcertID(ctx context.Context, concertID int64) ([]*model.Booking, error)
+}
+
+// BookingTokenRepository defines the interface for booking token data access
+type BookingTokenRepository interface {
+	// Create stores a new booking token by its hash
+	Create(ctx context.Context, tokenHash string, concertID int64, userID string, expiresAt time.Time) error
+
+	// Consume marks a token issued to the user for the concert that is unused and
+	// unexpired at now as used. It returns ErrInvalidBookingToken if no such token exists.
+	Consume(ctx context.Context, tokenHash string, concertID int64, userID string, now time.Time) error
+
+	// DeleteExpired removes tokens that expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+// IssueLimiter rate limits the issuance of booking tokens of every concert
+// across the replicas that share it
+type IssueLimiter interface {
+	// Allow takes a token from the bucket of a concert, which holds up to
+	// burst tokens and refills at rate tokens per second, and reports
+	// whether there was one
+	Allow(ctx context.Context, concertID int64, rate float64, burst int) (bool, error)
+}
+
+// SalesReportRepository defines the interface for final sales report data access
+type SalesReportRepository interface {
+	// ClaimDueConcerts marks concerts whose sale ended by now and that have no final
+	// report yet as finalizing and returns them. Claims older than staleAfter are
+	// reclaimed, so a crashed reporting run doesn't leave a concert unreported.
+	ClaimDueConcerts(ctx context.Context, now time.Time, limit int, staleAfter time.Duration) ([]*model.Concert, error)
+
+	// ReleaseClaim returns a claimed concert to the open reporting state
+	ReleaseClaim(ctx context.Context, concertID int64) error
+
+	// SaveFinal stores a final report and marks the concert as finalized
+	SaveFinal(ctx context.Context, report *model.SalesReport) error
+
+	// GetByConcertID retrieves the final report of a concert
+	GetByConcertID(ctx context.Context, concertID int64) (*model.SalesReport, error)
+
+	// ListUnsent retrieves reports with a recipient that haven't been emailed yet
+	ListUnsent(ctx context.Context, limit int) ([]*model.SalesReport, error)
+
+	// MarkEmailed records that a report was emailed
+	MarkEmailed(ctx context.Context, concertID int64, at time.Time) error
+}
+
+// AuditRepository defines the interface for audit log data access
+type AuditRepository interface {
+	// Create inserts a new audit log entry
+	Create(ctx context.Context, entry *model.AuditLog) error
+
+	// List returns a page of the entries matching the filter, latest first,
+	// and the total number of matching entries
+	List(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error)
+}
+
+// AdminRepository defines the data access of the operations of the internal
+// admin API, which bypass the checks of the public API
+type AdminRepository interface {
+	// ForceCancelBooking cancels a booking whoever made it and returns its
+	// tickets to the concert in one transaction. It returns the cancelled
+	// booking and the concert's available tickets afterwards.
+	ForceCancelBooking(ctx context.Context, reference string) (*model.Booking, *model.ConcertAvailability, error)
+
+	// AdjustInventory adds delta to the total and available tickets of a
+	// concert, failing with ErrInsufficientTickets if fewer than -delta are
+	// available
+	AdjustInventory(ctx context.Context, concertID int64, delta int) (*model.ConcertAvailability, error)
+
+	// CountInventory counts the tickets of a concert, or of every concert
+	// when concertID is 0, ordered by concert. It returns ErrNotFound if the
+	// concert doesn't exist.
+	CountInventory(ctx context.Context, concertID int64) ([]*model.InventoryCount, error)
+}
+
+// AccountingRepository defines the interface for tracking which bookings were
+// exported to accounting systems
+type AccountingRepository interface {
+	// ListPending retrieves the oldest sale and refund entries not yet synced to
+	// the provider. A booking's sale is always listed before its refund.
+	ListPending(ctx context.Context, provider string, limit int) ([]*model.AccountingRecord, error)
+
+	// MarkSynced records that an entry was pushed to the provider
+	MarkSynced(ctx context.Context, bookingID int64, entryType, provider, externalID string, at time.Time) error
+
+	// MarkFailed records a failed attempt to push an entry to the provider
+	MarkFailed(ctx context.Context, bookingID int64, entryType, provider, lastError string, at time.Time) error
+
+	// Reconcile counts the entries synced to the provider and those still pending
+	Reconcile(ctx context.Context, provider string) (*model.AccountingReconciliation, error)
+}
+
+// BookingAttemptRepository defines the interface for booking attempt data access
+type BookingAttemptRepository interface {
+	// CreateBatch inserts booking attempts in one statement
+	CreateBatch(ctx context.Context, attempts []*model.BookingAttempt) error
+
+	// Summarize aggregates the attempts since the given time, for one concert
+	// or for all concerts when concertID is zero
+	Summarize(ctx context.Context, concertID int64, since time.Time) (*model.BookingAttemptSummary, error)
+
+	// PurgeBefore deletes the attempts made before the given time
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AnalyticsRepository defines the aggregate queries behind the dashboards
+type AnalyticsRepository interface {
+	// OrganizerDashboard aggregates the bookings of the concerts with the
+	// organizer email: the sales booked between dayStart and dayEnd, the top
+	// concerts by tickets sold, the conversion of holds and the cancellations.
+	// The rates are left to the caller.
+	OrganizerDashboard(ctx context.Context, organizerEmail string, dayStart, dayEnd time.Time, top int) (*model.OrganizerDashboard, error)
+
+	// SalesTimeseries buckets the confirmed bookings of a concert made between
+	// from and to by the hour or day they were made in the location. Only the
+	// buckets with bookings are returned, in order, starting in the location.
+	SalesTimeseries(ctx context.Context, concertID int64, interval string, location *time.Location, from, to time.Time) ([]*model.SalesBucket, error)
+}
+
+// WarehouseRepository reads the rows changed since the last warehouse export
+// and tracks how far each dataset was exported
+type WarehouseRepository interface {
+	// GetWatermark returns the watermark of a dataset, or a zero watermark
+	// if it was never exported
+	GetWatermark(ctx context.Context, dataset string) (*model.WarehouseWatermark, error)
+
+	// SaveWatermark records how far a dataset was exported
+	SaveWatermark(ctx context.Context, watermark *model.WarehouseWatermark) error
+
+	// ListBookingChanges retrieves up to limit bookings, archived ones
+	// included, changed after the watermark and before before, in the order
+	// of their change and ID
+	ListBookingChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseBooking, error)
+
+	// ListConcertChanges retrieves up to limit concerts changed after the
+	// watermark and before before, in the order of their change and ID
+	ListConcertChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseConcert, error)
+}
+
+// JobRunRepository defines the interface for the history of background job runs
+type JobRunRepository interface {
+	worker.RunStore
+
+	// PurgeBefore deletes the runs that started before the given time
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// InventoryReleaseRepository defines the interface for scheduled inventory release data access
+type InventoryReleaseRepository interface {
+	// Create schedules a release and holds its tickets back from the concert's
+	// available tickets. It fails with ErrInsufficientTickets when fewer are available.
+	Create(ctx context.Context, release *model.InventoryRelease) (*model.InventoryRelease, error)
+
+	// ListByConcertID retrieves the releases of a concert in release order
+	ListByConcertID(ctx context.Context, concertID int64) ([]*model.InventoryRelease, error)
+
+	// Cancel deletes a pending release and returns its tickets to the concert's
+	// available tickets
+	Cancel(ctx context.Context, concertID, id int64) error
+
+	// ReleaseDue executes up to limit pending releases due by now, adding their
+	// tickets to the available tickets. Each release is executed once.
+	ReleaseDue(ctx context.Context, now time.Time, limit int) ([]*model.ReleasedInventory, error)
+}
+
+// SampleRepository defines the read-only data access used to draw anonymized samples
+type SampleRepository interface {
+	// ListConcerts retrieves all concerts except private ones
+	ListConcerts(ctx context.Context) ([]*model.Concert, error)
+
+	// ListBookings retrieves the bookings of the given concerts, without
+	// their references or attendee details
+	ListBookings(ctx context.Context, concertIDs []int64) ([]*model.Booking, error)
+}
+
+// ArchiveRepository defines the data access of the bookings archive
+type ArchiveRepository interface {
+	// ArchiveBookings moves up to limit bookings of concerts that took place
+	// before cutoff from the bookings table into the archive and returns how
+	// many it moved. Archived bookings are still read through all_bookings.
+	ArchiveBookings(ctx context.Context, cutoff time.Time, limit int) (int, error)
+}
+
+// OutboxRepository defines the data access of the outbox of domain events.
+// The events are written by the repositories of the changes they describe.
+type OutboxRepository interface {
+	// ListPending retrieves the oldest events not yet published, in the order
+	// they were written
+	ListPending(ctx context.Context, limit int) ([]*model.OutboxEvent, error)
+
+	// MarkPublished records that an event was published
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+
+	// MarkFailed records a failed attempt to publish an event
+	MarkFailed(ctx context.Context, id int64, lastError string, at time.Time) error
+
+	// PurgeBefore deletes the events written before a time, only those
+	// published if publishedOnly, and returns how many it deleted
+	PurgeBefore(ctx context.Context, before time.Time, publishedOnly bool) (int, error)
+}
+
+// WebhookRepository defines the data access of webhooks and their deliveries
+type WebhookRepository interface {
+	// Create inserts a webhook and sets its ID and timestamps
+	Create(ctx context.Context, webhook *model.Webhook) error
+
+	// GetByID retrieves a webhook without its secret
+	GetByID(ctx context.Context, id int64) (*model.Webhook, error)
+
+	// List retrieves the webhooks of an organizer without their secret, in
+	// ID order
+	List(ctx context.Context, organizerEmail string) ([]*model.Webhook, error)
+
+	// Update replaces a webhook, and its secret unless the secret is empty
+	Update(ctx context.Context, webhook *model.Webhook) error
+
+	// Delete deletes a webhook with its deliveries
+	Delete(ctx context.Context, id int64) error
+
+	// Enqueue creates the deliveries of the oldest outbox events not yet
+	// dispatched to the active webhooks receiving them, marks the events
+	// dispatched, and returns the numbers of events dispatched and
+	// deliveries created. Webhooks only receive the events of the concerts
+	// of their organizer written after they were created.
+	Enqueue(ctx context.Context, limit int, at time.Time) (int, int, error)
+
+	// ListDue retrieves the pending deliveries of active webhooks due at a
+	// time, oldest first, with the URL and secret of their webhook
+	ListDue(ctx context.Context, at time.Time, limit int) ([]*model.WebhookDelivery, error)
+
+	// RecordAttempt saves the status, attempts, schedule and outcome of a delivery
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error
+
+	// ListDeliveries retrieves the latest deliveries of a webhook, newest
+	// first, only those of a status unless it is empty
+	ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error)
+
+	// Redeliver schedules a delivery of a webhook that isn't delivered yet for
+	// another attempt at a time
+	Redeliver(ctx context.Context, webhookID, deliveryID int64, at time.Time) (*model.WebhookDelivery, error)
+
+	// PurgeDeliveries deletes the delivered and dead deliveries created
+	// before a time and returns how many it deleted
+	PurgeDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+// NotificationRepository defines the data access of the notifications sent
+// to the attendees and users of bookings
+type NotificationRepository interface {
+	// ListEvents retrieves the oldest outbox events not yet turned into
+	// notifications, in ID order
+	ListEvents(ctx context.Context, limit int) ([]*model.OutboxEvent, error)
+
+	// MarkEventsNotified records that events were turned into notifications
+	MarkEventsNotified(ctx context.Context, ids []int64, at time.Time) error
+
+	// Enqueue creates pending notifications and returns how many it created.
+	// Notifications of a channel and template that exist for the same
+	// booking and event are skipped.
+	Enqueue(ctx context.Context, notifications []*model.Notification) (int, error)
+
+	// EnqueueReminders creates the pending email reminders of a template for
+	// the confirmed bookings with an attendee email of the concerts taking
+	// place between two times, due at a time, and returns how many it
+	// created. Bookings of users who turned email or the template off are
+	// skipped, and a booking is reminded once.
+	EnqueueReminders(ctx context.Context, template string, from, to, at time.Time) (int, error)
+
+	// ListDue retrieves the pending notifications due at a time, oldest first
+	ListDue(ctx context.Context, at time.Time, limit int) ([]*model.Notification, error)
+
+	// RecordAttempt saves the status, attempts, schedule and outcome of a
+	// notification
+	RecordAttempt(ctx context.Context, notification *model.Notification) error
+
+	// Purge deletes the sent and dead notifications created before a time
+	// and returns how many it deleted
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// NotificationPreferenceRepository defines the data access of the channels
+// and notifications users want
+type NotificationPreferenceRepository interface {
+	// Get retrieves the preferences of a user, ErrNotFound if they set none
+	Get(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+
+	// Set creates or replaces the preferences of a user
+	Set(ctx context.Context, preferences *model.NotificationPreferences) error
+
+	// Delete removes the preferences of a user, if any
+	Delete(ctx context.Context, userID string) error
+}
+
+// PaymentRepository defines the interface for the payments of pending
+// bookings. Settling a payment settles its booking in the same transaction.
+type PaymentRepository interface {
+	// Create records the payment of a pending booking
+	Create(ctx context.Context, payment *model.Payment) error
+
+	// GetByBookingID retrieves the payment of a booking
+	GetByBookingID(ctx context.Context, bookingID int64) (*model.Payment, error)
+
+	// GetByProviderReference retrieves a payment by its ID at the provider
+	GetByProviderReference(ctx context.Context, provider, providerReference string) (*model.Payment, error)
+
+	// Confirm records that the pending payment of a booking was authorized
+	// and confirms the booking, writing its booking.confirmed event. It
+	// returns nil if the booking was confirmed already, and
+	// ErrBookingAlreadyCancelled, with the authorization recorded anyway, if
+	// the booking was cancelled.
+	Confirm(ctx context.Context, bookingID int64) (*model.Booking, error)
+
+	// Release settles the pending payment of a booking, if it has one, with
+	// a failed or canceled status, cancels the booking if it is still
+	// pending and returns its tickets to the concert. It returns nil if
+	// there was no pending booking to release.
+	Release(ctx context.Context, bookingID int64, status string) (*model.Booking, *model.ConcertAvailability, error)
+
+	// UpdateStatus moves the payment of a booking from one status to
+	// another and reports whether it had the first
+	UpdateStatus(ctx context.Context, bookingID int64, from, to string) (bool, error)
+
+	// ListExpired retrieves the pending and authorized payments whose hold
+	// expired by a time, the oldest first
+	ListExpired(ctx context.Context, at time.Time, limit int) ([]*model.Payment, error)
+
+	// ListForReconciliation retrieves the payments created after a time
+	// that didn't change since another, with their booking's status, the
+	// least recently reconciled first
+	ListForReconciliation(ctx context.Context, createdAfter, changedBefore time.Time, limit int) ([]*model.PaymentCheck, error)
+
+	// MarkReconciled records when the payment of a booking was reconciled
+	// and the mismatch found, or "" if there was none
+	MarkReconciled(ctx context.Context, bookingID int64, mismatch string, at time.Time) error
+
+	// CountMismatches counts the flagged payments by kind of mismatch
+	CountMismatches(ctx context.Context) (map[string]int, error)
+}
+
+// WaitingRoomStore keeps the queues of the shared waiting room, which every
+// replica reads and changes. Changes to a queue are atomic.
+type WaitingRoomStore interface {
+	// Enqueue adds a user to the back of a concert's queue, or marks a user
+	// who is already queued as connected again, and returns their 1-based
+	// position. The connection identifies the user's current connection.
+	Enqueue(ctx context.Context, concertID int64, userID, inviteToken, connection string) (int, error)
+
+	// Depart marks a queued user as disconnected since at, unless they
+	// connected again since the connection closed. They keep their place
+	// until RemoveDeparted removes them.
+	Depart(ctx context.Context, concertID int64, userID, connection string, at time.Time) error
+
+	// Remove takes a user out of a queue. With an outcome, it is kept for
+	// TakeOutcome until ttl passes, e.g. the token of an admitted user who
+	// is between connections.
+	Remove(ctx context.Context, concertID int64, userID string, outcome *model.WaitingRoomUpdate, ttl time.Duration) error
+
+	// TakeOutcome returns and deletes the outcome kept for a user, or nil
+	TakeOutcome(ctx context.Context, concertID int64, userID string) (*model.WaitingRoomUpdate, error)
+
+	// RemoveDeparted removes the users who departed before a time and
+	// returns how many there were
+	RemoveDeparted(ctx context.Context, before time.Time) (int, error)
+
+	// Positions returns the 1-based positions of users in a concert's queue,
+	// 0 for users who aren't queued
+	Positions(ctx context.Context, concertID int64, userIDs []string) ([]int, error)
+
+	// Front returns up to n members from the front of a concert's queue
+	Front(ctx context.Context, concertID int64, n int) ([]model.WaitingRoomMember, error)
+
+	// Concerts returns the IDs of the concerts with a queue
+	Concerts(ctx context.Context) ([]int64, error)
+
+	// Lead makes an instance the one admitting users for ttl, or extends its
+	// term, unless another instance holds it
+	Lead(ctx context.Context, instance string, ttl time.Duration) (bool, error)
+
+	// Snapshot returns the state of every queue
+	Snapshot(ctx context.Context) (*model.WaitingRoomState, error)
+
+	// Restore replaces the state of every queue, provided it is still at
+	// version from; otherwise it fails with ErrUpdateFailed
+	Restore(ctx context.Context, state *model.WaitingRoomState, from int64) error
+}
+
+// ConcertCache keeps copies of concerts for a short while, so frequent reads
+// of the same concert don't each reach the database
+type ConcertCache interface {
+	// Get returns a cached concert, or ErrNotFound if it isn't cached
+	Get(ctx context.Context, id int64) (*model.Concert, error)
+
+	// Set caches a concert until its time to live passes
+	Set(ctx context.Context, concert *model.Concert) error
+
+	// Invalidate drops concerts from the cache
+	Invalidate(ctx context.Context, ids ...int64) error
+
+	// SetTTL changes the time to live of concerts cached from now on. With a
+	// time to live of zero nothing is cached or read from the cache, but
+	// concerts are still invalidated, so entries cached before can't go stale.
+	SetTTL(ttl time.Duration)
+}
+
+// WaitingRoomSnapshotRepository keeps the latest snapshot of the shared
+// waiting room
+type WaitingRoomSnapshotRepository interface {
+	// Save stores a snapshot unless the stored one has the same format and
+	// the same or a higher version, and reports whether it was stored
+	Save(ctx context.Context, state *model.WaitingRoomState) (bool, error)
+
+	// Latest returns the stored snapshot, or ErrNotFound if there is none
+	Latest(ctx context.Context) (*model.WaitingRoomState, error)
+}
+
+// RuntimeSettingsRepository stores the runtime settings every replica shares
+type RuntimeSettingsRepository interface {
+	// Get returns the stored settings, or ErrNotFound if they were never
+	// changed
+	Get(ctx context.Context) (*model.RuntimeSettings, error)
+
+	// Save stores settings if the stored ones still have the version they
+	// are based on, settings.Version, or there are none for version 0, and
+	// sets their new version and update time. It fails with
+	// ErrOptimisticLockFailed otherwise.
+	Save(ctx context.Context, settings *model.RuntimeSettings) error
+
+	// Watch returns a channel that receives a value whenever the stored
+	// settings may have changed, on any replica, until ctx is done
+	Watch(ctx context.Context) (<-chan struct{}, error)
+}
+
+// ErrCounterMissing is returned by InventoryCounter.Reserve for concerts
+// without a counter, which must be seeded first
+var ErrCounterMissing = errors.New("inventory counter missing")
+
+// InventoryCounter keeps counters of the tickets available for concerts,
+// which bookings take from atomically. Each counter holds the version of the
+// concert it was computed at.
+type InventoryCounter interface {
+	// Reserve takes count tickets from the counter of a concert and returns
+	// how many are left. A counter of another version than the concert's is
+	// first reset to the concert's available tickets. It fails with
+	// ErrInsufficientTickets if fewer than count are left, and with
+	// ErrCounterMissing if the concert has no counter.
+	Reserve(ctx context.Context, concertID int64, count, version, available int) (int, error)
+
+	// Release returns count tickets to the counter of a concert unless it
+	// was reset since they were reserved at the given version
+	Release(ctx context.Context, concertID int64, count, version int) error
+
+	// Seed sets the counter of a concert
+	Seed(ctx context.Context, concertID int64, version, available int) error
+}
+
+// InventoryRepository books tickets reserved with an InventoryCounter and
+// later subtracts them from their concerts. Until then the bookings are
+// pending, and the concerts' available tickets count them.
+type InventoryRepository interface {
+	// Reserve reserves the tickets of a booking with the counter and creates
+	// the booking, pending, and returns how many tickets are left. Changes of
+	// the concert's tickets wait until it's done.
+	Reserve(ctx context.Context, booking *model.Booking, counter InventoryCounter) (int, error)
+
+	// Seed sets the counter of a concert to its available tickets less the
+	// tickets of its pending bookings
+	Seed(ctx context.Context, concertID int64, counter InventoryCounter) error
+
+	// Flush subtracts the tickets of pending bookings from their concerts
+	// and returns how many bookings it settled
+	Flush(ctx context.Context) (int, error)
+}
+
+// OrderRepository defines the interface for order data access. Orders are
+// returned with their bookings.
+type OrderRepository interface {
+	// GetByReference retrieves an order by its public reference
+	GetByReference(ctx context.Context, reference string) (*model.Order, error)
+
+	// GetByUserID retrieves the orders of a user, newest first
+	GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Order, error)
+
+	// Cancel cancels an order and its confirmed bookings and returns their
+	// tickets to the concerts in a transaction, and returns the availability
+	// of each concert. It fails with ErrOrderAlreadyCancelled if the order was
+	// cancelled, so concurrent cancellations return the tickets once.
+	Cancel(ctx context.Context, orderID int64) ([]*model.ConcertAvailability, error)
+}
+
+// CartRepository stores the carts of users and books them at checkout
+type CartRepository interface {
+	// ListItems returns the items of a user's cart in the order they were added
+	ListItems(ctx context.Context, userID string) ([]*model.CartItem, error)
+
+	// PutItem adds an item to a cart, replacing the item of the same concert
+	PutItem(ctx context.Context, item *model.CartItem) error
+
+	// RemoveItem removes the item of a concert from a cart, or fails with
+	// ErrNotFound if there is none
+	RemoveItem(ctx context.Context, userID string, concertID int64) error
+
+	// Checkout books the bookings of an order in one transaction, taking
+	// their tickets from their concerts and removing their items from the
+	// user's cart, and returns one error per booking, nil for those booked.
+	// Unless bestEffort, nothing is booked if any booking fails. The order is
+	// created, with the bookings made, if any is booked. It fails with
+	// ErrOptimisticLockFailed if any concert changed since the version in
+	// versions it was checked at.
+	Checkout(ctx context.Context, order *model.Order, bookings []*model.Booking, versions map[int64]int, bestEffort bool) ([]error, error)
 }
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/query"
+)
+
+type auditRepository struct {
+	store *Store
+}
+
+// NewAuditRepository creates an AuditRepository on the store
+func NewAuditRepository(store *Store) repository.AuditRepository {
+	return &auditRepository{
+		store: store,
+	}
+}
+
+// Create inserts a new audit log entry
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.lastAuditID++
+	entry.ID = r.store.lastAuditID
+	entry.CreatedAt = now()
+
+	stored := *entry
+	stored.Details = slices.Clone(entry.Details)
+	if len(stored.Details) == 0 {
+		stored.Details = []byte("{}")
+	}
+	r.store.audit = append(r.store.audit, &stored)
+
+	return nil
+}
+
+// List returns a page of the entries matching the filter, latest first, and
+// the total number of matching entries
+func (r *auditRepository) List(ctx context.Context, filter model.AuditFilter, p query.Page) ([]*model.AuditLog, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Entries are stored in the order they were created, with increasing IDs
+	var matching []*model.AuditLog
+	for i := len(r.store.audit) - 1; i >= 0; i-- {
+		entry := r.store.audit[i]
+		if matches(filter.Actor, entry.Actor) && matches(filter.Action, entry.Action) &&
+			matches(filter.ResourceType, entry.ResourceType) && matches(filter.ResourceID, entry.ResourceID) {
+			matching = append(matching, entry)
+		}
+	}
+
+	entries := []*model.AuditLog{}
+	for _, entry := range page(matching, p) {
+		e := *entry
+		e.Details = slices.Clone(entry.Details)
+		entries = append(entries, &e)
+	}
+
+	return entries, len(matching), nil
+}
+
+// matches reports whether a value matches a filter field, which matches
+// every value when it is empty
+func matches(filter, value string) bool {
+	return filter == "" || filter == value
+}
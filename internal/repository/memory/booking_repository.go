@@ -0,0 +1,450 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type bookingRepository struct {
+	store *Store
+}
+
+// GetDB returns nil: the memory repositories have no database
+func (r *bookingRepository) GetDB() *sqlx.DB {
+	return nil
+}
+
+// NewBookingRepository creates a BookingRepository on the store. Attendee
+// details are kept as they are, since they never leave the process.
+func NewBookingRepository(store *Store) repository.BookingRepository {
+	return &bookingRepository{
+		store: store,
+	}
+}
+
+// takeTickets subtracts tickets from a concert, which must already have been
+// checked to have them. The store must be locked.
+func (s *Store) takeTickets(concert *model.Concert, tickets int) {
+	concert.AvailableTickets -= tickets
+	concert.Version++
+	concert.UpdatedAt = now()
+}
+
+// bookingByReference returns the stored booking with a reference, or nil.
+// The store must be locked.
+func (s *Store) bookingByReference(reference string) *model.Booking {
+	for _, booking := range s.bookings {
+		if booking.Reference == reference {
+			return booking
+		}
+	}
+	return nil
+}
+
+// checkIdempotencyKeys fails with ErrIdempotencyKeyInUse if a user already
+// made a booking with the idempotency key of one of the bookings, so a
+// transaction can check before it changes anything. The store must be locked.
+func (s *Store) checkIdempotencyKeys(bookings ...*model.Booking) error {
+	seen := make(map[idempotencyKey]bool)
+	for _, booking := range bookings {
+		if booking.IdempotencyKey == "" {
+			continue
+		}
+
+		key := idempotencyKey{userID: booking.UserID, key: booking.IdempotencyKey}
+		if _, ok := s.idempotency[key]; ok || seen[key] {
+			return pkgErr.ErrIdempotencyKeyInUse
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// insert stores a booking whose idempotency key was checked and sets its ID
+// and times. Like the database repositories, the booking time is when the
+// booking is stored. The store must be locked.
+func (s *Store) insert(booking *model.Booking) {
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	s.lastBookingID++
+	createdAt := now()
+	booking.ID = s.lastBookingID
+	booking.BookingTime = createdAt
+	booking.CreatedAt = createdAt
+	booking.UpdatedAt = createdAt
+
+	stored := *booking
+	stored.IdempotencyKey = ""
+	s.bookings[booking.ID] = &stored
+	if booking.IdempotencyKey != "" {
+		s.idempotency[idempotencyKey{userID: booking.UserID, key: booking.IdempotencyKey}] = booking.ID
+	}
+}
+
+// get returns a copy of the stored booking with an ID
+func (r *bookingRepository) get(id int64) (*model.Booking, error) {
+	booking, ok := r.store.bookings[id]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	b := *booking
+	return &b, nil
+}
+
+// list returns copies of the stored bookings selected by keep in the order of compare
+func (r *bookingRepository) list(keep func(booking *model.Booking) bool, compare func(a, b *model.Booking) int) []*model.Booking {
+	var bookings []*model.Booking
+	for _, booking := range r.store.bookings {
+		if keep(booking) {
+			b := *booking
+			bookings = append(bookings, &b)
+		}
+	}
+	slices.SortFunc(bookings, compare)
+	return bookings
+}
+
+// latestFirst orders bookings by booking time, the latest first
+func latestFirst(a, b *model.Booking) int {
+	if c := b.BookingTime.Compare(a.BookingTime); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.ID, a.ID)
+}
+
+// GetByID retrieves a booking by its ID
+func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.get(id)
+}
+
+// GetByReference retrieves a booking by its public reference
+func (r *bookingRepository) GetByReference(ctx context.Context, reference string) (*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	booking := r.store.bookingByReference(reference)
+	if booking == nil {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	b := *booking
+	return &b, nil
+}
+
+// GetByIdempotencyKey retrieves the booking a user made with an idempotency key
+func (r *bookingRepository) GetByIdempotencyKey(ctx context.Context, userID, key string) (*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	id, ok := r.store.idempotency[idempotencyKey{userID: userID, key: key}]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	booking, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+	booking.IdempotencyKey = key
+
+	return booking, nil
+}
+
+// GetByUserID retrieves bookings for a user
+func (r *bookingRepository) GetByUserID(ctx context.Context, userID string, p query.Page) ([]*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	bookings := r.list(func(booking *model.Booking) bool {
+		return booking.UserID == userID
+	}, latestFirst)
+
+	return page(bookings, p), nil
+}
+
+// GetByUserIDWithConcerts retrieves bookings for a user with their concerts
+func (r *bookingRepository) GetByUserIDWithConcerts(ctx context.Context, userID string, p query.Page) ([]*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	bookings := page(r.list(func(booking *model.Booking) bool {
+		return booking.UserID == userID
+	}, latestFirst), p)
+
+	for _, booking := range bookings {
+		if concert, ok := r.store.concerts[booking.ConcertID]; ok {
+			booking.Concert = model.SummarizeConcert(concert)
+		}
+	}
+	return bookings, nil
+}
+
+// GetAllByConcertID retrieves every booking for a concert ordered by booking time
+func (r *bookingRepository) GetAllByConcertID(ctx context.Context, concertID int64) ([]*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.list(func(booking *model.Booking) bool {
+		return booking.ConcertID == concertID
+	}, func(a, b *model.Booking) int {
+		return latestFirst(b, a)
+	}), nil
+}
+
+// GetAllByUserID retrieves every booking for a user without pagination
+func (r *bookingRepository) GetAllByUserID(ctx context.Context, userID string) ([]*model.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.list(func(booking *model.Booking) bool {
+		return booking.UserID == userID
+	}, latestFirst), nil
+}
+
+// Create inserts a new booking
+func (r *bookingRepository) Create(ctx context.Context, booking *model.Booking) (*model.Booking, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.store.checkIdempotencyKeys(booking); err != nil {
+		return nil, err
+	}
+	r.store.insert(booking)
+
+	return booking, nil
+}
+
+// Update updates an existing booking
+func (r *bookingRepository) Update(ctx context.Context, booking *model.Booking) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.bookings[booking.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.ConcertID = booking.ConcertID
+	stored.UserID = booking.UserID
+	stored.TicketCount = booking.TicketCount
+	stored.Status = booking.Status
+	stored.UpdatedAt = now()
+
+	return nil
+}
+
+// CancelWithTicketRelease cancels a booking and returns its tickets to the concert
+func (r *bookingRepository) CancelWithTicketRelease(ctx context.Context, bookingID int64) (*model.ConcertAvailability, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	booking, ok := r.store.bookings[bookingID]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	if booking.Status == model.BookingStatusCancelled {
+		return nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+
+	concert, ok := r.store.concerts[booking.ConcertID]
+	if !ok {
+		return nil, fmt.Errorf("failed to release tickets: %w", pkgErr.ErrNotFound)
+	}
+
+	// Bumping the version makes concurrent concert edits based on the old
+	// ticket count fail their optimistic lock
+	updatedAt := now()
+	booking.Status = model.BookingStatusCancelled
+	booking.UpdatedAt = updatedAt
+	concert.AvailableTickets += booking.TicketCount
+	concert.Version++
+	concert.UpdatedAt = updatedAt
+
+	return &model.ConcertAvailability{
+		ConcertID:        concert.ID,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		UpdatedAt:        concert.UpdatedAt,
+	}, nil
+}
+
+// CountByUserAndConcert counts bookings by a user for a specific concert
+func (r *bookingRepository) CountByUserAndConcert(ctx context.Context, userID string, concertID int64) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	count := 0
+	for _, booking := range r.store.bookings {
+		if booking.UserID == userID && booking.ConcertID == concertID && booking.Status == model.BookingStatusConfirmed {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// bookable returns the stored concert, failing unless its booking window is
+// open and it has the tickets. The store must be locked.
+func (r *bookingRepository) bookable(concertID int64, tickets int) (*model.Concert, error) {
+	concert, ok := r.store.concerts[concertID]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	if !concert.IsBookingOpen() {
+		return nil, pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < tickets {
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+
+	return concert, nil
+}
+
+// CreateWithTicketUpdate creates a booking and updates ticket count in a
+// transaction. It fails with ErrOptimisticLockFailed unless the concert is
+// still at concertVersion.
+func (r *bookingRepository) CreateWithTicketUpdate(ctx context.Context, booking *model.Booking, concertVersion int) error {
+	return r.CreateBatchWithTicketUpdate(ctx, []*model.Booking{booking}, concertVersion)
+}
+
+// CreateWithConcertLock creates a booking and updates the ticket count while
+// holding the store's lock, which makes the bookings of every concert one
+// at a time without retries. check must not use the store.
+func (r *bookingRepository) CreateWithConcertLock(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	concert, err := r.bookable(booking.ConcertID, booking.TicketCount)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := copyConcert(concert)
+	if err := check(locked); err != nil {
+		return nil, err
+	}
+
+	if err := r.store.checkIdempotencyKeys(booking); err != nil {
+		return nil, err
+	}
+
+	r.store.takeTickets(concert, booking.TicketCount)
+	r.store.insert(booking)
+
+	return locked, nil
+}
+
+// CreateWithConditionalUpdate creates a booking, taking its tickets only
+// while the concert can be booked. Under the store's lock that is what
+// CreateWithConcertLock does.
+func (r *bookingRepository) CreateWithConditionalUpdate(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	return r.CreateWithConcertLock(ctx, booking, check)
+}
+
+// CreateBatchWithTicketUpdate creates bookings for one concert and updates
+// its ticket count in a single transaction
+func (r *bookingRepository) CreateBatchWithTicketUpdate(ctx context.Context, bookings []*model.Booking, concertVersion int) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	concertID := bookings[0].ConcertID
+	tickets := 0
+	for _, booking := range bookings {
+		if booking.ConcertID != concertID {
+			return fmt.Errorf("batch bookings must be for one concert")
+		}
+		tickets += booking.TicketCount
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	concert, ok := r.store.concerts[concertID]
+	if !ok {
+		return pkgErr.ErrNotFound
+	}
+
+	if concert.Version != concertVersion {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if _, err := r.bookable(concertID, tickets); err != nil {
+		return err
+	}
+
+	// Everything is checked before the first change, so a failure leaves
+	// the store as it was
+	if err := r.store.checkIdempotencyKeys(bookings...); err != nil {
+		return err
+	}
+
+	r.store.takeTickets(concert, tickets)
+	for _, booking := range bookings {
+		r.store.insert(booking)
+	}
+
+	return nil
+}
+
+// AnonymizeUser replaces the user ID on all of a user's bookings with a pseudonym
+// and clears the attendee details. Ticket counts and statuses are kept so
+// aggregate sales figures stay correct. The user's booking tokens are deleted.
+func (r *bookingRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	anonymized := 0
+	updatedAt := now()
+	for _, booking := range r.store.bookings {
+		if booking.UserID != userID {
+			continue
+		}
+
+		booking.UserID = pseudonym
+		booking.AttendeeName = ""
+		booking.AttendeeEmail = ""
+		booking.UpdatedAt = updatedAt
+		anonymized++
+	}
+
+	// Idempotency keys stay with their bookings
+	var keys []idempotencyKey
+	for key := range r.store.idempotency {
+		if key.userID == userID {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		r.store.idempotency[idempotencyKey{userID: pseudonym, key: key.key}] = r.store.idempotency[key]
+		delete(r.store.idempotency, key)
+	}
+
+	for hash, token := range r.store.tokens {
+		if token.userID == userID {
+			delete(r.store.tokens, hash)
+			anonymized++
+		}
+	}
+
+	return anonymized, nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+type bookingTokenRepository struct {
+	store *Store
+}
+
+// NewBookingTokenRepository creates a BookingTokenRepository on the store
+func NewBookingTokenRepository(store *Store) repository.BookingTokenRepository {
+	return &bookingTokenRepository{
+		store: store,
+	}
+}
+
+// Create stores a new booking token by its hash
+func (r *bookingTokenRepository) Create(ctx context.Context, tokenHash string, concertID int64, userID string, expiresAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.tokens[tokenHash]; ok {
+		return fmt.Errorf("failed to create booking token: token hash already exists")
+	}
+
+	r.store.tokens[tokenHash] = &bookingToken{
+		concertID: concertID,
+		userID:    userID,
+		expiresAt: expiresAt.UTC(),
+	}
+
+	return nil
+}
+
+// Consume marks an unused, unexpired token issued to the user for the concert
+// as used. It is checked and marked under the store's lock, so a token can
+// only be used once.
+func (r *bookingTokenRepository) Consume(ctx context.Context, tokenHash string, concertID int64, userID string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.tokens[tokenHash]
+	if !ok || token.concertID != concertID || token.userID != userID ||
+		token.usedAt != nil || !token.expiresAt.After(now) {
+		return pkgErr.ErrInvalidBookingToken
+	}
+
+	usedAt := now.UTC()
+	token.usedAt = &usedAt
+
+	return nil
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *bookingTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deleted := 0
+	for hash, token := range r.store.tokens {
+		if token.expiresAt.Before(before) {
+			delete(r.store.tokens, hash)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
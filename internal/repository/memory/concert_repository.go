@@ -0,0 +1,478 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/normalize"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type concertRepository struct {
+	store *Store
+}
+
+// GetDB returns nil: the memory repositories have no database
+func (r *concertRepository) GetDB() *sqlx.DB {
+	return nil
+}
+
+// NewConcertRepository creates a ConcertRepository on the store
+func NewConcertRepository(store *Store) repository.ConcertRepository {
+	return &concertRepository{
+		store: store,
+	}
+}
+
+// GetByID retrieves a concert by its ID
+func (r *concertRepository) GetByID(ctx context.Context, id int64) (*model.Concert, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	concert, ok := r.store.concerts[id]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	return copyConcert(concert), nil
+}
+
+// concertSortKeys compares concerts by the sortable concert fields. Names are
+// compared by their search keys so that case and accents don't affect the order.
+var concertSortKeys = map[string]func(a, b *model.Concert) int{
+	"concert_date":      func(a, b *model.Concert) int { return a.ConcertDate.Compare(b.ConcertDate) },
+	"name":              func(a, b *model.Concert) int { return strings.Compare(a.SearchName, b.SearchName) },
+	"artist":            func(a, b *model.Concert) int { return strings.Compare(a.SearchArtist, b.SearchArtist) },
+	"price":             func(a, b *model.Concert) int { return cmp.Compare(a.Price, b.Price) },
+	"available_tickets": func(a, b *model.Concert) int { return cmp.Compare(a.AvailableTickets, b.AvailableTickets) },
+	"created_at":        func(a, b *model.Concert) int { return a.CreatedAt.Compare(b.CreatedAt) },
+}
+
+// List retrieves a page of concerts with optional filtering and sorting
+func (r *concertRepository) List(ctx context.Context, opts query.Options) ([]*model.Concert, error) {
+	sorts := opts.Sort
+	if len(sorts) == 0 {
+		sorts = []query.Sort{{Field: "concert_date"}}
+	}
+	for _, s := range sorts {
+		if _, ok := concertSortKeys[s.Field]; !ok {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("cannot sort by %q", s.Field))
+		}
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	concerts := r.matching(opts.Filters)
+	// The ID breaks ties so that pages are stable
+	slices.SortFunc(concerts, func(a, b *model.Concert) int {
+		for _, s := range sorts {
+			if c := concertSortKeys[s.Field](a, b); c != 0 {
+				if s.Desc {
+					return -c
+				}
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	concerts = page(concerts, opts.Page)
+	for i, concert := range concerts {
+		concerts[i] = copyConcert(concert)
+	}
+
+	return concerts, nil
+}
+
+// Count returns the total number of concerts matching the filters
+func (r *concertRepository) Count(ctx context.Context, filters query.Filters) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return len(r.matching(filters)), nil
+}
+
+// EstimateCount counts the concerts matching the filters, which costs no
+// more than estimating them
+func (r *concertRepository) EstimateCount(ctx context.Context, filters query.Filters) (int, error) {
+	return r.Count(ctx, filters)
+}
+
+// matching returns the stored concerts matching the filters of a listing.
+// Listings only ever contain public concerts; unlisted and private ones are
+// reachable by ID only. The store must be locked.
+func (r *concertRepository) matching(filters query.Filters) []*model.Concert {
+	var concerts []*model.Concert
+	for _, concert := range r.store.concerts {
+		if concert.Visibility == model.VisibilityPublic && matchesFilters(concert, filters) {
+			concerts = append(concerts, concert)
+		}
+	}
+	return concerts
+}
+
+// matchesFilters reports whether a concert matches the filters of a listing
+func matchesFilters(concert *model.Concert, filters query.Filters) bool {
+	for key, value := range filters {
+		switch key {
+		case "artist":
+			if !strings.Contains(concert.SearchArtist, normalize.Text(fmt.Sprint(value))) {
+				return false
+			}
+		case "venue":
+			if !strings.Contains(concert.SearchVenue, normalize.Text(fmt.Sprint(value))) {
+				return false
+			}
+		case "name":
+			if !strings.Contains(concert.SearchName, normalize.Text(fmt.Sprint(value))) {
+				return false
+			}
+		case "date_from":
+			if from, ok := value.(time.Time); ok && concert.ConcertDate.Before(from) {
+				return false
+			}
+		case "date_to":
+			if to, ok := value.(time.Time); ok && concert.ConcertDate.After(to) {
+				return false
+			}
+		case "available":
+			if concert.AvailableTickets <= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// page returns the items of a page of a sorted listing
+func page[T any](items []T, p query.Page) []T {
+	offset := min(p.Offset(), len(items))
+	end := min(offset+p.Limit(), len(items))
+	return items[offset:end]
+}
+
+// LastModified returns the latest Concert.LastModified at the given time
+// across all concerts, listed or not, or the zero time if there are none
+func (r *concertRepository) LastModified(ctx context.Context, at time.Time) (time.Time, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var latest time.Time
+	for _, concert := range r.store.concerts {
+		if modified := concert.LastModified(at); modified.After(latest) {
+			latest = modified
+		}
+	}
+
+	return latest, nil
+}
+
+// Create inserts a new concert and records its initial price
+func (r *concertRepository) Create(ctx context.Context, concert *model.Concert) (*model.Concert, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	setSearchKeys(concert)
+
+	r.store.lastConcertID++
+	createdAt := now()
+	concert.ID = r.store.lastConcertID
+	concert.Version = 1
+	concert.CreatedAt = createdAt
+	concert.UpdatedAt = createdAt
+	concert.ReportingState = model.ReportingStateOpen
+	concert.ReportingClaimedAt = nil
+	concert.DoorPriceSwitchedAt = nil
+
+	r.store.concerts[concert.ID] = storedConcert(concert)
+	r.recordPrice(concert.ID, concert.Price, concert.Currency, createdAt)
+
+	return concert, nil
+}
+
+// recordPrice stores a price snapshot of a concert. The store must be locked.
+func (r *concertRepository) recordPrice(concertID int64, price float64, currency string, at time.Time) {
+	r.store.prices[concertID] = append(r.store.prices[concertID], &model.PriceSnapshot{
+		Price:      price,
+		Currency:   currency,
+		RecordedAt: at,
+	})
+}
+
+// Update updates an existing concert and records a price snapshot when its
+// price or currency changed. Changing the door price or when it applies
+// makes the pricing job announce the switch again.
+func (r *concertRepository) Update(ctx context.Context, concert *model.Concert) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	previous, ok := r.store.concerts[concert.ID]
+	if !ok || previous.Version != concert.Version {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	setSearchKeys(concert)
+
+	// The reporting job and the pricing job own their fields, which
+	// updates of the concert keep
+	updated := storedConcert(concert)
+	updated.Version = previous.Version + 1
+	updated.CreatedAt = previous.CreatedAt
+	updated.UpdatedAt = now()
+	updated.ReportingState = previous.ReportingState
+	updated.ReportingClaimedAt = copyPointer(previous.ReportingClaimedAt)
+	updated.DoorPriceSwitchedAt = copyPointer(previous.DoorPriceSwitchedAt)
+	if !sameDoorPrice(previous, concert) {
+		updated.DoorPriceSwitchedAt = nil
+	}
+	r.store.concerts[concert.ID] = updated
+
+	if concert.Price != previous.Price || concert.Currency != previous.Currency {
+		r.recordPrice(concert.ID, concert.Price, concert.Currency, updated.UpdatedAt)
+	}
+
+	// Increment version for the caller
+	concert.Version++
+
+	return nil
+}
+
+// sameDoorPrice reports whether two versions of a concert switch to the same
+// door price at the same time
+func sameDoorPrice(previous, concert *model.Concert) bool {
+	if (previous.DoorPrice == nil) != (concert.DoorPrice == nil) {
+		return false
+	}
+	if previous.DoorPrice != nil && *previous.DoorPrice != *concert.DoorPrice {
+		return false
+	}
+	return previous.DoorPriceLeadMinutes == concert.DoorPriceLeadMinutes &&
+		previous.ConcertDate.Equal(concert.ConcertDate)
+}
+
+// GetByIDs retrieves the concerts with the given IDs. Unknown IDs are skipped.
+func (r *concertRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+
+	var concerts []*model.Concert
+	for _, id := range slices.Compact(ids) {
+		if concert, ok := r.store.concerts[id]; ok {
+			concerts = append(concerts, copyConcert(concert))
+		}
+	}
+
+	return concerts, nil
+}
+
+// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+func (r *concertRepository) GetPriceHistory(ctx context.Context, concertID int64) ([]*model.PriceSnapshot, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var snapshots []*model.PriceSnapshot
+	for _, snapshot := range r.store.prices[concertID] {
+		s := *snapshot
+		snapshots = append(snapshots, &s)
+	}
+
+	return snapshots, nil
+}
+
+// SwitchDueDoorPrices marks concerts whose door price took effect as
+// switched and returns them
+func (r *concertRepository) SwitchDueDoorPrices(ctx context.Context, now time.Time, limit int) ([]*model.Concert, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var due []*model.Concert
+	for _, concert := range r.store.concerts {
+		if concert.DoorPrice != nil && concert.DoorPriceSwitchedAt == nil && !concert.DoorPriceStartsAt().After(now) {
+			due = append(due, concert)
+		}
+	}
+	slices.SortFunc(due, func(a, b *model.Concert) int { return cmp.Compare(a.ID, b.ID) })
+
+	switchedAt := now.UTC()
+	concerts := make([]*model.Concert, 0, min(len(due), limit))
+	for _, concert := range due[:min(len(due), limit)] {
+		concert.DoorPriceSwitchedAt = &switchedAt
+		concerts = append(concerts, copyConcert(concert))
+	}
+
+	return concerts, nil
+}
+
+// GetForUpdate retrieves a concert. There are no row locks to take: the
+// writes of the store check the version they are based on.
+func (r *concertRepository) GetForUpdate(ctx context.Context, id int64) (*model.Concert, error) {
+	return r.GetByID(ctx, id)
+}
+
+// UpdateTicketCount atomically updates the available ticket count using optimistic locking
+func (r *concertRepository) UpdateTicketCount(ctx context.Context, id int64, version int, ticketCount int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	concert, ok := r.store.concerts[id]
+	if !ok {
+		return fmt.Errorf("failed to get concert after update: %w", pkgErr.ErrNotFound)
+	}
+
+	if concert.Version != version {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if concert.AvailableTickets < ticketCount {
+		return pkgErr.ErrInsufficientTickets
+	}
+
+	r.store.takeTickets(concert, ticketCount)
+
+	return nil
+}
+
+// CreateInvites inserts invites of a concert and sets their IDs
+func (r *concertRepository) CreateInvites(ctx context.Context, invites []*model.ConcertInvite) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	createdAt := now()
+	for _, invite := range invites {
+		r.store.lastInviteID++
+		invite.ID = r.store.lastInviteID
+		invite.Redemptions = 0
+		invite.CreatedAt = createdAt
+
+		stored := *invite
+		stored.Token = ""
+		r.store.invites[invite.ID] = &stored
+	}
+
+	return nil
+}
+
+// GetInviteByTokenHash retrieves the invite of a concert by its token hash
+func (r *concertRepository) GetInviteByTokenHash(ctx context.Context, concertID int64, tokenHash string) (*model.ConcertInvite, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, invite := range r.store.invites {
+		if invite.ConcertID == concertID && invite.TokenHash == tokenHash {
+			i := *invite
+			return &i, nil
+		}
+	}
+
+	return nil, pkgErr.ErrNotFound
+}
+
+// RedeemInvite records a booking made with an invite. Single-use invites
+// are claimed under the store's lock, so concurrent bookings can't both
+// redeem them.
+func (r *concertRepository) RedeemInvite(ctx context.Context, redemption *model.InviteRedemption) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	invite, ok := r.store.invites[redemption.InviteID]
+	if !ok || (invite.SingleUse && invite.Redemptions > 0) {
+		return pkgErr.ErrInviteRedeemed
+	}
+
+	invite.Redemptions++
+	stored := *redemption
+	stored.RedeemedAt = redemption.RedeemedAt.UTC()
+	r.store.redemptions[invite.ID] = append(r.store.redemptions[invite.ID], &stored)
+
+	return nil
+}
+
+// WithdrawInviteRedemption removes the redemption of a booking that wasn't made
+func (r *concertRepository) WithdrawInviteRedemption(ctx context.Context, redemption *model.InviteRedemption) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	redemptions := r.store.redemptions[redemption.InviteID]
+	kept := slices.DeleteFunc(redemptions, func(ir *model.InviteRedemption) bool {
+		return ir.BookingReference == redemption.BookingReference
+	})
+	if len(kept) < len(redemptions) {
+		r.store.redemptions[redemption.InviteID] = kept
+		if invite, ok := r.store.invites[redemption.InviteID]; ok {
+			invite.Redemptions--
+		}
+	}
+
+	return nil
+}
+
+// ListInviteRedemptions lists the invites of a concert with their redemptions
+func (r *concertRepository) ListInviteRedemptions(ctx context.Context, concertID int64) ([]*model.InviteExportRow, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var invites []*model.ConcertInvite
+	for _, invite := range r.store.invites {
+		if invite.ConcertID == concertID {
+			invites = append(invites, invite)
+		}
+	}
+	slices.SortFunc(invites, func(a, b *model.ConcertInvite) int { return cmp.Compare(a.ID, b.ID) })
+
+	// User IDs come from the bookings, so erasing a user's data covers the export
+	rows := []*model.InviteExportRow{}
+	for _, invite := range invites {
+		redemptions := slices.Clone(r.store.redemptions[invite.ID])
+		slices.SortStableFunc(redemptions, func(a, b *model.InviteRedemption) int {
+			return a.RedeemedAt.Compare(b.RedeemedAt)
+		})
+
+		if len(redemptions) == 0 {
+			rows = append(rows, &model.InviteExportRow{InviteID: invite.ID, Invitee: invite.Invitee, SingleUse: invite.SingleUse})
+			continue
+		}
+
+		for _, redemption := range redemptions {
+			row := &model.InviteExportRow{
+				InviteID:         invite.ID,
+				Invitee:          invite.Invitee,
+				SingleUse:        invite.SingleUse,
+				BookingReference: copyPointer(&redemption.BookingReference),
+				RedeemedAt:       copyPointer(&redemption.RedeemedAt),
+			}
+			if booking := r.store.bookingByReference(redemption.BookingReference); booking != nil {
+				status := string(booking.Status)
+				row.UserID = copyPointer(&booking.UserID)
+				row.TicketCount = copyPointer(&booking.TicketCount)
+				row.BookingStatus = &status
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// setSearchKeys computes the normalized search keys of a concert from its names and aliases
+func setSearchKeys(concert *model.Concert) {
+	concert.SearchName = normalize.SearchKey(concert.Name)
+	concert.SearchArtist = normalize.SearchKey(concert.Artist, concert.ArtistAliases...)
+	concert.SearchVenue = normalize.SearchKey(concert.Venue, concert.VenueAliases...)
+}
@@ -0,0 +1,107 @@
+// Package memory implements the core repositories in the memory of the
+// process: concerts, bookings, booking tokens and the audit log. It lets
+// cmd/server run for development and demos without a database; everything
+// is gone when the process exits.
+//
+// The repositories share a Store, whose lock every write holds from its
+// first check to its last change. That makes each booking a transaction:
+// its ticket update and its booking are made together or not at all, and
+// the checks see no write of another booking in between.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+)
+
+// Store holds the data of the memory repositories
+type Store struct {
+	mu sync.RWMutex
+
+	concerts    map[int64]*model.Concert
+	prices      map[int64][]*model.PriceSnapshot
+	invites     map[int64]*model.ConcertInvite
+	redemptions map[int64][]*model.InviteRedemption
+
+	bookings    map[int64]*model.Booking
+	idempotency map[idempotencyKey]int64
+
+	tokens map[string]*bookingToken
+	audit  []*model.AuditLog
+
+	// The last IDs handed out, like the sequences of a database
+	lastConcertID int64
+	lastInviteID  int64
+	lastBookingID int64
+	lastAuditID   int64
+}
+
+// idempotencyKey is the unique key of the bookings made with an idempotency key
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// bookingToken is a stored booking token
+type bookingToken struct {
+	concertID int64
+	userID    string
+	expiresAt time.Time
+	usedAt    *time.Time
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{
+		concerts:    make(map[int64]*model.Concert),
+		prices:      make(map[int64][]*model.PriceSnapshot),
+		invites:     make(map[int64]*model.ConcertInvite),
+		redemptions: make(map[int64][]*model.InviteRedemption),
+		bookings:    make(map[int64]*model.Booking),
+		idempotency: make(map[idempotencyKey]int64),
+		tokens:      make(map[string]*bookingToken),
+	}
+}
+
+// now returns the current time in UTC, like the times databases store
+func now() time.Time {
+	return clock.Now().UTC()
+}
+
+// copyConcert returns a copy of a concert that shares nothing with it, so
+// neither the caller nor the store see the other's later changes. Aliases
+// are never nil, like those read from a database.
+func copyConcert(concert *model.Concert) *model.Concert {
+	c := *concert
+	c.ArtistAliases = append(model.StringList{}, concert.ArtistAliases...)
+	c.VenueAliases = append(model.StringList{}, concert.VenueAliases...)
+	c.ReportingClaimedAt = copyPointer(concert.ReportingClaimedAt)
+	c.DoorPrice = copyPointer(concert.DoorPrice)
+	c.DoorPriceSwitchedAt = copyPointer(concert.DoorPriceSwitchedAt)
+	c.MaxTicketsPerBooking = copyPointer(concert.MaxTicketsPerBooking)
+	c.MaxOrderValue = copyPointer(concert.MaxOrderValue)
+	return &c
+}
+
+// storedConcert returns the copy of a concert the store keeps, without the
+// fields that are only set for responses
+func storedConcert(concert *model.Concert) *model.Concert {
+	c := copyConcert(concert)
+	c.PriceDisplay = ""
+	c.PricingPhase = ""
+	c.CurrentPrice = 0
+	c.InviteToken = ""
+	return c
+}
+
+// copyPointer returns a pointer to a copy of the value p points to, or nil for nil
+func copyPointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
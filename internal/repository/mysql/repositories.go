@@ -0,0 +1,51 @@
+// Package mysql implements the core repositories on MySQL 8 with InnoDB:
+// concerts, bookings, booking tokens and the audit log. The other features
+// need the postgres driver.
+package mysql
+
+import (
+	"errors"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/portable"
+	"concert-ticket-api/pkg/crypto"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// errDuplicateEntry is the MySQL error number of a violated unique index
+const errDuplicateEntry = 1062
+
+// dialect locks the rows a transaction reads for update, like Postgres
+var dialect = portable.Dialect{
+	ForUpdate:         " FOR UPDATE",
+	IsUniqueViolation: isDuplicateEntry,
+}
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
+
+// NewConcertRepository creates a new MySQL implementation of ConcertRepository
+func NewConcertRepository(db *sqlx.DB) repository.ConcertRepository {
+	return portable.NewConcertRepository(db, dialect)
+}
+
+// NewBookingRepository creates a new MySQL implementation of BookingRepository.
+// Attendee details are encrypted with cipher before they are written and
+// decrypted when they are read.
+func NewBookingRepository(db *sqlx.DB, cipher crypto.Cipher) repository.BookingRepository {
+	return portable.NewBookingRepository(db, cipher, dialect)
+}
+
+// NewBookingTokenRepository creates a new MySQL implementation of BookingTokenRepository
+func NewBookingTokenRepository(db *sqlx.DB) repository.BookingTokenRepository {
+	return portable.NewBookingTokenRepository(db)
+}
+
+// NewAuditRepository creates a new MySQL implementation of AuditRepository
+func NewAuditRepository(db *sqlx.DB) repository.AuditRepository {
+	return portable.NewAuditRepository(db)
+}
@@ -0,0 +1,99 @@
+package portable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditRepository creates an AuditRepository on a MySQL or SQLite database
+func NewAuditRepository(db *sqlx.DB) repository.AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new audit log entry
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (
+			actor, action, resource_type, resource_id, details, created_at
+		) VALUES (
+			?, ?, ?, ?, ?, ?
+		)
+	`
+
+	details := entry.Details
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+
+	// MySQL only reads JSON from text, not from the bytes the driver sends
+	createdAt := now()
+	result, err := r.db.ExecContext(ctx, query,
+		entry.Actor, entry.Action, entry.ResourceType, entry.ResourceID, string(details), createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if entry.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get audit log ID: %w", err)
+	}
+	entry.CreatedAt = createdAt
+
+	return nil
+}
+
+// List returns a page of the entries matching the filter, latest first, and
+// the total number of matching entries
+func (r *auditRepository) List(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error) {
+	var conditions []string
+	var args []interface{}
+	for _, field := range []struct{ column, value string }{
+		{"actor", filter.Actor},
+		{"action", filter.Action},
+		{"resource_type", filter.ResourceType},
+		{"resource_id", filter.ResourceID},
+	} {
+		if field.value != "" {
+			args = append(args, field.value)
+			conditions = append(conditions, field.column+" = ?")
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_logs "+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT id, actor, action, resource_type, resource_id, details, created_at
+		FROM audit_logs
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, where)
+
+	entries := []*model.AuditLog{}
+	if err := r.db.SelectContext(ctx, &entries, stmt, append(args, page.Limit(), page.Offset())...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,600 @@
+package portable
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// bookingColumns lists the booking columns selected by queries
+const bookingColumns = `b.id, b.reference, b.concert_id, b.user_id, b.ticket_count, b.booking_time, b.status,
+	b.attendee_name, b.attendee_email, b.unit_price, b.created_at, b.updated_at`
+
+// The encrypted attendee columns, which bind their values to the booking
+// reference. Archived bookings keep the names of the bookings table.
+const (
+	attendeeNameColumn  = "bookings.attendee_name"
+	attendeeEmailColumn = "bookings.attendee_email"
+)
+
+// concertSummaryColumns reads the summary of the concert c into Booking.Concert
+const concertSummaryColumns = `c.id AS "concert.id", c.name AS "concert.name", c.artist AS "concert.artist",
+	c.venue AS "concert.venue", c.concert_date AS "concert.concert_date"`
+
+type bookingRepository struct {
+	db      *sqlx.DB
+	cipher  crypto.Cipher
+	dialect Dialect
+}
+
+func (r *bookingRepository) GetDB() *sqlx.DB {
+	return r.db
+}
+
+// NewBookingRepository creates a BookingRepository on a database of the
+// dialect. Attendee details are encrypted with cipher before they are
+// written and decrypted when they are read.
+func NewBookingRepository(db *sqlx.DB, cipher crypto.Cipher, dialect Dialect) repository.BookingRepository {
+	return &bookingRepository{
+		db:      db,
+		cipher:  cipher,
+		dialect: dialect,
+	}
+}
+
+// decryptAttendee decrypts the attendee details of bookings read from the database in place
+func (r *bookingRepository) decryptAttendee(bookings ...*model.Booking) error {
+	for _, booking := range bookings {
+		name, err := r.cipher.Decrypt(booking.AttendeeName, attendeeNameColumn, booking.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt attendee name: %w", err)
+		}
+
+		email, err := r.cipher.Decrypt(booking.AttendeeEmail, attendeeEmailColumn, booking.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt attendee email: %w", err)
+		}
+
+		booking.AttendeeName = name
+		booking.AttendeeEmail = email
+	}
+
+	return nil
+}
+
+// get retrieves the booking selected by a query
+func (r *bookingRepository) get(ctx context.Context, stmt string, args ...interface{}) (*model.Booking, error) {
+	var booking model.Booking
+	err := r.db.GetContext(ctx, &booking, stmt, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if err := r.decryptAttendee(&booking); err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// list retrieves the bookings selected by a query
+func (r *bookingRepository) list(ctx context.Context, what, stmt string, args ...interface{}) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+	err := r.db.SelectContext(ctx, &bookings, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", what, err)
+	}
+
+	if err := r.decryptAttendee(bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// GetByID retrieves a booking by its ID
+func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*model.Booking, error) {
+	return r.get(ctx, `SELECT `+bookingColumns+` FROM bookings b WHERE b.id = ?`, id)
+}
+
+// GetByReference retrieves a booking by its public reference
+func (r *bookingRepository) GetByReference(ctx context.Context, reference string) (*model.Booking, error) {
+	return r.get(ctx, `SELECT `+bookingColumns+` FROM bookings b WHERE b.reference = ?`, reference)
+}
+
+// GetByIdempotencyKey retrieves the booking a user made with an idempotency key
+func (r *bookingRepository) GetByIdempotencyKey(ctx context.Context, userID, key string) (*model.Booking, error) {
+	booking, err := r.get(ctx, `SELECT `+bookingColumns+`
+		FROM bookings b WHERE b.user_id = ? AND b.idempotency_key = ?`, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	booking.IdempotencyKey = key
+
+	return booking, nil
+}
+
+// GetByUserID retrieves bookings for a user
+func (r *bookingRepository) GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	return r.list(ctx, "user bookings", `
+		SELECT `+bookingColumns+`
+		FROM bookings b
+		JOIN concerts c ON b.concert_id = c.id
+		WHERE b.user_id = ?
+		ORDER BY b.booking_time DESC, b.id DESC
+		LIMIT ? OFFSET ?
+	`, userID, page.Limit(), page.Offset())
+}
+
+// GetByUserIDWithConcerts retrieves bookings for a user with their concerts
+func (r *bookingRepository) GetByUserIDWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	return r.list(ctx, "user bookings", `
+		SELECT `+bookingColumns+`, `+concertSummaryColumns+`
+		FROM bookings b
+		JOIN concerts c ON b.concert_id = c.id
+		WHERE b.user_id = ?
+		ORDER BY b.booking_time DESC, b.id DESC
+		LIMIT ? OFFSET ?
+	`, userID, page.Limit(), page.Offset())
+}
+
+// GetAllByConcertID retrieves every booking for a concert ordered by booking time
+func (r *bookingRepository) GetAllByConcertID(ctx context.Context, concertID int64) ([]*model.Booking, error) {
+	return r.list(ctx, "concert bookings", `
+		SELECT `+bookingColumns+`
+		FROM bookings b
+		WHERE b.concert_id = ?
+		ORDER BY b.booking_time ASC
+	`, concertID)
+}
+
+// GetAllByUserID retrieves every booking for a user without pagination
+func (r *bookingRepository) GetAllByUserID(ctx context.Context, userID string) ([]*model.Booking, error) {
+	return r.list(ctx, "all user bookings", `
+		SELECT `+bookingColumns+`
+		FROM bookings b
+		WHERE b.user_id = ?
+		ORDER BY b.booking_time DESC
+	`, userID)
+}
+
+// insert inserts a booking and sets its ID and times. Like the Postgres
+// repository, the booking time is when the row is written.
+func (r *bookingRepository) insert(ctx context.Context, tx sqlx.ExecerContext, booking *model.Booking) error {
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	name, err := r.cipher.Encrypt(booking.AttendeeName, attendeeNameColumn, booking.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt attendee name: %w", err)
+	}
+
+	email, err := r.cipher.Encrypt(booking.AttendeeEmail, attendeeEmailColumn, booking.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt attendee email: %w", err)
+	}
+
+	createdAt := now()
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO bookings (
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key, booking_time, created_at, updated_at
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?
+		)
+	`, booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		name, email, booking.Reference, booking.UnitPrice, booking.IdempotencyKey,
+		createdAt, createdAt, createdAt,
+	)
+	if err != nil {
+		// A concurrent request with the same idempotency key got in first
+		if booking.IdempotencyKey != "" && r.dialect.IsUniqueViolation(err) {
+			return pkgErr.ErrIdempotencyKeyInUse
+		}
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	if booking.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get booking ID: %w", err)
+	}
+	booking.BookingTime = createdAt
+	booking.CreatedAt = createdAt
+	booking.UpdatedAt = createdAt
+
+	return nil
+}
+
+// Create inserts a new booking
+func (r *bookingRepository) Create(ctx context.Context, booking *model.Booking) (*model.Booking, error) {
+	if err := r.insert(ctx, r.db, booking); err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
+
+// Update updates an existing booking
+func (r *bookingRepository) Update(ctx context.Context, booking *model.Booking) error {
+	query := `
+		UPDATE bookings
+		SET concert_id = ?, user_id = ?, ticket_count = ?, status = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status, now(), booking.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update booking: %w", err)
+	}
+
+	return nil
+}
+
+// takeTickets subtracts the tickets of a booking from its concert, which
+// must already have been checked to have them
+func takeTickets(ctx context.Context, tx *sqlx.Tx, concertID int64, tickets int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - ?,
+			version = version + 1,
+			updated_at = ?
+		WHERE id = ?
+	`, tickets, now(), concertID)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	return nil
+}
+
+// CancelWithTicketRelease cancels a booking and returns its tickets to the concert
+func (r *bookingRepository) CancelWithTicketRelease(ctx context.Context, bookingID int64) (*model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The conditional update locks the booking, so of two concurrent
+	// cancellations the second sees it cancelled
+	updatedAt := now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE bookings SET status = ?, updated_at = ?
+		WHERE id = ? AND status <> ?
+	`, model.BookingStatusCancelled, updatedAt, bookingID, model.BookingStatusCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	var booking struct {
+		ConcertID   int64 `db:"concert_id"`
+		TicketCount int   `db:"ticket_count"`
+	}
+	err = tx.GetContext(ctx, &booking, `SELECT concert_id, ticket_count FROM bookings WHERE id = ?`, bookingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pkgErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+
+	// Bumping the version makes concurrent concert edits based on the old
+	// ticket count fail their optimistic lock
+	_, err = tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets + ?, version = version + 1, updated_at = ?
+		WHERE id = ?
+	`, booking.TicketCount, updatedAt, booking.ConcertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	var availability struct {
+		AvailableTickets int       `db:"available_tickets"`
+		TotalTickets     int       `db:"total_tickets"`
+		UpdatedAt        time.Time `db:"updated_at"`
+	}
+	err = tx.GetContext(ctx, &availability, `
+		SELECT available_tickets, total_tickets, updated_at FROM concerts WHERE id = ?
+	`, booking.ConcertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &model.ConcertAvailability{
+		ConcertID:        booking.ConcertID,
+		AvailableTickets: availability.AvailableTickets,
+		TotalTickets:     availability.TotalTickets,
+		UpdatedAt:        availability.UpdatedAt,
+	}, nil
+}
+
+// CountByUserAndConcert counts bookings by a user for a specific concert
+func (r *bookingRepository) CountByUserAndConcert(ctx context.Context, userID string, concertID int64) (int, error) {
+	query := `
+		SELECT COUNT(id)
+		FROM bookings
+		WHERE user_id = ? AND concert_id = ? AND status = 'confirmed'
+	`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, userID, concertID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count user bookings for concert: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateWithTicketUpdate creates a booking and updates ticket count in a
+// transaction. It fails with ErrOptimisticLockFailed unless the concert is
+// still at concertVersion.
+func (r *bookingRepository) CreateWithTicketUpdate(ctx context.Context, booking *model.Booking, concertVersion int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	concert, err := getConcert(ctx, tx, booking.ConcertID, r.dialect.ForUpdate)
+	if err != nil {
+		return err
+	}
+
+	if concert.Version != concertVersion {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if !concert.IsBookingOpen() {
+		return pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < booking.TicketCount {
+		return pkgErr.ErrInsufficientTickets
+	}
+
+	if err := takeTickets(ctx, tx, booking.ConcertID, booking.TicketCount); err != nil {
+		return err
+	}
+
+	if err := r.insert(ctx, tx, booking); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateWithConcertLock creates a booking and updates the ticket count while
+// holding the lock of the concert row. Without advisory locks the bookings
+// of the concert queue on the row, or on the database in SQLite, which
+// still makes them one at a time without retries.
+func (r *bookingRepository) CreateWithConcertLock(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	concert, err := getConcert(ctx, tx, booking.ConcertID, r.dialect.ForUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	if !concert.IsBookingOpen() {
+		return nil, pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < booking.TicketCount {
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+
+	if err := check(concert); err != nil {
+		return nil, err
+	}
+
+	if err := takeTickets(ctx, tx, booking.ConcertID, booking.TicketCount); err != nil {
+		return nil, err
+	}
+
+	if err := r.insert(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return concert, nil
+}
+
+// CreateWithConditionalUpdate creates a booking, taking its tickets with an
+// update that only passes while the concert can be booked
+func (r *bookingRepository) CreateWithConditionalUpdate(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The update evaluates its conditions on the row as the previous
+	// booking left it, so none of them fails on a version it read before
+	at := now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - ?,
+			version = version + 1,
+			updated_at = ?
+		WHERE id = ? AND available_tickets >= ?
+			AND booking_start_time < ? AND booking_end_time > ?
+	`, booking.TicketCount, at, booking.ConcertID, booking.TicketCount, at, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	// Refusals and, lacking RETURNING, bookings read the concert in the
+	// transaction, which the update locked
+	concert, err := getConcert(ctx, tx, booking.ConcertID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		if !concert.IsBookingOpen() {
+			return nil, pkgErr.ErrBookingClosed
+		}
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+
+	// check sees the concert as it was before the booking
+	concert.AvailableTickets += booking.TicketCount
+	concert.Version--
+	if err := check(concert); err != nil {
+		return nil, err
+	}
+
+	if err := r.insert(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return concert, nil
+}
+
+// CreateBatchWithTicketUpdate creates bookings for one concert and updates
+// its ticket count in a single transaction
+func (r *bookingRepository) CreateBatchWithTicketUpdate(ctx context.Context, bookings []*model.Booking, concertVersion int) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	concertID := bookings[0].ConcertID
+	tickets := 0
+	for _, booking := range bookings {
+		if booking.ConcertID != concertID {
+			return fmt.Errorf("batch bookings must be for one concert")
+		}
+		tickets += booking.TicketCount
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	concert, err := getConcert(ctx, tx, concertID, r.dialect.ForUpdate)
+	if err != nil {
+		return err
+	}
+
+	if concert.Version != concertVersion {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if !concert.IsBookingOpen() {
+		return pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < tickets {
+		return pkgErr.ErrInsufficientTickets
+	}
+
+	if err := takeTickets(ctx, tx, concertID, tickets); err != nil {
+		return err
+	}
+
+	for _, booking := range bookings {
+		if err := r.insert(ctx, tx, booking); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AnonymizeUser replaces the user ID on all of a user's bookings with a pseudonym
+// and clears the attendee details. Ticket counts and statuses are kept so
+// aggregate sales figures stay correct. The user's booking tokens are deleted
+// in the same transaction.
+func (r *bookingRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE bookings
+		SET user_id = ?, attendee_name = '', attendee_email = '', updated_at = ?
+		WHERE user_id = ?
+	`, pseudonym, now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize user bookings: %w", err)
+	}
+
+	anonymized, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM booking_tokens WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user booking tokens: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int(anonymized + deleted), nil
+}
@@ -0,0 +1,80 @@
+package portable
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type bookingTokenRepository struct {
+	db *sqlx.DB
+}
+
+// NewBookingTokenRepository creates a BookingTokenRepository on a MySQL or SQLite database
+func NewBookingTokenRepository(db *sqlx.DB) repository.BookingTokenRepository {
+	return &bookingTokenRepository{
+		db: db,
+	}
+}
+
+// Create stores a new booking token by its hash
+func (r *bookingTokenRepository) Create(ctx context.Context, tokenHash string, concertID int64, userID string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO booking_tokens (token_hash, concert_id, user_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, tokenHash, concertID, userID, expiresAt.UTC(), now())
+	if err != nil {
+		return fmt.Errorf("failed to create booking token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks an unused, unexpired token issued to the user for the concert as used
+func (r *bookingTokenRepository) Consume(ctx context.Context, tokenHash string, concertID int64, userID string, now time.Time) error {
+	// The conditional update makes consumption atomic, so a token can only be used once
+	query := `
+		UPDATE booking_tokens
+		SET used_at = ?
+		WHERE token_hash = ? AND concert_id = ? AND user_id = ?
+			AND used_at IS NULL AND expires_at > ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), tokenHash, concertID, userID, now.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to consume booking token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return pkgErr.ErrInvalidBookingToken
+	}
+
+	return nil
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *bookingTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM booking_tokens WHERE expires_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired booking tokens: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,650 @@
+package portable
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/normalize"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// concertColumns lists the concert columns selected by queries.
+// door_price_starts_at is left out since the model computes it.
+const concertColumns = `id, name, artist, venue, concert_date, total_tickets, available_tickets, price,
+	booking_start_time, booking_end_time, version, created_at, updated_at,
+	artist_aliases, venue_aliases, search_name, search_artist, search_venue,
+	requires_booking_token, currency, organizer_email, reporting_state, reporting_claimed_at,
+	door_price, door_price_lead_minutes, door_price_switched_at, visibility, invite_token_hash,
+	max_tickets_per_booking, max_order_value`
+
+// concertWriteColumns lists the columns written by Create and Update, in the
+// order of concertValues
+var concertWriteColumns = []string{
+	"name", "artist", "venue", "concert_date", "total_tickets", "available_tickets", "price",
+	"booking_start_time", "booking_end_time", "artist_aliases", "venue_aliases",
+	"search_name", "search_artist", "search_venue", "requires_booking_token", "currency", "organizer_email",
+	"door_price", "door_price_lead_minutes", "door_price_starts_at", "visibility", "invite_token_hash",
+	"max_tickets_per_booking", "max_order_value",
+}
+
+// concertValues returns the values of concertWriteColumns of a concert
+func concertValues(concert *model.Concert) []interface{} {
+	var doorPriceStartsAt interface{}
+	if concert.DoorPrice != nil {
+		doorPriceStartsAt = concert.DoorPriceStartsAt().UTC()
+	}
+
+	return []interface{}{
+		concert.Name, concert.Artist, concert.Venue, concert.ConcertDate.UTC(),
+		concert.TotalTickets, concert.AvailableTickets, concert.Price,
+		concert.BookingStartTime.UTC(), concert.BookingEndTime.UTC(),
+		concert.ArtistAliases, concert.VenueAliases,
+		concert.SearchName, concert.SearchArtist, concert.SearchVenue,
+		concert.RequiresBookingToken, concert.Currency, concert.OrganizerEmail,
+		concert.DoorPrice, concert.DoorPriceLeadMinutes, doorPriceStartsAt,
+		concert.Visibility, concert.InviteTokenHash,
+		concert.MaxTicketsPerBooking, concert.MaxOrderValue,
+	}
+}
+
+type concertRepository struct {
+	db      *sqlx.DB
+	dialect Dialect
+}
+
+func (r *concertRepository) GetDB() *sqlx.DB {
+	return r.db
+}
+
+// NewConcertRepository creates a ConcertRepository on a database of the dialect
+func NewConcertRepository(db *sqlx.DB, dialect Dialect) repository.ConcertRepository {
+	return &concertRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// getConcert retrieves a concert by its ID with the given suffix, such as the row lock
+func getConcert(ctx context.Context, q sqlx.QueryerContext, id int64, suffix string) (*model.Concert, error) {
+	var concert model.Concert
+	err := sqlx.GetContext(ctx, q, &concert, `SELECT `+concertColumns+` FROM concerts WHERE id = ?`+suffix, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get concert: %w", err)
+	}
+
+	return &concert, nil
+}
+
+// GetByID retrieves a concert by its ID
+func (r *concertRepository) GetByID(ctx context.Context, id int64) (*model.Concert, error) {
+	return getConcert(ctx, r.db, id, "")
+}
+
+// concertSortColumns maps the sortable concert fields to columns. Names are
+// sorted by their search keys so that case and accents don't affect the order.
+var concertSortColumns = map[string]string{
+	"concert_date":      "concert_date",
+	"name":              "search_name",
+	"artist":            "search_artist",
+	"price":             "price",
+	"available_tickets": "available_tickets",
+	"created_at":        "created_at",
+}
+
+// List retrieves a page of concerts with optional filtering and sorting
+func (r *concertRepository) List(ctx context.Context, opts query.Options) ([]*model.Concert, error) {
+	where, args := buildWhereClause(opts.Filters)
+
+	sorts := opts.Sort
+	if len(sorts) == 0 {
+		sorts = []query.Sort{{Field: "concert_date"}}
+	}
+	orderBy, err := query.OrderBy(sorts, concertSortColumns, "id")
+	if err != nil {
+		return nil, pkgErr.ErrInvalidInput(err.Error())
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT %s FROM concerts
+		%s
+		%s
+		LIMIT ? OFFSET ?
+	`, concertColumns, where, orderBy)
+
+	args = append(args, opts.Page.Limit(), opts.Page.Offset())
+
+	var concerts []*model.Concert
+	err = r.db.SelectContext(ctx, &concerts, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list concerts: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// Count returns the total number of concerts matching the filters
+func (r *concertRepository) Count(ctx context.Context, filters query.Filters) (int, error) {
+	where, args := buildWhereClause(filters)
+
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM concerts `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count concerts: %w", err)
+	}
+
+	return count, nil
+}
+
+// EstimateCount counts the concerts matching the filters; the databases of
+// these drivers are small enough to count
+func (r *concertRepository) EstimateCount(ctx context.Context, filters query.Filters) (int, error) {
+	return r.Count(ctx, filters)
+}
+
+// LastModified returns the latest Concert.LastModified at the given time
+// across all concerts, listed or not, or the zero time if there are none
+func (r *concertRepository) LastModified(ctx context.Context, at time.Time) (time.Time, error) {
+	// Aggregates lose the column type in SQLite, which then can't read the
+	// time, so each part is the first row of an ordered select
+	updated, err := r.latest(ctx, `SELECT updated_at FROM concerts ORDER BY updated_at DESC LIMIT 1`)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switched, err := r.latest(ctx, `
+		SELECT door_price_starts_at FROM concerts
+		WHERE door_price_starts_at <= ?
+		ORDER BY door_price_starts_at DESC LIMIT 1
+	`, at.UTC())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if switched.After(updated) {
+		return switched, nil
+	}
+	return updated, nil
+}
+
+// latest returns the time selected by a query, or the zero time if it selects no row
+func (r *concertRepository) latest(ctx context.Context, stmt string, args ...interface{}) (time.Time, error) {
+	var latest sql.NullTime
+	err := r.db.GetContext(ctx, &latest, stmt, args...)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("failed to get concert modification time: %w", err)
+	}
+
+	return latest.Time, nil
+}
+
+// Create inserts a new concert and records its initial price
+func (r *concertRepository) Create(ctx context.Context, concert *model.Concert) (*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	setSearchKeys(concert)
+
+	createdAt := now()
+	stmt := fmt.Sprintf(`INSERT INTO concerts (%s, created_at, updated_at) VALUES (?%s, ?, ?)`,
+		strings.Join(concertWriteColumns, ", "), strings.Repeat(", ?", len(concertWriteColumns)-1))
+
+	result, err := tx.ExecContext(ctx, stmt, append(concertValues(concert), createdAt, createdAt)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create concert: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get concert ID: %w", err)
+	}
+
+	if err := recordPrice(ctx, tx, id, concert.Price, concert.Currency, createdAt); err != nil {
+		return nil, err
+	}
+
+	// Read back the defaults, like the version
+	err = tx.GetContext(ctx, concert, `SELECT `+concertColumns+` FROM concerts WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create concert: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return concert, nil
+}
+
+// recordPrice inserts a price snapshot of a concert
+func recordPrice(ctx context.Context, tx *sqlx.Tx, concertID int64, price float64, currency string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO concert_price_history (concert_id, price, currency, recorded_at)
+		VALUES (?, ?, ?, ?)
+	`, concertID, price, currency, at)
+	if err != nil {
+		return fmt.Errorf("failed to record concert price: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates an existing concert and records a price snapshot when its
+// price or currency changed. Changing the door price or when it applies
+// makes the pricing job announce the switch again.
+func (r *concertRepository) Update(ctx context.Context, concert *model.Concert) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the row keeps the version read here until the update, which
+	// checks it again for databases without row locks
+	previous, err := getConcert(ctx, tx, concert.ID, r.dialect.ForUpdate)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	setSearchKeys(concert)
+
+	switchedAt := previous.DoorPriceSwitchedAt
+	if !sameDoorPrice(previous, concert) {
+		switchedAt = nil
+	}
+
+	assignments := make([]string, len(concertWriteColumns))
+	for i, column := range concertWriteColumns {
+		assignments[i] = column + " = ?"
+	}
+	stmt := fmt.Sprintf(`
+		UPDATE concerts
+		SET %s, door_price_switched_at = ?, version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
+	`, strings.Join(assignments, ", "))
+
+	updatedAt := now()
+	args := append(concertValues(concert), utc(switchedAt), updatedAt, concert.ID, concert.Version)
+	result, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update concert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if concert.Price != previous.Price || concert.Currency != previous.Currency {
+		if err := recordPrice(ctx, tx, concert.ID, concert.Price, concert.Currency, updatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Increment version for the caller
+	concert.Version++
+
+	return nil
+}
+
+// sameDoorPrice reports whether two versions of a concert switch to the same
+// door price at the same time
+func sameDoorPrice(previous, concert *model.Concert) bool {
+	if (previous.DoorPrice == nil) != (concert.DoorPrice == nil) {
+		return false
+	}
+	if previous.DoorPrice != nil && *previous.DoorPrice != *concert.DoorPrice {
+		return false
+	}
+	return previous.DoorPriceLeadMinutes == concert.DoorPriceLeadMinutes &&
+		previous.ConcertDate.Equal(concert.ConcertDate)
+}
+
+// GetByIDs retrieves the concerts with the given IDs. Unknown IDs are skipped.
+func (r *concertRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	stmt, args, err := sqlx.In(`SELECT `+concertColumns+` FROM concerts WHERE id IN (?) ORDER BY id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build concerts query: %w", err)
+	}
+
+	var concerts []*model.Concert
+	if err := r.db.SelectContext(ctx, &concerts, stmt, args...); err != nil {
+		return nil, fmt.Errorf("failed to get concerts: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+func (r *concertRepository) GetPriceHistory(ctx context.Context, concertID int64) ([]*model.PriceSnapshot, error) {
+	query := `
+		SELECT price, currency, recorded_at FROM concert_price_history
+		WHERE concert_id = ?
+		ORDER BY recorded_at, id
+	`
+
+	var snapshots []*model.PriceSnapshot
+	err := r.db.SelectContext(ctx, &snapshots, query, concertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// SwitchDueDoorPrices marks concerts whose door price took effect as
+// switched and returns them
+func (r *concertRepository) SwitchDueDoorPrices(ctx context.Context, now time.Time, limit int) ([]*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var due []*model.Concert
+	err = tx.SelectContext(ctx, &due, `
+		SELECT `+concertColumns+` FROM concerts
+		WHERE door_price IS NOT NULL AND door_price_switched_at IS NULL
+			AND door_price_starts_at <= ?
+		ORDER BY id
+		LIMIT ?`+r.dialect.ForUpdate, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to switch door prices: %w", err)
+	}
+
+	// Each switch is conditional, so of several instances running the
+	// pricing job only one announces it
+	switchedAt := now.UTC()
+	concerts := make([]*model.Concert, 0, len(due))
+	for _, concert := range due {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE concerts SET door_price_switched_at = ?
+			WHERE id = ? AND door_price_switched_at IS NULL
+		`, switchedAt, concert.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to switch door prices: %w", err)
+		}
+
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		} else if rowsAffected == 1 {
+			concert.DoorPriceSwitchedAt = &switchedAt
+			concerts = append(concerts, concert)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// GetForUpdate retrieves a concert for update with row locking
+func (r *concertRepository) GetForUpdate(ctx context.Context, id int64) (*model.Concert, error) {
+	return getConcert(ctx, r.db, id, r.dialect.ForUpdate)
+}
+
+// UpdateTicketCount atomically updates the available ticket count using optimistic locking
+func (r *concertRepository) UpdateTicketCount(ctx context.Context, id int64, version int, ticketCount int) error {
+	query := `
+		UPDATE concerts
+		SET available_tickets = available_tickets - ?,
+			version = version + 1,
+			updated_at = ?
+		WHERE id = ? AND version = ? AND available_tickets >= ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, ticketCount, now(), id, version, ticketCount)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// Get the current available tickets to determine the error
+		concert, err := r.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get concert after update: %w", err)
+		}
+
+		if concert.Version != version {
+			return pkgErr.ErrOptimisticLockFailed
+		}
+
+		if concert.AvailableTickets < ticketCount {
+			return pkgErr.ErrInsufficientTickets
+		}
+
+		return pkgErr.ErrUpdateFailed
+	}
+
+	return nil
+}
+
+// CreateInvites inserts invites of a concert and sets their IDs
+func (r *concertRepository) CreateInvites(ctx context.Context, invites []*model.ConcertInvite) error {
+	if len(invites) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	createdAt := now()
+	for _, invite := range invites {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO concert_invites (concert_id, invitee, single_use, token_hash, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, invite.ConcertID, invite.Invitee, invite.SingleUse, invite.TokenHash, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert invites: %w", err)
+		}
+
+		if invite.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get invite ID: %w", err)
+		}
+		invite.CreatedAt = createdAt
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetInviteByTokenHash retrieves the invite of a concert by its token hash
+func (r *concertRepository) GetInviteByTokenHash(ctx context.Context, concertID int64, tokenHash string) (*model.ConcertInvite, error) {
+	var invite model.ConcertInvite
+	err := r.db.GetContext(ctx, &invite, `
+		SELECT id, concert_id, invitee, single_use, redemptions, token_hash, created_at
+		FROM concert_invites WHERE concert_id = ? AND token_hash = ?
+	`, concertID, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	return &invite, nil
+}
+
+// RedeemInvite records a booking made with an invite. The claim of a
+// single-use invite is a conditional update, so concurrent bookings can't
+// both redeem it.
+func (r *concertRepository) RedeemInvite(ctx context.Context, redemption *model.InviteRedemption) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE concert_invites SET redemptions = redemptions + 1
+		WHERE id = ? AND (NOT single_use OR redemptions = 0)
+	`, redemption.InviteID)
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return pkgErr.ErrInviteRedeemed
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO concert_invite_redemptions (invite_id, booking_reference, redeemed_at)
+		VALUES (?, ?, ?)
+	`, redemption.InviteID, redemption.BookingReference, redemption.RedeemedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WithdrawInviteRedemption removes the redemption of a booking that wasn't made
+func (r *concertRepository) WithdrawInviteRedemption(ctx context.Context, redemption *model.InviteRedemption) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM concert_invite_redemptions
+		WHERE invite_id = ? AND booking_reference = ?
+	`, redemption.InviteID, redemption.BookingReference)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw invite redemption: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		_, err := tx.ExecContext(ctx, `UPDATE concert_invites SET redemptions = redemptions - 1 WHERE id = ?`, redemption.InviteID)
+		if err != nil {
+			return fmt.Errorf("failed to withdraw invite redemption: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListInviteRedemptions lists the invites of a concert with their redemptions
+func (r *concertRepository) ListInviteRedemptions(ctx context.Context, concertID int64) ([]*model.InviteExportRow, error) {
+	// User IDs come from the bookings, so erasing a user's data covers the export
+	query := `
+		SELECT i.id AS invite_id, i.invitee, i.single_use,
+			ir.booking_reference, ir.redeemed_at,
+			b.user_id, b.ticket_count, b.status AS booking_status
+		FROM concert_invites i
+		LEFT JOIN concert_invite_redemptions ir ON ir.invite_id = i.id
+		LEFT JOIN bookings b ON b.reference = ir.booking_reference
+		WHERE i.concert_id = ?
+		ORDER BY i.id, ir.redeemed_at
+	`
+
+	rows := []*model.InviteExportRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, concertID); err != nil {
+		return nil, fmt.Errorf("failed to list invite redemptions: %w", err)
+	}
+
+	return rows, nil
+}
+
+// setSearchKeys computes the normalized search keys of a concert from its names and aliases
+func setSearchKeys(concert *model.Concert) {
+	concert.SearchName = normalize.SearchKey(concert.Name)
+	concert.SearchArtist = normalize.SearchKey(concert.Artist, concert.ArtistAliases...)
+	concert.SearchVenue = normalize.SearchKey(concert.Venue, concert.VenueAliases...)
+}
+
+// buildWhereClause builds the WHERE clause of a listing from its filters.
+// Listings only ever contain public concerts; unlisted and private ones are
+// reachable by ID only.
+func buildWhereClause(filters query.Filters) (string, []interface{}) {
+	conditions := []string{fmt.Sprintf("visibility = '%s'", model.VisibilityPublic)}
+	var args []interface{}
+
+	for key, value := range filters {
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC()
+		}
+
+		switch key {
+		case "artist":
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, "search_artist LIKE ?")
+		case "venue":
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, "search_venue LIKE ?")
+		case "date_from":
+			args = append(args, value)
+			conditions = append(conditions, "concert_date >= ?")
+		case "date_to":
+			args = append(args, value)
+			conditions = append(conditions, "concert_date <= ?")
+		case "name":
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, "search_name LIKE ?")
+		case "available":
+			conditions = append(conditions, "available_tickets > 0")
+		}
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
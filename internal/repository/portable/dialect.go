@@ -0,0 +1,40 @@
+// Package portable implements the core repositories in the SQL that MySQL
+// and SQLite share: concerts, bookings, booking tokens and the audit log.
+// It keeps the semantics of the Postgres repositories without their
+// Postgres-only statements, such as RETURNING, data-modifying CTEs and
+// advisory locks, so regardless of the database, updates of a concert
+// check its version and bookings only take the tickets left.
+//
+// Times are written in UTC, since SQLite stores them as text and compares
+// them as such.
+package portable
+
+import (
+	"time"
+
+	"concert-ticket-api/pkg/clock"
+)
+
+// Dialect describes what the SQL of a database differs in
+type Dialect struct {
+	// ForUpdate is appended to the selects that lock the rows they read in
+	// a transaction. Databases that lock whole tables or the database for
+	// a write transaction leave it empty.
+	ForUpdate string
+	// IsUniqueViolation reports whether an error is the violation of a
+	// unique index
+	IsUniqueViolation func(err error) bool
+}
+
+// now returns the current time of the process clock in UTC
+func now() time.Time {
+	return clock.Now().UTC()
+}
+
+// utc returns a time in UTC, or nil for nil
+func utc(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
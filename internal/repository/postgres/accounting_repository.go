@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// accountingEntries expands bookings into the journal entries they need: a
+// sale for every booking that was confirmed, and a refund for every booking
+// that was cancelled since
+const accountingEntries = `
+	SELECT b.id AS booking_id, b.reference, e.entry_type, c.id AS concert_id, c.name AS concert_name,
+		b.ticket_count, b.unit_price AS price, c.currency, b.status, b.booking_time, b.updated_at
+	FROM all_bookings b
+	JOIN concerts c ON c.id = b.concert_id
+	CROSS JOIN (VALUES ('sale'), ('refund')) AS e(entry_type)
+	WHERE b.status <> 'pending' AND (e.entry_type = 'sale' OR b.status = 'cancelled')
+`
+
+type accountingRepository struct {
+	db *sqlx.DB
+}
+
+// NewAccountingRepository creates a new PostgreSQL implementation of AccountingRepository
+func NewAccountingRepository(db *sqlx.DB) repository.AccountingRepository {
+	return &accountingRepository{
+		db: db,
+	}
+}
+
+// ListPending retrieves the oldest entries not yet synced to the provider
+func (r *accountingRepository) ListPending(ctx context.Context, provider string, limit int) ([]*model.AccountingRecord, error) {
+	// Sales sort before refunds, so a refund is never pushed ahead of its sale
+	query := `
+		SELECT e.*, COALESCE(s.attempts, 0) AS attempts, COALESCE(s.last_error, '') AS last_error
+		FROM (` + accountingEntries + `) e
+		LEFT JOIN accounting_sync s
+			ON s.booking_id = e.booking_id AND s.entry_type = e.entry_type AND s.provider = $1
+		WHERE s.synced_at IS NULL
+		ORDER BY e.booking_id, e.entry_type DESC
+		LIMIT $2
+	`
+
+	var records []*model.AccountingRecord
+	err := r.db.SelectContext(ctx, &records, query, provider, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending accounting entries: %w", err)
+	}
+
+	return records, nil
+}
+
+// MarkSynced records that an entry was pushed to the provider
+func (r *accountingRepository) MarkSynced(ctx context.Context, bookingID int64, entryType, provider, externalID string, at time.Time) error {
+	query := `
+		INSERT INTO accounting_sync (booking_id, entry_type, provider, external_id, attempts, synced_at, updated_at)
+		VALUES ($1, $2, $3, $4, 1, $5, $5)
+		ON CONFLICT (booking_id, entry_type, provider) DO UPDATE
+		SET external_id = EXCLUDED.external_id, attempts = accounting_sync.attempts + 1,
+			last_error = '', synced_at = EXCLUDED.synced_at, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, bookingID, entryType, provider, externalID, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark accounting entry as synced: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt to push an entry to the provider
+func (r *accountingRepository) MarkFailed(ctx context.Context, bookingID int64, entryType, provider, lastError string, at time.Time) error {
+	query := `
+		INSERT INTO accounting_sync (booking_id, entry_type, provider, attempts, last_error, updated_at)
+		VALUES ($1, $2, $3, 1, $4, $5)
+		ON CONFLICT (booking_id, entry_type, provider) DO UPDATE
+		SET attempts = accounting_sync.attempts + 1, last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, bookingID, entryType, provider, lastError, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark accounting entry as failed: %w", err)
+	}
+
+	return nil
+}
+
+// Reconcile counts the entries synced to the provider and those still pending
+func (r *accountingRepository) Reconcile(ctx context.Context, provider string) (*model.AccountingReconciliation, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE s.synced_at IS NOT NULL AND e.entry_type = 'sale') AS synced_sales,
+			COUNT(*) FILTER (WHERE s.synced_at IS NOT NULL AND e.entry_type = 'refund') AS synced_refunds,
+			COUNT(*) FILTER (WHERE s.synced_at IS NULL AND e.entry_type = 'sale') AS pending_sales,
+			COUNT(*) FILTER (WHERE s.synced_at IS NULL AND e.entry_type = 'refund') AS pending_refunds,
+			COUNT(*) FILTER (WHERE s.synced_at IS NULL AND s.attempts > 0) AS failing,
+			MAX(s.synced_at) AS last_synced_at
+		FROM (` + accountingEntries + `) e
+		LEFT JOIN accounting_sync s
+			ON s.booking_id = e.booking_id AND s.entry_type = e.entry_type AND s.provider = $1
+	`
+
+	var reconciliation model.AccountingReconciliation
+	if err := r.db.GetContext(ctx, &reconciliation, query, provider); err != nil {
+		return nil, fmt.Errorf("failed to reconcile accounting entries: %w", err)
+	}
+	reconciliation.Provider = provider
+
+	return &reconciliation, nil
+}
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type adminRepository struct {
+	db       *sqlx.DB
+	bookings *bookingRepository
+}
+
+// NewAdminRepository creates a new PostgreSQL implementation of
+// AdminRepository. Attendee details are decrypted with cipher.
+func NewAdminRepository(db *sqlx.DB, cipher crypto.Cipher) repository.AdminRepository {
+	return &adminRepository{
+		db:       db,
+		bookings: &bookingRepository{db: db, cipher: cipher},
+	}
+}
+
+// ForceCancelBooking cancels a booking whoever made it and returns its
+// tickets to the concert in one transaction
+func (r *adminRepository) ForceCancelBooking(ctx context.Context, reference string) (*model.Booking, *model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var booking model.Booking
+	err = tx.GetContext(ctx, &booking, `SELECT `+bookingColumns+` FROM bookings b WHERE b.reference = $1 FOR UPDATE`, reference)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, pkgErr.ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if booking.Status == model.BookingStatusCancelled {
+		return nil, nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+
+	if err := settlePending(ctx, tx, booking.ID); err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.GetContext(ctx, &booking.UpdatedAt,
+		`UPDATE bookings SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`,
+		model.BookingStatusCancelled, booking.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel booking: %w", err)
+	}
+	booking.Status = model.BookingStatusCancelled
+
+	availability, err := adjustTickets(ctx, tx, booking.ConcertID, 0, booking.TicketCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeBookingEvents(ctx, tx, model.EventBookingCancelled, &booking); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := r.bookings.decryptAttendee(&booking); err != nil {
+		return nil, nil, err
+	}
+
+	return &booking, availability, nil
+}
+
+// AdjustInventory adds delta to the total and available tickets of a concert
+func (r *adminRepository) AdjustInventory(ctx context.Context, concertID int64, delta int) (*model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	availability, err := adjustTickets(ctx, tx, concertID, delta, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return availability, nil
+}
+
+// CountInventory counts the tickets of a concert, or of every concert. The
+// counts come from one statement, which sees a single snapshot, so bookings
+// committed meanwhile don't show up as discrepancies.
+func (r *adminRepository) CountInventory(ctx context.Context, concertID int64) ([]*model.InventoryCount, error) {
+	query := `
+		SELECT c.id AS concert_id, c.total_tickets, c.available_tickets,
+			COALESCE(b.booked_tickets, 0) AS booked_tickets,
+			COALESCE(b.pending_tickets, 0) AS pending_tickets,
+			COALESCE(r.held_tickets, 0) AS held_tickets
+		FROM concerts c
+		LEFT JOIN (
+			SELECT concert_id,
+				SUM(ticket_count) FILTER (WHERE NOT inventory_pending) AS booked_tickets,
+				SUM(ticket_count) FILTER (WHERE inventory_pending) AS pending_tickets
+			FROM all_bookings
+			WHERE status <> $1
+			GROUP BY concert_id
+		) b ON b.concert_id = c.id
+		LEFT JOIN (
+			SELECT concert_id, SUM(quantity) AS held_tickets
+			FROM concert_inventory_releases
+			WHERE released_at IS NULL
+			GROUP BY concert_id
+		) r ON r.concert_id = c.id
+		WHERE $2 = 0 OR c.id = $2
+		ORDER BY c.id
+	`
+
+	var counts []*model.InventoryCount
+	if err := r.db.SelectContext(ctx, &counts, query, model.BookingStatusCancelled, concertID); err != nil {
+		return nil, fmt.Errorf("failed to count inventory: %w", err)
+	}
+	if concertID != 0 && len(counts) == 0 {
+		return nil, pkgErr.ErrNotFound
+	}
+	return counts, nil
+}
+
+// adjustTickets adds to the total and available tickets of a concert, failing
+// with ErrInsufficientTickets rather than leaving fewer than zero available
+func adjustTickets(ctx context.Context, tx *sqlx.Tx, concertID int64, total, available int) (*model.ConcertAvailability, error) {
+	query := `
+		UPDATE concerts
+		SET total_tickets = total_tickets + $1, available_tickets = available_tickets + $2,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $3 AND available_tickets + $2 >= 0
+		RETURNING id, available_tickets, total_tickets, updated_at
+	`
+
+	var row struct {
+		ID               int64     `db:"id"`
+		AvailableTickets int       `db:"available_tickets"`
+		TotalTickets     int       `db:"total_tickets"`
+		UpdatedAt        time.Time `db:"updated_at"`
+	}
+	err := tx.GetContext(ctx, &row, query, total, available, concertID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Tell a missing concert from one without enough tickets
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM concerts WHERE id = $1)`, concertID); err != nil {
+			return nil, fmt.Errorf("failed to get concert: %w", err)
+		}
+		if !exists {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+	if isInventoryExhausted(err) {
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust tickets: %w", err)
+	}
+
+	return &model.ConcertAvailability{
+		ConcertID:        row.ID,
+		AvailableTickets: row.AvailableTickets,
+		TotalTickets:     row.TotalTickets,
+		UpdatedAt:        row.UpdatedAt,
+	}, nil
+}
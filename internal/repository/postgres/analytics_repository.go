@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type analyticsRepository struct {
+	db *sqlx.DB
+}
+
+// NewAnalyticsRepository creates a new PostgreSQL implementation of AnalyticsRepository
+func NewAnalyticsRepository(db *sqlx.DB) repository.AnalyticsRepository {
+	return &analyticsRepository{
+		db: db,
+	}
+}
+
+// OrganizerDashboard aggregates the bookings of the concerts of an organizer.
+// Each figure is one aggregate query over the organizer's concerts, which
+// idx_concerts_organizer_email finds, and their bookings.
+func (r *analyticsRepository) OrganizerDashboard(ctx context.Context, organizerEmail string, dayStart, dayEnd time.Time, top int) (*model.OrganizerDashboard, error) {
+	dashboard := &model.OrganizerDashboard{
+		OrganizerEmail: organizerEmail,
+		DayStart:       dayStart,
+		DayEnd:         dayEnd,
+		SalesToday:     []*model.CurrencySales{},
+		TopConcerts:    []*model.ConcertSales{},
+	}
+
+	if err := r.db.GetContext(ctx, &dashboard.Concerts,
+		"SELECT COUNT(*) FROM concerts WHERE organizer_email = $1", organizerEmail); err != nil {
+		return nil, fmt.Errorf("failed to count organizer concerts: %w", err)
+	}
+	if dashboard.Concerts == 0 {
+		return dashboard, nil
+	}
+
+	salesQuery := `
+		SELECT c.currency, COUNT(*) AS bookings, SUM(b.ticket_count) AS tickets,
+			SUM(b.ticket_count * b.unit_price) AS gross
+		FROM concerts c
+		JOIN bookings b ON b.concert_id = c.id
+		WHERE c.organizer_email = $1 AND b.status = $2
+			AND b.booking_time >= $3 AND b.booking_time < $4
+		GROUP BY c.currency
+		ORDER BY c.currency
+	`
+	if err := r.db.SelectContext(ctx, &dashboard.SalesToday, salesQuery,
+		organizerEmail, model.BookingStatusConfirmed, dayStart.UTC(), dayEnd.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to aggregate sales today: %w", err)
+	}
+
+	topQuery := `
+		SELECT c.id AS concert_id, c.name, c.concert_date, c.total_tickets, c.currency,
+			COALESCE(SUM(b.ticket_count), 0) AS tickets_sold,
+			COALESCE(SUM(b.ticket_count * b.unit_price), 0) AS gross
+		FROM concerts c
+		LEFT JOIN bookings b ON b.concert_id = c.id AND b.status = $2
+		WHERE c.organizer_email = $1
+		GROUP BY c.id
+		ORDER BY tickets_sold DESC, gross DESC, c.id
+		LIMIT $3
+	`
+	if err := r.db.SelectContext(ctx, &dashboard.TopConcerts, topQuery,
+		organizerEmail, model.BookingStatusConfirmed, top); err != nil {
+		return nil, fmt.Errorf("failed to rank organizer concerts: %w", err)
+	}
+
+	// Every booking with a payment held its tickets until the payment was
+	// authorized. A captured payment confirmed its booking even if it was
+	// refunded since; a cancelled booking whose payment wasn't is a hold
+	// that lapsed, not a cancellation.
+	statsQuery := `
+		SELECT
+			COUNT(p.id) AS holds,
+			COUNT(p.id) FILTER (WHERE b.status = $2) AS pending,
+			COUNT(p.id) FILTER (WHERE b.status = $3 OR p.status IN ($5, $6)) AS confirmed,
+			COUNT(p.id) FILTER (WHERE b.status = $4 AND p.status NOT IN ($5, $6)) AS lapsed,
+			COUNT(*) FILTER (WHERE b.status = $3 OR (b.status = $4 AND (p.id IS NULL OR p.status IN ($5, $6)))) AS bookings,
+			COUNT(*) FILTER (WHERE b.status = $4 AND (p.id IS NULL OR p.status IN ($5, $6))) AS cancelled
+		FROM concerts c
+		JOIN bookings b ON b.concert_id = c.id
+		LEFT JOIN payments p ON p.booking_id = b.id
+		WHERE c.organizer_email = $1
+	`
+	var stats struct {
+		Holds     int `db:"holds"`
+		Pending   int `db:"pending"`
+		Confirmed int `db:"confirmed"`
+		Lapsed    int `db:"lapsed"`
+		Bookings  int `db:"bookings"`
+		Cancelled int `db:"cancelled"`
+	}
+	if err := r.db.GetContext(ctx, &stats, statsQuery, organizerEmail,
+		model.BookingStatusPending, model.BookingStatusConfirmed, model.BookingStatusCancelled,
+		model.PaymentSucceeded, model.PaymentRefunded); err != nil {
+		return nil, fmt.Errorf("failed to count organizer bookings: %w", err)
+	}
+	dashboard.Holds = model.HoldConversion{Holds: stats.Holds, Pending: stats.Pending, Confirmed: stats.Confirmed, Lapsed: stats.Lapsed}
+	dashboard.Cancellations = model.CancellationStats{Bookings: stats.Bookings, Cancelled: stats.Cancelled}
+
+	return dashboard, nil
+}
+
+// SalesTimeseries buckets the confirmed bookings of a concert with
+// date_trunc, in the location's wall time. idx_bookings_concert_time finds
+// the bookings of the range.
+func (r *analyticsRepository) SalesTimeseries(ctx context.Context, concertID int64, interval string, location *time.Location, from, to time.Time) ([]*model.SalesBucket, error) {
+	// Booking times are stored in UTC without a time zone
+	query := `
+		SELECT date_trunc($2, timezone($3, b.booking_time AT TIME ZONE 'UTC')) AS bucket,
+			COUNT(*) AS bookings, SUM(b.ticket_count) AS tickets,
+			SUM(b.ticket_count * b.unit_price) AS revenue
+		FROM bookings b
+		WHERE b.concert_id = $1 AND b.status = $4
+			AND b.booking_time >= $5 AND b.booking_time < $6
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	var rows []struct {
+		Bucket   time.Time `db:"bucket"`
+		Bookings int       `db:"bookings"`
+		Tickets  int       `db:"tickets"`
+		Revenue  float64   `db:"revenue"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, concertID, interval, location.String(),
+		model.BookingStatusConfirmed, from.UTC(), to.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to aggregate sales timeseries: %w", err)
+	}
+
+	buckets := make([]*model.SalesBucket, len(rows))
+	for i, row := range rows {
+		// The bucket is the wall time in the location
+		start := time.Date(row.Bucket.Year(), row.Bucket.Month(), row.Bucket.Day(), row.Bucket.Hour(), 0, 0, 0, location)
+		buckets[i] = &model.SalesBucket{Start: start, Bookings: row.Bookings, Tickets: row.Tickets, Revenue: row.Revenue}
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type archiveRepository struct {
+	db *sqlx.DB
+}
+
+// NewArchiveRepository creates a new PostgreSQL implementation of ArchiveRepository
+func NewArchiveRepository(db *sqlx.DB) repository.ArchiveRepository {
+	return &archiveRepository{
+		db: db,
+	}
+}
+
+// archivedBooking is a booking picked for the archive, with the date of its
+// concert that decides its partition
+type archivedBooking struct {
+	ID          int64     `db:"id"`
+	ConcertDate time.Time `db:"concert_date"`
+}
+
+// ArchiveBookings moves up to limit bookings of concerts that took place
+// before cutoff into bookings_archive in one transaction, creating the
+// yearly partitions they need. Bookings locked by other transactions are left
+// for the next batch, and so are bookings whose inventory hasn't been settled.
+func (r *archiveRepository) ArchiveBookings(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var picked []archivedBooking
+	err = tx.SelectContext(ctx, &picked, `
+		SELECT b.id, c.concert_date
+		FROM bookings b
+		JOIN concerts c ON c.id = b.concert_id
+		WHERE c.concert_date < $1 AND NOT b.inventory_pending
+		ORDER BY b.id
+		LIMIT $2
+		FOR UPDATE OF b SKIP LOCKED
+	`, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pick bookings to archive: %w", err)
+	}
+	if len(picked) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(picked))
+	years := map[int]bool{}
+	for i, booking := range picked {
+		ids[i] = booking.ID
+		years[booking.ConcertDate.Year()] = true
+	}
+
+	for year := range years {
+		partition := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS bookings_archive_%d PARTITION OF bookings_archive
+			FOR VALUES FROM ('%d-01-01') TO ('%d-01-01')`, year, year, year+1)
+		if _, err := tx.ExecContext(ctx, partition); err != nil {
+			return 0, fmt.Errorf("failed to create archive partition for %d: %w", year, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		WITH moved AS (
+			DELETE FROM bookings b
+			USING concerts c
+			WHERE c.id = b.concert_id AND b.id = ANY($1)
+			RETURNING b.id, b.concert_id, b.user_id, b.ticket_count, b.booking_time, b.status,
+				b.created_at, b.updated_at, b.attendee_name, b.attendee_email, b.reference,
+				b.unit_price, b.idempotency_key, b.inventory_pending, b.order_id, c.concert_date
+		)
+		INSERT INTO bookings_archive (id, concert_id, user_id, ticket_count, booking_time, status,
+			created_at, updated_at, attendee_name, attendee_email, reference,
+			unit_price, idempotency_key, inventory_pending, order_id, concert_date)
+		SELECT id, concert_id, user_id, ticket_count, booking_time, status,
+			created_at, updated_at, attendee_name, attendee_email, reference,
+			unit_price, idempotency_key, inventory_pending, order_id, concert_date
+		FROM moved
+	`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive bookings: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
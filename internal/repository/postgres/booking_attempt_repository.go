@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type bookingAttemptRepository struct {
+	db *sqlx.DB
+}
+
+// NewBookingAttemptRepository creates a new PostgreSQL implementation of BookingAttemptRepository
+func NewBookingAttemptRepository(db *sqlx.DB) repository.BookingAttemptRepository {
+	return &bookingAttemptRepository{
+		db: db,
+	}
+}
+
+// CreateBatch inserts booking attempts in one statement
+func (r *bookingAttemptRepository) CreateBatch(ctx context.Context, attempts []*model.BookingAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO booking_attempts (concert_id, user_id, reason, latency_ms, retries, attempted_at)
+		VALUES (:concert_id, :user_id, :reason, :latency_ms, :retries, :attempted_at)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, attempts); err != nil {
+		return fmt.Errorf("failed to insert booking attempts: %w", err)
+	}
+
+	return nil
+}
+
+// Summarize aggregates the attempts since the given time
+func (r *bookingAttemptRepository) Summarize(ctx context.Context, concertID int64, since time.Time) (*model.BookingAttemptSummary, error) {
+	reasonsQuery := `
+		SELECT reason, COUNT(*) AS attempts, COUNT(DISTINCT user_id) AS users,
+			AVG(latency_ms) AS avg_latency_ms, AVG(retries) AS avg_retries
+		FROM booking_attempts
+		WHERE attempted_at >= $1 AND ($2 = 0 OR concert_id = $2)
+		GROUP BY reason
+		ORDER BY attempts DESC, reason
+	`
+
+	reasons := []*model.BookingAttemptReason{}
+	if err := r.db.SelectContext(ctx, &reasons, reasonsQuery, since, concertID); err != nil {
+		return nil, fmt.Errorf("failed to summarize booking attempts: %w", err)
+	}
+
+	// Users who tried but never got tickets in the window
+	usersQuery := `
+		SELECT COUNT(*) FROM (
+			SELECT user_id
+			FROM booking_attempts
+			WHERE attempted_at >= $1 AND ($2 = 0 OR concert_id = $2)
+			GROUP BY user_id
+			HAVING COUNT(*) FILTER (WHERE reason = $3) = 0
+		) turned_away
+	`
+
+	summary := &model.BookingAttemptSummary{
+		ConcertID:   concertID,
+		WindowStart: since,
+		Reasons:     reasons,
+	}
+	if err := r.db.GetContext(ctx, &summary.TurnedAwayUsers, usersQuery, since, concertID, model.BookingAttemptBooked); err != nil {
+		return nil, fmt.Errorf("failed to count turned away users: %w", err)
+	}
+
+	for _, reason := range reasons {
+		summary.Attempts += reason.Attempts
+		if reason.Reason != model.BookingAttemptBooked {
+			summary.TurnedAway += reason.Attempts
+		}
+	}
+
+	return summary, nil
+}
+
+// PurgeBefore deletes the attempts made before the given time
+func (r *bookingAttemptRepository) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM booking_attempts WHERE attempted_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge booking attempts: %w", err)
+	}
+
+	return result.RowsAffected()
+}
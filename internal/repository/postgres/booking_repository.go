@@ -5,33 +5,113 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/crypto"
+	pkgDB "concert-ticket-api/pkg/db"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
 
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
+// bookingColumns lists the booking columns selected by queries
+const bookingColumns = `b.id, b.reference, b.concert_id, b.user_id, b.ticket_count, b.booking_time, b.status,
+	b.attendee_name, b.attendee_email, b.unit_price, b.created_at, b.updated_at`
+
+// The encrypted attendee columns, which bind their values to the booking
+// reference. Archived bookings keep the names of the bookings table.
+const (
+	attendeeNameColumn  = "bookings.attendee_name"
+	attendeeEmailColumn = "bookings.attendee_email"
+)
+
+// concertSummaryColumns reads the summary of the concert c into Booking.Concert
+const concertSummaryColumns = `c.id AS "concert.id", c.name AS "concert.name", c.artist AS "concert.artist",
+	c.venue AS "concert.venue", c.concert_date AS "concert.concert_date"`
+
 type bookingRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	cipher crypto.Cipher
+	// replicas take the reads that may lag behind writes, if set
+	replicas *pkgDB.Replicas
 }
 
 func (r *bookingRepository) GetDB() *sqlx.DB {
 	return r.db
 }
 
-// NewBookingRepository creates a new PostgreSQL implementation of BookingRepository
-func NewBookingRepository(db *sqlx.DB) repository.BookingRepository {
+// NewBookingRepository creates a new PostgreSQL implementation of BookingRepository.
+// Attendee details are encrypted with cipher before they are written and
+// decrypted when they are read.
+func NewBookingRepository(db *sqlx.DB, cipher crypto.Cipher) repository.BookingRepository {
+	return NewBookingRepositoryWithReplicas(db, nil, cipher)
+}
+
+// NewBookingRepositoryWithReplicas creates a BookingRepository that lists
+// the bookings of users on the read replicas. Everything else stays on the
+// primary, so a booking can be read back as soon as it was made.
+func NewBookingRepositoryWithReplicas(db *sqlx.DB, replicas *pkgDB.Replicas, cipher crypto.Cipher) repository.BookingRepository {
 	return &bookingRepository{
-		db: db,
+		db:       db,
+		cipher:   cipher,
+		replicas: replicas,
+	}
+}
+
+// reader returns the database of the reads that may lag behind writes
+func (r *bookingRepository) reader() *sqlx.DB {
+	if r.replicas == nil {
+		return r.db
+	}
+	return r.replicas.Reader()
+}
+
+// encryptAttendee returns the encrypted attendee name and email of a booking
+func (r *bookingRepository) encryptAttendee(booking *model.Booking) (string, string, error) {
+	name, err := r.cipher.Encrypt(booking.AttendeeName, attendeeNameColumn, booking.Reference)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt attendee name: %w", err)
+	}
+
+	email, err := r.cipher.Encrypt(booking.AttendeeEmail, attendeeEmailColumn, booking.Reference)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt attendee email: %w", err)
+	}
+
+	return name, email, nil
+}
+
+// decryptAttendee decrypts the attendee details of bookings read from the database in place
+func (r *bookingRepository) decryptAttendee(bookings ...*model.Booking) error {
+	for _, booking := range bookings {
+		name, err := r.cipher.Decrypt(booking.AttendeeName, attendeeNameColumn, booking.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt attendee name: %w", err)
+		}
+
+		email, err := r.cipher.Decrypt(booking.AttendeeEmail, attendeeEmailColumn, booking.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt attendee email: %w", err)
+		}
+
+		booking.AttendeeName = name
+		booking.AttendeeEmail = email
 	}
+
+	return nil
 }
 
 // GetByID retrieves a booking by its ID
 func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*model.Booking, error) {
-	query := `SELECT b.id, b.concert_id, b.user_id, b.ticket_count, b.booking_time, b.status, b.created_at, b.updated_at
-		FROM bookings b WHERE b.id = $1`
+	query := `SELECT ` + bookingColumns + `
+		FROM all_bookings b WHERE b.id = $1`
 
 	var booking model.Booking
 	err := r.db.GetContext(ctx, &booking, query, id)
@@ -42,26 +122,125 @@ func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*model.Booki
 		return nil, fmt.Errorf("failed to get booking: %w", err)
 	}
 
+	if err := r.decryptAttendee(&booking); err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// GetByReference retrieves a booking by its public reference
+func (r *bookingRepository) GetByReference(ctx context.Context, reference string) (*model.Booking, error) {
+	query := `SELECT ` + bookingColumns + `
+		FROM all_bookings b WHERE b.reference = $1`
+
+	var booking model.Booking
+	err := r.db.GetContext(ctx, &booking, query, reference)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if err := r.decryptAttendee(&booking); err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// GetByIdempotencyKey retrieves the booking a user made with an idempotency key
+func (r *bookingRepository) GetByIdempotencyKey(ctx context.Context, userID, key string) (*model.Booking, error) {
+	query := `SELECT ` + bookingColumns + `
+		FROM bookings b WHERE b.user_id = $1 AND b.idempotency_key = $2`
+
+	var booking model.Booking
+	err := r.db.GetContext(ctx, &booking, query, userID, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if err := r.decryptAttendee(&booking); err != nil {
+		return nil, err
+	}
+	booking.IdempotencyKey = key
+
 	return &booking, nil
 }
 
 // GetByUserID retrieves bookings for a user
-func (r *bookingRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Booking, error) {
+func (r *bookingRepository) GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
 	query := `
-		SELECT b.id, b.concert_id, b.user_id, b.ticket_count, b.booking_time, b.status, b.created_at, b.updated_at
-		FROM bookings b
+		SELECT ` + bookingColumns + `
+		FROM all_bookings b
 		JOIN concerts c ON b.concert_id = c.id
 		WHERE b.user_id = $1
-		ORDER BY b.booking_time DESC
+		ORDER BY b.booking_time DESC, b.id DESC
 		LIMIT $2 OFFSET $3
 	`
 
 	var bookings []*model.Booking
-	err := r.db.SelectContext(ctx, &bookings, query, userID, limit, offset)
+	err := r.reader().SelectContext(ctx, &bookings, query, userID, page.Limit(), page.Offset())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user bookings: %w", err)
 	}
 
+	if err := r.decryptAttendee(bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// GetByUserIDWithConcerts retrieves bookings for a user with their concerts,
+// from the join GetByUserID makes anyway
+func (r *bookingRepository) GetByUserIDWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `, ` + concertSummaryColumns + `
+		FROM all_bookings b
+		JOIN concerts c ON b.concert_id = c.id
+		WHERE b.user_id = $1
+		ORDER BY b.booking_time DESC, b.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	var bookings []*model.Booking
+	err := r.reader().SelectContext(ctx, &bookings, query, userID, page.Limit(), page.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user bookings: %w", err)
+	}
+
+	if err := r.decryptAttendee(bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// GetAllByConcertID retrieves every booking for a concert ordered by booking time
+func (r *bookingRepository) GetAllByConcertID(ctx context.Context, concertID int64) ([]*model.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM all_bookings b
+		JOIN concerts c ON b.concert_id = c.id
+		WHERE b.concert_id = $1
+		ORDER BY b.booking_time ASC
+	`
+
+	var bookings []*model.Booking
+	err := r.db.SelectContext(ctx, &bookings, query, concertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get concert bookings: %w", err)
+	}
+
+	if err := r.decryptAttendee(bookings...); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
@@ -69,16 +248,31 @@ func (r *bookingRepository) GetByUserID(ctx context.Context, userID string, limi
 func (r *bookingRepository) Create(ctx context.Context, booking *model.Booking) (*model.Booking, error) {
 	query := `
 		INSERT INTO bookings (
-			concert_id, user_id, ticket_count, status
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key
 		) VALUES (
-			$1, $2, $3, $4
-		) RETURNING *
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')
+		) RETURNING id, booking_time, created_at, updated_at
 	`
 
-	err := r.db.GetContext(ctx, booking, query,
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	attendeeName, attendeeEmail, err := r.encryptAttendee(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.GetContext(ctx, booking, query,
 		booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, booking.IdempotencyKey,
 	)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if booking.IdempotencyKey != "" && errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, pkgErr.ErrIdempotencyKeyInUse
+		}
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
 
@@ -103,6 +297,73 @@ func (r *bookingRepository) Update(ctx context.Context, booking *model.Booking)
 	return nil
 }
 
+// CancelWithTicketRelease cancels a booking and returns its tickets to the concert
+func (r *bookingRepository) CancelWithTicketRelease(ctx context.Context, bookingID int64) (*model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := settlePending(ctx, tx, bookingID); err != nil {
+		return nil, err
+	}
+
+	// The update locks the booking row, so of two concurrent cancellations
+	// the second sees it cancelled
+	var booking model.Booking
+	err = tx.GetContext(ctx, &booking, `
+		UPDATE bookings SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status <> $1
+		RETURNING id, concert_id, user_id, ticket_count, booking_time, status, reference, unit_price
+	`, model.BookingStatusCancelled, bookingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM bookings WHERE id = $1)`, bookingID); err != nil {
+			return nil, fmt.Errorf("failed to get booking: %w", err)
+		}
+		if !exists {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel booking: %w", err)
+	}
+
+	// Bumping the version makes concurrent concert edits based on the old
+	// ticket count fail their optimistic lock
+	var availability struct {
+		AvailableTickets int       `db:"available_tickets"`
+		TotalTickets     int       `db:"total_tickets"`
+		UpdatedAt        time.Time `db:"updated_at"`
+	}
+	err = tx.GetContext(ctx, &availability, `
+		UPDATE concerts
+		SET available_tickets = available_tickets + $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING available_tickets, total_tickets, updated_at
+	`, booking.TicketCount, booking.ConcertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	if err := writeBookingEvents(ctx, tx, model.EventBookingCancelled, &booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &model.ConcertAvailability{
+		ConcertID:        booking.ConcertID,
+		AvailableTickets: availability.AvailableTickets,
+		TotalTickets:     availability.TotalTickets,
+		UpdatedAt:        availability.UpdatedAt,
+	}, nil
+}
+
 // CountByUserAndConcert counts bookings by a user for a specific concert
 func (r *bookingRepository) CountByUserAndConcert(ctx context.Context, userID string, concertID int64) (int, error) {
 	query := `
@@ -173,25 +434,48 @@ func (r *bookingRepository) CreateWithTicketUpdate(ctx context.Context, booking
 	`
 	_, err = tx.ExecContext(ctx, updateTicketQuery, booking.TicketCount, booking.ConcertID)
 	if err != nil {
+		if isInventoryExhausted(err) {
+			return pkgErr.ErrInsufficientTickets
+		}
 		return fmt.Errorf("failed to update ticket count: %w", err)
 	}
 
 	// Create the booking
 	createBookingQuery := `
 		INSERT INTO bookings (
-			concert_id, user_id, ticket_count, status
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key
 		) VALUES (
-			$1, $2, $3, $4
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')
 		) RETURNING id, booking_time, created_at, updated_at
 	`
 
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	attendeeName, attendeeEmail, err := r.encryptAttendee(booking)
+	if err != nil {
+		return err
+	}
+
 	err = tx.GetContext(ctx, booking, createBookingQuery,
 		booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, booking.IdempotencyKey,
 	)
 	if err != nil {
+		// A concurrent request with the same idempotency key got in first
+		var pgErr *pgconn.PgError
+		if booking.IdempotencyKey != "" && errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return pkgErr.ErrIdempotencyKeyInUse
+		}
 		return fmt.Errorf("failed to create booking: %w", err)
 	}
 
+	if err := writeBookedEvents(ctx, tx, booking); err != nil {
+		return err
+	}
+
 	// Commit the transaction
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -199,3 +483,373 @@ func (r *bookingRepository) CreateWithTicketUpdate(ctx context.Context, booking
 
 	return nil
 }
+
+// CreateWithConcertLock creates a booking and updates the ticket count while
+// holding the concert's advisory lock. Bookings of the concert queue on the
+// lock, so only the one at its head waits for the row, and can't conflict
+// with each other, so they need no retries.
+func (r *bookingRepository) CreateWithConcertLock(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The single-key lock space is the concerts' booking locks; the
+	// inventory locks use the two-key space
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, booking.ConcertID); err != nil {
+		return nil, fmt.Errorf("failed to lock concert bookings: %w", err)
+	}
+
+	// Other changes of the concert, such as edits and releases, still lock
+	// the row, which is held only by the booking at the head of the queue
+	var concert model.Concert
+	err = tx.GetContext(ctx, &concert, `SELECT * FROM concerts WHERE id = $1 FOR UPDATE`, booking.ConcertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get concert for booking: %w", err)
+	}
+
+	if !concert.IsBookingOpen() {
+		return nil, pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < booking.TicketCount {
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+
+	if err := check(&concert); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE id = $2
+	`, booking.TicketCount, booking.ConcertID)
+	if err != nil {
+		if isInventoryExhausted(err) {
+			return nil, pkgErr.ErrInsufficientTickets
+		}
+		return nil, fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	attendeeName, attendeeEmail, err := r.encryptAttendee(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.GetContext(ctx, booking, `
+		INSERT INTO bookings (
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')
+		) RETURNING id, booking_time, created_at, updated_at
+	`, booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, booking.IdempotencyKey,
+	)
+	if err != nil {
+		// A concurrent request with the same idempotency key got in first
+		var pgErr *pgconn.PgError
+		if booking.IdempotencyKey != "" && errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, pkgErr.ErrIdempotencyKeyInUse
+		}
+		return nil, fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	if err := writeBookedEvents(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &concert, nil
+}
+
+// CreateWithConditionalUpdate creates a booking, taking its tickets with an
+// update that only passes while the concert can be booked
+func (r *bookingRepository) CreateWithConditionalUpdate(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Concurrent bookings queue on the row lock of the update and each one
+	// re-evaluates the conditions on the row as the previous one left it, so
+	// none of them fails on a version it read before
+	var concert model.Concert
+	err = tx.GetContext(ctx, &concert, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE id = $2 AND available_tickets >= $1
+			AND booking_start_time < $3 AND booking_end_time > $3
+		RETURNING *
+	`, booking.TicketCount, booking.ConcertID, clock.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.refusal(ctx, booking.ConcertID)
+		}
+		if isInventoryExhausted(err) {
+			return nil, pkgErr.ErrInsufficientTickets
+		}
+		return nil, fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	// check sees the concert as it was before the booking
+	concert.AvailableTickets += booking.TicketCount
+	concert.Version--
+	if err := check(&concert); err != nil {
+		return nil, err
+	}
+
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	attendeeName, attendeeEmail, err := r.encryptAttendee(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.GetContext(ctx, booking, `
+		INSERT INTO bookings (
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')
+		) RETURNING id, booking_time, created_at, updated_at
+	`, booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, booking.IdempotencyKey,
+	)
+	if err != nil {
+		// A concurrent request with the same idempotency key got in first
+		var pgErr *pgconn.PgError
+		if booking.IdempotencyKey != "" && errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, pkgErr.ErrIdempotencyKeyInUse
+		}
+		return nil, fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	if err := writeBookedEvents(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &concert, nil
+}
+
+// refusal reads a concert a conditional update didn't pass for and returns
+// why. Refusals are the only bookings that read the concert.
+func (r *bookingRepository) refusal(ctx context.Context, concertID int64) error {
+	var concert model.Concert
+	err := r.db.GetContext(ctx, &concert, `SELECT * FROM concerts WHERE id = $1`, concertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkgErr.ErrNotFound
+		}
+		return fmt.Errorf("failed to get concert for booking: %w", err)
+	}
+
+	if !concert.IsBookingOpen() {
+		return pkgErr.ErrBookingClosed
+	}
+	return pkgErr.ErrInsufficientTickets
+}
+
+// CreateBatchWithTicketUpdate creates bookings for one concert and updates
+// its ticket count in a single transaction
+func (r *bookingRepository) CreateBatchWithTicketUpdate(ctx context.Context, bookings []*model.Booking, concertVersion int) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	concertID := bookings[0].ConcertID
+	tickets := 0
+	for _, booking := range bookings {
+		if booking.ConcertID != concertID {
+			return fmt.Errorf("batch bookings must be for one concert")
+		}
+		tickets += booking.TicketCount
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Rolling back a committed transaction is a no-op
+	defer tx.Rollback()
+
+	var concert model.Concert
+	getConcertQuery := `SELECT id, total_tickets, available_tickets, booking_start_time, booking_end_time, version
+		FROM concerts WHERE id = $1 FOR UPDATE`
+	err = tx.GetContext(ctx, &concert, getConcertQuery, concertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkgErr.ErrNotFound
+		}
+		return fmt.Errorf("failed to get concert for booking: %w", err)
+	}
+
+	if concert.Version != concertVersion {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	if !concert.IsBookingOpen() {
+		return pkgErr.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < tickets {
+		return pkgErr.ErrInsufficientTickets
+	}
+
+	updateTicketQuery := `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err = tx.ExecContext(ctx, updateTicketQuery, tickets, concertID); err != nil {
+		if isInventoryExhausted(err) {
+			return pkgErr.ErrInsufficientTickets
+		}
+		return fmt.Errorf("failed to update ticket count: %w", err)
+	}
+
+	createBookingQuery := `
+		INSERT INTO bookings (
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id, booking_time, created_at, updated_at
+	`
+
+	for _, booking := range bookings {
+		if booking.Reference == "" {
+			booking.Reference = reference.New()
+		}
+
+		attendeeName, attendeeEmail, err := r.encryptAttendee(booking)
+		if err != nil {
+			return err
+		}
+
+		err = tx.GetContext(ctx, booking, createBookingQuery,
+			booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+			attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create booking: %w", err)
+		}
+	}
+
+	if err := writeBookingEvents(ctx, tx, model.EventBookingConfirmed, bookings...); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllByUserID retrieves every booking for a user without pagination
+func (r *bookingRepository) GetAllByUserID(ctx context.Context, userID string) ([]*model.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM all_bookings b
+		WHERE b.user_id = $1
+		ORDER BY b.booking_time DESC
+	`
+
+	var bookings []*model.Booking
+	err := r.db.SelectContext(ctx, &bookings, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all user bookings: %w", err)
+	}
+
+	if err := r.decryptAttendee(bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// AnonymizeUser replaces the user ID on all of a user's bookings with a pseudonym
+// and clears the attendee details. Ticket counts and statuses are kept so
+// aggregate sales figures stay correct. The user's orders, booking attempts
+// and booking events take the same pseudonym, and their cart and booking
+// tokens are deleted, all in one transaction so no record is left to link
+// the bookings back to the user.
+func (r *bookingRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Archived bookings hold personal data too
+	statements := []string{
+		`UPDATE bookings SET user_id = $1, attendee_name = '', attendee_email = '', updated_at = NOW() WHERE user_id = $2`,
+		`UPDATE bookings_archive SET user_id = $1, attendee_name = '', attendee_email = '', updated_at = NOW() WHERE user_id = $2`,
+		// The bookings keep their order, so the order mustn't name the user
+		`UPDATE orders SET user_id = $1 WHERE user_id = $2`,
+		`UPDATE booking_attempts SET user_id = $1 WHERE user_id = $2`,
+		// Webhook deliveries copy the events, so both are rewritten
+		`UPDATE outbox_events SET payload = jsonb_set(payload, '{user_id}', to_jsonb($1::text)) WHERE payload->>'user_id' = $2`,
+		`UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{user_id}', to_jsonb($1::text)) WHERE payload->>'user_id' = $2`,
+	}
+
+	var count int
+	for _, statement := range statements {
+		result, err := tx.ExecContext(ctx, statement, pseudonym, userID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to anonymize user data: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		count += int(rowsAffected)
+	}
+
+	// Carts and booking tokens are short-lived and mean nothing without the user
+	for _, table := range []string{"cart_items", "booking_tokens"} {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, table), userID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete user data: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		count += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/reference"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type cartRepository struct {
+	db       *sqlx.DB
+	bookings *bookingRepository
+}
+
+// NewCartRepository creates a new PostgreSQL implementation of
+// CartRepository. Attendee details are encrypted with cipher.
+func NewCartRepository(db *sqlx.DB, cipher crypto.Cipher) repository.CartRepository {
+	return &cartRepository{
+		db:       db,
+		bookings: &bookingRepository{db: db, cipher: cipher},
+	}
+}
+
+// ListItems returns the items of a user's cart
+func (r *cartRepository) ListItems(ctx context.Context, userID string) ([]*model.CartItem, error) {
+	items := []*model.CartItem{}
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT user_id, concert_id, ticket_count, added_at FROM cart_items
+		WHERE user_id = $1
+		ORDER BY added_at, concert_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cart items: %w", err)
+	}
+	return items, nil
+}
+
+// PutItem adds an item to a cart, replacing the item of the same concert
+func (r *cartRepository) PutItem(ctx context.Context, item *model.CartItem) error {
+	err := r.db.GetContext(ctx, &item.AddedAt, `
+		INSERT INTO cart_items (user_id, concert_id, ticket_count)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, concert_id) DO UPDATE SET ticket_count = EXCLUDED.ticket_count
+		RETURNING added_at
+	`, item.UserID, item.ConcertID, item.TicketCount)
+	if err != nil {
+		return fmt.Errorf("failed to put cart item: %w", err)
+	}
+	return nil
+}
+
+// RemoveItem removes the item of a concert from a cart
+func (r *cartRepository) RemoveItem(ctx context.Context, userID string, concertID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cart_items WHERE user_id = $1 AND concert_id = $2`, userID, concertID)
+	if err != nil {
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	if removed, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if removed == 0 {
+		return pkgErr.ErrNotFound
+	}
+	return nil
+}
+
+// Checkout books the bookings of an order in one transaction
+func (r *cartRepository) Checkout(ctx context.Context, order *model.Order, bookings []*model.Booking, versions map[int64]int, bestEffort bool) ([]error, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the concerts in ID order keeps two checkouts sharing concerts
+	// from deadlocking. Tickets of bookings pending in the redis inventory
+	// mode are taken already.
+	concertIDs := make([]int64, 0, len(versions))
+	for id := range versions {
+		concertIDs = append(concertIDs, id)
+	}
+	sort.Slice(concertIDs, func(i, j int) bool { return concertIDs[i] < concertIDs[j] })
+
+	var concerts []*model.Concert
+	err = tx.SelectContext(ctx, &concerts, `
+		SELECT id, total_tickets, booking_start_time, booking_end_time, version,
+			available_tickets - COALESCE((
+				SELECT SUM(b.ticket_count) FROM bookings b WHERE b.concert_id = c.id AND b.inventory_pending
+			), 0) AS available_tickets
+		FROM concerts c WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE
+	`, concertIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get concerts for checkout: %w", err)
+	}
+	if len(concerts) != len(concertIDs) {
+		// A concert was deleted since it was checked
+		return nil, pkgErr.ErrOptimisticLockFailed
+	}
+
+	remaining := make(map[int64]int, len(concerts))
+	for _, concert := range concerts {
+		if concert.Version != versions[concert.ID] {
+			return nil, pkgErr.ErrOptimisticLockFailed
+		}
+		if concert.IsBookingOpen() {
+			remaining[concert.ID] = concert.AvailableTickets
+		}
+	}
+
+	errs := make([]error, len(bookings))
+	booked := make([]*model.Booking, 0, len(bookings))
+	taken := make(map[int64]int)
+	for i, booking := range bookings {
+		left, open := remaining[booking.ConcertID]
+		switch {
+		case !open:
+			errs[i] = pkgErr.ErrBookingClosed
+		case left < booking.TicketCount:
+			errs[i] = pkgErr.ErrInsufficientTickets
+		default:
+			remaining[booking.ConcertID] -= booking.TicketCount
+			taken[booking.ConcertID] += booking.TicketCount
+			booked = append(booked, booking)
+			continue
+		}
+		if !bestEffort {
+			return errs, nil
+		}
+	}
+	if len(booked) == 0 {
+		return errs, nil
+	}
+
+	if order.Reference == "" {
+		order.Reference = reference.New()
+	}
+	err = tx.GetContext(ctx, order, `
+		INSERT INTO orders (reference, user_id, mode, currency)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, reference, user_id, mode, currency, status, created_at, updated_at
+	`, order.Reference, order.UserID, order.Mode, order.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, concertID := range concertIDs {
+		if taken[concertID] == 0 {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE concerts
+			SET available_tickets = available_tickets - $1, version = version + 1, updated_at = NOW()
+			WHERE id = $2
+		`, taken[concertID], concertID)
+		if err != nil {
+			// A reservation in the redis inventory mode got in first
+			if isInventoryExhausted(err) {
+				return nil, pkgErr.ErrInsufficientTickets
+			}
+			return nil, fmt.Errorf("failed to update ticket count: %w", err)
+		}
+	}
+
+	bookedIDs := make([]int64, 0, len(booked))
+	for _, booking := range booked {
+		if booking.Reference == "" {
+			booking.Reference = reference.New()
+		}
+
+		attendeeName, attendeeEmail, err := r.bookings.encryptAttendee(booking)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.GetContext(ctx, booking, `
+			INSERT INTO bookings (
+				concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price, order_id
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8, $9
+			) RETURNING id, booking_time, created_at, updated_at
+		`, booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+			attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, order.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create booking: %w", err)
+		}
+		bookedIDs = append(bookedIDs, booking.ConcertID)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM cart_items WHERE user_id = $1 AND concert_id = ANY($2)`,
+		order.UserID, bookedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to empty cart: %w", err)
+	}
+
+	if err := writeBookingEvents(ctx, tx, model.EventBookingConfirmed, booked...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	order.Bookings = booked
+	return errs, nil
+}
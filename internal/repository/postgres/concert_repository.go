@@ -3,19 +3,26 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/repository"
+	pkgDB "concert-ticket-api/pkg/db"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/normalize"
+	"concert-ticket-api/pkg/query"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type concertRepository struct {
 	db *sqlx.DB
+	// replicas take the reads that may lag behind writes, if set
+	replicas *pkgDB.Replicas
 }
 
 func (r *concertRepository) GetDB() *sqlx.DB {
@@ -24,9 +31,25 @@ func (r *concertRepository) GetDB() *sqlx.DB {
 
 // NewConcertRepository creates a new PostgreSQL implementation of ConcertRepository
 func NewConcertRepository(db *sqlx.DB) repository.ConcertRepository {
+	return NewConcertRepositoryWithReplicas(db, nil)
+}
+
+// NewConcertRepositoryWithReplicas creates a ConcertRepository that reads
+// concerts by ID, lists and counts them on the read replicas. Everything
+// else, the reads for update included, stays on the primary.
+func NewConcertRepositoryWithReplicas(db *sqlx.DB, replicas *pkgDB.Replicas) repository.ConcertRepository {
 	return &concertRepository{
-		db: db,
+		db:       db,
+		replicas: replicas,
+	}
+}
+
+// reader returns the database of the reads that may lag behind writes
+func (r *concertRepository) reader() *sqlx.DB {
+	if r.replicas == nil {
+		return r.db
 	}
+	return r.replicas.Reader()
 }
 
 // GetByID retrieves a concert by its ID
@@ -34,7 +57,7 @@ func (r *concertRepository) GetByID(ctx context.Context, id int64) (*model.Conce
 	query := `SELECT * FROM concerts WHERE id = $1`
 
 	var concert model.Concert
-	err := r.db.GetContext(ctx, &concert, query, id)
+	err := r.reader().GetContext(ctx, &concert, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, pkgErr.ErrNotFound
@@ -45,21 +68,41 @@ func (r *concertRepository) GetByID(ctx context.Context, id int64) (*model.Conce
 	return &concert, nil
 }
 
-// List retrieves concerts with optional filtering
-func (r *concertRepository) List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*model.Concert, error) {
-	where, args := buildWhereClause(filters)
+// concertSortColumns maps the sortable concert fields to columns. Names are
+// sorted by their search keys so that case and accents don't affect the order.
+var concertSortColumns = map[string]string{
+	"concert_date":      "concert_date",
+	"name":              "search_name",
+	"artist":            "search_artist",
+	"price":             "price",
+	"available_tickets": "available_tickets",
+	"created_at":        "created_at",
+}
+
+// List retrieves a page of concerts with optional filtering and sorting
+func (r *concertRepository) List(ctx context.Context, opts query.Options) ([]*model.Concert, error) {
+	where, args := buildWhereClause(opts.Filters)
 
-	query := fmt.Sprintf(`
+	sorts := opts.Sort
+	if len(sorts) == 0 {
+		sorts = []query.Sort{{Field: "concert_date"}}
+	}
+	orderBy, err := query.OrderBy(sorts, concertSortColumns, "id")
+	if err != nil {
+		return nil, pkgErr.ErrInvalidInput(err.Error())
+	}
+
+	stmt := fmt.Sprintf(`
 		SELECT * FROM concerts
 		%s
-		ORDER BY concert_date
+		%s
 		LIMIT $%d OFFSET $%d
-	`, where, len(args)+1, len(args)+2)
+	`, where, orderBy, len(args)+1, len(args)+2)
 
-	args = append(args, limit, offset)
+	args = append(args, opts.Page.Limit(), opts.Page.Offset())
 
 	var concerts []*model.Concert
-	err := r.db.SelectContext(ctx, &concerts, query, args...)
+	err = r.reader().SelectContext(ctx, &concerts, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list concerts: %w", err)
 	}
@@ -68,16 +111,16 @@ func (r *concertRepository) List(ctx context.Context, limit, offset int, filters
 }
 
 // Count returns the total number of concerts matching the filters
-func (r *concertRepository) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+func (r *concertRepository) Count(ctx context.Context, filters query.Filters) (int, error) {
 	where, args := buildWhereClause(filters)
 
-	query := fmt.Sprintf(`
+	stmt := fmt.Sprintf(`
 		SELECT COUNT(*) FROM concerts
 		%s
 	`, where)
 
 	var count int
-	err := r.db.GetContext(ctx, &count, query, args...)
+	err := r.reader().GetContext(ctx, &count, stmt, args...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count concerts: %w", err)
 	}
@@ -85,57 +128,204 @@ func (r *concertRepository) Count(ctx context.Context, filters map[string]interf
 	return count, nil
 }
 
-// Create inserts a new concert
+// exactCountBelow is the estimate under which concerts are counted anyway:
+// small counts are cheap, and the planner's guesses for small or freshly
+// loaded tables are the least reliable
+const exactCountBelow = 10000
+
+// EstimateCount returns the planner's estimate of the number of concerts
+// matching the filters, which it derives from pg_class.reltuples and the
+// column statistics of the last ANALYZE without reading the table. Estimates
+// below exactCountBelow are replaced by a count.
+func (r *concertRepository) EstimateCount(ctx context.Context, filters query.Filters) (int, error) {
+	where, args := buildWhereClause(filters)
+
+	var plan []byte
+	err := r.reader().GetContext(ctx, &plan, `EXPLAIN (FORMAT JSON) SELECT 1 FROM concerts `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate concerts: %w", err)
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &plans); err != nil {
+		return 0, fmt.Errorf("failed to read the estimate of concerts: %w", err)
+	}
+	if len(plans) == 0 {
+		return 0, errors.New("failed to read the estimate of concerts: empty plan")
+	}
+
+	if estimate := int(plans[0].Plan.Rows); estimate >= exactCountBelow {
+		return estimate, nil
+	}
+	return r.Count(ctx, filters)
+}
+
+// LastModified returns the latest Concert.LastModified at the given time
+// across all concerts, listed or not, or the zero time if there are none
+func (r *concertRepository) LastModified(ctx context.Context, at time.Time) (time.Time, error) {
+	// GREATEST ignores the NULL of concerts without a door price or before
+	// their switch, like Concert.LastModified
+	stmt := `
+		SELECT MAX(GREATEST(updated_at, CASE
+			WHEN door_price IS NOT NULL AND concert_date - make_interval(mins => door_price_lead_minutes) <= $1
+			THEN concert_date - make_interval(mins => door_price_lead_minutes)
+		END))
+		FROM concerts
+	`
+
+	var modified sql.NullTime
+	if err := r.db.GetContext(ctx, &modified, stmt, at); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get concert modification time: %w", err)
+	}
+
+	return modified.Time, nil
+}
+
+// Create inserts a new concert, records its initial price and writes its
+// concert.created event
 func (r *concertRepository) Create(ctx context.Context, concert *model.Concert) (*model.Concert, error) {
 	query := `
-		INSERT INTO concerts (
-			name, artist, venue, concert_date, total_tickets, available_tickets,
-			price, booking_start_time, booking_end_time
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
-		) RETURNING *
+		WITH created AS (
+			INSERT INTO concerts (
+				name, artist, venue, concert_date, total_tickets, available_tickets,
+				price, booking_start_time, booking_end_time,
+				artist_aliases, venue_aliases, search_name, search_artist, search_venue,
+				requires_booking_token, currency, organizer_email,
+				door_price, door_price_lead_minutes, visibility, invite_token_hash,
+				max_tickets_per_booking, max_order_value
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23
+			) RETURNING *
+		), snapshot AS (
+			INSERT INTO concert_price_history (concert_id, price, currency)
+			SELECT id, price, currency FROM created
+		)
+		SELECT * FROM created
 	`
 
-	err := r.db.GetContext(ctx, concert, query,
+	setSearchKeys(concert)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, concert, query,
 		concert.Name, concert.Artist, concert.Venue, concert.ConcertDate,
 		concert.TotalTickets, concert.AvailableTickets, concert.Price,
 		concert.BookingStartTime, concert.BookingEndTime,
+		concert.ArtistAliases, concert.VenueAliases,
+		concert.SearchName, concert.SearchArtist, concert.SearchVenue,
+		concert.RequiresBookingToken, concert.Currency, concert.OrganizerEmail,
+		concert.DoorPrice, concert.DoorPriceLeadMinutes, concert.Visibility, concert.InviteTokenHash,
+		concert.MaxTicketsPerBooking, concert.MaxOrderValue,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create concert: %w", err)
 	}
 
+	if err := writeConcertEvent(ctx, tx, model.EventConcertCreated, concert); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return concert, nil
 }
 
-// Update updates an existing concert
+// Update updates an existing concert and records a price snapshot when its
+// price or currency changed. Changing the door price or when it applies
+// makes the pricing job announce the switch again. The concert.updated event
+// is written in the same transaction, with the previous date if the concert
+// was rescheduled.
 func (r *concertRepository) Update(ctx context.Context, concert *model.Concert) error {
+	// All parts of the statement see the row as it was before the update, so
+	// previous holds the old price and date
 	query := `
-		UPDATE concerts
-		SET name = $1, artist = $2, venue = $3, concert_date = $4,
-			total_tickets = $5, available_tickets = $6, price = $7,
-			booking_start_time = $8, booking_end_time = $9,
-			version = version + 1, updated_at = NOW()
-		WHERE id = $10 AND version = $11
+		WITH previous AS (
+			SELECT price, currency, concert_date FROM concerts WHERE id = $18
+		), updated AS (
+			UPDATE concerts
+			SET name = $1, artist = $2, venue = $3, concert_date = $4,
+				total_tickets = $5, available_tickets = $6, price = $7,
+				booking_start_time = $8, booking_end_time = $9,
+				artist_aliases = $10, venue_aliases = $11,
+				search_name = $12, search_artist = $13, search_venue = $14,
+				requires_booking_token = $15, currency = $16, organizer_email = $17,
+				door_price_switched_at = CASE
+					WHEN door_price IS DISTINCT FROM $20 OR door_price_lead_minutes <> $21
+						OR concert_date <> $4 THEN NULL
+					ELSE door_price_switched_at
+				END,
+				door_price = $20, door_price_lead_minutes = $21,
+				visibility = $22, invite_token_hash = $23,
+				max_tickets_per_booking = $24, max_order_value = $25,
+				version = version + 1, updated_at = NOW()
+			WHERE id = $18 AND version = $19
+			RETURNING id, price, currency
+		), snapshot AS (
+			INSERT INTO concert_price_history (concert_id, price, currency)
+			SELECT u.id, u.price, u.currency FROM updated u, previous p
+			WHERE u.price <> p.price OR u.currency <> p.currency
+		)
+		SELECT (SELECT COUNT(*) FROM updated) AS updated, (SELECT concert_date FROM previous) AS previous_date
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	setSearchKeys(concert)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var result struct {
+		Updated      int        `db:"updated"`
+		PreviousDate *time.Time `db:"previous_date"`
+	}
+	err = tx.GetContext(ctx, &result, query,
 		concert.Name, concert.Artist, concert.Venue, concert.ConcertDate,
 		concert.TotalTickets, concert.AvailableTickets, concert.Price,
 		concert.BookingStartTime, concert.BookingEndTime,
+		concert.ArtistAliases, concert.VenueAliases,
+		concert.SearchName, concert.SearchArtist, concert.SearchVenue,
+		concert.RequiresBookingToken, concert.Currency, concert.OrganizerEmail,
 		concert.ID, concert.Version,
+		concert.DoorPrice, concert.DoorPriceLeadMinutes,
+		concert.Visibility, concert.InviteTokenHash,
+		concert.MaxTicketsPerBooking, concert.MaxOrderValue,
 	)
 	if err != nil {
+		// Fewer tickets are left than pending bookings took
+		if isInventoryExhausted(err) {
+			return pkgErr.ErrInsufficientTickets
+		}
 		return fmt.Errorf("failed to update concert: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if result.Updated == 0 {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	updated := *concert
+	updated.Version++
+	event, err := model.NewConcertUpdatedEvent(&updated, *result.PreviousDate)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to encode %s event: %w", model.EventConcertUpdated, err)
+	}
+	if err := writeEvents(ctx, tx, event); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return pkgErr.ErrOptimisticLockFailed
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	// Increment version for the caller
@@ -144,6 +334,61 @@ func (r *concertRepository) Update(ctx context.Context, concert *model.Concert)
 	return nil
 }
 
+// GetByIDs retrieves the concerts with the given IDs. Unknown IDs are skipped.
+func (r *concertRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	var concerts []*model.Concert
+	err := r.db.SelectContext(ctx, &concerts, `SELECT * FROM concerts WHERE id = ANY($1) ORDER BY id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get concerts: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+func (r *concertRepository) GetPriceHistory(ctx context.Context, concertID int64) ([]*model.PriceSnapshot, error) {
+	query := `
+		SELECT price, currency, recorded_at FROM concert_price_history
+		WHERE concert_id = $1
+		ORDER BY recorded_at, id
+	`
+
+	var snapshots []*model.PriceSnapshot
+	err := r.db.SelectContext(ctx, &snapshots, query, concertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// SwitchDueDoorPrices marks concerts whose door price took effect as
+// switched and returns them
+func (r *concertRepository) SwitchDueDoorPrices(ctx context.Context, now time.Time, limit int) ([]*model.Concert, error) {
+	// SKIP LOCKED lets several instances run the pricing job without
+	// announcing the same switch twice
+	query := `
+		UPDATE concerts
+		SET door_price_switched_at = $1
+		WHERE id IN (
+			SELECT id FROM concerts
+			WHERE door_price IS NOT NULL AND door_price_switched_at IS NULL
+				AND concert_date - make_interval(mins => door_price_lead_minutes) <= $1
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	var concerts []*model.Concert
+	if err := r.db.SelectContext(ctx, &concerts, query, now, limit); err != nil {
+		return nil, fmt.Errorf("failed to switch door prices: %w", err)
+	}
+
+	return concerts, nil
+}
+
 // GetForUpdate retrieves a concert for update with row locking
 func (r *concertRepository) GetForUpdate(ctx context.Context, id int64) (*model.Concert, error) {
 	query := `SELECT * FROM concerts WHERE id = $1 FOR UPDATE`
@@ -172,6 +417,9 @@ func (r *concertRepository) UpdateTicketCount(ctx context.Context, id int64, ver
 
 	result, err := r.db.ExecContext(ctx, query, ticketCount, id, version)
 	if err != nil {
+		if isInventoryExhausted(err) {
+			return pkgErr.ErrInsufficientTickets
+		}
 		return fmt.Errorf("failed to update ticket count: %w", err)
 	}
 
@@ -201,37 +449,168 @@ func (r *concertRepository) UpdateTicketCount(ctx context.Context, id int64, ver
 	return nil
 }
 
-// Helper function to build WHERE clause from filters
-func buildWhereClause(filters map[string]interface{}) (string, []interface{}) {
-	if len(filters) == 0 {
-		return "", nil
+// CreateInvites inserts invites of a concert and sets their IDs
+func (r *concertRepository) CreateInvites(ctx context.Context, invites []*model.ConcertInvite) error {
+	if len(invites) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO concert_invites (concert_id, invitee, single_use, token_hash)
+		VALUES (:concert_id, :invitee, :single_use, :token_hash)
+		RETURNING id, token_hash, created_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, r.db, query, invites)
+	if err != nil {
+		return fmt.Errorf("failed to insert invites: %w", err)
+	}
+	defer rows.Close()
+
+	// Rows come back in no guaranteed order, so match them by token hash
+	byHash := make(map[string]*model.ConcertInvite, len(invites))
+	for _, invite := range invites {
+		byHash[invite.TokenHash] = invite
+	}
+
+	for rows.Next() {
+		var created model.ConcertInvite
+		if err := rows.StructScan(&created); err != nil {
+			return fmt.Errorf("failed to scan invite: %w", err)
+		}
+		if invite, ok := byHash[created.TokenHash]; ok {
+			invite.ID = created.ID
+			invite.CreatedAt = created.CreatedAt
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to insert invites: %w", err)
+	}
+
+	return nil
+}
+
+// GetInviteByTokenHash retrieves the invite of a concert by its token hash
+func (r *concertRepository) GetInviteByTokenHash(ctx context.Context, concertID int64, tokenHash string) (*model.ConcertInvite, error) {
+	var invite model.ConcertInvite
+	err := r.db.GetContext(ctx, &invite, `SELECT * FROM concert_invites WHERE concert_id = $1 AND token_hash = $2`, concertID, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
 	}
 
-	var conditions []string
+	return &invite, nil
+}
+
+// RedeemInvite records a booking made with an invite. Claiming a single-use
+// invite and recording the redemption is one statement, so concurrent
+// bookings can't both redeem it.
+func (r *concertRepository) RedeemInvite(ctx context.Context, redemption *model.InviteRedemption) error {
+	query := `
+		WITH claimed AS (
+			UPDATE concert_invites SET redemptions = redemptions + 1
+			WHERE id = $1 AND (NOT single_use OR redemptions = 0)
+			RETURNING id
+		)
+		INSERT INTO concert_invite_redemptions (invite_id, booking_reference, redeemed_at)
+		SELECT id, $2, $3 FROM claimed
+	`
+
+	result, err := r.db.ExecContext(ctx, query, redemption.InviteID, redemption.BookingReference, redemption.RedeemedAt)
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return pkgErr.ErrInviteRedeemed
+	}
+
+	return nil
+}
+
+// WithdrawInviteRedemption removes the redemption of a booking that wasn't made
+func (r *concertRepository) WithdrawInviteRedemption(ctx context.Context, redemption *model.InviteRedemption) error {
+	query := `
+		WITH withdrawn AS (
+			DELETE FROM concert_invite_redemptions
+			WHERE invite_id = $1 AND booking_reference = $2
+			RETURNING invite_id
+		)
+		UPDATE concert_invites SET redemptions = redemptions - 1
+		WHERE id IN (SELECT invite_id FROM withdrawn)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, redemption.InviteID, redemption.BookingReference); err != nil {
+		return fmt.Errorf("failed to withdraw invite redemption: %w", err)
+	}
+
+	return nil
+}
+
+// ListInviteRedemptions lists the invites of a concert with their redemptions
+func (r *concertRepository) ListInviteRedemptions(ctx context.Context, concertID int64) ([]*model.InviteExportRow, error) {
+	// User IDs come from the bookings, so erasing a user's data covers the export
+	query := `
+		SELECT i.id AS invite_id, i.invitee, i.single_use,
+			ir.booking_reference, ir.redeemed_at,
+			b.user_id, b.ticket_count, b.status AS booking_status
+		FROM concert_invites i
+		LEFT JOIN concert_invite_redemptions ir ON ir.invite_id = i.id
+		LEFT JOIN all_bookings b ON b.reference = ir.booking_reference
+		WHERE i.concert_id = $1
+		ORDER BY i.id, ir.redeemed_at
+	`
+
+	rows := []*model.InviteExportRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, concertID); err != nil {
+		return nil, fmt.Errorf("failed to list invite redemptions: %w", err)
+	}
+
+	return rows, nil
+}
+
+// setSearchKeys computes the normalized search keys of a concert from its names and aliases
+func setSearchKeys(concert *model.Concert) {
+	concert.SearchName = normalize.SearchKey(concert.Name)
+	concert.SearchArtist = normalize.SearchKey(concert.Artist, concert.ArtistAliases...)
+	concert.SearchVenue = normalize.SearchKey(concert.Venue, concert.VenueAliases...)
+}
+
+// buildWhereClause builds the WHERE clause of a listing from its filters.
+// Listings only ever contain public concerts; unlisted and private ones are
+// reachable by ID only.
+func buildWhereClause(filters query.Filters) (string, []interface{}) {
+	conditions := []string{fmt.Sprintf("visibility = '%s'", model.VisibilityPublic)}
 	var args []interface{}
-	i := 1
 
 	for key, value := range filters {
 		switch key {
 		case "artist":
-			conditions = append(conditions, fmt.Sprintf("artist ILIKE $%d", i))
-			args = append(args, fmt.Sprintf("%%%v%%", value))
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, fmt.Sprintf("search_artist LIKE $%d", len(args)))
 		case "venue":
-			conditions = append(conditions, fmt.Sprintf("venue ILIKE $%d", i))
-			args = append(args, fmt.Sprintf("%%%v%%", value))
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, fmt.Sprintf("search_venue LIKE $%d", len(args)))
 		case "date_from":
-			conditions = append(conditions, fmt.Sprintf("concert_date >= $%d", i))
 			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("concert_date >= $%d", len(args)))
 		case "date_to":
-			conditions = append(conditions, fmt.Sprintf("concert_date <= $%d", i))
 			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("concert_date <= $%d", len(args)))
 		case "name":
-			conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", i))
-			args = append(args, fmt.Sprintf("%%%v%%", value))
+			args = append(args, fmt.Sprintf("%%%s%%", normalize.Text(fmt.Sprint(value))))
+			conditions = append(conditions, fmt.Sprintf("search_name LIKE $%d", len(args)))
 		case "available":
-			conditions = append(conditions, fmt.Sprintf("available_tickets > 0"))
+			conditions = append(conditions, "available_tickets > 0")
 		}
-		i++
 	}
 
 	return "WHERE " + strings.Join(conditions, " AND "), args
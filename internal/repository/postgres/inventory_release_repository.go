@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type inventoryReleaseRepository struct {
+	db *sqlx.DB
+}
+
+// NewInventoryReleaseRepository creates a new PostgreSQL implementation of InventoryReleaseRepository
+func NewInventoryReleaseRepository(db *sqlx.DB) repository.InventoryReleaseRepository {
+	return &inventoryReleaseRepository{
+		db: db,
+	}
+}
+
+// Create schedules a release and holds its tickets back in one transaction
+func (r *inventoryReleaseRepository) Create(ctx context.Context, release *model.InventoryRelease) (*model.InventoryRelease, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Bumping the version makes bookings that read the old availability retry
+	holdQuery := `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND available_tickets >= $1
+	`
+	result, err := tx.ExecContext(ctx, holdQuery, release.Quantity, release.ConcertID)
+	if isInventoryExhausted(err) {
+		return nil, pkgErr.ErrInsufficientTickets
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to hold back release tickets: %w", err)
+	}
+	if held, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if held == 0 {
+		return nil, r.holdError(ctx, tx, release.ConcertID)
+	}
+
+	insertQuery := `
+		INSERT INTO concert_inventory_releases (concert_id, quantity, release_at)
+		VALUES ($1, $2, $3)
+		RETURNING *
+	`
+	if err := tx.GetContext(ctx, release, insertQuery, release.ConcertID, release.Quantity, release.ReleaseAt); err != nil {
+		return nil, fmt.Errorf("failed to create inventory release: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return release, nil
+}
+
+// holdError tells an unknown concert apart from one without enough tickets
+func (r *inventoryReleaseRepository) holdError(ctx context.Context, tx *sqlx.Tx, concertID int64) error {
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM concerts WHERE id = $1)`, concertID); err != nil {
+		return fmt.Errorf("failed to check concert: %w", err)
+	}
+	if !exists {
+		return pkgErr.ErrNotFound
+	}
+	return pkgErr.ErrInsufficientTickets
+}
+
+// ListByConcertID retrieves the releases of a concert in release order
+func (r *inventoryReleaseRepository) ListByConcertID(ctx context.Context, concertID int64) ([]*model.InventoryRelease, error) {
+	query := `
+		SELECT * FROM concert_inventory_releases
+		WHERE concert_id = $1
+		ORDER BY release_at, id
+	`
+
+	releases := []*model.InventoryRelease{}
+	if err := r.db.SelectContext(ctx, &releases, query, concertID); err != nil {
+		return nil, fmt.Errorf("failed to list inventory releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+// Cancel deletes a pending release and returns its tickets in one transaction
+func (r *inventoryReleaseRepository) Cancel(ctx context.Context, concertID, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var quantity int
+	deleteQuery := `
+		DELETE FROM concert_inventory_releases
+		WHERE id = $1 AND concert_id = $2 AND released_at IS NULL
+		RETURNING quantity
+	`
+	if err := tx.GetContext(ctx, &quantity, deleteQuery, id, concertID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkgErr.ErrNotFound
+		}
+		return fmt.Errorf("failed to cancel inventory release: %w", err)
+	}
+
+	if err := addAvailableTickets(ctx, tx, concertID, quantity, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseDue executes the pending releases that are due in one transaction
+func (r *inventoryReleaseRepository) ReleaseDue(ctx context.Context, now time.Time, limit int) ([]*model.ReleasedInventory, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// SKIP LOCKED lets several instances run the release job without
+	// executing the same release twice
+	dueQuery := `
+		SELECT * FROM concert_inventory_releases
+		WHERE released_at IS NULL AND release_at <= $1
+		ORDER BY release_at, id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	var releases []*model.InventoryRelease
+	if err := tx.SelectContext(ctx, &releases, dueQuery, now, limit); err != nil {
+		return nil, fmt.Errorf("failed to get due inventory releases: %w", err)
+	}
+
+	released := make([]*model.ReleasedInventory, 0, len(releases))
+	for _, release := range releases {
+		result := &model.ReleasedInventory{Release: release}
+		if err := addAvailableTickets(ctx, tx, release.ConcertID, release.Quantity, result); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE concert_inventory_releases SET released_at = $1 WHERE id = $2`, now, release.ID); err != nil {
+			return nil, fmt.Errorf("failed to mark inventory release as released: %w", err)
+		}
+		releasedAt := now
+		release.ReleasedAt = &releasedAt
+
+		released = append(released, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return released, nil
+}
+
+// addAvailableTickets returns held back tickets to a concert and, if result
+// is given, records the concert's tickets afterwards
+func addAvailableTickets(ctx context.Context, tx *sqlx.Tx, concertID int64, quantity int, result *model.ReleasedInventory) error {
+	query := `
+		UPDATE concerts
+		SET available_tickets = available_tickets + $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING available_tickets, total_tickets
+	`
+
+	var counts struct {
+		AvailableTickets int `db:"available_tickets"`
+		TotalTickets     int `db:"total_tickets"`
+	}
+	if err := tx.GetContext(ctx, &counts, query, quantity, concertID); err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	if result != nil {
+		result.AvailableTickets = counts.AvailableTickets
+		result.TotalTickets = counts.TotalTickets
+	}
+
+	return nil
+}
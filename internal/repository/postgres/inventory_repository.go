@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/reference"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// inventoryLockNamespace is the first key of a concert's inventory lock,
+// which reservations hold shared and the concert_inventory_guard trigger
+// takes exclusively for every change of the concert's tickets
+const inventoryLockNamespace = 0x696e7674 // "invt"
+
+// inventoryConstraint names the check of the concert_inventory_guard trigger
+const inventoryConstraint = "concert_inventory"
+
+type inventoryRepository struct {
+	db       *sqlx.DB
+	bookings *bookingRepository
+}
+
+// NewInventoryRepository creates a new PostgreSQL implementation of
+// InventoryRepository. Attendee details are encrypted with cipher.
+func NewInventoryRepository(db *sqlx.DB, cipher crypto.Cipher) repository.InventoryRepository {
+	return &inventoryRepository{
+		db:       db,
+		bookings: &bookingRepository{db: db, cipher: cipher},
+	}
+}
+
+// isInventoryExhausted reports whether err is the concert_inventory_guard
+// trigger refusing a change that would give away tickets of pending bookings
+func isInventoryExhausted(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation && pgErr.ConstraintName == inventoryConstraint
+}
+
+// Reserve reserves the tickets of a booking with the counter and creates it
+func (r *inventoryRepository) Reserve(ctx context.Context, booking *model.Booking, counter repository.InventoryCounter) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The row lock comes before the inventory lock, in the order changes of
+	// the tickets take them. It is the one inserting the booking takes
+	// anyway, so it only waits for bookings locking the concert for update.
+	var exists bool
+	err = tx.GetContext(ctx, &exists, `SELECT TRUE FROM concerts WHERE id = $1 FOR KEY SHARE`, booking.ConcertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, pkgErr.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to lock concert: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock_shared($1, $2)`, inventoryLockNamespace, booking.ConcertID); err != nil {
+		return 0, fmt.Errorf("failed to lock concert inventory: %w", err)
+	}
+
+	// Read once the lock is held, so no change of the tickets is under way
+	concert, available, err := r.inventory(ctx, tx, booking.ConcertID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !concert.IsBookingOpen() {
+		return 0, pkgErr.ErrBookingClosed
+	}
+
+	left, err := counter.Reserve(ctx, booking.ConcertID, booking.TicketCount, concert.Version, available)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.createPending(ctx, tx, booking); err != nil {
+		// The lock is still held, so the counter has the same version. A
+		// booking that fails to commit keeps its tickets reserved instead,
+		// which leaves them unsold until the concert changes.
+		if releaseErr := counter.Release(ctx, booking.ConcertID, booking.TicketCount, concert.Version); releaseErr != nil {
+			return 0, fmt.Errorf("%w; and failed to release its tickets: %v", err, releaseErr)
+		}
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return left, nil
+}
+
+// inventory reads the booking window and version of a concert and how many
+// of its tickets aren't taken by pending bookings
+func (r *inventoryRepository) inventory(ctx context.Context, tx *sqlx.Tx, concertID int64) (*model.Concert, int, error) {
+	var concert model.Concert
+	err := tx.GetContext(ctx, &concert, `SELECT id, total_tickets, available_tickets, booking_start_time, booking_end_time, version
+		FROM concerts WHERE id = $1`, concertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, pkgErr.ErrNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to get concert inventory: %w", err)
+	}
+
+	var pending int
+	err = tx.GetContext(ctx, &pending, `SELECT COALESCE(SUM(ticket_count), 0) FROM bookings
+		WHERE concert_id = $1 AND inventory_pending`, concertID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get pending bookings: %w", err)
+	}
+
+	return &concert, concert.AvailableTickets - pending, nil
+}
+
+// createPending inserts a booking whose tickets are yet to be subtracted
+func (r *inventoryRepository) createPending(ctx context.Context, tx *sqlx.Tx, booking *model.Booking) error {
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+
+	attendeeName, attendeeEmail, err := r.bookings.encryptAttendee(booking)
+	if err != nil {
+		return err
+	}
+
+	err = tx.GetContext(ctx, booking, `
+		INSERT INTO bookings (
+			concert_id, user_id, ticket_count, status, attendee_name, attendee_email, reference, unit_price,
+			idempotency_key, inventory_pending
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), TRUE
+		) RETURNING id, booking_time, created_at, updated_at
+	`, booking.ConcertID, booking.UserID, booking.TicketCount, booking.Status,
+		attendeeName, attendeeEmail, booking.Reference, booking.UnitPrice, booking.IdempotencyKey)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if booking.IdempotencyKey != "" && errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return pkgErr.ErrIdempotencyKeyInUse
+		}
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	// A confirmed booking is confirmed once it commits; only its tickets are
+	// pending
+	return writeBookedEvents(ctx, tx, booking)
+}
+
+// Seed sets the counter of a concert to the tickets not taken
+func (r *inventoryRepository) Seed(ctx context.Context, concertID int64, counter repository.InventoryCounter) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Holding the lock exclusively, no reservation is under way either
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, inventoryLockNamespace, concertID); err != nil {
+		return fmt.Errorf("failed to lock concert inventory: %w", err)
+	}
+
+	concert, available, err := r.inventory(ctx, tx, concertID)
+	if err != nil {
+		return err
+	}
+
+	return counter.Seed(ctx, concertID, concert.Version, available)
+}
+
+// Flush subtracts the tickets of pending bookings from their concerts
+func (r *inventoryRepository) Flush(ctx context.Context) (int, error) {
+	var concertIDs []int64
+	if err := r.db.SelectContext(ctx, &concertIDs, `SELECT DISTINCT concert_id FROM bookings WHERE inventory_pending`); err != nil {
+		return 0, fmt.Errorf("failed to list concerts with pending bookings: %w", err)
+	}
+
+	settled := 0
+	for _, concertID := range concertIDs {
+		n, err := r.flushConcert(ctx, concertID)
+		if err != nil {
+			return settled, err
+		}
+		settled += n
+	}
+	return settled, nil
+}
+
+// flushConcert subtracts the tickets of a concert's pending bookings
+func (r *inventoryRepository) flushConcert(ctx context.Context, concertID int64) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The concert row, then the inventory lock, as every change of the
+	// tickets takes them
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM concerts WHERE id = $1 FOR NO KEY UPDATE`, concertID); err != nil {
+		return 0, fmt.Errorf("failed to lock concert: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, inventoryLockNamespace, concertID); err != nil {
+		return 0, fmt.Errorf("failed to lock concert inventory: %w", err)
+	}
+
+	// Bookings being cancelled hold their row and subtract their own
+	// tickets, so they are skipped rather than waited for, which could
+	// deadlock with the cancellation waiting for the concert row
+	var settled []int
+	err = tx.SelectContext(ctx, &settled, `
+		UPDATE bookings SET inventory_pending = FALSE
+		WHERE id IN (
+			SELECT id FROM bookings WHERE concert_id = $1 AND inventory_pending
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ticket_count
+	`, concertID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to settle pending bookings: %w", err)
+	}
+	if len(settled) == 0 {
+		return 0, nil
+	}
+
+	tickets := 0
+	for _, count := range settled {
+		tickets += count
+	}
+
+	// The tickets not taken stay the same, but the counter is reset to them
+	// once the version changes
+	_, err = tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2
+	`, tickets, concertID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to subtract pending tickets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(settled), nil
+}
+
+// settlePending subtracts the tickets of a booking that is still pending from
+// its concert, so cancelling it can return them like those of any other
+func settlePending(ctx context.Context, tx *sqlx.Tx, bookingID int64) error {
+	var booking struct {
+		ConcertID   int64 `db:"concert_id"`
+		TicketCount int   `db:"ticket_count"`
+	}
+	err := tx.GetContext(ctx, &booking, `
+		UPDATE bookings SET inventory_pending = FALSE
+		WHERE id = $1 AND inventory_pending
+		RETURNING concert_id, ticket_count
+	`, bookingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to settle booking: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE concerts
+		SET available_tickets = available_tickets - $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2
+	`, booking.TicketCount, booking.ConcertID)
+	if err != nil {
+		return fmt.Errorf("failed to subtract pending tickets: %w", err)
+	}
+	return nil
+}
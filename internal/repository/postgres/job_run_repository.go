@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// jobLockNamespace is the first key of the advisory locks of background jobs,
+// keeping them apart from advisory locks taken for other purposes
+const jobLockNamespace = 0x6a6f6273 // "jobs"
+
+type jobRunRepository struct {
+	db *sqlx.DB
+}
+
+// NewJobRunRepository creates a new PostgreSQL implementation of JobRunRepository
+func NewJobRunRepository(db *sqlx.DB) repository.JobRunRepository {
+	return &jobRunRepository{
+		db: db,
+	}
+}
+
+// RecordRun stores a finished run
+func (r *jobRunRepository) RecordRun(ctx context.Context, run *worker.Run) error {
+	query := `
+		INSERT INTO job_runs (job_name, instance_id, started_at, finished_at, outcome, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		run.Job, run.InstanceID, run.StartedAt, run.FinishedAt, run.Outcome, run.Error,
+	).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns a page of the runs of a job, latest first, and the total
+// number of its runs
+func (r *jobRunRepository) ListRuns(ctx context.Context, job string, page query.Page) ([]*worker.Run, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM job_runs WHERE job_name = $1", job); err != nil {
+		return nil, 0, fmt.Errorf("failed to count job runs: %w", err)
+	}
+
+	query := `
+		SELECT id, job_name, instance_id, started_at, finished_at, outcome, error
+		FROM job_runs
+		WHERE job_name = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	runs := []*worker.Run{}
+	if err := r.db.SelectContext(ctx, &runs, query, job, page.Limit(), page.Offset()); err != nil {
+		return nil, 0, fmt.Errorf("failed to list job runs: %w", err)
+	}
+
+	return runs, total, nil
+}
+
+// PurgeBefore deletes the runs that started before the given time
+func (r *jobRunRepository) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM job_runs WHERE started_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge job runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+type jobLocker struct {
+	db *sqlx.DB
+}
+
+// NewJobLocker creates a worker.Locker backed by session-level PostgreSQL
+// advisory locks. Each lock holds a connection of the pool until it is
+// released, and a crashed instance releases its locks when its connections
+// close.
+func NewJobLocker(db *sqlx.DB) worker.Locker {
+	return &jobLocker{
+		db: db,
+	}
+}
+
+// TryLock takes the advisory lock of a job without waiting
+func (l *jobLocker) TryLock(ctx context.Context, job string) (func(), bool, error) {
+	// Session locks belong to a connection, so lock and unlock on the same one
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get a connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRowxContext(ctx, "SELECT pg_try_advisory_lock($1, hashtext($2))", jobLockNamespace, job).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The run's context may be done by now. A connection that fails to
+		// unlock is discarded instead of returned to the pool, which ends its
+		// session and the lock with it.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))", jobLockNamespace, job); err != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
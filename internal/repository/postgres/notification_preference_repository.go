@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type notificationPreferenceRepository struct {
+	db     *sqlx.DB
+	cipher crypto.Cipher
+}
+
+// NewNotificationPreferenceRepository creates a new PostgreSQL
+// implementation of NotificationPreferenceRepository. Phone numbers are
+// encrypted with cipher.
+func NewNotificationPreferenceRepository(db *sqlx.DB, cipher crypto.Cipher) repository.NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+// Get retrieves the preferences of a user
+func (r *notificationPreferenceRepository) Get(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	var preferences model.NotificationPreferences
+	err := r.db.GetContext(ctx, &preferences, `
+		SELECT user_id, email, sms, push, phone_number, types, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	if preferences.PhoneNumber, err = r.cipher.Decrypt(preferences.PhoneNumber, "notification_preferences.phone_number", preferences.UserID); err != nil {
+		return nil, fmt.Errorf("failed to decrypt phone number: %w", err)
+	}
+
+	return &preferences, nil
+}
+
+// Set creates or replaces the preferences of a user
+func (r *notificationPreferenceRepository) Set(ctx context.Context, preferences *model.NotificationPreferences) error {
+	phoneNumber, err := r.cipher.Encrypt(preferences.PhoneNumber, "notification_preferences.phone_number", preferences.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt phone number: %w", err)
+	}
+
+	err = r.db.QueryRowxContext(ctx, `
+		INSERT INTO notification_preferences (user_id, email, sms, push, phone_number, types, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE
+		SET email = EXCLUDED.email, sms = EXCLUDED.sms, push = EXCLUDED.push, phone_number = EXCLUDED.phone_number,
+			types = EXCLUDED.types, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`, preferences.UserID, preferences.Email, preferences.SMS, preferences.Push, phoneNumber, preferences.Types).
+		Scan(&preferences.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set notification preferences: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the preferences of a user
+func (r *notificationPreferenceRepository) Delete(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification preferences: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// notificationColumns lists the notification columns selected by queries
+const notificationColumns = `id, channel, template, booking_id, event_id, previous_concert_date, status, attempts,
+	next_attempt_at, last_error, sent_at, created_at`
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationRepository creates a new PostgreSQL implementation of
+// NotificationRepository
+func NewNotificationRepository(db *sqlx.DB) repository.NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+// ListEvents retrieves the oldest events not yet notified in ID order
+func (r *notificationRepository) ListEvents(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	events := []*model.OutboxEvent{}
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, attempts
+		FROM outbox_events
+		WHERE notified_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events to notify: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkEventsNotified records that events were turned into notifications
+func (r *notificationRepository) MarkEventsNotified(ctx context.Context, ids []int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox_events SET notified_at = $1 WHERE id = ANY($2)`, at, ids)
+	if err != nil {
+		return fmt.Errorf("failed to mark events notified: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue creates notifications in one transaction, skipping the existing ones
+func (r *notificationRepository) Enqueue(ctx context.Context, notifications []*model.Notification) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := 0
+	for _, notification := range notifications {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO notifications (channel, template, booking_id, event_id, previous_concert_date, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (channel, template, booking_id, event_id) DO NOTHING
+		`, notification.Channel, notification.Template, notification.BookingID, notification.EventID,
+			notification.PreviousConcertDate, notification.NextAttemptAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to enqueue notification: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		created += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// EnqueueReminders creates the email reminders of the concerts taking place
+// in a window in one statement, skipping users who turned email or the
+// template off
+func (r *notificationRepository) EnqueueReminders(ctx context.Context, template string, from, to, at time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO notifications (channel, template, booking_id, event_id, next_attempt_at)
+		SELECT $1, $2, b.id, 0, $5
+		FROM bookings b
+		JOIN concerts c ON c.id = b.concert_id
+		WHERE b.status = 'confirmed' AND b.attendee_email <> ''
+			AND c.concert_date > $3 AND c.concert_date <= $4
+			AND NOT EXISTS (
+				SELECT 1 FROM notification_preferences p
+				WHERE p.user_id = b.user_id AND (NOT p.email OR p.types->'email'->>$2::text = 'false')
+			)
+		ON CONFLICT (channel, template, booking_id, event_id) DO NOTHING
+	`, model.NotificationChannelEmail, template, from, to, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue reminders: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ListDue retrieves the pending notifications due at a time
+func (r *notificationRepository) ListDue(ctx context.Context, at time.Time, limit int) ([]*model.Notification, error) {
+	notifications := []*model.Notification{}
+	err := r.db.SelectContext(ctx, &notifications, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at, id
+		LIMIT $2
+	`, at, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// RecordAttempt saves the outcome of an attempt to send a notification
+func (r *notificationRepository) RecordAttempt(ctx context.Context, notification *model.Notification) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, sent_at = $5
+		WHERE id = $6
+	`, notification.Status, notification.Attempts, notification.NextAttemptAt, notification.LastError,
+		notification.SentAt, notification.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record notification attempt: %w", err)
+	}
+
+	return nil
+}
+
+// Purge deletes the sent and dead notifications created before a time
+func (r *notificationRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM notifications
+		WHERE created_at < $1 AND status <> 'pending'
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
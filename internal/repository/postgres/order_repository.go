@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// orderColumns lists the order columns selected by queries
+const orderColumns = `id, reference, user_id, mode, currency, status, created_at, updated_at`
+
+type orderRepository struct {
+	db       *sqlx.DB
+	bookings *bookingRepository
+}
+
+// NewOrderRepository creates a new PostgreSQL implementation of
+// OrderRepository. Attendee details of the bookings are decrypted with cipher.
+func NewOrderRepository(db *sqlx.DB, cipher crypto.Cipher) repository.OrderRepository {
+	return &orderRepository{
+		db:       db,
+		bookings: &bookingRepository{db: db, cipher: cipher},
+	}
+}
+
+// GetByReference retrieves an order by its public reference
+func (r *orderRepository) GetByReference(ctx context.Context, reference string) (*model.Order, error) {
+	var order model.Order
+	err := r.db.GetContext(ctx, &order, `SELECT `+orderColumns+` FROM orders WHERE reference = $1`, reference)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if err := r.withBookings(ctx, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetByUserID retrieves the orders of a user, newest first
+func (r *orderRepository) GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Order, error) {
+	orders := []*model.Order{}
+	err := r.db.SelectContext(ctx, &orders, `
+		SELECT `+orderColumns+` FROM orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, page.Limit(), page.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user orders: %w", err)
+	}
+
+	if err := r.withBookings(ctx, orders...); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// withBookings sets the bookings of orders in one query
+func (r *orderRepository) withBookings(ctx context.Context, orders ...*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Order, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		order.Bookings = []*model.Booking{}
+		byID[order.ID] = order
+		ids = append(ids, order.ID)
+	}
+
+	var rows []struct {
+		model.Booking
+		OrderID int64 `db:"order_id"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+bookingColumns+`, b.order_id
+		FROM all_bookings b
+		WHERE b.order_id = ANY($1)
+		ORDER BY b.id
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get order bookings: %w", err)
+	}
+
+	for i := range rows {
+		booking := &rows[i].Booking
+		if err := r.bookings.decryptAttendee(booking); err != nil {
+			return err
+		}
+		order := byID[rows[i].OrderID]
+		order.Bookings = append(order.Bookings, booking)
+	}
+	return nil
+}
+
+// Cancel cancels an order and its confirmed bookings in one transaction
+func (r *orderRepository) Cancel(ctx context.Context, orderID int64) ([]*model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The update locks the order row, so of two concurrent cancellations the
+	// second sees it cancelled
+	result, err := tx.ExecContext(ctx, `
+		UPDATE orders SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status <> $1
+	`, model.BookingStatusCancelled, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel order: %w", err)
+	}
+	if cancelled, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if cancelled == 0 {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)`, orderID); err != nil {
+			return nil, fmt.Errorf("failed to get order: %w", err)
+		}
+		if !exists {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, pkgErr.ErrOrderAlreadyCancelled
+	}
+
+	// Bookings cancelled on their own already returned their tickets. The
+	// update waits for a concurrent cancellation of a booking and then skips it.
+	var cancelled []*model.Booking
+	err = tx.SelectContext(ctx, &cancelled, `
+		UPDATE bookings SET status = $1, updated_at = NOW()
+		WHERE order_id = $2 AND status <> $1
+		RETURNING id, concert_id, user_id, ticket_count, booking_time, status, reference, unit_price
+	`, model.BookingStatusCancelled, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel order bookings: %w", err)
+	}
+	if err := writeBookingEvents(ctx, tx, model.EventBookingCancelled, cancelled...); err != nil {
+		return nil, err
+	}
+
+	released := make(map[int64]int)
+	for _, booking := range cancelled {
+		released[booking.ConcertID] += booking.TicketCount
+	}
+	concertIDs := make([]int64, 0, len(released))
+	for id := range released {
+		concertIDs = append(concertIDs, id)
+	}
+	// Concerts are locked in ID order, like checkouts lock them
+	sort.Slice(concertIDs, func(i, j int) bool { return concertIDs[i] < concertIDs[j] })
+
+	availabilities := make([]*model.ConcertAvailability, 0, len(concertIDs))
+	for _, concertID := range concertIDs {
+		var availability struct {
+			AvailableTickets int       `db:"available_tickets"`
+			TotalTickets     int       `db:"total_tickets"`
+			UpdatedAt        time.Time `db:"updated_at"`
+		}
+		err = tx.GetContext(ctx, &availability, `
+			UPDATE concerts
+			SET available_tickets = available_tickets + $1, version = version + 1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING available_tickets, total_tickets, updated_at
+		`, released[concertID], concertID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release tickets: %w", err)
+		}
+		availabilities = append(availabilities, &model.ConcertAvailability{
+			ConcertID:        concertID,
+			AvailableTickets: availability.AvailableTickets,
+			TotalTickets:     availability.TotalTickets,
+			UpdatedAt:        availability.UpdatedAt,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return availabilities, nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository creates a new PostgreSQL implementation of OutboxRepository
+func NewOutboxRepository(db *sqlx.DB) repository.OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// ListPending retrieves the oldest unpublished events in ID order
+func (r *outboxRepository) ListPending(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	var events []*model.OutboxEvent
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, attempts
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkPublished records that an event was published
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox_events SET published_at = $1 WHERE id = $2`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark event published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt to publish an event
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1, last_attempt_at = $2
+		WHERE id = $3
+	`, lastError, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark event failed: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeBefore deletes the events written before a time
+func (r *outboxRepository) PurgeBefore(ctx context.Context, before time.Time, publishedOnly bool) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM outbox_events
+		WHERE created_at < $1 AND (NOT $2 OR published_at IS NOT NULL)
+	`, before, publishedOnly)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// writeEvents adds events to the outbox in tx, so they are published if and
+// only if the change they describe commits. The payload is passed as text,
+// which pgx sends as such in every query exec mode.
+func writeEvents(ctx context.Context, tx *sqlx.Tx, events ...*model.OutboxEvent) error {
+	for _, event := range events {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+			VALUES ($1, $2, $3, $4::jsonb)
+		`, event.AggregateType, event.AggregateID, event.EventType, string(event.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to write %s event: %w", event.EventType, err)
+		}
+	}
+
+	return nil
+}
+
+// writeBookingEvents adds an event of eventType about each booking to the
+// outbox in tx
+func writeBookingEvents(ctx context.Context, tx *sqlx.Tx, eventType string, bookings ...*model.Booking) error {
+	events := make([]*model.OutboxEvent, 0, len(bookings))
+	for _, booking := range bookings {
+		event, err := model.NewBookingEvent(eventType, booking)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+		}
+		events = append(events, event)
+	}
+
+	return writeEvents(ctx, tx, events...)
+}
+
+// writeBookedEvents adds a booking.confirmed event for each new booking that
+// is confirmed to the outbox in tx. Bookings waiting for their payment are
+// announced once they are paid.
+func writeBookedEvents(ctx context.Context, tx *sqlx.Tx, bookings ...*model.Booking) error {
+	confirmed := make([]*model.Booking, 0, len(bookings))
+	for _, booking := range bookings {
+		if booking.Status == model.BookingStatusConfirmed {
+			confirmed = append(confirmed, booking)
+		}
+	}
+
+	return writeBookingEvents(ctx, tx, model.EventBookingConfirmed, confirmed...)
+}
+
+// writeConcertEvent adds an event of eventType about a concert to the outbox in tx
+func writeConcertEvent(ctx context.Context, tx *sqlx.Tx, eventType string, concert *model.Concert) error {
+	event, err := model.NewConcertEvent(eventType, concert)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	return writeEvents(ctx, tx, event)
+}
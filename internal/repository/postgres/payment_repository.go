@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// paymentColumns lists the payment columns selected by queries
+const paymentColumns = `id, booking_id, provider, provider_reference, client_secret, amount, currency, status,
+	mismatch, expires_at, created_at, updated_at`
+
+// paymentCheckColumns lists the columns of payments joined with their
+// booking's status, without the client secret
+const paymentCheckColumns = `p.id, p.booking_id, p.provider, p.provider_reference, p.amount, p.currency, p.status,
+	p.mismatch, p.expires_at, p.created_at, p.updated_at, b.status AS booking_status`
+
+// settledBookingColumns lists the booking columns returned when a payment
+// settles its booking, enough for its event and its user
+const settledBookingColumns = `id, concert_id, user_id, ticket_count, booking_time, status, reference, unit_price`
+
+type paymentRepository struct {
+	db     *sqlx.DB
+	cipher crypto.Cipher
+}
+
+// NewPaymentRepository creates a new PostgreSQL implementation of
+// PaymentRepository. Client secrets are encrypted with cipher.
+func NewPaymentRepository(db *sqlx.DB, cipher crypto.Cipher) repository.PaymentRepository {
+	return &paymentRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+// Create inserts the payment of a pending booking
+func (r *paymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	clientSecret, err := r.cipher.Encrypt(payment.ClientSecret, "payments.client_secret", strconv.FormatInt(payment.BookingID, 10))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt client secret: %w", err)
+	}
+
+	err = r.db.QueryRowxContext(ctx, `
+		INSERT INTO payments (booking_id, provider, provider_reference, client_secret, amount, currency, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`, payment.BookingID, payment.Provider, payment.ProviderReference, clientSecret, payment.Amount,
+		payment.Currency, payment.Status, payment.ExpiresAt).
+		Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	return nil
+}
+
+// GetByBookingID retrieves the payment of a booking
+func (r *paymentRepository) GetByBookingID(ctx context.Context, bookingID int64) (*model.Payment, error) {
+	return r.get(ctx, `SELECT `+paymentColumns+` FROM payments WHERE booking_id = $1`, bookingID)
+}
+
+// GetByProviderReference retrieves a payment by its ID at the provider
+func (r *paymentRepository) GetByProviderReference(ctx context.Context, provider, providerReference string) (*model.Payment, error) {
+	return r.get(ctx, `SELECT `+paymentColumns+` FROM payments WHERE provider = $1 AND provider_reference = $2`,
+		provider, providerReference)
+}
+
+// get retrieves the payment a query selects and decrypts its client secret
+func (r *paymentRepository) get(ctx context.Context, query string, args ...interface{}) (*model.Payment, error) {
+	var payment model.Payment
+	if err := r.db.GetContext(ctx, &payment, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	clientSecret, err := r.cipher.Decrypt(payment.ClientSecret, "payments.client_secret", strconv.FormatInt(payment.BookingID, 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt client secret: %w", err)
+	}
+	payment.ClientSecret = clientSecret
+
+	return &payment, nil
+}
+
+// Confirm records that the pending payment of a booking was authorized and
+// confirms the booking if it is still pending
+func (r *paymentRepository) Confirm(ctx context.Context, bookingID int64) (*model.Booking, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The update locks the payment, so of two deliveries of the same
+	// notification the second finds the booking confirmed
+	if _, err := updatePaymentStatus(ctx, tx, bookingID, model.PaymentPending, model.PaymentAuthorized); err != nil {
+		return nil, err
+	}
+
+	var booking model.Booking
+	err = tx.GetContext(ctx, &booking, `
+		UPDATE bookings SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING `+settledBookingColumns,
+		model.BookingStatusConfirmed, bookingID, model.BookingStatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		var status model.BookingStatus
+		if err := tx.GetContext(ctx, &status, `SELECT status FROM bookings WHERE id = $1`, bookingID); err != nil {
+			return nil, fmt.Errorf("failed to get booking status: %w", err)
+		}
+		// A booking cancelled while it was paid keeps the authorization on
+		// record, so it can be voided
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		if status == model.BookingStatusConfirmed {
+			return nil, nil
+		}
+		return nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm booking: %w", err)
+	}
+
+	if err := writeBookingEvents(ctx, tx, model.EventBookingConfirmed, &booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &booking, nil
+}
+
+// Release settles the pending payment of a booking as failed or canceled, cancels
+// the booking if it is still pending and returns its tickets
+func (r *paymentRepository) Release(ctx context.Context, bookingID int64, status string) (*model.Booking, *model.ConcertAvailability, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A booking whose payment couldn't be created has none to settle
+	if _, err := updatePaymentStatus(ctx, tx, bookingID, model.PaymentPending, status); err != nil {
+		return nil, nil, err
+	}
+
+	if err := settlePending(ctx, tx, bookingID); err != nil {
+		return nil, nil, err
+	}
+
+	var booking model.Booking
+	err = tx.GetContext(ctx, &booking, `
+		UPDATE bookings SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING `+settledBookingColumns,
+		model.BookingStatusCancelled, bookingID, model.BookingStatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.Commit(); err != nil {
+			return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel booking: %w", err)
+	}
+
+	// Bumping the version makes concurrent concert edits based on the old
+	// ticket count fail their optimistic lock. The booking was never
+	// confirmed, so no event announces the cancellation.
+	var availability struct {
+		AvailableTickets int       `db:"available_tickets"`
+		TotalTickets     int       `db:"total_tickets"`
+		UpdatedAt        time.Time `db:"updated_at"`
+	}
+	err = tx.GetContext(ctx, &availability, `
+		UPDATE concerts
+		SET available_tickets = available_tickets + $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING available_tickets, total_tickets, updated_at
+	`, booking.TicketCount, booking.ConcertID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to release tickets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &booking, &model.ConcertAvailability{
+		ConcertID:        booking.ConcertID,
+		AvailableTickets: availability.AvailableTickets,
+		TotalTickets:     availability.TotalTickets,
+		UpdatedAt:        availability.UpdatedAt,
+	}, nil
+}
+
+// UpdateStatus moves the payment of a booking from one status to another
+func (r *paymentRepository) UpdateStatus(ctx context.Context, bookingID int64, from, to string) (bool, error) {
+	return updatePaymentStatus(ctx, r.db, bookingID, from, to)
+}
+
+// ListExpired retrieves the pending and authorized payments whose hold
+// expired by a time
+func (r *paymentRepository) ListExpired(ctx context.Context, at time.Time, limit int) ([]*model.Payment, error) {
+	payments := []*model.Payment{}
+	err := r.db.SelectContext(ctx, &payments, `
+		SELECT `+paymentColumns+` FROM payments
+		WHERE status IN ($1, $2) AND expires_at <= $3
+		ORDER BY expires_at, id
+		LIMIT $4
+	`, model.PaymentPending, model.PaymentAuthorized, at, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired payments: %w", err)
+	}
+
+	// The client secrets aren't needed to settle the holds
+	for _, payment := range payments {
+		payment.ClientSecret = ""
+	}
+
+	return payments, nil
+}
+
+// ListForReconciliation retrieves the payments created after a time that
+// didn't change since another, the least recently reconciled first
+func (r *paymentRepository) ListForReconciliation(ctx context.Context, createdAfter, changedBefore time.Time, limit int) ([]*model.PaymentCheck, error) {
+	checks := []*model.PaymentCheck{}
+	err := r.db.SelectContext(ctx, &checks, `
+		SELECT `+paymentCheckColumns+`
+		FROM payments p
+		JOIN bookings b ON b.id = p.booking_id
+		WHERE p.created_at >= $1 AND p.updated_at <= $2
+		ORDER BY p.reconciled_at NULLS FIRST, p.id
+		LIMIT $3
+	`, createdAfter, changedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments to reconcile: %w", err)
+	}
+
+	return checks, nil
+}
+
+// MarkReconciled records when the payment of a booking was reconciled and
+// the mismatch found, if any
+func (r *paymentRepository) MarkReconciled(ctx context.Context, bookingID int64, mismatch string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE payments SET reconciled_at = $1, mismatch = $2 WHERE booking_id = $3
+	`, at, mismatch, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to mark payment reconciled: %w", err)
+	}
+
+	return nil
+}
+
+// CountMismatches counts the payments flagged by kind of mismatch
+func (r *paymentRepository) CountMismatches(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Mismatch string `db:"mismatch"`
+		Count    int    `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT mismatch, COUNT(*) AS count FROM payments WHERE mismatch <> '' GROUP BY mismatch
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count payment mismatches: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Mismatch] = row.Count
+	}
+	return counts, nil
+}
+
+// updatePaymentStatus moves the payment of a booking from one status to
+// another and reports whether it had the first
+func updatePaymentStatus(ctx context.Context, exec sqlx.ExecerContext, bookingID int64, from, to string) (bool, error) {
+	result, err := exec.ExecContext(ctx, `
+		UPDATE payments SET status = $1, updated_at = NOW()
+		WHERE booking_id = $2 AND status = $3
+	`, to, bookingID, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
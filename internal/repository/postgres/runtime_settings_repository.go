@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// runtimeSettingsChannel is the channel changes of the runtime settings are
+// announced on
+const runtimeSettingsChannel = "runtime_settings"
+
+// listenerPingInterval is how often an idle listener checks its connection,
+// which would otherwise break unnoticed
+const listenerPingInterval = 90 * time.Second
+
+type runtimeSettingsRepository struct {
+	db  *sqlx.DB
+	dsn string
+}
+
+// NewRuntimeSettingsRepository creates a new PostgreSQL implementation of
+// RuntimeSettingsRepository. Changes are announced with NOTIFY; watching them
+// takes a connection of its own to dsn.
+func NewRuntimeSettingsRepository(db *sqlx.DB, dsn string) repository.RuntimeSettingsRepository {
+	return &runtimeSettingsRepository{
+		db:  db,
+		dsn: dsn,
+	}
+}
+
+// Get returns the stored settings
+func (r *runtimeSettingsRepository) Get(ctx context.Context) (*model.RuntimeSettings, error) {
+	var row struct {
+		Settings  []byte    `db:"settings"`
+		Version   int       `db:"version"`
+		UpdatedBy string    `db:"updated_by"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}
+	err := r.db.GetContext(ctx, &row, `SELECT settings, version, updated_by, updated_at FROM runtime_settings WHERE id = 1`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get runtime settings: %w", err)
+	}
+
+	var settings model.RuntimeSettings
+	if err := json.Unmarshal(row.Settings, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode runtime settings: %w", err)
+	}
+	settings.Version = row.Version
+	settings.UpdatedBy = row.UpdatedBy
+	settings.UpdatedAt = row.UpdatedAt
+	return &settings, nil
+}
+
+// Save stores settings based on the stored version and announces the change
+func (r *runtimeSettingsRepository) Save(ctx context.Context, settings *model.RuntimeSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode runtime settings: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The first change inserts the row; later ones must be based on it
+	query := `
+		UPDATE runtime_settings
+		SET settings = $1, version = version + 1, updated_by = $2, updated_at = NOW()
+		WHERE id = 1 AND version = $3
+		RETURNING version, updated_at
+	`
+	args := []interface{}{data, settings.UpdatedBy, settings.Version}
+	if settings.Version == 0 {
+		query = `
+			INSERT INTO runtime_settings (id, settings, version, updated_by, updated_at)
+			VALUES (1, $1, 1, $2, NOW())
+			ON CONFLICT (id) DO NOTHING
+			RETURNING version, updated_at
+		`
+		args = args[:2]
+	}
+
+	var saved struct {
+		Version   int       `db:"version"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}
+	if err := tx.GetContext(ctx, &saved, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkgErr.ErrOptimisticLockFailed
+		}
+		return fmt.Errorf("failed to save runtime settings: %w", err)
+	}
+
+	// Listeners are notified once the change commits
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, runtimeSettingsChannel, fmt.Sprint(saved.Version)); err != nil {
+		return fmt.Errorf("failed to announce runtime settings: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	settings.Version = saved.Version
+	settings.UpdatedAt = saved.UpdatedAt
+	return nil
+}
+
+// Watch listens for changes saved by any replica. Notifications sent while
+// the service reconnects are lost, so the channel closes when the listening
+// connection breaks, and the caller reloads when it watches again. Hot
+// standbys don't deliver notifications at all; replicas reading from one
+// only see changes when they poll.
+func (r *runtimeSettingsRepository) Watch(ctx context.Context) (<-chan struct{}, error) {
+	conn, err := pgx.Connect(ctx, r.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to listen for runtime settings: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+runtimeSettingsChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, fmt.Errorf("failed to listen for runtime settings: %w", err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer conn.Close(context.Background())
+
+		for {
+			// An idle connection is checked now and then, a broken one
+			// would otherwise go unnoticed
+			waitCtx, cancel := context.WithTimeout(ctx, listenerPingInterval)
+			_, err := conn.WaitForNotification(waitCtx)
+			cancel()
+
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err == nil:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case errors.Is(err, context.DeadlineExceeded):
+				if conn.Ping(ctx) != nil {
+					return
+				}
+			default:
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type salesReportRepository struct {
+	db *sqlx.DB
+}
+
+// NewSalesReportRepository creates a new PostgreSQL implementation of SalesReportRepository
+func NewSalesReportRepository(db *sqlx.DB) repository.SalesReportRepository {
+	return &salesReportRepository{
+		db: db,
+	}
+}
+
+// ClaimDueConcerts marks concerts whose sale ended as finalizing and returns them
+func (r *salesReportRepository) ClaimDueConcerts(ctx context.Context, now time.Time, limit int, staleAfter time.Duration) ([]*model.Concert, error) {
+	// SKIP LOCKED lets several instances run the reporting job without
+	// claiming the same concert twice
+	query := `
+		UPDATE concerts
+		SET reporting_state = $1, reporting_claimed_at = $3, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM concerts
+			WHERE (reporting_state = $2 AND (booking_end_time <= $3 OR (available_tickets <= 0 AND NOT EXISTS (
+					-- Concerts waiting for another inventory release aren't sold out yet
+					SELECT 1 FROM concert_inventory_releases r
+					WHERE r.concert_id = concerts.id AND r.released_at IS NULL
+				))))
+				OR (reporting_state = $1 AND reporting_claimed_at < $4)
+			ORDER BY id
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	var concerts []*model.Concert
+	err := r.db.SelectContext(ctx, &concerts, query,
+		model.ReportingStateFinalizing, model.ReportingStateOpen,
+		now, now.Add(-staleAfter), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim concerts for reporting: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// ReleaseClaim returns a claimed concert to the open reporting state
+func (r *salesReportRepository) ReleaseClaim(ctx context.Context, concertID int64) error {
+	query := `
+		UPDATE concerts
+		SET reporting_state = $1, reporting_claimed_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND reporting_state = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, model.ReportingStateOpen, concertID, model.ReportingStateFinalizing)
+	if err != nil {
+		return fmt.Errorf("failed to release reporting claim: %w", err)
+	}
+
+	return nil
+}
+
+// SaveFinal stores a final report and marks the concert as finalized
+func (r *salesReportRepository) SaveFinal(ctx context.Context, report *model.SalesReport) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO sales_reports (
+			concert_id, reason, tickets_sold, tickets_available, confirmed_bookings,
+			cancelled_bookings, revenue, currency, report_csv, availability_history,
+			recipient, generated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		report.ConcertID, report.Reason, report.TicketsSold, report.TicketsAvailable,
+		report.ConfirmedBookings, report.CancelledBookings, report.Revenue, report.Currency,
+		report.CSV, report.AvailabilityHistory, report.Recipient, report.GeneratedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sales report: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE concerts SET reporting_state = $1, reporting_claimed_at = NULL, updated_at = NOW() WHERE id = $2`,
+		model.ReportingStateFinalized, report.ConcertID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finalize concert reporting state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByConcertID retrieves the final report of a concert
+func (r *salesReportRepository) GetByConcertID(ctx context.Context, concertID int64) (*model.SalesReport, error) {
+	var report model.SalesReport
+	err := r.db.GetContext(ctx, &report, `SELECT * FROM sales_reports WHERE concert_id = $1`, concertID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get sales report: %w", err)
+	}
+
+	return &report, nil
+}
+
+// ListUnsent retrieves reports with a recipient that haven't been emailed yet
+func (r *salesReportRepository) ListUnsent(ctx context.Context, limit int) ([]*model.SalesReport, error) {
+	query := `
+		SELECT * FROM sales_reports
+		WHERE emailed_at IS NULL AND recipient <> ''
+		ORDER BY generated_at
+		LIMIT $1
+	`
+
+	var reports []*model.SalesReport
+	err := r.db.SelectContext(ctx, &reports, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsent sales reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// MarkEmailed records that a report was emailed
+func (r *salesReportRepository) MarkEmailed(ctx context.Context, concertID int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE sales_reports SET emailed_at = $1 WHERE concert_id = $2`, at, concertID)
+	if err != nil {
+		return fmt.Errorf("failed to mark sales report as emailed: %w", err)
+	}
+
+	return nil
+}
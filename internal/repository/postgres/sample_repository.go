@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type sampleRepository struct {
+	db *sqlx.DB
+}
+
+// NewSampleRepository creates a new PostgreSQL implementation of SampleRepository
+func NewSampleRepository(db *sqlx.DB) repository.SampleRepository {
+	return &sampleRepository{
+		db: db,
+	}
+}
+
+// ListConcerts retrieves all concerts except private ones. Only the columns a
+// sample publishes are read, so the organizer email never leaves the database.
+func (r *sampleRepository) ListConcerts(ctx context.Context) ([]*model.Concert, error) {
+	query := `
+		SELECT id, name, artist, venue, concert_date, total_tickets, available_tickets,
+			price, currency, booking_start_time, booking_end_time, visibility
+		FROM concerts
+		WHERE visibility <> $1
+		ORDER BY id
+	`
+
+	var concerts []*model.Concert
+	err := r.db.SelectContext(ctx, &concerts, query, model.VisibilityPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list concerts for sampling: %w", err)
+	}
+
+	return concerts, nil
+}
+
+// ListBookings retrieves the bookings of the given concerts. Attendee details
+// are not selected, so they are neither read nor decrypted.
+func (r *sampleRepository) ListBookings(ctx context.Context, concertIDs []int64) ([]*model.Booking, error) {
+	query := `
+		SELECT id, concert_id, user_id, ticket_count, booking_time, status, unit_price
+		FROM all_bookings
+		WHERE concert_id = ANY($1)
+		ORDER BY id
+	`
+
+	var bookings []*model.Booking
+	err := r.db.SelectContext(ctx, &bookings, query, concertIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings for sampling: %w", err)
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type waitingRoomSnapshotRepository struct {
+	db     *sqlx.DB
+	cipher crypto.Cipher
+}
+
+// NewWaitingRoomSnapshotRepository creates a new PostgreSQL implementation of
+// WaitingRoomSnapshotRepository. Snapshots hold invite tokens, so they are
+// stored encrypted with cipher.
+func NewWaitingRoomSnapshotRepository(db *sqlx.DB, cipher crypto.Cipher) repository.WaitingRoomSnapshotRepository {
+	return &waitingRoomSnapshotRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+// Save stores a snapshot unless the stored one of the same format is as recent
+func (r *waitingRoomSnapshotRepository) Save(ctx context.Context, state *model.WaitingRoomState) (bool, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode waiting room snapshot: %w", err)
+	}
+	encrypted, err := r.cipher.Encrypt(string(data), "waiting_room_snapshots.state", "1")
+	if err != nil {
+		return false, err
+	}
+
+	// A replica with an older view of the queues must not overwrite a newer snapshot
+	query := `
+		INSERT INTO waiting_room_snapshots (id, format, version, state, taken_at)
+		VALUES (1, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET format = EXCLUDED.format, version = EXCLUDED.version, state = EXCLUDED.state, taken_at = EXCLUDED.taken_at
+		WHERE waiting_room_snapshots.format <> EXCLUDED.format OR waiting_room_snapshots.version < EXCLUDED.version
+	`
+
+	result, err := r.db.ExecContext(ctx, query, state.Format, state.Version, encrypted, state.TakenAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save waiting room snapshot: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Latest returns the stored snapshot
+func (r *waitingRoomSnapshotRepository) Latest(ctx context.Context) (*model.WaitingRoomState, error) {
+	var row struct {
+		Format  int    `db:"format"`
+		Version int64  `db:"version"`
+		State   string `db:"state"`
+	}
+	err := r.db.GetContext(ctx, &row, `SELECT format, version, state FROM waiting_room_snapshots WHERE id = 1`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get waiting room snapshot: %w", err)
+	}
+
+	// Snapshots of other formats may not decode, so only the columns are read
+	if row.Format != model.WaitingRoomStateFormat {
+		return &model.WaitingRoomState{Format: row.Format, Version: row.Version}, nil
+	}
+
+	data, err := r.cipher.Decrypt(row.State, "waiting_room_snapshots.state", "1")
+	if err != nil {
+		return nil, err
+	}
+	var state model.WaitingRoomState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode waiting room snapshot: %w", err)
+	}
+
+	return &state, nil
+}
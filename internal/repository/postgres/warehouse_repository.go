@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type warehouseRepository struct {
+	db *sqlx.DB
+}
+
+// NewWarehouseRepository creates a new PostgreSQL implementation of WarehouseRepository
+func NewWarehouseRepository(db *sqlx.DB) repository.WarehouseRepository {
+	return &warehouseRepository{
+		db: db,
+	}
+}
+
+// GetWatermark returns the watermark of a dataset
+func (r *warehouseRepository) GetWatermark(ctx context.Context, dataset string) (*model.WarehouseWatermark, error) {
+	query := `
+		SELECT dataset, updated_at, last_id, rows_exported, last_object_key, exported_at
+		FROM warehouse_watermarks
+		WHERE dataset = $1
+	`
+
+	var watermark model.WarehouseWatermark
+	err := r.db.GetContext(ctx, &watermark, query, dataset)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.WarehouseWatermark{Dataset: dataset}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse watermark: %w", err)
+	}
+
+	return &watermark, nil
+}
+
+// SaveWatermark records how far a dataset was exported
+func (r *warehouseRepository) SaveWatermark(ctx context.Context, watermark *model.WarehouseWatermark) error {
+	query := `
+		INSERT INTO warehouse_watermarks (dataset, updated_at, last_id, rows_exported, last_object_key, exported_at)
+		VALUES (:dataset, :updated_at, :last_id, :rows_exported, :last_object_key, :exported_at)
+		ON CONFLICT (dataset) DO UPDATE
+		SET updated_at = EXCLUDED.updated_at, last_id = EXCLUDED.last_id, rows_exported = EXCLUDED.rows_exported,
+			last_object_key = EXCLUDED.last_object_key, exported_at = EXCLUDED.exported_at
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, watermark)
+	if err != nil {
+		return fmt.Errorf("failed to save warehouse watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ListBookingChanges retrieves the bookings changed after the watermark.
+// Archived bookings keep the time they last changed, so reading through
+// all_bookings exports those archived before the export first ran.
+func (r *warehouseRepository) ListBookingChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseBooking, error) {
+	query := `
+		SELECT id, reference, concert_id, order_id, user_id, ticket_count, unit_price, status,
+			booking_time, created_at, updated_at
+		FROM all_bookings
+		WHERE (updated_at, id) > ($1, $2) AND updated_at < $3
+		ORDER BY updated_at, id
+		LIMIT $4
+	`
+
+	var bookings []*model.WarehouseBooking
+	err := r.db.SelectContext(ctx, &bookings, query, after.UpdatedAt.UTC(), after.LastID, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed bookings: %w", err)
+	}
+
+	return bookings, nil
+}
+
+// ListConcertChanges retrieves the concerts changed after the watermark
+func (r *warehouseRepository) ListConcertChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseConcert, error) {
+	query := `
+		SELECT id, name, artist, venue, concert_date, total_tickets, available_tickets, price, currency,
+			visibility, booking_start_time, booking_end_time, created_at, updated_at
+		FROM concerts
+		WHERE (updated_at, id) > ($1, $2) AND updated_at < $3
+		ORDER BY updated_at, id
+		LIMIT $4
+	`
+
+	var concerts []*model.WarehouseConcert
+	err := r.db.SelectContext(ctx, &concerts, query, after.UpdatedAt.UTC(), after.LastID, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed concerts: %w", err)
+	}
+
+	return concerts, nil
+}
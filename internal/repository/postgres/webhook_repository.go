@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// webhookColumns lists the webhook columns selected by queries, without the secret
+const webhookColumns = `id, organizer_email, url, event_types, description, active, created_at, updated_at`
+
+// deliveryColumns lists the delivery columns selected by queries
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.event_created_at, d.status,
+	d.attempts, d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error, d.delivered_at, d.created_at`
+
+type webhookRepository struct {
+	db     *sqlx.DB
+	cipher crypto.Cipher
+}
+
+// NewWebhookRepository creates a new PostgreSQL implementation of
+// WebhookRepository. Webhook secrets are encrypted with cipher.
+func NewWebhookRepository(db *sqlx.DB, cipher crypto.Cipher) repository.WebhookRepository {
+	return &webhookRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+// webhookSecretColumn is the encrypted column of webhooks, whose values are
+// bound to the webhook ID
+const webhookSecretColumn = "webhooks.secret"
+
+// Create inserts a webhook. Its ID is taken before the insert, so the
+// secret can be bound to it.
+func (r *webhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	var id int64
+	if err := r.db.GetContext(ctx, &id, `SELECT nextval(pg_get_serial_sequence('webhooks', 'id'))`); err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	secret, err := r.cipher.Encrypt(webhook.Secret, webhookSecretColumn, strconv.FormatInt(id, 10))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	err = r.db.QueryRowxContext(ctx, `
+		INSERT INTO webhooks (id, organizer_email, url, secret, event_types, description, active)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, id, webhook.OrganizerEmail, webhook.URL, secret, webhook.EventTypes, webhook.Description, webhook.Active).
+		Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a webhook by ID
+func (r *webhookRepository) GetByID(ctx context.Context, id int64) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.db.GetContext(ctx, &webhook, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// List retrieves the webhooks of an organizer
+func (r *webhookRepository) List(ctx context.Context, organizerEmail string) ([]*model.Webhook, error) {
+	webhooks := []*model.Webhook{}
+	err := r.db.SelectContext(ctx, &webhooks, `SELECT `+webhookColumns+` FROM webhooks WHERE organizer_email = $1 ORDER BY id`,
+		organizerEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update replaces a webhook
+func (r *webhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	secret, err := r.cipher.Encrypt(webhook.Secret, webhookSecretColumn, strconv.FormatInt(webhook.ID, 10))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	err = r.db.QueryRowxContext(ctx, `
+		UPDATE webhooks
+		SET url = $1, event_types = $2::jsonb, description = $3, active = $4,
+			secret = CASE WHEN $5 = '' THEN secret ELSE $5 END, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`, webhook.URL, webhook.EventTypes, webhook.Description, webhook.Active, secret, webhook.ID).
+		Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkgErr.ErrNotFound
+		}
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a webhook
+func (r *webhookRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return pkgErr.ErrNotFound
+	}
+
+	return nil
+}
+
+// Enqueue fans a batch of outbox events out to the webhooks of the
+// organizers of their concerts in one statement, so an event is dispatched
+// if and only if its deliveries are created. Concert events name the concert
+// in their aggregate ID, booking events in their payload. Skipping locked
+// events lets a run that overlaps another take the next batch instead of
+// waiting.
+func (r *webhookRepository) Enqueue(ctx context.Context, limit int, at time.Time) (int, int, error) {
+	var dispatched, enqueued int
+	err := r.db.QueryRowxContext(ctx, `
+		WITH batch AS (
+			UPDATE outbox_events SET dispatched_at = $2
+			WHERE id IN (
+				SELECT id FROM outbox_events
+				WHERE dispatched_at IS NULL
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, aggregate_type, aggregate_id, event_type, payload, created_at
+		), enqueued AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, event_created_at, next_attempt_at)
+			SELECT w.id, b.id, b.event_type, b.payload, b.created_at, $2
+			FROM batch b
+			JOIN concerts c ON c.id = CASE b.aggregate_type
+				WHEN 'concert' THEN b.aggregate_id::bigint
+				ELSE (b.payload->>'concert_id')::bigint
+			END AND c.organizer_email <> ''
+			JOIN webhooks w ON w.organizer_email = c.organizer_email
+				AND w.active AND w.event_types @> to_jsonb(b.event_type::text) AND w.created_at <= b.created_at
+			ON CONFLICT (webhook_id, event_id) DO NOTHING
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM batch), (SELECT COUNT(*) FROM enqueued)
+	`, limit, at).Scan(&dispatched, &enqueued)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return dispatched, enqueued, nil
+}
+
+// ListDue retrieves the deliveries due at a time
+func (r *webhookRepository) ListDue(ctx context.Context, at time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	deliveries := []*model.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		SELECT `+deliveryColumns+`, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND w.active
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $2
+	`, at, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.Secret, err = r.cipher.Decrypt(delivery.Secret, webhookSecretColumn, strconv.FormatInt(delivery.WebhookID, 10)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+		}
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt saves the outcome of a delivery attempt
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4,
+			response_status = $5, last_error = $6, delivered_at = $7
+		WHERE id = $8
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	deliveries := []*model.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.id DESC
+		LIMIT $3
+	`, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules a delivery for another round of attempts
+func (r *webhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID int64, at time.Time) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.GetContext(ctx, &delivery, `
+		UPDATE webhook_deliveries d
+		SET status = 'pending', attempts = 0, next_attempt_at = $1, delivered_at = NULL
+		WHERE d.id = $2 AND d.webhook_id = $3
+		RETURNING `+deliveryColumns+`
+	`, at, deliveryID, webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkgErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// PurgeDeliveries deletes the finished deliveries created before a time
+func (r *webhookRepository) PurgeDeliveries(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries
+		WHERE created_at < $1 AND status <> 'pending'
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
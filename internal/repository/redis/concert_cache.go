@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// concertCachePrefix prefixes the keys of cached concerts, which hold their
+// JSON representation
+const concertCachePrefix = "concert_cache:"
+
+type concertCache struct {
+	client *goredis.Client
+	ttl    atomic.Int64
+}
+
+// NewConcertCache creates a concert cache on Redis whose entries expire after
+// ttl. Concerts are cached as they are served, so fields left out of their
+// JSON representation, such as the invite token hash, aren't kept.
+func NewConcertCache(client *goredis.Client, ttl time.Duration) repository.ConcertCache {
+	cache := &concertCache{client: client}
+	cache.SetTTL(ttl)
+	return cache
+}
+
+func concertCacheKey(id int64) string {
+	return concertCachePrefix + strconv.FormatInt(id, 10)
+}
+
+// Get returns a cached concert
+func (c *concertCache) Get(ctx context.Context, id int64) (*model.Concert, error) {
+	if c.ttl.Load() <= 0 {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	data, err := c.client.Get(ctx, concertCacheKey(id)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, pkgErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached concert: %w", err)
+	}
+
+	var concert model.Concert
+	if err := json.Unmarshal(data, &concert); err != nil {
+		return nil, fmt.Errorf("failed to decode cached concert: %w", err)
+	}
+	return &concert, nil
+}
+
+// Set caches a concert
+func (c *concertCache) Set(ctx context.Context, concert *model.Concert) error {
+	ttl := time.Duration(c.ttl.Load())
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(concert)
+	if err != nil {
+		return fmt.Errorf("failed to encode concert: %w", err)
+	}
+
+	if err := c.client.Set(ctx, concertCacheKey(concert.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache concert: %w", err)
+	}
+	return nil
+}
+
+// Invalidate drops concerts from the cache
+func (c *concertCache) Invalidate(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = concertCacheKey(id)
+	}
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate cached concerts: %w", err)
+	}
+	return nil
+}
+
+// SetTTL changes the time to live of concerts cached from now on
+func (c *concertCache) SetTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// inventoryCounterPrefix prefixes the keys of inventory counters, which are
+// hashes of the available tickets of a concert and the concert version the
+// count belongs to
+const inventoryCounterPrefix = "inventory:"
+
+// inventoryCounterTTL is how long the counter of a concert nobody books is
+// kept; it is seeded again from the database when needed
+const inventoryCounterTTL = 24 * time.Hour
+
+// Results of reserveScript other than the tickets left
+const (
+	reserveInsufficient = -1
+	reserveMissing      = -2
+)
+
+// reserveScript takes tickets from a counter, first resetting it if it
+// belongs to another version of the concert
+var reserveScript = goredis.NewScript(`
+local version = redis.call('HGET', KEYS[1], 'version')
+if not version then
+	return -2
+end
+if version ~= ARGV[2] then
+	redis.call('HSET', KEYS[1], 'available', ARGV[3], 'version', ARGV[2])
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+local count = tonumber(ARGV[1])
+if tonumber(redis.call('HGET', KEYS[1], 'available')) < count then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], 'available', -count)
+`)
+
+// releaseScript returns tickets to a counter still of the version they were
+// taken at
+var releaseScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[1], 'version') ~= ARGV[2] then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], 'available', ARGV[1])
+return 1
+`)
+
+type inventoryCounter struct {
+	client *goredis.Client
+}
+
+// NewInventoryCounter creates an InventoryCounter on Redis. Keeping the
+// counters consistent with the database is up to the InventoryRepository.
+func NewInventoryCounter(client *goredis.Client) repository.InventoryCounter {
+	return &inventoryCounter{client: client}
+}
+
+func inventoryCounterKey(concertID int64) string {
+	return inventoryCounterPrefix + strconv.FormatInt(concertID, 10)
+}
+
+// Reserve takes count tickets from the counter of a concert
+func (c *inventoryCounter) Reserve(ctx context.Context, concertID int64, count, version, available int) (int, error) {
+	left, err := reserveScript.Run(ctx, c.client, []string{inventoryCounterKey(concertID)},
+		count, version, available, inventoryCounterTTL.Milliseconds()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve tickets: %w", err)
+	}
+
+	switch left {
+	case reserveInsufficient:
+		return 0, pkgErr.ErrInsufficientTickets
+	case reserveMissing:
+		return 0, repository.ErrCounterMissing
+	}
+	return left, nil
+}
+
+// Release returns count tickets to the counter of a concert
+func (c *inventoryCounter) Release(ctx context.Context, concertID int64, count, version int) error {
+	if err := releaseScript.Run(ctx, c.client, []string{inventoryCounterKey(concertID)}, count, version).Err(); err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
+	}
+	return nil
+}
+
+// Seed sets the counter of a concert
+func (c *inventoryCounter) Seed(ctx context.Context, concertID int64, version, available int) error {
+	key := inventoryCounterKey(concertID)
+	_, err := c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.HSet(ctx, key, "available", available, "version", version)
+		pipe.PExpire(ctx, key, inventoryCounterTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed inventory counter: %w", err)
+	}
+	return nil
+}
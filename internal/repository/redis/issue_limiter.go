@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// issueLimiterPrefix prefixes the keys of issuance buckets, which are hashes
+// of the tokens left and the time in milliseconds they were counted at
+const issueLimiterPrefix = "issue-limit:"
+
+// maxIssueBucketTTL bounds how long the bucket of a very slow rate is kept.
+// A bucket that expires early is full again, as it nearly would be anyway.
+const maxIssueBucketTTL = 24 * time.Hour
+
+// allowScript takes a token from a bucket after refilling it for the time
+// since it was last counted. The time is Redis', so the clocks of the
+// replicas don't matter. A bucket expires once it would be full again.
+var allowScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+if now > at then
+	tokens = tokens + (now - at) * rate / 1000
+end
+tokens = math.min(tokens, burst)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return allowed
+`)
+
+type issueLimiter struct {
+	client *goredis.Client
+}
+
+// NewIssueLimiter creates an IssueLimiter on Redis, whose buckets every
+// replica takes from
+func NewIssueLimiter(client *goredis.Client) repository.IssueLimiter {
+	return &issueLimiter{client: client}
+}
+
+// Allow takes a token from the bucket of a concert
+func (l *issueLimiter) Allow(ctx context.Context, concertID int64, rate float64, burst int) (bool, error) {
+	ttl := maxIssueBucketTTL
+	if refill := float64(burst) / rate * float64(time.Second); refill < float64(maxIssueBucketTTL) {
+		ttl = time.Duration(math.Ceil(refill)) + time.Second
+	}
+	allowed, err := allowScript.Run(ctx, l.client, []string{issueLimiterPrefix + strconv.FormatInt(concertID, 10)},
+		rate, burst, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take an issuance token: %w", err)
+	}
+	return allowed == 1, nil
+}
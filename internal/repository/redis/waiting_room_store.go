@@ -0,0 +1,477 @@
+// Package redis implements repositories on Redis, for state every replica
+// shares and changes too often for the database
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Keys of the waiting room. Each concert's queue is a sorted set of user IDs
+// scored by their sequence number, with hashes of the members' invite
+// tokens, connections and departure times beside it.
+const (
+	waitingRoomPrefix   = "waiting_room:"
+	waitingRoomVersion  = waitingRoomPrefix + "version"
+	waitingRoomSeq      = waitingRoomPrefix + "seq"
+	waitingRoomConcerts = waitingRoomPrefix + "concerts"
+	waitingRoomLeader   = waitingRoomPrefix + "leader"
+)
+
+// snapshotAttempts limits how often a snapshot is retried while the queues change
+const snapshotAttempts = 10
+
+type queueKeys struct {
+	queue, invites, connections, departed string
+}
+
+func keysOf(concertID int64) queueKeys {
+	id := strconv.FormatInt(concertID, 10)
+	return queueKeys{
+		queue:       waitingRoomPrefix + "queue:" + id,
+		invites:     waitingRoomPrefix + "invites:" + id,
+		connections: waitingRoomPrefix + "connections:" + id,
+		departed:    waitingRoomPrefix + "departed:" + id,
+	}
+}
+
+func outcomeKey(concertID int64, userID string) string {
+	return waitingRoomPrefix + "outcome:" + strconv.FormatInt(concertID, 10) + ":" + userID
+}
+
+// keys lists the keys of a queue in the order the scripts expect them
+func (k queueKeys) keys() []string {
+	return []string{k.queue, k.invites, k.connections, k.departed, waitingRoomConcerts, waitingRoomSeq, waitingRoomVersion}
+}
+
+// enqueueScript queues a user or marks them connected and returns their position
+var enqueueScript = goredis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	redis.call('ZADD', KEYS[1], redis.call('INCR', KEYS[6]), ARGV[1])
+	redis.call('SADD', KEYS[5], ARGV[4])
+end
+if ARGV[2] == '' then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+else
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('INCR', KEYS[7])
+return redis.call('ZRANK', KEYS[1], ARGV[1]) + 1
+`)
+
+// departScript marks a user departed if the connection is still theirs
+var departScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[4], ARGV[1], ARGV[3])
+redis.call('INCR', KEYS[7])
+return 1
+`)
+
+// removeScript dequeues a user, dropping the queue once it is empty, and
+// keeps their outcome if there is one
+var removeScript = goredis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[3])
+redis.call('HDEL', KEYS[2], ARGV[3])
+redis.call('HDEL', KEYS[3], ARGV[3])
+redis.call('HDEL', KEYS[4], ARGV[3])
+if redis.call('ZCARD', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[2], KEYS[3], KEYS[4])
+	redis.call('SREM', KEYS[5], ARGV[2])
+end
+if ARGV[1] ~= '' then
+	redis.call('SET', KEYS[8], ARGV[1], 'PX', ARGV[4])
+end
+redis.call('INCR', KEYS[7])
+return 1
+`)
+
+// removeDepartedScript dequeues the given users who are still departed
+// before ARGV[2]; users who connected again since they were read stay
+var removeDepartedScript = goredis.NewScript(`
+local before = tonumber(ARGV[2])
+local removed = 0
+for i = 3, #ARGV do
+	local at = redis.call('HGET', KEYS[4], ARGV[i])
+	if at and tonumber(at) < before then
+		redis.call('ZREM', KEYS[1], ARGV[i])
+		redis.call('HDEL', KEYS[2], ARGV[i])
+		redis.call('HDEL', KEYS[3], ARGV[i])
+		redis.call('HDEL', KEYS[4], ARGV[i])
+		removed = removed + 1
+	end
+end
+if redis.call('ZCARD', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[2], KEYS[3], KEYS[4])
+	redis.call('SREM', KEYS[5], ARGV[1])
+end
+if removed > 0 then
+	redis.call('INCR', KEYS[7])
+end
+return removed
+`)
+
+// leadScript takes or extends the leader term of an instance
+var leadScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+type waitingRoomStore struct {
+	client *goredis.Client
+}
+
+// NewWaitingRoomStore creates a new Redis implementation of WaitingRoomStore
+func NewWaitingRoomStore(client *goredis.Client) repository.WaitingRoomStore {
+	return &waitingRoomStore{client: client}
+}
+
+// Enqueue adds a user to a queue or marks them connected again
+func (s *waitingRoomStore) Enqueue(ctx context.Context, concertID int64, userID, inviteToken, connection string) (int, error) {
+	position, err := enqueueScript.Run(ctx, s.client, keysOf(concertID).keys(), userID, inviteToken, connection, concertID).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue user: %w", err)
+	}
+	return position, nil
+}
+
+// Depart marks a queued user as disconnected
+func (s *waitingRoomStore) Depart(ctx context.Context, concertID int64, userID, connection string, at time.Time) error {
+	if err := departScript.Run(ctx, s.client, keysOf(concertID).keys(), userID, connection, at.UnixMilli()).Err(); err != nil {
+		return fmt.Errorf("failed to mark user departed: %w", err)
+	}
+	return nil
+}
+
+// Remove takes a user out of a queue
+func (s *waitingRoomStore) Remove(ctx context.Context, concertID int64, userID string, outcome *model.WaitingRoomUpdate, ttl time.Duration) error {
+	var encoded []byte
+	if outcome != nil {
+		var err error
+		if encoded, err = json.Marshal(outcome); err != nil {
+			return fmt.Errorf("failed to encode waiting room outcome: %w", err)
+		}
+	}
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+
+	keys := append(keysOf(concertID).keys(), outcomeKey(concertID, userID))
+	if err := removeScript.Run(ctx, s.client, keys, string(encoded), concertID, userID, ttl.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to remove user: %w", err)
+	}
+	return nil
+}
+
+// TakeOutcome returns and deletes the outcome kept for a user
+func (s *waitingRoomStore) TakeOutcome(ctx context.Context, concertID int64, userID string) (*model.WaitingRoomUpdate, error) {
+	encoded, err := s.client.GetDel(ctx, outcomeKey(concertID, userID)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take waiting room outcome: %w", err)
+	}
+
+	var outcome model.WaitingRoomUpdate
+	if err := json.Unmarshal(encoded, &outcome); err != nil {
+		return nil, fmt.Errorf("failed to decode waiting room outcome: %w", err)
+	}
+	return &outcome, nil
+}
+
+// RemoveDeparted removes the users who departed before a time
+func (s *waitingRoomStore) RemoveDeparted(ctx context.Context, before time.Time) (int, error) {
+	concertIDs, err := s.Concerts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, concertID := range concertIDs {
+		keys := keysOf(concertID)
+		departed, err := s.client.HGetAll(ctx, keys.departed).Result()
+		if err != nil {
+			return removed, fmt.Errorf("failed to get departed users: %w", err)
+		}
+
+		args := []interface{}{concertID, before.UnixMilli()}
+		for userID, at := range departed {
+			if ms, err := strconv.ParseInt(at, 10, 64); err == nil && ms < before.UnixMilli() {
+				args = append(args, userID)
+			}
+		}
+		if len(args) == 2 {
+			continue
+		}
+
+		n, err := removeDepartedScript.Run(ctx, s.client, keys.keys(), args...).Int()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove departed users: %w", err)
+		}
+		removed += n
+	}
+
+	return removed, nil
+}
+
+// Positions returns the positions of users in a queue
+func (s *waitingRoomStore) Positions(ctx context.Context, concertID int64, userIDs []string) ([]int, error) {
+	queue := keysOf(concertID).queue
+	ranks := make([]*goredis.IntCmd, len(userIDs))
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, userID := range userIDs {
+			ranks[i] = pipe.ZRank(ctx, queue, userID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("failed to get queue positions: %w", err)
+	}
+
+	positions := make([]int, len(userIDs))
+	for i, rank := range ranks {
+		if r, err := rank.Result(); err == nil {
+			positions[i] = int(r) + 1
+		}
+	}
+	return positions, nil
+}
+
+// Front returns the members at the front of a queue
+func (s *waitingRoomStore) Front(ctx context.Context, concertID int64, n int) ([]model.WaitingRoomMember, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var read func() ([]model.WaitingRoomMember, error)
+	_, err := s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		read = queueReader(ctx, pipe, concertID, int64(n-1))
+		return nil
+	})
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("failed to get queue front: %w", err)
+	}
+	return read()
+}
+
+// Concerts returns the IDs of the concerts with a queue
+func (s *waitingRoomStore) Concerts(ctx context.Context) ([]int64, error) {
+	members, err := s.client.SMembers(ctx, waitingRoomConcerts).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waiting room concerts: %w", err)
+	}
+	return parseConcertIDs(members), nil
+}
+
+// Lead takes or extends the admitting term of an instance
+func (s *waitingRoomStore) Lead(ctx context.Context, instance string, ttl time.Duration) (bool, error) {
+	led, err := leadScript.Run(ctx, s.client, []string{waitingRoomLeader}, instance, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take waiting room lead: %w", err)
+	}
+	return led == 1, nil
+}
+
+// Snapshot returns the state of every queue. The reads run in a transaction
+// watching the version, and start over if a change comes in between.
+func (s *waitingRoomStore) Snapshot(ctx context.Context) (*model.WaitingRoomState, error) {
+	for attempt := 0; attempt < snapshotAttempts; attempt++ {
+		var state *model.WaitingRoomState
+		err := s.client.Watch(ctx, func(tx *goredis.Tx) error {
+			var err error
+			state, err = s.readState(ctx, tx)
+			return err
+		}, waitingRoomVersion)
+		if errors.Is(err, goredis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot waiting room: %w", err)
+		}
+		return state, nil
+	}
+	return nil, fmt.Errorf("failed to snapshot waiting room: %w", pkgErr.ErrUpdateFailed)
+}
+
+func (s *waitingRoomStore) readState(ctx context.Context, tx *goredis.Tx) (*model.WaitingRoomState, error) {
+	version, seq, err := readCounters(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := tx.SMembers(ctx, waitingRoomConcerts).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &model.WaitingRoomState{
+		Format:  model.WaitingRoomStateFormat,
+		Version: version,
+		Seq:     seq,
+		Queues:  make(map[int64][]model.WaitingRoomMember),
+		TakenAt: clock.Now(),
+	}
+	concertIDs := parseConcertIDs(members)
+	reads := make([]func() ([]model.WaitingRoomMember, error), len(concertIDs))
+	// The transaction fails if the version changed since the WATCH
+	_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, concertID := range concertIDs {
+			reads[i] = queueReader(ctx, pipe, concertID, -1)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, err
+	}
+
+	for i, concertID := range concertIDs {
+		queue, err := reads[i]()
+		if err != nil {
+			return nil, err
+		}
+		if len(queue) > 0 {
+			state.Queues[concertID] = queue
+		}
+	}
+	return state, nil
+}
+
+// Restore replaces the state of every queue if it is still at version from
+func (s *waitingRoomStore) Restore(ctx context.Context, state *model.WaitingRoomState, from int64) error {
+	err := s.client.Watch(ctx, func(tx *goredis.Tx) error {
+		version, seq, err := readCounters(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if version != from {
+			return pkgErr.ErrUpdateFailed
+		}
+		current, err := tx.SMembers(ctx, waitingRoomConcerts).Result()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, concertID := range parseConcertIDs(current) {
+				keys := keysOf(concertID)
+				pipe.Del(ctx, keys.queue, keys.invites, keys.connections, keys.departed)
+			}
+			pipe.Del(ctx, waitingRoomConcerts)
+
+			for concertID, members := range state.Queues {
+				if len(members) == 0 {
+					continue
+				}
+				keys := keysOf(concertID)
+				pipe.SAdd(ctx, waitingRoomConcerts, concertID)
+				for _, member := range members {
+					pipe.ZAdd(ctx, keys.queue, goredis.Z{Score: float64(member.Seq), Member: member.UserID})
+					if member.InviteToken != "" {
+						pipe.HSet(ctx, keys.invites, member.UserID, member.InviteToken)
+					}
+					if member.Connection != "" {
+						pipe.HSet(ctx, keys.connections, member.UserID, member.Connection)
+					}
+					if member.DepartedAt != nil {
+						pipe.HSet(ctx, keys.departed, member.UserID, member.DepartedAt.UnixMilli())
+					}
+				}
+			}
+
+			// Sequence numbers only grow, so new members queue behind restored ones
+			pipe.Set(ctx, waitingRoomSeq, max(seq, state.Seq), 0)
+			pipe.Set(ctx, waitingRoomVersion, state.Version, 0)
+			return nil
+		})
+		return err
+	}, waitingRoomVersion)
+
+	if errors.Is(err, goredis.TxFailedErr) || errors.Is(err, pkgErr.ErrUpdateFailed) {
+		return pkgErr.ErrUpdateFailed
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore waiting room: %w", err)
+	}
+	return nil
+}
+
+func readCounters(ctx context.Context, tx *goredis.Tx) (version, seq int64, err error) {
+	values, err := tx.MGet(ctx, waitingRoomVersion, waitingRoomSeq).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	counters := make([]int64, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		if counters[i], err = strconv.ParseInt(value.(string), 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	return counters[0], counters[1], nil
+}
+
+// queueReader queues the reads of a queue's members up to index stop, -1
+// for all, on a pipeline and returns a function assembling the members
+// once the pipeline ran
+func queueReader(ctx context.Context, pipe goredis.Pipeliner, concertID int64, stop int64) func() ([]model.WaitingRoomMember, error) {
+	keys := keysOf(concertID)
+	queue := pipe.ZRangeWithScores(ctx, keys.queue, 0, stop)
+	invites := pipe.HGetAll(ctx, keys.invites)
+	connections := pipe.HGetAll(ctx, keys.connections)
+	departed := pipe.HGetAll(ctx, keys.departed)
+
+	return func() ([]model.WaitingRoomMember, error) {
+		entries, err := queue.Result()
+		if err != nil {
+			return nil, err
+		}
+		members := make([]model.WaitingRoomMember, len(entries))
+		for i, entry := range entries {
+			userID := entry.Member.(string)
+			members[i] = model.WaitingRoomMember{
+				UserID:      userID,
+				Seq:         int64(entry.Score),
+				InviteToken: invites.Val()[userID],
+				Connection:  connections.Val()[userID],
+			}
+			if ms, err := strconv.ParseInt(departed.Val()[userID], 10, 64); err == nil {
+				at := time.UnixMilli(ms).UTC()
+				members[i].DepartedAt = &at
+			}
+		}
+		return members, nil
+	}
+}
+
+func parseConcertIDs(members []string) []int64 {
+	concertIDs := make([]int64, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseInt(member, 10, 64); err == nil {
+			concertIDs = append(concertIDs, id)
+		}
+	}
+	sort.Slice(concertIDs, func(i, j int) bool { return concertIDs[i] < concertIDs[j] })
+	return concertIDs
+}
@@ -0,0 +1,51 @@
+// Package sqlite implements the core repositories on SQLite: concerts,
+// bookings, booking tokens and the audit log. The other features need the
+// postgres driver.
+//
+// SQLite runs one write transaction at a time, so the database is the row
+// lock of every concert; it suits a single instance of an edge or demo
+// deployment.
+package sqlite
+
+import (
+	"errors"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/portable"
+	"concert-ticket-api/pkg/crypto"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// dialect takes no row locks: transactions begin by locking the database
+var dialect = portable.Dialect{
+	IsUniqueViolation: isUniqueViolation,
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// NewConcertRepository creates a new SQLite implementation of ConcertRepository
+func NewConcertRepository(db *sqlx.DB) repository.ConcertRepository {
+	return portable.NewConcertRepository(db, dialect)
+}
+
+// NewBookingRepository creates a new SQLite implementation of BookingRepository.
+// Attendee details are encrypted with cipher before they are written and
+// decrypted when they are read.
+func NewBookingRepository(db *sqlx.DB, cipher crypto.Cipher) repository.BookingRepository {
+	return portable.NewBookingRepository(db, cipher, dialect)
+}
+
+// NewBookingTokenRepository creates a new SQLite implementation of BookingTokenRepository
+func NewBookingTokenRepository(db *sqlx.DB) repository.BookingTokenRepository {
+	return portable.NewBookingTokenRepository(db)
+}
+
+// NewAuditRepository creates a new SQLite implementation of AuditRepository
+func NewAuditRepository(db *sqlx.DB) repository.AuditRepository {
+	return portable.NewAuditRepository(db)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/accounting"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/money"
+)
+
+const (
+	// accountingBatchSize is the maximum number of entries pushed per provider and run
+	accountingBatchSize = 200
+	// reconciliationPendingLimit is the number of pending entries listed in a reconciliation report
+	reconciliationPendingLimit = 50
+)
+
+// AccountingService defines the interface for exporting bookings to accounting systems
+type AccountingService interface {
+	// SyncPending pushes the sale of every booking and the refund of every
+	// cancelled booking that hasn't been synced yet to each accounting system.
+	// It returns the number of entries pushed.
+	SyncPending(ctx context.Context) (int, error)
+
+	// Reconcile reports synced and pending entries for each accounting system
+	Reconcile(ctx context.Context) ([]*model.AccountingReconciliation, error)
+}
+
+type accountingService struct {
+	repo     repository.AccountingRepository
+	adapters []accounting.Adapter
+}
+
+// NewAccountingService creates a new implementation of AccountingService.
+// Without adapters, nothing is exported.
+func NewAccountingService(repo repository.AccountingRepository, adapters []accounting.Adapter) AccountingService {
+	return &accountingService{
+		repo:     repo,
+		adapters: adapters,
+	}
+}
+
+// SyncPending pushes pending journal entries to every accounting system
+func (s *accountingService) SyncPending(ctx context.Context) (int, error) {
+	// Keep going after a failure so one unreachable system doesn't block the others
+	var firstErr error
+	pushed := 0
+	for _, adapter := range s.adapters {
+		n, err := s.sync(ctx, adapter)
+		pushed += n
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to sync to %s: %w", adapter.Name(), err)
+		}
+	}
+
+	return pushed, firstErr
+}
+
+// sync pushes pending journal entries to one accounting system
+func (s *accountingService) sync(ctx context.Context, adapter accounting.Adapter) (int, error) {
+	records, err := s.repo.ListPending(ctx, adapter.Name(), accountingBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var firstErr error
+	pushed := 0
+	failedSales := make(map[int64]bool)
+	for _, record := range records {
+		// A refund can't be posted before its sale, so wait for the next run
+		if record.EntryType == model.AccountingEntryRefund && failedSales[record.BookingID] {
+			continue
+		}
+
+		externalID, err := adapter.Push(ctx, buildJournalEntry(record))
+		if err != nil {
+			if record.EntryType == model.AccountingEntrySale {
+				failedSales[record.BookingID] = true
+			}
+			if markErr := s.repo.MarkFailed(ctx, record.BookingID, record.EntryType, adapter.Name(), err.Error(), clock.Now()); markErr != nil {
+				return pushed, markErr
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to push %s of booking %s: %w", record.EntryType, record.Reference, err)
+			}
+			continue
+		}
+
+		if err := s.repo.MarkSynced(ctx, record.BookingID, record.EntryType, adapter.Name(), externalID, clock.Now()); err != nil {
+			return pushed, err
+		}
+		pushed++
+	}
+
+	return pushed, firstErr
+}
+
+// Reconcile reports synced and pending entries for each accounting system
+func (s *accountingService) Reconcile(ctx context.Context) ([]*model.AccountingReconciliation, error) {
+	reconciliations := make([]*model.AccountingReconciliation, 0, len(s.adapters))
+	for _, adapter := range s.adapters {
+		reconciliation, err := s.repo.Reconcile(ctx, adapter.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		reconciliation.Pending, err = s.repo.ListPending(ctx, adapter.Name(), reconciliationPendingLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		reconciliations = append(reconciliations, reconciliation)
+	}
+
+	return reconciliations, nil
+}
+
+// buildJournalEntry maps a booking sale or refund to a balanced journal entry.
+// Sales debit cash and credit revenue; refunds reverse them on the date the
+// booking was cancelled.
+func buildJournalEntry(record *model.AccountingRecord) *accounting.JournalEntry {
+	amount := money.Round(record.Price*float64(record.TicketCount), record.Currency)
+	description := fmt.Sprintf("%d ticket(s) for %s", record.TicketCount, record.ConcertName)
+
+	entry := &accounting.JournalEntry{
+		ID:       record.Reference + "-" + record.EntryType,
+		Currency: record.Currency,
+	}
+
+	if record.EntryType == model.AccountingEntryRefund {
+		entry.Date = record.UpdatedAt
+		entry.Memo = "Refund of booking " + record.Reference
+		entry.Lines = []accounting.JournalLine{
+			{Account: accounting.AccountRevenue, Debit: amount, Description: description},
+			{Account: accounting.AccountCash, Credit: amount, Description: description},
+		}
+		return entry
+	}
+
+	entry.Date = record.BookingTime
+	entry.Memo = "Ticket sale for booking " + record.Reference
+	entry.Lines = []accounting.JournalLine{
+		{Account: accounting.AccountCash, Debit: amount, Description: description},
+		{Account: accounting.AccountRevenue, Credit: amount, Description: description},
+	}
+	return entry
+}
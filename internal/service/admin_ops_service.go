@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/query"
+)
+
+// maxInventoryAdjustment caps a single manual inventory adjustment, so a
+// mistyped delta can't add or withdraw a venue's worth of tickets
+const maxInventoryAdjustment = 10000
+
+// AdminOpsService defines the dangerous operations of the internal admin API.
+// Every operation needs a reason and is written to the audit log with the
+// operator performing it.
+type AdminOpsService interface {
+	// ForceCancelBooking cancels a booking on behalf of its user and returns
+	// its tickets to the concert
+	ForceCancelBooking(ctx context.Context, actor, reference, reason string) (*model.Booking, error)
+
+	// AdjustInventory adds delta to the total and available tickets of a concert
+	AdjustInventory(ctx context.Context, actor string, concertID int64, adjustment model.InventoryAdjustment) (*model.ConcertAvailability, error)
+
+	// CheckInventory checks that the available tickets of a concert, or of
+	// every concert when concertID is 0, are what its bookings and pending
+	// releases leave
+	CheckInventory(ctx context.Context, concertID int64) (*model.InventoryCheck, error)
+
+	// ListAuditLogs returns a page of the matching audit log entries, latest first
+	ListAuditLogs(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error)
+}
+
+type adminOpsService struct {
+	adminRepo    repository.AdminRepository
+	auditService AuditService
+	events       *events.Bus
+}
+
+// NewAdminOpsService creates a new implementation of AdminOpsService
+func NewAdminOpsService(adminRepo repository.AdminRepository, auditService AuditService, bus *events.Bus) AdminOpsService {
+	return &adminOpsService{
+		adminRepo:    adminRepo,
+		auditService: auditService,
+		events:       bus,
+	}
+}
+
+// ForceCancelBooking cancels a booking on behalf of its user and returns its
+// tickets to the concert
+func (s *adminOpsService) ForceCancelBooking(ctx context.Context, actor, reference, reason string) (*model.Booking, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, pkgErr.ErrInvalidInput("reason is required")
+	}
+
+	booking, availability, err := s.adminRepo.ForceCancelBooking(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishAvailability(availability)
+	published := *booking
+	s.events.Publish(events.Event{
+		Topic:   model.EventBookingStatus,
+		Key:     model.UserEventKey(booking.UserID),
+		Payload: &published,
+		At:      clock.Now(),
+	})
+
+	// The cancellation is committed, so a failed audit write is reported
+	// without undoing it
+	if err := s.auditService.Record(ctx, actor, model.AuditActionBookingForceCancelled, "booking", booking.Reference, map[string]interface{}{
+		"reason":       reason,
+		"concert_id":   booking.ConcertID,
+		"ticket_count": booking.TicketCount,
+	}); err != nil {
+		return booking, fmt.Errorf("booking cancelled but not audited: %w", err)
+	}
+
+	return booking, nil
+}
+
+// AdjustInventory adds delta to the total and available tickets of a concert
+func (s *adminOpsService) AdjustInventory(ctx context.Context, actor string, concertID int64, adjustment model.InventoryAdjustment) (*model.ConcertAvailability, error) {
+	switch {
+	case strings.TrimSpace(adjustment.Reason) == "":
+		return nil, pkgErr.ErrInvalidInput("reason is required")
+	case adjustment.Delta == 0:
+		return nil, pkgErr.ErrInvalidInput("delta must not be zero")
+	case adjustment.Delta > maxInventoryAdjustment || adjustment.Delta < -maxInventoryAdjustment:
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("delta must be between -%d and %d", maxInventoryAdjustment, maxInventoryAdjustment))
+	}
+
+	availability, err := s.adminRepo.AdjustInventory(ctx, concertID, adjustment.Delta)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishAvailability(availability)
+
+	if err := s.auditService.Record(ctx, actor, model.AuditActionInventoryAdjusted, "concert", strconv.FormatInt(concertID, 10), map[string]interface{}{
+		"reason":            adjustment.Reason,
+		"delta":             adjustment.Delta,
+		"available_tickets": availability.AvailableTickets,
+		"total_tickets":     availability.TotalTickets,
+	}); err != nil {
+		return availability, fmt.Errorf("inventory adjusted but not audited: %w", err)
+	}
+
+	return availability, nil
+}
+
+// CheckInventory reports the concerts whose available tickets differ from
+// their total less the tickets their bookings took and their pending releases
+// hold back. It only reads, so it is neither audited nor announced; fixing a
+// discrepancy is an inventory adjustment.
+func (s *adminOpsService) CheckInventory(ctx context.Context, concertID int64) (*model.InventoryCheck, error) {
+	if concertID < 0 {
+		return nil, pkgErr.ErrInvalidInput("concert ID must not be negative")
+	}
+
+	counts, err := s.adminRepo.CountInventory(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &model.InventoryCheck{Checked: len(counts), Discrepancies: []*model.InventoryCount{}}
+	for _, count := range counts {
+		if count.AvailableTickets != count.ExpectedAvailable() {
+			check.Discrepancies = append(check.Discrepancies, count)
+		}
+	}
+	return check, nil
+}
+
+// ListAuditLogs returns a page of the matching audit log entries, latest first
+func (s *adminOpsService) ListAuditLogs(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error) {
+	return s.auditService.List(ctx, filter, page)
+}
+
+// publishAvailability announces the availability of a concert after an operation changed it
+func (s *adminOpsService) publishAvailability(availability *model.ConcertAvailability) {
+	published := *availability
+	s.events.Publish(events.Event{
+		Topic:   model.EventConcertAvailability,
+		Key:     availability.ConcertID,
+		Payload: &published,
+		At:      clock.Now(),
+	})
+}
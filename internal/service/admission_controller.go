@@ -0,0 +1,293 @@
+package service
+
+import (
+	"database/sql"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	admissionRate = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "admission_rate",
+		Help: "Booking tokens issued per second per concert, as set by the admission controller.",
+	})
+	admissionDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "admission_decisions_total",
+		Help: "Admission rate decisions of the controller, by decision.",
+	}, []string{"decision"})
+	admissionMissedTargets = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "admission_missed_targets_total",
+		Help: "Controller intervals whose signal missed its target, by signal.",
+	}, []string{"signal"})
+)
+
+// The signals that lower the admission rate when they miss their target
+const (
+	AdmissionSignalLatency   = "latency"
+	AdmissionSignalPoolWait  = "pool_wait"
+	AdmissionSignalErrorRate = "error_rate"
+	AdmissionSignalUnhealthy = "database_unhealthy"
+)
+
+// admissionMinAttempts is the number of booking attempts an interval needs
+// before its error rate counts, so one failure in a quiet interval doesn't
+// lower the rate
+const admissionMinAttempts = 20
+
+// AdmissionController adapts the waiting room's admission rate, the booking
+// token issue rate, to the load bookings put on the database. It lowers the
+// rate while bookings are slow or fail, or wait for database connections,
+// and raises it back towards the ceiling once they recover.
+type AdmissionController interface {
+	// Record counts a booking attempt towards the current interval
+	BookingAttemptRecorder
+
+	// Adjust sets the rate from the signals of the interval since the last
+	// adjustment and starts the next interval
+	Adjust() *model.AdmissionStatus
+
+	// SetCeiling changes the rate and burst the controller raises the rate
+	// up to, e.g. when the admission rate runtime setting changes. A ceiling
+	// of zero leaves issuance unlimited.
+	SetCeiling(rate float64, burst int)
+
+	// Override pins the rate until the override is cleared. The burst
+	// defaults to the ceiling's.
+	Override(rate float64, burst int, actor string) (*model.AdmissionStatus, error)
+
+	// ClearOverride hands the rate back to the controller
+	ClearOverride() *model.AdmissionStatus
+
+	// Status returns the rate in use and the decision that set it
+	Status() *model.AdmissionStatus
+}
+
+// PoolStats returns the statistics of a database connection pool
+type PoolStats func() sql.DBStats
+
+type admissionController struct {
+	tokens  BookingTokenService
+	policy  model.AdmissionPolicy
+	pool    PoolStats
+	healthy func() bool
+
+	// The booking attempts of the current interval
+	attempts  atomic.Int64
+	failures  atomic.Int64
+	latencyMS atomic.Int64
+
+	mu           sync.Mutex
+	ceiling      float64
+	ceilingBurst int
+	status       model.AdmissionStatus
+	// The pool statistics at the start of the interval
+	waitCount    int64
+	waitDuration time.Duration
+}
+
+// NewAdmissionController creates a new AdmissionController setting the
+// issue rate of the token service, which starts at the ceiling. pool and
+// healthy report on the database; either may be nil.
+func NewAdmissionController(tokens BookingTokenService, policy model.AdmissionPolicy, ceiling float64, burst int,
+	pool PoolStats, healthy func() bool) AdmissionController {
+	c := &admissionController{
+		tokens:       tokens,
+		policy:       policy,
+		pool:         pool,
+		healthy:      healthy,
+		ceiling:      ceiling,
+		ceilingBurst: burst,
+	}
+	if pool != nil {
+		stats := pool()
+		c.waitCount, c.waitDuration = stats.WaitCount, stats.WaitDuration
+	}
+
+	c.status.Decision = model.AdmissionDecisionHold
+	c.apply(ceiling)
+	return c
+}
+
+// Record counts a booking attempt. Only errors count as failures: sold out
+// concerts, conflicts and invalid requests say nothing about the database.
+func (c *admissionController) Record(attempt *model.BookingAttempt) {
+	c.attempts.Add(1)
+	c.latencyMS.Add(attempt.LatencyMS)
+	if attempt.Reason == model.BookingAttemptError {
+		c.failures.Add(1)
+	}
+}
+
+// Adjust sets the rate from the signals of the last interval
+func (c *admissionController) Adjust() *model.AdmissionStatus {
+	signals := model.AdmissionSignals{DatabaseHealthy: c.healthy == nil || c.healthy()}
+	attempts, failures, latencyMS := c.attempts.Swap(0), c.failures.Swap(0), c.latencyMS.Swap(0)
+	signals.Attempts = int(attempts)
+	if attempts > 0 {
+		signals.AvgLatencyMS = float64(latencyMS) / float64(attempts)
+		signals.ErrorRate = float64(failures) / float64(attempts)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pool != nil {
+		stats := c.pool()
+		if waits := stats.WaitCount - c.waitCount; waits > 0 {
+			signals.AvgPoolWaitMS = milliseconds(stats.WaitDuration-c.waitDuration) / float64(waits)
+		}
+		c.waitCount, c.waitDuration = stats.WaitCount, stats.WaitDuration
+	}
+
+	var missed []string
+	if !signals.DatabaseHealthy {
+		missed = append(missed, AdmissionSignalUnhealthy)
+	}
+	if signals.AvgLatencyMS > milliseconds(c.policy.LatencyTarget) {
+		missed = append(missed, AdmissionSignalLatency)
+	}
+	if signals.AvgPoolWaitMS > milliseconds(c.policy.PoolWaitTarget) {
+		missed = append(missed, AdmissionSignalPoolWait)
+	}
+	if attempts >= admissionMinAttempts && signals.ErrorRate > c.policy.ErrorRateTarget {
+		missed = append(missed, AdmissionSignalErrorRate)
+	}
+	for _, signal := range missed {
+		admissionMissedTargets.WithLabelValues(signal).Inc()
+	}
+
+	minRate := math.Min(c.policy.MinRate, c.ceiling)
+	rate, decision := c.status.Rate, model.AdmissionDecisionHold
+	switch {
+	case c.status.Override != nil:
+		decision = model.AdmissionDecisionOverride
+	case c.ceiling <= 0:
+		// Issuance is unlimited, there is no rate to adjust
+	case !signals.DatabaseHealthy:
+		rate, decision = minRate, model.AdmissionDecisionDecrease
+	case len(missed) > 0:
+		rate, decision = math.Max(minRate, rate*c.policy.DecreaseFactor), model.AdmissionDecisionDecrease
+	case rate < c.ceiling:
+		rate, decision = math.Min(c.ceiling, rate+c.policy.IncreaseStep), model.AdmissionDecisionIncrease
+	}
+
+	c.status.Decision = decision
+	c.status.Reasons = missed
+	c.status.Signals = signals
+	c.status.AdjustedAt = clock.Now()
+	c.apply(rate)
+	admissionDecisions.WithLabelValues(decision).Inc()
+
+	return c.snapshot()
+}
+
+// SetCeiling changes the rate the controller raises the rate up to. A rate
+// at the old ceiling follows it, any other is kept within the new one.
+func (c *admissionController) SetCeiling(rate float64, burst int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.ceiling
+	c.ceiling, c.ceilingBurst = rate, burst
+	if c.status.Override != nil {
+		return
+	}
+
+	current := c.status.Rate
+	if previous <= 0 || current >= previous || current > rate || rate <= 0 {
+		current = rate
+	}
+	c.apply(current)
+}
+
+// Override pins the rate until the override is cleared
+func (c *admissionController) Override(rate float64, burst int, actor string) (*model.AdmissionStatus, error) {
+	if rate <= 0 {
+		return nil, pkgErr.ErrInvalidInput("rate must be positive")
+	}
+	if burst < 0 {
+		return nil, pkgErr.ErrInvalidInput("burst cannot be negative")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.Override = &model.AdmissionOverride{Rate: rate, Burst: burst, SetBy: actor, SetAt: clock.Now()}
+	c.status.Decision = model.AdmissionDecisionOverride
+	c.status.Reasons = nil
+	c.apply(rate)
+	admissionDecisions.WithLabelValues(model.AdmissionDecisionOverride).Inc()
+
+	return c.snapshot(), nil
+}
+
+// ClearOverride hands the rate back to the controller, which moves it from
+// the overridden rate at the next adjustment
+func (c *admissionController) ClearOverride() *model.AdmissionStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status.Override != nil {
+		c.status.Override = nil
+		c.status.Decision = model.AdmissionDecisionHold
+		if c.ceiling <= 0 || c.status.Rate > c.ceiling {
+			c.apply(c.ceiling)
+		}
+	}
+	return c.snapshot()
+}
+
+// Status returns the rate in use and the decision that set it
+func (c *admissionController) Status() *model.AdmissionStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.snapshot()
+}
+
+// apply sets the rate, with a burst scaled down with it so a lowered rate
+// can't be bypassed by the burst of the ceiling. c.mu must be held.
+func (c *admissionController) apply(rate float64) {
+	burst := c.ceilingBurst
+	switch {
+	case c.status.Override != nil && c.status.Override.Burst > 0:
+		burst = c.status.Override.Burst
+	case c.status.Override == nil && c.ceiling > 0 && rate < c.ceiling:
+		burst = int(math.Ceil(float64(c.ceilingBurst) * rate / c.ceiling))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	if rate != c.status.Rate || burst != c.status.Burst {
+		c.tokens.SetIssueRate(rate, burst)
+	}
+	c.status.Rate, c.status.Burst = rate, burst
+	c.status.MinRate, c.status.Ceiling = c.policy.MinRate, c.ceiling
+	admissionRate.Set(rate)
+}
+
+// snapshot copies the status. c.mu must be held.
+func (c *admissionController) snapshot() *model.AdmissionStatus {
+	status := c.status
+	status.Reasons = append([]string(nil), c.status.Reasons...)
+	if c.status.Override != nil {
+		override := *c.status.Override
+		status.Override = &override
+	}
+	return &status
+}
+
+// milliseconds returns a duration in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
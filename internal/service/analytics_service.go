@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// maxSalesBuckets caps the buckets of a sales timeseries, a month of hours
+// or years of days
+const maxSalesBuckets = 1000
+
+// AnalyticsService aggregates bookings for the dashboards
+type AnalyticsService interface {
+	// OrganizerDashboard aggregates the concerts of an organizer, with the
+	// sales of the current day in the location and the top concerts
+	OrganizerDashboard(ctx context.Context, organizerEmail string, location *time.Location, top int) (*model.OrganizerDashboard, error)
+
+	// ConcertSalesTimeseries buckets the confirmed bookings of a concert of
+	// the organizer. It returns ErrNotFound for the concerts of other
+	// organizers, so their IDs can't be probed.
+	ConcertSalesTimeseries(ctx context.Context, organizerEmail string, concertID int64, query model.SalesTimeseriesQuery) (*model.SalesTimeseries, error)
+}
+
+type analyticsService struct {
+	repo        repository.AnalyticsRepository
+	concertRepo repository.ConcertRepository
+}
+
+// NewAnalyticsService creates a new AnalyticsService
+func NewAnalyticsService(repo repository.AnalyticsRepository, concertRepo repository.ConcertRepository) AnalyticsService {
+	return &analyticsService{
+		repo:        repo,
+		concertRepo: concertRepo,
+	}
+}
+
+// OrganizerDashboard aggregates the concerts of an organizer
+func (s *analyticsService) OrganizerDashboard(ctx context.Context, organizerEmail string, location *time.Location, top int) (*model.OrganizerDashboard, error) {
+	now := clock.Now().In(location)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	dashboard, err := s.repo.OrganizerDashboard(ctx, organizerEmail, dayStart, dayStart.AddDate(0, 0, 1), top)
+	if err != nil {
+		return nil, err
+	}
+
+	holds := &dashboard.Holds
+	holds.Rate = ratio(holds.Confirmed, holds.Confirmed+holds.Lapsed)
+	cancellations := &dashboard.Cancellations
+	cancellations.Rate = ratio(cancellations.Cancelled, cancellations.Bookings)
+	dashboard.GeneratedAt = now
+
+	return dashboard, nil
+}
+
+// ConcertSalesTimeseries buckets the confirmed bookings of a concert of the organizer
+func (s *analyticsService) ConcertSalesTimeseries(ctx context.Context, organizerEmail string, concertID int64, query model.SalesTimeseriesQuery) (*model.SalesTimeseries, error) {
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+	if concert.OrganizerEmail != organizerEmail {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	location := query.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	// next steps from the start of a bucket to the start of the next one,
+	// a day being 23 or 25 hours when the clocks change
+	var next func(t time.Time) time.Time
+	var lookback time.Duration
+	switch query.Interval {
+	case model.SalesIntervalHour:
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
+		lookback = 24 * time.Hour
+	case model.SalesIntervalDay:
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		lookback = 30 * 24 * time.Hour
+	default:
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("interval must be %s or %s", model.SalesIntervalHour, model.SalesIntervalDay))
+	}
+
+	to := query.To
+	if to.IsZero() {
+		to = clock.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-lookback)
+	}
+	if !from.Before(to) {
+		return nil, pkgErr.ErrInvalidInput("from must be before to")
+	}
+
+	// The first bucket starts at the hour or midnight before from
+	from = from.In(location)
+	start := time.Date(from.Year(), from.Month(), from.Day(), from.Hour(), 0, 0, 0, location)
+	if query.Interval == model.SalesIntervalDay {
+		start = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	}
+
+	timeseries := &model.SalesTimeseries{
+		ConcertID: concert.ID,
+		Interval:  query.Interval,
+		Currency:  concert.Currency,
+		From:      start,
+		To:        to.In(location),
+		Buckets:   []*model.SalesBucket{},
+	}
+	for t := start; t.Before(to); t = next(t) {
+		if len(timeseries.Buckets) == maxSalesBuckets {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("the range spans more than %d buckets", maxSalesBuckets))
+		}
+		timeseries.Buckets = append(timeseries.Buckets, &model.SalesBucket{Start: t})
+	}
+
+	sales, err := s.repo.SalesTimeseries(ctx, concert.ID, query.Interval, location, start, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill the buckets that had bookings in
+	byStart := make(map[int64]*model.SalesBucket, len(timeseries.Buckets))
+	for _, bucket := range timeseries.Buckets {
+		byStart[bucket.Start.Unix()] = bucket
+	}
+	for _, sale := range sales {
+		if bucket, ok := byStart[sale.Start.Unix()]; ok {
+			bucket.Bookings, bucket.Tickets, bucket.Revenue = sale.Bookings, sale.Tickets, sale.Revenue
+		}
+	}
+
+	return timeseries, nil
+}
+
+// ratio divides part by whole, or is 0 without a whole
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
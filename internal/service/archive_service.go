@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/errors"
+)
+
+// ArchiveService defines the interface for archiving the bookings of past concerts
+type ArchiveService interface {
+	// ArchiveBookings moves the bookings of concerts that took place more
+	// than months months ago into the archive, batchSize bookings a transaction
+	ArchiveBookings(ctx context.Context, months, batchSize int) (*model.ArchiveResult, error)
+}
+
+type archiveService struct {
+	archiveRepo repository.ArchiveRepository
+}
+
+// NewArchiveService creates a new implementation of ArchiveService
+func NewArchiveService(archiveRepo repository.ArchiveRepository) ArchiveService {
+	return &archiveService{
+		archiveRepo: archiveRepo,
+	}
+}
+
+// ArchiveBookings moves batches until one comes back short, so a run ends
+// once the bookings of past concerts are archived. Short transactions keep
+// the locks on the bookings table brief while the API keeps serving.
+func (s *archiveService) ArchiveBookings(ctx context.Context, months, batchSize int) (*model.ArchiveResult, error) {
+	if months < 1 {
+		return nil, errors.ErrInvalidInput("months must be at least 1")
+	}
+	if batchSize <= 0 {
+		return nil, errors.ErrInvalidInput("batch size must be positive")
+	}
+
+	result := &model.ArchiveResult{Cutoff: clock.Now().AddDate(0, -months, 0)}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		archived, err := s.archiveRepo.ArchiveBookings(ctx, result.Cutoff, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if archived > 0 {
+			result.Archived += archived
+			result.Batches++
+		}
+		if archived < batchSize {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/query"
+)
+
+// AuditService defines the interface for recording audit events
+type AuditService interface {
+	// Record stores an audit entry for an action performed by actor on a resource
+	Record(ctx context.Context, actor, action, resourceType, resourceID string, details map[string]interface{}) error
+
+	// List returns a page of the entries matching the filter, latest first,
+	// and the total number of matching entries
+	List(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+}
+
+// NewAuditService creates a new implementation of AuditService
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record stores an audit entry for an action performed by actor on a resource
+func (s *auditService) Record(ctx context.Context, actor, action, resourceType, resourceID string, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	return s.auditRepo.Create(ctx, &model.AuditLog{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Details:      encoded,
+	})
+}
+
+// List returns a page of the entries matching the filter, latest first, and
+// the total number of matching entries
+func (s *auditService) List(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error) {
+	return s.auditRepo.List(ctx, filter, page)
+}
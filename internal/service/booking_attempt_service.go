@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// attemptBatchSize caps the number of attempts written per statement
+const attemptBatchSize = 500
+
+var droppedAttempts = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "booking_attempts_dropped_total",
+	Help: "Booking attempts not recorded because the write buffer was full.",
+})
+
+// BookingAttemptRecorder records the outcome of booking attempts
+type BookingAttemptRecorder interface {
+	// Record queues an attempt to be written. It never blocks the booking.
+	Record(attempt *model.BookingAttempt)
+}
+
+// BookingAttemptService records booking attempts and aggregates them
+type BookingAttemptService interface {
+	BookingAttemptRecorder
+
+	// Flush writes the queued attempts and returns how many were written
+	Flush(ctx context.Context) (int, error)
+
+	// Summary aggregates the attempts in the window ending now, for one
+	// concert or for all concerts when concertID is zero
+	Summary(ctx context.Context, concertID int64, window time.Duration) (*model.BookingAttemptSummary, error)
+
+	// PurgeExpired deletes the attempts older than the retention period
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type bookingAttemptService struct {
+	repo      repository.BookingAttemptRepository
+	queue     chan *model.BookingAttempt
+	retention time.Duration
+}
+
+// NewBookingAttemptService creates a new BookingAttemptService. Up to
+// bufferSize attempts wait for the next flush; attempts beyond that are dropped
+// so recording can't slow down bookings.
+func NewBookingAttemptService(repo repository.BookingAttemptRepository, bufferSize int, retention time.Duration) BookingAttemptService {
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+
+	return &bookingAttemptService{
+		repo:      repo,
+		queue:     make(chan *model.BookingAttempt, bufferSize),
+		retention: retention,
+	}
+}
+
+// Record queues an attempt to be written
+func (s *bookingAttemptService) Record(attempt *model.BookingAttempt) {
+	select {
+	case s.queue <- attempt:
+	default:
+		droppedAttempts.Inc()
+	}
+}
+
+// Flush writes the queued attempts in batches
+func (s *bookingAttemptService) Flush(ctx context.Context) (int, error) {
+	written := 0
+	for {
+		batch := s.drain(attemptBatchSize)
+		if len(batch) == 0 {
+			return written, nil
+		}
+
+		if err := s.repo.CreateBatch(ctx, batch); err != nil {
+			return written, err
+		}
+		written += len(batch)
+	}
+}
+
+// drain takes up to max attempts from the queue without waiting
+func (s *bookingAttemptService) drain(max int) []*model.BookingAttempt {
+	var batch []*model.BookingAttempt
+	for len(batch) < max {
+		select {
+		case attempt := <-s.queue:
+			batch = append(batch, attempt)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// Summary aggregates the attempts in the window ending now
+func (s *bookingAttemptService) Summary(ctx context.Context, concertID int64, window time.Duration) (*model.BookingAttemptSummary, error) {
+	now := clock.Now()
+	summary, err := s.repo.Summarize(ctx, concertID, now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	summary.WindowEnd = now
+
+	return summary, nil
+}
+
+// PurgeExpired deletes the attempts older than the retention period
+func (s *bookingAttemptService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.PurgeBefore(ctx, clock.Now().Add(-s.retention))
+}
+
+// attemptReason classifies the result of a booking attempt
+func attemptReason(err error) string {
+	var errWithMsg *pkgErr.ErrorWithMessage
+
+	switch {
+	case err == nil:
+		return model.BookingAttemptBooked
+	case errors.Is(err, pkgErr.ErrInsufficientTickets):
+		return model.BookingAttemptSoldOut
+	case errors.Is(err, pkgErr.ErrBookingClosed):
+		return model.BookingAttemptClosed
+	case errors.Is(err, pkgErr.ErrOptimisticLockFailed):
+		return model.BookingAttemptConflict
+	case errors.Is(err, pkgErr.ErrBookingTokenRequired):
+		return model.BookingAttemptTokenRequired
+	case errors.Is(err, pkgErr.ErrInvalidBookingToken):
+		return model.BookingAttemptInvalidToken
+	case errors.Is(err, pkgErr.ErrInviteRedeemed):
+		return model.BookingAttemptInviteUsed
+	case errors.Is(err, pkgErr.ErrNotFound):
+		return model.BookingAttemptNotFound
+	case errors.As(err, &errWithMsg):
+		return model.BookingAttemptInvalidInput
+	default:
+		return model.BookingAttemptError
+	}
+}
+
+// noopAttemptRecorder is used when attempt recording is disabled
+type noopAttemptRecorder struct{}
+
+func (noopAttemptRecorder) Record(*model.BookingAttempt) {}
+
+// AttemptRecorders returns a recorder passing every attempt to each of the
+// recorders that isn't nil, or nil when all are
+func AttemptRecorders(recorders ...BookingAttemptRecorder) BookingAttemptRecorder {
+	var set attemptRecorders
+	for _, recorder := range recorders {
+		if recorder != nil {
+			set = append(set, recorder)
+		}
+	}
+
+	switch len(set) {
+	case 0:
+		return nil
+	case 1:
+		return set[0]
+	}
+	return set
+}
+
+// attemptRecorders records attempts with several recorders
+type attemptRecorders []BookingAttemptRecorder
+
+func (r attemptRecorders) Record(attempt *model.BookingAttempt) {
+	for _, recorder := range r {
+		recorder.Record(attempt)
+	}
+}
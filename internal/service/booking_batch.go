@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/reference"
+)
+
+// BookTicketsBatch books a chunk of requests, such as the allocation of an
+// agency, and records the outcome of each. The requests of each concert are
+// committed in one transaction, in the order they were given: a request that
+// doesn't fit the remaining tickets fails without stopping the smaller ones
+// after it. Results are in the order of reqs.
+func (s *bookingService) BookTicketsBatch(ctx context.Context, reqs []*model.BookingRequest) []*model.BatchBookingResult {
+	start := time.Now()
+	results := make([]*model.BatchBookingResult, len(reqs))
+
+	// Group the valid requests by concert, keeping the order of the concerts
+	var concertIDs []int64
+	groups := make(map[int64][]int)
+	for i, req := range reqs {
+		results[i] = &model.BatchBookingResult{}
+		if err := validateBookingRequest(req); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		if _, ok := groups[req.ConcertID]; !ok {
+			concertIDs = append(concertIDs, req.ConcertID)
+		}
+		groups[req.ConcertID] = append(groups[req.ConcertID], i)
+	}
+
+	retries := make(map[int64]int, len(concertIDs))
+	for _, concertID := range concertIDs {
+		indexes := groups[concertID]
+		groupReqs := make([]*model.BookingRequest, len(indexes))
+		groupResults := make([]*model.BatchBookingResult, len(indexes))
+		for j, i := range indexes {
+			groupReqs[j] = reqs[i]
+			groupResults[j] = results[i]
+		}
+		retries[concertID] = s.bookConcertBatch(ctx, concertID, groupReqs, groupResults)
+	}
+
+	latency := time.Since(start).Milliseconds()
+	now := clock.Now()
+	for i, req := range reqs {
+		s.attempts.Record(&model.BookingAttempt{
+			ConcertID:   req.ConcertID,
+			UserID:      req.UserID,
+			Reason:      attemptReason(results[i].Err),
+			LatencyMS:   latency,
+			Retries:     retries[req.ConcertID],
+			AttemptedAt: now,
+		})
+	}
+
+	return results
+}
+
+// bookConcertBatch books requests for one concert in one transaction, filling
+// in their results, and returns the number of retries it took
+func (s *bookingService) bookConcertBatch(ctx context.Context, concertID int64, reqs []*model.BookingRequest, results []*model.BatchBookingResult) int {
+	// fail sets err on every request that has no outcome yet
+	fail := func(err error) {
+		for _, result := range results {
+			if result.Booking == nil && result.Err == nil {
+				result.Err = err
+			}
+		}
+	}
+
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		fail(err)
+		return 0
+	}
+
+	// Private concerts need the concert's invite token; invites are redeemed
+	// one booking at a time and can't be shared by a batch
+	invite, err := presentedInvite(ctx, s.concertRepo, concert)
+	if err != nil {
+		fail(err)
+		return 0
+	}
+	if invite != nil {
+		fail(pkgErr.ErrInvalidInput("invites can't be redeemed by batch bookings"))
+		return 0
+	}
+
+	if !concert.IsBookingOpen() {
+		fail(pkgErr.ErrBookingClosed)
+		return 0
+	}
+
+	now := clock.Now()
+	bookings := make([]*model.Booking, len(reqs))
+	for i, req := range reqs {
+		if err := checkBookingLimits(s.limits.get(), concert, req.TicketCount, concert.PriceAt(now)); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		// Tokens are consumed before booking, as for single bookings
+		if concert.RequiresBookingToken {
+			if s.tokens == nil {
+				results[i].Err = pkgErr.ErrBookingTokenRequired
+				continue
+			}
+			if err := s.tokens.ConsumeToken(ctx, req.BookingToken, concertID, req.UserID); err != nil {
+				results[i].Err = err
+				continue
+			}
+		}
+
+		bookings[i] = &model.Booking{
+			ConcertID:     concertID,
+			UserID:        req.UserID,
+			TicketCount:   req.TicketCount,
+			Reference:     reference.New(),
+			Status:        model.BookingStatusConfirmed,
+			BookingTime:   now,
+			AttendeeName:  req.AttendeeName,
+			AttendeeEmail: req.AttendeeEmail,
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < s.retry.MaxRetries; attempt++ {
+		concertForUpdate, err := s.concertRepo.GetForUpdate(ctx, concertID)
+		if err != nil {
+			fail(err)
+			return attempt
+		}
+
+		if !concertForUpdate.IsBookingOpen() {
+			fail(pkgErr.ErrBookingClosed)
+			return attempt
+		}
+
+		// Take the requests in order while tickets last
+		remaining := concertForUpdate.AvailableTickets
+		unitPrice := concertForUpdate.PriceAt(now)
+		var batch []*model.Booking
+		var batchIndexes []int
+		for i, booking := range bookings {
+			if booking == nil || booking.TicketCount > remaining {
+				continue
+			}
+			if err := checkBookingLimits(s.limits.get(), concertForUpdate, booking.TicketCount, unitPrice); err != nil {
+				results[i].Err = err
+				bookings[i] = nil
+				continue
+			}
+			remaining -= booking.TicketCount
+			booking.UnitPrice = unitPrice
+			batch = append(batch, booking)
+			batchIndexes = append(batchIndexes, i)
+		}
+
+		if len(batch) == 0 {
+			fail(pkgErr.ErrInsufficientTickets)
+			return attempt
+		}
+
+		err = s.bookingRepo.CreateBatchWithTicketUpdate(ctx, batch, concertForUpdate.Version)
+		if err == nil {
+			for _, i := range batchIndexes {
+				results[i].Booking = bookings[i]
+				s.publishBookingStatus(bookings[i])
+			}
+			// The requests left over didn't fit
+			fail(pkgErr.ErrInsufficientTickets)
+
+			s.conflicts.RecordBooking(concertID, attempt+1, attempt, false)
+			observeAttempts("batch", attempt+1, false)
+			s.publishAvailability(ctx, concertID, remaining, concertForUpdate.TotalTickets)
+			return attempt
+		}
+
+		if errors.Is(err, pkgErr.ErrOptimisticLockFailed) {
+			lastErr = err
+			if attempt+1 == s.retry.MaxRetries {
+				break
+			}
+			if err = s.retry.wait(ctx, "batch", attempt); err == nil {
+				continue
+			}
+		}
+
+		s.conflicts.RecordBooking(concertID, attempt+1, attempt, false)
+		observeAttempts("batch", attempt+1, false)
+		fail(err)
+		return attempt
+	}
+
+	s.conflicts.RecordBooking(concertID, s.retry.MaxRetries, s.retry.MaxRetries, true)
+	observeAttempts("batch", s.retry.MaxRetries, true)
+	fail(fmt.Errorf("failed to book tickets after %d attempts: %w", s.retry.MaxRetries, lastErr))
+	return s.retry.MaxRetries - 1
+}
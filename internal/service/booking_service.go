@@ -3,10 +3,18 @@ package service
 import (
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
+	"concert-ticket-api/pkg/trace"
 	"context"
 	"errors"
 	"fmt"
+	"net/mail"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,36 +23,109 @@ type BookingService interface {
 	// GetBookingByID retrieves a booking by its ID
 	GetBookingByID(ctx context.Context, id int64) (*model.Booking, error)
 
+	// GetBookingByReference retrieves a booking by its public reference
+	GetBookingByReference(ctx context.Context, ref string) (*model.Booking, error)
+
 	// GetUserBookings retrieves bookings for a user
-	GetUserBookings(ctx context.Context, userID string, page, pageSize int) ([]*model.Booking, error)
+	GetUserBookings(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error)
+
+	// GetUserBookingsWithConcerts retrieves bookings for a user with a
+	// summary of each booking's concert, in one query
+	GetUserBookingsWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error)
 
 	// BookTickets books tickets for a concert
 	BookTickets(ctx context.Context, req *model.BookingRequest) (*model.Booking, error)
 
+	// BookTicketsBatch books a chunk of requests, committing the bookings of
+	// each concert in one transaction, and returns one result per request
+	BookTicketsBatch(ctx context.Context, reqs []*model.BookingRequest) []*model.BatchBookingResult
+
 	// CancelBooking cancels a booking
 	CancelBooking(ctx context.Context, bookingID int64, userID string) error
+
+	// CancelBookingByReference cancels a booking identified by its public reference
+	CancelBookingByReference(ctx context.Context, ref string, userID string) error
+
+	// SetBookingLimits replaces the limits of concerts without their own,
+	// when the configuration is reloaded
+	SetBookingLimits(limits model.BookingLimits)
 }
 
 type bookingService struct {
 	bookingRepo repository.BookingRepository
 	concertRepo repository.ConcertRepository
-	maxRetries  int
+	retry       RetryPolicy
+	conflicts   ConflictTracker
+	tokens      BookingTokenService
+	attempts    BookingAttemptRecorder
+	events      *events.Bus
+	limits      *bookingLimits
+	inventory   InventoryService
+	strategy    BookingStrategy
+	payments    PaymentService
 }
 
-// NewBookingService creates a new implementation of BookingService
+// BookingStrategy selects how a booking takes its tickets from the concert row
+type BookingStrategy int
+
+const (
+	// BookingOptimistic reads the concert under a row lock and books it if it
+	// is still at that version, retrying when another booking got in first
+	BookingOptimistic BookingStrategy = iota
+	// BookingSerialized makes the bookings of a concert wait for each other on
+	// the concert's booking lock instead of retrying
+	BookingSerialized
+	// BookingConditional takes the tickets with one update that only passes
+	// while the booking window is open and enough tickets are left, so
+	// bookings neither read the concert first nor retry
+	BookingConditional
+)
+
+// NewBookingService creates a new implementation of BookingService.
+// Bookings that lose a race for a concert are retried by the retry policy,
+// whose unset settings are defaulted. A nil conflict tracker disables conflict tracking. Without a booking token
+// service, concerts that require booking tokens can't be booked. A nil attempt
+// recorder disables recording booking attempts. Availability changes are
+// published to the event bus unless it is nil, where subscribers such as the
+// concert cache react to them. The limits apply to concerts without their
+// own. With an inventory service, bookings take their tickets from its
+// counters and only lock the concert row if they can't be reached. The
+// strategy selects how bookings without an inventory service take their
+// tickets from the concert row. With a payment service, bookings that cost
+// something are pending until they are paid.
 func NewBookingService(
 	bookingRepo repository.BookingRepository,
 	concertRepo repository.ConcertRepository,
-	maxRetries int,
+	retry RetryPolicy,
+	conflicts ConflictTracker,
+	tokens BookingTokenService,
+	attempts BookingAttemptRecorder,
+	bus *events.Bus,
+	limits model.BookingLimits,
+	inventory InventoryService,
+	strategy BookingStrategy,
+	payments PaymentService,
 ) BookingService {
-	if maxRetries <= 0 {
-		maxRetries = 3 // Default to 3 retries
+	if conflicts == nil {
+		conflicts = noopConflictTracker{}
+	}
+
+	if attempts == nil {
+		attempts = noopAttemptRecorder{}
 	}
 
 	return &bookingService{
 		bookingRepo: bookingRepo,
 		concertRepo: concertRepo,
-		maxRetries:  maxRetries,
+		retry:       retry.withDefaults(),
+		conflicts:   conflicts,
+		tokens:      tokens,
+		attempts:    attempts,
+		events:      bus,
+		limits:      newBookingLimits(limits),
+		inventory:   inventory,
+		strategy:    strategy,
+		payments:    payments,
 	}
 }
 
@@ -53,94 +134,278 @@ func (s *bookingService) GetBookingByID(ctx context.Context, id int64) (*model.B
 	return s.bookingRepo.GetByID(ctx, id)
 }
 
-// GetUserBookings retrieves bookings for a user
-func (s *bookingService) GetUserBookings(ctx context.Context, userID string, page, pageSize int) ([]*model.Booking, error) {
-	if page < 1 {
-		page = 1
+// GetBookingByReference retrieves a booking by its public reference
+func (s *bookingService) GetBookingByReference(ctx context.Context, ref string) (*model.Booking, error) {
+	// Malformed references can't exist, so they don't need a lookup
+	ref, ok := reference.Normalize(ref)
+	if !ok {
+		return nil, pkgErr.ErrNotFound
 	}
 
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
+	return s.bookingRepo.GetByReference(ctx, ref)
+}
 
-	offset := (page - 1) * pageSize
+// GetUserBookings retrieves bookings for a user
+func (s *bookingService) GetUserBookings(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	return s.bookingRepo.GetByUserID(ctx, userID, page.Normalize())
+}
 
-	return s.bookingRepo.GetByUserID(ctx, userID, pageSize, offset)
+// GetUserBookingsWithConcerts retrieves bookings for a user with their concerts
+func (s *bookingService) GetUserBookingsWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	return s.bookingRepo.GetByUserIDWithConcerts(ctx, userID, page.Normalize())
 }
 
-// BookTickets books tickets for a concert
+// BookTickets books tickets for a concert and records the outcome
 func (s *bookingService) BookTickets(ctx context.Context, req *model.BookingRequest) (*model.Booking, error) {
+	start := time.Now()
+	booking, retries, err := s.bookTickets(ctx, req)
+
+	s.attempts.Record(&model.BookingAttempt{
+		ConcertID:   req.ConcertID,
+		UserID:      req.UserID,
+		Reason:      attemptReason(err),
+		LatencyMS:   time.Since(start).Milliseconds(),
+		Retries:     retries,
+		AttemptedAt: clock.Now(),
+	})
+
+	return booking, err
+}
+
+// bookTickets books tickets and returns the number of retries it took
+func (s *bookingService) bookTickets(ctx context.Context, req *model.BookingRequest) (booked *model.Booking, retries int, err error) {
 	// Validate booking request
 	if err := validateBookingRequest(req); err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// A retried request returns the booking the first attempt made
+	if req.IdempotencyKey != "" {
+		existing, err := s.bookingRepo.GetByIdempotencyKey(ctx, req.UserID, req.IdempotencyKey)
+		switch {
+		case err == nil:
+			if existing.ConcertID != req.ConcertID || existing.TicketCount != req.TicketCount {
+				return nil, 0, pkgErr.ErrInvalidInput("idempotency key was used for a different booking")
+			}
+			// The retry gets the payment to complete the booking with
+			if existing.Status == model.BookingStatusPending && s.payments != nil {
+				if err := s.payments.Attach(ctx, existing); err != nil {
+					return nil, 0, err
+				}
+			}
+			return existing, 0, nil
+		case !errors.Is(err, pkgErr.ErrNotFound):
+			return nil, 0, err
+		}
 	}
 
 	// Get the concert
+	endSpan := trace.StartSpan(ctx, "concert.get")
 	concert, err := s.concertRepo.GetByID(ctx, req.ConcertID)
+	endSpan()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	// Private concerts can only be booked with their invite token or an invite
+	invite, err := presentedInvite(ctx, s.concertRepo, concert)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if invite != nil && !invite.IsRedeemable() {
+		return nil, 0, pkgErr.ErrInviteRedeemed
+	}
+
+	// The conditional update checks the booking window and the tickets
+	// itself; they are checked here only if a token or an invite would be
+	// spent on a booking that can't be made
+	precheck := s.strategy != BookingConditional || concert.RequiresBookingToken || invite != nil
+
 	// Check if booking is open
-	if !concert.IsBookingOpen() {
-		return nil, pkgErr.ErrBookingClosed
+	if precheck && !concert.IsBookingOpen() {
+		return nil, 0, pkgErr.ErrBookingClosed
+	}
+
+	// Check the limits before a booking token is spent on the request
+	if err := checkBookingLimits(s.limits.get(), concert, req.TicketCount, concert.PriceAt(clock.Now())); err != nil {
+		return nil, 0, err
 	}
 
 	// Check if there are enough tickets
-	if !concert.HasAvailableTickets(req.TicketCount) {
-		return nil, pkgErr.ErrInsufficientTickets
+	if precheck && !concert.HasAvailableTickets(req.TicketCount) {
+		return nil, 0, pkgErr.ErrInsufficientTickets
+	}
+
+	// High-demand concerts can only be booked by redeeming a booking token
+	if concert.RequiresBookingToken {
+		if s.tokens == nil {
+			return nil, 0, pkgErr.ErrBookingTokenRequired
+		}
+		if err := s.tokens.ConsumeToken(ctx, req.BookingToken, req.ConcertID, req.UserID); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// Create booking with retries for handling concurrent requests
 	booking := &model.Booking{
-		ConcertID:   req.ConcertID,
-		UserID:      req.UserID,
-		TicketCount: req.TicketCount,
-		Status:      model.BookingStatusConfirmed,
-		BookingTime: time.Now(),
+		ConcertID:      req.ConcertID,
+		UserID:         req.UserID,
+		TicketCount:    req.TicketCount,
+		Reference:      reference.New(),
+		Status:         model.BookingStatusConfirmed,
+		BookingTime:    clock.Now(),
+		AttendeeName:   req.AttendeeName,
+		AttendeeEmail:  req.AttendeeEmail,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+
+	// Redeem the invite before booking, so a single-use invite can't be used
+	// for two concurrent bookings. It is withdrawn if no booking is made.
+	if invite != nil {
+		redemption := &model.InviteRedemption{
+			InviteID:         invite.ID,
+			BookingReference: booking.Reference,
+			RedeemedAt:       booking.BookingTime,
+		}
+		if err := s.concertRepo.RedeemInvite(ctx, redemption); err != nil {
+			return nil, 0, err
+		}
+
+		defer func() {
+			if booked == nil {
+				// Without a booking the redemption doesn't count
+				_ = s.concertRepo.WithdrawInviteRedemption(context.WithoutCancel(ctx), redemption)
+			}
+		}()
+	}
+
+	// A pending booking holds its tickets until it is paid; without a
+	// payment it gives them back
+	if s.payments != nil {
+		defer func() {
+			if booked == nil || booked.Status != model.BookingStatusPending {
+				return
+			}
+			if err = s.payments.Start(ctx, booked, concert.Currency); err != nil {
+				booked = nil
+			}
+		}()
+	}
+
+	if s.inventory != nil {
+		// The price and limits were checked against the concert as read above
+		booking.UnitPrice = concert.PriceAt(booking.BookingTime)
+		booking.Status = s.bookingStatus(booking.UnitPrice)
+		endSpan = trace.StartSpan(ctx, "inventory.reserve")
+		left, err := s.inventory.Reserve(ctx, booking)
+		endSpan()
+
+		// Unless Redis or the database failed, which the booking below may
+		// get past, the outcome stands
+		if _, known := pkgErr.Lookup(err); err == nil || known {
+			s.conflicts.RecordBooking(req.ConcertID, 1, 0, false)
+			if err != nil {
+				return nil, 0, err
+			}
+			s.publishAvailability(ctx, concert.ID, left, concert.TotalTickets)
+			s.publishBookingStatus(booking)
+			return booking, 0, nil
+		}
+	}
+
+	if s.strategy != BookingOptimistic {
+		create, span := s.bookingRepo.CreateWithConcertLock, "booking.create_with_concert_lock"
+		if s.strategy == BookingConditional {
+			create, span = s.bookingRepo.CreateWithConditionalUpdate, "booking.create_with_conditional_update"
+		}
+
+		// The open window and tickets are checked by the repository, the
+		// price and limits against the concert row it holds
+		endSpan = trace.StartSpan(ctx, span)
+		locked, err := create(ctx, booking, func(concert *model.Concert) error {
+			booking.UnitPrice = concert.PriceAt(booking.BookingTime)
+			booking.Status = s.bookingStatus(booking.UnitPrice)
+			return checkBookingLimits(s.limits.get(), concert, req.TicketCount, booking.UnitPrice)
+		})
+		endSpan()
+		s.conflicts.RecordBooking(req.ConcertID, 1, 0, false)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		s.publishAvailability(ctx, locked.ID, locked.AvailableTickets-req.TicketCount, locked.TotalTickets)
+		s.publishBookingStatus(booking)
+		return booking, 0, nil
 	}
 
 	var lastErr error
 
 	// Retry loop for concurrent booking attempts
-	for attempt := 0; attempt < s.maxRetries; attempt++ {
+	for attempt := 0; attempt < s.retry.MaxRetries; attempt++ {
 		// Get the latest concert state with FOR UPDATE lock
+		endSpan = trace.StartSpan(ctx, fmt.Sprintf("attempt.%d.concert.get_for_update", attempt+1))
 		concertForUpdate, err := s.concertRepo.GetForUpdate(ctx, req.ConcertID)
+		endSpan()
 		if err != nil {
-			return nil, err
+			return nil, attempt, err
 		}
 
 		// Check if booking is still open
 		if !concertForUpdate.IsBookingOpen() {
-			return nil, pkgErr.ErrBookingClosed
+			return nil, attempt, pkgErr.ErrBookingClosed
 		}
 
 		// Check if there are still enough tickets
 		if !concertForUpdate.HasAvailableTickets(req.TicketCount) {
-			return nil, pkgErr.ErrInsufficientTickets
+			return nil, attempt, pkgErr.ErrInsufficientTickets
+		}
+
+		// Charge the price of the pricing phase the booking is made in
+		booking.UnitPrice = concertForUpdate.PriceAt(booking.BookingTime)
+		booking.Status = s.bookingStatus(booking.UnitPrice)
+
+		// The limits or the price may have changed since the first check
+		if err := checkBookingLimits(s.limits.get(), concertForUpdate, req.TicketCount, booking.UnitPrice); err != nil {
+			return nil, attempt, err
 		}
 
 		// Create booking and update ticket count in a transaction
+		endSpan = trace.StartSpan(ctx, fmt.Sprintf("attempt.%d.booking.create_with_ticket_update", attempt+1))
 		err = s.bookingRepo.CreateWithTicketUpdate(ctx, booking, concertForUpdate.Version)
+		endSpan()
 		if err == nil {
 			// Success!
-			return booking, nil
+			s.conflicts.RecordBooking(req.ConcertID, attempt+1, attempt, false)
+			observeAttempts("booking", attempt+1, false)
+			s.publishAvailability(ctx, concertForUpdate.ID, concertForUpdate.AvailableTickets-req.TicketCount, concertForUpdate.TotalTickets)
+			s.publishBookingStatus(booking)
+			return booking, attempt, nil
 		}
 
 		// If we encounter a version conflict, we'll retry
 		if errors.Is(err, pkgErr.ErrOptimisticLockFailed) {
 			lastErr = err
-			// Add a small delay before retrying to reduce contention
-			time.Sleep(time.Duration(attempt+1) * 10 * time.Millisecond)
-			continue
+			if attempt+1 == s.retry.MaxRetries {
+				break
+			}
+			// Back off before retrying to reduce contention, unless the
+			// caller gives up first
+			if err = s.retry.wait(ctx, "booking", attempt); err == nil {
+				continue
+			}
 		}
 
 		// For other errors, return immediately
-		return nil, err
+		s.conflicts.RecordBooking(req.ConcertID, attempt+1, attempt, false)
+		observeAttempts("booking", attempt+1, false)
+		return nil, attempt, err
 	}
 
 	// If we get here, we've exhausted our retries
-	return nil, fmt.Errorf("failed to book tickets after %d attempts: %w", s.maxRetries, lastErr)
+	s.conflicts.RecordBooking(req.ConcertID, s.retry.MaxRetries, s.retry.MaxRetries, true)
+	observeAttempts("booking", s.retry.MaxRetries, true)
+	return nil, s.retry.MaxRetries - 1, fmt.Errorf("failed to book tickets after %d attempts: %w", s.retry.MaxRetries, lastErr)
 }
 
 // CancelBooking cancels a booking
@@ -161,41 +426,88 @@ func (s *bookingService) CancelBooking(ctx context.Context, bookingID int64, use
 		return pkgErr.ErrBookingAlreadyCancelled
 	}
 
-	// Start a transaction
-	tx, err := s.concertRepo.GetDB().BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	// A booking waiting for its payment has its payment withdrawn first, so
+	// it can't be paid once it is cancelled
+	if booking.Status == model.BookingStatusPending && s.payments != nil {
+		return s.payments.Cancel(ctx, booking)
 	}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
+	// A paid booking is paid back first, so a failed refund leaves it booked
+	// for the user to cancel again. The refund claims the payment, so a
+	// concurrent cancellation gets ErrBookingAlreadyCancelled there instead
+	// of paying it back twice.
+	if s.payments != nil {
+		if err := s.payments.Refund(ctx, booking); err != nil {
+			return err
 		}
-	}()
-
-	// Update booking status
-	booking.Status = model.BookingStatusCancelled
-	if err = s.bookingRepo.Update(ctx, booking); err != nil {
-		return err
+		// The booking is cancelled even if the caller gave up during the
+		// refund. Should cancelling fail, the payment is refunded already,
+		// so cancelling again doesn't refund it twice, and reconciliation
+		// cancels a confirmed booking whose payment was refunded.
+		ctx = context.WithoutCancel(ctx)
 	}
 
-	// Return the tickets to the available pool
-	concert, err := s.concertRepo.GetForUpdate(ctx, booking.ConcertID)
+	// Cancel and return the tickets to the available pool in one
+	// transaction; a concurrent cancellation of the booking gets
+	// ErrBookingAlreadyCancelled there
+	availability, err := s.bookingRepo.CancelWithTicketRelease(ctx, booking.ID)
 	if err != nil {
 		return err
 	}
+	booking.Status = model.BookingStatusCancelled
+
+	s.publishAvailability(ctx, availability.ConcertID, availability.AvailableTickets, availability.TotalTickets)
+	s.publishBookingStatus(booking)
+
+	return nil
+}
 
-	concert.AvailableTickets += booking.TicketCount
-	if err = s.concertRepo.Update(ctx, concert); err != nil {
+// CancelBookingByReference cancels a booking identified by its public reference
+func (s *bookingService) CancelBookingByReference(ctx context.Context, ref string, userID string) error {
+	booking, err := s.GetBookingByReference(ctx, ref)
+	if err != nil {
 		return err
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return s.CancelBooking(ctx, booking.ID, userID)
+}
+
+// bookingStatus returns the status a booking at a unit price is made with:
+// while payments are taken, bookings that cost something wait for theirs
+func (s *bookingService) bookingStatus(unitPrice float64) model.BookingStatus {
+	if s.payments != nil && unitPrice > 0 {
+		return model.BookingStatusPending
 	}
+	return model.BookingStatusConfirmed
+}
 
-	return nil
+// publishAvailability announces the ticket availability of a concert after
+// it changed
+func (s *bookingService) publishAvailability(ctx context.Context, concertID int64, available, total int) {
+	now := clock.Now()
+	s.events.Publish(events.Event{
+		Topic: model.EventConcertAvailability,
+		Key:   concertID,
+		Payload: &model.ConcertAvailability{
+			ConcertID:        concertID,
+			AvailableTickets: available,
+			TotalTickets:     total,
+			UpdatedAt:        now,
+		},
+		At: now,
+	})
+}
+
+// publishBookingStatus announces a confirmed or cancelled booking to its user
+func (s *bookingService) publishBookingStatus(booking *model.Booking) {
+	// Subscribers get a copy, the caller keeps using the booking
+	published := *booking
+	s.events.Publish(events.Event{
+		Topic:   model.EventBookingStatus,
+		Key:     model.UserEventKey(booking.UserID),
+		Payload: &published,
+		At:      clock.Now(),
+	})
 }
 
 // validateBookingRequest validates booking request data
@@ -212,8 +524,64 @@ func validateBookingRequest(req *model.BookingRequest) error {
 		return pkgErr.ErrInvalidInput("ticket_count must be positive")
 	}
 
-	if req.TicketCount > 10 {
-		return pkgErr.ErrInvalidInput("cannot book more than 10 tickets at once")
+	if req.AttendeeEmail != "" {
+		if _, err := mail.ParseAddress(req.AttendeeEmail); err != nil {
+			return pkgErr.ErrInvalidInput("attendee_email is invalid")
+		}
+	}
+
+	if len(req.IdempotencyKey) > model.MaxIdempotencyKeyLength {
+		return pkgErr.ErrInvalidInput(fmt.Sprintf("idempotency key must be at most %d characters", model.MaxIdempotencyKeyLength))
+	}
+
+	return nil
+}
+
+// SetBookingLimits replaces the limits of concerts without their own
+func (s *bookingService) SetBookingLimits(limits model.BookingLimits) {
+	s.limits.set(limits)
+}
+
+// bookingLimits holds the limits of concerts without their own, which
+// change when the configuration is reloaded
+type bookingLimits struct {
+	current atomic.Pointer[model.BookingLimits]
+}
+
+func newBookingLimits(limits model.BookingLimits) *bookingLimits {
+	b := &bookingLimits{}
+	b.set(limits)
+	return b
+}
+
+func (b *bookingLimits) get() model.BookingLimits {
+	return *b.current.Load()
+}
+
+func (b *bookingLimits) set(limits model.BookingLimits) {
+	limits = defaultBookingLimits(limits)
+	b.current.Store(&limits)
+}
+
+// defaultBookingLimits fills in the limits that aren't configured
+func defaultBookingLimits(limits model.BookingLimits) model.BookingLimits {
+	if limits.MaxTickets <= 0 {
+		limits.MaxTickets = model.DefaultMaxTicketsPerBooking
+	}
+	return limits
+}
+
+// checkBookingLimits checks a booking of ticketCount tickets at unitPrice
+// against the limits of the concert, falling back to the defaults
+func checkBookingLimits(defaults model.BookingLimits, concert *model.Concert, ticketCount int, unitPrice float64) error {
+	limits := concert.BookingLimits(defaults)
+
+	if ticketCount > limits.MaxTickets {
+		return pkgErr.ErrInvalidInput(fmt.Sprintf("cannot book more than %d tickets at once", limits.MaxTickets))
+	}
+
+	if limits.MaxOrderValue > 0 && money.Round(unitPrice*float64(ticketCount), concert.Currency) > limits.MaxOrderValue {
+		return pkgErr.ErrInvalidInput(fmt.Sprintf("order value cannot exceed %s", money.Format(limits.MaxOrderValue, concert.Currency, "")))
 	}
 
 	return nil
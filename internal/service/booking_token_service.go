@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"golang.org/x/time/rate"
+)
+
+// BookingTokenService defines the interface for issuing and redeeming booking tokens
+type BookingTokenService interface {
+	// IssueToken issues a single-use booking token for a user and concert.
+	// Issuance is rate limited per concert, which throttles demand for hot concerts.
+	IssueToken(ctx context.Context, concertID int64, userID string) (*model.BookingToken, error)
+
+	// ConsumeToken redeems a booking token, failing if it is unknown, expired,
+	// already used, or issued to a different user or concert
+	ConsumeToken(ctx context.Context, token string, concertID int64, userID string) error
+
+	// PurgeExpired removes expired tokens
+	PurgeExpired(ctx context.Context) (int, error)
+
+	// SetIssueRate changes the issuance rate limit of every concert, e.g. to
+	// admit users from the waiting room faster during an on-sale
+	SetIssueRate(issueRate float64, issueBurst int)
+}
+
+type bookingTokenService struct {
+	tokenRepo   repository.BookingTokenRepository
+	concertRepo repository.ConcertRepository
+	ttl         time.Duration
+
+	// mu guards the issuance rate limit, not the limiters made with it
+	mu         sync.RWMutex
+	issueRate  rate.Limit
+	issueBurst int
+	limiters   sync.Map
+	// shared limits issuance across the replicas, if they share one
+	shared repository.IssueLimiter
+}
+
+// NewBookingTokenService creates a new implementation of BookingTokenService.
+// issueRate is the number of tokens issued per second per concert. With a
+// shared limiter the rate applies to all replicas together, otherwise to
+// each replica on its own.
+func NewBookingTokenService(
+	tokenRepo repository.BookingTokenRepository,
+	concertRepo repository.ConcertRepository,
+	ttl time.Duration,
+	issueRate float64,
+	issueBurst int,
+	shared repository.IssueLimiter,
+) BookingTokenService {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+
+	if issueBurst <= 0 {
+		issueBurst = 1
+	}
+
+	return &bookingTokenService{
+		tokenRepo:   tokenRepo,
+		concertRepo: concertRepo,
+		ttl:         ttl,
+		issueRate:   rate.Limit(issueRate),
+		issueBurst:  issueBurst,
+		shared:      shared,
+	}
+}
+
+// IssueToken issues a single-use booking token for a user and concert
+func (s *bookingTokenService) IssueToken(ctx context.Context, concertID int64, userID string) (*model.BookingToken, error) {
+	if userID == "" {
+		return nil, pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVisibility(ctx, s.concertRepo, concert); err != nil {
+		return nil, err
+	}
+
+	if !concert.IsBookingOpen() {
+		return nil, pkgErr.ErrBookingClosed
+	}
+
+	if !s.allow(ctx, concertID) {
+		return nil, pkgErr.ErrRateLimited
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := clock.Now().Add(s.ttl)
+	if err := s.tokenRepo.Create(ctx, hashToken(token), concertID, userID, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &model.BookingToken{
+		Token:     token,
+		ConcertID: concertID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ConsumeToken redeems a booking token
+func (s *bookingTokenService) ConsumeToken(ctx context.Context, token string, concertID int64, userID string) error {
+	if token == "" {
+		return pkgErr.ErrBookingTokenRequired
+	}
+
+	return s.tokenRepo.Consume(ctx, hashToken(token), concertID, userID, clock.Now())
+}
+
+// PurgeExpired removes expired tokens
+func (s *bookingTokenService) PurgeExpired(ctx context.Context) (int, error) {
+	return s.tokenRepo.DeleteExpired(ctx, clock.Now())
+}
+
+// SetIssueRate changes the issuance rate limit of every concert. Limiters
+// keep the tokens they have, so a lower burst only applies once spent.
+func (s *bookingTokenService) SetIssueRate(issueRate float64, issueBurst int) {
+	if issueBurst <= 0 {
+		issueBurst = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issueRate, s.issueBurst = rate.Limit(issueRate), issueBurst
+	s.limiters.Range(func(_, limiter any) bool {
+		limiter.(*rate.Limiter).SetLimit(s.issueRate)
+		limiter.(*rate.Limiter).SetBurst(s.issueBurst)
+		return true
+	})
+}
+
+// allow takes an issuance token of a concert from the shared limiter. If
+// there is none or it doesn't answer, the replica's own limiter is used,
+// which only counts the tokens the replica issues.
+func (s *bookingTokenService) allow(ctx context.Context, concertID int64) bool {
+	s.mu.RLock()
+	issueRate, issueBurst := s.issueRate, s.issueBurst
+	s.mu.RUnlock()
+
+	if issueRate <= 0 {
+		return true
+	}
+	if s.shared != nil {
+		if allowed, err := s.shared.Allow(ctx, concertID, float64(issueRate), issueBurst); err == nil {
+			return allowed
+		}
+	}
+	limiter := s.limiter(concertID)
+	return limiter == nil || limiter.Allow()
+}
+
+// limiter returns the issuance rate limiter for a concert, or nil if
+// issuance isn't rate limited
+func (s *bookingTokenService) limiter(concertID int64) *rate.Limiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.issueRate <= 0 {
+		return nil
+	}
+	limiter, _ := s.limiters.LoadOrStore(concertID, rate.NewLimiter(s.issueRate, s.issueBurst))
+	return limiter.(*rate.Limiter)
+}
+
+// newOpaqueToken generates a random opaque token, e.g. a booking token
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken hashes a token so that stored tokens can't be replayed from the database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
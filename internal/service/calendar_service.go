@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/pagelink"
+)
+
+// CalendarService defines the interface for the calendar feeds users
+// subscribe to from their calendar apps
+type CalendarService interface {
+	// UserBookings returns the confirmed bookings of a user with their
+	// concerts, ordered by concert date. It fails with pagelink.ErrInvalid
+	// unless token is the feed token of the user.
+	UserBookings(ctx context.Context, userID, token string) ([]*model.BookedConcert, error)
+}
+
+type calendarService struct {
+	bookingRepo repository.BookingRepository
+	concertRepo repository.ConcertRepository
+	signer      *pagelink.Signer
+}
+
+// NewCalendarService creates a new implementation of CalendarService
+// serving the feeds whose tokens signer issued
+func NewCalendarService(bookingRepo repository.BookingRepository, concertRepo repository.ConcertRepository, signer *pagelink.Signer) CalendarService {
+	return &calendarService{
+		bookingRepo: bookingRepo,
+		concertRepo: concertRepo,
+		signer:      signer,
+	}
+}
+
+// UserBookings returns the confirmed bookings of a user with their concerts.
+// Cancelled bookings are left out, so they disappear from subscribed
+// calendars on the next refresh.
+func (s *calendarService) UserBookings(ctx context.Context, userID, token string) ([]*model.BookedConcert, error) {
+	if userID == "" {
+		return nil, pkgErr.ErrInvalidInput("user ID is required")
+	}
+	// Calendar apps subscribe without credentials, so the token in the feed
+	// URL stands in for them
+	if err := s.signer.VerifySubject(pagelink.KindCalendar, userID, token); err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.bookingRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var confirmed []*model.Booking
+	seen := make(map[int64]bool)
+	var concertIDs []int64
+	for _, booking := range bookings {
+		if booking.Status != model.BookingStatusConfirmed {
+			continue
+		}
+		confirmed = append(confirmed, booking)
+		if !seen[booking.ConcertID] {
+			seen[booking.ConcertID] = true
+			concertIDs = append(concertIDs, booking.ConcertID)
+		}
+	}
+	if len(confirmed) == 0 {
+		return nil, nil
+	}
+
+	concerts, err := s.concertRepo.GetByIDs(ctx, concertIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.Concert, len(concerts))
+	for _, concert := range concerts {
+		byID[concert.ID] = concert
+	}
+
+	result := make([]*model.BookedConcert, 0, len(confirmed))
+	for _, booking := range confirmed {
+		// GetByIDs skips unknown concerts, which have nothing to show
+		concert, ok := byID[booking.ConcertID]
+		if !ok {
+			continue
+		}
+		result = append(result, &model.BookedConcert{Booking: booking, Concert: concert})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Concert.ConcertDate.Before(result[j].Concert.ConcertDate)
+	})
+	return result, nil
+}
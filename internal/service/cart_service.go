@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/reference"
+)
+
+// CartService defines the interface for carts of tickets across concerts,
+// which are booked together at checkout
+type CartService interface {
+	// GetCart retrieves the cart of a user, priced at the concerts' current prices
+	GetCart(ctx context.Context, userID string) (*model.Cart, error)
+
+	// AddItem puts tickets of a concert in a user's cart, replacing the
+	// tickets of the concert already in it
+	AddItem(ctx context.Context, concertID int64, req *model.CartItemRequest) (*model.CartItem, error)
+
+	// RemoveItem removes the tickets of a concert from a user's cart
+	RemoveItem(ctx context.Context, userID string, concertID int64) error
+
+	// Checkout books the items of a user's cart as one order
+	Checkout(ctx context.Context, req *model.CheckoutRequest) (*model.Order, error)
+
+	// SetBookingLimits replaces the limits of concerts without their own,
+	// when the configuration is reloaded
+	SetBookingLimits(limits model.BookingLimits)
+}
+
+type cartService struct {
+	cartRepo    repository.CartRepository
+	concertRepo repository.ConcertRepository
+	retry       RetryPolicy
+	events      *events.Bus
+	limits      *bookingLimits
+}
+
+// NewCartService creates a new implementation of CartService. Checkouts are
+// retried by the retry policy when a concert changes under them. Like
+// bookings, availability changes are published to the event bus unless it is
+// nil, and the limits apply to concerts without their own.
+func NewCartService(
+	cartRepo repository.CartRepository,
+	concertRepo repository.ConcertRepository,
+	retry RetryPolicy,
+	bus *events.Bus,
+	limits model.BookingLimits,
+) CartService {
+	return &cartService{
+		cartRepo:    cartRepo,
+		concertRepo: concertRepo,
+		retry:       retry.withDefaults(),
+		events:      bus,
+		limits:      newBookingLimits(limits),
+	}
+}
+
+// SetBookingLimits replaces the limits of concerts without their own
+func (s *cartService) SetBookingLimits(limits model.BookingLimits) {
+	s.limits.set(limits)
+}
+
+// GetCart retrieves the cart of a user
+func (s *cartService) GetCart(ctx context.Context, userID string) (*model.Cart, error) {
+	if userID == "" {
+		return nil, pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	items, err := s.cartRepo.ListItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	concerts, err := s.concertsOf(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	for _, item := range items {
+		// Concerts deleted since they were added are left unpriced; checkout
+		// reports them
+		if concert, ok := concerts[item.ConcertID]; ok {
+			item.UnitPrice = concert.PriceAt(now)
+			item.Currency = concert.Currency
+		}
+	}
+
+	return &model.Cart{UserID: userID, Items: items}, nil
+}
+
+// AddItem puts tickets of a concert in a user's cart after checking they
+// could be booked now
+func (s *cartService) AddItem(ctx context.Context, concertID int64, req *model.CartItemRequest) (*model.CartItem, error) {
+	if req.UserID == "" {
+		return nil, pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	if req.TicketCount <= 0 {
+		return nil, pkgErr.ErrInvalidInput("ticket_count must be positive")
+	}
+
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVisibility(ctx, s.concertRepo, concert); err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	if err := s.checkItem(concert, req.TicketCount, now); err != nil {
+		return nil, err
+	}
+
+	item := &model.CartItem{
+		UserID:      req.UserID,
+		ConcertID:   concertID,
+		TicketCount: req.TicketCount,
+	}
+	if err := s.cartRepo.PutItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	item.UnitPrice = concert.PriceAt(now)
+	item.Currency = concert.Currency
+	return item, nil
+}
+
+// RemoveItem removes the tickets of a concert from a user's cart
+func (s *cartService) RemoveItem(ctx context.Context, userID string, concertID int64) error {
+	if userID == "" {
+		return pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	return s.cartRepo.RemoveItem(ctx, userID, concertID)
+}
+
+// Checkout books the items of a user's cart as one order, retrying when a
+// concert changes between reading and booking it
+func (s *cartService) Checkout(ctx context.Context, req *model.CheckoutRequest) (*model.Order, error) {
+	if req.UserID == "" {
+		return nil, pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = model.CheckoutAllOrNothing
+	}
+	if mode != model.CheckoutAllOrNothing && mode != model.CheckoutBestEffort {
+		return nil, pkgErr.ErrInvalidInput("mode must be all_or_nothing or best_effort")
+	}
+
+	if req.AttendeeEmail != "" {
+		if _, err := mail.ParseAddress(req.AttendeeEmail); err != nil {
+			return nil, pkgErr.ErrInvalidInput("attendee_email is invalid")
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < s.retry.MaxRetries; attempt++ {
+		order, err := s.checkout(ctx, req, mode)
+		if !errors.Is(err, pkgErr.ErrOptimisticLockFailed) {
+			observeAttempts("checkout", attempt+1, false)
+			return order, err
+		}
+
+		lastErr = err
+		if attempt+1 == s.retry.MaxRetries {
+			break
+		}
+		// Back off before retrying to reduce contention, unless the caller
+		// gives up first
+		if err := s.retry.wait(ctx, "checkout", attempt); err != nil {
+			observeAttempts("checkout", attempt+1, false)
+			return nil, err
+		}
+	}
+
+	observeAttempts("checkout", s.retry.MaxRetries, true)
+	return nil, fmt.Errorf("failed to check out after %d attempts: %w", s.retry.MaxRetries, lastErr)
+}
+
+// checkout makes one attempt at booking the items of a cart
+func (s *cartService) checkout(ctx context.Context, req *model.CheckoutRequest, mode string) (*model.Order, error) {
+	items, err := s.cartRepo.ListItems(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, pkgErr.ErrInvalidInput("cart is empty")
+	}
+
+	concerts, err := s.concertsOf(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	bestEffort := mode == model.CheckoutBestEffort
+	order := &model.Order{
+		Reference: reference.New(),
+		UserID:    req.UserID,
+		Mode:      mode,
+	}
+
+	// Items are checked against the concerts as read here. The repository
+	// books them only if the concerts are still at these versions.
+	now := clock.Now()
+	versions := make(map[int64]int, len(items))
+	bookings := make([]*model.Booking, 0, len(items))
+	var failed []error
+	for _, item := range items {
+		concert, ok := concerts[item.ConcertID]
+		err := pkgErr.ErrNotFound
+		if ok {
+			err = s.checkItem(concert, item.TicketCount, now)
+		}
+		if err == nil && order.Currency != "" && concert.Currency != order.Currency {
+			return nil, pkgErr.ErrInvalidInput("the items of a cart must be priced in one currency")
+		}
+		if err != nil {
+			if !bestEffort {
+				return nil, itemError(item.ConcertID, err)
+			}
+			if _, known := pkgErr.Lookup(err); !known {
+				return nil, err
+			}
+			order.Failed = append(order.Failed, checkoutFailure(item, err))
+			failed = append(failed, err)
+			continue
+		}
+
+		order.Currency = concert.Currency
+		versions[concert.ID] = concert.Version
+		bookings = append(bookings, &model.Booking{
+			ConcertID:     item.ConcertID,
+			UserID:        req.UserID,
+			TicketCount:   item.TicketCount,
+			Reference:     reference.New(),
+			Status:        model.BookingStatusConfirmed,
+			BookingTime:   now,
+			AttendeeName:  req.AttendeeName,
+			AttendeeEmail: req.AttendeeEmail,
+			UnitPrice:     concert.PriceAt(now),
+		})
+	}
+
+	// An order needs at least one booking
+	if len(bookings) == 0 {
+		return nil, itemError(order.Failed[0].ConcertID, failed[0])
+	}
+
+	errs, err := s.cartRepo.Checkout(ctx, order, bookings, versions, bestEffort)
+	if err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if len(order.Bookings) == 0 {
+			return nil, itemError(bookings[i].ConcertID, err)
+		}
+		order.Failed = append(order.Failed, checkoutFailure(&model.CartItem{
+			ConcertID:   bookings[i].ConcertID,
+			TicketCount: bookings[i].TicketCount,
+		}, err))
+	}
+
+	setOrderTotal(order)
+	taken := make(map[int64]int)
+	for _, booking := range order.Bookings {
+		taken[booking.ConcertID] += booking.TicketCount
+	}
+
+	for concertID, count := range taken {
+		concert := concerts[concertID]
+		s.publishAvailability(ctx, concertID, concert.AvailableTickets-count, concert.TotalTickets)
+	}
+	for _, booking := range order.Bookings {
+		s.publishBookingStatus(booking)
+	}
+
+	return order, nil
+}
+
+// checkItem checks that ticketCount tickets of a concert can be booked from
+// a cart at now. Concerts whose bookings need an invite or a booking token
+// are booked on their own, as those can only be spent on one booking.
+func (s *cartService) checkItem(concert *model.Concert, ticketCount int, now time.Time) error {
+	if concert.Visibility == model.VisibilityPrivate {
+		return pkgErr.ErrInvalidInput("private concerts cannot be booked from a cart")
+	}
+
+	if concert.RequiresBookingToken {
+		return pkgErr.ErrBookingTokenRequired
+	}
+
+	if !concert.IsBookingOpen() {
+		return pkgErr.ErrBookingClosed
+	}
+
+	if err := checkBookingLimits(s.limits.get(), concert, ticketCount, concert.PriceAt(now)); err != nil {
+		return err
+	}
+
+	if !concert.HasAvailableTickets(ticketCount) {
+		return pkgErr.ErrInsufficientTickets
+	}
+
+	return nil
+}
+
+// concertsOf returns the concerts of the items of a cart by ID
+func (s *cartService) concertsOf(ctx context.Context, items []*model.CartItem) (map[int64]*model.Concert, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ConcertID)
+	}
+
+	concerts, err := s.concertRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*model.Concert, len(concerts))
+	for _, concert := range concerts {
+		byID[concert.ID] = concert
+	}
+	return byID, nil
+}
+
+// publishAvailability announces the ticket availability of a concert after
+// a checkout booked it
+func (s *cartService) publishAvailability(ctx context.Context, concertID int64, available, total int) {
+	now := clock.Now()
+	s.events.Publish(events.Event{
+		Topic: model.EventConcertAvailability,
+		Key:   concertID,
+		Payload: &model.ConcertAvailability{
+			ConcertID:        concertID,
+			AvailableTickets: available,
+			TotalTickets:     total,
+			UpdatedAt:        now,
+		},
+		At: now,
+	})
+}
+
+// publishBookingStatus announces a booking made by a checkout to its user
+func (s *cartService) publishBookingStatus(booking *model.Booking) {
+	published := *booking
+	s.events.Publish(events.Event{
+		Topic:   model.EventBookingStatus,
+		Key:     model.UserEventKey(booking.UserID),
+		Payload: &published,
+		At:      clock.Now(),
+	})
+}
+
+// itemError names the concert of the cart item an error is about, keeping
+// the error's kind. Errors that aren't registered are returned as they are.
+func itemError(concertID int64, err error) error {
+	if _, known := pkgErr.Lookup(err); !known {
+		return err
+	}
+	return pkgErr.NewErrorWithMessage(err, fmt.Sprintf("concert %d: %s", concertID, pkgErr.MessageOf(err, "")))
+}
+
+// checkoutFailure reports a cart item a best-effort checkout couldn't book
+func checkoutFailure(item *model.CartItem, err error) *model.CheckoutFailure {
+	kind, _ := pkgErr.Lookup(err)
+	return &model.CheckoutFailure{
+		ConcertID:   item.ConcertID,
+		TicketCount: item.TicketCount,
+		Code:        kind.Code,
+		Message:     pkgErr.MessageOf(err, kind.Message),
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// readConcert reads a concert through the cache. Private concerts aren't
+// cached, since checking their invites needs the token hash, which the cache
+// doesn't keep. A cache that fails to answer counts as a miss.
+func readConcert(ctx context.Context, concerts repository.ConcertRepository, cache repository.ConcertCache, id int64) (*model.Concert, error) {
+	if concert, err := cache.Get(ctx, id); err == nil {
+		return concert, nil
+	}
+
+	concert, err := concerts.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if concert.Visibility != model.VisibilityPrivate {
+		// Failing to cache only costs the next reader a query
+		_ = cache.Set(ctx, concert)
+	}
+	return concert, nil
+}
+
+// invalidateConcert drops a concert from the cache after it changed. The
+// change is committed by then, so it isn't failed if the cache doesn't
+// answer; the stale copy expires with its TTL instead.
+func invalidateConcert(ctx context.Context, cache repository.ConcertCache, id int64) {
+	_ = cache.Invalidate(ctx, id)
+}
+
+// noopConcertCache caches nothing, for services created without a cache
+type noopConcertCache struct{}
+
+func (noopConcertCache) Get(context.Context, int64) (*model.Concert, error) {
+	return nil, pkgErr.ErrNotFound
+}
+
+func (noopConcertCache) Set(context.Context, *model.Concert) error {
+	return nil
+}
+
+func (noopConcertCache) Invalidate(context.Context, ...int64) error {
+	return nil
+}
+
+func (noopConcertCache) SetTTL(time.Duration) {}
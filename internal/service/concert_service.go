@@ -2,64 +2,150 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
 	"time"
 
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
 	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/query"
 )
 
 // ConcertService defines the interface for concert operations
 type ConcertService interface {
-	// GetByID retrieves a concert by its ID
+	// GetByID retrieves a concert by its ID. Private concerts are only found
+	// with their invite token in the context (see WithInviteToken).
 	GetByID(ctx context.Context, id int64) (*model.Concert, error)
 
-	// ListConcerts retrieves concerts with filtering and pagination
-	ListConcerts(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Concert, int, error)
+	// GetByIDs retrieves the concerts with the given IDs. Unknown IDs and
+	// private concerts without their invite token are skipped.
+	GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error)
+
+	// ListConcerts retrieves a page of public concerts with filtering and
+	// sorting, along with the number of concerts matching the filters, which
+	// is estimated if opts.EstimateCount is set
+	ListConcerts(ctx context.Context, opts query.Options) ([]*model.Concert, int, error)
+
+	// ListingLastModified returns when any concert listing last changed, for
+	// conditional requests
+	ListingLastModified(ctx context.Context) (time.Time, error)
 
 	// CreateConcert creates a new concert
 	CreateConcert(ctx context.Context, concert *model.Concert) (*model.Concert, error)
 
 	// UpdateConcert updates an existing concert
 	UpdateConcert(ctx context.Context, concert *model.Concert) error
+
+	// PatchConcert updates the patched fields of the concert at the given
+	// version and keeps the others. The fields that changed are returned and
+	// recorded in the audit log for actor.
+	PatchConcert(ctx context.Context, actor string, id int64, version int, patch *model.ConcertPatch) (*model.Concert, []model.FieldChange, error)
+
+	// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+	GetPriceHistory(ctx context.Context, id int64) ([]*model.PriceSnapshot, error)
+
+	// BatchGetConcerts retrieves the given concerts in the order requested, in
+	// a single query. IDs of concerts that don't exist are returned separately.
+	BatchGetConcerts(ctx context.Context, ids []int64) ([]*model.Concert, []int64, error)
+
+	// CompareConcerts retrieves the given concerts for side-by-side comparison,
+	// in the order requested. IDs of concerts that don't exist are returned separately.
+	CompareConcerts(ctx context.Context, ids []int64) ([]*model.Concert, []int64, error)
+
+	// Quote prices tickets for a concert at the price that applies now
+	Quote(ctx context.Context, id int64, ticketCount int) (*model.PriceQuote, error)
+
+	// SetBookingLimits replaces the limits quotes are checked against for
+	// concerts without their own, when the configuration is reloaded
+	SetBookingLimits(limits model.BookingLimits)
 }
 
+// MaxComparedConcerts is the maximum number of concerts compared at once
+const MaxComparedConcerts = 10
+
+// MaxBatchedConcerts is the maximum number of concerts fetched in one batch,
+// the same as the largest page of a listing
+const MaxBatchedConcerts = 100
+
 type concertService struct {
-	concertRepo repository.ConcertRepository
+	concertRepo  repository.ConcertRepository
+	auditService AuditService
+	limits       *bookingLimits
+	cache        repository.ConcertCache
 }
 
-// NewConcertService creates a new implementation of ConcertService
-func NewConcertService(concertRepo repository.ConcertRepository) ConcertService {
+// NewConcertService creates a new implementation of ConcertService. Patches
+// aren't audited when auditService is nil. Quotes are checked against the
+// booking limits, which apply to concerts without their own. Concerts are
+// read through the cache unless it is nil.
+func NewConcertService(concertRepo repository.ConcertRepository, auditService AuditService, limits model.BookingLimits, cache repository.ConcertCache) ConcertService {
+	if cache == nil {
+		cache = noopConcertCache{}
+	}
+
 	return &concertService{
-		concertRepo: concertRepo,
+		concertRepo:  concertRepo,
+		auditService: auditService,
+		limits:       newBookingLimits(limits),
+		cache:        cache,
 	}
 }
 
-// GetByID retrieves a concert by its ID
+// SetBookingLimits replaces the limits of concerts without their own
+func (s *concertService) SetBookingLimits(limits model.BookingLimits) {
+	s.limits.set(limits)
+}
+
+// GetByID retrieves a concert by its ID, from the cache if it holds it
 func (s *concertService) GetByID(ctx context.Context, id int64) (*model.Concert, error) {
-	return s.concertRepo.GetByID(ctx, id)
+	concert, err := readConcert(ctx, s.concertRepo, s.cache, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVisibility(ctx, s.concertRepo, concert); err != nil {
+		return nil, err
+	}
+
+	return concert, nil
 }
 
-// ListConcerts retrieves concerts with filtering and pagination
-func (s *concertService) ListConcerts(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Concert, int, error) {
-	if page < 1 {
-		page = 1
+// GetByIDs retrieves the concerts with the given IDs
+func (s *concertService) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	if len(ids) == 0 {
+		return []*model.Concert{}, nil
 	}
 
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	concerts, err := s.concertRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
 	}
 
-	offset := (page - 1) * pageSize
+	return s.visibleConcerts(ctx, concerts)
+}
+
+// ListConcerts retrieves concerts with filtering, sorting and pagination
+func (s *concertService) ListConcerts(ctx context.Context, opts query.Options) ([]*model.Concert, int, error) {
+	opts.Page = opts.Page.Normalize()
+
+	if opts.EstimateCount {
+		return s.listEstimated(ctx, opts)
+	}
 
 	// Get total count for pagination
-	totalCount, err := s.concertRepo.Count(ctx, filters)
+	totalCount, err := s.concertRepo.Count(ctx, opts.Filters)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get concerts for current page
-	concerts, err := s.concertRepo.List(ctx, pageSize, offset, filters)
+	concerts, err := s.concertRepo.List(ctx, opts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -67,6 +153,41 @@ func (s *concertService) ListConcerts(ctx context.Context, page, pageSize int, f
 	return concerts, totalCount, nil
 }
 
+// listEstimated lists a page with an estimated total. The page itself says
+// more than the estimate: a short page is the last one, which makes the total
+// exact, and a full page may be followed by more, so the total leaves room
+// for the next page and its cursor.
+func (s *concertService) listEstimated(ctx context.Context, opts query.Options) ([]*model.Concert, int, error) {
+	concerts, err := s.concertRepo.List(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	listed := opts.Page.Offset() + len(concerts)
+	short := len(concerts) > 0 && len(concerts) < opts.Page.Size
+	if short || (opts.Page.Number == 1 && len(concerts) == 0) {
+		return concerts, listed, nil
+	}
+
+	estimate, err := s.concertRepo.EstimateCount(ctx, opts.Filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A page past the end holds no more than the pages before it
+	if len(concerts) == 0 {
+		return concerts, min(estimate, listed), nil
+	}
+	return concerts, max(estimate, listed+1), nil
+}
+
+// ListingLastModified returns when any concert listing last changed. Every
+// concert counts, since a change to an unlisted one can move it into or out
+// of a listing, and so does each page of a listing.
+func (s *concertService) ListingLastModified(ctx context.Context) (time.Time, error) {
+	return s.concertRepo.LastModified(ctx, clock.Now())
+}
+
 // CreateConcert creates a new concert
 func (s *concertService) CreateConcert(ctx context.Context, concert *model.Concert) (*model.Concert, error) {
 	// Validate concert data
@@ -74,6 +195,10 @@ func (s *concertService) CreateConcert(ctx context.Context, concert *model.Conce
 		return nil, err
 	}
 
+	if err := setVisibility(concert, nil); err != nil {
+		return nil, err
+	}
+
 	// Set initial available tickets equal to total tickets
 	concert.AvailableTickets = concert.TotalTickets
 
@@ -82,18 +207,222 @@ func (s *concertService) CreateConcert(ctx context.Context, concert *model.Conce
 
 // UpdateConcert updates an existing concert
 func (s *concertService) UpdateConcert(ctx context.Context, concert *model.Concert) error {
+	// Check if the concert exists
+	existing, err := s.concertRepo.GetByID(ctx, concert.ID)
+	if err != nil {
+		return err
+	}
+
+	// Keep the existing currency unless a new one is given
+	if concert.Currency == "" {
+		concert.Currency = existing.Currency
+	}
+
 	// Validate concert data
 	if err := validateConcert(concert); err != nil {
 		return err
 	}
 
-	// Check if the concert exists
-	_, err := s.concertRepo.GetByID(ctx, concert.ID)
-	if err != nil {
+	if err := setVisibility(concert, existing); err != nil {
 		return err
 	}
 
-	return s.concertRepo.Update(ctx, concert)
+	if err := s.concertRepo.Update(ctx, concert); err != nil {
+		return err
+	}
+	invalidateConcert(ctx, s.cache, concert.ID)
+	return nil
+}
+
+// PatchConcert updates the patched fields of a concert and audits the changes
+func (s *concertService) PatchConcert(ctx context.Context, actor string, id int64, version int, patch *model.ConcertPatch) (*model.Concert, []model.FieldChange, error) {
+	if len(patch.Fields) == 0 {
+		return nil, nil, errors.ErrInvalidInput("at least one field must be updated")
+	}
+	for _, field := range patch.Fields {
+		if !model.IsConcertUpdateField(field) {
+			return nil, nil, errors.ErrInvalidInput(fmt.Sprintf("field %q cannot be updated", field))
+		}
+	}
+
+	existing, err := s.concertRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated := *existing
+	updated.InviteToken = ""
+	patch.Apply(&updated)
+	updated.Version = version
+
+	// Tickets already sold stay sold when the total changes
+	updated.AvailableTickets = existing.AvailableTickets + updated.TotalTickets - existing.TotalTickets
+	if updated.AvailableTickets < 0 {
+		return nil, nil, errors.ErrInvalidInput("total_tickets cannot be less than the tickets already sold")
+	}
+
+	if updated.Currency == "" {
+		updated.Currency = existing.Currency
+	}
+	if err := validateConcert(&updated); err != nil {
+		return nil, nil, err
+	}
+	if err := setVisibility(&updated, existing); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.concertRepo.Update(ctx, &updated); err != nil {
+		return nil, nil, err
+	}
+	invalidateConcert(ctx, s.cache, id)
+
+	changes := model.DiffConcerts(existing, &updated)
+	if len(changes) == 0 || s.auditService == nil {
+		return &updated, changes, nil
+	}
+
+	// The update is committed, so a failed audit write is reported without
+	// undoing it
+	if err := s.auditService.Record(ctx, actor, model.AuditActionConcertUpdated, "concert", strconv.FormatInt(id, 10), map[string]interface{}{
+		"changes": changes,
+		"version": updated.Version,
+	}); err != nil {
+		return &updated, changes, fmt.Errorf("concert updated but not audited: %w", err)
+	}
+
+	return &updated, changes, nil
+}
+
+// GetPriceHistory retrieves the price snapshots of a concert
+func (s *concertService) GetPriceHistory(ctx context.Context, id int64) ([]*model.PriceSnapshot, error) {
+	// Distinguish unknown concerts from concerts without history
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.concertRepo.GetPriceHistory(ctx, id)
+}
+
+// BatchGetConcerts retrieves many concerts in one round trip
+func (s *concertService) BatchGetConcerts(ctx context.Context, ids []int64) ([]*model.Concert, []int64, error) {
+	unique := uniqueIDs(ids)
+
+	if len(unique) == 0 {
+		return nil, nil, errors.ErrInvalidInput("at least one concert ID is required")
+	}
+
+	if len(unique) > MaxBatchedConcerts {
+		return nil, nil, errors.ErrInvalidInput(fmt.Sprintf("cannot fetch more than %d concerts at once", MaxBatchedConcerts))
+	}
+
+	return s.getInOrder(ctx, unique)
+}
+
+// CompareConcerts retrieves concerts for side-by-side comparison
+func (s *concertService) CompareConcerts(ctx context.Context, ids []int64) ([]*model.Concert, []int64, error) {
+	unique := uniqueIDs(ids)
+
+	if len(unique) < 2 {
+		return nil, nil, errors.ErrInvalidInput("at least two distinct concert IDs are required")
+	}
+
+	if len(unique) > MaxComparedConcerts {
+		return nil, nil, errors.ErrInvalidInput(fmt.Sprintf("cannot compare more than %d concerts", MaxComparedConcerts))
+	}
+
+	return s.getInOrder(ctx, unique)
+}
+
+// getInOrder retrieves distinct concerts with one query and returns them in
+// the order of ids, followed by the IDs of concerts that weren't found.
+// Private concerts without their invite token count as not found.
+func (s *concertService) getInOrder(ctx context.Context, ids []int64) ([]*model.Concert, []int64, error) {
+	found, err := s.concertRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	visible, err := s.visibleConcerts(ctx, found)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[int64]*model.Concert, len(visible))
+	for _, concert := range visible {
+		byID[concert.ID] = concert
+	}
+
+	concerts := make([]*model.Concert, 0, len(ids))
+	var missing []int64
+	for _, id := range ids {
+		if concert, ok := byID[id]; ok {
+			concerts = append(concerts, concert)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return concerts, missing, nil
+}
+
+// uniqueIDs drops duplicate IDs but keeps the requested order
+func uniqueIDs(ids []int64) []int64 {
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Quote prices tickets for a concert at the price that applies now
+func (s *concertService) Quote(ctx context.Context, id int64, ticketCount int) (*model.PriceQuote, error) {
+	if ticketCount <= 0 {
+		return nil, errors.ErrInvalidInput("ticket_count must be positive")
+	}
+
+	concert, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	if err := checkBookingLimits(s.limits.get(), concert, ticketCount, concert.PriceAt(now)); err != nil {
+		return nil, err
+	}
+
+	quote := &model.PriceQuote{
+		ConcertID:    concert.ID,
+		TicketCount:  ticketCount,
+		PricingPhase: concert.PricingPhaseAt(now),
+		UnitPrice:    concert.PriceAt(now),
+		Currency:     concert.Currency,
+	}
+	quote.Total = money.Round(quote.UnitPrice*float64(ticketCount), concert.Currency)
+
+	if quote.PricingPhase == model.PricingPhaseAdvance && concert.DoorPrice != nil {
+		validUntil := concert.DoorPriceStartsAt()
+		quote.ValidUntil = &validUntil
+	}
+
+	return quote, nil
+}
+
+// visibleConcerts drops the private concerts the caller has no invite for
+func (s *concertService) visibleConcerts(ctx context.Context, concerts []*model.Concert) ([]*model.Concert, error) {
+	visible := concerts[:0]
+	for _, concert := range concerts {
+		err := checkVisibility(ctx, s.concertRepo, concert)
+		if err == nil {
+			visible = append(visible, concert)
+		} else if !stderrors.Is(err, errors.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return visible, nil
 }
 
 // validateConcert validates concert data
@@ -122,6 +451,48 @@ func validateConcert(concert *model.Concert) error {
 		return errors.ErrInvalidInput("price cannot be negative")
 	}
 
+	if concert.DoorPrice != nil && *concert.DoorPrice < 0 {
+		return errors.ErrInvalidInput("door price cannot be negative")
+	}
+
+	if concert.DoorPriceLeadMinutes < 0 {
+		return errors.ErrInvalidInput("door price lead minutes cannot be negative")
+	}
+
+	if concert.MaxTicketsPerBooking != nil && *concert.MaxTicketsPerBooking <= 0 {
+		return errors.ErrInvalidInput("max tickets per booking must be positive")
+	}
+
+	if concert.MaxOrderValue != nil && *concert.MaxOrderValue <= 0 {
+		return errors.ErrInvalidInput("max order value must be positive")
+	}
+
+	if concert.Currency == "" {
+		concert.Currency = money.DefaultCurrency
+	}
+	concert.Currency = strings.ToUpper(concert.Currency)
+
+	if !money.IsSupported(concert.Currency) {
+		return errors.ErrInvalidInput("unsupported currency")
+	}
+
+	if concert.OrganizerEmail != "" {
+		if _, err := mail.ParseAddress(concert.OrganizerEmail); err != nil {
+			return errors.ErrInvalidInput("invalid organizer email")
+		}
+	}
+
+	// Store prices at the precision the currency is displayed in
+	concert.Price = money.Round(concert.Price, concert.Currency)
+	if concert.DoorPrice != nil {
+		doorPrice := money.Round(*concert.DoorPrice, concert.Currency)
+		concert.DoorPrice = &doorPrice
+	}
+	if concert.MaxOrderValue != nil {
+		maxOrderValue := money.Round(*concert.MaxOrderValue, concert.Currency)
+		concert.MaxOrderValue = &maxOrderValue
+	}
+
 	if concert.BookingStartTime.IsZero() {
 		return errors.ErrInvalidInput("booking start time is required")
 	}
@@ -134,7 +505,7 @@ func validateConcert(concert *model.Concert) error {
 		return errors.ErrInvalidInput("booking end time must be after booking start time")
 	}
 
-	if concert.BookingStartTime.Before(time.Now()) && concert.ID == 0 {
+	if concert.BookingStartTime.Before(clock.Now()) && concert.ID == 0 {
 		return errors.ErrInvalidInput("booking start time must be in the future for new concerts")
 	}
 
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+)
+
+// MaxConflictBuckets is the most buckets a conflict summary splits its
+// window into
+const MaxConflictBuckets = 1000
+
+// ConflictTracker records the outcome of booking retry loops so conflict
+// hot spots can be analysed per concert
+type ConflictTracker interface {
+	// RecordBooking records a finished booking attempt that made the given number of
+	// attempts and hit the given number of optimistic-lock conflicts
+	RecordBooking(concertID int64, attempts, conflicts int, exhausted bool)
+
+	// Summary aggregates the recorded bookings in the window ending now
+	Summary(window, bucket time.Duration) *model.BookingConflictSummary
+}
+
+// ConflictResolution is the granularity the tracker counts bookings at;
+// summary buckets are at least this long
+const ConflictResolution = time.Minute
+
+// conflictSlot counts the bookings of a concert recorded in one minute
+type conflictSlot struct {
+	bookings   int
+	attempts   int
+	conflicts  int
+	exhausted  int
+	retryDepth map[int]int
+}
+
+// concertConflicts holds the slots of a concert by the minute they start,
+// with the earliest minute that may still have one, so expired slots are
+// deleted one by one as time passes instead of scanning or copying
+type concertConflicts struct {
+	slots  map[int64]*conflictSlot
+	oldest int64
+}
+
+type conflictTracker struct {
+	mutex     sync.Mutex
+	concerts  map[int64]*concertConflicts
+	retention time.Duration
+	// expired is the minute the slots were last expired in
+	expired int64
+}
+
+// NewConflictTracker creates an in-memory ConflictTracker keeping counts for the given retention
+func NewConflictTracker(retention time.Duration) ConflictTracker {
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+
+	return &conflictTracker{
+		concerts:  make(map[int64]*concertConflicts),
+		retention: retention,
+	}
+}
+
+// conflictMinute returns the slot of a time
+func conflictMinute(at time.Time) int64 {
+	return at.Unix() / int64(ConflictResolution/time.Second)
+}
+
+// RecordBooking records a finished booking attempt in the counters of its
+// concert and minute
+func (t *conflictTracker) RecordBooking(concertID int64, attempts, conflicts int, exhausted bool) {
+	now := clock.Now()
+	minute := conflictMinute(now)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	concert, ok := t.concerts[concertID]
+	if !ok {
+		concert = &concertConflicts{slots: make(map[int64]*conflictSlot), oldest: minute}
+		t.concerts[concertID] = concert
+	}
+	slot, ok := concert.slots[minute]
+	if !ok {
+		slot = &conflictSlot{retryDepth: make(map[int]int)}
+		concert.slots[minute] = slot
+		// The test clock may be turned back
+		if minute < concert.oldest {
+			concert.oldest = minute
+		}
+	}
+
+	slot.bookings++
+	slot.attempts += attempts
+	slot.conflicts += conflicts
+	slot.retryDepth[attempts]++
+	if exhausted {
+		slot.exhausted++
+	}
+
+	// Slots expire by the minute, so once a minute is enough
+	if minute != t.expired {
+		t.expired = minute
+		t.expire(now)
+	}
+}
+
+// expire drops the slots that ended before the retention, and concerts left
+// without any. The caller must hold the lock.
+func (t *conflictTracker) expire(now time.Time) {
+	cutoff := conflictMinute(now.Add(-t.retention))
+	for id, concert := range t.concerts {
+		if cutoff-concert.oldest > int64(len(concert.slots)) {
+			// After a long pause, or a jump of the test clock, going
+			// through the slots is shorter than through the minutes
+			for minute := range concert.slots {
+				if minute < cutoff {
+					delete(concert.slots, minute)
+				}
+			}
+		} else {
+			for ; concert.oldest < cutoff; concert.oldest++ {
+				delete(concert.slots, concert.oldest)
+			}
+		}
+		if concert.oldest < cutoff {
+			concert.oldest = cutoff
+		}
+		if len(concert.slots) == 0 {
+			delete(t.concerts, id)
+		}
+	}
+}
+
+// Summary aggregates the recorded bookings in the window ending now. The
+// window is capped at the retention, and the bucket is raised to the
+// resolution and to a size that keeps the buckets within MaxConflictBuckets.
+func (t *conflictTracker) Summary(window, bucket time.Duration) *model.BookingConflictSummary {
+	if window <= 0 || window > t.retention {
+		window = t.retention
+	}
+
+	if bucket <= 0 || bucket > window {
+		bucket = window
+	}
+	if bucket < ConflictResolution {
+		bucket = ConflictResolution
+	}
+	if (window-1)/bucket >= MaxConflictBuckets {
+		bucket = (window + MaxConflictBuckets - 1) / MaxConflictBuckets
+	}
+
+	end := clock.Now()
+	start := end.Add(-window)
+	bucketCount := int((window-1)/bucket) + 1
+	first, last := conflictMinute(start), conflictMinute(end)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	concerts := make([]*model.ConcertConflictStats, 0, len(t.concerts))
+	for id, concert := range t.concerts {
+		var s *model.ConcertConflictStats
+		attempts := 0
+		for minute, slot := range concert.slots {
+			if minute < first || minute > last {
+				continue
+			}
+			if s == nil {
+				s = &model.ConcertConflictStats{
+					ConcertID:  id,
+					RetryDepth: make(map[int]int),
+					Buckets:    make([]model.ConflictBucket, bucketCount),
+				}
+				for b := range s.Buckets {
+					s.Buckets[b].Start = start.Add(time.Duration(b) * bucket)
+				}
+			}
+
+			s.Bookings += slot.bookings
+			s.Conflicts += slot.conflicts
+			s.ExhaustedRetries += slot.exhausted
+			for depth, count := range slot.retryDepth {
+				s.RetryDepth[depth] += count
+			}
+			attempts += slot.attempts
+
+			// A slot counts in the bucket its minute starts in, the
+			// minute the window starts in in the first one
+			b := 0
+			if at := time.Unix(minute*int64(ConflictResolution/time.Second), 0); at.After(start) {
+				b = int(at.Sub(start) / bucket)
+			}
+			if b >= bucketCount {
+				b = bucketCount - 1
+			}
+			s.Buckets[b].Bookings += slot.bookings
+			s.Buckets[b].Conflicts += slot.conflicts
+		}
+		if s == nil {
+			continue
+		}
+		if attempts > 0 {
+			s.ConflictRate = float64(s.Conflicts) / float64(attempts)
+		}
+		concerts = append(concerts, s)
+	}
+
+	// Most contended concerts first
+	sort.Slice(concerts, func(i, j int) bool {
+		if concerts[i].Conflicts != concerts[j].Conflicts {
+			return concerts[i].Conflicts > concerts[j].Conflicts
+		}
+		return concerts[i].ConcertID < concerts[j].ConcertID
+	})
+
+	return &model.BookingConflictSummary{
+		WindowStart: start,
+		WindowEnd:   end,
+		BucketSize:  bucket.String(),
+		Concerts:    concerts,
+	}
+}
+
+// noopConflictTracker discards everything it records
+type noopConflictTracker struct{}
+
+func (noopConflictTracker) RecordBooking(int64, int, int, bool) {}
+
+func (noopConflictTracker) Summary(window, bucket time.Duration) *model.BookingConflictSummary {
+	end := clock.Now()
+	return &model.BookingConflictSummary{
+		WindowStart: end.Add(-window),
+		WindowEnd:   end,
+		BucketSize:  bucket.String(),
+		Concerts:    []*model.ConcertConflictStats{},
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+)
+
+// releaseBatchSize is the maximum number of inventory releases executed per transaction
+const releaseBatchSize = 100
+
+// InventoryReleaseService defines the interface for staggered inventory releases
+type InventoryReleaseService interface {
+	// Schedule holds back tickets of a concert until they are released at the given time
+	Schedule(ctx context.Context, concertID int64, req *model.InventoryReleaseRequest) (*model.InventoryRelease, error)
+
+	// ListReleases retrieves the releases of a concert in release order
+	ListReleases(ctx context.Context, concertID int64) ([]*model.InventoryRelease, error)
+
+	// Cancel cancels a pending release and puts its tickets on sale right away
+	Cancel(ctx context.Context, concertID, releaseID int64) error
+
+	// ReleaseDue puts the tickets of every release that is due on sale and
+	// returns how many releases were executed
+	ReleaseDue(ctx context.Context) (int, error)
+}
+
+type inventoryReleaseService struct {
+	releaseRepo repository.InventoryReleaseRepository
+	concertRepo repository.ConcertRepository
+	events      *events.Bus
+}
+
+// NewInventoryReleaseService creates a new implementation of InventoryReleaseService.
+// Availability changes and back-in-stock events are published to the event bus
+// unless it is nil.
+func NewInventoryReleaseService(
+	releaseRepo repository.InventoryReleaseRepository,
+	concertRepo repository.ConcertRepository,
+	bus *events.Bus,
+) InventoryReleaseService {
+	return &inventoryReleaseService{
+		releaseRepo: releaseRepo,
+		concertRepo: concertRepo,
+		events:      bus,
+	}
+}
+
+// Schedule holds back tickets of a concert until their release
+func (s *inventoryReleaseService) Schedule(ctx context.Context, concertID int64, req *model.InventoryReleaseRequest) (*model.InventoryRelease, error) {
+	if req.Quantity <= 0 {
+		return nil, pkgErr.ErrInvalidInput("quantity must be positive")
+	}
+
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.ReleaseAt.After(clock.Now()) {
+		return nil, pkgErr.ErrInvalidInput("release_at must be in the future")
+	}
+
+	if !req.ReleaseAt.Before(concert.BookingEndTime) {
+		return nil, pkgErr.ErrInvalidInput("release_at must be before the booking end time")
+	}
+
+	release, err := s.releaseRepo.Create(ctx, &model.InventoryRelease{
+		ConcertID: concertID,
+		Quantity:  req.Quantity,
+		ReleaseAt: req.ReleaseAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishCurrentAvailability(ctx, concertID)
+	return release, nil
+}
+
+// ListReleases retrieves the releases of a concert
+func (s *inventoryReleaseService) ListReleases(ctx context.Context, concertID int64) ([]*model.InventoryRelease, error) {
+	// Distinguish unknown concerts from concerts without releases
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVisibility(ctx, s.concertRepo, concert); err != nil {
+		return nil, err
+	}
+
+	return s.releaseRepo.ListByConcertID(ctx, concertID)
+}
+
+// Cancel cancels a pending release
+func (s *inventoryReleaseService) Cancel(ctx context.Context, concertID, releaseID int64) error {
+	if err := s.releaseRepo.Cancel(ctx, concertID, releaseID); err != nil {
+		return err
+	}
+
+	s.publishCurrentAvailability(ctx, concertID)
+	return nil
+}
+
+// ReleaseDue executes the releases that are due
+func (s *inventoryReleaseService) ReleaseDue(ctx context.Context) (int, error) {
+	executed := 0
+	for {
+		now := clock.Now()
+		released, err := s.releaseRepo.ReleaseDue(ctx, now, releaseBatchSize)
+		if err != nil {
+			return executed, fmt.Errorf("failed to execute inventory releases: %w", err)
+		}
+
+		for _, result := range released {
+			s.publish(model.EventConcertAvailability, result.Release.ConcertID, &model.ConcertAvailability{
+				ConcertID:        result.Release.ConcertID,
+				AvailableTickets: result.AvailableTickets,
+				TotalTickets:     result.TotalTickets,
+				UpdatedAt:        now,
+			})
+
+			if result.WasSoldOut() {
+				s.publish(model.EventConcertBackInStock, result.Release.ConcertID, result)
+			}
+		}
+		executed += len(released)
+
+		if len(released) < releaseBatchSize {
+			return executed, nil
+		}
+	}
+}
+
+// publishCurrentAvailability announces the availability of a concert after
+// tickets were held back or returned
+func (s *inventoryReleaseService) publishCurrentAvailability(ctx context.Context, concertID int64) {
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		// The change is committed; watchers catch up with the next one
+		return
+	}
+
+	s.publish(model.EventConcertAvailability, concertID, &model.ConcertAvailability{
+		ConcertID:        concertID,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		UpdatedAt:        clock.Now(),
+	})
+}
+
+func (s *inventoryReleaseService) publish(topic string, concertID int64, payload interface{}) {
+	s.events.Publish(events.Event{
+		Topic:   topic,
+		Key:     concertID,
+		Payload: payload,
+		At:      clock.Now(),
+	})
+}
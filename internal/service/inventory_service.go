@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+)
+
+// InventoryService defines the interface for booking tickets from inventory
+// counters in Redis, which the database catches up with behind them
+type InventoryService interface {
+	// Reserve books the tickets of a booking from its concert's counter and
+	// returns how many tickets are left
+	Reserve(ctx context.Context, booking *model.Booking) (int, error)
+
+	// Flush subtracts the tickets of bookings made since the last flush from
+	// their concerts and returns how many bookings it settled
+	Flush(ctx context.Context) (int, error)
+}
+
+type inventoryService struct {
+	repo    repository.InventoryRepository
+	counter repository.InventoryCounter
+}
+
+// NewInventoryService creates a new implementation of InventoryService
+func NewInventoryService(repo repository.InventoryRepository, counter repository.InventoryCounter) InventoryService {
+	return &inventoryService{
+		repo:    repo,
+		counter: counter,
+	}
+}
+
+// Reserve books the tickets of a booking from its concert's counter, seeding
+// the counter if it expired or Redis lost it
+func (s *inventoryService) Reserve(ctx context.Context, booking *model.Booking) (int, error) {
+	left, err := s.repo.Reserve(ctx, booking, s.counter)
+	if !errors.Is(err, repository.ErrCounterMissing) {
+		return left, err
+	}
+
+	if err := s.repo.Seed(ctx, booking.ConcertID, s.counter); err != nil {
+		return 0, err
+	}
+	return s.repo.Reserve(ctx, booking, s.counter)
+}
+
+// Flush subtracts the tickets of pending bookings from their concerts
+func (s *inventoryService) Flush(ctx context.Context) (int, error) {
+	return s.repo.Flush(ctx)
+}
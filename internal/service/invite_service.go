@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// MaxInvitesPerRequest is the maximum number of invites generated at once
+const MaxInvitesPerRequest = 1000
+
+// InviteService defines the interface for the invites of private concerts
+type InviteService interface {
+	// GenerateInvites creates invites for a private concert. The invites are
+	// returned with their tokens, which aren't stored and can't be retrieved later.
+	GenerateInvites(ctx context.Context, concertID int64, req *model.InviteRequest) ([]*model.ConcertInvite, error)
+
+	// ExportInvites lists the invites of a concert and the bookings made with them
+	ExportInvites(ctx context.Context, concertID int64) (*model.InviteExport, error)
+}
+
+type inviteService struct {
+	concertRepo repository.ConcertRepository
+}
+
+// NewInviteService creates a new implementation of InviteService
+func NewInviteService(concertRepo repository.ConcertRepository) InviteService {
+	return &inviteService{
+		concertRepo: concertRepo,
+	}
+}
+
+// GenerateInvites creates invites for a private concert
+func (s *inviteService) GenerateInvites(ctx context.Context, concertID int64, req *model.InviteRequest) ([]*model.ConcertInvite, error) {
+	invitees, err := inviteesOf(req)
+	if err != nil {
+		return nil, err
+	}
+
+	concert, err := s.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if concert.Visibility != model.VisibilityPrivate {
+		return nil, pkgErr.ErrInvalidInput("invites can only be generated for private concerts")
+	}
+
+	invites := make([]*model.ConcertInvite, 0, len(invitees))
+	for _, invitee := range invitees {
+		token, err := newOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+
+		invites = append(invites, &model.ConcertInvite{
+			ConcertID: concertID,
+			Invitee:   invitee,
+			SingleUse: req.SingleUse,
+			TokenHash: hashToken(token),
+			Token:     token,
+		})
+	}
+
+	if err := s.concertRepo.CreateInvites(ctx, invites); err != nil {
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+// inviteesOf returns the invitee of every invite requested, empty for anonymous invites
+func inviteesOf(req *model.InviteRequest) ([]string, error) {
+	if len(req.Invitees) > 0 && req.Count > 0 {
+		return nil, pkgErr.ErrInvalidInput("give either invitees or count, not both")
+	}
+
+	count := req.Count
+	if len(req.Invitees) > 0 {
+		count = len(req.Invitees)
+	}
+
+	if count <= 0 {
+		return nil, pkgErr.ErrInvalidInput("invitees or a positive count is required")
+	}
+
+	if count > MaxInvitesPerRequest {
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("cannot generate more than %d invites at once", MaxInvitesPerRequest))
+	}
+
+	invitees := make([]string, count)
+	for i, invitee := range req.Invitees {
+		invitee = strings.TrimSpace(invitee)
+		if invitee == "" {
+			return nil, pkgErr.ErrInvalidInput("invitees must not be blank")
+		}
+		if len(invitee) > 255 {
+			return nil, pkgErr.ErrInvalidInput("invitees must be at most 255 characters")
+		}
+		invitees[i] = invitee
+	}
+
+	return invitees, nil
+}
+
+// ExportInvites lists which invitees of a concert booked
+func (s *inviteService) ExportInvites(ctx context.Context, concertID int64) (*model.InviteExport, error) {
+	if _, err := s.concertRepo.GetByID(ctx, concertID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.concertRepo.ListInviteRedemptions(ctx, concertID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.InviteExport{
+		ConcertID:  concertID,
+		Rows:       rows,
+		ExportedAt: clock.Now(),
+	}
+
+	invites := make(map[int64]bool)
+	for _, row := range rows {
+		invites[row.InviteID] = true
+		if row.BookingReference != nil {
+			export.Redeemed++
+		}
+	}
+	export.Invites = len(invites)
+
+	export.CSV, err = inviteExportCSV(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// inviteExportCSV renders one row per invite and redemption
+func inviteExportCSV(rows []*model.InviteExportRow) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	records := [][]string{
+		{"invite_id", "invitee", "single_use", "booking_reference", "user_id", "ticket_count", "booking_status", "redeemed_at"},
+	}
+
+	for _, row := range rows {
+		record := []string{
+			strconv.FormatInt(row.InviteID, 10),
+			row.Invitee,
+			strconv.FormatBool(row.SingleUse),
+			"", "", "", "", "",
+		}
+		if row.BookingReference != nil {
+			record[3] = *row.BookingReference
+		}
+		if row.UserID != nil {
+			record[4] = *row.UserID
+		}
+		if row.TicketCount != nil {
+			record[5] = strconv.Itoa(*row.TicketCount)
+		}
+		if row.BookingStatus != nil {
+			record[6] = *row.BookingStatus
+		}
+		if row.RedeemedAt != nil {
+			record[7] = row.RedeemedAt.UTC().Format(time.RFC3339)
+		}
+		records = append(records, record)
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return "", fmt.Errorf("failed to write invite export: %w", err)
+	}
+
+	return buf.String(), nil
+}
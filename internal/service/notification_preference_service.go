@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/notifications"
+)
+
+// phoneNumberPattern matches phone numbers in E.164 format
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// NotificationPreferenceService defines the interface for the notification
+// preferences users manage themselves
+type NotificationPreferenceService interface {
+	// GetPreferences retrieves the preferences of a user, the defaults if
+	// they set none
+	GetPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+
+	// UpdatePreferences validates and saves the changes of a user to their
+	// preferences
+	UpdatePreferences(ctx context.Context, userID string, req *model.NotificationPreferencesRequest) (*model.NotificationPreferences, error)
+}
+
+type notificationPreferenceService struct {
+	repo repository.NotificationPreferenceRepository
+}
+
+// NewNotificationPreferenceService creates a new implementation of
+// NotificationPreferenceService
+func NewNotificationPreferenceService(repo repository.NotificationPreferenceRepository) NotificationPreferenceService {
+	return &notificationPreferenceService{
+		repo: repo,
+	}
+}
+
+// GetPreferences retrieves the preferences of a user
+func (s *notificationPreferenceService) GetPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	if userID == "" {
+		return nil, errors.ErrInvalidInput("user_id is required")
+	}
+
+	preferences, err := s.repo.Get(ctx, userID)
+	if stderrors.Is(err, errors.ErrNotFound) {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if preferences.Types == nil {
+		preferences.Types = model.NotificationTypes{}
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences applies a request to the current preferences of a user,
+// so users who set none start from the defaults
+func (s *notificationPreferenceService) UpdatePreferences(ctx context.Context, userID string,
+	req *model.NotificationPreferencesRequest) (*model.NotificationPreferences, error) {
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		preferences.Email = *req.Email
+	}
+	if req.SMS != nil {
+		preferences.SMS = *req.SMS
+	}
+	if req.Push != nil {
+		preferences.Push = *req.Push
+	}
+	if req.PhoneNumber != nil {
+		preferences.PhoneNumber = strings.TrimSpace(*req.PhoneNumber)
+		if preferences.PhoneNumber != "" && !phoneNumberPattern.MatchString(preferences.PhoneNumber) {
+			return nil, errors.ErrInvalidInput("phone_number must be in E.164 format, like +15005550006")
+		}
+	}
+	if preferences.SMS && preferences.PhoneNumber == "" {
+		return nil, errors.ErrInvalidInput("phone_number is required to turn sms on")
+	}
+	if req.Types != nil {
+		if err := validateNotificationTypes(req.Types); err != nil {
+			return nil, err
+		}
+		preferences.Types = req.Types
+	}
+
+	if err := s.repo.Set(ctx, preferences); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// validateNotificationTypes checks that types only name known channels and
+// templates
+func validateNotificationTypes(types model.NotificationTypes) error {
+	for channel, templates := range types {
+		if !slices.Contains(model.NotificationPreferenceChannels, channel) {
+			return errors.ErrInvalidInput(fmt.Sprintf("unknown channel %q in types, must be one of %s",
+				channel, strings.Join(model.NotificationPreferenceChannels, ", ")))
+		}
+		for template := range templates {
+			if !slices.Contains(notifications.Templates, template) {
+				return errors.ErrInvalidInput(fmt.Sprintf("unknown notification type %q, must be one of %s",
+					template, strings.Join(notifications.Templates, ", ")))
+			}
+		}
+	}
+	return nil
+}
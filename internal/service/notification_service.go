@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/notifications"
+)
+
+// NotificationService defines the interface for the notifications of
+// bookings and concerts sent to the attendees and users of bookings
+type NotificationService interface {
+	// Dispatch turns the booking and concert events written to the outbox
+	// since the last run into notifications of the enabled templates and
+	// returns the number created
+	Dispatch(ctx context.Context) (int, error)
+
+	// EnqueueReminders creates the reminders of the concerts taking place
+	// within the reminder lead and returns the number created
+	EnqueueReminders(ctx context.Context) (int, error)
+
+	// SendDue sends the notifications that are due and returns the number
+	// sent. A failed attempt is retried after a backoff, and a notification
+	// that failed every attempt is dead. Only failing to record an attempt
+	// is an error.
+	SendDue(ctx context.Context) (int, error)
+
+	// PurgeNotifications deletes the sent and dead notifications older than
+	// the retention and returns the number deleted
+	PurgeNotifications(ctx context.Context) (int, error)
+
+	// NotifyQueueTurn texts a user admitted from the waiting room that it's
+	// their turn to book, if they opted in. It is sent once, right away.
+	NotifyQueueTurn(ctx context.Context, token *model.BookingToken) error
+}
+
+// notificationChannels are the channels in the order a booking's
+// notifications are created
+var notificationChannels = []string{model.NotificationChannelEmail, model.NotificationChannelSMS}
+
+type notificationService struct {
+	repo        repository.NotificationRepository
+	preferences repository.NotificationPreferenceRepository
+	bookingRepo repository.BookingRepository
+	concertRepo repository.ConcertRepository
+	notifiers   map[string]notifications.Notifier
+	policy      model.NotificationPolicy
+}
+
+// NewNotificationService creates a new implementation of NotificationService
+// sending the notifications of each channel through its notifier
+func NewNotificationService(
+	repo repository.NotificationRepository,
+	preferences repository.NotificationPreferenceRepository,
+	bookingRepo repository.BookingRepository,
+	concertRepo repository.ConcertRepository,
+	notifiers map[string]notifications.Notifier,
+	policy model.NotificationPolicy,
+) NotificationService {
+	return &notificationService{
+		repo:        repo,
+		preferences: preferences,
+		bookingRepo: bookingRepo,
+		concertRepo: concertRepo,
+		notifiers:   notifiers,
+		policy:      policy,
+	}
+}
+
+// Dispatch works through the outbox a batch at a time. A batch is marked
+// notified once its notifications are created, so a run that fails halfway
+// creates the rest next time; notifications that exist already are skipped.
+func (s *notificationService) Dispatch(ctx context.Context) (int, error) {
+	enqueued := 0
+	for {
+		events, err := s.repo.ListEvents(ctx, s.policy.BatchSize)
+		if err != nil || len(events) == 0 {
+			return enqueued, err
+		}
+
+		now := clock.Now()
+		var pending []*model.Notification
+		ids := make([]int64, 0, len(events))
+		preferences := make(map[string]*model.NotificationPreferences)
+		for _, event := range events {
+			ids = append(ids, event.ID)
+			if now.Sub(event.CreatedAt) > s.policy.MaxEventAge {
+				continue
+			}
+
+			batch, err := s.notificationsOf(ctx, event, preferences)
+			if err != nil {
+				return enqueued, err
+			}
+			pending = append(pending, batch...)
+		}
+
+		created, err := s.repo.Enqueue(ctx, pending)
+		if err != nil {
+			return enqueued, err
+		}
+		enqueued += created
+
+		if err := s.repo.MarkEventsNotified(ctx, ids, now); err != nil {
+			return enqueued, err
+		}
+		if len(events) < s.policy.BatchSize || ctx.Err() != nil {
+			return enqueued, nil
+		}
+	}
+}
+
+// notificationsOf returns the notifications of an event: a confirmation or
+// cancellation for the attendee of a booking, or a rescheduling for the
+// attendees of a concert's confirmed bookings, over the channels their users
+// want. Preferences caches the preferences of the users of a batch.
+func (s *notificationService) notificationsOf(ctx context.Context, event *model.OutboxEvent,
+	preferences map[string]*model.NotificationPreferences) ([]*model.Notification, error) {
+	switch event.EventType {
+	case model.EventBookingConfirmed, model.EventBookingCancelled:
+		template := notifications.TemplateBookingConfirmed
+		if event.EventType == model.EventBookingCancelled {
+			template = notifications.TemplateBookingCancelled
+		}
+
+		var payload model.BookingEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		booking, err := s.bookingRepo.GetByReference(ctx, payload.Reference)
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.notificationsFor(ctx, template, booking, event.ID, preferences)
+
+	case model.EventConcertUpdated:
+		var payload model.ConcertEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode event %d: %w", event.ID, err)
+		}
+		if payload.PreviousConcertDate == nil {
+			return nil, nil
+		}
+		bookings, err := s.bookingRepo.GetAllByConcertID(ctx, payload.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		var rescheduled []*model.Notification
+		for _, booking := range bookings {
+			if booking.Status != model.BookingStatusConfirmed {
+				continue
+			}
+			batch, err := s.notificationsFor(ctx, notifications.TemplateConcertRescheduled, booking, event.ID, preferences)
+			if err != nil {
+				return nil, err
+			}
+			for _, notification := range batch {
+				notification.PreviousConcertDate = payload.PreviousConcertDate
+			}
+			rescheduled = append(rescheduled, batch...)
+		}
+		return rescheduled, nil
+	}
+
+	return nil, nil
+}
+
+// notificationsFor returns a pending notification of a template about a
+// booking, due now, for each channel the template is enabled on that the
+// booking's user wants and has a recipient for
+func (s *notificationService) notificationsFor(ctx context.Context, template string, booking *model.Booking, eventID int64,
+	preferences map[string]*model.NotificationPreferences) ([]*model.Notification, error) {
+	var created []*model.Notification
+	for _, channel := range notificationChannels {
+		if !s.policy.Enabled(channel, template) {
+			continue
+		}
+
+		userPreferences, ok := preferences[booking.UserID]
+		if !ok {
+			var err error
+			if userPreferences, err = s.preferencesOf(ctx, booking.UserID); err != nil {
+				return nil, err
+			}
+			preferences[booking.UserID] = userPreferences
+		}
+		if recipientOf(channel, template, booking, userPreferences) == "" {
+			continue
+		}
+
+		created = append(created, &model.Notification{
+			Channel:       channel,
+			Template:      template,
+			BookingID:     booking.ID,
+			EventID:       eventID,
+			Status:        model.NotificationPending,
+			NextAttemptAt: clock.Now(),
+		})
+	}
+	return created, nil
+}
+
+// preferencesOf returns the notification preferences of a user, the
+// defaults if they set none
+func (s *notificationService) preferencesOf(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	preferences, err := s.preferences.Get(ctx, userID)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+	return preferences, err
+}
+
+// recipientOf returns who a notification about a booking is sent to over a
+// channel: the attendee's email, or the phone number of the booking's user.
+// It is empty if there is no one or the user doesn't want the template on
+// the channel.
+func recipientOf(channel, template string, booking *model.Booking, preferences *model.NotificationPreferences) string {
+	if !preferences.Wants(channel, template) {
+		return ""
+	}
+
+	switch channel {
+	case model.NotificationChannelEmail:
+		return booking.AttendeeEmail
+	case model.NotificationChannelSMS:
+		return preferences.PhoneNumber
+	}
+	return ""
+}
+
+// EnqueueReminders creates the email reminders of the concerts starting
+// between now and the reminder lead from now
+func (s *notificationService) EnqueueReminders(ctx context.Context) (int, error) {
+	if !s.policy.Enabled(model.NotificationChannelEmail, notifications.TemplateConcertReminder) {
+		return 0, nil
+	}
+
+	now := clock.Now()
+	return s.repo.EnqueueReminders(ctx, notifications.TemplateConcertReminder, now, now.Add(s.policy.ReminderLead), now)
+}
+
+// SendDue sends the due notifications one after another
+func (s *notificationService) SendDue(ctx context.Context) (int, error) {
+	due, err := s.repo.ListDue(ctx, clock.Now(), s.policy.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.attempt(ctx, notification); err != nil {
+			return sent, err
+		}
+		if notification.Status == model.NotificationSent {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// errNoRecipient is recorded on notifications whose booking was deleted, has
+// no recipient anymore, e.g. because it was erased, or whose user turned
+// the channel or the template off
+var errNoRecipient = errors.New("no recipient for the notification")
+
+// attempt sends a notification once and records the outcome
+func (s *notificationService) attempt(ctx context.Context, notification *model.Notification) error {
+	err := s.send(ctx, notification)
+
+	now := clock.Now()
+	notification.Attempts++
+	if err == nil {
+		notification.Status = model.NotificationSent
+		notification.SentAt = &now
+		notification.LastError = nil
+		return s.repo.RecordAttempt(ctx, notification)
+	}
+
+	message := err.Error()
+	notification.LastError = &message
+	if errors.Is(err, errNoRecipient) || notification.Attempts >= s.policy.MaxAttempts {
+		notification.Status = model.NotificationDead
+	} else {
+		notification.NextAttemptAt = now.Add(s.policy.Backoff(notification.Attempts))
+	}
+
+	return s.repo.RecordAttempt(ctx, notification)
+}
+
+// send renders a notification from its booking and concert and sends it to
+// the recipient the booking and its user's preferences have now
+func (s *notificationService) send(ctx context.Context, notification *model.Notification) error {
+	notifier, ok := s.notifiers[notification.Channel]
+	if !ok {
+		return fmt.Errorf("%w: channel %s is disabled", errNoRecipient, notification.Channel)
+	}
+
+	booking, err := s.bookingRepo.GetByID(ctx, notification.BookingID)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return errNoRecipient
+	}
+	if err != nil {
+		return err
+	}
+	preferences, err := s.preferencesOf(ctx, booking.UserID)
+	if err != nil {
+		return err
+	}
+	to := recipientOf(notification.Channel, notification.Template, booking, preferences)
+	if to == "" {
+		return errNoRecipient
+	}
+	concert, err := s.concertRepo.GetByID(ctx, booking.ConcertID)
+	if err != nil {
+		return err
+	}
+
+	data := &notifications.Data{
+		AttendeeName: booking.AttendeeName,
+		Reference:    booking.Reference,
+		TicketCount:  booking.TicketCount,
+		Total:        money.Format(money.Round(booking.UnitPrice*float64(booking.TicketCount), concert.Currency), concert.Currency, ""),
+		ConcertName:  concert.Name,
+		Artist:       concert.Artist,
+		Venue:        concert.Venue,
+		ConcertDate:  concert.ConcertDate,
+	}
+	if notification.PreviousConcertDate != nil {
+		data.PreviousConcertDate = *notification.PreviousConcertDate
+	}
+
+	return notifier.Notify(ctx, to, notification.Template, data)
+}
+
+// PurgeNotifications deletes the finished notifications older than the
+// retention
+func (s *notificationService) PurgeNotifications(ctx context.Context) (int, error) {
+	return s.repo.Purge(ctx, clock.Now().Add(-s.policy.Retention))
+}
+
+// NotifyQueueTurn texts the user of a booking token, without a notification
+// to retry: the token expires in minutes, so a late text is no use
+func (s *notificationService) NotifyQueueTurn(ctx context.Context, token *model.BookingToken) error {
+	notifier, ok := s.notifiers[model.NotificationChannelSMS]
+	if !ok || !s.policy.Enabled(model.NotificationChannelSMS, notifications.TemplateQueueTurn) {
+		return nil
+	}
+
+	preferences, err := s.preferencesOf(ctx, token.UserID)
+	if err != nil || !preferences.Wants(model.NotificationChannelSMS, notifications.TemplateQueueTurn) {
+		return err
+	}
+	concert, err := s.concertRepo.GetByID(ctx, token.ConcertID)
+	if err != nil {
+		return err
+	}
+
+	return notifier.Notify(ctx, preferences.PhoneNumber, notifications.TemplateQueueTurn, &notifications.Data{
+		ConcertName:    concert.Name,
+		Artist:         concert.Artist,
+		Venue:          concert.Venue,
+		ConcertDate:    concert.ConcertDate,
+		TokenExpiresAt: token.ExpiresAt,
+	})
+}
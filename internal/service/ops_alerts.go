@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/alerts"
+	"concert-ticket-api/pkg/clock"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var opsAlertsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ops_alerts_total",
+	Help: "Operational alerts by kind and outcome: posted, throttled or failed.",
+}, []string{"kind", "outcome"})
+
+// The kinds of operational alerts
+const (
+	OpsAlertSellOut   = "sell_out"
+	OpsAlertErrorRate = "error_rate"
+	OpsAlertDrift     = "drift"
+)
+
+// OpsAlerts posts operational alerts to the ops channel: sell-outs, spikes
+// of failing bookings and bookings disagreeing with their payments. Repeats
+// are throttled by the alerter.
+type OpsAlerts interface {
+	// Record counts a booking attempt towards the current error rate
+	// interval
+	BookingAttemptRecorder
+
+	// SellOut alerts that a concert sold out
+	SellOut(ctx context.Context, concertID int64) error
+
+	// CheckErrorRate alerts if the error rate of the booking attempts since
+	// the last check is above the threshold, and starts the next interval
+	CheckErrorRate(ctx context.Context) error
+
+	// Drift alerts if a payment reconciliation run found mismatches
+	Drift(ctx context.Context, report *model.PaymentReconciliation) error
+
+	// SetPolicy replaces which alerts are posted and the error rate
+	// threshold, when the configuration is reloaded
+	SetPolicy(policy model.OpsAlertPolicy)
+}
+
+type opsAlerts struct {
+	alerter     *alerts.Alerter
+	concertRepo repository.ConcertRepository
+	policy      atomic.Pointer[model.OpsAlertPolicy]
+
+	// The booking attempts of the current interval
+	attempts atomic.Int64
+	failures atomic.Int64
+
+	mu        sync.Mutex
+	startedAt time.Time
+}
+
+// NewOpsAlerts creates a new implementation of OpsAlerts posting through
+// alerter
+func NewOpsAlerts(alerter *alerts.Alerter, concertRepo repository.ConcertRepository, policy model.OpsAlertPolicy) OpsAlerts {
+	a := &opsAlerts{
+		alerter:     alerter,
+		concertRepo: concertRepo,
+		startedAt:   clock.Now(),
+	}
+	a.policy.Store(&policy)
+	return a
+}
+
+// SetPolicy replaces the policy, from the next alert on
+func (a *opsAlerts) SetPolicy(policy model.OpsAlertPolicy) {
+	a.policy.Store(&policy)
+}
+
+// Record counts a booking attempt. Like for the admission controller, only
+// errors count as failures.
+func (a *opsAlerts) Record(attempt *model.BookingAttempt) {
+	a.attempts.Add(1)
+	if attempt.Reason == model.BookingAttemptError {
+		a.failures.Add(1)
+	}
+}
+
+// SellOut alerts once per concert within the throttle
+func (a *opsAlerts) SellOut(ctx context.Context, concertID int64) error {
+	if !a.policy.Load().SellOuts {
+		return nil
+	}
+
+	concert, err := a.concertRepo.GetByID(ctx, concertID)
+	if err != nil {
+		return err
+	}
+
+	return a.post(ctx, OpsAlertSellOut, strconv.FormatInt(concertID, 10), fmt.Sprintf(
+		"Sold out: %s by %s at %s on %s (concert %d), all %d tickets booked",
+		concert.Name, concert.Artist, concert.Venue, concert.ConcertDate.Format("2 Jan 2006"), concert.ID, concert.TotalTickets))
+}
+
+// CheckErrorRate compares the attempts since the last check with the
+// threshold
+func (a *opsAlerts) CheckErrorRate(ctx context.Context) error {
+	attempts, failures := a.attempts.Swap(0), a.failures.Swap(0)
+	now := clock.Now()
+	a.mu.Lock()
+	interval := now.Sub(a.startedAt).Round(time.Second)
+	a.startedAt = now
+	a.mu.Unlock()
+
+	policy := a.policy.Load()
+	if policy.ErrorRateThreshold <= 0 || attempts < int64(policy.ErrorRateMinAttempts) {
+		return nil
+	}
+	rate := float64(failures) / float64(attempts)
+	if rate <= policy.ErrorRateThreshold {
+		return nil
+	}
+
+	return a.post(ctx, OpsAlertErrorRate, policy.Instance, fmt.Sprintf(
+		"Booking error rate at %.0f%% on %s: %d of %d attempts failed in the last %s (threshold %.0f%%)",
+		rate*100, policy.Instance, failures, attempts, interval, policy.ErrorRateThreshold*100))
+}
+
+// Drift alerts on the mismatches of a reconciliation run
+func (a *opsAlerts) Drift(ctx context.Context, report *model.PaymentReconciliation) error {
+	if !a.policy.Load().Drift || report == nil {
+		return nil
+	}
+
+	paid, confirmed := report.Mismatches[model.MismatchPaidNotConfirmed], report.Mismatches[model.MismatchConfirmedNotPaid]
+	if paid+confirmed == 0 {
+		return nil
+	}
+
+	return a.post(ctx, OpsAlertDrift, "", fmt.Sprintf(
+		"Payment reconciliation found %d bookings disagreeing with their payments: %d paid but not confirmed, %d confirmed but not paid, %d repaired",
+		paid+confirmed, paid, confirmed, report.Repaired))
+}
+
+// post posts an alert of a kind about a subject and counts the outcome
+func (a *opsAlerts) post(ctx context.Context, kind, subject, text string) error {
+	posted, err := a.alerter.Alert(ctx, kind+":"+subject, text)
+	switch {
+	case err != nil:
+		opsAlertsTotal.WithLabelValues(kind, "failed").Inc()
+	case posted:
+		opsAlertsTotal.WithLabelValues(kind, "posted").Inc()
+	default:
+		opsAlertsTotal.WithLabelValues(kind, "throttled").Inc()
+	}
+	return err
+}
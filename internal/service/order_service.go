@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
+)
+
+// OrderService defines the interface for orders, the bookings of one
+// checkout paid as one
+type OrderService interface {
+	// GetOrderByReference retrieves an order with its bookings by its public reference
+	GetOrderByReference(ctx context.Context, ref string) (*model.Order, error)
+
+	// GetUserOrders retrieves the orders of a user, newest first
+	GetUserOrders(ctx context.Context, userID string, page query.Page) ([]*model.Order, error)
+
+	// CancelOrder cancels an order and every booking of it still confirmed
+	CancelOrder(ctx context.Context, ref string, userID string) (*model.Order, error)
+}
+
+type orderService struct {
+	orderRepo repository.OrderRepository
+	events    *events.Bus
+}
+
+// NewOrderService creates a new implementation of OrderService. Like
+// booking cancellations, order cancellations are published to the event bus
+// unless it is nil.
+func NewOrderService(orderRepo repository.OrderRepository, bus *events.Bus) OrderService {
+	return &orderService{
+		orderRepo: orderRepo,
+		events:    bus,
+	}
+}
+
+// GetOrderByReference retrieves an order by its public reference
+func (s *orderService) GetOrderByReference(ctx context.Context, ref string) (*model.Order, error) {
+	// Malformed references can't exist, so they don't need a lookup
+	ref, ok := reference.Normalize(ref)
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	order, err := s.orderRepo.GetByReference(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	setOrderTotal(order)
+	return order, nil
+}
+
+// GetUserOrders retrieves the orders of a user
+func (s *orderService) GetUserOrders(ctx context.Context, userID string, page query.Page) ([]*model.Order, error) {
+	if userID == "" {
+		return nil, pkgErr.ErrInvalidInput("user_id is required")
+	}
+
+	orders, err := s.orderRepo.GetByUserID(ctx, userID, page.Normalize())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		setOrderTotal(order)
+	}
+	return orders, nil
+}
+
+// CancelOrder cancels an order of a user and returns the tickets of its
+// confirmed bookings to the concerts
+func (s *orderService) CancelOrder(ctx context.Context, ref string, userID string) (*model.Order, error) {
+	order, err := s.GetOrderByReference(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the order belongs to the user
+	if order.UserID != userID {
+		return nil, pkgErr.ErrUnauthorized
+	}
+
+	if order.Status == model.BookingStatusCancelled {
+		return nil, pkgErr.ErrOrderAlreadyCancelled
+	}
+
+	// A concurrent cancellation of the order gets ErrOrderAlreadyCancelled
+	// there, and bookings cancelled concurrently are skipped
+	availabilities, err := s.orderRepo.Cancel(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, availability := range availabilities {
+		s.events.Publish(events.Event{
+			Topic:   model.EventConcertAvailability,
+			Key:     availability.ConcertID,
+			Payload: availability,
+			At:      availability.UpdatedAt,
+		})
+	}
+
+	// The order is read again for the bookings this cancellation cancelled
+	cancelled, err := s.GetOrderByReference(ctx, order.Reference)
+	if err != nil {
+		return nil, err
+	}
+	confirmed := make(map[int64]bool, len(order.Bookings))
+	for _, booking := range order.Bookings {
+		confirmed[booking.ID] = booking.Status == model.BookingStatusConfirmed
+	}
+	for _, booking := range cancelled.Bookings {
+		if confirmed[booking.ID] && booking.Status == model.BookingStatusCancelled {
+			published := *booking
+			s.events.Publish(events.Event{
+				Topic:   model.EventBookingStatus,
+				Key:     model.UserEventKey(booking.UserID),
+				Payload: &published,
+				At:      booking.UpdatedAt,
+			})
+		}
+	}
+
+	return cancelled, nil
+}
+
+// setOrderTotal sets the total of an order from the prices its bookings were
+// booked at. Each booking is rounded like it is charged, so the total is the
+// sum of the charges.
+func setOrderTotal(order *model.Order) {
+	order.Total = 0
+	for _, booking := range order.Bookings {
+		order.Total += money.Round(booking.UnitPrice*float64(booking.TicketCount), order.Currency)
+	}
+	order.Total = money.Round(order.Total, order.Currency)
+}
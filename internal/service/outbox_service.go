@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+)
+
+// OutboxService relays the outbox of domain events to the message broker
+type OutboxService interface {
+	// Relay publishes the pending events in the order they were written and
+	// returns the number published. An event is published at least once: it
+	// is published again if recording that it was fails. Once an event of an
+	// aggregate fails, the later events of the aggregate wait for the next
+	// run, so each aggregate's events are published in order.
+	Relay(ctx context.Context) (int, error)
+
+	// PurgeExpired deletes the events older than the retention, only the
+	// published ones if there is a broker, and returns the number deleted
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+type outboxService struct {
+	repo      repository.OutboxRepository
+	publisher events.Publisher
+	batchSize int
+	retention time.Duration
+}
+
+// NewOutboxService creates a new implementation of OutboxService. Without a
+// publisher, nothing is relayed.
+func NewOutboxService(repo repository.OutboxRepository, publisher events.Publisher, batchSize int, retention time.Duration) OutboxService {
+	return &outboxService{
+		repo:      repo,
+		publisher: publisher,
+		batchSize: batchSize,
+		retention: retention,
+	}
+}
+
+// Relay publishes a batch of pending events
+func (s *outboxService) Relay(ctx context.Context) (int, error) {
+	if s.publisher == nil {
+		return 0, nil
+	}
+
+	pending, err := s.repo.ListPending(ctx, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var firstErr error
+	published := 0
+	failed := make(map[string]bool)
+	for _, event := range pending {
+		key := event.Key()
+		if failed[key] {
+			continue
+		}
+
+		err := s.publisher.Publish(ctx, &events.Message{
+			ID:      event.ID,
+			Topic:   event.EventType,
+			Key:     key,
+			Payload: event.Payload,
+			At:      event.CreatedAt,
+		})
+		if err != nil {
+			failed[key] = true
+			if markErr := s.repo.MarkFailed(ctx, event.ID, err.Error(), clock.Now()); markErr != nil {
+				return published, markErr
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to publish event %d to %s: %w", event.ID, s.publisher.Name(), err)
+			}
+			continue
+		}
+
+		if err := s.repo.MarkPublished(ctx, event.ID, clock.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, firstErr
+}
+
+// PurgeExpired deletes the events older than the retention
+func (s *outboxService) PurgeExpired(ctx context.Context) (int, error) {
+	return s.repo.PurgeBefore(ctx, clock.Now().Add(-s.retention), s.publisher != nil)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/payments"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// paymentMismatchKinds are the kinds of mismatches reconciliation finds
+var paymentMismatchKinds = []string{model.MismatchPaidNotConfirmed, model.MismatchConfirmedNotPaid}
+
+var (
+	reconciledPayments = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "payment_reconciliation_checked_total",
+		Help: "Payments compared with the payment provider's records.",
+	})
+
+	paymentMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_reconciliation_mismatches_total",
+		Help: "Mismatches found between bookings and the payment provider's records, by kind.",
+	}, []string{"kind"})
+
+	repairedMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_reconciliation_repairs_total",
+		Help: "Mismatches repaired by confirming or expiring the booking, by kind.",
+	}, []string{"kind"})
+
+	flaggedPayments = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "payment_reconciliation_flagged",
+		Help: "Payments whose booking disagreed with the payment provider when last reconciled, by kind of mismatch.",
+	}, []string{"kind"})
+)
+
+// Reconcile compares the bookings of the least recently reconciled payments
+// with the provider's records. Payments that changed within the grace
+// period are left to the provider's notifications. A payment the provider
+// can't be asked about is tried again first on the next run.
+func (s *paymentService) Reconcile(ctx context.Context) (*model.PaymentReconciliation, error) {
+	now := clock.Now()
+	checks, err := s.repo.ListForReconciliation(ctx, now.Add(-s.policy.ReconcileLookback), now.Add(-s.policy.ReconcileGrace),
+		s.policy.ReconcileBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.PaymentReconciliation{Mismatches: make(map[string]int)}
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, check := range checks {
+		status, err := s.provider.Lookup(ctx, check.ProviderReference)
+		if err != nil {
+			fail(fmt.Errorf("failed to look up payment %s: %w", check.ProviderReference, err))
+			continue
+		}
+		report.Checked++
+		reconciledPayments.Inc()
+
+		mismatch := paymentMismatch(check.BookingStatus, status)
+		if mismatch != "" {
+			report.Mismatches[mismatch]++
+			paymentMismatches.WithLabelValues(mismatch).Inc()
+
+			if s.policy.Repair {
+				if err := s.repair(ctx, check, status, mismatch); err != nil {
+					fail(fmt.Errorf("failed to repair payment %s: %w", check.ProviderReference, err))
+				} else {
+					report.Repaired++
+					repairedMismatches.WithLabelValues(mismatch).Inc()
+					mismatch = ""
+				}
+			}
+		}
+
+		if err := s.repo.MarkReconciled(ctx, check.BookingID, mismatch, now); err != nil {
+			fail(err)
+		}
+	}
+
+	flagged, err := s.repo.CountMismatches(ctx)
+	if err != nil {
+		fail(err)
+	} else {
+		for _, kind := range paymentMismatchKinds {
+			flaggedPayments.WithLabelValues(kind).Set(float64(flagged[kind]))
+		}
+	}
+
+	return report, firstErr
+}
+
+// paymentMismatch returns the kind of mismatch between the status of a
+// booking and the provider's status of its payment, or "" if they agree.
+// An authorized payment counts as paid: it is captured after confirming.
+func paymentMismatch(booking model.BookingStatus, provider string) string {
+	paid := provider == payments.StatusAuthorized || provider == payments.StatusSucceeded
+	switch {
+	case paid && booking != model.BookingStatusConfirmed:
+		return model.MismatchPaidNotConfirmed
+	case !paid && booking == model.BookingStatusConfirmed:
+		return model.MismatchConfirmedNotPaid
+	}
+	return ""
+}
+
+// repair makes a booking agree with the provider's status of its payment. A
+// paid booking that is still pending is confirmed; one that was released
+// gets its money back. A confirmed booking that wasn't paid is expired.
+func (s *paymentService) repair(ctx context.Context, check *model.PaymentCheck, status, mismatch string) error {
+	payment := &check.Payment
+	captured := status == payments.StatusSucceeded
+
+	if mismatch == model.MismatchPaidNotConfirmed {
+		if check.BookingStatus == model.BookingStatusPending {
+			_, err := s.capture(ctx, payment, captured)
+			return err
+		}
+		return s.giveBack(ctx, payment, payment.Status, captured)
+	}
+
+	return s.expire(ctx, payment, status)
+}
+
+// expire cancels a confirmed booking whose payment the provider didn't take
+// and returns its tickets. A payment still open is voided first, so it
+// can't be paid once the booking is cancelled.
+func (s *paymentService) expire(ctx context.Context, payment *model.Payment, status string) error {
+	to := status
+	if status == payments.StatusPending {
+		if err := s.provider.Void(ctx, payment.ProviderReference); err != nil {
+			return fmt.Errorf("failed to void payment: %w", err)
+		}
+		to = model.PaymentCanceled
+	}
+
+	booking, err := s.bookingRepo.GetByID(ctx, payment.BookingID)
+	if err != nil {
+		return err
+	}
+	availability, err := s.bookingRepo.CancelWithTicketRelease(ctx, payment.BookingID)
+	switch {
+	case err == nil:
+		booking.Status = model.BookingStatusCancelled
+		s.publishAvailability(availability)
+		s.publishBookingStatus(booking)
+	case !errors.Is(err, pkgErr.ErrBookingAlreadyCancelled):
+		return err
+	}
+
+	_, err = s.repo.UpdateStatus(ctx, payment.BookingID, payment.Status, to)
+	return err
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/money"
+	"concert-ticket-api/pkg/payments"
+)
+
+// expiryBatchSize is the maximum number of expired holds released per run
+const expiryBatchSize = 100
+
+// PaymentService defines the interface for taking the payments of bookings.
+// A booking made while payments are taken is pending, holding its tickets,
+// until its payment is authorized, which confirms it, or its hold expires.
+// Authorized payments are captured once their booking is confirmed.
+type PaymentService interface {
+	// Provider names the payment provider, e.g. "stripe"
+	Provider() string
+
+	// Start creates the payment of a pending booking and attaches it to the
+	// booking. If the payment can't be created, the booking is released;
+	// if the provider authorizes it right away, the booking is confirmed.
+	Start(ctx context.Context, booking *model.Booking, currency string) error
+
+	// Attach attaches its payment to a pending booking, so a retried
+	// request can complete it
+	Attach(ctx context.Context, booking *model.Booking) error
+
+	// Cancel voids the payment of a pending booking and releases its
+	// tickets
+	Cancel(ctx context.Context, booking *model.Booking) error
+
+	// Refund pays back the payment of a confirmed booking before it is
+	// cancelled. Bookings that weren't paid have nothing to pay back. It
+	// returns ErrBookingAlreadyCancelled while another cancellation is paying
+	// the booking back.
+	Refund(ctx context.Context, booking *model.Booking) error
+
+	// HandleWebhook verifies a notification of the provider and confirms or
+	// releases the booking whose payment it settles
+	HandleWebhook(ctx context.Context, payload []byte, header http.Header) error
+
+	// ExpireHolds releases the tickets of bookings that weren't paid in time
+	// and returns how many it released. It also captures the authorized
+	// payments whose capture didn't go through.
+	ExpireHolds(ctx context.Context) (int, error)
+
+	// Reconcile compares the bookings of a batch of payments with the
+	// provider's records and flags, or repairs, the mismatches
+	Reconcile(ctx context.Context) (*model.PaymentReconciliation, error)
+}
+
+type paymentService struct {
+	repo        repository.PaymentRepository
+	bookingRepo repository.BookingRepository
+	provider    payments.Provider
+	events      *events.Bus
+	policy      model.PaymentPolicy
+}
+
+// NewPaymentService creates a new implementation of PaymentService.
+// Confirmations and released tickets are published to the event bus unless
+// it is nil.
+func NewPaymentService(repo repository.PaymentRepository, bookingRepo repository.BookingRepository, provider payments.Provider, bus *events.Bus, policy model.PaymentPolicy) PaymentService {
+	return &paymentService{
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		provider:    provider,
+		events:      bus,
+		policy:      policy,
+	}
+}
+
+// Provider names the payment provider
+func (s *paymentService) Provider() string {
+	return s.provider.Name()
+}
+
+// Start creates the payment of a pending booking at the provider
+func (s *paymentService) Start(ctx context.Context, booking *model.Booking, currency string) error {
+	amount := money.Round(booking.UnitPrice*float64(booking.TicketCount), currency)
+	authorization, err := s.provider.Authorize(ctx, &payments.AuthorizeRequest{
+		Reference:   booking.Reference,
+		Amount:      amount,
+		Currency:    currency,
+		Description: fmt.Sprintf("%d tickets, booking %s", booking.TicketCount, booking.Reference),
+	})
+	if err != nil {
+		// Without a payment the booking can't be confirmed, so it gives its
+		// tickets back right away
+		s.release(context.WithoutCancel(ctx), booking.ID, model.PaymentFailed)
+		return fmt.Errorf("%w: %v", pkgErr.ErrPaymentUnavailable, err)
+	}
+
+	payment := &model.Payment{
+		BookingID:         booking.ID,
+		Provider:          s.provider.Name(),
+		ProviderReference: authorization.ID,
+		ClientSecret:      authorization.ClientSecret,
+		Amount:            amount,
+		Currency:          currency,
+		Status:            model.PaymentPending,
+		ExpiresAt:         booking.BookingTime.Add(s.policy.HoldTTL),
+	}
+	if err := s.repo.Create(ctx, payment); err != nil {
+		_ = s.provider.Void(context.WithoutCancel(ctx), authorization.ID)
+		s.release(context.WithoutCancel(ctx), booking.ID, model.PaymentFailed)
+		return err
+	}
+	booking.Payment = payment
+
+	// A payment the provider authorized right away confirms the booking in
+	// this request. If confirming or capturing it fails, the expiry job
+	// settles it once the hold expires.
+	if authorization.Status == payments.StatusAuthorized {
+		confirmed, err := s.capture(ctx, payment, false)
+		switch {
+		case err == nil:
+			payment.Status = model.PaymentSucceeded
+		case confirmed != nil:
+			payment.Status = model.PaymentAuthorized
+		}
+		if confirmed != nil {
+			booking.Status = confirmed.Status
+		}
+	}
+
+	return nil
+}
+
+// Attach attaches its payment to a pending booking
+func (s *paymentService) Attach(ctx context.Context, booking *model.Booking) error {
+	payment, err := s.repo.GetByBookingID(ctx, booking.ID)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	booking.Payment = payment
+	return nil
+}
+
+// Cancel voids the payment of a pending booking before releasing it, so it
+// can't be paid after it was cancelled
+func (s *paymentService) Cancel(ctx context.Context, booking *model.Booking) error {
+	payment, err := s.repo.GetByBookingID(ctx, booking.ID)
+	switch {
+	case err == nil && !payment.IsSettled():
+		if err := s.provider.Void(ctx, payment.ProviderReference); err != nil {
+			return fmt.Errorf("%w: %v", pkgErr.ErrPaymentUnavailable, err)
+		}
+	case err != nil && !errors.Is(err, pkgErr.ErrNotFound):
+		return err
+	}
+
+	released, err := s.release(ctx, booking.ID, model.PaymentCanceled)
+	if err != nil {
+		return err
+	}
+	if released == nil {
+		return pkgErr.ErrBookingAlreadyCancelled
+	}
+
+	booking.Status = released.Status
+	return nil
+}
+
+// Refund pays back a captured payment, or voids an authorization that
+// wasn't captured yet. The payment is claimed as refunding before the
+// provider is called, so of concurrent cancellations only one pays it back
+// and the others get ErrBookingAlreadyCancelled. A refund that fails puts
+// the payment back, so the cancellation can be tried again; one refunded
+// already has nothing left to pay back.
+func (s *paymentService) Refund(ctx context.Context, booking *model.Booking) error {
+	var payment *model.Payment
+	for {
+		var err error
+		payment, err = s.repo.GetByBookingID(ctx, booking.ID)
+		if errors.Is(err, pkgErr.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch payment.Status {
+		case model.PaymentSucceeded, model.PaymentAuthorized:
+		case model.PaymentRefunding:
+			return pkgErr.ErrBookingAlreadyCancelled
+		default:
+			return nil
+		}
+
+		// A payment captured since it was read is claimed on the next round
+		claimed, err := s.repo.UpdateStatus(ctx, booking.ID, payment.Status, model.PaymentRefunding)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			break
+		}
+	}
+
+	// Once claimed, the outcome is recorded even if the caller gives up
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	to := model.PaymentRefunded
+	if payment.Status == model.PaymentSucceeded {
+		err = s.provider.Refund(ctx, payment.ProviderReference)
+	} else {
+		to = model.PaymentCanceled
+		err = s.provider.Void(ctx, payment.ProviderReference)
+	}
+	if err != nil {
+		if _, restoreErr := s.repo.UpdateStatus(ctx, booking.ID, model.PaymentRefunding, payment.Status); restoreErr != nil {
+			return fmt.Errorf("%w: %v, and failed to restore the payment: %v", pkgErr.ErrPaymentUnavailable, err, restoreErr)
+		}
+		return fmt.Errorf("%w: %v", pkgErr.ErrPaymentUnavailable, err)
+	}
+
+	_, err = s.repo.UpdateStatus(ctx, booking.ID, model.PaymentRefunding, to)
+	return err
+}
+
+// HandleWebhook settles the booking whose payment a notification is about.
+// Notifications about other payments, or about payments settled already,
+// are ignored, so the provider may deliver them more than once. Errors make
+// the provider deliver the notification again.
+func (s *paymentService) HandleWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	event, err := s.provider.VerifyWebhook(payload, header, clock.Now())
+	if err != nil {
+		return err
+	}
+	if event.PaymentID == "" {
+		return nil
+	}
+
+	payment, err := s.repo.GetByProviderReference(ctx, s.provider.Name(), event.PaymentID)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if payment.IsSettled() {
+		return nil
+	}
+
+	switch event.Status {
+	case payments.StatusAuthorized:
+		_, err = s.capture(ctx, payment, false)
+	case payments.StatusSucceeded:
+		// Providers that capture on their own report the payment captured
+		_, err = s.capture(ctx, payment, true)
+	case payments.StatusFailed:
+		if payment.Status != model.PaymentPending {
+			return nil
+		}
+		// A declined payment could still be authorized with another card,
+		// so it is voided before the tickets are released
+		if err := s.provider.Void(ctx, payment.ProviderReference); err != nil {
+			return fmt.Errorf("failed to void payment: %w", err)
+		}
+		_, err = s.release(ctx, payment.BookingID, model.PaymentFailed)
+	case payments.StatusCanceled:
+		if payment.Status == model.PaymentAuthorized {
+			// The authorization lapsed before it was captured; the booking
+			// stays confirmed, unpaid, for reconciliation
+			_, err = s.repo.UpdateStatus(ctx, payment.BookingID, model.PaymentAuthorized, model.PaymentCanceled)
+			return err
+		}
+		_, err = s.release(ctx, payment.BookingID, model.PaymentCanceled)
+	}
+
+	return err
+}
+
+// ExpireHolds voids the payments of bookings whose hold expired and
+// releases their tickets. A payment that can't be voided, because it was
+// captured in the meantime or the provider is down, keeps its hold until
+// the next run. Authorized payments past their hold belong to confirmed
+// bookings whose capture failed, and are captured again.
+func (s *paymentService) ExpireHolds(ctx context.Context) (int, error) {
+	expired, err := s.repo.ListExpired(ctx, clock.Now(), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	var firstErr error
+	for _, payment := range expired {
+		if payment.Status == model.PaymentAuthorized {
+			if _, err := s.capture(ctx, payment, false); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if err := s.provider.Void(ctx, payment.ProviderReference); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to void payment %s: %w", payment.ProviderReference, err)
+			}
+			continue
+		}
+
+		booking, err := s.release(ctx, payment.BookingID, model.PaymentCanceled)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if booking != nil {
+			released++
+		}
+	}
+
+	return released, firstErr
+}
+
+// capture confirms the booking of an authorized payment and captures the
+// payment, unless the provider captured it already. It returns the booking
+// if it confirmed it. A booking released before its payment was authorized
+// gets the payment voided or refunded instead.
+func (s *paymentService) capture(ctx context.Context, payment *model.Payment, captured bool) (*model.Booking, error) {
+	booking, err := s.repo.Confirm(ctx, payment.BookingID)
+	if errors.Is(err, pkgErr.ErrBookingAlreadyCancelled) {
+		return nil, s.giveBack(ctx, payment, model.PaymentAuthorized, captured)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if booking != nil {
+		s.publishBookingStatus(booking)
+	}
+
+	if !captured {
+		if err := s.provider.Capture(ctx, payment.ProviderReference); err != nil {
+			return booking, fmt.Errorf("failed to capture payment %s: %w", payment.ProviderReference, err)
+		}
+	}
+	if _, err := s.repo.UpdateStatus(ctx, payment.BookingID, model.PaymentAuthorized, model.PaymentSucceeded); err != nil {
+		return booking, err
+	}
+
+	return booking, nil
+}
+
+// giveBack voids or refunds the payment of a cancelled booking, whose
+// status is from
+func (s *paymentService) giveBack(ctx context.Context, payment *model.Payment, from string, captured bool) error {
+	to := model.PaymentCanceled
+	if captured {
+		to = model.PaymentRefunded
+		if err := s.provider.Refund(ctx, payment.ProviderReference); err != nil {
+			return fmt.Errorf("failed to refund payment %s: %w", payment.ProviderReference, err)
+		}
+	} else if err := s.provider.Void(ctx, payment.ProviderReference); err != nil {
+		return fmt.Errorf("failed to void payment %s: %w", payment.ProviderReference, err)
+	}
+
+	_, err := s.repo.UpdateStatus(ctx, payment.BookingID, from, to)
+	return err
+}
+
+// release releases a pending booking and announces its returned tickets. It
+// returns nil if the booking wasn't pending anymore.
+func (s *paymentService) release(ctx context.Context, bookingID int64, status string) (*model.Booking, error) {
+	booking, availability, err := s.repo.Release(ctx, bookingID, status)
+	if err != nil || booking == nil {
+		return nil, err
+	}
+
+	s.publishAvailability(availability)
+	s.publishBookingStatus(booking)
+	return booking, nil
+}
+
+// publishAvailability announces the ticket availability of a concert after
+// a hold released its tickets
+func (s *paymentService) publishAvailability(availability *model.ConcertAvailability) {
+	s.events.Publish(events.Event{
+		Topic:   model.EventConcertAvailability,
+		Key:     availability.ConcertID,
+		Payload: availability,
+		At:      clock.Now(),
+	})
+}
+
+// publishBookingStatus announces a paid or released booking to its user
+func (s *paymentService) publishBookingStatus(booking *model.Booking) {
+	published := *booking
+	s.events.Publish(events.Event{
+		Topic:   model.EventBookingStatus,
+		Key:     model.UserEventKey(booking.UserID),
+		Payload: &published,
+		At:      clock.Now(),
+	})
+}
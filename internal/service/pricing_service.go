@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+)
+
+// pricingBatchSize is the maximum number of door price switches announced per statement
+const pricingBatchSize = 100
+
+// PricingService defines the interface for pricing phase switches
+type PricingService interface {
+	// SwitchDue announces the switch to the door price of every concert whose
+	// door price took effect since the last run, and returns how many switched
+	SwitchDue(ctx context.Context) (int, error)
+}
+
+type pricingService struct {
+	concertRepo repository.ConcertRepository
+	events      *events.Bus
+}
+
+// NewPricingService creates a new implementation of PricingService. Switches
+// are published to the event bus unless it is nil.
+func NewPricingService(concertRepo repository.ConcertRepository, bus *events.Bus) PricingService {
+	return &pricingService{
+		concertRepo: concertRepo,
+		events:      bus,
+	}
+}
+
+// SwitchDue announces the door price switches that are due
+func (s *pricingService) SwitchDue(ctx context.Context) (int, error) {
+	switched := 0
+	for {
+		now := clock.Now()
+		concerts, err := s.concertRepo.SwitchDueDoorPrices(ctx, now, pricingBatchSize)
+		if err != nil {
+			return switched, err
+		}
+
+		for _, concert := range concerts {
+			s.events.Publish(events.Event{
+				Topic: model.EventConcertPricing,
+				Key:   concert.ID,
+				Payload: &model.PricingPhaseChange{
+					ConcertID: concert.ID,
+					Phase:     model.PricingPhaseDoor,
+					Price:     *concert.DoorPrice,
+					Currency:  concert.Currency,
+					At:        concert.DoorPriceStartsAt(),
+				},
+				At: now,
+			})
+		}
+		switched += len(concerts)
+
+		if len(concerts) < pricingBatchSize {
+			return switched, nil
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	retryAttempts = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "booking_retry_attempts",
+		Help:    "Attempts made by bookings, batch bookings and checkouts that retry version conflicts, by operation.",
+		Buckets: []float64{1, 2, 3, 4, 5, 7, 10},
+	}, []string{"operation"})
+	retryWaits = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "booking_retry_backoff_seconds",
+		Help:    "Delays waited before retrying a version conflict, by operation.",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+	retriesExhausted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "booking_retries_exhausted_total",
+		Help: "Operations that failed because every attempt hit a version conflict, by operation.",
+	}, []string{"operation"})
+)
+
+// Defaults of the retry policy settings left unset
+const (
+	defaultMaxRetries      = 3
+	defaultRetryBaseDelay  = 10 * time.Millisecond
+	defaultRetryMaxDelay   = 200 * time.Millisecond
+	defaultRetryMultiplier = 2
+)
+
+// RetryPolicy decides how often operations that lost a race for a concert,
+// failing with ErrOptimisticLockFailed, are tried again and how long they
+// wait in between. The delay before retry n is BaseDelay*Multiplier^(n-1),
+// at most MaxDelay, of which a random share of up to Jitter is left out so
+// bookings that collided don't collide again on their next attempt.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts, 3 when unset
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, 10ms when unset
+	BaseDelay time.Duration
+	// MaxDelay caps the delays, 200ms when unset
+	MaxDelay time.Duration
+	// Multiplier grows the delay for each retry, 2 when unset; 1 keeps it fixed
+	Multiplier float64
+	// Jitter is the largest share of a delay left out at random, from 0 for
+	// none to 1 for delays anywhere up to the full one
+	Jitter float64
+}
+
+// withDefaults returns the policy with its unset settings defaulted
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxRetries <= 0 {
+		p.MaxRetries = defaultMaxRetries
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultRetryBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = max(defaultRetryMaxDelay, p.BaseDelay)
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultRetryMultiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	return p
+}
+
+// Delay returns the delay before retrying after the given attempt, counted
+// from 0 for the first one
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := time.Duration(math.Min(float64(p.BaseDelay)*math.Pow(p.Multiplier, float64(attempt)), float64(p.MaxDelay)))
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// wait waits the delay before retrying after the given attempt, unless ctx
+// is done first, in which case it returns its error
+func (p RetryPolicy) wait(ctx context.Context, operation string, attempt int) error {
+	delay := p.Delay(attempt)
+	retryWaits.WithLabelValues(operation).Observe(delay.Seconds())
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observeAttempts records the attempts an operation made, and whether they
+// ran out
+func observeAttempts(operation string, attempts int, exhausted bool) {
+	retryAttempts.WithLabelValues(operation).Observe(float64(attempts))
+	if exhausted {
+		retriesExhausted.WithLabelValues(operation).Inc()
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// RuntimeSettingsService defines the interface for the operational settings
+// changed at runtime
+type RuntimeSettingsService interface {
+	// Current returns the settings in effect on this replica. They are
+	// shared, so callers must not modify them.
+	Current() *model.RuntimeSettings
+
+	// Update applies a patch to the settings of the given version, records
+	// the change in the audit log and applies it on this replica; the others
+	// follow once they are told about it. If the change is saved but not
+	// audited, the settings are returned with the error.
+	Update(ctx context.Context, actor string, version int, patch *model.RuntimeSettingsPatch) (*model.RuntimeSettings, []model.FieldChange, error)
+
+	// Reload reads the stored settings and applies them if they are newer
+	Reload(ctx context.Context) error
+
+	// OnChange registers fn to be called with the settings whenever this
+	// replica applies new ones, and once with the current settings
+	OnChange(fn func(*model.RuntimeSettings))
+
+	// SetDefaults replaces the configured defaults when the configuration is
+	// reloaded. They only take effect while the settings were never changed.
+	SetDefaults(defaults model.RuntimeSettings)
+
+	// Run applies the changes saved by any replica until ctx is done. Changes
+	// are applied as they are announced, and at least every pollInterval in
+	// case announcements are lost.
+	Run(ctx context.Context, pollInterval time.Duration)
+}
+
+type runtimeSettingsService struct {
+	repo         repository.RuntimeSettingsRepository
+	auditService AuditService
+	current      atomic.Pointer[model.RuntimeSettings]
+
+	// mu orders applying settings and calling the listeners
+	mu        sync.Mutex
+	listeners []func(*model.RuntimeSettings)
+}
+
+// NewRuntimeSettingsService creates a new implementation of
+// RuntimeSettingsService. The defaults are in effect until the settings are
+// first changed; from then on the stored settings are, whatever the defaults.
+func NewRuntimeSettingsService(repo repository.RuntimeSettingsRepository, auditService AuditService, defaults model.RuntimeSettings) RuntimeSettingsService {
+	defaults.Version = 0
+	s := &runtimeSettingsService{
+		repo:         repo,
+		auditService: auditService,
+	}
+	s.current.Store(&defaults)
+	return s
+}
+
+// Current returns the settings in effect on this replica
+func (s *runtimeSettingsService) Current() *model.RuntimeSettings {
+	return s.current.Load()
+}
+
+// Update applies a patch to the settings of the given version
+func (s *runtimeSettingsService) Update(ctx context.Context, actor string, version int, patch *model.RuntimeSettingsPatch) (*model.RuntimeSettings, []model.FieldChange, error) {
+	if patch.Empty() {
+		return nil, nil, pkgErr.ErrInvalidInput("at least one setting must be changed")
+	}
+
+	// Changes are based on the stored settings, which may be newer than the
+	// ones this replica applied so far
+	if err := s.Reload(ctx); err != nil {
+		return nil, nil, err
+	}
+	existing := s.Current()
+	if version != existing.Version {
+		return nil, nil, pkgErr.ErrOptimisticLockFailed
+	}
+
+	if err := validateRuntimeSettingsPatch(patch); err != nil {
+		return nil, nil, err
+	}
+	updated, changes := patch.Apply(existing)
+	updated.UpdatedBy = actor
+
+	if err := s.repo.Save(ctx, updated); err != nil {
+		return nil, nil, err
+	}
+	s.apply(updated)
+
+	if len(changes) == 0 || s.auditService == nil {
+		return updated, changes, nil
+	}
+
+	// The change is saved, so a failed audit write is reported without
+	// undoing it
+	if err := s.auditService.Record(ctx, actor, model.AuditActionRuntimeSettingsUpdated, "runtime_settings", "1", map[string]interface{}{
+		"changes": changes,
+		"version": updated.Version,
+	}); err != nil {
+		return updated, changes, fmt.Errorf("runtime settings updated but not audited: %w", err)
+	}
+
+	return updated, changes, nil
+}
+
+// Reload reads the stored settings and applies them if they are newer
+func (s *runtimeSettingsService) Reload(ctx context.Context) error {
+	stored, err := s.repo.Get(ctx)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.apply(stored)
+	return nil
+}
+
+// OnChange registers fn to be called with the settings this replica applies
+func (s *runtimeSettingsService) OnChange(fn func(*model.RuntimeSettings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, fn)
+	fn(s.current.Load())
+}
+
+// SetDefaults puts the defaults into effect while the settings are at
+// version 0
+func (s *runtimeSettingsService) SetDefaults(defaults model.RuntimeSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.Load().Version != 0 {
+		return
+	}
+	defaults.Version = 0
+	s.current.Store(&defaults)
+	for _, fn := range s.listeners {
+		fn(&defaults)
+	}
+}
+
+// Run applies the changes saved by any replica until ctx is done
+func (s *runtimeSettingsService) Run(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var changes <-chan struct{}
+	for {
+		// Watching is retried on every poll while it fails
+		if changes == nil {
+			if watched, err := s.repo.Watch(ctx); err == nil {
+				changes = watched
+				_ = s.Reload(ctx)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				// The watch ended with ctx or lost its connection
+				changes = nil
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		// A failed reload keeps the settings in effect until the next one
+		_ = s.Reload(ctx)
+	}
+}
+
+// apply puts settings into effect unless newer ones already are
+func (s *runtimeSettingsService) apply(settings *model.RuntimeSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if settings.Version <= s.current.Load().Version {
+		return
+	}
+	s.current.Store(settings)
+	for _, fn := range s.listeners {
+		fn(settings)
+	}
+}
+
+// validateRuntimeSettingsPatch checks the settings a patch changes. Allowing
+// any origin can only be configured, since it can't be combined with
+// credentials.
+func validateRuntimeSettingsPatch(p *model.RuntimeSettingsPatch) error {
+	if p.RateLimit != nil && *p.RateLimit <= 0 {
+		return pkgErr.ErrInvalidInput("rate_limit must be positive")
+	}
+	if p.AdmissionRate != nil && *p.AdmissionRate < 0 {
+		return pkgErr.ErrInvalidInput("admission_rate cannot be negative")
+	}
+	if p.AdmissionBurst != nil && *p.AdmissionBurst <= 0 {
+		return pkgErr.ErrInvalidInput("admission_burst must be positive")
+	}
+	if p.ConcertCacheTTLMs != nil && *p.ConcertCacheTTLMs < 0 {
+		return pkgErr.ErrInvalidInput("concert_cache_ttl_ms cannot be negative")
+	}
+
+	if p.CORSAllowOrigins == nil {
+		return nil
+	}
+	if len(*p.CORSAllowOrigins) == 0 {
+		return pkgErr.ErrInvalidInput("cors_allow_origins must not be empty")
+	}
+	for _, origin := range *p.CORSAllowOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return pkgErr.ErrInvalidInput(fmt.Sprintf("cors_allow_origins contains invalid origin %q", origin))
+		}
+	}
+	return nil
+}
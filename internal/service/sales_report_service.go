@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/mail"
+	"concert-ticket-api/pkg/money"
+)
+
+const (
+	// reportBatchSize is the maximum number of concerts finalized per run
+	reportBatchSize = 50
+	// reportClaimTimeout is how long a claim is held before another run may retry it
+	reportClaimTimeout = 10 * time.Minute
+)
+
+// SalesReportService defines the interface for end-of-sale reporting
+type SalesReportService interface {
+	// FinalizeDue generates the final sales report for every concert that sold
+	// out or whose booking window closed, marks its reporting state as finalized
+	// and emails the report to the organizer. It returns the number of reports generated.
+	FinalizeDue(ctx context.Context) (int, error)
+
+	// GetReport retrieves the final sales report of a concert
+	GetReport(ctx context.Context, concertID int64) (*model.SalesReport, error)
+}
+
+type salesReportService struct {
+	reportRepo  repository.SalesReportRepository
+	concertRepo repository.ConcertRepository
+	bookingRepo repository.BookingRepository
+	sender      mail.Sender
+}
+
+// NewSalesReportService creates a new implementation of SalesReportService
+func NewSalesReportService(
+	reportRepo repository.SalesReportRepository,
+	concertRepo repository.ConcertRepository,
+	bookingRepo repository.BookingRepository,
+	sender mail.Sender,
+) SalesReportService {
+	return &salesReportService{
+		reportRepo:  reportRepo,
+		concertRepo: concertRepo,
+		bookingRepo: bookingRepo,
+		sender:      sender,
+	}
+}
+
+// FinalizeDue generates and emails final reports for concerts whose sale ended
+func (s *salesReportService) FinalizeDue(ctx context.Context) (int, error) {
+	concerts, err := s.reportRepo.ClaimDueConcerts(ctx, clock.Now(), reportBatchSize, reportClaimTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	// Keep going after a failure so one broken concert doesn't block the others
+	var firstErr error
+	generated := 0
+	for _, concert := range concerts {
+		if err := s.finalize(ctx, concert); err != nil {
+			if releaseErr := s.reportRepo.ReleaseClaim(ctx, concert.ID); releaseErr != nil {
+				err = fmt.Errorf("%w (release claim: %v)", err, releaseErr)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to finalize concert %d: %w", concert.ID, err)
+			}
+			continue
+		}
+		generated++
+	}
+
+	// Send reports that are new or failed to send in an earlier run
+	if err := s.sendPending(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return generated, firstErr
+}
+
+// GetReport retrieves the final sales report of a concert
+func (s *salesReportService) GetReport(ctx context.Context, concertID int64) (*model.SalesReport, error) {
+	return s.reportRepo.GetByConcertID(ctx, concertID)
+}
+
+// finalize builds and stores the final report of a concert
+func (s *salesReportService) finalize(ctx context.Context, concert *model.Concert) error {
+	bookings, err := s.bookingRepo.GetAllByConcertID(ctx, concert.ID)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildSalesReport(concert, bookings, clock.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.reportRepo.SaveFinal(ctx, report)
+}
+
+// sendPending emails reports that haven't been sent yet
+func (s *salesReportService) sendPending(ctx context.Context) error {
+	reports, err := s.reportRepo.ListUnsent(ctx, reportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, report := range reports {
+		if err := s.send(ctx, report); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to email sales report for concert %d: %w", report.ConcertID, err)
+		}
+	}
+
+	return firstErr
+}
+
+// send emails a report to its recipient and records it as sent
+func (s *salesReportService) send(ctx context.Context, report *model.SalesReport) error {
+	concert, err := s.concertRepo.GetByID(ctx, report.ConcertID)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Sales for %s at %s have ended (%s).\n\n"+
+			"Tickets sold: %d of %d\n"+
+			"Confirmed bookings: %d\n"+
+			"Cancelled bookings: %d\n"+
+			"Revenue: %s\n\n"+
+			"The full report is attached.\n",
+		concert.Name, concert.Venue, report.Reason,
+		report.TicketsSold, concert.TotalTickets,
+		report.ConfirmedBookings, report.CancelledBookings,
+		money.Format(report.Revenue, report.Currency, ""),
+	)
+
+	err = s.sender.Send(ctx, &mail.Message{
+		To:      []string{report.Recipient},
+		Subject: fmt.Sprintf("Final sales report: %s", concert.Name),
+		Body:    body,
+		Attachments: []mail.Attachment{{
+			Filename:    fmt.Sprintf("sales-report-%d.csv", report.ConcertID),
+			ContentType: "text/csv",
+			Data:        []byte(report.CSV),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reportRepo.MarkEmailed(ctx, report.ConcertID, clock.Now())
+}
+
+// buildSalesReport computes the final report of a concert from its bookings
+func buildSalesReport(concert *model.Concert, bookings []*model.Booking, now time.Time) (*model.SalesReport, error) {
+	report := &model.SalesReport{
+		ConcertID:        concert.ID,
+		Reason:           model.SalesReportReasonBookingClosed,
+		TicketsAvailable: concert.AvailableTickets,
+		Currency:         concert.Currency,
+		Recipient:        concert.OrganizerEmail,
+		GeneratedAt:      now,
+	}
+
+	if concert.AvailableTickets <= 0 {
+		report.Reason = model.SalesReportReasonSoldOut
+	}
+
+	// Bookings are charged the price of the pricing phase they were made in
+	var revenue float64
+	for _, booking := range bookings {
+		switch booking.Status {
+		case model.BookingStatusConfirmed:
+			report.ConfirmedBookings++
+			report.TicketsSold += booking.TicketCount
+			revenue += float64(booking.TicketCount) * booking.UnitPrice
+		case model.BookingStatusCancelled:
+			report.CancelledBookings++
+		}
+	}
+
+	report.Revenue = money.Round(revenue, concert.Currency)
+	report.AvailabilityHistory = availabilityHistory(concert, bookings, now)
+
+	data, err := salesReportCSV(concert, report, bookings)
+	if err != nil {
+		return nil, err
+	}
+	report.CSV = data
+
+	return report, nil
+}
+
+// availabilityHistory reconstructs hourly ticket availability from bookings.
+// Each point holds the availability at the end of an hour in which it changed.
+func availabilityHistory(concert *model.Concert, bookings []*model.Booking, now time.Time) model.AvailabilityHistory {
+	type change struct {
+		at    time.Time
+		delta int
+	}
+
+	var changes []change
+	for _, booking := range bookings {
+		changes = append(changes, change{at: booking.BookingTime, delta: -booking.TicketCount})
+		if booking.Status == model.BookingStatusCancelled {
+			changes = append(changes, change{at: booking.UpdatedAt, delta: booking.TicketCount})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].at.Before(changes[j].at)
+	})
+
+	history := model.AvailabilityHistory{{Time: concert.BookingStartTime, AvailableTickets: concert.TotalTickets}}
+	available := concert.TotalTickets
+	for i, c := range changes {
+		available += c.delta
+
+		hour := c.at.Truncate(time.Hour)
+		if i+1 < len(changes) && changes[i+1].at.Truncate(time.Hour).Equal(hour) {
+			continue
+		}
+		history = append(history, model.AvailabilityPoint{Time: hour.Add(time.Hour), AvailableTickets: available})
+	}
+
+	// The final point is the actual availability, which also reflects changes
+	// that aren't bookings (e.g. a changed ticket total)
+	history = append(history, model.AvailabilityPoint{Time: now, AvailableTickets: concert.AvailableTickets})
+
+	return history
+}
+
+// salesReportCSV renders a report summary followed by one row per booking
+func salesReportCSV(concert *model.Concert, report *model.SalesReport, bookings []*model.Booking) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"concert_id", strconv.FormatInt(concert.ID, 10)},
+		{"concert", concert.Name},
+		{"artist", concert.Artist},
+		{"venue", concert.Venue},
+		{"concert_date", concert.ConcertDate.UTC().Format(time.RFC3339)},
+		{"reason", report.Reason},
+		{"total_tickets", strconv.Itoa(concert.TotalTickets)},
+		{"tickets_sold", strconv.Itoa(report.TicketsSold)},
+		{"tickets_available", strconv.Itoa(report.TicketsAvailable)},
+		{"confirmed_bookings", strconv.Itoa(report.ConfirmedBookings)},
+		{"cancelled_bookings", strconv.Itoa(report.CancelledBookings)},
+		{"price", money.Format(concert.Price, concert.Currency, "")},
+		{"revenue", money.Format(report.Revenue, report.Currency, "")},
+		{"generated_at", report.GeneratedAt.UTC().Format(time.RFC3339)},
+		{},
+		{"booking_reference", "user_id", "ticket_count", "unit_price", "status", "booking_time"},
+	}
+
+	for _, booking := range bookings {
+		rows = append(rows, []string{
+			booking.Reference,
+			booking.UserID,
+			strconv.Itoa(booking.TicketCount),
+			money.Format(booking.UnitPrice, concert.Currency, ""),
+			string(booking.Status),
+			booking.BookingTime.UTC().Format(time.RFC3339),
+		})
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = csvCell(cell)
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write sales report: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// csvCell defuses a value spreadsheets would run as a formula, such as a
+// concert named "=HYPERLINK(...)", by prefixing it with a quote
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
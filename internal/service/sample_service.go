@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/errors"
+)
+
+// SampleService defines the interface for drawing anonymized sample datasets
+// for analytics and load testing
+type SampleService interface {
+	// Generate draws a sample of concerts and their bookings
+	Generate(ctx context.Context, opts model.SampleOptions) (*model.SampleDataset, error)
+}
+
+type sampleService struct {
+	sampleRepo repository.SampleRepository
+}
+
+// NewSampleService creates a new implementation of SampleService
+func NewSampleService(sampleRepo repository.SampleRepository) SampleService {
+	return &sampleService{
+		sampleRepo: sampleRepo,
+	}
+}
+
+// Generate draws a stratified sample: concerts are grouped by the month they
+// take place in and the same fraction is drawn from every month, so seasonal
+// patterns survive. Every booking of a sampled concert is kept, so per-concert
+// sales curves are complete.
+//
+// Private concerts are never sampled. User IDs are replaced by keyed hashes,
+// booking timestamps are jittered, and concerts with fewer than MinBookings
+// bookings are suppressed.
+func (s *sampleService) Generate(ctx context.Context, opts model.SampleOptions) (*model.SampleDataset, error) {
+	if opts.Rate <= 0 || opts.Rate > 1 {
+		return nil, errors.ErrInvalidInput("rate must be greater than 0 and at most 1")
+	}
+	if opts.Jitter < 0 {
+		return nil, errors.ErrInvalidInput("jitter must not be negative")
+	}
+	if opts.MinBookings < 0 {
+		return nil, errors.ErrInvalidInput("min bookings must not be negative")
+	}
+
+	salt := opts.Salt
+	if len(salt) == 0 {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+
+	concerts, err := s.sampleRepo.ListConcerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := mathrand.New(mathrand.NewSource(opts.Seed))
+	sampled, weights := sampleConcerts(concerts, opts.Rate, rng)
+
+	ids := make([]int64, len(sampled))
+	for i, concert := range sampled {
+		ids[i] = concert.ID
+	}
+
+	bookings, err := s.sampleRepo.ListBookings(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byConcert := make(map[int64][]*model.Booking)
+	for _, booking := range bookings {
+		byConcert[booking.ConcertID] = append(byConcert[booking.ConcertID], booking)
+	}
+
+	dataset := &model.SampleDataset{
+		Concerts:    []*model.SampleConcert{},
+		Bookings:    []*model.SampleBooking{},
+		GeneratedAt: clock.Now(),
+	}
+
+	for _, concert := range sampled {
+		concertBookings := byConcert[concert.ID]
+		if len(concertBookings) < opts.MinBookings {
+			dataset.Suppressed++
+			continue
+		}
+
+		sampleConcert := &model.SampleConcert{
+			SampleID:         len(dataset.Concerts) + 1,
+			Month:            sampleMonth(concert),
+			Name:             concert.Name,
+			Artist:           concert.Artist,
+			Venue:            concert.Venue,
+			ConcertDate:      concert.ConcertDate,
+			TotalTickets:     concert.TotalTickets,
+			AvailableTickets: concert.AvailableTickets,
+			Price:            concert.Price,
+			Currency:         concert.Currency,
+			BookingStartTime: concert.BookingStartTime,
+			BookingEndTime:   concert.BookingEndTime,
+			Weight:           weights[concert.ID],
+		}
+		dataset.Concerts = append(dataset.Concerts, sampleConcert)
+
+		var sampleBookings []*model.SampleBooking
+		for _, booking := range concertBookings {
+			sampleBookings = append(sampleBookings, &model.SampleBooking{
+				ConcertSampleID: sampleConcert.SampleID,
+				UserHash:        hashUserID(salt, booking.UserID),
+				TicketCount:     booking.TicketCount,
+				Status:          booking.Status,
+				UnitPrice:       booking.UnitPrice,
+				BookedAt:        jitter(booking.BookingTime, opts.Jitter, concert.ConcertDate, rng),
+			})
+		}
+
+		// Ordering by the jittered time hides the order the bookings were made in
+		sort.SliceStable(sampleBookings, func(i, j int) bool {
+			return sampleBookings[i].BookedAt.Before(sampleBookings[j].BookedAt)
+		})
+		dataset.Bookings = append(dataset.Bookings, sampleBookings...)
+	}
+
+	return dataset, nil
+}
+
+// sampleConcerts draws the fraction rate of the concerts of every month and
+// returns them in date order, together with the weight of each drawn concert.
+// The number drawn from a month is rounded up or down at random in
+// proportion to the fraction, so small months are neither always left out
+// nor always overrepresented.
+func sampleConcerts(concerts []*model.Concert, rate float64, rng *mathrand.Rand) ([]*model.Concert, map[int64]float64) {
+	strata := make(map[string][]*model.Concert)
+	for _, concert := range concerts {
+		month := sampleMonth(concert)
+		strata[month] = append(strata[month], concert)
+	}
+
+	months := make([]string, 0, len(strata))
+	for month := range strata {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	var sampled []*model.Concert
+	weights := make(map[int64]float64)
+	for _, month := range months {
+		stratum := strata[month]
+		expected := rate * float64(len(stratum))
+		n := int(math.Floor(expected))
+		if rng.Float64() < expected-float64(n) {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+
+		rng.Shuffle(len(stratum), func(i, j int) { stratum[i], stratum[j] = stratum[j], stratum[i] })
+		for _, concert := range stratum[:n] {
+			sampled = append(sampled, concert)
+			weights[concert.ID] = float64(len(stratum)) / float64(n)
+		}
+	}
+
+	sort.Slice(sampled, func(i, j int) bool {
+		if !sampled[i].ConcertDate.Equal(sampled[j].ConcertDate) {
+			return sampled[i].ConcertDate.Before(sampled[j].ConcertDate)
+		}
+		return sampled[i].ID < sampled[j].ID
+	})
+
+	return sampled, weights
+}
+
+// sampleMonth is the stratum of a concert
+func sampleMonth(concert *model.Concert) string {
+	return concert.ConcertDate.UTC().Format("2006-01")
+}
+
+// hashUserID replaces a user ID by a keyed hash, so the bookings of one user
+// can be linked within a sample but not traced back to the user
+func hashUserID(salt []byte, userID string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// jitter shifts t by a random offset of up to maxShift in either direction,
+// without moving it past the concert date
+func jitter(t time.Time, maxShift time.Duration, concertDate time.Time, rng *mathrand.Rand) time.Time {
+	if maxShift > 0 {
+		t = t.Add(time.Duration(rng.Int63n(int64(2*maxShift)+1)) - maxShift)
+	}
+	if t.After(concertDate) {
+		t = concertDate
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// WriteSampleCSV renders the concerts and bookings of a sample as CSV
+func WriteSampleCSV(dataset *model.SampleDataset, concerts, bookings io.Writer) error {
+	concertRecords := [][]string{
+		{"sample_id", "month", "name", "artist", "venue", "concert_date", "total_tickets", "available_tickets",
+			"price", "currency", "booking_start_time", "booking_end_time", "weight"},
+	}
+	for _, concert := range dataset.Concerts {
+		concertRecords = append(concertRecords, []string{
+			strconv.Itoa(concert.SampleID),
+			concert.Month,
+			concert.Name,
+			concert.Artist,
+			concert.Venue,
+			concert.ConcertDate.UTC().Format(time.RFC3339),
+			strconv.Itoa(concert.TotalTickets),
+			strconv.Itoa(concert.AvailableTickets),
+			strconv.FormatFloat(concert.Price, 'f', -1, 64),
+			concert.Currency,
+			concert.BookingStartTime.UTC().Format(time.RFC3339),
+			concert.BookingEndTime.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(concert.Weight, 'f', 4, 64),
+		})
+	}
+	if err := csv.NewWriter(concerts).WriteAll(concertRecords); err != nil {
+		return fmt.Errorf("failed to write sample concerts: %w", err)
+	}
+
+	bookingRecords := [][]string{
+		{"concert_sample_id", "user_hash", "ticket_count", "status", "unit_price", "booked_at"},
+	}
+	for _, booking := range dataset.Bookings {
+		bookingRecords = append(bookingRecords, []string{
+			strconv.Itoa(booking.ConcertSampleID),
+			booking.UserHash,
+			strconv.Itoa(booking.TicketCount),
+			string(booking.Status),
+			strconv.FormatFloat(booking.UnitPrice, 'f', -1, 64),
+			booking.BookedAt.Format(time.RFC3339),
+		})
+	}
+	if err := csv.NewWriter(bookings).WriteAll(bookingRecords); err != nil {
+		return fmt.Errorf("failed to write sample bookings: %w", err)
+	}
+
+	return nil
+}
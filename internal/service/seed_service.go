@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
+)
+
+// SeedService defines the interface for seeding development and demo
+// databases with fixtures
+type SeedService interface {
+	// Seed creates the concerts and bookings of opts that earlier runs
+	// didn't create yet
+	Seed(ctx context.Context, opts model.SeedOptions) (*model.SeedResult, error)
+}
+
+type seedService struct {
+	concertRepo repository.ConcertRepository
+	bookingRepo repository.BookingRepository
+}
+
+// NewSeedService creates a new implementation of SeedService
+func NewSeedService(concertRepo repository.ConcertRepository, bookingRepo repository.BookingRepository) SeedService {
+	return &seedService{
+		concertRepo: concertRepo,
+		bookingRepo: bookingRepo,
+	}
+}
+
+// seedVenue is a venue concerts are seeded at. Its size decides how many
+// tickets its concerts have and what they cost.
+type seedVenue struct {
+	name                   string
+	minTickets, maxTickets int
+	minPrice, maxPrice     int
+	// doorPrices is set for club venues, which charge more on the night
+	doorPrices bool
+}
+
+var (
+	seedArtists = []string{
+		"The Midnight Owls", "Aurora Vale", "Neon Harbor", "Rosa Calderón", "The Paper Kites Collective",
+		"Kenji & The Tides", "Velvet Static", "Lena Marsh", "Northbound Choir", "DJ Solstice",
+		"The Copper Lanterns", "Amara Osei", "Glass Orchard", "Tomás Reyes Quartet", "Static Bloom",
+	}
+	seedTours = []string{
+		"World Tour", "Spring Tour", "Acoustic Nights", "Greatest Hits Live", "Album Release Show",
+		"Summer Sessions", "Farewell Tour",
+	}
+	seedVenues = []seedVenue{
+		{name: "The Basement, Nashville", minTickets: 150, maxTickets: 400, minPrice: 15, maxPrice: 45, doorPrices: true},
+		{name: "Blue Note Club, New York", minTickets: 200, maxTickets: 300, minPrice: 30, maxPrice: 60, doorPrices: true},
+		{name: "The Roxy, Los Angeles", minTickets: 400, maxTickets: 500, minPrice: 25, maxPrice: 55, doorPrices: true},
+		{name: "Paradiso, Amsterdam", minTickets: 900, maxTickets: 1500, minPrice: 30, maxPrice: 70},
+		{name: "The Fillmore, San Francisco", minTickets: 1000, maxTickets: 1300, minPrice: 40, maxPrice: 90},
+		{name: "Royal Albert Hall, London", minTickets: 4000, maxTickets: 5200, minPrice: 55, maxPrice: 150},
+		{name: "Red Rocks Amphitheatre, Morrison", minTickets: 8000, maxTickets: 9500, minPrice: 60, maxPrice: 170},
+		{name: "Madison Square Garden, New York", minTickets: 15000, maxTickets: 20000, minPrice: 80, maxPrice: 250},
+		{name: "Tokyo Dome, Tokyo", minTickets: 30000, maxTickets: 45000, minPrice: 70, maxPrice: 220},
+	}
+	seedFirstNames = []string{
+		"Ava", "Liam", "Sofia", "Noah", "Mia", "Mateo", "Yuki", "Amir", "Chloe", "Lucas",
+		"Priya", "Ethan", "Zara", "Leo", "Hana", "Omar", "Grace", "Diego", "Ines", "Kai",
+	}
+	seedLastNames = []string{
+		"Smith", "Garcia", "Müller", "Tanaka", "Okafor", "Rossi", "Kowalski", "Nguyen", "Silva", "Johansson",
+		"Dubois", "Patel", "Kim", "Novak", "O'Brien",
+	}
+	// seedTicketCounts are the tickets of a booking, weighted by how often
+	// people buy that many: mostly pairs, then singles
+	seedTicketCounts  = []int{1, 2, 3, 4, 5, 6}
+	seedTicketWeights = []float64{30, 45, 8, 12, 2, 3}
+)
+
+// seedCancellationRate is the share of seeded bookings cancelled again
+const seedCancellationRate = 0.05
+
+// Seed draws concert i of a run from a random source of its own, seeded by
+// the seed and i. Its name and artist are unique to i, so a run finds the
+// concerts earlier runs created and creates the missing ones. Booking i has
+// a reference derived from the seed and i, so runs skip the bookings that
+// exist. Concert popularity and how often users book follow power laws:
+// a few concerts sell most of the tickets and a few fans make many bookings.
+func (s *seedService) Seed(ctx context.Context, opts model.SeedOptions) (*model.SeedResult, error) {
+	if opts.Concerts < 0 || opts.Users < 0 || opts.Bookings < 0 {
+		return nil, pkgErr.ErrInvalidInput("concerts, users and bookings must not be negative")
+	}
+	if opts.Bookings > 0 && (opts.Concerts == 0 || opts.Users == 0) {
+		return nil, pkgErr.ErrInvalidInput("bookings need at least one concert and one user")
+	}
+
+	result := &model.SeedResult{}
+	concerts := make([]*model.Concert, 0, opts.Concerts)
+	for i := 0; i < opts.Concerts; i++ {
+		concert, created, err := s.seedConcert(ctx, opts.Seed, i)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.ConcertsCreated++
+		} else {
+			result.ConcertsFound++
+		}
+		concerts = append(concerts, concert)
+	}
+
+	// Only concerts on sale can be booked. Their popularity is an order
+	// shuffled by the seed, the most popular selling the most tickets.
+	var onSale []*model.Concert
+	for _, concert := range concerts {
+		if concert.IsBookingOpen() {
+			onSale = append(onSale, concert)
+		}
+	}
+	rng := seedRand(opts.Seed, "popularity", 0)
+	rng.Shuffle(len(onSale), func(i, j int) { onSale[i], onSale[j] = onSale[j], onSale[i] })
+	popularity := make([]float64, len(onSale))
+	for rank := range onSale {
+		popularity[rank] = 1 / float64(rank+1)
+	}
+
+	for i := 0; i < opts.Bookings; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.seedBooking(ctx, opts, i, onSale, popularity, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// seedConcert returns concert i of the fixtures, creating it unless an
+// earlier run did
+func (s *seedService) seedConcert(ctx context.Context, seed int64, i int) (*model.Concert, bool, error) {
+	concert := seedConcertFixture(seed, i)
+
+	existing, err := s.concertRepo.List(ctx, query.Options{
+		Page:    query.NewPage(1, query.MaxPageSize),
+		Filters: query.Filters{"name": concert.Name, "artist": concert.Artist},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up seeded concert %q: %w", concert.Name, err)
+	}
+	for _, found := range existing {
+		if found.Name == concert.Name && found.Artist == concert.Artist {
+			return found, false, nil
+		}
+	}
+
+	created, err := s.concertRepo.Create(ctx, concert)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to seed concert %q: %w", concert.Name, err)
+	}
+	return created, true, nil
+}
+
+// seedConcertFixture returns concert i of the fixtures. Its name is unique
+// to i; the venue, date, tickets and prices are drawn for it.
+func seedConcertFixture(seed int64, i int) *model.Concert {
+	rng := seedRand(seed, "concert", i)
+
+	artist := seedArtists[i%len(seedArtists)]
+	name := artist + ": " + seedTours[(i/len(seedArtists))%len(seedTours)]
+	if night := i / (len(seedArtists) * len(seedTours)); night > 0 {
+		name = fmt.Sprintf("%s, Night %d", name, night+1)
+	}
+
+	venue := seedVenues[rng.Intn(len(seedVenues))]
+	tickets := roundTo(venue.minTickets+rng.Intn(venue.maxTickets-venue.minTickets+1), 50)
+	price := float64(roundTo(venue.minPrice+rng.Intn(venue.maxPrice-venue.minPrice+1), 5))
+
+	// Shows start in the evening, a week to six months out. Most are on sale
+	// already, some go on sale within the next two weeks.
+	today := clock.Now().UTC().Truncate(24 * time.Hour)
+	date := today.AddDate(0, 0, 7+rng.Intn(180)).Add(time.Duration(19*60+30*rng.Intn(4)) * time.Minute)
+	saleStart := today.AddDate(0, 0, -1-rng.Intn(60))
+	if rng.Float64() < 0.15 {
+		saleStart = today.AddDate(0, 0, 1+rng.Intn(14))
+	}
+
+	concert := &model.Concert{
+		Name:             name,
+		Artist:           artist,
+		Venue:            venue.name,
+		ConcertDate:      date,
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            price,
+		Currency:         "USD",
+		BookingStartTime: saleStart,
+		BookingEndTime:   date,
+		Visibility:       model.VisibilityPublic,
+	}
+	if venue.doorPrices && rng.Float64() < 0.5 {
+		doorPrice := price + float64(roundTo(5+rng.Intn(11), 5))
+		concert.DoorPrice = &doorPrice
+		concert.DoorPriceLeadMinutes = 180
+	}
+	return concert
+}
+
+// seedBooking makes booking i of the fixtures, unless an earlier run did
+func (s *seedService) seedBooking(ctx context.Context, opts model.SeedOptions, i int, onSale []*model.Concert, popularity []float64, result *model.SeedResult) error {
+	ref := reference.Derive(fmt.Sprintf("seed:%d:booking:%d", opts.Seed, i))
+	_, err := s.bookingRepo.GetByReference(ctx, ref)
+	switch {
+	case err == nil:
+		result.BookingsFound++
+		return nil
+	case !errors.Is(err, pkgErr.ErrNotFound):
+		return fmt.Errorf("failed to look up seeded booking %s: %w", ref, err)
+	case len(onSale) == 0:
+		result.BookingsSkipped++
+		return nil
+	}
+
+	rng := seedRand(opts.Seed, "booking", i)
+	concert := onSale[pickWeighted(rng, popularity)]
+	user := 0
+	if opts.Users > 1 {
+		user = int(rand.NewZipf(rng, 1.2, 4, uint64(opts.Users-1)).Uint64())
+	}
+	first := seedFirstNames[user%len(seedFirstNames)]
+	last := seedLastNames[(user/len(seedFirstNames))%len(seedLastNames)]
+
+	booking := &model.Booking{
+		Reference:     ref,
+		ConcertID:     concert.ID,
+		UserID:        fmt.Sprintf("seed-user-%d", user+1),
+		TicketCount:   seedTicketCounts[pickWeighted(rng, seedTicketWeights)],
+		Status:        model.BookingStatusConfirmed,
+		AttendeeName:  first + " " + last,
+		AttendeeEmail: fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(strings.ReplaceAll(last, "'", "")), user+1),
+	}
+	_, err = s.bookingRepo.CreateWithConcertLock(ctx, booking, func(locked *model.Concert) error {
+		booking.UnitPrice = locked.PriceAt(clock.Now())
+		return nil
+	})
+	switch {
+	case errors.Is(err, pkgErr.ErrInsufficientTickets), errors.Is(err, pkgErr.ErrBookingClosed):
+		result.BookingsSkipped++
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to seed booking %s: %w", ref, err)
+	}
+	result.BookingsCreated++
+
+	if rng.Float64() < seedCancellationRate {
+		if _, err := s.bookingRepo.CancelWithTicketRelease(ctx, booking.ID); err != nil {
+			return fmt.Errorf("failed to cancel seeded booking %s: %w", ref, err)
+		}
+		result.BookingsCancelled++
+	}
+	return nil
+}
+
+// seedRand returns the random source of fixture i of a kind, the same for
+// the same seed
+func seedRand(seed int64, kind string, i int) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s:%d", seed, kind, i)
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// pickWeighted returns an index into weights, each drawn in proportion to
+// its weight
+func pickWeighted(rng *rand.Rand, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
+
+// roundTo rounds n to the nearest multiple of step, at least step
+func roundTo(n, step int) int {
+	return max(step, (n+step/2)/step*step)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+)
+
+// SubscribeConcertCache drops a concert from the cache whenever its ticket
+// availability or price phase changes, whichever service changed it. The
+// handler runs before the change is announced to subscribers, so a client
+// reading the concert once it sees the change doesn't get the cached copy.
+func SubscribeConcertCache(bus *events.Bus, cache repository.ConcertCache) {
+	invalidate := func(event events.Event) {
+		// The change is committed already, so the cache gets a context of
+		// its own instead of one the request may cancel first
+		invalidateConcert(context.Background(), cache, event.Key)
+	}
+	bus.Handle(model.EventConcertAvailability, invalidate)
+	bus.Handle(model.EventConcertPricing, invalidate)
+}
+
+// SubscribeQueueTurnNotifications texts the users admitted from the waiting
+// room that it's their turn. Texts are sent in the background, so admitting
+// doesn't wait for the SMS provider, and given up when the token expires.
+func SubscribeQueueTurnNotifications(bus *events.Bus, notifications NotificationService, log logger.Logger) {
+	bus.Handle(model.EventWaitingRoomAdmitted, func(event events.Event) {
+		token, ok := event.Payload.(*model.BookingToken)
+		if !ok {
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), token.ExpiresAt.Sub(clock.Now()))
+			defer cancel()
+			if err := notifications.NotifyQueueTurn(ctx, token); err != nil {
+				log.Warn("Failed to text the user admitted for concert %d: %v", token.ConcertID, err)
+			}
+		}()
+	})
+}
+
+// SubscribeSellOutAlerts alerts the ops channel when a concert's last
+// tickets are booked. Alerts are posted in the background, so the booking
+// doesn't wait for the chat webhook.
+func SubscribeSellOutAlerts(bus *events.Bus, alerts OpsAlerts, log logger.Logger) {
+	bus.Handle(model.EventConcertAvailability, func(event events.Event) {
+		availability, ok := event.Payload.(*model.ConcertAvailability)
+		if !ok || availability.AvailableTickets > 0 || availability.TotalTickets <= 0 {
+			return
+		}
+
+		go func() {
+			if err := alerts.SellOut(context.Background(), availability.ConcertID); err != nil {
+				log.Warn("Failed to alert that concert %d sold out: %v", availability.ConcertID, err)
+			}
+		}()
+	})
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/pagelink"
+	"concert-ticket-api/pkg/reference"
+)
+
+// TicketPageService defines the interface for the HTML ticket and receipt
+// pages, which users open from signed links on devices without the app
+type TicketPageService interface {
+	// Links issues the signed page links of a booking to its user, and the
+	// link of the user's calendar feed
+	Links(ctx context.Context, ref, userID string) (*model.BookingLinks, error)
+
+	// Ticket returns the booking of a ticket link. It fails with
+	// pagelink.ErrInvalid or pagelink.ErrExpired for links it didn't issue
+	// or that expired.
+	Ticket(ctx context.Context, token string) (*model.BookingPage, error)
+
+	// Receipt returns the booking of a receipt link, failing like Ticket
+	Receipt(ctx context.Context, token string) (*model.BookingPage, error)
+}
+
+type ticketPageService struct {
+	bookingRepo repository.BookingRepository
+	concertRepo repository.ConcertRepository
+	signer      *pagelink.Signer
+	baseURL     string
+	linkTTL     time.Duration
+}
+
+// NewTicketPageService creates a new implementation of TicketPageService
+// issuing links under baseURL that stay valid for linkTTL
+func NewTicketPageService(
+	bookingRepo repository.BookingRepository,
+	concertRepo repository.ConcertRepository,
+	signer *pagelink.Signer,
+	baseURL string,
+	linkTTL time.Duration,
+) TicketPageService {
+	return &ticketPageService{
+		bookingRepo: bookingRepo,
+		concertRepo: concertRepo,
+		signer:      signer,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		linkTTL:     linkTTL,
+	}
+}
+
+// Links issues the signed page links of a booking to its user. Bookings of
+// other users are reported as not found.
+func (s *ticketPageService) Links(ctx context.Context, ref, userID string) (*model.BookingLinks, error) {
+	if userID == "" {
+		return nil, pkgErr.ErrInvalidInput("user ID is required")
+	}
+
+	ref, ok := reference.Normalize(ref)
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+	booking, err := s.bookingRepo.GetByReference(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if booking.UserID != userID {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	expires := clock.Now().Add(s.linkTTL)
+	feed := s.baseURL + "/api/v1/users/" + url.PathEscape(userID) + "/bookings.ics"
+	return &model.BookingLinks{
+		TicketURL:   s.baseURL + "/t/" + url.PathEscape(s.signer.Sign(pagelink.KindTicket, booking.Reference, expires)),
+		ReceiptURL:  s.baseURL + "/r/" + url.PathEscape(s.signer.Sign(pagelink.KindReceipt, booking.Reference, expires)),
+		ExpiresAt:   time.Unix(expires.Unix(), 0).UTC(),
+		CalendarURL: feed + "?token=" + url.QueryEscape(s.signer.SignSubject(pagelink.KindCalendar, userID)),
+	}, nil
+}
+
+// Ticket returns the booking of a ticket link with its door code
+func (s *ticketPageService) Ticket(ctx context.Context, token string) (*model.BookingPage, error) {
+	page, err := s.page(ctx, pagelink.KindTicket, token)
+	if err != nil {
+		return nil, err
+	}
+	page.Code = token
+	return page, nil
+}
+
+// Receipt returns the booking of a receipt link
+func (s *ticketPageService) Receipt(ctx context.Context, token string) (*model.BookingPage, error) {
+	return s.page(ctx, pagelink.KindReceipt, token)
+}
+
+// page verifies a link and loads its booking and concert
+func (s *ticketPageService) page(ctx context.Context, kind, token string) (*model.BookingPage, error) {
+	ref, err := s.signer.Verify(kind, token, clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	booking, err := s.bookingRepo.GetByReference(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	concert, err := s.concertRepo.GetByID(ctx, booking.ConcertID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BookingPage{Booking: booking, Concert: concert}, nil
+}
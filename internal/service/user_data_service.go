@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/errors"
+)
+
+// UserDataService defines the interface for data subject requests (export and erasure)
+type UserDataService interface {
+	// ExportUserData returns all data stored about a user
+	ExportUserData(ctx context.Context, userID, actor string) (*model.UserDataExport, error)
+
+	// EraseUserData anonymizes all data stored about a user
+	EraseUserData(ctx context.Context, userID, actor string) (*model.UserDataErasure, error)
+}
+
+type userDataService struct {
+	bookingRepo     repository.BookingRepository
+	preferencesRepo repository.NotificationPreferenceRepository
+	auditService    AuditService
+}
+
+// NewUserDataService creates a new implementation of UserDataService.
+// preferencesRepo is nil where notification preferences aren't stored.
+func NewUserDataService(bookingRepo repository.BookingRepository, preferencesRepo repository.NotificationPreferenceRepository,
+	auditService AuditService) UserDataService {
+	return &userDataService{
+		bookingRepo:     bookingRepo,
+		preferencesRepo: preferencesRepo,
+		auditService:    auditService,
+	}
+}
+
+// ExportUserData returns all data stored about a user
+func (s *userDataService) ExportUserData(ctx context.Context, userID, actor string) (*model.UserDataExport, error) {
+	if userID == "" {
+		return nil, errors.ErrInvalidInput("user_id is required")
+	}
+
+	bookings, err := s.bookingRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bookings == nil {
+		bookings = []*model.Booking{}
+	}
+
+	var preferences *model.NotificationPreferences
+	if s.preferencesRepo != nil {
+		preferences, err = s.preferencesRepo.Get(ctx, userID)
+		if err != nil && !stderrors.Is(err, errors.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	// The export must not be handed out unless it has been audited
+	err = s.auditService.Record(ctx, actor, model.AuditActionUserDataExported, "user", userID, map[string]interface{}{
+		"bookings": len(bookings),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserDataExport{
+		UserID:                  userID,
+		ExportedAt:              clock.Now(),
+		Bookings:                bookings,
+		NotificationPreferences: preferences,
+	}, nil
+}
+
+// EraseUserData anonymizes all data stored about a user. Bookings are kept with
+// a random pseudonym in place of the user ID so ticket counts and sales totals
+// are preserved, and so are the orders, attempts and events tied to them.
+func (s *userDataService) EraseUserData(ctx context.Context, userID, actor string) (*model.UserDataErasure, error) {
+	if userID == "" {
+		return nil, errors.ErrInvalidInput("user_id is required")
+	}
+
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.bookingRepo.AnonymizeUser(ctx, userID, pseudonym)
+	if err != nil {
+		return nil, err
+	}
+
+	// The preferences hold the user's phone number and can't be tied to a
+	// pseudonym, so they go
+	if s.preferencesRepo != nil {
+		if err := s.preferencesRepo.Delete(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The pseudonym is deliberately not recorded so it can't be linked back to the user
+	err = s.auditService.Record(ctx, actor, model.AuditActionUserDataErased, "user", userID, map[string]interface{}{
+		"anonymized_records": count,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserDataErasure{
+		UserID:            userID,
+		ErasedAt:          clock.Now(),
+		AnonymizedRecords: count,
+	}, nil
+}
+
+// newPseudonym generates a random identifier for anonymized records
+func newPseudonym() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate pseudonym: %w", err)
+	}
+
+	return "erased-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// InviteTokenHeader is the HTTP header callers present invite tokens in. gRPC
+// callers send it as metadata under the lowercased name.
+const InviteTokenHeader = "X-Invite-Token"
+
+type inviteTokenKey struct{}
+
+type privateAccessKey struct{}
+
+// WithInviteToken returns a context carrying the invite token presented by
+// the caller, which grants access to the private concert it was issued for
+func WithInviteToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, inviteTokenKey{}, token)
+}
+
+// WithPrivateAccess returns a context that grants access to every private
+// concert, for organizers and admins managing them
+func WithPrivateAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privateAccessKey{}, true)
+}
+
+// inviteTokenFromContext returns the invite token presented by the caller, if any
+func inviteTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(inviteTokenKey{}).(string)
+	return token
+}
+
+// checkVisibility hides private concerts from callers without their invite
+// token or an invite. They get ErrNotFound, so private concerts can't be found
+// by trying IDs.
+func checkVisibility(ctx context.Context, concerts repository.ConcertRepository, concert *model.Concert) error {
+	_, err := presentedInvite(ctx, concerts, concert)
+	return err
+}
+
+// presentedInvite checks that the caller may access a concert and returns the
+// invite they presented. It is nil unless the concert is private and the
+// caller used one of its invites rather than the concert's own token.
+func presentedInvite(ctx context.Context, concerts repository.ConcertRepository, concert *model.Concert) (*model.ConcertInvite, error) {
+	if concert.Visibility != model.VisibilityPrivate {
+		return nil, nil
+	}
+
+	if access, _ := ctx.Value(privateAccessKey{}).(bool); access {
+		return nil, nil
+	}
+
+	token := inviteTokenFromContext(ctx)
+	if token == "" {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	hash := hashToken(token)
+	if concert.InviteTokenHash != "" &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(concert.InviteTokenHash)) == 1 {
+		return nil, nil
+	}
+
+	// Invites are looked up by the hash, which doesn't reveal the token
+	return concerts.GetInviteByTokenHash(ctx, concert.ID, hash)
+}
+
+// setVisibility validates the visibility of a concert and issues an invite
+// token when it becomes private. previous is the stored concert, nil for new ones.
+func setVisibility(concert, previous *model.Concert) error {
+	// Invite tokens are only ever issued here, never taken from the request
+	concert.InviteToken = ""
+
+	if concert.Visibility == "" && previous != nil {
+		concert.Visibility = previous.Visibility
+	}
+	if concert.Visibility == "" {
+		concert.Visibility = model.VisibilityPublic
+	}
+
+	switch concert.Visibility {
+	case model.VisibilityPublic, model.VisibilityUnlisted, model.VisibilityPrivate:
+	default:
+		return pkgErr.ErrInvalidInput("visibility must be public, unlisted or private")
+	}
+
+	// The invite token is kept across updates, so invites already sent keep working
+	if previous != nil {
+		concert.InviteTokenHash = previous.InviteTokenHash
+	}
+
+	if concert.Visibility == model.VisibilityPrivate && concert.InviteTokenHash == "" {
+		token, err := newOpaqueToken()
+		if err != nil {
+			return err
+		}
+		concert.InviteToken = token
+		concert.InviteTokenHash = hashToken(token)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+)
+
+// WaitingRoom queues users for the booking tokens of a concert and admits
+// them in order as the token issue rate allows, so clients don't have to
+// retry token requests until one gets through
+type WaitingRoom interface {
+	// Join queues a user for a booking token. A user who is already queued for
+	// the concert keeps their place, and their previous entry is closed. The
+	// invite token in ctx, if any, is used to admit the user to a private concert.
+	Join(ctx context.Context, concertID int64, userID string) *WaitingEntry
+
+	// Leave removes an entry from its queue
+	Leave(entry *WaitingEntry)
+
+	// Disconnect closes an entry whose connection closed. Waiting rooms that
+	// outlive connections keep the user's place for a while, so they can
+	// connect again, e.g. to another replica during a deploy.
+	Disconnect(entry *WaitingEntry)
+
+	// Admit issues tokens to the users at the front of every queue while the
+	// issue rate allows, reports the new positions to the others and returns
+	// how many users were admitted
+	Admit(ctx context.Context) int
+}
+
+// WaitingEntry is a user's place in a waiting room queue
+type WaitingEntry struct {
+	ConcertID int64
+	UserID    string
+
+	inviteToken string
+	connection  string
+	updates     chan model.WaitingRoomUpdate
+	position    int
+	element     *list.Element
+}
+
+// Updates returns the channel position updates are delivered on. Only the
+// latest update is kept for a slow reader. The channel is closed once the
+// user is admitted, fails to be admitted or leaves.
+func (e *WaitingEntry) Updates() <-chan model.WaitingRoomUpdate {
+	return e.updates
+}
+
+// deliver replaces an unread update with a newer one
+func (e *WaitingEntry) deliver(update model.WaitingRoomUpdate) {
+	for {
+		select {
+		case e.updates <- update:
+			return
+		default:
+		}
+
+		select {
+		case <-e.updates:
+		default:
+		}
+	}
+}
+
+type waitingRoom struct {
+	tokens BookingTokenService
+	events *events.Bus
+
+	// mu also serializes admission, so tokens are issued in queue order
+	mu     sync.Mutex
+	queues map[int64]*list.List
+}
+
+// NewWaitingRoom creates a new in-memory WaitingRoom issuing tokens through
+// the token service and announcing admitted users on bus
+func NewWaitingRoom(tokens BookingTokenService, bus *events.Bus) WaitingRoom {
+	return &waitingRoom{
+		tokens: tokens,
+		events: bus,
+		queues: make(map[int64]*list.List),
+	}
+}
+
+// Join queues a user for a booking token
+func (w *waitingRoom) Join(ctx context.Context, concertID int64, userID string) *WaitingEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry := &WaitingEntry{
+		ConcertID:   concertID,
+		UserID:      userID,
+		inviteToken: inviteTokenFromContext(ctx),
+		updates:     make(chan model.WaitingRoomUpdate, 1),
+	}
+
+	queue, ok := w.queues[concertID]
+	if !ok {
+		queue = list.New()
+		w.queues[concertID] = queue
+	}
+
+	position := 1
+	for e := queue.Front(); e != nil; e = e.Next() {
+		previous := e.Value.(*WaitingEntry)
+		if previous.UserID == userID {
+			// Reconnecting keeps the user's place in the queue
+			previous.element = nil
+			close(previous.updates)
+			e.Value = entry
+			entry.element = e
+			break
+		}
+		position++
+	}
+
+	if entry.element == nil {
+		entry.element = queue.PushBack(entry)
+	}
+
+	entry.position = position
+	entry.deliver(model.WaitingRoomUpdate{ConcertID: concertID, Position: position})
+	return entry
+}
+
+// Leave removes an entry from its queue
+func (w *waitingRoom) Leave(entry *WaitingEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.remove(entry)
+}
+
+// Disconnect removes an entry from its queue: the in-memory queues don't
+// outlive the process, so there is no one to keep the place for
+func (w *waitingRoom) Disconnect(entry *WaitingEntry) {
+	w.Leave(entry)
+}
+
+// Admit issues tokens to the users at the front of the queues
+func (w *waitingRoom) Admit(ctx context.Context) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	admitted := 0
+	for concertID, queue := range w.queues {
+		for queue.Len() > 0 {
+			entry := queue.Front().Value.(*WaitingEntry)
+			token, err := w.tokens.IssueToken(WithInviteToken(ctx, entry.inviteToken), concertID, entry.UserID)
+			if errors.Is(err, pkgErr.ErrRateLimited) {
+				break
+			}
+
+			update := model.WaitingRoomUpdate{ConcertID: concertID, Token: token}
+			if err != nil {
+				update.Error = err.Error()
+			} else {
+				admitted++
+				publishAdmitted(w.events, token)
+			}
+			entry.deliver(update)
+			w.remove(entry)
+		}
+
+		// Tell everyone still waiting how far they moved up
+		position := 1
+		for e := queue.Front(); e != nil; e = e.Next() {
+			entry := e.Value.(*WaitingEntry)
+			if entry.position != position {
+				entry.position = position
+				entry.deliver(model.WaitingRoomUpdate{ConcertID: concertID, Position: position})
+			}
+			position++
+		}
+	}
+
+	return admitted
+}
+
+// remove takes an entry out of its queue and closes it; the caller must hold the lock
+func (w *waitingRoom) remove(entry *WaitingEntry) {
+	if entry.element == nil {
+		return
+	}
+
+	queue := w.queues[entry.ConcertID]
+	queue.Remove(entry.element)
+	entry.element = nil
+	close(entry.updates)
+
+	if queue.Len() == 0 {
+		delete(w.queues, entry.ConcertID)
+	}
+}
+
+// publishAdmitted announces a user admitted from a waiting room with the
+// token they were issued
+func publishAdmitted(bus *events.Bus, token *model.BookingToken) {
+	// Handlers get a copy, the token is also delivered to the user
+	published := *token
+	bus.Publish(events.Event{
+		Topic:   model.EventWaitingRoomAdmitted,
+		Key:     model.UserEventKey(token.UserID),
+		Payload: &published,
+		At:      clock.Now(),
+	})
+}
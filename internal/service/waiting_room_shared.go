@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+)
+
+const (
+	// sharedAdmitBatch is how many queued users one store read admits
+	sharedAdmitBatch = 50
+	// restoreAttempts limits how often a restore is retried while the
+	// queues change
+	restoreAttempts = 3
+	// errWaitingRoomUnavailable is reported to users the store can't queue
+	errWaitingRoomUnavailable = "waiting room is unavailable, please try again"
+)
+
+// PersistentWaitingRoom is a WaitingRoom whose queues survive the process
+type PersistentWaitingRoom interface {
+	WaitingRoom
+
+	// Persist saves a snapshot of the queues. If the store lost queues the
+	// latest snapshot still has, they are restored first, keeping the places
+	// of the users in the snapshot ahead of those who joined since.
+	Persist(ctx context.Context) error
+}
+
+type sharedWaitingRoom struct {
+	tokens      BookingTokenService
+	events      *events.Bus
+	store       repository.WaitingRoomStore
+	snapshots   repository.WaitingRoomSnapshotRepository
+	instance    string
+	leaderTTL   time.Duration
+	rejoinGrace time.Duration
+
+	// mu guards the entries of local connections, not the queues
+	mu      sync.Mutex
+	entries map[int64]map[string]*WaitingEntry
+}
+
+// NewSharedWaitingRoom creates a new WaitingRoom whose queues every replica
+// shares through the store. One instance at a time admits users; the others
+// report positions and outcomes to the users connected to them. Users whose
+// connection closes keep their place for rejoinGrace, e.g. to reconnect to
+// another replica during a deploy. The admitting instance announces the
+// users it admits on bus.
+func NewSharedWaitingRoom(tokens BookingTokenService, bus *events.Bus, store repository.WaitingRoomStore,
+	snapshots repository.WaitingRoomSnapshotRepository, instance string, admitInterval, rejoinGrace time.Duration) PersistentWaitingRoom {
+	return &sharedWaitingRoom{
+		tokens:    tokens,
+		events:    bus,
+		store:     store,
+		snapshots: snapshots,
+		instance:  instance,
+		// Another instance takes over when the admitting one missed a few runs
+		leaderTTL:   3 * admitInterval,
+		rejoinGrace: rejoinGrace,
+		entries:     make(map[int64]map[string]*WaitingEntry),
+	}
+}
+
+// Join queues a user for a booking token
+func (w *sharedWaitingRoom) Join(ctx context.Context, concertID int64, userID string) *WaitingEntry {
+	entry := &WaitingEntry{
+		ConcertID:   concertID,
+		UserID:      userID,
+		inviteToken: inviteTokenFromContext(ctx),
+		updates:     make(chan model.WaitingRoomUpdate, 1),
+	}
+	connection, err := newOpaqueToken()
+	if err != nil {
+		w.fail(entry)
+		return entry
+	}
+	entry.connection = connection
+
+	// A user admitted while they were between connections gets their outcome
+	outcome, err := w.store.TakeOutcome(ctx, concertID, userID)
+	if err != nil {
+		w.fail(entry)
+		return entry
+	}
+	if outcome != nil {
+		entry.deliver(*outcome)
+		close(entry.updates)
+		return entry
+	}
+
+	position, err := w.store.Enqueue(ctx, concertID, userID, entry.inviteToken, entry.connection)
+	if err != nil {
+		w.fail(entry)
+		return entry
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	users, ok := w.entries[concertID]
+	if !ok {
+		users = make(map[string]*WaitingEntry)
+		w.entries[concertID] = users
+	}
+	if previous, ok := users[userID]; ok {
+		close(previous.updates)
+	}
+	users[userID] = entry
+
+	entry.position = position
+	entry.deliver(model.WaitingRoomUpdate{ConcertID: concertID, Position: position})
+	return entry
+}
+
+// fail tells a user the store couldn't queue them and closes their entry
+func (w *sharedWaitingRoom) fail(entry *WaitingEntry) {
+	entry.deliver(model.WaitingRoomUpdate{ConcertID: entry.ConcertID, Error: errWaitingRoomUnavailable})
+	close(entry.updates)
+}
+
+// Leave removes an entry from its queue
+func (w *sharedWaitingRoom) Leave(entry *WaitingEntry) {
+	if w.detach(entry) {
+		w.store.Remove(context.Background(), entry.ConcertID, entry.UserID, nil, 0)
+	}
+}
+
+// Disconnect closes an entry and keeps the user's place for the rejoin grace
+func (w *sharedWaitingRoom) Disconnect(entry *WaitingEntry) {
+	if w.detach(entry) {
+		w.store.Depart(context.Background(), entry.ConcertID, entry.UserID, entry.connection, clock.Now())
+	}
+}
+
+// detach closes an entry of a local connection and reports whether it was
+// open, i.e. not closed or replaced by a newer entry
+func (w *sharedWaitingRoom) detach(entry *WaitingEntry) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	users := w.entries[entry.ConcertID]
+	if users[entry.UserID] != entry {
+		return false
+	}
+	w.closeLocked(entry)
+	return true
+}
+
+// closeLocked closes an entry; the caller must hold the lock
+func (w *sharedWaitingRoom) closeLocked(entry *WaitingEntry) {
+	users := w.entries[entry.ConcertID]
+	delete(users, entry.UserID)
+	if len(users) == 0 {
+		delete(w.entries, entry.ConcertID)
+	}
+	close(entry.updates)
+}
+
+// Admit drops the users who didn't reconnect in time, issues tokens if this
+// instance is the one admitting and reports to the local connections
+func (w *sharedWaitingRoom) Admit(ctx context.Context) int {
+	now := clock.Now()
+	w.store.RemoveDeparted(ctx, now.Add(-w.rejoinGrace))
+
+	admitted := 0
+	if leader, err := w.store.Lead(ctx, w.instance, w.leaderTTL); err == nil && leader {
+		admitted = w.admitQueued(ctx, now)
+	}
+
+	w.report(ctx)
+	return admitted
+}
+
+// admitQueued issues tokens to the users at the front of every queue while
+// the issue rate allows. The outcomes are kept for the instances the users
+// are connected to.
+func (w *sharedWaitingRoom) admitQueued(ctx context.Context, now time.Time) int {
+	concertIDs, err := w.store.Concerts(ctx)
+	if err != nil {
+		return 0
+	}
+
+	admitted := 0
+	for _, concertID := range concertIDs {
+	queue:
+		for {
+			members, err := w.store.Front(ctx, concertID, sharedAdmitBatch)
+			if err != nil || len(members) == 0 {
+				break
+			}
+
+			for _, member := range members {
+				token, err := w.tokens.IssueToken(WithInviteToken(ctx, member.InviteToken), concertID, member.UserID)
+				if errors.Is(err, pkgErr.ErrRateLimited) {
+					break queue
+				}
+
+				update := model.WaitingRoomUpdate{ConcertID: concertID, Token: token}
+				// Tokens are kept until they expire, errors for the rejoin grace
+				ttl := w.rejoinGrace
+				if err != nil {
+					update.Error = err.Error()
+				} else if token.ExpiresAt.After(now) {
+					ttl = token.ExpiresAt.Sub(now)
+				}
+				if err := w.store.Remove(ctx, concertID, member.UserID, &update, ttl); err != nil {
+					break queue
+				}
+				if token != nil {
+					admitted++
+					publishAdmitted(w.events, token)
+				}
+			}
+		}
+	}
+
+	return admitted
+}
+
+// report delivers outcomes and changed positions to the local connections
+func (w *sharedWaitingRoom) report(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for concertID, users := range w.entries {
+		userIDs := make([]string, 0, len(users))
+		for userID := range users {
+			userIDs = append(userIDs, userID)
+		}
+		positions, err := w.store.Positions(ctx, concertID, userIDs)
+		if err != nil {
+			continue
+		}
+
+		for i, userID := range userIDs {
+			entry := users[userID]
+			if positions[i] > 0 {
+				if entry.position != positions[i] {
+					entry.position = positions[i]
+					entry.deliver(model.WaitingRoomUpdate{ConcertID: concertID, Position: positions[i]})
+				}
+				continue
+			}
+
+			// The user was admitted, or removed by a connection elsewhere
+			outcome, err := w.store.TakeOutcome(ctx, concertID, userID)
+			if err != nil {
+				continue
+			}
+			if outcome != nil {
+				entry.deliver(*outcome)
+			}
+			w.closeLocked(entry)
+		}
+	}
+}
+
+// Persist saves a snapshot of the queues, restoring them first if the store
+// lost them
+func (w *sharedWaitingRoom) Persist(ctx context.Context) error {
+	latest, err := w.snapshots.Latest(ctx)
+	if errors.Is(err, pkgErr.ErrNotFound) {
+		latest = nil
+	} else if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := w.store.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		// A snapshot of another format is replaced, not restored
+		if latest != nil && latest.Format != model.WaitingRoomStateFormat {
+			if _, err := w.snapshots.Save(ctx, current); err != nil {
+				return err
+			}
+			return fmt.Errorf("replaced waiting room snapshot of format %d, which can't be restored", latest.Format)
+		}
+
+		// Versions only grow, so a store behind the snapshot lost its data
+		if latest == nil || latest.Version <= current.Version {
+			_, err := w.snapshots.Save(ctx, current)
+			return err
+		}
+
+		merged := model.MergeWaitingRoomStates(latest, current)
+		err = w.store.Restore(ctx, merged, current.Version)
+		if errors.Is(err, pkgErr.ErrUpdateFailed) && attempt < restoreAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = w.snapshots.Save(ctx, merged)
+		return err
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/warehouse"
+)
+
+// WarehouseService exports snapshots of the bookings and concerts that
+// changed to the data warehouse, so analytics don't query the database
+type WarehouseService interface {
+	// Export writes the rows of every dataset changed since its watermark,
+	// in files of up to the batch size, and moves the watermark past each
+	// file written. It returns the number of rows exported.
+	Export(ctx context.Context) (int, error)
+}
+
+// warehouseRow is a row of a dataset as a CSV record, with the change time
+// and ID the watermark moves to
+type warehouseRow struct {
+	id        int64
+	updatedAt time.Time
+	record    []string
+}
+
+// warehouseDataset maps a dataset to the columns of its files
+type warehouseDataset struct {
+	name   string
+	header []string
+	list   func(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]warehouseRow, error)
+}
+
+type warehouseService struct {
+	repo      repository.WarehouseRepository
+	sink      warehouse.Sink
+	prefix    string
+	batchSize int
+	lag       time.Duration
+}
+
+// NewWarehouseService creates a new implementation of WarehouseService.
+// Files are written to sink under prefix. Rows changed within lag of the
+// export are left to the next one.
+func NewWarehouseService(repo repository.WarehouseRepository, sink warehouse.Sink, prefix string, batchSize int, lag time.Duration) WarehouseService {
+	return &warehouseService{
+		repo:      repo,
+		sink:      sink,
+		prefix:    prefix,
+		batchSize: batchSize,
+		lag:       lag,
+	}
+}
+
+// Export writes the changed rows of every dataset
+func (s *warehouseService) Export(ctx context.Context) (int, error) {
+	// Rows are dated when their transaction started, so one committing now
+	// may have changed rows a moment ago, behind rows already exported
+	before := clock.Now().Add(-s.lag)
+
+	// Keep going after a failure so the other datasets are still exported
+	var firstErr error
+	exported := 0
+	for _, dataset := range s.datasets() {
+		n, err := s.export(ctx, dataset, before)
+		exported += n
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to export %s: %w", dataset.name, err)
+		}
+	}
+
+	return exported, firstErr
+}
+
+// export writes the rows of one dataset changed before before
+func (s *warehouseService) export(ctx context.Context, dataset warehouseDataset, before time.Time) (int, error) {
+	watermark, err := s.repo.GetWatermark(ctx, dataset.name)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for {
+		rows, err := dataset.list(ctx, watermark, before, s.batchSize)
+		if err != nil || len(rows) == 0 {
+			return exported, err
+		}
+
+		records := make([][]string, len(rows))
+		for i, row := range rows {
+			records[i] = row.record
+		}
+		body, err := warehouse.EncodeCSV(dataset.header, records)
+		if err != nil {
+			return exported, err
+		}
+
+		// The key follows from the first row, so a file written again after
+		// the watermark failed to save replaces the first one
+		key := s.objectKey(dataset.name, rows[0])
+		if err := s.sink.Put(ctx, key, body, "application/gzip"); err != nil {
+			return exported, fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+
+		last := rows[len(rows)-1]
+		watermark = &model.WarehouseWatermark{
+			Dataset:       dataset.name,
+			UpdatedAt:     last.updatedAt,
+			LastID:        last.id,
+			RowsExported:  watermark.RowsExported + int64(len(rows)),
+			LastObjectKey: key,
+			ExportedAt:    clock.Now(),
+		}
+		if err := s.repo.SaveWatermark(ctx, watermark); err != nil {
+			return exported, err
+		}
+		exported += len(rows)
+
+		if len(rows) < s.batchSize {
+			return exported, nil
+		}
+	}
+}
+
+// objectKey names the file starting with row, partitioned by the day it changed:
+// <prefix>/bookings/dt=2026-10-14/bookings-20261014T093000.123456Z-42.csv.gz
+func (s *warehouseService) objectKey(dataset string, first warehouseRow) string {
+	prefix := strings.TrimSuffix(s.prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	changed := first.updatedAt.UTC()
+	return fmt.Sprintf("%s%s/dt=%s/%s-%s-%d%s", prefix, dataset, changed.Format("2006-01-02"),
+		dataset, changed.Format("20060102T150405.000000Z"), first.id, warehouse.CSVExtension)
+}
+
+// datasets lists the exported datasets with their columns
+func (s *warehouseService) datasets() []warehouseDataset {
+	return []warehouseDataset{
+		{
+			name: model.WarehouseDatasetBookings,
+			header: []string{"id", "reference", "concert_id", "order_id", "user_id", "ticket_count", "unit_price",
+				"status", "booking_time", "created_at", "updated_at"},
+			list: func(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]warehouseRow, error) {
+				bookings, err := s.repo.ListBookingChanges(ctx, after, before, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]warehouseRow, len(bookings))
+				for i, b := range bookings {
+					orderID := ""
+					if b.OrderID != nil {
+						orderID = strconv.FormatInt(*b.OrderID, 10)
+					}
+					rows[i] = warehouseRow{id: b.ID, updatedAt: b.UpdatedAt, record: []string{
+						strconv.FormatInt(b.ID, 10), b.Reference, strconv.FormatInt(b.ConcertID, 10), orderID, b.UserID,
+						strconv.Itoa(b.TicketCount), formatDecimal(b.UnitPrice), string(b.Status),
+						formatTimestamp(b.BookingTime), formatTimestamp(b.CreatedAt), formatTimestamp(b.UpdatedAt),
+					}}
+				}
+				return rows, nil
+			},
+		},
+		{
+			name: model.WarehouseDatasetConcerts,
+			header: []string{"id", "name", "artist", "venue", "concert_date", "total_tickets", "available_tickets",
+				"price", "currency", "visibility", "booking_start_time", "booking_end_time", "created_at", "updated_at"},
+			list: func(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]warehouseRow, error) {
+				concerts, err := s.repo.ListConcertChanges(ctx, after, before, limit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]warehouseRow, len(concerts))
+				for i, c := range concerts {
+					rows[i] = warehouseRow{id: c.ID, updatedAt: c.UpdatedAt, record: []string{
+						strconv.FormatInt(c.ID, 10), c.Name, c.Artist, c.Venue, formatTimestamp(c.ConcertDate),
+						strconv.Itoa(c.TotalTickets), strconv.Itoa(c.AvailableTickets), formatDecimal(c.Price),
+						c.Currency, c.Visibility, formatTimestamp(c.BookingStartTime), formatTimestamp(c.BookingEndTime),
+						formatTimestamp(c.CreatedAt), formatTimestamp(c.UpdatedAt),
+					}}
+				}
+				return rows, nil
+			},
+		},
+	}
+}
+
+// formatTimestamp writes a time in UTC as RFC 3339, which warehouses load as timestamps
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatDecimal writes an amount without exponent or trailing zeros
+func formatDecimal(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
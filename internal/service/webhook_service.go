@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/webhook"
+)
+
+// Limits of the delivery log
+const (
+	DefaultWebhookDeliveries = 50
+	MaxWebhookDeliveries     = 100
+)
+
+// minWebhookSecretLength keeps secrets chosen by organizers hard to guess
+const minWebhookSecretLength = 16
+
+// WebhookService defines the interface for the webhooks of organizers and
+// the delivery of domain events to them. Organizers only see and change
+// their own webhooks: those of other organizers are ErrNotFound, so their
+// IDs can't be probed.
+type WebhookService interface {
+	// CreateWebhook registers a webhook of an organizer. It is returned with
+	// its secret, which isn't shown again.
+	CreateWebhook(ctx context.Context, organizerEmail string, req *model.WebhookRequest) (*model.Webhook, error)
+
+	// GetWebhook retrieves a webhook of an organizer without its secret
+	GetWebhook(ctx context.Context, organizerEmail string, id int64) (*model.Webhook, error)
+
+	// ListWebhooks retrieves the webhooks of an organizer without their secret
+	ListWebhooks(ctx context.Context, organizerEmail string) ([]*model.Webhook, error)
+
+	// UpdateWebhook replaces a webhook of an organizer. It is returned with
+	// its secret only if the request changed it.
+	UpdateWebhook(ctx context.Context, organizerEmail string, id int64, req *model.WebhookRequest) (*model.Webhook, error)
+
+	// DeleteWebhook deletes a webhook of an organizer and its deliveries
+	DeleteWebhook(ctx context.Context, organizerEmail string, id int64) error
+
+	// ListDeliveries retrieves the latest deliveries of a webhook of an
+	// organizer, newest first, only those of a status unless it is empty
+	ListDeliveries(ctx context.Context, organizerEmail string, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error)
+
+	// Redeliver attempts a delivery of a webhook of an organizer again from
+	// the next run on, with every attempt of the policy, whatever became of
+	// it before
+	Redeliver(ctx context.Context, organizerEmail string, webhookID, deliveryID int64) (*model.WebhookDelivery, error)
+
+	// Dispatch creates the deliveries of the events written to the outbox
+	// since the last run to the webhooks of the organizers of their concerts
+	// and returns the number created
+	Dispatch(ctx context.Context) (int, error)
+
+	// DeliverDue attempts the deliveries that are due and returns the number
+	// delivered. A failed attempt is retried after a backoff, and a delivery
+	// that failed every attempt is dead. Only failing to record an attempt
+	// is an error.
+	DeliverDue(ctx context.Context) (int, error)
+
+	// PurgeDeliveries deletes the delivered and dead deliveries older than
+	// the retention and returns the number deleted
+	PurgeDeliveries(ctx context.Context) (int, error)
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	sender *webhook.Sender
+	policy model.WebhookPolicy
+}
+
+// NewWebhookService creates a new implementation of WebhookService
+func NewWebhookService(repo repository.WebhookRepository, sender *webhook.Sender, policy model.WebhookPolicy) WebhookService {
+	return &webhookService{
+		repo:   repo,
+		sender: sender,
+		policy: policy,
+	}
+}
+
+// CreateWebhook validates and registers a webhook
+func (s *webhookService) CreateWebhook(ctx context.Context, organizerEmail string, req *model.WebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.webhookOf(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.OrganizerEmail = organizerEmail
+	webhook.Active = req.Active == nil || *req.Active
+	if webhook.Secret == "" {
+		token, err := newOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = "whsec_" + token
+	}
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetWebhook retrieves a webhook of the organizer
+func (s *webhookService) GetWebhook(ctx context.Context, organizerEmail string, id int64) (*model.Webhook, error) {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.OrganizerEmail != organizerEmail {
+		return nil, pkgErr.ErrNotFound
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves the webhooks of the organizer
+func (s *webhookService) ListWebhooks(ctx context.Context, organizerEmail string) ([]*model.Webhook, error) {
+	return s.repo.List(ctx, organizerEmail)
+}
+
+// UpdateWebhook validates and replaces a webhook. Without active in the
+// request, the webhook stays active or inactive.
+func (s *webhookService) UpdateWebhook(ctx context.Context, organizerEmail string, id int64, req *model.WebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.webhookOf(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.GetWebhook(ctx, organizerEmail, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.ID = id
+	webhook.OrganizerEmail = organizerEmail
+	webhook.Active = existing.Active
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// webhookOf validates a webhook request and returns the webhook it describes
+func (s *webhookService) webhookOf(ctx context.Context, req *model.WebhookRequest) (*model.Webhook, error) {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || target.Host == "" || (target.Scheme != "https" && target.Scheme != "http") {
+		return nil, pkgErr.ErrInvalidInput("url must be an absolute http or https URL")
+	}
+	if target.Scheme == "http" && !s.policy.AllowHTTP {
+		return nil, pkgErr.ErrInvalidInput("url must use https")
+	}
+	if target.User != nil {
+		return nil, pkgErr.ErrInvalidInput("url must not contain credentials, the signature authenticates deliveries")
+	}
+	if !s.policy.AllowPrivateNetworks {
+		if err := webhook.CheckHost(ctx, target.Hostname()); err != nil {
+			return nil, pkgErr.ErrInvalidInput("url must point to a public address")
+		}
+	}
+
+	eventTypes := make(model.StringList, 0, len(req.EventTypes))
+	seen := make(map[string]bool, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("unknown event type %q, must be one of %s",
+				eventType, strings.Join(model.WebhookEventTypes, ", ")))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return nil, pkgErr.ErrInvalidInput("at least one event type is required")
+	}
+
+	if len(req.Description) > 255 {
+		return nil, pkgErr.ErrInvalidInput("description must be at most 255 characters")
+	}
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength))
+	}
+
+	return &model.Webhook{
+		URL:         target.String(),
+		Secret:      req.Secret,
+		EventTypes:  eventTypes,
+		Description: req.Description,
+	}, nil
+}
+
+// isWebhookEventType checks if webhooks can receive events of a type
+func isWebhookEventType(eventType string) bool {
+	for _, t := range model.WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteWebhook deletes a webhook of the organizer
+func (s *webhookService) DeleteWebhook(ctx context.Context, organizerEmail string, id int64) error {
+	if _, err := s.GetWebhook(ctx, organizerEmail, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// ListDeliveries retrieves the delivery log of a webhook of the organizer
+func (s *webhookService) ListDeliveries(ctx context.Context, organizerEmail string, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	switch status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryDead:
+	default:
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("status must be %s, %s or %s",
+			model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryDead))
+	}
+
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveries
+	}
+	if limit > MaxWebhookDeliveries {
+		return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("limit must be at most %d", MaxWebhookDeliveries))
+	}
+
+	if _, err := s.GetWebhook(ctx, organizerEmail, webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(ctx, webhookID, status, limit)
+}
+
+// Redeliver schedules a delivery of a webhook of the organizer for the next run
+func (s *webhookService) Redeliver(ctx context.Context, organizerEmail string, webhookID, deliveryID int64) (*model.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, organizerEmail, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.Redeliver(ctx, webhookID, deliveryID, clock.Now())
+}
+
+// Dispatch fans the new outbox events out to the webhooks of the organizers
+// of their concerts, batch by batch
+// until the outbox is caught up
+func (s *webhookService) Dispatch(ctx context.Context) (int, error) {
+	enqueued := 0
+	for {
+		dispatched, created, err := s.repo.Enqueue(ctx, s.policy.BatchSize, clock.Now())
+		enqueued += created
+		if err != nil || dispatched < s.policy.BatchSize || ctx.Err() != nil {
+			return enqueued, err
+		}
+	}
+}
+
+// webhookEnvelope is the body of a delivery
+type webhookEnvelope struct {
+	// ID identifies the event, so receivers can drop redeliveries
+	ID        int64         `json:"id"`
+	Type      string        `json:"type"`
+	CreatedAt time.Time     `json:"created_at"`
+	Data      model.RawJSON `json:"data"`
+}
+
+// DeliverDue attempts the due deliveries, a few at a time
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	due, err := s.repo.ListDue(ctx, clock.Now(), s.policy.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mutex     sync.Mutex
+		wg        sync.WaitGroup
+		delivered int
+		firstErr  error
+	)
+	slots := make(chan struct{}, s.policy.Concurrency)
+	for _, delivery := range due {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := s.attempt(ctx, delivery)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if err == nil && delivery.Status == model.WebhookDeliveryDelivered {
+				delivered++
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return delivered, firstErr
+}
+
+// attempt sends a delivery once and records the outcome
+func (s *webhookService) attempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	body, err := json.Marshal(&webhookEnvelope{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.EventCreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery %d: %w", delivery.ID, err)
+	}
+
+	resp, err := s.sender.Send(ctx, &webhook.Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		Event:      delivery.EventType,
+		EventID:    delivery.EventID,
+		DeliveryID: delivery.ID,
+		Body:       body,
+	}, clock.Now())
+
+	now := clock.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	if resp != nil {
+		delivery.ResponseStatus = &resp.StatusCode
+	}
+
+	if err == nil && resp.OK() {
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+		return s.repo.RecordAttempt(ctx, delivery)
+	}
+
+	var message string
+	if err != nil {
+		message = err.Error()
+	} else {
+		message = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	delivery.LastError = &message
+
+	if delivery.Attempts >= s.policy.MaxAttempts {
+		delivery.Status = model.WebhookDeliveryDead
+	} else {
+		delivery.NextAttemptAt = now.Add(s.policy.Backoff(delivery.Attempts))
+	}
+
+	return s.repo.RecordAttempt(ctx, delivery)
+}
+
+// PurgeDeliveries deletes the finished deliveries older than the retention
+func (s *webhookService) PurgeDeliveries(ctx context.Context) (int, error) {
+	return s.repo.PurgeDeliveries(ctx, clock.Now().Add(-s.policy.Retention))
+}
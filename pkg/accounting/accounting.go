@@ -0,0 +1,103 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"concert-ticket-api/config"
+)
+
+// Account is the role of an account in a journal entry. Adapters map roles
+// to the accounts configured in their system.
+type Account string
+
+const (
+	// AccountCash is where ticket payments are received
+	AccountCash Account = "cash"
+	// AccountRevenue is where ticket sales are recognized
+	AccountRevenue Account = "revenue"
+)
+
+// JournalLine is one debit or credit of a journal entry
+type JournalLine struct {
+	Account     Account
+	Debit       float64
+	Credit      float64
+	Description string
+}
+
+// JournalEntry is a balanced set of journal lines
+type JournalEntry struct {
+	// ID identifies the entry across retries, so pushing it twice doesn't
+	// post it twice in systems that support idempotent requests
+	ID       string
+	Date     time.Time
+	Currency string
+	Memo     string
+	Lines    []JournalLine
+}
+
+// Adapter pushes journal entries to an accounting system
+type Adapter interface {
+	// Name identifies the accounting system, e.g. "quickbooks"
+	Name() string
+
+	// Push posts a journal entry and returns its ID in the accounting system
+	Push(ctx context.Context, entry *JournalEntry) (string, error)
+}
+
+// NewAdapters creates an adapter for every accounting system with an access token
+func NewAdapters(cfg config.Accounting) []Adapter {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var adapters []Adapter
+	if cfg.QuickBooks.AccessToken != "" {
+		adapters = append(adapters, NewQuickBooks(cfg.QuickBooks, client))
+	}
+	if cfg.Xero.AccessToken != "" {
+		adapters = append(adapters, NewXero(cfg.Xero, client))
+	}
+
+	return adapters
+}
+
+// postJSON sends body as JSON and decodes a successful JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Error bodies explain what was rejected, which is what ends up in the reconciliation report
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"concert-ticket-api/config"
+)
+
+// QuickBooks pushes journal entries to QuickBooks Online
+type QuickBooks struct {
+	cfg    config.QuickBooks
+	client *http.Client
+}
+
+// NewQuickBooks creates a QuickBooks Online adapter
+func NewQuickBooks(cfg config.QuickBooks, client *http.Client) *QuickBooks {
+	return &QuickBooks{cfg: cfg, client: client}
+}
+
+// Name returns "quickbooks"
+func (q *QuickBooks) Name() string {
+	return "quickbooks"
+}
+
+type qbRef struct {
+	Value string `json:"value"`
+}
+
+type qbLineDetail struct {
+	PostingType string `json:"PostingType"`
+	AccountRef  qbRef  `json:"AccountRef"`
+}
+
+type qbLine struct {
+	DetailType             string       `json:"DetailType"`
+	Amount                 float64      `json:"Amount"`
+	Description            string       `json:"Description,omitempty"`
+	JournalEntryLineDetail qbLineDetail `json:"JournalEntryLineDetail"`
+}
+
+type qbJournalEntry struct {
+	DocNumber   string   `json:"DocNumber"`
+	TxnDate     string   `json:"TxnDate"`
+	PrivateNote string   `json:"PrivateNote,omitempty"`
+	CurrencyRef qbRef    `json:"CurrencyRef"`
+	Line        []qbLine `json:"Line"`
+}
+
+// Push creates a QuickBooks journal entry
+func (q *QuickBooks) Push(ctx context.Context, entry *JournalEntry) (string, error) {
+	body := qbJournalEntry{
+		DocNumber:   entry.ID,
+		TxnDate:     entry.Date.Format("2006-01-02"),
+		PrivateNote: entry.Memo,
+		CurrencyRef: qbRef{Value: entry.Currency},
+	}
+
+	for _, line := range entry.Lines {
+		account, err := q.account(line.Account)
+		if err != nil {
+			return "", err
+		}
+
+		// QuickBooks lines carry a positive amount and a posting type
+		postingType, amount := "Debit", line.Debit
+		if line.Credit != 0 {
+			postingType, amount = "Credit", line.Credit
+		}
+
+		body.Line = append(body.Line, qbLine{
+			DetailType:  "JournalEntryLineDetail",
+			Amount:      amount,
+			Description: line.Description,
+			JournalEntryLineDetail: qbLineDetail{
+				PostingType: postingType,
+				AccountRef:  qbRef{Value: account},
+			},
+		})
+	}
+
+	// requestid makes QuickBooks return the original entry when a push is retried
+	endpoint := fmt.Sprintf("%s/v3/company/%s/journalentry?requestid=%s",
+		strings.TrimRight(q.cfg.BaseURL, "/"), url.PathEscape(q.cfg.RealmID), url.QueryEscape(entry.ID))
+
+	var resp struct {
+		JournalEntry struct {
+			ID string `json:"Id"`
+		} `json:"JournalEntry"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + q.cfg.AccessToken}
+	if err := postJSON(ctx, q.client, endpoint, headers, body, &resp); err != nil {
+		return "", fmt.Errorf("quickbooks: %w", err)
+	}
+
+	return resp.JournalEntry.ID, nil
+}
+
+// account maps an account role to the configured QuickBooks account ID
+func (q *QuickBooks) account(account Account) (string, error) {
+	switch account {
+	case AccountCash:
+		return q.cfg.CashAccountID, nil
+	case AccountRevenue:
+		return q.cfg.RevenueAccountID, nil
+	default:
+		return "", fmt.Errorf("quickbooks: no account configured for %q", account)
+	}
+}
@@ -0,0 +1,137 @@
+// Package alerts posts operational alerts to the channel of a Slack or
+// Discord webhook, holding back repeats so an incident doesn't flood it
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+)
+
+// Poster posts alerts to a chat channel
+type Poster interface {
+	// Post posts the text of an alert
+	Post(ctx context.Context, text string) error
+}
+
+// NewPoster creates a Poster for the configured provider
+func NewPoster(cfg config.Alerts) Poster {
+	// Slack takes the message as text, Discord as content
+	field := "text"
+	if cfg.Provider == config.AlertProviderDiscord {
+		field = "content"
+	}
+
+	return &webhookPoster{url: cfg.WebhookURL, field: field, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+type webhookPoster struct {
+	url    string
+	field  string
+	client *http.Client
+}
+
+// Post sends the alert as a JSON message. Slack answers 200 OK and Discord
+// 204 No Content.
+func (p *webhookPoster) Post(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{p.field: text})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to post alert: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
+
+// Alerter posts alerts through a Poster, at most one per key within the
+// throttle. The alerts held back are counted in the next one posted. A nil
+// Alerter discards every alert.
+type Alerter struct {
+	poster   Poster
+	throttle time.Duration
+
+	mu sync.Mutex
+	// posted is when the last alert of each key was posted, held the number
+	// held back since
+	posted map[string]time.Time
+	held   map[string]int
+}
+
+// NewAlerter creates an Alerter posting through poster
+func NewAlerter(poster Poster, throttle time.Duration) *Alerter {
+	return &Alerter{
+		poster:   poster,
+		throttle: throttle,
+		posted:   make(map[string]time.Time),
+		held:     make(map[string]int),
+	}
+}
+
+// Alert posts an alert unless one of the same key was posted within the
+// throttle, and reports whether it was posted. An alert that fails to post
+// doesn't hold back the next.
+func (a *Alerter) Alert(ctx context.Context, key, text string) (bool, error) {
+	if a == nil {
+		return false, nil
+	}
+
+	now := clock.Now()
+	a.mu.Lock()
+	if last, ok := a.posted[key]; ok && now.Sub(last) < a.throttle {
+		a.held[key]++
+		a.mu.Unlock()
+		return false, nil
+	}
+	held := a.held[key]
+	a.posted[key] = now
+	delete(a.held, key)
+	a.forget(now)
+	a.mu.Unlock()
+
+	if held > 0 {
+		text = fmt.Sprintf("%s (%d more held back since the last one)", text, held)
+	}
+	if err := a.poster.Post(ctx, text); err != nil {
+		a.mu.Lock()
+		delete(a.posted, key)
+		a.held[key] += held
+		a.mu.Unlock()
+		return false, err
+	}
+
+	return true, nil
+}
+
+// forget drops the keys whose throttle ran out without holding alerts back,
+// so keys naming concerts don't pile up. The mutex must be held.
+func (a *Alerter) forget(now time.Time) {
+	for key, last := range a.posted {
+		if now.Sub(last) >= a.throttle && a.held[key] == 0 {
+			delete(a.posted, key)
+		}
+	}
+}
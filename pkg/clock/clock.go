@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// OffsetClock is a Clock that runs at real speed, shifted by an adjustable offset
+type OffsetClock struct {
+	offset atomic.Int64
+}
+
+// Now returns the real time shifted by the clock's offset
+func (c *OffsetClock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+// Offset returns how far the clock is shifted from real time
+func (c *OffsetClock) Offset() time.Duration {
+	return time.Duration(c.offset.Load())
+}
+
+// Advance shifts the clock by d, which may be negative
+func (c *OffsetClock) Advance(d time.Duration) {
+	c.offset.Add(int64(d))
+}
+
+// Set shifts the clock so that it currently reads t
+func (c *OffsetClock) Set(t time.Time) {
+	c.offset.Store(int64(time.Until(t)))
+}
+
+// Reset returns the clock to real time
+func (c *OffsetClock) Reset() {
+	c.offset.Store(0)
+}
+
+// process is the clock used by all time-dependent business logic. It only
+// deviates from real time when shifted through the test clock admin API.
+var process = &OffsetClock{}
+
+// Process returns the process-wide clock
+func Process() *OffsetClock {
+	return process
+}
+
+// Now returns the current time of the process-wide clock. Business logic
+// (booking windows, token expiry, reporting) must use it instead of time.Now.
+func Now() time.Time {
+	return process.Now()
+}
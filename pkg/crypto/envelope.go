@@ -0,0 +1,240 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"concert-ticket-api/config"
+)
+
+// Prefixes of stored values. Values without one are treated as legacy
+// plaintext and returned unchanged by Decrypt.
+const (
+	// reservedPrefix starts every prefix below. Plaintext starting with it
+	// is escaped, so it can't be mistaken for a ciphertext.
+	reservedPrefix = "enc:"
+	// ciphertextPrefix marks values encrypted before ciphertexts were bound
+	// to their column and row. They are still decrypted.
+	ciphertextPrefix = "enc:v1:"
+	// boundCiphertextPrefix marks values encrypted with their column and
+	// row as additional data
+	boundCiphertextPrefix = "enc:v2:"
+	// escapePrefix marks plaintext that started with reservedPrefix
+	escapePrefix = "enc:raw:"
+)
+
+// Cipher encrypts and decrypts individual field values. Every value is
+// bound to the column and row it is stored in, e.g. "bookings.attendee_email"
+// and the booking reference, so a ciphertext copied into another column or
+// row doesn't decrypt.
+type Cipher interface {
+	// Encrypt encrypts a plaintext value of a column and row. Empty values
+	// are returned unchanged.
+	Encrypt(plaintext, column, row string) (string, error)
+
+	// Decrypt decrypts a value produced by Encrypt for the same column and row
+	Decrypt(ciphertext, column, row string) (string, error)
+}
+
+// envelopeCipher implements envelope encryption: every value is encrypted with
+// a fresh data key, and the data key is encrypted (wrapped) with a master key.
+type envelopeCipher struct {
+	activeKeyID string
+	masterKeys  map[string]cipher.AEAD
+}
+
+// NewEnvelopeCipher creates a Cipher that wraps data keys with the master key
+// named by activeKeyID. The remaining keys are only used to decrypt values
+// written before a key rotation.
+func NewEnvelopeCipher(activeKeyID string, keys map[string][]byte) (Cipher, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", activeKeyID)
+	}
+
+	masterKeys := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key id %q", id)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		masterKeys[id] = aead
+	}
+
+	return &envelopeCipher{
+		activeKeyID: activeKeyID,
+		masterKeys:  masterKeys,
+	}, nil
+}
+
+// NewCipher creates a Cipher from the encryption configuration. When no key is
+// configured a pass-through cipher is returned and values are stored in plaintext.
+func NewCipher(cfg config.Encryption) (Cipher, error) {
+	if cfg.Key == "" {
+		return plaintextCipher{}, nil
+	}
+
+	keys := make(map[string][]byte, len(cfg.RetiredKeys)+1)
+	for id, encoded := range cfg.RetiredKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encryption key %q: %w", cfg.KeyID, err)
+	}
+	keys[cfg.KeyID] = key
+
+	return NewEnvelopeCipher(cfg.KeyID, keys)
+}
+
+// Encrypt encrypts a plaintext value with a fresh data key
+func (c *envelopeCipher) Encrypt(plaintext, column, row string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealedData, err := seal(dataAEAD, []byte(plaintext), additionalData(column, row))
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(c.masterKeys[c.activeKeyID], dataKey, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return boundCiphertextPrefix + c.activeKeyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedData), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt
+func (c *envelopeCipher) Decrypt(ciphertext, column, row string) (string, error) {
+	var data []byte
+	switch {
+	case strings.HasPrefix(ciphertext, boundCiphertextPrefix):
+		data = additionalData(column, row)
+		ciphertext = strings.TrimPrefix(ciphertext, boundCiphertextPrefix)
+	case strings.HasPrefix(ciphertext, ciphertextPrefix):
+		ciphertext = strings.TrimPrefix(ciphertext, ciphertextPrefix)
+	default:
+		return unescape(ciphertext), nil
+	}
+
+	parts := strings.Split(ciphertext, ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed ciphertext")
+	}
+
+	masterAEAD, ok := c.masterKeys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key %q", parts[0])
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed wrapped key: %w", err)
+	}
+
+	sealedData, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	dataKey, err := open(masterAEAD, wrappedKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataAEAD, sealedData, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// plaintextCipher stores values unchanged, except that values starting with
+// reservedPrefix are escaped. It is used when encryption is not configured.
+type plaintextCipher struct{}
+
+func (plaintextCipher) Encrypt(plaintext, column, row string) (string, error) {
+	if strings.HasPrefix(plaintext, reservedPrefix) {
+		return escapePrefix + plaintext, nil
+	}
+	return plaintext, nil
+}
+
+func (plaintextCipher) Decrypt(ciphertext, column, row string) (string, error) {
+	if strings.HasPrefix(ciphertext, ciphertextPrefix) || strings.HasPrefix(ciphertext, boundCiphertextPrefix) {
+		return "", errors.New("value is encrypted but no encryption key is configured")
+	}
+	return unescape(ciphertext), nil
+}
+
+// unescape returns the plaintext of a value stored by plaintextCipher
+func unescape(value string) string {
+	return strings.TrimPrefix(value, escapePrefix)
+}
+
+// additionalData authenticates the column and row of a value. Column names
+// don't contain a NUL byte, so no two pairs give the same data.
+func additionalData(column, row string) []byte {
+	return []byte(column + "\x00" + row)
+}
+
+// newAEAD creates an AES-GCM AEAD from a 128, 192 or 256 bit key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data, authenticating additional, and prepends the random nonce
+func seal(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+// open decrypts data produced by seal with the same additional data
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additional)
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	circuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_circuit_open",
+		Help: "Whether the circuit breaker of a database pool is open (1) or closed (0).",
+	}, []string{"database"})
+	circuitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_circuit_rejections_total",
+		Help: "Database operations failed fast because the circuit breaker of their pool was open.",
+	}, []string{"database"})
+)
+
+// breaker fails the operations on a pool fast once the database stopped
+// answering, instead of letting requests queue for connections that hang.
+// After failure threshold consecutive failed operations it opens for the
+// open duration, then lets a single operation through to try the database
+// again: the circuit closes if it succeeds and opens again if it fails.
+type breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+// newBreaker creates the breaker of the pool on the database of cfg, or nil
+// when the breaker is disabled. A nil breaker lets every operation through.
+func newBreaker(cfg config.Database) *breaker {
+	if cfg.CircuitBreaker.FailureThreshold <= 0 {
+		return nil
+	}
+
+	name := cfg.Path
+	if cfg.Driver != config.DriverSQLite {
+		name = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	}
+	circuitOpen.WithLabelValues(name).Set(0)
+	return &breaker{name: name, threshold: cfg.CircuitBreaker.FailureThreshold, cooldown: cfg.CircuitBreaker.OpenDuration}
+}
+
+// allow returns an UnavailableError while the circuit is open. Once the open
+// duration passed it lets one operation through, which must be recorded.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return nil
+	}
+	remaining := b.cooldown - clock.Now().Sub(b.openedAt)
+	if remaining <= 0 && !b.probing {
+		b.probing = true
+		return nil
+	}
+
+	circuitRejections.WithLabelValues(b.name).Inc()
+	// Clients are asked to wait whole seconds, at least one
+	retry := max((remaining + time.Second - 1).Truncate(time.Second), time.Second)
+	return pkgErr.NewUnavailableError("database", retry)
+}
+
+// record records the outcome of an operation allow let through. ctx is the
+// caller's context: an operation it cancelled says nothing about the database.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The caller gave up, which says nothing about the database
+	case err != nil && isOutage(err):
+		b.failures++
+		if probe || b.failures >= b.threshold {
+			b.open = true
+			b.openedAt = clock.Now()
+			circuitOpen.WithLabelValues(b.name).Set(1)
+		}
+	default:
+		b.failures = 0
+		if b.open {
+			b.open = false
+			circuitOpen.WithLabelValues(b.name).Set(0)
+		}
+	}
+}
+
+// isOutage reports whether err means the database didn't answer, rather than
+// that it rejected the operation, like a constraint violation or a missing row
+func isOutage(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	// Connection exceptions, insufficient resources and operator
+	// interventions like admin_shutdown
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "53") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+// pkg/db/db.go
+package db
+
+import (
+	"fmt"
+
+	"concert-ticket-api/config"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Open connects to the database of the configured driver
+func Open(cfg config.Database) (*sqlx.DB, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return NewMySQLDB(cfg)
+	case config.DriverSQLite:
+		return NewSQLiteDB(cfg)
+	}
+	return NewPostgresDB(cfg)
+}
+
+// connect opens a pool on the database of cfg with its pool settings and
+// checks that the database is reachable
+func connect(cfg config.Database) (*sqlx.DB, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	configurePool(db, cfg)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// configurePool applies the pool settings of cfg to db
+func configurePool(db *sqlx.DB, cfg config.Database) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
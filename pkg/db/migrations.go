@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"concert-ticket-api/config"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+)
+
+// Migrations returns the migrations of the configured driver in migrations:
+// those of postgres are at the top, those of the other drivers in the
+// directory named after the driver
+func Migrations(cfg config.Database, migrations fs.FS) (fs.FS, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL, config.DriverSQLite:
+		return fs.Sub(migrations, cfg.Driver)
+	}
+	return migrations, nil
+}
+
+// LatestMigration returns the version of the last migration of the
+// configured driver in migrations, the number its file names start with
+func LatestMigration(cfg config.Database, migrations fs.FS) (uint, error) {
+	migrations, err := Migrations(cfg, migrations)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	return latestMigration(migrations)
+}
+
+func latestMigration(migrations fs.FS) (uint, error) {
+	entries, err := fs.ReadDir(migrations, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found")
+	}
+	return latest, nil
+}
+
+// Migrate applies the migrations of the configured driver that the database
+// doesn't have yet
+func Migrate(db *sqlx.DB, cfg config.Database, migrations fs.FS) error {
+	migrator, err := NewMigrator(db, cfg, migrations)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.Up()
+}
+
+// MigrationStatus is where the schema of a database stands
+type MigrationStatus struct {
+	// Version is the last migration applied, 0 for none
+	Version uint
+	// Dirty is set when migration Version failed halfway
+	Dirty bool
+	// Latest is the last migration there is
+	Latest uint
+}
+
+// Migrator applies and reverts the migrations of a database. Postgres and
+// MySQL are migrated on a connection of its own, without the statement
+// timeouts of the pool; SQLite on db, since an in-memory database only
+// exists on its connection.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+	latest uint
+	// own is set when the migrator opened its connection, which it closes
+	own bool
+}
+
+// NewMigrator creates the migrator of the database of cfg, with the
+// migrations of its driver in migrations
+func NewMigrator(db *sqlx.DB, cfg config.Database, migrations fs.FS) (*Migrator, error) {
+	migrations, err := Migrations(cfg, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	latest, err := latestMigration(migrations)
+	if err != nil {
+		return nil, err
+	}
+	files, err := iofs.New(migrations, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	if cfg.Driver == config.DriverSQLite {
+		driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create migrate driver: %w", err)
+		}
+		m, err := migrate.NewWithInstance("iofs", files, "sqlite3", driver)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+		}
+		return &Migrator{m: m, source: files, latest: latest}, nil
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", files, cfg.MigrationDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return &Migrator{m: m, source: files, latest: latest, own: true}, nil
+}
+
+// Up applies the migrations the database doesn't have yet
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations, or all of them when steps is 0
+func (m *Migrator) Down(steps int) error {
+	if _, _, err := m.m.Version(); errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+
+	var err error
+	if steps == 0 {
+		err = m.m.Down()
+	} else {
+		err = m.m.Steps(-steps)
+	}
+
+	var short migrate.ErrShortLimit
+	switch {
+	case errors.Is(err, migrate.ErrNoChange), errors.As(err, &short):
+		// Fewer migrations were applied than asked to revert, all of them
+		// are reverted now
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to revert migrations: %w", err)
+	}
+	return nil
+}
+
+// Status returns where the schema of the database stands
+func (m *Migrator) Status() (MigrationStatus, error) {
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	return MigrationStatus{Version: version, Dirty: dirty, Latest: m.latest}, nil
+}
+
+// Force records version as the last migration applied and clears the dirty
+// flag, without running anything, after a migration that failed halfway was
+// fixed by hand. -1 records that none is applied.
+func (m *Migrator) Force(version int) error {
+	if version < -1 || version > int(m.latest) {
+		return fmt.Errorf("version must be between -1 and %d", m.latest)
+	}
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Close closes the connection the migrator opened. The database it was
+// created on stays open.
+func (m *Migrator) Close() error {
+	// Closing the migrate instance closes its database too
+	if !m.own {
+		return m.source.Close()
+	}
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// CheckMigrations checks that the schema has every migration up to latest
+// applied and that none failed halfway, from the schema_migrations table
+// golang-migrate keeps
+func CheckMigrations(ctx context.Context, db *sqlx.DB, latest uint) error {
+	var applied struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	if err := db.GetContext(ctx, &applied, `SELECT version, dirty FROM schema_migrations LIMIT 1`); err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+
+	switch {
+	case applied.Dirty:
+		return fmt.Errorf("migration %d failed halfway and needs fixing by hand", applied.Version)
+	case applied.Version < int64(latest):
+		return fmt.Errorf("schema is at migration %d of %d", applied.Version, latest)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+// pkg/db/mysql.go
+package db
+
+import (
+	"concert-ticket-api/config"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewMySQLDB creates a new MySQL database connection. MySQL closes idle
+// connections after wait_timeout, 8 hours by default, so
+// database.conn_max_lifetime must stay below it.
+func NewMySQLDB(cfg config.Database) (*sqlx.DB, error) {
+	return connect(cfg)
+}
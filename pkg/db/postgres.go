@@ -2,37 +2,79 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"math"
 	"time"
 
 	"concert-ticket-api/config"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
 )
 
-// NewPostgresDB creates a new PostgreSQL database connection
+// NewPostgresDB creates a new PostgreSQL database connection. It runs on a
+// pgx pool behind database/sql, so the repositories keep scanning rows into
+// structs with sqlx. Each connection prepares a statement the first time it
+// runs it and keeps it in its statement cache.
 func NewPostgresDB(cfg config.Database) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", cfg.DSN())
+	return connect(cfg)
+}
+
+// poolConnector hands out the connections of a pgx pool, which it closes
+// with the database
+type poolConnector struct {
+	driver.Connector
+	pool *pgxpool.Pool
+}
+
+func (c poolConnector) Close() error {
+	c.pool.Close()
+	return nil
+}
+
+// newPostgresConnector creates the pgx pool of cfg. database/sql manages
+// the connections it takes from the pool, so the pool only limits them to
+// database.max_open_conns and closes none of them itself.
+func newPostgresConnector(cfg config.Database) (driver.Connector, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(100)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	poolConfig.MaxConns = math.MaxInt32
+	if cfg.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	poolConfig.MaxConnLifetime = math.MaxInt64
+	poolConfig.MaxConnIdleTime = math.MaxInt64
+
+	if cfg.PasswordSource != nil {
+		poolConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			password, err := cfg.PasswordSource(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get the database password: %w", err)
+			}
+			connConfig.Password = password
+			return nil
+		}
+	}
 
-	// Test connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	poolConfig.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	if cfg.StatementCacheCapacity == 0 {
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
 	}
 
-	return db, nil
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	return poolConnector{Connector: stdlib.GetPoolConnector(pool), pool: pool}, nil
 }
 
 // WaitForDatabase waits for the database to be available with a timeout
@@ -40,7 +82,7 @@ func WaitForDatabase(cfg config.Database, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 
 	for time.Now().Before(deadline) {
-		db, err := sql.Open("postgres", cfg.DSN())
+		db, err := sql.Open(cfg.DriverName(), cfg.DSN())
 		if err == nil {
 			err = db.Ping()
 			db.Close()
@@ -58,21 +100,6 @@ func WaitForDatabase(cfg config.Database, timeout time.Duration) error {
 	return fmt.Errorf("timeout waiting for database after %s", timeout)
 }
 
-// RunMigrations runs database migrations
-func RunMigrations(cfg config.Database, migrationsPath string) error {
-	migrationDSN := cfg.MigrationDSN()
-	m, err := migrate.New(fmt.Sprintf("file://%s", migrationsPath), migrationDSN)
-	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-
-	return nil
-}
-
 // CreateDatabase creates the database if it doesn't exist
 // This is useful for development and test environments
 func CreateDatabase(cfg config.Database) error {
@@ -81,7 +108,7 @@ func CreateDatabase(cfg config.Database) error {
 	tempCfg.Name = "postgres"
 
 	// Connect to the 'postgres' database
-	db, err := sqlx.Connect("postgres", tempCfg.DSN())
+	db, err := sqlx.Connect(tempCfg.DriverName(), tempCfg.DSN())
 	if err != nil {
 		return fmt.Errorf("failed to connect to postgres database: %w", err)
 	}
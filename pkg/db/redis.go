@@ -0,0 +1,30 @@
+// pkg/db/redis.go
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient creates a new Redis client and checks the connection
+func NewRedisClient(cfg config.Redis) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return client, nil
+}
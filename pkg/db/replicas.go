@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"concert-ticket-api/config"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Replica is a read replica of the primary database
+type Replica struct {
+	// Name is the health component of the replica
+	Name string
+	DB   *sqlx.DB
+}
+
+// Replicas splits the reads that may lag behind writes across the read
+// replicas of the primary database. Replicas that aren't healthy are
+// skipped, and while none is healthy the reads go to the primary.
+type Replicas struct {
+	primary  *sqlx.DB
+	replicas []Replica
+	healthy  func(name string) bool
+	next     atomic.Uint64
+}
+
+// NewReplicas creates the reads of primary for its replicas. healthy
+// reports whether the replica with a name is healthy, e.g. Registry.Healthy
+// of pkg/health.
+func NewReplicas(primary *sqlx.DB, replicas []Replica, healthy func(name string) bool) *Replicas {
+	return &Replicas{
+		primary:  primary,
+		replicas: replicas,
+		healthy:  healthy,
+	}
+}
+
+// Reader returns the database of the next read: the healthy replicas take
+// turns, and the primary takes the reads none of them can
+func (r *Replicas) Reader() *sqlx.DB {
+	n := len(r.replicas)
+	if n == 0 {
+		return r.primary
+	}
+
+	start := int(r.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		replica := r.replicas[(start+i)%n]
+		if r.healthy(replica.Name) {
+			return replica.DB
+		}
+	}
+	return r.primary
+}
+
+// OpenPostgresReplica opens the pool of a postgres read replica. Unlike
+// NewPostgresDB it doesn't ping it, so a replica that is down doesn't stop
+// the service from starting; once its health checks fail, reads go elsewhere.
+func OpenPostgresReplica(cfg config.Database) (*sqlx.DB, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica %s:%d: %w", cfg.Host, cfg.Port, err)
+	}
+	configurePool(db, cfg)
+	return db, nil
+}
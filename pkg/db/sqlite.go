@@ -0,0 +1,33 @@
+// pkg/db/sqlite.go
+package db
+
+import (
+	"fmt"
+
+	"concert-ticket-api/config"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// NewSQLiteDB opens the SQLite database file of the configuration, creating
+// it if it doesn't exist
+func NewSQLiteDB(cfg config.Database) (*sqlx.DB, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite runs one write transaction at a time anyway. One connection
+	// makes them queue in the pool rather than on the busy timeout, and
+	// keeps an in-memory database, which only exists on its connection.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return db, nil
+}
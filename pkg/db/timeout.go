@@ -0,0 +1,375 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"concert-ticket-api/config"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// open opens a pool on the database of cfg without connecting. With a
+// query or exec timeout, every statement on the pool runs with a context
+// that has the timeout as its deadline, in transactions too, and the
+// deadline of a query lasts until its rows are closed. Transactions
+// themselves live as long as the caller's context. With a circuit breaker,
+// the operations fail fast while the database doesn't answer.
+func open(cfg config.Database) (*sqlx.DB, error) {
+	connector, err := newConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &guard{connect: cfg.Timeouts.Connect, query: cfg.QueryTimeout(), exec: cfg.ExecTimeout(), breaker: newBreaker(cfg)}
+	if g.connect > 0 || g.query > 0 || g.exec > 0 || g.breaker != nil {
+		connector = guardedConnector{Connector: connector, guard: g}
+	}
+	return sqlx.NewDb(sql.OpenDB(connector), cfg.DriverName()), nil
+}
+
+// newConnector returns the connector of the database of cfg: a pgx pool for
+// postgres, the registered driver for the others
+func newConnector(cfg config.Database) (driver.Connector, error) {
+	if cfg.Driver != config.DriverMySQL && cfg.Driver != config.DriverSQLite {
+		return newPostgresConnector(cfg)
+	}
+
+	// The registered driver is only reachable through a pool of its own
+	probe, err := sql.Open(cfg.DriverName(), cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	d := probe.Driver()
+	probe.Close()
+
+	if cfg.PasswordSource != nil {
+		return passwordConnector{cfg: cfg, driver: d}, nil
+	}
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(cfg.DSN())
+	}
+	return dsnConnector{dsn: cfg.DSN(), driver: d}, nil
+}
+
+// dsnConnector connects drivers that don't have connectors of their own
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// passwordConnector connects with the password of the source of cfg at the
+// time, for passwords that rotate
+type passwordConnector struct {
+	cfg    config.Database
+	driver driver.Driver
+}
+
+func (c passwordConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	password, err := c.cfg.PasswordSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the database password: %w", err)
+	}
+	cfg := c.cfg
+	cfg.Password = password
+	return c.driver.Open(cfg.DSN())
+}
+
+func (c passwordConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// guard holds the deadlines of the operations on a pool and its circuit
+// breaker
+type guard struct {
+	connect, query, exec time.Duration
+	breaker              *breaker
+}
+
+// withTimeout returns ctx with the deadline of an operation, if it has one
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// done records the outcome of an operation that ran with opCtx, derived from
+// the caller's ctx, with the breaker. An operation its own deadline ended
+// timed out, whatever error the driver reports for it.
+func (g *guard) done(ctx, opCtx context.Context, err error) {
+	if err != nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		err = context.DeadlineExceeded
+	}
+	g.breaker.record(ctx, err)
+}
+
+// guardedConnector hands out connections whose operations are guarded
+type guardedConnector struct {
+	driver.Connector
+	guard *guard
+}
+
+func (c guardedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+	opCtx, cancel := withTimeout(ctx, c.guard.connect)
+	defer cancel()
+
+	conn, err := c.Connector.Connect(opCtx)
+	c.guard.done(ctx, opCtx, err)
+	if err != nil {
+		return nil, err
+	}
+	return &guardedConn{Conn: conn, guard: c.guard}, nil
+}
+
+// Close closes the connector it wraps, if it has to be
+func (c guardedConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// guardedConn runs the statements of a connection with their timeouts,
+// through the breaker. The optional interfaces of the driver's connection
+// are passed on; those it lacks return driver.ErrSkip, so database/sql
+// falls back like it would without the wrapper.
+type guardedConn struct {
+	driver.Conn
+	guard *guard
+}
+
+func (c *guardedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	opCtx, cancel := withTimeout(ctx, c.guard.exec)
+	defer cancel()
+	result, err := execer.ExecContext(opCtx, query, args)
+	c.guard.done(ctx, opCtx, err)
+	return result, err
+}
+
+func (c *guardedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	opCtx, cancel := withTimeout(ctx, c.guard.query)
+	rows, err := queryer.QueryContext(opCtx, query, args)
+	if err != nil {
+		c.guard.done(ctx, opCtx, err)
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel, guard: c.guard, ctx: ctx, opCtx: opCtx}, nil
+}
+
+func (c *guardedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	c.guard.done(ctx, ctx, err)
+	if err != nil {
+		return nil, err
+	}
+	return &guardedStmt{Stmt: stmt, guard: c.guard}, nil
+}
+
+// BeginTx begins a transaction with the caller's context, which decides
+// how long the transaction may take
+func (c *guardedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	c.guard.done(ctx, ctx, err)
+	return tx, err
+}
+
+// Ping goes through the breaker too, so the health checks report the
+// database down while the circuit is open and try it once it may close
+func (c *guardedConn) Ping(ctx context.Context) error {
+	if err := c.guard.breaker.allow(); err != nil {
+		return err
+	}
+
+	var err error
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		err = pinger.Ping(ctx)
+	}
+	c.guard.done(ctx, ctx, err)
+	return err
+}
+
+func (c *guardedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *guardedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *guardedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// guardedStmt runs a prepared statement with its timeout, through the breaker
+type guardedStmt struct {
+	driver.Stmt
+	guard *guard
+}
+
+func (s *guardedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+	opCtx, cancel := withTimeout(ctx, s.guard.exec)
+	defer cancel()
+
+	var (
+		result driver.Result
+		err    error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(opCtx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	s.guard.done(ctx, opCtx, err)
+	return result, err
+}
+
+func (s *guardedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.guard.breaker.allow(); err != nil {
+		return nil, err
+	}
+	opCtx, cancel := withTimeout(ctx, s.guard.query)
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(opCtx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	if err != nil {
+		s.guard.done(ctx, opCtx, err)
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel, guard: s.guard, ctx: ctx, opCtx: opCtx}, nil
+}
+
+func (s *guardedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// timeoutRows ends the deadline of a query when its rows are closed. Drivers
+// may only run the query while the rows are read, so its outcome is recorded
+// with the first error reading them, or once they are closed.
+type timeoutRows struct {
+	driver.Rows
+	cancel   context.CancelFunc
+	guard    *guard
+	ctx      context.Context
+	opCtx    context.Context
+	recorded bool
+}
+
+func (r *timeoutRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil && err != io.EOF && !r.recorded {
+		r.recorded = true
+		r.guard.done(r.ctx, r.opCtx, err)
+	}
+	return err
+}
+
+func (r *timeoutRows) Close() error {
+	defer r.cancel()
+	err := r.Rows.Close()
+	if !r.recorded {
+		r.recorded = true
+		r.guard.done(r.ctx, r.opCtx, err)
+	}
+	return err
+}
+
+// namedValues returns the values of positional arguments, for drivers that
+// don't take named ones
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("the database driver doesn't support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Common errors
@@ -16,8 +17,45 @@ var (
 	ErrInsufficientTickets     = errors.New("insufficient tickets")
 	ErrBookingClosed           = errors.New("booking is closed")
 	ErrBookingAlreadyCancelled = errors.New("booking is already cancelled")
+	ErrOrderAlreadyCancelled   = errors.New("order is already cancelled")
+	ErrBookingTokenRequired    = errors.New("booking token required")
+	ErrInvalidBookingToken     = errors.New("invalid booking token")
+	ErrRateLimited             = errors.New("rate limit exceeded")
+	ErrInviteRedeemed          = errors.New("invite already redeemed")
+	ErrIdempotencyKeyInUse     = errors.New("idempotency key in use")
+	ErrPaymentUnavailable      = errors.New("payment provider unavailable")
+	ErrServiceUnavailable      = errors.New("service unavailable")
 )
 
+// UnavailableError fails a request fast because a dependency it needs is
+// known to be down, rather than letting it wait for the dependency
+type UnavailableError struct {
+	// Dependency names what is down, e.g. the database
+	Dependency string
+	// Retry is how long the dependency is given before it is tried again
+	Retry time.Duration
+}
+
+// NewUnavailableError creates an error for a dependency that won't be
+// tried again for retry
+func NewUnavailableError(dependency string, retry time.Duration) *UnavailableError {
+	return &UnavailableError{Dependency: dependency, Retry: retry}
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Dependency + " unavailable"
+}
+
+// Unwrap returns ErrServiceUnavailable
+func (e *UnavailableError) Unwrap() error {
+	return ErrServiceUnavailable
+}
+
+// RetryAfter returns how long clients should wait before retrying
+func (e *UnavailableError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
 // ErrorWithMessage represents an error with a message
 type ErrorWithMessage struct {
 	err     error
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Public codes identify errors to clients over every transport: REST reports
+// them as the problem code, gRPC as the ErrorInfo reason. Clients may branch
+// on them, so existing codes must never be renamed.
+const (
+	CodeInvalidInput            = "INVALID_INPUT"
+	CodeNotFound                = "NOT_FOUND"
+	CodeBookingClosed           = "BOOKING_CLOSED"
+	CodeInsufficientTickets     = "INSUFFICIENT_TICKETS"
+	CodeBookingConflict         = "BOOKING_CONFLICT"
+	CodeBookingAlreadyCancelled = "BOOKING_ALREADY_CANCELLED"
+	CodeOrderAlreadyCancelled   = "ORDER_ALREADY_CANCELLED"
+	CodeBookingTokenRequired    = "BOOKING_TOKEN_REQUIRED"
+	CodeInvalidBookingToken     = "INVALID_BOOKING_TOKEN"
+	CodeInviteRedeemed          = "INVITE_REDEEMED"
+	CodeIdempotencyKeyInUse     = "IDEMPOTENCY_KEY_IN_USE"
+	CodePaymentUnavailable      = "PAYMENT_UNAVAILABLE"
+	CodeServiceUnavailable      = "SERVICE_UNAVAILABLE"
+	CodePreconditionFailed      = "PRECONDITION_FAILED"
+	CodeForbidden               = "FORBIDDEN"
+	CodeRateLimited             = "RATE_LIMITED"
+	CodeInternal                = "INTERNAL_ERROR"
+)
+
+// Clients may retry conflicts, rate-limited requests and payments after
+// these delays
+const (
+	conflictRetryDelay    = 100 * time.Millisecond
+	rateLimitedRetryDelay = time.Second
+	paymentRetryDelay     = 5 * time.Second
+	unavailableRetryDelay = 5 * time.Second
+)
+
+// Kind declares how an error is reported to clients
+type Kind struct {
+	Err        error
+	Code       string
+	HTTPStatus int
+	GRPCCode   codes.Code
+	// Message is reported for errors that carry no message of their own
+	Message string
+	// Precondition names the precondition a FailedPrecondition error
+	// violates, if any
+	Precondition string
+	// RetryAfter is how long clients should wait before retrying, if the
+	// request may be retried unchanged
+	RetryAfter time.Duration
+}
+
+// registry holds the kind of every error in this package, in match order.
+// Errors with a message match their wrapped sentinel first, so invalid input
+// comes after the sentinels.
+var registry = []Kind{
+	{Err: ErrNotFound, Code: CodeNotFound, HTTPStatus: http.StatusNotFound, GRPCCode: codes.NotFound,
+		Message: "Resource not found"},
+	{Err: ErrBookingClosed, Code: CodeBookingClosed, HTTPStatus: http.StatusBadRequest, GRPCCode: codes.FailedPrecondition,
+		Message: "Booking is not open for this concert", Precondition: "BOOKING_WINDOW"},
+	{Err: ErrBookingAlreadyCancelled, Code: CodeBookingAlreadyCancelled, HTTPStatus: http.StatusBadRequest, GRPCCode: codes.FailedPrecondition,
+		Message: "Booking is already cancelled", Precondition: "BOOKING_STATUS"},
+	{Err: ErrOrderAlreadyCancelled, Code: CodeOrderAlreadyCancelled, HTTPStatus: http.StatusBadRequest, GRPCCode: codes.FailedPrecondition,
+		Message: "Order is already cancelled", Precondition: "ORDER_STATUS"},
+	{Err: ErrInviteRedeemed, Code: CodeInviteRedeemed, HTTPStatus: http.StatusConflict, GRPCCode: codes.FailedPrecondition,
+		Message: "This invite has already been used to book", Precondition: "INVITE"},
+	{Err: ErrInsufficientTickets, Code: CodeInsufficientTickets, HTTPStatus: http.StatusBadRequest, GRPCCode: codes.ResourceExhausted,
+		Message: "Not enough tickets available"},
+	{Err: ErrRateLimited, Code: CodeRateLimited, HTTPStatus: http.StatusTooManyRequests, GRPCCode: codes.ResourceExhausted,
+		Message: "Too many requests, please try again", RetryAfter: rateLimitedRetryDelay},
+	{Err: ErrOptimisticLockFailed, Code: CodeBookingConflict, HTTPStatus: http.StatusConflict, GRPCCode: codes.Aborted,
+		Message: "Booking conflict, please try again", RetryAfter: conflictRetryDelay},
+	{Err: ErrIdempotencyKeyInUse, Code: CodeIdempotencyKeyInUse, HTTPStatus: http.StatusConflict, GRPCCode: codes.Aborted,
+		Message: "A request with this idempotency key is in progress, please try again", RetryAfter: conflictRetryDelay},
+	{Err: ErrPaymentUnavailable, Code: CodePaymentUnavailable, HTTPStatus: http.StatusServiceUnavailable, GRPCCode: codes.Unavailable,
+		Message: "Payments can't be taken right now, please try again", RetryAfter: paymentRetryDelay},
+	{Err: ErrServiceUnavailable, Code: CodeServiceUnavailable, HTTPStatus: http.StatusServiceUnavailable, GRPCCode: codes.Unavailable,
+		Message: "Service temporarily unavailable, please try again", RetryAfter: unavailableRetryDelay},
+	{Err: ErrUpdateFailed, Code: CodePreconditionFailed, HTTPStatus: http.StatusPreconditionFailed, GRPCCode: codes.Aborted,
+		Message: "The resource was modified concurrently, please try again", RetryAfter: conflictRetryDelay},
+	{Err: ErrBookingTokenRequired, Code: CodeBookingTokenRequired, HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied,
+		Message: "A booking token is required for this concert"},
+	{Err: ErrInvalidBookingToken, Code: CodeInvalidBookingToken, HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied,
+		Message: "Invalid or expired booking token"},
+	{Err: ErrUnauthorized, Code: CodeForbidden, HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied,
+		Message: "You are not authorized to perform this action"},
+	{Err: ErrForbidden, Code: CodeForbidden, HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied,
+		Message: "Forbidden"},
+	internalKind,
+	{Err: ErrInvalidInput(""), Code: CodeInvalidInput, HTTPStatus: http.StatusBadRequest, GRPCCode: codes.InvalidArgument,
+		Message: "Invalid input"},
+}
+
+// internalKind is also the kind of errors that aren't registered
+var internalKind = Kind{Err: ErrInternalServer, Code: CodeInternal, HTTPStatus: http.StatusInternalServerError,
+	GRPCCode: codes.Internal, Message: "Internal error"}
+
+// Kinds returns the registered kinds in match order
+func Kinds() []Kind {
+	return append([]Kind(nil), registry...)
+}
+
+// Lookup returns the kind of the first registered error that err matches.
+// Errors that match none are reported as ErrInternalServer, with ok false so
+// callers can log them. An error with a RetryAfter method, like
+// UnavailableError, replaces the retry delay of its kind.
+func Lookup(err error) (kind Kind, ok bool) {
+	for _, kind := range registry {
+		if errors.Is(err, kind.Err) {
+			var delayed interface{ RetryAfter() time.Duration }
+			if errors.As(err, &delayed) {
+				kind.RetryAfter = delayed.RetryAfter()
+			}
+			return kind, true
+		}
+	}
+	return internalKind, false
+}
+
+// MessageOf returns the message clients are shown for err: its own message if
+// it has one, otherwise that of its kind. Errors that aren't registered get
+// the fallback, so internals don't leak.
+func MessageOf(err error, fallback string) string {
+	kind, ok := Lookup(err)
+	if !ok {
+		return fallback
+	}
+	var errWithMsg *ErrorWithMessage
+	if errors.As(err, &errWithMsg) && errWithMsg.Message() != "" {
+		return errWithMsg.Message()
+	}
+	return kind.Message
+}
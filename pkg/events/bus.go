@@ -0,0 +1,152 @@
+// Package events is an in-process publish/subscribe bus for domain events,
+// and the publishers that relay them to message brokers.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var droppedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "events_dropped_total",
+	Help: "Events discarded because a subscriber didn't keep up.",
+}, []string{"topic"})
+
+// Event is something that happened to an entity
+type Event struct {
+	// Topic names the kind of event
+	Topic string
+	// Key identifies the entity the event is about, such as a concert ID
+	Key int64
+	// Payload carries the event data
+	Payload interface{}
+	// At is when the event happened
+	At time.Time
+}
+
+// Bus fans events out to handlers and subscribers. Handlers run before
+// Publish returns, so the publisher can rely on what they do. Publishing
+// never waits for subscribers: a subscriber whose buffer is full loses its
+// oldest event, so it always sees the latest state. A nil Bus discards
+// everything published to it.
+type Bus struct {
+	mu       sync.RWMutex
+	subs     map[*Subscription]struct{}
+	handlers map[string][]Handler
+}
+
+// Handler reacts to an event in the goroutine that published it
+type Handler func(event Event)
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{
+		subs:     make(map[*Subscription]struct{}),
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Handle calls handler with every event of a topic, whatever its key. The
+// handlers of a topic run one after another in the order they were added,
+// before the event is delivered to subscriptions, so they should be quick.
+// They may publish events of their own.
+func (b *Bus) Handle(topic string, handler Handler) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+	b.mu.Unlock()
+}
+
+// Subscription receives the events of one topic
+type Subscription struct {
+	bus    *Bus
+	topic  string
+	key    int64
+	events chan Event
+	once   sync.Once
+}
+
+// Subscribe receives the events of a topic, only those about key unless key
+// is zero. Up to buffer events wait for the subscriber.
+func (b *Bus) Subscribe(topic string, key int64, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 1
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		topic:  topic,
+		key:    key,
+		events: make(chan Event, buffer),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish runs the handlers of an event and delivers it to the matching
+// subscribers
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	// The handlers run without the lock, so they can publish and subscribe
+	b.mu.RLock()
+	handlers := b.handlers[event.Topic]
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if sub.topic != event.Topic || (sub.key != 0 && sub.key != event.Key) {
+			continue
+		}
+		sub.deliver(event)
+	}
+}
+
+// deliver queues an event, making room by dropping the oldest one
+func (s *Subscription) deliver(event Event) {
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-s.events:
+			droppedEvents.WithLabelValues(s.topic).Inc()
+		default:
+		}
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.events)
+	})
+}
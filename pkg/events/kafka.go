@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"concert-ticket-api/config"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type kafkaPublisher struct {
+	writer  *kafka.Writer
+	brokers []string
+	prefix  string
+}
+
+// NewKafkaPublisher creates a publisher that writes each event to the topic
+// of its type, encoded with Encode. Events are partitioned by their key, so
+// the events of an aggregate stay in order, and Publish returns once every
+// in-sync replica has the event. Topics must exist unless the brokers create
+// them.
+func NewKafkaPublisher(cfg config.Kafka) Publisher {
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			WriteTimeout: cfg.WriteTimeout,
+			// Write each event as it comes instead of waiting to fill a batch
+			BatchSize: 1,
+			// The relay retries on its next run, after the later events of
+			// the aggregate are held back
+			MaxAttempts: 1,
+		},
+		brokers: cfg.Brokers,
+		prefix:  cfg.TopicPrefix,
+	}
+}
+
+func (p *kafkaPublisher) Name() string {
+	return config.BrokerKafka
+}
+
+// Publish writes the event to its topic
+func (p *kafkaPublisher) Publish(ctx context.Context, msg *Message) error {
+	value, err := Encode(msg)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: p.prefix + msg.Topic,
+		Key:   []byte(msg.Key),
+		Value: value,
+		Time:  msg.At,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(strconv.FormatInt(msg.ID, 10))},
+			{Key: "event-type", Value: []byte(msg.Topic)},
+			{Key: "content-type", Value: []byte(ContentTypeProtobuf)},
+		},
+	})
+}
+
+// Ping connects to the brokers until one answers. The writer picks the
+// leader of each partition itself, so one reachable broker is enough.
+func (p *kafkaPublisher) Ping(ctx context.Context) error {
+	var err error
+	for _, broker := range p.brokers {
+		var conn *kafka.Conn
+		if conn, err = kafka.DialContext(ctx, "tcp", broker); err == nil {
+			_, err = conn.Brokers()
+			conn.Close()
+			if err == nil {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no Kafka broker reachable: %w", err)
+}
+
+// Close flushes and closes the connections to the brokers
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"concert-ticket-api/config"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type natsPublisher struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	prefix  string
+	timeout time.Duration
+}
+
+// NewNATSPublisher creates a publisher that publishes each event to the
+// JetStream subject of its type, encoded with Encode. A stream must capture
+// the subjects. The event ID is the message ID, so JetStream drops the
+// events published again within the duplicate window of the stream. The
+// connection is retried in the background, so the server starts while NATS
+// is down and the events wait in the outbox.
+func NewNATSPublisher(cfg config.NATS) (Publisher, error) {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name("concert-ticket-api"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	return &natsPublisher{
+		conn:    conn,
+		js:      js,
+		prefix:  cfg.SubjectPrefix,
+		timeout: cfg.PublishTimeout,
+	}, nil
+}
+
+func (p *natsPublisher) Name() string {
+	return config.BrokerNATS
+}
+
+// Publish publishes the event and waits for the stream to store it
+func (p *natsPublisher) Publish(ctx context.Context, msg *Message) error {
+	value, err := Encode(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	id := strconv.FormatInt(msg.ID, 10)
+	natsMsg := nats.NewMsg(p.prefix + msg.Topic)
+	natsMsg.Data = value
+	natsMsg.Header.Set("event-id", id)
+	natsMsg.Header.Set("event-type", msg.Topic)
+	natsMsg.Header.Set("event-key", msg.Key)
+	natsMsg.Header.Set("content-type", ContentTypeProtobuf)
+
+	_, err = p.js.PublishMsg(ctx, natsMsg, jetstream.WithMsgID(id))
+	return err
+}
+
+// Ping waits for the server to answer a round trip on the connection
+func (p *natsPublisher) Ping(ctx context.Context) error {
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close flushes and closes the connection
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+)
+
+// Message is a domain event on its way to the message broker
+type Message struct {
+	// ID identifies the event, so consumers can drop redeliveries
+	ID int64
+	// Topic names the kind of event, such as booking.confirmed
+	Topic string
+	// Key identifies the aggregate the event is about. The messages of a key
+	// are published in order.
+	Key string
+	// Payload is the JSON encoding of the event
+	Payload []byte
+	// At is when the event happened
+	At time.Time
+}
+
+// Publisher publishes messages to a message broker
+type Publisher interface {
+	// Name identifies the broker in logs and errors
+	Name() string
+
+	// Publish returns once the broker has the message
+	Publish(ctx context.Context, msg *Message) error
+
+	// Ping checks that the broker is reachable, for readiness checks
+	Ping(ctx context.Context) error
+
+	// Close releases the connections to the broker
+	Close() error
+}
+
+// NewPublisher creates the publisher of the configured broker, or nil when
+// the events aren't published
+func NewPublisher(cfg config.Events, log logger.Logger) (Publisher, error) {
+	switch cfg.Broker {
+	case config.BrokerNone:
+		return nil, nil
+	case config.BrokerLog:
+		return &logPublisher{logger: log}, nil
+	case config.BrokerKafka:
+		return NewKafkaPublisher(cfg.Kafka), nil
+	case config.BrokerNATS:
+		return NewNATSPublisher(cfg.NATS)
+	}
+
+	return nil, fmt.Errorf("unknown events broker %q", cfg.Broker)
+}
+
+type logPublisher struct {
+	logger logger.Logger
+}
+
+func (p *logPublisher) Name() string {
+	return config.BrokerLog
+}
+
+// Publish logs the message instead of publishing it
+func (p *logPublisher) Publish(ctx context.Context, msg *Message) error {
+	p.logger.Info("Event %d published: topic=%s key=%s payload=%s", msg.ID, msg.Topic, msg.Key, msg.Payload)
+	return nil
+}
+
+// Ping always succeeds, the log is always there
+func (p *logPublisher) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *logPublisher) Close() error {
+	return nil
+}
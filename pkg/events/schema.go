@@ -0,0 +1,39 @@
+package events
+
+import (
+	"fmt"
+
+	eventspb "concert-ticket-api/api/events/proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeProtobuf is the content type of messages encoded with Encode
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// schemas maps the topics to the protobuf messages of api/events/proto their
+// events are encoded in
+var schemas = map[string]func() proto.Message{
+	"concert.created":   func() proto.Message { return &eventspb.ConcertEvent{} },
+	"concert.updated":   func() proto.Message { return &eventspb.ConcertEvent{} },
+	"booking.confirmed": func() proto.Message { return &eventspb.BookingEvent{} },
+	"booking.cancelled": func() proto.Message { return &eventspb.BookingEvent{} },
+}
+
+// Encode converts the JSON payload of a message to the protobuf schema of its
+// topic. The JSON fields are named like the fields of the schema; those the
+// schema doesn't have are dropped.
+func Encode(msg *Message) ([]byte, error) {
+	schema, ok := schemas[msg.Topic]
+	if !ok {
+		return nil, fmt.Errorf("no schema for topic %s", msg.Topic)
+	}
+
+	event := schema()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(msg.Payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode event %d: %w", msg.ID, err)
+	}
+
+	return proto.Marshal(event)
+}
@@ -0,0 +1,224 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Database is the name of the primary database component
+const Database = "database"
+
+// Replica returns the name of the nth read replica of the database, counting from 1
+func Replica(n int) string {
+	return fmt.Sprintf("database_replica_%d", n)
+}
+
+// Redis is the name of the Redis component, when one is configured
+const Redis = "redis"
+
+// Migrations is the name of the component checking that the database schema
+// has every migration applied
+const Migrations = "migrations"
+
+// Broker is the name of the message broker the domain events are published
+// to, when one is configured
+const Broker = "broker"
+
+// Check reports whether a component is usable
+type Check func(ctx context.Context) error
+
+// Status is the last known state of a component
+type Status struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Required components must be healthy for the service to be ready
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+	// Since is when the component entered its current state
+	Since     time.Time `json:"since"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Registry periodically checks the components the service depends on, so
+// request paths can react to outages without waiting for their own timeouts
+type Registry struct {
+	mutex    sync.RWMutex
+	checks   map[string]Check
+	statuses map[string]*Status
+	timeout  time.Duration
+	// failureThreshold is the number of consecutive failed checks before a
+	// component is reported unhealthy, so a single slow check doesn't flap it
+	failureThreshold int
+	failures         map[string]int
+	// draining is set once the service is shutting down
+	draining bool
+}
+
+// NewRegistry creates a registry whose checks time out after timeout and
+// that reports a component unhealthy after failureThreshold consecutive failures
+func NewRegistry(timeout time.Duration, failureThreshold int) *Registry {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	return &Registry{
+		checks:           make(map[string]Check),
+		statuses:         make(map[string]*Status),
+		failures:         make(map[string]int),
+		timeout:          timeout,
+		failureThreshold: failureThreshold,
+	}
+}
+
+// Register adds the check of a component the service isn't ready without.
+// Components are healthy until a check fails.
+func (r *Registry) Register(name string, check Check) {
+	r.register(name, check, true)
+}
+
+// RegisterOptional adds the check of a component the service can do
+// without, like a read replica whose reads fall back to the primary
+func (r *Registry) RegisterOptional(name string, check Check) {
+	r.register(name, check, false)
+}
+
+func (r *Registry) register(name string, check Check, required bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	r.checks[name] = check
+	r.statuses[name] = &Status{Name: name, Healthy: true, Required: required, Since: now}
+}
+
+// Run checks every component at the given interval until ctx is done
+func (r *Registry) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckNow(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckNow runs every component check once
+func (r *Registry) CheckNow(ctx context.Context) {
+	r.mutex.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mutex.RUnlock()
+
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		err := check(checkCtx)
+		cancel()
+		r.Report(name, err)
+	}
+}
+
+// Report records the outcome of a check of a component
+func (r *Registry) Report(name string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status, ok := r.statuses[name]
+	if !ok {
+		status = &Status{Name: name, Healthy: true, Since: time.Now()}
+		r.statuses[name] = status
+	}
+
+	now := time.Now()
+	status.CheckedAt = now
+
+	if err == nil {
+		r.failures[name] = 0
+		status.Error = ""
+		if !status.Healthy {
+			status.Healthy = true
+			status.Since = now
+		}
+		return
+	}
+
+	r.failures[name]++
+	status.Error = err.Error()
+	if status.Healthy && r.failures[name] >= r.failureThreshold {
+		status.Healthy = false
+		status.Since = now
+	}
+}
+
+// Healthy reports whether a component is healthy. Unknown components are healthy.
+func (r *Registry) Healthy(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	status, ok := r.statuses[name]
+	return !ok || status.Healthy
+}
+
+// Statuses returns the state of every component, sorted by name
+func (r *Registry) Statuses() []Status {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	statuses := make([]Status, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		statuses = append(statuses, *status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// AllHealthy reports whether every component is healthy
+func (r *Registry) AllHealthy() bool {
+	for _, status := range r.Statuses() {
+		if !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Drain marks the service as shutting down. It stops being ready, so load
+// balancers send new traffic to the other instances.
+func (r *Registry) Drain() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.draining = true
+}
+
+// Draining reports whether the service is shutting down
+func (r *Registry) Draining() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.draining
+}
+
+// Ready reports whether every required component is healthy and the service
+// isn't shutting down, so it can take traffic
+func (r *Registry) Ready() bool {
+	if r.Draining() {
+		return false
+	}
+	for _, status := range r.Statuses() {
+		if status.Required && !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
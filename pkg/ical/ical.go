@@ -0,0 +1,134 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar apps can
+// subscribe to
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of iCalendar feeds
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line RFC 5545 allows before folding
+const maxLineOctets = 75
+
+// Calendar is a feed of events
+type Calendar struct {
+	// ProdID identifies the product that generated the feed
+	ProdID string
+	// Name is shown by calendar apps for subscribed feeds
+	Name string
+	// RefreshInterval suggests how often subscribers poll the feed
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a calendar entry. Times are written in UTC.
+type Event struct {
+	// UID identifies the event across feed refreshes
+	UID          string
+	Start        time.Time
+	Duration     time.Duration
+	Summary      string
+	Location     string
+	Description  string
+	LastModified time.Time
+}
+
+// Encode returns the feed, stamped with the given time
+func (c *Calendar) Encode(stamp time.Time) []byte {
+	var w writer
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + text(c.ProdID))
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME:" + text(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		w.line("REFRESH-INTERVAL;VALUE=DURATION:" + duration(c.RefreshInterval))
+		w.line("X-PUBLISHED-TTL:" + duration(c.RefreshInterval))
+	}
+
+	for _, event := range c.Events {
+		w.line("BEGIN:VEVENT")
+		w.line("UID:" + text(event.UID))
+		w.line("DTSTAMP:" + utc(stamp))
+		w.line("DTSTART:" + utc(event.Start))
+		if event.Duration > 0 {
+			w.line("DURATION:" + duration(event.Duration))
+		}
+		w.line("SUMMARY:" + text(event.Summary))
+		if event.Location != "" {
+			w.line("LOCATION:" + text(event.Location))
+		}
+		if event.Description != "" {
+			w.line("DESCRIPTION:" + text(event.Description))
+		}
+		if !event.LastModified.IsZero() {
+			w.line("LAST-MODIFIED:" + utc(event.LastModified))
+		}
+		w.line("END:VEVENT")
+	}
+
+	w.line("END:VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// writer folds content lines and ends them with CRLF
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes a content line, folding it after 75 octets without splitting
+// a UTF-8 sequence. Continuation lines start with a space, which counts
+// towards their length.
+func (w *writer) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// text escapes a TEXT value
+func text(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// utc formats a DATE-TIME value in UTC
+func utc(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// duration formats a DURATION value in whole seconds
+func duration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := seconds / 3600; h > 0 {
+		b.WriteString(strconv.FormatInt(h, 10) + "H")
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		b.WriteString(strconv.FormatInt(m, 10) + "M")
+	}
+	if s := seconds % 60; s > 0 || seconds == 0 {
+		b.WriteString(strconv.FormatInt(s, 10) + "S")
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+// Package lifecycle runs the servers and workers of a process and shuts them
+// down in order. A process serves until it is told to stop or one of its
+// servers fails, then runs its shutdown steps one after the other within a
+// deadline, so in-flight work finishes before the resources it uses go away.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"concert-ticket-api/pkg/logger"
+)
+
+// Run serves until the server it runs is stopped by a shutdown step
+type Run func() error
+
+// Step is a shutdown step. It should return once its part of the process has
+// stopped, or when ctx is done.
+type Step func(ctx context.Context) error
+
+type step struct {
+	name string
+	stop Step
+}
+
+// Manager runs the servers of a process and shuts them down in order
+type Manager struct {
+	logger  logger.Logger
+	timeout time.Duration
+
+	mutex    sync.Mutex
+	steps    []step
+	stopping bool
+	failed   error
+
+	running sync.WaitGroup
+	// failures receives the first error of a server failing before shutdown
+	failures chan error
+}
+
+// New creates a manager whose shutdown steps must finish within timeout
+func New(logger logger.Logger, timeout time.Duration) *Manager {
+	return &Manager{
+		logger:   logger,
+		timeout:  timeout,
+		failures: make(chan error, 1),
+	}
+}
+
+// Go runs a server in its own goroutine. A server returning before shutdown
+// began, with or without an error, shuts the process down; once shutdown
+// began, returning is how it reports having stopped.
+func (m *Manager) Go(name string, run Run) {
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+
+		err := run()
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if m.stopping {
+			if err != nil {
+				m.logger.Error("%s stopped with an error: %v", name, err)
+			}
+			return
+		}
+		if err == nil {
+			err = errors.New("stopped unexpectedly")
+		}
+		if m.failed == nil {
+			m.failed = fmt.Errorf("%s: %w", name, err)
+			m.failures <- m.failed
+		}
+	}()
+}
+
+// OnShutdown adds a shutdown step. Steps run in the order they were added,
+// each one after the previous one returned.
+func (m *Manager) OnShutdown(name string, stop Step) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.steps = append(m.steps, step{name: name, stop: stop})
+}
+
+// Wait serves until ctx is done or a server fails, then shuts down. It
+// returns the error of the failed server, or else the first error of a
+// shutdown step, including its deadline passing.
+func (m *Manager) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		m.logger.Info("Shutting down")
+	case err := <-m.failures:
+		m.logger.Error("Shutting down, %v", err)
+	}
+
+	m.mutex.Lock()
+	m.stopping = true
+	failed := m.failed
+	steps := m.steps
+	m.mutex.Unlock()
+
+	return errors.Join(failed, m.shutdown(steps))
+}
+
+// shutdown runs the steps and waits for the servers to return
+func (m *Manager) shutdown(steps []step) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var first error
+	for _, s := range steps {
+		started := time.Now()
+		if err := s.stop(ctx); err != nil {
+			m.logger.Error("Shutdown step %s failed: %v", s.name, err)
+			if first == nil {
+				first = fmt.Errorf("%s: %w", s.name, err)
+			}
+			continue
+		}
+		m.logger.Debug("Shutdown step %s done in %s", s.name, time.Since(started).Round(time.Millisecond))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		if first == nil {
+			first = fmt.Errorf("servers still running after %s: %w", m.timeout, ctx.Err())
+		}
+	}
+	return first
+}
@@ -0,0 +1,530 @@
+// Package loadgen drives a live deployment through the Go client with virtual
+// users that browse, book and cancel, for capacity tests of the whole stack
+// rather than of the services over mocks. The same run works over REST and
+// gRPC since both transports implement the client's interfaces.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/client"
+
+	"google.golang.org/grpc/codes"
+)
+
+// The operations a run reports on
+const (
+	OpListConcerts = "list_concerts"
+	OpGetConcert   = "get_concert"
+	OpBook         = "book"
+	OpCancel       = "cancel"
+)
+
+var operations = []string{OpListConcerts, OpGetConcert, OpBook, OpCancel}
+
+// browsePage is how many concerts a browsing user looks at, within one page
+// at the server's default page size
+const browsePage = 20
+
+// maxPool caps the bookings kept for cancelling
+const maxPool = 10000
+
+// Mix weighs the scenarios a virtual user picks from for each iteration.
+// Browsing lists concerts and opens one, booking books tickets of a concert
+// and cancelling cancels a booking made earlier in the run.
+type Mix struct {
+	Browse int `json:"browse"`
+	Book   int `json:"book"`
+	Cancel int `json:"cancel"`
+}
+
+// ParseMix parses a mix such as "browse=70,book=25,cancel=5"; scenarios left
+// out weigh 0
+func ParseMix(s string) (Mix, error) {
+	var mix Mix
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		weight, err := strconv.Atoi(value)
+		if !ok || err != nil || weight < 0 {
+			return Mix{}, fmt.Errorf("invalid scenario weight %q, want name=weight", part)
+		}
+		switch name {
+		case "browse":
+			mix.Browse = weight
+		case "book":
+			mix.Book = weight
+		case "cancel":
+			mix.Cancel = weight
+		default:
+			return Mix{}, fmt.Errorf("unknown scenario %q, want browse, book or cancel", name)
+		}
+	}
+	if mix.Browse+mix.Book+mix.Cancel == 0 {
+		return Mix{}, errors.New("the mix must weigh at least one scenario")
+	}
+	return mix, nil
+}
+
+func (m Mix) String() string {
+	return fmt.Sprintf("browse=%d,book=%d,cancel=%d", m.Browse, m.Book, m.Cancel)
+}
+
+// Budget is what a run may cost before it counts as failed
+type Budget struct {
+	// ErrorRate is the share of requests that may fail, across operations
+	ErrorRate float64
+	// P99 caps the 99th percentile latency of every operation, 0 for no cap
+	P99 time.Duration
+}
+
+// Runner runs virtual users against a deployment
+type Runner struct {
+	Concerts client.ConcertsClient
+	Bookings client.BookingsClient
+
+	// Concurrency is the number of virtual users at full load
+	Concurrency int
+	// RampUp is how long starting all virtual users takes; they start
+	// evenly spaced over it
+	RampUp time.Duration
+	// Duration is the length of the run including the ramp-up
+	Duration time.Duration
+	// Think is the pause of a virtual user between iterations
+	Think time.Duration
+	// Timeout bounds each request, 10 seconds if zero
+	Timeout time.Duration
+	// Interval is the width of the timeline's intervals, 10 seconds if zero
+	Interval time.Duration
+
+	Mix Mix
+	// ConcertIDs are the concerts users open and book; the concerts with
+	// tickets left on the first page of the listing if empty
+	ConcertIDs []int64
+	// Users is the number of user IDs the bookings are spread over
+	Users int
+	// MaxTickets caps the tickets of a booking; bookings take 1 to MaxTickets
+	MaxTickets int
+	// Seed makes the choices of the virtual users reproducible
+	Seed uint64
+
+	Budget Budget
+}
+
+// Report summarizes a run
+type Report struct {
+	Duration time.Duration `json:"duration"`
+	Requests int           `json:"requests"`
+	// Errors counts the requests that failed at the deployment: no
+	// response, a timeout, a 5xx status or an internal or unavailable gRPC
+	// status. Refusals such as sold out concerts are outcomes, not errors.
+	Errors int `json:"errors"`
+	// Throughput is requests per second over the run
+	Throughput float64           `json:"throughput"`
+	Operations []OperationReport `json:"operations"`
+	Timeline   []Interval        `json:"timeline"`
+	// Violations lists the budgets the run exceeded, empty if it kept them
+	Violations []string `json:"violations"`
+}
+
+// OperationReport summarizes the requests of one operation
+type OperationReport struct {
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+	Errors int    `json:"errors"`
+	// Outcomes counts the responses by problem code, "ok" for successes
+	Outcomes map[string]int `json:"outcomes"`
+	P50      time.Duration  `json:"p50"`
+	P90      time.Duration  `json:"p90"`
+	P95      time.Duration  `json:"p95"`
+	P99      time.Duration  `json:"p99"`
+	Max      time.Duration  `json:"max"`
+}
+
+// Interval summarizes the requests that started in one interval of the run,
+// showing how latency and errors follow the ramp-up
+type Interval struct {
+	Start time.Duration `json:"start"`
+	// Users is the number of virtual users running at the interval's start
+	Users    int           `json:"users"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	P95      time.Duration `json:"p95"`
+}
+
+// sample is one request of a run
+type sample struct {
+	op      string
+	start   time.Duration
+	latency time.Duration
+	outcome string
+	failed  bool
+}
+
+// booked is a booking of the run that may be cancelled
+type booked struct {
+	reference string
+	userID    string
+}
+
+// run is the state of one Run call
+type run struct {
+	*Runner
+
+	start time.Time
+
+	mutex   sync.Mutex
+	samples []sample
+	pool    []booked
+}
+
+// Run runs the virtual users for the duration and reports on their requests.
+// Canceling ctx stops the run early and reports the requests completed.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	switch {
+	case r.Concurrency <= 0:
+		return nil, errors.New("concurrency must be positive")
+	case r.Duration <= 0:
+		return nil, errors.New("duration must be positive")
+	case r.RampUp < 0 || r.RampUp >= r.Duration:
+		return nil, errors.New("ramp-up must be shorter than the duration")
+	case r.Users <= 0 || r.MaxTickets <= 0:
+		return nil, errors.New("users and max tickets must be positive")
+	case r.Mix.Browse+r.Mix.Book+r.Mix.Cancel == 0:
+		return nil, errors.New("the mix must weigh at least one scenario")
+	}
+
+	// The defaults and discovered concerts apply to this run only
+	settings := *r
+	state := &run{Runner: &settings}
+	if state.Timeout <= 0 {
+		state.Timeout = 10 * time.Second
+	}
+	if state.Interval <= 0 {
+		state.Interval = 10 * time.Second
+	}
+	if len(state.ConcertIDs) == 0 {
+		ids, err := state.discover(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("finding concerts on sale: %w", err)
+		}
+		state.ConcertIDs = ids
+	}
+
+	state.start = time.Now()
+	deadline := state.start.Add(r.Duration)
+	var wg sync.WaitGroup
+	for i := 0; i < r.Concurrency; i++ {
+		wg.Add(1)
+		go func(user int) {
+			defer wg.Done()
+			if !sleep(ctx, state.startOffset(user)) {
+				return
+			}
+			state.user(ctx, user, deadline)
+		}(i)
+	}
+	wg.Wait()
+
+	return state.report(time.Since(state.start)), nil
+}
+
+// discover returns the concerts with tickets left on the first page of the
+// listing
+func (s *run) discover(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	for concert, err := range s.Concerts.List(ctx, client.ListConcertsOptions{AvailableOnly: true}) {
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, concert.ID)
+		if len(ids) == browsePage {
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no concert has tickets left; seed some or pass concert IDs")
+	}
+	return ids, nil
+}
+
+// startOffset is when a virtual user starts after the start of the run
+func (s *run) startOffset(user int) time.Duration {
+	return s.RampUp * time.Duration(user) / time.Duration(s.Concurrency)
+}
+
+// usersAt returns the number of virtual users running at an offset
+func (s *run) usersAt(offset time.Duration) int {
+	if s.RampUp == 0 {
+		return s.Concurrency
+	}
+	users := int(offset*time.Duration(s.Concurrency)/s.RampUp) + 1
+	return min(users, s.Concurrency)
+}
+
+// user runs the iterations of one virtual user until the deadline
+func (s *run) user(ctx context.Context, user int, deadline time.Time) {
+	rng := rand.New(rand.NewPCG(s.Seed, uint64(user)))
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		switch pick := rng.IntN(s.Mix.Browse + s.Mix.Book + s.Mix.Cancel); {
+		case pick < s.Mix.Browse:
+			s.browse(ctx, rng)
+		case pick < s.Mix.Browse+s.Mix.Book:
+			s.book(ctx, rng)
+		default:
+			s.cancel(ctx, rng)
+		}
+		if s.Think > 0 && !sleep(ctx, min(s.Think, time.Until(deadline))) {
+			return
+		}
+	}
+}
+
+// browse lists concerts and opens one of them
+func (s *run) browse(ctx context.Context, rng *rand.Rand) {
+	s.measure(ctx, OpListConcerts, func(ctx context.Context) error {
+		seen := 0
+		for _, err := range s.Concerts.List(ctx, client.ListConcertsOptions{}) {
+			if err != nil {
+				return err
+			}
+			if seen++; seen == browsePage {
+				break
+			}
+		}
+		return nil
+	})
+
+	id := s.ConcertIDs[rng.IntN(len(s.ConcertIDs))]
+	s.measure(ctx, OpGetConcert, func(ctx context.Context) error {
+		_, err := s.Concerts.Get(ctx, id)
+		return err
+	})
+}
+
+// book books tickets of one of the concerts for one of the users, keeping
+// the booking for a later cancellation
+func (s *run) book(ctx context.Context, rng *rand.Rand) {
+	req := client.BookRequest{
+		ConcertID:   s.ConcertIDs[rng.IntN(len(s.ConcertIDs))],
+		UserID:      fmt.Sprintf("loadgen-user-%d", rng.IntN(s.Users)+1),
+		TicketCount: rng.IntN(s.MaxTickets) + 1,
+	}
+	s.measure(ctx, OpBook, func(ctx context.Context) error {
+		booking, err := s.Bookings.Book(ctx, req)
+		if err == nil {
+			s.keep(booked{reference: booking.Reference, userID: req.UserID})
+		}
+		return err
+	})
+}
+
+// cancel cancels a booking made earlier in the run, or books if there is none
+func (s *run) cancel(ctx context.Context, rng *rand.Rand) {
+	booking, ok := s.take(rng)
+	if !ok {
+		s.book(ctx, rng)
+		return
+	}
+	s.measure(ctx, OpCancel, func(ctx context.Context) error {
+		return s.Bookings.Cancel(ctx, booking.reference, booking.userID)
+	})
+}
+
+func (s *run) keep(booking booked) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.pool) < maxPool {
+		s.pool = append(s.pool, booking)
+	}
+}
+
+func (s *run) take(rng *rand.Rand) (booked, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.pool) == 0 {
+		return booked{}, false
+	}
+	i := rng.IntN(len(s.pool))
+	booking := s.pool[i]
+	s.pool[i] = s.pool[len(s.pool)-1]
+	s.pool = s.pool[:len(s.pool)-1]
+	return booking, true
+}
+
+// measure times one request and records its outcome. Requests cut short by
+// the end of ctx aren't recorded; the deployment didn't fail them.
+func (s *run) measure(ctx context.Context, op string, request func(ctx context.Context) error) {
+	callCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := request(callCtx)
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		return
+	}
+
+	outcome, failed := classify(err)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.samples = append(s.samples, sample{
+		op:      op,
+		start:   start.Sub(s.start),
+		latency: latency,
+		outcome: outcome,
+		failed:  failed,
+	})
+}
+
+// classify names the outcome of a request and reports whether it failed at
+// the deployment
+func classify(err error) (string, bool) {
+	if err == nil {
+		return "ok", false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout", true
+	}
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return "transport", true
+	}
+	outcome := apiErr.Code
+	if outcome == "" && apiErr.HTTPStatus != 0 {
+		outcome = strconv.Itoa(apiErr.HTTPStatus)
+	} else if outcome == "" {
+		outcome = apiErr.GRPCCode.String()
+	}
+
+	switch apiErr.GRPCCode {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.DataLoss, codes.Unimplemented:
+		return outcome, true
+	}
+	return outcome, apiErr.HTTPStatus >= 500
+}
+
+// report summarizes the samples and checks them against the budget
+func (s *run) report(elapsed time.Duration) *Report {
+	report := &Report{Duration: elapsed, Requests: len(s.samples)}
+	if elapsed > 0 {
+		report.Throughput = float64(len(s.samples)) / elapsed.Seconds()
+	}
+
+	// Requests started after the deadline, finishing an iteration, count
+	// towards the last interval
+	intervals := int((min(elapsed, s.Duration) + s.Interval - 1) / s.Interval)
+	byOp := make(map[string][]sample)
+	buckets := make([][]sample, intervals)
+	for _, sample := range s.samples {
+		byOp[sample.op] = append(byOp[sample.op], sample)
+		i := min(int(sample.start/s.Interval), intervals-1)
+		buckets[i] = append(buckets[i], sample)
+		if sample.failed {
+			report.Errors++
+		}
+	}
+
+	for _, op := range operations {
+		samples := byOp[op]
+		if len(samples) == 0 {
+			continue
+		}
+		summary := OperationReport{Name: op, Count: len(samples), Outcomes: make(map[string]int)}
+		for _, sample := range samples {
+			summary.Outcomes[sample.outcome]++
+			if sample.failed {
+				summary.Errors++
+			}
+		}
+		latencies := sortedLatencies(samples)
+		summary.P50 = percentile(latencies, 0.5)
+		summary.P90 = percentile(latencies, 0.9)
+		summary.P95 = percentile(latencies, 0.95)
+		summary.P99 = percentile(latencies, 0.99)
+		summary.Max = latencies[len(latencies)-1]
+		report.Operations = append(report.Operations, summary)
+	}
+
+	for i := range buckets {
+		start := time.Duration(i) * s.Interval
+		interval := Interval{Start: start, Users: s.usersAt(start), Requests: len(buckets[i])}
+		for _, sample := range buckets[i] {
+			if sample.failed {
+				interval.Errors++
+			}
+		}
+		interval.P95 = percentile(sortedLatencies(buckets[i]), 0.95)
+		report.Timeline = append(report.Timeline, interval)
+	}
+
+	report.Violations = s.Budget.check(report)
+	return report
+}
+
+// check returns the budgets a report exceeds
+func (b Budget) check(report *Report) []string {
+	violations := []string{}
+	if report.Requests == 0 {
+		return append(violations, "no request completed")
+	}
+	if rate := float64(report.Errors) / float64(report.Requests); rate > b.ErrorRate {
+		violations = append(violations, fmt.Sprintf("error rate %.2f%% exceeds the budget of %.2f%%", 100*rate, 100*b.ErrorRate))
+	}
+	if b.P99 > 0 {
+		for _, op := range report.Operations {
+			if op.P99 > b.P99 {
+				violations = append(violations, fmt.Sprintf("p99 of %s %s exceeds the budget of %s",
+					op.Name, op.P99.Round(time.Millisecond), b.P99))
+			}
+		}
+	}
+	return violations
+}
+
+func sortedLatencies(samples []sample) []time.Duration {
+	latencies := make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		latencies[i] = sample.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// sleep waits for d, reporting false if ctx ended first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
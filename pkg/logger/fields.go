@@ -0,0 +1,45 @@
+package logger
+
+import "time"
+
+// Field is a key and value added to log messages, a key=value pair in text
+// and a property of the object in JSON
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// RequestID creates the field of the ID of the request being served
+func RequestID(id string) Field {
+	return Field{Key: "request_id", Value: id}
+}
+
+// UserID creates the field of the ID of a user
+func UserID(id string) Field {
+	return Field{Key: "user_id", Value: id}
+}
+
+// ConcertID creates the field of the ID of a concert
+func ConcertID(id int64) Field {
+	return Field{Key: "concert_id", Value: id}
+}
+
+// BookingID creates the field of the ID of a booking
+func BookingID(id int64) Field {
+	return Field{Key: "booking_id", Value: id}
+}
+
+// Latency creates the field of how long something took, in milliseconds
+func Latency(d time.Duration) Field {
+	return Field{Key: "latency_ms", Value: d}
+}
+
+// Err creates the field of an error
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +28,14 @@ const (
 	FATAL
 )
 
+// The output formats
+const (
+	// FormatText writes a line of text per message, its fields as key=value
+	FormatText = "text"
+	// FormatJSON writes a JSON object per message for log aggregation
+	FormatJSON = "json"
+)
+
 // Logger represents a logger
 type Logger interface {
 	Debug(format string, args ...interface{})
@@ -31,19 +43,95 @@ type Logger interface {
 	Warn(format string, args ...interface{})
 	Error(format string, args ...interface{})
 	Fatal(format string, args ...interface{})
+
+	// With returns a logger adding fields to every message
+	With(fields ...Field) Logger
+
+	// Named returns the logger of a component, logging at the level
+	// configured for it
+	Named(component string) Logger
+
+	// SetLevels changes the levels of the logger and of every logger derived
+	// from the same New, like Options.Level and Options.Levels, for
+	// configuration reloads
+	SetLevels(level string, levels map[string]string)
+}
+
+// Options configures a logger
+type Options struct {
+	// Level is the level of components without one in Levels
+	Level string
+	// Format is FormatText or FormatJSON, FormatText when empty
+	Format string
+	// Levels maps components to their level
+	Levels map[string]string
+	// Output is where messages are written, os.Stdout when nil
+	Output io.Writer
+}
+
+// output is the destination shared by a logger and the loggers derived from
+// it, so their lines don't interleave
+type output struct {
+	mu     sync.Mutex
+	writer io.Writer
+	json   bool
+	levels atomic.Pointer[levels]
+}
+
+// levels are the level of the components without one of their own and the
+// levels of the others
+type levels struct {
+	root       LogLevel
+	components map[string]LogLevel
+}
+
+func newLevels(level string, components map[string]string) *levels {
+	l := &levels{root: parseLevel(level), components: make(map[string]LogLevel, len(components))}
+	for component, level := range components {
+		l.components[component] = parseLevel(level)
+	}
+	return l
+}
+
+// of returns the level of a component: its own, or else that of the closest
+// parent with one. Components nest with dots, like "workers.archive".
+func (l *levels) of(component string) LogLevel {
+	for component != "" {
+		if level, ok := l.components[component]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(component, '.')
+		if i < 0 {
+			break
+		}
+		component = component[:i]
+	}
+	return l.root
 }
 
 type logger struct {
-	level  LogLevel
-	logger *log.Logger
+	out       *output
+	component string
+	fields    []Field
 }
 
-// NewLogger creates a new logger
+// NewLogger creates a new logger writing text to stdout
 func NewLogger(level string) Logger {
-	return &logger{
-		level:  parseLevel(level),
-		logger: log.New(os.Stdout, "", 0),
+	return New(Options{Level: level})
+}
+
+// New creates a new logger with options
+func New(opts Options) Logger {
+	out := &output{
+		writer: opts.Output,
+		json:   strings.EqualFold(opts.Format, FormatJSON),
 	}
+	if out.writer == nil {
+		out.writer = os.Stdout
+	}
+	out.levels.Store(newLevels(opts.Level, opts.Levels))
+
+	return &logger{out: out}
 }
 
 // parseLevel parses a string level to a LogLevel
@@ -82,17 +170,137 @@ func levelToString(level LogLevel) string {
 	}
 }
 
+// With returns a copy of the logger with fields appended to its own
+func (l *logger) With(fields ...Field) Logger {
+	derived := *l
+	derived.fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	return &derived
+}
+
+// Named returns a copy of the logger for a component. Components nest with
+// dots, like "workers.archive", and a component without a level of its own
+// keeps the level of its parent.
+func (l *logger) Named(component string) Logger {
+	derived := *l
+	if l.component != "" {
+		derived.component = l.component + "." + component
+	} else {
+		derived.component = component
+	}
+	return &derived
+}
+
+// SetLevels replaces the levels shared by the loggers of the same output
+func (l *logger) SetLevels(level string, components map[string]string) {
+	l.out.levels.Store(newLevels(level, components))
+}
+
 // log logs a message with the given level
 func (l *logger) log(level LogLevel, format string, args ...interface{}) {
-	if level < l.level {
+	if level < l.out.levels.Load().of(l.component) {
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	levelStr := levelToString(level)
+	now := time.Now()
 	message := fmt.Sprintf(format, args...)
 
-	l.logger.Printf("%s [%s] %s", now, levelStr, message)
+	var line []byte
+	if l.out.json {
+		line = l.jsonLine(now, level, message)
+	} else {
+		line = l.textLine(now, level, message)
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	_, _ = l.out.writer.Write(line)
+}
+
+// textLine formats a message as "2006-01-02 15:04:05 [LEVEL] [component]
+// message key=value ..."
+func (l *logger) textLine(now time.Time, level LogLevel, message string) []byte {
+	var b strings.Builder
+	b.WriteString(now.Format("2006-01-02 15:04:05"))
+	b.WriteString(" [")
+	b.WriteString(levelToString(level))
+	b.WriteString("] ")
+	if l.component != "" {
+		b.WriteString("[")
+		b.WriteString(l.component)
+		b.WriteString("] ")
+	}
+	b.WriteString(message)
+	for _, field := range l.fields {
+		b.WriteString(" ")
+		b.WriteString(field.Key)
+		b.WriteString("=")
+		b.WriteString(textValue(field.Value))
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// jsonLine formats a message as a JSON object, the fields after time, level,
+// component and msg in the order they were added
+func (l *logger) jsonLine(now time.Time, level LogLevel, message string) []byte {
+	b := []byte(`{"time":`)
+	b = appendJSON(b, now.UTC().Format(time.RFC3339Nano))
+	b = append(b, `,"level":`...)
+	b = appendJSON(b, strings.ToLower(levelToString(level)))
+	if l.component != "" {
+		b = append(b, `,"component":`...)
+		b = appendJSON(b, l.component)
+	}
+	b = append(b, `,"msg":`...)
+	b = appendJSON(b, message)
+	for _, field := range l.fields {
+		b = append(b, ',')
+		b = appendJSON(b, field.Key)
+		b = append(b, ':')
+		b = appendJSON(b, jsonValue(field.Value))
+	}
+	return append(b, "}\n"...)
+}
+
+// appendJSON appends the JSON encoding of a value, its fmt representation
+// if it has none
+func appendJSON(b []byte, value interface{}) []byte {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return append(b, encoded...)
+}
+
+// jsonValue converts the values JSON would encode unhelpfully: errors as
+// their message and durations as milliseconds
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return value
+	}
+}
+
+// textValue formats a value for text output, quoted if it would otherwise be
+// ambiguous
+func textValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case time.Duration:
+		s = strconv.FormatFloat(float64(v)/float64(time.Millisecond), 'f', -1, 64)
+	default:
+		s = fmt.Sprint(value)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
 }
 
 // Debug logs a debug message
@@ -0,0 +1,146 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+)
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email message
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Sender sends email messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender creates a sender of the configured provider. Without an SMTP
+// host, the smtp provider only logs messages.
+func NewSender(cfg config.Mail, log logger.Logger) Sender {
+	if cfg.Provider == config.MailProviderSendGrid {
+		return &sendGridSender{cfg: cfg, client: &http.Client{Timeout: cfg.SendGrid.Timeout}}
+	}
+
+	if cfg.Host == "" {
+		return &logSender{logger: log}
+	}
+
+	return &smtpSender{cfg: cfg}
+}
+
+type smtpSender struct {
+	cfg config.Mail
+}
+
+// Send sends a message through the configured SMTP server
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	data, err := encode(s.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	// net/smtp has no context support, so run it in the background and stop
+	// waiting when the context is done
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.cfg.From, msg.To, data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type logSender struct {
+	logger logger.Logger
+}
+
+// Send logs the message instead of sending it
+func (s *logSender) Send(ctx context.Context, msg *Message) error {
+	s.logger.Info("Email not sent (no SMTP host configured): to=%s subject=%q attachments=%d",
+		strings.Join(msg.To, ","), msg.Subject, len(msg.Attachments))
+	return nil
+}
+
+// encode renders a message as a MIME multipart email
+func encode(from string, msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if _, err := part.Write([]byte(msg.Body)); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+
+	for _, attachment := range msg.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode email attachment: %w", err)
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"concert-ticket-api/config"
+)
+
+type sendGridSender struct {
+	cfg    config.Mail
+	client *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridMessage struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+// Send sends a message through the SendGrid v3 mail API, which accepts it
+// with 202 Accepted
+func (s *sendGridSender) Send(ctx context.Context, msg *Message) error {
+	var recipients sendGridPersonalization
+	for _, to := range msg.To {
+		recipients.To = append(recipients.To, sendGridAddress{Email: to})
+	}
+	payload := sendGridMessage{
+		Personalizations: []sendGridPersonalization{recipients},
+		From:             sendGridAddress{Email: s.cfg.From},
+		Subject:          msg.Subject,
+		Content:          []sendGridContent{{Type: "text/plain", Value: msg.Body}},
+	}
+	for _, attachment := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Filename,
+			Disposition: "attachment",
+		})
+	}
+
+	body, err := json.Marshal(&payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.SendGrid.BaseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.SendGrid.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to send email: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when no currency is specified
+const DefaultCurrency = "USD"
+
+// currency describes how amounts in a currency are rounded and displayed
+type currency struct {
+	code   string
+	symbol string
+	// decimals is the number of fraction digits used for prices and display.
+	// It can be lower than the ISO 4217 minor unit when the minor unit isn't
+	// used in practice (e.g. IDR).
+	decimals int
+	// locale is used when no locale is requested
+	locale string
+}
+
+// locale describes how numbers and currency symbols are laid out
+type locale struct {
+	group   string
+	decimal string
+	// symbolAfter places the symbol after the number, separated by a space
+	symbolAfter bool
+}
+
+var currencies = map[string]currency{
+	"USD": {code: "USD", symbol: "$", decimals: 2, locale: "en-US"},
+	"EUR": {code: "EUR", symbol: "€", decimals: 2, locale: "de-DE"},
+	"GBP": {code: "GBP", symbol: "£", decimals: 2, locale: "en-GB"},
+	"SGD": {code: "SGD", symbol: "S$", decimals: 2, locale: "en-SG"},
+	"JPY": {code: "JPY", symbol: "¥", decimals: 0, locale: "ja-JP"},
+	"IDR": {code: "IDR", symbol: "Rp", decimals: 0, locale: "id-ID"},
+}
+
+var locales = map[string]locale{
+	"en-US": {group: ",", decimal: "."},
+	"en-GB": {group: ",", decimal: "."},
+	"en-SG": {group: ",", decimal: "."},
+	"ja-JP": {group: ",", decimal: "."},
+	"id-ID": {group: ".", decimal: ","},
+	"de-DE": {group: ".", decimal: ",", symbolAfter: true},
+}
+
+// IsSupported reports whether a currency code is supported
+func IsSupported(code string) bool {
+	_, ok := currencies[strings.ToUpper(code)]
+	return ok
+}
+
+// Decimals returns the number of fraction digits used for a currency
+func Decimals(code string) int {
+	return lookup(code).decimals
+}
+
+// Round rounds an amount to the precision of a currency, rounding halves away from zero
+func Round(amount float64, code string) float64 {
+	rounded, _ := strconv.ParseFloat(roundString(amount, lookup(code).decimals), 64)
+	return rounded
+}
+
+// Format formats an amount for display in the given locale (e.g. "en-US" or
+// "id"). An empty or unsupported locale falls back to the currency's home locale.
+func Format(amount float64, code, localeTag string) string {
+	cur := lookup(code)
+	loc := resolveLocale(localeTag, cur)
+
+	digits := roundString(amount, cur.decimals)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i+1:]
+	}
+
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(loc.group)
+		}
+		b.WriteRune(r)
+	}
+	if fracPart != "" {
+		b.WriteString(loc.decimal)
+		b.WriteString(fracPart)
+	}
+
+	number := b.String()
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	if loc.symbolAfter {
+		return fmt.Sprintf("%s%s %s", sign, number, cur.symbol)
+	}
+	return fmt.Sprintf("%s%s%s", sign, cur.symbol, number)
+}
+
+// ResolveLocale picks the first supported locale from an Accept-Language
+// header value, or returns an empty string if none is supported
+func ResolveLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" {
+			continue
+		}
+		if canonical, ok := matchLocale(tag); ok {
+			return canonical
+		}
+	}
+	return ""
+}
+
+// lookup returns the currency for a code, falling back to the default currency
+func lookup(code string) currency {
+	if cur, ok := currencies[strings.ToUpper(code)]; ok {
+		return cur
+	}
+	return currencies[DefaultCurrency]
+}
+
+// resolveLocale returns the formatting rules for a locale tag
+func resolveLocale(tag string, cur currency) locale {
+	if canonical, ok := matchLocale(tag); ok {
+		return locales[canonical]
+	}
+	return locales[cur.locale]
+}
+
+// matchLocale matches a full tag (e.g. "id-ID") or a bare language (e.g. "id")
+func matchLocale(tag string) (string, bool) {
+	tag = strings.ReplaceAll(tag, "_", "-")
+	for canonical := range locales {
+		if strings.EqualFold(canonical, tag) {
+			return canonical, true
+		}
+	}
+
+	language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	best := ""
+	for canonical := range locales {
+		if strings.HasPrefix(strings.ToLower(canonical), language+"-") && (best == "" || canonical < best) {
+			best = canonical
+		}
+	}
+	return best, best != ""
+}
+
+// roundString rounds an amount to the given number of decimals using exact
+// decimal arithmetic, so values like 1.005 round to 1.01 rather than 1.00
+func roundString(amount float64, decimals int) string {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(amount, 'f', -1, 64))
+	if !ok {
+		return strconv.FormatFloat(amount, 'f', decimals, 64)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+
+	// Round half away from zero
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	return new(big.Rat).SetFrac(quo, scale).FloatString(decimals)
+}
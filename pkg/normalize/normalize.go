@@ -0,0 +1,54 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// searchKeySeparator separates values combined into one search key. It can't
+// appear in normalized text, so a pattern never matches across two values.
+const searchKeySeparator = "\x1f"
+
+// letterFolds maps letters that don't decompose into a base letter and a
+// combining mark to their closest ASCII spelling
+var letterFolds = strings.NewReplacer(
+	"ø", "o", "æ", "ae", "œ", "oe", "ß", "ss", "đ", "d", "ł", "l", "þ", "th", "ı", "i",
+)
+
+// isDiacritic reports whether r is a combining diacritical mark. Only the
+// general block is stripped; marks such as the kana voicing marks change the
+// meaning of CJK text and are kept.
+func isDiacritic(r rune) bool {
+	return unicode.Is(unicode.Mn, r) && r >= 0x0300 && r <= 0x036F
+}
+
+// Text normalizes text for case- and accent-insensitive matching: it folds
+// full-width and half-width forms, lowercases, strips diacritics and collapses
+// whitespace. Scripts without case or accents (e.g. CJK) pass through unchanged.
+func Text(s string) string {
+	t := transform.Chain(width.Fold, norm.NFD, runes.Remove(runes.Predicate(isDiacritic)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+
+	folded = letterFolds.Replace(strings.ToLower(folded))
+	return strings.Join(strings.Fields(folded), " ")
+}
+
+// SearchKey combines a value and its aliases into a single normalized key
+// that can be matched with a substring search
+func SearchKey(value string, aliases ...string) string {
+	keys := make([]string, 0, len(aliases)+1)
+	for _, v := range append([]string{value}, aliases...) {
+		if n := Text(v); n != "" {
+			keys = append(keys, n)
+		}
+	}
+	return strings.Join(keys, searchKeySeparator)
+}
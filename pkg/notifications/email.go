@@ -0,0 +1,44 @@
+package notifications
+
+import (
+	"context"
+	"strings"
+
+	"concert-ticket-api/pkg/mail"
+)
+
+// RenderEmail renders an email of a template to a recipient
+func RenderEmail(name, to string, data *Data) (*mail.Message, error) {
+	subject, err := execute(name+".subject", data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute(name+".body", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      []string{to},
+		Subject: strings.TrimSpace(subject),
+		Body:    strings.TrimSpace(body) + "\n",
+	}, nil
+}
+
+type emailNotifier struct {
+	sender mail.Sender
+}
+
+// NewEmailNotifier creates a Notifier sending emails through sender
+func NewEmailNotifier(sender mail.Sender) Notifier {
+	return &emailNotifier{sender: sender}
+}
+
+// Notify renders an email and sends it to an email address
+func (n *emailNotifier) Notify(ctx context.Context, to, template string, data *Data) error {
+	msg, err := RenderEmail(template, to, data)
+	if err != nil {
+		return err
+	}
+	return n.sender.Send(ctx, msg)
+}
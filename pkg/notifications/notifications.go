@@ -0,0 +1,95 @@
+// Package notifications renders the notifications sent to the attendees of
+// bookings and sends them over a channel. Emails and text messages are plain
+// text rendered from the templates embedded in the package.
+package notifications
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Notification templates
+const (
+	TemplateBookingConfirmed   = "booking_confirmed"
+	TemplateBookingCancelled   = "booking_cancelled"
+	TemplateConcertReminder    = "concert_reminder"
+	TemplateConcertRescheduled = "concert_rescheduled"
+	TemplateQueueTurn          = "queue_turn"
+)
+
+// Templates lists every template, the types of notifications users turn on
+// or off
+var Templates = []string{
+	TemplateBookingConfirmed,
+	TemplateBookingCancelled,
+	TemplateConcertReminder,
+	TemplateConcertRescheduled,
+	TemplateQueueTurn,
+}
+
+// EmailTemplates lists the templates sent by email
+var EmailTemplates = []string{
+	TemplateBookingConfirmed,
+	TemplateBookingCancelled,
+	TemplateConcertReminder,
+	TemplateConcertRescheduled,
+}
+
+// SMSTemplates lists the templates sent by text message, kept to the events
+// worth a text
+var SMSTemplates = []string{
+	TemplateBookingConfirmed,
+	TemplateQueueTurn,
+}
+
+// Notifier sends the notifications of one channel
+type Notifier interface {
+	// Notify renders a template with data and sends it to a recipient, an
+	// email address or a phone number depending on the channel
+	Notify(ctx context.Context, to, template string, data *Data) error
+}
+
+//go:embed templates
+var templateFiles embed.FS
+
+// templates holds a subject and a body template for each of EmailTemplates,
+// named like "booking_confirmed.subject" and "booking_confirmed.body", and a
+// text message template for each of SMSTemplates, like "queue_turn.sms"
+var templates = template.Must(template.New("notifications").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("Mon 2 Jan 2006, 15:04 MST") },
+	"time": func(t time.Time) string { return t.Format("15:04 MST") },
+}).ParseFS(templateFiles, "templates/*.txt"))
+
+// Data is what the templates show about a booking and its concert
+type Data struct {
+	AttendeeName string
+	Reference    string
+	TicketCount  int
+	// Total is the formatted price of the booking
+	Total       string
+	ConcertName string
+	Artist      string
+	Venue       string
+	ConcertDate time.Time
+	// PreviousConcertDate is when a rescheduled concert was planned
+	PreviousConcertDate time.Time
+	// TokenExpiresAt is when the booking token of a user admitted from the
+	// waiting room expires
+	TokenExpiresAt time.Time
+}
+
+func execute(name string, data *Data) (string, error) {
+	if templates.Lookup(name) == nil {
+		return "", fmt.Errorf("unknown notification template %s", name)
+	}
+
+	var out strings.Builder
+	if err := templates.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("failed to render notification template %s: %w", name, err)
+	}
+	return out.String(), nil
+}
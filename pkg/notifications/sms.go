@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+)
+
+// RenderSMS renders the text message of a template
+func RenderSMS(name string, data *Data) (string, error) {
+	body, err := execute(name+".sms", data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(body), nil
+}
+
+// NewSMSNotifier creates a Notifier sending text messages through the
+// configured provider. The log provider only logs them.
+func NewSMSNotifier(cfg config.SMS, log logger.Logger) Notifier {
+	if cfg.Provider == config.SMSProviderTwilio {
+		return &twilioNotifier{cfg: cfg.Twilio, client: &http.Client{Timeout: cfg.Twilio.Timeout}}
+	}
+
+	return &logSMSNotifier{logger: log}
+}
+
+type twilioNotifier struct {
+	cfg    config.Twilio
+	client *http.Client
+}
+
+// Notify sends a text message through the Twilio Messages API, which accepts
+// it with 201 Created
+func (n *twilioNotifier) Notify(ctx context.Context, to, template string, data *Data) error {
+	body, err := RenderSMS(template, data)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{"To": {to}, "From": {n.cfg.From}, "Body": {body}}
+	endpoint := n.cfg.BaseURL + "/2010-04-01/Accounts/" + url.PathEscape(n.cfg.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(n.cfg.AccountSID, n.cfg.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send text message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to send text message: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
+
+type logSMSNotifier struct {
+	logger logger.Logger
+}
+
+// Notify logs the text message instead of sending it, without the phone
+// number
+func (n *logSMSNotifier) Notify(ctx context.Context, to, template string, data *Data) error {
+	body, err := RenderSMS(template, data)
+	if err != nil {
+		return err
+	}
+
+	n.logger.Info("Text message not sent (no SMS provider configured): template=%s length=%d", template, len(body))
+	return nil
+}
@@ -0,0 +1,277 @@
+// Package openapi builds OpenAPI 3 documents from Go types. Schemas are
+// derived by reflection from the json tags of the request and response types
+// the handlers actually bind and render, so the document can't drift from
+// the wire format.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.0.3"
+
+// Operation describes one route for the document generator
+type Operation struct {
+	Method  string
+	Path    string // Gin-style path, e.g. /api/v1/concerts/:id
+	Tag     string
+	Summary string
+
+	// Parameters lists path, query and header parameters. Path parameters that
+	// aren't listed are documented as strings.
+	Parameters []Parameter
+
+	// Request is a value of the JSON request body type, nil for no body
+	Request interface{}
+
+	// Responses maps status codes to a value of the JSON response body type.
+	// A nil value documents a response without a body.
+	Responses map[int]interface{}
+
+	// Admin marks routes that require the admin bearer token
+	Admin bool
+
+	// Organizer marks routes that require the bearer token of an organizer
+	Organizer bool
+
+	// Deprecated marks routes of a deprecated API version
+	Deprecated bool
+}
+
+// Mount returns copies of the operations with the path prefix prepended,
+// such as the /api/v1 group they are registered under
+func Mount(prefix string, operations []Operation, deprecated bool) []Operation {
+	mounted := make([]Operation, len(operations))
+	for i, op := range operations {
+		op.Path = prefix + op.Path
+		op.Deprecated = op.Deprecated || deprecated
+		mounted[i] = op
+	}
+	return mounted
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// PathParam documents a required path parameter of the given schema type
+func PathParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: typ}}
+}
+
+// QueryParam documents an optional query parameter of the given schema type
+func QueryParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// HeaderParam documents a required request header of the given schema type
+func HeaderParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Required: true, Schema: &Schema{Type: typ}}
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Tags       []map[string]string   `json:"tags,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info holds the document metadata
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*OperationObject
+
+// OperationObject is a documented operation
+type OperationObject struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how a route is authenticated
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// adminScheme is the name of the admin bearer token security scheme
+const adminScheme = "adminToken"
+
+// organizerScheme is the name of the organizer bearer token security scheme
+const organizerScheme = "organizerToken"
+
+const jsonContent = "application/json"
+
+// contentTyper is implemented by response body types that are served with a
+// media type other than application/json, such as problem details
+type contentTyper interface {
+	ContentType() string
+}
+
+var pathParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Build generates a document describing the given operations
+func Build(info Info, operations []Operation) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+	schemas := newRegistry(doc.Components.Schemas)
+
+	tags := make(map[string]bool)
+	for _, op := range operations {
+		path := pathParamPattern.ReplaceAllString(op.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+
+		obj := &OperationObject{
+			Summary:     op.Summary,
+			OperationID: operationID(op.Method, op.Path),
+			Parameters:  parameters(op),
+			Responses:   make(map[string]*Response),
+			Deprecated:  op.Deprecated,
+		}
+		if op.Tag != "" {
+			obj.Tags = []string{op.Tag}
+			tags[op.Tag] = true
+		}
+
+		if op.Request != nil {
+			obj.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{jsonContent: {Schema: schemas.schemaFor(reflect.TypeOf(op.Request))}},
+			}
+		}
+
+		for status, body := range op.Responses {
+			resp := &Response{Description: http.StatusText(status)}
+			if body != nil {
+				contentType := jsonContent
+				if typed, ok := body.(contentTyper); ok {
+					contentType = typed.ContentType()
+				}
+				resp.Content = map[string]MediaType{contentType: {Schema: schemas.schemaFor(reflect.TypeOf(body))}}
+			}
+			obj.Responses[strconv.Itoa(status)] = resp
+		}
+
+		if op.Admin {
+			doc.secure(obj, adminScheme)
+		}
+		if op.Organizer {
+			doc.secure(obj, organizerScheme)
+		}
+
+		item[strings.ToLower(op.Method)] = obj
+	}
+
+	for _, tag := range sortedKeys(tags) {
+		doc.Tags = append(doc.Tags, map[string]string{"name": tag})
+	}
+
+	return doc
+}
+
+// secure requires the bearer token of a security scheme on an operation
+func (d *Document) secure(obj *OperationObject, scheme string) {
+	obj.Security = append(obj.Security, map[string][]string{scheme: {}})
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	d.Components.SecuritySchemes[scheme] = &SecurityScheme{Type: "http", Scheme: "bearer"}
+}
+
+// parameters lists the parameters of an operation, adding undocumented path parameters
+func parameters(op Operation) []Parameter {
+	params := append([]Parameter(nil), op.Parameters...)
+
+	documented := make(map[string]bool, len(params))
+	for _, p := range params {
+		if p.In == "path" {
+			documented[p.Name] = true
+		}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		if !documented[match[1]] {
+			params = append(params, PathParam(match[1], "string", ""))
+		}
+	}
+
+	return params
+}
+
+// operationID derives a stable operation ID such as getApiV1ConcertsIdPriceHistory
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// registry turns Go types into schemas, storing named structs as components
+type registry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newRegistry(schemas map[string]*Schema) *registry {
+	return &registry{schemas: schemas, names: make(map[reflect.Type]string)}
+}
+
+// schemaFor returns the schema of a type, referencing components for named structs
+func (r *registry) schemaFor(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		// References can't carry siblings such as nullable in OpenAPI 3.0
+		return &Schema{Ref: "#/components/schemas/" + r.component(t)}
+	case t.Kind() == reflect.Struct:
+		schema = r.structSchema(t)
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// Custom encodings can't be described by reflection
+		schema = &Schema{}
+	default:
+		schema = r.basicSchema(t)
+	}
+
+	// encoding/json renders nil slices and maps as null
+	schema.Nullable = nullable || t.Kind() == reflect.Slice || t.Kind() == reflect.Map
+	return schema
+}
+
+// basicSchema returns the schema of a non-struct type
+func (r *registry) basicSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	default:
+		// Interfaces can hold anything
+		return &Schema{}
+	}
+}
+
+// component registers a named struct and returns its component name
+func (r *registry) component(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := r.schemas[name]; taken {
+		// Qualify the name when two packages use the same type name
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	// Register before recursing so self-referencing types terminate
+	r.names[t] = name
+	r.schemas[name] = &Schema{}
+	*r.schemas[name] = *r.structSchema(t)
+	return name
+}
+
+// structSchema describes the JSON encoding of a struct
+func (r *registry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the exported fields of a struct, flattening embedded structs
+// the way encoding/json does
+func (r *registry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.schemaFor(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isRequired reports whether a field is marked required for request validation
+func isRequired(field reflect.StructField) bool {
+	for _, key := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(field.Tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
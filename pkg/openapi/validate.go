@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const componentPrefix = "#/components/schemas/"
+
+// Operation returns the documented operation of a method and path template,
+// such as GET /api/v1/concerts/{id}, or nil if the document has none
+func (d *Document) Operation(method, path string) *OperationObject {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Validate checks a JSON body against a schema of the document, following
+// references to its components. Objects with documented properties may not
+// carry others, so a field a handler renders without documenting it is
+// reported like a mistyped one. The error lists every mismatch by its JSON
+// path, such as $.data[0].price.
+func (d *Document) Validate(schema *Schema, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	v := &validator{components: d.Components.Schemas}
+	v.validate("$", schema, value)
+	return errors.Join(v.errs...)
+}
+
+// validator collects the mismatches of a value against a schema
+type validator struct {
+	components map[string]*Schema
+	errs       []error
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) validate(path string, schema *Schema, value interface{}) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		resolved, ok := v.components[strings.TrimPrefix(schema.Ref, componentPrefix)]
+		if !ok {
+			v.fail(path, "unknown schema %s", schema.Ref)
+			return
+		}
+		schema = resolved
+	}
+
+	if value == nil {
+		// Custom encodings and interfaces are documented without a type and
+		// may be anything
+		if !schema.Nullable && schema.Type != "" {
+			v.fail(path, "is null, want %s", schema.Type)
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "is %s, want object", kindOf(value))
+			return
+		}
+		v.validateObject(path, schema, object)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, "is %s, want array", kindOf(value))
+			return
+		}
+		for i, item := range items {
+			v.validate(fmt.Sprintf("%s[%d]", path, i), schema.Items, item)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.fail(path, "is %s, want string", kindOf(value))
+			return
+		}
+		v.validateFormat(path, schema.Format, s)
+	case "integer":
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			v.fail(path, "is %s, want integer", kindOf(value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			v.fail(path, "is %s, want number", kindOf(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "is %s, want boolean", kindOf(value))
+		}
+	}
+}
+
+func (v *validator) validateObject(path string, schema *Schema, object map[string]interface{}) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(path, "misses required property %s", name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		switch {
+		case ok:
+			v.validate(path+"."+name, property, object[name])
+		case schema.AdditionalProperties != nil:
+			v.validate(path+"."+name, schema.AdditionalProperties, object[name])
+		case len(schema.Properties) > 0:
+			v.fail(path, "has undocumented property %s", name)
+		}
+	}
+}
+
+func (v *validator) validateFormat(path, format, s string) {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			v.fail(path, "%q is not a date-time", s)
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			v.fail(path, "%q is not base64", s)
+		}
+	}
+}
+
+// kindOf names the JSON type of a decoded value
+func kindOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,217 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/webhook"
+)
+
+// fakeWebhookTolerance is how old the notifications of the fake provider may be
+const fakeWebhookTolerance = 5 * time.Minute
+
+// errFakeDown is returned by a fake provider that was taken down
+var errFakeDown = errors.New("fake payment provider is down")
+
+// Fake takes payments in memory, for development and tests. Depending on
+// its outcome it authorizes payments right away or leaves them pending for
+// notifications, which are signed like our own webhooks. It has no
+// customers: a verified notification is what happened to a payment.
+type Fake struct {
+	cfg config.FakePayments
+
+	mutex      sync.Mutex
+	payments   map[string]string
+	references map[string]string
+	nextID     int
+	nextEvent  int
+	down       bool
+}
+
+// NewFake creates a fake provider
+func NewFake(cfg config.FakePayments) *Fake {
+	return &Fake{
+		cfg:        cfg,
+		payments:   make(map[string]string),
+		references: make(map[string]string),
+		nextID:     1,
+		nextEvent:  1,
+	}
+}
+
+// Name returns "fake"
+func (f *Fake) Name() string {
+	return config.PaymentProviderFake
+}
+
+// Authorize creates a payment, authorized unless the outcome is pending.
+// Requests for the same reference get the same payment.
+func (f *Fake) Authorize(ctx context.Context, req *AuthorizeRequest) (*Authorization, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.down {
+		return nil, errFakeDown
+	}
+
+	id, ok := f.references[req.Reference]
+	if !ok {
+		id = "fake_" + strconv.Itoa(f.nextID)
+		f.nextID++
+		f.references[req.Reference] = id
+		f.payments[id] = StatusPending
+		if f.cfg.Outcome != config.FakeOutcomePending {
+			f.payments[id] = StatusAuthorized
+		}
+	}
+
+	return &Authorization{ID: id, ClientSecret: id + "_secret", Status: f.payments[id]}, nil
+}
+
+// Capture captures an authorized payment
+func (f *Fake) Capture(ctx context.Context, id string) error {
+	return f.move(id, StatusSucceeded, StatusAuthorized)
+}
+
+// Void cancels a payment that wasn't captured
+func (f *Fake) Void(ctx context.Context, id string) error {
+	return f.move(id, StatusCanceled, StatusPending, StatusAuthorized)
+}
+
+// Refund refunds a captured payment
+func (f *Fake) Refund(ctx context.Context, id string) error {
+	return f.move(id, StatusRefunded, StatusSucceeded)
+}
+
+// Lookup returns the status of a payment
+func (f *Fake) Lookup(ctx context.Context, id string) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.down {
+		return "", errFakeDown
+	}
+
+	status, ok := f.payments[id]
+	if !ok {
+		return "", fmt.Errorf("no such payment %s", id)
+	}
+	return status, nil
+}
+
+// move changes the status of a payment that has one of the from statuses
+func (f *Fake) move(id, to string, from ...string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.down {
+		return errFakeDown
+	}
+
+	status, ok := f.payments[id]
+	if !ok {
+		return fmt.Errorf("no such payment %s", id)
+	}
+	for _, allowed := range from {
+		if status == allowed {
+			f.payments[id] = to
+			return nil
+		}
+	}
+
+	return fmt.Errorf("payment %s is %s", id, status)
+}
+
+type fakeNotification struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+}
+
+// fakeNotificationStatuses are the statuses notifications may report, with
+// the status they leave the payment in. Like a declined card, a failed
+// attempt leaves the payment open for another one.
+var fakeNotificationStatuses = map[string]string{
+	StatusAuthorized: StatusAuthorized,
+	StatusFailed:     StatusPending,
+	StatusCanceled:   StatusCanceled,
+}
+
+// VerifyWebhook verifies the signature headers of a notification and
+// records the status it gives its payment
+func (f *Fake) VerifyWebhook(payload []byte, header http.Header, now time.Time) (*Event, error) {
+	err := webhook.Verify(f.cfg.WebhookSecret, header.Get(webhook.HeaderSignature), header.Get(webhook.HeaderTimestamp),
+		payload, now, fakeWebhookTolerance)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	var notification fakeNotification
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+
+	event := &Event{ID: notification.ID, Type: notification.Type}
+	recorded, ok := fakeNotificationStatuses[notification.Status]
+	if !ok {
+		return event, nil
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if status, ok := f.payments[notification.PaymentID]; ok && (status == StatusPending || status == StatusAuthorized) {
+		f.payments[notification.PaymentID] = recorded
+	}
+	event.PaymentID = notification.PaymentID
+	event.Status = notification.Status
+	return event, nil
+}
+
+// Notification returns a notification giving a payment a status, with the
+// headers signing it at a time
+func (f *Fake) Notification(id, status string, at time.Time) ([]byte, http.Header) {
+	f.mutex.Lock()
+	event := "evt_" + strconv.Itoa(f.nextEvent)
+	f.nextEvent++
+	f.mutex.Unlock()
+
+	payload, _ := json.Marshal(fakeNotification{ID: event, Type: "payment." + status, PaymentID: id, Status: status})
+	header := http.Header{}
+	header.Set(webhook.HeaderTimestamp, strconv.FormatInt(at.Unix(), 10))
+	header.Set(webhook.HeaderSignature, webhook.Sign(f.cfg.WebhookSecret, at, payload))
+	return payload, header
+}
+
+// Status returns the status of a payment, for tests
+func (f *Fake) Status(id string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.payments[id]
+}
+
+// SetStatus changes the status of a payment behind the service's back, as
+// a lost notification or a refund in the provider's dashboard would
+func (f *Fake) SetStatus(id, status string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.payments[id] = status
+}
+
+// SetDown makes subsequent requests fail or succeed
+func (f *Fake) SetDown(down bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.down = down
+}
@@ -0,0 +1,114 @@
+// Package payments takes the payments of bookings through payment
+// providers. A booking waits for its payment as a pending booking holding
+// its tickets. Once the customer authorized the payment, the booking is
+// confirmed and the payment captured; the provider notifies us of the
+// customer's actions with signed webhooks.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"concert-ticket-api/config"
+)
+
+// Statuses of payments at a provider, as far as bookings are concerned. They
+// match the statuses of model.Payment.
+const (
+	// StatusPending waits for the customer to pay
+	StatusPending = "pending"
+	// StatusAuthorized holds the customer's money until it is captured or
+	// voided
+	StatusAuthorized = "authorized"
+	// StatusSucceeded was captured
+	StatusSucceeded = "succeeded"
+	// StatusFailed was declined
+	StatusFailed = "failed"
+	// StatusCanceled was voided and can't be paid anymore
+	StatusCanceled = "canceled"
+	// StatusRefunded was captured and paid back
+	StatusRefunded = "refunded"
+)
+
+// ErrInvalidSignature is returned for webhooks that weren't signed by the
+// provider or are too old
+var ErrInvalidSignature = errors.New("invalid payment webhook signature")
+
+// AuthorizeRequest asks a provider for a payment
+type AuthorizeRequest struct {
+	// Reference is the booking reference. It is kept in the provider's
+	// records and makes retried requests return the same payment.
+	Reference   string
+	Amount      float64
+	Currency    string
+	Description string
+}
+
+// Authorization is a payment at a provider
+type Authorization struct {
+	// ID identifies the payment at the provider
+	ID string
+	// ClientSecret lets the client complete the payment with the provider
+	ClientSecret string
+	// Status is StatusPending while the customer has to act, or
+	// StatusAuthorized if the provider authorized the payment right away
+	Status string
+}
+
+// Event is a notification of a provider about one of its payments
+type Event struct {
+	// ID identifies the notification at the provider
+	ID   string
+	Type string
+	// PaymentID and Status are the payment the notification is about and
+	// its new status. PaymentID is empty for notifications that don't
+	// change a payment's status.
+	PaymentID string
+	Status    string
+}
+
+// Provider takes payments. Adding a provider takes an implementation, its
+// configuration and a case in New; bookings only deal with this interface.
+type Provider interface {
+	// Name identifies the provider, e.g. "stripe"
+	Name() string
+
+	// Authorize creates a payment for the customer to authorize. The money
+	// is only taken once the payment is captured.
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*Authorization, error)
+
+	// Capture takes the money of an authorized payment
+	Capture(ctx context.Context, id string) error
+
+	// Void cancels a payment that wasn't captured, so it can't be paid
+	// anymore. It fails if the payment was captured already.
+	Void(ctx context.Context, id string) error
+
+	// Refund pays the money of a captured payment back in full
+	Refund(ctx context.Context, id string) error
+
+	// Lookup returns the status of a payment in the provider's records
+	Lookup(ctx context.Context, id string) (string, error)
+
+	// VerifyWebhook verifies and decodes a notification sent at most the
+	// provider's tolerance before now
+	VerifyWebhook(payload []byte, header http.Header, now time.Time) (*Event, error)
+}
+
+// New creates the configured payment provider, or nil when bookings aren't
+// paid
+func New(cfg config.Payments) (Provider, error) {
+	switch cfg.Provider {
+	case config.PaymentProviderNone:
+		return nil, nil
+	case config.PaymentProviderFake:
+		return NewFake(cfg.Fake), nil
+	case config.PaymentProviderStripe:
+		return NewStripe(cfg.Stripe), nil
+	}
+
+	return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+}
@@ -0,0 +1,285 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"concert-ticket-api/config"
+)
+
+// stripeZeroDecimal are the currencies Stripe takes amounts of in whole
+// units; the others are in hundredths
+var stripeZeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true, "KMF": true, "KRW": true, "MGA": true,
+	"PYG": true, "RWF": true, "UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// StripeSignatureHeader carries the signature of Stripe's webhooks
+const StripeSignatureHeader = "Stripe-Signature"
+
+// Stripe takes payments with Stripe PaymentIntents
+type Stripe struct {
+	cfg    config.Stripe
+	client *http.Client
+}
+
+// NewStripe creates a Stripe provider
+func NewStripe(cfg config.Stripe) *Stripe {
+	return &Stripe{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Name returns "stripe"
+func (s *Stripe) Name() string {
+	return config.PaymentProviderStripe
+}
+
+type stripeIntent struct {
+	ID           string `json:"id"`
+	ClientSecret string `json:"client_secret"`
+	Status       string `json:"status"`
+	// LatestCharge is only expanded by Lookup
+	LatestCharge *struct {
+		Refunded bool `json:"refunded"`
+	} `json:"latest_charge"`
+}
+
+// Authorize creates a PaymentIntent the client confirms with Stripe.js. It
+// is captured by hand, so confirming it only authorizes the card. The
+// booking reference is its idempotency key, so a retried request gets the
+// same intent.
+func (s *Stripe) Authorize(ctx context.Context, req *AuthorizeRequest) (*Authorization, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(stripeAmount(req.Amount, req.Currency), 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("description", req.Description)
+	form.Set("capture_method", "manual")
+	form.Set("metadata[booking_reference]", req.Reference)
+	form.Set("automatic_payment_methods[enabled]", "true")
+
+	var intent stripeIntent
+	if err := s.post(ctx, "/v1/payment_intents", "booking-"+req.Reference, form, &intent); err != nil {
+		return nil, err
+	}
+
+	return &Authorization{ID: intent.ID, ClientSecret: intent.ClientSecret, Status: stripeStatus(intent.Status)}, nil
+}
+
+// Capture captures the full amount of an authorized PaymentIntent
+func (s *Stripe) Capture(ctx context.Context, id string) error {
+	var intent stripeIntent
+	return s.post(ctx, "/v1/payment_intents/"+url.PathEscape(id)+"/capture", "capture-"+id, url.Values{}, &intent)
+}
+
+// Void cancels a PaymentIntent, releasing its authorization. Stripe refuses
+// to cancel intents that were captured.
+func (s *Stripe) Void(ctx context.Context, id string) error {
+	var intent stripeIntent
+	return s.post(ctx, "/v1/payment_intents/"+url.PathEscape(id)+"/cancel", "", url.Values{}, &intent)
+}
+
+// Refund refunds a captured PaymentIntent in full. The intent is the
+// idempotency key, so it is refunded once however often it is asked.
+func (s *Stripe) Refund(ctx context.Context, id string) error {
+	form := url.Values{}
+	form.Set("payment_intent", id)
+
+	var refund struct {
+		ID string `json:"id"`
+	}
+	return s.post(ctx, "/v1/refunds", "refund-"+id, form, &refund)
+}
+
+// Lookup retrieves a PaymentIntent. A refund doesn't change the status of
+// the intent, so its charge tells refunded intents apart.
+func (s *Stripe) Lookup(ctx context.Context, id string) (string, error) {
+	query := url.Values{}
+	query.Set("expand[]", "latest_charge")
+
+	var intent stripeIntent
+	if err := s.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(id), "", query, &intent); err != nil {
+		return "", err
+	}
+
+	if intent.Status == "succeeded" && intent.LatestCharge != nil && intent.LatestCharge.Refunded {
+		return StatusRefunded, nil
+	}
+	return stripeStatus(intent.Status), nil
+}
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID     string `json:"id"`
+			Object string `json:"object"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// stripeEventStatuses are the statuses the PaymentIntent events move an
+// intent to. A failed attempt leaves the intent open for another one, but
+// the booking gives up its hold.
+var stripeEventStatuses = map[string]string{
+	"payment_intent.amount_capturable_updated": StatusAuthorized,
+	"payment_intent.succeeded":                 StatusSucceeded,
+	"payment_intent.payment_failed":            StatusFailed,
+	"payment_intent.canceled":                  StatusCanceled,
+}
+
+// VerifyWebhook verifies the Stripe-Signature header of a webhook and
+// decodes its event. Events other than the PaymentIntent events above are
+// returned without a payment.
+func (s *Stripe) VerifyWebhook(payload []byte, header http.Header, now time.Time) (*Event, error) {
+	if err := VerifyStripeSignature(s.cfg.WebhookSecret, header.Get(StripeSignatureHeader), payload, now, s.cfg.WebhookTolerance); err != nil {
+		return nil, err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode Stripe event: %w", err)
+	}
+
+	parsed := &Event{ID: event.ID, Type: event.Type}
+	if status, ok := stripeEventStatuses[event.Type]; ok && event.Data.Object.Object == "payment_intent" {
+		parsed.PaymentID = event.Data.Object.ID
+		parsed.Status = status
+	}
+
+	return parsed, nil
+}
+
+// SignStripe returns the Stripe-Signature header Stripe would send with a
+// payload at a time, for tests and local development
+func SignStripe(secret string, timestamp time.Time, payload []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + stripeSignature(secret, unix, payload)
+}
+
+// VerifyStripeSignature checks that one of the v1 signatures of a
+// Stripe-Signature header signs the payload, at most tolerance before now
+func VerifyStripeSignature(secret, header string, payload []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	sent := time.Unix(unix, 0)
+	if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := stripeSignature(secret, timestamp, payload)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// stripeSignature is the hex HMAC-SHA256 of "timestamp.payload"
+func stripeSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// stripeAmount converts an amount to the smallest unit Stripe takes it in
+func stripeAmount(amount float64, currency string) int64 {
+	if stripeZeroDecimal[strings.ToUpper(currency)] {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount * 100))
+}
+
+// stripeStatus maps the status of a PaymentIntent to the status of a payment
+func stripeStatus(status string) string {
+	switch status {
+	case "requires_capture":
+		return StatusAuthorized
+	case "succeeded":
+		return StatusSucceeded
+	case "canceled":
+		return StatusCanceled
+	}
+	return StatusPending
+}
+
+// post sends a form to the Stripe API and decodes a successful response into out
+func (s *Stripe) post(ctx context.Context, path, idempotencyKey string, form url.Values, out interface{}) error {
+	return s.do(ctx, http.MethodPost, path, idempotencyKey, form, out)
+}
+
+// do sends a request to the Stripe API, with the form as its body or, for
+// GET requests, its query, and decodes a successful response into out
+func (s *Stripe) do(ctx context.Context, method, path, idempotencyKey string, form url.Values, out interface{}) error {
+	var body io.Reader
+	if method == http.MethodGet {
+		path += "?" + form.Encode()
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	secretKey := s.cfg.SecretKey
+	if s.cfg.SecretKeySource != nil {
+		if secretKey, err = s.cfg.SecretKeySource(ctx); err != nil {
+			return fmt.Errorf("failed to get the secret key: %w", err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+secretKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// cursor is the position of a page. Clients treat cursors as opaque, so the
+// encoding can move to keyset pagination without changing the API.
+type cursor struct {
+	Offset int `json:"o"`
+	Size   int `json:"s"`
+}
+
+// Cursor encodes p as an opaque cursor
+func (p Page) Cursor() string {
+	// Marshaling two ints cannot fail
+	data, _ := json.Marshal(cursor{Offset: p.Offset(), Size: p.Size})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor produced by Page.Cursor
+func DecodeCursor(raw string) (Page, error) {
+	invalid := pkgErr.ErrInvalidInput("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return Page{}, invalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Page{}, invalid
+	}
+
+	if c.Size < 1 || c.Size > MaxPageSize || c.Offset < 0 || c.Offset%c.Size != 0 {
+		return Page{}, invalid
+	}
+	return Page{Number: c.Offset/c.Size + 1, Size: c.Size}, nil
+}
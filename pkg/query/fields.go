@@ -0,0 +1,109 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// Fields is a sparse fieldset: the response fields a client asked for. An
+// empty fieldset selects every field.
+type Fields []string
+
+// ParseFields parses a comma-separated list of response fields, such as
+// name,artist,price. Only the allowed fields may be used.
+func ParseFields(raw string, allowed []string) (Fields, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields Fields
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(allowed, field) {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("unknown field %q, expected one of %s", field, strings.Join(allowed, ", ")))
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// Expansions lists the related resources a client asked to have embedded in
+// the items of a response, such as the concert of each booking
+type Expansions []string
+
+// ParseExpand parses a comma-separated list of related resources to embed.
+// Only the allowed resources may be used.
+func ParseExpand(raw string, allowed []string) (Expansions, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var expansions Expansions
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(allowed, name) {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("unknown expansion %q, expected one of %s", name, strings.Join(allowed, ", ")))
+		}
+		if !slices.Contains(expansions, name) {
+			expansions = append(expansions, name)
+		}
+	}
+	return expansions, nil
+}
+
+// Has reports whether the resource with the given name is to be embedded
+func (e Expansions) Has(name string) bool {
+	return slices.Contains(e, name)
+}
+
+// FieldNames returns the JSON names of the fields of a struct, which are
+// the fields ParseFields allows for it
+func FieldNames(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Select removes the fields outside the fieldset from each object of an
+// encoded JSON array. The identifying fields named by keep are never
+// removed, so clients can still tell the items apart.
+func (f Fields) Select(items json.RawMessage, keep ...string) (json.RawMessage, error) {
+	if len(f) == 0 {
+		return items, nil
+	}
+
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(items, &objects); err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		for name := range object {
+			if !slices.Contains(f, name) && !slices.Contains(keep, name) {
+				delete(object, name)
+			}
+		}
+	}
+	return json.Marshal(objects)
+}
@@ -0,0 +1,20 @@
+package query
+
+import (
+	"time"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// Filters restricts a listing. The keys are defined by each listing, such as
+// artist or date_from for concerts, and unknown keys are ignored.
+type Filters map[string]interface{}
+
+// ParseTime parses an RFC 3339 filter value, naming the parameter in the error
+func ParseTime(name, raw string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, pkgErr.ErrInvalidInput(name + " must be an RFC 3339 time")
+	}
+	return t, nil
+}
@@ -0,0 +1,142 @@
+// Package query parses, validates and normalizes the paging, sorting and
+// filtering parameters of listings, so that REST handlers, the gRPC and
+// GraphQL conversions and the repositories agree on defaults and limits.
+package query
+
+import (
+	"strconv"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+const (
+	// DefaultPageSize is the page size used when none is requested
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page size; larger requests are clamped
+	MaxPageSize = 100
+)
+
+// Page identifies one page of a listing
+type Page struct {
+	// Number is the page number, starting at 1
+	Number int
+	// Size is the number of items per page
+	Size int
+}
+
+// Options selects a page of a sorted and filtered listing
+type Options struct {
+	Page    Page
+	Sort    []Sort
+	Filters Filters
+	// EstimateCount allows the total of the listing to be estimated instead
+	// of counted, which large listings can't afford on every page
+	EstimateCount bool
+}
+
+// NewPage creates a normalized page. Zero values select the first page and
+// the default size, as they do for unset gRPC and GraphQL arguments.
+func NewPage(number, size int) Page {
+	return Page{Number: number, Size: size}.Normalize()
+}
+
+// ParsePage parses the page, pageSize and cursor parameters of a REST request.
+// Missing parameters take their defaults and malformed ones are rejected; a
+// cursor takes precedence over page and pageSize.
+func ParsePage(page, pageSize, cursor string) (Page, error) {
+	if cursor != "" {
+		return DecodeCursor(cursor)
+	}
+
+	number, err := parsePositive("page", page, 1)
+	if err != nil {
+		return Page{}, err
+	}
+
+	size, err := parsePositive("pageSize", pageSize, DefaultPageSize)
+	if err != nil {
+		return Page{}, err
+	}
+
+	return NewPage(number, size), nil
+}
+
+// Normalize replaces missing values with the defaults and clamps the size to
+// MaxPageSize
+func (p Page) Normalize() Page {
+	if p.Number < 1 {
+		p.Number = 1
+	}
+	if p.Size < 1 {
+		p.Size = DefaultPageSize
+	}
+	if p.Size > MaxPageSize {
+		p.Size = MaxPageSize
+	}
+	return p
+}
+
+// Limit returns the maximum number of items on the page
+func (p Page) Limit() int {
+	return p.Size
+}
+
+// Offset returns the number of items before the page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// Next returns the page after p
+func (p Page) Next() Page {
+	return Page{Number: p.Number + 1, Size: p.Size}
+}
+
+// Meta describes a page of a listing in responses
+type Meta struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"pageSize"`
+	TotalCount int `json:"totalCount"`
+	TotalPages int `json:"totalPages"`
+	// TotalCountEstimated is set when an estimate of the total was asked for;
+	// TotalCount and TotalPages may then be off, but never end the listing
+	// before the page
+	TotalCountEstimated bool `json:"totalCountEstimated,omitempty"`
+	// NextCursor fetches the following page and is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Meta describes p for a listing of totalCount items
+func (p Page) Meta(totalCount int) Meta {
+	meta := Meta{
+		Page:       p.Number,
+		PageSize:   p.Size,
+		TotalCount: totalCount,
+		TotalPages: (totalCount + p.Size - 1) / p.Size,
+	}
+	if p.Number < meta.TotalPages {
+		meta.NextCursor = p.Next().Cursor()
+	}
+	return meta
+}
+
+// NextCursor returns the cursor of the following page for listings that
+// aren't counted: a full page may be followed by more items, a partial page
+// is the last one and gets no cursor.
+func (p Page) NextCursor(items int) string {
+	if items < p.Size {
+		return ""
+	}
+	return p.Next().Cursor()
+}
+
+func parsePositive(name, raw string, fallback int) (int, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, pkgErr.ErrInvalidInput(name + " must be a positive integer")
+	}
+	return n, nil
+}
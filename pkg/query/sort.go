@@ -0,0 +1,69 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// Sort orders a listing by one field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma-separated list of fields, each optionally prefixed
+// with - for descending order, such as -price,concert_date. Only the allowed
+// fields may be used, each at most once.
+func ParseSort(raw string, allowed []string) ([]Sort, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var sorts []Sort
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		s := Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !slices.Contains(allowed, s.Field) {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("cannot sort by %q, expected one of %s", s.Field, strings.Join(allowed, ", ")))
+		}
+		if seen[s.Field] {
+			return nil, pkgErr.ErrInvalidInput(fmt.Sprintf("cannot sort by %q twice", s.Field))
+		}
+		seen[s.Field] = true
+		sorts = append(sorts, s)
+	}
+	return sorts, nil
+}
+
+// String formats s in the syntax accepted by ParseSort
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// OrderBy builds an ORDER BY clause, mapping fields to columns. The
+// tiebreaker column is appended so that pages are stable when the sorted
+// fields are equal. Fields without a column are rejected, so nothing from a
+// request is ever interpolated into the query.
+func OrderBy(sorts []Sort, columns map[string]string, tiebreaker string) (string, error) {
+	terms := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		column, ok := columns[s.Field]
+		if !ok {
+			return "", fmt.Errorf("cannot sort by %q", s.Field)
+		}
+		if s.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	terms = append(terms, tiebreaker)
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
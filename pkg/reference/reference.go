@@ -0,0 +1,59 @@
+package reference
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"strings"
+)
+
+// Length is the number of characters in a reference
+const Length = 12
+
+// alphabet is Crockford's base32, which leaves out letters that are easily
+// confused (I, L, O, U), so references can be read out over the phone
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns a random, unguessable reference with 60 bits of entropy
+func New() string {
+	buf := make([]byte, Length)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken
+		panic("reference: failed to read random bytes: " + err.Error())
+	}
+
+	// 256 is a multiple of 32, so the modulo doesn't bias the distribution
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf)
+}
+
+// Derive returns the reference derived from key, the same one every time,
+// for fixtures that are recreated with the references they had. Derived
+// references are guessable from their key, so real bookings use New.
+func Derive(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	buf := make([]byte, Length)
+	for i := range buf {
+		buf[i] = alphabet[int(sum[i])%len(alphabet)]
+	}
+	return string(buf)
+}
+
+// Normalize canonicalizes a user-supplied reference (uppercase, with O read
+// as 0 and I/L as 1) and reports whether it is well-formed, so malformed
+// input can be rejected without a database lookup
+func Normalize(s string) (string, bool) {
+	s = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(strings.ToUpper(strings.TrimSpace(s)))
+	if len(s) != Length {
+		return "", false
+	}
+
+	for _, r := range s {
+		if !strings.ContainsRune(alphabet, r) {
+			return "", false
+		}
+	}
+	return s, true
+}
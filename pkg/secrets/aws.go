@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/sigv4"
+)
+
+// awsProvider reads the current version of secrets from AWS Secrets
+// Manager. The path of a reference is the name or ARN of the secret.
+type awsProvider struct {
+	cfg    config.AWSSecrets
+	client *http.Client
+}
+
+func (a *awsProvider) Fetch(ctx context.Context, path string) (string, error) {
+	if a.cfg.AccessKeyID == "" {
+		return "", fmt.Errorf("no AWS credentials, set secrets.aws.access_key_id or AWS_ACCESS_KEY_ID")
+	}
+
+	endpoint := a.cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", a.cfg.Region)
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sigv4.Sign(req, sigv4.Credentials{
+		AccessKeyID:     a.cfg.AccessKeyID,
+		SecretAccessKey: a.cfg.SecretAccessKey,
+		SessionToken:    a.cfg.SessionToken,
+	}, a.cfg.Region, "secretsmanager", sigv4.PayloadHash(body), clock.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if json.Unmarshal(detail, &failure) == nil && failure.Type != "" {
+			return "", fmt.Errorf("secrets manager answered %d: %s: %s", resp.StatusCode, failure.Type, failure.Message)
+		}
+		return "", fmt.Errorf("secrets manager answered %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if secret.SecretString != nil {
+		return *secret.SecretString, nil
+	}
+	return string(secret.SecretBinary), nil
+}
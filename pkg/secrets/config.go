@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"concert-ticket-api/config"
+)
+
+// ResolveConfig returns a copy of cfg whose settings referencing secrets
+// hold the secrets instead, fetched through a store of cfg.Secrets. The
+// database password and the Stripe secret key also get sources resolving
+// them again, so they can rotate. cfg keeps its references, which a reload
+// of the config compares with those of the file.
+func ResolveConfig(ctx context.Context, cfg *config.Config) (*config.Config, error) {
+	store := NewStore(cfg.Secrets)
+	resolved := *cfg
+	if err := store.resolve(ctx, "", reflect.ValueOf(&resolved).Elem()); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	// Secrets change what the config can be checked for, like the length of
+	// a signing key
+	if err := resolved.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if config.IsSecretReference(cfg.Database.Password) {
+		resolved.Database.PasswordSource = store.Source(cfg.Database.Password)
+	}
+	if config.IsSecretReference(cfg.Payments.Stripe.SecretKey) {
+		resolved.Payments.Stripe.SecretKeySource = store.Source(cfg.Payments.Stripe.SecretKey)
+	}
+	return &resolved, nil
+}
+
+// resolve replaces the references in the string settings under value, the
+// setting at key. Lists and maps are copied before anything in them is
+// replaced, so the config they were copied from keeps its references.
+func (s *Store) resolve(ctx context.Context, key string, value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.String:
+		secret, err := s.Resolve(ctx, value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		value.SetString(secret)
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			name := value.Type().Field(i).Tag.Get("mapstructure")
+			if name == "" || name == "-" {
+				continue
+			}
+			if key != "" {
+				name = key + "." + name
+			}
+			if err := s.resolve(ctx, name, value.Field(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		if value.IsNil() {
+			return nil
+		}
+		items := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(items, value)
+		for i := 0; i < items.Len(); i++ {
+			if err := s.resolve(ctx, fmt.Sprintf("%s.%d", key, i), items.Index(i)); err != nil {
+				return err
+			}
+		}
+		value.Set(items)
+
+	case reflect.Map:
+		if value.IsNil() || value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		entries := reflect.MakeMapWithSize(value.Type(), value.Len())
+		for _, name := range value.MapKeys() {
+			entry := reflect.New(value.Type().Elem()).Elem()
+			entry.Set(value.MapIndex(name))
+			if err := s.resolve(ctx, fmt.Sprintf("%s.%v", key, name), entry); err != nil {
+				return err
+			}
+			entries.SetMapIndex(name, entry)
+		}
+		value.Set(entries)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package secrets resolves the settings that reference secrets in a file,
+// HashiCorp Vault or AWS Secrets Manager instead of holding them, caching
+// what it fetched so rotated secrets are picked up without hammering the
+// stores
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var secretFetchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "secret_fetches_total",
+	Help: "Secrets fetched by store and outcome: fetched, failed, or stale when a failed fetch kept the previous value.",
+}, []string{"store", "outcome"})
+
+// Provider fetches secrets from a store
+type Provider interface {
+	// Fetch returns the secret at the path of a reference, the part between
+	// the scheme and the #key
+	Fetch(ctx context.Context, path string) (string, error)
+}
+
+// Store resolves references to secrets through the provider of their scheme
+type Store struct {
+	providers map[string]Provider
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// NewStore creates a store of the configured providers. Files can always be
+// referenced; Vault and AWS Secrets Manager need an address and a region.
+func NewStore(cfg config.Secrets) *Store {
+	client := &http.Client{Timeout: cfg.Timeout}
+	providers := map[string]Provider{config.SecretStoreFile: fileProvider{}}
+
+	vault := cfg.Vault
+	vault.Addr = or(vault.Addr, os.Getenv("VAULT_ADDR"))
+	vault.Token = or(vault.Token, os.Getenv("VAULT_TOKEN"))
+	vault.Namespace = or(vault.Namespace, os.Getenv("VAULT_NAMESPACE"))
+	if vault.Addr != "" {
+		providers[config.SecretStoreVault] = &vaultProvider{cfg: vault, client: client}
+	}
+
+	aws := cfg.AWS
+	aws.Region = or(aws.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if aws.AccessKeyID == "" {
+		aws.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		aws.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		aws.SessionToken = or(aws.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))
+	}
+	if aws.Region != "" {
+		providers[config.SecretStoreAWS] = &awsProvider{cfg: aws, client: client}
+	}
+
+	return &Store{providers: providers, ttl: cfg.CacheTTL, cache: make(map[string]cached)}
+}
+
+// Resolve returns the secret value references, or value itself if it isn't
+// a reference. A reference with a #key picks that field of a secret holding
+// a JSON object, like the key-value pairs of Vault. A secret is fetched again
+// once it is older than the cache TTL; if that fails, the previous value is
+// returned until a fetch succeeds.
+func (s *Store) Resolve(ctx context.Context, value string) (string, error) {
+	if !config.IsSecretReference(value) {
+		return value, nil
+	}
+
+	s.mu.Lock()
+	entry, ok := s.cache[value]
+	s.mu.Unlock()
+	if ok && clock.Now().Sub(entry.fetchedAt) < s.ttl {
+		return entry.value, nil
+	}
+
+	scheme, _, _ := strings.Cut(value, "://")
+	secret, err := s.fetch(ctx, value)
+	if err != nil {
+		if ok {
+			secretFetchesTotal.WithLabelValues(scheme, "stale").Inc()
+			return entry.value, nil
+		}
+		secretFetchesTotal.WithLabelValues(scheme, "failed").Inc()
+		return "", err
+	}
+	secretFetchesTotal.WithLabelValues(scheme, "fetched").Inc()
+
+	s.mu.Lock()
+	s.cache[value] = cached{value: secret, fetchedAt: clock.Now()}
+	s.mu.Unlock()
+	return secret, nil
+}
+
+// Source returns a function resolving reference each time it is called, for
+// secrets that may rotate while the process runs
+func (s *Store) Source(reference string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return s.Resolve(ctx, reference)
+	}
+}
+
+// fetch fetches the secret of a reference from its store
+func (s *Store) fetch(ctx context.Context, reference string) (string, error) {
+	scheme, path, _ := strings.Cut(reference, "://")
+	path, key, _ := strings.Cut(path, "#")
+	provider, ok := s.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("secret store %s isn't configured", scheme)
+	}
+	if path == "" {
+		return "", fmt.Errorf("secret reference %s has no path", reference)
+	}
+	if scheme == config.SecretStoreVault && key == "" {
+		return "", fmt.Errorf("vault secret %s needs the #key of its field", path)
+	}
+
+	secret, err := provider.Fetch(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s secret %s: %w", scheme, path, err)
+	}
+	if key == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("%s secret %s isn't a JSON object to take %s from", scheme, path, key)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%s secret %s has no %s", scheme, path, key)
+	}
+	if text, ok := field.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(field)
+	if err != nil {
+		return "", fmt.Errorf("%s secret %s has no %s", scheme, path, key)
+	}
+	return string(encoded), nil
+}
+
+// fileProvider reads secrets from files, like those Docker and Kubernetes
+// mount. The trailing newline of the file isn't part of the secret.
+type fileProvider struct{}
+
+func (fileProvider) Fetch(_ context.Context, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// or returns the first of values that isn't empty
+func or(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"concert-ticket-api/config"
+)
+
+// vaultProvider reads the latest version of secrets from KV version 2
+// secrets engines. The path of a reference starts with the mount of the
+// engine: secret/tickets/db reads tickets/db from the engine at secret/.
+type vaultProvider struct {
+	cfg    config.VaultSecrets
+	client *http.Client
+}
+
+func (v *vaultProvider) Fetch(ctx context.Context, path string) (string, error) {
+	mount, name, ok := strings.Cut(path, "/")
+	if !ok || name == "" {
+		return "", fmt.Errorf("the path needs the mount of the secrets engine and the name of the secret")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimRight(v.cfg.Addr, "/")+"/v1/"+mount+"/data/"+name, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.cfg.Token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault answered %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var secret struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(secret.Data.Data) == 0 || string(secret.Data.Data) == "null" {
+		return "", fmt.Errorf("the secret has no data, it may be deleted")
+	}
+	return string(secret.Data.Data), nil
+}
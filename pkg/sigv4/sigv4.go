@@ -0,0 +1,145 @@
+// Package sigv4 signs requests to AWS APIs with Signature Version 4, so the
+// clients of S3 and Secrets Manager don't need the AWS SDK.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are the AWS access key a request is signed with
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials, e.g. of an IAM role
+	SessionToken string
+}
+
+// Sign signs req for a service in a region. It sets X-Amz-Date, and
+// X-Amz-Security-Token for temporary credentials, and signs them with the
+// host and every other header of req, so headers must be set before. The
+// path is encoded once, the way S3 expects; services that canonicalize
+// paths differently are only called on "/". payloadHash is the hex SHA-256
+// of the body, see PayloadHash.
+func Sign(req *http.Request, creds Credentials, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	canonicalHeaders, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escape(req.URL.Path, true),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// PayloadHash returns the hex SHA-256 of a request body
+func PayloadHash(body []byte) string {
+	return hexSHA256(body)
+}
+
+// canonicalHeaders returns the host and the headers of req as lower case
+// names and trimmed values, sorted by name, and the list of their names
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, list := range req.Header {
+		trimmed := make([]string, len(list))
+		for i, value := range list {
+			// Runs of spaces inside a value count as one
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery encodes the query of req sorted by name, then value
+func canonicalQuery(req *http.Request) string {
+	var pairs [][2]string
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{escape(name, false), escape(value, false)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// escape URI-encodes every byte of s but the unreserved characters, and
+// slashes if keepSlash is set
+func escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 || c == '/' && keepSlash {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	if b.Len() == 0 && keepSlash {
+		return "/"
+	}
+	return b.String()
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,166 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is a timed section of work within a trace
+type Span struct {
+	Name string `json:"name"`
+	// Offset is the time between the start of the trace and the start of the span
+	Offset   time.Duration `json:"offset"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace collects spans recorded while handling a single request
+type Trace struct {
+	mutex sync.Mutex
+	start time.Time
+	spans []Span
+}
+
+type traceKey struct{}
+
+type idKey struct{}
+
+type requestIDKey struct{}
+
+// maxRequestIDLength bounds the request IDs accepted from callers, so they
+// can't bloat every log line
+const maxRequestIDLength = 128
+
+// New starts a trace and attaches it to the returned context
+func New(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{start: time.Now()}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// FromContext returns the trace attached to ctx, or nil
+func FromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// StartSpan starts a span on the trace attached to ctx and returns a function
+// that ends it. It is a no-op when ctx carries no trace, so callers can
+// instrument code unconditionally.
+func StartSpan(ctx context.Context, name string) func() {
+	t := FromContext(ctx)
+	if t == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.spans = append(t.spans, Span{
+			Name:     name,
+			Offset:   start.Sub(t.start),
+			Duration: time.Since(start),
+		})
+	}
+}
+
+// Spans returns a copy of the spans recorded so far
+func (t *Trace) Spans() []Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]Span(nil), t.spans...)
+}
+
+// WithID attaches the ID of the distributed trace a request belongs to
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// ID returns the trace ID attached to ctx, or an empty string
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// NewID generates a random trace ID in the W3C Trace Context format
+func NewID() string {
+	var id [16]byte
+	// crypto/rand only fails when the OS entropy source is unavailable
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// WithRequestID attaches the ID of the request being served, which callers
+// may choose themselves, unlike the trace ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID attached to ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID generates a random request ID in the UUID format
+func NewRequestID() string {
+	var id [16]byte
+	// crypto/rand only fails when the OS entropy source is unavailable
+	_, _ = rand.Read(id[:])
+	// Version 4, variant 10
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	s := hex.EncodeToString(id[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// ValidRequestID reports whether a request ID chosen by a caller can be
+// kept: at most 128 letters, digits and the punctuation of common ID
+// formats, so it is safe to log and echo in headers
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTraceparent extracts the trace ID from a W3C traceparent header such
+// as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !isHex(parts[0], 2) || !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", false
+	}
+	if parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// isHex reports whether s consists of n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+// Package traffic records a sample of anonymized REST requests and replays
+// recordings against another deployment, so capacity tests reproduce the
+// shape of a real on-sale instead of a synthetic load.
+package traffic
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is one recorded request. Recordings are JSON Lines files of records.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	// Route is the route template the request matched, e.g. /api/v1/concerts/:id
+	Route string `json:"route"`
+	// Path is the anonymized request URI
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header,omitempty"`
+	// Body is the anonymized JSON body
+	Body json.RawMessage `json:"body,omitempty"`
+	// BodyOmitted is set when the request had a body that was too large or
+	// not JSON, so it couldn't be anonymized
+	BodyOmitted bool `json:"body_omitted,omitempty"`
+	// Admin is set for requests that passed the admin authentication; the
+	// admin token itself is never recorded
+	Admin bool `json:"admin,omitempty"`
+	// Client is the pseudonym of the client address
+	Client    string  `json:"client"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// recordedHeaders are the request headers kept in recordings. Credentials,
+// invite tokens and operator names are left out.
+var recordedHeaders = []string{
+	"Accept", "Accept-Language", "Content-Type", "If-Match", "If-Modified-Since", "If-None-Match",
+}
+
+// pseudonymKinds maps the JSON fields and query parameters holding personal
+// data or secrets to the kind of pseudonym that replaces them
+var pseudonymKinds = map[string]string{
+	"user_id":        "user",
+	"userID":         "user",
+	"booking_token":  "token",
+	"token":          "token",
+	"attendee_name":  "attendee",
+	"attendee_email": "email",
+}
+
+// Anonymizer replaces personal data and secrets by keyed pseudonyms. The
+// same value always gets the same pseudonym under one key, so a user's
+// requests stay linked without revealing who the user is.
+type Anonymizer struct {
+	key []byte
+}
+
+// NewAnonymizer creates an anonymizer keyed with salt
+func NewAnonymizer(salt []byte) *Anonymizer {
+	return &Anonymizer{key: salt}
+}
+
+// sum returns the keyed hash of a value of the given kind
+func (a *Anonymizer) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind + ":" + value))
+	return mac.Sum(nil)
+}
+
+// Pseudonym returns the pseudonym of a value of the given kind. Emails get a
+// pseudonym that is still a valid address.
+func (a *Anonymizer) Pseudonym(kind, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	id := hex.EncodeToString(a.sum(kind, value)[:8])
+	if kind == "email" {
+		return id + "@example.com"
+	}
+	return kind + "-" + id
+}
+
+// sampled reports whether a value falls into the given fraction of values
+func (a *Anonymizer) sampled(kind, value string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	n := binary.BigEndian.Uint64(a.sum(kind, value))
+	return float64(n) < rate*math.MaxUint64
+}
+
+// Path builds the anonymized URI of a request from its route template, its
+// route parameters and its query. Booking references and the users of the
+// user data routes are replaced by pseudonyms.
+func (a *Anonymizer) Path(route string, params map[string]string, query url.Values) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+
+		name := segment[1:]
+		value := params[name]
+		switch {
+		case name == "reference":
+			value = a.Pseudonym("ref", value)
+		case name == "id" && i > 0 && segments[i-1] == "users":
+			value = a.Pseudonym("user", value)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+
+	path := strings.Join(segments, "/")
+	if len(query) == 0 {
+		return path
+	}
+
+	anonymized := make(url.Values, len(query))
+	for key, values := range query {
+		kind, ok := pseudonymKinds[key]
+		for _, value := range values {
+			if ok {
+				value = a.Pseudonym(kind, value)
+			}
+			anonymized.Add(key, value)
+		}
+	}
+	return path + "?" + anonymized.Encode()
+}
+
+// Body anonymizes a JSON body. ok is false for bodies that aren't JSON.
+func (a *Anonymizer) Body(body []byte) (anonymized json.RawMessage, ok bool) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	data, err := json.Marshal(a.value("", value))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// value anonymizes a decoded JSON value found under the given key
+func (a *Anonymizer) value(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			v[k] = a.value(k, field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.value(key, item)
+		}
+	case string:
+		if kind, ok := pseudonymKinds[key]; ok {
+			if kind == "attendee" {
+				return "Attendee " + a.Pseudonym(kind, v)
+			}
+			return a.Pseudonym(kind, v)
+		}
+	}
+	return value
+}
+
+// Header keeps the recorded headers of a request
+func Header(header http.Header) map[string]string {
+	var kept map[string]string
+	for _, name := range recordedHeaders {
+		if value := header.Get(name); value != "" {
+			if kept == nil {
+				kept = make(map[string]string)
+			}
+			kept[name] = value
+		}
+	}
+	return kept
+}
+
+// ReadRecords reads recordings, such as the files of several replicas, and
+// merges their records in the order the requests arrived
+func ReadRecords(readers ...io.Reader) ([]Record, error) {
+	var records []Record
+	for _, r := range readers {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+			}
+			records = append(records, record)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
@@ -0,0 +1,144 @@
+package traffic
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"concert-ticket-api/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var recordedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "traffic_recorded_requests_total",
+	Help: "Number of sampled REST requests written to the traffic recording, by result.",
+}, []string{"result"})
+
+// Request is a served request as seen by the recorder, before anonymization
+type Request struct {
+	Time     time.Time
+	Method   string
+	Route    string
+	Params   map[string]string
+	Query    url.Values
+	Header   http.Header
+	Body     []byte
+	Admin    bool
+	ClientIP string
+	Status   int
+	Latency  time.Duration
+	// BodyOmitted is set when the body was larger than MaxBodyBytes
+	BodyOmitted bool
+}
+
+// Recorder writes a sample of requests to a recording. Clients are sampled
+// rather than requests, so the sequence of requests of a sampled client, such
+// as its token request and booking, is recorded whole.
+type Recorder struct {
+	anonymizer   *Anonymizer
+	sampleRate   float64
+	maxBodyBytes int
+
+	mutex  sync.Mutex
+	out    io.Writer
+	closer io.Closer
+}
+
+// NewRecorder creates a recorder writing to out. A random salt is used
+// unless one is configured.
+func NewRecorder(out io.Writer, cfg config.Recording) (*Recorder, error) {
+	salt := []byte(cfg.Salt)
+	if len(salt) == 0 {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+
+	return &Recorder{
+		anonymizer:   NewAnonymizer(salt),
+		sampleRate:   cfg.SampleRate,
+		maxBodyBytes: cfg.MaxBodyBytes,
+		out:          out,
+	}, nil
+}
+
+// OpenRecorder creates a recorder appending to the configured file
+func OpenRecorder(cfg config.Recording) (*Recorder, error) {
+	file, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traffic recording: %w", err)
+	}
+
+	recorder, err := NewRecorder(file, cfg)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	recorder.closer = file
+	return recorder, nil
+}
+
+// Sampled reports whether the requests of a client are recorded
+func (r *Recorder) Sampled(clientIP string) bool {
+	return r.anonymizer.sampled("client", clientIP, r.sampleRate)
+}
+
+// MaxBodyBytes returns the size of the largest body that is recorded
+func (r *Recorder) MaxBodyBytes() int {
+	return r.maxBodyBytes
+}
+
+// Record anonymizes a request and writes it to the recording
+func (r *Recorder) Record(req Request) error {
+	record := Record{
+		Time:        req.Time.UTC(),
+		Method:      req.Method,
+		Route:       req.Route,
+		Path:        r.anonymizer.Path(req.Route, req.Params, req.Query),
+		Header:      Header(req.Header),
+		BodyOmitted: req.BodyOmitted,
+		Admin:       req.Admin,
+		Client:      r.anonymizer.Pseudonym("client", req.ClientIP),
+		Status:      req.Status,
+		LatencyMS:   float64(req.Latency.Microseconds()) / 1000,
+	}
+	if len(req.Body) > 0 && !req.BodyOmitted {
+		body, ok := r.anonymizer.Body(req.Body)
+		record.Body, record.BodyOmitted = body, !ok
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		recordedRequests.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// One write per line, so lines of concurrent requests never interleave
+	if _, err := r.out.Write(line); err != nil {
+		recordedRequests.WithLabelValues("failed").Inc()
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	recordedRequests.WithLabelValues("recorded").Inc()
+	return nil
+}
+
+// Close closes the recording file opened by OpenRecorder
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
@@ -0,0 +1,321 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Replayer sends the requests of a recording to another deployment at the
+// pace they were recorded, sped up or slowed down by Speed
+type Replayer struct {
+	// BaseURL is the deployment requests are sent to, e.g. https://staging.example.com
+	BaseURL string
+	// Speed multiplies the pace of the recording; 2 replays an hour in 30 minutes
+	Speed float64
+	// AdminToken authenticates the requests recorded as admin requests
+	AdminToken string
+	// MaxInFlight caps the concurrent requests, 0 for no limit. Requests
+	// waiting for a slot fall behind schedule, which shows in Report.MaxLag.
+	MaxInFlight int
+	// Client sends the requests; a client with a 30 second timeout is used if nil
+	Client *http.Client
+}
+
+// Report summarizes a replay
+type Report struct {
+	Sent int `json:"sent"`
+	// Failed counts the requests that got no response
+	Failed int `json:"failed"`
+	// Statuses counts the replayed responses by status code
+	Statuses map[int]int `json:"statuses"`
+	// RecordedStatuses counts the recorded responses of the same requests
+	RecordedStatuses map[int]int `json:"recorded_statuses"`
+	// MaxLag is how far the request most behind schedule was sent late. A
+	// lag close to the replay duration means the replayer couldn't keep up.
+	MaxLag   time.Duration `json:"max_lag"`
+	Duration time.Duration `json:"duration"`
+	Routes   []RouteReport `json:"routes"`
+}
+
+// RouteReport summarizes the replayed requests of one route
+type RouteReport struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	Count  int    `json:"count"`
+	// Errors counts the requests that failed or were answered with a 5xx status
+	Errors      int           `json:"errors"`
+	P50         time.Duration `json:"p50"`
+	P95         time.Duration `json:"p95"`
+	P99         time.Duration `json:"p99"`
+	RecordedP99 time.Duration `json:"recorded_p99"`
+}
+
+// replay is the state of one Replay call
+type replay struct {
+	*Replayer
+	client *http.Client
+
+	mutex     sync.Mutex
+	report    *Report
+	latencies map[string][]time.Duration
+	recorded  map[string][]time.Duration
+	errors    map[string]int
+	// tokens maps the user pseudonym and concert of a replayed token request
+	// to the booking token the deployment issued
+	tokens map[string]string
+}
+
+// Replay sends the records, which must be sorted by time as ReadRecords
+// returns them, and waits for all responses. Canceling ctx stops the replay
+// and reports the requests sent so far.
+func (r *Replayer) Replay(ctx context.Context, records []Record) (*Report, error) {
+	if r.Speed <= 0 {
+		return nil, errors.New("speed must be positive")
+	}
+	if r.BaseURL == "" {
+		return nil, errors.New("base URL must be set")
+	}
+
+	state := &replay{
+		Replayer:  r,
+		client:    r.Client,
+		report:    &Report{Statuses: make(map[int]int), RecordedStatuses: make(map[int]int)},
+		latencies: make(map[string][]time.Duration),
+		recorded:  make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		tokens:    make(map[string]string),
+	}
+	if state.client == nil {
+		state.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if len(records) == 0 {
+		return state.report, nil
+	}
+
+	var slots chan struct{}
+	if r.MaxInFlight > 0 {
+		slots = make(chan struct{}, r.MaxInFlight)
+	}
+
+	start, first := time.Now(), records[0].Time
+	var wg sync.WaitGroup
+
+schedule:
+	for _, record := range records {
+		due := start.Add(time.Duration(float64(record.Time.Sub(first)) / r.Speed))
+		if wait := time.Until(due); wait > 0 {
+			select {
+			case <-ctx.Done():
+				break schedule
+			case <-time.After(wait):
+			}
+		}
+
+		if slots != nil {
+			select {
+			case <-ctx.Done():
+				break schedule
+			case slots <- struct{}{}:
+			}
+		}
+		state.lag(time.Since(due))
+
+		wg.Add(1)
+		go func(record Record) {
+			defer wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			state.send(ctx, record)
+		}(record)
+	}
+
+	wg.Wait()
+	state.report.Duration = time.Since(start)
+	state.summarize()
+	return state.report, nil
+}
+
+func (s *replay) lag(lag time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if lag > s.report.MaxLag {
+		s.report.MaxLag = lag
+	}
+}
+
+// send replays one record and counts its response
+func (s *replay) send(ctx context.Context, record Record) {
+	key := record.Method + " " + record.Route
+
+	req, err := s.request(ctx, record)
+	if err != nil {
+		s.count(key, record, 0, 0)
+		return
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.count(key, record, 0, 0)
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	latency := time.Since(start)
+
+	if resp.StatusCode == http.StatusCreated && strings.HasSuffix(record.Route, "/booking-token") {
+		s.rememberToken(body)
+	}
+	s.count(key, record, resp.StatusCode, latency)
+}
+
+// request builds the request of a record for the target deployment
+func (s *replay) request(ctx context.Context, record Record) (*http.Request, error) {
+	var body io.Reader
+	if len(record.Body) > 0 {
+		body = bytes.NewReader(s.substituteToken(record.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, record.Method, strings.TrimSuffix(s.BaseURL, "/")+record.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range record.Header {
+		req.Header.Set(name, value)
+	}
+	if record.Admin && s.AdminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.AdminToken)
+	}
+	return req, nil
+}
+
+// tokenKey identifies the booking token of a user for a concert
+func tokenKey(fields map[string]interface{}) string {
+	return fmt.Sprint(fields["user_id"], "|", fields["concert_id"])
+}
+
+// rememberToken keeps the token the deployment issued for a replayed token request
+func (s *replay) rememberToken(body []byte) {
+	var token map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&token); err != nil {
+		return
+	}
+	value, ok := token["token"].(string)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[tokenKey(token)] = value
+}
+
+// substituteToken replaces the pseudonym of a recorded booking token by the
+// token the deployment issued for the same user and concert. Without one the
+// token is left out; the recorded token would be rejected in any case.
+func (s *replay) substituteToken(body json.RawMessage) []byte {
+	if !bytes.Contains(body, []byte(`"booking_token"`)) {
+		return body
+	}
+
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return body
+	}
+	if _, ok := fields["booking_token"]; !ok {
+		return body
+	}
+
+	s.mutex.Lock()
+	token, ok := s.tokens[tokenKey(fields)]
+	s.mutex.Unlock()
+	if ok {
+		fields["booking_token"] = token
+	} else {
+		delete(fields, "booking_token")
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// count adds a response to the report; status 0 is a request that failed
+func (s *replay) count(key string, record Record, status int, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.report.Sent++
+	s.report.RecordedStatuses[record.Status]++
+	s.recorded[key] = append(s.recorded[key], time.Duration(record.LatencyMS*float64(time.Millisecond)))
+
+	if status == 0 {
+		s.report.Failed++
+		s.errors[key]++
+		return
+	}
+	s.report.Statuses[status]++
+	if status >= http.StatusInternalServerError {
+		s.errors[key]++
+	}
+	s.latencies[key] = append(s.latencies[key], latency)
+}
+
+// summarize computes the route reports, sorted by request count
+func (s *replay) summarize() {
+	for key, recorded := range s.recorded {
+		method, route, _ := strings.Cut(key, " ")
+		latencies := s.latencies[key]
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		sort.Slice(recorded, func(i, j int) bool { return recorded[i] < recorded[j] })
+
+		s.report.Routes = append(s.report.Routes, RouteReport{
+			Method:      method,
+			Route:       route,
+			Count:       len(recorded),
+			Errors:      s.errors[key],
+			P50:         percentile(latencies, 0.5),
+			P95:         percentile(latencies, 0.95),
+			P99:         percentile(latencies, 0.99),
+			RecordedP99: percentile(recorded, 0.99),
+		})
+	}
+
+	sort.Slice(s.report.Routes, func(i, j int) bool {
+		a, b := s.report.Routes[i], s.report.Routes[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Method+a.Route < b.Method+b.Route
+	})
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,53 @@
+package warehouse
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"concert-ticket-api/config"
+)
+
+// Dir writes files to a local directory, the keys being their paths in it
+type Dir struct {
+	root string
+}
+
+// NewDir creates a new directory sink
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// Name returns "dir"
+func (d *Dir) Name() string {
+	return config.WarehouseDestinationDir
+}
+
+// Put writes body to a temporary file and renames it to key, so readers
+// never see a partial file
+func (d *Dir) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	path := filepath.Join(d.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create the directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move the file in place: %w", err)
+	}
+	return nil
+}
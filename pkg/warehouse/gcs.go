@@ -0,0 +1,43 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"concert-ticket-api/config"
+)
+
+// GCS uploads files to a Google Cloud Storage bucket through the JSON API
+type GCS struct {
+	cfg    config.WarehouseGCS
+	client *http.Client
+}
+
+// NewGCS creates a new Google Cloud Storage sink
+func NewGCS(cfg config.WarehouseGCS, client *http.Client) *GCS {
+	return &GCS{cfg: cfg, client: client}
+}
+
+// Name returns "gcs"
+func (g *GCS) Name() string {
+	return config.WarehouseDestinationGCS
+}
+
+// Put uploads body to key with a single media upload
+func (g *GCS) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimRight(g.cfg.BaseURL, "/"), url.PathEscape(g.cfg.Bucket), url.QueryEscape(key))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+g.cfg.AccessToken)
+
+	return send(g.client, req)
+}
@@ -0,0 +1,101 @@
+package warehouse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"concert-ticket-api/config"
+)
+
+// CSVExtension ends the keys of the files written by EncodeCSV
+const CSVExtension = ".csv.gz"
+
+// Sink stores export files in an object store
+type Sink interface {
+	// Name identifies the store, e.g. "s3"
+	Name() string
+
+	// Put stores body under key, replacing the object at key if there is one
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// NewSink creates the sink of the configured destination
+func NewSink(cfg config.Warehouse) (Sink, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	switch cfg.Destination {
+	case config.WarehouseDestinationS3:
+		s3 := cfg.S3
+		s3.Region = or(s3.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+		if s3.AccessKeyID == "" {
+			s3.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+			s3.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+			s3.SessionToken = or(s3.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))
+		}
+		if s3.Region == "" {
+			return nil, fmt.Errorf("no AWS region, set warehouse.s3.region or AWS_REGION")
+		}
+		if s3.AccessKeyID == "" {
+			return nil, fmt.Errorf("no AWS credentials, set warehouse.s3.access_key_id or AWS_ACCESS_KEY_ID")
+		}
+		return NewS3(s3, client), nil
+	case config.WarehouseDestinationGCS:
+		return NewGCS(cfg.GCS, client), nil
+	case config.WarehouseDestinationDir:
+		return NewDir(cfg.Dir), nil
+	default:
+		return nil, fmt.Errorf("unknown warehouse destination %q", cfg.Destination)
+	}
+}
+
+// EncodeCSV writes a header row and rows as a gzipped CSV file
+func EncodeCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := csv.NewWriter(zw)
+
+	if err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write the header: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write the rows: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress the file: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// send sends req and fails on responses other than 2xx
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Object stores explain rejected uploads in the body, e.g. an expired token
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
+
+func or(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+// Package webhook signs and sends webhook requests. Each request is signed
+// with an HMAC-SHA256 of its timestamp and body, keyed with the secret of
+// the webhook, so receivers can check it came from us and wasn't replayed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers of webhook requests
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Event-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the signature algorithm, so it can change without
+// breaking receivers that check the prefix
+const signaturePrefix = "sha256="
+
+// ErrInvalidSignature is returned by Verify for requests that weren't
+// signed with the secret or are too old
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header of a body sent at a time
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a request body, and
+// that it was sent at most tolerance before now
+func Verify(secret, signature, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	sent := time.Unix(unix, 0)
+	if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ErrPrivateAddress is returned for hosts that resolve to an address that
+// isn't public, so webhooks can't reach the services next to ours
+var ErrPrivateAddress = errors.New("webhook host resolves to a private address")
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate leaves out
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublic checks if an address may receive webhooks: not loopback,
+// private, link-local (like the metadata service on 169.254.169.254),
+// unspecified, multicast or shared
+func IsPublic(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// CheckHost resolves a host and returns ErrPrivateAddress if any of its
+// addresses isn't public. A host that doesn't resolve is accepted, the
+// sender checks the addresses again when it connects.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// checkDial refuses connections to addresses that aren't public. It runs
+// after the host was resolved, so a host can't pass CheckHost and then
+// resolve to a private address when the request is sent.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Request is a webhook request to send
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	EventID    int64
+	DeliveryID int64
+	Body       []byte
+}
+
+// Response is the outcome of a request that got a response
+type Response struct {
+	StatusCode int
+}
+
+// OK checks if the receiver accepted the request
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode <= 299
+}
+
+// Sender sends signed webhook requests
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose requests time out after timeout.
+// Redirects aren't followed: a receiver that moved should be updated.
+// Unless allowPrivate is set, connections to addresses that aren't public
+// are refused, and no proxy is used so the check sees the receiver.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = checkDial
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send posts a request signed at now. It returns an error if there was no
+// response; a response of any status is returned for the caller to judge.
+func (s *Sender) Send(ctx context.Context, req *Request, now time.Time) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "concert-ticket-api-webhooks")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderEventID, strconv.FormatInt(req.EventID, 10))
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatInt(req.DeliveryID, 10))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, now, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The body isn't kept, receivers aren't trusted to say what ends up in
+	// the delivery log. Draining a little of it lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return &Response{StatusCode: resp.StatusCode}, nil
+}
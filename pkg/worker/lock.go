@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"concert-ticket-api/pkg/query"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Locker makes sure an exclusive job runs on a single instance at a time
+type Locker interface {
+	// TryLock takes the lock of a job without waiting. ok is false when
+	// another instance holds it; otherwise unlock releases it after the run.
+	TryLock(ctx context.Context, job string) (unlock func(), ok bool, err error)
+}
+
+// Outcomes of a run
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Run records one run of an exclusive job
+type Run struct {
+	ID         int64     `json:"id" db:"id"`
+	Job        string    `json:"job" db:"job_name"`
+	InstanceID string    `json:"instance_id" db:"instance_id"`
+	StartedAt  time.Time `json:"started_at" db:"started_at"`
+	FinishedAt time.Time `json:"finished_at" db:"finished_at"`
+	Outcome    string    `json:"outcome" db:"outcome"`
+	Error      string    `json:"error,omitempty" db:"error"`
+}
+
+// RunStore keeps the history of the runs of exclusive jobs, shared by all
+// instances
+type RunStore interface {
+	// RecordRun stores a finished run
+	RecordRun(ctx context.Context, run *Run) error
+
+	// ListRuns returns a page of the runs of a job, latest first, and the
+	// total number of its runs
+	ListRuns(ctx context.Context, job string, page query.Page) ([]*Run, int, error)
+}
+
+// Results of taking the lock of a job
+const (
+	lockAcquired  = "acquired"
+	lockContended = "contended"
+	lockError     = "error"
+)
+
+var (
+	lockAcquisitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_lock_acquisitions_total",
+		Help: "Attempts to take the lock of an exclusive job, by result.",
+	}, []string{"job", "result"})
+
+	lockDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "worker_lock_acquire_duration_seconds",
+		Help:    "Time taken to try the lock of an exclusive job.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 6),
+	}, []string{"job"})
+
+	skippedRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_runs_skipped_total",
+		Help: "Runs skipped because the worker was paused, still busy or locked by another instance.",
+	}, []string{"job", "reason"})
+
+	runDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "worker_run_duration_seconds",
+		Help: "Duration of background job runs, by outcome.",
+	}, []string{"job", "outcome"})
+)
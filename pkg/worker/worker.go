@@ -0,0 +1,344 @@
+// Package worker runs the periodic background jobs of the service and lets
+// operators pause and resume individual jobs without restarting the process.
+// Exclusive jobs run on one instance at a time and record their runs, so the
+// replica that executed a job can be told afterwards.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/query"
+)
+
+// Job is one run of a background job
+type Job func(ctx context.Context) error
+
+// State is whether a worker runs its job
+type State string
+
+// States of a worker
+const (
+	StateRunning State = "running"
+	StatePaused  State = "paused"
+)
+
+// ErrUnknownWorker is returned for names no worker was registered under
+var ErrUnknownWorker = errors.New("unknown worker")
+
+// Status is the current state of a worker
+type Status struct {
+	Name     string `json:"name"`
+	State    State  `json:"state"`
+	Interval string `json:"interval"`
+	// Since is when the worker entered its current state
+	Since time.Time `json:"since"`
+	// PausedBy identifies the operator who paused the worker
+	PausedBy string `json:"paused_by,omitempty"`
+	// Exclusive workers take a lock shared by all instances before each run
+	Exclusive bool `json:"exclusive"`
+	// Busy is set while a run is in progress
+	Busy bool `json:"busy"`
+	// Runs counts the runs on this instance
+	Runs int64 `json:"runs"`
+	// LockContended counts the runs skipped because another instance held the lock
+	LockContended int64      `json:"lock_contended"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+type worker struct {
+	job       Job
+	interval  time.Duration
+	exclusive bool
+	status    Status
+}
+
+// Registry runs registered jobs at their intervals. Pausing a worker skips
+// its runs until it is resumed; a run in progress when the worker is paused
+// is finished, so jobs are never interrupted halfway.
+type Registry struct {
+	instanceID string
+	locker     Locker
+	runs       RunStore
+
+	mutex   sync.RWMutex
+	workers map[string]*worker
+
+	// stopping is closed by Stop to end the loops started by Start, and
+	// abort cancels the runs that outlast the deadline of Stop
+	stopping chan struct{}
+	abort    context.CancelFunc
+	loops    sync.WaitGroup
+}
+
+// NewRegistry creates an empty registry for the instance with the given ID.
+// Without a locker exclusive jobs run on every instance, and without a run
+// store their runs aren't recorded.
+func NewRegistry(instanceID string, locker Locker, runs RunStore) *Registry {
+	return &Registry{
+		instanceID: instanceID,
+		locker:     locker,
+		runs:       runs,
+		workers:    make(map[string]*worker),
+	}
+}
+
+// InstanceID returns the ID of the instance the registry runs on
+func (r *Registry) InstanceID() string {
+	return r.instanceID
+}
+
+// Register adds a job that runs every interval once the registry is started.
+// Every instance runs it, which suits jobs working on per-instance state.
+func (r *Registry) Register(name string, interval time.Duration, job Job) {
+	r.register(name, interval, job, false)
+}
+
+// RegisterExclusive adds a job that runs every interval on whichever instance
+// takes its lock first; the other instances skip that run
+func (r *Registry) RegisterExclusive(name string, interval time.Duration, job Job) {
+	r.register(name, interval, job, true)
+}
+
+func (r *Registry) register(name string, interval time.Duration, job Job, exclusive bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.workers[name] = &worker{
+		job:       job,
+		interval:  interval,
+		exclusive: exclusive,
+		status: Status{
+			Name:      name,
+			State:     StateRunning,
+			Interval:  interval.String(),
+			Since:     clock.Now(),
+			Exclusive: exclusive,
+		},
+	}
+}
+
+// Start runs every registered job at its interval until ctx is done or the
+// registry is stopped
+func (r *Registry) Start(ctx context.Context) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ctx, r.abort = context.WithCancel(ctx)
+	r.stopping = make(chan struct{})
+	for name, w := range r.workers {
+		r.loops.Add(1)
+		go r.loop(ctx, r.stopping, name, w.interval)
+	}
+}
+
+// Stop ends the loops started by Start and waits for the runs in progress to
+// finish. Runs still going when ctx is done are cancelled.
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mutex.Lock()
+	stopping, abort := r.stopping, r.abort
+	r.stopping, r.abort = nil, nil
+	r.mutex.Unlock()
+
+	if stopping == nil {
+		return nil
+	}
+	close(stopping)
+	defer abort()
+
+	finished := make(chan struct{})
+	go func() {
+		r.loops.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers still running: %w", ctx.Err())
+	}
+}
+
+func (r *Registry) loop(ctx context.Context, stopping <-chan struct{}, name string, interval time.Duration) {
+	defer r.loops.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping:
+			return
+		case <-ticker.C:
+			// A tick may be ready along with the stop, and no run may
+			// start once the registry is stopping
+			select {
+			case <-stopping:
+				return
+			default:
+			}
+			r.RunOnce(ctx, name)
+		}
+	}
+}
+
+// RunOnce runs a job now unless its worker is paused or already busy, or
+// another instance holds the lock of an exclusive job, and reports whether
+// it ran
+func (r *Registry) RunOnce(ctx context.Context, name string) bool {
+	r.mutex.Lock()
+	w, ok := r.workers[name]
+	if !ok {
+		r.mutex.Unlock()
+		return false
+	}
+	if w.status.State == StatePaused || w.status.Busy {
+		reason := "busy"
+		if w.status.State == StatePaused {
+			reason = "paused"
+		}
+		r.mutex.Unlock()
+		skippedRuns.WithLabelValues(name, reason).Inc()
+		return false
+	}
+	w.status.Busy = true
+	r.mutex.Unlock()
+
+	unlock := func() {}
+	if w.exclusive && r.locker != nil {
+		var locked bool
+		var err error
+		if unlock, locked, err = r.lock(ctx, name); err != nil || !locked {
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+
+			w.status.Busy = false
+			if err != nil {
+				w.status.LastError = err.Error()
+			} else {
+				w.status.LockContended++
+			}
+			skippedRuns.WithLabelValues(name, "locked").Inc()
+			return false
+		}
+	}
+
+	started := clock.Now()
+	err := w.job(ctx)
+	finished := clock.Now()
+	unlock()
+
+	outcome := OutcomeSucceeded
+	if err != nil {
+		outcome = OutcomeFailed
+	}
+	runDuration.WithLabelValues(name, outcome).Observe(finished.Sub(started).Seconds())
+
+	var recordErr error
+	if w.exclusive && r.runs != nil {
+		run := &Run{Job: name, InstanceID: r.instanceID, StartedAt: started, FinishedAt: finished, Outcome: outcome}
+		if err != nil {
+			run.Error = err.Error()
+		}
+		recordErr = r.runs.RecordRun(ctx, run)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	w.status.Busy = false
+	w.status.Runs++
+	w.status.LastRunAt = &finished
+	w.status.LastError = ""
+	if err != nil {
+		w.status.LastError = err.Error()
+	} else if recordErr != nil {
+		w.status.LastError = fmt.Sprintf("failed to record run: %v", recordErr)
+	}
+	return true
+}
+
+// lock tries the lock of an exclusive job and counts the result
+func (r *Registry) lock(ctx context.Context, name string) (func(), bool, error) {
+	start := clock.Now()
+	unlock, locked, err := r.locker.TryLock(ctx, name)
+	lockDuration.WithLabelValues(name).Observe(clock.Now().Sub(start).Seconds())
+
+	switch {
+	case err != nil:
+		lockAcquisitions.WithLabelValues(name, lockError).Inc()
+		return nil, false, fmt.Errorf("failed to take the job lock: %w", err)
+	case !locked:
+		lockAcquisitions.WithLabelValues(name, lockContended).Inc()
+		return nil, false, nil
+	default:
+		lockAcquisitions.WithLabelValues(name, lockAcquired).Inc()
+		return unlock, true, nil
+	}
+}
+
+// Runs returns a page of the recorded runs of a worker across all instances,
+// latest first, and the total number of its runs
+func (r *Registry) Runs(ctx context.Context, name string, page query.Page) ([]*Run, int, error) {
+	r.mutex.RLock()
+	_, ok := r.workers[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return nil, 0, ErrUnknownWorker
+	}
+
+	if r.runs == nil {
+		return []*Run{}, 0, nil
+	}
+	return r.runs.ListRuns(ctx, name, page)
+}
+
+// Pause stops a worker from running its job until it is resumed
+func (r *Registry) Pause(name, actor string) (Status, error) {
+	return r.setState(name, StatePaused, actor)
+}
+
+// Resume lets a paused worker run its job again from its next tick
+func (r *Registry) Resume(name string) (Status, error) {
+	return r.setState(name, StateRunning, "")
+}
+
+func (r *Registry) setState(name string, state State, actor string) (Status, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	w, ok := r.workers[name]
+	if !ok {
+		return Status{}, ErrUnknownWorker
+	}
+
+	if w.status.State != state {
+		w.status.State = state
+		w.status.Since = clock.Now()
+		w.status.PausedBy = actor
+	}
+	return w.status, nil
+}
+
+// Statuses returns the state of every worker, sorted by name
+func (r *Registry) Statuses() []Status {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	statuses := make([]Status, 0, len(r.workers))
+	for _, w := range r.workers {
+		statuses = append(statuses, w.status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
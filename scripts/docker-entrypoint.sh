@@ -9,6 +9,10 @@ until nc -z db 5432; do
 done
 echo "PostgreSQL is ready!"
 
+# Apply pending migrations before serving
+echo "Running database migrations..."
+/app/concert-ticket-api migrate up
+
 # Start the application
 echo "Starting Concert Ticket API..."
 exec "/app/concert-ticket-api"
\ No newline at end of file
@@ -0,0 +1,16 @@
+#!/bin/sh
+# Regenerates the gRPC code and the REST gateway from api/grpc/proto, and the
+# schemas of the published events from api/events/proto.
+# Requires protoc, protoc-gen-go, protoc-gen-go-grpc and protoc-gen-grpc-gateway.
+set -e
+cd "$(dirname "$0")/.."
+
+protoc -I . -I third_party/googleapis \
+  --go_out=. --go_opt=paths=source_relative \
+  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+  --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative \
+  api/grpc/proto/*.proto
+
+protoc -I . \
+  --go_out=. --go_opt=paths=source_relative \
+  api/events/proto/*.proto
@@ -0,0 +1,2 @@
+ALTER TABLE bookings DROP COLUMN IF EXISTS attendee_email;
+ALTER TABLE bookings DROP COLUMN IF EXISTS attendee_name;
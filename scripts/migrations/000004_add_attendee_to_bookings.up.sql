@@ -0,0 +1,3 @@
+-- Attendee contact details are stored encrypted by the application
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS attendee_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS attendee_email TEXT NOT NULL DEFAULT '';
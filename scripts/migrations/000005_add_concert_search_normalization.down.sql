@@ -0,0 +1,5 @@
+ALTER TABLE concerts DROP COLUMN IF EXISTS search_venue;
+ALTER TABLE concerts DROP COLUMN IF EXISTS search_artist;
+ALTER TABLE concerts DROP COLUMN IF EXISTS search_name;
+ALTER TABLE concerts DROP COLUMN IF EXISTS venue_aliases;
+ALTER TABLE concerts DROP COLUMN IF EXISTS artist_aliases;
@@ -0,0 +1,12 @@
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS artist_aliases JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS venue_aliases JSONB NOT NULL DEFAULT '[]';
+
+-- Normalized (lowercased, unaccented) search keys are written by the application.
+-- Existing rows are backfilled with a lowercase approximation until they are next updated.
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS search_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS search_artist TEXT NOT NULL DEFAULT '';
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS search_venue TEXT NOT NULL DEFAULT '';
+
+UPDATE concerts
+SET search_name = lower(name), search_artist = lower(artist), search_venue = lower(venue)
+WHERE search_name = '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS booking_tokens;
+ALTER TABLE concerts DROP COLUMN IF EXISTS requires_booking_token;
@@ -0,0 +1,12 @@
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS requires_booking_token BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS booking_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    user_id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_booking_tokens_expires_at ON booking_tokens(expires_at);
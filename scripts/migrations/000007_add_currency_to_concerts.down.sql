@@ -0,0 +1 @@
+ALTER TABLE concerts DROP COLUMN IF EXISTS currency;
@@ -0,0 +1 @@
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
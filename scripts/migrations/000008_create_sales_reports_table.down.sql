@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS sales_reports;
+
+DROP INDEX IF EXISTS idx_concerts_reporting_state;
+
+ALTER TABLE concerts DROP COLUMN IF EXISTS reporting_claimed_at;
+ALTER TABLE concerts DROP COLUMN IF EXISTS reporting_state;
+ALTER TABLE concerts DROP COLUMN IF EXISTS organizer_email;
@@ -0,0 +1,21 @@
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS organizer_email TEXT NOT NULL DEFAULT '';
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS reporting_state VARCHAR(20) NOT NULL DEFAULT 'open';
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS reporting_claimed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_concerts_reporting_state ON concerts(reporting_state);
+
+CREATE TABLE IF NOT EXISTS sales_reports (
+    concert_id INT PRIMARY KEY REFERENCES concerts(id),
+    reason VARCHAR(20) NOT NULL,
+    tickets_sold INT NOT NULL,
+    tickets_available INT NOT NULL,
+    confirmed_bookings INT NOT NULL,
+    cancelled_bookings INT NOT NULL,
+    revenue DECIMAL(12, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    report_csv TEXT NOT NULL,
+    availability_history JSONB NOT NULL DEFAULT '[]',
+    recipient TEXT NOT NULL DEFAULT '',
+    emailed_at TIMESTAMP,
+    generated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
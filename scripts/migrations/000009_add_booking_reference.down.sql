@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bookings_reference;
+ALTER TABLE bookings DROP COLUMN IF EXISTS reference;
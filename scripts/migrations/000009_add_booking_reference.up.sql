@@ -0,0 +1,10 @@
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS reference VARCHAR(16);
+
+-- Existing bookings get a random reference; new ones are generated by the application
+UPDATE bookings
+SET reference = upper(substr(md5(random()::text || id::text), 1, 12))
+WHERE reference IS NULL;
+
+ALTER TABLE bookings ALTER COLUMN reference SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_reference ON bookings(reference);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_accounting_sync_pending;
+
+DROP TABLE IF EXISTS accounting_sync;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS accounting_sync (
+    booking_id INT NOT NULL REFERENCES bookings(id),
+    entry_type VARCHAR(10) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    external_id TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    synced_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (booking_id, entry_type, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_sync_pending ON accounting_sync(provider) WHERE synced_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_concert_price_history_concert;
+
+DROP TABLE IF EXISTS concert_price_history;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS concert_price_history (
+    id SERIAL PRIMARY KEY,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    price DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_price_history_concert ON concert_price_history(concert_id, recorded_at);
+
+-- Start the history of existing concerts with their current price
+INSERT INTO concert_price_history (concert_id, price, currency, recorded_at)
+SELECT id, price, currency, created_at FROM concerts
+WHERE NOT EXISTS (SELECT 1 FROM concert_price_history h WHERE h.concert_id = concerts.id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_booking_attempts_concert;
+DROP INDEX IF EXISTS idx_booking_attempts_attempted_at;
+
+DROP TABLE IF EXISTS booking_attempts;
@@ -0,0 +1,13 @@
+-- No foreign key on concert_id: attempts for unknown concerts are recorded too
+CREATE TABLE IF NOT EXISTS booking_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    concert_id INT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    latency_ms INT NOT NULL,
+    retries INT NOT NULL DEFAULT 0,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_attempts_attempted_at ON booking_attempts(attempted_at);
+CREATE INDEX IF NOT EXISTS idx_booking_attempts_concert ON booking_attempts(concert_id, attempted_at);
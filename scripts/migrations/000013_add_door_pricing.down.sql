@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_concerts_door_price_pending;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS unit_price;
+
+ALTER TABLE concerts DROP COLUMN IF EXISTS door_price_switched_at;
+ALTER TABLE concerts DROP COLUMN IF EXISTS door_price_lead_minutes;
+ALTER TABLE concerts DROP COLUMN IF EXISTS door_price;
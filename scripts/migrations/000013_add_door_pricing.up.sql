@@ -0,0 +1,16 @@
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS door_price DECIMAL(10, 2);
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS door_price_lead_minutes INT NOT NULL DEFAULT 0;
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS door_price_switched_at TIMESTAMP;
+
+-- Bookings keep the price they were made at, since it can change with the pricing phase
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS unit_price DECIMAL(10, 2);
+
+UPDATE bookings b
+SET unit_price = c.price
+FROM concerts c
+WHERE c.id = b.concert_id AND b.unit_price IS NULL;
+
+ALTER TABLE bookings ALTER COLUMN unit_price SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_concerts_door_price_pending ON concerts(concert_date)
+WHERE door_price IS NOT NULL AND door_price_switched_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_concert_inventory_releases_pending;
+DROP INDEX IF EXISTS idx_concert_inventory_releases_concert;
+
+DROP TABLE IF EXISTS concert_inventory_releases;
@@ -0,0 +1,14 @@
+-- Tickets of a pending release are held back from available_tickets until released_at is set
+CREATE TABLE IF NOT EXISTS concert_inventory_releases (
+    id SERIAL PRIMARY KEY,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    quantity INT NOT NULL,
+    release_at TIMESTAMP NOT NULL,
+    released_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_release_quantity CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_inventory_releases_concert ON concert_inventory_releases(concert_id, release_at);
+CREATE INDEX IF NOT EXISTS idx_concert_inventory_releases_pending ON concert_inventory_releases(release_at)
+WHERE released_at IS NULL;
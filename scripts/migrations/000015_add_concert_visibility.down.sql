@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_concerts_listed;
+
+ALTER TABLE concerts DROP COLUMN IF EXISTS invite_token_hash;
+ALTER TABLE concerts DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,7 @@
+-- Unlisted concerts are only reachable by ID, private ones also need their invite token
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'unlisted', 'private'));
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS invite_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_concerts_listed ON concerts(concert_date)
+WHERE visibility = 'public';
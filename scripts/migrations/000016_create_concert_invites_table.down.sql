@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_concert_invite_redemptions_invite;
+DROP INDEX IF EXISTS idx_concert_invites_concert;
+
+DROP TABLE IF EXISTS concert_invite_redemptions;
+DROP TABLE IF EXISTS concert_invites;
@@ -0,0 +1,23 @@
+-- Invites grant access to private concerts; only the hash of their token is stored
+CREATE TABLE IF NOT EXISTS concert_invites (
+    id SERIAL PRIMARY KEY,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    invitee VARCHAR(255) NOT NULL DEFAULT '',
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    redemptions INT NOT NULL DEFAULT 0,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_invite_redemptions CHECK (redemptions >= 0 AND (NOT single_use OR redemptions <= 1))
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_invites_concert ON concert_invites(concert_id);
+
+-- One row per booking made with an invite
+CREATE TABLE IF NOT EXISTS concert_invite_redemptions (
+    id SERIAL PRIMARY KEY,
+    invite_id INT NOT NULL REFERENCES concert_invites(id),
+    booking_reference VARCHAR(16) NOT NULL UNIQUE,
+    redeemed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_invite_redemptions_invite ON concert_invite_redemptions(invite_id);
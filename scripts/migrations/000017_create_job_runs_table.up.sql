@@ -0,0 +1,14 @@
+-- One row per run of an exclusive background job, naming the instance that ran it
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(64) NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    CONSTRAINT valid_job_run_outcome CHECK (outcome IN ('succeeded', 'failed')),
+    CONSTRAINT valid_job_run_period CHECK (finished_at >= started_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job_name, started_at DESC);
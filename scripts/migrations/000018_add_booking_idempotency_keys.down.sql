@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_bookings_user_idempotency_key;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS idempotency_key;
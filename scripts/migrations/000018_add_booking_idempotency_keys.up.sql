@@ -0,0 +1,6 @@
+-- Clients retrying a booking send the same key, so the retry returns the
+-- booking already made instead of booking twice. Keys are scoped to the user.
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_user_idempotency_key
+    ON bookings(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,10 @@
+-- The latest snapshot of the shared waiting room, to recover the queues if
+-- Redis loses them. The state is encrypted JSON, as it holds invite tokens.
+CREATE TABLE IF NOT EXISTS waiting_room_snapshots (
+    id SMALLINT PRIMARY KEY DEFAULT 1,
+    format INT NOT NULL,
+    version BIGINT NOT NULL,
+    state TEXT NOT NULL,
+    taken_at TIMESTAMP NOT NULL,
+    CONSTRAINT single_waiting_room_snapshot CHECK (id = 1)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE concerts DROP COLUMN IF EXISTS max_order_value;
+ALTER TABLE concerts DROP COLUMN IF EXISTS max_tickets_per_booking;
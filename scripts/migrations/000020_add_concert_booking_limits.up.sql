@@ -0,0 +1,3 @@
+-- Concerts without their own limits use the configured defaults
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS max_tickets_per_booking INT;
+ALTER TABLE concerts ADD COLUMN IF NOT EXISTS max_order_value DECIMAL(10, 2);
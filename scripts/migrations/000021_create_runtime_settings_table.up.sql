@@ -0,0 +1,10 @@
+-- The operational settings changed at runtime through the internal admin
+-- listener. There is one row, replaced as a whole on every change.
+CREATE TABLE IF NOT EXISTS runtime_settings (
+    id SMALLINT PRIMARY KEY DEFAULT 1,
+    settings JSONB NOT NULL,
+    version INT NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT single_runtime_settings CHECK (id = 1)
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS concert_inventory_guard ON concerts;
+DROP FUNCTION IF EXISTS guard_concert_inventory();
+DROP INDEX IF EXISTS idx_bookings_inventory_pending;
+ALTER TABLE bookings DROP COLUMN IF EXISTS inventory_pending;
@@ -0,0 +1,38 @@
+-- In the redis inventory mode bookings take their tickets from a counter in
+-- Redis, and a worker subtracts them from the concert later. Until then the
+-- booking is pending, and its tickets are taken although the concert still
+-- counts them as available.
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS inventory_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_bookings_inventory_pending ON bookings(concert_id) WHERE inventory_pending;
+
+-- Counters are computed while holding the concert's inventory lock shared,
+-- so a change of the tickets, which takes it exclusively, waits for the
+-- reservations in flight. Whatever makes the change, it can't give away
+-- tickets pending bookings took. The lock namespace is "invt".
+CREATE OR REPLACE FUNCTION guard_concert_inventory() RETURNS TRIGGER AS $$
+DECLARE
+    pending BIGINT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(1768846964, NEW.id);
+
+    IF NEW.available_tickets < OLD.available_tickets THEN
+        SELECT COALESCE(SUM(ticket_count), 0) INTO pending
+        FROM bookings WHERE concert_id = NEW.id AND inventory_pending;
+
+        IF NEW.available_tickets < pending THEN
+            RAISE EXCEPTION 'concert % has fewer available tickets than its pending bookings took', NEW.id
+                USING ERRCODE = 'check_violation', CONSTRAINT = 'concert_inventory';
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS concert_inventory_guard ON concerts;
+CREATE TRIGGER concert_inventory_guard
+    BEFORE UPDATE ON concerts
+    FOR EACH ROW
+    WHEN (OLD.version IS DISTINCT FROM NEW.version OR OLD.available_tickets IS DISTINCT FROM NEW.available_tickets)
+    EXECUTE FUNCTION guard_concert_inventory();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_bookings_order_id;
+ALTER TABLE bookings DROP COLUMN IF EXISTS order_id;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS cart_items;
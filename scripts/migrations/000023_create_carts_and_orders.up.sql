@@ -0,0 +1,26 @@
+-- Each user has one cart, the tickets they mean to book at checkout
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id VARCHAR(255) NOT NULL,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    ticket_count INT NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, concert_id),
+    CONSTRAINT valid_cart_ticket_count CHECK (ticket_count > 0)
+);
+
+-- A checkout makes one order, grouping the bookings it made. The total is
+-- the sum of the bookings, which share the order's currency.
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    reference VARCHAR(16) NOT NULL UNIQUE,
+    user_id VARCHAR(255) NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS order_id INT REFERENCES orders(id);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_order_id ON bookings(order_id) WHERE order_id IS NOT NULL;
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+DROP INDEX IF EXISTS idx_orders_user_created;
+ALTER TABLE orders DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
@@ -0,0 +1,11 @@
+INSERT INTO bookings (id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+    attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id)
+SELECT id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+    attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id
+FROM bookings_archive;
+
+DROP VIEW IF EXISTS all_bookings;
+DROP TABLE IF EXISTS bookings_archive;
+
+ALTER TABLE accounting_sync
+    ADD CONSTRAINT accounting_sync_booking_id_fkey FOREIGN KEY (booking_id) REFERENCES bookings(id);
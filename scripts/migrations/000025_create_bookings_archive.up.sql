@@ -0,0 +1,46 @@
+-- Bookings of concerts that took place long ago are moved here by
+-- cmd/archive, so the bookings table only holds those still being booked,
+-- cancelled and checked. The archive is partitioned by the concert date, one
+-- partition a year, which cmd/archive creates as it needs them. The bookings
+-- table itself isn't partitioned: its references, idempotency keys and
+-- primary key are unique across all concerts, which a partitioned table can
+-- only enforce per partition.
+CREATE TABLE IF NOT EXISTS bookings_archive (
+    id INT NOT NULL,
+    concert_id INT NOT NULL REFERENCES concerts(id),
+    user_id VARCHAR(255) NOT NULL,
+    ticket_count INT NOT NULL,
+    booking_time TIMESTAMP,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    attendee_name TEXT NOT NULL DEFAULT '',
+    attendee_email TEXT NOT NULL DEFAULT '',
+    reference VARCHAR(16) NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    idempotency_key VARCHAR(255),
+    inventory_pending BOOLEAN NOT NULL DEFAULT FALSE,
+    order_id INT REFERENCES orders(id),
+    concert_date TIMESTAMP NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, concert_date)
+) PARTITION BY RANGE (concert_date);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_archive_reference ON bookings_archive(reference);
+CREATE INDEX IF NOT EXISTS idx_bookings_archive_user_id ON bookings_archive(user_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_archive_concert_id ON bookings_archive(concert_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_archive_order_id ON bookings_archive(order_id) WHERE order_id IS NOT NULL;
+
+-- The reads that find past bookings too, such as a user's bookings, their
+-- data export and order receipts, read both tables through this view
+CREATE OR REPLACE VIEW all_bookings AS
+    SELECT id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+        attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id
+    FROM bookings
+    UNION ALL
+    SELECT id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+        attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id
+    FROM bookings_archive;
+
+-- Accounting keeps the sync state of archived bookings
+ALTER TABLE accounting_sync DROP CONSTRAINT IF EXISTS accounting_sync_booking_id_fkey;
@@ -0,0 +1,20 @@
+-- Domain events of concerts and bookings, written in the transaction of the
+-- change they describe and relayed to the message broker by the server. The
+-- id orders the events of an aggregate: the changes of one aggregate lock its
+-- row, so the later change takes its id after the earlier one committed.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(50) NOT NULL,
+    -- The concert ID or the booking reference
+    aggregate_id VARCHAR(50) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_attempt_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events(created_at);
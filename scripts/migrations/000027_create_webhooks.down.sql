@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_events_undispatched;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS dispatched_at;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
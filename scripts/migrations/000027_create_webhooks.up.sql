@@ -0,0 +1,42 @@
+-- Webhooks registered by organizers, and the deliveries of domain events to
+-- them. The secret signs the deliveries, encrypted like attendee details.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- JSON array of the event types delivered, such as booking.confirmed
+    event_types JSONB NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One delivery per webhook and event. The event is copied, so purging the
+-- outbox doesn't lose deliveries still being retried.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    event_created_at TIMESTAMP NOT NULL,
+    -- pending, delivered or dead
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_attempt_at TIMESTAMP,
+    response_status INT,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+
+-- Events are fanned out to the webhooks once, whether or not the broker
+-- relay published them yet
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dispatched_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_undispatched ON outbox_events(id) WHERE dispatched_at IS NULL;
@@ -0,0 +1,22 @@
+-- Payments of bookings taken through a payment provider. A booking is
+-- pending, holding its tickets, until its payment succeeds or the hold
+-- expires. The client secret completes the payment on the client and is
+-- encrypted like attendee details.
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    booking_id BIGINT NOT NULL UNIQUE REFERENCES bookings(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    -- The ID of the payment at the provider, such as a Stripe PaymentIntent
+    provider_reference VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL DEFAULT '',
+    amount DECIMAL(12, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    -- pending, succeeded, failed or canceled
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, provider_reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_expiring ON payments(expires_at) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_unsettled;
+
+CREATE INDEX IF NOT EXISTS idx_payments_expiring ON payments(expires_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- Payments are authorized before they are captured. The expiry job also
+-- picks up authorized payments whose capture didn't go through.
+DROP INDEX IF EXISTS idx_payments_expiring;
+
+CREATE INDEX IF NOT EXISTS idx_payments_unsettled ON payments(expires_at) WHERE status IN ('pending', 'authorized');
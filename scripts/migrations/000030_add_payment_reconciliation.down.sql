@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payments_mismatch;
+DROP INDEX IF EXISTS idx_payments_reconciled;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS mismatch;
+ALTER TABLE payments DROP COLUMN IF EXISTS reconciled_at;
@@ -0,0 +1,8 @@
+-- The reconciliation job compares the bookings of payments with the
+-- provider's records, the least recently reconciled first, and flags the
+-- payments whose booking disagrees
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMP;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS mismatch VARCHAR(30) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_payments_reconciled ON payments(reconciled_at NULLS FIRST, id);
+CREATE INDEX IF NOT EXISTS idx_payments_mismatch ON payments(mismatch) WHERE mismatch <> '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_events_unnotified;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS notified_at;
+
+DROP TABLE IF EXISTS notifications;
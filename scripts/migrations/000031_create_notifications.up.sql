@@ -0,0 +1,30 @@
+-- Notifications of bookings sent to their attendees, such as confirmation
+-- emails. The recipient is read from the booking when a notification is
+-- sent, so no personal data is copied here.
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    -- email
+    channel VARCHAR(20) NOT NULL,
+    template VARCHAR(50) NOT NULL,
+    booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    -- The outbox event the notification is about, 0 for reminders
+    event_id BIGINT NOT NULL DEFAULT 0,
+    previous_concert_date TIMESTAMP,
+    -- pending, sent or dead
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (channel, template, booking_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications(next_attempt_at) WHERE status = 'pending';
+
+-- Events are turned into notifications once, whether or not they were
+-- published or dispatched to webhooks yet. The events written before
+-- notifications existed aren't.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS notified_at TIMESTAMP;
+UPDATE outbox_events SET notified_at = created_at WHERE notified_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unnotified ON outbox_events(id) WHERE notified_at IS NULL;
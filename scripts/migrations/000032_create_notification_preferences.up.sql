@@ -0,0 +1,12 @@
+-- The channels users want to be notified on. Users without a row are only
+-- emailed. The phone number is encrypted like attendee details.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    sms BOOLEAN NOT NULL DEFAULT FALSE,
+    phone_number TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Reminders skip the users who turned email off
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_no_email ON notification_preferences(user_id) WHERE NOT email;
@@ -0,0 +1,5 @@
+-- Users also choose push notifications, which the apps read, and turn the
+-- notifications of each template on or off per channel, as a JSON object
+-- like {"email": {"concert_reminder": false}}
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS push BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS types JSONB NOT NULL DEFAULT '{}';
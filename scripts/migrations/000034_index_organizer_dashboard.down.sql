@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bookings_concert_time;
+DROP INDEX IF EXISTS idx_concerts_organizer_email;
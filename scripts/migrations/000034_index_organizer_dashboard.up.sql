@@ -0,0 +1,4 @@
+-- The organizer dashboard aggregates the concerts of an organizer, and the
+-- sales of a day
+CREATE INDEX IF NOT EXISTS idx_concerts_organizer_email ON concerts(organizer_email);
+CREATE INDEX IF NOT EXISTS idx_bookings_concert_time ON bookings(concert_id, booking_time);
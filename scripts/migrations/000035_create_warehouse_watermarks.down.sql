@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_concerts_updated_at;
+DROP INDEX IF EXISTS idx_bookings_archive_updated_at;
+DROP INDEX IF EXISTS idx_bookings_updated_at;
+DROP TABLE IF EXISTS warehouse_watermarks;
@@ -0,0 +1,15 @@
+-- How far the warehouse export got in each dataset: every row changed before
+-- updated_at, and those changed at updated_at up to last_id, were exported
+CREATE TABLE IF NOT EXISTS warehouse_watermarks (
+    dataset VARCHAR(32) PRIMARY KEY,
+    updated_at TIMESTAMP NOT NULL,
+    last_id BIGINT NOT NULL,
+    rows_exported BIGINT NOT NULL DEFAULT 0,
+    last_object_key TEXT NOT NULL DEFAULT '',
+    exported_at TIMESTAMP NOT NULL
+);
+
+-- The export reads the rows changed after the watermark in this order
+CREATE INDEX IF NOT EXISTS idx_bookings_updated_at ON bookings(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_bookings_archive_updated_at ON bookings_archive(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_concerts_updated_at ON concerts(updated_at, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_webhooks_organizer_email;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS organizer_email;
@@ -0,0 +1,5 @@
+-- Webhooks belong to the organizer who registered them and only receive the
+-- events of the organizer's concerts. Webhooks registered before have no
+-- organizer and receive nothing until they are registered again.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS organizer_email TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_webhooks_organizer_email ON webhooks(organizer_email);
@@ -0,0 +1,11 @@
+// Package migrations embeds the schema migrations in the binary, so it
+// migrates from any working directory. Those of postgres are at the top,
+// those of mysql and sqlite in the directory named after the driver.
+package migrations
+
+import "embed"
+
+// FS holds the migrations of every driver
+//
+//go:embed *.sql mysql/*.sql sqlite/*.sql
+var FS embed.FS
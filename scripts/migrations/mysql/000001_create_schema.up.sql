@@ -0,0 +1,120 @@
+-- The tables of the features the mysql driver supports, as of the Postgres
+-- migrations up to 000020. Times are stored in UTC.
+CREATE TABLE IF NOT EXISTS concerts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    artist VARCHAR(255) NOT NULL,
+    venue VARCHAR(255) NOT NULL,
+    concert_date DATETIME(6) NOT NULL,
+    total_tickets INT NOT NULL,
+    available_tickets INT NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    booking_start_time DATETIME(6) NOT NULL,
+    booking_end_time DATETIME(6) NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    artist_aliases JSON NOT NULL,
+    venue_aliases JSON NOT NULL,
+    search_name VARCHAR(255) NOT NULL DEFAULT '',
+    search_artist TEXT NOT NULL,
+    search_venue TEXT NOT NULL,
+    requires_booking_token BOOLEAN NOT NULL DEFAULT FALSE,
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    organizer_email VARCHAR(255) NOT NULL DEFAULT '',
+    reporting_state VARCHAR(20) NOT NULL DEFAULT 'open',
+    reporting_claimed_at DATETIME(6) NULL,
+    door_price DECIMAL(10, 2) NULL,
+    door_price_lead_minutes INT NOT NULL DEFAULT 0,
+    -- concert_date less the lead, kept by the application since the door
+    -- price queries can't compute it portably
+    door_price_starts_at DATETIME(6) NULL,
+    door_price_switched_at DATETIME(6) NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+    invite_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    max_tickets_per_booking INT NULL,
+    max_order_value DECIMAL(10, 2) NULL,
+    CONSTRAINT valid_visibility CHECK (visibility IN ('public', 'unlisted', 'private')),
+    INDEX idx_concerts_date (concert_date),
+    INDEX idx_concerts_door_price_pending (door_price_starts_at, door_price_switched_at)
+);
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    reference VARCHAR(16) NOT NULL,
+    concert_id BIGINT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    ticket_count INT NOT NULL,
+    booking_time DATETIME(6) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+    attendee_name TEXT NOT NULL,
+    attendee_email TEXT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    idempotency_key VARCHAR(255) NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    CONSTRAINT valid_ticket_count CHECK (ticket_count > 0),
+    CONSTRAINT fk_bookings_concert FOREIGN KEY (concert_id) REFERENCES concerts(id),
+    UNIQUE INDEX idx_bookings_reference (reference),
+    -- NULL keys don't collide, like the partial index of Postgres
+    UNIQUE INDEX idx_bookings_user_idempotency_key (user_id, idempotency_key),
+    INDEX idx_bookings_concert_id (concert_id),
+    INDEX idx_bookings_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    details JSON NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    INDEX idx_audit_logs_resource (resource_type, resource_id),
+    INDEX idx_audit_logs_created_at (created_at)
+);
+
+CREATE TABLE IF NOT EXISTS booking_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    concert_id BIGINT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    used_at DATETIME(6) NULL,
+    created_at DATETIME(6) NOT NULL,
+    CONSTRAINT fk_booking_tokens_concert FOREIGN KEY (concert_id) REFERENCES concerts(id),
+    INDEX idx_booking_tokens_expires_at (expires_at)
+);
+
+CREATE TABLE IF NOT EXISTS concert_price_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    concert_id BIGINT NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    recorded_at DATETIME(6) NOT NULL,
+    CONSTRAINT fk_concert_price_history_concert FOREIGN KEY (concert_id) REFERENCES concerts(id),
+    INDEX idx_concert_price_history_concert (concert_id, recorded_at)
+);
+
+CREATE TABLE IF NOT EXISTS concert_invites (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    concert_id BIGINT NOT NULL,
+    invitee VARCHAR(255) NOT NULL DEFAULT '',
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    redemptions INT NOT NULL DEFAULT 0,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    CONSTRAINT valid_invite_redemptions CHECK (redemptions >= 0 AND (NOT single_use OR redemptions <= 1)),
+    CONSTRAINT fk_concert_invites_concert FOREIGN KEY (concert_id) REFERENCES concerts(id),
+    UNIQUE INDEX idx_concert_invites_token_hash (token_hash),
+    INDEX idx_concert_invites_concert (concert_id)
+);
+
+CREATE TABLE IF NOT EXISTS concert_invite_redemptions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    invite_id BIGINT NOT NULL,
+    booking_reference VARCHAR(16) NOT NULL,
+    redeemed_at DATETIME(6) NOT NULL,
+    CONSTRAINT fk_concert_invite_redemptions_invite FOREIGN KEY (invite_id) REFERENCES concert_invites(id),
+    UNIQUE INDEX idx_concert_invite_redemptions_reference (booking_reference),
+    INDEX idx_concert_invite_redemptions_invite (invite_id)
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS concert_invite_redemptions;
+DROP TABLE IF EXISTS concert_invites;
+DROP TABLE IF EXISTS concert_price_history;
+DROP TABLE IF EXISTS booking_tokens;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS concerts;
@@ -0,0 +1,119 @@
+-- The tables of the features the sqlite driver supports, as of the Postgres
+-- migrations up to 000020. Times are stored as text in UTC, which sorts and
+-- compares like the times.
+CREATE TABLE IF NOT EXISTS concerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    artist TEXT NOT NULL,
+    venue TEXT NOT NULL,
+    concert_date DATETIME NOT NULL,
+    total_tickets INTEGER NOT NULL,
+    available_tickets INTEGER NOT NULL,
+    price REAL NOT NULL,
+    booking_start_time DATETIME NOT NULL,
+    booking_end_time DATETIME NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    artist_aliases TEXT NOT NULL DEFAULT '[]',
+    venue_aliases TEXT NOT NULL DEFAULT '[]',
+    search_name TEXT NOT NULL DEFAULT '',
+    search_artist TEXT NOT NULL DEFAULT '',
+    search_venue TEXT NOT NULL DEFAULT '',
+    requires_booking_token BOOLEAN NOT NULL DEFAULT FALSE,
+    currency TEXT NOT NULL DEFAULT 'USD',
+    organizer_email TEXT NOT NULL DEFAULT '',
+    reporting_state TEXT NOT NULL DEFAULT 'open',
+    reporting_claimed_at DATETIME,
+    door_price REAL,
+    door_price_lead_minutes INTEGER NOT NULL DEFAULT 0,
+    -- concert_date less the lead, kept by the application since the door
+    -- price queries can't compute it portably
+    door_price_starts_at DATETIME,
+    door_price_switched_at DATETIME,
+    visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    invite_token_hash TEXT NOT NULL DEFAULT '',
+    max_tickets_per_booking INTEGER,
+    max_order_value REAL
+);
+
+CREATE INDEX IF NOT EXISTS idx_concerts_date ON concerts(concert_date);
+CREATE INDEX IF NOT EXISTS idx_concerts_door_price_pending ON concerts(door_price_starts_at)
+    WHERE door_price_switched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reference TEXT NOT NULL UNIQUE,
+    concert_id INTEGER NOT NULL REFERENCES concerts(id),
+    user_id TEXT NOT NULL,
+    ticket_count INTEGER NOT NULL CHECK (ticket_count > 0),
+    booking_time DATETIME NOT NULL,
+    status TEXT NOT NULL DEFAULT 'confirmed',
+    attendee_name TEXT NOT NULL DEFAULT '',
+    attendee_email TEXT NOT NULL DEFAULT '',
+    unit_price REAL NOT NULL,
+    idempotency_key TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_concert_id ON bookings(concert_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_user_id ON bookings(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_user_idempotency_key
+    ON bookings(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    resource_type TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+CREATE TABLE IF NOT EXISTS booking_tokens (
+    token_hash TEXT PRIMARY KEY,
+    concert_id INTEGER NOT NULL REFERENCES concerts(id),
+    user_id TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_tokens_expires_at ON booking_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS concert_price_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    concert_id INTEGER NOT NULL REFERENCES concerts(id),
+    price REAL NOT NULL,
+    currency TEXT NOT NULL,
+    recorded_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_price_history_concert ON concert_price_history(concert_id, recorded_at);
+
+CREATE TABLE IF NOT EXISTS concert_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    concert_id INTEGER NOT NULL REFERENCES concerts(id),
+    invitee TEXT NOT NULL DEFAULT '',
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    redemptions INTEGER NOT NULL DEFAULT 0,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    CHECK (redemptions >= 0 AND (NOT single_use OR redemptions <= 1))
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_invites_concert ON concert_invites(concert_id);
+
+CREATE TABLE IF NOT EXISTS concert_invite_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invite_id INTEGER NOT NULL REFERENCES concert_invites(id),
+    booking_reference TEXT NOT NULL UNIQUE,
+    redeemed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_concert_invite_redemptions_invite ON concert_invite_redemptions(invite_id);
@@ -0,0 +1,230 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createConcert(t testing.TB, b backend, tickets int) *model.Concert {
+	t.Helper()
+
+	// Created through the repository, as the service only takes concerts
+	// whose booking window is yet to open
+	concert, err := b.concerts.Create(context.Background(), &model.Concert{
+		Name:             "Race Night",
+		Artist:           "The Locks",
+		Venue:            "Mutex Hall",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            40,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(time.Hour),
+		Visibility:       model.VisibilityPublic,
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func bookingService(b backend) service.BookingService {
+	return strategyBookingService(b, service.BookingOptimistic)
+}
+
+func strategyBookingService(b backend, strategy service.BookingStrategy) service.BookingService {
+	return service.NewBookingService(b.bookings, b.concerts, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, strategy, nil)
+}
+
+// strategies are the booking strategies, which must all keep the invariants
+var strategies = map[string]service.BookingStrategy{
+	"optimistic":  service.BookingOptimistic,
+	"serialized":  service.BookingSerialized,
+	"conditional": service.BookingConditional,
+}
+
+// bookingFailed reports whether err is a way booking may fail under
+// contention: sold out, or out of retries against concurrent bookings
+func bookingFailed(err error) bool {
+	return errors.Is(err, pkgErr.ErrInsufficientTickets) || errors.Is(err, pkgErr.ErrOptimisticLockFailed)
+}
+
+// checkTickets asserts that the available tickets and the confirmed bookings
+// of a concert add up to its total
+func checkTickets(t *testing.T, b backend, concertID int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	bookings, err := b.bookings.GetAllByConcertID(ctx, concertID)
+	require.NoError(t, err)
+	confirmed := 0
+	for _, booking := range bookings {
+		if booking.Status == model.BookingStatusConfirmed {
+			confirmed += booking.TicketCount
+		}
+	}
+
+	concert, err := b.concerts.GetByID(ctx, concertID)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, concert.AvailableTickets, 0, "tickets are never oversold")
+	assert.Equal(t, concert.TotalTickets, concert.AvailableTickets+confirmed, "every ticket is either available or booked")
+}
+
+func TestConcurrentBookingsDontOversell(t *testing.T) {
+	for _, b := range backends(t) {
+		for name, strategy := range strategies {
+			t.Run(b.name+"/"+name, func(t *testing.T) {
+				testConcurrentBookingsDontOversell(t, b, strategy)
+			})
+		}
+	}
+}
+
+func testConcurrentBookingsDontOversell(t *testing.T, b backend, strategy service.BookingStrategy) {
+	concert := createConcert(t, b, 30)
+	bookings := strategyBookingService(b, strategy)
+
+	var booked atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			booking, err := bookings.BookTickets(context.Background(), &model.BookingRequest{
+				ConcertID:   concert.ID,
+				UserID:      fmt.Sprintf("user-%d", i),
+				TicketCount: 2,
+			})
+			if err != nil {
+				assert.True(t, bookingFailed(err), "unexpected booking error: %v", err)
+				return
+			}
+			assert.NotEmpty(t, booking.Reference)
+			booked.Add(1)
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := b.bookings.GetAllByConcertID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, int(booked.Load()), "every successful booking is stored once")
+	references := map[string]bool{}
+	for _, booking := range stored {
+		assert.False(t, references[booking.Reference], "references are unique")
+		references[booking.Reference] = true
+	}
+	assert.LessOrEqual(t, booked.Load(), int64(15))
+	checkTickets(t, b, concert.ID)
+}
+
+func TestConcurrentCancellationsReturnTicketsOnce(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			concert := createConcert(t, b, 20)
+			bookings := bookingService(b)
+			ctx := context.Background()
+
+			var made []*model.Booking
+			for i := 0; i < 5; i++ {
+				booking, err := bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: fmt.Sprintf("user-%d", i), TicketCount: 2})
+				require.NoError(t, err)
+				made = append(made, booking)
+			}
+
+			// Every booking is cancelled by several requests at once, as
+			// when a client retries a cancellation that timed out
+			var cancelled [5]atomic.Int32
+			var wg sync.WaitGroup
+			for i, booking := range made {
+				for j := 0; j < 4; j++ {
+					wg.Add(1)
+					go func(i int, booking *model.Booking) {
+						defer wg.Done()
+						err := bookings.CancelBooking(ctx, booking.ID, booking.UserID)
+						if err != nil {
+							assert.ErrorIs(t, err, pkgErr.ErrBookingAlreadyCancelled)
+							return
+						}
+						cancelled[i].Add(1)
+					}(i, booking)
+				}
+			}
+			wg.Wait()
+
+			for i := range made {
+				assert.EqualValues(t, 1, cancelled[i].Load(), "booking %d is cancelled exactly once", i)
+			}
+			checkTickets(t, b, concert.ID)
+			reloaded, err := b.concerts.GetByID(ctx, concert.ID)
+			require.NoError(t, err)
+			assert.Equal(t, concert.TotalTickets, reloaded.AvailableTickets)
+		})
+	}
+}
+
+func TestConcurrentBookingCancellationAndConcertEdits(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			concert := createConcert(t, b, 40)
+			bookings := bookingService(b)
+			concerts := service.NewConcertService(b.concerts, nil, model.BookingLimits{}, nil)
+			ctx := context.Background()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					userID := fmt.Sprintf("user-%d", i)
+					booking, err := bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: userID, TicketCount: 1 + i%3})
+					if err != nil {
+						assert.True(t, bookingFailed(err), "unexpected booking error: %v", err)
+						return
+					}
+
+					// Half of the users change their mind straight away
+					if i%2 == 0 {
+						assert.NoError(t, bookings.CancelBookingByReference(ctx, booking.Reference, userID))
+					}
+				}(i)
+			}
+
+			// Organizers edit the concert meanwhile. An edit made from a
+			// concert read before a booking must not undo its ticket count.
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for attempt := 0; attempt < 5; attempt++ {
+						current, err := b.concerts.GetByID(ctx, concert.ID)
+						if !assert.NoError(t, err) {
+							return
+						}
+						patch := &model.ConcertPatch{
+							Fields: []string{"name", "total_tickets"},
+							Values: model.Concert{Name: fmt.Sprintf("Race Night %d", i), TotalTickets: current.TotalTickets + 1},
+						}
+						_, _, err = concerts.PatchConcert(ctx, "organizer", concert.ID, current.Version, patch)
+						if err == nil {
+							return
+						}
+						assert.ErrorIs(t, err, pkgErr.ErrOptimisticLockFailed)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			checkTickets(t, b, concert.ID)
+		})
+	}
+}
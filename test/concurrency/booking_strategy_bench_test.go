@@ -0,0 +1,96 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+)
+
+// BenchmarkBookingStrategies books one ticket per operation from parallel
+// goroutines with each strategy, of one hot concert as in an on-sale and
+// spread over eight concerts. Only the Postgres runs compare the strategies;
+// the mock ones measure the services' overhead, with miniredis for the
+// counters.
+//
+//	make bench
+//	go test ./test/concurrency -run '^$' -bench 'BookingStrategies/postgres/.*/concerts=1' -cpu 1,8,32
+//
+// failed/op counts bookings that ran out of retries, which only the
+// optimistic strategy does, and p99-ms is the latency of the slowest
+// bookings, which wait for the others on a hot concert.
+func BenchmarkBookingStrategies(b *testing.B) {
+	for _, backend := range backends(b) {
+		for _, name := range []string{"optimistic", "serialized", "conditional", "redis"} {
+			if name == "redis" && backend.counter == nil {
+				continue
+			}
+			for _, spread := range []int{1, 8} {
+				b.Run(fmt.Sprintf("%s/%s/concerts=%d", backend.name, name, spread), func(b *testing.B) {
+					benchmarkBookings(b, backend, name, spread)
+				})
+			}
+		}
+	}
+}
+
+func benchmarkBookings(b *testing.B, backend backend, strategy string, spread int) {
+	concertIDs := make([]int64, spread)
+	for i := range concertIDs {
+		concertIDs[i] = createConcert(b, backend, b.N).ID
+	}
+	bookings := benchBookingService(backend, strategy)
+
+	var user, failed atomic.Int64
+	var mutex sync.Mutex
+	latencies := make([]time.Duration, 0, b.N)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var own []time.Duration
+		for pb.Next() {
+			n := user.Add(1)
+			start := time.Now()
+			_, err := bookings.BookTickets(context.Background(), &model.BookingRequest{
+				ConcertID:   concertIDs[int(n)%spread],
+				UserID:      fmt.Sprintf("user-%d", n),
+				TicketCount: 1,
+			})
+			own = append(own, time.Since(start))
+			if err != nil {
+				if !bookingFailed(err) {
+					b.Errorf("unexpected booking error: %v", err)
+				}
+				failed.Add(1)
+			}
+		}
+
+		mutex.Lock()
+		latencies = append(latencies, own...)
+		mutex.Unlock()
+	})
+	b.StopTimer()
+
+	b.ReportMetric(float64(failed.Load())/float64(b.N), "failed/op")
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if len(latencies) > 0 {
+		p99 := latencies[int(math.Ceil(0.99*float64(len(latencies))))-1]
+		b.ReportMetric(float64(p99)/float64(time.Millisecond), "p99-ms")
+	}
+}
+
+// benchBookingService returns the booking service of a strategy; "redis"
+// takes the tickets from the backend's inventory counters
+func benchBookingService(b backend, strategy string) service.BookingService {
+	if strategy != "redis" {
+		return strategyBookingService(b, strategies[strategy])
+	}
+	inventory := service.NewInventoryService(b.inventory, b.counter)
+	return service.NewBookingService(b.bookings, b.concerts, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, inventory, service.BookingOptimistic, nil)
+}
@@ -0,0 +1,136 @@
+// Package concurrency runs the booking flows concurrently against every
+// repository implementation and checks the invariants that the locking in
+// the repositories and services must keep. Run it with the race detector:
+//
+//	make test-race
+//
+// The memory and SQLite runs need nothing but the process; the Postgres
+// runs need Docker and are skipped without it, and so are their Redis
+// inventory counters.
+package concurrency
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/memory"
+	"concert-ticket-api/internal/repository/postgres"
+	redisrepo "concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/repository/sqlite"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/test/mocks"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jmoiron/sqlx"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+var (
+	postgresOnce sync.Once
+	postgresDB   *sqlx.DB
+	postgresErr  error
+
+	redisOnce   sync.Once
+	redisClient *goredis.Client
+	redisErr    error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if postgresDB != nil {
+		testutil.TeardownTestDB()
+	}
+	if redisClient != nil {
+		testutil.TeardownTestRedis()
+	}
+
+	os.Exit(code)
+}
+
+// backend is a set of repositories the flows run against
+type backend struct {
+	name     string
+	concerts repository.ConcertRepository
+	bookings repository.BookingRepository
+	// inventory and counter take bookings from Redis inventory counters;
+	// they are nil for the backends without an inventory repository
+	inventory repository.InventoryRepository
+	counter   repository.InventoryCounter
+}
+
+// backends returns a fresh mock backend, a memory backend on a new store, a
+// SQLite backend on a new database and, if Docker is available, a Postgres
+// backend on an emptied database. The mock backend counts inventory in
+// miniredis and the Postgres one in Redis in Docker.
+func backends(t testing.TB) []backend {
+	cipher, err := crypto.NewEnvelopeCipher("test", map[string][]byte{"test": make([]byte, 32)})
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	miniRedis := miniredis.RunT(t)
+	miniClient := goredis.NewClient(&goredis.Options{Addr: miniRedis.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { miniClient.Close() })
+	result := []backend{{
+		name:      "mock",
+		concerts:  concerts,
+		bookings:  bookings,
+		inventory: mocks.NewMockInventoryRepository(concerts, bookings),
+		counter:   redisrepo.NewInventoryCounter(miniClient),
+	}}
+
+	store := memory.NewStore()
+	result = append(result, backend{
+		name:     "memory",
+		concerts: memory.NewConcertRepository(store),
+		bookings: memory.NewBookingRepository(store),
+	})
+
+	sqliteDB, err := testutil.SetupSQLiteDB()
+	if err != nil {
+		t.Fatalf("failed to set up the SQLite database: %v", err)
+	}
+	t.Cleanup(func() { sqliteDB.Close() })
+	result = append(result, backend{
+		name:     "sqlite",
+		concerts: sqlite.NewConcertRepository(sqliteDB),
+		bookings: sqlite.NewBookingRepository(sqliteDB, cipher),
+	})
+
+	postgresOnce.Do(func() {
+		postgresDB, postgresErr = testutil.SetupTestDB()
+	})
+	if postgresErr != nil {
+		t.Logf("skipping the Postgres runs: %v", postgresErr)
+		return result
+	}
+	if err := testutil.CleanupTestDB(postgresDB); err != nil {
+		t.Fatalf("failed to clean up the test database: %v", err)
+	}
+
+	postgresBackend := backend{
+		name:     "postgres",
+		concerts: postgres.NewConcertRepository(postgresDB),
+		bookings: postgres.NewBookingRepository(postgresDB, cipher),
+	}
+
+	redisOnce.Do(func() {
+		redisClient, redisErr = testutil.SetupTestRedis()
+	})
+	if redisErr != nil {
+		t.Logf("skipping the Postgres runs with Redis inventory counters: %v", redisErr)
+		return append(result, postgresBackend)
+	}
+	if err := testutil.CleanupTestRedis(redisClient); err != nil {
+		t.Fatalf("failed to clean up the test Redis: %v", err)
+	}
+	postgresBackend.inventory = postgres.NewInventoryRepository(postgresDB, cipher)
+	postgresBackend.counter = redisrepo.NewInventoryCounter(redisClient)
+	return append(result, postgresBackend)
+}
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/accounting"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/test/mocks"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccountingTestSuite struct {
+	suite.Suite
+	db                *sqlx.DB
+	concertRepo       repository.ConcertRepository
+	bookingService    service.BookingService
+	accountingService service.AccountingService
+	adapter           *mocks.MockAccountingAdapter
+}
+
+func (s *AccountingTestSuite) SetupSuite() {
+	// Connect to test database
+	var err error
+	s.db, err = testutil.SetupTestDB()
+	require.NoError(s.T(), err)
+
+	// Initialize repositories and services
+	s.concertRepo = postgres.NewConcertRepository(s.db)
+	cipher, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(s.T(), err)
+
+	bookingRepo := postgres.NewBookingRepository(s.db, cipher)
+	s.bookingService = service.NewBookingService(bookingRepo, s.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	s.adapter = mocks.NewMockAccountingAdapter("mock")
+	s.accountingService = service.NewAccountingService(postgres.NewAccountingRepository(s.db), []accounting.Adapter{s.adapter})
+}
+
+func (s *AccountingTestSuite) TearDownTest() {
+	// Clean up database after each test
+	testutil.CleanupTestDB(s.db)
+	s.adapter.SetFailing(false)
+}
+
+func (s *AccountingTestSuite) TearDownSuite() {
+	// Close database connection
+	s.db.Close()
+}
+
+func (s *AccountingTestSuite) TestSyncSalesAndRefunds() {
+	ctx := context.Background()
+
+	concert, err := s.concertRepo.Create(ctx, &model.Concert{
+		Name:             "Accounting Show",
+		Artist:           "Test Artist",
+		Venue:            "Test Venue",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25.0,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-1 * time.Hour),
+		BookingEndTime:   time.Now().Add(1 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	kept, err := s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(s.T(), err)
+	cancelled, err := s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.bookingService.CancelBooking(ctx, cancelled.ID, "user-2"))
+
+	// Failed pushes stay pending and show up in the reconciliation report
+	s.adapter.SetFailing(true)
+	pushed, err := s.accountingService.SyncPending(ctx)
+	assert.Error(s.T(), err)
+	assert.Zero(s.T(), pushed)
+
+	reconciliations, err := s.accountingService.Reconcile(ctx)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), reconciliations, 1)
+	assert.Equal(s.T(), 2, reconciliations[0].PendingSales)
+	assert.Equal(s.T(), 1, reconciliations[0].PendingRefunds)
+	assert.Equal(s.T(), 2, reconciliations[0].Failing)
+	assert.Len(s.T(), reconciliations[0].Pending, 3)
+
+	// Once the system is back, two sales and one refund are pushed, sale first
+	s.adapter.SetFailing(false)
+	pushed, err = s.accountingService.SyncPending(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, pushed)
+
+	entries := s.adapter.Entries()
+	require.Len(s.T(), entries, 3)
+	assert.Equal(s.T(), kept.Reference+"-sale", entries[0].ID)
+	assert.Equal(s.T(), cancelled.Reference+"-sale", entries[1].ID)
+	assert.Equal(s.T(), cancelled.Reference+"-refund", entries[2].ID)
+	assert.Equal(s.T(), 50.0, entries[0].Lines[0].Debit)
+	assert.Equal(s.T(), accounting.AccountCash, entries[0].Lines[0].Account)
+	assert.Equal(s.T(), accounting.AccountCash, entries[2].Lines[1].Account)
+	assert.Equal(s.T(), 25.0, entries[2].Lines[1].Credit)
+
+	reconciliations, err = s.accountingService.Reconcile(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, reconciliations[0].SyncedSales)
+	assert.Equal(s.T(), 1, reconciliations[0].SyncedRefunds)
+	assert.Zero(s.T(), reconciliations[0].PendingSales+reconciliations[0].PendingRefunds)
+	assert.NotNil(s.T(), reconciliations[0].LastSyncedAt)
+	assert.Empty(s.T(), reconciliations[0].Pending)
+
+	// Synced entries are not pushed again
+	pushed, err = s.accountingService.SyncPending(ctx)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), pushed)
+}
+
+func TestAccountingExport(t *testing.T) {
+	suite.Run(t, new(AccountingTestSuite))
+}
@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AnalyticsTestSuite struct {
+	suite.Suite
+	db               *sqlx.DB
+	concertRepo      repository.ConcertRepository
+	bookingRepo      repository.BookingRepository
+	paymentRepo      repository.PaymentRepository
+	analyticsService service.AnalyticsService
+}
+
+func (s *AnalyticsTestSuite) SetupSuite() {
+	// Connect to test database
+	var err error
+	s.db, err = testutil.SetupTestDB()
+	require.NoError(s.T(), err)
+
+	// Initialize repositories and services
+	cipher, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(s.T(), err)
+
+	s.concertRepo = postgres.NewConcertRepository(s.db)
+	s.bookingRepo = postgres.NewBookingRepository(s.db, cipher)
+	s.paymentRepo = postgres.NewPaymentRepository(s.db, cipher)
+	s.analyticsService = service.NewAnalyticsService(postgres.NewAnalyticsRepository(s.db), s.concertRepo)
+}
+
+func (s *AnalyticsTestSuite) TearDownTest() {
+	// Clean up database after each test
+	testutil.CleanupTestDB(s.db)
+}
+
+func (s *AnalyticsTestSuite) TearDownSuite() {
+	// Close database connection
+	s.db.Close()
+}
+
+func (s *AnalyticsTestSuite) createConcert(name, organizer string, price float64) *model.Concert {
+	concert, err := s.concertRepo.Create(context.Background(), &model.Concert{
+		Name:             name,
+		Artist:           "Test Artist",
+		Venue:            "Test Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            price,
+		Currency:         "USD",
+		OrganizerEmail:   organizer,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+	return concert
+}
+
+// book inserts a booking, moved back by age, with a payment of the status
+// unless it is empty
+func (s *AnalyticsTestSuite) book(concert *model.Concert, tickets int, status model.BookingStatus, age time.Duration, payment string) {
+	ctx := context.Background()
+	booking, err := s.bookingRepo.Create(ctx, &model.Booking{
+		ConcertID: concert.ID, UserID: "user-1", TicketCount: tickets, UnitPrice: concert.Price, Status: status,
+	})
+	require.NoError(s.T(), err)
+
+	if age > 0 {
+		_, err = s.db.Exec("UPDATE bookings SET booking_time = booking_time - make_interval(secs => $1) WHERE id = $2", age.Seconds(), booking.ID)
+		require.NoError(s.T(), err)
+	}
+
+	if payment != "" {
+		require.NoError(s.T(), s.paymentRepo.Create(ctx, &model.Payment{
+			BookingID: booking.ID, Provider: "fake", ProviderReference: booking.Reference,
+			Amount: float64(tickets) * concert.Price, Currency: concert.Currency, Status: payment,
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}))
+	}
+}
+
+func (s *AnalyticsTestSuite) TestOrganizerDashboard() {
+	arena := s.createConcert("Arena Night", "promoter@example.com", 50)
+	club := s.createConcert("Club Night", "promoter@example.com", 20)
+	other := s.createConcert("Other Night", "other@example.com", 10)
+
+	s.book(arena, 4, model.BookingStatusConfirmed, 0, "")
+	s.book(arena, 2, model.BookingStatusConfirmed, 48*time.Hour, "")
+	s.book(arena, 1, model.BookingStatusCancelled, 48*time.Hour, "")
+	s.book(club, 3, model.BookingStatusConfirmed, 0, model.PaymentSucceeded)
+	s.book(club, 1, model.BookingStatusPending, 0, model.PaymentPending)
+	s.book(club, 2, model.BookingStatusCancelled, 0, model.PaymentFailed)
+	s.book(club, 1, model.BookingStatusCancelled, 48*time.Hour, model.PaymentRefunded)
+	s.book(other, 9, model.BookingStatusConfirmed, 0, "")
+
+	dashboard, err := s.analyticsService.OrganizerDashboard(context.Background(), "promoter@example.com", time.UTC, 1)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 2, dashboard.Concerts)
+	require.Len(s.T(), dashboard.SalesToday, 1)
+	assert.Equal(s.T(), model.CurrencySales{Currency: "USD", Bookings: 2, Tickets: 7, Gross: 260}, *dashboard.SalesToday[0])
+
+	require.Len(s.T(), dashboard.TopConcerts, 1)
+	assert.Equal(s.T(), arena.ID, dashboard.TopConcerts[0].ConcertID)
+	assert.Equal(s.T(), 6, dashboard.TopConcerts[0].TicketsSold)
+	assert.Equal(s.T(), 300.0, dashboard.TopConcerts[0].Gross)
+
+	assert.Equal(s.T(), model.HoldConversion{Holds: 4, Pending: 1, Confirmed: 2, Lapsed: 1, Rate: 2.0 / 3}, dashboard.Holds)
+	assert.Equal(s.T(), model.CancellationStats{Bookings: 5, Cancelled: 2, Rate: 2.0 / 5}, dashboard.Cancellations)
+}
+
+func (s *AnalyticsTestSuite) TestConcertSalesTimeseries() {
+	arena := s.createConcert("Arena Night", "promoter@example.com", 50)
+	other := s.createConcert("Other Night", "other@example.com", 10)
+
+	s.book(arena, 4, model.BookingStatusConfirmed, 0, "")
+	s.book(arena, 1, model.BookingStatusConfirmed, 0, "")
+	s.book(arena, 2, model.BookingStatusConfirmed, 48*time.Hour, "")
+	s.book(arena, 3, model.BookingStatusCancelled, 0, "")
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(s.T(), err)
+	timeseries, err := s.analyticsService.ConcertSalesTimeseries(context.Background(), "promoter@example.com", arena.ID, model.SalesTimeseriesQuery{
+		Interval: model.SalesIntervalDay,
+		Location: tokyo,
+		From:     time.Now().Add(-72 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	// The bookings of today and two days ago fill the 1st and 3rd of 4 days
+	require.Len(s.T(), timeseries.Buckets, 4)
+	assert.Equal(s.T(), 2, timeseries.Buckets[1].Tickets)
+	assert.Equal(s.T(), 5, timeseries.Buckets[3].Tickets)
+	assert.Equal(s.T(), 2, timeseries.Buckets[3].Bookings)
+	assert.Equal(s.T(), 250.0, timeseries.Buckets[3].Revenue)
+	assert.Equal(s.T(), tokyo, timeseries.Buckets[3].Start.Location())
+
+	_, err = s.analyticsService.ConcertSalesTimeseries(context.Background(), "promoter@example.com", other.ID, model.SalesTimeseriesQuery{Interval: model.SalesIntervalHour})
+	assert.Error(s.T(), err)
+}
+
+func TestAnalytics(t *testing.T) {
+	suite.Run(t, new(AnalyticsTestSuite))
+}
@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BookingAttemptTestSuite struct {
+	suite.Suite
+	db             *sqlx.DB
+	concertRepo    repository.ConcertRepository
+	bookingService service.BookingService
+	attemptService service.BookingAttemptService
+}
+
+func (s *BookingAttemptTestSuite) SetupSuite() {
+	// Connect to test database
+	var err error
+	s.db, err = testutil.SetupTestDB()
+	require.NoError(s.T(), err)
+
+	// Initialize repositories and services
+	s.concertRepo = postgres.NewConcertRepository(s.db)
+	cipher, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(s.T(), err)
+
+	s.attemptService = service.NewBookingAttemptService(postgres.NewBookingAttemptRepository(s.db), 100, time.Hour)
+	bookingRepo := postgres.NewBookingRepository(s.db, cipher)
+	s.bookingService = service.NewBookingService(bookingRepo, s.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, s.attemptService, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+}
+
+func (s *BookingAttemptTestSuite) TearDownTest() {
+	// Clean up database after each test
+	testutil.CleanupTestDB(s.db)
+}
+
+func (s *BookingAttemptTestSuite) TearDownSuite() {
+	// Close database connection
+	s.db.Close()
+}
+
+func (s *BookingAttemptTestSuite) TestSummarizeOutcomes() {
+	ctx := context.Background()
+
+	concert, err := s.concertRepo.Create(ctx, &model.Concert{
+		Name:             "Small Show",
+		Artist:           "Test Artist",
+		Venue:            "Test Venue",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     2,
+		AvailableTickets: 2,
+		Price:            25.0,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-1 * time.Hour),
+		BookingEndTime:   time.Now().Add(1 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(s.T(), err)
+	_, err = s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.Error(s.T(), err)
+	_, err = s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.Error(s.T(), err)
+	_, err = s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID + 1, UserID: "user-3", TicketCount: 1})
+	require.Error(s.T(), err)
+
+	written, err := s.attemptService.Flush(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 4, written)
+
+	summary, err := s.attemptService.Summary(ctx, concert.ID, time.Hour)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, summary.Attempts)
+	assert.Equal(s.T(), 2, summary.TurnedAway)
+	assert.Equal(s.T(), 1, summary.TurnedAwayUsers)
+	require.Len(s.T(), summary.Reasons, 2)
+	assert.Equal(s.T(), model.BookingAttemptSoldOut, summary.Reasons[0].Reason)
+	assert.Equal(s.T(), 2, summary.Reasons[0].Attempts)
+	assert.Equal(s.T(), 1, summary.Reasons[0].Users)
+
+	// Without a concert, attempts for unknown concerts are included
+	summary, err = s.attemptService.Summary(ctx, 0, time.Hour)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 4, summary.Attempts)
+	assert.Equal(s.T(), 2, summary.TurnedAwayUsers)
+
+	purged, err := s.attemptService.PurgeExpired(ctx)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), purged)
+}
+
+func TestBookingAttempts(t *testing.T) {
+	suite.Run(t, new(BookingAttemptTestSuite))
+}
@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,7 +11,10 @@ import (
 	"concert-ticket-api/internal/repository"
 	"concert-ticket-api/internal/repository/postgres"
 	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/crypto"
 	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
 	"concert-ticket-api/test/testutil"
 
 	"github.com/jmoiron/sqlx"
@@ -36,9 +40,12 @@ func (s *BookingServiceTestSuite) SetupSuite() {
 
 	// Initialize repositories and services
 	s.concertRepo = postgres.NewConcertRepository(s.db)
-	s.bookingRepo = postgres.NewBookingRepository(s.db)
-	s.concertService = service.NewConcertService(s.concertRepo)
-	s.bookingService = service.NewBookingService(s.bookingRepo, s.concertRepo, 3)
+	cipher, err := crypto.NewEnvelopeCipher("test", map[string][]byte{"test": make([]byte, 32)})
+	require.NoError(s.T(), err)
+
+	s.bookingRepo = postgres.NewBookingRepository(s.db, cipher)
+	s.concertService = service.NewConcertService(s.concertRepo, nil, model.BookingLimits{}, nil)
+	s.bookingService = service.NewBookingService(s.bookingRepo, s.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
 }
 
 func (s *BookingServiceTestSuite) TearDownTest() {
@@ -92,6 +99,12 @@ func (s *BookingServiceTestSuite) TestBookTickets() {
 	assert.Equal(s.T(), "test-user", booking.UserID)
 	assert.Equal(s.T(), 5, booking.TicketCount)
 	assert.Equal(s.T(), model.BookingStatusConfirmed, booking.Status)
+	assert.Len(s.T(), booking.Reference, reference.Length)
+
+	// The booking can be looked up by its reference, case-insensitively
+	byReference, err := s.bookingService.GetBookingByReference(ctx, strings.ToLower(booking.Reference))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), booking.ID, byReference.ID)
 
 	// Check that tickets were deducted from available tickets
 	updatedConcert, err := s.concertService.GetByID(ctx, concert.ID)
@@ -99,6 +112,41 @@ func (s *BookingServiceTestSuite) TestBookTickets() {
 	assert.Equal(s.T(), concert.AvailableTickets-5, updatedConcert.AvailableTickets)
 }
 
+func (s *BookingServiceTestSuite) TestAttendeeDataEncryptedAtRest() {
+	ctx := context.Background()
+
+	// Create a concert
+	concert := s.createTestConcert()
+
+	// Book tickets with attendee details
+	bookingReq := &model.BookingRequest{
+		ConcertID:     concert.ID,
+		UserID:        "test-user",
+		TicketCount:   1,
+		AttendeeName:  "Jane Doe",
+		AttendeeEmail: "jane@example.com",
+	}
+
+	booking, err := s.bookingService.BookTickets(ctx, bookingReq)
+	require.NoError(s.T(), err)
+
+	// The stored values must not contain the plaintext
+	var stored struct {
+		Name  string `db:"attendee_name"`
+		Email string `db:"attendee_email"`
+	}
+	err = s.db.Get(&stored, "SELECT attendee_name, attendee_email FROM bookings WHERE id = $1", booking.ID)
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), stored.Name, "Jane")
+	assert.NotContains(s.T(), stored.Email, "jane@example.com")
+
+	// Reading through the repository decrypts transparently
+	fetched, err := s.bookingService.GetBookingByID(ctx, booking.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "Jane Doe", fetched.AttendeeName)
+	assert.Equal(s.T(), "jane@example.com", fetched.AttendeeEmail)
+}
+
 func (s *BookingServiceTestSuite) TestBookTooManyTickets() {
 	ctx := context.Background()
 
@@ -224,16 +272,111 @@ func (s *BookingServiceTestSuite) TestGetUserBookings() {
 	}
 
 	// Get user bookings
-	bookings, err := s.bookingService.GetUserBookings(ctx, "test-user", 1, 10)
+	bookings, err := s.bookingService.GetUserBookings(ctx, "test-user", query.NewPage(1, 10))
 	require.NoError(s.T(), err)
 	assert.Equal(s.T(), 3, len(bookings))
 
 	// Check pagination
-	limitedBookings, err := s.bookingService.GetUserBookings(ctx, "test-user", 1, 2)
+	limitedBookings, err := s.bookingService.GetUserBookings(ctx, "test-user", query.NewPage(1, 2))
 	require.NoError(s.T(), err)
 	assert.Equal(s.T(), 2, len(limitedBookings))
 }
 
+func (s *BookingServiceTestSuite) TestCreateStoresTheIdempotencyKey() {
+	ctx := context.Background()
+	concert := s.createTestConcert()
+
+	booking, err := s.bookingRepo.Create(ctx, &model.Booking{
+		ConcertID: concert.ID, UserID: "test-user", TicketCount: 2, UnitPrice: 50,
+		Status: model.BookingStatusConfirmed, IdempotencyKey: "checkout-1",
+	})
+	require.NoError(s.T(), err)
+	assert.NotZero(s.T(), booking.ID)
+
+	var key string
+	err = s.db.Get(&key, "SELECT idempotency_key FROM bookings WHERE id = $1", booking.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "checkout-1", key)
+
+	// The key can't be used twice
+	_, err = s.bookingRepo.Create(ctx, &model.Booking{
+		ConcertID: concert.ID, UserID: "test-user", TicketCount: 2, UnitPrice: 50,
+		Status: model.BookingStatusConfirmed, IdempotencyKey: "checkout-1",
+	})
+	assert.True(s.T(), errors.Is(err, pkgErr.ErrIdempotencyKeyInUse))
+
+	// Bookings without a key don't conflict
+	for i := 0; i < 2; i++ {
+		_, err = s.bookingRepo.Create(ctx, &model.Booking{
+			ConcertID: concert.ID, UserID: "test-user", TicketCount: 1, UnitPrice: 50, Status: model.BookingStatusConfirmed,
+		})
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *BookingServiceTestSuite) TestAnonymizeUserLeavesNoRecordNamingTheUser() {
+	ctx := context.Background()
+	concert := s.createTestConcert()
+
+	booking, err := s.bookingService.BookTickets(ctx, &model.BookingRequest{
+		ConcertID: concert.ID, UserID: "user-1", TicketCount: 2, AttendeeName: "Jane Doe", AttendeeEmail: "jane@example.com",
+	})
+	require.NoError(s.T(), err)
+	other, err := s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.NoError(s.T(), err)
+
+	// The records tied to the user outside the bookings
+	var orderID int64
+	err = s.db.Get(&orderID, `INSERT INTO orders (reference, user_id, mode, currency) VALUES ('ORDER-1', 'user-1', 'atomic', 'USD') RETURNING id`)
+	require.NoError(s.T(), err)
+	for _, statement := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE bookings SET order_id = $1 WHERE id = $2`, []interface{}{orderID, booking.ID}},
+		{`INSERT INTO cart_items (user_id, concert_id, ticket_count) VALUES ('user-1', $1, 1), ('user-2', $1, 1)`, []interface{}{concert.ID}},
+		{`INSERT INTO booking_attempts (concert_id, user_id, reason, latency_ms) VALUES ($1, 'user-1', 'sold_out', 5)`, []interface{}{concert.ID}},
+		{`INSERT INTO booking_tokens (token_hash, concert_id, user_id, expires_at) VALUES ('token-1', $1, 'user-1', NOW() + INTERVAL '1 hour')`, []interface{}{concert.ID}},
+		{`INSERT INTO webhooks (url, secret, event_types) VALUES ('https://example.com/hook', 'secret', '["booking.confirmed"]')`, nil},
+		// Deliveries copy the payloads of the events
+		{`INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, event_created_at, next_attempt_at)
+			SELECT 1, id, event_type, payload, created_at, NOW() FROM outbox_events`, nil},
+	} {
+		_, err = s.db.Exec(statement.query, statement.args...)
+		require.NoError(s.T(), err, statement.query)
+	}
+
+	count, err := s.bookingRepo.AnonymizeUser(ctx, "user-1", "erased-1")
+	require.NoError(s.T(), err)
+	// The booking, the order, the attempt, the event, its delivery, the cart item and the token
+	assert.Equal(s.T(), 7, count)
+
+	for _, table := range []string{"bookings", "orders", "booking_attempts", "cart_items", "booking_tokens"} {
+		var left int
+		require.NoError(s.T(), s.db.Get(&left, "SELECT COUNT(*) FROM "+table+" WHERE user_id = 'user-1'"))
+		assert.Zero(s.T(), left, table)
+	}
+	for _, table := range []string{"outbox_events", "webhook_deliveries"} {
+		var userIDs []string
+		require.NoError(s.T(), s.db.Select(&userIDs, "SELECT payload->>'user_id' FROM "+table+" ORDER BY id"))
+		assert.ElementsMatch(s.T(), []string{"erased-1", "user-2"}, userIDs, table)
+	}
+
+	// The booking keeps its order under the pseudonym, and its sales figures
+	anonymized, err := s.bookingRepo.GetByID(ctx, booking.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "erased-1", anonymized.UserID)
+	assert.Empty(s.T(), anonymized.AttendeeName)
+	assert.Equal(s.T(), 2, anonymized.TicketCount)
+	var orderUser string
+	require.NoError(s.T(), s.db.Get(&orderUser, "SELECT o.user_id FROM orders o JOIN bookings b ON b.order_id = o.id WHERE b.id = $1", booking.ID))
+	assert.Equal(s.T(), "erased-1", orderUser)
+
+	untouched, err := s.bookingRepo.GetByID(ctx, other.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "user-2", untouched.UserID)
+}
+
 func TestBookingService(t *testing.T) {
 	suite.Run(t, new(BookingServiceTestSuite))
 }
@@ -9,6 +9,8 @@ import (
 	"concert-ticket-api/internal/repository"
 	"concert-ticket-api/internal/repository/postgres"
 	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
 	"concert-ticket-api/test/testutil"
 
 	"github.com/jmoiron/sqlx"
@@ -32,7 +34,7 @@ func (s *ConcertServiceTestSuite) SetupSuite() {
 
 	// Initialize repositories and services
 	s.concertRepo = postgres.NewConcertRepository(s.db)
-	s.concertService = service.NewConcertService(s.concertRepo)
+	s.concertService = service.NewConcertService(s.concertRepo, nil, model.BookingLimits{}, nil)
 }
 
 func (s *ConcertServiceTestSuite) TearDownTest() {
@@ -131,14 +133,24 @@ func (s *ConcertServiceTestSuite) TestListConcerts() {
 	}
 
 	// List concerts
-	listedConcerts, count, err := s.concertService.ListConcerts(ctx, 1, 10, nil)
+	listedConcerts, count, err := s.concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 10)})
 	require.NoError(s.T(), err)
 	assert.GreaterOrEqual(s.T(), len(listedConcerts), 2)
 	assert.GreaterOrEqual(s.T(), count, 2)
 
+	// Test sorting by price, most expensive first
+	sortedConcerts, _, err := s.concertService.ListConcerts(ctx, query.Options{
+		Page: query.NewPage(1, 10),
+		Sort: []query.Sort{{Field: "price", Desc: true}},
+	})
+	require.NoError(s.T(), err)
+	require.GreaterOrEqual(s.T(), len(sortedConcerts), 2)
+	assert.Equal(s.T(), "Concert 2", sortedConcerts[0].Name)
+
 	// Test filtering by artist
-	filteredConcerts, filteredCount, err := s.concertService.ListConcerts(ctx, 1, 10, map[string]interface{}{
-		"artist": "Artist 1",
+	filteredConcerts, filteredCount, err := s.concertService.ListConcerts(ctx, query.Options{
+		Page:    query.NewPage(1, 10),
+		Filters: query.Filters{"artist": "Artist 1"},
 	})
 	require.NoError(s.T(), err)
 	assert.Equal(s.T(), 1, len(filteredConcerts))
@@ -146,6 +158,40 @@ func (s *ConcertServiceTestSuite) TestListConcerts() {
 	assert.Equal(s.T(), "Artist 1", filteredConcerts[0].Artist)
 }
 
+func (s *ConcertServiceTestSuite) TestSearchNormalizesNamesAndAliases() {
+	ctx := context.Background()
+
+	// Create a concert with an accented artist name and an alias in another script
+	concert := &model.Concert{
+		Name:             "Cornucopia",
+		Artist:           "Björk",
+		ArtistAliases:    model.StringList{"ビョーク"},
+		Venue:            "Tōkyō Dome",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     100,
+		Price:            50.0,
+		BookingStartTime: time.Now().Add(1 * time.Hour),
+		BookingEndTime:   time.Now().Add(2 * time.Hour),
+	}
+
+	_, err := s.concertService.CreateConcert(ctx, concert)
+	require.NoError(s.T(), err)
+
+	for _, filters := range []query.Filters{
+		{"artist": "Bjork"},
+		{"artist": "BJÖRK"},
+		{"artist": "ビョーク"},
+		{"venue": "tokyo dome"},
+	} {
+		concerts, count, err := s.concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 10), Filters: filters})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 1, count, "filters %v", filters)
+		if assert.Len(s.T(), concerts, 1) {
+			assert.Equal(s.T(), "Björk", concerts[0].Artist)
+		}
+	}
+}
+
 func (s *ConcertServiceTestSuite) TestUpdateConcert() {
 	ctx := context.Background()
 
@@ -178,6 +224,55 @@ func (s *ConcertServiceTestSuite) TestUpdateConcert() {
 	assert.Equal(s.T(), 75.0, updatedConcert.Price)
 }
 
+func (s *ConcertServiceTestSuite) TestPriceHistoryAndCompare() {
+	ctx := context.Background()
+
+	newConcert := func(name string, price float64) *model.Concert {
+		concert, err := s.concertService.CreateConcert(ctx, &model.Concert{
+			Name:             name,
+			Artist:           "Test Artist",
+			Venue:            name + " Hall",
+			ConcertDate:      time.Now().Add(24 * time.Hour),
+			TotalTickets:     100,
+			Price:            price,
+			BookingStartTime: time.Now().Add(1 * time.Hour),
+			BookingEndTime:   time.Now().Add(2 * time.Hour),
+		})
+		require.NoError(s.T(), err)
+		return concert
+	}
+
+	first := newConcert("First", 50.0)
+	second := newConcert("Second", 40.0)
+
+	// Only price changes add snapshots
+	first.Name = "First Renamed"
+	require.NoError(s.T(), s.concertService.UpdateConcert(ctx, first))
+	first.Price = 65.0
+	require.NoError(s.T(), s.concertService.UpdateConcert(ctx, first))
+
+	history, err := s.concertService.GetPriceHistory(ctx, first.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), history, 2)
+	assert.Equal(s.T(), 50.0, history[0].Price)
+	assert.Equal(s.T(), 65.0, history[1].Price)
+	assert.Equal(s.T(), "USD", history[1].Currency)
+
+	_, err = s.concertService.GetPriceHistory(ctx, 9999)
+	assert.ErrorIs(s.T(), err, pkgErr.ErrNotFound)
+
+	// Results keep the requested order and report unknown IDs
+	concerts, missing, err := s.concertService.CompareConcerts(ctx, []int64{second.ID, first.ID, 9999, second.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), concerts, 2)
+	assert.Equal(s.T(), second.ID, concerts[0].ID)
+	assert.Equal(s.T(), first.ID, concerts[1].ID)
+	assert.Equal(s.T(), []int64{9999}, missing)
+
+	_, _, err = s.concertService.CompareConcerts(ctx, []int64{first.ID})
+	assert.Error(s.T(), err)
+}
+
 func TestConcertService(t *testing.T) {
 	suite.Run(t, new(ConcertServiceTestSuite))
 }
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/test/mocks"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SalesReportTestSuite struct {
+	suite.Suite
+	db             *sqlx.DB
+	concertRepo    repository.ConcertRepository
+	bookingService service.BookingService
+	reportService  service.SalesReportService
+	sender         *mocks.MockMailSender
+}
+
+func (s *SalesReportTestSuite) SetupSuite() {
+	// Connect to test database
+	var err error
+	s.db, err = testutil.SetupTestDB()
+	require.NoError(s.T(), err)
+
+	// Initialize repositories and services
+	s.concertRepo = postgres.NewConcertRepository(s.db)
+	cipher, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(s.T(), err)
+
+	bookingRepo := postgres.NewBookingRepository(s.db, cipher)
+	s.bookingService = service.NewBookingService(bookingRepo, s.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	s.sender = mocks.NewMockMailSender()
+	s.reportService = service.NewSalesReportService(postgres.NewSalesReportRepository(s.db), s.concertRepo, bookingRepo, s.sender)
+}
+
+func (s *SalesReportTestSuite) TearDownTest() {
+	// Clean up database after each test
+	testutil.CleanupTestDB(s.db)
+}
+
+func (s *SalesReportTestSuite) TearDownSuite() {
+	// Close database connection
+	s.db.Close()
+}
+
+func (s *SalesReportTestSuite) TestFinalizeSoldOutConcert() {
+	ctx := context.Background()
+
+	// Create a small concert with booking open now
+	concert, err := s.concertRepo.Create(ctx, &model.Concert{
+		Name:             "Small Show",
+		Artist:           "Test Artist",
+		Venue:            "Test Venue",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     3,
+		AvailableTickets: 3,
+		Price:            25.0,
+		Currency:         "USD",
+		OrganizerEmail:   "organizer@example.com",
+		BookingStartTime: time.Now().Add(-1 * time.Hour),
+		BookingEndTime:   time.Now().Add(1 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	// Nothing is due while tickets are left
+	generated, err := s.reportService.FinalizeDue(ctx)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), generated)
+
+	// Sell out the concert
+	_, err = s.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 3})
+	require.NoError(s.T(), err)
+
+	generated, err = s.reportService.FinalizeDue(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, generated)
+
+	// The report is stored and the concert is finalized
+	report, err := s.reportService.GetReport(ctx, concert.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), model.SalesReportReasonSoldOut, report.Reason)
+	assert.Equal(s.T(), 3, report.TicketsSold)
+	assert.Equal(s.T(), 75.0, report.Revenue)
+	assert.Contains(s.T(), report.CSV, "user-1")
+	assert.NotEmpty(s.T(), report.AvailabilityHistory)
+	assert.NotNil(s.T(), report.EmailedAt)
+
+	updated, err := s.concertRepo.GetByID(ctx, concert.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), model.ReportingStateFinalized, updated.ReportingState)
+
+	// The organizer got the report exactly once
+	messages := s.sender.Messages()
+	require.Len(s.T(), messages, 1)
+	assert.Equal(s.T(), []string{"organizer@example.com"}, messages[0].To)
+	require.Len(s.T(), messages[0].Attachments, 1)
+
+	generated, err = s.reportService.FinalizeDue(ctx)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), generated)
+	assert.Len(s.T(), s.sender.Messages(), 1)
+}
+
+func TestSalesReport(t *testing.T) {
+	suite.Run(t, new(SalesReportTestSuite))
+}
@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/postgres"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/crypto"
+	"concert-ticket-api/pkg/warehouse"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type WarehouseTestSuite struct {
+	suite.Suite
+	db            *sqlx.DB
+	concertRepo   repository.ConcertRepository
+	bookingRepo   repository.BookingRepository
+	warehouseRepo repository.WarehouseRepository
+}
+
+func (s *WarehouseTestSuite) SetupSuite() {
+	// Connect to test database
+	var err error
+	s.db, err = testutil.SetupTestDB()
+	require.NoError(s.T(), err)
+
+	// Initialize repositories
+	cipher, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(s.T(), err)
+
+	s.concertRepo = postgres.NewConcertRepository(s.db)
+	s.bookingRepo = postgres.NewBookingRepository(s.db, cipher)
+	s.warehouseRepo = postgres.NewWarehouseRepository(s.db)
+}
+
+func (s *WarehouseTestSuite) TearDownTest() {
+	// Clean up database after each test
+	testutil.CleanupTestDB(s.db)
+}
+
+func (s *WarehouseTestSuite) TearDownSuite() {
+	// Close database connection
+	s.db.Close()
+}
+
+// files reads the rows of the files of a dataset written to dir
+func (s *WarehouseTestSuite) files(dir, dataset string) [][]string {
+	paths, err := filepath.Glob(filepath.Join(dir, dataset, "dt=*", "*"+warehouse.CSVExtension))
+	require.NoError(s.T(), err)
+
+	var rows [][]string
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		require.NoError(s.T(), err)
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(s.T(), err)
+		records, err := csv.NewReader(zr).ReadAll()
+		require.NoError(s.T(), err)
+		rows = append(rows, records[1:]...)
+	}
+	return rows
+}
+
+func (s *WarehouseTestSuite) TestExportResumesAfterTheWatermark() {
+	ctx := context.Background()
+	concert, err := s.concertRepo.Create(ctx, &model.Concert{
+		Name:             "Arena Night",
+		Artist:           "Test Artist",
+		Venue:            "Test Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            50,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	var first *model.Booking
+	for i := 0; i < 3; i++ {
+		booking, err := s.bookingRepo.Create(ctx, &model.Booking{
+			ConcertID: concert.ID, UserID: "user-1", TicketCount: 2, UnitPrice: 50, Status: model.BookingStatusConfirmed,
+		})
+		require.NoError(s.T(), err)
+		if first == nil {
+			first = booking
+		}
+	}
+
+	// Date the changes a minute back, past the lag of the export
+	_, err = s.db.Exec("UPDATE bookings SET updated_at = updated_at - INTERVAL '1 minute'")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("UPDATE concerts SET updated_at = updated_at - INTERVAL '1 minute'")
+	require.NoError(s.T(), err)
+
+	dir := s.T().TempDir()
+	exporter := service.NewWarehouseService(s.warehouseRepo, warehouse.NewDir(dir), "", 2, time.Second)
+	exported, err := exporter.Export(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 4, exported)
+	assert.Len(s.T(), s.files(dir, model.WarehouseDatasetBookings), 3)
+	assert.Len(s.T(), s.files(dir, model.WarehouseDatasetConcerts), 1)
+
+	watermark, err := s.warehouseRepo.GetWatermark(ctx, model.WarehouseDatasetBookings)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(3), watermark.RowsExported)
+
+	// Only the cancelled booking changed since
+	_, err = s.db.Exec("UPDATE bookings SET status = 'cancelled', updated_at = updated_at + INTERVAL '10 seconds' WHERE id = $1", first.ID)
+	require.NoError(s.T(), err)
+	exported, err = exporter.Export(ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, exported)
+
+	rows := s.files(dir, model.WarehouseDatasetBookings)
+	require.Len(s.T(), rows, 4)
+	var statuses []string
+	for _, row := range rows {
+		statuses = append(statuses, row[7])
+	}
+	assert.ElementsMatch(s.T(), []string{"confirmed", "confirmed", "confirmed", "cancelled"}, statuses,
+		"the warehouse keeps both snapshots of the cancelled booking")
+}
+
+func TestWarehouse(t *testing.T) {
+	suite.Run(t, new(WarehouseTestSuite))
+}
@@ -30,11 +30,11 @@ func TestConcurrentBookings(t *testing.T) {
 
 	// Initialize mock repositories
 	concertRepo := mocks.NewMockConcertRepository()
-	bookingRepo := mocks.NewMockBookingRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
 
 	// Initialize services
-	concertService := service.NewConcertService(concertRepo)
-	bookingService := service.NewBookingService(bookingRepo, concertRepo, 3) // Use 3 retries
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	bookingService := service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingConditional, nil)
 
 	// Create a test concert with a limited number of tickets
 	ctx := context.Background()
@@ -44,12 +44,14 @@ func TestConcurrentBookings(t *testing.T) {
 		Venue:            "Load Test Venue",
 		ConcertDate:      time.Now().Add(24 * time.Hour),
 		TotalTickets:     1000, // Limited to 1000 tickets
+		AvailableTickets: 1000,
 		Price:            50.0,
 		BookingStartTime: time.Now().Add(-1 * time.Minute), // Booking started a minute ago
 		BookingEndTime:   time.Now().Add(1 * time.Hour),    // Booking ends in an hour
 	}
 
-	createdConcert, err := concertService.CreateConcert(ctx, concert)
+	// The sale is already open, which the service refuses for new concerts
+	createdConcert, err := concertRepo.Create(ctx, concert)
 	require.NoError(t, err)
 
 	// Number of concurrent users
@@ -147,11 +149,11 @@ func TestConcurrencyRaceCondition(t *testing.T) {
 
 	// Initialize mock repositories
 	concertRepo := mocks.NewMockConcertRepository()
-	bookingRepo := mocks.NewMockBookingRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
 
 	// Initialize services
-	concertService := service.NewConcertService(concertRepo)
-	bookingService := service.NewBookingService(bookingRepo, concertRepo, 3) // Use 3 retries
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	bookingService := service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingConditional, nil)
 
 	// Create a test concert with very limited tickets
 	ctx := context.Background()
@@ -161,12 +163,14 @@ func TestConcurrencyRaceCondition(t *testing.T) {
 		Venue:            "Test Venue",
 		ConcertDate:      time.Now().Add(24 * time.Hour),
 		TotalTickets:     20, // Very limited tickets
+		AvailableTickets: 20,
 		Price:            50.0,
 		BookingStartTime: time.Now().Add(-1 * time.Minute),
 		BookingEndTime:   time.Now().Add(1 * time.Hour),
 	}
 
-	createdConcert, err := concertService.CreateConcert(ctx, concert)
+	// The sale is already open, which the service refuses for new concerts
+	createdConcert, err := concertRepo.Create(ctx, concert)
 	require.NoError(t, err)
 
 	// Number of concurrent users (intentionally more than available tickets)
@@ -0,0 +1,136 @@
+// test/load/replay/main.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/traffic"
+)
+
+// replay sends a traffic recording to a staging deployment at a multiple of
+// the recorded pace, for capacity tests shaped like a real on-sale. The
+// recordings of several replicas are merged in arrival order.
+//
+//	go run ./test/load/replay -target https://staging.example.com -speed 2 traffic-*.jsonl
+func main() {
+	target := flag.String("target", "", "base URL of the deployment to replay against")
+	speed := flag.Float64("speed", 1, "replay pace as a multiple of the recorded pace")
+	skip := flag.Duration("skip", 0, "leave out the requests of the first part of the recording")
+	duration := flag.Duration("duration", 0, "replay only this much of the recording after skip, 0 for all of it")
+	maxInFlight := flag.Int("max-in-flight", 0, "cap on concurrent requests, 0 for no limit")
+	adminToken := flag.String("admin-token", os.Getenv("APP_ADMIN_TOKEN"), "admin token for the requests recorded as admin requests")
+	jsonReport := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	log := logger.NewLogger("info")
+
+	if *target == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replay -target <url> [flags] <recording>...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	records, err := readRecordings(flag.Args())
+	if err != nil {
+		log.Error("Failed to read recordings: %v", err)
+		os.Exit(1)
+	}
+	records = window(records, *skip, *duration)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Info("Replaying %d requests against %s at %gx", len(records), *target, *speed)
+	replayer := &traffic.Replayer{
+		BaseURL:     *target,
+		Speed:       *speed,
+		AdminToken:  *adminToken,
+		MaxInFlight: *maxInFlight,
+	}
+	report, err := replayer.Replay(ctx, records)
+	if err != nil {
+		log.Error("Failed to replay: %v", err)
+		os.Exit(1)
+	}
+
+	if *jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Error("Failed to write report: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printReport(os.Stdout, report)
+}
+
+func readRecordings(paths []string) ([]traffic.Record, error) {
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	return traffic.ReadRecords(readers...)
+}
+
+// window keeps the records from skip after the first record, for duration
+func window(records []traffic.Record, skip, duration time.Duration) []traffic.Record {
+	if len(records) == 0 || (skip == 0 && duration == 0) {
+		return records
+	}
+
+	from := records[0].Time.Add(skip)
+	kept := records[:0]
+	for _, record := range records {
+		if record.Time.Before(from) || (duration > 0 && !record.Time.Before(from.Add(duration))) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+func printReport(out io.Writer, report *traffic.Report) {
+	fmt.Fprintf(out, "Sent %d requests in %s, %d failed, max lag %s\n",
+		report.Sent, report.Duration.Round(time.Millisecond), report.Failed, report.MaxLag.Round(time.Millisecond))
+
+	codes := make([]int, 0, len(report.RecordedStatuses))
+	seen := make(map[int]bool)
+	for _, statuses := range []map[int]int{report.RecordedStatuses, report.Statuses} {
+		for code := range statuses {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(out, "  %d: %d replayed, %d recorded\n", code, report.Statuses[code], report.RecordedStatuses[code])
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nROUTE\tCOUNT\tERRORS\tP50\tP95\tP99\tRECORDED P99")
+	for _, route := range report.Routes {
+		fmt.Fprintf(w, "%s %s\t%d\t%d\t%s\t%s\t%s\t%s\n", route.Method, route.Route, route.Count, route.Errors,
+			route.P50.Round(time.Millisecond), route.P95.Round(time.Millisecond),
+			route.P99.Round(time.Millisecond), route.RecordedP99.Round(time.Millisecond))
+	}
+	w.Flush()
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"concert-ticket-api/pkg/accounting"
+)
+
+// MockAccountingAdapter is a mock implementation of accounting.Adapter that
+// records pushed journal entries
+type MockAccountingAdapter struct {
+	mutex   sync.Mutex
+	name    string
+	entries []*accounting.JournalEntry
+	failing bool
+}
+
+// NewMockAccountingAdapter creates a new mock accounting adapter
+func NewMockAccountingAdapter(name string) *MockAccountingAdapter {
+	return &MockAccountingAdapter{name: name}
+}
+
+// Name returns the adapter name
+func (a *MockAccountingAdapter) Name() string {
+	return a.name
+}
+
+// Push records the entry, or fails while the adapter is set to fail
+func (a *MockAccountingAdapter) Push(ctx context.Context, entry *accounting.JournalEntry) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.failing {
+		return "", errors.New("accounting system unavailable")
+	}
+
+	a.entries = append(a.entries, entry)
+	return fmt.Sprintf("%s-%d", a.name, len(a.entries)), nil
+}
+
+// SetFailing makes subsequent pushes fail or succeed
+func (a *MockAccountingAdapter) SetFailing(failing bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.failing = failing
+}
+
+// Entries returns the entries pushed so far
+func (a *MockAccountingAdapter) Entries() []*accounting.JournalEntry {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]*accounting.JournalEntry(nil), a.entries...)
+}
+
+// Ensure the mock implements the interface
+var _ accounting.Adapter = (*MockAccountingAdapter)(nil)
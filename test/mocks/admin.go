@@ -0,0 +1,195 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+)
+
+// MockAuditRepository is a mock implementation of AuditRepository
+type MockAuditRepository struct {
+	mutex   sync.RWMutex
+	entries []*model.AuditLog
+	nextID  int64
+}
+
+// NewMockAuditRepository creates a new mock audit repository
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{
+		nextID: 1,
+	}
+}
+
+// Create stores a new audit log entry
+func (r *MockAuditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry.ID = r.nextID
+	r.nextID++
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entryCopy := *entry
+	r.entries = append(r.entries, &entryCopy)
+	return nil
+}
+
+// List returns a page of the entries matching the filter, latest first, and
+// the total number of matching entries
+func (r *MockAuditRepository) List(ctx context.Context, filter model.AuditFilter, page query.Page) ([]*model.AuditLog, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	matching := []*model.AuditLog{}
+	for _, entry := range r.entries {
+		if matches(filter.Actor, entry.Actor) && matches(filter.Action, entry.Action) &&
+			matches(filter.ResourceType, entry.ResourceType) && matches(filter.ResourceID, entry.ResourceID) {
+			entryCopy := *entry
+			matching = append(matching, &entryCopy)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt) {
+			return matching[i].CreatedAt.After(matching[j].CreatedAt)
+		}
+		return matching[i].ID > matching[j].ID
+	})
+
+	total := len(matching)
+	start := page.Offset()
+	if start > total {
+		start = total
+	}
+	end := start + page.Limit()
+	if end > total {
+		end = total
+	}
+	return matching[start:end], total, nil
+}
+
+// matches reports whether a value passes a filter field; empty fields match everything
+func matches(filter, value string) bool {
+	return filter == "" || filter == value
+}
+
+// MockAdminRepository is a mock implementation of AdminRepository that works
+// on the concerts and bookings of the mock repositories
+type MockAdminRepository struct {
+	concerts *MockConcertRepository
+	bookings *MockBookingRepository
+}
+
+// NewMockAdminRepository creates a new mock admin repository
+func NewMockAdminRepository(concerts *MockConcertRepository, bookings *MockBookingRepository) *MockAdminRepository {
+	return &MockAdminRepository{
+		concerts: concerts,
+		bookings: bookings,
+	}
+}
+
+// ForceCancelBooking cancels a booking and returns its tickets to the concert
+func (r *MockAdminRepository) ForceCancelBooking(ctx context.Context, reference string) (*model.Booking, *model.ConcertAvailability, error) {
+	r.bookings.mutex.Lock()
+	defer r.bookings.mutex.Unlock()
+
+	var booking *model.Booking
+	for _, candidate := range r.bookings.bookings {
+		if candidate.Reference == reference {
+			booking = candidate
+			break
+		}
+	}
+	if booking == nil {
+		return nil, nil, errors.ErrNotFound
+	}
+	if booking.Status == model.BookingStatusCancelled {
+		return nil, nil, errors.ErrBookingAlreadyCancelled
+	}
+
+	availability, err := r.adjustTickets(booking.ConcertID, 0, booking.TicketCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	booking.Status = model.BookingStatusCancelled
+	booking.UpdatedAt = time.Now()
+	bookingCopy := *booking
+	return &bookingCopy, availability, nil
+}
+
+// AdjustInventory adds delta to the total and available tickets of a concert
+func (r *MockAdminRepository) AdjustInventory(ctx context.Context, concertID int64, delta int) (*model.ConcertAvailability, error) {
+	return r.adjustTickets(concertID, delta, delta)
+}
+
+// CountInventory counts the tickets of a concert, or of every concert, from
+// the mock bookings. The mocks have no inventory releases.
+func (r *MockAdminRepository) CountInventory(ctx context.Context, concertID int64) ([]*model.InventoryCount, error) {
+	// Locked in the order ForceCancelBooking locks them
+	r.bookings.mutex.RLock()
+	defer r.bookings.mutex.RUnlock()
+	r.concerts.mutex.RLock()
+	defer r.concerts.mutex.RUnlock()
+
+	var counts []*model.InventoryCount
+	for _, concert := range r.concerts.concerts {
+		if concertID != 0 && concert.ID != concertID {
+			continue
+		}
+		count := &model.InventoryCount{
+			ConcertID:        concert.ID,
+			TotalTickets:     concert.TotalTickets,
+			AvailableTickets: concert.AvailableTickets,
+		}
+		for _, booking := range r.bookings.bookings {
+			if booking.ConcertID == concert.ID && booking.Status != model.BookingStatusCancelled {
+				count.BookedTickets += booking.TicketCount
+			}
+		}
+		counts = append(counts, count)
+	}
+	if concertID != 0 && len(counts) == 0 {
+		return nil, errors.ErrNotFound
+	}
+
+	sort.Slice(counts, func(i, j int) bool { return counts[i].ConcertID < counts[j].ConcertID })
+	return counts, nil
+}
+
+// adjustTickets adds to the total and available tickets of a concert
+func (r *MockAdminRepository) adjustTickets(concertID int64, total, available int) (*model.ConcertAvailability, error) {
+	r.concerts.mutex.Lock()
+	defer r.concerts.mutex.Unlock()
+
+	concert, ok := r.concerts.concerts[concertID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	if concert.AvailableTickets+available < 0 {
+		return nil, errors.ErrInsufficientTickets
+	}
+
+	concert.TotalTickets += total
+	concert.AvailableTickets += available
+	concert.Version++
+	concert.UpdatedAt = time.Now()
+
+	return &model.ConcertAvailability{
+		ConcertID:        concert.ID,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		UpdatedAt:        concert.UpdatedAt,
+	}, nil
+}
+
+// Ensure the mocks implement the interfaces
+var _ repository.AuditRepository = (*MockAuditRepository)(nil)
+var _ repository.AdminRepository = (*MockAdminRepository)(nil)
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"concert-ticket-api/pkg/alerts"
+)
+
+// MockAlertPoster is a mock implementation of alerts.Poster that records
+// the alerts posted. Post fails as injected with FailNext.
+type MockAlertPoster struct {
+	Failures
+
+	mutex  sync.Mutex
+	posted []string
+}
+
+// NewMockAlertPoster creates a new mock alert poster
+func NewMockAlertPoster() *MockAlertPoster {
+	return &MockAlertPoster{}
+}
+
+// Post records the alert
+func (p *MockAlertPoster) Post(ctx context.Context, text string) error {
+	if err := p.fail("Post"); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.posted = append(p.posted, text)
+	return nil
+}
+
+// Posted returns the alerts posted so far
+func (p *MockAlertPoster) Posted() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]string(nil), p.posted...)
+}
+
+// Ensure the mock implements the interface
+var _ alerts.Poster = (*MockAlertPoster)(nil)
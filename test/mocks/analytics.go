@@ -0,0 +1,172 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+)
+
+// MockAnalyticsRepository is a mock implementation of AnalyticsRepository
+// that aggregates the bookings of a mock payment repository, like the
+// queries of the PostgreSQL implementation
+type MockAnalyticsRepository struct {
+	payments *MockPaymentRepository
+}
+
+var _ repository.AnalyticsRepository = (*MockAnalyticsRepository)(nil)
+
+// NewMockAnalyticsRepository creates a new mock analytics repository over a
+// payment repository, whose booking repository has concerts
+func NewMockAnalyticsRepository(payments *MockPaymentRepository) *MockAnalyticsRepository {
+	return &MockAnalyticsRepository{
+		payments: payments,
+	}
+}
+
+// OrganizerDashboard aggregates the bookings of the concerts of an organizer
+func (r *MockAnalyticsRepository) OrganizerDashboard(ctx context.Context, organizerEmail string, dayStart, dayEnd time.Time, top int) (*model.OrganizerDashboard, error) {
+	r.payments.mutex.Lock()
+	defer r.payments.mutex.Unlock()
+	bookings := r.payments.bookings
+	concerts := bookings.concerts
+	concerts.mutex.RLock()
+	defer concerts.mutex.RUnlock()
+	bookings.mutex.RLock()
+	defer bookings.mutex.RUnlock()
+
+	dashboard := &model.OrganizerDashboard{
+		OrganizerEmail: organizerEmail,
+		DayStart:       dayStart,
+		DayEnd:         dayEnd,
+		SalesToday:     []*model.CurrencySales{},
+		TopConcerts:    []*model.ConcertSales{},
+	}
+
+	sales := make(map[int64]*model.ConcertSales)
+	for _, concert := range concerts.concerts {
+		if concert.OrganizerEmail == organizerEmail {
+			sales[concert.ID] = &model.ConcertSales{
+				ConcertID:    concert.ID,
+				Name:         concert.Name,
+				ConcertDate:  concert.ConcertDate,
+				TotalTickets: concert.TotalTickets,
+				Currency:     concert.Currency,
+			}
+		}
+	}
+	dashboard.Concerts = len(sales)
+
+	today := make(map[string]*model.CurrencySales)
+	for _, booking := range bookings.bookings {
+		concert, ok := sales[booking.ConcertID]
+		if !ok {
+			continue
+		}
+
+		payment := r.payments.byBooking(booking.ID)
+		captured := payment != nil && (payment.Status == model.PaymentSucceeded || payment.Status == model.PaymentRefunded)
+		if payment != nil {
+			dashboard.Holds.Holds++
+			switch {
+			case booking.Status == model.BookingStatusPending:
+				dashboard.Holds.Pending++
+			case booking.Status == model.BookingStatusConfirmed || captured:
+				dashboard.Holds.Confirmed++
+			default:
+				dashboard.Holds.Lapsed++
+			}
+		}
+
+		switch {
+		case booking.Status == model.BookingStatusConfirmed:
+			dashboard.Cancellations.Bookings++
+		case booking.Status == model.BookingStatusCancelled && (payment == nil || captured):
+			dashboard.Cancellations.Bookings++
+			dashboard.Cancellations.Cancelled++
+		}
+
+		if booking.Status != model.BookingStatusConfirmed {
+			continue
+		}
+		gross := float64(booking.TicketCount) * booking.UnitPrice
+		concert.TicketsSold += booking.TicketCount
+		concert.Gross += gross
+
+		if booking.BookingTime.Before(dayStart) || !booking.BookingTime.Before(dayEnd) {
+			continue
+		}
+		currency, ok := today[concert.Currency]
+		if !ok {
+			currency = &model.CurrencySales{Currency: concert.Currency}
+			today[concert.Currency] = currency
+			dashboard.SalesToday = append(dashboard.SalesToday, currency)
+		}
+		currency.Bookings++
+		currency.Tickets += booking.TicketCount
+		currency.Gross += gross
+	}
+
+	sort.Slice(dashboard.SalesToday, func(i, j int) bool {
+		return dashboard.SalesToday[i].Currency < dashboard.SalesToday[j].Currency
+	})
+
+	for _, concert := range sales {
+		dashboard.TopConcerts = append(dashboard.TopConcerts, concert)
+	}
+	sort.Slice(dashboard.TopConcerts, func(i, j int) bool {
+		a, b := dashboard.TopConcerts[i], dashboard.TopConcerts[j]
+		if a.TicketsSold != b.TicketsSold {
+			return a.TicketsSold > b.TicketsSold
+		}
+		if a.Gross != b.Gross {
+			return a.Gross > b.Gross
+		}
+		return a.ConcertID < b.ConcertID
+	})
+	if len(dashboard.TopConcerts) > top {
+		dashboard.TopConcerts = dashboard.TopConcerts[:top]
+	}
+
+	return dashboard, nil
+}
+
+// SalesTimeseries buckets the confirmed bookings of a concert by the hour or
+// day they were made in the location
+func (r *MockAnalyticsRepository) SalesTimeseries(ctx context.Context, concertID int64, interval string, location *time.Location, from, to time.Time) ([]*model.SalesBucket, error) {
+	bookings := r.payments.bookings
+	bookings.mutex.RLock()
+	defer bookings.mutex.RUnlock()
+
+	byStart := make(map[time.Time]*model.SalesBucket)
+	var buckets []*model.SalesBucket
+	for _, booking := range bookings.bookings {
+		if booking.ConcertID != concertID || booking.Status != model.BookingStatusConfirmed ||
+			booking.BookingTime.Before(from) || !booking.BookingTime.Before(to) {
+			continue
+		}
+
+		at := booking.BookingTime.In(location)
+		start := time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, location)
+		if interval == model.SalesIntervalDay {
+			start = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, location)
+		}
+
+		bucket, ok := byStart[start]
+		if !ok {
+			bucket = &model.SalesBucket{Start: start}
+			byStart[start] = bucket
+			buckets = append(buckets, bucket)
+		}
+		bucket.Bookings++
+		bucket.Tickets += booking.TicketCount
+		bucket.Revenue += float64(booking.TicketCount) * booking.UnitPrice
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+	return buckets, nil
+}
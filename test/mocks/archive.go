@@ -0,0 +1,74 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+)
+
+// MockArchiveRepository is a mock implementation of ArchiveRepository that
+// moves bookings out of a MockBookingRepository, by the dates of the concerts
+// of a MockConcertRepository
+type MockArchiveRepository struct {
+	concerts *MockConcertRepository
+	bookings *MockBookingRepository
+	archived map[int64]*model.Booking
+	// Calls counts the batches asked for, including those that moved nothing
+	Calls int
+}
+
+// NewMockArchiveRepository creates a new mock archive repository
+func NewMockArchiveRepository(concerts *MockConcertRepository, bookings *MockBookingRepository) *MockArchiveRepository {
+	return &MockArchiveRepository{
+		concerts: concerts,
+		bookings: bookings,
+		archived: make(map[int64]*model.Booking),
+	}
+}
+
+// ArchiveBookings moves up to limit bookings of concerts that took place
+// before cutoff, in ID order
+func (r *MockArchiveRepository) ArchiveBookings(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	r.concerts.mutex.RLock()
+	defer r.concerts.mutex.RUnlock()
+	r.bookings.mutex.Lock()
+	defer r.bookings.mutex.Unlock()
+	r.Calls++
+
+	ids := []int64{}
+	for id, booking := range r.bookings.bookings {
+		concert, ok := r.concerts.concerts[booking.ConcertID]
+		if ok && concert.ConcertDate.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	for _, id := range ids {
+		r.archived[id] = r.bookings.bookings[id]
+		delete(r.bookings.bookings, id)
+	}
+	return len(ids), nil
+}
+
+// Archived returns the IDs of the archived bookings in order
+func (r *MockArchiveRepository) Archived() []int64 {
+	r.bookings.mutex.RLock()
+	defer r.bookings.mutex.RUnlock()
+
+	ids := make([]int64, 0, len(r.archived))
+	for id := range r.archived {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Ensure the mock implements the interface
+var _ repository.ArchiveRepository = (*MockArchiveRepository)(nil)
@@ -0,0 +1,165 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/reference"
+)
+
+// MockCartRepository is a mock implementation of CartRepository. Checkouts
+// create their bookings in a MockBookingRepository and, unlike the booking
+// mock, take their tickets from the concerts of a MockConcertRepository.
+type MockCartRepository struct {
+	mutex       sync.Mutex
+	concerts    *MockConcertRepository
+	bookings    *MockBookingRepository
+	items       map[string]map[int64]*model.CartItem
+	orders      []*model.Order
+	nextOrderID int64
+}
+
+// NewMockCartRepository creates a new mock cart repository
+func NewMockCartRepository(concerts *MockConcertRepository, bookings *MockBookingRepository) *MockCartRepository {
+	return &MockCartRepository{
+		concerts:    concerts,
+		bookings:    bookings,
+		items:       make(map[string]map[int64]*model.CartItem),
+		nextOrderID: 1,
+	}
+}
+
+// ListItems returns the items of a user's cart
+func (r *MockCartRepository) ListItems(ctx context.Context, userID string) ([]*model.CartItem, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	items := []*model.CartItem{}
+	for _, item := range r.items[userID] {
+		itemCopy := *item
+		items = append(items, &itemCopy)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].AddedAt.Equal(items[j].AddedAt) {
+			return items[i].AddedAt.Before(items[j].AddedAt)
+		}
+		return items[i].ConcertID < items[j].ConcertID
+	})
+	return items, nil
+}
+
+// PutItem adds an item to a cart, replacing the item of the same concert
+func (r *MockCartRepository) PutItem(ctx context.Context, item *model.CartItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Like the foreign key, items must be of existing concerts
+	if _, err := r.concerts.GetByID(ctx, item.ConcertID); err != nil {
+		return err
+	}
+
+	cart, ok := r.items[item.UserID]
+	if !ok {
+		cart = make(map[int64]*model.CartItem)
+		r.items[item.UserID] = cart
+	}
+
+	if existing, ok := cart[item.ConcertID]; ok {
+		item.AddedAt = existing.AddedAt
+	} else {
+		item.AddedAt = time.Now()
+	}
+	itemCopy := *item
+	cart[item.ConcertID] = &itemCopy
+	return nil
+}
+
+// RemoveItem removes the item of a concert from a cart
+func (r *MockCartRepository) RemoveItem(ctx context.Context, userID string, concertID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.items[userID][concertID]; !ok {
+		return errors.ErrNotFound
+	}
+	delete(r.items[userID], concertID)
+	return nil
+}
+
+// Checkout books the bookings of an order, holding the concerts' lock like
+// the transaction holds their rows
+func (r *MockCartRepository) Checkout(ctx context.Context, order *model.Order, bookings []*model.Booking, versions map[int64]int, bestEffort bool) ([]error, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.concerts.mutex.Lock()
+	defer r.concerts.mutex.Unlock()
+
+	remaining := make(map[int64]int, len(versions))
+	for concertID, version := range versions {
+		concert, ok := r.concerts.concerts[concertID]
+		if !ok || concert.Version != version {
+			return nil, errors.ErrOptimisticLockFailed
+		}
+		if concert.IsBookingOpen() {
+			remaining[concertID] = concert.AvailableTickets
+		}
+	}
+
+	errs := make([]error, len(bookings))
+	booked := make([]*model.Booking, 0, len(bookings))
+	for i, booking := range bookings {
+		left, open := remaining[booking.ConcertID]
+		switch {
+		case !open:
+			errs[i] = errors.ErrBookingClosed
+		case left < booking.TicketCount:
+			errs[i] = errors.ErrInsufficientTickets
+		default:
+			remaining[booking.ConcertID] -= booking.TicketCount
+			booked = append(booked, booking)
+			continue
+		}
+		if !bestEffort {
+			return errs, nil
+		}
+	}
+	if len(booked) == 0 {
+		return errs, nil
+	}
+
+	taken := make(map[int64]bool)
+	for _, booking := range booked {
+		if _, err := r.bookings.Create(ctx, booking); err != nil {
+			return nil, err
+		}
+		concert := r.concerts.concerts[booking.ConcertID]
+		concert.AvailableTickets -= booking.TicketCount
+		taken[booking.ConcertID] = true
+		delete(r.items[order.UserID], booking.ConcertID)
+	}
+	for concertID := range taken {
+		r.concerts.concerts[concertID].Version++
+	}
+
+	order.ID = r.nextOrderID
+	r.nextOrderID++
+	if order.Reference == "" {
+		order.Reference = reference.New()
+	}
+	order.Status = model.BookingStatusConfirmed
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	order.Bookings = booked
+
+	orderCopy := *order
+	r.orders = append(r.orders, &orderCopy)
+	return errs, nil
+}
+
+var _ repository.CartRepository = (*MockCartRepository)(nil)
@@ -0,0 +1,37 @@
+package mocks
+
+import "sync"
+
+// Failures makes the next calls of a mock's methods fail, for testing the
+// paths that retry or give up, such as optimistic lock conflicts. It is
+// embedded by the concert and booking repositories; a failed call changes
+// nothing.
+type Failures struct {
+	mutex   sync.Mutex
+	pending map[string][]error
+}
+
+// FailNext makes the next calls of the named method, e.g.
+// "CreateWithTicketUpdate", return errs, one call per error in order
+func (f *Failures) FailNext(method string, errs ...error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.pending == nil {
+		f.pending = make(map[string][]error)
+	}
+	f.pending[method] = append(f.pending[method], errs...)
+}
+
+// fail returns the next error injected for a method, or nil
+func (f *Failures) fail(method string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	errs := f.pending[method]
+	if len(errs) == 0 {
+		return nil
+	}
+	f.pending[method] = errs[1:]
+	return errs[0]
+}
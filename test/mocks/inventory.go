@@ -0,0 +1,128 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/errors"
+)
+
+// MockInventoryRepository is a mock implementation of InventoryRepository
+// that creates bookings in a MockBookingRepository and subtracts their
+// tickets from the concerts of a MockConcertRepository when flushed. Like the
+// inventory lock in the database, reservations run concurrently with each
+// other but not with seeding or flushing.
+type MockInventoryRepository struct {
+	lock     sync.RWMutex
+	concerts *MockConcertRepository
+	bookings *MockBookingRepository
+
+	// mutex guards pending, the ticket counts of the pending bookings of
+	// each concert
+	mutex   sync.Mutex
+	pending map[int64][]int
+}
+
+// NewMockInventoryRepository creates a new mock inventory repository
+func NewMockInventoryRepository(concerts *MockConcertRepository, bookings *MockBookingRepository) *MockInventoryRepository {
+	return &MockInventoryRepository{
+		concerts: concerts,
+		bookings: bookings,
+		pending:  make(map[int64][]int),
+	}
+}
+
+// Reserve reserves the tickets of a booking with the counter and creates it
+func (r *MockInventoryRepository) Reserve(ctx context.Context, booking *model.Booking, counter repository.InventoryCounter) (int, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	concert, available, err := r.inventory(ctx, booking.ConcertID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !concert.IsBookingOpen() {
+		return 0, errors.ErrBookingClosed
+	}
+
+	left, err := counter.Reserve(ctx, booking.ConcertID, booking.TicketCount, concert.Version, available)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := r.bookings.Create(ctx, booking); err != nil {
+		if releaseErr := counter.Release(ctx, booking.ConcertID, booking.TicketCount, concert.Version); releaseErr != nil {
+			return 0, fmt.Errorf("%w; and failed to release its tickets: %v", err, releaseErr)
+		}
+		return 0, err
+	}
+
+	r.mutex.Lock()
+	r.pending[booking.ConcertID] = append(r.pending[booking.ConcertID], booking.TicketCount)
+	r.mutex.Unlock()
+
+	return left, nil
+}
+
+// inventory returns a concert and how many of its tickets pending bookings
+// didn't take
+func (r *MockInventoryRepository) inventory(ctx context.Context, concertID int64) (*model.Concert, int, error) {
+	concert, err := r.concerts.GetByID(ctx, concertID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	available := concert.AvailableTickets
+	for _, count := range r.pending[concertID] {
+		available -= count
+	}
+	return concert, available, nil
+}
+
+// Seed sets the counter of a concert to the tickets not taken
+func (r *MockInventoryRepository) Seed(ctx context.Context, concertID int64, counter repository.InventoryCounter) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	concert, available, err := r.inventory(ctx, concertID)
+	if err != nil {
+		return err
+	}
+	return counter.Seed(ctx, concertID, concert.Version, available)
+}
+
+// Flush subtracts the tickets of pending bookings from their concerts
+func (r *MockInventoryRepository) Flush(ctx context.Context) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.concerts.mutex.Lock()
+	defer r.concerts.mutex.Unlock()
+
+	settled := 0
+	for concertID, counts := range r.pending {
+		concert, ok := r.concerts.concerts[concertID]
+		if !ok {
+			return settled, errors.ErrNotFound
+		}
+		for _, count := range counts {
+			concert.AvailableTickets -= count
+		}
+		concert.Version++
+		settled += len(counts)
+		delete(r.pending, concertID)
+	}
+	return settled, nil
+}
+
+var _ repository.InventoryRepository = (*MockInventoryRepository)(nil)
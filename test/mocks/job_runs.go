@@ -0,0 +1,124 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
+)
+
+// MockJobRunRepository is a mock implementation of JobRunRepository
+type MockJobRunRepository struct {
+	mutex  sync.RWMutex
+	runs   []*worker.Run
+	nextID int64
+}
+
+// NewMockJobRunRepository creates a new mock job run repository
+func NewMockJobRunRepository() *MockJobRunRepository {
+	return &MockJobRunRepository{
+		nextID: 1,
+	}
+}
+
+// RecordRun stores a finished run
+func (r *MockJobRunRepository) RecordRun(ctx context.Context, run *worker.Run) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	run.ID = r.nextID
+	r.nextID++
+	runCopy := *run
+	r.runs = append(r.runs, &runCopy)
+	return nil
+}
+
+// ListRuns returns a page of the runs of a job, latest first, and the total
+// number of its runs
+func (r *MockJobRunRepository) ListRuns(ctx context.Context, job string, page query.Page) ([]*worker.Run, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	matching := []*worker.Run{}
+	for _, run := range r.runs {
+		if run.Job == job {
+			runCopy := *run
+			matching = append(matching, &runCopy)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].StartedAt.Equal(matching[j].StartedAt) {
+			return matching[i].StartedAt.After(matching[j].StartedAt)
+		}
+		return matching[i].ID > matching[j].ID
+	})
+
+	total := len(matching)
+	start := page.Offset()
+	if start > total {
+		start = total
+	}
+	end := start + page.Limit()
+	if end > total {
+		end = total
+	}
+	return matching[start:end], total, nil
+}
+
+// PurgeBefore deletes the runs that started before the given time
+func (r *MockJobRunRepository) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	kept := r.runs[:0]
+	for _, run := range r.runs {
+		if !run.StartedAt.Before(before) {
+			kept = append(kept, run)
+		}
+	}
+	purged := int64(len(r.runs) - len(kept))
+	r.runs = kept
+	return purged, nil
+}
+
+// MockJobLocker is a mock implementation of worker.Locker; registries sharing
+// one behave like instances sharing a database
+type MockJobLocker struct {
+	mutex sync.Mutex
+	held  map[string]bool
+}
+
+// NewMockJobLocker creates a new mock job locker
+func NewMockJobLocker() *MockJobLocker {
+	return &MockJobLocker{
+		held: make(map[string]bool),
+	}
+}
+
+// TryLock takes the lock of a job unless it is held
+func (l *MockJobLocker) TryLock(ctx context.Context, job string) (func(), bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.held[job] {
+		return nil, false, nil
+	}
+	l.held[job] = true
+
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		delete(l.held, job)
+	}, true, nil
+}
+
+// Ensure the mocks implement the interfaces
+var (
+	_ repository.JobRunRepository = (*MockJobRunRepository)(nil)
+	_ worker.Locker               = (*MockJobLocker)(nil)
+)
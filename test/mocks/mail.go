@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"concert-ticket-api/pkg/mail"
+)
+
+// MockMailSender is a mock implementation of mail.Sender that records sent
+// messages. Send fails as injected with FailNext.
+type MockMailSender struct {
+	Failures
+
+	mutex    sync.Mutex
+	messages []*mail.Message
+}
+
+// NewMockMailSender creates a new mock mail sender
+func NewMockMailSender() *MockMailSender {
+	return &MockMailSender{}
+}
+
+// Send records the message
+func (s *MockMailSender) Send(ctx context.Context, msg *mail.Message) error {
+	if err := s.fail("Send"); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (s *MockMailSender) Messages() []*mail.Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*mail.Message(nil), s.messages...)
+}
+
+// Ensure the mock implements the interface
+var _ mail.Sender = (*MockMailSender)(nil)
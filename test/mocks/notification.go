@@ -0,0 +1,307 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/notifications"
+)
+
+// MockNotificationRepository is a mock implementation of
+// NotificationRepository that turns the events of a mock outbox into
+// notifications of the bookings of a mock booking repository with concerts
+type MockNotificationRepository struct {
+	mutex         sync.Mutex
+	outbox        *MockOutboxRepository
+	bookings      *MockBookingRepository
+	preferences   *MockNotificationPreferenceRepository
+	notifications map[int64]*model.Notification
+	notified      map[int64]time.Time
+	nextID        int64
+}
+
+// NewMockNotificationRepository creates a new mock notification repository
+// over an outbox, a booking repository with concerts and the preferences of
+// their users
+func NewMockNotificationRepository(outbox *MockOutboxRepository, bookings *MockBookingRepository,
+	preferences *MockNotificationPreferenceRepository) *MockNotificationRepository {
+	return &MockNotificationRepository{
+		outbox:        outbox,
+		bookings:      bookings,
+		preferences:   preferences,
+		notifications: make(map[int64]*model.Notification),
+		notified:      make(map[int64]time.Time),
+		nextID:        1,
+	}
+}
+
+// ListEvents retrieves the oldest events not yet notified in ID order
+func (r *MockNotificationRepository) ListEvents(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	events := r.outbox.Events()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending := []*model.OutboxEvent{}
+	for _, event := range events {
+		if _, ok := r.notified[event.ID]; ok {
+			continue
+		}
+		copied := *event
+		pending = append(pending, &copied)
+		if len(pending) == limit {
+			break
+		}
+	}
+	return pending, nil
+}
+
+// MarkEventsNotified records that events were turned into notifications
+func (r *MockNotificationRepository) MarkEventsNotified(ctx context.Context, ids []int64, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, id := range ids {
+		r.notified[id] = at
+	}
+	return nil
+}
+
+// Enqueue creates notifications, skipping the existing ones
+func (r *MockNotificationRepository) Enqueue(ctx context.Context, notifications []*model.Notification) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	created := 0
+	for _, notification := range notifications {
+		if r.add(notification) {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// EnqueueReminders creates the email reminders of the confirmed bookings
+// with an attendee email of the concerts taking place in a window, unless
+// their users turned email or the template off
+func (r *MockNotificationRepository) EnqueueReminders(ctx context.Context, template string, from, to, at time.Time) (int, error) {
+	r.bookings.mutex.RLock()
+	r.bookings.concerts.mutex.RLock()
+	r.preferences.mutex.Lock()
+	var reminded []int64
+	for _, booking := range r.bookings.bookings {
+		concert, ok := r.bookings.concerts.concerts[booking.ConcertID]
+		if !ok || booking.Status != model.BookingStatusConfirmed || booking.AttendeeEmail == "" ||
+			!concert.ConcertDate.After(from) || concert.ConcertDate.After(to) {
+			continue
+		}
+		if preferences, ok := r.preferences.preferences[booking.UserID]; ok && !preferences.Wants(model.NotificationChannelEmail, template) {
+			continue
+		}
+		reminded = append(reminded, booking.ID)
+	}
+	r.preferences.mutex.Unlock()
+	r.bookings.concerts.mutex.RUnlock()
+	r.bookings.mutex.RUnlock()
+	sort.Slice(reminded, func(i, j int) bool { return reminded[i] < reminded[j] })
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	created := 0
+	for _, bookingID := range reminded {
+		if r.add(&model.Notification{Channel: model.NotificationChannelEmail, Template: template, BookingID: bookingID, NextAttemptAt: at}) {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// ListDue retrieves the pending notifications due at a time
+func (r *MockNotificationRepository) ListDue(ctx context.Context, at time.Time, limit int) ([]*model.Notification, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	due := []*model.Notification{}
+	for _, notification := range r.sorted() {
+		if notification.Status != model.NotificationPending || notification.NextAttemptAt.After(at) {
+			continue
+		}
+		copied := *notification
+		due = append(due, &copied)
+		if len(due) == limit {
+			break
+		}
+	}
+	return due, nil
+}
+
+// RecordAttempt saves the outcome of an attempt to send a notification
+func (r *MockNotificationRepository) RecordAttempt(ctx context.Context, notification *model.Notification) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *notification
+	r.notifications[stored.ID] = &stored
+	return nil
+}
+
+// Purge deletes the sent and dead notifications created before a time
+func (r *MockNotificationRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := 0
+	for id, notification := range r.notifications {
+		if notification.CreatedAt.Before(before) && notification.Status != model.NotificationPending {
+			delete(r.notifications, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Notifications returns every notification in ID order
+func (r *MockNotificationRepository) Notifications() []*model.Notification {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	notifications := []*model.Notification{}
+	for _, notification := range r.sorted() {
+		copied := *notification
+		notifications = append(notifications, &copied)
+	}
+	return notifications
+}
+
+// add stores a pending notification unless one exists for the same channel,
+// template, booking and event. The mutex must be held.
+func (r *MockNotificationRepository) add(notification *model.Notification) bool {
+	for _, existing := range r.notifications {
+		if existing.Channel == notification.Channel && existing.Template == notification.Template &&
+			existing.BookingID == notification.BookingID && existing.EventID == notification.EventID {
+			return false
+		}
+	}
+
+	stored := *notification
+	stored.ID = r.nextID
+	stored.Status = model.NotificationPending
+	stored.CreatedAt = notification.NextAttemptAt
+	r.notifications[stored.ID] = &stored
+	r.nextID++
+	return true
+}
+
+// sorted returns the notifications in ID order. The mutex must be held.
+func (r *MockNotificationRepository) sorted() []*model.Notification {
+	sorted := make([]*model.Notification, 0, len(r.notifications))
+	for _, notification := range r.notifications {
+		sorted = append(sorted, notification)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// MockNotificationPreferenceRepository is a mock implementation of
+// NotificationPreferenceRepository
+type MockNotificationPreferenceRepository struct {
+	mutex       sync.Mutex
+	preferences map[string]*model.NotificationPreferences
+}
+
+// NewMockNotificationPreferenceRepository creates a new mock notification
+// preference repository
+func NewMockNotificationPreferenceRepository() *MockNotificationPreferenceRepository {
+	return &MockNotificationPreferenceRepository{
+		preferences: make(map[string]*model.NotificationPreferences),
+	}
+}
+
+// Get retrieves the preferences of a user
+func (r *MockNotificationPreferenceRepository) Get(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	preferences, ok := r.preferences[userID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *preferences
+	return &copied, nil
+}
+
+// Set creates or replaces the preferences of a user
+func (r *MockNotificationPreferenceRepository) Set(ctx context.Context, preferences *model.NotificationPreferences) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	preferences.UpdatedAt = clock.Now()
+	stored := *preferences
+	r.preferences[stored.UserID] = &stored
+	return nil
+}
+
+// Delete removes the preferences of a user
+func (r *MockNotificationPreferenceRepository) Delete(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.preferences, userID)
+	return nil
+}
+
+// Notified is a notification a MockNotifier sent
+type Notified struct {
+	To       string
+	Template string
+	Data     notifications.Data
+}
+
+// MockNotifier is a mock implementation of notifications.Notifier that
+// records the notifications. Notify fails as injected with FailNext.
+type MockNotifier struct {
+	Failures
+
+	mutex    sync.Mutex
+	notified []Notified
+}
+
+// NewMockNotifier creates a new mock notifier
+func NewMockNotifier() *MockNotifier {
+	return &MockNotifier{}
+}
+
+// Notify records the notification
+func (n *MockNotifier) Notify(ctx context.Context, to, template string, data *notifications.Data) error {
+	if err := n.fail("Notify"); err != nil {
+		return err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.notified = append(n.notified, Notified{To: to, Template: template, Data: *data})
+	return nil
+}
+
+// Notified returns the notifications sent so far
+func (n *MockNotifier) Notified() []Notified {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return append([]Notified(nil), n.notified...)
+}
+
+// Ensure the mocks implement the interfaces
+var (
+	_ repository.NotificationRepository           = (*MockNotificationRepository)(nil)
+	_ repository.NotificationPreferenceRepository = (*MockNotificationPreferenceRepository)(nil)
+	_ notifications.Notifier                      = (*MockNotifier)(nil)
+)
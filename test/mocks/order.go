@@ -0,0 +1,133 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+)
+
+// MockOrderRepository is a mock implementation of OrderRepository over the
+// orders checked out by a MockCartRepository. Cancellations return the
+// tickets to the concerts the checkouts took them from.
+type MockOrderRepository struct {
+	carts *MockCartRepository
+}
+
+// NewMockOrderRepository creates a new mock order repository
+func NewMockOrderRepository(carts *MockCartRepository) *MockOrderRepository {
+	return &MockOrderRepository{carts: carts}
+}
+
+// GetByReference retrieves an order by its public reference
+func (r *MockOrderRepository) GetByReference(ctx context.Context, reference string) (*model.Order, error) {
+	r.carts.mutex.Lock()
+	defer r.carts.mutex.Unlock()
+
+	for _, order := range r.carts.orders {
+		if order.Reference == reference {
+			return r.copyOrder(order), nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+// GetByUserID retrieves the orders of a user, newest first
+func (r *MockOrderRepository) GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Order, error) {
+	r.carts.mutex.Lock()
+	defer r.carts.mutex.Unlock()
+
+	result := []*model.Order{}
+	for _, order := range r.carts.orders {
+		if order.UserID == userID {
+			result = append(result, r.copyOrder(order))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+
+	offset := page.Offset()
+	if offset > len(result) {
+		return []*model.Order{}, nil
+	}
+	end := offset + page.Limit()
+	if end > len(result) {
+		end = len(result)
+	}
+	return result[offset:end], nil
+}
+
+// Cancel cancels an order and its confirmed bookings
+func (r *MockOrderRepository) Cancel(ctx context.Context, orderID int64) ([]*model.ConcertAvailability, error) {
+	r.carts.mutex.Lock()
+	defer r.carts.mutex.Unlock()
+
+	var order *model.Order
+	for _, o := range r.carts.orders {
+		if o.ID == orderID {
+			order = o
+		}
+	}
+	if order == nil {
+		return nil, errors.ErrNotFound
+	}
+	if order.Status == model.BookingStatusCancelled {
+		return nil, errors.ErrOrderAlreadyCancelled
+	}
+
+	r.carts.concerts.mutex.Lock()
+	defer r.carts.concerts.mutex.Unlock()
+	r.carts.bookings.mutex.Lock()
+	defer r.carts.bookings.mutex.Unlock()
+
+	now := time.Now()
+	order.Status = model.BookingStatusCancelled
+	order.UpdatedAt = now
+
+	released := make(map[int64]int)
+	for _, b := range order.Bookings {
+		booking := r.carts.bookings.bookings[b.ID]
+		if booking.Status == model.BookingStatusCancelled {
+			continue
+		}
+		booking.Status = model.BookingStatusCancelled
+		booking.UpdatedAt = now
+		released[booking.ConcertID] += booking.TicketCount
+	}
+
+	availabilities := []*model.ConcertAvailability{}
+	for concertID, tickets := range released {
+		concert := r.carts.concerts.concerts[concertID]
+		concert.AvailableTickets += tickets
+		concert.Version++
+		availabilities = append(availabilities, &model.ConcertAvailability{
+			ConcertID:        concertID,
+			AvailableTickets: concert.AvailableTickets,
+			TotalTickets:     concert.TotalTickets,
+			UpdatedAt:        now,
+		})
+	}
+	sort.Slice(availabilities, func(i, j int) bool { return availabilities[i].ConcertID < availabilities[j].ConcertID })
+	return availabilities, nil
+}
+
+// copyOrder copies an order with the current state of its bookings
+func (r *MockOrderRepository) copyOrder(order *model.Order) *model.Order {
+	r.carts.bookings.mutex.RLock()
+	defer r.carts.bookings.mutex.RUnlock()
+
+	orderCopy := *order
+	orderCopy.Total = 0
+	orderCopy.Failed = nil
+	orderCopy.Bookings = make([]*model.Booking, 0, len(order.Bookings))
+	for _, b := range order.Bookings {
+		bookingCopy := *r.carts.bookings.bookings[b.ID]
+		orderCopy.Bookings = append(orderCopy.Bookings, &bookingCopy)
+	}
+	return &orderCopy
+}
+
+var _ repository.OrderRepository = (*MockOrderRepository)(nil)
@@ -0,0 +1,194 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/events"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository. Events
+// are added with Write, as the repositories of their changes would.
+type MockOutboxRepository struct {
+	mutex     sync.Mutex
+	events    map[int64]*model.OutboxEvent
+	published map[int64]time.Time
+	lastError map[int64]string
+	nextID    int64
+}
+
+// NewMockOutboxRepository creates a new mock outbox repository
+func NewMockOutboxRepository() *MockOutboxRepository {
+	return &MockOutboxRepository{
+		events:    make(map[int64]*model.OutboxEvent),
+		published: make(map[int64]time.Time),
+		lastError: make(map[int64]string),
+		nextID:    1,
+	}
+}
+
+// Write adds events to the outbox, written at a time
+func (r *MockOutboxRepository) Write(at time.Time, outboxEvents ...*model.OutboxEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, event := range outboxEvents {
+		stored := *event
+		stored.ID = r.nextID
+		stored.CreatedAt = at
+		r.events[stored.ID] = &stored
+		r.nextID++
+	}
+}
+
+// ListPending retrieves the oldest unpublished events in ID order
+func (r *MockOutboxRepository) ListPending(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending := []*model.OutboxEvent{}
+	for _, event := range r.sorted() {
+		if _, ok := r.published[event.ID]; ok {
+			continue
+		}
+		copied := *event
+		pending = append(pending, &copied)
+		if len(pending) == limit {
+			break
+		}
+	}
+	return pending, nil
+}
+
+// MarkPublished records that an event was published
+func (r *MockOutboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.published[id] = at
+	return nil
+}
+
+// MarkFailed records a failed attempt to publish an event
+func (r *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if event, ok := r.events[id]; ok {
+		event.Attempts++
+	}
+	r.lastError[id] = lastError
+	return nil
+}
+
+// PurgeBefore deletes the events written before a time
+func (r *MockOutboxRepository) PurgeBefore(ctx context.Context, before time.Time, publishedOnly bool) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := 0
+	for id, event := range r.events {
+		if _, ok := r.published[id]; !event.CreatedAt.Before(before) || (publishedOnly && !ok) {
+			continue
+		}
+		delete(r.events, id)
+		delete(r.published, id)
+		delete(r.lastError, id)
+		purged++
+	}
+	return purged, nil
+}
+
+// Events returns the events in the outbox in ID order
+func (r *MockOutboxRepository) Events() []*model.OutboxEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.sorted()
+}
+
+// LastError returns the error of the last failed attempt to publish an event
+func (r *MockOutboxRepository) LastError(id int64) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.lastError[id]
+}
+
+// sorted returns the events in ID order. The mutex must be held.
+func (r *MockOutboxRepository) sorted() []*model.OutboxEvent {
+	sorted := make([]*model.OutboxEvent, 0, len(r.events))
+	for _, event := range r.events {
+		sorted = append(sorted, event)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// MockPublisher is a mock implementation of events.Publisher that records
+// published messages
+type MockPublisher struct {
+	mutex    sync.Mutex
+	messages []*events.Message
+	failing  map[string]bool
+}
+
+// NewMockPublisher creates a new mock publisher
+func NewMockPublisher() *MockPublisher {
+	return &MockPublisher{failing: make(map[string]bool)}
+}
+
+// Name returns the broker name
+func (p *MockPublisher) Name() string {
+	return "mock"
+}
+
+// Publish records the message, or fails while its key is set to fail
+func (p *MockPublisher) Publish(ctx context.Context, msg *events.Message) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.failing[msg.Key] {
+		return errors.New("broker unavailable")
+	}
+
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Ping always succeeds
+func (p *MockPublisher) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing
+func (p *MockPublisher) Close() error {
+	return nil
+}
+
+// SetFailing makes subsequent messages of a key fail or succeed
+func (p *MockPublisher) SetFailing(key string, failing bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.failing[key] = failing
+}
+
+// Messages returns the messages published so far
+func (p *MockPublisher) Messages() []*events.Message {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]*events.Message(nil), p.messages...)
+}
+
+// Ensure the mocks implement the interfaces
+var (
+	_ repository.OutboxRepository = (*MockOutboxRepository)(nil)
+	_ events.Publisher            = (*MockPublisher)(nil)
+)
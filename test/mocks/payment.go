@@ -0,0 +1,460 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// MockPaymentRepository is a mock implementation of PaymentRepository that
+// settles the bookings of a mock booking repository
+type MockPaymentRepository struct {
+	mutex      sync.Mutex
+	bookings   *MockBookingRepository
+	payments   map[int64]*model.Payment
+	reconciled map[int64]time.Time
+	nextID     int64
+}
+
+// NewMockPaymentRepository creates a new mock payment repository over a
+// booking repository with concerts
+func NewMockPaymentRepository(bookings *MockBookingRepository) *MockPaymentRepository {
+	return &MockPaymentRepository{
+		bookings:   bookings,
+		payments:   make(map[int64]*model.Payment),
+		reconciled: make(map[int64]time.Time),
+		nextID:     1,
+	}
+}
+
+// Create records the payment of a pending booking
+func (r *MockPaymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.payments {
+		if existing.BookingID == payment.BookingID {
+			return fmt.Errorf("booking %d already has a payment", payment.BookingID)
+		}
+	}
+
+	payment.ID = r.nextID
+	payment.CreatedAt = clock.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	r.nextID++
+
+	stored := *payment
+	r.payments[stored.ID] = &stored
+	return nil
+}
+
+// GetByBookingID retrieves the payment of a booking
+func (r *MockPaymentRepository) GetByBookingID(ctx context.Context, bookingID int64) (*model.Payment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if payment := r.byBooking(bookingID); payment != nil {
+		copied := *payment
+		return &copied, nil
+	}
+	return nil, pkgErr.ErrNotFound
+}
+
+// GetByProviderReference retrieves a payment by its ID at the provider
+func (r *MockPaymentRepository) GetByProviderReference(ctx context.Context, provider, providerReference string) (*model.Payment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.Provider == provider && payment.ProviderReference == providerReference {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+	return nil, pkgErr.ErrNotFound
+}
+
+// Confirm records that the pending payment of a booking was authorized and
+// confirms the booking if it is still pending
+func (r *MockPaymentRepository) Confirm(ctx context.Context, bookingID int64) (*model.Booking, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if payment := r.byBooking(bookingID); payment != nil && payment.Status == model.PaymentPending {
+		payment.Status = model.PaymentAuthorized
+		payment.UpdatedAt = clock.Now()
+	}
+
+	unlock, err := r.bookings.lockConcerts()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	booking, ok := r.bookings.bookings[bookingID]
+	switch {
+	case ok && booking.Status == model.BookingStatusConfirmed:
+		return nil, nil
+	case !ok || booking.Status != model.BookingStatusPending:
+		return nil, pkgErr.ErrBookingAlreadyCancelled
+	}
+	booking.Status = model.BookingStatusConfirmed
+	booking.UpdatedAt = time.Now()
+
+	copied := *booking
+	return &copied, nil
+}
+
+// Release settles the pending payment of a booking, cancels the booking if
+// it is still pending and returns its tickets
+func (r *MockPaymentRepository) Release(ctx context.Context, bookingID int64, status string) (*model.Booking, *model.ConcertAvailability, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if payment := r.byBooking(bookingID); payment != nil && payment.Status == model.PaymentPending {
+		payment.Status = status
+		payment.UpdatedAt = clock.Now()
+	}
+
+	unlock, err := r.bookings.lockConcerts()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	booking, ok := r.bookings.bookings[bookingID]
+	if !ok || booking.Status != model.BookingStatusPending {
+		return nil, nil, nil
+	}
+	concert, ok := r.bookings.concerts.concerts[booking.ConcertID]
+	if !ok {
+		return nil, nil, pkgErr.ErrNotFound
+	}
+
+	booking.Status = model.BookingStatusCancelled
+	booking.UpdatedAt = time.Now()
+	takeTickets(concert, -booking.TicketCount)
+
+	copied := *booking
+	return &copied, &model.ConcertAvailability{
+		ConcertID:        concert.ID,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		UpdatedAt:        concert.UpdatedAt,
+	}, nil
+}
+
+// UpdateStatus moves the payment of a booking from one status to another
+func (r *MockPaymentRepository) UpdateStatus(ctx context.Context, bookingID int64, from, to string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payment := r.byBooking(bookingID)
+	if payment == nil || payment.Status != from {
+		return false, nil
+	}
+	payment.Status = to
+	payment.UpdatedAt = clock.Now()
+	return true, nil
+}
+
+// ListExpired retrieves the pending and authorized payments whose hold
+// expired by a time
+func (r *MockPaymentRepository) ListExpired(ctx context.Context, at time.Time, limit int) ([]*model.Payment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	expired := []*model.Payment{}
+	for _, payment := range r.payments {
+		if !payment.IsSettled() && !payment.ExpiresAt.After(at) {
+			copied := *payment
+			copied.ClientSecret = ""
+			expired = append(expired, &copied)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(expired[j].ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+// ListForReconciliation retrieves the payments created after a time that
+// didn't change since another, the least recently reconciled first
+func (r *MockPaymentRepository) ListForReconciliation(ctx context.Context, createdAfter, changedBefore time.Time, limit int) ([]*model.PaymentCheck, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.bookings.mutex.RLock()
+	defer r.bookings.mutex.RUnlock()
+
+	checks := []*model.PaymentCheck{}
+	for _, payment := range r.payments {
+		booking, ok := r.bookings.bookings[payment.BookingID]
+		if !ok || payment.CreatedAt.Before(createdAfter) || payment.UpdatedAt.After(changedBefore) {
+			continue
+		}
+		check := &model.PaymentCheck{Payment: *payment, BookingStatus: booking.Status}
+		check.ClientSecret = ""
+		checks = append(checks, check)
+	}
+	// Payments never reconciled have the zero time, so they come first
+	sort.Slice(checks, func(i, j int) bool {
+		a, b := r.reconciled[checks[i].BookingID], r.reconciled[checks[j].BookingID]
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return checks[i].ID < checks[j].ID
+	})
+	if len(checks) > limit {
+		checks = checks[:limit]
+	}
+	return checks, nil
+}
+
+// MarkReconciled records when the payment of a booking was reconciled and
+// the mismatch found
+func (r *MockPaymentRepository) MarkReconciled(ctx context.Context, bookingID int64, mismatch string, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if payment := r.byBooking(bookingID); payment != nil {
+		payment.Mismatch = mismatch
+		r.reconciled[bookingID] = at
+	}
+	return nil
+}
+
+// CountMismatches counts the flagged payments by kind of mismatch
+func (r *MockPaymentRepository) CountMismatches(ctx context.Context) (map[string]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	counts := make(map[string]int)
+	for _, payment := range r.payments {
+		if payment.Mismatch != "" {
+			counts[payment.Mismatch]++
+		}
+	}
+	return counts, nil
+}
+
+// byBooking returns the stored payment of a booking. The mutex must be held.
+func (r *MockPaymentRepository) byBooking(bookingID int64) *model.Payment {
+	for _, payment := range r.payments {
+		if payment.BookingID == bookingID {
+			return payment
+		}
+	}
+	return nil
+}
+
+// MockStripe is a fake of the Stripe API's PaymentIntents and refunds,
+// served over HTTP for the Stripe provider to talk to
+type MockStripe struct {
+	*httptest.Server
+
+	mutex          sync.Mutex
+	intents        map[string]string
+	nextID         int
+	down           bool
+	canceled       []string
+	refunded       []string
+	lastForm       map[string]string
+	idempotencyKey string
+	replays        map[string]bool
+}
+
+// NewMockStripe starts a fake Stripe API. Close it when the test is done.
+func NewMockStripe() *MockStripe {
+	s := &MockStripe{
+		intents: make(map[string]string),
+		nextID:  1,
+		replays: make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *MockStripe) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.down {
+		http.Error(w, `{"error":{"message":"service unavailable"}}`, http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/payment_intents/") {
+		s.writeIntent(w, strings.TrimPrefix(r.URL.Path, "/v1/payment_intents/"))
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+		return
+	}
+
+	id, operation, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/payment_intents/"), "/")
+	if r.URL.Path == "/v1/refunds" {
+		id, operation = r.PostForm.Get("payment_intent"), "refund"
+	}
+
+	// Like Stripe, a capture or refund repeating an idempotency key gets the
+	// first response
+	key := r.Header.Get("Idempotency-Key")
+	if key != "" && s.replays[key] {
+		s.writeIntent(w, id)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/payment_intents":
+		s.lastForm = make(map[string]string)
+		for name := range r.PostForm {
+			s.lastForm[name] = r.PostForm.Get(name)
+		}
+		s.idempotencyKey = key
+
+		id := fmt.Sprintf("pi_%d", s.nextID)
+		s.nextID++
+		s.intents[id] = "requires_payment_method"
+		s.writeIntent(w, id)
+		return
+	case operation == "refund":
+		if !s.move(w, id, "succeeded", "succeeded") {
+			return
+		}
+		s.refunded = append(s.refunded, id)
+	case operation == "capture":
+		if !s.move(w, id, "succeeded", "requires_capture") {
+			return
+		}
+	case operation == "cancel":
+		if !s.move(w, id, "canceled", "requires_payment_method", "requires_capture") {
+			return
+		}
+		s.canceled = append(s.canceled, id)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if key != "" {
+		s.replays[key] = true
+	}
+	s.writeIntent(w, id)
+}
+
+// move changes the status of an intent that has one of the from statuses,
+// or writes Stripe's error. The mutex must be held.
+func (s *MockStripe) move(w http.ResponseWriter, id, to string, from ...string) bool {
+	status, ok := s.intents[id]
+	if !ok {
+		http.Error(w, `{"error":{"message":"no such payment_intent"}}`, http.StatusNotFound)
+		return false
+	}
+	for _, allowed := range from {
+		if status == allowed {
+			s.intents[id] = to
+			return true
+		}
+	}
+	http.Error(w, `{"error":{"code":"payment_intent_unexpected_state"}}`, http.StatusBadRequest)
+	return false
+}
+
+// writeIntent writes the PaymentIntent of an ID with its latest charge. The
+// mutex must be held.
+func (s *MockStripe) writeIntent(w http.ResponseWriter, id string) {
+	status, ok := s.intents[id]
+	if !ok {
+		http.Error(w, `{"error":{"message":"no such payment_intent"}}`, http.StatusNotFound)
+		return
+	}
+
+	refunded := false
+	for _, refundedID := range s.refunded {
+		refunded = refunded || refundedID == id
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":            id,
+		"object":        "payment_intent",
+		"client_secret": id + "_secret",
+		"status":        status,
+		"latest_charge": map[string]interface{}{"object": "charge", "refunded": refunded},
+	})
+}
+
+// SetDown makes subsequent requests fail or succeed
+func (s *MockStripe) SetDown(down bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.down = down
+}
+
+// Pay has the customer authorize an intent, so it can be captured
+func (s *MockStripe) Pay(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.intents[id] = "requires_capture"
+}
+
+// Status returns the status of an intent
+func (s *MockStripe) Status(id string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.intents[id]
+}
+
+// Canceled returns the IDs of the intents canceled so far
+func (s *MockStripe) Canceled() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.canceled...)
+}
+
+// Refunded returns the IDs of the intents refunded so far
+func (s *MockStripe) Refunded() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.refunded...)
+}
+
+// LastCreate returns the form and idempotency key of the last intent created
+func (s *MockStripe) LastCreate() (map[string]string, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastForm, s.idempotencyKey
+}
+
+// Event returns the payload of a Stripe event about an intent
+func (s *MockStripe) Event(eventType, intentID string) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":   "evt_" + intentID + "_" + eventType,
+		"type": eventType,
+		"data": map[string]interface{}{
+			"object": map[string]string{"id": intentID, "object": "payment_intent"},
+		},
+	})
+	return payload
+}
+
+// Ensure the mock implements the interface
+var _ repository.PaymentRepository = (*MockPaymentRepository)(nil)
@@ -1,37 +1,67 @@
 package mocks
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"concert-ticket-api/internal/model"
 	"concert-ticket-api/internal/repository"
 	"concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/reference"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // MockConcertRepository is a mock implementation of ConcertRepository
 type MockConcertRepository struct {
+	Failures
+
+	// Estimate is returned by EstimateCount instead of the count when set
+	Estimate int
+
 	mutex    sync.RWMutex
 	concerts map[int64]*model.Concert
+	history  map[int64][]*model.PriceSnapshot
 	nextID   int64
+
+	invites      []*model.ConcertInvite
+	redemptions  []*model.InviteRedemption
+	nextInviteID int64
 }
 
+// GetDB returns nil: the mock has no database
 func (r *MockConcertRepository) GetDB() *sqlx.DB {
-	return r.GetDB()
+	return nil
 }
 
 // NewMockConcertRepository creates a new mock concert repository
 func NewMockConcertRepository() *MockConcertRepository {
 	return &MockConcertRepository{
 		concerts: make(map[int64]*model.Concert),
+		history:  make(map[int64][]*model.PriceSnapshot),
 		nextID:   1,
+
+		nextInviteID: 1,
 	}
 }
 
 // GetByID retrieves a concert by its ID
 func (r *MockConcertRepository) GetByID(ctx context.Context, id int64) (*model.Concert, error) {
+	if err := r.fail("GetByID"); err != nil {
+		return nil, err
+	}
+
+	return r.get(id)
+}
+
+// get returns a copy of a stored concert
+func (r *MockConcertRepository) get(id int64) (*model.Concert, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -46,7 +76,7 @@ func (r *MockConcertRepository) GetByID(ctx context.Context, id int64) (*model.C
 }
 
 // List retrieves concerts with optional filtering
-func (r *MockConcertRepository) List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*model.Concert, error) {
+func (r *MockConcertRepository) List(ctx context.Context, opts query.Options) ([]*model.Concert, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -54,10 +84,26 @@ func (r *MockConcertRepository) List(ctx context.Context, limit, offset int, fil
 	for _, concert := range r.concerts {
 		// Apply filters here if needed
 		// For now, we're ignoring filters for simplicity
-		result = append(result, concert)
+		if listed(concert) {
+			result = append(result, concert)
+		}
 	}
 
+	sorts := opts.Sort
+	if len(sorts) == 0 {
+		sorts = []query.Sort{{Field: "concert_date"}}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		for _, s := range sorts {
+			if c := compareConcerts(result[i], result[j], s.Field); c != 0 {
+				return (c < 0) != s.Desc
+			}
+		}
+		return result[i].ID < result[j].ID
+	})
+
 	// Apply limit and offset
+	offset, limit := opts.Page.Offset(), opts.Page.Limit()
 	if offset >= len(result) {
 		return []*model.Concert{}, nil
 	}
@@ -70,13 +116,67 @@ func (r *MockConcertRepository) List(ctx context.Context, limit, offset int, fil
 	return result[offset:end], nil
 }
 
+// compareConcerts compares two concerts by one of model.ConcertSortFields
+func compareConcerts(a, b *model.Concert, field string) int {
+	switch field {
+	case "concert_date":
+		return a.ConcertDate.Compare(b.ConcertDate)
+	case "name":
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case "artist":
+		return strings.Compare(strings.ToLower(a.Artist), strings.ToLower(b.Artist))
+	case "price":
+		return cmp.Compare(a.Price, b.Price)
+	case "available_tickets":
+		return cmp.Compare(a.AvailableTickets, b.AvailableTickets)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return 0
+}
+
 // Count returns the total number of concerts matching the filters
-func (r *MockConcertRepository) Count(ctx context.Context, filters map[string]interface{}) (int, error) {
+func (r *MockConcertRepository) Count(ctx context.Context, filters query.Filters) (int, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	// For simplicity, we're ignoring filters
-	return len(r.concerts), nil
+	count := 0
+	for _, concert := range r.concerts {
+		if listed(concert) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// EstimateCount returns Estimate when it is set and the count otherwise
+func (r *MockConcertRepository) EstimateCount(ctx context.Context, filters query.Filters) (int, error) {
+	if r.Estimate > 0 {
+		return r.Estimate, nil
+	}
+	return r.Count(ctx, filters)
+}
+
+// LastModified returns the latest Concert.LastModified at the given time
+// across all concerts, listed or not
+func (r *MockConcertRepository) LastModified(ctx context.Context, at time.Time) (time.Time, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var latest time.Time
+	for _, concert := range r.concerts {
+		if modified := concert.LastModified(at); modified.After(latest) {
+			latest = modified
+		}
+	}
+	return latest, nil
+}
+
+// listed reports whether a concert shows up in listings, like the Postgres
+// repository only listing public concerts
+func listed(concert *model.Concert) bool {
+	return concert.Visibility == "" || concert.Visibility == model.VisibilityPublic
 }
 
 // Create inserts a new concert
@@ -88,49 +188,147 @@ func (r *MockConcertRepository) Create(ctx context.Context, concert *model.Conce
 	concert.ID = r.nextID
 	r.nextID++
 
-	// Set initial version
+	// Set initial version and timestamps
 	concert.Version = 1
+	if concert.CreatedAt.IsZero() {
+		concert.CreatedAt = time.Now()
+	}
+	if concert.UpdatedAt.IsZero() {
+		concert.UpdatedAt = concert.CreatedAt
+	}
 
-	// Make a copy and store it
+	// Make a copy and store it; only the hash of the invite token is stored
 	concertCopy := *concert
+	concertCopy.InviteToken = ""
 	r.concerts[concert.ID] = &concertCopy
+	r.recordPrice(concert)
 
 	return concert, nil
 }
 
 // Update updates an existing concert
 func (r *MockConcertRepository) Update(ctx context.Context, concert *model.Concert) error {
+	if err := r.fail("Update"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	// Like the database, a concert that doesn't exist fails the version check
 	existing, ok := r.concerts[concert.ID]
-	if !ok {
-		return errors.ErrNotFound
-	}
-
-	if existing.Version != concert.Version {
+	if !ok || existing.Version != concert.Version {
 		return errors.ErrOptimisticLockFailed
 	}
 
 	// Update version
 	concert.Version++
+	concert.UpdatedAt = time.Now()
+
+	if existing.Price != concert.Price || existing.Currency != concert.Currency {
+		r.recordPrice(concert)
+	}
+
+	// The switch state is maintained by SwitchDueDoorPrices, like in the database
+	concert.DoorPriceSwitchedAt = existing.DoorPriceSwitchedAt
+	if !sameDoorPricing(existing, concert) {
+		concert.DoorPriceSwitchedAt = nil
+	}
 
 	// Store updated concert
 	concertCopy := *concert
+	concertCopy.InviteToken = ""
 	r.concerts[concert.ID] = &concertCopy
 
 	return nil
 }
 
+// GetByIDs retrieves the concerts with the given IDs. Unknown IDs are skipped.
+func (r *MockConcertRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]*model.Concert, 0, len(ids))
+	for _, id := range ids {
+		if concert, ok := r.concerts[id]; ok {
+			concertCopy := *concert
+			result = append(result, &concertCopy)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// GetPriceHistory retrieves the price snapshots of a concert, oldest first
+func (r *MockConcertRepository) GetPriceHistory(ctx context.Context, concertID int64) ([]*model.PriceSnapshot, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]*model.PriceSnapshot(nil), r.history[concertID]...), nil
+}
+
+// recordPrice appends a price snapshot; the caller must hold the lock
+func (r *MockConcertRepository) recordPrice(concert *model.Concert) {
+	r.history[concert.ID] = append(r.history[concert.ID], &model.PriceSnapshot{
+		Price:      concert.Price,
+		Currency:   concert.Currency,
+		RecordedAt: time.Now(),
+	})
+}
+
+// sameDoorPricing checks if two versions of a concert switch to the same door price at the same time
+func sameDoorPricing(a, b *model.Concert) bool {
+	if (a.DoorPrice == nil) != (b.DoorPrice == nil) || (a.DoorPrice != nil && *a.DoorPrice != *b.DoorPrice) {
+		return false
+	}
+	return a.DoorPriceLeadMinutes == b.DoorPriceLeadMinutes && a.ConcertDate.Equal(b.ConcertDate)
+}
+
+// SwitchDueDoorPrices marks concerts whose door price took effect as switched and returns them
+func (r *MockConcertRepository) SwitchDueDoorPrices(ctx context.Context, now time.Time, limit int) ([]*model.Concert, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var result []*model.Concert
+	for _, concert := range r.concerts {
+		if concert.DoorPrice == nil || concert.DoorPriceSwitchedAt != nil || concert.DoorPriceStartsAt().After(now) {
+			continue
+		}
+		switchedAt := now
+		concert.DoorPriceSwitchedAt = &switchedAt
+		concertCopy := *concert
+		result = append(result, &concertCopy)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	if len(result) > limit {
+		// Not returned, so not announced
+		for _, concert := range result[limit:] {
+			r.concerts[concert.ID].DoorPriceSwitchedAt = nil
+		}
+		result = result[:limit]
+	}
+	return result, nil
+}
+
 // GetForUpdate retrieves a concert for update with row locking
 func (r *MockConcertRepository) GetForUpdate(ctx context.Context, id int64) (*model.Concert, error) {
-	// In this mock, we'll just call GetByID
-	// In a real database, this would acquire a lock
-	return r.GetByID(ctx, id)
+	if err := r.fail("GetForUpdate"); err != nil {
+		return nil, err
+	}
+
+	// In a real database, this would acquire a lock; the writes of the
+	// mock check the version instead
+	return r.get(id)
 }
 
 // UpdateTicketCount atomically updates the available ticket count using optimistic locking
 func (r *MockConcertRepository) UpdateTicketCount(ctx context.Context, id int64, version int, ticketCount int) error {
+	if err := r.fail("UpdateTicketCount"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -147,22 +345,142 @@ func (r *MockConcertRepository) UpdateTicketCount(ctx context.Context, id int64,
 		return errors.ErrInsufficientTickets
 	}
 
-	// Update ticket count and version
-	concert.AvailableTickets -= ticketCount
-	concert.Version++
+	takeTickets(concert, ticketCount)
 
 	return nil
 }
 
-// MockBookingRepository is a mock implementation of BookingRepository
+// CreateInvites inserts invites of a concert
+func (r *MockConcertRepository) CreateInvites(ctx context.Context, invites []*model.ConcertInvite) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, invite := range invites {
+		invite.ID = r.nextInviteID
+		invite.CreatedAt = time.Now()
+		r.nextInviteID++
+
+		inviteCopy := *invite
+		inviteCopy.Token = ""
+		r.invites = append(r.invites, &inviteCopy)
+	}
+
+	return nil
+}
+
+// GetInviteByTokenHash retrieves the invite of a concert by its token hash
+func (r *MockConcertRepository) GetInviteByTokenHash(ctx context.Context, concertID int64, tokenHash string) (*model.ConcertInvite, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, invite := range r.invites {
+		if invite.ConcertID == concertID && invite.TokenHash == tokenHash {
+			inviteCopy := *invite
+			return &inviteCopy, nil
+		}
+	}
+
+	return nil, errors.ErrNotFound
+}
+
+// RedeemInvite records a booking made with an invite
+func (r *MockConcertRepository) RedeemInvite(ctx context.Context, redemption *model.InviteRedemption) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, invite := range r.invites {
+		if invite.ID != redemption.InviteID {
+			continue
+		}
+		if !invite.IsRedeemable() {
+			return errors.ErrInviteRedeemed
+		}
+		invite.Redemptions++
+
+		redemptionCopy := *redemption
+		r.redemptions = append(r.redemptions, &redemptionCopy)
+		return nil
+	}
+
+	return errors.ErrInviteRedeemed
+}
+
+// WithdrawInviteRedemption removes the redemption of a booking that wasn't made
+func (r *MockConcertRepository) WithdrawInviteRedemption(ctx context.Context, redemption *model.InviteRedemption) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, existing := range r.redemptions {
+		if existing.InviteID != redemption.InviteID || existing.BookingReference != redemption.BookingReference {
+			continue
+		}
+		r.redemptions = append(r.redemptions[:i], r.redemptions[i+1:]...)
+
+		for _, invite := range r.invites {
+			if invite.ID == redemption.InviteID {
+				invite.Redemptions--
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// ListInviteRedemptions lists the invites of a concert with their
+// redemptions. Booking fields other than the reference are left empty.
+func (r *MockConcertRepository) ListInviteRedemptions(ctx context.Context, concertID int64) ([]*model.InviteExportRow, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	rows := []*model.InviteExportRow{}
+	for _, invite := range r.invites {
+		if invite.ConcertID != concertID {
+			continue
+		}
+
+		redeemed := false
+		for _, redemption := range r.redemptions {
+			if redemption.InviteID != invite.ID {
+				continue
+			}
+			reference := redemption.BookingReference
+			redeemedAt := redemption.RedeemedAt
+			rows = append(rows, &model.InviteExportRow{
+				InviteID:         invite.ID,
+				Invitee:          invite.Invitee,
+				SingleUse:        invite.SingleUse,
+				BookingReference: &reference,
+				RedeemedAt:       &redeemedAt,
+			})
+			redeemed = true
+		}
+
+		if !redeemed {
+			rows = append(rows, &model.InviteExportRow{InviteID: invite.ID, Invitee: invite.Invitee, SingleUse: invite.SingleUse})
+		}
+	}
+
+	return rows, nil
+}
+
+// MockBookingRepository is a mock implementation of BookingRepository. The
+// methods that move tickets book the concerts set by WithConcerts, holding
+// their lock like a transaction holds the concert row, and fail with
+// ErrNotFound without them.
 type MockBookingRepository struct {
+	Failures
+
 	mutex    sync.RWMutex
 	bookings map[int64]*model.Booking
 	nextID   int64
+
+	concerts *MockConcertRepository
 }
 
+// GetDB returns nil: the mock has no database
 func (r *MockBookingRepository) GetDB() *sqlx.DB {
-	return r.GetDB()
+	return nil
 }
 
 // NewMockBookingRepository creates a new mock booking repository
@@ -173,6 +491,14 @@ func NewMockBookingRepository() *MockBookingRepository {
 	}
 }
 
+// WithConcerts makes the booking and cancellation methods take and return
+// the tickets of the concerts of a MockConcertRepository and returns the
+// repository
+func (r *MockBookingRepository) WithConcerts(concerts *MockConcertRepository) *MockBookingRepository {
+	r.concerts = concerts
+	return r
+}
+
 // GetByID retrieves a booking by its ID
 func (r *MockBookingRepository) GetByID(ctx context.Context, id int64) (*model.Booking, error) {
 	r.mutex.RLock()
@@ -188,19 +514,59 @@ func (r *MockBookingRepository) GetByID(ctx context.Context, id int64) (*model.B
 	return &bookingCopy, nil
 }
 
+// GetByReference retrieves a booking by its public reference
+func (r *MockBookingRepository) GetByReference(ctx context.Context, reference string) (*model.Booking, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, booking := range r.bookings {
+		if booking.Reference == reference {
+			bookingCopy := *booking
+			return &bookingCopy, nil
+		}
+	}
+
+	return nil, errors.ErrNotFound
+}
+
+// GetByIdempotencyKey retrieves the booking a user made with an idempotency key
+func (r *MockBookingRepository) GetByIdempotencyKey(ctx context.Context, userID, key string) (*model.Booking, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, booking := range r.bookings {
+		if booking.UserID == userID && booking.IdempotencyKey == key {
+			bookingCopy := *booking
+			return &bookingCopy, nil
+		}
+	}
+
+	return nil, errors.ErrNotFound
+}
+
 // GetByUserID retrieves bookings for a user
-func (r *MockBookingRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Booking, error) {
+func (r *MockBookingRepository) GetByUserID(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	var result []*model.Booking
 	for _, booking := range r.bookings {
 		if booking.UserID == userID {
-			result = append(result, booking)
+			bookingCopy := *booking
+			result = append(result, &bookingCopy)
 		}
 	}
 
+	// Newest first, like the repository
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].BookingTime.Equal(result[j].BookingTime) {
+			return result[i].BookingTime.After(result[j].BookingTime)
+		}
+		return result[i].ID > result[j].ID
+	})
+
 	// Apply limit and offset
+	offset, limit := page.Offset(), page.Limit()
 	if offset >= len(result) {
 		return []*model.Booking{}, nil
 	}
@@ -213,24 +579,87 @@ func (r *MockBookingRepository) GetByUserID(ctx context.Context, userID string,
 	return result[offset:end], nil
 }
 
+// GetByUserIDWithConcerts retrieves bookings for a user with the concerts
+// set by WithConcerts
+func (r *MockBookingRepository) GetByUserIDWithConcerts(ctx context.Context, userID string, page query.Page) ([]*model.Booking, error) {
+	bookings, err := r.GetByUserID(ctx, userID, page)
+	if err != nil || r.concerts == nil {
+		return bookings, err
+	}
+
+	r.concerts.mutex.RLock()
+	defer r.concerts.mutex.RUnlock()
+	for _, booking := range bookings {
+		if concert, ok := r.concerts.concerts[booking.ConcertID]; ok {
+			booking.Concert = model.SummarizeConcert(concert)
+		}
+	}
+	return bookings, nil
+}
+
 // Create inserts a new booking
 func (r *MockBookingRepository) Create(ctx context.Context, booking *model.Booking) (*model.Booking, error) {
+	if err := r.fail("Create"); err != nil {
+		return nil, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	// Assign ID
+	if err := r.checkIdempotencyKeys(booking); err != nil {
+		return nil, err
+	}
+	r.insert(booking)
+
+	return booking, nil
+}
+
+// checkIdempotencyKeys fails with ErrIdempotencyKeyInUse if a booking's
+// idempotency key was used by its user before, as the unique key in the
+// database does. The caller must hold the lock.
+func (r *MockBookingRepository) checkIdempotencyKeys(bookings ...*model.Booking) error {
+	for i, booking := range bookings {
+		if booking.IdempotencyKey == "" {
+			continue
+		}
+		for _, existing := range r.bookings {
+			if existing.UserID == booking.UserID && existing.IdempotencyKey == booking.IdempotencyKey {
+				return errors.ErrIdempotencyKeyInUse
+			}
+		}
+		for _, earlier := range bookings[:i] {
+			if earlier.UserID == booking.UserID && earlier.IdempotencyKey == booking.IdempotencyKey {
+				return errors.ErrIdempotencyKeyInUse
+			}
+		}
+	}
+	return nil
+}
+
+// insert stores a booking whose idempotency key was checked and sets its ID
+// and, if unset, its reference and times. The caller must hold the lock.
+func (r *MockBookingRepository) insert(booking *model.Booking) {
 	booking.ID = r.nextID
 	r.nextID++
 
-	// Make a copy and store it
+	if booking.Reference == "" {
+		booking.Reference = reference.New()
+	}
+	if booking.CreatedAt.IsZero() {
+		booking.CreatedAt = time.Now()
+		booking.UpdatedAt = booking.CreatedAt
+	}
+
 	bookingCopy := *booking
 	r.bookings[booking.ID] = &bookingCopy
-
-	return booking, nil
 }
 
 // Update updates an existing booking
 func (r *MockBookingRepository) Update(ctx context.Context, booking *model.Booking) error {
+	if err := r.fail("Update"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -261,14 +690,391 @@ func (r *MockBookingRepository) CountByUserAndConcert(ctx context.Context, userI
 	return count, nil
 }
 
-// CreateWithTicketUpdate creates a booking and updates ticket count in a transaction
+// lockConcerts locks the concerts and then the bookings, the order every
+// method that moves tickets takes them in, and returns the unlock
+func (r *MockBookingRepository) lockConcerts() (func(), error) {
+	if r.concerts == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	r.concerts.mutex.Lock()
+	r.mutex.Lock()
+	return func() {
+		r.mutex.Unlock()
+		r.concerts.mutex.Unlock()
+	}, nil
+}
+
+// bookable returns the stored concert, failing unless its booking window is
+// open and it has the tickets. The caller must hold the concerts' lock.
+func (r *MockBookingRepository) bookable(concertID int64, tickets int) (*model.Concert, error) {
+	concert, ok := r.concerts.concerts[concertID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+
+	if !concert.IsBookingOpen() {
+		return nil, errors.ErrBookingClosed
+	}
+
+	if concert.AvailableTickets < tickets {
+		return nil, errors.ErrInsufficientTickets
+	}
+
+	return concert, nil
+}
+
+// takeTickets subtracts tickets from a stored concert that was checked to
+// have them. The caller must hold the concerts' lock.
+func takeTickets(concert *model.Concert, tickets int) {
+	concert.AvailableTickets -= tickets
+	concert.Version++
+	concert.UpdatedAt = time.Now()
+}
+
+// CreateWithTicketUpdate creates a booking and takes its tickets from the
+// concert, failing with ErrOptimisticLockFailed unless the concert is still
+// at concertVersion
 func (r *MockBookingRepository) CreateWithTicketUpdate(ctx context.Context, booking *model.Booking, concertVersion int) error {
-	// This would normally be implemented with a proper transaction
-	// For the mock, we just create the booking
-	_, err := r.Create(ctx, booking)
-	return err
+	if err := r.fail("CreateWithTicketUpdate"); err != nil {
+		return err
+	}
+
+	return r.createWithTicketUpdate([]*model.Booking{booking}, concertVersion)
+}
+
+// CreateBatchWithTicketUpdate creates bookings for one concert and takes
+// their tickets, all or none of them
+func (r *MockBookingRepository) CreateBatchWithTicketUpdate(ctx context.Context, bookings []*model.Booking, concertVersion int) error {
+	if err := r.fail("CreateBatchWithTicketUpdate"); err != nil {
+		return err
+	}
+
+	if len(bookings) == 0 {
+		return nil
+	}
+	return r.createWithTicketUpdate(bookings, concertVersion)
+}
+
+// createWithTicketUpdate checks the version and tickets of the concert of
+// bookings, then takes the tickets and creates the bookings
+func (r *MockBookingRepository) createWithTicketUpdate(bookings []*model.Booking, concertVersion int) error {
+	concertID := bookings[0].ConcertID
+	tickets := 0
+	for _, booking := range bookings {
+		if booking.ConcertID != concertID {
+			return fmt.Errorf("batch bookings must be for one concert")
+		}
+		tickets += booking.TicketCount
+	}
+
+	unlock, err := r.lockConcerts()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	concert, ok := r.concerts.concerts[concertID]
+	if !ok {
+		return errors.ErrNotFound
+	}
+
+	if concert.Version != concertVersion {
+		return errors.ErrOptimisticLockFailed
+	}
+
+	if _, err := r.bookable(concertID, tickets); err != nil {
+		return err
+	}
+
+	if err := r.checkIdempotencyKeys(bookings...); err != nil {
+		return err
+	}
+
+	takeTickets(concert, tickets)
+	for _, booking := range bookings {
+		r.insert(booking)
+	}
+
+	return nil
+}
+
+// CreateWithConcertLock creates a booking and takes its tickets from the
+// concert, one booking at a time
+func (r *MockBookingRepository) CreateWithConcertLock(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	if err := r.fail("CreateWithConcertLock"); err != nil {
+		return nil, err
+	}
+
+	return r.createWithCheck(booking, check)
+}
+
+// CreateWithConditionalUpdate creates a booking and takes its tickets from
+// the concert while holding the concerts' lock, like the update holds the
+// row
+func (r *MockBookingRepository) CreateWithConditionalUpdate(ctx context.Context, booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	if err := r.fail("CreateWithConditionalUpdate"); err != nil {
+		return nil, err
+	}
+
+	return r.createWithCheck(booking, check)
+}
+
+// createWithCheck books a concert that check accepts as it was before the
+// booking, and returns it so
+func (r *MockBookingRepository) createWithCheck(booking *model.Booking, check func(concert *model.Concert) error) (*model.Concert, error) {
+	unlock, err := r.lockConcerts()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	concert, err := r.bookable(booking.ConcertID, booking.TicketCount)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *concert
+	if err := check(&before); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkIdempotencyKeys(booking); err != nil {
+		return nil, err
+	}
+
+	takeTickets(concert, booking.TicketCount)
+	r.insert(booking)
+	return &before, nil
+}
+
+// CancelWithTicketRelease cancels a booking and returns its tickets to the concert
+func (r *MockBookingRepository) CancelWithTicketRelease(ctx context.Context, bookingID int64) (*model.ConcertAvailability, error) {
+	if err := r.fail("CancelWithTicketRelease"); err != nil {
+		return nil, err
+	}
+
+	unlock, err := r.lockConcerts()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	booking, ok := r.bookings[bookingID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	if booking.Status == model.BookingStatusCancelled {
+		return nil, errors.ErrBookingAlreadyCancelled
+	}
+
+	concert, ok := r.concerts.concerts[booking.ConcertID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+
+	booking.Status = model.BookingStatusCancelled
+	booking.UpdatedAt = time.Now()
+	takeTickets(concert, -booking.TicketCount)
+
+	return &model.ConcertAvailability{
+		ConcertID:        concert.ID,
+		AvailableTickets: concert.AvailableTickets,
+		TotalTickets:     concert.TotalTickets,
+		UpdatedAt:        concert.UpdatedAt,
+	}, nil
+}
+
+// GetAllByUserID retrieves every booking for a user without pagination
+func (r *MockBookingRepository) GetAllByUserID(ctx context.Context, userID string) ([]*model.Booking, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*model.Booking
+	for _, booking := range r.bookings {
+		if booking.UserID == userID {
+			bookingCopy := *booking
+			result = append(result, &bookingCopy)
+		}
+	}
+
+	return result, nil
+}
+
+// AnonymizeUser replaces the user ID on all of a user's bookings with a pseudonym
+func (r *MockBookingRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, booking := range r.bookings {
+		if booking.UserID == userID {
+			booking.UserID = pseudonym
+			booking.AttendeeName = ""
+			booking.AttendeeEmail = ""
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetAllByConcertID retrieves every booking for a concert ordered by booking time
+func (r *MockBookingRepository) GetAllByConcertID(ctx context.Context, concertID int64) ([]*model.Booking, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*model.Booking
+	for _, booking := range r.bookings {
+		if booking.ConcertID == concertID {
+			bookingCopy := *booking
+			result = append(result, &bookingCopy)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BookingTime.Before(result[j].BookingTime)
+	})
+
+	return result, nil
 }
 
 // Ensure the mocks implement the interfaces
 var _ repository.ConcertRepository = (*MockConcertRepository)(nil)
 var _ repository.BookingRepository = (*MockBookingRepository)(nil)
+
+// MockInventoryReleaseRepository is a mock implementation of InventoryReleaseRepository
+// that holds back and releases the tickets of a MockConcertRepository
+type MockInventoryReleaseRepository struct {
+	mutex    sync.Mutex
+	concerts *MockConcertRepository
+	releases map[int64]*model.InventoryRelease
+	nextID   int64
+}
+
+// NewMockInventoryReleaseRepository creates a new mock inventory release repository
+func NewMockInventoryReleaseRepository(concerts *MockConcertRepository) *MockInventoryReleaseRepository {
+	return &MockInventoryReleaseRepository{
+		concerts: concerts,
+		releases: make(map[int64]*model.InventoryRelease),
+		nextID:   1,
+	}
+}
+
+// Create schedules a release and holds its tickets back
+func (r *MockInventoryReleaseRepository) Create(ctx context.Context, release *model.InventoryRelease) (*model.InventoryRelease, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.addAvailableTickets(release.ConcertID, -release.Quantity, nil); err != nil {
+		return nil, err
+	}
+
+	release.ID = r.nextID
+	release.CreatedAt = time.Now()
+	r.nextID++
+
+	releaseCopy := *release
+	r.releases[release.ID] = &releaseCopy
+
+	return release, nil
+}
+
+// ListByConcertID retrieves the releases of a concert in release order
+func (r *MockInventoryReleaseRepository) ListByConcertID(ctx context.Context, concertID int64) ([]*model.InventoryRelease, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := []*model.InventoryRelease{}
+	for _, release := range r.releases {
+		if release.ConcertID == concertID {
+			releaseCopy := *release
+			result = append(result, &releaseCopy)
+		}
+	}
+
+	sortReleases(result)
+	return result, nil
+}
+
+// Cancel deletes a pending release and returns its tickets
+func (r *MockInventoryReleaseRepository) Cancel(ctx context.Context, concertID, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	release, ok := r.releases[id]
+	if !ok || release.ConcertID != concertID || release.ReleasedAt != nil {
+		return errors.ErrNotFound
+	}
+
+	delete(r.releases, id)
+	return r.addAvailableTickets(concertID, release.Quantity, nil)
+}
+
+// ReleaseDue executes the pending releases due by now
+func (r *MockInventoryReleaseRepository) ReleaseDue(ctx context.Context, now time.Time, limit int) ([]*model.ReleasedInventory, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var due []*model.InventoryRelease
+	for _, release := range r.releases {
+		if release.ReleasedAt == nil && !release.ReleaseAt.After(now) {
+			due = append(due, release)
+		}
+	}
+
+	sortReleases(due)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	result := make([]*model.ReleasedInventory, 0, len(due))
+	for _, release := range due {
+		releasedAt := now
+		release.ReleasedAt = &releasedAt
+
+		releaseCopy := *release
+		released := &model.ReleasedInventory{Release: &releaseCopy}
+		if err := r.addAvailableTickets(release.ConcertID, release.Quantity, released); err != nil {
+			return nil, err
+		}
+		result = append(result, released)
+	}
+
+	return result, nil
+}
+
+// addAvailableTickets changes the available tickets of a concert; the caller must hold the lock
+func (r *MockInventoryReleaseRepository) addAvailableTickets(concertID int64, delta int, result *model.ReleasedInventory) error {
+	r.concerts.mutex.Lock()
+	defer r.concerts.mutex.Unlock()
+
+	concert, ok := r.concerts.concerts[concertID]
+	if !ok {
+		return errors.ErrNotFound
+	}
+
+	if concert.AvailableTickets+delta < 0 {
+		return errors.ErrInsufficientTickets
+	}
+
+	concert.AvailableTickets += delta
+	concert.Version++
+
+	if result != nil {
+		result.AvailableTickets = concert.AvailableTickets
+		result.TotalTickets = concert.TotalTickets
+	}
+
+	return nil
+}
+
+// sortReleases orders releases by release time, like the database
+func sortReleases(releases []*model.InventoryRelease) {
+	sort.Slice(releases, func(i, j int) bool {
+		if !releases[i].ReleaseAt.Equal(releases[j].ReleaseAt) {
+			return releases[i].ReleaseAt.Before(releases[j].ReleaseAt)
+		}
+		return releases[i].ID < releases[j].ID
+	})
+}
@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// MockRuntimeSettingsRepository is a mock implementation of
+// RuntimeSettingsRepository. Services sharing it behave like replicas sharing
+// the database: each one watching it is told about every save.
+type MockRuntimeSettingsRepository struct {
+	mutex    sync.Mutex
+	settings []byte
+	version  int
+	watchers []chan struct{}
+}
+
+// NewMockRuntimeSettingsRepository creates a new mock runtime settings repository
+func NewMockRuntimeSettingsRepository() *MockRuntimeSettingsRepository {
+	return &MockRuntimeSettingsRepository{}
+}
+
+// Get returns the stored settings
+func (r *MockRuntimeSettingsRepository) Get(ctx context.Context) (*model.RuntimeSettings, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.settings == nil {
+		return nil, pkgErr.ErrNotFound
+	}
+	var settings model.RuntimeSettings
+	if err := json.Unmarshal(r.settings, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save stores settings based on the stored version and notifies the watchers
+func (r *MockRuntimeSettingsRepository) Save(ctx context.Context, settings *model.RuntimeSettings) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if settings.Version != r.version {
+		return pkgErr.ErrOptimisticLockFailed
+	}
+
+	saved := *settings
+	saved.Version = r.version + 1
+	saved.UpdatedAt = time.Now()
+	encoded, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	r.settings, r.version = encoded, saved.Version
+	settings.Version, settings.UpdatedAt = saved.Version, saved.UpdatedAt
+
+	for _, watcher := range r.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Watch returns a channel notified of every save until ctx is done
+func (r *MockRuntimeSettingsRepository) Watch(ctx context.Context) (<-chan struct{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	watcher := make(chan struct{}, 1)
+	r.watchers = append(r.watchers, watcher)
+	go func() {
+		<-ctx.Done()
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		for i, w := range r.watchers {
+			if w == watcher {
+				r.watchers = append(r.watchers[:i], r.watchers[i+1:]...)
+				break
+			}
+		}
+		close(watcher)
+	}()
+	return watcher, nil
+}
@@ -0,0 +1,71 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+)
+
+// MockSampleRepository is a mock implementation of SampleRepository that reads
+// from a MockConcertRepository and a MockBookingRepository
+type MockSampleRepository struct {
+	concerts *MockConcertRepository
+	bookings *MockBookingRepository
+}
+
+// NewMockSampleRepository creates a new mock sample repository
+func NewMockSampleRepository(concerts *MockConcertRepository, bookings *MockBookingRepository) *MockSampleRepository {
+	return &MockSampleRepository{
+		concerts: concerts,
+		bookings: bookings,
+	}
+}
+
+// ListConcerts retrieves all concerts except private ones, in ID order
+func (r *MockSampleRepository) ListConcerts(ctx context.Context) ([]*model.Concert, error) {
+	r.concerts.mutex.RLock()
+	defer r.concerts.mutex.RUnlock()
+
+	result := []*model.Concert{}
+	for _, concert := range r.concerts.concerts {
+		if concert.Visibility != model.VisibilityPrivate {
+			concertCopy := *concert
+			concertCopy.OrganizerEmail = ""
+			result = append(result, &concertCopy)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// ListBookings retrieves the bookings of the given concerts, in ID order and
+// without references or attendee details
+func (r *MockSampleRepository) ListBookings(ctx context.Context, concertIDs []int64) ([]*model.Booking, error) {
+	r.bookings.mutex.RLock()
+	defer r.bookings.mutex.RUnlock()
+
+	wanted := make(map[int64]bool, len(concertIDs))
+	for _, id := range concertIDs {
+		wanted[id] = true
+	}
+
+	result := []*model.Booking{}
+	for _, booking := range r.bookings.bookings {
+		if wanted[booking.ConcertID] {
+			bookingCopy := *booking
+			bookingCopy.Reference = ""
+			bookingCopy.AttendeeName = ""
+			bookingCopy.AttendeeEmail = ""
+			result = append(result, &bookingCopy)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// Ensure the mock implements the interface
+var _ repository.SampleRepository = (*MockSampleRepository)(nil)
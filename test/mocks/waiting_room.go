@@ -0,0 +1,56 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"concert-ticket-api/internal/model"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// MockWaitingRoomSnapshotRepository is a mock implementation of WaitingRoomSnapshotRepository
+type MockWaitingRoomSnapshotRepository struct {
+	mutex    sync.RWMutex
+	snapshot []byte
+	format   int
+	version  int64
+}
+
+// NewMockWaitingRoomSnapshotRepository creates a new mock waiting room snapshot repository
+func NewMockWaitingRoomSnapshotRepository() *MockWaitingRoomSnapshotRepository {
+	return &MockWaitingRoomSnapshotRepository{}
+}
+
+// Save stores a snapshot unless the stored one of the same format is as recent
+func (r *MockWaitingRoomSnapshotRepository) Save(ctx context.Context, state *model.WaitingRoomState) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.snapshot != nil && r.format == state.Format && r.version >= state.Version {
+		return false, nil
+	}
+
+	// Stored encoded, so later changes to the state don't leak into it
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+	r.snapshot, r.format, r.version = encoded, state.Format, state.Version
+	return true, nil
+}
+
+// Latest returns the stored snapshot
+func (r *MockWaitingRoomSnapshotRepository) Latest(ctx context.Context) (*model.WaitingRoomState, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.snapshot == nil {
+		return nil, pkgErr.ErrNotFound
+	}
+	var state model.WaitingRoomState
+	if err := json.Unmarshal(r.snapshot, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
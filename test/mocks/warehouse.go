@@ -0,0 +1,184 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/pkg/warehouse"
+)
+
+// MockWarehouseRepository is a mock implementation of WarehouseRepository
+// that reads the changes of a mock booking repository and its concerts
+type MockWarehouseRepository struct {
+	Failures
+
+	mutex      sync.Mutex
+	bookings   *MockBookingRepository
+	watermarks map[string]model.WarehouseWatermark
+}
+
+var _ repository.WarehouseRepository = (*MockWarehouseRepository)(nil)
+
+// NewMockWarehouseRepository creates a new mock warehouse repository over a
+// booking repository with concerts
+func NewMockWarehouseRepository(bookings *MockBookingRepository) *MockWarehouseRepository {
+	return &MockWarehouseRepository{
+		bookings:   bookings,
+		watermarks: make(map[string]model.WarehouseWatermark),
+	}
+}
+
+// GetWatermark returns the watermark of a dataset
+func (r *MockWarehouseRepository) GetWatermark(ctx context.Context, dataset string) (*model.WarehouseWatermark, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	watermark, ok := r.watermarks[dataset]
+	if !ok {
+		return &model.WarehouseWatermark{Dataset: dataset}, nil
+	}
+	return &watermark, nil
+}
+
+// SaveWatermark records how far a dataset was exported
+func (r *MockWarehouseRepository) SaveWatermark(ctx context.Context, watermark *model.WarehouseWatermark) error {
+	if err := r.fail("SaveWatermark"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.watermarks[watermark.Dataset] = *watermark
+	return nil
+}
+
+// ListBookingChanges retrieves the bookings changed after the watermark
+func (r *MockWarehouseRepository) ListBookingChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseBooking, error) {
+	r.bookings.mutex.RLock()
+	defer r.bookings.mutex.RUnlock()
+
+	var bookings []*model.WarehouseBooking
+	for _, b := range r.bookings.bookings {
+		if changedAfter(b.UpdatedAt, b.ID, after) && b.UpdatedAt.Before(before) {
+			bookings = append(bookings, &model.WarehouseBooking{
+				ID: b.ID, Reference: b.Reference, ConcertID: b.ConcertID, UserID: b.UserID,
+				TicketCount: b.TicketCount, UnitPrice: b.UnitPrice, Status: b.Status,
+				BookingTime: b.BookingTime, CreatedAt: b.CreatedAt, UpdatedAt: b.UpdatedAt,
+			})
+		}
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		return changedBefore(bookings[i].UpdatedAt, bookings[i].ID, bookings[j].UpdatedAt, bookings[j].ID)
+	})
+	if len(bookings) > limit {
+		bookings = bookings[:limit]
+	}
+	return bookings, nil
+}
+
+// ListConcertChanges retrieves the concerts changed after the watermark
+func (r *MockWarehouseRepository) ListConcertChanges(ctx context.Context, after *model.WarehouseWatermark, before time.Time, limit int) ([]*model.WarehouseConcert, error) {
+	concerts := r.bookings.concerts
+	concerts.mutex.RLock()
+	defer concerts.mutex.RUnlock()
+
+	var changed []*model.WarehouseConcert
+	for _, c := range concerts.concerts {
+		if changedAfter(c.UpdatedAt, c.ID, after) && c.UpdatedAt.Before(before) {
+			changed = append(changed, &model.WarehouseConcert{
+				ID: c.ID, Name: c.Name, Artist: c.Artist, Venue: c.Venue, ConcertDate: c.ConcertDate,
+				TotalTickets: c.TotalTickets, AvailableTickets: c.AvailableTickets, Price: c.Price,
+				Currency: c.Currency, Visibility: c.Visibility, BookingStartTime: c.BookingStartTime,
+				BookingEndTime: c.BookingEndTime, CreatedAt: c.CreatedAt, UpdatedAt: c.UpdatedAt,
+			})
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changedBefore(changed[i].UpdatedAt, changed[i].ID, changed[j].UpdatedAt, changed[j].ID)
+	})
+	if len(changed) > limit {
+		changed = changed[:limit]
+	}
+	return changed, nil
+}
+
+// changedAfter compares a row with a watermark like (updated_at, id) > (…, …)
+func changedAfter(updatedAt time.Time, id int64, watermark *model.WarehouseWatermark) bool {
+	return changedBefore(watermark.UpdatedAt, watermark.LastID, updatedAt, id)
+}
+
+// changedBefore orders rows by their change time, then ID
+func changedBefore(updatedAt time.Time, id int64, otherUpdatedAt time.Time, otherID int64) bool {
+	if !updatedAt.Equal(otherUpdatedAt) {
+		return updatedAt.Before(otherUpdatedAt)
+	}
+	return id < otherID
+}
+
+// MockWarehouseSink is a mock implementation of warehouse.Sink that keeps
+// the files put in it by key
+type MockWarehouseSink struct {
+	mutex   sync.Mutex
+	files   map[string][]byte
+	keys    []string
+	failing bool
+}
+
+var _ warehouse.Sink = (*MockWarehouseSink)(nil)
+
+// NewMockWarehouseSink creates a new mock warehouse sink
+func NewMockWarehouseSink() *MockWarehouseSink {
+	return &MockWarehouseSink{files: make(map[string][]byte)}
+}
+
+// Name returns "mock"
+func (s *MockWarehouseSink) Name() string {
+	return "mock"
+}
+
+// Put keeps the file, or fails while the sink is set to fail
+func (s *MockWarehouseSink) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failing {
+		return errors.New("object store unavailable")
+	}
+
+	s.files[key] = body
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+// SetFailing makes subsequent puts fail or succeed
+func (s *MockWarehouseSink) SetFailing(failing bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failing = failing
+}
+
+// Keys returns the keys put so far in order, once per put
+func (s *MockWarehouseSink) Keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.keys...)
+}
+
+// File returns the last file put at key
+func (s *MockWarehouseSink) File(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, ok := s.files[key]
+	return body, ok
+}
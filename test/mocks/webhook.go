@@ -0,0 +1,298 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	pkgErr "concert-ticket-api/pkg/errors"
+)
+
+// MockWebhookRepository is a mock implementation of WebhookRepository that
+// fans out the events of a mock outbox to the organizers of the concerts of
+// a mock concert repository
+type MockWebhookRepository struct {
+	mutex      sync.Mutex
+	outbox     *MockOutboxRepository
+	concerts   *MockConcertRepository
+	webhooks   map[int64]*model.Webhook
+	deliveries map[int64]*model.WebhookDelivery
+	dispatched map[int64]bool
+	nextID     int64
+	nextDelID  int64
+}
+
+// NewMockWebhookRepository creates a new mock webhook repository over an
+// outbox and the concerts its events are about
+func NewMockWebhookRepository(outbox *MockOutboxRepository, concerts *MockConcertRepository) *MockWebhookRepository {
+	return &MockWebhookRepository{
+		outbox:     outbox,
+		concerts:   concerts,
+		webhooks:   make(map[int64]*model.Webhook),
+		deliveries: make(map[int64]*model.WebhookDelivery),
+		dispatched: make(map[int64]bool),
+		nextID:     1,
+		nextDelID:  1,
+	}
+}
+
+// Create inserts a webhook
+func (r *MockWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhook.ID = r.nextID
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	r.nextID++
+
+	stored := *webhook
+	r.webhooks[stored.ID] = &stored
+	return nil
+}
+
+// GetByID retrieves a webhook without its secret
+func (r *MockWebhookRepository) GetByID(ctx context.Context, id int64) (*model.Webhook, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	copied := *webhook
+	copied.Secret = ""
+	return &copied, nil
+}
+
+// List retrieves the webhooks of an organizer without their secret
+func (r *MockWebhookRepository) List(ctx context.Context, organizerEmail string) ([]*model.Webhook, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhooks := []*model.Webhook{}
+	for _, webhook := range r.webhooks {
+		if webhook.OrganizerEmail != organizerEmail {
+			continue
+		}
+		copied := *webhook
+		copied.Secret = ""
+		webhooks = append(webhooks, &copied)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+// Update replaces a webhook, and its secret unless the secret is empty
+func (r *MockWebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, ok := r.webhooks[webhook.ID]
+	if !ok {
+		return pkgErr.ErrNotFound
+	}
+
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+	stored := *webhook
+	if stored.Secret == "" {
+		stored.Secret = existing.Secret
+	}
+	r.webhooks[stored.ID] = &stored
+	return nil
+}
+
+// Delete deletes a webhook with its deliveries
+func (r *MockWebhookRepository) Delete(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return pkgErr.ErrNotFound
+	}
+
+	delete(r.webhooks, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// Enqueue creates the deliveries of the outbox events not yet dispatched to
+// the webhooks of the organizers of their concerts
+func (r *MockWebhookRepository) Enqueue(ctx context.Context, limit int, at time.Time) (int, int, error) {
+	events := r.outbox.Events()
+	organizers := make(map[int64]string, len(events))
+	for _, event := range events {
+		if concert, err := r.concerts.get(eventConcertID(event)); err == nil {
+			organizers[event.ID] = concert.OrganizerEmail
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	dispatched, enqueued := 0, 0
+	for _, event := range events {
+		if dispatched == limit {
+			break
+		}
+		if r.dispatched[event.ID] {
+			continue
+		}
+		r.dispatched[event.ID] = true
+		dispatched++
+
+		organizer := organizers[event.ID]
+		for _, webhook := range r.sortedWebhooks() {
+			if organizer == "" || webhook.OrganizerEmail != organizer {
+				continue
+			}
+			if !webhook.Active || !webhook.Receives(event.EventType) || webhook.CreatedAt.After(event.CreatedAt) {
+				continue
+			}
+			r.deliveries[r.nextDelID] = &model.WebhookDelivery{
+				ID:             r.nextDelID,
+				WebhookID:      webhook.ID,
+				EventID:        event.ID,
+				EventType:      event.EventType,
+				Payload:        model.RawJSON(event.Payload),
+				EventCreatedAt: event.CreatedAt,
+				Status:         model.WebhookDeliveryPending,
+				NextAttemptAt:  at,
+				CreatedAt:      at,
+			}
+			r.nextDelID++
+			enqueued++
+		}
+	}
+	return dispatched, enqueued, nil
+}
+
+// ListDue retrieves the pending deliveries of active webhooks due at a time
+func (r *MockWebhookRepository) ListDue(ctx context.Context, at time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	due := []*model.WebhookDelivery{}
+	for _, delivery := range r.sortedDeliveries() {
+		webhook := r.webhooks[delivery.WebhookID]
+		if delivery.Status != model.WebhookDeliveryPending || delivery.NextAttemptAt.After(at) || !webhook.Active {
+			continue
+		}
+		copied := *delivery
+		copied.URL = webhook.URL
+		copied.Secret = webhook.Secret
+		due = append(due, &copied)
+		if len(due) == limit {
+			break
+		}
+	}
+	return due, nil
+}
+
+// RecordAttempt saves the outcome of a delivery attempt
+func (r *MockWebhookRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *delivery
+	stored.URL, stored.Secret = "", ""
+	r.deliveries[stored.ID] = &stored
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first
+func (r *MockWebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sorted := r.sortedDeliveries()
+	deliveries := []*model.WebhookDelivery{}
+	for i := len(sorted) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		delivery := sorted[i]
+		if delivery.WebhookID != webhookID || (status != "" && delivery.Status != status) {
+			continue
+		}
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+// Redeliver schedules a delivery for another round of attempts
+func (r *MockWebhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID int64, at time.Time) (*model.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delivery, ok := r.deliveries[deliveryID]
+	if !ok || delivery.WebhookID != webhookID {
+		return nil, pkgErr.ErrNotFound
+	}
+
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = at
+	delivery.DeliveredAt = nil
+	copied := *delivery
+	return &copied, nil
+}
+
+// PurgeDeliveries deletes the delivered and dead deliveries created before a time
+func (r *MockWebhookRepository) PurgeDeliveries(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := 0
+	for id, delivery := range r.deliveries {
+		if delivery.CreatedAt.Before(before) && delivery.Status != model.WebhookDeliveryPending {
+			delete(r.deliveries, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// eventConcertID returns the ID of the concert an event is about, named by
+// the aggregate ID of concert events and the payload of booking events
+func eventConcertID(event *model.OutboxEvent) int64 {
+	if event.AggregateType == model.AggregateConcert {
+		id, _ := strconv.ParseInt(event.AggregateID, 10, 64)
+		return id
+	}
+	var payload model.BookingEvent
+	_ = json.Unmarshal(event.Payload, &payload)
+	return payload.ConcertID
+}
+
+// sortedWebhooks returns the webhooks in ID order. The mutex must be held.
+func (r *MockWebhookRepository) sortedWebhooks() []*model.Webhook {
+	sorted := make([]*model.Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		sorted = append(sorted, webhook)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// sortedDeliveries returns the deliveries in ID order. The mutex must be held.
+func (r *MockWebhookRepository) sortedDeliveries() []*model.WebhookDelivery {
+	sorted := make([]*model.WebhookDelivery, 0, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		sorted = append(sorted, delivery)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// Ensure the mock implements the interface
+var _ repository.WebhookRepository = (*MockWebhookRepository)(nil)
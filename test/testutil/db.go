@@ -3,8 +3,8 @@ package testutil
 import (
 	"fmt"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 )
@@ -60,7 +60,7 @@ func SetupTestDB() (*sqlx.DB, error) {
 	// Set a 30 second timeout
 	if err = pool.Retry(func() error {
 		// Try to connect to the database
-		testDB, err = sqlx.Connect("postgres", fmt.Sprintf("postgres://%s:%s@localhost:%s/%s?sslmode=disable", dbUser, dbPassword, dbPort, dbName))
+		testDB, err = sqlx.Connect("pgx", fmt.Sprintf("postgres://%s:%s@localhost:%s/%s?sslmode=disable", dbUser, dbPassword, dbPort, dbName))
 		if err != nil {
 			return err
 		}
@@ -85,7 +85,7 @@ func SetupTestDB() (*sqlx.DB, error) {
 // CleanupTestDB cleans up the test database
 func CleanupTestDB(db *sqlx.DB) error {
 	// Truncate all tables
-	_, err := db.Exec("TRUNCATE TABLE bookings, concerts RESTART IDENTITY CASCADE")
+	_, err := db.Exec("TRUNCATE TABLE warehouse_watermarks, notification_preferences, notifications, payments, webhook_deliveries, webhooks, outbox_events, cart_items, orders, runtime_settings, waiting_room_snapshots, job_runs, concert_invite_redemptions, concert_invites, concert_inventory_releases, booking_attempts, concert_price_history, accounting_sync, sales_reports, booking_tokens, bookings_archive, bookings, concerts RESTART IDENTITY CASCADE")
 	return err
 }
 
@@ -119,7 +119,24 @@ func createTables(db *sqlx.DB) error {
 			booking_end_time TIMESTAMP NOT NULL,
 			version INT NOT NULL DEFAULT 1,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			artist_aliases JSONB NOT NULL DEFAULT '[]',
+			venue_aliases JSONB NOT NULL DEFAULT '[]',
+			search_name TEXT NOT NULL DEFAULT '',
+			search_artist TEXT NOT NULL DEFAULT '',
+			search_venue TEXT NOT NULL DEFAULT '',
+			requires_booking_token BOOLEAN NOT NULL DEFAULT FALSE,
+			currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+			organizer_email TEXT NOT NULL DEFAULT '',
+			reporting_state VARCHAR(20) NOT NULL DEFAULT 'open',
+			reporting_claimed_at TIMESTAMP,
+			door_price DECIMAL(10, 2),
+			door_price_lead_minutes INT NOT NULL DEFAULT 0,
+			door_price_switched_at TIMESTAMP,
+			visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+			invite_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+			max_tickets_per_booking INT,
+			max_order_value DECIMAL(10, 2)
 		)
 	`)
 	if err != nil {
@@ -135,10 +152,419 @@ func createTables(db *sqlx.DB) error {
 			ticket_count INT NOT NULL,
 			booking_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+			attendee_name TEXT NOT NULL DEFAULT '',
+			attendee_email TEXT NOT NULL DEFAULT '',
+			reference VARCHAR(16) NOT NULL UNIQUE,
+			unit_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			idempotency_key VARCHAR(255),
+			inventory_pending BOOLEAN NOT NULL DEFAULT FALSE,
+			order_id INT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			CONSTRAINT valid_ticket_count CHECK (ticket_count > 0)
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	// Create booking tokens table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS booking_tokens (
+			token_hash VARCHAR(64) PRIMARY KEY,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			user_id VARCHAR(255) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create sales reports table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sales_reports (
+			concert_id INT PRIMARY KEY REFERENCES concerts(id),
+			reason VARCHAR(20) NOT NULL,
+			tickets_sold INT NOT NULL,
+			tickets_available INT NOT NULL,
+			confirmed_bookings INT NOT NULL,
+			cancelled_bookings INT NOT NULL,
+			revenue DECIMAL(12, 2) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			report_csv TEXT NOT NULL,
+			availability_history JSONB NOT NULL DEFAULT '[]',
+			recipient TEXT NOT NULL DEFAULT '',
+			emailed_at TIMESTAMP,
+			generated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create concert price history table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS concert_price_history (
+			id SERIAL PRIMARY KEY,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			price DECIMAL(10, 2) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create accounting sync table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS accounting_sync (
+			booking_id INT NOT NULL,
+			entry_type VARCHAR(10) NOT NULL,
+			provider VARCHAR(20) NOT NULL,
+			external_id TEXT NOT NULL DEFAULT '',
+			attempts INT NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			synced_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (booking_id, entry_type, provider)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create booking attempts table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS booking_attempts (
+			id BIGSERIAL PRIMARY KEY,
+			concert_id INT NOT NULL,
+			user_id VARCHAR(255) NOT NULL,
+			reason VARCHAR(32) NOT NULL,
+			latency_ms INT NOT NULL,
+			retries INT NOT NULL DEFAULT 0,
+			attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create inventory releases table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS concert_inventory_releases (
+			id SERIAL PRIMARY KEY,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			quantity INT NOT NULL,
+			release_at TIMESTAMP NOT NULL,
+			released_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT valid_release_quantity CHECK (quantity > 0)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create invite tables
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS concert_invites (
+			id SERIAL PRIMARY KEY,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			invitee VARCHAR(255) NOT NULL DEFAULT '',
+			single_use BOOLEAN NOT NULL DEFAULT FALSE,
+			redemptions INT NOT NULL DEFAULT 0,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT valid_invite_redemptions CHECK (redemptions >= 0 AND (NOT single_use OR redemptions <= 1))
+		);
+
+		CREATE TABLE IF NOT EXISTS concert_invite_redemptions (
+			id SERIAL PRIMARY KEY,
+			invite_id INT NOT NULL REFERENCES concert_invites(id),
+			booking_reference VARCHAR(16) NOT NULL UNIQUE,
+			redeemed_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create job runs table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS job_runs (
+			id BIGSERIAL PRIMARY KEY,
+			job_name VARCHAR(64) NOT NULL,
+			instance_id VARCHAR(255) NOT NULL,
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP NOT NULL,
+			outcome VARCHAR(16) NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create waiting room snapshots table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS waiting_room_snapshots (
+			id SMALLINT PRIMARY KEY DEFAULT 1,
+			format INT NOT NULL,
+			version BIGINT NOT NULL,
+			state TEXT NOT NULL,
+			taken_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create runtime settings table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS runtime_settings (
+			id SMALLINT PRIMARY KEY DEFAULT 1,
+			settings JSONB NOT NULL,
+			version INT NOT NULL,
+			updated_by VARCHAR(255) NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the inventory guard of the redis inventory mode
+	_, err = db.Exec(`
+		CREATE OR REPLACE FUNCTION guard_concert_inventory() RETURNS TRIGGER AS $$
+		DECLARE
+			pending BIGINT;
+		BEGIN
+			PERFORM pg_advisory_xact_lock(1768846964, NEW.id);
+
+			IF NEW.available_tickets < OLD.available_tickets THEN
+				SELECT COALESCE(SUM(ticket_count), 0) INTO pending
+				FROM bookings WHERE concert_id = NEW.id AND inventory_pending;
+
+				IF NEW.available_tickets < pending THEN
+					RAISE EXCEPTION 'concert % has fewer available tickets than its pending bookings took', NEW.id
+						USING ERRCODE = 'check_violation', CONSTRAINT = 'concert_inventory';
+				END IF;
+			END IF;
+
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS concert_inventory_guard ON concerts;
+		CREATE TRIGGER concert_inventory_guard
+			BEFORE UPDATE ON concerts
+			FOR EACH ROW
+			WHEN (OLD.version IS DISTINCT FROM NEW.version OR OLD.available_tickets IS DISTINCT FROM NEW.available_tickets)
+			EXECUTE FUNCTION guard_concert_inventory();
+
+		CREATE INDEX IF NOT EXISTS idx_bookings_inventory_pending ON bookings(concert_id) WHERE inventory_pending;
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_user_idempotency_key
+			ON bookings(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create cart and order tables
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS cart_items (
+			user_id VARCHAR(255) NOT NULL,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			ticket_count INT NOT NULL,
+			added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, concert_id),
+			CONSTRAINT valid_cart_ticket_count CHECK (ticket_count > 0)
+		);
+
+		CREATE TABLE IF NOT EXISTS orders (
+			id SERIAL PRIMARY KEY,
+			reference VARCHAR(16) NOT NULL UNIQUE,
+			user_id VARCHAR(255) NOT NULL,
+			mode VARCHAR(20) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the booking archive and the view over both booking tables
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS bookings_archive (
+			id INT NOT NULL,
+			concert_id INT NOT NULL REFERENCES concerts(id),
+			user_id VARCHAR(255) NOT NULL,
+			ticket_count INT NOT NULL,
+			booking_time TIMESTAMP,
+			status VARCHAR(20) NOT NULL,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			attendee_name TEXT NOT NULL DEFAULT '',
+			attendee_email TEXT NOT NULL DEFAULT '',
+			reference VARCHAR(16) NOT NULL,
+			unit_price DECIMAL(10, 2) NOT NULL,
+			idempotency_key VARCHAR(255),
+			inventory_pending BOOLEAN NOT NULL DEFAULT FALSE,
+			order_id INT,
+			concert_date TIMESTAMP NOT NULL,
+			archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id, concert_date)
+		) PARTITION BY RANGE (concert_date);
+
+		CREATE OR REPLACE VIEW all_bookings AS
+			SELECT id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+				attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id
+			FROM bookings
+			UNION ALL
+			SELECT id, concert_id, user_id, ticket_count, booking_time, status, created_at, updated_at,
+				attendee_name, attendee_email, reference, unit_price, idempotency_key, inventory_pending, order_id
+			FROM bookings_archive
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the outbox of domain events
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS outbox_events (
+			id BIGSERIAL PRIMARY KEY,
+			aggregate_type VARCHAR(50) NOT NULL,
+			aggregate_id VARCHAR(50) NOT NULL,
+			event_type VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			published_at TIMESTAMP,
+			attempts INT NOT NULL DEFAULT 0,
+			last_error TEXT,
+			last_attempt_at TIMESTAMP,
+			dispatched_at TIMESTAMP,
+			notified_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the webhooks and their deliveries
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id BIGSERIAL PRIMARY KEY,
+			organizer_email TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			event_types JSONB NOT NULL,
+			description VARCHAR(255) NOT NULL DEFAULT '',
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id BIGSERIAL PRIMARY KEY,
+			webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event_id BIGINT NOT NULL,
+			event_type VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			event_created_at TIMESTAMP NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_attempt_at TIMESTAMP,
+			response_status INT,
+			last_error TEXT,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (webhook_id, event_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the payments of bookings
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS payments (
+			id BIGSERIAL PRIMARY KEY,
+			booking_id BIGINT NOT NULL UNIQUE REFERENCES bookings(id) ON DELETE CASCADE,
+			provider VARCHAR(20) NOT NULL,
+			provider_reference VARCHAR(255) NOT NULL,
+			client_secret TEXT NOT NULL DEFAULT '',
+			amount DECIMAL(12, 2) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			expires_at TIMESTAMP NOT NULL,
+			reconciled_at TIMESTAMP,
+			mismatch VARCHAR(30) NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (provider, provider_reference)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the notifications of bookings
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id BIGSERIAL PRIMARY KEY,
+			channel VARCHAR(20) NOT NULL,
+			template VARCHAR(50) NOT NULL,
+			booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+			event_id BIGINT NOT NULL DEFAULT 0,
+			previous_concert_date TIMESTAMP,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_error TEXT,
+			sent_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (channel, template, booking_id, event_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the notification preferences of users
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id VARCHAR(255) PRIMARY KEY,
+			email BOOLEAN NOT NULL DEFAULT TRUE,
+			sms BOOLEAN NOT NULL DEFAULT FALSE,
+			push BOOLEAN NOT NULL DEFAULT FALSE,
+			phone_number TEXT NOT NULL DEFAULT '',
+			types JSONB NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the watermarks of the warehouse export
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS warehouse_watermarks (
+			dataset VARCHAR(32) PRIMARY KEY,
+			updated_at TIMESTAMP NOT NULL,
+			last_id BIGINT NOT NULL,
+			rows_exported BIGINT NOT NULL DEFAULT 0,
+			last_object_key TEXT NOT NULL DEFAULT '',
+			exported_at TIMESTAMP NOT NULL
+		)
+	`)
 	return err
 }
@@ -0,0 +1,75 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+var (
+	testRedis     *goredis.Client
+	redisPool     *dockertest.Pool
+	redisResource *dockertest.Resource
+)
+
+// SetupTestRedis starts Redis in Docker, for the inventory counters of the
+// Postgres runs. Unlike miniredis it runs the scripts concurrently with the
+// network in between, as production does.
+func SetupTestRedis() (*goredis.Client, error) {
+	if testRedis != nil {
+		return testRedis, nil
+	}
+
+	var err error
+	redisPool, err = dockertest.NewPool("")
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	redisResource, err = redisPool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "7",
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not start resource: %w", err)
+	}
+
+	client := goredis.NewClient(&goredis.Options{Addr: "localhost:" + redisResource.GetPort("6379/tcp"), MaxRetries: -1})
+	if err = redisPool.Retry(func() error {
+		return client.Ping(context.Background()).Err()
+	}); err != nil {
+		client.Close()
+		if purgeErr := redisPool.Purge(redisResource); purgeErr != nil {
+			fmt.Printf("Could not purge resource: %s\n", purgeErr)
+		}
+		return nil, fmt.Errorf("could not connect to redis: %w", err)
+	}
+
+	testRedis = client
+	return testRedis, nil
+}
+
+// CleanupTestRedis removes the counters, which would otherwise outlive the
+// concerts whose IDs the emptied database hands out again
+func CleanupTestRedis(client *goredis.Client) error {
+	return client.FlushDB(context.Background()).Err()
+}
+
+// TeardownTestRedis stops the Redis container
+func TeardownTestRedis() {
+	if testRedis != nil {
+		testRedis.Close()
+	}
+
+	if redisResource != nil && redisPool != nil {
+		if err := redisPool.Purge(redisResource); err != nil {
+			fmt.Printf("Could not purge resource: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package testutil
+
+import (
+	"fmt"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/scripts/migrations"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SetupSQLiteDB opens a new in-memory SQLite database with the schema of the
+// sqlite driver. Unlike the Postgres test database it needs no Docker, and
+// each call returns an empty database.
+func SetupSQLiteDB() (*sqlx.DB, error) {
+	database, err := db.NewSQLiteDB(config.Database{Driver: config.DriverSQLite, Path: ":memory:"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Migrate(database, config.Database{Driver: config.DriverSQLite}, migrations.FS); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("could not migrate the SQLite database: %w", err)
+	}
+
+	return database, nil
+}
@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/accounting"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saleEntry() *accounting.JournalEntry {
+	return &accounting.JournalEntry{
+		ID:       "7K3M9X2QAB4Z-sale",
+		Date:     time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC),
+		Currency: "USD",
+		Memo:     "Ticket sale for booking 7K3M9X2QAB4Z",
+		Lines: []accounting.JournalLine{
+			{Account: accounting.AccountCash, Debit: 50},
+			{Account: accounting.AccountRevenue, Credit: 50},
+		},
+	}
+}
+
+func TestQuickBooksPush(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/company/realm-1/journalentry", r.URL.Path)
+		assert.Equal(t, "7K3M9X2QAB4Z-sale", r.URL.Query().Get("requestid"))
+		assert.Equal(t, "Bearer qb-token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"JournalEntry":{"Id":"145"}}`))
+	}))
+	defer server.Close()
+
+	adapter := accounting.NewQuickBooks(config.QuickBooks{
+		AccessToken:      "qb-token",
+		BaseURL:          server.URL,
+		RealmID:          "realm-1",
+		CashAccountID:    "35",
+		RevenueAccountID: "79",
+	}, server.Client())
+
+	id, err := adapter.Push(context.Background(), saleEntry())
+	require.NoError(t, err)
+	assert.Equal(t, "145", id)
+
+	assert.Equal(t, "2026-03-01", body["TxnDate"])
+	lines := body["Line"].([]interface{})
+	require.Len(t, lines, 2)
+	debit := lines[0].(map[string]interface{})["JournalEntryLineDetail"].(map[string]interface{})
+	assert.Equal(t, "Debit", debit["PostingType"])
+	assert.Equal(t, "35", debit["AccountRef"].(map[string]interface{})["value"])
+	credit := lines[1].(map[string]interface{})["JournalEntryLineDetail"].(map[string]interface{})
+	assert.Equal(t, "Credit", credit["PostingType"])
+	assert.Equal(t, 50.0, lines[1].(map[string]interface{})["Amount"])
+}
+
+func TestXeroPush(t *testing.T) {
+	var body struct {
+		ManualJournals []struct {
+			JournalLines []struct {
+				LineAmount  float64
+				AccountCode string
+			}
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api.xro/2.0/ManualJournals", r.URL.Path)
+		assert.Equal(t, "tenant-1", r.Header.Get("Xero-Tenant-Id"))
+		assert.Equal(t, "7K3M9X2QAB4Z-sale", r.Header.Get("Idempotency-Key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"ManualJournals":[{"ManualJournalID":"mj-1"}]}`))
+	}))
+	defer server.Close()
+
+	adapter := accounting.NewXero(config.Xero{
+		AccessToken:        "xero-token",
+		BaseURL:            server.URL,
+		TenantID:           "tenant-1",
+		CashAccountCode:    "090",
+		RevenueAccountCode: "200",
+	}, server.Client())
+
+	id, err := adapter.Push(context.Background(), saleEntry())
+	require.NoError(t, err)
+	assert.Equal(t, "mj-1", id)
+
+	// Debits are positive and credits negative
+	require.Len(t, body.ManualJournals, 1)
+	lines := body.ManualJournals[0].JournalLines
+	require.Len(t, lines, 2)
+	assert.Equal(t, "090", lines[0].AccountCode)
+	assert.Equal(t, 50.0, lines[0].LineAmount)
+	assert.Equal(t, "200", lines[1].AccountCode)
+	assert.Equal(t, -50.0, lines[1].LineAmount)
+}
+
+func TestAdapterReportsRejectedEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"Message":"Account code 200 is archived"}`))
+	}))
+	defer server.Close()
+
+	adapter := accounting.NewXero(config.Xero{BaseURL: server.URL, CashAccountCode: "090", RevenueAccountCode: "200"}, server.Client())
+
+	_, err := adapter.Push(context.Background(), saleEntry())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Account code 200 is archived")
+}
@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueRates is a token service that only keeps the issue rate it was given
+type issueRates struct {
+	service.BookingTokenService
+	rate  float64
+	burst int
+	sets  int
+}
+
+func (r *issueRates) SetIssueRate(rate float64, burst int) {
+	r.rate, r.burst = rate, burst
+	r.sets++
+}
+
+var admissionPolicy = model.AdmissionPolicy{
+	MinRate:         5,
+	IncreaseStep:    10,
+	DecreaseFactor:  0.5,
+	LatencyTarget:   500 * time.Millisecond,
+	PoolWaitTarget:  50 * time.Millisecond,
+	ErrorRateTarget: 0.05,
+}
+
+func recordAttempts(admission service.AdmissionController, n int, latency time.Duration, reason string) {
+	for i := 0; i < n; i++ {
+		admission.Record(&model.BookingAttempt{Reason: reason, LatencyMS: latency.Milliseconds()})
+	}
+}
+
+func TestAdmissionRateFollowsBookingLatency(t *testing.T) {
+	tokens := &issueRates{}
+	admission := service.NewAdmissionController(tokens, admissionPolicy, 50, 100, nil, nil)
+	assert.Equal(t, 50.0, tokens.rate, "admission starts at the ceiling")
+	assert.Equal(t, 100, tokens.burst)
+
+	recordAttempts(admission, 10, 800*time.Millisecond, model.BookingAttemptBooked)
+	status := admission.Adjust()
+	assert.Equal(t, model.AdmissionDecisionDecrease, status.Decision)
+	assert.Equal(t, []string{service.AdmissionSignalLatency}, status.Reasons)
+	assert.Equal(t, 800.0, status.Signals.AvgLatencyMS)
+	assert.Equal(t, 25.0, tokens.rate)
+	assert.Equal(t, 50, tokens.burst, "the burst is scaled down with the rate")
+
+	for i := 0; i < 3; i++ {
+		recordAttempts(admission, 10, 800*time.Millisecond, model.BookingAttemptBooked)
+		status = admission.Adjust()
+	}
+	assert.Equal(t, 5.0, status.Rate, "the rate doesn't drop below the minimum")
+
+	recordAttempts(admission, 10, 100*time.Millisecond, model.BookingAttemptBooked)
+	status = admission.Adjust()
+	assert.Equal(t, model.AdmissionDecisionIncrease, status.Decision)
+	assert.Equal(t, 15.0, status.Rate)
+
+	for i := 0; i < 5; i++ {
+		status = admission.Adjust()
+	}
+	assert.Equal(t, model.AdmissionDecisionHold, status.Decision)
+	assert.Equal(t, 50.0, tokens.rate, "the rate doesn't rise above the ceiling")
+	assert.Equal(t, 100, tokens.burst)
+}
+
+func TestAdmissionRateFollowsDatabaseHealth(t *testing.T) {
+	tokens := &issueRates{}
+	healthy := true
+	stats := sql.DBStats{}
+	admission := service.NewAdmissionController(tokens, admissionPolicy, 50, 100,
+		func() sql.DBStats { return stats }, func() bool { return healthy })
+
+	stats.WaitCount, stats.WaitDuration = 4, 400*time.Millisecond
+	status := admission.Adjust()
+	assert.Equal(t, []string{service.AdmissionSignalPoolWait}, status.Reasons)
+	assert.Equal(t, 100.0, status.Signals.AvgPoolWaitMS)
+	assert.Equal(t, 25.0, tokens.rate)
+
+	status = admission.Adjust()
+	assert.Zero(t, status.Signals.AvgPoolWaitMS, "only the waits of the interval count")
+	assert.Equal(t, model.AdmissionDecisionIncrease, status.Decision)
+
+	healthy = false
+	status = admission.Adjust()
+	assert.Equal(t, []string{service.AdmissionSignalUnhealthy}, status.Reasons)
+	assert.Equal(t, 5.0, tokens.rate, "an unhealthy database gets the minimum rate at once")
+	assert.False(t, status.Signals.DatabaseHealthy)
+}
+
+func TestAdmissionErrorRateNeedsEnoughAttempts(t *testing.T) {
+	tokens := &issueRates{}
+	admission := service.NewAdmissionController(tokens, admissionPolicy, 50, 100, nil, nil)
+
+	recordAttempts(admission, 2, time.Millisecond, model.BookingAttemptError)
+	status := admission.Adjust()
+	assert.Equal(t, 1.0, status.Signals.ErrorRate)
+	assert.Equal(t, model.AdmissionDecisionHold, status.Decision, "two failures of a quiet interval don't count")
+
+	recordAttempts(admission, 30, time.Millisecond, model.BookingAttemptSoldOut)
+	recordAttempts(admission, 10, time.Millisecond, model.BookingAttemptConflict)
+	status = admission.Adjust()
+	assert.Zero(t, status.Signals.ErrorRate, "refused bookings aren't failures")
+
+	recordAttempts(admission, 30, time.Millisecond, model.BookingAttemptBooked)
+	recordAttempts(admission, 10, time.Millisecond, model.BookingAttemptError)
+	status = admission.Adjust()
+	assert.Equal(t, []string{service.AdmissionSignalErrorRate}, status.Reasons)
+	assert.Equal(t, 25.0, status.Rate)
+}
+
+func TestAdmissionOverrideAndCeiling(t *testing.T) {
+	tokens := &issueRates{}
+	admission := service.NewAdmissionController(tokens, admissionPolicy, 50, 100, nil, func() bool { return false })
+
+	_, err := admission.Override(0, 0, "ops")
+	assertInvalidInput(t, err)
+
+	status, err := admission.Override(200, 0, "ops")
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, tokens.rate, "operators may admit above the ceiling")
+	assert.Equal(t, 100, tokens.burst, "the burst defaults to the ceiling's")
+	require.NotNil(t, status.Override)
+	assert.Equal(t, "ops", status.Override.SetBy)
+
+	status = admission.Adjust()
+	assert.Equal(t, model.AdmissionDecisionOverride, status.Decision)
+	assert.Equal(t, 200.0, tokens.rate, "the unhealthy database doesn't move an overridden rate")
+
+	admission.SetCeiling(40, 80)
+	assert.Equal(t, 200.0, tokens.rate)
+
+	status = admission.ClearOverride()
+	assert.Nil(t, status.Override)
+	assert.Equal(t, 40.0, tokens.rate, "a cleared override is kept within the ceiling")
+
+	admission.SetCeiling(60, 120)
+	assert.Equal(t, 60.0, tokens.rate, "a rate at the ceiling follows it")
+	admission.Adjust()
+	admission.SetCeiling(100, 200)
+	assert.Equal(t, 5.0, tokens.rate, "a lowered rate is kept")
+
+	admission.SetCeiling(0, 1)
+	assert.Equal(t, 0.0, tokens.rate, "no ceiling leaves issuance unlimited")
+	assert.Equal(t, model.AdmissionDecisionHold, admission.Adjust().Decision)
+}
+
+func TestAdmissionHandlerOverridesTheRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := &issueRates{}
+	admission := service.NewAdmissionController(tokens, admissionPolicy, 50, 100, nil, nil)
+	router := gin.New()
+	handler.NewAdmissionHandler(admission, logger.NewLogger("error")).RegisterRoutes(router, func(c *gin.Context) {
+		c.Set("adminActor", "oncall")
+	})
+
+	send := func(method, body string) *httptest.ResponseRecorder {
+		path := "/admin/admission/override"
+		if method == http.MethodGet {
+			path = "/admin/admission"
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPut, `{"rate": -1}`).Code)
+
+	w := send(http.MethodPut, `{"rate": 12.5, "burst": 5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var status model.AdmissionStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, 12.5, status.Rate)
+	assert.Equal(t, "oncall", status.Override.SetBy)
+	assert.Equal(t, 5, tokens.burst)
+
+	w = send(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"decision":"override"`)
+
+	w = send(http.MethodDelete, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"override":`)
+}
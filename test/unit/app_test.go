@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/app"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freePort returns a port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+func loadMemoryConfig(t *testing.T, restPort, grpcPort int) *config.Config {
+	t.Helper()
+	cfg, err := config.Load(writeConfig(t, fmt.Sprintf(`
+log_level: error
+rest_port: %d
+grpc_port: %d
+database:
+  driver: memory
+shutdown:
+  timeout: 5s
+`, restPort, grpcPort)))
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestRunServesUntilCancelled(t *testing.T) {
+	restPort := freePort(t)
+	cfg := loadMemoryConfig(t, restPort, freePort(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx, cfg) }()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/health/live", restPort)
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run didn't return after ctx was cancelled")
+	}
+}
+
+func TestRunReturnsTheErrorOfAFailingServer(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+	cfg := loadMemoryConfig(t, freePort(t), taken.Addr().(*net.TCPAddr).Port)
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run(context.Background(), cfg) }()
+
+	select {
+	case err := <-done:
+		require.Error(t, err, "the REST server is shut down when the gRPC server can't listen")
+		assert.Contains(t, err.Error(), "gRPC server")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run didn't return after the gRPC server failed")
+	}
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveTestRepositories creates a concert 13 months ago with five
+// bookings and one a month ago with two
+func newArchiveTestRepositories(t *testing.T) (*mocks.MockArchiveRepository, *mocks.MockBookingRepository, []int64) {
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+
+	create := func(date time.Time, bookings int) []int64 {
+		concert, err := concertRepo.Create(ctx, &model.Concert{Name: "Past Concert", ConcertDate: date, TotalTickets: 100})
+		require.NoError(t, err)
+
+		ids := []int64{}
+		for i := 0; i < bookings; i++ {
+			booking, err := bookingRepo.Create(ctx, &model.Booking{ConcertID: concert.ID, UserID: "user-1",
+				TicketCount: 1, Status: model.BookingStatusConfirmed})
+			require.NoError(t, err)
+			ids = append(ids, booking.ID)
+		}
+		return ids
+	}
+
+	old := create(time.Now().AddDate(0, -13, 0), 5)
+	create(time.Now().AddDate(0, -1, 0), 2)
+	return mocks.NewMockArchiveRepository(concertRepo, bookingRepo), bookingRepo, old
+}
+
+func TestArchiveBookingsMovesThoseOfOldConcertsInBatches(t *testing.T) {
+	archiveRepo, bookingRepo, old := newArchiveTestRepositories(t)
+	ctx := context.Background()
+
+	result, err := service.NewArchiveService(archiveRepo).ArchiveBookings(ctx, 12, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Archived)
+	assert.Equal(t, 3, result.Batches)
+	assert.Equal(t, 3, archiveRepo.Calls, "a short batch ends the run")
+	assert.WithinDuration(t, time.Now().AddDate(-1, 0, 0), result.Cutoff, time.Minute)
+
+	assert.Equal(t, old, archiveRepo.Archived())
+	_, err = bookingRepo.GetByID(ctx, old[0])
+	assert.Error(t, err, "archived bookings leave the bookings table")
+
+	// Bookings of the recent concert stay, and a second run has nothing to do
+	result, err = service.NewArchiveService(archiveRepo).ArchiveBookings(ctx, 12, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Archived)
+	assert.Equal(t, 0, result.Batches)
+	assert.Len(t, archiveRepo.Archived(), 5)
+}
+
+func TestArchiveBookingsRejectsInvalidOptions(t *testing.T) {
+	archiveRepo, _, _ := newArchiveTestRepositories(t)
+	archiveService := service.NewArchiveService(archiveRepo)
+
+	_, err := archiveService.ArchiveBookings(context.Background(), 0, 100)
+	assertInvalidInput(t, err)
+
+	_, err = archiveService.ArchiveBookings(context.Background(), 12, 0)
+	assertInvalidInput(t, err)
+	assert.Zero(t, archiveRepo.Calls)
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestBusDropsOldestEventsOfSlowSubscribers(t *testing.T) {
+	bus := events.NewBus()
+	sub := bus.Subscribe("topic", 7, 2)
+	defer sub.Close()
+
+	bus.Publish(events.Event{Topic: "topic", Key: 8, Payload: 0})
+	bus.Publish(events.Event{Topic: "other", Key: 7, Payload: 0})
+	for i := 1; i <= 3; i++ {
+		bus.Publish(events.Event{Topic: "topic", Key: 7, Payload: i})
+	}
+
+	assert.Equal(t, 2, (<-sub.Events()).Payload)
+	assert.Equal(t, 3, (<-sub.Events()).Payload)
+
+	sub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok, "closing a subscription closes its channel")
+	bus.Publish(events.Event{Topic: "topic", Key: 7})
+}
+
+func TestWatchConcertAvailabilityStreamsBookings(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, bus, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	server := grpc.NewServer()
+	pb.RegisterConcertServiceServer(server, grpcapi.NewServer(concertService, bookingService, nil, nil, bus,
+		newTestAuthorizer(), false, logger.NewLogger("error"), 0))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewConcertServiceClient(conn).WatchConcertAvailability(ctx, &pb.WatchConcertAvailabilityRequest{ConcertId: concert.ID})
+	require.NoError(t, err)
+
+	// The current availability comes first, so watchers never start blind
+	current, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), current.AvailableTickets)
+	assert.Equal(t, int32(100), current.TotalTickets)
+
+	_, err = bookingService.BookTickets(context.Background(), &model.BookingRequest{
+		ConcertID:   concert.ID,
+		UserID:      "user-1",
+		TicketCount: 2,
+	})
+	require.NoError(t, err)
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, concert.ID, update.ConcertId)
+	assert.Equal(t, int32(98), update.AvailableTickets)
+}
+
+func TestBusRunsHandlersBeforeDeliveringToSubscribers(t *testing.T) {
+	bus := events.NewBus()
+	sub := bus.Subscribe("topic", 7, 4)
+	defer sub.Close()
+
+	var handled []int64
+	bus.Handle("topic", func(event events.Event) {
+		handled = append(handled, event.Key)
+		if event.Key == 7 {
+			assert.Empty(t, sub.Events(), "subscribers get the event once the handlers are done")
+			bus.Publish(events.Event{Topic: "follow-up", Key: event.Key})
+		}
+	})
+	followed := 0
+	bus.Handle("follow-up", func(event events.Event) { followed++ })
+
+	bus.Publish(events.Event{Topic: "topic", Key: 7})
+	bus.Publish(events.Event{Topic: "topic", Key: 8})
+	bus.Publish(events.Event{Topic: "other", Key: 7})
+
+	assert.Equal(t, []int64{7, 8}, handled, "handlers see every key of their topic")
+	assert.Equal(t, 1, followed, "handlers can publish events of their own")
+	assert.Len(t, sub.Events(), 1)
+
+	var nilBus *events.Bus
+	nilBus.Handle("topic", func(event events.Event) { t.Fatal("a nil bus runs no handlers") })
+	nilBus.Publish(events.Event{Topic: "topic"})
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createBatchConcerts(t *testing.T, repo *countingConcertRepository, count int) []int64 {
+	var ids []int64
+	for i := 0; i < count; i++ {
+		concert, err := repo.Create(context.Background(), &model.Concert{
+			Name:             "Concert " + strconv.Itoa(i),
+			Artist:           "Artist",
+			Venue:            "Venue",
+			ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+			TotalTickets:     100,
+			AvailableTickets: 100,
+			Price:            25,
+			Currency:         "USD",
+			BookingStartTime: time.Now().Add(-time.Hour),
+			BookingEndTime:   time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(t, err)
+		ids = append(ids, concert.ID)
+	}
+	return ids
+}
+
+func TestBatchGetConcertsKeepsRequestedOrder(t *testing.T) {
+	concertRepo := &countingConcertRepository{MockConcertRepository: mocks.NewMockConcertRepository()}
+	ids := createBatchConcerts(t, concertRepo, 3)
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+
+	concerts, missing, err := concertService.BatchGetConcerts(context.Background(), []int64{ids[2], 999, ids[0], ids[2]})
+	require.NoError(t, err)
+	require.Len(t, concerts, 2, "duplicates are returned once")
+	assert.Equal(t, ids[2], concerts[0].ID)
+	assert.Equal(t, ids[0], concerts[1].ID)
+	assert.Equal(t, []int64{999}, missing)
+
+	assert.Equal(t, int32(1), concertRepo.byIDs.Load(), "the batch is fetched with one query")
+	assert.Equal(t, int32(0), concertRepo.byID.Load())
+
+	_, _, err = concertService.BatchGetConcerts(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one concert ID is required")
+
+	tooMany := make([]int64, service.MaxBatchedConcerts+1)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+	_, _, err = concertService.BatchGetConcerts(context.Background(), tooMany)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot fetch more than 100 concerts at once")
+}
+
+func TestListConcertsByIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	concertRepo := &countingConcertRepository{MockConcertRepository: mocks.NewMockConcertRepository()}
+	ids := createBatchConcerts(t, concertRepo, 3)
+
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+
+	recorder := httptest.NewRecorder()
+	path := "/api/v1/concerts?ids=" + strconv.FormatInt(ids[1], 10) + ",999," + strconv.FormatInt(ids[0], 10)
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp handler.ConcertListResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, ids[1], resp.Data[0].ID)
+	assert.Equal(t, ids[0], resp.Data[1].ID)
+	assert.NotEmpty(t, resp.Data[0].PriceDisplay)
+	assert.Equal(t, []int64{999}, resp.NotFound)
+	assert.Equal(t, 2, resp.Meta.TotalCount)
+	assert.Equal(t, int32(1), concertRepo.byIDs.Load())
+
+	for _, path := range []string{"/api/v1/concerts?ids=", "/api/v1/concerts?ids=1,abc"} {
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, path)
+	}
+}
+
+func TestBatchGetConcertsRPC(t *testing.T) {
+	concertRepo := &countingConcertRepository{MockConcertRepository: mocks.NewMockConcertRepository()}
+	ids := createBatchConcerts(t, concertRepo, 2)
+
+	server := grpcapi.NewServer(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil), nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	resp, err := server.BatchGetConcerts(context.Background(), &pb.BatchGetConcertsRequest{Ids: []int64{ids[1], 999, ids[0]}})
+	require.NoError(t, err)
+	require.Len(t, resp.Concerts, 2)
+	assert.Equal(t, ids[1], resp.Concerts[0].Id)
+	assert.Equal(t, ids[0], resp.Concerts[1].Id)
+	assert.Equal(t, []int64{999}, resp.NotFound)
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturingAttemptRepository keeps written attempts in memory
+type capturingAttemptRepository struct {
+	mutex    sync.Mutex
+	batches  int
+	attempts []*model.BookingAttempt
+}
+
+func (r *capturingAttemptRepository) CreateBatch(ctx context.Context, attempts []*model.BookingAttempt) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.batches++
+	r.attempts = append(r.attempts, attempts...)
+	return nil
+}
+
+func (r *capturingAttemptRepository) Summarize(ctx context.Context, concertID int64, since time.Time) (*model.BookingAttemptSummary, error) {
+	return &model.BookingAttemptSummary{ConcertID: concertID, WindowStart: since}, nil
+}
+
+func (r *capturingAttemptRepository) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestBookingAttemptsRecordOutcomes(t *testing.T) {
+	ctx := context.Background()
+	repo := &capturingAttemptRepository{}
+	attempts := service.NewBookingAttemptService(repo, 100, time.Hour)
+
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, attempts, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	concert, err := concertRepo.Create(ctx, &model.Concert{
+		Name:             "Small Show",
+		TotalTickets:     2,
+		AvailableTickets: 2,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	requests := []*model.BookingRequest{
+		{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1},
+		{ConcertID: concert.ID, UserID: "user-2", TicketCount: 5},
+		{ConcertID: concert.ID, UserID: "user-3", TicketCount: 11},
+		{ConcertID: concert.ID + 1, UserID: "user-4", TicketCount: 1},
+	}
+	for _, req := range requests {
+		_, _ = bookingService.BookTickets(ctx, req)
+	}
+
+	// Nothing is written before the flush
+	assert.Empty(t, repo.attempts)
+
+	written, err := attempts.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(requests), written)
+
+	reasons := make([]string, 0, len(repo.attempts))
+	for _, attempt := range repo.attempts {
+		reasons = append(reasons, attempt.Reason)
+		assert.False(t, attempt.AttemptedAt.IsZero())
+	}
+	assert.Equal(t, []string{
+		model.BookingAttemptBooked,
+		model.BookingAttemptSoldOut,
+		model.BookingAttemptInvalidInput,
+		model.BookingAttemptNotFound,
+	}, reasons)
+	assert.Equal(t, "user-2", repo.attempts[1].UserID)
+}
+
+func TestBookingAttemptsDropWhenBufferIsFull(t *testing.T) {
+	repo := &capturingAttemptRepository{}
+	attempts := service.NewBookingAttemptService(repo, 2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		attempts.Record(&model.BookingAttempt{ConcertID: 1, UserID: "user", Reason: model.BookingAttemptBooked})
+	}
+
+	written, err := attempts.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, written, "attempts beyond the buffer are dropped instead of blocking")
+	assert.Equal(t, 1, repo.batches)
+}
+
+func TestBookingAttemptsValidate(t *testing.T) {
+	cfg := config.BookingAttempts{}
+	assert.NoError(t, cfg.Validate(), "disabled recording needs no settings")
+
+	cfg = config.BookingAttempts{Enabled: true, BufferSize: 10, FlushInterval: time.Second, Retention: time.Hour}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.FlushInterval = 0
+	assert.Error(t, cfg.Validate())
+}
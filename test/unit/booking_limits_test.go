@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createLimitedConcert(t *testing.T, repo *mocks.MockConcertRepository, maxTickets *int, maxOrderValue *float64) *model.Concert {
+	concert, err := repo.Create(context.Background(), &model.Concert{
+		Name:                 "Concert",
+		Artist:               "Artist",
+		Venue:                "Venue",
+		ConcertDate:          time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:         100,
+		AvailableTickets:     100,
+		Price:                40,
+		Currency:             "USD",
+		Visibility:           model.VisibilityPublic,
+		BookingStartTime:     time.Now().Add(-time.Hour),
+		BookingEndTime:       time.Now().Add(24 * time.Hour),
+		MaxTicketsPerBooking: maxTickets,
+		MaxOrderValue:        maxOrderValue,
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func TestBookingLimitsFallBackToDefaults(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	festival := createLimitedConcert(t, concertRepo, nil, nil)
+	ctx := context.Background()
+
+	builtIn := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	_, err := builtIn.BookTickets(ctx, &model.BookingRequest{ConcertID: festival.ID, UserID: "user-1", TicketCount: 11})
+	assert.ErrorIs(t, err, pkgErr.ErrInvalidInput(""))
+	assert.EqualError(t, err, "cannot book more than 10 tickets at once")
+
+	configured := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil,
+		model.BookingLimits{MaxTickets: 20, MaxOrderValue: 600}, nil, service.BookingOptimistic, nil)
+	booking, err := configured.BookTickets(ctx, &model.BookingRequest{ConcertID: festival.ID, UserID: "user-1", TicketCount: 15})
+	require.NoError(t, err)
+	assert.Equal(t, 15, booking.TicketCount)
+
+	_, err = configured.BookTickets(ctx, &model.BookingRequest{ConcertID: festival.ID, UserID: "user-2", TicketCount: 16})
+	assert.EqualError(t, err, "order value cannot exceed $600.00")
+}
+
+func TestSetBookingLimitsAppliesToNextBookings(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	festival := createLimitedConcert(t, concertRepo, nil, nil)
+	ctx := context.Background()
+
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil,
+		model.BookingLimits{MaxTickets: 20}, nil, service.BookingOptimistic, nil)
+	bookingService.SetBookingLimits(model.BookingLimits{MaxTickets: 4})
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: festival.ID, UserID: "user-1", TicketCount: 5})
+	assert.EqualError(t, err, "cannot book more than 4 tickets at once")
+
+	bookingService.SetBookingLimits(model.BookingLimits{})
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: festival.ID, UserID: "user-1", TicketCount: 11})
+	assert.EqualError(t, err, "cannot book more than 10 tickets at once", "unset limits fall back to the built-in ones")
+}
+
+func TestConcertBookingLimitsReplaceDefaults(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	two, hundred := 2, 100.0
+	intimate := createLimitedConcert(t, concertRepo, &two, nil)
+	capped := createLimitedConcert(t, concertRepo, nil, &hundred)
+	ctx := context.Background()
+	defaults := model.BookingLimits{MaxTickets: 20, MaxOrderValue: 1000}
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, defaults, nil, service.BookingOptimistic, nil)
+	concertService := service.NewConcertService(concertRepo, nil, defaults, nil)
+
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: intimate.ID, UserID: "user-1", TicketCount: 3})
+	assert.EqualError(t, err, "cannot book more than 2 tickets at once")
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: intimate.ID, UserID: "user-1", TicketCount: 2})
+	assert.NoError(t, err)
+
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: capped.ID, UserID: "user-1", TicketCount: 3})
+	assert.EqualError(t, err, "order value cannot exceed $100.00")
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: capped.ID, UserID: "user-1", TicketCount: 2})
+	assert.NoError(t, err)
+
+	// Quotes are refused like the bookings they price
+	_, err = concertService.Quote(ctx, intimate.ID, 3)
+	assert.ErrorIs(t, err, pkgErr.ErrInvalidInput(""))
+	_, err = concertService.Quote(ctx, capped.ID, 3)
+	assert.ErrorIs(t, err, pkgErr.ErrInvalidInput(""))
+	quote, err := concertService.Quote(ctx, capped.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, quote.Total)
+
+	// Batches fail the requests over the limits and book the others
+	results := bookingService.BookTicketsBatch(ctx, []*model.BookingRequest{
+		{ConcertID: intimate.ID, UserID: "agency", TicketCount: 4},
+		{ConcertID: intimate.ID, UserID: "agency", TicketCount: 1},
+	})
+	assert.ErrorIs(t, results[0].Err, pkgErr.ErrInvalidInput(""))
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, 1, results[1].Booking.TicketCount)
+}
+
+func TestConcertBookingLimitsAreValidated(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	concert := createLimitedConcert(t, concertRepo, nil, nil)
+	ctx := context.Background()
+
+	zero, negative := 0, -5.0
+	for _, patch := range []*model.ConcertPatch{
+		{Fields: []string{"max_tickets_per_booking"}, Values: model.Concert{MaxTicketsPerBooking: &zero}},
+		{Fields: []string{"max_order_value"}, Values: model.Concert{MaxOrderValue: &negative}},
+	} {
+		_, _, err := concertService.PatchConcert(ctx, "organizer", concert.ID, concert.Version, patch)
+		assert.ErrorIs(t, err, pkgErr.ErrInvalidInput(""), "patch of %v", patch.Fields)
+	}
+
+	maxOrderValue := 99.999
+	updated, changes, err := concertService.PatchConcert(ctx, "organizer", concert.ID, concert.Version,
+		&model.ConcertPatch{Fields: []string{"max_order_value"}, Values: model.Concert{MaxOrderValue: &maxOrderValue}})
+	require.NoError(t, err)
+	require.NotNil(t, updated.MaxOrderValue)
+	assert.Equal(t, 100.0, *updated.MaxOrderValue, "order values are rounded like prices")
+	require.Len(t, changes, 1)
+	assert.Equal(t, "max_order_value", changes[0].Field)
+}
+
+func TestBookingLimitsConfigValidate(t *testing.T) {
+	limits := config.BookingLimits{MaxTicketsPerBooking: 10}
+	assert.NoError(t, limits.Validate())
+
+	limits.MaxTicketsPerBooking = 0
+	assert.Error(t, limits.Validate())
+
+	limits = config.BookingLimits{MaxTicketsPerBooking: 10, MaxOrderValue: -1}
+	assert.Error(t, limits.Validate())
+}
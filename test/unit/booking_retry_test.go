@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryBookings(t *testing.T, maxRetries int) (service.BookingService, *mocks.MockConcertRepository, *mocks.MockBookingRepository, *model.Concert) {
+	return newRetryPolicyBookings(t, service.RetryPolicy{MaxRetries: maxRetries})
+}
+
+func newRetryPolicyBookings(t *testing.T, retry service.RetryPolicy) (service.BookingService, *mocks.MockConcertRepository, *mocks.MockBookingRepository, *model.Concert) {
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	concert, err := concerts.Create(context.Background(), &model.Concert{
+		Name:             "Retry Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     10,
+		AvailableTickets: 10,
+		Price:            30,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	bookingService := service.NewBookingService(bookings, concerts, retry, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	return bookingService, concerts, bookings, concert
+}
+
+func TestOptimisticBookingRetriesVersionConflicts(t *testing.T) {
+	bookingService, concerts, bookings, concert := newRetryBookings(t, 3)
+	ctx := context.Background()
+	bookings.FailNext("CreateWithTicketUpdate", pkgErr.ErrOptimisticLockFailed, pkgErr.ErrOptimisticLockFailed)
+
+	booking, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+
+	made, err := bookings.GetAllByConcertID(ctx, concert.ID)
+	require.NoError(t, err)
+	require.Len(t, made, 1, "the failed attempts made no booking")
+	assert.Equal(t, booking.ID, made[0].ID)
+
+	updated, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, updated.AvailableTickets)
+	assert.Equal(t, concert.Version+1, updated.Version)
+}
+
+func TestOptimisticBookingGivesUpAfterMaxRetries(t *testing.T) {
+	bookingService, concerts, bookings, concert := newRetryBookings(t, 3)
+	ctx := context.Background()
+	bookings.FailNext("CreateWithTicketUpdate", pkgErr.ErrOptimisticLockFailed, pkgErr.ErrOptimisticLockFailed, pkgErr.ErrOptimisticLockFailed)
+
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	assert.ErrorIs(t, err, pkgErr.ErrOptimisticLockFailed)
+
+	updated, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, updated.AvailableTickets)
+
+	// The failures are used up, so the next booking goes through
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+}
+
+func TestOptimisticBookingDoesNotRetryOtherErrors(t *testing.T) {
+	bookingService, concerts, bookings, concert := newRetryBookings(t, 3)
+	ctx := context.Background()
+	unavailable := errors.New("connection refused")
+	bookings.FailNext("CreateWithTicketUpdate", unavailable)
+
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	assert.ErrorIs(t, err, unavailable)
+
+	// A stale version from the concert repository is refused by the mock itself
+	updated, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, updated.AvailableTickets)
+	err = bookings.CreateWithTicketUpdate(ctx, &model.Booking{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1,
+		Status: model.BookingStatusConfirmed}, updated.Version-1)
+	assert.ErrorIs(t, err, pkgErr.ErrOptimisticLockFailed)
+}
+
+func TestRetryPolicyBacksOffExponentially(t *testing.T) {
+	policy := service.RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond, Multiplier: 2}
+	var delays []time.Duration
+	for attempt := 0; attempt < 4; attempt++ {
+		delays = append(delays, policy.Delay(attempt))
+	}
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}, delays)
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(0)
+		assert.GreaterOrEqual(t, delay, 5*time.Millisecond)
+		assert.LessOrEqual(t, delay, 10*time.Millisecond)
+	}
+}
+
+func TestOptimisticBookingStopsBackingOffWhenTheCallerGivesUp(t *testing.T) {
+	bookingService, concerts, bookings, concert := newRetryPolicyBookings(t, service.RetryPolicy{MaxRetries: 3, BaseDelay: time.Hour})
+	bookings.FailNext("CreateWithTicketUpdate", pkgErr.ErrOptimisticLockFailed)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second, "the booking doesn't wait out the backoff")
+
+	updated, err := concerts.GetByID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, updated.AvailableTickets)
+}
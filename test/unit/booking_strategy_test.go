@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// strategiesWithoutRetries are the booking strategies that don't fail
+// bookings on version conflicts
+var strategiesWithoutRetries = map[string]service.BookingStrategy{
+	"serialized":  service.BookingSerialized,
+	"conditional": service.BookingConditional,
+}
+
+func newStrategyBookings(t *testing.T, strategy service.BookingStrategy, tickets int) (service.BookingService, *mocks.MockConcertRepository, *model.Concert) {
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	concert, err := concerts.Create(context.Background(), &model.Concert{
+		Name:             "Strategy Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            30,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	// A single attempt shows that no booking needs a retry
+	bookingService := service.NewBookingService(bookings, concerts, service.RetryPolicy{MaxRetries: 1}, nil, nil, nil, nil, model.BookingLimits{}, nil, strategy, nil)
+	return bookingService, concerts, concert
+}
+
+func TestStrategiesWithoutRetriesBookConcurrentRequests(t *testing.T) {
+	for name, strategy := range strategiesWithoutRetries {
+		t.Run(name, func(t *testing.T) {
+			bookingService, concerts, concert := newStrategyBookings(t, strategy, 50)
+
+			var (
+				wg     sync.WaitGroup
+				mu     sync.Mutex
+				booked int
+			)
+			for i := 0; i < 40; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, err := bookingService.BookTickets(context.Background(), &model.BookingRequest{
+						ConcertID:   concert.ID,
+						UserID:      "user-" + strconv.Itoa(i),
+						TicketCount: 2,
+					})
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						booked++
+						return
+					}
+					assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets, "bookings don't fail on version conflicts")
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, 25, booked)
+			updated, err := concerts.GetByID(context.Background(), concert.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 0, updated.AvailableTickets)
+		})
+	}
+}
+
+func TestStrategiesWithoutRetriesRefusalsTakeNoTickets(t *testing.T) {
+	for name, strategy := range strategiesWithoutRetries {
+		t.Run(name, func(t *testing.T) {
+			bookingService, concerts, concert := newStrategyBookings(t, strategy, 10)
+
+			booking, err := bookingService.BookTickets(context.Background(), &model.BookingRequest{
+				ConcertID:   concert.ID,
+				UserID:      "user-1",
+				TicketCount: 3,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 30.0, booking.UnitPrice)
+
+			updated, err := concerts.GetByID(context.Background(), concert.ID)
+			require.NoError(t, err)
+			limit := 2
+			updated.MaxTicketsPerBooking = &limit
+			require.NoError(t, concerts.Update(context.Background(), updated))
+
+			_, err = bookingService.BookTickets(context.Background(), &model.BookingRequest{
+				ConcertID:   concert.ID,
+				UserID:      "user-2",
+				TicketCount: 3,
+			})
+			assertInvalidInput(t, err)
+
+			updated, err = concerts.GetByID(context.Background(), concert.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 7, updated.AvailableTickets, "a refused booking takes no tickets")
+		})
+	}
+}
+
+func TestConditionalStrategyRefusalsComeFromTheUpdate(t *testing.T) {
+	bookingService, concerts, concert := newStrategyBookings(t, service.BookingConditional, 4)
+	ctx := context.Background()
+
+	_, err := bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 5})
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+
+	updated, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	updated.BookingEndTime = time.Now().Add(-time.Minute)
+	require.NoError(t, concerts.Update(ctx, updated))
+
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1})
+	assert.ErrorIs(t, err, pkgErr.ErrBookingClosed)
+
+	updated, err = concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, updated.AvailableTickets)
+}
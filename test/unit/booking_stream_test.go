@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func createStreamTestConcert(t *testing.T, concertRepo *mocks.MockConcertRepository, tickets int) *model.Concert {
+	t.Helper()
+
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Allocation Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            40,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func TestBatchBookingsFillTheRemainingTicketsInOrder(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	concert := createStreamTestConcert(t, concertRepo, 15)
+	bookingService := service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	results := bookingService.BookTicketsBatch(context.Background(), []*model.BookingRequest{
+		{ConcertID: concert.ID, UserID: "agency-1", TicketCount: 5},
+		{ConcertID: concert.ID, UserID: "agency-1", TicketCount: 0},
+		{ConcertID: 999, UserID: "agency-1", TicketCount: 2},
+		{ConcertID: concert.ID, UserID: "agency-1", TicketCount: 10},
+		{ConcertID: concert.ID, UserID: "agency-1", TicketCount: 1},
+	})
+	require.Len(t, results, 5)
+
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, 5, results[0].Booking.TicketCount)
+	assert.Equal(t, 40.0, results[0].Booking.UnitPrice)
+	assert.NotEmpty(t, results[0].Booking.Reference)
+
+	var errWithMsg *pkgErr.ErrorWithMessage
+	assert.True(t, errors.As(results[1].Err, &errWithMsg), "invalid requests fail on their own")
+	assert.True(t, errors.Is(results[2].Err, pkgErr.ErrNotFound))
+
+	require.NoError(t, results[3].Err)
+	assert.True(t, errors.Is(results[4].Err, pkgErr.ErrInsufficientTickets), "15 tickets are taken by the requests before it")
+
+	bookings, err := bookingRepo.GetAllByConcertID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	assert.Len(t, bookings, 2)
+}
+
+func TestBookTicketsStreamCommitsInChunks(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	concert := createStreamTestConcert(t, concertRepo, 1000)
+	bookingService := service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	server := grpc.NewServer()
+	pb.RegisterBookingServiceServer(server, grpcapi.NewServer(nil, bookingService, nil, nil, nil,
+		newTestAuthorizer(), false, logger.NewLogger("error"), 0))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewBookingServiceClient(conn).BookTicketsStream(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 250; i++ {
+		req := &pb.BookTicketsRequest{ConcertId: concert.ID, UserId: "agency-1", TicketCount: 2}
+		if i == 120 {
+			req.TicketCount = 50
+		}
+		require.NoError(t, stream.Send(req))
+	}
+
+	summary, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(250), summary.Received)
+	assert.Equal(t, int32(249), summary.Booked)
+	assert.Equal(t, int32(1), summary.Failed)
+	assert.Equal(t, int32(498), summary.TicketsBooked)
+	assert.Equal(t, int32(3), summary.Chunks)
+
+	require.Len(t, summary.Results, 250)
+	for i, result := range summary.Results {
+		assert.Equal(t, int32(i), result.Index)
+	}
+	failed := summary.Results[120]
+	assert.Empty(t, failed.Reference)
+	assert.Equal(t, problem.CodeInvalidInput, failed.ErrorReason)
+	assert.NotEmpty(t, failed.ErrorMessage)
+	assert.NotEmpty(t, summary.Results[121].Reference)
+
+	bookings, err := bookingRepo.GetAllByConcertID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	assert.Len(t, bookings, 249)
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/ical"
+	"concert-ticket-api/pkg/pagelink"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeedOfConfirmedBookings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+
+	concert := func(name, venue string, date time.Time) *model.Concert {
+		created, err := concertRepo.Create(ctx, &model.Concert{
+			Name: name, Artist: "Artist", Venue: venue, ConcertDate: date, TotalTickets: 100, AvailableTickets: 100,
+		})
+		require.NoError(t, err)
+		return created
+	}
+	book := func(concertID int64, userID string, status model.BookingStatus, tickets int) *model.Booking {
+		booking, err := bookingRepo.Create(ctx, &model.Booking{ConcertID: concertID, UserID: userID, TicketCount: tickets, Status: status})
+		require.NoError(t, err)
+		return booking
+	}
+
+	later := concert("Autumn Night", "Hall", time.Date(2026, 9, 1, 19, 30, 0, 0, time.UTC))
+	sooner := concert("Summer Night, Live; Encore", "Park\nStage 2", time.Date(2026, 7, 4, 20, 0, 0, 0, time.FixedZone("CEST", 2*3600)))
+	cancelled := concert("Cancelled Night", "Club", time.Date(2026, 8, 1, 21, 0, 0, 0, time.UTC))
+	laterBooking := book(later.ID, "alice", model.BookingStatusConfirmed, 1)
+	soonerBooking := book(sooner.ID, "alice", model.BookingStatusConfirmed, 3)
+	book(cancelled.ID, "alice", model.BookingStatusCancelled, 2)
+	book(later.ID, "bob", model.BookingStatusConfirmed, 2)
+
+	signer := pagelink.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	feed := "/api/v1/users/alice/bookings.ics?token=" + signer.SignSubject(pagelink.KindCalendar, "alice")
+	router := gin.New()
+	handler.NewCalendarHandler(service.NewCalendarService(bookingRepo, concertRepo, signer)).RegisterRoutes(router.Group("/api/v1"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, feed, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ical.ContentType, w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+	assert.NotContains(t, strings.ReplaceAll(body, "\r\n", ""), "\n", "lines end with CRLF")
+
+	events := strings.Split(body, "BEGIN:VEVENT\r\n")[1:]
+	require.Len(t, events, 2, "only confirmed bookings of the user are in the feed")
+	assert.Contains(t, events[0], "UID:booking-"+soonerBooking.Reference+"@concert-ticket-api\r\n", "events are ordered by concert date")
+	assert.Contains(t, events[0], "DTSTART:20260704T180000Z\r\n", "start times are in UTC")
+	assert.Contains(t, events[0], "DURATION:PT3H\r\n")
+	assert.Contains(t, events[0], `SUMMARY:Summer Night\, Live\; Encore`+"\r\n")
+	assert.Contains(t, events[0], `LOCATION:Park\nStage 2`+"\r\n")
+	assert.Contains(t, events[0], "DTSTAMP:20260601T120000Z\r\n")
+	assert.Contains(t, events[1], "UID:booking-"+laterBooking.Reference+"@concert-ticket-api\r\n")
+	assert.Contains(t, events[1], "DTSTART:20260901T193000Z\r\n")
+	assert.NotContains(t, body, "Cancelled Night")
+
+	// Cancelling a booking drops it from the next refresh
+	require.NoError(t, service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil).CancelBooking(ctx, laterBooking.ID, "alice"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, feed, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+}
+
+func TestCalendarFeedNeedsTheTokenOfTheUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	concert, err := concertRepo.Create(ctx, &model.Concert{Name: "Night", Venue: "Hall", ConcertDate: time.Now().Add(24 * time.Hour), TotalTickets: 10})
+	require.NoError(t, err)
+	_, err = bookingRepo.Create(ctx, &model.Booking{ConcertID: concert.ID, UserID: "alice", TicketCount: 1, Status: model.BookingStatusConfirmed})
+	require.NoError(t, err)
+
+	signer := pagelink.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	router := gin.New()
+	handler.NewCalendarHandler(service.NewCalendarService(bookingRepo, concertRepo, signer)).RegisterRoutes(router.Group("/api/v1"))
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	for name, path := range map[string]string{
+		"no token":         "/api/v1/users/alice/bookings.ics",
+		"garbage":          "/api/v1/users/alice/bookings.ics?token=not-a-token",
+		"another user's":   "/api/v1/users/alice/bookings.ics?token=" + signer.SignSubject(pagelink.KindCalendar, "bob"),
+		"another kind":     "/api/v1/users/alice/bookings.ics?token=" + signer.SignSubject(pagelink.KindTicket, "alice"),
+		"another key's":    "/api/v1/users/alice/bookings.ics?token=" + pagelink.NewSigner([]byte("another key")).SignSubject(pagelink.KindCalendar, "alice"),
+		"the user changed": "/api/v1/users/bob/bookings.ics?token=" + signer.SignSubject(pagelink.KindCalendar, "alice"),
+	} {
+		w := get(path)
+		assert.Equal(t, http.StatusNotFound, w.Code, name)
+		assert.NotContains(t, w.Body.String(), "VEVENT", name)
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/v1/users/alice/bookings.ics?token="+signer.SignSubject(pagelink.KindCalendar, "alice")).Code)
+}
+
+func TestCalendarFoldsLongLines(t *testing.T) {
+	calendar := &ical.Calendar{
+		ProdID: "-//Test//EN",
+		Events: []ical.Event{{
+			UID:         "event-1",
+			Start:       time.Date(2026, 7, 4, 20, 0, 0, 0, time.UTC),
+			Summary:     "Short",
+			Description: strings.Repeat("é", 100),
+		}},
+	}
+	feed := string(calendar.Encode(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "lines are at most 75 octets")
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+			continue
+		}
+		unfolded.WriteString("\n" + line)
+	}
+	assert.Contains(t, unfolded.String(), "\nDESCRIPTION:"+strings.Repeat("é", 100)+"\n", "folding doesn't split characters")
+}
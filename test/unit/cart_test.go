@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cartFixture struct {
+	concerts *mocks.MockConcertRepository
+	bookings *mocks.MockBookingRepository
+	carts    service.CartService
+	orders   service.OrderService
+}
+
+func newCartFixture() *cartFixture {
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	carts := mocks.NewMockCartRepository(concerts, bookings)
+	return &cartFixture{
+		concerts: concerts,
+		bookings: bookings,
+		carts:    service.NewCartService(carts, concerts, service.RetryPolicy{MaxRetries: 3}, nil, model.BookingLimits{}),
+		orders:   service.NewOrderService(mocks.NewMockOrderRepository(carts), nil),
+	}
+}
+
+func (f *cartFixture) createConcert(t *testing.T, tickets int, price float64) *model.Concert {
+	concert, err := f.concerts.Create(context.Background(), &model.Concert{
+		Name:             "Cart Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            price,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func (f *cartFixture) add(t *testing.T, user string, concertID int64, tickets int) {
+	_, err := f.carts.AddItem(context.Background(), concertID, &model.CartItemRequest{UserID: user, TicketCount: tickets})
+	require.NoError(t, err)
+}
+
+func (f *cartFixture) available(t *testing.T, concertID int64) int {
+	concert, err := f.concerts.GetByID(context.Background(), concertID)
+	require.NoError(t, err)
+	return concert.AvailableTickets
+}
+
+// drain takes tickets of a concert after they were put in carts, leaving left
+func (f *cartFixture) drain(t *testing.T, concertID int64, left int) {
+	concert, err := f.concerts.GetByID(context.Background(), concertID)
+	require.NoError(t, err)
+	require.NoError(t, f.concerts.UpdateTicketCount(context.Background(), concertID, concert.Version, concert.AvailableTickets-left))
+}
+
+func assertInvalidInput(t *testing.T, err error, msgAndArgs ...interface{}) {
+	kind, _ := pkgErr.Lookup(err)
+	assert.Equal(t, pkgErr.CodeInvalidInput, kind.Code, msgAndArgs...)
+}
+
+func TestCheckoutBooksEveryItemAsOneOrder(t *testing.T) {
+	f := newCartFixture()
+	first := f.createConcert(t, 10, 25)
+	second := f.createConcert(t, 10, 40.5)
+
+	f.add(t, "user-1", first.ID, 2)
+	f.add(t, "user-1", second.ID, 1)
+	f.add(t, "user-1", first.ID, 3) // replaces the first item
+
+	cart, err := f.carts.GetCart(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, 3, cart.Items[0].TicketCount)
+	assert.Equal(t, 25.0, cart.Items[0].UnitPrice)
+
+	order, err := f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: "user-1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, order.Reference)
+	assert.Equal(t, model.CheckoutAllOrNothing, order.Mode)
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, 115.5, order.Total)
+	assert.Len(t, order.Bookings, 2)
+	assert.Empty(t, order.Failed)
+
+	assert.Equal(t, 7, f.available(t, first.ID))
+	assert.Equal(t, 9, f.available(t, second.ID))
+
+	cart, err = f.carts.GetCart(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items, "booked items leave the cart")
+
+	_, err = f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: "user-1"})
+	assertInvalidInput(t, err, "an empty cart can't be checked out")
+}
+
+func TestAllOrNothingCheckoutBooksNothingIfAnItemFails(t *testing.T) {
+	f := newCartFixture()
+	first := f.createConcert(t, 10, 25)
+	second := f.createConcert(t, 10, 25)
+
+	f.add(t, "user-1", first.ID, 2)
+	f.add(t, "user-1", second.ID, 5)
+	f.drain(t, second.ID, 3)
+
+	_, err := f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: "user-1"})
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+	assert.Contains(t, err.Error(), "concert "+strconv.FormatInt(second.ID, 10))
+
+	assert.Equal(t, 10, f.available(t, first.ID))
+	assert.Equal(t, 3, f.available(t, second.ID))
+
+	cart, err := f.carts.GetCart(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 2, "the cart is kept")
+}
+
+func TestBestEffortCheckoutLeavesFailedItemsInTheCart(t *testing.T) {
+	f := newCartFixture()
+	first := f.createConcert(t, 10, 25)
+	second := f.createConcert(t, 10, 25)
+
+	f.add(t, "user-1", first.ID, 2)
+	f.add(t, "user-1", second.ID, 5)
+	f.drain(t, second.ID, 3)
+
+	order, err := f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: "user-1", Mode: model.CheckoutBestEffort})
+	require.NoError(t, err)
+	require.Len(t, order.Bookings, 1)
+	assert.Equal(t, first.ID, order.Bookings[0].ConcertID)
+	assert.Equal(t, 50.0, order.Total)
+	require.Len(t, order.Failed, 1)
+	assert.Equal(t, second.ID, order.Failed[0].ConcertID)
+	assert.Equal(t, pkgErr.CodeInsufficientTickets, order.Failed[0].Code)
+
+	cart, err := f.carts.GetCart(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 1)
+	assert.Equal(t, second.ID, cart.Items[0].ConcertID)
+
+	// Nothing to book is an error rather than an empty order
+	_, err = f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: "user-1", Mode: model.CheckoutBestEffort})
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+}
+
+func TestCartRejectsItemsThatCantBeBookedTogether(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+
+	closed := f.createConcert(t, 10, 25)
+	closed.BookingEndTime = time.Now().Add(-time.Minute)
+	require.NoError(t, f.concerts.Update(ctx, closed))
+	_, err := f.carts.AddItem(ctx, closed.ID, &model.CartItemRequest{UserID: "user-1", TicketCount: 1})
+	assert.ErrorIs(t, err, pkgErr.ErrBookingClosed)
+
+	gated := f.createConcert(t, 10, 25)
+	gated.RequiresBookingToken = true
+	require.NoError(t, f.concerts.Update(ctx, gated))
+	_, err = f.carts.AddItem(ctx, gated.ID, &model.CartItemRequest{UserID: "user-1", TicketCount: 1})
+	assert.ErrorIs(t, err, pkgErr.ErrBookingTokenRequired)
+
+	dollars := f.createConcert(t, 10, 25)
+	euros := f.createConcert(t, 10, 25)
+	euros.Currency = "EUR"
+	require.NoError(t, f.concerts.Update(ctx, euros))
+	f.add(t, "user-1", dollars.ID, 1)
+	f.add(t, "user-1", euros.ID, 1)
+	_, err = f.carts.Checkout(ctx, &model.CheckoutRequest{UserID: "user-1", Mode: model.CheckoutBestEffort})
+	assertInvalidInput(t, err, "an order is paid in one currency")
+
+	_, err = f.carts.Checkout(ctx, &model.CheckoutRequest{UserID: "user-1", Mode: "some"})
+	assertInvalidInput(t, err)
+
+	assert.ErrorIs(t, f.carts.RemoveItem(ctx, "user-1", closed.ID), pkgErr.ErrNotFound)
+	assert.NoError(t, f.carts.RemoveItem(ctx, "user-1", euros.ID))
+}
+
+func TestConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	f := newCartFixture()
+	first := f.createConcert(t, 20, 25)
+	second := f.createConcert(t, 20, 25)
+
+	const users = 30
+	for i := 0; i < users; i++ {
+		user := "user-" + strconv.Itoa(i)
+		f.add(t, user, first.ID, 1)
+		f.add(t, user, second.ID, 1)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		orders int
+	)
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			_, err := f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: user})
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			orders++
+			mu.Unlock()
+		}("user-" + strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, orders, 20)
+	assert.Equal(t, 20-orders, f.available(t, first.ID))
+	assert.Equal(t, 20-orders, f.available(t, second.ID), "orders book both concerts or neither")
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/db"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func openGuardedSQLite(t *testing.T, threshold int) *sqlx.DB {
+	t.Helper()
+	database, err := db.NewSQLiteDB(config.Database{
+		Driver:         config.DriverSQLite,
+		Path:           ":memory:",
+		Timeouts:       config.DatabaseTimeouts{Query: 50 * time.Millisecond},
+		CircuitBreaker: config.CircuitBreaker{FailureThreshold: threshold, OpenDuration: time.Minute},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestCircuitBreakerFailsFastAfterTimeouts(t *testing.T) {
+	defer clock.Process().Reset()
+	database := openGuardedSQLite(t, 2)
+	ctx := context.Background()
+
+	var count int
+	for i := 0; i < 2; i++ {
+		assert.Error(t, database.GetContext(ctx, &count, slowQuery))
+	}
+
+	started := time.Now()
+	err := database.GetContext(ctx, &count, `SELECT 1`)
+	assert.ErrorIs(t, err, pkgErr.ErrServiceUnavailable)
+	assert.Less(t, time.Since(started), 50*time.Millisecond, "an open circuit doesn't wait for the database")
+	kind, _ := pkgErr.Lookup(fmt.Errorf("failed to get concert: %w", err))
+	assert.Equal(t, http.StatusServiceUnavailable, kind.HTTPStatus)
+	assert.Equal(t, time.Minute, kind.RetryAfter, "clients wait until the circuit may close")
+	assert.Error(t, database.PingContext(ctx), "the health checks see the database down")
+
+	// After the open duration one query tries the database and closes the circuit
+	clock.Process().Advance(time.Minute)
+	require.NoError(t, database.GetContext(ctx, &count, `SELECT 1`))
+	require.NoError(t, database.GetContext(ctx, &count, `SELECT 2`))
+	assert.Equal(t, 2, count)
+}
+
+func TestCircuitBreakerIgnoresRejectedStatements(t *testing.T) {
+	database := openGuardedSQLite(t, 1)
+	ctx := context.Background()
+
+	var count int
+	assert.Error(t, database.GetContext(ctx, &count, `SELECT * FROM missing_table`))
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, database.GetContext(canceled, &count, slowQuery))
+
+	assert.NoError(t, database.GetContext(ctx, &count, `SELECT 1`),
+		"errors of the statement and of the caller don't open the circuit")
+}
+
+func TestOpenCircuitIsUnavailableOverEveryTransport(t *testing.T) {
+	err := fmt.Errorf("failed to book tickets: %w", pkgErr.NewUnavailableError("database", 7*time.Second))
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/bookings", nil)
+	problem.Error(c, err, "Failed to book tickets")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "7", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, "SERVICE_UNAVAILABLE", decodeProblem(t, recorder).Code)
+
+	st, ok := status.FromError(grpcapi.ToStatus(err))
+	require.True(t, ok)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	require.NotNil(t, retry)
+	assert.Equal(t, 7*time.Second, retry.RetryDelay.AsDuration())
+}
@@ -0,0 +1,266 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/client"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var fastRetries = client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+type clientFixture struct {
+	concertRepo *mocks.MockConcertRepository
+	bookingRepo *mocks.MockBookingRepository
+	concerts    []*model.Concert
+}
+
+func newClientFixture(t *testing.T) *clientFixture {
+	concertRepo := mocks.NewMockConcertRepository()
+	f := &clientFixture{concertRepo: concertRepo, bookingRepo: mocks.NewMockBookingRepository().WithConcerts(concertRepo)}
+	for i := 0; i < 5; i++ {
+		f.concerts = append(f.concerts, createStreamTestConcert(t, f.concertRepo, 10))
+	}
+	return f
+}
+
+func (f *clientFixture) bookingService() service.BookingService {
+	return service.NewBookingService(f.bookingRepo, f.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+}
+
+func (f *clientFixture) storedBookings(t *testing.T) []*model.Booking {
+	bookings, err := f.bookingRepo.GetAllByUserID(context.Background(), "user-1")
+	require.NoError(t, err)
+	return bookings
+}
+
+// lossyHandler serves requests but answers the first of every POST with
+// 503, as if the response was lost on the way back, and records the
+// idempotency keys it sees
+type lossyHandler struct {
+	next  http.Handler
+	mutex sync.Mutex
+	seen  map[string]bool
+	keys  []string
+}
+
+func (h *lossyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	h.mutex.Lock()
+	h.keys = append(h.keys, r.Header.Get("Idempotency-Key"))
+	first := !h.seen[r.URL.Path]
+	h.seen[r.URL.Path] = true
+	h.mutex.Unlock()
+
+	if !first {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	h.next.ServeHTTP(httptest.NewRecorder(), r)
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func TestRESTClientRetriesWithoutBookingTwice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newClientFixture(t)
+	router := gin.New()
+	handler.NewBookingHandler(f.bookingService()).RegisterRoutes(router.Group("/api/v1"))
+	lossy := &lossyHandler{next: router, seen: make(map[string]bool)}
+	server := httptest.NewServer(lossy)
+	defer server.Close()
+
+	bookings := client.NewREST(server.URL, nil, client.Options{Retry: fastRetries}).Bookings()
+	ctx := context.Background()
+
+	booking, err := bookings.Book(ctx, client.BookRequest{ConcertID: f.concerts[0].ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, booking.TicketCount)
+	assert.Len(t, f.storedBookings(t), 1, "the retry returns the booking the lost attempt made")
+	require.Len(t, lossy.keys, 2)
+	assert.NotEmpty(t, lossy.keys[0])
+	assert.Equal(t, lossy.keys[0], lossy.keys[1])
+
+	// A key can't be reused for another booking
+	_, err = bookings.Book(ctx, client.BookRequest{ConcertID: f.concerts[1].ID, UserID: "user-1", TicketCount: 2, IdempotencyKey: lossy.keys[0]})
+	assert.Equal(t, problem.CodeInvalidInput, client.ErrorCode(err))
+
+	// Errors that another attempt can't fix aren't retried
+	attempts := len(lossy.keys)
+	soldOut := createStreamTestConcert(t, f.concertRepo, 1)
+	_, err = bookings.Book(ctx, client.BookRequest{ConcertID: soldOut.ID, UserID: "user-1", TicketCount: 2})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, problem.CodeInsufficientTickets, apiErr.Code)
+	assert.Equal(t, http.StatusBadRequest, apiErr.HTTPStatus)
+	assert.Equal(t, attempts+1, len(lossy.keys))
+
+}
+
+func TestRESTClientCancelsOnce(t *testing.T) {
+	// Cancelling isn't idempotent on the server: the retry of a lost
+	// cancellation finds the booking cancelled
+	cancels := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/bookings/BK-1/cancel", r.URL.Path)
+		cancels++
+		if cancels == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", problem.ContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"code":"BOOKING_ALREADY_CANCELLED","detail":"Booking is already cancelled"}`))
+	}))
+	defer server.Close()
+
+	bookings := client.NewREST(server.URL, nil, client.Options{Retry: fastRetries}).Bookings()
+	require.NoError(t, bookings.Cancel(context.Background(), "BK-1", "user-1"))
+	assert.Equal(t, 2, cancels)
+
+	err := bookings.Cancel(context.Background(), "BK-1", "user-1")
+	assert.Equal(t, problem.CodeBookingAlreadyCancelled, client.ErrorCode(err), "a first attempt reports it")
+	assert.EqualError(t, err, "BOOKING_ALREADY_CANCELLED: Booking is already cancelled")
+}
+
+func TestRESTClientPagesThroughListings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newClientFixture(t)
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(f.concertRepo, nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+	handler.NewBookingHandler(f.bookingService()).RegisterRoutes(router.Group("/api/v1"))
+
+	var mutex sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mutex.Unlock()
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := client.NewREST(server.URL, nil, client.Options{PageSize: 2, Retry: fastRetries})
+	var ids []int64
+	for concert, err := range c.Concerts().List(context.Background(), client.ListConcertsOptions{Artist: "Artist"}) {
+		require.NoError(t, err)
+		ids = append(ids, concert.ID)
+	}
+	assert.Len(t, ids, 5)
+	require.Len(t, queries, 3, "five concerts take three pages of two")
+	assert.Contains(t, queries[2], "artist=Artist", "filters are sent with every page")
+	assert.Contains(t, queries[2], "cursor=")
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Bookings().Book(context.Background(), client.BookRequest{ConcertID: f.concerts[i].ID, UserID: "user-1", TicketCount: 1})
+		require.NoError(t, err)
+	}
+	count := 0
+	for booking, err := range c.Bookings().ListByUser(context.Background(), "user-1") {
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", booking.UserID)
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count, "breaking out of the loop stops the iteration")
+
+	for _, err := range c.Concerts().List(context.Background(), client.ListConcertsOptions{Sort: "unknown"}) {
+		assert.Equal(t, problem.CodeInvalidInput, client.ErrorCode(err))
+	}
+}
+
+func TestGRPCClientRetriesWithoutBookingTwice(t *testing.T) {
+	f := newClientFixture(t)
+	authorizer := newTestAuthorizer()
+	api := grpcapi.NewServer(service.NewConcertService(f.concertRepo, nil, model.BookingLimits{}, nil), f.bookingService(), nil, nil, nil,
+		authorizer, false, logger.NewLogger("error"), 0)
+
+	var mutex sync.Mutex
+	var keys []string
+	lossy := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if book, ok := req.(*pb.BookTicketsRequest); ok {
+			mutex.Lock()
+			defer mutex.Unlock()
+			keys = append(keys, book.IdempotencyKey)
+			if len(keys) == 1 {
+				return nil, status.Error(codes.Unavailable, "connection reset")
+			}
+		}
+		return resp, err
+	}
+	toStatus := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, grpcapi.ToStatus(err)
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(lossy, toStatus, authorizer.UnaryInterceptor()))
+	pb.RegisterConcertServiceServer(server, api)
+	pb.RegisterBookingServiceServer(server, api)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := client.NewGRPC(conn, client.Options{Token: "web-token", PageSize: 2, Retry: fastRetries})
+
+	booking, err := c.Bookings().Book(ctx, client.BookRequest{ConcertID: f.concerts[0].ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, f.concerts[0].ID, booking.ConcertID)
+	assert.Len(t, f.storedBookings(t), 1)
+	require.Len(t, keys, 2)
+	assert.Equal(t, keys[0], keys[1])
+
+	fetched, err := c.Bookings().Get(ctx, booking.Reference)
+	require.NoError(t, err)
+	assert.Equal(t, booking.Reference, fetched.Reference)
+
+	var ids []int64
+	for concert, err := range c.Concerts().List(ctx, client.ListConcertsOptions{}) {
+		require.NoError(t, err)
+		ids = append(ids, concert.ID)
+	}
+	assert.Len(t, ids, 5)
+
+	_, err = c.Concerts().Get(ctx, 999)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, codes.NotFound, apiErr.GRPCCode)
+	assert.Equal(t, problem.CodeNotFound, apiErr.Code)
+
+	_, err = client.NewGRPC(conn, client.Options{Retry: fastRetries}).Bookings().Get(ctx, booking.Reference)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, codes.Unauthenticated, apiErr.GRPCCode, "bookings need a token")
+}
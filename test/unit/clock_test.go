@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetClock(t *testing.T) {
+	c := &clock.OffsetClock{}
+	assert.WithinDuration(t, time.Now(), c.Now(), time.Second)
+
+	c.Advance(2 * time.Hour)
+	assert.Equal(t, 2*time.Hour, c.Offset())
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), c.Now(), time.Second)
+
+	target := time.Now().Add(-24 * time.Hour)
+	c.Set(target)
+	assert.WithinDuration(t, target, c.Now(), time.Second)
+
+	c.Reset()
+	assert.Zero(t, c.Offset())
+}
+
+func TestProcessClockDrivesBookingWindow(t *testing.T) {
+	defer clock.Process().Reset()
+
+	concert := &model.Concert{
+		AvailableTickets: 10,
+		BookingStartTime: time.Now().Add(1 * time.Hour),
+		BookingEndTime:   time.Now().Add(2 * time.Hour),
+	}
+	assert.False(t, concert.IsBookingOpen())
+
+	clock.Process().Advance(90 * time.Minute)
+	assert.True(t, concert.IsBookingOpen())
+	assert.False(t, concert.IsSaleEnded())
+
+	clock.Process().Advance(time.Hour)
+	assert.False(t, concert.IsBookingOpen())
+	assert.True(t, concert.IsSaleEnded())
+}
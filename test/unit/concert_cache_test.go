@@ -0,0 +1,233 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	redisrepo "concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConcertCacheTTL = 5 * time.Second
+
+type concertCacheFixture struct {
+	redis    *miniredis.Miniredis
+	cache    repository.ConcertCache
+	repo     *countingConcertRepository
+	concerts service.ConcertService
+	bookings service.BookingService
+}
+
+func newConcertCacheFixture(t *testing.T) *concertCacheFixture {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cache := redisrepo.NewConcertCache(client, testConcertCacheTTL)
+	bus := events.NewBus()
+	service.SubscribeConcertCache(bus, cache)
+	repo := &countingConcertRepository{MockConcertRepository: mocks.NewMockConcertRepository()}
+	return &concertCacheFixture{
+		redis:    mr,
+		cache:    cache,
+		repo:     repo,
+		concerts: service.NewConcertService(repo, nil, model.BookingLimits{}, cache),
+		bookings: service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(repo.MockConcertRepository), repo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, bus, model.BookingLimits{}, nil, service.BookingOptimistic, nil),
+	}
+}
+
+func (f *concertCacheFixture) createConcert(t *testing.T, visibility string) *model.Concert {
+	concert, err := f.repo.Create(context.Background(), &model.Concert{
+		Name:             "Cached Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            40,
+		Currency:         "USD",
+		Visibility:       visibility,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func (f *concertCacheFixture) cached(concertID int64) bool {
+	return f.redis.Exists("concert_cache:" + strconv.FormatInt(concertID, 10))
+}
+
+func TestConcertReadsGoThroughTheCache(t *testing.T) {
+	f := newConcertCacheFixture(t)
+	concert := f.createConcert(t, model.VisibilityPublic)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		got, err := f.concerts.GetByID(ctx, concert.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Cached Night", got.Name)
+	}
+	assert.EqualValues(t, 1, f.repo.byID.Load(), "only the first read reaches the database")
+	assert.True(t, f.cached(concert.ID))
+
+	// Changes made around the services are only seen once the entry expires
+	stored, err := f.repo.MockConcertRepository.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	stored.Name = "Renamed Elsewhere"
+	require.NoError(t, f.repo.Update(ctx, stored))
+
+	got, err := f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Cached Night", got.Name)
+
+	f.redis.FastForward(testConcertCacheTTL)
+	got, err = f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed Elsewhere", got.Name)
+	assert.EqualValues(t, 2, f.repo.byID.Load())
+}
+
+func TestConcertCacheIsInvalidatedByChanges(t *testing.T) {
+	f := newConcertCacheFixture(t)
+	concert := f.createConcert(t, model.VisibilityPublic)
+	ctx := context.Background()
+
+	_, err := f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	require.True(t, f.cached(concert.ID))
+
+	_, _, err = f.concerts.PatchConcert(ctx, "organizer", concert.ID, concert.Version,
+		&model.ConcertPatch{Fields: []string{"name"}, Values: model.Concert{Name: "Patched Night"}})
+	require.NoError(t, err)
+	assert.False(t, f.cached(concert.ID), "patches drop the concert from the cache")
+	got, err := f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Patched Night", got.Name)
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.False(t, f.cached(concert.ID), "bookings drop the concert from the cache")
+
+	_, err = f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	require.NoError(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"))
+	assert.False(t, f.cached(concert.ID), "cancellations drop the concert from the cache")
+
+	results := f.bookings.BookTicketsBatch(ctx, []*model.BookingRequest{{ConcertID: concert.ID, UserID: "agency", TicketCount: 1}})
+	require.NoError(t, results[0].Err)
+	_, err = f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	results = f.bookings.BookTicketsBatch(ctx, []*model.BookingRequest{{ConcertID: concert.ID, UserID: "agency", TicketCount: 1}})
+	require.NoError(t, results[0].Err)
+	assert.False(t, f.cached(concert.ID), "batch bookings drop the concert from the cache")
+}
+
+func TestPrivateConcertsAreNotCached(t *testing.T) {
+	f := newConcertCacheFixture(t)
+	concert := f.createConcert(t, model.VisibilityPrivate)
+
+	got, err := f.concerts.GetByID(service.WithPrivateAccess(context.Background()), concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, concert.ID, got.ID)
+	assert.False(t, f.cached(concert.ID), "invite checks need the token hash the cache doesn't keep")
+
+	_, err = f.concerts.GetByID(context.Background(), concert.ID)
+	assert.Error(t, err, "private concerts stay hidden without an invite")
+}
+
+func TestConcertReadsSurviveLosingTheCache(t *testing.T) {
+	f := newConcertCacheFixture(t)
+	concert := f.createConcert(t, model.VisibilityPublic)
+	ctx := context.Background()
+	f.redis.Close()
+
+	got, err := f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, concert.ID, got.ID)
+
+	_, err = f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1})
+	assert.NoError(t, err, "bookings don't fail when the cache can't be invalidated")
+}
+
+func TestConcertCacheTTLChangesAtRuntime(t *testing.T) {
+	f := newConcertCacheFixture(t)
+	concert := f.createConcert(t, model.VisibilityPublic)
+	ctx := context.Background()
+
+	_, err := f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	require.True(t, f.cached(concert.ID))
+
+	f.cache.SetTTL(0)
+	for i := 0; i < 2; i++ {
+		_, err = f.concerts.GetByID(ctx, concert.ID)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, f.repo.byID.Load(), "a TTL of zero stops reading from the cache")
+
+	_, _, err = f.concerts.PatchConcert(ctx, "organizer", concert.ID, concert.Version,
+		&model.ConcertPatch{Fields: []string{"name"}, Values: model.Concert{Name: "Patched Night"}})
+	require.NoError(t, err)
+	assert.False(t, f.cached(concert.ID), "entries cached before are still invalidated")
+
+	f.cache.SetTTL(time.Minute)
+	_, err = f.concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, f.redis.TTL("concert_cache:"+strconv.FormatInt(concert.ID, 10)))
+}
+
+func TestCartCheckoutsInvalidateTheCacheThroughTheBus(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cache := redisrepo.NewConcertCache(client, testConcertCacheTTL)
+	bus := events.NewBus()
+	service.SubscribeConcertCache(bus, cache)
+
+	concertRepo := mocks.NewMockConcertRepository()
+	carts := mocks.NewMockCartRepository(concertRepo, mocks.NewMockBookingRepository().WithConcerts(concertRepo))
+	cartService := service.NewCartService(carts, concertRepo, service.RetryPolicy{MaxRetries: 3}, bus, model.BookingLimits{})
+	concerts := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, cache)
+	ctx := context.Background()
+
+	concert, err := concertRepo.Create(ctx, &model.Concert{
+		Name:             "Cached Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            40,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	require.True(t, mr.Exists("concert_cache:"+strconv.FormatInt(concert.ID, 10)))
+
+	_, err = cartService.AddItem(ctx, concert.ID, &model.CartItemRequest{UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	_, err = cartService.Checkout(ctx, &model.CheckoutRequest{UserID: "user-1"})
+	require.NoError(t, err)
+	assert.False(t, mr.Exists("concert_cache:"+strconv.FormatInt(concert.ID, 10)),
+		"the cart service doesn't know the cache, the subscriber invalidates it")
+
+	got, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 98, got.AvailableTickets)
+}
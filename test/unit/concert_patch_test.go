@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type patchFixture struct {
+	concertRepo *mocks.MockConcertRepository
+	auditRepo   *mocks.MockAuditRepository
+	service     service.ConcertService
+	concert     *model.Concert
+}
+
+func newPatchFixture(t *testing.T) *patchFixture {
+	concertRepo := mocks.NewMockConcertRepository()
+	doorPrice := 60.0
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:                 "Concert",
+		Artist:               "Artist",
+		Venue:                "Venue",
+		ConcertDate:          time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second),
+		TotalTickets:         100,
+		AvailableTickets:     80,
+		Price:                50,
+		Currency:             "USD",
+		BookingStartTime:     time.Now().Add(time.Hour).Truncate(time.Second),
+		BookingEndTime:       time.Now().Add(24 * time.Hour).Truncate(time.Second),
+		ArtistAliases:        model.StringList{"The Artist"},
+		DoorPrice:            &doorPrice,
+		DoorPriceLeadMinutes: 120,
+		Visibility:           model.VisibilityPublic,
+	})
+	require.NoError(t, err)
+
+	auditRepo := mocks.NewMockAuditRepository()
+	return &patchFixture{
+		concertRepo: concertRepo,
+		auditRepo:   auditRepo,
+		service:     service.NewConcertService(concertRepo, service.NewAuditService(auditRepo), model.BookingLimits{}, nil),
+		concert:     concert,
+	}
+}
+
+func (f *patchFixture) auditChanges(t *testing.T) []map[string]interface{} {
+	entries, _, err := f.auditRepo.List(context.Background(), model.AuditFilter{Action: model.AuditActionConcertUpdated}, query.Page{Number: 1, Size: 10})
+	require.NoError(t, err)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var details struct {
+		Changes []map[string]interface{} `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal(entries[0].Details, &details))
+	return details.Changes
+}
+
+func patchConcert(router *gin.Engine, id int64, body, ifMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/concerts/"+strconv.FormatInt(id, 10), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestPatchConcertUpdatesOnlyTheGivenFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newPatchFixture(t)
+	router := gin.New()
+	handler.NewConcertHandler(f.service).RegisterRoutes(router.Group("/api/v1"))
+
+	recorder := patchConcert(router, f.concert.ID, `{"price": 55, "door_price": null}`, "")
+	assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+
+	recorder = patchConcert(router, f.concert.ID, `{"price": 55, "door_price": null}`, `"1"`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+
+	updated, err := f.concertRepo.GetByID(context.Background(), f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 55.0, updated.Price)
+	assert.Nil(t, updated.DoorPrice, "null clears a field")
+	assert.Equal(t, "Concert", updated.Name)
+	assert.True(t, f.concert.BookingEndTime.Equal(updated.BookingEndTime))
+	assert.Equal(t, model.StringList{"The Artist"}, updated.ArtistAliases)
+	assert.Equal(t, 80, updated.AvailableTickets)
+
+	assert.Equal(t, []map[string]interface{}{
+		{"field": "price", "from": 50.0, "to": 55.0},
+		{"field": "door_price", "from": 60.0, "to": nil},
+	}, f.auditChanges(t))
+
+	for body, code := range map[string]int{
+		`{"available_tickets": 500}`: http.StatusBadRequest,
+		`{}`:                         http.StatusBadRequest,
+		`["price"]`:                  http.StatusBadRequest,
+		`{"total_tickets": 10}`:      http.StatusBadRequest,
+	} {
+		recorder = patchConcert(router, f.concert.ID, body, `"2"`)
+		assert.Equal(t, code, recorder.Code, body)
+	}
+
+	recorder = patchConcert(router, f.concert.ID, `{"total_tickets": 120}`, `"2"`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	updated, err = f.concertRepo.GetByID(context.Background(), f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, updated.AvailableTickets, "sold tickets stay sold")
+}
+
+func TestPatchConcertWithoutChangesIsNotAudited(t *testing.T) {
+	f := newPatchFixture(t)
+
+	_, changes, err := f.service.PatchConcert(context.Background(), "ops", f.concert.ID, 1, &model.ConcertPatch{
+		Fields: []string{"name"},
+		Values: model.Concert{Name: "Concert"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Nil(t, f.auditChanges(t))
+}
+
+func TestUpdateConcertRPCKeepsFieldsOutsideTheMask(t *testing.T) {
+	f := newPatchFixture(t)
+	authorizer := newTestAuthorizer()
+	server := grpcapi.NewServer(f.service, nil, nil, nil, nil, authorizer, false, logger.NewLogger("error"), 0)
+
+	update := func(req *pb.UpdateConcertRequest) (*pb.Concert, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer organizer-token"))
+		resp, err := authorizer.UnaryInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: pb.ConcertService_UpdateConcert_FullMethodName},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.UpdateConcert(ctx, req.(*pb.UpdateConcertRequest))
+			})
+		if err != nil {
+			return nil, err
+		}
+		return resp.(*pb.Concert), nil
+	}
+
+	// Without a mask, fields left at zero are kept
+	concert, err := update(&pb.UpdateConcertRequest{Id: f.concert.ID, Version: 1, Name: "Renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", concert.Name)
+	assert.Equal(t, "Venue", concert.Venue)
+	assert.Equal(t, int32(100), concert.TotalTickets)
+	assert.Equal(t, 50.0, concert.Price)
+	require.NotNil(t, concert.DoorPrice)
+	assert.Equal(t, f.concert.ConcertDate.Unix(), concert.ConcertDate.AsTime().Unix())
+
+	// With a mask, listed fields are set even to zero and the rest is kept
+	concert, err = update(&pb.UpdateConcertRequest{
+		Id: f.concert.ID, Version: 2, Name: "Ignored",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"artist_aliases", "door_price"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", concert.Name)
+	assert.Empty(t, concert.ArtistAliases)
+	assert.Nil(t, concert.DoorPrice)
+
+	entries, _, err := f.auditRepo.List(context.Background(), model.AuditFilter{Action: model.AuditActionConcertUpdated}, query.Page{Number: 1, Size: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "backoffice", entries[0].Actor)
+	assert.JSONEq(t, `{"version": 3, "changes": [
+		{"field": "artist_aliases", "from": ["The Artist"], "to": []},
+		{"field": "door_price", "from": 60, "to": null}
+	]}`, string(entries[0].Details))
+
+	for _, path := range []string{"available_tickets", "version", "secret"} {
+		_, err = update(&pb.UpdateConcertRequest{Id: f.concert.ID, Version: 3, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{path}}})
+		assert.Error(t, err, path)
+	}
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConditionalTestRouter(t *testing.T) (*gin.Engine, *mocks.MockConcertRepository, *model.Concert) {
+	gin.SetMode(gin.TestMode)
+	concertRepo := mocks.NewMockConcertRepository()
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+		CreatedAt:        updatedAt,
+		UpdatedAt:        updatedAt,
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+	return router, concertRepo, concert
+}
+
+func conditionalGet(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestListingsAnswerIfModifiedSince(t *testing.T) {
+	router, concertRepo, concert := newConditionalTestRouter(t)
+
+	recorder := conditionalGet(router, "/api/v1/concerts", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	lastModified := recorder.Header().Get("Last-Modified")
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", lastModified)
+
+	recorder = conditionalGet(router, "/api/v1/concerts?page=2", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusNotModified, recorder.Code, "every page shares the listing date")
+	assert.Empty(t, recorder.Body.String())
+	assert.Equal(t, lastModified, recorder.Header().Get("Last-Modified"))
+
+	recorder = conditionalGet(router, "/api/v1/concerts", map[string]string{"If-Modified-Since": "Sat, 28 Feb 2026 12:00:00 GMT"})
+	assert.Equal(t, http.StatusOK, recorder.Code, "older copies are replaced")
+
+	recorder = conditionalGet(router, "/api/v1/concerts", map[string]string{"If-Modified-Since": "yesterday"})
+	assert.Equal(t, http.StatusOK, recorder.Code, "invalid dates are ignored")
+
+	concert.Price = 30
+	require.NoError(t, concertRepo.Update(context.Background(), concert))
+
+	recorder = conditionalGet(router, "/api/v1/concerts", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, recorder.Code, "an update changes the listing")
+	assert.Empty(t, recorder.Header().Get("Last-Modified"), "no date is given within the second of a change")
+}
+
+func TestGetConcertAnswersConditionalRequests(t *testing.T) {
+	router, _, concert := newConditionalTestRouter(t)
+	path := "/api/v1/concerts/" + strconv.FormatInt(concert.ID, 10)
+
+	recorder := conditionalGet(router, path, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	etag, lastModified := recorder.Header().Get("ETag"), recorder.Header().Get("Last-Modified")
+	require.NotEmpty(t, etag)
+	require.NotEmpty(t, lastModified)
+
+	recorder = conditionalGet(router, path, map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = conditionalGet(router, path, map[string]string{"If-None-Match": `"9", W/` + etag})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	recorder = conditionalGet(router, path, map[string]string{"If-None-Match": `"9"`, "If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, recorder.Code, "If-None-Match takes precedence")
+}
+
+func TestHeadAnswersLikeGetWithoutBody(t *testing.T) {
+	router, _, concert := newConditionalTestRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for _, path := range []string{"/api/v1/concerts", "/api/v1/concerts/" + strconv.FormatInt(concert.ID, 10)} {
+		resp, err := http.Head(server.URL + path)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Empty(t, body, path)
+		assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", resp.Header.Get("Last-Modified"), path)
+	}
+
+	resp, err := http.Head(server.URL + "/api/v1/concerts/999")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,601 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validCORS() config.CORS {
+	return config.CORS{
+		AllowOrigins: []string{"https://tickets.example.com"},
+		AllowMethods: []string{"GET", "POST"},
+		MaxAge:       time.Hour,
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	cors := validCORS()
+	assert.NoError(t, cors.Validate())
+
+	cors.AllowCredentials = true
+	assert.NoError(t, cors.Validate())
+
+	cors.AllowOrigins = []string{"*"}
+	assert.Error(t, cors.Validate(), "wildcard origin with credentials must be rejected")
+
+	cors.AllowCredentials = false
+	assert.NoError(t, cors.Validate())
+}
+
+func TestCORSValidateRejectsInvalidValues(t *testing.T) {
+	tests := map[string]func(c *config.CORS){
+		"no origins":       func(c *config.CORS) { c.AllowOrigins = nil },
+		"origin with path": func(c *config.CORS) { c.AllowOrigins = []string{"https://example.com/app"} },
+		"missing scheme":   func(c *config.CORS) { c.AllowOrigins = []string{"example.com"} },
+		"no methods":       func(c *config.CORS) { c.AllowMethods = nil },
+		"unknown method":   func(c *config.CORS) { c.AllowMethods = []string{"FETCH"} },
+		"negative max age": func(c *config.CORS) { c.MaxAge = -time.Second },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			cors := validCORS()
+			mutate(&cors)
+			assert.Error(t, cors.Validate())
+		})
+	}
+}
+
+func TestAccountingValidate(t *testing.T) {
+	accounting := config.Accounting{Interval: time.Minute}
+	assert.NoError(t, accounting.Validate(), "no adapters configured")
+
+	accounting.QuickBooks = config.QuickBooks{AccessToken: "token", RealmID: "123"}
+	assert.Error(t, accounting.Validate(), "QuickBooks without accounts must be rejected")
+
+	accounting.QuickBooks.CashAccountID = "35"
+	accounting.QuickBooks.RevenueAccountID = "79"
+	assert.NoError(t, accounting.Validate())
+
+	accounting.Xero = config.Xero{AccessToken: "token", CashAccountCode: "090", RevenueAccountCode: "200"}
+	assert.Error(t, accounting.Validate(), "Xero without tenant must be rejected")
+
+	accounting.Xero.TenantID = "tenant"
+	assert.NoError(t, accounting.Validate())
+}
+
+func TestWebhooksValidate(t *testing.T) {
+	webhooks := config.Webhooks{}
+	assert.NoError(t, webhooks.Validate(), "disabled webhooks need no settings")
+
+	webhooks = config.Webhooks{Enabled: true, Interval: time.Second, BatchSize: 100, Concurrency: 8, Timeout: 10 * time.Second,
+		MaxAttempts: 15, BackoffBase: 30 * time.Second, BackoffMax: 6 * time.Hour, Retention: 720 * time.Hour}
+	assert.NoError(t, webhooks.Validate())
+
+	webhooks.BackoffMax = time.Second
+	assert.Error(t, webhooks.Validate(), "the maximum backoff can't be below the base")
+
+	webhooks.BackoffMax = 6 * time.Hour
+	webhooks.MaxAttempts = 0
+	assert.Error(t, webhooks.Validate())
+}
+
+func TestPaymentsValidate(t *testing.T) {
+	payments := config.Payments{Provider: config.PaymentProviderNone}
+	assert.NoError(t, payments.Validate(), "without a provider payments need no settings")
+	assert.False(t, payments.Enabled())
+
+	payments = config.Payments{Provider: config.PaymentProviderStripe, HoldTTL: 15 * time.Minute, ExpiryInterval: 30 * time.Second,
+		Stripe: config.Stripe{SecretKey: "sk_test", WebhookSecret: "whsec_test", BaseURL: "https://api.stripe.com",
+			Timeout: 10 * time.Second, WebhookTolerance: 5 * time.Minute}}
+	assert.NoError(t, payments.Validate())
+	assert.True(t, payments.Enabled())
+
+	payments.Stripe.WebhookSecret = ""
+	assert.Error(t, payments.Validate(), "webhooks can't be verified without their secret")
+
+	payments.Stripe.WebhookSecret = "whsec_test"
+	payments.HoldTTL = 0
+	assert.Error(t, payments.Validate())
+
+	payments.HoldTTL = 15 * time.Minute
+	payments.Provider = "paypal"
+	assert.Error(t, payments.Validate())
+
+	payments.Provider = config.PaymentProviderFake
+	payments.Fake = config.FakePayments{Outcome: config.FakeOutcomeAuthorized}
+	assert.NoError(t, payments.Validate())
+
+	payments.Fake.Outcome = config.FakeOutcomePending
+	assert.Error(t, payments.Validate(), "pending payments are settled by signed notifications")
+	payments.Fake.WebhookSecret = "fake-secret"
+	assert.NoError(t, payments.Validate())
+
+	payments.Reconciliation = config.PaymentReconciliation{Enabled: true, Interval: 10 * time.Minute, Lookback: 168 * time.Hour,
+		Grace: 15 * time.Minute, BatchSize: 100}
+	assert.NoError(t, payments.Validate())
+	payments.Reconciliation.BatchSize = 0
+	assert.Error(t, payments.Validate())
+	payments.Reconciliation.Enabled = false
+	assert.NoError(t, payments.Validate(), "disabled reconciliation needs no settings")
+}
+
+func TestMailValidate(t *testing.T) {
+	mail := config.Mail{Provider: config.MailProviderSMTP}
+	assert.NoError(t, mail.Validate())
+
+	mail = config.Mail{Provider: config.MailProviderSendGrid, SendGrid: config.SendGrid{BaseURL: "https://api.sendgrid.com", Timeout: 10 * time.Second}}
+	assert.Error(t, mail.Validate(), "SendGrid needs an API key")
+	mail.SendGrid.APIKey = "sg-key"
+	assert.NoError(t, mail.Validate())
+
+	mail.Provider = "mailgun"
+	assert.Error(t, mail.Validate())
+}
+
+func TestSMSValidate(t *testing.T) {
+	sms := config.SMS{Provider: config.SMSProviderLog}
+	assert.NoError(t, sms.Validate())
+
+	sms = config.SMS{Provider: config.SMSProviderTwilio, Twilio: config.Twilio{AccountSID: "AC123", AuthToken: "token",
+		BaseURL: "https://api.twilio.com", Timeout: 10 * time.Second}}
+	assert.Error(t, sms.Validate(), "Twilio needs a number to send from")
+	sms.Twilio.From = "+15005550006"
+	assert.NoError(t, sms.Validate())
+
+	sms.Twilio.AuthToken = ""
+	assert.Error(t, sms.Validate())
+
+	sms.Provider = "vonage"
+	assert.Error(t, sms.Validate())
+}
+
+func TestNotificationsValidate(t *testing.T) {
+	notifications := config.Notifications{}
+	assert.NoError(t, notifications.Validate(), "disabled notifications need no settings")
+	assert.False(t, notifications.Enabled())
+
+	notifications = config.Notifications{Interval: 30 * time.Second, BatchSize: 100, MaxAttempts: 8,
+		BackoffBase: time.Minute, BackoffMax: 2 * time.Hour, Retention: 720 * time.Hour, MaxEventAge: 24 * time.Hour,
+		ReminderLead: 24 * time.Hour, Email: config.EmailNotifications{Enabled: true, Templates: config.EmailTemplates{ConcertReminder: true}}}
+	assert.NoError(t, notifications.Validate())
+	assert.True(t, notifications.Enabled())
+
+	notifications.BackoffMax = time.Second
+	assert.Error(t, notifications.Validate(), "the maximum backoff can't be below the base")
+
+	notifications.BackoffMax = 2 * time.Hour
+	notifications.ReminderLead = 0
+	assert.Error(t, notifications.Validate(), "reminders need a lead")
+	notifications.Email.Templates.ConcertReminder = false
+	assert.NoError(t, notifications.Validate())
+
+	notifications.Email.Enabled = false
+	notifications.SMS.Enabled = true
+	notifications.BatchSize = 0
+	assert.Error(t, notifications.Validate(), "text messages are delivered like emails")
+}
+
+func TestAlertsValidate(t *testing.T) {
+	alerts := config.Alerts{}
+	assert.NoError(t, alerts.Validate(), "alerts are disabled without a provider")
+	assert.False(t, alerts.Enabled())
+
+	alerts = config.Alerts{Provider: config.AlertProviderSlack, WebhookURL: "https://hooks.slack.com/services/T0/B0/x",
+		Timeout: 10 * time.Second, Throttle: 15 * time.Minute,
+		ErrorRate: config.ErrorRateAlert{Threshold: 0.2, MinAttempts: 50, Interval: time.Minute}}
+	assert.NoError(t, alerts.Validate())
+	assert.True(t, alerts.Enabled())
+
+	alerts.WebhookURL = "http://hooks.slack.com/services/T0/B0/x"
+	assert.Error(t, alerts.Validate(), "the webhook URL carries its secret, so it must use https")
+
+	alerts.Provider = config.AlertProviderDiscord
+	alerts.WebhookURL = "https://discord.com/api/webhooks/1/x"
+	assert.NoError(t, alerts.Validate())
+
+	alerts.ErrorRate.Threshold = 1.5
+	assert.Error(t, alerts.Validate())
+	alerts.ErrorRate = config.ErrorRateAlert{Threshold: 0.2}
+	assert.Error(t, alerts.Validate(), "the error rate needs attempts and an interval")
+	alerts.ErrorRate.Threshold = 0
+	assert.NoError(t, alerts.Validate(), "the error rate alert is off")
+
+	alerts.Provider = "teams"
+	assert.Error(t, alerts.Validate())
+}
+
+func TestDebugValidate(t *testing.T) {
+	debug := config.Debug{}
+	assert.NoError(t, debug.Validate(), "the listener is off without a port")
+
+	debug = config.Debug{Port: 6060, AllowedNetworks: []string{"10.0.0.0/8"}}
+	assert.NoError(t, debug.Validate())
+
+	debug.AllowedNetworks = []string{"10.0.0.0"}
+	assert.Error(t, debug.Validate())
+	debug.AllowedNetworks = nil
+	assert.Error(t, debug.Validate())
+
+	debug = config.Debug{Port: 6060, AllowedNetworks: []string{"10.0.0.0/8"}, MutexProfileFraction: -1}
+	assert.Error(t, debug.Validate())
+}
+
+func TestOrganizersValidate(t *testing.T) {
+	organizers := config.Organizers{Accounts: []config.OrganizerAccount{
+		{Email: "promoter@example.com", Token: "promoter-token"},
+		{Email: "promoter@example.com", Token: "rotated-token"},
+	}}
+	assert.NoError(t, organizers.Validate("admin-token"), "an organizer may have several tokens")
+
+	organizers.Accounts[1].Token = "promoter-token"
+	assert.Error(t, organizers.Validate("admin-token"))
+
+	organizers.Accounts = []config.OrganizerAccount{{Email: "promoter@example.com", Token: "admin-token"}}
+	assert.Error(t, organizers.Validate("admin-token"), "the admin token doesn't identify an organizer")
+
+	organizers.Accounts = []config.OrganizerAccount{{Token: "promoter-token"}}
+	assert.Error(t, organizers.Validate(""))
+	organizers.Accounts = []config.OrganizerAccount{{Email: "promoter@example.com"}}
+	assert.Error(t, organizers.Validate(""))
+}
+
+func TestShutdownValidate(t *testing.T) {
+	shutdown := config.Shutdown{DrainDelay: 5 * time.Second, Timeout: 30 * time.Second}
+	assert.NoError(t, shutdown.Validate())
+
+	shutdown.Timeout = 0
+	assert.Error(t, shutdown.Validate())
+
+	shutdown = config.Shutdown{DrainDelay: 30 * time.Second, Timeout: 30 * time.Second}
+	assert.Error(t, shutdown.Validate(), "the delay leaves no time to finish the requests in flight")
+}
+
+func TestLoggingValidate(t *testing.T) {
+	logging := config.Logging{Format: config.LogFormatJSON, Levels: map[string]string{"http": "warn", "workers": "DEBUG"}}
+	assert.NoError(t, logging.Validate())
+
+	logging.Levels["grpc"] = "verbose"
+	assert.Error(t, logging.Validate())
+
+	logging = config.Logging{Format: "logfmt"}
+	assert.Error(t, logging.Validate())
+}
+
+func TestInventoryValidate(t *testing.T) {
+	inventory := config.Inventory{Mode: config.InventoryModeDatabase}
+	assert.NoError(t, inventory.Validate(config.Redis{}))
+
+	inventory = config.Inventory{Mode: config.InventoryModeRedis, FlushInterval: time.Second}
+	assert.Error(t, inventory.Validate(config.Redis{}), "the redis mode needs a Redis server")
+	assert.NoError(t, inventory.Validate(config.Redis{Addr: "redis:6379"}))
+
+	inventory.FlushInterval = 0
+	assert.Error(t, inventory.Validate(config.Redis{Addr: "redis:6379"}))
+
+	inventory.Mode = "memory"
+	assert.Error(t, inventory.Validate(config.Redis{Addr: "redis:6379"}))
+}
+
+func TestBookingValidate(t *testing.T) {
+	for _, strategy := range []string{config.BookingStrategyConditional, config.BookingStrategyOptimistic, config.BookingStrategyAdvisory} {
+		booking := config.Booking{Strategy: strategy}
+		assert.NoError(t, booking.Validate())
+	}
+
+	booking := config.Booking{Strategy: "pessimistic"}
+	assert.Error(t, booking.Validate())
+
+	booking = config.Booking{Strategy: config.BookingStrategyOptimistic, Retry: config.BookingRetry{BaseDelay: 50 * time.Millisecond, MaxDelay: 10 * time.Millisecond}}
+	assert.ErrorContains(t, booking.Validate(), "booking.retry.max_delay")
+	booking.Retry = config.BookingRetry{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second, Multiplier: 0.5}
+	assert.ErrorContains(t, booking.Validate(), "booking.retry.multiplier")
+	booking.Retry.Multiplier = 2
+	booking.Retry.Jitter = 1.5
+	assert.ErrorContains(t, booking.Validate(), "booking.retry.jitter")
+	booking.Retry.Jitter = 1
+	assert.NoError(t, booking.Validate())
+}
+
+func TestAdmissionValidate(t *testing.T) {
+	tokens := config.BookingTokens{IssueRate: 50, IssueBurst: 100}
+	admission := config.Admission{
+		Enabled: true, Interval: 5 * time.Second, MinRate: 5, IncreaseStep: 5, DecreaseFactor: 0.5,
+		LatencyTarget: 500 * time.Millisecond, PoolWaitTarget: 50 * time.Millisecond, ErrorRateTarget: 0.05,
+	}
+	assert.NoError(t, admission.Validate(tokens))
+	assert.Error(t, admission.Validate(config.BookingTokens{}), "unlimited issuance has no rate to adjust")
+
+	admission.MinRate = 60
+	assert.Error(t, admission.Validate(tokens), "the minimum is above the issue rate")
+
+	admission.MinRate, admission.DecreaseFactor = 5, 1
+	assert.Error(t, admission.Validate(tokens))
+
+	admission.Enabled = false
+	assert.NoError(t, admission.Validate(tokens))
+}
+
+func TestDatabaseValidate(t *testing.T) {
+	for _, driver := range []string{config.DriverPostgres, config.DriverMySQL, config.DriverMemory} {
+		database := config.Database{Driver: driver}
+		assert.NoError(t, database.Validate())
+	}
+
+	database := config.Database{Driver: config.DriverSQLite}
+	assert.Error(t, database.Validate(), "the sqlite driver needs a file")
+	database.Path = ":memory:"
+	assert.NoError(t, database.Validate())
+
+	database = config.Database{Driver: "oracle"}
+	assert.Error(t, database.Validate())
+}
+
+func TestLoadSheddingValidate(t *testing.T) {
+	shedding := config.LoadShedding{Enabled: true, MaxInFlight: 10, MaxInFlightWrites: 20}
+	assert.ErrorContains(t, shedding.Validate(), "load_shedding.max_in_flight_writes")
+
+	shedding.MaxInFlightWrites = 5
+	shedding.MaxQueue = 10
+	assert.ErrorContains(t, shedding.Validate(), "load_shedding.queue_timeout")
+
+	shedding.QueueTimeout = 100 * time.Millisecond
+	assert.NoError(t, shedding.Validate())
+}
+
+func TestDatabaseTimeoutsAndCircuitBreaker(t *testing.T) {
+	database := config.Database{Driver: config.DriverMemory, StatementTimeout: 2 * time.Second}
+	database.Timeouts.Query = time.Second
+	assert.Equal(t, time.Second, database.QueryTimeout())
+	assert.Equal(t, 2*time.Second, database.ExecTimeout(), "unset timeouts fall back to the statement timeout")
+
+	database.Timeouts.Exec = -time.Second
+	assert.ErrorContains(t, database.Validate(), "database.timeouts")
+	database.Timeouts.Exec = 0
+
+	database.CircuitBreaker.FailureThreshold = 3
+	assert.ErrorContains(t, database.Validate(), "database.circuit_breaker.open_duration")
+	database.CircuitBreaker.OpenDuration = 10 * time.Second
+	assert.NoError(t, database.Validate())
+}
+
+func TestDatabaseReplicas(t *testing.T) {
+	database := config.Database{Driver: config.DriverPostgres, Host: "primary", Port: 5432, Username: "tickets",
+		Replicas: []string{"replica-1:5433", "10.0.0.7:5432"}}
+	require.NoError(t, database.Validate())
+
+	replica := database.Replica("replica-1:5433")
+	assert.Equal(t, "replica-1", replica.Host)
+	assert.Equal(t, 5433, replica.Port)
+	assert.Equal(t, "tickets", replica.Username, "replicas share the primary's credentials")
+	assert.Empty(t, replica.Replicas)
+
+	for _, addr := range []string{"replica-1", ":5432", "replica-1:0", "replica-1:port"} {
+		database.Replicas = []string{addr}
+		assert.Error(t, database.Validate(), addr)
+	}
+
+	database = config.Database{Driver: config.DriverMemory, Replicas: []string{"replica-1:5432"}}
+	assert.Error(t, database.Validate(), "replicas need postgres")
+}
+
+func TestLoadReadsReplicasFromTheEnvironment(t *testing.T) {
+	t.Setenv("APP_DATABASE_REPLICAS", "replica-1:5432,replica-2:5432")
+	cfg, err := config.Load(writeConfig(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica-1:5432", "replica-2:5432"}, cfg.Database.Replicas)
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	database := config.Database{Driver: config.DriverMySQL, Host: "db", Port: 3306, Username: "tickets", Password: "secret", Name: "concert_tickets"}
+	assert.Equal(t, "mysql", database.DriverName())
+	assert.Equal(t, "tickets:secret@tcp(db:3306)/concert_tickets?parseTime=true&loc=UTC", database.DSN())
+
+	database = config.Database{Driver: config.DriverPostgres, Host: "db", Port: 5432, Username: "tickets", Password: "secret", Name: "concert_tickets", SSLMode: "disable"}
+	assert.Equal(t, "pgx", database.DriverName())
+	assert.Equal(t, "pgx5://tickets:secret@db:5432/concert_tickets?sslmode=disable", database.MigrationDSN())
+
+	database = config.Database{Driver: config.DriverSQLite, Path: "tickets.db"}
+	assert.Equal(t, "sqlite3", database.DriverName())
+	assert.Contains(t, database.DSN(), "file:tickets.db?")
+}
+
+func TestLoadStrictOnlyRequiresThePathForSQLite(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  driver: sqlite
+  path: tickets.db
+`)
+	cfg, err := config.LoadStrict(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.DriverSQLite, cfg.Database.Driver)
+
+	path = writeConfig(t, `
+database:
+  driver: sqlite
+  path: tickets.db
+inventory:
+  mode: redis
+redis:
+  addr: redis:6379
+`)
+	_, err = config.Load(path)
+	assert.Error(t, err, "Redis inventory counters are written behind to Postgres only")
+}
+
+func TestLoadStrictRequiresNoDatabaseSettingsForMemory(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  driver: memory
+`)
+	cfg, err := config.LoadStrict(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Database.Memory())
+	assert.False(t, cfg.Database.Postgres())
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const strictDatabase = `
+database:
+  host: db
+  username: tickets
+  password: secret
+  name: concert_tickets
+`
+
+func TestLoadStrictReportsUnknownKeysByPath(t *testing.T) {
+	path := writeConfig(t, strictDatabase+`
+max_retires: 5
+cors:
+  allow_orgins: ["https://tickets.example.com"]
+grpc_auth:
+  clients:
+    - name: web
+      token: change-me
+      roles: [user]
+      rols: [admin]
+latency:
+  budgets:
+    "GET /api/v1/concerts": 200ms
+`)
+	t.Setenv("APP_DATABASE_SSL_MODE", "require")
+
+	_, err := config.LoadStrict(path)
+	var strictErr *config.StrictError
+	require.True(t, errors.As(err, &strictErr), "got %v", err)
+	assert.Equal(t, []config.Problem{
+		{Path: "cors.allow_orgins", Message: "unknown key, did you mean cors.allow_origins?"},
+		{Path: "grpc_auth.clients[0].rols", Message: "unknown key, did you mean roles?"},
+		{Path: "max_retires", Message: "unknown key, did you mean max_retries?"},
+		{Path: "APP_DATABASE_SSL_MODE", Message: "environment variable does not match a config key, did you mean APP_DATABASE_SSLMODE?"},
+	}, strictErr.Problems, "map entries like latency.budgets accept any key")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err, "typos are ignored outside strict mode")
+	assert.Equal(t, 3, cfg.MaxRetries)
+}
+
+func TestLoadStrictRequiresDatabaseKeys(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  host: db
+  password: ""
+`)
+	t.Setenv("APP_DATABASE_NAME", "concert_tickets")
+
+	_, err := config.LoadStrict(path)
+	var strictErr *config.StrictError
+	require.True(t, errors.As(err, &strictErr), "got %v", err)
+	assert.Equal(t, []config.Problem{
+		{Path: "database.username", Message: "is required, set it in the config file or APP_DATABASE_USERNAME"},
+		{Path: "database.password", Message: "must not be empty"},
+	}, strictErr.Problems)
+
+	t.Setenv("APP_DATABASE_USERNAME", "tickets")
+	t.Setenv("APP_DATABASE_PASSWORD", "secret")
+	cfg, err := config.LoadStrict(path)
+	require.NoError(t, err)
+	assert.Equal(t, "tickets", cfg.Database.Username)
+}
+
+func TestStrictKeyEnablesStrictMode(t *testing.T) {
+	path := writeConfig(t, "strict: true\nmax_retires: 5\n"+strictDatabase)
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, "max_retires: unknown key")
+
+	path = writeConfig(t, "strict: true\n"+strictDatabase)
+	_, err = config.Load(path)
+	assert.NoError(t, err)
+}
+
+func TestShippedConfigPassesStrictMode(t *testing.T) {
+	_, err := config.LoadStrict("../../config/config.yaml")
+	assert.NoError(t, err)
+}
+
+func TestReloadAppliesReloadableKeys(t *testing.T) {
+	path := writeConfig(t, `
+log_level: info
+rest_port: 8080
+booking_limits:
+  max_tickets_per_booking: 10
+`)
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	reloader := config.NewReloader(cfg)
+	var reloaded *config.Config
+	reloader.OnReload(func(cfg *config.Config) { reloaded = cfg })
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+log_level: debug
+rest_port: 9090
+booking_limits:
+  max_tickets_per_booking: 4
+`), 0o600))
+	reload, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log_level", "booking_limits.max_tickets_per_booking"}, reload.Applied)
+	assert.Equal(t, []string{"rest_port"}, reload.Restart)
+
+	require.NotNil(t, reloaded)
+	assert.Same(t, reloaded, reloader.Current())
+	assert.Equal(t, "debug", reloaded.LogLevel)
+	assert.Equal(t, 4, reloaded.BookingLimits.MaxTicketsPerBooking)
+	assert.Equal(t, 8080, reloaded.RESTPort, "the port keeps its value until a restart")
+	assert.Equal(t, 10, cfg.BookingLimits.MaxTicketsPerBooking, "the config loaded at startup isn't changed")
+}
+
+func TestReloadKeepsTheCurrentConfigWhenInvalid(t *testing.T) {
+	path := writeConfig(t, "log_level: info\n")
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	reloader := config.NewReloader(cfg)
+	reloader.OnReload(func(*config.Config) { t.Fatal("an invalid config was applied") })
+
+	require.NoError(t, os.WriteFile(path, []byte("log_level: info\nbooking_limits:\n  max_tickets_per_booking: 0\n"), 0o600))
+	_, err = reloader.Reload()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "booking_limits.max_tickets_per_booking")
+	assert.Same(t, cfg, reloader.Current())
+
+	require.NoError(t, os.WriteFile(path, []byte("log_level: info\n"), 0o600))
+	reload, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, reload.Applied, "unchanged configs aren't applied")
+}
+
+func TestSecretsValidate(t *testing.T) {
+	secrets := config.Secrets{CacheTTL: 5 * time.Minute, Timeout: 5 * time.Second}
+	assert.NoError(t, secrets.Validate())
+
+	secrets.Vault.Addr = "vault.internal:8200"
+	assert.Error(t, secrets.Validate(), "the vault address needs a scheme")
+	secrets.Vault.Addr = "https://vault.internal:8200"
+	assert.NoError(t, secrets.Validate())
+
+	secrets.AWS.AccessKeyID = "AKIDEXAMPLE"
+	assert.Error(t, secrets.Validate(), "an access key needs its secret")
+
+	secrets = config.Secrets{Timeout: time.Second}
+	assert.Error(t, secrets.Validate())
+}
+
+func TestSecretReferencesPassValidation(t *testing.T) {
+	pages := config.Pages{Enabled: true, SigningKey: "vault://secret/pages#key", BaseURL: "https://tickets.example.com", LinkTTL: time.Hour}
+	assert.NoError(t, pages.Validate(), "the length of the key is checked once it is resolved")
+	assert.True(t, config.IsSecretReference("awssm://tickets/stripe#secret_key"))
+	assert.False(t, config.IsSecretReference("https://tickets.example.com"))
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concertConflicts returns the stats of a concert in a summary, nil if it has none
+func concertConflicts(summary *model.BookingConflictSummary, concertID int64) *model.ConcertConflictStats {
+	for _, stats := range summary.Concerts {
+		if stats.ConcertID == concertID {
+			return stats
+		}
+	}
+	return nil
+}
+
+func TestConflictTrackerBucketsBookings(t *testing.T) {
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	tracker := service.NewConflictTracker(24 * time.Hour)
+
+	tracker.RecordBooking(1, 1, 0, false)
+	tracker.RecordBooking(1, 3, 2, false)
+	clock.Process().Advance(10 * time.Minute)
+	tracker.RecordBooking(1, 5, 5, true)
+	tracker.RecordBooking(2, 2, 1, false)
+	clock.Process().Advance(5 * time.Minute)
+
+	summary := tracker.Summary(30*time.Minute, 10*time.Minute)
+	assert.Equal(t, "10m0s", summary.BucketSize)
+	require.Len(t, summary.Concerts, 2)
+	assert.Equal(t, int64(1), summary.Concerts[0].ConcertID, "the most contended concert comes first")
+
+	first := concertConflicts(summary, 1)
+	assert.Equal(t, 3, first.Bookings)
+	assert.Equal(t, 7, first.Conflicts)
+	assert.Equal(t, 1, first.ExhaustedRetries)
+	assert.InDelta(t, 7.0/9.0, first.ConflictRate, 1e-9)
+	assert.Equal(t, map[int]int{1: 1, 3: 1, 5: 1}, first.RetryDepth)
+
+	// The window started at 11:45, so the bookings of 12:00 and 12:10 fall
+	// in the second and third bucket
+	require.Len(t, first.Buckets, 3)
+	assert.Equal(t, summary.WindowStart, first.Buckets[0].Start)
+	assert.Equal(t, []int{0, 2, 1}, []int{first.Buckets[0].Bookings, first.Buckets[1].Bookings, first.Buckets[2].Bookings})
+	assert.Equal(t, []int{0, 2, 5}, []int{first.Buckets[0].Conflicts, first.Buckets[1].Conflicts, first.Buckets[2].Conflicts})
+
+	// Bookings before the window are left out
+	recent := concertConflicts(tracker.Summary(10*time.Minute, 5*time.Minute), 1)
+	require.NotNil(t, recent)
+	assert.Equal(t, 1, recent.Bookings)
+	assert.Equal(t, 5, recent.Conflicts)
+}
+
+func TestConflictTrackerDropsBookingsPastTheRetention(t *testing.T) {
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	tracker := service.NewConflictTracker(time.Hour)
+
+	tracker.RecordBooking(1, 2, 1, false)
+	clock.Process().Advance(30 * time.Minute)
+	tracker.RecordBooking(2, 2, 1, false)
+
+	summary := tracker.Summary(24*time.Hour, time.Hour)
+	assert.Equal(t, summary.WindowEnd.Add(-time.Hour), summary.WindowStart, "the window is capped at the retention")
+	assert.Len(t, summary.Concerts, 2)
+
+	// An hour after the first booking it is gone, the second one is kept
+	clock.Process().Advance(31 * time.Minute)
+	tracker.RecordBooking(3, 1, 0, false)
+	summary = tracker.Summary(time.Hour, 10*time.Minute)
+	assert.Nil(t, concertConflicts(summary, 1))
+	assert.NotNil(t, concertConflicts(summary, 2))
+
+	// After a long pause everything recorded before expires at once
+	clock.Process().Advance(30 * 24 * time.Hour)
+	tracker.RecordBooking(4, 1, 0, false)
+	summary = tracker.Summary(time.Hour, 10*time.Minute)
+	require.Len(t, summary.Concerts, 1)
+	assert.Equal(t, int64(4), summary.Concerts[0].ConcertID)
+}
+
+func TestConflictTrackerLimitsBuckets(t *testing.T) {
+	tracker := service.NewConflictTracker(24 * time.Hour)
+	tracker.RecordBooking(1, 1, 0, false)
+
+	stats := concertConflicts(tracker.Summary(time.Hour, time.Millisecond), 1)
+	require.NotNil(t, stats)
+	assert.Len(t, stats.Buckets, 60, "buckets are at least a minute long")
+
+	stats = concertConflicts(tracker.Summary(24*time.Hour, service.ConflictResolution), 1)
+	require.NotNil(t, stats)
+	assert.Len(t, stats.Buckets, service.MaxConflictBuckets)
+}
@@ -0,0 +1,786 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/app"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The contract tests start the whole application on the memory driver and
+// drive it over the network as clients do. Every operation of the published
+// OpenAPI document and every RPC of the proto services must have a case
+// below, and every response must be one the contract allows: a documented
+// status, the documented media type, a body matching the documented schema
+// and errors in the shape of the API. A handler that drifts from what it
+// publishes, or a route published without a case, fails here.
+
+const (
+	contractAdminToken     = "contract-admin-token"
+	contractUserToken      = "contract-user-token"
+	contractOrganizerToken = "contract-organizer-token"
+	contractAgencyToken    = "contract-agency-token"
+	contractUser           = "contract-user"
+)
+
+// contractAPI is the running application and the fixtures of its cases
+type contractAPI struct {
+	t        *testing.T
+	base     string
+	conn     *grpc.ClientConn
+	document *openapi.Document
+	// vars are substituted for {name} in the URLs, headers and bodies of
+	// the cases
+	vars map[string]func() string
+}
+
+func startContractAPI(t *testing.T) *contractAPI {
+	t.Helper()
+	restPort, grpcPort := freePort(t), freePort(t)
+	cfg, err := config.Load(writeConfig(t, fmt.Sprintf(`
+log_level: error
+rest_port: %d
+grpc_port: %d
+database:
+  driver: memory
+admin:
+  token: %s
+grpc_auth:
+  clients:
+    - name: contract-user
+      token: %s
+      roles: [user]
+    - name: contract-organizer
+      token: %s
+      roles: [organizer]
+    - name: contract-agency
+      token: %s
+      roles: [agency]
+admission:
+  enabled: true
+pages:
+  enabled: true
+  signing_key: contract-page-signing-key-0123456789
+  base_url: https://tickets.example.com
+  link_ttl: 1h
+test_clock:
+  enabled: true
+shutdown:
+  timeout: 5s
+`, restPort, grpcPort, contractAdminToken, contractUserToken, contractOrganizerToken, contractAgencyToken)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx, cfg) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		clock.Process().Reset()
+	})
+
+	api := &contractAPI{t: t, base: fmt.Sprintf("http://127.0.0.1:%d", restPort)}
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(api.base + "/health/live")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	api.conn, err = grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", grpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { api.conn.Close() })
+
+	resp := api.do(http.MethodGet, "/api/v1/openapi.json", nil, "")
+	require.Equal(t, http.StatusOK, resp.status)
+	require.NoError(t, json.Unmarshal(resp.body, &api.document))
+	return api
+}
+
+// contractResponse is a response read in full
+type contractResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (api *contractAPI) do(method, path string, header map[string]string, body string) contractResponse {
+	api.t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, api.base+path, reader)
+	require.NoError(api.t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(api.t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(api.t, err)
+	return contractResponse{status: resp.StatusCode, header: resp.Header, body: data}
+}
+
+// create makes a request the fixtures depend on and decodes its response
+func (api *contractAPI) create(path, body string, out interface{}) {
+	api.t.Helper()
+	resp := api.do(http.MethodPost, path, nil, body)
+	require.Equal(api.t, http.StatusCreated, resp.status, string(resp.body))
+	require.NoError(api.t, json.Unmarshal(resp.body, out))
+}
+
+// fixed returns a variable of a value known up front
+func fixed(value string) func() string {
+	return func() string { return value }
+}
+
+// contractVar matches the {name} placeholders of the cases
+var contractVar = regexp.MustCompile(`\{(\w+)\}`)
+
+func (api *contractAPI) expand(s string) string {
+	api.t.Helper()
+	return contractVar.ReplaceAllStringFunc(s, func(match string) string {
+		value, ok := api.vars[match[1:len(match)-1]]
+		require.True(api.t, ok, "unknown contract variable %s", match)
+		return value()
+	})
+}
+
+// setUp creates the concert the cases book, open for booking, another one
+// and the variables of the cases
+func (api *contractAPI) setUp() {
+	t := api.t
+	opens := time.Now().Add(time.Second).UTC()
+	var open, other struct {
+		ID int64 `json:"id"`
+	}
+	api.create("/api/v1/concerts", fmt.Sprintf(`{"name": "Other Night", "artist": "The Contracts", "venue": "Spec Hall",
+		"concert_date": %q, "total_tickets": 50, "price": 30, "booking_start_time": %q, "booking_end_time": %q}`,
+		opens.Add(60*24*time.Hour).Format(time.RFC3339), opens.Add(24*time.Hour).Format(time.RFC3339), opens.Add(7*24*time.Hour).Format(time.RFC3339)), &other)
+	api.create("/api/v1/concerts", fmt.Sprintf(`{"name": "Contract Night", "artist": "The Contracts", "venue": "Spec Hall",
+		"concert_date": %q, "total_tickets": 500, "price": 40, "booking_start_time": %q, "booking_end_time": %q}`,
+		opens.Add(30*24*time.Hour).Format(time.RFC3339), opens.Format(time.RFC3339Nano), opens.Add(7*24*time.Hour).Format(time.RFC3339)), &open)
+
+	api.vars = map[string]func() string{
+		"concert": fixed(strconv.FormatInt(open.ID, 10)),
+		"other":   fixed(strconv.FormatInt(other.ID, 10)),
+		"user":    fixed(contractUser),
+		"past":    fixed(time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)),
+		"future":  fixed(time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)),
+		"later":   fixed(time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)),
+		"month":   fixed(time.Now().Add(30 * 24 * time.Hour).UTC().Format(time.RFC3339)),
+		// The ETag of the concert changes with every update
+		"etag": func() string {
+			return api.do(http.MethodHead, "/api/v1/concerts/"+strconv.FormatInt(open.ID, 10), nil, "").header.Get("ETag")
+		},
+		// The token of the calendar feed, from the links of a booking
+		"feed": func() string {
+			var links struct {
+				CalendarURL string `json:"calendar_url"`
+			}
+			resp := api.do(http.MethodGet, api.expand("/api/v1/bookings/{booking}/links?userID={user}"), nil, "")
+			require.NoError(t, json.Unmarshal(resp.body, &links))
+			feed, err := url.Parse(links.CalendarURL)
+			require.NoError(t, err)
+			return url.QueryEscape(feed.Query().Get("token"))
+		},
+		"worker": func() string {
+			var workers struct {
+				Workers []struct {
+					Name string `json:"name"`
+				} `json:"workers"`
+			}
+			resp := api.do(http.MethodGet, "/api/v1/admin/workers", map[string]string{"Authorization": "Bearer " + contractAdminToken}, "")
+			require.NoError(t, json.Unmarshal(resp.body, &workers))
+			require.NotEmpty(t, workers.Workers)
+			return workers.Workers[0].Name
+		},
+	}
+
+	time.Sleep(time.Until(opens))
+}
+
+// book creates a booking of the concert for the fixtures and returns its reference
+func (api *contractAPI) book(user string) string {
+	api.t.Helper()
+	var booking struct {
+		Reference string `json:"reference"`
+	}
+	api.create("/api/v1/bookings", api.expand(fmt.Sprintf(`{"concert_id": {concert}, "user_id": %q, "ticket_count": 1}`, user)), &booking)
+	return booking.Reference
+}
+
+// restCase is a request to a documented operation and the status it must get
+type restCase struct {
+	// operation is the documented method and path template relative to the
+	// API version, such as GET /concerts/{id}
+	operation string
+	// url is the path and query relative to the API version
+	url    string
+	header map[string]string
+	body   string
+	admin  bool
+	status int
+}
+
+// restCases cover every operation the memory driver serves. They run in
+// order, so a case may rely on the changes of the cases before it.
+var restCases = []restCase{
+	{operation: "POST /concerts", url: "/concerts", status: http.StatusCreated,
+		body: `{"name": "Created Show", "artist": "Contract Band", "venue": "Spec Hall", "concert_date": "{month}",
+			"total_tickets": 100, "price": 25.5, "booking_start_time": "{future}", "booking_end_time": "{later}"}`},
+	{operation: "POST /concerts", url: "/concerts", body: `{"name": "No Dates"}`, status: http.StatusBadRequest},
+	{operation: "POST /concerts", url: "/concerts", body: `{"total_tickets": "many"}`, status: http.StatusBadRequest},
+
+	{operation: "GET /concerts", url: "/concerts", status: http.StatusOK},
+	{operation: "GET /concerts", url: "/concerts?page=1&pageSize=1&sort=-price&fields=name,price", status: http.StatusOK},
+	{operation: "GET /concerts", url: "/concerts?ids={concert},999999", status: http.StatusOK},
+	{operation: "GET /concerts", url: "/concerts?sort=loudness", status: http.StatusBadRequest},
+	{operation: "HEAD /concerts", url: "/concerts", status: http.StatusOK},
+	{operation: "GET /concerts/compare", url: "/concerts/compare?ids={concert},{other}", status: http.StatusOK},
+	{operation: "GET /concerts/compare", url: "/concerts/compare", status: http.StatusBadRequest},
+
+	{operation: "GET /concerts/{id}", url: "/concerts/{concert}", status: http.StatusOK},
+	{operation: "GET /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-None-Match": "{etag}"}, status: http.StatusNotModified},
+	{operation: "GET /concerts/{id}", url: "/concerts/999999", status: http.StatusNotFound},
+	{operation: "HEAD /concerts/{id}", url: "/concerts/{concert}", status: http.StatusOK},
+	{operation: "HEAD /concerts/{id}", url: "/concerts/999999", status: http.StatusNotFound},
+	{operation: "GET /concerts/{id}/price-history", url: "/concerts/{concert}/price-history", status: http.StatusOK},
+	{operation: "GET /concerts/{id}/price-history", url: "/concerts/999999/price-history", status: http.StatusNotFound},
+	{operation: "GET /concerts/{id}/quote", url: "/concerts/{concert}/quote?ticketCount=2", status: http.StatusOK},
+	{operation: "GET /concerts/{id}/quote", url: "/concerts/{concert}/quote?ticketCount=-1", status: http.StatusBadRequest},
+	{operation: "GET /concerts/{id}/quote", url: "/concerts/999999/quote", status: http.StatusNotFound},
+
+	{operation: "PUT /concerts/{id}", url: "/concerts/{concert}", status: http.StatusPreconditionRequired,
+		body: `{"name": "Contract Night", "artist": "The Contracts", "venue": "Spec Hall", "concert_date": "{month}",
+			"total_tickets": 500, "price": 40, "booking_start_time": "{past}", "booking_end_time": "{later}"}`},
+	{operation: "PUT /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-Match": `"1000"`}, status: http.StatusPreconditionFailed,
+		body: `{"name": "Contract Night", "artist": "The Contracts", "venue": "Spec Hall", "concert_date": "{month}",
+			"total_tickets": 500, "price": 40, "booking_start_time": "{past}", "booking_end_time": "{later}"}`},
+	{operation: "PUT /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-Match": "{etag}"}, status: http.StatusBadRequest,
+		body: `{"name": "No Dates"}`},
+	{operation: "PUT /concerts/{id}", url: "/concerts/999999", header: map[string]string{"If-Match": `"1"`}, status: http.StatusNotFound,
+		body: `{"name": "Contract Night", "artist": "The Contracts", "venue": "Spec Hall", "concert_date": "{month}",
+			"total_tickets": 500, "price": 40, "booking_start_time": "{past}", "booking_end_time": "{later}"}`},
+	{operation: "PUT /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-Match": "{etag}"}, status: http.StatusOK,
+		body: `{"name": "Contract Night", "artist": "The Contracts", "venue": "Spec Hall", "concert_date": "{month}",
+			"total_tickets": 500, "price": 45, "booking_start_time": "{past}", "booking_end_time": "{later}"}`},
+	{operation: "PATCH /concerts/{id}", url: "/concerts/{concert}", body: `{"price": 42}`, status: http.StatusPreconditionRequired},
+	{operation: "PATCH /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-Match": `"1000"`}, body: `{"price": 42}`, status: http.StatusPreconditionFailed},
+	{operation: "PATCH /concerts/{id}", url: "/concerts/999999", header: map[string]string{"If-Match": `"1"`}, body: `{"price": 42}`, status: http.StatusNotFound},
+	{operation: "PATCH /concerts/{id}", url: "/concerts/{concert}", header: map[string]string{"If-Match": "{etag}"}, body: `{"price": 42}`, status: http.StatusOK},
+
+	{operation: "POST /bookings", url: "/bookings", header: map[string]string{"Idempotency-Key": "contract-{version}"}, status: http.StatusCreated,
+		body: `{"concert_id": {concert}, "user_id": "{user}", "ticket_count": 2, "attendee_name": "Casey Contract", "attendee_email": "casey@example.com"}`},
+	{operation: "POST /bookings", url: "/bookings", body: `{"concert_id": {concert}, "user_id": "{user}", "ticket_count": 0}`, status: http.StatusBadRequest},
+	{operation: "POST /bookings", url: "/bookings", body: `{"concert_id": {concert}, "user_id": "{user}", "ticket_count": 5000}`, status: http.StatusBadRequest},
+	{operation: "POST /bookings", url: "/bookings", body: `{"concert_id": 999999, "user_id": "{user}", "ticket_count": 1}`, status: http.StatusNotFound},
+	{operation: "GET /bookings", url: "/bookings?userID={user}", status: http.StatusOK},
+	{operation: "GET /bookings", url: "/bookings?userID={user}&pageSize=1&fields=concert_id,status&expand=concert", status: http.StatusOK},
+	{operation: "GET /bookings", url: "/bookings", status: http.StatusBadRequest},
+	{operation: "GET /bookings/{reference}", url: "/bookings/{booking}", status: http.StatusOK},
+	{operation: "GET /bookings/{reference}", url: "/bookings/ZZZZZZZZZZZZ", status: http.StatusNotFound},
+	{operation: "GET /bookings/{reference}/links", url: "/bookings/{booking}/links?userID={user}", status: http.StatusOK},
+	{operation: "GET /bookings/{reference}/links", url: "/bookings/{booking}/links", status: http.StatusBadRequest},
+	{operation: "GET /bookings/{reference}/links", url: "/bookings/ZZZZZZZZZZZZ/links?userID={user}", status: http.StatusNotFound},
+	{operation: "POST /bookings/{reference}/cancel", url: "/bookings/{cancelled}/cancel", body: `{"userID": "someone-else"}`, status: http.StatusForbidden},
+	{operation: "POST /bookings/{reference}/cancel", url: "/bookings/{cancelled}/cancel", body: `{"userID": "{user}"}`, status: http.StatusOK},
+	{operation: "POST /bookings/{reference}/cancel", url: "/bookings/{cancelled}/cancel", body: `{"userID": "{user}"}`, status: http.StatusBadRequest},
+	{operation: "POST /bookings/{reference}/cancel", url: "/bookings/ZZZZZZZZZZZZ/cancel", body: `{"userID": "{user}"}`, status: http.StatusNotFound},
+	{operation: "POST /concerts/{id}/booking-token", url: "/concerts/{concert}/booking-token", body: `{"user_id": "{user}"}`, status: http.StatusCreated},
+	{operation: "POST /concerts/{id}/booking-token", url: "/concerts/{concert}/booking-token", body: `{}`, status: http.StatusBadRequest},
+	{operation: "POST /concerts/{id}/booking-token", url: "/concerts/999999/booking-token", body: `{"user_id": "{user}"}`, status: http.StatusNotFound},
+
+	{operation: "GET /users/{id}/bookings.ics", url: "/users/{user}/bookings.ics?token={feed}", status: http.StatusOK},
+	{operation: "GET /users/{id}/bookings.ics", url: "/users/{user}/bookings.ics", status: http.StatusNotFound},
+	{operation: "GET /users/{id}/bookings.ics", url: "/users/someone-else/bookings.ics?token={feed}", status: http.StatusNotFound},
+	{operation: "GET /users/{id}/export", url: "/users/{user}/export", status: http.StatusUnauthorized},
+	{operation: "GET /users/{id}/export", url: "/users/{user}/export", header: map[string]string{"Authorization": "Bearer wrong"}, status: http.StatusForbidden},
+	{operation: "GET /users/{id}/export", url: "/users/{user}/export", admin: true, status: http.StatusOK},
+	{operation: "DELETE /users/{id}/data", url: "/users/contract-erased/data", admin: true, status: http.StatusOK},
+
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=30m&bucket=1m", admin: true, status: http.StatusOK},
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=soon", admin: true, status: http.StatusBadRequest},
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=1000m&bucket=1m", admin: true, status: http.StatusOK},
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=1001m&bucket=1m", admin: true, status: http.StatusBadRequest},
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=24h&bucket=1ms", admin: true, status: http.StatusBadRequest},
+	{operation: "GET /admin/booking-conflicts", url: "/admin/booking-conflicts?window=1h&bucket=59s", admin: true, status: http.StatusBadRequest},
+	{operation: "POST /admin/concerts/{id}/invites", url: "/admin/concerts/{concert}/invites", admin: true, body: `{"count": 2}`, status: http.StatusBadRequest},
+	{operation: "POST /admin/concerts/{id}/invites", url: "/admin/concerts/999999/invites", admin: true, body: `{"count": 2}`, status: http.StatusNotFound},
+	{operation: "GET /admin/concerts/{id}/invites/export", url: "/admin/concerts/{concert}/invites/export", admin: true, status: http.StatusOK},
+	{operation: "GET /admin/concerts/{id}/invites/export", url: "/admin/concerts/999999/invites/export", admin: true, status: http.StatusNotFound},
+	{operation: "GET /admin/workers", url: "/admin/workers", admin: true, status: http.StatusOK},
+	{operation: "GET /admin/workers/{name}/runs", url: "/admin/workers/no-such-worker/runs", admin: true, status: http.StatusNotFound},
+	{operation: "POST /admin/workers/{name}/pause", url: "/admin/workers/{worker}/pause", admin: true, status: http.StatusOK},
+	{operation: "POST /admin/workers/{name}/pause", url: "/admin/workers/no-such-worker/pause", admin: true, status: http.StatusNotFound},
+	{operation: "POST /admin/workers/{name}/resume", url: "/admin/workers/{worker}/resume", admin: true, status: http.StatusOK},
+	{operation: "POST /admin/workers/{name}/resume", url: "/admin/workers/no-such-worker/resume", admin: true, status: http.StatusNotFound},
+	{operation: "GET /admin/admission", url: "/admin/admission", admin: true, status: http.StatusOK},
+	{operation: "PUT /admin/admission/override", url: "/admin/admission/override", admin: true, body: `{"rate": 20, "burst": 40}`, status: http.StatusOK},
+	{operation: "PUT /admin/admission/override", url: "/admin/admission/override", admin: true, body: `{"rate": 0}`, status: http.StatusBadRequest},
+	{operation: "DELETE /admin/admission/override", url: "/admin/admission/override", admin: true, status: http.StatusOK},
+
+	{operation: "GET /admin/test-clock", url: "/admin/test-clock", admin: true, status: http.StatusOK},
+	{operation: "PUT /admin/test-clock", url: "/admin/test-clock", admin: true, body: `{"time": "{future}"}`, status: http.StatusOK},
+	{operation: "PUT /admin/test-clock", url: "/admin/test-clock", admin: true, body: `{"time": "tomorrow"}`, status: http.StatusBadRequest},
+	{operation: "POST /admin/test-clock/advance", url: "/admin/test-clock/advance", admin: true, body: `{"duration": "1h"}`, status: http.StatusOK},
+	{operation: "POST /admin/test-clock/advance", url: "/admin/test-clock/advance", admin: true, body: `{"duration": "a while"}`, status: http.StatusBadRequest},
+	{operation: "DELETE /admin/test-clock", url: "/admin/test-clock", admin: true, status: http.StatusOK},
+}
+
+func TestRESTResponsesMatchTheOpenAPIDocument(t *testing.T) {
+	api := startContractAPI(t)
+	api.setUp()
+
+	// Every API version serves and documents the same operations
+	versions := make(map[string]map[string]bool)
+	for path, item := range api.document.Paths {
+		parts := strings.SplitN(path, "/", 4)
+		require.Len(t, parts, 4, path)
+		prefix := "/" + parts[1] + "/" + parts[2]
+		if versions[prefix] == nil {
+			versions[prefix] = make(map[string]bool)
+		}
+		for method := range item {
+			versions[prefix][strings.ToUpper(method)+" /"+parts[3]] = true
+		}
+	}
+	require.Contains(t, versions, "/api/v1")
+
+	for _, prefix := range sortedSet(versions) {
+		t.Run(prefix, func(t *testing.T) {
+			api.t = t
+			api.vars["version"] = fixed(strings.TrimPrefix(prefix, "/api/"))
+			api.vars["booking"] = fixed(api.book(contractUser))
+			api.vars["cancelled"] = fixed(api.book(contractUser))
+
+			covered := make(map[string]bool)
+			for _, tc := range restCases {
+				if !versions[prefix][tc.operation] {
+					// Operations of other drivers, such as carts on Postgres
+					continue
+				}
+				covered[tc.operation] = true
+				api.checkREST(t, prefix, tc)
+			}
+			for operation := range versions[prefix] {
+				assert.True(t, covered[operation], "%s %s is documented but has no contract case", prefix, operation)
+			}
+		})
+	}
+}
+
+// checkREST makes the request of a case and checks its response against the document
+func (api *contractAPI) checkREST(t *testing.T, prefix string, tc restCase) {
+	method, template, _ := strings.Cut(tc.operation, " ")
+	name := fmt.Sprintf("%s %s -> %d", method, tc.url, tc.status)
+	operation := api.document.Operation(method, prefix+template)
+	require.NotNil(t, operation, name)
+
+	header := make(map[string]string, len(tc.header)+1)
+	for key, value := range tc.header {
+		header[key] = api.expand(value)
+	}
+	if tc.admin {
+		header["Authorization"] = "Bearer " + contractAdminToken
+	}
+	target, body := api.expand(tc.url), api.expand(tc.body)
+
+	// The cases themselves keep to the contract: documented parameters, and
+	// valid bodies for requests that must succeed
+	documented := make(map[string]bool)
+	for _, param := range operation.Parameters {
+		documented[param.In+":"+param.Name] = true
+	}
+	parsed, err := url.Parse(target)
+	require.NoError(t, err)
+	for param := range parsed.Query() {
+		assert.True(t, documented["query:"+param], "%s: query parameter %s isn't documented", name, param)
+	}
+	if body != "" && tc.status < http.StatusBadRequest {
+		require.NotNil(t, operation.RequestBody, "%s: the request body isn't documented", name)
+		assert.NoError(t, api.document.Validate(operation.RequestBody.Content["application/json"].Schema, []byte(body)), "%s: request body", name)
+	}
+
+	resp := api.do(method, prefix+target, header, body)
+	if !assert.Equal(t, tc.status, resp.status, "%s: %s", name, resp.body) {
+		return
+	}
+	documentedResponse, ok := operation.Responses[strconv.Itoa(resp.status)]
+	if !assert.True(t, ok, "%s: status %d isn't documented", name, resp.status) {
+		return
+	}
+
+	if method == http.MethodHead || documentedResponse.Content == nil {
+		assert.Empty(t, resp.body, "%s: the response must have no body", name)
+		return
+	}
+	contentType, _, err := mime.ParseMediaType(resp.header.Get("Content-Type"))
+	require.NoError(t, err, name)
+	media, ok := documentedResponse.Content[contentType]
+	if !assert.True(t, ok, "%s: content type %s isn't documented", name, contentType) {
+		return
+	}
+	if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+		assert.NoError(t, api.document.Validate(media.Schema, resp.body), "%s: response body", name)
+	}
+	if contentType == problem.ContentType {
+		checkProblem(t, name, resp)
+	}
+}
+
+// checkProblem checks that an error response is a problem of its status
+// with a code, and that errors pkg/errors registers are reported with the
+// statuses they are registered with
+func checkProblem(t *testing.T, name string, resp contractResponse) {
+	var details problem.Details
+	require.NoError(t, json.Unmarshal(resp.body, &details), name)
+	assert.Equal(t, resp.status, details.Status, "%s: problem status", name)
+	assert.Equal(t, http.StatusText(resp.status), details.Title, "%s: problem title", name)
+	assert.Regexp(t, `^[A-Z]+(_[A-Z]+)*$`, details.Code, "%s: problem code", name)
+
+	var statuses []int
+	for _, kind := range pkgErr.Kinds() {
+		if kind.Code == details.Code {
+			statuses = append(statuses, kind.HTTPStatus)
+		}
+	}
+	if len(statuses) > 0 {
+		assert.Contains(t, statuses, resp.status, "%s: %s is registered with other statuses", name, details.Code)
+	}
+}
+
+// rpcCase is a call of an RPC and the code it must get
+type rpcCase struct {
+	method string
+	call   func(ctx context.Context, api *contractAPI) error
+	code   codes.Code
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// rpcCases cover every RPC of the proto services, in order. The memory
+// driver has no orders and no internal admin API, so those RPCs are
+// unimplemented there.
+var rpcCases = []rpcCase{
+	{method: pb.ConcertService_GetConcert_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).GetConcert(ctx, &pb.GetConcertRequest{Id: api.concertID()})
+		return err
+	}},
+	{method: pb.ConcertService_GetConcert_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).GetConcert(ctx, &pb.GetConcertRequest{Id: 999999})
+		return err
+	}},
+	{method: pb.ConcertService_ListConcerts_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).ListConcerts(ctx, &pb.ListConcertsRequest{PageSize: 5})
+		return err
+	}},
+	{method: pb.ConcertService_ListConcerts_FullMethodName, code: codes.InvalidArgument, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).ListConcerts(ctx, &pb.ListConcertsRequest{Sort: "loudness"})
+		return err
+	}},
+	{method: pb.ConcertService_BatchGetConcerts_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).BatchGetConcerts(ctx, &pb.BatchGetConcertsRequest{Ids: []int64{api.concertID(), 999999}})
+		return err
+	}},
+	{method: pb.ConcertService_CreateConcert_FullMethodName, code: codes.Unauthenticated, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).CreateConcert(ctx, &pb.CreateConcertRequest{Name: "Anonymous"})
+		return err
+	}},
+	{method: pb.ConcertService_CreateConcert_FullMethodName, code: codes.PermissionDenied, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).CreateConcert(withToken(ctx, contractUserToken), &pb.CreateConcertRequest{Name: "Fan Made"})
+		return err
+	}},
+	{method: pb.ConcertService_CreateConcert_FullMethodName, code: codes.InvalidArgument, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).CreateConcert(withToken(ctx, contractOrganizerToken), &pb.CreateConcertRequest{Name: "No Dates"})
+		return err
+	}},
+	{method: pb.ConcertService_CreateConcert_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		now := time.Now()
+		_, err := pb.NewConcertServiceClient(api.conn).CreateConcert(withToken(ctx, contractOrganizerToken), &pb.CreateConcertRequest{
+			Name: "RPC Show", Artist: "Contract Band", Venue: "Spec Hall", ConcertDate: timestamppb.New(now.Add(30 * 24 * time.Hour)),
+			TotalTickets: 100, Price: 30, BookingStartTime: timestamppb.New(now.Add(time.Hour)), BookingEndTime: timestamppb.New(now.Add(48 * time.Hour)),
+		})
+		return err
+	}},
+	{method: pb.ConcertService_UpdateConcert_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewConcertServiceClient(api.conn).UpdateConcert(withToken(ctx, contractOrganizerToken), &pb.UpdateConcertRequest{Id: 999999, Name: "Missing"})
+		return err
+	}},
+	{method: pb.ConcertService_UpdateConcert_FullMethodName, code: codes.Aborted, call: func(ctx context.Context, api *contractAPI) error {
+		client := pb.NewConcertServiceClient(api.conn)
+		concert, err := client.GetConcert(ctx, &pb.GetConcertRequest{Id: api.concertID()})
+		if err != nil {
+			return err
+		}
+		concert.Price++
+		_, err = client.UpdateConcert(withToken(ctx, contractOrganizerToken), &pb.UpdateConcertRequest{
+			Id: concert.Id, Name: concert.Name, Artist: concert.Artist, Venue: concert.Venue, ConcertDate: concert.ConcertDate,
+			TotalTickets: concert.TotalTickets, Price: concert.Price, BookingStartTime: concert.BookingStartTime,
+			BookingEndTime: concert.BookingEndTime, Version: concert.Version - 1,
+		})
+		return err
+	}},
+	{method: pb.ConcertService_UpdateConcert_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		client := pb.NewConcertServiceClient(api.conn)
+		concert, err := client.GetConcert(ctx, &pb.GetConcertRequest{Id: api.concertID()})
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateConcert(withToken(ctx, contractOrganizerToken), &pb.UpdateConcertRequest{
+			Id: concert.Id, Name: concert.Name, Artist: concert.Artist, Venue: concert.Venue, ConcertDate: concert.ConcertDate,
+			TotalTickets: concert.TotalTickets, Price: concert.Price + 1, BookingStartTime: concert.BookingStartTime,
+			BookingEndTime: concert.BookingEndTime, Version: concert.Version,
+		})
+		return err
+	}},
+	{method: pb.ConcertService_WatchConcertAvailability_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		stream, err := pb.NewConcertServiceClient(api.conn).WatchConcertAvailability(ctx, &pb.WatchConcertAvailabilityRequest{ConcertId: api.concertID()})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}},
+	{method: pb.ConcertService_WatchConcertAvailability_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		stream, err := pb.NewConcertServiceClient(api.conn).WatchConcertAvailability(ctx, &pb.WatchConcertAvailabilityRequest{ConcertId: 999999})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}},
+
+	{method: pb.BookingService_BookTickets_FullMethodName, code: codes.Unauthenticated, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).BookTickets(ctx, &pb.BookTicketsRequest{ConcertId: api.concertID(), UserId: contractUser, TicketCount: 1})
+		return err
+	}},
+	{method: pb.BookingService_BookTickets_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).BookTickets(withToken(ctx, contractUserToken),
+			&pb.BookTicketsRequest{ConcertId: api.concertID(), UserId: contractUser, TicketCount: 1, IdempotencyKey: "contract-rpc"})
+		return err
+	}},
+	{method: pb.BookingService_BookTickets_FullMethodName, code: codes.InvalidArgument, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).BookTickets(withToken(ctx, contractUserToken), &pb.BookTicketsRequest{ConcertId: api.concertID(), UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_BookTickets_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).BookTickets(withToken(ctx, contractUserToken), &pb.BookTicketsRequest{ConcertId: 999999, UserId: contractUser, TicketCount: 1})
+		return err
+	}},
+	{method: pb.BookingService_BookTicketsStream_FullMethodName, code: codes.PermissionDenied, call: func(ctx context.Context, api *contractAPI) error {
+		stream, err := pb.NewBookingServiceClient(api.conn).BookTicketsStream(withToken(ctx, contractUserToken))
+		if err != nil {
+			return err
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	}},
+	{method: pb.BookingService_BookTicketsStream_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		stream, err := pb.NewBookingServiceClient(api.conn).BookTicketsStream(withToken(ctx, contractAgencyToken))
+		if err != nil {
+			return err
+		}
+		for _, count := range []int32{1, 0} {
+			if err := stream.Send(&pb.BookTicketsRequest{ConcertId: api.concertID(), UserId: "contract-agency", TicketCount: count}); err != nil {
+				return err
+			}
+		}
+		summary, err := stream.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		// Rejected requests report the codes of the errors registry
+		for _, result := range summary.Results {
+			if result.ErrorReason != "" {
+				assert.Contains(api.t, registeredCodes(), result.ErrorReason, "BookTicketsStream error reason")
+			}
+		}
+		assert.Equal(api.t, int32(1), summary.Failed)
+		return nil
+	}},
+	{method: pb.BookingService_GetBooking_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).GetBooking(withToken(ctx, contractUserToken), &pb.GetBookingRequest{Reference: api.vars["booking"]()})
+		return err
+	}},
+	{method: pb.BookingService_GetBooking_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).GetBooking(withToken(ctx, contractUserToken), &pb.GetBookingRequest{Reference: "ZZZZZZZZZZZZ"})
+		return err
+	}},
+	{method: pb.BookingService_GetUserBookings_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).GetUserBookings(withToken(ctx, contractUserToken), &pb.GetUserBookingsRequest{UserId: contractUser, Expand: "concert"})
+		return err
+	}},
+	{method: pb.BookingService_CancelBooking_FullMethodName, code: codes.PermissionDenied, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).CancelBooking(withToken(ctx, contractUserToken), &pb.CancelBookingRequest{Reference: api.vars["cancelled"](), UserId: "someone-else"})
+		return err
+	}},
+	{method: pb.BookingService_CancelBooking_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).CancelBooking(withToken(ctx, contractUserToken), &pb.CancelBookingRequest{Reference: api.vars["cancelled"](), UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_CancelBooking_FullMethodName, code: codes.FailedPrecondition, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).CancelBooking(withToken(ctx, contractUserToken), &pb.CancelBookingRequest{Reference: api.vars["cancelled"](), UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_IssueBookingToken_FullMethodName, code: codes.OK, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).IssueBookingToken(withToken(ctx, contractUserToken), &pb.IssueBookingTokenRequest{ConcertId: api.concertID(), UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_IssueBookingToken_FullMethodName, code: codes.NotFound, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).IssueBookingToken(withToken(ctx, contractUserToken), &pb.IssueBookingTokenRequest{ConcertId: 999999, UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_GetOrder_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).GetOrder(withToken(ctx, contractUserToken), &pb.GetOrderRequest{Reference: "ZZZZZZZZZZZZ"})
+		return err
+	}},
+	{method: pb.BookingService_GetUserOrders_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).GetUserOrders(withToken(ctx, contractUserToken), &pb.GetUserOrdersRequest{UserId: contractUser})
+		return err
+	}},
+	{method: pb.BookingService_CancelOrder_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewBookingServiceClient(api.conn).CancelOrder(withToken(ctx, contractUserToken), &pb.CancelOrderRequest{Reference: "ZZZZZZZZZZZZ", UserId: contractUser})
+		return err
+	}},
+
+	{method: pb.AdminService_ForceCancelBooking_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewAdminServiceClient(api.conn).ForceCancelBooking(ctx, &pb.ForceCancelBookingRequest{Reference: "ZZZZZZZZZZZZ", Reason: "contract"})
+		return err
+	}},
+	{method: pb.AdminService_AdjustInventory_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewAdminServiceClient(api.conn).AdjustInventory(ctx, &pb.AdjustInventoryRequest{ConcertId: api.concertID(), Delta: 1, Reason: "contract"})
+		return err
+	}},
+	{method: pb.AdminService_CheckInventory_FullMethodName, code: codes.Unimplemented, call: func(ctx context.Context, api *contractAPI) error {
+		_, err := pb.NewAdminServiceClient(api.conn).CheckInventory(ctx, &pb.CheckInventoryRequest{})
+		return err
+	}},
+}
+
+func (api *contractAPI) concertID() int64 {
+	id, err := strconv.ParseInt(api.vars["concert"](), 10, 64)
+	require.NoError(api.t, err)
+	return id
+}
+
+func TestRPCStatusesMatchTheErrorContract(t *testing.T) {
+	api := startContractAPI(t)
+	api.setUp()
+	api.vars["booking"] = fixed(api.book(contractUser))
+	api.vars["cancelled"] = fixed(api.book(contractUser))
+
+	covered := make(map[string]bool)
+	for _, tc := range rpcCases {
+		covered[tc.method] = true
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := tc.call(ctx, api)
+		cancel()
+
+		if assert.Equal(t, tc.code, status.Code(err), "%s: %v", tc.method, err) {
+			checkStatus(t, tc.method, err)
+		}
+	}
+
+	for _, file := range []protoreflect.FileDescriptor{pb.File_api_grpc_proto_concert_proto, pb.File_api_grpc_proto_booking_proto, pb.File_api_grpc_proto_admin_proto} {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := fmt.Sprintf("/%s/%s", services.Get(i).FullName(), methods.Get(j).Name())
+				assert.True(t, covered[method], "%s is in the proto files but has no contract case", method)
+			}
+		}
+	}
+}
+
+// checkStatus checks that an RPC error carries the ErrorInfo of a registered
+// error with the code it is registered with. Only the authorization
+// interceptor, the transport and unimplemented RPCs answer without one.
+func checkStatus(t *testing.T, method string, err error) {
+	if err == nil {
+		return
+	}
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, grpcapi.ErrorDomain, info.Domain, method)
+		var registered []codes.Code
+		for _, kind := range pkgErr.Kinds() {
+			if kind.Code == info.Reason {
+				registered = append(registered, kind.GRPCCode)
+			}
+		}
+		if assert.NotEmpty(t, registered, "%s: reason %s isn't registered", method, info.Reason) {
+			assert.Contains(t, registered, st.Code(), "%s: %s is registered with other codes", method, info.Reason)
+		}
+		return
+	}
+
+	switch st.Code() {
+	case codes.Unauthenticated, codes.PermissionDenied, codes.Unimplemented, codes.Canceled, codes.DeadlineExceeded, codes.Unavailable:
+	default:
+		t.Errorf("%s: %s status without ErrorInfo: %v", method, st.Code(), st.Message())
+	}
+}
+
+// registeredCodes lists the codes of the errors registry
+func registeredCodes() []string {
+	var codes []string
+	for _, kind := range pkgErr.Kinds() {
+		codes = append(codes, kind.Code)
+	}
+	return codes
+}
+
+// sortedSet returns the keys of a map in order
+func sortedSet[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
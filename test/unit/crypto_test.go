@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attendeeEmail is the column the tests bind their values to
+const attendeeEmail = "bookings.attendee_email"
+
+// encryptionKey returns a base64-encoded 256-bit key of one repeated byte
+func encryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestEnvelopeCipherRoundTrip(t *testing.T) {
+	cipher, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+
+	encrypted, err := cipher.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v2:k1:"), encrypted)
+	assert.NotContains(t, encrypted, "ada@example.com")
+
+	again, err := cipher.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "every value gets a fresh data key and nonce")
+
+	decrypted, err := cipher.Decrypt(encrypted, attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decrypted)
+
+	empty, err := cipher.Encrypt("", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Empty(t, empty, "empty values stay empty")
+}
+
+func TestEnvelopeCipherBindsValuesToTheirColumnAndRow(t *testing.T) {
+	cipher, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+
+	_, err = cipher.Decrypt(encrypted, attendeeEmail, "BK2")
+	assert.ErrorContains(t, err, "failed to decrypt value", "a value copied into another row doesn't decrypt")
+	_, err = cipher.Decrypt(encrypted, "bookings.attendee_name", "BK1")
+	assert.ErrorContains(t, err, "failed to decrypt value", "a value copied into another column doesn't decrypt")
+}
+
+// legacyCiphertext encrypts a value like the cipher did before values were
+// bound to their column and row
+func legacyCiphertext(t *testing.T, keyID string, key byte, value string) string {
+	seal := func(key, data []byte) string {
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err)
+		aead, err := cipher.NewGCM(block)
+		require.NoError(t, err)
+		nonce := make([]byte, aead.NonceSize())
+		_, err = rand.Read(nonce)
+		require.NoError(t, err)
+		return base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, data, nil))
+	}
+
+	dataKey := bytes.Repeat([]byte{42}, 32)
+	return "enc:v1:" + keyID + ":" + seal(bytes.Repeat([]byte{key}, 32), dataKey) + ":" + seal(dataKey, []byte(value))
+}
+
+func TestEnvelopeCipherDecryptsLegacyCiphertexts(t *testing.T) {
+	cipher, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+
+	decrypted, err := cipher.Decrypt(legacyCiphertext(t, "k1", 1, "ada@example.com"), attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decrypted, "values written before they were bound are still read")
+}
+
+func TestEnvelopeCipherDecryptsWithRetiredKeysAfterRotation(t *testing.T) {
+	before, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+	old, err := before.Encrypt("Ada Lovelace", attendeeEmail, "BK1")
+	require.NoError(t, err)
+
+	rotated, err := crypto.NewCipher(config.Encryption{KeyID: "k2", Key: encryptionKey(2),
+		RetiredKeys: map[string]string{"k1": encryptionKey(1)}})
+	require.NoError(t, err)
+
+	decrypted, err := rotated.Decrypt(old, attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", decrypted, "values written before the rotation are read with the retired key")
+
+	encrypted, err := rotated.Encrypt("Ada Lovelace", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v2:k2:"), "new values use the active key")
+
+	withoutRetired, err := crypto.NewCipher(config.Encryption{KeyID: "k2", Key: encryptionKey(2)})
+	require.NoError(t, err)
+	_, err = withoutRetired.Decrypt(old, attendeeEmail, "BK1")
+	assert.ErrorContains(t, err, `unknown encryption key "k1"`)
+}
+
+func TestEnvelopeCipherPassesLegacyPlaintextThrough(t *testing.T) {
+	cipher, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+
+	decrypted, err := cipher.Decrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decrypted, "values written before encryption was enabled are read as they are")
+}
+
+func TestEnvelopeCipherRejectsTamperedCiphertext(t *testing.T) {
+	cipher, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+
+	parts := strings.Split(encrypted, ":")
+	require.Len(t, parts, 5)
+	flip := func(encoded string) string {
+		raw, err := base64.RawStdEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		raw[len(raw)-1] ^= 1
+		return base64.RawStdEncoding.EncodeToString(raw)
+	}
+
+	tests := map[string]struct {
+		ciphertext string
+		err        string
+	}{
+		"tampered value":       {strings.Join([]string{parts[0], parts[1], parts[2], parts[3], flip(parts[4])}, ":"), "failed to decrypt value"},
+		"tampered wrapped key": {strings.Join([]string{parts[0], parts[1], parts[2], flip(parts[3]), parts[4]}, ":"), "failed to unwrap data key"},
+		"unknown key id":       {strings.Join([]string{parts[0], parts[1], "k9", parts[3], parts[4]}, ":"), `unknown encryption key "k9"`},
+		"missing part":         {strings.Join(parts[:4], ":"), "malformed ciphertext"},
+		"invalid base64":       {strings.Join([]string{parts[0], parts[1], parts[2], "!!", parts[4]}, ":"), "malformed wrapped key"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := cipher.Decrypt(tt.ciphertext, attendeeEmail, "BK1")
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestCipherValidatesKeys(t *testing.T) {
+	_, err := crypto.NewEnvelopeCipher("k2", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	assert.ErrorContains(t, err, `active encryption key "k2" is not configured`)
+
+	_, err = crypto.NewEnvelopeCipher("k:1", map[string][]byte{"k:1": bytes.Repeat([]byte{1}, 32)})
+	assert.ErrorContains(t, err, "invalid encryption key id")
+
+	_, err = crypto.NewEnvelopeCipher("k1", map[string][]byte{"k1": []byte("short")})
+	assert.ErrorContains(t, err, `invalid encryption key "k1"`)
+
+	_, err = crypto.NewCipher(config.Encryption{KeyID: "k1", Key: "not base64!"})
+	assert.ErrorContains(t, err, `failed to decode encryption key "k1"`)
+}
+
+func TestPlaintextCipherRejectsEncryptedValues(t *testing.T) {
+	plaintext, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(t, err)
+
+	stored, err := plaintext.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", stored, "values are stored as they are without a key")
+	decrypted, err := plaintext.Decrypt(stored, attendeeEmail, "BK1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decrypted)
+
+	envelope, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+	encrypted, err := envelope.Encrypt("ada@example.com", attendeeEmail, "BK1")
+	require.NoError(t, err)
+
+	_, err = plaintext.Decrypt(encrypted, attendeeEmail, "BK1")
+	assert.ErrorContains(t, err, "no encryption key is configured", "encrypted values aren't returned as plaintext")
+	_, err = plaintext.Decrypt(legacyCiphertext(t, "k1", 1, "ada@example.com"), attendeeEmail, "BK1")
+	assert.ErrorContains(t, err, "no encryption key is configured")
+}
+
+func TestPlaintextCipherEscapesValuesThatLookEncrypted(t *testing.T) {
+	plaintext, err := crypto.NewCipher(config.Encryption{})
+	require.NoError(t, err)
+	envelope, err := crypto.NewCipher(config.Encryption{KeyID: "k1", Key: encryptionKey(1)})
+	require.NoError(t, err)
+
+	for _, value := range []string{"enc:v1:k1:abc:def", "enc:v2:k1:abc:def", "enc:raw:ada", "enc:"} {
+		stored, err := plaintext.Encrypt(value, attendeeEmail, "BK1")
+		require.NoError(t, err)
+		assert.NotEqual(t, value, stored)
+
+		decrypted, err := plaintext.Decrypt(stored, attendeeEmail, "BK1")
+		require.NoError(t, err)
+		assert.Equal(t, value, decrypted)
+
+		decrypted, err = envelope.Decrypt(stored, attendeeEmail, "BK1")
+		require.NoError(t, err)
+		assert.Equal(t, value, decrypted, "escaped values are still read once encryption is enabled")
+	}
+}
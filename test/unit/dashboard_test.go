@@ -0,0 +1,348 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOrganizer = "promoter@example.com"
+
+// newDashboardFixture creates the concerts of two organizers and books them
+// in every way a booking can end up
+func newDashboardFixture(t *testing.T) (service.AnalyticsService, []*model.Concert) {
+	t.Helper()
+	ctx := context.Background()
+
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	paymentRepo := mocks.NewMockPaymentRepository(bookingRepo)
+
+	var concerts []*model.Concert
+	for _, c := range []struct {
+		name, organizer, currency string
+		price                     float64
+	}{
+		{"Arena Night", testOrganizer, "USD", 50},
+		{"Club Night", testOrganizer, "USD", 20},
+		{"Berlin Night", testOrganizer, "EUR", 30},
+		{"Other Night", "other@example.com", "USD", 10},
+	} {
+		concert, err := concertRepo.Create(ctx, &model.Concert{
+			Name: c.name, Artist: "Artist", Venue: "Venue",
+			ConcertDate:  time.Now().Add(30 * 24 * time.Hour),
+			TotalTickets: 100, AvailableTickets: 100,
+			Price: c.price, Currency: c.currency,
+			OrganizerEmail: c.organizer,
+		})
+		require.NoError(t, err)
+		concerts = append(concerts, concert)
+	}
+
+	now := time.Now()
+	yesterday := now.Add(-48 * time.Hour)
+	for _, b := range []struct {
+		concert int
+		tickets int
+		status  model.BookingStatus
+		at      time.Time
+		payment string
+	}{
+		{0, 4, model.BookingStatusConfirmed, now, ""},
+		{0, 2, model.BookingStatusConfirmed, yesterday, ""},
+		{0, 1, model.BookingStatusCancelled, yesterday, ""},
+		{1, 3, model.BookingStatusConfirmed, now, model.PaymentSucceeded},
+		{1, 1, model.BookingStatusPending, now, model.PaymentPending},
+		{1, 2, model.BookingStatusCancelled, now, model.PaymentFailed},
+		{1, 1, model.BookingStatusCancelled, yesterday, model.PaymentRefunded},
+		{2, 2, model.BookingStatusConfirmed, now, ""},
+		{3, 9, model.BookingStatusConfirmed, now, ""},
+	} {
+		concert := concerts[b.concert]
+		booking, err := bookingRepo.Create(ctx, &model.Booking{
+			ConcertID: concert.ID, UserID: "user-1", TicketCount: b.tickets,
+			UnitPrice: concert.Price, Status: b.status, BookingTime: b.at,
+		})
+		require.NoError(t, err)
+
+		if b.payment != "" {
+			require.NoError(t, paymentRepo.Create(ctx, &model.Payment{
+				BookingID: booking.ID, Provider: "fake", ProviderReference: booking.Reference,
+				Amount: float64(b.tickets) * concert.Price, Currency: concert.Currency, Status: b.payment,
+			}))
+		}
+	}
+
+	return service.NewAnalyticsService(mocks.NewMockAnalyticsRepository(paymentRepo), concertRepo), concerts
+}
+
+func TestOrganizerDashboardAggregatesTheOrganizersConcerts(t *testing.T) {
+	analytics, concerts := newDashboardFixture(t)
+
+	dashboard, err := analytics.OrganizerDashboard(context.Background(), testOrganizer, time.UTC, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, testOrganizer, dashboard.OrganizerEmail)
+	assert.Equal(t, 3, dashboard.Concerts, "the other organizer's concert isn't counted")
+	assert.Equal(t, 24*time.Hour, dashboard.DayEnd.Sub(dashboard.DayStart))
+	assert.False(t, dashboard.GeneratedAt.Before(dashboard.DayStart))
+
+	// Only the confirmed bookings of today, per currency
+	assert.Equal(t, []*model.CurrencySales{
+		{Currency: "EUR", Bookings: 1, Tickets: 2, Gross: 60},
+		{Currency: "USD", Bookings: 2, Tickets: 7, Gross: 260},
+	}, dashboard.SalesToday)
+
+	require.Len(t, dashboard.TopConcerts, 2)
+	assert.Equal(t, concerts[0].ID, dashboard.TopConcerts[0].ConcertID)
+	assert.Equal(t, 6, dashboard.TopConcerts[0].TicketsSold)
+	assert.Equal(t, 300.0, dashboard.TopConcerts[0].Gross)
+	assert.Equal(t, concerts[1].ID, dashboard.TopConcerts[1].ConcertID)
+	assert.Equal(t, 3, dashboard.TopConcerts[1].TicketsSold)
+
+	// The refunded hold was confirmed before it was cancelled; the failed one lapsed
+	assert.Equal(t, model.HoldConversion{Holds: 4, Pending: 1, Confirmed: 2, Lapsed: 1, Rate: 2.0 / 3}, dashboard.Holds)
+	assert.Equal(t, model.CancellationStats{Bookings: 6, Cancelled: 2, Rate: 2.0 / 6}, dashboard.Cancellations)
+}
+
+func TestOrganizerDashboardOfAnOrganizerWithoutConcerts(t *testing.T) {
+	analytics, _ := newDashboardFixture(t)
+
+	dashboard, err := analytics.OrganizerDashboard(context.Background(), "new@example.com", time.UTC, 5)
+	require.NoError(t, err)
+	assert.Zero(t, dashboard.Concerts)
+	assert.Empty(t, dashboard.SalesToday)
+	assert.NotNil(t, dashboard.TopConcerts, "renders as an empty list")
+	assert.Zero(t, dashboard.Holds.Rate)
+	assert.Zero(t, dashboard.Cancellations.Rate)
+}
+
+func TestOrganizerDashboardStartsTheDayInTheTimeZone(t *testing.T) {
+	analytics, _ := newDashboardFixture(t)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	dashboard, err := analytics.OrganizerDashboard(context.Background(), testOrganizer, tokyo, 5)
+	require.NoError(t, err)
+	assert.Equal(t, tokyo, dashboard.DayStart.Location())
+	assert.Zero(t, dashboard.DayStart.Hour())
+}
+
+func TestOrganizerDashboardEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	analytics, _ := newDashboardFixture(t)
+
+	router := gin.New()
+	organizerHandler := handler.NewOrganizerHandler(analytics)
+	organizerHandler.RegisterRoutes(router.Group("/api/v1"), middleware.OrganizerAuth([]config.OrganizerAccount{
+		{Email: testOrganizer, Token: "promoter-token"},
+		{Email: "new@example.com", Token: "new-token"},
+	}))
+
+	get := func(url, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/api/v1/organizers/me/dashboard", "").Code)
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/organizers/me/dashboard", "admin-token").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/organizers/me/dashboard?tz=Mars/Olympus", "promoter-token").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/organizers/me/dashboard?top=0", "promoter-token").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/organizers/me/dashboard?top=51", "promoter-token").Code)
+
+	// Each token only sees the concerts of its organizer
+	for token, concerts := range map[string]int{"promoter-token": 3, "new-token": 0} {
+		w := get("/api/v1/organizers/me/dashboard?tz=Europe/Berlin&top=1", token)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var dashboard model.OrganizerDashboard
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dashboard))
+		assert.Equal(t, concerts, dashboard.Concerts, token)
+		assert.LessOrEqual(t, len(dashboard.TopConcerts), 1)
+	}
+
+	// The dashboard is documented behind the organizer token
+	document := openapi.Build(openapi.Info{Title: "test", Version: "1"}, openapi.Mount("/api/v1", organizerHandler.Operations(), false))
+	operation := document.Operation(http.MethodGet, "/api/v1/organizers/me/dashboard")
+	require.NotNil(t, operation)
+	assert.Equal(t, []map[string][]string{{"organizerToken": {}}}, operation.Security)
+	assert.Contains(t, document.Components.SecuritySchemes, "organizerToken")
+}
+
+func TestOrganizerAuthIsDisabledWithoutAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/dashboard", middleware.OrganizerAuth(nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// newTimeseriesFixture books a concert of the test organizer at fixed times
+// on June 1st, 2026, and returns it with a concert of another organizer
+func newTimeseriesFixture(t *testing.T) (service.AnalyticsService, *model.Concert, *model.Concert) {
+	t.Helper()
+	ctx := context.Background()
+
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	paymentRepo := mocks.NewMockPaymentRepository(bookingRepo)
+
+	var concerts []*model.Concert
+	for _, organizer := range []string{testOrganizer, "other@example.com"} {
+		concert, err := concertRepo.Create(ctx, &model.Concert{
+			Name: "Night", Artist: "Artist", Venue: "Venue",
+			ConcertDate:  time.Date(2026, 7, 1, 20, 0, 0, 0, time.UTC),
+			TotalTickets: 100, AvailableTickets: 100,
+			Price: 20, Currency: "EUR",
+			OrganizerEmail: organizer,
+		})
+		require.NoError(t, err)
+		concerts = append(concerts, concert)
+	}
+
+	for _, b := range []struct {
+		at      string
+		tickets int
+		status  model.BookingStatus
+	}{
+		{"2026-06-01T10:15:00Z", 2, model.BookingStatusConfirmed},
+		{"2026-06-01T10:45:00Z", 1, model.BookingStatusConfirmed},
+		{"2026-06-01T11:30:00Z", 5, model.BookingStatusCancelled},
+		{"2026-06-01T12:05:00Z", 3, model.BookingStatusConfirmed},
+		{"2026-06-01T23:30:00Z", 1, model.BookingStatusConfirmed},
+	} {
+		at, err := time.Parse(time.RFC3339, b.at)
+		require.NoError(t, err)
+		_, err = bookingRepo.Create(ctx, &model.Booking{
+			ConcertID: concerts[0].ID, UserID: "user-1", TicketCount: b.tickets,
+			UnitPrice: 20, Status: b.status, BookingTime: at,
+		})
+		require.NoError(t, err)
+	}
+
+	return service.NewAnalyticsService(mocks.NewMockAnalyticsRepository(paymentRepo), concertRepo), concerts[0], concerts[1]
+}
+
+func TestSalesTimeseriesFillsEveryHour(t *testing.T) {
+	analytics, concert, _ := newTimeseriesFixture(t)
+
+	timeseries, err := analytics.ConcertSalesTimeseries(context.Background(), testOrganizer, concert.ID, model.SalesTimeseriesQuery{
+		Interval: model.SalesIntervalHour,
+		From:     time.Date(2026, 6, 1, 10, 20, 0, 0, time.UTC),
+		To:       time.Date(2026, 6, 1, 13, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "EUR", timeseries.Currency)
+	assert.Equal(t, time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC), timeseries.From, "the first hour is whole")
+	require.Len(t, timeseries.Buckets, 3)
+	assert.Equal(t, model.SalesBucket{Start: time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC), Bookings: 2, Tickets: 3, Revenue: 60}, *timeseries.Buckets[0])
+	assert.Equal(t, model.SalesBucket{Start: time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC)}, *timeseries.Buckets[1], "the cancelled booking isn't a sale")
+	assert.Equal(t, model.SalesBucket{Start: time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC), Bookings: 1, Tickets: 3, Revenue: 60}, *timeseries.Buckets[2])
+}
+
+func TestSalesTimeseriesBucketsDaysInTheTimeZone(t *testing.T) {
+	analytics, concert, _ := newTimeseriesFixture(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	timeseries, err := analytics.ConcertSalesTimeseries(context.Background(), testOrganizer, concert.ID, model.SalesTimeseriesQuery{
+		Interval: model.SalesIntervalDay,
+		Location: berlin,
+		From:     time.Date(2026, 6, 1, 0, 0, 0, 0, berlin),
+		To:       time.Date(2026, 6, 3, 0, 0, 0, 0, berlin),
+	})
+	require.NoError(t, err)
+
+	// 23:30 UTC is past midnight in Berlin
+	require.Len(t, timeseries.Buckets, 2)
+	assert.True(t, timeseries.Buckets[0].Start.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, berlin)))
+	assert.Equal(t, 6, timeseries.Buckets[0].Tickets)
+	assert.Equal(t, 120.0, timeseries.Buckets[0].Revenue)
+	assert.True(t, timeseries.Buckets[1].Start.Equal(time.Date(2026, 6, 2, 0, 0, 0, 0, berlin)))
+	assert.Equal(t, 1, timeseries.Buckets[1].Tickets)
+}
+
+func TestSalesTimeseriesRejectsInvalidQueries(t *testing.T) {
+	analytics, concert, other := newTimeseriesFixture(t)
+	ctx := context.Background()
+	june := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := analytics.ConcertSalesTimeseries(ctx, testOrganizer, other.ID, model.SalesTimeseriesQuery{Interval: model.SalesIntervalHour})
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound, "another organizer's concert is hidden")
+	_, err = analytics.ConcertSalesTimeseries(ctx, testOrganizer, other.ID+1, model.SalesTimeseriesQuery{Interval: model.SalesIntervalHour})
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound)
+
+	for name, query := range map[string]model.SalesTimeseriesQuery{
+		"unknown interval": {Interval: "week"},
+		"empty range":      {Interval: model.SalesIntervalHour, From: june, To: june},
+		"too many buckets": {Interval: model.SalesIntervalHour, From: june, To: june.AddDate(0, 2, 0)},
+	} {
+		_, err := analytics.ConcertSalesTimeseries(ctx, testOrganizer, concert.ID, query)
+		var errWithMsg *pkgErr.ErrorWithMessage
+		assert.ErrorAs(t, err, &errWithMsg, name)
+	}
+
+	timeseries, err := analytics.ConcertSalesTimeseries(ctx, testOrganizer, concert.ID, model.SalesTimeseriesQuery{Interval: model.SalesIntervalDay})
+	require.NoError(t, err)
+	assert.Len(t, timeseries.Buckets, 31, "the last 30 days up to today by default")
+}
+
+func TestSalesTimeseriesEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	analytics, concert, other := newTimeseriesFixture(t)
+
+	router := gin.New()
+	handler.NewOrganizerHandler(analytics).RegisterRoutes(router.Group("/api/v1"), middleware.OrganizerAuth([]config.OrganizerAccount{
+		{Email: testOrganizer, Token: "promoter-token"},
+	}))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer promoter-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	path := fmt.Sprintf("/api/v1/concerts/%d/sales-timeseries", concert.ID)
+
+	w := get(path + "?interval=day&from=2026-06-01T00:00:00%2B02:00&to=2026-06-03T00:00:00%2B02:00&tz=Europe/Berlin")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var timeseries model.SalesTimeseries
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &timeseries))
+	assert.Equal(t, model.SalesIntervalDay, timeseries.Interval)
+	require.Len(t, timeseries.Buckets, 2)
+	assert.Equal(t, 6, timeseries.Buckets[0].Tickets)
+
+	assert.Equal(t, http.StatusNotFound, get(fmt.Sprintf("/api/v1/concerts/%d/sales-timeseries", other.ID)).Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/concerts/abc/sales-timeseries").Code)
+	assert.Equal(t, http.StatusBadRequest, get(path+"?interval=week").Code)
+	assert.Equal(t, http.StatusBadRequest, get(path+"?from=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, get(path+"?tz=Local").Code)
+}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDebugTestServer() http.Handler {
+	gin.SetMode(gin.TestMode)
+	return rest.NewDebugServer(logger.NewLogger("error"), config.Debug{
+		Port: 6060, Host: "127.0.0.1", AllowedNetworks: []string{"127.0.0.1/32"},
+	}).Handler()
+}
+
+func debugRequest(server http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestDebugServerServesProfiles(t *testing.T) {
+	server := newDebugTestServer()
+
+	recorder := debugRequest(server, "/debug/pprof/", "127.0.0.1:40000")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "goroutine")
+
+	recorder = debugRequest(server, "/debug/pprof/heap?debug=1", "127.0.0.1:40000")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "heap profile")
+
+	recorder = debugRequest(server, "/debug/pprof/nonsense", "127.0.0.1:40000")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestDebugServerReportsGCStatsAndGoroutines(t *testing.T) {
+	server := newDebugTestServer()
+
+	recorder := debugRequest(server, "/debug/gc", "127.0.0.1:40000")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var stats handler.GCStats
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.GOMAXPROCS)
+	assert.Positive(t, stats.HeapAlloc)
+
+	recorder = debugRequest(server, "/debug/goroutines", "127.0.0.1:40000")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Body.String(), "goroutine "), "the dump is in the format of a panic")
+	assert.Contains(t, recorder.Body.String(), "TestDebugServerReportsGCStatsAndGoroutines")
+}
+
+func TestDebugServerOnlyServesAllowedNetworks(t *testing.T) {
+	server := newDebugTestServer()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/gc", "/debug/goroutines"} {
+		assert.Equal(t, http.StatusForbidden, debugRequest(server, path, "203.0.113.7:40000").Code, path)
+	}
+}
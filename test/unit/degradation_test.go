@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDegradationRouter(registry *health.Registry, maxStaleness time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Degradation(config.Degradation{
+		Enabled:      true,
+		CacheEntries: 10,
+		MaxStaleness: maxStaleness,
+		RetryAfter:   30 * time.Second,
+	}, registry))
+
+	calls := 0
+	router.GET("/api/v1/concerts/:id", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "calls": calls})
+	})
+	router.POST("/api/v1/bookings", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return router
+}
+
+func serve(router *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	return recorder
+}
+
+func TestHealthRegistryFailureThreshold(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 2)
+	registry.Register(health.Database, nil)
+	assert.True(t, registry.Healthy(health.Database))
+
+	registry.Report(health.Database, errors.New("connection refused"))
+	assert.True(t, registry.Healthy(health.Database), "a single failure must not flip the state")
+
+	registry.Report(health.Database, errors.New("connection refused"))
+	assert.False(t, registry.Healthy(health.Database))
+	assert.False(t, registry.AllHealthy())
+	assert.Equal(t, "connection refused", registry.Statuses()[0].Error)
+
+	registry.Report(health.Database, nil)
+	assert.True(t, registry.Healthy(health.Database))
+}
+
+func TestDegradationServesStaleReads(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 1)
+	registry.Register(health.Database, nil)
+	router := newDegradationRouter(registry, time.Hour)
+
+	fresh := serve(router, http.MethodGet, "/api/v1/concerts/1")
+	require.Equal(t, http.StatusOK, fresh.Code)
+	assert.Empty(t, fresh.Header().Get("Warning"))
+
+	registry.Report(health.Database, errors.New("connection refused"))
+
+	// Cached reads are served with a staleness warning instead of hitting the handler
+	stale := serve(router, http.MethodGet, "/api/v1/concerts/1")
+	require.Equal(t, http.StatusOK, stale.Code)
+	assert.Equal(t, fresh.Body.String(), stale.Body.String())
+	assert.Contains(t, stale.Header().Get("Warning"), "110")
+	fetchedAt, err := time.Parse(time.RFC3339, stale.Header().Get("X-Data-Fetched-At"))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), fetchedAt, time.Minute)
+
+	// Uncached reads and writes are unavailable
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router, http.MethodGet, "/api/v1/concerts/2").Code)
+	write := serve(router, http.MethodPost, "/api/v1/bookings")
+	assert.Equal(t, http.StatusServiceUnavailable, write.Code)
+	assert.Equal(t, "30", write.Header().Get("Retry-After"))
+
+	// Fresh data is served again once the database recovers
+	registry.Report(health.Database, nil)
+	recovered := serve(router, http.MethodGet, "/api/v1/concerts/1")
+	assert.Empty(t, recovered.Header().Get("Warning"))
+	assert.NotEqual(t, fresh.Body.String(), recovered.Body.String())
+}
+
+func TestDegradationDoesNotServeExpiredEntries(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 1)
+	registry.Register(health.Database, nil)
+	router := newDegradationRouter(registry, time.Nanosecond)
+
+	require.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/api/v1/concerts/1").Code)
+	registry.Report(health.Database, errors.New("connection refused"))
+
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router, http.MethodGet, "/api/v1/concerts/1").Code)
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createDoorPricedConcert creates a concert whose door price applies from an hour from now
+func createDoorPricedConcert(t *testing.T, repo *mocks.MockConcertRepository) *model.Concert {
+	doorPrice := 40.0
+	concert, err := repo.Create(context.Background(), &model.Concert{
+		Name:                 "Concert",
+		Artist:               "Artist",
+		Venue:                "Venue",
+		ConcertDate:          time.Now().Add(3 * time.Hour),
+		TotalTickets:         100,
+		AvailableTickets:     100,
+		Price:                25,
+		Currency:             "USD",
+		DoorPrice:            &doorPrice,
+		DoorPriceLeadMinutes: 120,
+		BookingStartTime:     time.Now().Add(-time.Hour),
+		BookingEndTime:       time.Now().Add(3 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func TestDoorPriceAppliesFromLeadBeforeShowtime(t *testing.T) {
+	defer clock.Process().Reset()
+
+	concertRepo := mocks.NewMockConcertRepository()
+	concert := createDoorPricedConcert(t, concertRepo)
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	quote, err := concertService.Quote(context.Background(), concert.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.PricingPhaseAdvance, quote.PricingPhase)
+	assert.Equal(t, 25.0, quote.UnitPrice)
+	assert.Equal(t, 50.0, quote.Total)
+	require.NotNil(t, quote.ValidUntil)
+	assert.True(t, quote.ValidUntil.Equal(concert.DoorPriceStartsAt()))
+
+	early, err := bookingService.BookTickets(context.Background(), &model.BookingRequest{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, early.UnitPrice)
+
+	clock.Process().Advance(90 * time.Minute)
+
+	quote, err = concertService.Quote(context.Background(), concert.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.PricingPhaseDoor, quote.PricingPhase)
+	assert.Equal(t, 80.0, quote.Total)
+	assert.Nil(t, quote.ValidUntil)
+
+	late, err := bookingService.BookTickets(context.Background(), &model.BookingRequest{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, late.UnitPrice)
+
+	concert.SetPricing()
+	assert.Equal(t, model.PricingPhaseDoor, concert.PricingPhase)
+	assert.Equal(t, 40.0, concert.CurrentPrice)
+}
+
+func TestQuoteRejectsInvalidTicketCounts(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	concert := createDoorPricedConcert(t, concertRepo)
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+
+	for _, count := range []int{0, 11} {
+		_, err := concertService.Quote(context.Background(), concert.ID, count)
+		assert.Error(t, err, "ticket count %d", count)
+	}
+}
+
+func TestPricingServiceAnnouncesSwitchOnce(t *testing.T) {
+	defer clock.Process().Reset()
+
+	concertRepo := mocks.NewMockConcertRepository()
+	concert := createDoorPricedConcert(t, concertRepo)
+
+	bus := events.NewBus()
+	sub := bus.Subscribe(model.EventConcertPricing, 0, 4)
+	defer sub.Close()
+	pricingService := service.NewPricingService(concertRepo, bus)
+
+	switched, err := pricingService.SwitchDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, switched, "the door price doesn't apply yet")
+
+	clock.Process().Advance(90 * time.Minute)
+
+	switched, err = pricingService.SwitchDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, switched)
+
+	event := <-sub.Events()
+	change, ok := event.Payload.(*model.PricingPhaseChange)
+	require.True(t, ok)
+	assert.Equal(t, concert.ID, change.ConcertID)
+	assert.Equal(t, model.PricingPhaseDoor, change.Phase)
+	assert.Equal(t, 40.0, change.Price)
+
+	switched, err = pricingService.SwitchDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, switched, "a switch is announced once")
+}
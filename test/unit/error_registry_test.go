@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"concert-ticket-api/api/rest/problem"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// registeredErrors are the errors pkg/errors declares, by name
+var registeredErrors = map[string]error{
+	"ErrNotFound":                pkgErr.ErrNotFound,
+	"ErrUnauthorized":            pkgErr.ErrUnauthorized,
+	"ErrForbidden":               pkgErr.ErrForbidden,
+	"ErrInternalServer":          pkgErr.ErrInternalServer,
+	"ErrOptimisticLockFailed":    pkgErr.ErrOptimisticLockFailed,
+	"ErrUpdateFailed":            pkgErr.ErrUpdateFailed,
+	"ErrInsufficientTickets":     pkgErr.ErrInsufficientTickets,
+	"ErrBookingClosed":           pkgErr.ErrBookingClosed,
+	"ErrBookingAlreadyCancelled": pkgErr.ErrBookingAlreadyCancelled,
+	"ErrOrderAlreadyCancelled":   pkgErr.ErrOrderAlreadyCancelled,
+	"ErrBookingTokenRequired":    pkgErr.ErrBookingTokenRequired,
+	"ErrInvalidBookingToken":     pkgErr.ErrInvalidBookingToken,
+	"ErrRateLimited":             pkgErr.ErrRateLimited,
+	"ErrInviteRedeemed":          pkgErr.ErrInviteRedeemed,
+	"ErrIdempotencyKeyInUse":     pkgErr.ErrIdempotencyKeyInUse,
+	"ErrPaymentUnavailable":      pkgErr.ErrPaymentUnavailable,
+	"ErrServiceUnavailable":      pkgErr.ErrServiceUnavailable,
+	"ErrInvalidInput":            pkgErr.ErrInvalidInput("ticket_count must be positive"),
+}
+
+// declaredErrors returns the names of the exported Err variables and
+// constructors in pkg/errors
+func declaredErrors(t *testing.T) []string {
+	t.Helper()
+
+	packages, err := parser.ParseDir(token.NewFileSet(), "../../pkg/errors", nil, 0)
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range packages["errors"].Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						if strings.HasPrefix(name.Name, "Err") {
+							names = append(names, name.Name)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil && strings.HasPrefix(decl.Name.Name, "Err") {
+					names = append(names, decl.Name.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestEveryErrorIsRegistered(t *testing.T) {
+	names := declaredErrors(t)
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		err, ok := registeredErrors[name]
+		if !assert.True(t, ok, "pkg/errors declares %s; register its kind and add it to registeredErrors", name) {
+			continue
+		}
+
+		kind, ok := pkgErr.Lookup(err)
+		assert.True(t, ok, "%s has no registered kind", name)
+		assert.NotEmpty(t, kind.Code, name)
+		assert.NotEmpty(t, kind.Message, name)
+		assert.NotEmpty(t, http.StatusText(kind.HTTPStatus), "%s has no valid HTTP status", name)
+		assert.NotEqual(t, codes.OK, kind.GRPCCode, name)
+		assert.Equal(t, kind.Precondition != "", kind.GRPCCode == codes.FailedPrecondition,
+			"%s describes its precondition exactly if it is a failed precondition", name)
+
+		wrapped := fmt.Errorf("failed to do something: %w", err)
+		wrappedKind, _ := pkgErr.Lookup(wrapped)
+		assert.Equal(t, kind.Code, wrappedKind.Code, "wrapped %s keeps its kind", name)
+	}
+	assert.Len(t, pkgErr.Kinds(), len(names), "every registered kind is declared by pkg/errors")
+}
+
+func TestUnregisteredErrorsAreInternal(t *testing.T) {
+	kind, ok := pkgErr.Lookup(fmt.Errorf("pq: connection refused"))
+	assert.False(t, ok)
+	assert.Equal(t, pkgErr.CodeInternal, kind.Code)
+	assert.Equal(t, http.StatusInternalServerError, kind.HTTPStatus)
+	assert.Equal(t, codes.Internal, kind.GRPCCode)
+	assert.Equal(t, "Failed to book tickets", pkgErr.MessageOf(fmt.Errorf("pq: connection refused"), "Failed to book tickets"))
+}
+
+func TestTransportsReportRegisteredKinds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for name, err := range registeredErrors {
+		kind, _ := pkgErr.Lookup(err)
+
+		st, reason := statusDetails(t, err)
+		assert.Equal(t, kind.GRPCCode, st.Code(), name)
+		assert.Equal(t, kind.Code, reason, "%s has the same code over gRPC", name)
+
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) { problem.Error(c, err, "Failed") })
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, kind.HTTPStatus, recorder.Code, name)
+		details := decodeProblem(t, recorder)
+		assert.Equal(t, kind.Code, details.Code, "%s has the same code over REST", name)
+		if kind.Err != pkgErr.ErrInternalServer {
+			assert.Equal(t, st.Message(), details.Detail, "%s has the same message over both transports", name)
+		}
+	}
+}
+
+func TestRetryableProblemsSetRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/limited", func(c *gin.Context) { problem.Error(c, pkgErr.ErrRateLimited, "Failed") })
+	router.GET("/conflict", func(c *gin.Context) { problem.Error(c, pkgErr.ErrOptimisticLockFailed, "Failed") })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/conflict", nil))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Retry-After"), "Retry-After has a resolution of seconds")
+}
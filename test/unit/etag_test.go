@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newETagTestRouter(t *testing.T) (*gin.Engine, *model.Concert) {
+	gin.SetMode(gin.TestMode)
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+	return router, concert
+}
+
+func putConcert(t *testing.T, router *gin.Engine, concert *model.Concert, ifMatch string) *httptest.ResponseRecorder {
+	body, err := json.Marshal(concert)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/concerts/"+strconv.FormatInt(concert.ID, 10), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestConcertUpdatesRequireMatchingETag(t *testing.T) {
+	router, concert := newETagTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts/"+strconv.FormatInt(concert.ID, 10), nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	concert.Name = "Renamed"
+
+	recorder = putConcert(t, router, concert, "")
+	assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+
+	for _, invalid := range []string{"*", "1", `"one"`, `"1", "2"`} {
+		recorder = putConcert(t, router, concert, invalid)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, invalid)
+	}
+
+	recorder = putConcert(t, router, concert, etag)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+
+	// A second update based on the same ETag lost the race
+	concert.Name = "Renamed again"
+	recorder = putConcert(t, router, concert, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+	// The body version is ignored, and weakened tags still match
+	concert.Version = 1
+	recorder = putConcert(t, router, concert, `W/"2"`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBookingsExpandsConcerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bookingService, _, bookingRepo, concert := newRetryBookings(t, 3)
+	_, err := bookingRepo.Create(context.Background(), &model.Booking{ConcertID: concert.ID, UserID: "alice", TicketCount: 2, Status: model.BookingStatusConfirmed})
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler.NewBookingHandler(bookingService).RegisterRoutes(router.Group("/api/v1"))
+	list := func(path string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		}
+		return recorder, resp.Data
+	}
+
+	recorder, data := list("/api/v1/bookings?userID=alice")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, data, 1)
+	assert.NotContains(t, data[0], "concert", "concerts are only embedded on request")
+
+	recorder, data = list("/api/v1/bookings?userID=alice&expand=concert")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, data, 1)
+	embedded, ok := data[0]["concert"].(map[string]interface{})
+	require.True(t, ok, recorder.Body.String())
+	assert.Equal(t, float64(concert.ID), embedded["id"])
+	assert.Equal(t, "Retry Night", embedded["name"])
+	assert.Equal(t, "Venue", embedded["venue"])
+
+	// Sparse fieldsets can keep the embedded concert
+	recorder, data = list("/api/v1/bookings?userID=alice&expand=concert&fields=concert")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{"concert", "reference"}, keysOf(data[0]))
+
+	recorder, _ = list("/api/v1/bookings?userID=alice&expand=venue")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestGetUserBookingsRPCExpandsConcerts(t *testing.T) {
+	bookingService, _, bookingRepo, concert := newRetryBookings(t, 3)
+	ctx := context.Background()
+	_, err := bookingRepo.Create(ctx, &model.Booking{ConcertID: concert.ID, UserID: "alice", TicketCount: 2, Status: model.BookingStatusConfirmed})
+	require.NoError(t, err)
+	server := grpcapi.NewServer(nil, bookingService, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+
+	resp, err := server.GetUserBookings(ctx, &pb.GetUserBookingsRequest{UserId: "alice"})
+	require.NoError(t, err)
+	require.Len(t, resp.Bookings, 1)
+	assert.Nil(t, resp.Bookings[0].Concert)
+
+	resp, err = server.GetUserBookings(ctx, &pb.GetUserBookingsRequest{UserId: "alice", Expand: "concert"})
+	require.NoError(t, err)
+	require.Len(t, resp.Bookings, 1)
+	require.NotNil(t, resp.Bookings[0].Concert)
+	assert.Equal(t, concert.ID, resp.Bookings[0].Concert.Id)
+	assert.Equal(t, "Artist", resp.Bookings[0].Concert.Artist)
+	assert.True(t, concert.ConcertDate.Equal(resp.Bookings[0].Concert.ConcertDate.AsTime()))
+
+	_, err = server.GetUserBookings(ctx, &pb.GetUserBookingsRequest{UserId: "alice", Expand: "venue"})
+	assert.Error(t, err)
+}
+
+func TestSQLiteBookingsWithConcertsReadTheJoin(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupSQLite(t)
+	concert := createStoredConcert(t, concerts, 3)
+	require.NoError(t, bookings.CreateWithTicketUpdate(ctx, &model.Booking{
+		Reference: "BK-EXPAND-1", ConcertID: concert.ID, UserID: "user-1", TicketCount: 1,
+		Status: model.BookingStatusConfirmed, AttendeeName: "Ada",
+	}, concert.Version))
+
+	expanded, err := bookings.GetByUserIDWithConcerts(ctx, "user-1", query.NewPage(1, 10))
+	require.NoError(t, err)
+	require.Len(t, expanded, 1)
+	require.NotNil(t, expanded[0].Concert)
+	assert.Equal(t, concert.ID, expanded[0].Concert.ID)
+	assert.Equal(t, "Flat File Live", expanded[0].Concert.Name)
+	assert.Equal(t, "The Pages", expanded[0].Concert.Artist)
+	assert.Equal(t, "Journal Hall", expanded[0].Concert.Venue)
+	assert.WithinDuration(t, concert.ConcertDate, expanded[0].Concert.ConcertDate, time.Second)
+	assert.Equal(t, "Ada", expanded[0].AttendeeName)
+
+	plain, err := bookings.GetByUserID(ctx, "user-1", query.NewPage(1, 10))
+	require.NoError(t, err)
+	require.Len(t, plain, 1)
+	assert.Nil(t, plain[0].Concert)
+}
+
+func TestMemoryBookingsWithConcerts(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	concert := createStoredConcert(t, concerts, 3)
+	require.NoError(t, bookings.CreateWithTicketUpdate(ctx, &model.Booking{
+		Reference: "BK-EXPAND-1", ConcertID: concert.ID, UserID: "user-1", TicketCount: 1,
+		Status: model.BookingStatusConfirmed,
+	}, concert.Version))
+
+	expanded, err := bookings.GetByUserIDWithConcerts(ctx, "user-1", query.NewPage(1, 10))
+	require.NoError(t, err)
+	require.Len(t, expanded, 1)
+	assert.Equal(t, model.SummarizeConcert(concert), expanded[0].Concert)
+}
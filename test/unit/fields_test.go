@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestParseFields(t *testing.T) {
+	allowed := query.FieldNames(model.Concert{})
+	assert.Contains(t, allowed, "price_display")
+	assert.NotContains(t, allowed, "DoorPriceSwitchedAt", "fields hidden from JSON can't be selected")
+
+	fields, err := query.ParseFields(" name, price,name", allowed)
+	require.NoError(t, err)
+	assert.Equal(t, query.Fields{"name", "price"}, fields)
+
+	fields, err = query.ParseFields("", allowed)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = query.ParseFields("name,secret", allowed)
+	assert.ErrorContains(t, err, `unknown field "secret"`)
+}
+
+func TestListConcertsSelectsFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(newQueryTestRepository(t), nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+
+	list := func(path string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		}
+		return recorder, resp.Data
+	}
+
+	recorder, data := list("/api/v1/concerts?fields=name,price&sort=price")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, data, 3)
+	assert.Equal(t, map[string]interface{}{"id": float64(2), "name": "Concert 1", "price": float64(10)}, data[0])
+
+	var full handler.ConcertListResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &full))
+	assert.Equal(t, 3, full.Meta.TotalCount, "the meta is kept")
+
+	recorder, data = list("/api/v1/concerts?ids=1,3&fields=available_tickets")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, data, 2)
+	assert.Equal(t, []string{"available_tickets", "id"}, keysOf(data[0]))
+
+	recorder, _ = list("/api/v1/concerts?fields=name,organizer_password")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestListBookingsSelectsFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bookingRepo := mocks.NewMockBookingRepository()
+	_, err := bookingRepo.Create(context.Background(), &model.Booking{ConcertID: 1, UserID: "alice", TicketCount: 2, Status: model.BookingStatusConfirmed})
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler.NewBookingHandler(service.NewBookingService(bookingRepo, mocks.NewMockConcertRepository(), service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)).RegisterRoutes(router.Group("/api/v1"))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/bookings?userID=alice&fields=status", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, []string{"reference", "status"}, keysOf(resp.Data[0]), "bookings are identified by their reference")
+}
+
+func TestListConcertsRPCReadMask(t *testing.T) {
+	server := grpcapi.NewServer(service.NewConcertService(newQueryTestRepository(t), nil, model.BookingLimits{}, nil), nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+
+	resp, err := server.ListConcerts(context.Background(), &pb.ListConcertsRequest{
+		Sort:     "price",
+		ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "price"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Concerts, 3)
+	assert.Equal(t, int64(2), resp.Concerts[0].Id, "the id is always returned")
+	assert.Equal(t, "Concert 1", resp.Concerts[0].Name)
+	assert.Equal(t, 10.0, resp.Concerts[0].Price)
+	assert.Empty(t, resp.Concerts[0].Artist)
+	assert.Nil(t, resp.Concerts[0].ConcertDate)
+
+	batch, err := server.BatchGetConcerts(context.Background(), &pb.BatchGetConcertsRequest{
+		Ids:      []int64{1},
+		ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"available_tickets"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), batch.Concerts[0].AvailableTickets)
+	assert.Empty(t, batch.Concerts[0].Name)
+
+	for _, path := range []string{"secret", "concert_date.seconds"} {
+		_, err = server.ListConcerts(context.Background(), &pb.ListConcertsRequest{ReadMask: &fieldmaskpb.FieldMask{Paths: []string{path}}})
+		assert.Error(t, err, path)
+	}
+}
+
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
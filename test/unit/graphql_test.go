@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphqlapi "concert-ticket-api/api/graphql"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingConcertRepository counts the lookups that reach the repository
+type countingConcertRepository struct {
+	*mocks.MockConcertRepository
+	byID  atomic.Int32
+	byIDs atomic.Int32
+}
+
+func (r *countingConcertRepository) GetByID(ctx context.Context, id int64) (*model.Concert, error) {
+	r.byID.Add(1)
+	return r.MockConcertRepository.GetByID(ctx, id)
+}
+
+func (r *countingConcertRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Concert, error) {
+	r.byIDs.Add(1)
+	return r.MockConcertRepository.GetByIDs(ctx, ids)
+}
+
+type graphqlFixture struct {
+	handler     http.Handler
+	concertRepo *countingConcertRepository
+	bookingRepo *mocks.MockBookingRepository
+}
+
+func newGraphQLFixture(t *testing.T) *graphqlFixture {
+	concertRepo := &countingConcertRepository{MockConcertRepository: mocks.NewMockConcertRepository()}
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo.MockConcertRepository)
+
+	handler, err := graphqlapi.NewHandler(
+		service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil),
+		service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil),
+		8,
+	)
+	require.NoError(t, err)
+
+	return &graphqlFixture{handler: handler, concertRepo: concertRepo, bookingRepo: bookingRepo}
+}
+
+func (f *graphqlFixture) createConcert(t *testing.T, name string) *model.Concert {
+	concert, err := f.concertRepo.Create(context.Background(), &model.Concert{
+		Name:             name,
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (f *graphqlFixture) do(t *testing.T, query string, variables map[string]interface{}) graphqlResponse {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	f.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestGraphQLBatchesConcertsOfBookings(t *testing.T) {
+	f := newGraphQLFixture(t)
+
+	for i := 0; i < 5; i++ {
+		concert := f.createConcert(t, "Concert")
+		for j := 0; j < 2; j++ {
+			_, err := f.bookingRepo.Create(context.Background(), &model.Booking{
+				ConcertID:   concert.ID,
+				UserID:      "user-1",
+				TicketCount: 1,
+				Status:      model.BookingStatusConfirmed,
+				BookingTime: time.Now(),
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	resp := f.do(t, `query($user: String!) { bookings(userID: $user) { reference concert { id name } } }`,
+		map[string]interface{}{"user": "user-1"})
+	require.Empty(t, resp.Errors)
+
+	var bookings []struct {
+		Reference string `json:"reference"`
+		Concert   struct {
+			ID string `json:"id"`
+		} `json:"concert"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["bookings"], &bookings))
+	assert.Len(t, bookings, 10)
+	for _, booking := range bookings {
+		assert.NotEmpty(t, booking.Reference)
+		assert.NotEmpty(t, booking.Concert.ID)
+	}
+
+	assert.Equal(t, int32(1), f.concertRepo.byIDs.Load(), "concerts of all bookings must be loaded in one batch")
+	assert.Equal(t, int32(0), f.concertRepo.byID.Load())
+}
+
+func TestGraphQLBookAndCancel(t *testing.T) {
+	f := newGraphQLFixture(t)
+	concert := f.createConcert(t, "Concert")
+
+	resp := f.do(t, `mutation($input: BookTicketsInput!) { bookTickets(input: $input) { reference status ticketCount concert { availableTickets } } }`,
+		map[string]interface{}{"input": map[string]interface{}{"concertID": strconv.FormatInt(concert.ID, 10), "userID": "user-1", "ticketCount": 2}})
+	require.Empty(t, resp.Errors)
+
+	var booked struct {
+		Reference   string `json:"reference"`
+		Status      string `json:"status"`
+		TicketCount int    `json:"ticketCount"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["bookTickets"], &booked))
+	assert.Equal(t, string(model.BookingStatusConfirmed), booked.Status)
+	assert.Equal(t, 2, booked.TicketCount)
+
+	resp = f.do(t, `mutation($ref: String!) { cancelBooking(reference: $ref, userID: "someone-else") { status } }`,
+		map[string]interface{}{"ref": booked.Reference})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"])
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	f := newGraphQLFixture(t)
+
+	resp := f.do(t, `{ concert(id: "999") { name } }`, nil)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, "null", string(resp.Data["concert"]), "unknown concerts resolve to null")
+
+	// Limits are checked against the concert booked
+	concert := f.createConcert(t, "Limited Night")
+	resp = f.do(t, `mutation($id: ID!) { bookTickets(input: {concertID: $id, userID: "user-1", ticketCount: 20}) { reference } }`,
+		map[string]interface{}{"id": strconv.FormatInt(concert.ID, 10)})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "INVALID_INPUT", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, "cannot book more than 10 tickets at once", resp.Errors[0].Message)
+
+	resp = f.do(t, `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { name } } } } } } } } }`, nil)
+	require.NotEmpty(t, resp.Errors, "queries deeper than the limit are rejected")
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newGRPCAdminFixture(t *testing.T, networks ...string) (pb.AdminServiceClient, *mocks.MockConcertRepository, *mocks.MockBookingRepository) {
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	ops := service.NewAdminOpsService(mocks.NewMockAdminRepository(concertRepo, bookingRepo),
+		service.NewAuditService(mocks.NewMockAuditRepository()), events.NewBus())
+
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	server.RegisterAdmin(ops, config.InternalAdmin{Port: 9090, Token: "internal", AllowedNetworks: networks})
+	require.NoError(t, server.ValidateAuthorization())
+	return pb.NewAdminServiceClient(serveGRPC(t, server)), concertRepo, bookingRepo
+}
+
+func adminContext(pairs ...string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestGRPCAdminRequiresInternalCredentials(t *testing.T) {
+	client, _, _ := newGRPCAdminFixture(t, "127.0.0.0/8")
+	req := &pb.CheckInventoryRequest{}
+
+	_, err := client.CheckInventory(adminContext(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CheckInventory(adminContext("authorization", "Bearer admin-token", "x-admin-actor", "oncall"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the admin token doesn't grant the internal operations")
+
+	_, err = client.CheckInventory(adminContext("authorization", "Bearer internal"), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "operations are attributed to an operator")
+
+	_, err = client.CheckInventory(adminContext("authorization", "Bearer internal", "x-admin-actor", "oncall"), req)
+	assert.NoError(t, err)
+
+	outside, _, _ := newGRPCAdminFixture(t, "10.1.0.0/16")
+	_, err = outside.CheckInventory(adminContext("authorization", "Bearer internal", "x-admin-actor", "oncall"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "addresses outside the allowed networks are rejected even with the token")
+}
+
+func TestGRPCAdminOperations(t *testing.T) {
+	client, concertRepo, bookingRepo := newGRPCAdminFixture(t, "127.0.0.0/8", "::1/128")
+	ctx := adminContext("authorization", "Bearer internal", "x-admin-actor", "oncall")
+
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 96,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(20 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+	booking, err := bookingRepo.Create(context.Background(), &model.Booking{
+		ConcertID:   concert.ID,
+		UserID:      "alice",
+		TicketCount: 4,
+		Status:      model.BookingStatusConfirmed,
+	})
+	require.NoError(t, err)
+
+	check, err := client.CheckInventory(ctx, &pb.CheckInventoryRequest{ConcertId: concert.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), check.Checked)
+	assert.Empty(t, check.Discrepancies)
+
+	cancelled, err := client.ForceCancelBooking(ctx, &pb.ForceCancelBookingRequest{Reference: booking.Reference, Reason: "chargeback"})
+	require.NoError(t, err)
+	assert.Equal(t, string(model.BookingStatusCancelled), cancelled.Status)
+
+	_, err = client.ForceCancelBooking(ctx, &pb.ForceCancelBookingRequest{Reference: booking.Reference})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "a reason is required")
+
+	availability, err := client.AdjustInventory(ctx, &pb.AdjustInventoryRequest{ConcertId: concert.ID, Delta: -50, Reason: "fire code"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(50), availability.AvailableTickets)
+	assert.Equal(t, int32(50), availability.TotalTickets)
+
+	_, err = client.AdjustInventory(ctx, &pb.AdjustInventoryRequest{ConcertId: 999, Delta: 1, Reason: "comp"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Tickets taken behind the bookings' backs show up in the check
+	stored, err := concertRepo.GetByID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	stored.AvailableTickets -= 3
+	require.NoError(t, concertRepo.Update(context.Background(), stored))
+
+	check, err = client.CheckInventory(ctx, &pb.CheckInventoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, check.Discrepancies, 1)
+	assert.Equal(t, int32(47), check.Discrepancies[0].AvailableTickets)
+	assert.Equal(t, int32(50), check.Discrepancies[0].ExpectedAvailableTickets)
+}
@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAuthorizer() *grpcapi.Authorizer {
+	return grpcapi.NewAuthorizer(config.GRPCAuth{
+		Clients: []config.GRPCClient{
+			{Name: "web", Token: "web-token", Roles: []string{grpcapi.RoleUser}},
+			{Name: "backoffice", Token: "organizer-token", Roles: []string{grpcapi.RoleOrganizer}},
+		},
+	}, "admin-token")
+}
+
+// callUnary runs the authorization interceptor for a method with the given bearer token
+func callUnary(t *testing.T, authorizer *grpcapi.Authorizer, method, token string) (*grpcapi.Client, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	var client *grpcapi.Client
+	_, err := authorizer.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			client = grpcapi.ClientFromContext(ctx)
+			return nil, nil
+		})
+	return client, err
+}
+
+// contextStream is a server stream that only has a context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// callStream runs the authorization interceptor for a streaming method with
+// the given bearer token and returns the client its handler saw
+func callStream(t *testing.T, authorizer *grpcapi.Authorizer, method, token string) (*grpcapi.Client, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	var client *grpcapi.Client
+	err := authorizer.StreamInterceptor()(nil, &contextStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: method},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			client = grpcapi.ClientFromContext(stream.Context())
+			return nil
+		})
+	return client, err
+}
+
+func TestEveryRegisteredRPCHasAPolicy(t *testing.T) {
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	require.NoError(t, server.ValidateAuthorization())
+}
+
+func TestCheckCoverageReportsMissingPolicies(t *testing.T) {
+	err := newTestAuthorizer().CheckCoverage(map[string]grpc.ServiceInfo{
+		"concert.ConcertService": {Methods: []grpc.MethodInfo{{Name: "GetConcert"}, {Name: "DeleteConcert"}}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/concert.ConcertService/DeleteConcert")
+	assert.NotContains(t, err.Error(), "GetConcert")
+}
+
+func TestPublicRPCAllowsAnonymousCallers(t *testing.T) {
+	client, err := callUnary(t, newTestAuthorizer(), pb.ConcertService_ListConcerts_FullMethodName, "")
+	require.NoError(t, err)
+	assert.Nil(t, client)
+}
+
+func TestProtectedRPCRequiresCredentials(t *testing.T) {
+	_, err := callUnary(t, newTestAuthorizer(), pb.BookingService_BookTickets_FullMethodName, "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = callUnary(t, newTestAuthorizer(), pb.BookingService_BookTickets_FullMethodName, "wrong-token")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRolesAreEnforcedPerRPC(t *testing.T) {
+	authorizer := newTestAuthorizer()
+
+	client, err := callUnary(t, authorizer, pb.BookingService_BookTickets_FullMethodName, "web-token")
+	require.NoError(t, err)
+	assert.Equal(t, "web", client.Name)
+
+	_, err = callUnary(t, authorizer, pb.ConcertService_CreateConcert_FullMethodName, "web-token")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = callUnary(t, authorizer, pb.ConcertService_CreateConcert_FullMethodName, "organizer-token")
+	assert.NoError(t, err)
+
+	_, err = callUnary(t, authorizer, pb.BookingService_CancelBooking_FullMethodName, "organizer-token")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestStreamingRPCsSeeTheAuthorizedClient(t *testing.T) {
+	authorizer := newTestAuthorizer()
+
+	client, err := callStream(t, authorizer, pb.BookingService_BookTicketsStream_FullMethodName, "admin-token")
+	require.NoError(t, err)
+	require.NotNil(t, client, "the handler reads the client from the stream's context")
+	assert.Contains(t, client.Roles, grpcapi.RoleAdmin)
+
+	_, err = callStream(t, authorizer, pb.BookingService_BookTicketsStream_FullMethodName, "web-token")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = callStream(t, authorizer, pb.BookingService_BookTicketsStream_FullMethodName, "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAdminTokenMayCallEveryRPC(t *testing.T) {
+	authorizer := newTestAuthorizer()
+	for _, method := range []string{
+		pb.ConcertService_UpdateConcert_FullMethodName,
+		pb.BookingService_GetUserBookings_FullMethodName,
+	} {
+		_, err := callUnary(t, authorizer, method, "admin-token")
+		assert.NoError(t, err, method)
+	}
+}
+
+func TestUnknownRPCIsDenied(t *testing.T) {
+	_, err := callUnary(t, newTestAuthorizer(), "/concert.ConcertService/DeleteConcert", "admin-token")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestOnlyAdminsAddressBookingsByInternalID(t *testing.T) {
+	f := newClientFixture(t)
+	server := grpcapi.NewServer(nil, f.bookingService(), nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	client := pb.NewBookingServiceClient(serveGRPC(t, server))
+	user := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer web-token")
+	admin := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin-token")
+
+	booked, err := client.BookTickets(user, &pb.BookTicketsRequest{ConcertId: f.concerts[0].ID, UserId: "user-1", TicketCount: 1})
+	require.NoError(t, err)
+
+	_, err = client.GetBooking(user, &pb.GetBookingRequest{Id: booked.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "users can't enumerate bookings by counting up")
+	_, err = client.CancelBooking(user, &pb.CancelBookingRequest{Id: booked.Id, UserId: "user-1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	found, err := client.GetBooking(user, &pb.GetBookingRequest{Reference: booked.Reference})
+	require.NoError(t, err)
+	assert.Equal(t, booked.Id, found.Id)
+
+	found, err = client.GetBooking(admin, &pb.GetBookingRequest{Id: booked.Id})
+	require.NoError(t, err)
+	assert.Equal(t, booked.Reference, found.Reference)
+	_, err = client.CancelBooking(admin, &pb.CancelBookingRequest{Id: booked.Id, UserId: "user-1"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	"concert-ticket-api/api/rest/problem"
+	pkgErr "concert-ticket-api/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusDetails converts err and returns its status with the ErrorInfo reason
+func statusDetails(t *testing.T, err error) (*status.Status, string) {
+	t.Helper()
+
+	st, ok := status.FromError(grpcapi.ToStatus(err))
+	require.True(t, ok)
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			assert.Equal(t, grpcapi.ErrorDomain, info.Domain)
+			return st, info.Reason
+		}
+	}
+	t.Fatalf("status %v has no ErrorInfo", st)
+	return nil, ""
+}
+
+func TestServiceErrorsMapToStatusCodes(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{pkgErr.ErrNotFound, codes.NotFound, problem.CodeNotFound},
+		{fmt.Errorf("failed to get concert: %w", pkgErr.ErrNotFound), codes.NotFound, problem.CodeNotFound},
+		{pkgErr.ErrBookingClosed, codes.FailedPrecondition, problem.CodeBookingClosed},
+		{pkgErr.ErrBookingAlreadyCancelled, codes.FailedPrecondition, problem.CodeBookingAlreadyCancelled},
+		{pkgErr.ErrInsufficientTickets, codes.ResourceExhausted, problem.CodeInsufficientTickets},
+		{pkgErr.ErrRateLimited, codes.ResourceExhausted, problem.CodeRateLimited},
+		{pkgErr.ErrOptimisticLockFailed, codes.Aborted, problem.CodeBookingConflict},
+		{pkgErr.ErrInvalidBookingToken, codes.PermissionDenied, problem.CodeInvalidBookingToken},
+		{pkgErr.ErrInvalidInput("ticket_count must be positive"), codes.InvalidArgument, problem.CodeInvalidInput},
+		{errors.New("pq: connection refused"), codes.Internal, problem.CodeInternal},
+	} {
+		st, reason := statusDetails(t, tc.err)
+		assert.Equal(t, tc.code, st.Code(), tc.err.Error())
+		assert.Equal(t, tc.reason, reason, tc.err.Error())
+	}
+
+	st, _ := statusDetails(t, pkgErr.ErrInvalidInput("ticket_count must be positive"))
+	assert.Equal(t, "ticket_count must be positive", st.Message())
+
+	st, _ = statusDetails(t, errors.New("pq: connection refused"))
+	assert.Equal(t, "internal error", st.Message(), "internal errors don't leak")
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(grpcapi.ToStatus(context.DeadlineExceeded)))
+	assert.NoError(t, grpcapi.ToStatus(nil))
+
+	existing := status.Error(codes.Unauthenticated, "missing credentials")
+	assert.Equal(t, existing, grpcapi.ToStatus(existing), "statuses are kept")
+}
+
+func TestStatusDetailsDescribeRecovery(t *testing.T) {
+	st, _ := statusDetails(t, pkgErr.ErrOptimisticLockFailed)
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = d
+		}
+	}
+	require.NotNil(t, retry, "conflicts may be retried")
+	assert.Equal(t, 100*time.Millisecond, retry.RetryDelay.AsDuration())
+
+	st, _ = statusDetails(t, pkgErr.ErrBookingClosed)
+	var precondition *errdetails.PreconditionFailure
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.PreconditionFailure); ok {
+			precondition = d
+		}
+	}
+	require.NotNil(t, precondition)
+	require.Len(t, precondition.Violations, 1)
+	assert.Equal(t, "BOOKING_WINDOW", precondition.Violations[0].Type)
+}
@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestEveryRPCHasAnHTTPBinding(t *testing.T) {
+	for _, file := range []protoreflect.FileDescriptor{pb.File_api_grpc_proto_concert_proto, pb.File_api_grpc_proto_booking_proto} {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := methods.Get(j)
+				rule, _ := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+				assert.NotNil(t, rule, "%s has no google.api.http binding", method.FullName())
+			}
+		}
+	}
+}
+
+type gatewayConcertServer struct {
+	pb.UnimplementedConcertServiceServer
+}
+
+func (gatewayConcertServer) GetConcert(ctx context.Context, req *pb.GetConcertRequest) (*pb.Concert, error) {
+	return &pb.Concert{Id: req.Id, Name: "Gateway Show", AvailableTickets: 0}, nil
+}
+
+func TestGatewayForwardsThroughInterceptors(t *testing.T) {
+	authorizer := newTestAuthorizer()
+	server := grpc.NewServer(grpc.UnaryInterceptor(authorizer.UnaryInterceptor()))
+	pb.RegisterConcertServiceServer(server, gatewayConcertServer{})
+	pb.RegisterBookingServiceServer(server, pb.UnimplementedBookingServiceServer{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gateway, err := grpcapi.NewGateway(ctx, lis.Addr().String())
+	require.NoError(t, err)
+
+	// Public RPCs work without credentials, and fields keep their proto names
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gateway/v1/concerts/7", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var concert map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &concert))
+	assert.Equal(t, "7", concert["id"])
+	assert.Equal(t, "Gateway Show", concert["name"])
+	assert.Contains(t, concert, "available_tickets")
+
+	// Protected RPCs are rejected by the authorization interceptor
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gateway/v1/bookings/7K3M9X2QAB4Z", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The bearer token is forwarded as gRPC metadata
+	req := httptest.NewRequest(http.MethodGet, "/gateway/v1/bookings/7K3M9X2QAB4Z", nil)
+	req.Header.Set("Authorization", "Bearer web-token")
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
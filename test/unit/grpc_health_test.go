@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func serveGRPC(t *testing.T, server *grpcapi.Server) *grpc.ClientConn {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func servingStatus(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.Status
+}
+
+func TestGRPCHealthFollowsDatabase(t *testing.T) {
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	client := healthpb.NewHealthClient(serveGRPC(t, server))
+
+	registry := health.NewRegistry(time.Second, 1)
+	registry.Register(health.Database, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.TrackHealth(ctx, registry, 10*time.Millisecond)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client, "concert.ConcertService"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client, "booking.BookingService"))
+
+	registry.Report(health.Database, errors.New("connection refused"))
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, client, "booking.BookingService") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client, "concert.ConcertService"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client, ""), "the server itself stays live")
+
+	registry.Report(health.Database, nil)
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, client, "concert.ConcertService") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	server.Shutdown(context.Background())
+}
+
+func TestGRPCHealthOnlyReportsRegisteredServices(t *testing.T) {
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), true, logger.NewLogger("error"), 0)
+	defer server.Shutdown(context.Background())
+	client := healthpb.NewHealthClient(serveGRPC(t, server))
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client, "concert.ConcertService"))
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "booking.BookingService"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "read-only mirrors don't serve bookings")
+}
+
+func TestChannelzIsForAdmins(t *testing.T) {
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	defer server.Shutdown(context.Background())
+	client := channelzpb.NewChannelzClient(serveGRPC(t, server))
+
+	_, err := client.GetServers(context.Background(), &channelzpb.GetServersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin-token")
+	resp, err := client.GetServers(ctx, &channelzpb.GetServersRequest{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Server)
+}
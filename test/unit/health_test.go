@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/scripts/migrations"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type healthResponse struct {
+	Status     string          `json:"status"`
+	Components []health.Status `json:"components"`
+}
+
+func getHealth(t *testing.T, router *gin.Engine, path string) (int, healthResponse) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	return recorder.Code, resp
+}
+
+func newHealthTestRouter(registry *health.Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewHealthHandler(registry).RegisterRoutes(router)
+	return router
+}
+
+func TestReadinessFollowsRequiredComponents(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 1)
+	registry.Register(health.Database, nil)
+	registry.Register(health.Broker, nil)
+	registry.RegisterOptional(health.Replica(1), nil)
+	router := newHealthTestRouter(registry)
+
+	code, resp := getHealth(t, router, "/health/ready")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", resp.Status)
+	require.Len(t, resp.Components, 3)
+
+	registry.Report(health.Replica(1), errors.New("connection refused"))
+	code, resp = getHealth(t, router, "/health/ready")
+	assert.Equal(t, http.StatusOK, code, "reads fall back to the primary without replicas")
+	_, resp = getHealth(t, router, "/health")
+	assert.Equal(t, "degraded", resp.Status)
+
+	registry.Report(health.Broker, errors.New("no Kafka broker reachable"))
+	code, resp = getHealth(t, router, "/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", resp.Status)
+	for _, component := range resp.Components {
+		if component.Name == health.Broker {
+			assert.True(t, component.Required)
+			assert.Equal(t, "no Kafka broker reachable", component.Error)
+		}
+	}
+
+	code, resp = getHealth(t, router, "/health/live")
+	assert.Equal(t, http.StatusOK, code, "a replica missing a dependency is alive")
+	assert.Equal(t, "up", resp.Status)
+}
+
+func TestMigrationsCheckComparesTheSchemaVersion(t *testing.T) {
+	database, err := testutil.SetupSQLiteDB()
+	require.NoError(t, err)
+	defer database.Close()
+
+	latest, err := db.LatestMigration(config.Database{Driver: config.DriverSQLite}, migrations.FS)
+	require.NoError(t, err)
+	assert.NoError(t, db.CheckMigrations(context.Background(), database, latest))
+	assert.ErrorContains(t, db.CheckMigrations(context.Background(), database, latest+1), "schema is at migration")
+
+	_, err = database.Exec(`UPDATE schema_migrations SET dirty = 1`)
+	require.NoError(t, err)
+	assert.ErrorContains(t, db.CheckMigrations(context.Background(), database, latest), "failed halfway")
+
+	_, err = db.LatestMigration(config.Database{Driver: config.DriverPostgres}, os.DirFS(t.TempDir()))
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type internalAdminFixture struct {
+	concertRepo *mocks.MockConcertRepository
+	bookingRepo *mocks.MockBookingRepository
+	server      http.Handler
+	bus         *events.Bus
+	concert     *model.Concert
+}
+
+func newInternalAdminFixture(t *testing.T) *internalAdminFixture {
+	gin.SetMode(gin.TestMode)
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 90,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(20 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	auditService := service.NewAuditService(mocks.NewMockAuditRepository())
+	ops := service.NewAdminOpsService(mocks.NewMockAdminRepository(concertRepo, bookingRepo), auditService, bus)
+	cfg := config.InternalAdmin{Port: 9090, Token: "internal", AllowedNetworks: []string{"10.1.0.0/16", "::1/128"}}
+
+	return &internalAdminFixture{
+		concertRepo: concertRepo,
+		bookingRepo: bookingRepo,
+		server:      rest.NewAdminServer(ops, nil, logger.NewLogger("error"), cfg).Handler(),
+		bus:         bus,
+		concert:     concert,
+	}
+}
+
+func (f *internalAdminFixture) send(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = "10.1.2.3:51000"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer internal")
+	req.Header.Set("X-Admin-Actor", "oncall")
+	for name, value := range header {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	f.server.ServeHTTP(w, req)
+	return w
+}
+
+func TestInternalAdminAuth(t *testing.T) {
+	f := newInternalAdminFixture(t)
+
+	assert.Equal(t, http.StatusOK, f.send(http.MethodGet, "/admin/audit-logs", "", nil).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit-logs", nil)
+	req.RemoteAddr = "203.0.113.9:51000"
+	req.Header.Set("Authorization", "Bearer internal")
+	req.Header.Set("X-Admin-Actor", "oncall")
+	w := httptest.NewRecorder()
+	f.server.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "addresses outside the allowed networks are rejected even with the token")
+	assert.Equal(t, problem.CodeForbidden, decodeProblem(t, w).Code)
+
+	req.RemoteAddr = "[::1]:51000"
+	w = httptest.NewRecorder()
+	f.server.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, f.send(http.MethodGet, "/admin/audit-logs", "", map[string]string{"Authorization": ""}).Code)
+	assert.Equal(t, http.StatusForbidden, f.send(http.MethodGet, "/admin/audit-logs", "", map[string]string{"Authorization": "Bearer public-admin"}).Code)
+
+	w = f.send(http.MethodGet, "/admin/audit-logs", "", map[string]string{"X-Admin-Actor": ""})
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "operations are attributed to an operator")
+
+	disabled := rest.NewAdminServer(nil, nil, logger.NewLogger("error"), config.InternalAdmin{AllowedNetworks: []string{"10.1.0.0/16"}}).Handler()
+	w = httptest.NewRecorder()
+	req.RemoteAddr = "10.1.2.3:51000"
+	disabled.ServeHTTP(w, req)
+	assert.Equal(t, problem.CodeAdminDisabled, decodeProblem(t, w).Code)
+}
+
+func TestInternalAdminForceCancelReturnsTickets(t *testing.T) {
+	f := newInternalAdminFixture(t)
+	ctx := context.Background()
+
+	booking, err := f.bookingRepo.Create(ctx, &model.Booking{
+		ConcertID:   f.concert.ID,
+		UserID:      "alice",
+		TicketCount: 4,
+		Status:      model.BookingStatusConfirmed,
+	})
+	require.NoError(t, err)
+
+	updates := f.bus.Subscribe(model.EventConcertAvailability, f.concert.ID, 1)
+	defer updates.Close()
+
+	path := "/admin/bookings/" + booking.Reference + "/force-cancel"
+	w := f.send(http.MethodPost, path, `{}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a reason is required")
+
+	w = f.send(http.MethodPost, path, `{"reason":"chargeback"}`, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var cancelled model.Booking
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cancelled))
+	assert.Equal(t, model.BookingStatusCancelled, cancelled.Status)
+
+	concert, err := f.concertRepo.GetByID(ctx, f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 94, concert.AvailableTickets)
+	assert.Equal(t, 100, concert.TotalTickets)
+
+	event := <-updates.Events()
+	assert.Equal(t, 94, event.Payload.(*model.ConcertAvailability).AvailableTickets)
+
+	w = f.send(http.MethodPost, path, `{"reason":"chargeback"}`, nil)
+	assert.Equal(t, problem.CodeBookingAlreadyCancelled, decodeProblem(t, w).Code)
+	w = f.send(http.MethodPost, "/admin/bookings/BK-MISSING/force-cancel", `{"reason":"chargeback"}`, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	entries := f.auditLogs(t, "?action="+model.AuditActionBookingForceCancelled)
+	require.Len(t, entries.Data, 1)
+	assert.Equal(t, "oncall", entries.Data[0].Actor)
+	assert.Equal(t, booking.Reference, entries.Data[0].ResourceID)
+	assert.JSONEq(t, `{"reason":"chargeback","concert_id":`+strconv.FormatInt(f.concert.ID, 10)+`,"ticket_count":4}`, string(entries.Data[0].Details))
+}
+
+func TestInternalAdminAdjustsInventory(t *testing.T) {
+	f := newInternalAdminFixture(t)
+	path := "/admin/concerts/" + strconv.FormatInt(f.concert.ID, 10) + "/inventory-adjustments"
+
+	w := f.send(http.MethodPost, path, `{"delta":10,"reason":"second stage opened"}`, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var availability model.ConcertAvailability
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &availability))
+	assert.Equal(t, 100, availability.AvailableTickets)
+	assert.Equal(t, 110, availability.TotalTickets)
+
+	w = f.send(http.MethodPost, path, `{"delta":-101,"reason":"fire code"}`, nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "sold tickets can't be withdrawn")
+	assert.Equal(t, problem.CodeInsufficientTickets, decodeProblem(t, w).Code)
+
+	w = f.send(http.MethodPost, path, `{"delta":-100,"reason":"fire code"}`, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	concert, err := f.concertRepo.GetByID(context.Background(), f.concert.ID)
+	require.NoError(t, err)
+	assert.Zero(t, concert.AvailableTickets)
+	assert.Equal(t, 10, concert.TotalTickets)
+
+	for _, body := range []string{`{"delta":0,"reason":"noop"}`, `{"delta":20000,"reason":"typo"}`, `{"delta":5}`} {
+		assert.Equal(t, http.StatusBadRequest, f.send(http.MethodPost, path, body, nil).Code, body)
+	}
+	assert.Equal(t, http.StatusNotFound, f.send(http.MethodPost, "/admin/concerts/999/inventory-adjustments", `{"delta":1,"reason":"x"}`, nil).Code)
+
+	entries := f.auditLogs(t, "?resource_type=concert&resource_id="+strconv.FormatInt(f.concert.ID, 10))
+	require.Len(t, entries.Data, 2, "only applied adjustments are audited")
+	assert.JSONEq(t, `{"reason":"fire code","delta":-100,"available_tickets":0,"total_tickets":10}`, string(entries.Data[0].Details), "latest first")
+}
+
+func TestInternalAdminListsAuditLogs(t *testing.T) {
+	f := newInternalAdminFixture(t)
+	path := "/admin/concerts/" + strconv.FormatInt(f.concert.ID, 10) + "/inventory-adjustments"
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, f.send(http.MethodPost, path, `{"delta":1,"reason":"comp"}`, nil).Code)
+	}
+	require.Equal(t, http.StatusOK, f.send(http.MethodPost, path, `{"delta":1,"reason":"comp"}`, map[string]string{"X-Admin-Actor": "ops-lead"}).Code)
+
+	entries := f.auditLogs(t, "?actor=oncall&pageSize=2")
+	assert.Len(t, entries.Data, 2)
+	assert.Equal(t, 3, entries.Meta.TotalCount)
+	assert.NotEmpty(t, entries.Meta.NextCursor)
+
+	entries = f.auditLogs(t, "?actor=oncall&cursor="+entries.Meta.NextCursor)
+	assert.Len(t, entries.Data, 1)
+
+	entries = f.auditLogs(t, "?actor=ops-lead")
+	require.Len(t, entries.Data, 1)
+	assert.Equal(t, model.AuditActionInventoryAdjusted, entries.Data[0].Action)
+
+	assert.Equal(t, http.StatusBadRequest, f.send(http.MethodGet, "/admin/audit-logs?page=0", "", nil).Code)
+}
+
+func TestInternalAdminChecksInventory(t *testing.T) {
+	f := newInternalAdminFixture(t)
+	consistent, err := f.concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Matinee",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     50,
+		AvailableTickets: 50,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(20 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The fixture's concert lost 10 tickets that no booking holds
+	w := f.send(http.MethodGet, "/admin/inventory-check", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var check model.InventoryCheck
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &check))
+	assert.Equal(t, 2, check.Checked)
+	require.Len(t, check.Discrepancies, 1)
+	assert.Equal(t, f.concert.ID, check.Discrepancies[0].ConcertID)
+	assert.Equal(t, 90, check.Discrepancies[0].AvailableTickets)
+	assert.Equal(t, 100, check.Discrepancies[0].ExpectedAvailable())
+
+	w = f.send(http.MethodGet, "/admin/inventory-check?concert_id="+strconv.FormatInt(consistent.ID, 10), "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"checked":1,"discrepancies":[]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, f.send(http.MethodGet, "/admin/inventory-check?concert_id=999", "", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, f.send(http.MethodGet, "/admin/inventory-check?concert_id=abc", "", nil).Code)
+}
+
+func TestInternalAdminValidate(t *testing.T) {
+	valid := config.InternalAdmin{Port: 9090, Token: "internal", AllowedNetworks: []string{"10.0.0.0/8"}}
+	assert.NoError(t, valid.Validate("public"))
+	assert.NoError(t, (&config.InternalAdmin{}).Validate("public"), "the listener is off without a port")
+
+	for name, cfg := range map[string]config.InternalAdmin{
+		"shared token":    {Port: 9090, Token: "public", AllowedNetworks: []string{"10.0.0.0/8"}},
+		"no token":        {Port: 9090, AllowedNetworks: []string{"10.0.0.0/8"}},
+		"no networks":     {Port: 9090, Token: "internal"},
+		"invalid network": {Port: 9090, Token: "internal", AllowedNetworks: []string{"10.0.0.1"}},
+		"invalid port":    {Port: 70000, Token: "internal", AllowedNetworks: []string{"10.0.0.0/8"}},
+	} {
+		assert.Error(t, cfg.Validate("public"), name)
+	}
+}
+
+func (f *internalAdminFixture) auditLogs(t *testing.T, query string) handler.AuditLogListResponse {
+	w := f.send(http.MethodGet, "/admin/audit-logs"+query, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var entries handler.AuditLogListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	return entries
+}
@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	redisrepo "concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type inventoryFixture struct {
+	redis     *miniredis.Miniredis
+	concerts  *mocks.MockConcertRepository
+	inventory service.InventoryService
+	bookings  service.BookingService
+}
+
+func newInventoryFixture(t *testing.T) *inventoryFixture {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	inventory := service.NewInventoryService(mocks.NewMockInventoryRepository(concerts, bookings), redisrepo.NewInventoryCounter(client))
+	return &inventoryFixture{
+		redis:     mr,
+		concerts:  concerts,
+		inventory: inventory,
+		bookings:  service.NewBookingService(bookings, concerts, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, inventory, service.BookingOptimistic, nil),
+	}
+}
+
+func (f *inventoryFixture) createConcert(t *testing.T, tickets int) *model.Concert {
+	concert, err := f.concerts.Create(context.Background(), &model.Concert{
+		Name:             "Counted Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            40,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+func (f *inventoryFixture) book(concertID int64, user string, tickets int) (*model.Booking, error) {
+	return f.bookings.BookTickets(context.Background(), &model.BookingRequest{
+		ConcertID:   concertID,
+		UserID:      user,
+		TicketCount: tickets,
+	})
+}
+
+func (f *inventoryFixture) counter(concertID int64) string {
+	return f.redis.HGet("inventory:"+strconv.FormatInt(concertID, 10), "available")
+}
+
+func (f *inventoryFixture) available(t *testing.T, concertID int64) int {
+	concert, err := f.concerts.GetByID(context.Background(), concertID)
+	require.NoError(t, err)
+	return concert.AvailableTickets
+}
+
+func TestRedisInventoryDoesNotOversell(t *testing.T) {
+	f := newInventoryFixture(t)
+	concert := f.createConcert(t, 100)
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		booked, sold int
+	)
+	for i := 0; i < 80; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := f.book(concert.ID, "user-"+strconv.Itoa(i), 2)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				booked++
+				sold += 2
+				return
+			}
+			assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, booked)
+	assert.Equal(t, 100, sold)
+	assert.Equal(t, "0", f.counter(concert.ID))
+	assert.Equal(t, 100, f.available(t, concert.ID), "the database catches up when flushed")
+
+	settled, err := f.inventory.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 50, settled)
+	assert.Equal(t, 0, f.available(t, concert.ID))
+
+	_, err = f.book(concert.ID, "late", 1)
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets, "the counter is reset to the flushed concert")
+}
+
+func TestRedisInventoryReseedsLostCounters(t *testing.T) {
+	f := newInventoryFixture(t)
+	concert := f.createConcert(t, 100)
+
+	_, err := f.book(concert.ID, "user-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "90", f.counter(concert.ID))
+
+	// Redis lost the counter before the booking was flushed
+	f.redis.FlushAll()
+	_, err = f.book(concert.ID, "user-2", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "85", f.counter(concert.ID), "the reseeded counter leaves out pending bookings")
+
+	// Changes of the concert's tickets reset the counter
+	updated, err := f.concerts.GetByID(context.Background(), concert.ID)
+	require.NoError(t, err)
+	updated.AvailableTickets += 20
+	updated.TotalTickets += 20
+	require.NoError(t, f.concerts.Update(context.Background(), updated))
+	_, err = f.book(concert.ID, "user-3", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "104", f.counter(concert.ID))
+
+	_, err = f.inventory.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 104, f.available(t, concert.ID))
+}
+
+func TestRedisInventoryRefusalsTakeNoTickets(t *testing.T) {
+	f := newInventoryFixture(t)
+	concert := f.createConcert(t, 10)
+
+	booking, err := f.book(concert.ID, "user-1", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, booking.UnitPrice)
+	assert.Equal(t, model.BookingStatusConfirmed, booking.Status)
+
+	_, err = f.book(concert.ID, "user-2", 8)
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+	assert.Equal(t, "7", f.counter(concert.ID), "a refused booking takes no tickets")
+}
+
+func TestRedisInventoryFallsBackToTheDatabase(t *testing.T) {
+	f := newInventoryFixture(t)
+	concert := f.createConcert(t, 10)
+
+	f.redis.Close()
+	booking, err := f.book(concert.ID, "user-1", 2)
+	require.NoError(t, err, "bookings lock the concert row while Redis is down")
+	assert.NotZero(t, booking.ID)
+
+	_, err = f.inventory.Reserve(context.Background(), &model.Booking{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1})
+	require.Error(t, err)
+	_, known := pkgErr.Lookup(err)
+	assert.False(t, known, "Redis failures aren't booking outcomes")
+}
@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type inventoryReleaseFixture struct {
+	concertRepo *mocks.MockConcertRepository
+	service     service.InventoryReleaseService
+	bus         *events.Bus
+	concert     *model.Concert
+}
+
+func newInventoryReleaseFixture(t *testing.T) *inventoryReleaseFixture {
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     3000,
+		AvailableTickets: 3000,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(20 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	return &inventoryReleaseFixture{
+		concertRepo: concertRepo,
+		service:     service.NewInventoryReleaseService(mocks.NewMockInventoryReleaseRepository(concertRepo), concertRepo, bus),
+		bus:         bus,
+		concert:     concert,
+	}
+}
+
+func (f *inventoryReleaseFixture) available(t *testing.T) int {
+	concert, err := f.concertRepo.GetByID(context.Background(), f.concert.ID)
+	require.NoError(t, err)
+	return concert.AvailableTickets
+}
+
+func TestInventoryReleasesHoldBackTicketsUntilDue(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newInventoryReleaseFixture(t)
+	ctx := context.Background()
+
+	_, err := f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 1000, ReleaseAt: time.Now().Add(7 * 24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 2000, f.available(t), "the tickets of a release are held back")
+
+	_, err = f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 2001, ReleaseAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+
+	released, err := f.service.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	clock.Process().Advance(7*24*time.Hour + time.Minute)
+	released, err = f.service.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.Equal(t, 3000, f.available(t))
+
+	released, err = f.service.ReleaseDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released, "a release is executed once")
+
+	releases, err := f.service.ListReleases(ctx, f.concert.ID)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.NotNil(t, releases[0].ReleasedAt)
+}
+
+func TestInventoryReleaseOfSoldOutConcertIsBackInStock(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newInventoryReleaseFixture(t)
+	ctx := context.Background()
+
+	_, err := f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 3000, ReleaseAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Zero(t, f.available(t))
+
+	backInStock := f.bus.Subscribe(model.EventConcertBackInStock, f.concert.ID, 4)
+	defer backInStock.Close()
+	availability := f.bus.Subscribe(model.EventConcertAvailability, f.concert.ID, 4)
+	defer availability.Close()
+
+	clock.Process().Advance(2 * time.Hour)
+	_, err = f.service.ReleaseDue(ctx)
+	require.NoError(t, err)
+
+	event := <-backInStock.Events()
+	result, ok := event.Payload.(*model.ReleasedInventory)
+	require.True(t, ok)
+	assert.Equal(t, 3000, result.AvailableTickets)
+
+	event = <-availability.Events()
+	assert.Equal(t, 3000, event.Payload.(*model.ConcertAvailability).AvailableTickets)
+}
+
+func TestCancelledInventoryReleaseGoesOnSaleImmediately(t *testing.T) {
+	f := newInventoryReleaseFixture(t)
+	ctx := context.Background()
+
+	release, err := f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 500, ReleaseAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 2500, f.available(t))
+
+	require.NoError(t, f.service.Cancel(ctx, f.concert.ID, release.ID))
+	assert.Equal(t, 3000, f.available(t))
+	assert.ErrorIs(t, f.service.Cancel(ctx, f.concert.ID, release.ID), pkgErr.ErrNotFound)
+
+	_, err = f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 10, ReleaseAt: time.Now().Add(-time.Minute)})
+	assert.Error(t, err, "releases can't be scheduled in the past")
+	_, err = f.service.Schedule(ctx, f.concert.ID, &model.InventoryReleaseRequest{Quantity: 10, ReleaseAt: time.Now().Add(25 * 24 * time.Hour)})
+	assert.Error(t, err, "releases must happen while booking is open")
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingBookingRepository fails every booking write
+type failingBookingRepository struct {
+	*mocks.MockBookingRepository
+}
+
+func (r *failingBookingRepository) CreateWithTicketUpdate(ctx context.Context, booking *model.Booking, concertVersion int) error {
+	return errors.New("database unavailable")
+}
+
+type inviteFixture struct {
+	concertRepo    *mocks.MockConcertRepository
+	concertService service.ConcertService
+	bookingService service.BookingService
+	inviteService  service.InviteService
+	concert        *model.Concert
+}
+
+// newInviteFixture creates a private concert whose booking window opens after the fixture is set up
+func newInviteFixture(t *testing.T) *inviteFixture {
+	concertRepo := mocks.NewMockConcertRepository()
+	f := &inviteFixture{
+		concertRepo:    concertRepo,
+		concertService: service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil),
+		bookingService: service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil),
+		inviteService:  service.NewInviteService(concertRepo),
+	}
+
+	concert, err := f.concertService.CreateConcert(context.Background(), newVisibilityConcert(model.VisibilityPrivate))
+	require.NoError(t, err)
+	f.concert = concert
+	return f
+}
+
+func (f *inviteFixture) book(ctx context.Context, userID string) (*model.Booking, error) {
+	return f.bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: userID, TicketCount: 1})
+}
+
+func TestGenerateInvites(t *testing.T) {
+	f := newInviteFixture(t)
+	ctx := context.Background()
+
+	invites, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, &model.InviteRequest{Invitees: []string{" ceo@example.com ", "cfo@example.com"}, SingleUse: true})
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	assert.Equal(t, "ceo@example.com", invites[0].Invitee)
+	assert.True(t, invites[0].SingleUse)
+	assert.NotEmpty(t, invites[0].Token)
+	assert.NotEqual(t, invites[0].Token, invites[1].Token)
+	assert.NotEqual(t, invites[0].ID, invites[1].ID)
+
+	anonymous, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, &model.InviteRequest{Count: 3})
+	require.NoError(t, err)
+	require.Len(t, anonymous, 3)
+	assert.Empty(t, anonymous[0].Invitee)
+	assert.False(t, anonymous[0].SingleUse)
+
+	for _, req := range []*model.InviteRequest{
+		{},
+		{Count: -1},
+		{Count: service.MaxInvitesPerRequest + 1},
+		{Count: 1, Invitees: []string{"a@example.com"}},
+		{Invitees: []string{" "}},
+	} {
+		_, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, req)
+		var errWithMsg *pkgErr.ErrorWithMessage
+		assert.True(t, errors.As(err, &errWithMsg), "%+v", req)
+	}
+
+	public, err := f.concertService.CreateConcert(ctx, newVisibilityConcert(model.VisibilityPublic))
+	require.NoError(t, err)
+	_, err = f.inviteService.GenerateInvites(ctx, public.ID, &model.InviteRequest{Count: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invites can only be generated for private concerts")
+
+	_, err = f.inviteService.GenerateInvites(ctx, 999, &model.InviteRequest{Count: 1})
+	assert.True(t, errors.Is(err, pkgErr.ErrNotFound))
+}
+
+func TestSingleUseInvitesBookOnce(t *testing.T) {
+	defer clock.Process().Reset()
+
+	f := newInviteFixture(t)
+	ctx := context.Background()
+
+	invites, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, &model.InviteRequest{Invitees: []string{"guest@example.com"}, SingleUse: true})
+	require.NoError(t, err)
+	invited := service.WithInviteToken(ctx, invites[0].Token)
+	clock.Process().Advance(2 * time.Hour)
+
+	found, err := f.concertService.GetByID(invited, f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, f.concert.ID, found.ID)
+
+	_, err = f.concertService.GetByID(ctx, f.concert.ID)
+	assert.True(t, errors.Is(err, pkgErr.ErrNotFound), "the concert stays hidden without the invite")
+
+	booking, err := f.book(invited, "guest")
+	require.NoError(t, err)
+
+	_, err = f.book(invited, "plus-one")
+	assert.True(t, errors.Is(err, pkgErr.ErrInviteRedeemed))
+
+	_, err = f.concertService.GetByID(invited, f.concert.ID)
+	assert.NoError(t, err, "redeemed invites can still view the concert")
+
+	export, err := f.inviteService.ExportInvites(ctx, f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, export.Invites)
+	assert.Equal(t, 1, export.Redeemed)
+	require.Len(t, export.Rows, 1)
+	require.NotNil(t, export.Rows[0].BookingReference)
+	assert.Equal(t, booking.Reference, *export.Rows[0].BookingReference)
+	assert.True(t, strings.HasPrefix(export.CSV, "invite_id,invitee,single_use,booking_reference,"))
+	assert.Contains(t, export.CSV, "guest@example.com,true,"+booking.Reference)
+}
+
+func TestMultiUseInvitesTrackEveryRedemption(t *testing.T) {
+	defer clock.Process().Reset()
+
+	f := newInviteFixture(t)
+	ctx := context.Background()
+
+	invites, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, &model.InviteRequest{Count: 2})
+	require.NoError(t, err)
+	invited := service.WithInviteToken(ctx, invites[0].Token)
+	clock.Process().Advance(2 * time.Hour)
+
+	for _, user := range []string{"user-1", "user-2"} {
+		_, err := f.book(invited, user)
+		require.NoError(t, err)
+	}
+
+	export, err := f.inviteService.ExportInvites(ctx, f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, export.Invites)
+	assert.Equal(t, 2, export.Redeemed)
+	require.Len(t, export.Rows, 3, "two redemptions of the first invite and the unused second one")
+	assert.Nil(t, export.Rows[2].BookingReference)
+}
+
+func TestFailedBookingsWithdrawTheRedemption(t *testing.T) {
+	defer clock.Process().Reset()
+
+	f := newInviteFixture(t)
+	ctx := context.Background()
+
+	invites, err := f.inviteService.GenerateInvites(ctx, f.concert.ID, &model.InviteRequest{Count: 1, SingleUse: true})
+	require.NoError(t, err)
+	invited := service.WithInviteToken(ctx, invites[0].Token)
+	clock.Process().Advance(2 * time.Hour)
+
+	failing := service.NewBookingService(&failingBookingRepository{mocks.NewMockBookingRepository().WithConcerts(f.concertRepo)}, f.concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	_, err = failing.BookTickets(invited, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "guest", TicketCount: 1})
+	require.Error(t, err)
+
+	export, err := f.inviteService.ExportInvites(ctx, f.concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, export.Redeemed)
+
+	_, err = f.book(invited, "guest")
+	assert.NoError(t, err, "the single-use invite can still be redeemed")
+}
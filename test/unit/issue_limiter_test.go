@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	redisrepo "concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueLimiterRefillsItsBucket(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	limiter := redisrepo.NewIssueLimiter(client)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, err := limiter.Allow(ctx, 1, 2, 3)
+		require.NoError(t, err)
+		assert.True(t, allowed, "the burst is allowed at once")
+	}
+	allowed, err := limiter.Allow(ctx, 1, 2, 3)
+	require.NoError(t, err)
+	assert.False(t, allowed, "the burst is spent")
+
+	allowed, err = limiter.Allow(ctx, 2, 2, 3)
+	require.NoError(t, err)
+	assert.True(t, allowed, "every concert has a bucket of its own")
+
+	mr.SetTime(time.Date(2026, 3, 1, 12, 0, 0, int(500*time.Millisecond), time.UTC))
+	allowed, err = limiter.Allow(ctx, 1, 2, 3)
+	require.NoError(t, err)
+	assert.True(t, allowed, "at 2 per second a token is back after half a second")
+	allowed, err = limiter.Allow(ctx, 1, 2, 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestBookingTokenIssueRateIsSharedByReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "On-sale",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            40,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Two replicas with a burst of 2 issue 2 tokens together, not 2 each
+	replicas := []service.BookingTokenService{
+		service.NewBookingTokenService(issuedTokens{}, concertRepo, time.Minute, 0.001, 2, redisrepo.NewIssueLimiter(client)),
+		service.NewBookingTokenService(issuedTokens{}, concertRepo, time.Minute, 0.001, 2, redisrepo.NewIssueLimiter(client)),
+	}
+	issued := 0
+	for i := 0; i < 4; i++ {
+		_, err := replicas[i%2].IssueToken(ctx, concert.ID, "user-1")
+		if err == nil {
+			issued++
+		} else {
+			assert.ErrorIs(t, err, pkgErr.ErrRateLimited)
+		}
+	}
+	assert.Equal(t, 2, issued)
+
+	// Without Redis each replica falls back to a limiter of its own
+	mr.Close()
+	_, err = replicas[0].IssueToken(ctx, concert.ID, "user-1")
+	assert.NoError(t, err, "the replica's own burst is unspent")
+	_, err = replicas[0].IssueToken(ctx, concert.ID, "user-1")
+	assert.NoError(t, err)
+	_, err = replicas[0].IssueToken(ctx, concert.ID, "user-1")
+	assert.ErrorIs(t, err, pkgErr.ErrRateLimited)
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/trace"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowRequestCount reads http_slow_requests_total of a route from the
+// default registry, where the middleware registers it
+func slowRequestCount(t *testing.T, method, route string) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "http_slow_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == method && labels["route"] == route {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// latencyRouter serves routes that take 5ms behind the latency budget
+// middleware and reports whether each request was flagged slow
+func latencyRouter(cfg config.Latency, out *bytes.Buffer, slow *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		*slow = c.GetBool("slowRequest")
+	})
+	router.Use(middleware.LatencyBudget(cfg, logger.New(logger.Options{Level: "info", Output: out})))
+
+	work := func(c *gin.Context) {
+		end := trace.StartSpan(c.Request.Context(), "reserve")
+		time.Sleep(5 * time.Millisecond)
+		end()
+		c.Status(http.StatusOK)
+	}
+	router.POST("/api/v1/latency/:id", work)
+	router.GET("/api/v1/latency/:id", work)
+	router.GET("/api/v1/unbudgeted", work)
+	return router
+}
+
+func TestLatencyBudgetsAreMatchedByRoute(t *testing.T) {
+	var out bytes.Buffer
+	var slow bool
+	// Viper lowercases the keys of the budgets map
+	router := latencyRouter(config.Latency{
+		DefaultBudget: time.Hour,
+		Budgets:       map[string]time.Duration{"post /api/v1/latency/:id": time.Millisecond},
+	}, &out, &slow)
+	before := slowRequestCount(t, http.MethodPost, "/api/v1/latency/:id")
+
+	call := func(method, path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+
+	call(http.MethodPost, "/api/v1/latency/42")
+	assert.True(t, slow, "the budget of the route pattern applies to every path it matches")
+	assert.Equal(t, before+1, slowRequestCount(t, http.MethodPost, "/api/v1/latency/:id"))
+	assert.Contains(t, out.String(), "Slow request: POST /api/v1/latency/:id took")
+	assert.Contains(t, out.String(), "(budget 1ms)")
+
+	out.Reset()
+	call(http.MethodGet, "/api/v1/latency/42")
+	assert.False(t, slow, "other methods of the route get the default budget")
+	assert.Zero(t, slowRequestCount(t, http.MethodGet, "/api/v1/latency/:id"))
+	assert.Empty(t, out.String())
+
+	call(http.MethodPost, "/api/v1/missing")
+	assert.False(t, slow)
+	assert.Zero(t, slowRequestCount(t, http.MethodPost, ""), "unmatched routes aren't counted")
+}
+
+func TestZeroDefaultBudgetDisablesTracking(t *testing.T) {
+	var out bytes.Buffer
+	var slow bool
+	router := latencyRouter(config.Latency{}, &out, &slow)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/unbudgeted", nil))
+	assert.False(t, slow)
+	assert.Zero(t, slowRequestCount(t, http.MethodGet, "/api/v1/unbudgeted"))
+	assert.Empty(t, out.String())
+}
+
+func TestSampledSlowRequestsLogTheirSpans(t *testing.T) {
+	budget := config.Latency{DefaultBudget: time.Millisecond}
+
+	var out bytes.Buffer
+	var slow bool
+	budget.TraceSampleRate = 1
+	latencyRouter(budget, &out, &slow).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/latency/1", nil))
+	require.True(t, slow)
+	assert.Regexp(t, `\(budget 1ms\) \| reserve \+\S+ \S+`, out.String())
+
+	out.Reset()
+	budget.TraceSampleRate = 0
+	latencyRouter(budget, &out, &slow).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/latency/1", nil))
+	require.True(t, slow)
+	assert.Contains(t, out.String(), "(budget 1ms)")
+	assert.NotContains(t, out.String(), "reserve", "requests outside the sample record no spans")
+}
+
+func TestSpansAreRecordedOnTheTraceOfTheContext(t *testing.T) {
+	// Without a trace, spans cost nothing and are dropped
+	trace.StartSpan(context.Background(), "untraced")()
+	assert.Nil(t, trace.FromContext(context.Background()))
+
+	ctx, tr := trace.New(context.Background())
+	require.Same(t, tr, trace.FromContext(ctx))
+
+	time.Sleep(2 * time.Millisecond)
+	end := trace.StartSpan(ctx, "reserve")
+	time.Sleep(2 * time.Millisecond)
+	end()
+	trace.StartSpan(ctx, "publish")()
+
+	spans := tr.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "reserve", spans[0].Name)
+	assert.GreaterOrEqual(t, spans[0].Offset, 2*time.Millisecond, "offsets are relative to the start of the trace")
+	assert.GreaterOrEqual(t, spans[0].Duration, 2*time.Millisecond)
+	assert.Equal(t, "publish", spans[1].Name)
+	assert.GreaterOrEqual(t, spans[1].Offset, spans[0].Offset+spans[0].Duration)
+
+	// Spans returns a copy
+	spans[0].Name = "changed"
+	assert.Equal(t, "reserve", tr.Spans()[0].Name)
+}
+
+func TestMetricsRequireTheAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		CORS:  config.CORS{AllowOrigins: []string{"*"}},
+		Admin: config.Admin{Token: "admin-secret"},
+	}
+	server := rest.NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, health.NewRegistry(time.Second, 1), nil, nil, logger.NewLogger("error"), cfg)
+
+	scrape := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, scrape("").Code)
+	assert.Equal(t, http.StatusForbidden, scrape("wrong").Code)
+	recorder := scrape("admin-secret")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "go_goroutines")
+}
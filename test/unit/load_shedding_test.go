@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sheddingRouter serves concerts and bookings that hold their slot until
+// release is closed, telling started when they begin
+type sheddingRouter struct {
+	*gin.Engine
+	started chan string
+	release chan struct{}
+}
+
+func newSheddingRouter(t *testing.T, cfg config.LoadShedding) *sheddingRouter {
+	gin.SetMode(gin.TestMode)
+	cfg.Enabled = true
+	cfg.RetryAfter = time.Second
+	router := &sheddingRouter{Engine: gin.New(), started: make(chan string, 10), release: make(chan struct{})}
+	router.Use(middleware.LoadShedding(cfg))
+
+	hold := func(c *gin.Context) {
+		router.started <- c.Request.Method
+		<-router.release
+		c.Status(http.StatusOK)
+	}
+	router.GET("/api/v1/concerts/:id", hold)
+	router.POST("/api/v1/bookings", hold)
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	t.Cleanup(func() {
+		select {
+		case <-router.release:
+		default:
+			close(router.release)
+		}
+	})
+	return router
+}
+
+// start serves a request in the background, returning its response once done
+func (r *sheddingRouter) start(method, target string) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- serve(r.Engine, method, target) }()
+	return done
+}
+
+func (r *sheddingRouter) waitStarted(t *testing.T, method string) {
+	t.Helper()
+	select {
+	case started := <-r.started:
+		assert.Equal(t, method, started)
+	case <-time.After(time.Second):
+		t.Fatalf("no %s request started", method)
+	}
+}
+
+func TestLoadSheddingRejectsPastTheInFlightLimit(t *testing.T) {
+	router := newSheddingRouter(t, config.LoadShedding{MaxInFlight: 1, MaxInFlightWrites: 1})
+	first := router.start(http.MethodGet, "/api/v1/concerts/1")
+	router.waitStarted(t, http.MethodGet)
+
+	shed := serve(router.Engine, http.MethodGet, "/api/v1/concerts/2")
+	assert.Equal(t, http.StatusServiceUnavailable, shed.Code)
+	assert.Equal(t, "1", shed.Header().Get("Retry-After"))
+	assert.Equal(t, "SERVICE_UNAVAILABLE", decodeProblem(t, shed).Code)
+	assert.Equal(t, http.StatusOK, serve(router.Engine, http.MethodGet, "/health").Code, "only API routes are limited")
+
+	close(router.release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, serve(router.Engine, http.MethodGet, "/api/v1/concerts/2").Code, "the slot is free again")
+}
+
+func TestLoadSheddingQueuesUntilTheTimeout(t *testing.T) {
+	router := newSheddingRouter(t, config.LoadShedding{MaxInFlight: 1, MaxInFlightWrites: 1, MaxQueue: 1, QueueTimeout: 200 * time.Millisecond})
+	first := router.start(http.MethodGet, "/api/v1/concerts/1")
+	router.waitStarted(t, http.MethodGet)
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router.Engine, http.MethodGet, "/api/v1/concerts/2").Code,
+		"a request waits no longer than the queue timeout")
+
+	queued := router.start(http.MethodGet, "/api/v1/concerts/3")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(router.Engine, http.MethodGet, "/api/v1/concerts/4").Code,
+		"requests past a full queue are rejected at once")
+
+	router.release <- struct{}{}
+	router.waitStarted(t, http.MethodGet)
+	close(router.release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-queued).Code, "a queued request takes the freed slot")
+}
+
+func TestLoadSheddingPrefersReads(t *testing.T) {
+	router := newSheddingRouter(t, config.LoadShedding{MaxInFlight: 2, MaxInFlightWrites: 1, MaxQueue: 1, QueueTimeout: time.Second})
+	booking := router.start(http.MethodPost, "/api/v1/bookings")
+	router.waitStarted(t, http.MethodPost)
+
+	// Writes past their limit queue while reads still get the free slot
+	queuedWrite := router.start(http.MethodPost, "/api/v1/bookings")
+	time.Sleep(10 * time.Millisecond)
+	read := router.start(http.MethodGet, "/api/v1/concerts/1")
+	router.waitStarted(t, http.MethodGet)
+
+	// A read arriving at a full queue takes the place of the queued write
+	queuedRead := router.start(http.MethodGet, "/api/v1/concerts/2")
+	shed := <-queuedWrite
+	require.Equal(t, http.StatusServiceUnavailable, shed.Code)
+
+	router.release <- struct{}{}
+	router.waitStarted(t, http.MethodGet)
+	close(router.release)
+	for _, done := range []<-chan *httptest.ResponseRecorder{booking, read, queuedRead} {
+		assert.Equal(t, http.StatusOK, (<-done).Code)
+	}
+}
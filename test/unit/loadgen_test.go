@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/client"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/loadgen"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := loadgen.ParseMix("browse=70, book=25,cancel=5")
+	require.NoError(t, err)
+	assert.Equal(t, loadgen.Mix{Browse: 70, Book: 25, Cancel: 5}, mix)
+
+	mix, err = loadgen.ParseMix("book=1")
+	require.NoError(t, err)
+	assert.Equal(t, loadgen.Mix{Book: 1}, mix)
+
+	for _, invalid := range []string{"", "browse", "browse=-1", "browse=0", "refund=5"} {
+		_, err := loadgen.ParseMix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLoadgenRunsTheMixAgainstTheAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newClientFixture(t)
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(f.concertRepo, nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+	handler.NewBookingHandler(f.bookingService()).RegisterRoutes(router.Group("/api/v1"))
+
+	// Every tenth concert read fails like an overloaded instance
+	var reads atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/concerts/") && reads.Add(1)%10 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	rest := client.NewREST(server.URL, nil, client.Options{Retry: client.RetryPolicy{MaxAttempts: 1}})
+	runner := &loadgen.Runner{
+		Concerts:    rest.Concerts(),
+		Bookings:    rest.Bookings(),
+		Concurrency: 8,
+		RampUp:      100 * time.Millisecond,
+		Duration:    400 * time.Millisecond,
+		Interval:    100 * time.Millisecond,
+		Mix:         loadgen.Mix{Browse: 2, Book: 2, Cancel: 1},
+		Users:       20,
+		MaxTickets:  2,
+		Seed:        1,
+		Budget:      loadgen.Budget{ErrorRate: 0.01},
+	}
+	report, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	ops := make(map[string]loadgen.OperationReport)
+	for _, op := range report.Operations {
+		ops[op.Name] = op
+	}
+	require.Contains(t, ops, loadgen.OpBook)
+	require.Contains(t, ops, loadgen.OpGetConcert)
+	assert.Positive(t, ops[loadgen.OpBook].Outcomes["ok"])
+	assert.Positive(t, ops[loadgen.OpBook].Outcomes["INSUFFICIENT_TICKETS"], "the small concerts sell out")
+	assert.Zero(t, ops[loadgen.OpBook].Errors, "refusals aren't errors of the deployment")
+	assert.Positive(t, ops[loadgen.OpGetConcert].Errors)
+	assert.Equal(t, ops[loadgen.OpGetConcert].Errors, ops[loadgen.OpGetConcert].Outcomes["500"])
+	assert.LessOrEqual(t, ops[loadgen.OpBook].P50, ops[loadgen.OpBook].P99)
+
+	// The concerts only sell what they have, cancellations returning tickets
+	for _, concert := range f.concerts {
+		stored, err := f.concertRepo.GetByID(context.Background(), concert.ID)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, stored.AvailableTickets, 0)
+	}
+
+	assert.Equal(t, report.Errors, ops[loadgen.OpGetConcert].Errors+ops[loadgen.OpListConcerts].Errors+ops[loadgen.OpCancel].Errors)
+	require.NotEmpty(t, report.Violations, "the failed reads exceed the error budget")
+	assert.Contains(t, report.Violations[0], "error rate")
+
+	require.Len(t, report.Timeline, 4)
+	assert.Less(t, report.Timeline[0].Users, report.Timeline[2].Users, "the users ramp up")
+	assert.Equal(t, 8, report.Timeline[3].Users)
+}
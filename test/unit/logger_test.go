@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextLogsCarryFieldsAsKeyValues(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Output: &out})
+
+	log.Named("booking").With(logger.UserID("user-1"), logger.ConcertID(7), logger.BookingID(42),
+		logger.Latency(1500*time.Microsecond), logger.F("note", "two words")).Info("Booked %d tickets", 2)
+
+	line := strings.TrimSpace(out.String())
+	assert.Contains(t, line, "[INFO] [booking] Booked 2 tickets user_id=user-1 concert_id=7 booking_id=42 latency_ms=1.5 note=\"two words\"")
+}
+
+func TestJSONLogsCarryFieldsAsProperties(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Format: logger.FormatJSON, Output: &out})
+
+	log.Named("http").With(logger.F("request_id", "abc"), logger.Latency(250*time.Millisecond), logger.Err(errors.New("boom"))).
+		Error("Request failed: %s", "GET /")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "http", entry["component"])
+	assert.Equal(t, "Request failed: GET /", entry["msg"])
+	assert.Equal(t, "abc", entry["request_id"])
+	assert.Equal(t, 250.0, entry["latency_ms"])
+	assert.Equal(t, "boom", entry["error"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestComponentsLogAtTheirOwnLevel(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Levels: map[string]string{"http": "error", "workers": "debug"}, Output: &out})
+
+	log.Debug("root debug")
+	log.Named("http").Info("http info")
+	log.Named("http").Error("http error")
+	log.Named("workers").Named("archive").Debug("archive debug")
+
+	logged := out.String()
+	assert.NotContains(t, logged, "root debug")
+	assert.NotContains(t, logged, "http info")
+	assert.Contains(t, logged, "[http] http error")
+	assert.Contains(t, logged, "[workers.archive] archive debug", "nested components keep the level of their parent")
+}
+
+func TestSetLevelsChangesDerivedLoggers(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(logger.Options{Level: "info", Output: &out})
+	http := log.Named("http")
+
+	http.Debug("before reload")
+	log.SetLevels("warn", map[string]string{"http": "debug"})
+	http.Debug("after reload")
+	log.Info("root info")
+
+	logged := out.String()
+	assert.NotContains(t, logged, "before reload")
+	assert.Contains(t, logged, "[http] after reload", "loggers named before the reload take the new levels")
+	assert.NotContains(t, logged, "root info")
+}
+
+func TestWithDoesNotShareFieldsBetweenLoggers(t *testing.T) {
+	var out bytes.Buffer
+	base := logger.New(logger.Options{Level: "info", Output: &out}).With(logger.F("instance", "a"))
+
+	first := base.With(logger.BookingID(1))
+	second := base.With(logger.BookingID(2))
+	first.Info("first")
+	second.Info("second")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], "first instance=a booking_id=1"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], "second instance=a booking_id=2"), lines[1])
+}
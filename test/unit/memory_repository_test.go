@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/memory"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMemory() (repository.ConcertRepository, repository.BookingRepository) {
+	store := memory.NewStore()
+	return memory.NewConcertRepository(store), memory.NewBookingRepository(store)
+}
+
+func TestMemoryBookingTakesTicketsWithTheBooking(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	concert := createStoredConcert(t, concerts, 10)
+
+	booking := &model.Booking{ConcertID: concert.ID, UserID: "user-1", TicketCount: 3,
+		Status: model.BookingStatusConfirmed, IdempotencyKey: "key-1"}
+	require.NoError(t, bookings.CreateWithTicketUpdate(ctx, booking, concert.Version))
+	assert.NotZero(t, booking.ID)
+	assert.NotEmpty(t, booking.Reference)
+
+	stored, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 7, stored.AvailableTickets)
+	assert.Equal(t, concert.Version+1, stored.Version)
+
+	// A stale version is refused without touching the tickets
+	stale := &model.Booking{ConcertID: concert.ID, UserID: "user-2", TicketCount: 1, Status: model.BookingStatusConfirmed}
+	assert.ErrorIs(t, bookings.CreateWithTicketUpdate(ctx, stale, concert.Version), pkgErr.ErrOptimisticLockFailed)
+
+	// So is a reused idempotency key, which is checked before the tickets are taken
+	retry := &model.Booking{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2,
+		Status: model.BookingStatusConfirmed, IdempotencyKey: "key-1"}
+	assert.ErrorIs(t, bookings.CreateWithTicketUpdate(ctx, retry, stored.Version), pkgErr.ErrIdempotencyKeyInUse)
+
+	after, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 7, after.AvailableTickets)
+	assert.Equal(t, stored.Version, after.Version)
+
+	found, err := bookings.GetByIdempotencyKey(ctx, "user-1", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, booking.ID, found.ID)
+}
+
+func TestMemoryBatchBookingIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	concert := createStoredConcert(t, concerts, 4)
+
+	batch := []*model.Booking{
+		{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2, Status: model.BookingStatusConfirmed},
+		{ConcertID: concert.ID, UserID: "user-2", TicketCount: 3, Status: model.BookingStatusConfirmed},
+	}
+	assert.ErrorIs(t, bookings.CreateBatchWithTicketUpdate(ctx, batch, concert.Version), pkgErr.ErrInsufficientTickets)
+
+	made, err := bookings.GetAllByConcertID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Empty(t, made)
+
+	batch[1].TicketCount = 2
+	require.NoError(t, bookings.CreateBatchWithTicketUpdate(ctx, batch, concert.Version))
+
+	stored, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.AvailableTickets)
+}
+
+func TestMemoryCancelReleasesTicketsOnce(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	concert := createStoredConcert(t, concerts, 5)
+
+	booking := &model.Booking{ConcertID: concert.ID, UserID: "user-1", TicketCount: 2, Status: model.BookingStatusConfirmed}
+	_, err := bookings.CreateWithConditionalUpdate(ctx, booking, acceptConcert)
+	require.NoError(t, err)
+
+	availability, err := bookings.CancelWithTicketRelease(ctx, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, availability.AvailableTickets)
+
+	_, err = bookings.CancelWithTicketRelease(ctx, booking.ID)
+	assert.ErrorIs(t, err, pkgErr.ErrBookingAlreadyCancelled)
+
+	stored, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.AvailableTickets)
+}
+
+func TestMemoryErasureDeletesBookingTokens(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	concert := createStoredConcert(t, memory.NewConcertRepository(store), 5)
+	bookings, tokens := memory.NewBookingRepository(store), memory.NewBookingTokenRepository(store)
+
+	booking := &model.Booking{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1, Status: model.BookingStatusConfirmed}
+	_, err := bookings.CreateWithConditionalUpdate(ctx, booking, acceptConcert)
+	require.NoError(t, err)
+	require.NoError(t, tokens.Create(ctx, "token-1", concert.ID, "user-1", time.Now().Add(time.Hour)))
+
+	count, err := bookings.AnonymizeUser(ctx, "user-1", "erased-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "the booking and the token")
+	assert.ErrorIs(t, tokens.Consume(ctx, "token-1", concert.ID, "user-1", time.Now()), pkgErr.ErrInvalidBookingToken)
+}
+
+func TestMemoryConcertListFiltersAndCopies(t *testing.T) {
+	ctx := context.Background()
+	concerts, _ := setupMemory()
+	createStoredConcert(t, concerts, 10)
+	soldOut := createStoredConcert(t, concerts, 0)
+	hidden := createStoredConcert(t, concerts, 10)
+	hidden.Visibility = model.VisibilityUnlisted
+	require.NoError(t, concerts.Update(ctx, hidden))
+
+	available := query.Filters{"available": true, "artist": "the PAGES"}
+	count, err := concerts.Count(ctx, available)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "sold out and unlisted concerts are left out")
+
+	listed, err := concerts.List(ctx, query.Options{Page: query.NewPage(1, 10), Sort: []query.Sort{{Field: "available_tickets"}}})
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, soldOut.ID, listed[0].ID)
+
+	// Changing a concert that was read doesn't change the stored one
+	listed[0].AvailableTickets = 100
+	stored, err := concerts.GetByID(ctx, soldOut.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.AvailableTickets)
+
+	_, err = concerts.List(ctx, query.Options{Page: query.NewPage(1, 10), Sort: []query.Sort{{Field: "venue"}}})
+	assertInvalidInput(t, err)
+}
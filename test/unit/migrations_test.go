@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/scripts/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrationsCoverEveryDriver(t *testing.T) {
+	for _, driver := range []string{config.DriverPostgres, config.DriverMySQL, config.DriverSQLite} {
+		latest, err := db.LatestMigration(config.Database{Driver: driver}, migrations.FS)
+		require.NoError(t, err, driver)
+		assert.Positive(t, latest, driver)
+	}
+}
+
+func TestMigratorAppliesRevertsAndForcesVersions(t *testing.T) {
+	cfg := config.Database{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "tickets.db")}
+	database, err := db.NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	defer database.Close()
+
+	files := fstest.MapFS{
+		"sqlite/000001_create_venues.up.sql":   {Data: []byte(`CREATE TABLE venues (id INTEGER PRIMARY KEY);`)},
+		"sqlite/000001_create_venues.down.sql": {Data: []byte(`DROP TABLE venues;`)},
+		"sqlite/000002_create_stages.up.sql":   {Data: []byte(`CREATE TABLE stages (id INTEGER PRIMARY KEY);`)},
+		"sqlite/000002_create_stages.down.sql": {Data: []byte(`DROP TABLE stages;`)},
+	}
+	migrator, err := db.NewMigrator(database, cfg, files)
+	require.NoError(t, err)
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, db.MigrationStatus{Version: 0, Latest: 2}, status)
+
+	require.NoError(t, migrator.Up())
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, db.MigrationStatus{Version: 2, Latest: 2}, status)
+
+	require.NoError(t, migrator.Down(1))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Version)
+	_, err = database.Exec(`SELECT id FROM stages`)
+	assert.Error(t, err, "the last migration is reverted")
+	_, err = database.Exec(`SELECT id FROM venues`)
+	assert.NoError(t, err)
+
+	require.NoError(t, migrator.Down(5), "reverting more than there is reverts everything")
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Version)
+
+	require.NoError(t, migrator.Force(2))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, db.MigrationStatus{Version: 2, Latest: 2}, status)
+	assert.Error(t, migrator.Force(3), "there is no migration 3")
+
+	require.NoError(t, migrator.Close())
+	assert.NoError(t, database.Ping(), "the database the migrator ran on stays open")
+}
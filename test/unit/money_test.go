@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"testing"
+
+	"concert-ticket-api/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUSD(t *testing.T) {
+	assert.Equal(t, "$1,234.50", money.Format(1234.5, "USD", ""))
+	assert.Equal(t, "$0.00", money.Format(0, "USD", ""))
+	assert.Equal(t, "$1.01", money.Format(1.005, "USD", ""))
+	assert.Equal(t, "-$12.35", money.Format(-12.345, "USD", ""))
+	assert.Equal(t, 19.99, money.Round(19.989, "USD"))
+}
+
+func TestFormatJPY(t *testing.T) {
+	assert.Equal(t, 0, money.Decimals("JPY"))
+	assert.Equal(t, "¥8,500", money.Format(8500, "JPY", ""))
+	assert.Equal(t, "¥1,235", money.Format(1234.5, "JPY", ""))
+	assert.Equal(t, float64(1235), money.Round(1234.5, "JPY"))
+}
+
+func TestFormatIDR(t *testing.T) {
+	assert.Equal(t, 0, money.Decimals("IDR"))
+	assert.Equal(t, "Rp1.500.000", money.Format(1500000, "IDR", ""))
+	assert.Equal(t, "Rp750.000", money.Format(749999.6, "IDR", ""))
+	assert.Equal(t, float64(750000), money.Round(749999.6, "IDR"))
+}
+
+func TestFormatEUR(t *testing.T) {
+	assert.Equal(t, "1.234,50 €", money.Format(1234.5, "EUR", ""))
+	assert.Equal(t, "€1,234.50", money.Format(1234.5, "EUR", "en-US"))
+}
+
+func TestFormatGBP(t *testing.T) {
+	assert.Equal(t, "£99.90", money.Format(99.9, "GBP", ""))
+}
+
+func TestFormatSGD(t *testing.T) {
+	assert.Equal(t, "S$1,000,000.00", money.Format(1000000, "SGD", ""))
+}
+
+func TestFormatUsesRequestedLocale(t *testing.T) {
+	assert.Equal(t, "$1.234,50", money.Format(1234.5, "USD", "id-ID"))
+	assert.Equal(t, "Rp1,500,000", money.Format(1500000, "IDR", "en-US"))
+	assert.Equal(t, "Rp1.500.000", money.Format(1500000, "IDR", "unknown"))
+}
+
+func TestUnsupportedCurrencyFallsBackToDefault(t *testing.T) {
+	assert.False(t, money.IsSupported("XYZ"))
+	assert.True(t, money.IsSupported("jpy"))
+	assert.Equal(t, "$5.00", money.Format(5, "XYZ", ""))
+}
+
+func TestResolveLocale(t *testing.T) {
+	assert.Equal(t, "id-ID", money.ResolveLocale("id-ID,id;q=0.9,en;q=0.8"))
+	assert.Equal(t, "id-ID", money.ResolveLocale("id"))
+	assert.Equal(t, "de-DE", money.ResolveLocale("fr-FR, de;q=0.7"))
+	assert.Equal(t, "ja-JP", money.ResolveLocale("ja_JP"))
+	assert.Equal(t, "", money.ResolveLocale("fr-FR"))
+	assert.Equal(t, "", money.ResolveLocale(""))
+}
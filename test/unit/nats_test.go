@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	eventspb "concert-ticket-api/api/events/proto"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/events"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// startJetStream runs a NATS server with JetStream in the test and a stream
+// capturing the subjects behind prefix
+func startJetStream(t *testing.T, prefix string) (string, jetstream.Stream) {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir()})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "EVENTS", Subjects: []string{prefix + ">"}})
+	require.NoError(t, err)
+	return srv.ClientURL(), stream
+}
+
+func TestNATSPublisherStoresEventsOnce(t *testing.T) {
+	url, stream := startJetStream(t, "tickets.")
+	ctx := context.Background()
+
+	publisher, err := events.NewPublisher(config.Events{Broker: config.BrokerNATS,
+		NATS: config.NATS{URL: url, SubjectPrefix: "tickets.", PublishTimeout: 5 * time.Second}}, nil)
+	require.NoError(t, err)
+	defer publisher.Close()
+	assert.Equal(t, config.BrokerNATS, publisher.Name())
+
+	outboxEvent := bookingEvent(t, model.EventBookingConfirmed, "7K3M9X2QAB4Z")
+	msg := &events.Message{ID: 42, Topic: outboxEvent.EventType, Key: outboxEvent.Key(), Payload: outboxEvent.Payload, At: time.Now()}
+	require.NoError(t, publisher.Publish(ctx, msg))
+	// A redelivery after a relay failed to record the event
+	require.NoError(t, publisher.Publish(ctx, msg))
+
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs, "the event ID drops the redelivery")
+
+	stored, err := stream.GetMsg(ctx, info.State.FirstSeq)
+	require.NoError(t, err)
+	assert.Equal(t, "tickets.booking.confirmed", stored.Subject)
+	assert.Equal(t, "42", stored.Header.Get("event-id"))
+	assert.Equal(t, "booking:7K3M9X2QAB4Z", stored.Header.Get("event-key"))
+	assert.Equal(t, events.ContentTypeProtobuf, stored.Header.Get("content-type"))
+
+	var booking eventspb.BookingEvent
+	require.NoError(t, proto.Unmarshal(stored.Data, &booking))
+	assert.Equal(t, "7K3M9X2QAB4Z", booking.Reference)
+	assert.Equal(t, int32(2), booking.TicketCount)
+}
+
+func TestNATSPublisherFailsWithoutStream(t *testing.T) {
+	url, _ := startJetStream(t, "tickets.")
+
+	publisher, err := events.NewNATSPublisher(config.NATS{URL: url, SubjectPrefix: "elsewhere.", PublishTimeout: time.Second})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	outboxEvent := bookingEvent(t, model.EventBookingCancelled, "7K3M9X2QAB4Z")
+	err = publisher.Publish(context.Background(), &events.Message{ID: 1, Topic: outboxEvent.EventType,
+		Key: outboxEvent.Key(), Payload: outboxEvent.Payload})
+	assert.Error(t, err, "no stream captures the subject, so the event stays in the outbox")
+}
@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/notifications"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPreferenceRouter(repo *mocks.MockNotificationPreferenceRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewNotificationPreferenceHandler(service.NewNotificationPreferenceService(repo)).RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func putPreferences(t *testing.T, router *gin.Engine, userID, body string) (*httptest.ResponseRecorder, *model.NotificationPreferences) {
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/notification-preferences?userID="+userID, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var preferences model.NotificationPreferences
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+	}
+	return w, &preferences
+}
+
+func TestNotificationPreferencesDefaultToEmail(t *testing.T) {
+	router := newPreferenceRouter(mocks.NewMockNotificationPreferenceRepository())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/notification-preferences?userID=user-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id": "user-1", "email": true, "sms": false, "push": false, "types": {}, "updated_at": "0001-01-01T00:00:00Z"}`,
+		w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/notification-preferences", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	repo := mocks.NewMockNotificationPreferenceRepository()
+	router := newPreferenceRouter(repo)
+
+	w, preferences := putPreferences(t, router, "user-1",
+		`{"sms": true, "push": true, "phone_number": " +15005550006 ", "types": {"email": {"concert_reminder": false}, "sms": {"queue_turn": false}}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, preferences.Email, "fields left out keep their value")
+	assert.True(t, preferences.SMS)
+	assert.True(t, preferences.Push)
+	assert.Equal(t, "+15005550006", preferences.PhoneNumber)
+	assert.False(t, preferences.Wants(model.NotificationChannelEmail, notifications.TemplateConcertReminder))
+	assert.True(t, preferences.Wants(model.NotificationChannelEmail, notifications.TemplateBookingConfirmed))
+	assert.False(t, preferences.Wants(model.NotificationChannelSMS, notifications.TemplateQueueTurn))
+	assert.True(t, preferences.Wants(model.NotificationChannelSMS, notifications.TemplateBookingConfirmed))
+	assert.True(t, preferences.Wants(model.NotificationChannelPush, notifications.TemplateQueueTurn))
+
+	w, preferences = putPreferences(t, router, "user-1", `{"email": false}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.False(t, preferences.Email)
+	assert.True(t, preferences.SMS)
+	assert.Len(t, preferences.Types, 2, "types are only replaced when given")
+
+	stored, err := repo.Get(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, preferences.Types, stored.Types)
+	assert.False(t, stored.Email)
+
+	w, preferences = putPreferences(t, router, "user-1", `{"sms": false, "phone_number": "", "types": {}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, preferences.PhoneNumber)
+	assert.Empty(t, preferences.Types)
+}
+
+func TestUpdateNotificationPreferencesValidates(t *testing.T) {
+	repo := mocks.NewMockNotificationPreferenceRepository()
+	router := newPreferenceRouter(repo)
+
+	for name, body := range map[string]string{
+		"phone number not in E.164":  `{"sms": true, "phone_number": "555-0100"}`,
+		"sms without a phone number": `{"sms": true}`,
+		"unknown channel":            `{"types": {"pigeon": {"booking_confirmed": false}}}`,
+		"unknown notification type":  `{"types": {"email": {"newsletter": false}}}`,
+		"malformed body":             `{"email": "yes"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, _ := putPreferences(t, router, "user-1", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	_, err := repo.Get(context.Background(), "user-1")
+	assert.Error(t, err, "invalid preferences aren't saved")
+
+	w, _ := putPreferences(t, router, "", `{"email": false}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotificationsOfTypesTurnedOffAreNotSent(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+	preferences := service.NewNotificationPreferenceService(f.preferences)
+
+	email, sms := false, true
+	phoneNumber := "+15005550006"
+	_, err := preferences.UpdatePreferences(ctx, "user-1", &model.NotificationPreferencesRequest{
+		SMS:         &sms,
+		PhoneNumber: &phoneNumber,
+		Types: model.NotificationTypes{
+			model.NotificationChannelEmail: {notifications.TemplateBookingConfirmed: false, notifications.TemplateConcertReminder: false},
+			model.NotificationChannelSMS:   {notifications.TemplateQueueTurn: false},
+		},
+	})
+	require.NoError(t, err)
+
+	confirmed := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	cancelled := f.book(t, model.BookingStatusCancelled, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, confirmed, clock.Now())
+	f.writeBookingEvent(t, model.EventBookingCancelled, cancelled, clock.Now())
+
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, enqueued, "the confirmation is texted, the cancellation emailed")
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	require.Len(t, f.sender.Messages(), 1)
+	assert.Contains(t, f.sender.Messages()[0].Subject, "cancelled")
+	require.Len(t, f.sms.Notified(), 1)
+	assert.Equal(t, notifications.TemplateBookingConfirmed, f.sms.Notified()[0].Template)
+
+	// Turning email off stops the emails already queued
+	f.writeBookingEvent(t, model.EventBookingCancelled, cancelled, clock.Now())
+	_, err = f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	_, err = preferences.UpdatePreferences(ctx, "user-1", &model.NotificationPreferencesRequest{Email: &email})
+	require.NoError(t, err)
+	sent, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(50 * time.Hour)
+	email = true
+	_, err = preferences.UpdatePreferences(ctx, "user-1", &model.NotificationPreferencesRequest{Email: &email})
+	require.NoError(t, err)
+	enqueued, err = f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "reminders are turned off")
+
+	require.NoError(t, f.notifications.NotifyQueueTurn(ctx, &model.BookingToken{
+		Token: "token", ConcertID: f.concert.ID, UserID: "user-1", ExpiresAt: clock.Now().Add(5 * time.Minute),
+	}))
+	assert.Len(t, f.sms.Notified(), 1, "queue turns are turned off")
+}
@@ -0,0 +1,648 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/mail"
+	"concert-ticket-api/pkg/notifications"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationFixture struct {
+	concerts      *mocks.MockConcertRepository
+	bookings      *mocks.MockBookingRepository
+	outbox        *mocks.MockOutboxRepository
+	repo          *mocks.MockNotificationRepository
+	preferences   *mocks.MockNotificationPreferenceRepository
+	sender        *mocks.MockMailSender
+	sms           *mocks.MockNotifier
+	notifications service.NotificationService
+	concert       *model.Concert
+}
+
+func testNotificationPolicy() model.NotificationPolicy {
+	templates := map[string]map[string]bool{
+		model.NotificationChannelEmail: {},
+		model.NotificationChannelSMS:   {},
+	}
+	for _, name := range notifications.EmailTemplates {
+		templates[model.NotificationChannelEmail][name] = true
+	}
+	for _, name := range notifications.SMSTemplates {
+		templates[model.NotificationChannelSMS][name] = true
+	}
+	return model.NotificationPolicy{
+		BatchSize:    2,
+		MaxAttempts:  3,
+		BackoffBase:  time.Minute,
+		BackoffMax:   time.Hour,
+		Retention:    24 * time.Hour,
+		MaxEventAge:  time.Hour,
+		ReminderLead: 24 * time.Hour,
+		Templates:    templates,
+	}
+}
+
+func newNotificationFixture(t *testing.T, policy model.NotificationPolicy) *notificationFixture {
+	concerts := mocks.NewMockConcertRepository()
+	bookings := mocks.NewMockBookingRepository().WithConcerts(concerts)
+	outbox := mocks.NewMockOutboxRepository()
+	preferences := mocks.NewMockNotificationPreferenceRepository()
+	repo := mocks.NewMockNotificationRepository(outbox, bookings, preferences)
+	sender := mocks.NewMockMailSender()
+	sms := mocks.NewMockNotifier()
+
+	concert, err := concerts.Create(context.Background(), &model.Concert{
+		Name:             "Summer Nights",
+		Artist:           "The Band",
+		Venue:            "Main Hall",
+		ConcertDate:      clock.Now().Add(72 * time.Hour).Truncate(time.Minute),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+	})
+	require.NoError(t, err)
+
+	notifiers := map[string]notifications.Notifier{
+		model.NotificationChannelEmail: notifications.NewEmailNotifier(sender),
+		model.NotificationChannelSMS:   sms,
+	}
+	return &notificationFixture{
+		concerts:      concerts,
+		bookings:      bookings,
+		outbox:        outbox,
+		repo:          repo,
+		preferences:   preferences,
+		sender:        sender,
+		sms:           sms,
+		notifications: service.NewNotificationService(repo, preferences, bookings, concerts, notifiers, policy),
+		concert:       concert,
+	}
+}
+
+// book creates a booking of two tickets for the fixture's concert
+func (f *notificationFixture) book(t *testing.T, status model.BookingStatus, email string) *model.Booking {
+	return f.bookFor(t, "user-1", status, email)
+}
+
+// bookFor creates a booking of two tickets for the fixture's concert by a user
+func (f *notificationFixture) bookFor(t *testing.T, userID string, status model.BookingStatus, email string) *model.Booking {
+	booking, err := f.bookings.Create(context.Background(), &model.Booking{
+		ConcertID:     f.concert.ID,
+		UserID:        userID,
+		TicketCount:   2,
+		Status:        status,
+		AttendeeName:  "Ada",
+		AttendeeEmail: email,
+		UnitPrice:     f.concert.Price,
+	})
+	require.NoError(t, err)
+	return booking
+}
+
+func (f *notificationFixture) writeBookingEvent(t *testing.T, eventType string, booking *model.Booking, at time.Time) {
+	event, err := model.NewBookingEvent(eventType, booking)
+	require.NoError(t, err)
+	f.outbox.Write(at, event)
+}
+
+func TestBookingEventsAreEmailedToTheAttendee(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	confirmed := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	cancelled := f.book(t, model.BookingStatusCancelled, "ada@example.com")
+	anonymous := f.book(t, model.BookingStatusConfirmed, "")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, confirmed, clock.Now())
+	f.writeBookingEvent(t, model.EventBookingCancelled, cancelled, clock.Now())
+	f.writeBookingEvent(t, model.EventBookingConfirmed, anonymous, clock.Now())
+
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, enqueued, "the booking without an email is skipped")
+
+	enqueued, err = f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "events are only turned into notifications once")
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	messages := f.sender.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, []string{"ada@example.com"}, messages[0].To)
+	assert.Contains(t, messages[0].Subject, "Summer Nights")
+	assert.Contains(t, messages[0].Body, confirmed.Reference)
+	assert.Contains(t, messages[0].Body, "$50.00")
+	assert.Contains(t, messages[1].Body, cancelled.Reference)
+	assert.NotEqual(t, messages[0].Subject, messages[1].Subject)
+
+	for _, notification := range f.repo.Notifications() {
+		assert.Equal(t, model.NotificationSent, notification.Status)
+		assert.Equal(t, 1, notification.Attempts)
+		assert.NotNil(t, notification.SentAt)
+	}
+}
+
+func TestDisabledTemplatesAreNotEmailed(t *testing.T) {
+	ctx := context.Background()
+	policy := testNotificationPolicy()
+	policy.Templates[model.NotificationChannelEmail][notifications.TemplateBookingConfirmed] = false
+	policy.Templates[model.NotificationChannelEmail][notifications.TemplateConcertReminder] = false
+	f := newNotificationFixture(t, policy)
+
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued)
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(60 * time.Hour)
+	enqueued, err = f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued)
+	assert.Empty(t, f.repo.Notifications())
+}
+
+func TestStaleEventsAreNotEmailed(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now().Add(-2*time.Hour))
+
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "events older than the max event age are skipped")
+
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	enqueued, err = f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, enqueued, "skipped events are marked notified all the same")
+}
+
+func TestRescheduledConcertsAreEmailedToConfirmedAttendees(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	confirmed := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.book(t, model.BookingStatusCancelled, "bob@example.com")
+	f.book(t, model.BookingStatusPending, "eve@example.com")
+
+	previous := f.concert.ConcertDate
+	rescheduled := *f.concert
+	rescheduled.ConcertDate = previous.Add(48 * time.Hour)
+	require.NoError(t, f.concerts.Update(ctx, &rescheduled))
+
+	unchanged, err := model.NewConcertUpdatedEvent(&rescheduled, rescheduled.ConcertDate)
+	require.NoError(t, err)
+	event, err := model.NewConcertUpdatedEvent(&rescheduled, previous)
+	require.NoError(t, err)
+	f.outbox.Write(clock.Now(), unchanged, event)
+
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, enqueued, "only updates that move the concert are emailed")
+
+	_, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	messages := f.sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{confirmed.AttendeeEmail}, messages[0].To)
+	assert.Contains(t, messages[0].Body, previous.Format("Mon 2 Jan 2006"))
+	assert.Contains(t, messages[0].Body, rescheduled.ConcertDate.Format("Mon 2 Jan 2006"))
+}
+
+func TestConcertRemindersAreEmailedOnce(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.book(t, model.BookingStatusCancelled, "bob@example.com")
+
+	enqueued, err := f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "the concert is further away than the reminder lead")
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(50 * time.Hour)
+	enqueued, err = f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, enqueued)
+
+	clock.Process().Advance(time.Hour)
+	enqueued, err = f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "each booking is reminded once")
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, f.sender.Messages(), 1)
+	assert.Contains(t, f.sender.Messages()[0].Subject, "coming up")
+}
+
+func TestFailedEmailsAreRetriedWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	_, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+
+	down := errors.New("mail server down")
+	f.sender.FailNext("Send", down, down, down)
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	notification := f.repo.Notifications()[0]
+	assert.Equal(t, model.NotificationPending, notification.Status)
+	assert.Equal(t, 1, notification.Attempts)
+	assert.WithinDuration(t, clock.Now().Add(time.Minute), notification.NextAttemptAt, time.Second)
+	require.NotNil(t, notification.LastError)
+	assert.Contains(t, *notification.LastError, "mail server down")
+
+	sent, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "the notification is not due before its backoff")
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(time.Minute)
+	_, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, clock.Now().Add(2*time.Minute), f.repo.Notifications()[0].NextAttemptAt, time.Second)
+
+	clock.Process().Advance(2 * time.Minute)
+	_, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	notification = f.repo.Notifications()[0]
+	assert.Equal(t, model.NotificationDead, notification.Status, "the notification is dead after the max attempts")
+	assert.Equal(t, 3, notification.Attempts)
+	assert.Empty(t, f.sender.Messages())
+}
+
+func TestEmailsOfErasedAttendeesAreDropped(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	_, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+
+	booking.AttendeeName = ""
+	booking.AttendeeEmail = ""
+	require.NoError(t, f.bookings.Update(ctx, booking))
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	notification := f.repo.Notifications()[0]
+	assert.Equal(t, model.NotificationDead, notification.Status, "there is no one left to retry for")
+	assert.Equal(t, 1, notification.Attempts)
+	assert.Empty(t, f.sender.Messages())
+}
+
+func TestFinishedNotificationsArePurged(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	f.writeBookingEvent(t, model.EventBookingCancelled, booking, clock.Now())
+	_, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+
+	f.sender.FailNext("Send", errors.New("mail server down"))
+	_, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(25 * time.Hour)
+	purged, err := f.notifications.PurgeNotifications(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged, "pending notifications are kept")
+	require.Len(t, f.repo.Notifications(), 1)
+	assert.Equal(t, model.NotificationPending, f.repo.Notifications()[0].Status)
+}
+
+func TestEveryEmailTemplateRenders(t *testing.T) {
+	data := &notifications.Data{
+		AttendeeName:        "Ada",
+		Reference:           "BK-123",
+		TicketCount:         2,
+		Total:               "$50.00",
+		ConcertName:         "Summer Nights",
+		Artist:              "The Band",
+		Venue:               "Main Hall",
+		ConcertDate:         time.Date(2026, 7, 4, 20, 0, 0, 0, time.UTC),
+		PreviousConcertDate: time.Date(2026, 7, 2, 20, 0, 0, 0, time.UTC),
+	}
+
+	for _, name := range notifications.EmailTemplates {
+		t.Run(name, func(t *testing.T) {
+			msg, err := notifications.RenderEmail(name, "ada@example.com", data)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"ada@example.com"}, msg.To)
+			assert.NotEmpty(t, msg.Subject)
+			assert.NotContains(t, msg.Subject, "\n")
+			assert.Contains(t, msg.Body, "Summer Nights")
+			assert.Contains(t, msg.Body, "Sat 4 Jul 2026, 20:00 UTC")
+			assert.NotContains(t, msg.Body, "<no value>")
+		})
+	}
+
+	_, err := notifications.RenderEmail("unknown", "ada@example.com", data)
+	assert.Error(t, err)
+}
+
+func TestSendGridSender(t *testing.T) {
+	var got struct {
+		Personalizations []struct {
+			To []struct {
+				Email string `json:"email"`
+			} `json:"to"`
+		} `json:"personalizations"`
+		From struct {
+			Email string `json:"email"`
+		} `json:"from"`
+		Subject string `json:"subject"`
+		Content []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"content"`
+		Attachments []struct {
+			Content  string `json:"content"`
+			Filename string `json:"filename"`
+		} `json:"attachments"`
+	}
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer sg-key", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"bad request"}]}`))
+	}))
+	defer server.Close()
+
+	sender := mail.NewSender(config.Mail{
+		Provider: config.MailProviderSendGrid,
+		From:     "tickets@example.com",
+		SendGrid: config.SendGrid{APIKey: "sg-key", BaseURL: server.URL, Timeout: time.Second},
+	}, logger.NewLogger("error"))
+
+	msg := &mail.Message{
+		To:          []string{"ada@example.com"},
+		Subject:     "Your tickets",
+		Body:        "Enjoy the show\n",
+		Attachments: []mail.Attachment{{Filename: "tickets.pdf", ContentType: "application/pdf", Data: []byte("%PDF")}},
+	}
+	require.NoError(t, sender.Send(context.Background(), msg))
+	require.Len(t, got.Personalizations, 1)
+	assert.Equal(t, "ada@example.com", got.Personalizations[0].To[0].Email)
+	assert.Equal(t, "tickets@example.com", got.From.Email)
+	assert.Equal(t, "Your tickets", got.Subject)
+	assert.Equal(t, "text/plain", got.Content[0].Type)
+	assert.Equal(t, "Enjoy the show\n", got.Content[0].Value)
+	require.Len(t, got.Attachments, 1)
+	assert.Equal(t, "JVBERg==", got.Attachments[0].Content)
+
+	status = http.StatusBadRequest
+	err := sender.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestBookingConfirmationsAreTextedToUsersWhoOptedIn(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "texted", Email: true, SMS: true, PhoneNumber: "+15005550006"}))
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "no-phone", Email: true, SMS: true}))
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "text-only", SMS: true, PhoneNumber: "+15005550007"}))
+
+	texted := f.bookFor(t, "texted", model.BookingStatusConfirmed, "ada@example.com")
+	noPhone := f.bookFor(t, "no-phone", model.BookingStatusConfirmed, "bob@example.com")
+	textOnly := f.bookFor(t, "text-only", model.BookingStatusConfirmed, "eve@example.com")
+	cancelled := f.bookFor(t, "texted", model.BookingStatusCancelled, "ada@example.com")
+	for _, booking := range []*model.Booking{texted, noPhone, textOnly} {
+		f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	}
+	f.writeBookingEvent(t, model.EventBookingCancelled, cancelled, clock.Now())
+
+	enqueued := 0
+	for batch := 0; batch < 2; batch++ {
+		created, err := f.notifications.Dispatch(ctx)
+		require.NoError(t, err)
+		enqueued += created
+	}
+	assert.Equal(t, 5, enqueued, "emails but to the user who turned them off, texts but of cancellations")
+
+	sent := 0
+	for batch := 0; batch < 3; batch++ {
+		delivered, err := f.notifications.SendDue(ctx)
+		require.NoError(t, err)
+		sent += delivered
+	}
+	assert.Equal(t, 5, sent)
+
+	emailed := []string{}
+	for _, msg := range f.sender.Messages() {
+		emailed = append(emailed, msg.To...)
+	}
+	assert.ElementsMatch(t, []string{"ada@example.com", "bob@example.com", "ada@example.com"}, emailed)
+
+	notified := f.sms.Notified()
+	require.Len(t, notified, 2)
+	assert.Equal(t, "+15005550006", notified[0].To)
+	assert.Equal(t, notifications.TemplateBookingConfirmed, notified[0].Template)
+	assert.Equal(t, texted.Reference, notified[0].Data.Reference)
+	assert.Equal(t, "$50.00", notified[0].Data.Total)
+	assert.Equal(t, "+15005550007", notified[1].To)
+}
+
+func TestNotificationsOfUsersWhoOptedOutAreDropped(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	preferences := &model.NotificationPreferences{UserID: "user-1", Email: true, SMS: true, PhoneNumber: "+15005550006"}
+	require.NoError(t, f.preferences.Set(ctx, preferences))
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	enqueued, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, enqueued)
+
+	preferences.Email, preferences.SMS = false, false
+	require.NoError(t, f.preferences.Set(ctx, preferences))
+
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "preferences are checked again when sending")
+	for _, notification := range f.repo.Notifications() {
+		assert.Equal(t, model.NotificationDead, notification.Status)
+	}
+	assert.Empty(t, f.sender.Messages())
+	assert.Empty(t, f.sms.Notified())
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(50 * time.Hour)
+	enqueued, err = f.notifications.EnqueueReminders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued, "users who turned email off aren't reminded")
+}
+
+func TestFailedTextsAreRetried(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "user-1", SMS: true, PhoneNumber: "+15005550006"}))
+	booking := f.book(t, model.BookingStatusConfirmed, "ada@example.com")
+	f.writeBookingEvent(t, model.EventBookingConfirmed, booking, clock.Now())
+	_, err := f.notifications.Dispatch(ctx)
+	require.NoError(t, err)
+
+	f.sms.FailNext("Notify", errors.New("provider down"))
+	sent, err := f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	notification := f.repo.Notifications()[0]
+	assert.Equal(t, model.NotificationChannelSMS, notification.Channel)
+	assert.Equal(t, model.NotificationPending, notification.Status)
+
+	defer clock.Process().Reset()
+	clock.Process().Advance(time.Minute)
+	sent, err = f.notifications.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, f.sms.Notified(), 1)
+}
+
+func TestQueueTurnsAreTextedToUsersWhoOptedIn(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, testNotificationPolicy())
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "user-1", SMS: true, PhoneNumber: "+15005550006"}))
+
+	bus := events.NewBus()
+	service.SubscribeQueueTurnNotifications(bus, f.notifications, logger.NewLogger("error"))
+
+	expiresAt := clock.Now().Add(5 * time.Minute)
+	for _, userID := range []string{"user-1", "user-2"} {
+		bus.Publish(events.Event{
+			Topic:   model.EventWaitingRoomAdmitted,
+			Key:     model.UserEventKey(userID),
+			Payload: &model.BookingToken{Token: "token-" + userID, ConcertID: f.concert.ID, UserID: userID, ExpiresAt: expiresAt},
+			At:      clock.Now(),
+		})
+	}
+
+	require.Eventually(t, func() bool { return len(f.sms.Notified()) == 1 }, time.Second, 5*time.Millisecond)
+	notified := f.sms.Notified()[0]
+	assert.Equal(t, "+15005550006", notified.To)
+	assert.Equal(t, notifications.TemplateQueueTurn, notified.Template)
+	assert.Equal(t, "Summer Nights", notified.Data.ConcertName)
+	assert.Equal(t, expiresAt, notified.Data.TokenExpiresAt)
+	assert.Empty(t, f.repo.Notifications(), "queue turns aren't queued for retries")
+}
+
+func TestWaitingRoomAnnouncesAdmittedUsers(t *testing.T) {
+	bus := events.NewBus()
+	var admitted []*model.BookingToken
+	bus.Handle(model.EventWaitingRoomAdmitted, func(event events.Event) {
+		admitted = append(admitted, event.Payload.(*model.BookingToken))
+	})
+
+	tokens := &quotaTokenService{}
+	room := service.NewWaitingRoom(tokens, bus)
+	room.Join(context.Background(), 1, "user-1")
+	room.Join(context.Background(), 1, "user-2")
+
+	tokens.grant(1)
+	assert.Equal(t, 1, room.Admit(context.Background()))
+	require.Len(t, admitted, 1)
+	assert.Equal(t, "user-1", admitted[0].UserID)
+}
+
+func TestEverySMSTemplateRenders(t *testing.T) {
+	data := &notifications.Data{
+		Reference:      "BK-123",
+		TicketCount:    1,
+		Total:          "$25.00",
+		ConcertName:    "Summer Nights",
+		ConcertDate:    time.Date(2026, 7, 4, 20, 0, 0, 0, time.UTC),
+		TokenExpiresAt: time.Date(2026, 7, 1, 10, 5, 0, 0, time.UTC),
+	}
+
+	for _, name := range notifications.SMSTemplates {
+		t.Run(name, func(t *testing.T) {
+			body, err := notifications.RenderSMS(name, data)
+			require.NoError(t, err)
+			assert.Contains(t, body, "Summer Nights")
+			assert.NotContains(t, body, "\n")
+			assert.LessOrEqual(t, len(body), 160, "a text fits one SMS segment")
+		})
+	}
+
+	body, err := notifications.RenderSMS(notifications.TemplateQueueTurn, data)
+	require.NoError(t, err)
+	assert.Contains(t, body, "10:05 UTC")
+}
+
+func TestTwilioNotifier(t *testing.T) {
+	var form url.Values
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "auth-token", password)
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number"}`))
+	}))
+	defer server.Close()
+
+	notifier := notifications.NewSMSNotifier(config.SMS{
+		Provider: config.SMSProviderTwilio,
+		Twilio: config.Twilio{AccountSID: "AC123", AuthToken: "auth-token", From: "+15005550000",
+			BaseURL: server.URL, Timeout: time.Second},
+	}, logger.NewLogger("error"))
+
+	data := &notifications.Data{ConcertName: "Summer Nights", TokenExpiresAt: time.Date(2026, 7, 1, 10, 5, 0, 0, time.UTC)}
+	require.NoError(t, notifier.Notify(context.Background(), "+15005550006", notifications.TemplateQueueTurn, data))
+	assert.Equal(t, "+15005550006", form.Get("To"))
+	assert.Equal(t, "+15005550000", form.Get("From"))
+	assert.Contains(t, form.Get("Body"), "Summer Nights")
+
+	status = http.StatusBadRequest
+	err := notifier.Notify(context.Background(), "+1", notifications.TemplateQueueTurn, data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid 'To' Phone Number")
+}
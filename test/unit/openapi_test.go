@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/pkg/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOpenAPITestServer(t *testing.T) (*rest.Server, *openapi.Document) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		CORS:      config.CORS{AllowOrigins: []string{"*"}},
+		TestClock: config.TestClock{Enabled: true},
+	}
+	server := rest.NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, health.NewRegistry(time.Second, 1), worker.NewRegistry("test", nil, nil), nil, logger.NewLogger("error"), cfg)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document openapi.Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	return server, &document
+}
+
+// pathParam matches gin path parameters, which OpenAPI writes in braces
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	server, document := newOpenAPITestServer(t)
+	assert.Equal(t, openapi.Version, document.OpenAPI)
+
+	documented := 0
+	for _, route := range server.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/") || route.Path == "/api/v1/openapi.json" {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		item, ok := document.Paths[path]
+		if assert.True(t, ok, "%s is not documented", route.Path) {
+			assert.Contains(t, item, strings.ToLower(route.Method), "%s %s is not documented", route.Method, route.Path)
+		}
+		documented++
+	}
+
+	operations := 0
+	for _, item := range document.Paths {
+		operations += len(item)
+	}
+	assert.Equal(t, documented, operations, "the document lists routes that aren't registered")
+}
+
+func TestOpenAPISchemasFollowJSONTags(t *testing.T) {
+	_, document := newOpenAPITestServer(t)
+
+	booking := document.Components.Schemas["Booking"]
+	require.NotNil(t, booking)
+	assert.Contains(t, booking.Properties, "reference")
+	assert.NotContains(t, booking.Properties, "ID", "fields hidden from JSON must not be documented")
+	assert.Equal(t, "date-time", booking.Properties["booking_time"].Format)
+
+	request := document.Components.Schemas["BookingRequest"]
+	require.NotNil(t, request)
+	assert.ElementsMatch(t, []string{"concert_id", "user_id", "ticket_count"}, request.Required)
+
+	body := document.Paths["/api/v1/bookings"]["post"].RequestBody
+	require.NotNil(t, body)
+	assert.Equal(t, "#/components/schemas/BookingRequest", body.Content["application/json"].Schema.Ref)
+
+	list := document.Components.Schemas["ConcertListResponse"]
+	require.NotNil(t, list)
+	assert.Equal(t, "#/components/schemas/Concert", list.Properties["data"].Items.Ref)
+
+	admin := document.Paths["/api/v1/admin/booking-conflicts"]["get"]
+	assert.NotEmpty(t, admin.Security, "admin routes must require the admin token")
+}
+
+func TestOpenAPIBuildHandlesNestedTypes(t *testing.T) {
+	type node struct {
+		Name     string            `json:"name" binding:"required"`
+		Children []*node           `json:"children,omitempty"`
+		Labels   map[string]string `json:"labels"`
+		Seen     *time.Time        `json:"seen"`
+		hidden   int
+	}
+
+	document := openapi.Build(openapi.Info{Title: "test", Version: "1"}, []openapi.Operation{{
+		Method:    http.MethodGet,
+		Path:      "/nodes/:id",
+		Responses: map[int]interface{}{http.StatusOK: node{}, http.StatusNotFound: nil},
+	}})
+
+	schema := document.Components.Schemas["node"]
+	require.NotNil(t, schema)
+	assert.Equal(t, "#/components/schemas/node", schema.Properties["children"].Items.Ref)
+	assert.Equal(t, "string", schema.Properties["labels"].AdditionalProperties.Type)
+	assert.True(t, schema.Properties["seen"].Nullable)
+	assert.NotContains(t, schema.Properties, "hidden")
+	assert.Equal(t, []string{"name"}, schema.Required)
+
+	operation := document.Paths["/nodes/{id}"]["get"]
+	require.Len(t, operation.Parameters, 1)
+	assert.Equal(t, "path", operation.Parameters[0].In)
+	assert.Nil(t, operation.Responses["404"].Content)
+}
+
+func TestSwaggerUIAllowsItsAssets(t *testing.T) {
+	server, _ := newOpenAPITestServer(t)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "swagger-ui-bundle.js")
+	assert.Contains(t, recorder.Header().Get("Content-Security-Policy"), "https://unpkg.com")
+}
+
+func TestOpenAPIValidateReportsMismatches(t *testing.T) {
+	type item struct {
+		Name  string    `json:"name"`
+		Price float64   `json:"price"`
+		Tags  []string  `json:"tags"`
+		At    time.Time `json:"at"`
+	}
+	document := openapi.Build(openapi.Info{Title: "test", Version: "1"}, []openapi.Operation{{
+		Method:    http.MethodGet,
+		Path:      "/items",
+		Responses: map[int]interface{}{http.StatusOK: []item{}},
+	}})
+	schema := document.Operation(http.MethodGet, "/items").Responses["200"].Content["application/json"].Schema
+
+	assert.NoError(t, document.Validate(schema, []byte(`[{"name": "a", "price": 1.5, "tags": null, "at": "2025-01-02T03:04:05Z"}]`)))
+	assert.NoError(t, document.Validate(schema, []byte(`null`)), "nil slices render as null")
+
+	err := document.Validate(schema, []byte(`[{"name": 1, "price": "free", "at": "today", "colour": "red"}]`))
+	require.Error(t, err)
+	for _, mismatch := range []string{"$[0].name: is number, want string", "$[0].price: is string, want number",
+		`$[0].at: "today" is not a date-time`, "$[0]: has undocumented property colour"} {
+		assert.Contains(t, err.Error(), mismatch)
+	}
+}
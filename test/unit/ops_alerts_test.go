@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/alerts"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOpsAlertPolicy() model.OpsAlertPolicy {
+	return model.OpsAlertPolicy{SellOuts: true, Drift: true, ErrorRateThreshold: 0.2, ErrorRateMinAttempts: 50, Instance: "api-1"}
+}
+
+func newOpsAlertsFixture(t *testing.T, policy model.OpsAlertPolicy) (service.OpsAlerts, *mocks.MockAlertPoster, *model.Concert) {
+	concerts := mocks.NewMockConcertRepository()
+	concert, err := concerts.Create(context.Background(), &model.Concert{
+		Name:         "Summer Nights",
+		Artist:       "The Band",
+		Venue:        "Main Hall",
+		ConcertDate:  time.Date(2026, 7, 4, 20, 0, 0, 0, time.UTC),
+		TotalTickets: 500,
+		Price:        25,
+		Currency:     "USD",
+	})
+	require.NoError(t, err)
+
+	poster := mocks.NewMockAlertPoster()
+	return service.NewOpsAlerts(alerts.NewAlerter(poster, 15*time.Minute), concerts, policy), poster, concert
+}
+
+func TestAlertPosters(t *testing.T) {
+	for provider, field := range map[string]string{config.AlertProviderSlack: "text", config.AlertProviderDiscord: "content"} {
+		t.Run(provider, func(t *testing.T) {
+			var body map[string]string
+			status := http.StatusNoContent
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte("invalid_token"))
+			}))
+			defer server.Close()
+
+			poster := alerts.NewPoster(config.Alerts{Provider: provider, WebhookURL: server.URL, Timeout: time.Second})
+			require.NoError(t, poster.Post(context.Background(), "Sold out: Summer Nights"))
+			assert.Equal(t, map[string]string{field: "Sold out: Summer Nights"}, body)
+
+			status = http.StatusForbidden
+			err := poster.Post(context.Background(), "Sold out: Summer Nights")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid_token")
+		})
+	}
+}
+
+func TestAlertsAreThrottled(t *testing.T) {
+	defer clock.Process().Reset()
+	ctx := context.Background()
+	poster := mocks.NewMockAlertPoster()
+	alerter := alerts.NewAlerter(poster, 15*time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := alerter.Alert(ctx, "sell_out:1", "Sold out: Summer Nights")
+		require.NoError(t, err)
+	}
+	posted, err := alerter.Alert(ctx, "sell_out:2", "Sold out: Winter Nights")
+	require.NoError(t, err)
+	assert.True(t, posted, "alerts of other keys aren't held back")
+	assert.Equal(t, []string{"Sold out: Summer Nights", "Sold out: Winter Nights"}, poster.Posted())
+
+	clock.Process().Advance(16 * time.Minute)
+	posted, err = alerter.Alert(ctx, "sell_out:1", "Sold out: Summer Nights")
+	require.NoError(t, err)
+	assert.True(t, posted)
+	assert.Equal(t, "Sold out: Summer Nights (2 more held back since the last one)", poster.Posted()[2])
+
+	// An alert that failed to post doesn't hold back the next
+	clock.Process().Advance(16 * time.Minute)
+	poster.FailNext("Post", errors.New("webhook down"))
+	_, err = alerter.Alert(ctx, "sell_out:1", "Sold out: Summer Nights")
+	require.Error(t, err)
+	posted, err = alerter.Alert(ctx, "sell_out:1", "Sold out: Summer Nights")
+	require.NoError(t, err)
+	assert.True(t, posted)
+	assert.Len(t, poster.Posted(), 4)
+
+	var discarded *alerts.Alerter
+	posted, err = discarded.Alert(ctx, "sell_out:1", "Sold out: Summer Nights")
+	assert.NoError(t, err)
+	assert.False(t, posted)
+}
+
+func TestSellOutsAreAlerted(t *testing.T) {
+	opsAlerts, poster, concert := newOpsAlertsFixture(t, testOpsAlertPolicy())
+	bus := events.NewBus()
+	service.SubscribeSellOutAlerts(bus, opsAlerts, logger.NewLogger("error"))
+
+	for _, available := range []int{3, 0} {
+		bus.Publish(events.Event{
+			Topic:   model.EventConcertAvailability,
+			Key:     concert.ID,
+			Payload: &model.ConcertAvailability{ConcertID: concert.ID, AvailableTickets: available, TotalTickets: 500},
+			At:      clock.Now(),
+		})
+	}
+
+	require.Eventually(t, func() bool { return len(poster.Posted()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "Sold out: Summer Nights by The Band at Main Hall on 4 Jul 2026 (concert 1), all 500 tickets booked", poster.Posted()[0])
+
+	require.NoError(t, opsAlerts.SellOut(context.Background(), concert.ID))
+	assert.Len(t, poster.Posted(), 1, "a concert selling out again is throttled")
+
+	policy := testOpsAlertPolicy()
+	policy.SellOuts = false
+	opsAlerts, poster, concert = newOpsAlertsFixture(t, policy)
+	require.NoError(t, opsAlerts.SellOut(context.Background(), concert.ID))
+	assert.Empty(t, poster.Posted())
+}
+
+func TestBookingErrorRateSpikesAreAlerted(t *testing.T) {
+	ctx := context.Background()
+	opsAlerts, poster, concert := newOpsAlertsFixture(t, testOpsAlertPolicy())
+	record := func(reason string, n int) {
+		for i := 0; i < n; i++ {
+			opsAlerts.Record(&model.BookingAttempt{ConcertID: concert.ID, Reason: reason})
+		}
+	}
+
+	record(model.BookingAttemptError, 20)
+	require.NoError(t, opsAlerts.CheckErrorRate(ctx))
+	assert.Empty(t, poster.Posted(), "too few attempts to count")
+
+	record(model.BookingAttemptBooked, 40)
+	record(model.BookingAttemptSoldOut, 10)
+	record(model.BookingAttemptError, 10)
+	require.NoError(t, opsAlerts.CheckErrorRate(ctx))
+	assert.Empty(t, poster.Posted(), "sold out concerts aren't errors")
+
+	record(model.BookingAttemptBooked, 40)
+	record(model.BookingAttemptError, 20)
+	require.NoError(t, opsAlerts.CheckErrorRate(ctx))
+	require.Len(t, poster.Posted(), 1)
+	assert.Contains(t, poster.Posted()[0], "Booking error rate at 33% on api-1: 20 of 60 attempts failed")
+}
+
+func TestPaymentDriftIsAlerted(t *testing.T) {
+	ctx := context.Background()
+	opsAlerts, poster, _ := newOpsAlertsFixture(t, testOpsAlertPolicy())
+
+	require.NoError(t, opsAlerts.Drift(ctx, &model.PaymentReconciliation{Checked: 40, Mismatches: map[string]int{}}))
+	assert.Empty(t, poster.Posted())
+
+	require.NoError(t, opsAlerts.Drift(ctx, &model.PaymentReconciliation{Checked: 40, Repaired: 1,
+		Mismatches: map[string]int{model.MismatchPaidNotConfirmed: 2, model.MismatchConfirmedNotPaid: 1}}))
+	require.Len(t, poster.Posted(), 1)
+	assert.Equal(t, "Payment reconciliation found 3 bookings disagreeing with their payments: 2 paid but not confirmed, "+
+		"1 confirmed but not paid, 1 repaired", poster.Posted()[0])
+}
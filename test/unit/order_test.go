@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"concert-ticket-api/internal/model"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *cartFixture) checkout(t *testing.T, user string, items map[int64]int) *model.Order {
+	for concertID, tickets := range items {
+		f.add(t, user, concertID, tickets)
+	}
+	order, err := f.carts.Checkout(context.Background(), &model.CheckoutRequest{UserID: user})
+	require.NoError(t, err)
+	return order
+}
+
+func TestOrdersAreFetchedWithTheirBookings(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+	first := f.createConcert(t, 10, 25)
+	second := f.createConcert(t, 10, 40.5)
+
+	older := f.checkout(t, "user-1", map[int64]int{first.ID: 1})
+	newer := f.checkout(t, "user-1", map[int64]int{first.ID: 3, second.ID: 1})
+	f.checkout(t, "user-2", map[int64]int{second.ID: 2})
+
+	order, err := f.orders.GetOrderByReference(ctx, strings.ToLower(newer.Reference))
+	require.NoError(t, err)
+	assert.Equal(t, newer.Reference, order.Reference)
+	assert.Equal(t, model.BookingStatusConfirmed, order.Status)
+	assert.Equal(t, 115.5, order.Total, "the total is what the checkout charged")
+	assert.Len(t, order.Bookings, 2)
+
+	_, err = f.orders.GetOrderByReference(ctx, "not-a-reference")
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound)
+
+	orders, err := f.orders.GetUserOrders(ctx, "user-1", query.Page{Number: 1, Size: 1})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, newer.Reference, orders[0].Reference, "newest first")
+
+	orders, err = f.orders.GetUserOrders(ctx, "user-1", query.Page{Number: 2, Size: 1})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, older.Reference, orders[0].Reference)
+	assert.Equal(t, 25.0, orders[0].Total)
+
+	_, err = f.orders.GetUserOrders(ctx, "", query.Page{})
+	assertInvalidInput(t, err)
+}
+
+func TestCancelOrderReleasesTheTicketsOfItsConfirmedBookings(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+	first := f.createConcert(t, 10, 25)
+	second := f.createConcert(t, 10, 25)
+
+	order := f.checkout(t, "user-1", map[int64]int{first.ID: 2, second.ID: 3})
+	assert.Equal(t, 8, f.available(t, first.ID))
+	assert.Equal(t, 7, f.available(t, second.ID))
+
+	// A booking of the order cancelled on its own already released its tickets
+	for _, booking := range order.Bookings {
+		if booking.ConcertID == second.ID {
+			booking.Status = model.BookingStatusCancelled
+			require.NoError(t, f.bookings.Update(ctx, booking))
+		}
+	}
+
+	_, err := f.orders.CancelOrder(ctx, order.Reference, "user-2")
+	assert.ErrorIs(t, err, pkgErr.ErrUnauthorized)
+
+	cancelled, err := f.orders.CancelOrder(ctx, order.Reference, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusCancelled, cancelled.Status)
+	for _, booking := range cancelled.Bookings {
+		assert.Equal(t, model.BookingStatusCancelled, booking.Status)
+	}
+	assert.Equal(t, 125.0, cancelled.Total, "the total stays what was paid")
+
+	assert.Equal(t, 10, f.available(t, first.ID))
+	assert.Equal(t, 7, f.available(t, second.ID), "tickets aren't released twice")
+
+	_, err = f.orders.CancelOrder(ctx, order.Reference, "user-1")
+	assert.ErrorIs(t, err, pkgErr.ErrOrderAlreadyCancelled)
+}
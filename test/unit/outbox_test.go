@@ -0,0 +1,210 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	eventspb "concert-ticket-api/api/events/proto"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func bookingEvent(t *testing.T, eventType, reference string) *model.OutboxEvent {
+	event, err := model.NewBookingEvent(eventType, &model.Booking{Reference: reference, ConcertID: 1,
+		UserID: "user-1", TicketCount: 2, UnitPrice: 25, Status: model.BookingStatusConfirmed})
+	require.NoError(t, err)
+	return event
+}
+
+func TestNewBookingEventLeavesOutAttendee(t *testing.T) {
+	event, err := model.NewBookingEvent(model.EventBookingConfirmed, &model.Booking{Reference: "7K3M9X2QAB4Z",
+		ConcertID: 3, UserID: "user-1", TicketCount: 2, Status: model.BookingStatusConfirmed,
+		AttendeeName: "Ada Lovelace", AttendeeEmail: "ada@example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "booking:7K3M9X2QAB4Z", event.Key())
+	assert.Equal(t, model.EventBookingConfirmed, event.EventType)
+	assert.NotContains(t, string(event.Payload), "ada@example.com")
+	assert.NotContains(t, string(event.Payload), "Ada Lovelace")
+
+	var payload model.BookingEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, int64(3), payload.ConcertID)
+	assert.Equal(t, 2, payload.TicketCount)
+}
+
+func TestOutboxRelayPublishesInOrder(t *testing.T) {
+	repo := mocks.NewMockOutboxRepository()
+	publisher := mocks.NewMockPublisher()
+	ctx := context.Background()
+
+	concert, err := model.NewConcertEvent(model.EventConcertCreated, &model.Concert{ID: 9, Name: "Opening Night"})
+	require.NoError(t, err)
+	repo.Write(time.Now(), concert,
+		bookingEvent(t, model.EventBookingConfirmed, "AAAA"),
+		bookingEvent(t, model.EventBookingConfirmed, "BBBB"),
+		bookingEvent(t, model.EventBookingCancelled, "AAAA"))
+
+	relay := service.NewOutboxService(repo, publisher, 10, time.Hour)
+	published, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, published)
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 4)
+	assert.Equal(t, "concert:9", messages[0].Key)
+	assert.Equal(t, model.EventConcertCreated, messages[0].Topic)
+	assert.Equal(t, "booking:AAAA", messages[3].Key)
+	assert.Equal(t, model.EventBookingCancelled, messages[3].Topic)
+	for i, message := range messages {
+		assert.Equal(t, int64(i+1), message.ID)
+	}
+
+	// Published events aren't published again
+	published, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+}
+
+func TestOutboxRelayHoldsBackAggregateAfterFailure(t *testing.T) {
+	repo := mocks.NewMockOutboxRepository()
+	publisher := mocks.NewMockPublisher()
+	ctx := context.Background()
+
+	repo.Write(time.Now(),
+		bookingEvent(t, model.EventBookingConfirmed, "AAAA"),
+		bookingEvent(t, model.EventBookingConfirmed, "BBBB"),
+		bookingEvent(t, model.EventBookingCancelled, "AAAA"))
+
+	relay := service.NewOutboxService(repo, publisher, 10, time.Hour)
+	publisher.SetFailing("booking:AAAA", true)
+	published, err := relay.Relay(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, published, "other aggregates are published")
+	require.Len(t, publisher.Messages(), 1)
+	assert.Equal(t, "booking:BBBB", publisher.Messages()[0].Key)
+
+	written := repo.Events()
+	assert.Equal(t, 1, written[0].Attempts)
+	assert.Equal(t, "broker unavailable", repo.LastError(written[0].ID))
+	assert.Equal(t, 0, written[2].Attempts, "the cancellation waits for the confirmation")
+
+	publisher.SetFailing("booking:AAAA", false)
+	published, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 3)
+	assert.Equal(t, model.EventBookingConfirmed, messages[1].Topic)
+	assert.Equal(t, model.EventBookingCancelled, messages[2].Topic)
+}
+
+func TestOutboxPurge(t *testing.T) {
+	ctx := context.Background()
+	old := time.Now().Add(-2 * time.Hour)
+
+	// Without a broker, nothing is published and old events are purged anyway
+	repo := mocks.NewMockOutboxRepository()
+	repo.Write(old, bookingEvent(t, model.EventBookingConfirmed, "AAAA"))
+	repo.Write(time.Now(), bookingEvent(t, model.EventBookingConfirmed, "BBBB"))
+	relay := service.NewOutboxService(repo, nil, 10, time.Hour)
+
+	published, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+
+	purged, err := relay.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Len(t, repo.Events(), 1)
+
+	// With a broker, unpublished events are kept however old they are
+	repo = mocks.NewMockOutboxRepository()
+	publisher := mocks.NewMockPublisher()
+	repo.Write(old, bookingEvent(t, model.EventBookingConfirmed, "AAAA"),
+		bookingEvent(t, model.EventBookingConfirmed, "BBBB"))
+	relay = service.NewOutboxService(repo, publisher, 10, time.Hour)
+
+	publisher.SetFailing("booking:BBBB", true)
+	_, err = relay.Relay(ctx)
+	assert.Error(t, err)
+
+	purged, err = relay.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	require.Len(t, repo.Events(), 1)
+	assert.Equal(t, "BBBB", repo.Events()[0].AggregateID)
+}
+
+func TestEventsValidate(t *testing.T) {
+	cfg := config.Events{Broker: config.BrokerNone, RelayInterval: time.Second, BatchSize: 100, Retention: time.Hour}
+	assert.NoError(t, cfg.Validate())
+	assert.False(t, cfg.Publishes())
+
+	cfg.Broker = config.BrokerLog
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.Publishes())
+
+	cfg.Broker = config.BrokerKafka
+	assert.Error(t, cfg.Validate(), "kafka needs brokers")
+	cfg.Kafka = config.Kafka{Brokers: []string{"kafka-1:9092"}, WriteTimeout: 10 * time.Second}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Broker = config.BrokerNATS
+	assert.Error(t, cfg.Validate(), "nats needs a URL")
+	cfg.NATS = config.NATS{URL: "nats://nats-1:4222", PublishTimeout: 10 * time.Second}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Broker = "carrier-pigeon"
+	assert.Error(t, cfg.Validate())
+
+	cfg = config.Events{Broker: config.BrokerNone, RelayInterval: time.Second, Retention: time.Hour}
+	assert.Error(t, cfg.Validate(), "batch size must be positive")
+}
+
+func TestEncodeEventsInTheirSchema(t *testing.T) {
+	bookingTime := time.Date(2026, 3, 1, 18, 30, 0, 0, time.UTC)
+	outboxEvent, err := model.NewBookingEvent(model.EventBookingCancelled, &model.Booking{Reference: "7K3M9X2QAB4Z",
+		ConcertID: 3, UserID: "user-1", TicketCount: 2, UnitPrice: 49.5, Status: model.BookingStatusCancelled,
+		BookingTime: bookingTime, AttendeeEmail: "ada@example.com"})
+	require.NoError(t, err)
+
+	value, err := events.Encode(&events.Message{ID: 7, Topic: outboxEvent.EventType, Key: outboxEvent.Key(), Payload: outboxEvent.Payload})
+	require.NoError(t, err)
+
+	var booking eventspb.BookingEvent
+	require.NoError(t, proto.Unmarshal(value, &booking))
+	assert.Equal(t, "7K3M9X2QAB4Z", booking.Reference)
+	assert.Equal(t, int64(3), booking.ConcertId)
+	assert.Equal(t, int32(2), booking.TicketCount)
+	assert.Equal(t, 49.5, booking.UnitPrice)
+	assert.Equal(t, "cancelled", booking.Status)
+	assert.True(t, bookingTime.Equal(booking.BookingTime.AsTime()))
+
+	concertDate := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	outboxEvent, err = model.NewConcertEvent(model.EventConcertUpdated, &model.Concert{ID: 9, Name: "Opening Night",
+		ConcertDate: concertDate, TotalTickets: 500, Version: 4, OrganizerEmail: "org@example.com"})
+	require.NoError(t, err)
+
+	value, err = events.Encode(&events.Message{Topic: outboxEvent.EventType, Payload: outboxEvent.Payload})
+	require.NoError(t, err)
+
+	var concert eventspb.ConcertEvent
+	require.NoError(t, proto.Unmarshal(value, &concert))
+	assert.Equal(t, int64(9), concert.Id)
+	assert.Equal(t, int32(4), concert.Version)
+	assert.True(t, concertDate.Equal(concert.ConcertDate.AsTime()))
+
+	_, err = events.Encode(&events.Message{Topic: "concert.deleted", Payload: []byte(`{}`)})
+	assert.Error(t, err, "topics without a schema aren't published")
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentProvidersFollowTheConfig(t *testing.T) {
+	provider, err := payments.New(config.Payments{Provider: config.PaymentProviderNone})
+	require.NoError(t, err)
+	assert.Nil(t, provider, "bookings aren't paid")
+
+	provider, err = payments.New(config.Payments{Provider: config.PaymentProviderFake, Fake: config.FakePayments{Outcome: config.FakeOutcomeAuthorized}})
+	require.NoError(t, err)
+	assert.Equal(t, "fake", provider.Name())
+
+	provider, err = payments.New(config.Payments{Provider: config.PaymentProviderStripe})
+	require.NoError(t, err)
+	assert.Equal(t, "stripe", provider.Name())
+
+	_, err = payments.New(config.Payments{Provider: "adyen"})
+	assert.Error(t, err)
+}
+
+func TestFakeProviderAuthorizesRightAway(t *testing.T) {
+	fake := payments.NewFake(config.FakePayments{Outcome: config.FakeOutcomeAuthorized})
+	f := newProviderFixture(t, fake, 25)
+	ctx := context.Background()
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusConfirmed, booking.Status)
+	require.NotNil(t, booking.Payment)
+	assert.Equal(t, model.PaymentSucceeded, booking.Payment.Status)
+	assert.Equal(t, payments.StatusSucceeded, fake.Status(booking.Payment.ProviderReference))
+	assert.Equal(t, 8, f.available(t))
+
+	require.NoError(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"))
+	assert.Equal(t, payments.StatusRefunded, fake.Status(booking.Payment.ProviderReference))
+	assert.Equal(t, 10, f.available(t))
+}
+
+func TestFakeProviderSettlesPendingPaymentsFromNotifications(t *testing.T) {
+	fake := payments.NewFake(config.FakePayments{Outcome: config.FakeOutcomePending, WebhookSecret: testWebhookSecret})
+	f := newProviderFixture(t, fake, 25)
+	ctx := context.Background()
+
+	paid, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusPending, paid.Status)
+	declined, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-2", TicketCount: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 5, f.available(t))
+
+	forged := payments.NewFake(config.FakePayments{WebhookSecret: "someone-else"})
+	payload, header := forged.Notification(paid.Payment.ProviderReference, payments.StatusAuthorized, clock.Now())
+	assert.ErrorIs(t, f.payments.HandleWebhook(ctx, payload, header), payments.ErrInvalidSignature)
+
+	payload, header = fake.Notification(paid.Payment.ProviderReference, payments.StatusAuthorized, clock.Now())
+	require.NoError(t, f.payments.HandleWebhook(ctx, payload, header))
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, paid.ID))
+	assert.Equal(t, payments.StatusSucceeded, fake.Status(paid.Payment.ProviderReference))
+
+	payload, header = fake.Notification(declined.Payment.ProviderReference, payments.StatusFailed, clock.Now())
+	require.NoError(t, f.payments.HandleWebhook(ctx, payload, header))
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, declined.ID))
+	assert.Equal(t, 8, f.available(t))
+}
+
+func TestFakeProviderCanBeTakenDown(t *testing.T) {
+	fake := payments.NewFake(config.FakePayments{Outcome: config.FakeOutcomeAuthorized})
+	f := newProviderFixture(t, fake, 25)
+	fake.SetDown(true)
+
+	_, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	assert.ErrorIs(t, err, pkgErr.ErrPaymentUnavailable)
+	assert.Equal(t, 10, f.available(t))
+}
@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReconciliationFixture(t *testing.T) (*paymentFixture, *payments.Fake) {
+	t.Helper()
+
+	fake := payments.NewFake(config.FakePayments{Outcome: config.FakeOutcomePending, WebhookSecret: testWebhookSecret})
+	return newProviderFixture(t, fake, 25), fake
+}
+
+func (f *paymentFixture) book(t *testing.T, userID string, tickets int) *model.Booking {
+	t.Helper()
+
+	booking, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: userID, TicketCount: tickets})
+	require.NoError(t, err)
+	return booking
+}
+
+func TestReconciliationFlagsBookingsThatDisagreeWithTheProvider(t *testing.T) {
+	defer clock.Process().Reset()
+	f, fake := newReconciliationFixture(t)
+	ctx := context.Background()
+
+	// The notification authorizing the first booking was lost, the second
+	// one was paid and then refunded in the provider's dashboard
+	lost := f.book(t, "user-1", 2)
+	refunded := f.book(t, "user-2", 2)
+	unpaid := f.book(t, "user-3", 1)
+	fake.SetStatus(refunded.Payment.ProviderReference, payments.StatusAuthorized)
+	payload, header := fake.Notification(refunded.Payment.ProviderReference, payments.StatusAuthorized, clock.Now())
+	require.NoError(t, f.payments.HandleWebhook(ctx, payload, header))
+	require.Equal(t, model.BookingStatusConfirmed, f.status(t, refunded.ID))
+	fake.SetStatus(lost.Payment.ProviderReference, payments.StatusAuthorized)
+	fake.SetStatus(refunded.Payment.ProviderReference, payments.StatusRefunded)
+
+	report, err := f.payments.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Checked, "payments that just changed are left to the notifications")
+
+	clock.Process().Advance(11 * time.Minute)
+	report, err = f.payments.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, map[string]int{model.MismatchPaidNotConfirmed: 1, model.MismatchConfirmedNotPaid: 1}, report.Mismatches)
+	assert.Zero(t, report.Repaired, "mismatches are only flagged unless repairing is enabled")
+
+	assert.Equal(t, model.BookingStatusPending, f.status(t, lost.ID))
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, refunded.ID))
+	assert.Equal(t, model.BookingStatusPending, f.status(t, unpaid.ID))
+
+	flagged, err := f.paymentsRepo.CountMismatches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{model.MismatchPaidNotConfirmed: 1, model.MismatchConfirmedNotPaid: 1}, flagged)
+
+	// Fixed by hand at the provider, the flag is cleared on the next run
+	fake.SetStatus(lost.Payment.ProviderReference, payments.StatusCanceled)
+	fake.SetStatus(refunded.Payment.ProviderReference, payments.StatusSucceeded)
+	_, err = f.payments.Reconcile(ctx)
+	require.NoError(t, err)
+	flagged, err = f.paymentsRepo.CountMismatches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, flagged)
+}
+
+func TestReconciliationRepairsMismatches(t *testing.T) {
+	defer clock.Process().Reset()
+	f, fake := newReconciliationFixture(t)
+	ctx := context.Background()
+
+	lost := f.book(t, "user-1", 2)
+	refunded := f.book(t, "user-2", 2)
+	released := f.book(t, "user-3", 1)
+	require.Equal(t, 5, f.available(t))
+
+	payload, header := fake.Notification(refunded.Payment.ProviderReference, payments.StatusAuthorized, clock.Now())
+	require.NoError(t, f.payments.HandleWebhook(ctx, payload, header))
+	require.NoError(t, f.bookings.CancelBooking(ctx, released.ID, "user-3"))
+	require.Equal(t, 6, f.available(t))
+
+	// The customer paid the released booking after it was cancelled
+	fake.SetStatus(lost.Payment.ProviderReference, payments.StatusAuthorized)
+	fake.SetStatus(refunded.Payment.ProviderReference, payments.StatusRefunded)
+	fake.SetStatus(released.Payment.ProviderReference, payments.StatusAuthorized)
+
+	clock.Process().Advance(11 * time.Minute)
+	report, err := f.repairing().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, map[string]int{model.MismatchPaidNotConfirmed: 2, model.MismatchConfirmedNotPaid: 1}, report.Mismatches)
+	assert.Equal(t, 3, report.Repaired)
+
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, lost.ID), "the paid booking is confirmed")
+	assert.Equal(t, payments.StatusSucceeded, fake.Status(lost.Payment.ProviderReference), "and its payment captured")
+
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, refunded.ID), "the refunded booking is expired")
+	assert.Equal(t, 8, f.available(t), "and its tickets returned")
+
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, released.ID))
+	assert.Equal(t, payments.StatusCanceled, fake.Status(released.Payment.ProviderReference),
+		"the payment of the cancelled booking is voided")
+
+	flagged, err := f.paymentsRepo.CountMismatches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, flagged, "repaired mismatches aren't flagged")
+
+	report, err = f.repairing().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches, "the bookings agree with the provider now")
+}
+
+func TestReconciliationExpiresConfirmedBookingsWithOpenPayments(t *testing.T) {
+	defer clock.Process().Reset()
+	f, fake := newReconciliationFixture(t)
+	ctx := context.Background()
+
+	booking := f.book(t, "user-1", 2)
+	payload, header := fake.Notification(booking.Payment.ProviderReference, payments.StatusAuthorized, clock.Now())
+	require.NoError(t, f.payments.HandleWebhook(ctx, payload, header))
+	fake.SetStatus(booking.Payment.ProviderReference, payments.StatusPending)
+
+	clock.Process().Advance(11 * time.Minute)
+	report, err := f.repairing().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, booking.ID))
+	assert.Equal(t, payments.StatusCanceled, fake.Status(booking.Payment.ProviderReference),
+		"the payment can't be paid once the booking is cancelled")
+	assert.Equal(t, 10, f.available(t))
+}
+
+func TestReconciliationRetriesPaymentsTheProviderCantFind(t *testing.T) {
+	defer clock.Process().Reset()
+	f, fake := newReconciliationFixture(t)
+	ctx := context.Background()
+
+	booking := f.book(t, "user-1", 2)
+	fake.SetStatus(booking.Payment.ProviderReference, payments.StatusAuthorized)
+	clock.Process().Advance(11 * time.Minute)
+
+	fake.SetDown(true)
+	report, err := f.repairing().Reconcile(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, report.Checked)
+
+	fake.SetDown(false)
+	report, err = f.repairing().Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, booking.ID))
+}
+
+func TestStripeLookupReportsRefundedCharges(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+	ctx := context.Background()
+
+	booking := f.book(t, "user-1", 2)
+	id := booking.Payment.ProviderReference
+
+	status, err := f.provider.Lookup(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusPending, status)
+
+	f.stripe.Pay(id)
+	status, err = f.provider.Lookup(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusAuthorized, status)
+
+	require.NoError(t, f.provider.Capture(ctx, id))
+	require.NoError(t, f.provider.Refund(ctx, id))
+	status, err = f.provider.Lookup(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusRefunded, status)
+
+	_, err = f.provider.Lookup(ctx, "pi_unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,392 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/payments"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// testPaymentPolicy holds payments for 15 minutes and reconciles them once
+// they didn't change for 10
+var testPaymentPolicy = model.PaymentPolicy{
+	HoldTTL:            15 * time.Minute,
+	ReconcileLookback:  24 * time.Hour,
+	ReconcileGrace:     10 * time.Minute,
+	ReconcileBatchSize: 100,
+}
+
+type paymentFixture struct {
+	stripe       *mocks.MockStripe
+	provider     payments.Provider
+	concerts     *mocks.MockConcertRepository
+	repo         *mocks.MockBookingRepository
+	paymentsRepo *mocks.MockPaymentRepository
+	bookings     service.BookingService
+	payments     service.PaymentService
+	concert      *model.Concert
+}
+
+func newPaymentFixture(t *testing.T, price float64) *paymentFixture {
+	t.Helper()
+
+	stripe := mocks.NewMockStripe()
+	t.Cleanup(stripe.Close)
+
+	f := newProviderFixture(t, payments.NewStripe(config.Stripe{
+		SecretKey:        "sk_test",
+		WebhookSecret:    testWebhookSecret,
+		BaseURL:          stripe.URL,
+		Timeout:          5 * time.Second,
+		WebhookTolerance: 5 * time.Minute,
+	}), price)
+	f.stripe = stripe
+	return f
+}
+
+// newProviderFixture books a concert at a price with payments taken by a
+// provider
+func newProviderFixture(t *testing.T, provider payments.Provider, price float64) *paymentFixture {
+	t.Helper()
+
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	paymentRepo := mocks.NewMockPaymentRepository(bookingRepo)
+	paymentService := service.NewPaymentService(paymentRepo, bookingRepo, provider, nil, testPaymentPolicy)
+
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Paid Night",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     10,
+		AvailableTickets: 10,
+		Price:            price,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	return &paymentFixture{
+		provider:     provider,
+		concerts:     concertRepo,
+		repo:         bookingRepo,
+		paymentsRepo: paymentRepo,
+		bookings:     service.NewBookingService(bookingRepo, concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, paymentService),
+		payments:     paymentService,
+		concert:      concert,
+	}
+}
+
+// repairing returns a payment service over the fixture's payments that
+// repairs the mismatches it reconciles
+func (f *paymentFixture) repairing() service.PaymentService {
+	policy := testPaymentPolicy
+	policy.Repair = true
+	return service.NewPaymentService(f.paymentsRepo, f.repo, f.provider, nil, policy)
+}
+
+// deliver delivers a signed Stripe event about an intent to the payment service
+func (f *paymentFixture) deliver(eventType, intentID string) error {
+	payload := f.stripe.Event(eventType, intentID)
+	header := http.Header{}
+	header.Set(payments.StripeSignatureHeader, payments.SignStripe(testWebhookSecret, clock.Now(), payload))
+	return f.payments.HandleWebhook(context.Background(), payload, header)
+}
+
+func (f *paymentFixture) notify(t *testing.T, eventType, intentID string) {
+	t.Helper()
+
+	require.NoError(t, f.deliver(eventType, intentID))
+}
+
+func (f *paymentFixture) status(t *testing.T, bookingID int64) model.BookingStatus {
+	t.Helper()
+
+	booking, err := f.repo.GetByID(context.Background(), bookingID)
+	require.NoError(t, err)
+	return booking.Status
+}
+
+func (f *paymentFixture) available(t *testing.T) int {
+	t.Helper()
+
+	concert, err := f.concerts.GetByID(context.Background(), f.concert.ID)
+	require.NoError(t, err)
+	return concert.AvailableTickets
+}
+
+func TestStripeSignaturesVerifyThePayloadAndItsAge(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+	header := payments.SignStripe(testWebhookSecret, now, payload)
+
+	assert.NoError(t, payments.VerifyStripeSignature(testWebhookSecret, header, payload, now, 5*time.Minute))
+	assert.NoError(t, payments.VerifyStripeSignature(testWebhookSecret, "v1=rolled,"+header, payload, now, 5*time.Minute),
+		"any of the signatures may match while the secret is rolled")
+
+	assert.ErrorIs(t, payments.VerifyStripeSignature(testWebhookSecret, header, []byte(`{"id":"evt_2"}`), now, 5*time.Minute), payments.ErrInvalidSignature)
+	assert.ErrorIs(t, payments.VerifyStripeSignature("whsec_other", header, payload, now, 5*time.Minute), payments.ErrInvalidSignature)
+	assert.ErrorIs(t, payments.VerifyStripeSignature(testWebhookSecret, header, payload, now.Add(10*time.Minute), 5*time.Minute), payments.ErrInvalidSignature,
+		"replayed notifications are too old")
+	assert.ErrorIs(t, payments.VerifyStripeSignature(testWebhookSecret, "", payload, now, 5*time.Minute), payments.ErrInvalidSignature)
+}
+
+func TestPaidBookingsWaitForTheirPayment(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+
+	booking, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusPending, booking.Status)
+	require.NotNil(t, booking.Payment)
+	assert.Equal(t, "pi_1", booking.Payment.ProviderReference)
+	assert.Equal(t, "pi_1_secret", booking.Payment.ClientSecret)
+	assert.Equal(t, 50.0, booking.Payment.Amount)
+	assert.Equal(t, booking.BookingTime.Add(15*time.Minute), booking.Payment.ExpiresAt)
+	assert.Equal(t, 8, f.available(t), "the pending booking holds its tickets")
+
+	form, idempotencyKey := f.stripe.LastCreate()
+	assert.Equal(t, "5000", form["amount"], "Stripe takes cents")
+	assert.Equal(t, "usd", form["currency"])
+	assert.Equal(t, "manual", form["capture_method"], "the card is only charged once the booking is confirmed")
+	assert.Equal(t, booking.Reference, form["metadata[booking_reference]"])
+	assert.Equal(t, "booking-"+booking.Reference, idempotencyKey)
+
+	f.stripe.Pay("pi_1")
+	f.notify(t, "payment_intent.amount_capturable_updated", "pi_1")
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, booking.ID))
+	assert.Equal(t, "succeeded", f.stripe.Status("pi_1"), "the confirmed booking's payment is captured")
+
+	// Stripe delivers notifications at least once
+	f.notify(t, "payment_intent.amount_capturable_updated", "pi_1")
+	f.notify(t, "payment_intent.succeeded", "pi_1")
+	f.notify(t, "payment_intent.canceled", "pi_1")
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, booking.ID))
+	assert.Equal(t, 8, f.available(t))
+}
+
+func TestFailedPaymentsReleaseTheirTickets(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+
+	booking, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 3})
+	require.NoError(t, err)
+
+	f.notify(t, "payment_intent.payment_failed", "pi_1")
+	assert.Equal(t, []string{"pi_1"}, f.stripe.Canceled(), "the intent can't be paid with another card after the tickets are gone")
+	assert.Equal(t, 10, f.available(t))
+
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, booking.ID))
+
+	// Notifications about other intents are acknowledged and ignored
+	f.notify(t, "payment_intent.succeeded", "pi_unknown")
+	f.notify(t, "charge.refunded", "pi_1")
+}
+
+func TestUnpaidHoldsExpire(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newPaymentFixture(t, 25)
+	ctx := context.Background()
+
+	unpaid, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	late, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-2", TicketCount: 2})
+	require.NoError(t, err)
+
+	released, err := f.payments.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released, "the holds haven't expired yet")
+
+	// An authorization whose notification comes after the hold expired is
+	// voided with the hold
+	f.stripe.Pay(late.Payment.ProviderReference)
+	clock.Process().Advance(16 * time.Minute)
+	released, err = f.payments.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+	assert.Equal(t, "canceled", f.stripe.Status(unpaid.Payment.ProviderReference))
+	assert.Equal(t, "canceled", f.stripe.Status(late.Payment.ProviderReference))
+	assert.Equal(t, 10, f.available(t))
+
+	f.notify(t, "payment_intent.amount_capturable_updated", late.Payment.ProviderReference)
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, late.ID))
+
+	released, err = f.payments.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+}
+
+func TestFailedCapturesAreRetriedAfterTheHold(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newPaymentFixture(t, 25)
+	ctx := context.Background()
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	f.stripe.Pay("pi_1")
+
+	// Stripe answers the notification but not the capture
+	payload := f.stripe.Event("payment_intent.amount_capturable_updated", "pi_1")
+	f.stripe.SetDown(true)
+	header := http.Header{}
+	header.Set(payments.StripeSignatureHeader, payments.SignStripe(testWebhookSecret, clock.Now(), payload))
+	assert.Error(t, f.payments.HandleWebhook(ctx, payload, header), "Stripe delivers the notification again")
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, booking.ID))
+	assert.Equal(t, "requires_capture", f.stripe.Status("pi_1"))
+
+	f.stripe.SetDown(false)
+	clock.Process().Advance(16 * time.Minute)
+	released, err := f.payments.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released, "the confirmed booking keeps its tickets")
+	assert.Equal(t, "succeeded", f.stripe.Status("pi_1"))
+	assert.Equal(t, 8, f.available(t))
+}
+
+func TestBookingsAreReleasedWhenThePaymentProviderIsDown(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+	f.stripe.SetDown(true)
+
+	_, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	assert.ErrorIs(t, err, pkgErr.ErrPaymentUnavailable)
+	assert.Equal(t, 10, f.available(t))
+}
+
+func TestCancellingAPendingBookingCancelsItsPayment(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+	ctx := context.Background()
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"))
+	assert.Equal(t, []string{"pi_1"}, f.stripe.Canceled())
+	assert.Equal(t, 10, f.available(t))
+
+	assert.ErrorIs(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"), pkgErr.ErrBookingAlreadyCancelled)
+}
+
+func TestCancellingAPaidBookingRefundsIt(t *testing.T) {
+	f := newPaymentFixture(t, 25)
+	ctx := context.Background()
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	f.stripe.Pay("pi_1")
+	f.notify(t, "payment_intent.amount_capturable_updated", "pi_1")
+
+	f.stripe.SetDown(true)
+	assert.ErrorIs(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"), pkgErr.ErrPaymentUnavailable)
+	assert.Equal(t, model.BookingStatusConfirmed, f.status(t, booking.ID), "the booking stays until its refund goes through")
+
+	f.stripe.SetDown(false)
+	require.NoError(t, f.bookings.CancelBooking(ctx, booking.ID, "user-1"))
+	assert.Equal(t, []string{"pi_1"}, f.stripe.Refunded())
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, booking.ID))
+	assert.Equal(t, 10, f.available(t))
+}
+
+// heldRefunds counts the refunds of a provider and holds them until released
+type heldRefunds struct {
+	payments.Provider
+	refunds atomic.Int32
+	release chan struct{}
+}
+
+func (p *heldRefunds) Refund(ctx context.Context, id string) error {
+	p.refunds.Add(1)
+	<-p.release
+	return p.Provider.Refund(ctx, id)
+}
+
+func TestConcurrentCancellationsRefundOnce(t *testing.T) {
+	provider := &heldRefunds{
+		Provider: payments.NewFake(config.FakePayments{Outcome: config.FakeOutcomeAuthorized}),
+		release:  make(chan struct{}),
+	}
+	f := newProviderFixture(t, provider, 25)
+	ctx := context.Background()
+
+	booking, err := f.bookings.BookTickets(ctx, &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	require.Equal(t, model.BookingStatusConfirmed, booking.Status)
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			results <- f.bookings.CancelBooking(ctx, booking.ID, "user-1")
+		}()
+	}
+
+	// The cancellation that lost the claim on the payment returns while the
+	// other one is refunding. Without the claim, both would wait for the
+	// provider until released.
+	var errs []error
+	select {
+	case err := <-results:
+		errs = append(errs, err)
+	case <-time.After(time.Second):
+	}
+	close(provider.release)
+	for len(errs) < 2 {
+		errs = append(errs, <-results)
+	}
+
+	assert.Equal(t, int32(1), provider.refunds.Load(), "the payment is refunded once")
+	assert.ElementsMatch(t, []error{nil, pkgErr.ErrBookingAlreadyCancelled}, errs)
+	assert.Equal(t, model.BookingStatusCancelled, f.status(t, booking.ID))
+	assert.Equal(t, 10, f.available(t))
+
+	payment, err := f.paymentsRepo.GetByBookingID(ctx, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PaymentRefunded, payment.Status)
+}
+
+func TestFreeBookingsNeedNoPayment(t *testing.T) {
+	f := newPaymentFixture(t, 0)
+
+	booking, err := f.bookings.BookTickets(context.Background(), &model.BookingRequest{ConcertID: f.concert.ID, UserID: "user-1", TicketCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusConfirmed, booking.Status)
+	assert.Nil(t, booking.Payment)
+	form, _ := f.stripe.LastCreate()
+	assert.Nil(t, form)
+}
+
+func TestPaymentWebhookRejectsUnsignedNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newPaymentFixture(t, 25)
+	router := gin.New()
+	handler.NewPaymentHandler(f.payments).RegisterRoutes(router.Group("/api/v1"))
+
+	payload := f.stripe.Event("payment_intent.succeeded", "pi_1")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments/stripe/webhook", bytes.NewReader(payload))
+	req.Header.Set(payments.StripeSignatureHeader, payments.SignStripe("whsec_forged", time.Now(), payload))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/payments/stripe/webhook", bytes.NewReader(payload))
+	req.Header.Set(payments.StripeSignatureHeader, payments.SignStripe(testWebhookSecret, time.Now(), payload))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
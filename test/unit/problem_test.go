@@ -0,0 +1,145 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/trace"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProblemTestRouter(t *testing.T) (*gin.Engine, *model.Concert) {
+	gin.SetMode(gin.TestMode)
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     2,
+		AvailableTickets: 2,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.TraceID())
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+	handler.NewBookingHandler(bookingService).RegisterRoutes(router.Group("/api/v1"))
+	handler.NewInventoryReleaseHandler(service.NewInventoryReleaseService(nil, concertRepo, nil)).RegisterRoutes(router.Group("/api/v1"), func(c *gin.Context) {})
+	return router, concert
+}
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) problem.Details {
+	assert.Equal(t, problem.ContentType, recorder.Header().Get("Content-Type"))
+
+	var details problem.Details
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &details))
+	assert.Equal(t, recorder.Code, details.Status)
+	assert.Equal(t, "about:blank", details.Type)
+	assert.Equal(t, http.StatusText(recorder.Code), details.Title)
+	return details
+}
+
+func TestErrorsAreProblemDetails(t *testing.T) {
+	router, concert := newProblemTestRouter(t)
+
+	body := `{"concert_id":` + strconv.FormatInt(concert.ID, 10) + `,"user_id":"user-1","ticket_count":3}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bookings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	details := decodeProblem(t, recorder)
+	assert.Equal(t, problem.CodeInsufficientTickets, details.Code)
+	assert.Equal(t, "Not enough tickets available", details.Detail)
+	assert.Equal(t, "/api/v1/bookings", details.Instance)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", details.TraceID, "the caller's trace is kept")
+	assert.Equal(t, details.TraceID, recorder.Header().Get(middleware.TraceIDHeader))
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/bookings/UNKNOWN", nil))
+	details = decodeProblem(t, recorder)
+	assert.Equal(t, problem.CodeBookingNotFound, details.Code)
+	assert.Len(t, details.TraceID, 32, "a trace ID is generated without traceparent")
+}
+
+func TestProblemDetailsListInvalidFields(t *testing.T) {
+	router, concert := newProblemTestRouter(t)
+	path := "/api/v1/admin/concerts/" + strconv.FormatInt(concert.ID, 10) + "/inventory-releases"
+
+	for body, fields := range map[string][]problem.FieldError{
+		`{}`: {
+			{Field: "quantity", Message: "is required"},
+			{Field: "release_at", Message: "is required"},
+		},
+		`{"quantity":"ten","release_at":"2030-01-01T00:00:00Z"}`: {
+			{Field: "quantity", Message: "must be of type int"},
+		},
+		`{"quantity":`: nil,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, body)
+
+		details := decodeProblem(t, recorder)
+		assert.Equal(t, problem.CodeInvalidInput, details.Code)
+		assert.Equal(t, fields, details.Errors, body)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	id, ok := trace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", id)
+
+	_, ok = trace.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+	assert.True(t, ok, "later versions may add fields")
+
+	for _, invalid := range []string{
+		"",
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, ok := trace.ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+
+	assert.NotEqual(t, trace.NewID(), trace.NewID())
+}
+
+func TestOpenAPIDocumentsProblemDetails(t *testing.T) {
+	_, document := newOpenAPITestServer(t)
+
+	notFound := document.Paths["/api/v1/bookings/{reference}"]["get"].Responses["404"]
+	require.NotNil(t, notFound)
+	assert.Equal(t, "#/components/schemas/Details", notFound.Content[problem.ContentType].Schema.Ref)
+	assert.Contains(t, document.Components.Schemas["Details"].Properties, "trace_id")
+}
@@ -0,0 +1,228 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePage(t *testing.T) {
+	page, err := query.ParsePage("", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, query.Page{Number: 1, Size: query.DefaultPageSize}, page)
+
+	page, err = query.ParsePage("3", "1000", "")
+	require.NoError(t, err)
+	assert.Equal(t, query.Page{Number: 3, Size: query.MaxPageSize}, page, "large sizes are clamped")
+	assert.Equal(t, 200, page.Offset())
+
+	for _, params := range [][2]string{{"0", ""}, {"abc", ""}, {"", "-5"}, {"", "ten"}} {
+		_, err := query.ParsePage(params[0], params[1], "")
+		assert.Error(t, err, "%v", params)
+	}
+
+	// Unset gRPC and GraphQL arguments take the defaults
+	assert.Equal(t, query.Page{Number: 1, Size: query.DefaultPageSize}, query.NewPage(0, 0))
+}
+
+func TestPageCursors(t *testing.T) {
+	page := query.NewPage(2, 10)
+
+	next, err := query.ParsePage("1", "50", page.Cursor())
+	require.NoError(t, err)
+	assert.Equal(t, page, next, "a cursor takes precedence over page and pageSize")
+
+	for _, invalid := range []string{"not base64!", "e30", page.Cursor() + "x"} {
+		_, err := query.DecodeCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	meta := page.Meta(25)
+	assert.Equal(t, 3, meta.TotalPages)
+	require.NotEmpty(t, meta.NextCursor)
+	third, err := query.DecodeCursor(meta.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, query.Page{Number: 3, Size: 10}, third)
+	assert.Empty(t, third.Meta(25).NextCursor, "the last page has no next cursor")
+
+	assert.NotEmpty(t, page.NextCursor(10), "a full page of an uncounted listing may be followed by more")
+	assert.Empty(t, page.NextCursor(9))
+}
+
+func TestParseSort(t *testing.T) {
+	sorts, err := query.ParseSort("-price, concert_date", model.ConcertSortFields)
+	require.NoError(t, err)
+	assert.Equal(t, []query.Sort{{Field: "price", Desc: true}, {Field: "concert_date"}}, sorts)
+
+	orderBy, err := query.OrderBy(sorts, map[string]string{"price": "price", "concert_date": "concert_date"}, "id")
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER BY price DESC, concert_date, id", orderBy)
+
+	_, err = query.OrderBy([]query.Sort{{Field: "name; DROP TABLE concerts"}}, map[string]string{}, "id")
+	assert.Error(t, err)
+
+	for _, invalid := range []string{"venue", "price,-price", "price,"} {
+		_, err := query.ParseSort(invalid, model.ConcertSortFields)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func newQueryTestRepository(t *testing.T) *mocks.MockConcertRepository {
+	concertRepo := mocks.NewMockConcertRepository()
+	for i, price := range []float64{30, 10, 20} {
+		_, err := concertRepo.Create(context.Background(), &model.Concert{
+			Name:             "Concert " + strconv.Itoa(i),
+			Artist:           "Artist",
+			Venue:            "Venue",
+			ConcertDate:      time.Now().Add(time.Duration(i+1) * 24 * time.Hour),
+			TotalTickets:     100,
+			AvailableTickets: 100,
+			Price:            price,
+			Currency:         "USD",
+			BookingStartTime: time.Now().Add(-time.Hour),
+			BookingEndTime:   time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+	return concertRepo
+}
+
+func TestListConcertsSortsAndFollowsCursors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewConcertHandler(service.NewConcertService(newQueryTestRepository(t), nil, model.BookingLimits{}, nil)).RegisterRoutes(router.Group("/api/v1"))
+
+	list := func(path string) (*httptest.ResponseRecorder, handler.ConcertListResponse) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp handler.ConcertListResponse
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		}
+		return recorder, resp
+	}
+
+	recorder, first := list("/api/v1/concerts?sort=-price&pageSize=2")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, first.Data, 2)
+	assert.Equal(t, 30.0, first.Data[0].Price)
+	assert.Equal(t, 20.0, first.Data[1].Price)
+	assert.Equal(t, query.Meta{Page: 1, PageSize: 2, TotalCount: 3, TotalPages: 2, NextCursor: first.Meta.NextCursor}, first.Meta)
+	require.NotEmpty(t, first.Meta.NextCursor)
+
+	recorder, second := list("/api/v1/concerts?sort=-price&cursor=" + first.Meta.NextCursor)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, second.Data, 1)
+	assert.Equal(t, 10.0, second.Data[0].Price)
+	assert.Equal(t, 2, second.Meta.Page)
+	assert.Empty(t, second.Meta.NextCursor)
+
+	_, byDate := list("/api/v1/concerts")
+	require.Len(t, byDate.Data, 3)
+	assert.Equal(t, "Concert 0", byDate.Data[0].Name, "concerts are listed by date by default")
+
+	for _, path := range []string{
+		"/api/v1/concerts?sort=venue",
+		"/api/v1/concerts?page=0",
+		"/api/v1/concerts?cursor=bogus",
+		"/api/v1/concerts?dateFrom=tomorrow",
+	} {
+		recorder, _ := list(path)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, path)
+	}
+}
+
+func TestListConcertsRPCPagination(t *testing.T) {
+	server := grpcapi.NewServer(service.NewConcertService(newQueryTestRepository(t), nil, model.BookingLimits{}, nil), nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+
+	// An unset page size takes the default instead of dividing by zero
+	resp, err := server.ListConcerts(context.Background(), &pb.ListConcertsRequest{Sort: "price"})
+	require.NoError(t, err)
+	require.Len(t, resp.Concerts, 3)
+	assert.Equal(t, 10.0, resp.Concerts[0].Price)
+	assert.Equal(t, int32(1), resp.Meta.Page)
+	assert.Equal(t, int32(query.DefaultPageSize), resp.Meta.PageSize)
+	assert.Equal(t, int32(1), resp.Meta.TotalPages)
+
+	resp, err = server.ListConcerts(context.Background(), &pb.ListConcertsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Meta.NextCursor)
+
+	resp, err = server.ListConcerts(context.Background(), &pb.ListConcertsRequest{Cursor: resp.Meta.NextCursor})
+	require.NoError(t, err)
+	assert.Len(t, resp.Concerts, 1)
+	assert.Equal(t, int32(2), resp.Meta.Page)
+
+	_, err = server.ListConcerts(context.Background(), &pb.ListConcertsRequest{Sort: "venue"})
+	assert.Error(t, err)
+}
+
+func TestListConcertsEstimatesTheTotalOnRequest(t *testing.T) {
+	concertRepo := newQueryTestRepository(t)
+	concertRepo.Estimate = 1000
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	ctx := context.Background()
+
+	_, total, err := concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 2)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total, "totals are counted unless an estimate is asked for")
+
+	_, total, err = concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 2), EstimateCount: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1000, total)
+
+	// A short page ends the listing whatever the estimate says
+	_, total, err = concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(2, 2), EstimateCount: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	// An estimate short of a full page leaves room for the next page
+	concertRepo.Estimate = 1
+	_, total, err = concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 2), EstimateCount: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewConcertHandler(concertService).RegisterRoutes(router.Group("/api/v1"))
+	concertRepo.Estimate = 1000
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts?pageSize=2&exactCount=false", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var resp handler.ConcertListResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, 1000, resp.Meta.TotalCount)
+	assert.Equal(t, 500, resp.Meta.TotalPages)
+	assert.True(t, resp.Meta.TotalCountEstimated)
+	assert.NotEmpty(t, resp.Meta.NextCursor)
+
+	server := grpcapi.NewServer(concertService, nil, nil, nil, nil, newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	exact := false
+	rpcResp, err := server.ListConcerts(ctx, &pb.ListConcertsRequest{PageSize: 2, ExactCount: &exact})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000), rpcResp.Meta.TotalCount)
+	assert.True(t, rpcResp.Meta.TotalCountEstimated)
+
+	rpcResp, err = server.ListConcerts(ctx, &pb.ListConcertsRequest{PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), rpcResp.Meta.TotalCount)
+	assert.False(t, rpcResp.Meta.TotalCountEstimated)
+}
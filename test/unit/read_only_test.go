@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReadOnlyTestServer(t *testing.T) (*rest.Server, *model.Concert) {
+	gin.SetMode(gin.TestMode)
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		CORS:      config.CORS{AllowOrigins: []string{"*"}},
+		Admin:     config.Admin{Token: "secret"},
+		TestClock: config.TestClock{Enabled: true},
+		GraphQL:   config.GraphQL{Enabled: true},
+		ReadOnly:  config.ReadOnly{Enabled: true, MaxAge: time.Minute, StaleWhileRevalidate: 5 * time.Minute},
+	}
+	server := rest.NewServer(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		health.NewRegistry(time.Second, 1), nil, nil, logger.NewLogger("error"), cfg)
+	return server, concert
+}
+
+func TestReadOnlyModeServesOnlyPublicReads(t *testing.T) {
+	server, _ := newReadOnlyTestServer(t)
+
+	for _, route := range server.Routes() {
+		assert.Contains(t, []string{http.MethodGet, http.MethodHead}, route.Method, "%s %s must not be served in read-only mode", route.Method, route.Path)
+		assert.False(t, strings.HasPrefix(route.Path, "/api/v1/admin"), "%s must not be served in read-only mode", route.Path)
+		assert.NotEqual(t, "/graphql", route.Path)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/bookings", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document openapi.Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Contains(t, document.Paths, "/api/v1/concerts/{id}")
+	for path, item := range document.Paths {
+		for method := range item {
+			assert.Contains(t, []string{"get", "head"}, method, "%s %s is documented but not served", method, path)
+		}
+	}
+}
+
+func TestReadOnlyModeLetsCachesKeepReads(t *testing.T) {
+	server, concert := newReadOnlyTestServer(t)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts/"+strconv.FormatInt(concert.ID, 10), nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "public, max-age=60, stale-while-revalidate=300", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "Accept-Language", recorder.Header().Get("Vary"))
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts/999", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"), "errors must not be cached")
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Empty(t, recorder.Header().Get("Cache-Control"))
+}
+
+func TestReadOnlyGRPCServerRegistersOnlyPublicReads(t *testing.T) {
+	server := grpcapi.NewServer(nil, nil, nil, nil, nil, newTestAuthorizer(), true, logger.NewLogger("error"), 0)
+	require.NoError(t, server.ValidateAuthorization())
+
+	var methods []string
+	for name, info := range server.ServiceInfo() {
+		if strings.HasPrefix(name, "grpc.") {
+			continue
+		}
+		for _, method := range info.Methods {
+			methods = append(methods, "/"+name+"/"+method.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"/concert.ConcertService/GetConcert",
+		"/concert.ConcertService/ListConcerts",
+		"/concert.ConcertService/BatchGetConcerts",
+		"/concert.ConcertService/WatchConcertAvailability",
+	}, methods)
+}
@@ -0,0 +1,61 @@
+package unit
+
+import (
+	"testing"
+
+	"concert-ticket-api/pkg/reference"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReference(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		ref := reference.New()
+		assert.Len(t, ref, reference.Length)
+
+		normalized, ok := reference.Normalize(ref)
+		assert.True(t, ok)
+		assert.Equal(t, ref, normalized)
+
+		assert.False(t, seen[ref], "duplicate reference %s", ref)
+		seen[ref] = true
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		ok    bool
+	}{
+		{"canonical", "7K3M9X2QAB4Z", "7K3M9X2QAB4Z", true},
+		{"lowercase", "7k3m9x2qab4z", "7K3M9X2QAB4Z", true},
+		{"confusable letters", "OIL3M9X2QAB4", "0113M9X2QAB4", true},
+		{"surrounding space", " 7K3M9X2QAB4Z ", "7K3M9X2QAB4Z", true},
+		{"too short", "7K3M9X", "", false},
+		{"too long", "7K3M9X2QAB4Z0", "", false},
+		{"excluded letter", "7K3M9X2QAB4U", "", false},
+		{"integer id", "42", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := reference.Normalize(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDeriveReference(t *testing.T) {
+	ref := reference.Derive("seed:1:booking:1")
+	assert.Equal(t, ref, reference.Derive("seed:1:booking:1"), "a key always derives the same reference")
+	assert.NotEqual(t, ref, reference.Derive("seed:1:booking:2"))
+
+	normalized, ok := reference.Normalize(ref)
+	assert.True(t, ok)
+	assert.Equal(t, ref, normalized)
+}
@@ -0,0 +1,44 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"concert-ticket-api/pkg/db"
+	"concert-ticket-api/pkg/health"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicasTakeTurnsAndFailOverToThePrimary(t *testing.T) {
+	primary, first, second := &sqlx.DB{}, &sqlx.DB{}, &sqlx.DB{}
+	registry := health.NewRegistry(0, 1)
+	replicas := db.NewReplicas(primary, []db.Replica{
+		{Name: health.Replica(1), DB: first},
+		{Name: health.Replica(2), DB: second},
+	}, registry.Healthy)
+
+	readers := map[*sqlx.DB]int{}
+	for i := 0; i < 4; i++ {
+		readers[replicas.Reader()]++
+	}
+	assert.Equal(t, map[*sqlx.DB]int{first: 2, second: 2}, readers, "the primary takes no reads while replicas are healthy")
+
+	registry.Report(health.Replica(1), errors.New("connection refused"))
+	for i := 0; i < 4; i++ {
+		assert.Same(t, second, replicas.Reader())
+	}
+
+	registry.Report(health.Replica(2), errors.New("connection refused"))
+	assert.Same(t, primary, replicas.Reader())
+
+	registry.Report(health.Replica(1), nil)
+	assert.Same(t, first, replicas.Reader(), "a replica takes reads again once it recovers")
+}
+
+func TestReplicasWithoutReplicasReadThePrimary(t *testing.T) {
+	primary := &sqlx.DB{}
+	replicas := db.NewReplicas(primary, nil, func(string) bool { return true })
+	assert.Same(t, primary, replicas.Reader())
+}
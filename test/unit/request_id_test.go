@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/trace"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// logEntries decodes the JSON log lines written to out
+func logEntries(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newRequestIDTestRouter(out *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.TraceID())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(logger.New(logger.Options{Level: "info", Format: logger.FormatJSON, Output: out})))
+	router.GET("/api/v1/concerts/:id", func(c *gin.Context) {
+		problem.Error(c, errors.New("connection reset by peer"), "Failed to get concert")
+	})
+	return router
+}
+
+func TestRequestIDsOfCallersAreKept(t *testing.T) {
+	var out bytes.Buffer
+	router := newRequestIDTestRouter(&out)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/concerts/7", nil)
+	req.Header.Set(middleware.RequestIDHeader, "checkout-42")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "checkout-42", recorder.Header().Get(middleware.RequestIDHeader))
+	details := decodeProblem(t, recorder)
+	assert.Equal(t, "checkout-42", details.RequestID)
+	assert.Equal(t, "Failed to get concert", details.Detail, "the cause isn't shown to the caller")
+
+	entries := logEntries(t, &out)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "error", entries[0]["level"])
+	assert.Equal(t, "checkout-42", entries[0]["request_id"])
+	assert.Equal(t, "connection reset by peer", entries[0]["error"], "the cause is logged under the request ID")
+	assert.Equal(t, 7.0, entries[0]["concert_id"])
+}
+
+func TestRequestIDsAreGeneratedWhenMissingOrInvalid(t *testing.T) {
+	var out bytes.Buffer
+	router := newRequestIDTestRouter(&out)
+
+	for _, header := range []string{"", "has spaces", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/concerts/7", nil)
+		if header != "" {
+			req.Header.Set(middleware.RequestIDHeader, header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		id := recorder.Header().Get(middleware.RequestIDHeader)
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id, header)
+		assert.Equal(t, id, decodeProblem(t, recorder).RequestID)
+	}
+
+	assert.NotEqual(t, trace.NewRequestID(), trace.NewRequestID())
+}
+
+func TestRequestIDsArePropagatedOverGRPC(t *testing.T) {
+	var out bytes.Buffer
+	concertRepo := mocks.NewMockConcertRepository()
+	concertRepo.FailNext("GetByID", errors.New("connection reset by peer"))
+	server := grpcapi.NewServer(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil), nil, nil, nil, nil, newTestAuthorizer(), false,
+		logger.New(logger.Options{Level: "info", Format: logger.FormatJSON, Output: &out}), 0)
+	client := pb.NewConcertServiceClient(serveGRPC(t, server))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "checkout-42")
+	_, err := client.GetConcert(ctx, &pb.GetConcertRequest{Id: 1}, grpc.Header(&header))
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []string{"checkout-42"}, header.Get("x-request-id"))
+
+	entries := logEntries(t, &out)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "checkout-42", entries[0]["request_id"])
+	assert.Equal(t, "Failed to get concert: connection reset by peer", entries[0]["msg"])
+
+	_, err = client.GetConcert(context.Background(), &pb.GetConcertRequest{Id: 1}, grpc.Header(&header))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	require.Len(t, header.Get("x-request-id"), 1, "an ID is generated without one")
+	entries = logEntries(t, &out)
+	require.Len(t, entries, 2)
+	assert.Equal(t, header.Get("x-request-id")[0], entries[1]["request_id"])
+}
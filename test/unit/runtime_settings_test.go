@@ -0,0 +1,240 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/api/rest/problem"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRuntimeDefaults() model.RuntimeSettings {
+	return model.RuntimeSettings{
+		RateLimit:         500,
+		AdmissionRate:     50,
+		AdmissionBurst:    100,
+		ConcertCacheTTLMs: 5000,
+		CORSAllowOrigins:  []string{"https://tickets.example.com"},
+	}
+}
+
+func newRuntimeSettingsServer(settings service.RuntimeSettingsService) http.Handler {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		CORS: config.CORS{AllowOrigins: []string{"https://tickets.example.com"}, AllowMethods: []string{http.MethodGet}},
+	}
+	concertService := service.NewConcertService(mocks.NewMockConcertRepository(), nil, model.BookingLimits{}, nil)
+	return rest.NewServer(concertService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		health.NewRegistry(time.Second, 1), nil, settings, logger.NewLogger("error"), cfg).Handler()
+}
+
+func TestRuntimeSettingsAdminAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auditService := service.NewAuditService(mocks.NewMockAuditRepository())
+	settings := service.NewRuntimeSettingsService(mocks.NewMockRuntimeSettingsRepository(), auditService, testRuntimeDefaults())
+	f := &internalAdminFixture{server: rest.NewAdminServer(nil, settings, logger.NewLogger("error"),
+		config.InternalAdmin{Port: 9090, Token: "internal", AllowedNetworks: []string{"10.1.0.0/16"}}).Handler()}
+
+	w := f.send(http.MethodGet, "/admin/settings", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"0"`, w.Header().Get("ETag"), "the defaults are in effect until the first change")
+	var current model.RuntimeSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &current))
+	assert.Equal(t, 500, current.RateLimit)
+
+	body := `{"maintenance": true, "maintenance_message": "Back at 10:00", "rate_limit": 200}`
+	w = f.send(http.MethodPatch, "/admin/settings", body, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	w = f.send(http.MethodPatch, "/admin/settings", body, map[string]string{"If-Match": `"0"`})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.True(t, settings.Current().Maintenance)
+	assert.Equal(t, 200, settings.Current().RateLimit)
+	assert.Equal(t, "oncall", settings.Current().UpdatedBy)
+
+	w = f.send(http.MethodPatch, "/admin/settings", `{"maintenance": false}`, map[string]string{"If-Match": `"0"`})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "changes must be based on the latest settings")
+	assert.Equal(t, problem.CodePreconditionFailed, decodeProblem(t, w).Code)
+
+	for _, invalid := range []string{`{"rate_limit": 0}`, `{"admission_burst": -1}`, `{"concert_cache_ttl_ms": -5}`,
+		`{"cors_allow_origins": []}`, `{"cors_allow_origins": ["*"]}`, `{}`} {
+		w = f.send(http.MethodPatch, "/admin/settings", invalid, map[string]string{"If-Match": `"1"`})
+		assert.Equal(t, http.StatusBadRequest, w.Code, invalid)
+	}
+	assert.Equal(t, 1, settings.Current().Version, "rejected changes aren't saved")
+
+	entries, _, err := auditService.List(context.Background(), model.AuditFilter{Action: model.AuditActionRuntimeSettingsUpdated}, query.Page{Number: 1, Size: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "oncall", entries[0].Actor)
+	var details struct {
+		Changes []model.FieldChange `json:"changes"`
+		Version int                 `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(entries[0].Details, &details))
+	assert.Equal(t, 1, details.Version)
+	fields := make([]string, 0, len(details.Changes))
+	for _, change := range details.Changes {
+		fields = append(fields, change.Field)
+	}
+	assert.Equal(t, []string{"maintenance", "maintenance_message", "rate_limit"}, fields)
+}
+
+func TestRuntimeSettingsPropagateToReplicas(t *testing.T) {
+	repo := mocks.NewMockRuntimeSettingsRepository()
+	primary := service.NewRuntimeSettingsService(repo, nil, testRuntimeDefaults())
+	replica := service.NewRuntimeSettingsService(repo, nil, testRuntimeDefaults())
+
+	applied := make(chan *model.RuntimeSettings, 4)
+	replica.OnChange(func(settings *model.RuntimeSettings) { applied <- settings })
+	assert.Equal(t, 0, (<-applied).Version, "listeners start with the settings in effect")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Polling is too slow to pass the test; only the announcement can
+	go replica.Run(ctx, time.Hour)
+
+	rate := 5.0
+	require.Eventually(t, func() bool {
+		_, _, err := primary.Update(context.Background(), "oncall", primary.Current().Version,
+			&model.RuntimeSettingsPatch{AdmissionRate: &rate})
+		require.NoError(t, err)
+		select {
+		case settings := <-applied:
+			assert.Equal(t, 5.0, settings.AdmissionRate)
+			return true
+		case <-time.After(50 * time.Millisecond):
+			// The replica may not have started watching yet
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 5.0, replica.Current().AdmissionRate)
+	assert.Equal(t, primary.Current().Version, replica.Current().Version)
+
+	// A replica that missed the announcement catches up on its next change
+	other := service.NewRuntimeSettingsService(repo, nil, testRuntimeDefaults())
+	burst := 10
+	updated, _, err := other.Update(context.Background(), "oncall", primary.Current().Version,
+		&model.RuntimeSettingsPatch{AdmissionBurst: &burst})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, updated.AdmissionRate, "changes are based on the stored settings")
+}
+
+func TestMaintenanceModeRejectsClientRequests(t *testing.T) {
+	settings := service.NewRuntimeSettingsService(mocks.NewMockRuntimeSettingsRepository(), nil, testRuntimeDefaults())
+	server := newRuntimeSettingsServer(settings)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Origin", "https://tickets.example.com")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, get("/api/v1/concerts").Code)
+
+	on, message := true, "Back at 10:00"
+	_, _, err := settings.Update(context.Background(), "oncall", 0, &model.RuntimeSettingsPatch{Maintenance: &on, MaintenanceMessage: &message})
+	require.NoError(t, err)
+
+	w := get("/api/v1/concerts")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "https://tickets.example.com", w.Header().Get("Access-Control-Allow-Origin"), "browsers can read the rejection")
+	details := decodeProblem(t, w)
+	assert.Equal(t, problem.CodeMaintenance, details.Code)
+	assert.Equal(t, "Back at 10:00", details.Detail)
+
+	assert.Equal(t, http.StatusOK, get("/health").Code, "health checks keep passing during maintenance")
+	assert.NotEqual(t, http.StatusServiceUnavailable, get("/api/v1/admin/booking-conflicts").Code, "operators can still work")
+
+	off := false
+	_, _, err = settings.Update(context.Background(), "oncall", 1, &model.RuntimeSettingsPatch{Maintenance: &off})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, get("/api/v1/concerts").Code)
+}
+
+func TestRuntimeRateLimitAndOriginsApplyAtOnce(t *testing.T) {
+	settings := service.NewRuntimeSettingsService(mocks.NewMockRuntimeSettingsRepository(), nil, testRuntimeDefaults())
+	server := newRuntimeSettingsServer(settings)
+
+	get := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/concerts", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, get("https://tickets.example.com").Code)
+	assert.Equal(t, http.StatusForbidden, get("https://partner.example.com").Code)
+
+	limit := 1
+	origins := []string{"https://tickets.example.com", "https://partner.example.com"}
+	_, _, err := settings.Update(context.Background(), "oncall", 0, &model.RuntimeSettingsPatch{RateLimit: &limit, CORSAllowOrigins: &origins})
+	require.NoError(t, err)
+
+	w := get("https://partner.example.com")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://partner.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusTooManyRequests, get("https://partner.example.com").Code, "the lower rate limit applies to existing clients")
+}
+
+// issuedTokens stores booking tokens nowhere, for tests of issuance only
+type issuedTokens struct{}
+
+func (issuedTokens) Create(context.Context, string, int64, string, time.Time) error { return nil }
+
+func (issuedTokens) Consume(context.Context, string, int64, string, time.Time) error { return nil }
+
+func (issuedTokens) DeleteExpired(context.Context, time.Time) (int, error) { return 0, nil }
+
+func TestBookingTokenIssueRateChangesAtRuntime(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "On-sale",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            40,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	tokens := service.NewBookingTokenService(issuedTokens{}, concertRepo, time.Minute, 0.001, 1, nil)
+	ctx := context.Background()
+
+	_, err = tokens.IssueToken(ctx, concert.ID, "user-1")
+	require.NoError(t, err)
+	_, err = tokens.IssueToken(ctx, concert.ID, "user-2")
+	require.Error(t, err, "the burst is spent")
+
+	tokens.SetIssueRate(1000, 10)
+	require.Eventually(t, func() bool {
+		_, err := tokens.IssueToken(ctx, concert.ID, "user-2")
+		return err == nil
+	}, time.Second, 5*time.Millisecond, "the faster rate applies to the concert's existing limiter")
+
+	tokens.SetIssueRate(0, 0)
+	for i := 0; i < 20; i++ {
+		_, err := tokens.IssueToken(ctx, concert.ID, "user-3")
+		require.NoError(t, err, "issuance is unlimited at rate 0")
+	}
+}
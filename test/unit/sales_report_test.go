@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// salesReportStore hands out one due concert and keeps the reports saved for it
+type salesReportStore struct {
+	due     []*model.Concert
+	reports map[int64]*model.SalesReport
+}
+
+func (s *salesReportStore) ClaimDueConcerts(ctx context.Context, now time.Time, limit int, staleAfter time.Duration) ([]*model.Concert, error) {
+	due := s.due
+	s.due = nil
+	return due, nil
+}
+
+func (s *salesReportStore) ReleaseClaim(ctx context.Context, concertID int64) error {
+	return nil
+}
+
+func (s *salesReportStore) SaveFinal(ctx context.Context, report *model.SalesReport) error {
+	s.reports[report.ConcertID] = report
+	return nil
+}
+
+func (s *salesReportStore) GetByConcertID(ctx context.Context, concertID int64) (*model.SalesReport, error) {
+	return s.reports[concertID], nil
+}
+
+func (s *salesReportStore) ListUnsent(ctx context.Context, limit int) ([]*model.SalesReport, error) {
+	return nil, nil
+}
+
+func (s *salesReportStore) MarkEmailed(ctx context.Context, concertID int64, at time.Time) error {
+	return nil
+}
+
+func TestSalesReportCSVDefusesFormulas(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	concert := createStreamTestConcert(t, concertRepo, 10)
+	concert.Name = `=HYPERLINK("https://evil.example","Open")`
+	concert.Artist = "+cmd|' /C calc'!A0"
+	concert.Venue = "-2+3"
+
+	for _, userID := range []string{"@SUM(A1:A9)", "\tuser", "\ruser", "user-1"} {
+		_, err := bookingRepo.Create(context.Background(), &model.Booking{
+			ConcertID:   concert.ID,
+			UserID:      userID,
+			TicketCount: 1,
+			UnitPrice:   concert.Price,
+			Status:      model.BookingStatusConfirmed,
+			BookingTime: time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	store := &salesReportStore{due: []*model.Concert{concert}, reports: map[int64]*model.SalesReport{}}
+	generated, err := service.NewSalesReportService(store, concertRepo, bookingRepo, mocks.NewMockMailSender()).FinalizeDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, generated)
+
+	reader := csv.NewReader(strings.NewReader(store.reports[concert.ID].CSV))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	cells := map[string]string{}
+	var users []string
+	for _, row := range rows {
+		if len(row) == 2 {
+			cells[row[0]] = row[1]
+		}
+		if len(row) == 6 && row[0] != "booking_reference" {
+			users = append(users, row[1])
+		}
+	}
+	assert.Equal(t, `'=HYPERLINK("https://evil.example","Open")`, cells["concert"])
+	assert.Equal(t, "'+cmd|' /C calc'!A0", cells["artist"])
+	assert.Equal(t, "'-2+3", cells["venue"])
+	assert.ElementsMatch(t, []string{"'@SUM(A1:A9)", "'\tuser", "'\ruser", "user-1"}, users, "plain values are left as they are")
+	assert.Equal(t, "4", cells["confirmed_bookings"], "numbers are left as they are")
+}
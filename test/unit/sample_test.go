@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSampleTestService creates ten public concerts in March and two in April
+// with n bookings each, plus a private concert
+func newSampleTestService(t *testing.T, bookingsPerConcert int) service.SampleService {
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+
+	march := time.Date(2030, time.March, 1, 20, 0, 0, 0, time.UTC)
+	april := time.Date(2030, time.April, 1, 20, 0, 0, 0, time.UTC)
+	dates := []time.Time{april, april}
+	for i := 0; i < 10; i++ {
+		dates = append(dates, march.AddDate(0, 0, i))
+	}
+
+	create := func(date time.Time, visibility string) {
+		concert := newVisibilityConcert(visibility)
+		concert.ConcertDate = date
+		concert.OrganizerEmail = "organizer@example.com"
+		concert, err := concertRepo.Create(ctx, concert)
+		require.NoError(t, err)
+
+		for i := 0; i < bookingsPerConcert; i++ {
+			_, err := bookingRepo.Create(ctx, &model.Booking{
+				ConcertID:     concert.ID,
+				UserID:        "user-" + string(rune('a'+i)),
+				TicketCount:   1,
+				Status:        model.BookingStatusConfirmed,
+				UnitPrice:     25,
+				BookingTime:   date.Add(-time.Duration(i+1) * time.Minute),
+				AttendeeName:  "Jane Doe",
+				AttendeeEmail: "jane@example.com",
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	for _, date := range dates {
+		create(date, model.VisibilityPublic)
+	}
+	create(march, model.VisibilityPrivate)
+
+	return service.NewSampleService(mocks.NewMockSampleRepository(concertRepo, bookingRepo))
+}
+
+func TestSampleIsStratifiedByMonth(t *testing.T) {
+	sampleService := newSampleTestService(t, 3)
+
+	dataset, err := sampleService.Generate(context.Background(), model.SampleOptions{Rate: 0.5, Seed: 1})
+	require.NoError(t, err)
+
+	months := make(map[string]int)
+	for i, concert := range dataset.Concerts {
+		assert.Equal(t, i+1, concert.SampleID)
+		months[concert.Month]++
+		assert.Equal(t, 2.0, concert.Weight)
+	}
+	assert.Equal(t, map[string]int{"2030-03": 5, "2030-04": 1}, months, "the private concert is never sampled")
+	assert.Len(t, dataset.Bookings, 18, "every booking of a sampled concert is kept")
+
+	again, err := sampleService.Generate(context.Background(), model.SampleOptions{Rate: 0.5, Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, dataset.Concerts, again.Concerts, "the same seed draws the same sample")
+
+	all, err := sampleService.Generate(context.Background(), model.SampleOptions{Rate: 1, Seed: 1})
+	require.NoError(t, err)
+	assert.Len(t, all.Concerts, 12)
+
+	for _, rate := range []float64{0, -0.5, 1.5} {
+		_, err := sampleService.Generate(context.Background(), model.SampleOptions{Rate: rate})
+		var errWithMsg *pkgErr.ErrorWithMessage
+		assert.True(t, errors.As(err, &errWithMsg), "rate %v", rate)
+	}
+}
+
+func TestSampleAppliesPrivacyFilters(t *testing.T) {
+	sampleService := newSampleTestService(t, 3)
+	ctx := context.Background()
+
+	dataset, err := sampleService.Generate(ctx, model.SampleOptions{Rate: 1, Seed: 7, Jitter: time.Hour, Salt: []byte("salt")})
+	require.NoError(t, err)
+
+	hashes := make(map[string]bool)
+	for _, booking := range dataset.Bookings {
+		assert.NotContains(t, booking.UserHash, "user-")
+		assert.Len(t, booking.UserHash, 32)
+		hashes[booking.UserHash] = true
+
+		concert := dataset.Concerts[booking.ConcertSampleID-1]
+		assert.False(t, booking.BookedAt.After(concert.ConcertDate), "jitter never moves a booking past the concert")
+		assert.WithinDuration(t, concert.ConcertDate, booking.BookedAt, 4*time.Hour)
+	}
+	assert.Len(t, hashes, 3, "the bookings of one user share a hash")
+
+	again, err := sampleService.Generate(ctx, model.SampleOptions{Rate: 1, Seed: 7, Jitter: time.Hour})
+	require.NoError(t, err)
+	assert.NotEqual(t, dataset.Bookings[0].UserHash, again.Bookings[0].UserHash, "samples with different salts cannot be joined")
+
+	suppressed, err := sampleService.Generate(ctx, model.SampleOptions{Rate: 1, MinBookings: 4})
+	require.NoError(t, err)
+	assert.Empty(t, suppressed.Concerts)
+	assert.Empty(t, suppressed.Bookings)
+	assert.Equal(t, 12, suppressed.Suppressed)
+
+	var concerts, bookings bytes.Buffer
+	require.NoError(t, service.WriteSampleCSV(dataset, &concerts, &bookings))
+	assert.True(t, strings.HasPrefix(concerts.String(), "sample_id,month,name,"))
+	assert.True(t, strings.HasPrefix(bookings.String(), "concert_sample_id,user_hash,"))
+	for _, personal := range []string{"organizer@example.com", "Jane Doe", "jane@example.com", "user-a"} {
+		assert.NotContains(t, concerts.String()+bookings.String(), personal)
+	}
+}
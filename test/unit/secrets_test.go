@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func secretsConfig() config.Secrets {
+	return config.Secrets{CacheTTL: time.Minute, Timeout: time.Second}
+}
+
+// fakeVault serves the KV secret tickets/db of the engine at secret/ and
+// counts the requests for it
+func fakeVault(t *testing.T, password *atomic.Value, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/tickets/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if password.Load() == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"username": "tickets", "password": password.Load()},
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStoreLeavesPlainValuesAlone(t *testing.T) {
+	store := secrets.NewStore(secretsConfig())
+	value, err := store.Resolve(context.Background(), "https://tickets.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "https://tickets.example.com", value)
+
+	_, err = store.Resolve(context.Background(), "awssm://tickets/stripe#secret_key")
+	assert.ErrorContains(t, err, "secret store awssm isn't configured")
+}
+
+func TestStoreReadsSecretsFromFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\n"), 0o600))
+
+	value, err := secrets.NewStore(secretsConfig()).Resolve(context.Background(), "file://"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value, "the trailing newline isn't part of the secret")
+}
+
+func TestStoreCachesVaultSecretsUntilTheyExpire(t *testing.T) {
+	defer clock.Process().Reset()
+	var password atomic.Value
+	password.Store("first")
+	var requests atomic.Int32
+	cfg := secretsConfig()
+	cfg.Vault = config.VaultSecrets{Addr: fakeVault(t, &password, &requests).URL, Token: "vault-token"}
+	store := secrets.NewStore(cfg)
+	ctx := context.Background()
+
+	value, err := store.Resolve(ctx, "vault://secret/tickets/db#password")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	password.Store("rotated")
+	value, err = store.Resolve(ctx, "vault://secret/tickets/db#password")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+	assert.Equal(t, int32(1), requests.Load(), "cached secrets aren't fetched again")
+
+	clock.Process().Advance(2 * time.Minute)
+	value, err = store.Resolve(ctx, "vault://secret/tickets/db#password")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", value)
+
+	_, err = store.Resolve(ctx, "vault://secret/tickets/db")
+	assert.ErrorContains(t, err, "needs the #key")
+	_, err = store.Resolve(ctx, "vault://secret/tickets/db#api_key")
+	assert.ErrorContains(t, err, "has no api_key")
+
+	password.Store("")
+	clock.Process().Advance(2 * time.Minute)
+	value, err = store.Resolve(ctx, "vault://secret/tickets/db#password")
+	require.NoError(t, err, "an unavailable store keeps the previous value")
+	assert.Equal(t, "rotated", value)
+}
+
+func TestStoreReadsSecretsManagerWithSignedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&body)
+		authorization := r.Header.Get("Authorization")
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || body.SecretId != "tickets/stripe" ||
+			!strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(authorization, "/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidSignatureException","message":"bad request"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"Name": "tickets/stripe", "SecretString": `{"secret_key":"sk_live_1"}`})
+	}))
+	defer server.Close()
+
+	cfg := secretsConfig()
+	cfg.AWS = config.AWSSecrets{Region: "eu-west-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", Endpoint: server.URL}
+	value, err := secrets.NewStore(cfg).Resolve(context.Background(), "awssm://tickets/stripe#secret_key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_1", value)
+}
+
+func TestResolveConfigKeepsTheReferencesOfTheOriginal(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("s3cret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client_token"), []byte("client-token"), 0o600))
+
+	cfg, err := config.Load(writeConfig(t, `
+database:
+  password: file://`+dir+`/db_password
+grpc_auth:
+  clients:
+    - name: box-office
+      token: file://`+dir+`/client_token
+      roles: [booking]
+`))
+	require.NoError(t, err)
+
+	resolved, err := secrets.ResolveConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", resolved.Database.Password)
+	assert.Equal(t, "client-token", resolved.GRPCAuth.Clients[0].Token)
+	assert.Equal(t, "file://"+dir+"/client_token", cfg.GRPCAuth.Clients[0].Token, "the list of the original isn't changed")
+
+	require.NotNil(t, resolved.Database.PasswordSource, "the password is resolved again for new connections")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("rotated"), 0o600))
+	password, err := resolved.Database.PasswordSource(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", password, "until the cached password expires")
+
+	cfg.Database.Password = "file://" + dir + "/missing"
+	_, err = secrets.ResolveConfig(context.Background(), cfg)
+	assert.ErrorContains(t, err, "database.password")
+}
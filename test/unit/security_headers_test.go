@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithSecurityHeaders(cfg config.SecurityHeaders) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.SecurityHeaders(cfg))
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	return recorder
+}
+
+func TestSecurityHeadersAreApplied(t *testing.T) {
+	recorder := serveWithSecurityHeaders(config.SecurityHeaders{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "DENY",
+		ContentSecurityPolicy: "default-src 'none'",
+		ReferrerPolicy:        "no-referrer",
+	})
+
+	assert.Equal(t, "max-age=31536000; includeSubDomains", recorder.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'", recorder.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "no-referrer", recorder.Header().Get("Referrer-Policy"))
+}
+
+func TestEmptySecurityHeadersAreOmitted(t *testing.T) {
+	recorder := serveWithSecurityHeaders(config.SecurityHeaders{ContentTypeOptions: "nosniff"})
+
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	for _, name := range []string{"Strict-Transport-Security", "X-Frame-Options", "Content-Security-Policy", "Referrer-Policy"} {
+		_, present := recorder.Header()[name]
+		assert.False(t, present, name)
+	}
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	seeder := service.NewSeedService(concerts, bookings)
+	opts := model.SeedOptions{Concerts: 20, Users: 30, Bookings: 300, Seed: 7}
+
+	first, err := seeder.Seed(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 20, first.ConcertsCreated)
+	assert.Positive(t, first.BookingsCreated)
+	assert.Equal(t, 300, first.BookingsCreated+first.BookingsSkipped)
+
+	again, err := seeder.Seed(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, model.SeedResult{ConcertsFound: 20, BookingsFound: first.BookingsCreated, BookingsSkipped: first.BookingsSkipped}, *again)
+
+	more, err := seeder.Seed(ctx, model.SeedOptions{Concerts: 25, Users: 30, Bookings: 350, Seed: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 5, more.ConcertsCreated, "larger counts add what is missing")
+	assert.Equal(t, 20, more.ConcertsFound)
+	assert.Equal(t, first.BookingsCreated, more.BookingsFound)
+}
+
+func TestSeededBookingsTakeTheirTickets(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupMemory()
+	result, err := service.NewSeedService(concerts, bookings).Seed(ctx, model.SeedOptions{Concerts: 10, Users: 40, Bookings: 400, Seed: 3})
+	require.NoError(t, err)
+
+	sold := make(map[int64]int)
+	perUser := make(map[string]int)
+	var cancelled int
+	for user := 1; user <= 40; user++ {
+		userID := fmt.Sprintf("seed-user-%d", user)
+		booked, err := bookings.GetAllByUserID(ctx, userID)
+		require.NoError(t, err)
+		for _, booking := range booked {
+			assert.NotEmpty(t, booking.AttendeeEmail)
+			if booking.Status == model.BookingStatusCancelled {
+				cancelled++
+				continue
+			}
+			sold[booking.ConcertID] += booking.TicketCount
+			perUser[userID]++
+		}
+	}
+	assert.Equal(t, result.BookingsCancelled, cancelled)
+
+	seeded, err := concerts.List(ctx, query.Options{Page: query.NewPage(1, query.MaxPageSize)})
+	require.NoError(t, err)
+	require.Len(t, seeded, 10)
+	for _, concert := range seeded {
+		assert.Equal(t, concert.TotalTickets-concert.AvailableTickets, sold[concert.ID], concert.Name)
+	}
+
+	// A few fans book far more often than most
+	var most int
+	for _, n := range perUser {
+		most = max(most, n)
+	}
+	assert.Greater(t, most, 3*(result.BookingsCreated-result.BookingsCancelled)/40)
+}
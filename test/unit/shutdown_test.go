@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	grpcapi "concert-ticket-api/api/grpc"
+	pb "concert-ticket-api/api/grpc/proto"
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/lifecycle"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/worker"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestShutdownStepsRunInOrder(t *testing.T) {
+	manager := lifecycle.New(logger.NewLogger("error"), time.Second)
+
+	var mutex sync.Mutex
+	var steps []string
+	stopped := make(chan struct{})
+	manager.Go("server", func() error {
+		<-stopped
+		return http.ErrServerClosed
+	})
+	manager.OnShutdown("drain", func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		steps = append(steps, "drain")
+		return nil
+	})
+	manager.OnShutdown("server", func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		steps = append(steps, "server")
+		close(stopped)
+		return nil
+	})
+	manager.OnShutdown("flush", func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		steps = append(steps, "flush")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, manager.Wait(ctx), "servers closing on shutdown aren't failures")
+	assert.Equal(t, []string{"drain", "server", "flush"}, steps)
+}
+
+func TestFailingServerShutsDownTheOthers(t *testing.T) {
+	manager := lifecycle.New(logger.NewLogger("error"), time.Second)
+
+	stopped := make(chan struct{})
+	manager.Go("REST server", func() error {
+		<-stopped
+		return nil
+	})
+	manager.Go("gRPC server", func() error {
+		return errors.New("address already in use")
+	})
+	manager.OnShutdown("REST server", func(ctx context.Context) error {
+		close(stopped)
+		return nil
+	})
+
+	err := manager.Wait(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gRPC server: address already in use")
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("the REST server wasn't shut down")
+	}
+}
+
+func TestShutdownReportsStepsMissingTheDeadline(t *testing.T) {
+	manager := lifecycle.New(logger.NewLogger("error"), 20*time.Millisecond)
+	manager.OnShutdown("outbox", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := manager.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStoppingWorkersFinishesRunsInProgress(t *testing.T) {
+	workers := worker.NewRegistry("test", nil, nil)
+	started := make(chan struct{})
+	var finished bool
+	workers.Register("outbox-relay", 10*time.Millisecond, func(ctx context.Context) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		// The transaction of the run commits although the workers are stopping
+		finished = ctx.Err() == nil
+		return nil
+	})
+	workers.Start(context.Background())
+	<-started
+
+	require.NoError(t, workers.Stop(context.Background()))
+	assert.True(t, finished)
+	assert.Equal(t, int64(1), workers.Statuses()[0].Runs, "no run starts once stopping")
+	assert.NoError(t, workers.Stop(context.Background()), "stopping twice is harmless")
+}
+
+func TestStoppingWorkersCancelsRunsPastTheDeadline(t *testing.T) {
+	workers := worker.NewRegistry("test", nil, nil)
+	started := make(chan struct{})
+	workers.Register("accounting-export", 10*time.Millisecond, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	workers.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, workers.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestDrainingRejectsNewWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := health.NewRegistry(time.Second, 1)
+	router := gin.New()
+	router.Use(middleware.Draining(registry))
+	handler.NewHealthHandler(registry).RegisterRoutes(router)
+	router.POST("/api/v1/bookings", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/api/v1/concerts", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost, "/api/v1/bookings").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health/ready").Code)
+
+	registry.Drain()
+	recorder := serve(http.MethodPost, "/api/v1/bookings")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, "SERVICE_UNAVAILABLE", decodeProblem(t, recorder).Code)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/concerts").Code, "reads are served until the listener closes")
+	recorder = serve(http.MethodGet, "/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"status":"draining"`)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health/live").Code)
+}
+
+func TestDrainingGRPCServerRejectsBookingsAndEndsWatchers(t *testing.T) {
+	concertRepo := mocks.NewMockConcertRepository()
+	concert, err := concertRepo.Create(context.Background(), &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	server := grpcapi.NewServer(service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil), nil, nil, nil, nil,
+		newTestAuthorizer(), false, logger.NewLogger("error"), 0)
+	conn := serveGRPC(t, server)
+	concerts := pb.NewConcertServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := concerts.WatchConcertAvailability(ctx, &pb.WatchConcertAvailabilityRequest{ConcertId: concert.ID})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	server.Drain()
+	_, err = pb.NewBookingServiceClient(conn).BookTickets(ctx, &pb.BookTicketsRequest{ConcertId: concert.ID, UserId: "user-1", TicketCount: 1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = concerts.GetConcert(ctx, &pb.GetConcertRequest{Id: concert.ID})
+	assert.NoError(t, err, "reads are served while draining")
+
+	require.NoError(t, server.Shutdown(ctx), "watchers don't hold up the shutdown")
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,240 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	"concert-ticket-api/internal/repository/sqlite"
+	"concert-ticket-api/pkg/crypto"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSQLite(t *testing.T) (repository.ConcertRepository, repository.BookingRepository) {
+	t.Helper()
+
+	database, err := testutil.SetupSQLiteDB()
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	cipher, err := crypto.NewEnvelopeCipher("test", map[string][]byte{"test": make([]byte, 32)})
+	require.NoError(t, err)
+	return sqlite.NewConcertRepository(database), sqlite.NewBookingRepository(database, cipher)
+}
+
+func createStoredConcert(t *testing.T, concerts repository.ConcertRepository, tickets int) *model.Concert {
+	t.Helper()
+
+	concert, err := concerts.Create(context.Background(), &model.Concert{
+		Name:             "Flat File Live",
+		Artist:           "The Pages",
+		Venue:            "Journal Hall",
+		ConcertDate:      time.Now().Add(24 * time.Hour),
+		TotalTickets:     tickets,
+		AvailableTickets: tickets,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(time.Hour),
+		Visibility:       model.VisibilityPublic,
+	})
+	require.NoError(t, err)
+	return concert
+}
+
+// acceptConcert is a booking check that lets every booking through
+func acceptConcert(*model.Concert) error { return nil }
+
+func TestSQLiteConcertUpdateChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	concerts, _ := setupSQLite(t)
+	concert := createStoredConcert(t, concerts, 10)
+	assert.NotZero(t, concert.ID)
+	assert.Equal(t, 1, concert.Version)
+
+	stale := *concert
+	concert.Price = 30
+	require.NoError(t, concerts.Update(ctx, concert))
+	assert.Equal(t, 2, concert.Version)
+
+	stale.Name = "Overwritten"
+	assert.ErrorIs(t, concerts.Update(ctx, &stale), pkgErr.ErrOptimisticLockFailed)
+
+	stored, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Flat File Live", stored.Name)
+	assert.Equal(t, 30.0, stored.Price)
+
+	history, err := concerts.GetPriceHistory(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 2, "the creation price and the change")
+}
+
+func TestSQLiteConcertListAndCount(t *testing.T) {
+	ctx := context.Background()
+	concerts, _ := setupSQLite(t)
+	createStoredConcert(t, concerts, 10)
+	soldOut := createStoredConcert(t, concerts, 0)
+	soldOut.Artist = "Sold Out Band"
+	require.NoError(t, concerts.Update(ctx, soldOut))
+
+	listed, err := concerts.List(ctx, query.Options{
+		Page:    query.NewPage(1, 10),
+		Filters: query.Filters{"available": true, "date_from": time.Now()},
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "The Pages", listed[0].Artist)
+
+	count, err := concerts.Count(ctx, query.Filters{"artist": "sold out"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLiteBookingTakesOnlyTheTicketsLeft(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupSQLite(t)
+	concert := createStoredConcert(t, concerts, 3)
+
+	booking := &model.Booking{
+		Reference: "BK-SQLITE-1", ConcertID: concert.ID, UserID: "user-1", TicketCount: 2,
+		Status: model.BookingStatusConfirmed, AttendeeName: "Ada", IdempotencyKey: "key-1",
+	}
+	require.NoError(t, bookings.CreateWithTicketUpdate(ctx, booking, concert.Version))
+	assert.NotZero(t, booking.ID)
+
+	// The version has moved on
+	second := &model.Booking{Reference: "BK-SQLITE-2", ConcertID: concert.ID, UserID: "user-2", TicketCount: 1, Status: model.BookingStatusConfirmed}
+	assert.ErrorIs(t, bookings.CreateWithTicketUpdate(ctx, second, concert.Version), pkgErr.ErrOptimisticLockFailed)
+
+	_, err := bookings.CreateWithConditionalUpdate(ctx, &model.Booking{
+		Reference: "BK-SQLITE-3", ConcertID: concert.ID, UserID: "user-3", TicketCount: 2, Status: model.BookingStatusConfirmed,
+	}, acceptConcert)
+	assert.ErrorIs(t, err, pkgErr.ErrInsufficientTickets)
+
+	retry := &model.Booking{
+		Reference: "BK-SQLITE-4", ConcertID: concert.ID, UserID: "user-1", TicketCount: 1,
+		Status: model.BookingStatusConfirmed, IdempotencyKey: "key-1",
+	}
+	_, err = bookings.CreateWithConditionalUpdate(ctx, retry, acceptConcert)
+	assert.ErrorIs(t, err, pkgErr.ErrIdempotencyKeyInUse)
+
+	stored, err := bookings.GetByIdempotencyKey(ctx, "user-1", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, booking.Reference, stored.Reference)
+	assert.Equal(t, "Ada", stored.AttendeeName, "attendee details are decrypted")
+
+	current, err := concerts.GetByID(ctx, concert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, current.AvailableTickets, "refused bookings take no tickets")
+}
+
+func TestSQLiteConditionalBookingRefusals(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupSQLite(t)
+
+	_, err := bookings.CreateWithConditionalUpdate(ctx, &model.Booking{
+		Reference: "BK-MISSING", ConcertID: 404, UserID: "user-1", TicketCount: 1, Status: model.BookingStatusConfirmed,
+	}, acceptConcert)
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound)
+
+	concert := createStoredConcert(t, concerts, 10)
+	concert.BookingEndTime = time.Now().Add(-time.Minute)
+	require.NoError(t, concerts.Update(ctx, concert))
+
+	_, err = bookings.CreateWithConditionalUpdate(ctx, &model.Booking{
+		Reference: "BK-CLOSED", ConcertID: concert.ID, UserID: "user-1", TicketCount: 1, Status: model.BookingStatusConfirmed,
+	}, acceptConcert)
+	assert.ErrorIs(t, err, pkgErr.ErrBookingClosed)
+}
+
+func TestSQLiteCancellationReleasesTicketsOnce(t *testing.T) {
+	ctx := context.Background()
+	concerts, bookings := setupSQLite(t)
+	concert := createStoredConcert(t, concerts, 5)
+
+	booking := &model.Booking{Reference: "BK-CANCEL", ConcertID: concert.ID, UserID: "user-1", TicketCount: 2, Status: model.BookingStatusConfirmed}
+	_, err := bookings.CreateWithConcertLock(ctx, booking, acceptConcert)
+	require.NoError(t, err)
+
+	availability, err := bookings.CancelWithTicketRelease(ctx, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, availability.AvailableTickets)
+
+	_, err = bookings.CancelWithTicketRelease(ctx, booking.ID)
+	assert.ErrorIs(t, err, pkgErr.ErrBookingAlreadyCancelled)
+
+	_, err = bookings.CancelWithTicketRelease(ctx, 404)
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound)
+}
+
+func TestSQLiteSingleUseInviteIsRedeemedOnce(t *testing.T) {
+	ctx := context.Background()
+	concerts, _ := setupSQLite(t)
+	concert := createStoredConcert(t, concerts, 5)
+
+	invite := &model.ConcertInvite{ConcertID: concert.ID, Invitee: "guest@example.com", SingleUse: true, TokenHash: "hash"}
+	require.NoError(t, concerts.CreateInvites(ctx, []*model.ConcertInvite{invite}))
+	require.NotZero(t, invite.ID)
+
+	redemption := &model.InviteRedemption{InviteID: invite.ID, BookingReference: "BK-INVITE-1"}
+	require.NoError(t, concerts.RedeemInvite(ctx, redemption))
+	assert.ErrorIs(t, concerts.RedeemInvite(ctx, &model.InviteRedemption{InviteID: invite.ID, BookingReference: "BK-INVITE-2"}), pkgErr.ErrInviteRedeemed)
+
+	// A withdrawn redemption frees the invite again
+	require.NoError(t, concerts.WithdrawInviteRedemption(ctx, redemption))
+	assert.NoError(t, concerts.RedeemInvite(ctx, &model.InviteRedemption{InviteID: invite.ID, BookingReference: "BK-INVITE-3"}))
+}
+
+func TestSQLiteBookingTokenIsConsumedOnce(t *testing.T) {
+	ctx := context.Background()
+	database, err := testutil.SetupSQLiteDB()
+	require.NoError(t, err)
+	defer database.Close()
+
+	concert := createStoredConcert(t, sqlite.NewConcertRepository(database), 5)
+	tokens := sqlite.NewBookingTokenRepository(database)
+	require.NoError(t, tokens.Create(ctx, "token-hash", concert.ID, "user-1", time.Now().Add(time.Hour)))
+
+	assert.ErrorIs(t, tokens.Consume(ctx, "token-hash", concert.ID, "user-2", time.Now()), pkgErr.ErrInvalidBookingToken)
+	require.NoError(t, tokens.Consume(ctx, "token-hash", concert.ID, "user-1", time.Now()))
+	assert.ErrorIs(t, tokens.Consume(ctx, "token-hash", concert.ID, "user-1", time.Now()), pkgErr.ErrInvalidBookingToken)
+}
+
+func TestSQLiteErasureDeletesBookingTokens(t *testing.T) {
+	ctx := context.Background()
+	database, err := testutil.SetupSQLiteDB()
+	require.NoError(t, err)
+	defer database.Close()
+
+	cipher, err := crypto.NewEnvelopeCipher("test", map[string][]byte{"test": make([]byte, 32)})
+	require.NoError(t, err)
+	concert := createStoredConcert(t, sqlite.NewConcertRepository(database), 5)
+	bookings := sqlite.NewBookingRepository(database, cipher)
+	tokens := sqlite.NewBookingTokenRepository(database)
+
+	booking := &model.Booking{ConcertID: concert.ID, UserID: "user-1", TicketCount: 1, Status: model.BookingStatusConfirmed,
+		AttendeeName: "Jane Doe", AttendeeEmail: "jane@example.com"}
+	_, err = bookings.CreateWithConditionalUpdate(ctx, booking, acceptConcert)
+	require.NoError(t, err)
+	require.NoError(t, tokens.Create(ctx, "token-1", concert.ID, "user-1", time.Now().Add(time.Hour)))
+	require.NoError(t, tokens.Create(ctx, "token-2", concert.ID, "user-2", time.Now().Add(time.Hour)))
+
+	count, err := bookings.AnonymizeUser(ctx, "user-1", "erased-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "the booking and the token")
+
+	stored, err := bookings.GetByID(ctx, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "erased-1", stored.UserID)
+	assert.Empty(t, stored.AttendeeEmail)
+	assert.ErrorIs(t, tokens.Consume(ctx, "token-1", concert.ID, "user-1", time.Now()), pkgErr.ErrInvalidBookingToken)
+	assert.NoError(t, tokens.Consume(ctx, "token-2", concert.ID, "user-2", time.Now()), "other users keep their tokens")
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowQuery counts far enough to outlast the statement timeout
+const slowQuery = `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c LIMIT 1000000000) SELECT COUNT(*) FROM c`
+
+func TestStatementTimeoutEndsSlowStatements(t *testing.T) {
+	database, err := db.NewSQLiteDB(config.Database{Driver: config.DriverSQLite, Path: ":memory:", StatementTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer database.Close()
+	ctx := context.Background()
+
+	started := time.Now()
+	var count int
+	assert.Error(t, database.GetContext(ctx, &count, slowQuery))
+	assert.Less(t, time.Since(started), 5*time.Second)
+
+	// The connection stays usable, and so do transactions that outlast a statement
+	_, err = database.ExecContext(ctx, `CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)`)
+	require.NoError(t, err)
+
+	tx, err := database.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO notes (body) VALUES (?)`, "first")
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = tx.ExecContext(ctx, `INSERT INTO notes (body) VALUES (?)`, "second")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	var bodies []string
+	require.NoError(t, database.SelectContext(ctx, &bodies, `SELECT body FROM notes ORDER BY id`))
+	assert.Equal(t, []string{"first", "second"}, bodies)
+}
+
+func TestDatabaseValidatePool(t *testing.T) {
+	database := config.Database{Driver: config.DriverPostgres, MaxOpenConns: 10, MaxIdleConns: 10,
+		ConnMaxLifetime: time.Minute, StatementTimeout: time.Second}
+	assert.NoError(t, database.Validate())
+
+	database.MaxIdleConns = 11
+	assert.Error(t, database.Validate(), "more idle than open connections")
+
+	database = config.Database{Driver: config.DriverPostgres, MaxIdleConns: 50}
+	assert.NoError(t, database.Validate(), "0 open connections are unlimited")
+
+	database = config.Database{Driver: config.DriverPostgres, ConnMaxIdleTime: -time.Second}
+	assert.Error(t, database.Validate())
+
+	database = config.Database{Driver: config.DriverPostgres, StatementTimeout: -time.Second}
+	assert.Error(t, database.Validate())
+
+	database = config.Database{Driver: config.DriverPostgres, StatementCacheCapacity: -1}
+	assert.Error(t, database.Validate())
+}
@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/pagelink"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageLinksAreSignedPerKind(t *testing.T) {
+	signer := pagelink.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	now := time.Now()
+	token := signer.Sign(pagelink.KindTicket, "7K2M9QX4PZ1B", now.Add(time.Hour))
+
+	ref, err := signer.Verify(pagelink.KindTicket, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "7K2M9QX4PZ1B", ref)
+
+	_, err = signer.Verify(pagelink.KindReceipt, token, now)
+	assert.ErrorIs(t, err, pagelink.ErrInvalid, "a ticket link doesn't open the receipt")
+	_, err = signer.Verify(pagelink.KindTicket, strings.Replace(token, "7K2M9QX4PZ1B", "7K2M9QX4PZ1C", 1), now)
+	assert.ErrorIs(t, err, pagelink.ErrInvalid, "the reference can't be changed")
+	_, err = pagelink.NewSigner([]byte("another key")).Verify(pagelink.KindTicket, token, now)
+	assert.ErrorIs(t, err, pagelink.ErrInvalid)
+	_, err = signer.Verify(pagelink.KindTicket, token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, pagelink.ErrExpired)
+	_, err = signer.Verify(pagelink.KindTicket, "garbage", now)
+	assert.ErrorIs(t, err, pagelink.ErrInvalid)
+}
+
+type ticketPageFixture struct {
+	router      *gin.Engine
+	bookingRepo *mocks.MockBookingRepository
+	booking     *model.Booking
+}
+
+func newTicketPageFixture(t *testing.T) *ticketPageFixture {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+
+	concert, err := concertRepo.Create(ctx, &model.Concert{
+		Name:             "Summer <Night>",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Date(2026, 7, 4, 20, 0, 0, 0, time.UTC),
+		TotalTickets:     100,
+		AvailableTickets: 98,
+		Price:            19.99,
+		Currency:         "EUR",
+		BookingStartTime: time.Now().Add(-time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	booking, err := bookingRepo.Create(ctx, &model.Booking{
+		ConcertID:    concert.ID,
+		UserID:       "alice",
+		TicketCount:  2,
+		UnitPrice:    19.99,
+		Status:       model.BookingStatusConfirmed,
+		AttendeeName: "Alice",
+		BookingTime:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	signer := pagelink.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	pageService := service.NewTicketPageService(bookingRepo, concertRepo, signer, "https://tickets.example.com/", 24*time.Hour)
+	pageHandler := handler.NewTicketPageHandler(pageService)
+	router := gin.New()
+	pageHandler.RegisterRoutes(router.Group("/api/v1"))
+	pageHandler.RegisterPageRoutes(router)
+	handler.NewCalendarHandler(service.NewCalendarService(bookingRepo, concertRepo, signer)).RegisterRoutes(router.Group("/api/v1"))
+
+	return &ticketPageFixture{router: router, bookingRepo: bookingRepo, booking: booking}
+}
+
+func (f *ticketPageFixture) get(path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Language", "en-US")
+	f.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (f *ticketPageFixture) links(t *testing.T) model.BookingLinks {
+	recorder := f.get("/api/v1/bookings/" + f.booking.Reference + "/links?userID=alice")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var links model.BookingLinks
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &links))
+	return links
+}
+
+func pathOf(t *testing.T, link string) string {
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "tickets.example.com", parsed.Host)
+	return parsed.EscapedPath()
+}
+
+func TestTicketPageShowsTheDoorCode(t *testing.T) {
+	f := newTicketPageFixture(t)
+	links := f.links(t)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), links.ExpiresAt, 2*time.Second)
+
+	recorder := f.get(pathOf(t, links.TicketURL))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "no-referrer", recorder.Header().Get("Referrer-Policy"))
+
+	body := recorder.Body.String()
+	style := body[strings.Index(body, "<style>")+len("<style>") : strings.Index(body, "</style>")]
+	hash := sha256.Sum256([]byte(style))
+	assert.Contains(t, recorder.Header().Get("Content-Security-Policy"), "style-src 'sha256-"+base64.StdEncoding.EncodeToString(hash[:])+"'",
+		"the policy allows exactly the inline stylesheet")
+	assert.Contains(t, body, "<svg class=\"code\"")
+	assert.Contains(t, body, f.booking.Reference)
+	assert.Contains(t, body, "Summer &lt;Night&gt;", "concert details are escaped")
+	assert.Contains(t, body, "Sat 4 Jul 2026, 20:00 UTC")
+
+	recorder = f.get(pathOf(t, links.ReceiptURL))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "€39.98")
+	assert.NotContains(t, recorder.Body.String(), "<svg", "receipts aren't tickets")
+
+	assert.Equal(t, http.StatusNotFound, f.get(strings.Replace(pathOf(t, links.ReceiptURL), "/r/", "/t/", 1)).Code,
+		"a receipt link doesn't open the ticket")
+}
+
+func TestTicketPageOfACancelledBookingHasNoCode(t *testing.T) {
+	f := newTicketPageFixture(t)
+	links := f.links(t)
+	f.booking.Status = model.BookingStatusCancelled
+	require.NoError(t, f.bookingRepo.Update(context.Background(), f.booking))
+
+	recorder := f.get(pathOf(t, links.TicketURL))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "<svg")
+	assert.Contains(t, recorder.Body.String(), "This booking is cancelled")
+}
+
+func TestTicketPageLinks(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newTicketPageFixture(t)
+	links := f.links(t)
+
+	assert.Equal(t, http.StatusNotFound, f.get("/api/v1/bookings/"+f.booking.Reference+"/links?userID=mallory").Code,
+		"other users' bookings don't exist")
+	assert.Equal(t, http.StatusBadRequest, f.get("/api/v1/bookings/"+f.booking.Reference+"/links").Code)
+	assert.Equal(t, http.StatusNotFound, f.get("/t/not-a-token").Code)
+
+	clock.Process().Advance(25 * time.Hour)
+	recorder := f.get(pathOf(t, links.TicketURL))
+	assert.Equal(t, http.StatusGone, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "This link has expired")
+
+	// The calendar feed is subscribed to for good
+	feed, err := url.Parse(links.CalendarURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/users/alice/bookings.ics", pathOf(t, links.CalendarURL))
+	recorder = f.get(feed.RequestURI())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "UID:booking-"+f.booking.Reference)
+}
+
+func TestPagesValidate(t *testing.T) {
+	valid := config.Pages{Enabled: true, SigningKey: strings.Repeat("k", 32), BaseURL: "https://tickets.example.com", LinkTTL: time.Hour}
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, (&config.Pages{}).Validate(), "disabled pages need nothing")
+
+	short := valid
+	short.SigningKey = "short"
+	assert.Error(t, short.Validate())
+	relative := valid
+	relative.BaseURL = "/tickets"
+	assert.Error(t, relative.Validate())
+	noTTL := valid
+	noTTL.LinkTTL = 0
+	assert.Error(t, noTTL.Validate())
+}
@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/config"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/traffic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficRecorderAnonymizesRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var recording bytes.Buffer
+	recorder, err := traffic.NewRecorder(&recording, config.Recording{SampleRate: 1, Salt: "salt", MaxBodyBytes: 1024})
+	require.NoError(t, err)
+
+	var received string
+	router := gin.New()
+	router.Use(middleware.TrafficRecorder(recorder, logger.NewLogger("error")))
+	router.POST("/api/v1/bookings", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = string(body)
+		c.Status(http.StatusCreated)
+	})
+	router.GET("/api/v1/bookings/:reference", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.DELETE("/api/v1/users/:id/data", func(c *gin.Context) {
+		c.Set("adminActor", "ops")
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/api/v1/users/:id/bookings.ics", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Invite-Token", "invite")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	booking := `{"concert_id":7,"user_id":"alice","ticket_count":2,"attendee_name":"Alice Smith","attendee_email":"alice@example.org","booking_token":"tok"}`
+	send(http.MethodPost, "/api/v1/bookings", booking)
+	assert.Equal(t, booking, received, "handlers get the whole body")
+	send(http.MethodGet, "/api/v1/bookings/BK-ALICE?userID=alice", "")
+	send(http.MethodDelete, "/api/v1/users/alice/data", "")
+	send(http.MethodGet, "/api/v1/users/alice/bookings.ics?token=feed-secret", "")
+	send(http.MethodGet, "/health", "")
+	large := `{"user_id":"` + strings.Repeat("x", 2000) + `"}`
+	send(http.MethodPost, "/api/v1/bookings", large)
+	assert.Equal(t, large, received, "bodies over the limit are passed on whole")
+
+	for _, secret := range []string{"alice", "Alice", "tok\"", "secret", "invite", "BK-", "feed-secret"} {
+		assert.NotContains(t, recording.String(), secret)
+	}
+
+	records, err := traffic.ReadRecords(&recording)
+	require.NoError(t, err)
+	require.Len(t, records, 5, "only /api routes are recorded")
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(records[0].Body, &body))
+	assert.Equal(t, float64(7), body["concert_id"])
+	assert.Equal(t, float64(2), body["ticket_count"])
+	assert.Regexp(t, `^user-[0-9a-f]{16}$`, body["user_id"])
+	assert.Regexp(t, `^[0-9a-f]{16}@example.com$`, body["attendee_email"])
+	assert.Equal(t, "/api/v1/bookings", records[0].Route)
+	assert.Equal(t, http.StatusCreated, records[0].Status)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, records[0].Header)
+
+	userPseudonym := traffic.NewAnonymizer([]byte("salt")).Pseudonym("user", "alice")
+	assert.Equal(t, userPseudonym, body["user_id"], "pseudonyms are stable under one salt")
+	assert.Regexp(t, `^/api/v1/bookings/ref-[0-9a-f]{16}\?userID=`+userPseudonym+`$`, records[1].Path)
+	assert.Equal(t, "/api/v1/users/"+userPseudonym+"/data", records[2].Path)
+	assert.True(t, records[2].Admin)
+	assert.False(t, records[0].Admin)
+	assert.Regexp(t, `^/api/v1/users/`+userPseudonym+`/bookings\.ics\?token=token-[0-9a-f]{16}$`, records[3].Path)
+	assert.True(t, records[4].BodyOmitted, "bodies over the limit aren't recorded")
+	assert.Empty(t, records[4].Body)
+}
+
+func TestTrafficRecorderSamplesClients(t *testing.T) {
+	recorder, err := traffic.NewRecorder(io.Discard, config.Recording{SampleRate: 0.5, Salt: "salt"})
+	require.NoError(t, err)
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+		if recorder.Sampled(ip) {
+			sampled++
+		}
+		assert.Equal(t, recorder.Sampled(ip), recorder.Sampled(ip), "a client is always or never sampled")
+	}
+	assert.Greater(t, sampled, 0)
+	assert.Less(t, sampled, 1000)
+}
+
+func TestReplayKeepsThePaceAndSubstitutesTokens(t *testing.T) {
+	var mutex sync.Mutex
+	var bookings []map[string]interface{}
+	var adminAuth []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/booking-token"):
+			var req map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"token": "staging-token", "concert_id": 7, "user_id": req["user_id"]})
+		case r.URL.Path == "/api/v1/bookings":
+			var req map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			bookings = append(bookings, req)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			adminAuth = append(adminAuth, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer target.Close()
+
+	start := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	records := []traffic.Record{
+		{Time: start, Method: http.MethodPost, Route: "/api/v1/concerts/:id/booking-token", Path: "/api/v1/concerts/7/booking-token",
+			Body: json.RawMessage(`{"user_id":"user-1"}`), Status: http.StatusCreated, LatencyMS: 4},
+		{Time: start.Add(time.Second), Method: http.MethodPost, Route: "/api/v1/bookings", Path: "/api/v1/bookings",
+			Body: json.RawMessage(`{"concert_id":7,"user_id":"user-1","ticket_count":1,"booking_token":"token-1"}`), Status: http.StatusCreated, LatencyMS: 20},
+		{Time: start.Add(2 * time.Second), Method: http.MethodPost, Route: "/api/v1/bookings", Path: "/api/v1/bookings",
+			Body: json.RawMessage(`{"concert_id":7,"user_id":"user-2","ticket_count":1,"booking_token":"token-2"}`), Status: http.StatusCreated, LatencyMS: 30},
+		{Time: start.Add(4 * time.Second), Method: http.MethodGet, Route: "/api/v1/admin/booking-conflicts", Path: "/api/v1/admin/booking-conflicts",
+			Admin: true, Status: http.StatusOK, LatencyMS: 2},
+	}
+
+	replayer := &traffic.Replayer{BaseURL: target.URL, Speed: 20, AdminToken: "staging-admin"}
+	began := time.Now()
+	report, err := replayer.Replay(context.Background(), records)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(began), 200*time.Millisecond, "4 recorded seconds take 200ms at 20x")
+
+	assert.Equal(t, 4, report.Sent)
+	assert.Zero(t, report.Failed)
+	assert.Equal(t, map[int]int{http.StatusCreated: 3, http.StatusInternalServerError: 1}, report.Statuses)
+	assert.Equal(t, map[int]int{http.StatusCreated: 3, http.StatusOK: 1}, report.RecordedStatuses)
+	require.Len(t, report.Routes, 3)
+	assert.Equal(t, "/api/v1/bookings", report.Routes[0].Route, "busiest route first")
+	assert.Equal(t, 2, report.Routes[0].Count)
+	assert.Equal(t, 30*time.Millisecond, report.Routes[0].RecordedP99)
+
+	require.Len(t, bookings, 2)
+	assert.Equal(t, "staging-token", bookings[0]["booking_token"], "the token issued by the target is used")
+	assert.NotContains(t, bookings[1], "booking_token", "tokens the target never issued are left out")
+	assert.Equal(t, []string{"Bearer staging-admin"}, adminAuth)
+
+	for _, route := range report.Routes {
+		if route.Route == "/api/v1/admin/booking-conflicts" {
+			assert.Equal(t, 1, route.Errors)
+		}
+	}
+
+	_, err = (&traffic.Replayer{BaseURL: target.URL}).Replay(context.Background(), records)
+	assert.Error(t, err, "speed must be set")
+}
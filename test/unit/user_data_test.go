@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/api/rest/middleware"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userDataFixture struct {
+	bookings    *mocks.MockBookingRepository
+	preferences *mocks.MockNotificationPreferenceRepository
+	audit       *mocks.MockAuditRepository
+	service     service.UserDataService
+}
+
+func newUserDataFixture(t *testing.T) *userDataFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &userDataFixture{
+		bookings:    mocks.NewMockBookingRepository(),
+		preferences: mocks.NewMockNotificationPreferenceRepository(),
+		audit:       mocks.NewMockAuditRepository(),
+	}
+	f.service = service.NewUserDataService(f.bookings, f.preferences, service.NewAuditService(f.audit))
+
+	for _, userID := range []string{"user-1", "user-1", "user-2"} {
+		_, err := f.bookings.Create(ctx, &model.Booking{ConcertID: 1, UserID: userID, TicketCount: 2,
+			Status: model.BookingStatusConfirmed, AttendeeName: "Jane Doe", AttendeeEmail: "jane@example.com"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.preferences.Set(ctx, &model.NotificationPreferences{UserID: "user-1", SMS: true, PhoneNumber: "+15555550100"}))
+	return f
+}
+
+// auditEntries returns the audit log entries of an action
+func (f *userDataFixture) auditEntries(t *testing.T, action string) []*model.AuditLog {
+	t.Helper()
+
+	entries, _, err := f.audit.List(context.Background(), model.AuditFilter{Action: action}, query.NewPage(1, 10))
+	require.NoError(t, err)
+	return entries
+}
+
+func TestExportUserDataHoldsEverythingStoredAboutTheUser(t *testing.T) {
+	defer clock.Process().Reset()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock.Process().Set(now)
+	f := newUserDataFixture(t)
+
+	export, err := f.service.ExportUserData(context.Background(), "user-1", "ops")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", export.UserID)
+	assert.WithinDuration(t, now, export.ExportedAt, time.Minute, "dated by the process clock")
+	require.Len(t, export.Bookings, 2)
+	for _, booking := range export.Bookings {
+		assert.Equal(t, "user-1", booking.UserID, "only the user's own bookings")
+		assert.Equal(t, "jane@example.com", booking.AttendeeEmail)
+	}
+	require.NotNil(t, export.NotificationPreferences)
+	assert.Equal(t, "+15555550100", export.NotificationPreferences.PhoneNumber)
+
+	entries := f.auditEntries(t, model.AuditActionUserDataExported)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ops", entries[0].Actor)
+	assert.Equal(t, "user-1", entries[0].ResourceID)
+	assert.JSONEq(t, `{"bookings": 2}`, string(entries[0].Details))
+
+	// A user without bookings or preferences gets an empty export
+	export, err = f.service.ExportUserData(context.Background(), "user-3", "ops")
+	require.NoError(t, err)
+	assert.NotNil(t, export.Bookings)
+	assert.Empty(t, export.Bookings)
+	assert.Nil(t, export.NotificationPreferences)
+}
+
+func TestEraseUserDataLeavesNothingNamingTheUser(t *testing.T) {
+	defer clock.Process().Reset()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock.Process().Set(now)
+	f := newUserDataFixture(t)
+	ctx := context.Background()
+
+	erasure, err := f.service.EraseUserData(ctx, "user-1", "ops")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", erasure.UserID)
+	assert.WithinDuration(t, now, erasure.ErasedAt, time.Minute, "dated by the process clock")
+	assert.Equal(t, 2, erasure.AnonymizedRecords)
+
+	left, err := f.bookings.GetAllByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, left)
+
+	// The bookings stay for the sales figures, under one pseudonym and
+	// without the attendee
+	all, err := f.bookings.GetAllByConcertID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	var pseudonym string
+	for _, booking := range all {
+		if booking.UserID == "user-2" {
+			assert.Equal(t, "jane@example.com", booking.AttendeeEmail, "other users are left alone")
+			continue
+		}
+		if pseudonym == "" {
+			pseudonym = booking.UserID
+		}
+		assert.True(t, strings.HasPrefix(booking.UserID, "erased-"))
+		assert.Equal(t, pseudonym, booking.UserID)
+		assert.Empty(t, booking.AttendeeName)
+		assert.Empty(t, booking.AttendeeEmail)
+	}
+
+	_, err = f.preferences.Get(ctx, "user-1")
+	assert.ErrorIs(t, err, pkgErr.ErrNotFound)
+
+	// The audit log names the user but never the pseudonym
+	entries := f.auditEntries(t, model.AuditActionUserDataErased)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "user-1", entries[0].ResourceID)
+	assert.JSONEq(t, `{"anonymized_records": 2}`, string(entries[0].Details))
+	assert.NotContains(t, string(entries[0].Details), pseudonym)
+}
+
+func TestUserDataEndpointsRequireTheAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newUserDataFixture(t)
+
+	router := gin.New()
+	handler.NewUserHandler(f.service).RegisterRoutes(router.Group("/api/v1"), middleware.AdminAuth("admin-secret"))
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("X-Admin-Actor", "ops")
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/users/user-1/export"},
+		{http.MethodDelete, "/api/v1/users/user-1/data"},
+	} {
+		assert.Equal(t, http.StatusUnauthorized, call(route.method, route.path, "").Code, route.path)
+		assert.Equal(t, http.StatusForbidden, call(route.method, route.path, "wrong").Code, route.path)
+	}
+	assert.Empty(t, f.auditEntries(t, ""), "rejected requests touch no data")
+	remaining, err := f.bookings.GetAllByUserID(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+
+	recorder := call(http.MethodGet, "/api/v1/users/user-1/export", "admin-secret")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var export model.UserDataExport
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &export))
+	assert.Len(t, export.Bookings, 2)
+
+	recorder = call(http.MethodDelete, "/api/v1/users/user-1/data", "admin-secret")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	entries := f.auditEntries(t, model.AuditActionUserDataErased)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ops", entries[0].Actor, "the actor comes from the admin middleware")
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest"
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/health"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/openapi"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersioningTestServer(api config.API) *rest.Server {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		CORS: config.CORS{AllowOrigins: []string{"*"}},
+		API:  api,
+	}
+	concertService := service.NewConcertService(mocks.NewMockConcertRepository(), nil, model.BookingLimits{}, nil)
+	return rest.NewServer(concertService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, health.NewRegistry(time.Second, 1), nil, nil, logger.NewLogger("error"), cfg)
+}
+
+func TestDeprecatedVersionsAnnounceTheirSunset(t *testing.T) {
+	server := newVersioningTestServer(config.API{
+		V2Enabled: true,
+		V1: config.APIVersion{
+			DeprecatedAt: "2030-01-01T00:00:00Z",
+			SunsetAt:     "2031-01-01T00:00:00Z",
+			Link:         "https://docs.example.com/migrate-to-v2",
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, "v1 keeps working while deprecated")
+	assert.Equal(t, "@1893456000", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 01 Jan 2031 00:00:00 GMT", recorder.Header().Get("Sunset"))
+	assert.Equal(t, `<https://docs.example.com/migrate-to-v2>; rel="deprecation"; type="text/html", </api/v2/concerts>; rel="successor-version"`,
+		recorder.Header().Get("Link"))
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v2/concerts", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+	assert.Empty(t, recorder.Header().Get("Sunset"))
+	assert.Empty(t, recorder.Header().Get("Link"))
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var document openapi.Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.True(t, document.Paths["/api/v1/concerts/{id}"]["get"].Deprecated)
+	assert.False(t, document.Paths["/api/v2/concerts/{id}"]["get"].Deprecated)
+}
+
+func TestV2CanBeTurnedOff(t *testing.T) {
+	server := newVersioningTestServer(config.API{})
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v2/concerts", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/concerts", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+}
+
+func TestAPIVersionScheduleValidation(t *testing.T) {
+	valid := config.API{V2Enabled: true, V1: config.APIVersion{DeprecatedAt: "2030-01-01T00:00:00Z", SunsetAt: "2031-01-01T00:00:00Z"}}
+	assert.NoError(t, valid.Validate())
+
+	for name, api := range map[string]config.API{
+		"invalid time":               {V2Enabled: true, V1: config.APIVersion{DeprecatedAt: "next year"}},
+		"sunset without deprecation": {V2Enabled: true, V1: config.APIVersion{SunsetAt: "2031-01-01T00:00:00Z"}},
+		"sunset before deprecation":  {V2Enabled: true, V1: config.APIVersion{DeprecatedAt: "2031-01-01T00:00:00Z", SunsetAt: "2030-01-01T00:00:00Z"}},
+		"no successor":               {V1: config.APIVersion{DeprecatedAt: "2030-01-01T00:00:00Z"}},
+	} {
+		assert.Error(t, api.Validate(), name)
+	}
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVisibilityConcert(visibility string) *model.Concert {
+	return &model.Concert{
+		Name:             "Concert",
+		Artist:           "Artist",
+		Venue:            "Venue",
+		ConcertDate:      time.Now().Add(30 * 24 * time.Hour),
+		TotalTickets:     100,
+		AvailableTickets: 100,
+		Price:            25,
+		Currency:         "USD",
+		BookingStartTime: time.Now().Add(time.Hour),
+		BookingEndTime:   time.Now().Add(24 * time.Hour),
+		Visibility:       visibility,
+	}
+}
+
+func TestUnlistedConcertsAreReachableByIDOnly(t *testing.T) {
+	ctx := context.Background()
+	concertService := service.NewConcertService(mocks.NewMockConcertRepository(), nil, model.BookingLimits{}, nil)
+
+	public, err := concertService.CreateConcert(ctx, newVisibilityConcert(""))
+	require.NoError(t, err)
+	assert.Equal(t, model.VisibilityPublic, public.Visibility)
+	assert.Empty(t, public.InviteToken)
+
+	unlisted, err := concertService.CreateConcert(ctx, newVisibilityConcert(model.VisibilityUnlisted))
+	require.NoError(t, err)
+	assert.Empty(t, unlisted.InviteToken, "unlisted concerts need no invite")
+
+	concerts, total, err := concertService.ListConcerts(ctx, query.Options{Page: query.NewPage(1, 10)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, concerts, 1)
+	assert.Equal(t, public.ID, concerts[0].ID)
+
+	found, err := concertService.GetByID(ctx, unlisted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.VisibilityUnlisted, found.Visibility)
+}
+
+func TestPrivateConcertsRequireTheirInviteToken(t *testing.T) {
+	defer clock.Process().Reset()
+
+	ctx := context.Background()
+	concertRepo := mocks.NewMockConcertRepository()
+	concertService := service.NewConcertService(concertRepo, nil, model.BookingLimits{}, nil)
+	bookingService := service.NewBookingService(mocks.NewMockBookingRepository().WithConcerts(concertRepo), concertRepo, service.RetryPolicy{MaxRetries: 3}, nil, nil, nil, nil, model.BookingLimits{}, nil, service.BookingOptimistic, nil)
+
+	private, err := concertService.CreateConcert(ctx, newVisibilityConcert(model.VisibilityPrivate))
+	require.NoError(t, err)
+	require.NotEmpty(t, private.InviteToken, "the invite token is returned once on creation")
+
+	_, err = concertService.GetByID(ctx, private.ID)
+	assert.True(t, errors.Is(err, pkgErr.ErrNotFound))
+
+	_, err = concertService.GetByID(service.WithInviteToken(ctx, "wrong"), private.ID)
+	assert.True(t, errors.Is(err, pkgErr.ErrNotFound))
+
+	public, err := concertService.CreateConcert(ctx, newVisibilityConcert(model.VisibilityPublic))
+	require.NoError(t, err)
+
+	// Open the booking window
+	clock.Process().Advance(2 * time.Hour)
+
+	_, err = bookingService.BookTickets(ctx, &model.BookingRequest{ConcertID: private.ID, UserID: "user-1", TicketCount: 1})
+	assert.True(t, errors.Is(err, pkgErr.ErrNotFound))
+
+	compared, missing, err := concertService.CompareConcerts(ctx, []int64{public.ID, private.ID})
+	require.NoError(t, err)
+	require.Len(t, compared, 1)
+	assert.Equal(t, public.ID, compared[0].ID)
+	assert.Equal(t, []int64{private.ID}, missing)
+
+	invited := service.WithInviteToken(ctx, private.InviteToken)
+
+	found, err := concertService.GetByID(invited, private.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.InviteToken, "the invite token is never read back")
+
+	booking, err := bookingService.BookTickets(invited, &model.BookingRequest{ConcertID: private.ID, UserID: "user-1", TicketCount: 1})
+	require.NoError(t, err)
+	assert.Equal(t, model.BookingStatusConfirmed, booking.Status)
+
+	found, err = concertService.GetByID(service.WithPrivateAccess(ctx), private.ID)
+	require.NoError(t, err)
+	assert.Equal(t, private.ID, found.ID)
+}
+
+func TestInviteTokensSurviveUpdates(t *testing.T) {
+	ctx := context.Background()
+	concertService := service.NewConcertService(mocks.NewMockConcertRepository(), nil, model.BookingLimits{}, nil)
+
+	private, err := concertService.CreateConcert(ctx, newVisibilityConcert(model.VisibilityPrivate))
+	require.NoError(t, err)
+	token := private.InviteToken
+
+	// Updates that leave the visibility out keep it
+	update := newVisibilityConcert("")
+	update.ID = private.ID
+	update.Version = private.Version
+	update.Name = "Renamed"
+	require.NoError(t, concertService.UpdateConcert(ctx, update))
+	assert.Equal(t, model.VisibilityPrivate, update.Visibility)
+	assert.Empty(t, update.InviteToken, "no new invite token is issued")
+
+	found, err := concertService.GetByID(service.WithInviteToken(ctx, token), private.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+
+	// Making the concert unlisted and private again keeps the invites working
+	update.Visibility = model.VisibilityUnlisted
+	require.NoError(t, concertService.UpdateConcert(ctx, update))
+	update.Visibility = model.VisibilityPrivate
+	require.NoError(t, concertService.UpdateConcert(ctx, update))
+
+	_, err = concertService.GetByID(service.WithInviteToken(ctx, token), private.ID)
+	assert.NoError(t, err)
+
+	update.Visibility = "secret"
+	err = concertService.UpdateConcert(ctx, update)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "visibility must be public, unlisted or private")
+}
@@ -0,0 +1,233 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/repository"
+	redisrepo "concert-ticket-api/internal/repository/redis"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAdmitInterval = time.Second
+	testRejoinGrace   = time.Minute
+)
+
+type sharedRoomFixture struct {
+	redis     *miniredis.Miniredis
+	store     repository.WaitingRoomStore
+	snapshots *mocks.MockWaitingRoomSnapshotRepository
+	tokens    *quotaTokenService
+}
+
+func newSharedRoomFixture(t *testing.T) *sharedRoomFixture {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return &sharedRoomFixture{
+		redis:     mr,
+		store:     redisrepo.NewWaitingRoomStore(client),
+		snapshots: mocks.NewMockWaitingRoomSnapshotRepository(),
+		tokens:    &quotaTokenService{},
+	}
+}
+
+// replica starts a waiting room as another instance would
+func (f *sharedRoomFixture) replica(instance string) service.PersistentWaitingRoom {
+	return service.NewSharedWaitingRoom(f.tokens, nil, f.store, f.snapshots, instance, testAdmitInterval, testRejoinGrace)
+}
+
+// expireLead lets another instance take over admission
+func (f *sharedRoomFixture) expireLead() {
+	f.redis.FastForward(3 * testAdmitInterval)
+}
+
+func TestSharedWaitingRoomAcrossReplicas(t *testing.T) {
+	f := newSharedRoomFixture(t)
+	a, b := f.replica("replica-a"), f.replica("replica-b")
+	ctx := context.Background()
+
+	first := a.Join(ctx, 1, "user-1")
+	second := b.Join(ctx, 1, "user-2")
+	third := a.Join(ctx, 1, "user-3")
+	assert.Equal(t, 1, latestUpdate(t, first).Position)
+	assert.Equal(t, 2, latestUpdate(t, second).Position, "replicas share one queue")
+	assert.Equal(t, 3, latestUpdate(t, third).Position)
+
+	f.tokens.grant(2)
+	assert.Equal(t, 2, a.Admit(ctx))
+	assert.Zero(t, b.Admit(ctx), "one instance admits at a time")
+
+	assert.Equal(t, "token-user-1", latestUpdate(t, first).Token.Token)
+	assert.Equal(t, "token-user-2", latestUpdate(t, second).Token.Token, "the outcome reaches the replica the user is connected to")
+	_, open := <-second.Updates()
+	assert.False(t, open)
+	assert.Equal(t, 1, latestUpdate(t, third).Position)
+
+	// The admitting instance stopping hands admission over
+	f.tokens.grant(1)
+	f.expireLead()
+	assert.Equal(t, 1, b.Admit(ctx))
+	a.Admit(ctx)
+	assert.Equal(t, "token-user-3", latestUpdate(t, third).Token.Token)
+}
+
+func TestSharedWaitingRoomKeepsPlacesAcrossDeploy(t *testing.T) {
+	defer clock.Process().Reset()
+	f := newSharedRoomFixture(t)
+	old := f.replica("old")
+	ctx := context.Background()
+
+	entries := make([]*service.WaitingEntry, 4)
+	for i, userID := range []string{"user-1", "user-2", "user-3", "user-4"} {
+		entries[i] = old.Join(ctx, 1, userID)
+		assert.Equal(t, i+1, latestUpdate(t, entries[i]).Position)
+	}
+
+	// user-4 reconnects to a new replica before the old one goes away
+	next := f.replica("new")
+	moved := next.Join(ctx, 1, "user-4")
+	assert.Equal(t, 4, latestUpdate(t, moved).Position)
+
+	// Shutting the old replica down closes its connections
+	for _, entry := range entries {
+		old.Disconnect(entry)
+	}
+	require.NoError(t, old.Persist(ctx))
+	f.expireLead()
+
+	// Users reconnect to the new replica in another order
+	rejoined := map[string]*service.WaitingEntry{}
+	for _, userID := range []string{"user-3", "user-1"} {
+		rejoined[userID] = next.Join(ctx, 1, userID)
+	}
+	assert.Equal(t, 3, latestUpdate(t, rejoined["user-3"]).Position, "users keep their place")
+	assert.Equal(t, 1, latestUpdate(t, rejoined["user-1"]).Position)
+	late := next.Join(ctx, 1, "user-5")
+	assert.Equal(t, 5, latestUpdate(t, late).Position)
+
+	// user-2 doesn't come back within the grace period
+	clock.Process().Advance(testRejoinGrace + time.Second)
+	assert.Zero(t, next.Admit(ctx))
+	assert.Equal(t, 2, latestUpdate(t, rejoined["user-3"]).Position)
+	assert.Equal(t, 3, latestUpdate(t, moved).Position, "closing an older connection doesn't depart the user")
+	assert.Equal(t, 4, latestUpdate(t, late).Position)
+
+	f.tokens.grant(2)
+	assert.Equal(t, 2, next.Admit(ctx))
+	assert.Equal(t, "token-user-1", latestUpdate(t, rejoined["user-1"]).Token.Token)
+	assert.Equal(t, "token-user-3", latestUpdate(t, rejoined["user-3"]).Token.Token)
+
+	// A user admitted between connections gets the token on reconnecting
+	next.Disconnect(moved)
+	f.tokens.grant(1)
+	assert.Equal(t, 1, next.Admit(ctx))
+	again := next.Join(ctx, 1, "user-4")
+	assert.Equal(t, "token-user-4", latestUpdate(t, again).Token.Token)
+}
+
+func TestSharedWaitingRoomRecoversFromSnapshot(t *testing.T) {
+	f := newSharedRoomFixture(t)
+	room := f.replica("replica-a")
+	ctx := context.Background()
+
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		room.Join(ctx, 1, userID)
+	}
+	room.Join(ctx, 2, "user-1")
+	require.NoError(t, room.Persist(ctx))
+
+	// Redis restarts empty and a user joins before the snapshot job runs
+	f.redis.FlushAll()
+	newcomer := room.Join(ctx, 1, "user-4")
+	assert.Equal(t, 1, latestUpdate(t, newcomer).Position)
+
+	require.NoError(t, room.Persist(ctx))
+	saved, err := f.snapshots.Latest(ctx)
+	require.NoError(t, err)
+
+	state, err := f.store.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, saved.Version, state.Version, "the merged state is saved")
+	var queue []string
+	for _, member := range state.Queues[1] {
+		queue = append(queue, member.UserID)
+	}
+	assert.Equal(t, []string{"user-1", "user-2", "user-3", "user-4"}, queue, "snapshot users keep their places ahead of newcomers")
+	require.Len(t, state.Queues[2], 1)
+
+	room.Admit(ctx)
+	assert.Equal(t, 4, latestUpdate(t, newcomer).Position)
+	rejoined := room.Join(ctx, 1, "user-2")
+	assert.Equal(t, 2, latestUpdate(t, rejoined).Position)
+
+	// Later joins queue behind the restored users
+	late := room.Join(ctx, 1, "user-5")
+	assert.Equal(t, 5, latestUpdate(t, late).Position)
+}
+
+func TestWaitingRoomSnapshotVersions(t *testing.T) {
+	f := newSharedRoomFixture(t)
+	ctx := context.Background()
+
+	saved, err := f.snapshots.Save(ctx, &model.WaitingRoomState{Format: model.WaitingRoomStateFormat, Version: 5})
+	require.NoError(t, err)
+	assert.True(t, saved)
+	saved, err = f.snapshots.Save(ctx, &model.WaitingRoomState{Format: model.WaitingRoomStateFormat, Version: 3})
+	require.NoError(t, err)
+	assert.False(t, saved, "a stale snapshot doesn't replace a newer one")
+	latest, err := f.snapshots.Latest(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, latest.Version)
+
+	// Restores only apply to the version they were merged from
+	_, err = f.store.Enqueue(ctx, 1, "user-1", "", "conn-1")
+	require.NoError(t, err)
+	state, err := f.store.Snapshot(ctx)
+	require.NoError(t, err)
+	_, err = f.store.Enqueue(ctx, 1, "user-2", "", "conn-2")
+	require.NoError(t, err)
+	assert.ErrorIs(t, f.store.Restore(ctx, state, state.Version), pkgErr.ErrUpdateFailed)
+	positions, err := f.store.Positions(ctx, 1, []string{"user-1", "user-2", "user-3"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 0}, positions)
+}
+
+func TestWaitingRoomSnapshotOfAnotherFormatIsReplaced(t *testing.T) {
+	f := newSharedRoomFixture(t)
+	room := f.replica("replica-a")
+	ctx := context.Background()
+
+	future := &model.WaitingRoomState{
+		Format:  model.WaitingRoomStateFormat + 1,
+		Version: 1000,
+		Queues:  map[int64][]model.WaitingRoomMember{1: {{UserID: "user-9", Seq: 1}}},
+	}
+	_, err := f.snapshots.Save(ctx, future)
+	require.NoError(t, err)
+
+	entry := room.Join(ctx, 1, "user-1")
+	assert.Equal(t, 1, latestUpdate(t, entry).Position)
+	assert.Error(t, room.Persist(ctx))
+
+	positions, err := f.store.Positions(ctx, 1, []string{"user-9", "user-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, positions, "the snapshot isn't restored")
+
+	latest, err := f.snapshots.Latest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.WaitingRoomStateFormat, latest.Format)
+	require.NoError(t, room.Persist(ctx), "the replaced snapshot is saved from then on")
+}
@@ -0,0 +1,290 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"concert-ticket-api/config"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/warehouse"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWarehouseFixture creates two concerts and four bookings changed on
+// June 1st, 2026 between 10:00 and 11:00, two of them at the same time
+func newWarehouseFixture(t *testing.T) (*mocks.MockBookingRepository, *mocks.MockWarehouseRepository) {
+	t.Helper()
+	ctx := context.Background()
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	concertRepo := mocks.NewMockConcertRepository()
+	bookingRepo := mocks.NewMockBookingRepository().WithConcerts(concertRepo)
+	for _, name := range []string{"Arena Night", "Club Night"} {
+		_, err := concertRepo.Create(ctx, &model.Concert{
+			Name: name, Artist: "Artist", Venue: "Venue, Hall 1",
+			ConcertDate:  day.AddDate(0, 1, 0),
+			TotalTickets: 100, AvailableTickets: 90,
+			Price: 49.5, Currency: "EUR", Visibility: model.VisibilityPublic,
+			CreatedAt: day.Add(10 * time.Hour), UpdatedAt: day.Add(10 * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	for _, changed := range []time.Duration{10*time.Hour + 30*time.Minute, 10 * time.Hour, 11 * time.Hour, 11 * time.Hour} {
+		_, err := bookingRepo.Create(ctx, &model.Booking{
+			ConcertID: 1, UserID: "user-1", TicketCount: 2, UnitPrice: 49.5,
+			Status: model.BookingStatusConfirmed, BookingTime: day.Add(changed),
+			AttendeeName: "Ada Lovelace", AttendeeEmail: "ada@example.com",
+			CreatedAt: day.Add(changed), UpdatedAt: day.Add(changed),
+		})
+		require.NoError(t, err)
+	}
+
+	return bookingRepo, mocks.NewMockWarehouseRepository(bookingRepo)
+}
+
+// readCSV decompresses and parses an exported file
+func readCSV(t *testing.T, body []byte) [][]string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	records, err := csv.NewReader(zr).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestWarehouseExportWritesChangesInBatches(t *testing.T) {
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	_, repo := newWarehouseFixture(t)
+	sink := mocks.NewMockWarehouseSink()
+	exporter := service.NewWarehouseService(repo, sink, "tickets/", 3, 5*time.Minute)
+
+	exported, err := exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 6, exported)
+
+	assert.Equal(t, []string{
+		"tickets/bookings/dt=2026-06-01/bookings-20260601T100000.000000Z-2.csv.gz",
+		"tickets/bookings/dt=2026-06-01/bookings-20260601T110000.000000Z-4.csv.gz",
+		"tickets/concerts/dt=2026-06-01/concerts-20260601T100000.000000Z-1.csv.gz",
+	}, sink.Keys())
+
+	body, ok := sink.File(sink.Keys()[0])
+	require.True(t, ok)
+	records := readCSV(t, body)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"id", "reference", "concert_id", "order_id", "user_id", "ticket_count", "unit_price",
+		"status", "booking_time", "created_at", "updated_at"}, records[0])
+	assert.Equal(t, "2", records[1][0], "rows are in the order of their change")
+	assert.Equal(t, "1", records[2][0])
+	assert.Equal(t, "3", records[3][0], "rows changed at the same time are in the order of their ID")
+	assert.Equal(t, []string{"", "user-1", "2", "49.5", "confirmed", "2026-06-01T10:00:00Z"}, records[1][3:9])
+	assert.NotContains(t, string(readAll(t, body)), "ada@example.com", "attendees aren't exported")
+
+	concerts, ok := sink.File(sink.Keys()[2])
+	require.True(t, ok)
+	records = readCSV(t, concerts)
+	require.Len(t, records, 3)
+	assert.Equal(t, "Venue, Hall 1", records[1][3])
+
+	watermark, err := repo.GetWatermark(context.Background(), model.WarehouseDatasetBookings)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), watermark.LastID)
+	assert.Equal(t, time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC), watermark.UpdatedAt)
+	assert.Equal(t, int64(4), watermark.RowsExported)
+
+	// Nothing changed since
+	exported, err = exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, exported)
+	assert.Len(t, sink.Keys(), 3)
+}
+
+func TestWarehouseExportHoldsBackTheLatestChanges(t *testing.T) {
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	bookingRepo, repo := newWarehouseFixture(t)
+	sink := mocks.NewMockWarehouseSink()
+	exporter := service.NewWarehouseService(repo, sink, "", 100, 5*time.Minute)
+	_, err := exporter.Export(context.Background())
+	require.NoError(t, err)
+
+	// A booking cancelled a minute ago waits for the lag to pass
+	booking, err := bookingRepo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	booking.Status = model.BookingStatusCancelled
+	booking.UpdatedAt = time.Date(2026, 6, 1, 11, 59, 0, 0, time.UTC)
+	require.NoError(t, bookingRepo.Update(context.Background(), booking))
+
+	exported, err := exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, exported)
+
+	clock.Process().Advance(5 * time.Minute)
+	exported, err = exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, exported)
+
+	key := "bookings/dt=2026-06-01/bookings-20260601T115900.000000Z-1.csv.gz"
+	body, ok := sink.File(key)
+	require.True(t, ok, "keys: %v", sink.Keys())
+	records := readCSV(t, body)
+	require.Len(t, records, 2)
+	assert.Equal(t, "cancelled", records[1][7], "the snapshot is the booking as it is now")
+}
+
+func TestWarehouseExportRetriesFailedFiles(t *testing.T) {
+	defer clock.Process().Reset()
+	clock.Process().Set(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	_, repo := newWarehouseFixture(t)
+	sink := mocks.NewMockWarehouseSink()
+	exporter := service.NewWarehouseService(repo, sink, "", 100, 0)
+
+	sink.SetFailing(true)
+	exported, err := exporter.Export(context.Background())
+	assert.ErrorContains(t, err, "failed to export bookings")
+	assert.Zero(t, exported)
+	sink.SetFailing(false)
+
+	// The file was written but the watermark not saved: the next export
+	// writes it again under the same key
+	repo.FailNext("SaveWatermark", errors.New("connection reset"))
+	exported, err = exporter.Export(context.Background())
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, 2, exported, "the concerts are still exported")
+
+	exported, err = exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, exported)
+	assert.Equal(t, []string{
+		"bookings/dt=2026-06-01/bookings-20260601T100000.000000Z-2.csv.gz",
+		"concerts/dt=2026-06-01/concerts-20260601T100000.000000Z-1.csv.gz",
+		"bookings/dt=2026-06-01/bookings-20260601T100000.000000Z-2.csv.gz",
+	}, sink.Keys())
+}
+
+func TestS3SinkSignsPutObject(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	sink := warehouse.NewS3(config.WarehouseS3{
+		Bucket: "analytics", Region: "eu-west-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret",
+		SessionToken: "session", Endpoint: server.URL,
+	}, server.Client())
+	require.NoError(t, sink.Put(context.Background(), "tickets/bookings/dt=2026-06-01/a+b.csv.gz", []byte("rows"), "application/gzip"))
+
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/analytics/tickets/bookings/dt%3D2026-06-01/a%2Bb.csv.gz", got.URL.EscapedPath())
+	assert.Equal(t, "rows", string(body))
+	assert.Equal(t, "session", got.Header.Get("X-Amz-Security-Token"))
+	sum := sha256.Sum256([]byte("rows"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), got.Header.Get("X-Amz-Content-Sha256"))
+	authorization := got.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), authorization)
+	assert.Contains(t, authorization, "/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature=")
+}
+
+func TestGCSSinkUploadsMedia(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if r.Header.Get("Authorization") != "Bearer gcs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"message": "Invalid Credentials"}}`))
+		}
+	}))
+	defer server.Close()
+
+	sink := warehouse.NewGCS(config.WarehouseGCS{Bucket: "analytics", AccessToken: "gcs-token", BaseURL: server.URL}, server.Client())
+	require.NoError(t, sink.Put(context.Background(), "bookings/dt=2026-06-01/b.csv.gz", []byte("rows"), "application/gzip"))
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/upload/storage/v1/b/analytics/o", got.URL.Path)
+	assert.Equal(t, "media", got.URL.Query().Get("uploadType"))
+	assert.Equal(t, "bookings/dt=2026-06-01/b.csv.gz", got.URL.Query().Get("name"))
+
+	expired := warehouse.NewGCS(config.WarehouseGCS{Bucket: "analytics", AccessToken: "expired", BaseURL: server.URL}, server.Client())
+	err := expired.Put(context.Background(), "b.csv.gz", []byte("rows"), "application/gzip")
+	assert.ErrorContains(t, err, "unexpected status 401: {\"error\": {\"message\": \"Invalid Credentials\"}}")
+}
+
+func TestDirSinkWritesFiles(t *testing.T) {
+	root := t.TempDir()
+	sink := warehouse.NewDir(root)
+
+	require.NoError(t, sink.Put(context.Background(), "bookings/dt=2026-06-01/b.csv.gz", []byte("first"), "application/gzip"))
+	require.NoError(t, sink.Put(context.Background(), "bookings/dt=2026-06-01/b.csv.gz", []byte("second"), "application/gzip"))
+
+	body, err := os.ReadFile(filepath.Join(root, "bookings", "dt=2026-06-01", "b.csv.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(body))
+	entries, err := os.ReadDir(filepath.Join(root, "bookings", "dt=2026-06-01"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
+func TestWarehouseValidate(t *testing.T) {
+	valid := config.Warehouse{
+		Interval: time.Hour, Destination: config.WarehouseDestinationS3, Format: config.WarehouseFormatCSV,
+		BatchSize: 1000, Lag: time.Minute, S3: config.WarehouseS3{Bucket: "analytics"},
+	}
+	require.NoError(t, valid.Validate())
+
+	disabled := config.Warehouse{}
+	assert.NoError(t, disabled.Validate(), "a disabled export needs no destination")
+
+	for name, tc := range map[string]struct {
+		change func(w *config.Warehouse)
+		err    string
+	}{
+		"parquet":         {func(w *config.Warehouse) { w.Format = "parquet" }, "warehouse.format parquet isn't supported"},
+		"no bucket":       {func(w *config.Warehouse) { w.S3.Bucket = "" }, "warehouse.s3.bucket is required"},
+		"half a key":      {func(w *config.Warehouse) { w.S3.AccessKeyID = "AKIDEXAMPLE" }, "must be set together"},
+		"no gcs token":    {func(w *config.Warehouse) { w.Destination = config.WarehouseDestinationGCS }, "warehouse.gcs needs bucket and access_token"},
+		"no dir":          {func(w *config.Warehouse) { w.Destination = config.WarehouseDestinationDir }, "warehouse.dir is required"},
+		"unknown":         {func(w *config.Warehouse) { w.Destination = "ftp" }, "unknown warehouse.destination"},
+		"no batch":        {func(w *config.Warehouse) { w.BatchSize = 0 }, "warehouse.batch_size must be positive"},
+		"negative lag":    {func(w *config.Warehouse) { w.Lag = -time.Second }, "warehouse.lag cannot be negative"},
+		"negative period": {func(w *config.Warehouse) { w.Interval = -time.Second }, "warehouse.interval cannot be negative"},
+	} {
+		w := valid
+		tc.change(&w)
+		assert.ErrorContains(t, w.Validate(), tc.err, name)
+	}
+}
+
+func readAll(t *testing.T, body []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,195 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/internal/model"
+	"concert-ticket-api/internal/service"
+	pkgErr "concert-ticket-api/pkg/errors"
+	"concert-ticket-api/pkg/events"
+	"concert-ticket-api/pkg/pagelink"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// quotaTokenService issues as many tokens as its quota allows, like the rate
+// limited token service within one admission tick
+type quotaTokenService struct {
+	service.BookingTokenService
+	mu    sync.Mutex
+	quota int
+}
+
+func (s *quotaTokenService) grant(n int) {
+	s.mu.Lock()
+	s.quota += n
+	s.mu.Unlock()
+}
+
+func (s *quotaTokenService) IssueToken(ctx context.Context, concertID int64, userID string) (*model.BookingToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.quota == 0 {
+		return nil, pkgErr.ErrRateLimited
+	}
+	s.quota--
+	return &model.BookingToken{Token: "token-" + userID, ConcertID: concertID, UserID: userID}, nil
+}
+
+func latestUpdate(t *testing.T, entry *service.WaitingEntry) model.WaitingRoomUpdate {
+	select {
+	case update := <-entry.Updates():
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("no waiting room update")
+		return model.WaitingRoomUpdate{}
+	}
+}
+
+func TestWaitingRoomAdmitsInOrder(t *testing.T) {
+	tokens := &quotaTokenService{}
+	room := service.NewWaitingRoom(tokens, nil)
+
+	first := room.Join(context.Background(), 1, "user-1")
+	second := room.Join(context.Background(), 1, "user-2")
+	third := room.Join(context.Background(), 1, "user-3")
+	assert.Equal(t, 1, latestUpdate(t, first).Position)
+	assert.Equal(t, 2, latestUpdate(t, second).Position)
+	assert.Equal(t, 3, latestUpdate(t, third).Position)
+
+	assert.Zero(t, room.Admit(context.Background()), "nobody is admitted while the issue rate is exhausted")
+
+	tokens.grant(1)
+	assert.Equal(t, 1, room.Admit(context.Background()))
+
+	admitted := latestUpdate(t, first)
+	require.NotNil(t, admitted.Token)
+	assert.Equal(t, "token-user-1", admitted.Token.Token)
+	_, open := <-first.Updates()
+	assert.False(t, open, "admitted entries are closed")
+
+	assert.Equal(t, 1, latestUpdate(t, second).Position)
+	assert.Equal(t, 2, latestUpdate(t, third).Position)
+
+	// Reconnecting keeps the place in the queue
+	rejoined := room.Join(context.Background(), 1, "user-3")
+	assert.Equal(t, 2, latestUpdate(t, rejoined).Position)
+	_, open = <-third.Updates()
+	assert.False(t, open)
+
+	room.Leave(second)
+	tokens.grant(5)
+	assert.Equal(t, 1, room.Admit(context.Background()))
+	assert.NotNil(t, latestUpdate(t, rejoined).Token)
+}
+
+func TestWebSocketPushesQueueAndBookingUpdates(t *testing.T) {
+	tokens := &quotaTokenService{}
+	room := service.NewWaitingRoom(tokens, nil)
+	bus := events.NewBus()
+
+	server := newWebSocketServer(room, bus)
+	defer server.Close()
+
+	url := webSocketURL(server, "user-1", webSocketSigner.Sign(pagelink.KindWebSocket, "user-1", time.Now().Add(time.Hour)))
+	_, err := websocket.Dial(url, "", "https://evil.example.com")
+	require.Error(t, err, "browsers from other origins are rejected")
+
+	conn, err := websocket.Dial(url, "", "https://tickets.example.com")
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	receive := func() handler.WebSocketMessage {
+		var msg handler.WebSocketMessage
+		require.NoError(t, websocket.JSON.Receive(conn, &msg))
+		return msg
+	}
+
+	require.NoError(t, websocket.JSON.Send(conn, handler.WebSocketCommand{Action: handler.WebSocketActionJoin, ConcertID: 7}))
+	msg := receive()
+	require.Equal(t, handler.WebSocketMessageQueue, msg.Type)
+	assert.Equal(t, 1, msg.Queue.Position)
+
+	tokens.grant(1)
+	room.Admit(context.Background())
+	msg = receive()
+	require.Equal(t, handler.WebSocketMessageQueue, msg.Type)
+	require.NotNil(t, msg.Queue.Token)
+	assert.Equal(t, int64(7), msg.Queue.ConcertID)
+
+	// Bookings of other users aren't pushed
+	for _, userID := range []string{"user-2", "user-1"} {
+		bus.Publish(events.Event{
+			Topic:   model.EventBookingStatus,
+			Key:     model.UserEventKey(userID),
+			Payload: &model.Booking{Reference: "REF-" + userID, UserID: userID, Status: model.BookingStatusConfirmed},
+		})
+	}
+	msg = receive()
+	require.Equal(t, handler.WebSocketMessageBooking, msg.Type)
+	assert.Equal(t, "REF-user-1", msg.Booking.Reference)
+
+	require.NoError(t, websocket.JSON.Send(conn, handler.WebSocketCommand{Action: "dance", ConcertID: 7}))
+	assert.Equal(t, handler.WebSocketMessageError, receive().Type)
+}
+
+var webSocketSigner = pagelink.NewSigner([]byte("websocket-signing-key-of-32-bytes"))
+
+func newWebSocketServer(room service.WaitingRoom, bus *events.Bus) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.NewWebSocketHandler(room, bus, webSocketSigner, func() []string { return []string{"https://tickets.example.com"} }).RegisterRoutes(router)
+	return httptest.NewServer(router)
+}
+
+// webSocketURL returns the /ws URL of server for a user and token
+func webSocketURL(server *httptest.Server, userID, token string) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?userID=" + url.QueryEscape(userID) + "&token=" + url.QueryEscape(token)
+}
+
+func TestWebSocketRefusesConnectionsWithoutTheUsersToken(t *testing.T) {
+	room := service.NewWaitingRoom(&quotaTokenService{}, nil)
+	bus := events.NewBus()
+	server := newWebSocketServer(room, bus)
+	defer server.Close()
+
+	expires := time.Now().Add(time.Hour)
+	tests := map[string]string{
+		"missing token":         "",
+		"token of another user": webSocketSigner.Sign(pagelink.KindWebSocket, "user-2", expires),
+		"expired token":         webSocketSigner.Sign(pagelink.KindWebSocket, "user-1", time.Now().Add(-time.Minute)),
+		"token of another kind": webSocketSigner.Sign(pagelink.KindTicket, "user-1", expires),
+		"foreign key":           pagelink.NewSigner([]byte("another-signing-key-of-32-bytes!")).Sign(pagelink.KindWebSocket, "user-1", expires),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := websocket.Dial(webSocketURL(server, "user-1", token), "", "https://tickets.example.com")
+			var dialErr *websocket.DialError
+			require.ErrorAs(t, err, &dialErr)
+			assert.Equal(t, websocket.ErrBadStatus, dialErr.Err)
+
+			resp, err := http.Get(strings.Replace(webSocketURL(server, "user-1", token), "ws", "http", 1))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+
+	// A user's token works for dotted user IDs too
+	conn, err := websocket.Dial(webSocketURL(server, "ada.lovelace", webSocketSigner.Sign(pagelink.KindWebSocket, "ada.lovelace", expires)), "", "https://tickets.example.com")
+	require.NoError(t, err)
+	conn.Close()
+}
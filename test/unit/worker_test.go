@@ -0,0 +1,279 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concert-ticket-api/api/rest/handler"
+	"concert-ticket-api/pkg/clock"
+	"concert-ticket-api/pkg/logger"
+	"concert-ticket-api/pkg/query"
+	"concert-ticket-api/pkg/worker"
+	"concert-ticket-api/test/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPausedWorkersSkipTheirRuns(t *testing.T) {
+	workers := worker.NewRegistry("test", nil, nil)
+	runs := 0
+	workers.Register("accounting-export", time.Minute, func(ctx context.Context) error {
+		runs++
+		if runs == 2 {
+			return errors.New("provider unavailable")
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	assert.True(t, workers.RunOnce(ctx, "accounting-export"))
+
+	status, err := workers.Pause("accounting-export", "ops")
+	require.NoError(t, err)
+	assert.Equal(t, worker.StatePaused, status.State)
+	assert.Equal(t, "ops", status.PausedBy)
+	assert.False(t, workers.RunOnce(ctx, "accounting-export"))
+	assert.Equal(t, 1, runs)
+
+	status, err = workers.Resume("accounting-export")
+	require.NoError(t, err)
+	assert.Equal(t, worker.StateRunning, status.State)
+	assert.Empty(t, status.PausedBy)
+	assert.True(t, workers.RunOnce(ctx, "accounting-export"))
+
+	statuses := workers.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, int64(2), statuses[0].Runs)
+	assert.Equal(t, "provider unavailable", statuses[0].LastError)
+	assert.Equal(t, "1m0s", statuses[0].Interval)
+	assert.NotNil(t, statuses[0].LastRunAt)
+
+	_, err = workers.Pause("webhooks", "ops")
+	assert.True(t, errors.Is(err, worker.ErrUnknownWorker))
+	assert.False(t, workers.RunOnce(ctx, "webhooks"))
+}
+
+func TestPausingWaitsForTheRunInProgress(t *testing.T) {
+	workers := worker.NewRegistry("test", nil, nil)
+	started, release := make(chan struct{}), make(chan struct{})
+	workers.Register("sales-reports", time.Minute, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan bool)
+	go func() { done <- workers.RunOnce(context.Background(), "sales-reports") }()
+	<-started
+
+	assert.True(t, workers.Statuses()[0].Busy)
+	assert.False(t, workers.RunOnce(context.Background(), "sales-reports"), "runs never overlap")
+
+	_, err := workers.Pause("sales-reports", "ops")
+	require.NoError(t, err)
+	close(release)
+	assert.True(t, <-done, "the run in progress is finished")
+
+	status := workers.Statuses()[0]
+	assert.False(t, status.Busy)
+	assert.Equal(t, worker.StatePaused, status.State)
+}
+
+func TestNoRunStartsAfterStop(t *testing.T) {
+	// A tick is ready along with the stop every time, and the loop picks
+	// between them at random, so a few rounds hit both orders
+	for round := 0; round < 20; round++ {
+		workers := worker.NewRegistry("test", nil, nil)
+		started, release := make(chan struct{}), make(chan struct{})
+		var runs atomic.Int64
+		workers.Register("outbox-relay", time.Millisecond, func(ctx context.Context) error {
+			if runs.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			return nil
+		})
+		workers.Start(context.Background())
+		<-started
+
+		stopped := make(chan error)
+		go func() { stopped <- workers.Stop(context.Background()) }()
+		// Ticks pile up while the run is held, until Stop has closed the loop
+		time.Sleep(5 * time.Millisecond)
+		close(release)
+		require.NoError(t, <-stopped)
+
+		assert.Equal(t, int64(1), runs.Load(), "round %d: only the run in progress ran", round)
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, int64(1), runs.Load(), "round %d: no run after Stop returned", round)
+	}
+}
+
+func TestExclusiveJobsRunOnOneInstance(t *testing.T) {
+	locker, runs := mocks.NewMockJobLocker(), mocks.NewMockJobRunRepository()
+	started, release := make(chan struct{}), make(chan struct{})
+	first := worker.NewRegistry("api-1", locker, runs)
+	first.RegisterExclusive("sales-reports", time.Minute, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	second := worker.NewRegistry("api-2", locker, runs)
+	second.RegisterExclusive("sales-reports", time.Minute, func(ctx context.Context) error {
+		return errors.New("mail server unavailable")
+	})
+	ctx := context.Background()
+
+	done := make(chan bool)
+	go func() { done <- first.RunOnce(ctx, "sales-reports") }()
+	<-started
+
+	assert.False(t, second.RunOnce(ctx, "sales-reports"), "the lock is held by api-1")
+	status := second.Statuses()[0]
+	assert.True(t, status.Exclusive)
+	assert.False(t, status.Busy)
+	assert.Equal(t, int64(1), status.LockContended)
+	assert.Zero(t, status.Runs)
+
+	close(release)
+	require.True(t, <-done)
+	assert.True(t, second.RunOnce(ctx, "sales-reports"), "the lock is released after the run")
+
+	history, total, err := first.Runs(ctx, "sales-reports", query.NewPage(1, 10))
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	assert.Equal(t, "api-2", history[0].InstanceID, "latest first")
+	assert.Equal(t, worker.OutcomeFailed, history[0].Outcome)
+	assert.Equal(t, "mail server unavailable", history[0].Error)
+	assert.Equal(t, "api-1", history[1].InstanceID)
+	assert.Equal(t, worker.OutcomeSucceeded, history[1].Outcome)
+	assert.False(t, history[1].FinishedAt.Before(history[1].StartedAt))
+
+	_, _, err = first.Runs(ctx, "webhooks", query.NewPage(1, 10))
+	assert.True(t, errors.Is(err, worker.ErrUnknownWorker))
+}
+
+func TestPerInstanceJobsAreNotLockedOrRecorded(t *testing.T) {
+	locker, runs := mocks.NewMockJobLocker(), mocks.NewMockJobRunRepository()
+	workers := worker.NewRegistry("api-1", locker, runs)
+	workers.Register("waiting-room", time.Second, func(ctx context.Context) error { return nil })
+
+	// Another instance holding a lock under the same name doesn't matter
+	unlock, ok, err := locker.TryLock(context.Background(), "waiting-room")
+	require.NoError(t, err)
+	require.True(t, ok)
+	defer unlock()
+
+	assert.True(t, workers.RunOnce(context.Background(), "waiting-room"))
+	_, total, err := workers.Runs(context.Background(), "waiting-room", query.NewPage(1, 10))
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestWorkerTimesFollowTheProcessClock(t *testing.T) {
+	defer clock.Process().Reset()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock.Process().Set(now)
+
+	locker, runs := mocks.NewMockJobLocker(), mocks.NewMockJobRunRepository()
+	workers := worker.NewRegistry("api-1", locker, runs)
+	workers.RegisterExclusive("sales-reports", time.Minute, func(ctx context.Context) error { return nil })
+	assert.WithinDuration(t, now, workers.Statuses()[0].Since, time.Minute)
+
+	require.True(t, workers.RunOnce(context.Background(), "sales-reports"))
+	status := workers.Statuses()[0]
+	require.NotNil(t, status.LastRunAt)
+	assert.WithinDuration(t, now, *status.LastRunAt, time.Minute)
+
+	history, _, err := workers.Runs(context.Background(), "sales-reports", query.NewPage(1, 10))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.WithinDuration(t, now, history[0].StartedAt, time.Minute, "run history matches a shifted staging clock")
+
+	_, err = workers.Pause("sales-reports", "ops")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, workers.Statuses()[0].Since, time.Minute)
+}
+
+func TestWorkerAdminEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	workers := worker.NewRegistry("test", nil, nil)
+	workers.Register("inventory-releases", 10*time.Second, func(ctx context.Context) error { return nil })
+
+	router := gin.New()
+	handler.NewWorkerHandler(workers, logger.NewLogger("error")).RegisterRoutes(router.Group("/api/v1"), func(c *gin.Context) {
+		c.Set("adminActor", "ops")
+	})
+
+	call := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	recorder := call(http.MethodPost, "/api/v1/admin/workers/inventory-releases/pause")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var status worker.Status
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	assert.Equal(t, worker.StatePaused, status.State)
+	assert.Equal(t, "ops", status.PausedBy)
+
+	recorder = call(http.MethodGet, "/api/v1/admin/workers")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var list handler.WorkerListResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	require.Len(t, list.Workers, 1)
+	assert.Equal(t, worker.StatePaused, list.Workers[0].State)
+	assert.Equal(t, "test", list.Instance)
+
+	recorder = call(http.MethodPost, "/api/v1/admin/workers/inventory-releases/resume")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, worker.StateRunning, workers.Statuses()[0].State)
+
+	recorder = call(http.MethodPost, "/api/v1/admin/workers/reminders/pause")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestWorkerRunHistoryEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	workers := worker.NewRegistry("api-1", mocks.NewMockJobLocker(), mocks.NewMockJobRunRepository())
+	workers.RegisterExclusive("inventory-releases", 10*time.Second, func(ctx context.Context) error { return nil })
+	for i := 0; i < 3; i++ {
+		require.True(t, workers.RunOnce(context.Background(), "inventory-releases"))
+	}
+
+	router := gin.New()
+	handler.NewWorkerHandler(workers, logger.NewLogger("error")).RegisterRoutes(router.Group("/api/v1"), func(c *gin.Context) {})
+
+	call := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	recorder := call("/api/v1/admin/workers/inventory-releases/runs?pageSize=2")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var page handler.WorkerRunListResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, "api-1", page.Data[0].InstanceID)
+	assert.Equal(t, "inventory-releases", page.Data[0].Job)
+	assert.Equal(t, 3, page.Meta.TotalCount)
+	assert.NotEmpty(t, page.Meta.NextCursor)
+
+	recorder = call("/api/v1/admin/workers/inventory-releases/runs?cursor=" + page.Meta.NextCursor)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	assert.Len(t, page.Data, 1)
+
+	assert.Equal(t, http.StatusBadRequest, call("/api/v1/admin/workers/inventory-releases/runs?page=0").Code)
+	assert.Equal(t, http.StatusNotFound, call("/api/v1/admin/workers/reminders/runs").Code)
+}